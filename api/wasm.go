@@ -285,6 +285,114 @@ type MemoryDefinition interface {
 	internalapi.WazeroOnly
 }
 
+// TableDefinition is a WebAssembly table exported in a module
+// (wazero.CompiledModule).
+//
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#exports%E2%91%A0
+//
+// # Notes
+//
+//   - This is an interface for decoupling, not third-party implementations.
+//     All implementations are in wazero.
+type TableDefinition interface {
+	ExportDefinition
+
+	// Type is the type of the references in the table, e.g. 0x70 for
+	// funcref or ValueTypeExternref.
+	Type() ValueType
+
+	// Min returns the possibly zero initial count of elements.
+	Min() uint32
+
+	// Max returns the possibly zero max count of elements, or false if
+	// unbounded.
+	Max() (uint32, bool)
+
+	internalapi.WazeroOnly
+}
+
+// GlobalDefinition is a WebAssembly global exported in a module
+// (wazero.CompiledModule).
+//
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#exports%E2%91%A0
+//
+// # Notes
+//
+//   - This is an interface for decoupling, not third-party implementations.
+//     All implementations are in wazero.
+type GlobalDefinition interface {
+	ExportDefinition
+
+	// Type is the type of the global's value, e.g. ValueTypeI32.
+	Type() ValueType
+
+	// Mutable returns true if the global can be set, i.e. it is a
+	// MutableGlobal once instantiated.
+	Mutable() bool
+
+	internalapi.WazeroOnly
+}
+
+// Import is an entry in the import section of a module
+// (wazero.CompiledModule), which must be satisfied to instantiate it.
+//
+// Here's an example of listing the functions a module needs:
+//
+//	for _, imp := range compiled.Imports() {
+//		if imp.Type() == api.ExternTypeFunc {
+//			def := imp.Definition().(api.FunctionDefinition)
+//			fmt.Println(imp.ModuleName(), imp.Name(), def.ParamTypes(), def.ResultTypes())
+//		}
+//	}
+//
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#imports%E2%91%A0
+//
+// # Notes
+//
+//   - This is an interface for decoupling, not third-party implementations.
+//     All implementations are in wazero.
+type Import interface {
+	// Type is the kind of the import, e.g. ExternTypeFunc.
+	Type() ExternType
+
+	// ModuleName is the possibly empty name of the module to import from.
+	ModuleName() string
+
+	// Name is the possibly empty name of the import in that module.
+	Name() string
+
+	// Definition returns the definition of the import according to Type:
+	// FunctionDefinition, TableDefinition, MemoryDefinition or
+	// GlobalDefinition. This returns nil for kinds not listed here, e.g. the
+	// tags of the exception handling proposal.
+	Definition() ExportDefinition
+
+	internalapi.WazeroOnly
+}
+
+// Export is an entry in the export section of a module
+// (wazero.CompiledModule).
+//
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#exports%E2%91%A0
+//
+// # Notes
+//
+//   - This is an interface for decoupling, not third-party implementations.
+//     All implementations are in wazero.
+type Export interface {
+	// Type is the kind of the export, e.g. ExternTypeMemory.
+	Type() ExternType
+
+	// Name is the possibly empty name of the export.
+	Name() string
+
+	// Definition returns the definition of the export according to Type, as
+	// documented on Import.Definition.
+	Definition() ExportDefinition
+
+	internalapi.WazeroOnly
+}
+
 // FunctionDefinition is a WebAssembly function exported in a module
 // (wazero.CompiledModule).
 //
@@ -332,8 +440,20 @@ type FunctionDefinition interface {
 
 	// ParamNames are index-correlated with ParamTypes or nil if not available
 	// for one or more parameters.
+	//
+	// Names of WebAssembly functions are read from the "name" custom section,
+	// or otherwise from WIT metadata embedded by bindings generators, e.g.
+	// wit-bindgen, when each parameter lowers to exactly one core parameter.
+	// WIT metadata is a custom section, so is ignored when neither debug info
+	// nor custom sections are enabled in the wazero.RuntimeConfig.
 	ParamNames() []string
 
+	// LocalNames are the names of the locals a function declares, which follow
+	// its params, or nil if none are named. Unnamed locals are empty.
+	//
+	// Note: This is always nil for imported or host functions.
+	LocalNames() []string
+
 	// ResultTypes are the results of the function.
 	//
 	// When WebAssembly 1.0 (20191205), there can be at most one result.
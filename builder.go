@@ -2,6 +2,8 @@ package wazero
 
 import (
 	"context"
+	"reflect"
+	"unicode"
 
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/wasm"
@@ -185,6 +187,37 @@ type HostModuleBuilder interface {
 	// NewFunctionBuilder begins the definition of a host function.
 	NewFunctionBuilder() HostFunctionBuilder
 
+	// ExportMethods exports each exported method of `receiver`, e.g. a pointer
+	// to a struct, as a host function named in snake case, e.g. "GetRandom"
+	// as "get_random". Methods are exported in lexicographic order.
+	//
+	// Here's an example of exporting two functions, "add" and "log":
+	//
+	//	type env struct{ out io.Writer }
+	//
+	//	func (e *env) Add(x, y uint32) uint32 {
+	//		return x + y
+	//	}
+	//
+	//	func (e *env) Log(msg string) {
+	//		fmt.Fprintln(e.out, msg)
+	//	}
+	//
+	//	env, _ := r.NewHostModuleBuilder("env").
+	//		ExportMethods(&env{out: os.Stdout}).
+	//		Instantiate(ctx)
+	//
+	// Methods have the signatures documented on HostFunctionBuilder.WithFunc,
+	// except params and results can also be bools, which are i32, and params
+	// can be strings or byte slices. These are each lowered to two i32 params:
+	// an offset and a length in the memory of the calling module, e.g.
+	// "Log" above has the signature (i32, i32) -> (). Byte slices are views of
+	// that memory, so must not be retained after the call.
+	//
+	// Use NewFunctionBuilder to export any method differently, e.g. with
+	// parameter names, after this.
+	ExportMethods(receiver interface{}) HostModuleBuilder
+
 	// Compile returns a CompiledModule that can be instantiated by Runtime.
 	Compile(context.Context) (CompiledModule, error)
 
@@ -312,6 +345,40 @@ func (b *hostModuleBuilder) NewFunctionBuilder() HostFunctionBuilder {
 	return &hostFunctionBuilder{b: b}
 }
 
+// ExportMethods implements HostModuleBuilder.ExportMethods
+func (b *hostModuleBuilder) ExportMethods(receiver interface{}) HostModuleBuilder {
+	v := reflect.ValueOf(receiver)
+	if !v.IsValid() {
+		return b // nil has no methods.
+	}
+	t := v.Type()
+	for i := 0; i < t.NumMethod(); i++ {
+		b.ExportHostFunc(&wasm.HostFunc{
+			ExportName: snakeCase(t.Method(i).Name),
+			Code:       wasm.Code{GoFunc: &wasm.GoMethod{Func: v.Method(i)}},
+		})
+	}
+	return b
+}
+
+// snakeCase converts the Go identifier `name` to snake case, e.g.
+// "HTTPGetURL" to "http_get_url".
+func snakeCase(name string) string {
+	runes := []rune(name)
+	ret := make([]rune, 0, len(runes)+4)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (!unicode.IsUpper(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				ret = append(ret, '_')
+			}
+			r = unicode.ToLower(r)
+		}
+		ret = append(ret, r)
+	}
+	return string(ret)
+}
+
 // Compile implements HostModuleBuilder.Compile
 func (b *hostModuleBuilder) Compile(ctx context.Context) (CompiledModule, error) {
 	module, err := wasm.NewHostModule(b.moduleName, b.exportNames, b.nameToHostFunc, b.r.enabledFeatures)
@@ -350,6 +350,13 @@ func TestNewHostModuleBuilder_Compile_Errors(t *testing.T) {
 			},
 			expectedErr: `func[host.fn] param[0] is unsupported: string`,
 		},
+		{
+			name: "ExportMethods unsupported result",
+			input: func(rt Runtime) HostModuleBuilder {
+				return rt.NewHostModuleBuilder("host").ExportMethods(&methods{})
+			},
+			expectedErr: `func[host.name] result[0] is unsupported: string`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -362,6 +369,93 @@ func TestNewHostModuleBuilder_Compile_Errors(t *testing.T) {
 	}
 }
 
+// greeter is exported by ExportMethods in tests.
+type greeter struct {
+	ctx       context.Context
+	greetings []string
+}
+
+func (g *greeter) Greet(ctx context.Context, name string, excited bool) uint32 {
+	g.ctx = ctx
+	greeting := "hello " + name
+	if excited {
+		greeting += "!"
+	}
+	g.greetings = append(g.greetings, greeting)
+	return uint32(len(greeting))
+}
+
+func (g *greeter) IsEmpty(buf []byte) bool {
+	return len(buf) == 0
+}
+
+// methods has a method with an unsupported signature.
+type methods struct{}
+
+func (methods) Name() string {
+	return "methods"
+}
+
+func TestNewHostModuleBuilder_ExportMethods(t *testing.T) {
+	r := NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	g := &greeter{}
+	host, err := r.NewHostModuleBuilder("greeter").ExportMethods(g).Instantiate(testCtx)
+	require.NoError(t, err)
+
+	defs := host.ExportedFunctionDefinitions()
+	require.Equal(t, 2, len(defs))
+	require.Equal(t, []api.ValueType{api.ValueTypeI32, api.ValueTypeI32, api.ValueTypeI32}, defs["greet"].ParamTypes())
+	require.Equal(t, []api.ValueType{api.ValueTypeI32}, defs["greet"].ResultTypes())
+	require.Equal(t, []api.ValueType{api.ValueTypeI32, api.ValueTypeI32}, defs["is_empty"].ParamTypes())
+	require.Equal(t, []api.ValueType{api.ValueTypeI32}, defs["is_empty"].ResultTypes())
+
+	mod, err := r.Instantiate(testCtx, []byte(`(module
+  (import "greeter" "greet" (func $greet (param i32 i32 i32) (result i32)))
+  (import "greeter" "is_empty" (func $is_empty (param i32 i32) (result i32)))
+  (memory (export "memory") 1)
+  (data (i32.const 8) "wazero")
+  (func (export "greet") (param i32) (result i32)
+    (call $greet (i32.const 8) (i32.const 6) (local.get 0)))
+  (func (export "is_empty") (param i32) (result i32)
+    (call $is_empty (i32.const 8) (local.get 0)))
+  (func (export "greet_out_of_bounds") (result i32)
+    (call $greet (i32.const 65535) (i32.const 2) (i32.const 0))))`))
+	require.NoError(t, err)
+
+	results, err := mod.ExportedFunction("greet").Call(testCtx, 1)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{13}, results)
+	_, err = mod.ExportedFunction("greet").Call(testCtx, 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"hello wazero!", "hello wazero"}, g.greetings)
+	require.Equal(t, testCtx, g.ctx)
+
+	results, err = mod.ExportedFunction("is_empty").Call(testCtx, 0)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1}, results)
+	results, err = mod.ExportedFunction("is_empty").Call(testCtx, 3)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{0}, results)
+
+	_, err = mod.ExportedFunction("greet_out_of_bounds").Call(testCtx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "out of bounds memory access")
+}
+
+func Test_snakeCase(t *testing.T) {
+	for _, tc := range []struct{ name, expected string }{
+		{name: "Add", expected: "add"},
+		{name: "GetRandom", expected: "get_random"},
+		{name: "HTTPGetURL", expected: "http_get_url"},
+		{name: "FdRead2", expected: "fd_read2"},
+		{name: "Add2Ints", expected: "add2_ints"},
+	} {
+		require.Equal(t, tc.expected, snakeCase(tc.name))
+	}
+}
+
 // TestNewHostModuleBuilder_Instantiate ensures Runtime.InstantiateModule is called on success.
 func TestNewHostModuleBuilder_Instantiate(t *testing.T) {
 	r := NewRuntime(testCtx)
@@ -20,8 +20,10 @@ import (
 	"github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/experimental/gojs"
 	"github.com/tetratelabs/wazero/experimental/logging"
+	"github.com/tetratelabs/wazero/experimental/pproflabels"
 	"github.com/tetratelabs/wazero/experimental/sock"
 	"github.com/tetratelabs/wazero/experimental/sysfs"
+	"github.com/tetratelabs/wazero/experimental/wat"
 	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
 	"github.com/tetratelabs/wazero/internal/platform"
 	internalsys "github.com/tetratelabs/wazero/internal/sys"
@@ -62,6 +64,8 @@ func doMain(stdOut io.Writer, stdErr logging.Writer) int {
 	case "version":
 		fmt.Fprintln(stdOut, version.GetWazeroVersion())
 		return 0
+	case "wat":
+		return doWat(flag.Args()[1:], stdOut, stdErr)
 	default:
 		fmt.Fprintln(stdErr, "invalid command")
 		printUsage(stdErr)
@@ -150,6 +154,51 @@ func doCompile(args []string, stdErr io.Writer) int {
 	return 0
 }
 
+func doWat(args []string, stdOut, stdErr io.Writer) int {
+	flags := flag.NewFlagSet("wat", flag.ExitOnError)
+	flags.SetOutput(stdErr)
+
+	var help bool
+	flags.BoolVar(&help, "h", false, "Prints usage.")
+
+	var folded bool
+	flags.BoolVar(&folded, "folded", false,
+		"Prints instructions folded into their operands, e.g. (i32.add (local.get 0) (i32.const 1)).")
+
+	_ = flags.Parse(args)
+
+	if help {
+		printWatUsage(stdErr, flags)
+		return 0
+	}
+
+	if flags.NArg() < 1 {
+		fmt.Fprintln(stdErr, "missing path to wasm file")
+		printWatUsage(stdErr, flags)
+		return 1
+	}
+
+	wasmPath := flags.Arg(0)
+
+	wasm, err := os.ReadFile(wasmPath)
+	if err != nil {
+		fmt.Fprintf(stdErr, "error reading wasm binary: %v\n", err)
+		return 1
+	}
+
+	disassemble := wat.Disassemble
+	if folded {
+		disassemble = wat.DisassembleFolded
+	}
+	text, err := disassemble(wasm)
+	if err != nil {
+		fmt.Fprintf(stdErr, "error disassembling wasm binary: %v\n", err)
+		return 1
+	}
+	_, _ = stdOut.Write(text)
+	return 0
+}
+
 func doRun(args []string, stdOut io.Writer, stdErr logging.Writer) int {
 	flags := flag.NewFlagSet("run", flag.ExitOnError)
 	flags.SetOutput(stdErr)
@@ -172,10 +221,17 @@ func doRun(args []string, stdOut io.Writer, stdErr logging.Writer) int {
 
 	var mounts sliceFlag
 	flags.Var(&mounts, "mount",
-		"Filesystem path to expose to the binary in the form of <path>[:<wasm path>][:ro]. "+
+		"Filesystem path to expose to the binary in the form of <path>[:<wasm path>][:ci][:ro]. "+
 			"This may be specified multiple times. When <wasm path> is unset, <path> is used. "+
 			"For example, -mount=/:/ or c:\\:/ makes the entire host volume writeable by wasm. "+
-			"For read-only mounts, append the suffix ':ro'.")
+			"For read-only mounts, append the suffix ':ro'. "+
+			"For case-insensitive lookups, like on macOS or Windows, append the suffix ':ci' before any ':ro'.")
+
+	var rawTTY bool
+	flags.BoolVar(&rawTTY, "raw-tty", false,
+		"Puts the terminal attached to stdin into raw mode while the binary runs, restoring it after. "+
+			"This passes each keystroke to the binary immediately, without echo or line editing, "+
+			"for terminal UIs and line editors. Has no effect if stdin is not a terminal.")
 
 	var listens sliceFlag
 	flags.Var(&listens, "listen",
@@ -200,7 +256,10 @@ func doRun(args []string, stdOut io.Writer, stdErr logging.Writer) int {
 	var memProfile string
 	if version.GetWazeroVersion() == version.Default {
 		flags.StringVar(&cpuProfile, "cpuprofile", "",
-			"Enables cpu profiling and writes the profile at the given path.")
+			"Enables cpu profiling and writes the profile at the given path. "+
+				"Samples are labeled with "+pproflabels.LabelKey+", the wasm function being executed. "+
+				"Note: labeling uses function listeners, which add overhead to each call and disable "+
+				"inlining, so the profiled code differs from that of a normal run.")
 
 		flags.StringVar(&memProfile, "memprofile", "",
 			"Enables memory profiling and writes the profile at the given path.")
@@ -274,6 +333,9 @@ func doRun(args []string, stdOut io.Writer, stdErr logging.Writer) int {
 	}
 
 	ctx := maybeHostLogging(context.Background(), logging.LogScopes(hostlogging), stdErr)
+	if cpuProfile != "" {
+		ctx = withProfilerLabels(ctx)
+	}
 
 	if rc, cache := maybeUseCacheDir(cacheDir, stdErr); rc != 0 {
 		return rc
@@ -323,6 +385,15 @@ func doRun(args []string, stdOut io.Writer, stdErr logging.Writer) int {
 		return 1
 	}
 
+	if rawTTY {
+		if restore, err := platform.MakeRaw(os.Stdin.Fd()); err == nil {
+			defer restore() //nolint
+		} else if !platform.IsNotTerminal(err) {
+			fmt.Fprintf(stdErr, "error making stdin raw: %v\n", err)
+			return 1
+		}
+	}
+
 	switch detectImports(guest.ImportedFunctions()) {
 	case modeWasi:
 		wasi_snapshot_preview1.MustInstantiate(ctx, rt)
@@ -395,6 +466,12 @@ func validateMounts(mounts sliceFlag, stdErr logging.Writer) (rc int, rootPath s
 			readOnly = true
 		}
 
+		caseInsensitive := false
+		if trimmed := strings.TrimSuffix(mount, ":ci"); trimmed != mount {
+			mount = trimmed
+			caseInsensitive = true
+		}
+
 		// TODO: Support wasm paths with colon in them.
 		var dir, guestPath string
 		if clnIdx := strings.LastIndexByte(mount, ':'); clnIdx != -1 {
@@ -420,6 +497,9 @@ func validateMounts(mounts sliceFlag, stdErr logging.Writer) (rc int, rootPath s
 		}
 
 		root := sysfs.DirFS(dir)
+		if caseInsensitive {
+			root = sysfs.CaseInsensitiveFS(root)
+		}
 		if readOnly {
 			root = &sysfs.ReadFS{FS: root}
 		}
@@ -485,6 +565,16 @@ func maybeHostLogging(ctx context.Context, scopes logging.LogScopes, stdErr logg
 	return ctx
 }
 
+// withProfilerLabels adds the listener which attributes the samples of the
+// cpu profile to wasm functions, in addition to the host logging one if any.
+func withProfilerLabels(ctx context.Context) context.Context {
+	factory := pproflabels.NewFunctionListenerFactory()
+	if f, ok := ctx.Value(experimental.FunctionListenerFactoryKey{}).(experimental.FunctionListenerFactory); ok {
+		factory = experimental.MultiFunctionListenerFactory(f, factory)
+	}
+	return context.WithValue(ctx, experimental.FunctionListenerFactoryKey{}, factory)
+}
+
 func cacheDirFlag(flags *flag.FlagSet) *string {
 	return flags.String("cachedir", "", "Writeable directory for native code compiled from wasm. "+
 		"Contents are re-used for the same version of wazero.")
@@ -511,6 +601,7 @@ func printUsage(stdErr io.Writer) {
 	fmt.Fprintln(stdErr, "  compile\tPre-compiles a WebAssembly binary")
 	fmt.Fprintln(stdErr, "  run\t\tRuns a WebAssembly binary")
 	fmt.Fprintln(stdErr, "  version\tDisplays the version of wazero CLI")
+	fmt.Fprintln(stdErr, "  wat\t\tPrints a WebAssembly binary in the text format")
 }
 
 func printCompileUsage(stdErr io.Writer, flags *flag.FlagSet) {
@@ -522,6 +613,15 @@ func printCompileUsage(stdErr io.Writer, flags *flag.FlagSet) {
 	flags.PrintDefaults()
 }
 
+func printWatUsage(stdErr io.Writer, flags *flag.FlagSet) {
+	fmt.Fprintln(stdErr, "wazero CLI")
+	fmt.Fprintln(stdErr)
+	fmt.Fprintln(stdErr, "Usage:\n  wazero wat <options> <path to wasm file>")
+	fmt.Fprintln(stdErr)
+	fmt.Fprintln(stdErr, "Options:")
+	flags.PrintDefaults()
+}
+
 func printRunUsage(stdErr io.Writer, flags *flag.FlagSet) {
 	fmt.Fprintln(stdErr, "wazero CLI")
 	fmt.Fprintln(stdErr)
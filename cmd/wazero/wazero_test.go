@@ -291,6 +291,12 @@ func TestRun(t *testing.T) {
 			wazeroOpts:     []string{"-env-inherit", "--env=ANIMAL=bear"},
 			expectedStdout: "ANIMAL=bear\x00INHERITED=wazero\u0000", // not ANIMAL=kitten
 		},
+		{
+			name:           "raw-tty without a terminal",
+			wasm:           wasmWasiArg,
+			wazeroOpts:     []string{"--raw-tty"}, // no effect when stdin isn't a terminal
+			expectedStdout: "test.wasm\x00",
+		},
 		{
 			name:           "interpreter",
 			wasm:           wasmWasiArg,
@@ -316,6 +322,13 @@ func TestRun(t *testing.T) {
 			wasmArgs:       []string{"/animals/bear.txt"},
 			expectedStdout: "pooh\n",
 		},
+		{
+			name:           "wasi case-insensitive",
+			wasm:           wasmCatTinygo,
+			wazeroOpts:     []string{fmt.Sprintf("--mount=%s:/animals:ci:ro", bearDir)},
+			wasmArgs:       []string{"/animals/Bear.TXT"},
+			expectedStdout: "pooh\n",
+		},
 		{
 			name:       "wasi hostlogging=all",
 			wasm:       wasmWasiRandomGet,
@@ -579,6 +592,83 @@ func TestVersion(t *testing.T) {
 	require.Equal(t, "", stderr)
 }
 
+func TestWat(t *testing.T) {
+	wasmPath := filepath.Join(t.TempDir(), "infinite_loop.wasm")
+	require.NoError(t, os.WriteFile(wasmPath, wasmInfiniteLoop, 0o600))
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name: "default",
+			args: []string{wasmPath},
+			expected: `(module
+  (type (;0;) (func))
+  (func (;0;) (type 0)
+    loop
+      br 0
+    end)
+  (export "_start" (func 0)))
+`,
+		},
+		{
+			name: "folded",
+			args: []string{"-folded", wasmPath},
+			expected: `(module
+  (type (;0;) (func))
+  (func (;0;) (type 0)
+    (loop
+      (br 0)))
+  (export "_start" (func 0)))
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		tt := tc
+		t.Run(tt.name, func(t *testing.T) {
+			exitCode, stdout, stderr := runMain(t, "", append([]string{"wat"}, tt.args...))
+			require.Equal(t, 0, exitCode, stderr)
+			require.Equal(t, tt.expected, stdout)
+		})
+	}
+}
+
+func TestWat_Errors(t *testing.T) {
+	notWasmPath := filepath.Join(t.TempDir(), "bears.wasm")
+	require.NoError(t, os.WriteFile(notWasmPath, []byte("pooh"), 0o600))
+
+	tests := []struct {
+		message string
+		args    []string
+	}{
+		{
+			message: "missing path to wasm file",
+			args:    []string{},
+		},
+		{
+			message: "error reading wasm binary",
+			args:    []string{"non-existent.wasm"},
+		},
+		{
+			message: "error disassembling wasm binary",
+			args:    []string{notWasmPath},
+		},
+	}
+
+	for _, tc := range tests {
+		tt := tc
+		t.Run(tt.message, func(t *testing.T) {
+			exitCode, _, stderr := runMain(t, "", append([]string{"wat"}, tt.args...))
+
+			require.Equal(t, 1, exitCode)
+			require.Contains(t, stderr, tt.message)
+		})
+	}
+}
+
 func TestRun_Errors(t *testing.T) {
 	wasmPath := filepath.Join(t.TempDir(), "test.wasm")
 	require.NoError(t, os.WriteFile(wasmPath, wasmWasiArg, 0o700))
@@ -649,6 +739,7 @@ func (i importer) DebugName() string            { return "" }
 func (i importer) GoFunction() interface{}      { return nil }
 func (i importer) ParamTypes() []api.ValueType  { return nil }
 func (i importer) ParamNames() []string         { return nil }
+func (i importer) LocalNames() []string         { return nil }
 func (i importer) ResultTypes() []api.ValueType { return nil }
 func (i importer) ResultNames() []string        { return nil }
 
@@ -786,6 +877,7 @@ Commands:
   compile	Pre-compiles a WebAssembly binary
   run		Runs a WebAssembly binary
   version	Displays the version of wazero CLI
+  wat		Prints a WebAssembly binary in the text format
 `, stderr)
 }
 
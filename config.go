@@ -12,13 +12,16 @@ import (
 
 	"github.com/tetratelabs/wazero/api"
 	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/internal/callstack"
 	"github.com/tetratelabs/wazero/internal/engine/compiler"
 	"github.com/tetratelabs/wazero/internal/engine/interpreter"
+	"github.com/tetratelabs/wazero/internal/engine/tiered"
 	"github.com/tetratelabs/wazero/internal/filecache"
 	"github.com/tetratelabs/wazero/internal/internalapi"
 	"github.com/tetratelabs/wazero/internal/platform"
 	internalsock "github.com/tetratelabs/wazero/internal/sock"
 	internalsys "github.com/tetratelabs/wazero/internal/sys"
+	"github.com/tetratelabs/wazero/internal/sysfs"
 	"github.com/tetratelabs/wazero/internal/wasm"
 	"github.com/tetratelabs/wazero/sys"
 )
@@ -79,6 +82,34 @@ type RuntimeConfig interface {
 	// results in allocating 4GB. See the doc on WithMemoryLimitPages for detail.
 	WithMemoryCapacityFromMax(memoryCapacityFromMax bool) RuntimeConfig
 
+	// WithMemoryMmap reserves the address space of each memory up to its max
+	// with mmap (VirtualAlloc on Windows), followed by guard pages. Defaults
+	// to false, which allocates memory on the Go heap.
+	//
+	// Like WithMemoryCapacityFromMax, any memory.grow instruction never
+	// re-allocates, so never copies. Unlike it, pages are only backed by
+	// physical memory once grown into, so an instance only uses the memory
+	// it needs. The guard pages make accesses past the reservation fault.
+	//
+	// On Linux, Runtime.CloneModule and instantiating a snapshot from
+	// Runtime.SnapshotModule also share the pages of the memory copy-on-write
+	// instead of copying them, so forking a large template is cheap in both
+	// time and resident memory.
+	//
+	// This example reserves memory with mmap:
+	//	rConfig = wazero.NewRuntimeConfig().WithMemoryMmap(true)
+	//
+	// # Notes
+	//
+	//   - This falls back to the Go heap on platforms without mmap, or when
+	//     the reservation fails, such as 4GB on a 32-bit platform.
+	//   - Compiled code still checks the bounds of memory accesses, as Go
+	//     can't recover from a fault in generated code.
+	//   - A reservation is released when the module defining its memory is
+	//     closed, so slices returned by api.Memory Read must not be used
+	//     afterwards. Modules importing the memory must be closed first.
+	WithMemoryMmap(bool) RuntimeConfig
+
 	// WithDebugInfoEnabled toggles DWARF based stack traces in the face of
 	// runtime errors. Defaults to true.
 	//
@@ -148,6 +179,25 @@ type RuntimeConfig interface {
 	//	customSections := c.CustomSections()
 	WithCustomSections(bool) RuntimeConfig
 
+	// WithMaxCallDepth limits the number of nested function calls of the
+	// interpreter, above which calls fail with a stack overflow trap, e.g. on
+	// infinite recursion. Zero, the default, is 2000. The compiler is limited
+	// by WithMaxStackSize instead.
+	//
+	// Use experimental.WithCallStackLimits to override this for a module.
+	WithMaxCallDepth(depth uint32) RuntimeConfig
+
+	// WithMaxStackSize limits the size in bytes of the stack of the compiler,
+	// which holds the locals, operands and frames of nested function calls,
+	// above which calls fail with a stack overflow trap, e.g. on infinite
+	// recursion. Zero, the default, is 40MB. The interpreter is limited by
+	// WithMaxCallDepth instead.
+	//
+	// Note: This is approximate, as the stack grows by doubling its size.
+	//
+	// Use experimental.WithCallStackLimits to override this for a module.
+	WithMaxStackSize(size uint64) RuntimeConfig
+
 	// WithCloseOnContextDone ensures the executions of functions to be closed under one of the following circumstances:
 	//
 	// 	- context.Context passed to the Call method of api.Function is canceled during execution. (i.e. ctx by context.WithCancel)
@@ -166,8 +216,32 @@ type RuntimeConfig interface {
 	// See examples in context_done_example_test.go for the end-to-end demonstrations.
 	//
 	// When the invocations of api.Function are closed due to this, sys.ExitError is raised to the callers and
-	// the api.Module from which the functions are derived is made closed.
+	// the api.Module from which the functions are derived is made closed. To only fail the call instead, use
+	// experimental.WithCancelCallOnContextDone.
 	WithCloseOnContextDone(bool) RuntimeConfig
+
+	// WithCompilationTarget compiles modules for the given GOOS and GOARCH
+	// instead of runtime.GOOS and runtime.GOARCH. e.g. "linux" and "arm64".
+	//
+	// This allows compiling modules on one platform, such as amd64 CI, for
+	// another, such as arm64 edge nodes. Use CompiledModule.Marshal to get
+	// the result, and Runtime.UnmarshalCompiledModule on the target:
+	//
+	//	config := wazero.NewRuntimeConfigCompiler().WithCompilationTarget("linux", "arm64")
+	//	r := wazero.NewRuntimeWithConfig(ctx, config)
+	//	compiled, _ := r.CompileModule(ctx, wasm)
+	//	data, _ := compiled.Marshal()
+	//
+	// # Notes
+	//
+	//   - Modules can't be instantiated in a runtime with a target, even when
+	//     it is the same as the current platform.
+	//   - Code generated for amd64 only uses CPU features required by wazero,
+	//     as those of the target CPU are unknown.
+	//   - WithCompilationCache has no effect when a target is set.
+	//   - This panics if the target isn't supported by the compiler, or this
+	//     is configured with NewRuntimeConfigInterpreter.
+	WithCompilationTarget(goos, goarch string) RuntimeConfig
 }
 
 // NewRuntimeConfig returns a RuntimeConfig using the compiler if it is supported in this environment,
@@ -182,12 +256,16 @@ type runtimeConfig struct {
 	enabledFeatures       api.CoreFeatures
 	memoryLimitPages      uint32
 	memoryCapacityFromMax bool
+	memoryMmap            bool
 	engineKind            engineKind
 	dwarfDisabled         bool // negative as defaults to enabled
 	newEngine             newEngine
 	cache                 CompilationCache
 	storeCustomSections   bool
 	ensureTermination     bool
+	callStackLimits       callstack.Limits
+	// targetGOOS and targetGOARCH are set by WithCompilationTarget.
+	targetGOOS, targetGOARCH string
 }
 
 // engineLessConfig helps avoid copy/pasting the wrong defaults.
@@ -203,6 +281,7 @@ type engineKind int
 const (
 	engineKindCompiler engineKind = iota
 	engineKindInterpreter
+	engineKindTiered
 	engineKindCount
 )
 
@@ -217,6 +296,10 @@ const (
 // part. wazero automatically performs ahead-of-time compilation as needed when
 // Runtime.CompileModule is invoked.
 //
+// Note: On Linux, setting the environment variable WAZERO_PERFMAP=1 appends the
+// address range and name of each compiled function to /tmp/perf-<pid>.map, so
+// that `perf report` shows the Wasm function names.
+//
 // Warning: This panics at runtime if the runtime.GOOS or runtime.GOARCH does not
 // support Compiler. Use NewRuntimeConfig to safely detect and fallback to
 // NewRuntimeConfigInterpreter if needed.
@@ -235,6 +318,40 @@ func NewRuntimeConfigInterpreter() RuntimeConfig {
 	return ret
 }
 
+// NewRuntimeConfigTiered interprets WebAssembly modules while compiling them
+// in the background, like NewRuntimeConfigCompiler. This avoids the delay of
+// Runtime.CompileModule for large modules, at the cost of slower execution
+// until they are compiled.
+//
+// The tier is chosen per module instance, when it is instantiated, and never
+// changes: there is no promotion of hot functions of a running instance. An
+// anonymous instance, which imports no guest modules, is compiled when the
+// compiler completed before Runtime.InstantiateModule. Otherwise, it is
+// interpreted until closed. For example, a long-running instance started
+// immediately after Runtime.CompileModule is interpreted, while subsequent
+// instances of the same module are compiled. Use
+// experimental.WithEngineKind to interpret or compile specific modules
+// instead.
+//
+// Instances of guest modules which import from each other must use the same
+// tier, as compiled code can't call into the interpreter and vice versa. So,
+// named instances, which other modules can import, and instances importing
+// from guest modules don't depend on timing: they wait for the compiler,
+// unless they import from a module compiled with
+// experimental.EngineKindInterpreter. Host modules can be imported by either
+// tier. Anonymous instances imported with experimental.WithImportResolver
+// may be interpreted, so name them or compile them with
+// experimental.EngineKindCompiler instead.
+//
+// Note: This falls back to NewRuntimeConfigInterpreter if the runtime.GOOS or
+// runtime.GOARCH does not support Compiler.
+func NewRuntimeConfigTiered() RuntimeConfig {
+	ret := engineLessConfig.clone()
+	ret.engineKind = engineKindTiered
+	ret.newEngine = tiered.NewEngine
+	return ret
+}
+
 // clone makes a deep copy of this runtime config.
 func (c *runtimeConfig) clone() *runtimeConfig {
 	ret := *c // copy except maps which share a ref
@@ -255,6 +372,32 @@ func (c *runtimeConfig) WithCloseOnContextDone(ensure bool) RuntimeConfig {
 	return ret
 }
 
+// WithMaxCallDepth implements RuntimeConfig.WithMaxCallDepth
+func (c *runtimeConfig) WithMaxCallDepth(depth uint32) RuntimeConfig {
+	ret := c.clone()
+	ret.callStackLimits.MaxDepth = depth
+	return ret
+}
+
+// WithMaxStackSize implements RuntimeConfig.WithMaxStackSize
+func (c *runtimeConfig) WithMaxStackSize(size uint64) RuntimeConfig {
+	ret := c.clone()
+	ret.callStackLimits.MaxSize = size
+	return ret
+}
+
+// WithCompilationTarget implements RuntimeConfig.WithCompilationTarget
+func (c *runtimeConfig) WithCompilationTarget(goos, goarch string) RuntimeConfig {
+	if c.engineKind != engineKindCompiler {
+		panic(errors.New("compilation target requires the compiler"))
+	} else if !platform.CompilerTargetSupported(goos, goarch) {
+		panic(fmt.Errorf("unsupported compilation target %s/%s", goos, goarch))
+	}
+	ret := c.clone()
+	ret.targetGOOS, ret.targetGOARCH = goos, goarch
+	return ret
+}
+
 // WithMemoryLimitPages implements RuntimeConfig.WithMemoryLimitPages
 func (c *runtimeConfig) WithMemoryLimitPages(memoryLimitPages uint32) RuntimeConfig {
 	ret := c.clone()
@@ -280,6 +423,13 @@ func (c *runtimeConfig) WithMemoryCapacityFromMax(memoryCapacityFromMax bool) Ru
 	return ret
 }
 
+// WithMemoryMmap implements RuntimeConfig.WithMemoryMmap
+func (c *runtimeConfig) WithMemoryMmap(memoryMmap bool) RuntimeConfig {
+	ret := c.clone()
+	ret.memoryMmap = memoryMmap
+	return ret
+}
+
 // WithDebugInfoEnabled implements RuntimeConfig.WithDebugInfoEnabled
 func (c *runtimeConfig) WithDebugInfoEnabled(dwarfEnabled bool) RuntimeConfig {
 	ret := c.clone()
@@ -336,10 +486,45 @@ type CompiledModule interface {
 	// memory.
 	ExportedMemories() map[string]api.MemoryDefinition
 
-	// CustomSections returns all the custom sections
-	// (api.CustomSection) in this module keyed on the section name.
+	// Imports returns all the imports (api.Import) of this module in the
+	// order of its import section, or an empty slice if there are none.
+	//
+	// Unlike ImportedFunctions and ImportedMemories, this includes tables
+	// and globals, so hosts can check all requirements of a module before
+	// instantiating it, e.g. to bind its imports automatically.
+	Imports() []api.Import
+
+	// Exports returns all the exports (api.Export) of this module in the
+	// order of its export section, or an empty slice if there are none.
+	Exports() []api.Export
+
+	// CustomSections returns all the custom sections (api.CustomSection) in
+	// this module, e.g. "producers", "target_features" or vendor specific
+	// ones. The "name" section is first, if present, and others are in the
+	// order they appear in the binary. Names aren't unique.
+	//
+	// Note: This is only complete when RuntimeConfig.WithCustomSections is
+	// enabled.
 	CustomSections() []api.CustomSection
 
+	// CustomSection returns the first custom section (api.CustomSection) in
+	// this module named `name`, or nil if there is none.
+	//
+	// Note: This is only reliable when RuntimeConfig.WithCustomSections is
+	// enabled. See experimental/customsection to decode well-known ones.
+	CustomSection(name string) api.CustomSection
+
+	// Marshal returns the compiled machine code and the original binary, so
+	// that Runtime.UnmarshalCompiledModule can load this without compiling
+	// it again. e.g. to precompile a module at build time.
+	//
+	// # Notes
+	//
+	//   - The result is specific to the version of wazero, GOOS and GOARCH.
+	//   - This returns an error for the interpreter, and for modules defined
+	//     with HostModuleBuilder.
+	Marshal() ([]byte, error)
+
 	// Close releases all the allocated resources for this CompiledModule.
 	//
 	// Note: It is safe to call Close while having outstanding calls from an
@@ -357,6 +542,13 @@ type compiledModule struct {
 	// closeWithModule prevents leaking compiled code when a module is compiled implicitly.
 	closeWithModule bool
 	typeIDs         []wasm.FunctionTypeID
+	// binary is the source of `module`, if it was decoded from one.
+	binary []byte
+	// goos and goarch are the platform `module` was compiled for.
+	goos, goarch string
+	// snapshot is the state of instances, when returned by
+	// Runtime.SnapshotModule.
+	snapshot *wasm.Snapshot
 }
 
 // Name implements CompiledModule.Name
@@ -369,6 +561,9 @@ func (c *compiledModule) Name() (moduleName string) {
 
 // Close implements CompiledModule.Close
 func (c *compiledModule) Close(context.Context) error {
+	if c.snapshot != nil {
+		return nil // The compiled code is shared with the snapshotted module.
+	}
 	c.compiledEngine.DeleteCompiledModule(c.module)
 	// It is possible the underlying may need to return an error later, but in any case this matches api.Module.Close.
 	return nil
@@ -394,15 +589,41 @@ func (c *compiledModule) ExportedMemories() map[string]api.MemoryDefinition {
 	return c.module.ExportedMemories()
 }
 
+// Imports implements CompiledModule.Imports
+func (c *compiledModule) Imports() []api.Import {
+	return c.module.ImportDefinitions()
+}
+
+// Exports implements CompiledModule.Exports
+func (c *compiledModule) Exports() []api.Export {
+	return c.module.ExportDefinitions()
+}
+
 // CustomSections implements CompiledModule.CustomSections
 func (c *compiledModule) CustomSections() []api.CustomSection {
-	ret := make([]api.CustomSection, len(c.module.CustomSections))
-	for i, d := range c.module.CustomSections {
-		ret[i] = &customSection{data: d.Data, name: d.Name}
+	ret := make([]api.CustomSection, 0, len(c.module.CustomSections)+1)
+	if d := c.module.NameSectionData; d != nil {
+		ret = append(ret, &customSection{data: d, name: "name"})
+	}
+	for _, d := range c.module.CustomSections {
+		ret = append(ret, &customSection{data: d.Data, name: d.Name})
 	}
 	return ret
 }
 
+// CustomSection implements CompiledModule.CustomSection
+func (c *compiledModule) CustomSection(name string) api.CustomSection {
+	if d := c.module.NameSectionData; name == "name" && d != nil {
+		return &customSection{data: d, name: name}
+	}
+	for _, d := range c.module.CustomSections {
+		if d.Name == name {
+			return &customSection{data: d.Data, name: d.Name}
+		}
+	}
+	return nil
+}
+
 // customSection implements wasm.CustomSection
 type customSection struct {
 	internalapi.WazeroOnlyType
@@ -622,6 +843,11 @@ type ModuleConfig interface {
 
 	// WithSysNanosleep uses time.Sleep for sys.Nanosleep.
 	//
+	// Unlike WithNanosleep, sleeps in host functions such as WASI
+	// "poll_oneoff" stop early when the context of the call is done or the
+	// module is closed. This allows graceful shutdown of guests sleeping for a
+	// long time.
+	//
 	// See WithNanosleep
 	WithSysNanosleep() ModuleConfig
 
@@ -650,6 +876,7 @@ type moduleConfig struct {
 	nanotime           sys.Nanotime
 	nanotimeResolution sys.ClockResolution
 	nanosleep          sys.Nanosleep
+	sysNanosleep       bool // true when nanosleep is from WithSysNanosleep
 	osyield            sys.Osyield
 	args               [][]byte
 	// environ is pair-indexed to retain order similar to os.Environ.
@@ -660,6 +887,14 @@ type moduleConfig struct {
 	fsConfig FSConfig
 	// sockConfig is the network listener configuration for ABI like WASI.
 	sockConfig *internalsock.Config
+	// stdioConfig overrides the file type of stdio for ABI like WASI.
+	stdioConfig *internalsys.StdioConfig
+	// procExitConfig overrides the behavior of proc_exit in WASI.
+	procExitConfig *internalsys.ProcExitConfig
+	// rightsConfig restricts the rights of pre-opened directories in WASI.
+	rightsConfig internalsys.RightsConfig
+	// hostState is the state of the embedder attached to the module.
+	hostState *internalsys.HostState
 }
 
 // NewModuleConfig returns a ModuleConfig that can be used for configuring module instantiation.
@@ -797,6 +1032,7 @@ func (c *moduleConfig) WithSysNanotime() ModuleConfig {
 func (c *moduleConfig) WithNanosleep(nanosleep sys.Nanosleep) ModuleConfig {
 	ret := *c // copy
 	ret.nanosleep = nanosleep
+	ret.sysNanosleep = false
 	return &ret
 }
 
@@ -809,7 +1045,9 @@ func (c *moduleConfig) WithOsyield(osyield sys.Osyield) ModuleConfig {
 
 // WithSysNanosleep implements ModuleConfig.WithSysNanosleep
 func (c *moduleConfig) WithSysNanosleep() ModuleConfig {
-	return c.WithNanosleep(platform.Nanosleep)
+	ret := c.WithNanosleep(platform.Nanosleep).(*moduleConfig)
+	ret.sysNanosleep = true
+	return ret
 }
 
 // WithRandSource implements ModuleConfig.WithRandSource
@@ -846,10 +1084,16 @@ func (c *moduleConfig) toSysContext() (sysCtx *internalsys.Context, err error) {
 		environ = append(environ, result)
 	}
 
+	randSource := c.randSource
+	if randSource == nil {
+		randSource = platform.NewFakeRandSource()
+	}
+
 	var fs []experimentalsys.FS
 	var guestPaths []string
 	if f, ok := c.fsConfig.(*fsConfig); ok {
-		fs, guestPaths = f.preopens()
+		// Any sysfs.DevFS defaults to the same sources as the module.
+		fs, guestPaths = f.preopens(&sysfs.DevFS{Rand: randSource, Stdin: c.stdin, Stdout: c.stdout})
 	}
 
 	var listeners []*net.TCPListener
@@ -859,18 +1103,45 @@ func (c *moduleConfig) toSysContext() (sysCtx *internalsys.Context, err error) {
 		}
 	}
 
-	return internalsys.NewContext(
+	if sysCtx, err = internalsys.NewContext(
 		math.MaxUint32,
 		c.args,
 		environ,
 		c.stdin,
 		c.stdout,
 		c.stderr,
-		c.randSource,
+		randSource,
 		c.walltime, c.walltimeResolution,
 		c.nanotime, c.nanotimeResolution,
 		c.nanosleep, c.osyield,
 		fs, guestPaths,
 		listeners,
-	)
+	); err != nil {
+		return
+	}
+
+	if s := c.stdioConfig; s != nil {
+		sysCtx.FS().OverrideStdio(*s)
+	}
+
+	if c.sysNanosleep {
+		sysCtx.UseTimerNanosleep()
+	}
+
+	if p := c.procExitConfig; p != nil {
+		sysCtx.SetProcExitConfig(p)
+	}
+
+	if r := c.rightsConfig; r != nil {
+		sysCtx.FS().RestrictPreopens(r)
+	}
+
+	if h := c.hostState; h != nil {
+		sysCtx.SetHostState(h.State)
+	}
+
+	if n := c.sockConfig; n != nil && n.Permit != nil {
+		sysCtx.FS().AllowSockOpen(n.Permit)
+	}
+	return
 }
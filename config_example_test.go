@@ -26,9 +26,14 @@ func Example_runtimeConfig_WithCustomSections() {
 		log.Panicln("Custom sections should not be nil")
 	}
 
+	mustContain(m.CustomSections(), "name")
 	mustContain(m.CustomSections(), "producers")
 	mustContain(m.CustomSections(), "target_features")
 
+	if m.CustomSection("producers") == nil {
+		log.Panicln("producers section should not be nil")
+	}
+
 	// Output:
 	//
 }
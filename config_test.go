@@ -52,6 +52,15 @@ func TestRuntimeConfig(t *testing.T) {
 				memoryCapacityFromMax: true,
 			},
 		},
+		{
+			name: "memoryMmap",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithMemoryMmap(true)
+			},
+			expected: &runtimeConfig{
+				memoryMmap: true,
+			},
+		},
 		{
 			name: "WithDebugInfoEnabled",
 			with: func(c RuntimeConfig) RuntimeConfig {
@@ -75,6 +84,11 @@ func TestRuntimeConfig(t *testing.T) {
 			with:     func(c RuntimeConfig) RuntimeConfig { return c.WithCloseOnContextDone(true) },
 			expected: &runtimeConfig{ensureTermination: true},
 		},
+		{
+			name:     "WithCompilationTarget",
+			with:     func(c RuntimeConfig) RuntimeConfig { return c.WithCompilationTarget("linux", "arm64") },
+			expected: &runtimeConfig{targetGOOS: "linux", targetGOARCH: "arm64"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -96,6 +110,21 @@ func TestRuntimeConfig(t *testing.T) {
 		})
 		require.EqualError(t, err, "memoryLimitPages invalid: 65537 > 65536")
 	})
+
+	t.Run("WithCompilationTarget unsupported panics", func(t *testing.T) {
+		err := require.CapturePanic(func() {
+			input := &runtimeConfig{}
+			input.WithCompilationTarget("plan9", "amd64")
+		})
+		require.EqualError(t, err, "unsupported compilation target plan9/amd64")
+	})
+
+	t.Run("WithCompilationTarget interpreter panics", func(t *testing.T) {
+		err := require.CapturePanic(func() {
+			NewRuntimeConfigInterpreter().WithCompilationTarget("linux", "amd64")
+		})
+		require.EqualError(t, err, "compilation target requires the compiler")
+	})
 }
 
 func TestModuleConfig(t *testing.T) {
@@ -643,6 +672,14 @@ func Test_compiledModule_CustomSections(t *testing.T) {
 			input:    &compiledModule{module: &wasm.Module{}},
 			expected: []string{},
 		},
+		{
+			name: "name section",
+			input: &compiledModule{module: &wasm.Module{
+				NameSectionData: []byte{0},
+				CustomSections:  []*wasm.CustomSection{{Name: "custom1"}},
+			}},
+			expected: []string{"name", "custom1"},
+		},
 		{
 			name: "name",
 			input: &compiledModule{module: &wasm.Module{
@@ -675,6 +712,27 @@ func Test_compiledModule_CustomSections(t *testing.T) {
 	}
 }
 
+func Test_compiledModule_CustomSection(t *testing.T) {
+	c := &compiledModule{module: &wasm.Module{
+		NameSectionData: []byte{0},
+		CustomSections: []*wasm.CustomSection{
+			{Name: "custom1", Data: []byte{1}},
+			{Name: "customDup", Data: []byte{2}},
+			{Name: "customDup", Data: []byte{3}},
+		},
+	}}
+
+	s := c.CustomSection("name")
+	require.Equal(t, "name", s.Name())
+	require.Equal(t, []byte{0}, s.Data())
+	s = c.CustomSection("custom1")
+	require.Equal(t, "custom1", s.Name())
+	require.Equal(t, []byte{1}, s.Data())
+	require.Equal(t, []byte{2}, c.CustomSection("customDup").Data())
+	require.Nil(t, c.CustomSection("custom2"))
+	require.Nil(t, (&compiledModule{module: &wasm.Module{}}).CustomSection("name"))
+}
+
 func Test_compiledModule_Close(t *testing.T) {
 	for _, ctx := range []context.Context{nil, testCtx} { // Ensure it doesn't crash on nil!
 		e := &mockEngine{name: "1", cachedModules: map[*wasm.Module]struct{}{}}
@@ -0,0 +1,36 @@
+package experimental
+
+import (
+	"context"
+
+	"github.com/tetratelabs/wazero/internal/callstack"
+)
+
+// CallStackLimits limit the call stack of calls to a module, above which they
+// fail with a stack overflow trap. Zero fields use the limits of the
+// wazero.RuntimeConfig.
+type CallStackLimits struct {
+	// MaxDepth is the maximum number of nested function calls of the
+	// interpreter. See wazero.RuntimeConfig WithMaxCallDepth.
+	MaxDepth uint32
+
+	// MaxStackSize is the maximum size in bytes of the stack of the compiler.
+	// See wazero.RuntimeConfig WithMaxStackSize.
+	MaxStackSize uint64
+}
+
+// WithCallStackLimits registers the given CallStackLimits into the given
+// context.Context. Modules instantiated with it by wazero.Runtime
+// InstantiateModule use them instead of the limits of the runtime.
+//
+// For example, this allows a deeply recursive module to use a larger stack
+// than others:
+//
+//	ctx = experimental.WithCallStackLimits(ctx, experimental.CallStackLimits{MaxDepth: 100_000})
+//	mod, _ := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig())
+func WithCallStackLimits(ctx context.Context, limits CallStackLimits) context.Context {
+	return context.WithValue(ctx, callstack.LimitsKey{}, callstack.Limits{
+		MaxDepth: limits.MaxDepth,
+		MaxSize:  limits.MaxStackSize,
+	})
+}
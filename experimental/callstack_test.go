@@ -0,0 +1,65 @@
+package experimental_test
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/binaryencoding"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+func TestWithCallStackLimits(t *testing.T) {
+	// recurse calls itself, decrementing its parameter until zero.
+	bin := binaryencoding.EncodeModule(&wasm.Module{
+		TypeSection:     []wasm.FunctionType{{Params: []wasm.ValueType{wasm.ValueTypeI32}, ParamNumInUint64: 1}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []wasm.Code{{Body: []byte{
+			wasm.OpcodeLocalGet, 0, wasm.OpcodeIf, 0x40,
+			wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Sub, wasm.OpcodeCall, 0,
+			wasm.OpcodeEnd, wasm.OpcodeEnd,
+		}}},
+		ExportSection: []wasm.Export{{Name: "recurse", Type: wasm.ExternTypeFunc, Index: 0}},
+	})
+
+	t.Run("interpreter", func(t *testing.T) {
+		r := wazero.NewRuntimeWithConfig(testCtx, wazero.NewRuntimeConfigInterpreter().WithMaxCallDepth(100))
+		defer r.Close(testCtx)
+
+		mod, err := r.Instantiate(testCtx, bin)
+		require.NoError(t, err)
+		_, err = mod.ExportedFunction("recurse").Call(testCtx, 50)
+		require.NoError(t, err)
+		_, err = mod.ExportedFunction("recurse").Call(testCtx, 100)
+		require.Contains(t, err.Error(), "wasm error: stack overflow: exceeded the maximum call depth of 100")
+		require.Contains(t, err.Error(), "... 68 frames omitted")
+
+		// The limits of the context override the ones of the runtime.
+		ctx := experimental.WithCallStackLimits(testCtx, experimental.CallStackLimits{MaxDepth: 1000})
+		mod, err = r.InstantiateWithConfig(ctx, bin, wazero.NewModuleConfig().WithName("deeper"))
+		require.NoError(t, err)
+		_, err = mod.ExportedFunction("recurse").Call(testCtx, 500)
+		require.NoError(t, err)
+	})
+
+	if platform.CompilerSupported() {
+		t.Run("compiler", func(t *testing.T) {
+			r := wazero.NewRuntimeWithConfig(testCtx, wazero.NewRuntimeConfigCompiler().WithMaxStackSize(64<<10))
+			defer r.Close(testCtx)
+
+			mod, err := r.Instantiate(testCtx, bin)
+			require.NoError(t, err)
+			_, err = mod.ExportedFunction("recurse").Call(testCtx, 100_000)
+			require.Contains(t, err.Error(), "wasm error: stack overflow: exceeded the maximum stack size of 65536 bytes")
+			require.Contains(t, err.Error(), "frames omitted")
+
+			ctx := experimental.WithCallStackLimits(testCtx, experimental.CallStackLimits{MaxStackSize: 64 << 20})
+			mod, err = r.InstantiateWithConfig(ctx, bin, wazero.NewModuleConfig().WithName("deeper"))
+			require.NoError(t, err)
+			_, err = mod.ExportedFunction("recurse").Call(testCtx, 100_000)
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package experimental
+
+import (
+	"context"
+
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
+)
+
+// WithCancelCallOnContextDone returns a copy of the given context.Context,
+// which fails calls made with it when it is done, e.g. reaches its deadline or
+// is canceled, instead of closing the module.
+//
+// The call fails with an error wrapping the error of the context, so it can be
+// checked with errors.Is. For example:
+//
+//	ctx, cancel := context.WithTimeout(ctx, time.Second)
+//	defer cancel()
+//	ctx = experimental.WithCancelCallOnContextDone(ctx)
+//	_, err := mod.ExportedFunction("run").Call(ctx)
+//	if errors.Is(err, context.DeadlineExceeded) {
+//		// handle the timeout, mod is still open.
+//	}
+//
+// # Notes
+//
+//   - This requires wazero.RuntimeConfig WithCloseOnContextDone, as its
+//     checks stop tight loops in the guest. Otherwise, the context has no
+//     effect on the call.
+//   - Like a trap, the call may stop at any loop, so the memory and globals
+//     of the module may be inconsistent. Only keep using the module if the
+//     guest can recover from this, e.g. it has no state between calls.
+//   - Host functions called by the guest should also honor the context, as
+//     the call can't stop while they run.
+func WithCancelCallOnContextDone(ctx context.Context) context.Context {
+	return context.WithValue(ctx, internalsys.CancelCallKey{}, true)
+}
@@ -0,0 +1,79 @@
+package experimental_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/binaryencoding"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+func TestWithCancelCallOnContextDone(t *testing.T) {
+	configs := map[string]wazero.RuntimeConfig{"interpreter": wazero.NewRuntimeConfigInterpreter()}
+	if platform.CompilerSupported() {
+		configs["compiler"] = wazero.NewRuntimeConfigCompiler()
+	}
+	bin := binaryencoding.EncodeModule(&wasm.Module{
+		TypeSection:     []wasm.FunctionType{{}},
+		FunctionSection: []wasm.Index{0, 0},
+		CodeSection: []wasm.Code{
+			{Body: []byte{wasm.OpcodeLoop, 0x40, wasm.OpcodeBr, 0, wasm.OpcodeEnd, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeEnd}},
+		},
+		ExportSection: []wasm.Export{
+			{Name: "infinite_loop", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "noop", Type: wasm.ExternTypeFunc, Index: 1},
+		},
+	})
+
+	for n, c := range configs {
+		config := c.WithCloseOnContextDone(true)
+		t.Run(n, func(t *testing.T) {
+			r := wazero.NewRuntimeWithConfig(testCtx, config)
+			defer r.Close(testCtx)
+
+			mod, err := r.Instantiate(testCtx, bin)
+			require.NoError(t, err)
+
+			t.Run("deadline", func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(testCtx, 10*time.Millisecond)
+				defer cancel()
+
+				_, err := mod.ExportedFunction("infinite_loop").Call(experimental.WithCancelCallOnContextDone(ctx))
+				require.True(t, errors.Is(err, context.DeadlineExceeded))
+				require.False(t, mod.IsClosed())
+			})
+
+			t.Run("canceled", func(t *testing.T) {
+				ctx, cancel := context.WithCancel(testCtx)
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					cancel()
+				}()
+
+				_, err := mod.ExportedFunction("infinite_loop").Call(experimental.WithCancelCallOnContextDone(ctx))
+				require.True(t, errors.Is(err, context.Canceled))
+				require.False(t, mod.IsClosed())
+			})
+
+			t.Run("already done", func(t *testing.T) {
+				ctx, cancel := context.WithCancel(testCtx)
+				cancel()
+
+				_, err := mod.ExportedFunction("noop").Call(experimental.WithCancelCallOnContextDone(ctx))
+				require.True(t, errors.Is(err, context.Canceled))
+				require.False(t, mod.IsClosed())
+			})
+
+			// The module is still usable.
+			_, err = mod.ExportedFunction("noop").Call(testCtx)
+			require.NoError(t, err)
+		})
+	}
+}
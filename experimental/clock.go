@@ -0,0 +1,52 @@
+package experimental
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// ScaleNanotime returns a sys.Nanotime where time passes `scale` times as fast
+// as `nanotime`, starting from its first reading. For example, 2 is twice as
+// fast and 0.5 half as fast. This allows simulations to control how guests
+// perceive elapsed time.
+//
+// Use this with wazero.ModuleConfig WithNanotime, which also declares the
+// resolution returned to the guest, e.g. by WASI clock_res_get. Use
+// ScaleNanosleep with the same `scale`, so that sleeping agrees with the
+// clock.
+//
+// Note: This panics if `scale` isn't positive, as the clock would not
+// increase.
+func ScaleNanotime(nanotime sys.Nanotime, scale float64) sys.Nanotime {
+	requirePositiveScale(scale)
+	var once sync.Once
+	var start int64
+	return func() int64 {
+		now := nanotime()
+		once.Do(func() { start = now })
+		return start + int64(float64(now-start)*scale)
+	}
+}
+
+// ScaleNanosleep returns a sys.Nanosleep which sleeps for the duration
+// `nanosleep` would, divided by `scale`. This is the counterpart of
+// ScaleNanotime: when time passes twice as fast, sleeping for one second
+// takes half a second.
+//
+// Use this with wazero.ModuleConfig WithNanosleep.
+//
+// Note: This panics if `scale` isn't positive.
+func ScaleNanosleep(nanosleep sys.Nanosleep, scale float64) sys.Nanosleep {
+	requirePositiveScale(scale)
+	return func(ns int64) {
+		nanosleep(int64(float64(ns) / scale))
+	}
+}
+
+func requirePositiveScale(scale float64) {
+	if !(scale > 0) { // Also catches NaN.
+		panic(fmt.Errorf("invalid scale: %v", scale))
+	}
+}
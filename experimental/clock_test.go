@@ -0,0 +1,34 @@
+package experimental_test
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestScaleNanotime(t *testing.T) {
+	now := int64(1000)
+	nanotime := experimental.ScaleNanotime(func() int64 { return now }, 2)
+
+	// The first reading is unchanged.
+	require.Equal(t, int64(1000), nanotime())
+
+	// Elapsed time is scaled.
+	now += 500
+	require.Equal(t, int64(2000), nanotime())
+
+	err := require.CapturePanic(func() { experimental.ScaleNanotime(nil, 0) })
+	require.EqualError(t, err, "invalid scale: 0")
+}
+
+func TestScaleNanosleep(t *testing.T) {
+	var slept int64
+	nanosleep := experimental.ScaleNanosleep(func(ns int64) { slept = ns }, 2)
+
+	nanosleep(1000)
+	require.Equal(t, int64(500), slept)
+
+	err := require.CapturePanic(func() { experimental.ScaleNanosleep(nil, -1) })
+	require.EqualError(t, err, "invalid scale: -1")
+}
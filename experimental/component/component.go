@@ -0,0 +1,528 @@
+// Package component instantiates components of the component model, which
+// link core modules with the canonical ABI, so that their functions are
+// called with values like strings instead of numbers and pointers.
+//
+// A component is decoded, then instantiated by a Linker which defines the
+// functions it imports:
+//
+//	c, err := component.Decode(bin)
+//	inst, err := component.NewLinker(r).
+//		DefineInstanceFunc("example:greeter/host", "log", func(msg string) {
+//			fmt.Println(msg)
+//		}).
+//		Instantiate(ctx, c)
+//	results, err := inst.ExportedFunction("greet").Call(ctx, "wazero")
+//
+// Values of component types are passed as Go values: bool, int8, uint8,
+// int16, uint16, int32, uint32, int64, uint64, float32, float64, rune for
+// char, and string.
+//
+// # Notes
+//
+//   - This is experimental, and likely to change.
+//   - Only a subset of the component model is supported: components which
+//     don't nest components, and whose imported and exported functions have
+//     params and results of the types above, encoded as UTF-8. Other types
+//     decode, e.g. records and resources, but their functions can't be
+//     lifted nor lowered.
+//   - WASI preview2 interfaces aren't implemented. Instead, a Linker defines
+//     the functions of the interfaces a component imports, as long as their
+//     types are supported.
+//   - Core modules are compiled each time a component is instantiated, as
+//     their imports are renamed to the module instances satisfying them.
+package component
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	internalcomponent "github.com/tetratelabs/wazero/internal/component"
+)
+
+// Component is a decoded component, which can be instantiated multiple times.
+type Component struct {
+	c *internalcomponent.Component
+}
+
+// Decode decodes the component `bin`, e.g. built with `wasm-tools component
+// new`. This errs if it uses features which aren't supported, e.g. nested
+// components.
+func Decode(bin []byte) (*Component, error) {
+	c, err := internalcomponent.Decode(bin)
+	if err != nil {
+		return nil, fmt.Errorf("invalid component: %w", err)
+	}
+	return &Component{c: c}, nil
+}
+
+// IsComponent returns true if `bin` is a component, instead of a core module
+// to compile with wazero.Runtime CompileModule.
+func IsComponent(bin []byte) bool {
+	return internalcomponent.IsComponent(bin)
+}
+
+// Linker defines the functions imported by components, and instantiates them
+// in a wazero.Runtime.
+type Linker struct {
+	r     wazero.Runtime
+	funcs map[importKey]interface{}
+}
+
+// importKey is the name of an imported function, and of the instance
+// exporting it, if any.
+type importKey struct {
+	instance, name string
+}
+
+// NewLinker returns a Linker instantiating components in `r`.
+func NewLinker(r wazero.Runtime) *Linker {
+	return &Linker{r: r, funcs: map[importKey]interface{}{}}
+}
+
+// DefineFunc defines the function imported as `name`. `fn` is a Go func,
+// whose params and results are the Go values of the params and results of
+// the imported function, optionally preceded by a context.Context.
+//
+// The type of `fn` is only checked when a component lowering it is
+// instantiated.
+func (l *Linker) DefineFunc(name string, fn interface{}) *Linker {
+	return l.DefineInstanceFunc("", name, fn)
+}
+
+// DefineInstanceFunc is like DefineFunc, but defines the function exported as
+// `name` by the instance imported as `instance`, e.g. an interface like
+// "wasi:cli/environment@0.2.0".
+func (l *Linker) DefineInstanceFunc(instance, name string, fn interface{}) *Linker {
+	l.funcs[importKey{instance: instance, name: name}] = fn
+	return l
+}
+
+// instanceCount makes the names of the module instances of each component
+// instance unique.
+var instanceCount uint64
+
+// Instantiate instantiates the component `c`, whose imported functions which
+// are lowered must be defined.
+//
+// Each core module of the component is instantiated as a module named after
+// the component instance, e.g. "component[1]/0", and so are the functions
+// lowered to core functions, e.g. "component[1]/lowered".
+func (l *Linker) Instantiate(ctx context.Context, c *Component) (*Instance, error) {
+	i := &Instance{
+		c:               c.c,
+		prefix:          fmt.Sprintf("component[%d]", atomic.AddUint64(&instanceCount, 1)),
+		core:            make([]api.Module, len(c.c.CoreInstances)),
+		funcs:           map[string]*Function{},
+		instanceFuncs:   map[string]map[string]*Function{},
+		loweredFuncName: map[uint32]string{},
+	}
+	if err := i.instantiate(ctx, l); err != nil {
+		_ = i.Close(ctx)
+		return nil, err
+	}
+	return i, nil
+}
+
+// Instance is an instance of a component.
+type Instance struct {
+	c      *internalcomponent.Component
+	prefix string
+	// lowered is the host module exporting the lowered functions, if any.
+	lowered api.Module
+	// loweredFuncName are the export names of the lowered functions in
+	// lowered, by their core function index.
+	loweredFuncName map[uint32]string
+	// core are the instances of core modules, by their core instance index,
+	// or nil for core instances defined by inline exports.
+	core          []api.Module
+	funcs         map[string]*Function
+	instanceFuncs map[string]map[string]*Function
+}
+
+func (i *Instance) instantiate(ctx context.Context, l *Linker) error {
+	if err := i.instantiateLowered(ctx, l); err != nil {
+		return err
+	}
+
+	for index, ci := range i.c.CoreInstances {
+		if ci.Args == nil {
+			continue // inline exports
+		}
+		args := ci.Args
+		bin, err := internalcomponent.RenameImports(i.c.CoreModules[ci.Module], func(module, name string) (string, string, error) {
+			arg, ok := args[module]
+			if !ok {
+				return "", "", fmt.Errorf("core instance[%d]: no argument for imports of module %q", index, module)
+			}
+			return i.resolveImport(arg, name)
+		})
+		if err != nil {
+			return err
+		}
+		compiled, err := l.r.CompileModule(ctx, bin)
+		if err != nil {
+			return fmt.Errorf("core module[%d]: %w", ci.Module, err)
+		}
+		config := wazero.NewModuleConfig().WithName(i.coreInstanceName(uint32(index))).WithStartFunctions()
+		i.core[index], err = l.r.InstantiateModule(ctx, compiled, config)
+		_ = compiled.Close(ctx)
+		if err != nil {
+			return fmt.Errorf("core instance[%d]: %w", index, err)
+		}
+	}
+
+	for name, index := range i.c.FuncExports {
+		f, err := i.function(index)
+		if err != nil {
+			return fmt.Errorf("export %q: %w", name, err)
+		}
+		i.funcs[name] = f
+	}
+	for name, index := range i.c.InstanceExports {
+		funcs := map[string]*Function{}
+		for funcName, funcIndex := range i.c.Instances[index].Funcs {
+			f, err := i.function(funcIndex)
+			if err != nil {
+				return fmt.Errorf("export %q: func %q: %w", name, funcName, err)
+			}
+			funcs[funcName] = f
+		}
+		i.instanceFuncs[name] = funcs
+	}
+	return nil
+}
+
+func (i *Instance) coreInstanceName(index uint32) string {
+	return i.prefix + "/" + strconv.Itoa(int(index))
+}
+
+// resolveImport returns the module and the export name of the item exported
+// as `name` by the core instance `arg`.
+func (i *Instance) resolveImport(arg uint32, name string) (string, string, error) {
+	ci := &i.c.CoreInstances[arg]
+	if ci.Args != nil {
+		return i.coreInstanceName(arg), name, nil
+	}
+	for _, e := range ci.Exports {
+		if e.Name != name {
+			continue
+		}
+		var alias internalcomponent.CoreAlias
+		switch e.Sort {
+		case internalcomponent.CoreSortFunc:
+			f := i.c.CoreFuncs[e.Index]
+			if f.Lower != nil {
+				return i.prefix + "/lowered", i.loweredFuncName[e.Index], nil
+			}
+			alias = *f.Alias
+		case internalcomponent.CoreSortTable:
+			alias = i.c.CoreTables[e.Index]
+		case internalcomponent.CoreSortMemory:
+			alias = i.c.CoreMemories[e.Index]
+		case internalcomponent.CoreSortGlobal:
+			alias = i.c.CoreGlobals[e.Index]
+		}
+		return i.coreInstanceName(alias.Instance), alias.Name, nil
+	}
+	return "", "", fmt.Errorf("core instance[%d] doesn't export %q", arg, name)
+}
+
+// instantiateLowered instantiates the host module exporting the functions
+// lowered to core functions, which call the functions defined in `l`.
+func (i *Instance) instantiateLowered(ctx context.Context, l *Linker) error {
+	b := l.r.NewHostModuleBuilder(i.prefix + "/lowered")
+	for index, cf := range i.c.CoreFuncs {
+		if cf.Lower == nil {
+			continue
+		}
+		f := &i.c.Funcs[cf.Lower.Func]
+		if f.Lift != nil {
+			return fmt.Errorf("core func[%d]: lowering a lifted function isn't supported", index)
+		}
+		key := importKey{instance: f.ImportInstance, name: f.ImportName}
+		fn, ok := l.funcs[key]
+		if !ok {
+			return fmt.Errorf("import %s isn't defined", key)
+		}
+		params, results, err := f.Type.CoreType(false)
+		if err != nil {
+			return fmt.Errorf("import %s: %w", key, err)
+		} else if err = checkOptions(f.Type, cf.Lower, false); err != nil {
+			return fmt.Errorf("import %s: %w", key, err)
+		}
+		host, hasCtx, err := checkGoFunc(f.Type, fn)
+		if err != nil {
+			return fmt.Errorf("import %s: %w", key, err)
+		}
+
+		name := strconv.Itoa(index)
+		i.loweredFuncName[uint32(index)] = name
+		b.NewFunctionBuilder().
+			WithGoModuleFunction(i.lower(f.Type, cf.Lower, host, hasCtx), params, results).
+			Export(name)
+	}
+	if len(i.loweredFuncName) == 0 {
+		return nil
+	}
+	var err error
+	i.lowered, err = b.Instantiate(ctx)
+	return err
+}
+
+// String implements fmt.Stringer.
+func (k importKey) String() string {
+	if k.instance == "" {
+		return strconv.Quote(k.name)
+	}
+	return strconv.Quote(k.instance + "#" + k.name)
+}
+
+// checkOptions returns an error if the canonical options `canon` don't allow
+// lifting or lowering a function of type `t`.
+func checkOptions(t *internalcomponent.FuncType, canon *internalcomponent.Canon, lift bool) error {
+	if t.RequiresMemory() {
+		if canon.Memory == nil {
+			return fmt.Errorf("memory option is required to lift or lower %s", t)
+		} else if canon.StringEncoding != internalcomponent.StringEncodingUTF8 {
+			return fmt.Errorf("string encodings other than UTF-8 aren't supported")
+		}
+	}
+	if t.RequiresRealloc(lift) && canon.Realloc == nil {
+		return fmt.Errorf("realloc option is required to lift or lower %s", t)
+	}
+	return nil
+}
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// checkGoFunc returns the reflect.Value of `fn`, if it is a Go func of the
+// Go types of `t`, and whether its first param is a context.Context.
+func checkGoFunc(t *internalcomponent.FuncType, fn interface{}) (v reflect.Value, hasCtx bool, err error) {
+	v = reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return v, false, fmt.Errorf("expected a func, but was %T", fn)
+	}
+	ft := v.Type()
+	in := 0
+	if ft.NumIn() > 0 && ft.In(0) == contextType {
+		hasCtx, in = true, 1
+	}
+	if ft.IsVariadic() || ft.NumIn()-in != len(t.Params) || ft.NumOut() != len(t.Results) {
+		return v, false, fmt.Errorf("expected a func of %s, but was %T", t, fn)
+	}
+	for k, p := range t.Params {
+		if expected := internalcomponent.GoType(p.Type); ft.In(in+k) != expected {
+			return v, false, fmt.Errorf("param %q: expected %s, but was %s", p.Name, expected, ft.In(in+k))
+		}
+	}
+	for k, r := range t.Results {
+		if expected := internalcomponent.GoType(r); ft.Out(k) != expected {
+			return v, false, fmt.Errorf("result[%d]: expected %s, but was %s", k, expected, ft.Out(k))
+		}
+	}
+	return v, hasCtx, nil
+}
+
+// lower returns the host function of a function of type `t` lowered with the
+// options `canon`, which calls `host`.
+func (i *Instance) lower(t *internalcomponent.FuncType, canon *internalcomponent.Canon, host reflect.Value, hasCtx bool) api.GoModuleFunc {
+	paramTypes := make([]internalcomponent.ValueType, len(t.Params))
+	for k, p := range t.Params {
+		paramTypes[k] = p.Type
+	}
+	coreParams, coreResults, _ := t.CoreType(false)
+	// Results which don't fit in core results are stored at the address
+	// passed after the params.
+	indirect := len(coreResults) == 0 && len(t.Results) > 0
+	return func(ctx context.Context, _ api.Module, stack []uint64) {
+		opts, err := i.options(canon)
+		if err != nil {
+			panic(err)
+		}
+		params, err := opts.LiftFlat(paramTypes, stack)
+		if err != nil {
+			panic(err)
+		}
+
+		in := make([]reflect.Value, 0, len(params)+1)
+		if hasCtx {
+			in = append(in, reflect.ValueOf(ctx))
+		}
+		for _, p := range params {
+			in = append(in, reflect.ValueOf(p))
+		}
+		out := host.Call(in)
+		results := make([]interface{}, len(out))
+		for k, r := range out {
+			results[k] = r.Interface()
+		}
+
+		if indirect {
+			err = opts.Store(ctx, uint32(stack[len(coreParams)-1]), t.Results, results)
+		} else {
+			_, err = opts.LowerFlat(ctx, stack[:0], t.Results, results)
+		}
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// options resolves the canonical options `canon` in the instance. This is
+// done when they are used, as lowered functions are defined before the core
+// instances exporting their memory and realloc function.
+func (i *Instance) options(canon *internalcomponent.Canon) (*internalcomponent.Options, error) {
+	opts := &internalcomponent.Options{}
+	if canon.Memory != nil {
+		alias := i.c.CoreMemories[*canon.Memory]
+		if mod := i.core[alias.Instance]; mod != nil {
+			opts.Memory = mod.ExportedMemory(alias.Name)
+		}
+		if opts.Memory == nil {
+			return nil, fmt.Errorf("memory %q of core instance[%d] isn't instantiated", alias.Name, alias.Instance)
+		}
+	}
+	if canon.Realloc != nil {
+		var err error
+		if opts.Realloc, err = i.coreFunc(*canon.Realloc); err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+// coreFunc returns the core function `index`.
+func (i *Instance) coreFunc(index uint32) (api.Function, error) {
+	cf := i.c.CoreFuncs[index]
+	mod, name := i.lowered, i.loweredFuncName[index]
+	if cf.Lower == nil {
+		mod, name = i.core[cf.Alias.Instance], cf.Alias.Name
+	}
+	var fn api.Function
+	if mod != nil {
+		fn = mod.ExportedFunction(name)
+	}
+	if fn == nil {
+		return nil, fmt.Errorf("core func[%d] isn't instantiated", index)
+	}
+	return fn, nil
+}
+
+// function returns the Function `index`, which must be lifted.
+func (i *Instance) function(index uint32) (*Function, error) {
+	f := &i.c.Funcs[index]
+	if f.Lift == nil {
+		return nil, fmt.Errorf("exporting imported functions isn't supported")
+	} else if _, _, err := f.Type.CoreType(true); err != nil {
+		return nil, err
+	} else if err = checkOptions(f.Type, f.Lift, true); err != nil {
+		return nil, err
+	}
+
+	core, err := i.coreFunc(f.Lift.Func)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := i.options(f.Lift)
+	if err != nil {
+		return nil, err
+	}
+	_, coreResults, _ := f.Type.CoreType(false)
+	ret := &Function{t: f.Type, core: core, opts: opts, indirect: len(coreResults) == 0 && len(f.Type.Results) > 0}
+	if f.Lift.PostReturn != nil {
+		if ret.postReturn, err = i.coreFunc(*f.Lift.PostReturn); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// ExportedFunction returns the function exported as `name`, or nil if there
+// is none.
+func (i *Instance) ExportedFunction(name string) *Function {
+	return i.funcs[name]
+}
+
+// ExportedInstanceFunction returns the function exported as `name` by the
+// instance exported as `instance`, e.g. an interface like
+// "wasi:cli/run@0.2.0", or nil if there is none.
+func (i *Instance) ExportedInstanceFunction(instance, name string) *Function {
+	return i.instanceFuncs[instance][name]
+}
+
+// Close closes the module instances of the component instance.
+func (i *Instance) Close(ctx context.Context) (err error) {
+	for k := len(i.core) - 1; k >= 0; k-- {
+		if mod := i.core[k]; mod != nil {
+			if e := mod.Close(ctx); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	if i.lowered != nil {
+		if e := i.lowered.Close(ctx); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+// Function is a function exported by a component instance, which is lifted
+// from a core function.
+//
+// Like api.Function, this isn't safe for concurrent use.
+type Function struct {
+	t          *internalcomponent.FuncType
+	core       api.Function
+	opts       *internalcomponent.Options
+	postReturn api.Function
+	// indirect is true when the core function returns the address of the
+	// results, as they don't fit in core results.
+	indirect bool
+}
+
+// String implements fmt.Stringer, returning the type of the function in WIT,
+// e.g. "func(name: string) -> string".
+func (f *Function) String() string {
+	return f.t.String()
+}
+
+// Call calls the function with the Go values of its params, and returns the
+// Go values of its results. See the package documentation for their types.
+func (f *Function) Call(ctx context.Context, params ...interface{}) ([]interface{}, error) {
+	if len(params) != len(f.t.Params) {
+		return nil, fmt.Errorf("expected %d params, but passed %d", len(f.t.Params), len(params))
+	}
+	var flat []uint64
+	for k, p := range f.t.Params {
+		var err error
+		if flat, err = f.opts.LowerFlat(ctx, flat, []internalcomponent.ValueType{p.Type}, params[k:k+1]); err != nil {
+			return nil, fmt.Errorf("param %q: %w", p.Name, err)
+		}
+	}
+
+	coreResults, err := f.core.Call(ctx, flat...)
+	if err != nil {
+		return nil, err
+	}
+	var results []interface{}
+	if f.indirect {
+		results, err = f.opts.Load(uint32(coreResults[0]), f.t.Results)
+	} else {
+		results, err = f.opts.LiftFlat(f.t.Results, coreResults)
+	}
+	if f.postReturn != nil {
+		if _, postErr := f.postReturn.Call(ctx, coreResults...); postErr != nil && err == nil {
+			err = postErr
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,233 @@
+package component_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental/component"
+	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/testing/binaryencoding"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
+var testCtx = context.WithValue(context.Background(), struct{}{}, "arbitrary")
+
+// libcWasm exports a memory, and a bump allocator as "realloc".
+var libcWasm = binaryencoding.EncodeModule(&wasm.Module{
+	TypeSection: []wasm.FunctionType{{
+		Params:           []api.ValueType{api.ValueTypeI32, api.ValueTypeI32, api.ValueTypeI32, api.ValueTypeI32},
+		Results:          []api.ValueType{api.ValueTypeI32},
+		ParamNumInUint64: 4, ResultNumInUint64: 1,
+	}},
+	FunctionSection: []wasm.Index{0},
+	CodeSection: []wasm.Code{{Body: []byte{
+		wasm.OpcodeGlobalGet, 0,
+		wasm.OpcodeGlobalGet, 0, wasm.OpcodeLocalGet, 3, wasm.OpcodeI32Add, wasm.OpcodeGlobalSet, 0,
+		wasm.OpcodeEnd,
+	}}},
+	MemorySection: &wasm.Memory{Min: 1},
+	GlobalSection: []wasm.Global{{
+		Type: wasm.GlobalType{ValType: api.ValueTypeI32, Mutable: true},
+		Init: wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: leb128.EncodeInt32(1024)},
+	}},
+	ExportSection: []wasm.Export{
+		{Name: "memory", Type: wasm.ExternTypeMemory, Index: 0},
+		{Name: "realloc", Type: wasm.ExternTypeFunc, Index: 0},
+	},
+})
+
+// greeterWasm imports the memory of libcWasm, and "log" from "host". It
+// exports "greet", which logs the string it is passed and returns it, and
+// "add".
+var greeterWasm = binaryencoding.EncodeModule(&wasm.Module{
+	TypeSection: []wasm.FunctionType{
+		{Params: []api.ValueType{api.ValueTypeI32, api.ValueTypeI32}, ParamNumInUint64: 2},
+		{
+			Params:           []api.ValueType{api.ValueTypeI32, api.ValueTypeI32},
+			Results:          []api.ValueType{api.ValueTypeI32},
+			ParamNumInUint64: 2, ResultNumInUint64: 1,
+		},
+	},
+	ImportSection: []wasm.Import{
+		{Module: "env", Name: "memory", Type: wasm.ExternTypeMemory, DescMem: &wasm.Memory{Min: 1}},
+		{Module: "host", Name: "log", Type: wasm.ExternTypeFunc, DescFunc: 0},
+	},
+	FunctionSection: []wasm.Index{1, 1},
+	CodeSection: []wasm.Code{
+		{Body: []byte{
+			wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeCall, 0,
+			// Store the string at 16, and return its address.
+			wasm.OpcodeI32Const, 16, wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Store, 0x2, 0,
+			wasm.OpcodeI32Const, 16, wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Store, 0x2, 4,
+			wasm.OpcodeI32Const, 16,
+			wasm.OpcodeEnd,
+		}},
+		{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Add, wasm.OpcodeEnd}},
+	},
+	ExportSection: []wasm.Export{
+		{Name: "greet", Type: wasm.ExternTypeFunc, Index: 1},
+		{Name: "add", Type: wasm.ExternTypeFunc, Index: 2},
+	},
+})
+
+func encodeName(name string) []byte {
+	return append(leb128.EncodeUint32(uint32(len(name))), name...)
+}
+
+// encodeSection encodes a section of a component, whose items are a vector.
+func encodeSection(id byte, items ...[]byte) []byte {
+	payload := leb128.EncodeUint32(uint32(len(items)))
+	for _, item := range items {
+		payload = append(payload, item...)
+	}
+	return append(append([]byte{id}, leb128.EncodeUint32(uint32(len(payload)))...), payload...)
+}
+
+func join(parts ...[]byte) (ret []byte) {
+	for _, p := range parts {
+		ret = append(ret, p...)
+	}
+	return
+}
+
+// greeterComponent imports the instance "example:greeter/host", whose "log"
+// function it lowers, and lifts the functions of greeterWasm, exporting them
+// as "greet" and "add". It also exports "add" from the instance
+// "example:greeter/math".
+var greeterComponent = join(
+	[]byte{0x00, 0x61, 0x73, 0x6d, 0x0d, 0x00, 0x01, 0x00},
+	// core modules 0 and 1
+	[]byte{1}, leb128.EncodeUint32(uint32(len(libcWasm))), libcWasm,
+	[]byte{1}, leb128.EncodeUint32(uint32(len(greeterWasm))), greeterWasm,
+	encodeSection(7, // types
+		join([]byte{0x40, 1}, encodeName("msg"), []byte{0x73, 0x01, 0x00}),                              // 0: func(msg: string)
+		join([]byte{0x40, 1}, encodeName("name"), []byte{0x73, 0x00, 0x73}),                             // 1: func(name: string) -> string
+		join([]byte{0x40, 2}, encodeName("a"), []byte{0x79}, encodeName("b"), []byte{0x79, 0x00, 0x79}), // 2: func(a: u32, b: u32) -> u32
+		join([]byte{0x42, 2, 0x02, 0x03, 0x02, 0x01, 0}, []byte{0x04, 0x00}, encodeName("log"), []byte{0x01, 0}),
+	),
+	encodeSection(10, join([]byte{0x00}, encodeName("example:greeter/host"), []byte{0x05, 3})),
+	encodeSection(6, join([]byte{0x01, 0x00, 0}, encodeName("log"))), // func 0
+	encodeSection(2, []byte{0x00, 0, 0}),                             // core instance 0 of libcWasm
+	encodeSection(6,
+		join([]byte{0x00, 0x02, 0x01, 0}, encodeName("memory")),  // core memory 0
+		join([]byte{0x00, 0x00, 0x01, 0}, encodeName("realloc")), // core func 0
+	),
+	encodeSection(8, []byte{0x01, 0x00, 0, 1, 0x03, 0}), // core func 1 lowers func 0
+	encodeSection(2,
+		join([]byte{0x01, 1}, encodeName("log"), []byte{0x00, 1}),                                         // core instance 1
+		join([]byte{0x00, 1, 2}, encodeName("env"), []byte{0x12, 0}, encodeName("host"), []byte{0x12, 1}), // core instance 2 of greeterWasm
+	),
+	encodeSection(6,
+		join([]byte{0x00, 0x00, 0x01, 2}, encodeName("greet")), // core func 2
+		join([]byte{0x00, 0x00, 0x01, 2}, encodeName("add")),   // core func 3
+	),
+	encodeSection(8,
+		[]byte{0x00, 0x00, 2, 3, 0x00, 0x03, 0, 0x04, 0, 1}, // func 1 lifts core func 2
+		[]byte{0x00, 0x00, 3, 0, 2},                         // func 2 lifts core func 3
+	),
+	encodeSection(5, join([]byte{0x01, 1, 0x00}, encodeName("add"), []byte{0x01, 2})), // instance 1
+	encodeSection(11,
+		join([]byte{0x00}, encodeName("greet"), []byte{0x01, 1, 0x00}),
+		join([]byte{0x00}, encodeName("add"), []byte{0x01, 2, 0x00}),
+		join([]byte{0x00}, encodeName("example:greeter/math"), []byte{0x05, 1, 0x00}),
+	),
+)
+
+func TestLinker_Instantiate(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	require.True(t, component.IsComponent(greeterComponent))
+	c, err := component.Decode(greeterComponent)
+	require.NoError(t, err)
+
+	var logged []string
+	linker := component.NewLinker(r).DefineInstanceFunc("example:greeter/host", "log", func(ctx context.Context, msg string) {
+		require.Equal(t, testCtx, ctx)
+		logged = append(logged, msg)
+	})
+	// Instantiate twice, as the names of module instances are unique.
+	for i := 0; i < 2; i++ {
+		inst, err := linker.Instantiate(testCtx, c)
+		require.NoError(t, err)
+
+		greet := inst.ExportedFunction("greet")
+		require.Equal(t, "func(name: string) -> string", greet.String())
+		results, err := greet.Call(testCtx, "wazero")
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{"wazero"}, results)
+
+		results, err = inst.ExportedFunction("add").Call(testCtx, uint32(1), uint32(2))
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{uint32(3)}, results)
+		results, err = inst.ExportedInstanceFunction("example:greeter/math", "add").Call(testCtx, uint32(3), uint32(4))
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{uint32(7)}, results)
+
+		_, err = inst.ExportedFunction("add").Call(testCtx, 1, 2)
+		require.EqualError(t, err, `param "a": expected u32 (uint32), but was int`)
+		_, err = greet.Call(testCtx)
+		require.EqualError(t, err, "expected 1 params, but passed 0")
+		require.Nil(t, inst.ExportedFunction("log"))
+
+		require.NoError(t, inst.Close(testCtx))
+	}
+	require.Equal(t, []string{"wazero", "wazero"}, logged)
+}
+
+func TestLinker_Instantiate_Errors(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	c, err := component.Decode(greeterComponent)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		linker      *component.Linker
+		expectedErr string
+	}{
+		{
+			name:        "undefined",
+			linker:      component.NewLinker(r).DefineFunc("log", func(string) {}),
+			expectedErr: `import "example:greeter/host#log" isn't defined`,
+		},
+		{
+			name:        "not a func",
+			linker:      component.NewLinker(r).DefineInstanceFunc("example:greeter/host", "log", "log"),
+			expectedErr: `import "example:greeter/host#log": expected a func, but was string`,
+		},
+		{
+			name:        "wrong param",
+			linker:      component.NewLinker(r).DefineInstanceFunc("example:greeter/host", "log", func([]byte) {}),
+			expectedErr: `import "example:greeter/host#log": param "msg": expected string, but was []uint8`,
+		},
+		{
+			name:        "wrong results",
+			linker:      component.NewLinker(r).DefineInstanceFunc("example:greeter/host", "log", func(string) error { return nil }),
+			expectedErr: `import "example:greeter/host#log": expected a func of func(msg: string), but was func(string) error`,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.linker.Instantiate(testCtx, c)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	require.False(t, component.IsComponent(libcWasm))
+	_, err := component.Decode(libcWasm)
+	require.EqualError(t, err, "invalid component: invalid magic number or version")
+
+	// A nested component.
+	_, err = component.Decode(join(greeterComponent[:8], []byte{4, 0}))
+	require.EqualError(t, err, "invalid component: section 4: nested components aren't supported")
+}
@@ -0,0 +1,116 @@
+package experimental
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/fuel"
+)
+
+// Counters count the Wasm instructions executed by calls made with a context
+// from WithCounters, and the calls of each function.
+type Counters struct {
+	meter fuel.Meter
+
+	mu sync.Mutex
+	// calls are the counts of each function of the modules compiled with the
+	// context.
+	calls []*callCount
+}
+
+// WithCounters returns a context which counts the instructions executed and
+// the functions called by calls made with it, and the Counters to query them.
+// The modules must also be compiled by wazero.Runtime CompileModule with it.
+//
+// Counts are deterministic, so they are useful to benchmark or calibrate
+// FuelCosts, as they don't depend on the machine like a duration. Here's an
+// example:
+//
+//	ctx, counters := experimental.WithCounters(ctx)
+//	compiled, _ := r.CompileModule(ctx, wasm)
+//	mod, _ := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig())
+//
+//	_, err := mod.ExportedFunction("run").Call(ctx)
+//	fmt.Println(counters.Instructions(), counters.Calls()["env.run"])
+//
+// # Notes
+//
+//   - Instructions are counted with fuel, which has the same precision, i.e.
+//     each block of instructions is counted when entering it. Therefore, this
+//     replaces the FuelCosts and the Fuel of the context.
+//   - Calls are counted by a FunctionListener, which is added to the
+//     FunctionListenerFactory of the context, if any. Therefore, calls to
+//     the functions of modules compiled with the context are counted
+//     regardless of the context they are called with.
+//   - Counting slows down calls, especially in the compiler which calls the
+//     listener from Go.
+func WithCounters(ctx context.Context) (context.Context, *Counters) {
+	c := &Counters{}
+	c.meter.Remaining = fuel.Unlimited
+
+	var factory FunctionListenerFactory = FunctionListenerFactoryFunc(c.newFunctionListener)
+	if f, ok := ctx.Value(FunctionListenerFactoryKey{}).(FunctionListenerFactory); ok {
+		factory = MultiFunctionListenerFactory(f, factory)
+	}
+	ctx = context.WithValue(ctx, FunctionListenerFactoryKey{}, factory)
+	ctx = context.WithValue(ctx, fuel.CostsKey{}, &fuel.Costs{Default: 1})
+	ctx = context.WithValue(ctx, fuel.MeterKey{}, &c.meter)
+	return ctx, c
+}
+
+// Instructions returns the number of instructions executed.
+//
+// Note: The count is updated when a call returns or calls a host function.
+// Therefore, it must only be read concurrently with calls by host functions
+// called by them.
+func (c *Counters) Instructions() uint64 {
+	return uint64(fuel.Unlimited - c.meter.Remaining)
+}
+
+// Calls returns the number of calls of the functions called at least once,
+// by their api.FunctionDefinition DebugName, e.g. "env.run". Calls to
+// functions of different modules with the same name are summed.
+//
+// This can be read at any time.
+func (c *Counters) Calls() map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	calls := map[string]uint64{}
+	for _, count := range c.calls {
+		if n := atomic.LoadUint64(&count.n); n > 0 {
+			calls[count.def.DebugName()] += n
+		}
+	}
+	return calls
+}
+
+// Reset sets all counts to zero.
+//
+// Note: The instruction count must only be reset concurrently with calls by
+// host functions called by them.
+func (c *Counters) Reset() {
+	c.meter.Remaining = fuel.Unlimited
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, count := range c.calls {
+		atomic.StoreUint64(&count.n, 0)
+	}
+}
+
+func (c *Counters) newFunctionListener(def api.FunctionDefinition) FunctionListener {
+	count := &callCount{def: def}
+	c.mu.Lock()
+	c.calls = append(c.calls, count)
+	c.mu.Unlock()
+	return FunctionListenerFunc(func(context.Context, api.Module, api.FunctionDefinition, []uint64, StackIterator) {
+		atomic.AddUint64(&count.n, 1)
+	})
+}
+
+// callCount is the number of calls of a function.
+type callCount struct {
+	def api.FunctionDefinition
+	n   uint64
+}
@@ -0,0 +1,80 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestWithCounters(t *testing.T) {
+	configs := map[string]wazero.RuntimeConfig{"interpreter": wazero.NewRuntimeConfigInterpreter()}
+	if platform.CompilerSupported() {
+		configs["compiler"] = wazero.NewRuntimeConfigCompiler()
+		configs["tiered"] = wazero.NewRuntimeConfigTiered()
+	}
+	for n, c := range configs {
+		config := c
+		t.Run(n, func(t *testing.T) {
+			r := wazero.NewRuntimeWithConfig(testCtx, config)
+			defer r.Close(testCtx)
+
+			// An existing listener factory is kept.
+			recorder := &recorder{m: map[string]struct{}{}}
+			ctx := context.WithValue(testCtx, experimental.FunctionListenerFactoryKey{}, recorder)
+			ctx, counters := experimental.WithCounters(ctx)
+
+			// charge is the implementation of "env.charge", set by each test.
+			var charge func(ctx context.Context)
+			_, err := r.NewHostModuleBuilder("env").NewFunctionBuilder().
+				WithFunc(func(ctx context.Context) { charge(ctx) }).Export("charge").
+				Instantiate(ctx)
+			require.NoError(t, err)
+
+			compiled, err := r.CompileModule(ctx, fuelWasm)
+			require.NoError(t, err)
+			mod, err := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig())
+			require.NoError(t, err)
+
+			t.Run("loop", func(t *testing.T) {
+				counters.Reset()
+				_, err := mod.ExportedFunction("loop").Call(ctx, 10)
+				require.NoError(t, err)
+				require.Equal(t, uint64(1+10*5+2), counters.Instructions())
+				require.Equal(t, map[string]uint64{".$1": 1}, counters.Calls())
+				require.Equal(t, []string{".$1"}, recorder.beforeNames)
+
+				_, err = mod.ExportedFunction("loop").Call(ctx, 1)
+				require.NoError(t, err)
+				require.Equal(t, uint64(1+10*5+2+1+5+2), counters.Instructions())
+				require.Equal(t, map[string]uint64{".$1": 2}, counters.Calls())
+			})
+
+			t.Run("during call", func(t *testing.T) {
+				counters.Reset()
+				charge = func(ctx context.Context) {
+					// The block calling charge is counted when entering it.
+					require.Equal(t, uint64(2), counters.Instructions())
+					require.Equal(t, map[string]uint64{".$2": 1, "env.charge": 1}, counters.Calls())
+					_, err := mod.ExportedFunction("loop").Call(ctx, 1)
+					require.NoError(t, err)
+				}
+				_, err := mod.ExportedFunction("call_charge").Call(ctx)
+				require.NoError(t, err)
+				require.Equal(t, uint64(2+1+5+2), counters.Instructions())
+				require.Equal(t, map[string]uint64{".$1": 1, ".$2": 1, "env.charge": 1}, counters.Calls())
+			})
+
+			t.Run("not counted", func(t *testing.T) {
+				counters.Reset()
+				_, err := mod.ExportedFunction("loop").Call(testCtx, 10)
+				require.NoError(t, err)
+				require.Equal(t, uint64(0), counters.Instructions())
+				require.Equal(t, map[string]uint64{".$1": 1}, counters.Calls())
+			})
+		})
+	}
+}
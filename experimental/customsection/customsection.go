@@ -0,0 +1,202 @@
+// Package customsection decodes well-known custom sections, such as those
+// returned by wazero.CompiledModule CustomSection.
+package customsection
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+// Names are the symbolic names in the "name" custom section, including those
+// of the extended-name-section proposal. Each map is nil if no names of its
+// kind are present.
+//
+// Indices of functions, tables, memories, globals and tags begin with
+// imported ones. Locals begin with params.
+//
+// See https://github.com/WebAssembly/extended-name-section/blob/main/proposals/extended-name-section/Overview.md
+type Names struct {
+	// Module is the name of the module, or empty.
+	Module string
+
+	Functions map[uint32]string
+	// Locals are by function index, then local index.
+	Locals map[uint32]map[uint32]string
+	// Labels are by function index, then label index, which is the position
+	// of the block, loop, if or try in the function.
+	Labels          map[uint32]map[uint32]string
+	Types           map[uint32]string
+	Tables          map[uint32]string
+	Memories        map[uint32]string
+	Globals         map[uint32]string
+	ElementSegments map[uint32]string
+	DataSegments    map[uint32]string
+	Tags            map[uint32]string
+}
+
+// DecodeNames decodes the data of the "name" custom section.
+//
+// Note: Unknown subsections are skipped.
+func DecodeNames(data []byte) (*Names, error) {
+	n, err := binary.DecodeNameSection(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name section: %w", err)
+	}
+	return &Names{
+		Module:          n.ModuleName,
+		Functions:       nameMap(n.FunctionNames),
+		Locals:          indirectNameMap(n.LocalNames),
+		Labels:          indirectNameMap(n.LabelNames),
+		Types:           nameMap(n.TypeNames),
+		Tables:          nameMap(n.TableNames),
+		Memories:        nameMap(n.MemoryNames),
+		Globals:         nameMap(n.GlobalNames),
+		ElementSegments: nameMap(n.ElementNames),
+		DataSegments:    nameMap(n.DataNames),
+		Tags:            nameMap(n.TagNames),
+	}, nil
+}
+
+func nameMap(m wasm.NameMap) map[uint32]string {
+	if len(m) == 0 {
+		return nil
+	}
+	ret := make(map[uint32]string, len(m))
+	for _, na := range m {
+		ret[na.Index] = na.Name
+	}
+	return ret
+}
+
+func indirectNameMap(m wasm.IndirectNameMap) map[uint32]map[uint32]string {
+	if len(m) == 0 {
+		return nil
+	}
+	ret := make(map[uint32]map[uint32]string, len(m))
+	for _, nma := range m {
+		ret[nma.Index] = nameMap(nma.NameMap)
+	}
+	return ret
+}
+
+// ProducerField is a field of the "producers" section, e.g. "language",
+// "processed-by" or "sdk".
+//
+// See https://github.com/WebAssembly/tool-conventions/blob/main/ProducersSection.md
+type ProducerField struct {
+	// Name is the name of the field, e.g. "processed-by".
+	Name string
+	// Values are the tools, languages or SDKs of the field.
+	Values []ProducerValue
+}
+
+// ProducerValue is a value of a ProducerField, e.g. Name "clang" and Version
+// "17.0.0".
+type ProducerValue struct {
+	Name, Version string
+}
+
+// DecodeProducers decodes the data of the "producers" custom section.
+func DecodeProducers(data []byte) ([]ProducerField, error) {
+	r := bytes.NewReader(data)
+	count, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid producers section: read field count: %w", err)
+	}
+	var ret []ProducerField
+	for i := uint32(0); i < count; i++ {
+		f := ProducerField{}
+		if f.Name, err = decodeName(r); err != nil {
+			return nil, fmt.Errorf("invalid producers section: field[%d]: %w", i, err)
+		}
+		valueCount, _, err := leb128.DecodeUint32(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid producers section: field[%d]: read value count: %w", i, err)
+		}
+		for j := uint32(0); j < valueCount; j++ {
+			v := ProducerValue{}
+			if v.Name, err = decodeName(r); err == nil {
+				v.Version, err = decodeName(r)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid producers section: field[%d] value[%d]: %w", i, j, err)
+			}
+			f.Values = append(f.Values, v)
+		}
+		ret = append(ret, f)
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("invalid producers section: %d bytes left", r.Len())
+	}
+	return ret, nil
+}
+
+// TargetFeature is a feature of the "target_features" section, e.g. Prefix
+// '+' and Name "bulk-memory".
+//
+// See https://github.com/WebAssembly/tool-conventions/blob/main/Linking.md#target-features-section
+type TargetFeature struct {
+	// Prefix is '+' if the module uses the feature, '-' if it must not be
+	// linked with modules that use it, or '=' if all linked modules must use
+	// it.
+	Prefix byte
+	// Name is the name of the feature, e.g. "sign-ext".
+	Name string
+}
+
+// String implements fmt.Stringer
+func (f TargetFeature) String() string {
+	return string(f.Prefix) + f.Name
+}
+
+// DecodeTargetFeatures decodes the data of the "target_features" custom
+// section.
+func DecodeTargetFeatures(data []byte) ([]TargetFeature, error) {
+	r := bytes.NewReader(data)
+	count, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target_features section: read feature count: %w", err)
+	}
+	var ret []TargetFeature
+	for i := uint32(0); i < count; i++ {
+		f := TargetFeature{}
+		if f.Prefix, err = r.ReadByte(); err != nil {
+			return nil, fmt.Errorf("invalid target_features section: feature[%d]: %w", i, io.ErrUnexpectedEOF)
+		}
+		switch f.Prefix {
+		case '+', '-', '=':
+		default:
+			return nil, fmt.Errorf("invalid target_features section: feature[%d]: invalid prefix 0x%x", i, f.Prefix)
+		}
+		if f.Name, err = decodeName(r); err != nil {
+			return nil, fmt.Errorf("invalid target_features section: feature[%d]: %w", i, err)
+		}
+		ret = append(ret, f)
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("invalid target_features section: %d bytes left", r.Len())
+	}
+	return ret, nil
+}
+
+func decodeName(r *bytes.Reader) (string, error) {
+	size, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return "", fmt.Errorf("read name size: %w", err)
+	} else if uint64(size) > uint64(r.Len()) {
+		return "", io.ErrUnexpectedEOF
+	}
+	buf := make([]byte, size)
+	_, _ = r.Read(buf)
+	if !utf8.Valid(buf) {
+		return "", errors.New("name is not valid UTF-8")
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,168 @@
+package customsection_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental/customsection"
+	"github.com/tetratelabs/wazero/internal/testing/binaryencoding"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
+var testCtx = context.WithValue(context.Background(), struct{}{}, "arbitrary")
+
+func TestDecodeNames(t *testing.T) {
+	bin := binaryencoding.EncodeModule(&wasm.Module{
+		TypeSection:     []wasm.FunctionType{{Params: []api.ValueType{api.ValueTypeI32}, ParamNumInUint64: 1}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection:     []wasm.Code{{LocalTypes: []api.ValueType{api.ValueTypeI32}, Body: []byte{wasm.OpcodeEnd}}},
+		MemorySection:   &wasm.Memory{Min: 1},
+		NameSection: &wasm.NameSection{
+			ModuleName:    "example",
+			FunctionNames: wasm.NameMap{{Index: 0, Name: "run"}},
+			LocalNames:    wasm.IndirectNameMap{{Index: 0, NameMap: wasm.NameMap{{Index: 0, Name: "n"}, {Index: 1, Name: "i"}}}},
+			TypeNames:     wasm.NameMap{{Index: 0, Name: "i32_v"}},
+			MemoryNames:   wasm.NameMap{{Index: 0, Name: "heap"}},
+		},
+	})
+
+	r := wazero.NewRuntimeWithConfig(testCtx, wazero.NewRuntimeConfig().WithCustomSections(true))
+	defer r.Close(testCtx)
+
+	compiled, err := r.CompileModule(testCtx, bin)
+	require.NoError(t, err)
+
+	names, err := customsection.DecodeNames(compiled.CustomSection("name").Data())
+	require.NoError(t, err)
+	require.Equal(t, &customsection.Names{
+		Module:    "example",
+		Functions: map[uint32]string{0: "run"},
+		Locals:    map[uint32]map[uint32]string{0: {0: "n", 1: "i"}},
+		Types:     map[uint32]string{0: "i32_v"},
+		Memories:  map[uint32]string{0: "heap"},
+	}, names)
+
+	_, err = customsection.DecodeNames([]byte{1, 2, 1})
+	require.EqualError(t, err, "invalid name section: failed to read a function index in subsection[1]: EOF")
+}
+
+func TestDecodeProducers(t *testing.T) {
+	data := []byte{
+		2, // 2 fields
+		8, 'l', 'a', 'n', 'g', 'u', 'a', 'g', 'e', 1, 3, 'C', '9', '9', 0,
+		12, 'p', 'r', 'o', 'c', 'e', 's', 's', 'e', 'd', '-', 'b', 'y', 2,
+		5, 'c', 'l', 'a', 'n', 'g', 2, '1', '5',
+		6, 'T', 'i', 'n', 'y', 'G', 'o', 6, '0', '.', '2', '8', '.', '1',
+	}
+	fields, err := customsection.DecodeProducers(data)
+	require.NoError(t, err)
+	require.Equal(t, []customsection.ProducerField{
+		{Name: "language", Values: []customsection.ProducerValue{{Name: "C99"}}},
+		{Name: "processed-by", Values: []customsection.ProducerValue{
+			{Name: "clang", Version: "15"},
+			{Name: "TinyGo", Version: "0.28.1"},
+		}},
+	}, fields)
+
+	fields, err = customsection.DecodeProducers([]byte{0})
+	require.NoError(t, err)
+	require.Nil(t, fields)
+}
+
+func TestDecodeProducers_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []byte
+		expectedErr string
+	}{
+		{
+			name:        "empty",
+			input:       []byte{},
+			expectedErr: "invalid producers section: read field count: EOF",
+		},
+		{
+			name:        "field name too long",
+			input:       []byte{1, 8, 'l'},
+			expectedErr: "invalid producers section: field[0]: unexpected EOF",
+		},
+		{
+			name:        "missing version",
+			input:       []byte{1, 1, 'l', 1, 1, 'C'},
+			expectedErr: "invalid producers section: field[0] value[0]: read name size: EOF",
+		},
+		{
+			name:        "invalid UTF-8",
+			input:       []byte{1, 1, 0xff, 0},
+			expectedErr: "invalid producers section: field[0]: name is not valid UTF-8",
+		},
+		{
+			name:        "trailing bytes",
+			input:       []byte{0, 0},
+			expectedErr: "invalid producers section: 1 bytes left",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := customsection.DecodeProducers(tc.input)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestDecodeTargetFeatures(t *testing.T) {
+	data := []byte{
+		2, // 2 features
+		'+', 8, 's', 'i', 'g', 'n', '-', 'e', 'x', 't',
+		'-', 7, 'a', 't', 'o', 'm', 'i', 'c', 's',
+	}
+	features, err := customsection.DecodeTargetFeatures(data)
+	require.NoError(t, err)
+	require.Equal(t, []customsection.TargetFeature{
+		{Prefix: '+', Name: "sign-ext"},
+		{Prefix: '-', Name: "atomics"},
+	}, features)
+	require.Equal(t, "+sign-ext", features[0].String())
+}
+
+func TestDecodeTargetFeatures_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []byte
+		expectedErr string
+	}{
+		{
+			name:        "empty",
+			input:       []byte{},
+			expectedErr: "invalid target_features section: read feature count: EOF",
+		},
+		{
+			name:        "missing prefix",
+			input:       []byte{1},
+			expectedErr: "invalid target_features section: feature[0]: unexpected EOF",
+		},
+		{
+			name:        "invalid prefix",
+			input:       []byte{1, '*', 1, 'a'},
+			expectedErr: "invalid target_features section: feature[0]: invalid prefix 0x2a",
+		},
+		{
+			name:        "trailing bytes",
+			input:       []byte{1, '=', 1, 'a', 0},
+			expectedErr: "invalid target_features section: 1 bytes left",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := customsection.DecodeTargetFeatures(tc.input)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
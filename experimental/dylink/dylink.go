@@ -0,0 +1,677 @@
+// Package dylink loads side modules into the memory and table of a running
+// main module, like dlopen, following the conventions of dynamic linking
+// toolchains, such as Emscripten and wasm-ld -pie, produce.
+//
+// A side module is position independent: it imports the memory and the
+// indirect function table of the main module, as "env" "memory" and "env"
+// "__indirect_function_table", with "env" "__memory_base" and "env"
+// "__table_base" globals locating its data and functions in them. It imports
+// the addresses of the symbols it doesn't call directly as mutable globals,
+// the "GOT.mem" ones for data and "GOT.func" ones for functions, i.e. their
+// table slots.
+//
+// The Loader allocates the memory and table space of each side module,
+// satisfies its "env" imports with the exports of the main module or of
+// previously loaded side modules, and patches its GOT entries, which can
+// also refer to its own exports. Other imports, e.g. of WASI, are satisfied
+// by the modules instantiated in the wazero.Runtime with that name.
+//
+// The main module is either a regular module, passed to NewLoader, or a
+// position independent one importing its memory and table like a side
+// module, e.g. built with Emscripten's MAIN_MODULE, which is instantiated by
+// Loader.InstantiateMain. The latter is laid out like Emscripten does: its
+// data starts at address 1024, followed by a 64KiB stack, then the heap.
+//
+// See https://github.com/WebAssembly/tool-conventions/blob/main/DynamicLinking.md
+//
+// # Notes
+//
+//   - This is experimental, and likely to change.
+//   - A main module passed to NewLoader must export its memory as "memory",
+//     and its indirect function table as "__indirect_function_table", e.g.
+//     with the wasm-ld flags --export-table and --export=memory.
+//   - Memory is allocated with the "malloc" exported by the main module if
+//     any, or otherwise by growing the memory.
+//   - Only 32-bit memories are supported.
+//   - Libraries a side module needs, listed in its "dylink.0" section, aren't
+//     loaded with it, so must be loaded before.
+//   - A Loader isn't safe for concurrent use.
+package dylink
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// Loader loads side modules into a main module.
+type Loader struct {
+	r wazero.Runtime
+	// symbols are the modules whose exports satisfy the imports of side
+	// modules, in priority order: the main module, then each side module in
+	// load order.
+	symbols []*loaded
+	// hosts satisfy the "env" imports no module in symbols exports: the
+	// module defining the memory, table and stack of a main module from
+	// InstantiateMain, then the module passed to NewMainLoader, if any.
+	hosts []*loaded
+	// indirectTable is the table defined for a main module from
+	// InstantiateMain, or nil if the main module exports its own.
+	indirectTable *wasm.TableInstance
+	// slots are the table slots of the functions whose address were taken by
+	// GOT.func imports, so that each has only one.
+	slots map[wasm.Reference]uint32
+}
+
+// loaded is a module which was loaded by a Loader.
+type loaded struct {
+	m *wasm.ModuleInstance
+	// memoryBase is the address of the data of m, which is zero for the main
+	// module.
+	memoryBase uint32
+	// shims satisfy the "env", "GOT.mem" and "GOT.func" imports of m.
+	shims []api.Module
+}
+
+// NewLoader returns a Loader of side modules into `main`, which must have
+// been instantiated in `r`.
+func NewLoader(r wazero.Runtime, main api.Module) *Loader {
+	return &Loader{
+		r:       r,
+		symbols: []*loaded{{m: main.(*wasm.ModuleInstance)}},
+		slots:   map[wasm.Reference]uint32{},
+	}
+}
+
+// NewMainLoader returns a Loader whose position independent main module is
+// instantiated with InstantiateMain. The "env" imports no loaded module
+// exports are satisfied by `env`, if not nil, e.g. host functions of the
+// Emscripten JS library.
+func NewMainLoader(r wazero.Runtime, env api.Module) *Loader {
+	l := &Loader{r: r, slots: map[wasm.Reference]uint32{}}
+	if env != nil {
+		l.hosts = []*loaded{{m: env.(*wasm.ModuleInstance)}}
+	}
+	return l
+}
+
+// Constants of the layout of a main module from InstantiateMain, which are
+// the defaults of Emscripten's GLOBAL_BASE and STACK_SIZE.
+const (
+	mainMemoryBase = 1024
+	mainStackSize  = 64 * 1024
+)
+
+// InstantiateMain instantiates the position independent main module `binary`
+// with `config`, defining the memory and table it imports from "env", as
+// well as its "__stack_pointer". Like Load, this patches its GOT entries,
+// then calls its "__wasm_apply_data_relocs" and "__wasm_call_ctors"
+// functions, if exported, instead of the start functions of `config`. Call
+// its entry point, e.g. "main", on the result instead.
+//
+// This errs if the main module was already instantiated, e.g. the Loader is
+// from NewLoader. Closing the result doesn't close the modules satisfying its
+// imports, so close the wazero.Runtime instead.
+func (l *Loader) InstantiateMain(ctx context.Context, binary []byte, config wazero.ModuleConfig) (api.Module, error) {
+	if len(l.symbols) > 0 {
+		return nil, errors.New("main module already instantiated")
+	}
+	info, err := decodeMemInfo(binary)
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := l.r.CompileModule(ctx, binary)
+	if err != nil {
+		return nil, err
+	}
+
+	main := &loaded{memoryBase: uint32(alignUp(mainMemoryBase, uint64(1)<<info.memoryAlign))}
+	tableBase := uint32(alignUp(1, uint64(1)<<info.tableAlign)) // slot zero is null.
+	shim, err := mainShim(compiled, main.memoryBase+info.memorySize, tableBase+info.tableSize)
+	if err != nil {
+		return nil, err
+	}
+	host, err := l.instantiateShim(ctx, shim)
+	if err != nil {
+		return nil, err
+	}
+	l.hosts = append([]*loaded{{m: host.(*wasm.ModuleInstance)}}, l.hosts...)
+	l.indirectTable = host.(*wasm.ModuleInstance).Tables[0]
+
+	mod, err := l.link(ctx, compiled, config, main, tableBase)
+	if err != nil {
+		_ = host.Close(ctx)
+		l.hosts, l.indirectTable = l.hosts[1:], nil
+		return nil, err
+	}
+	return mod, nil
+}
+
+// mainShim returns a module in the text format defining the memory, table
+// and stack of the main module `compiled`, which uses `dataEnd` bytes of
+// memory and `tableEnd` table slots. The memory and table have at least the
+// size `compiled` imports them with.
+func mainShim(compiled wazero.CompiledModule, dataEnd, tableEnd uint32) (string, error) {
+	stackLow := alignUp(uint64(dataEnd), 16)
+	stackHigh := stackLow + mainStackSize
+	pages := (stackHigh + uint64(wasm.MemoryPageSize) - 1) / uint64(wasm.MemoryPageSize)
+	memory, table := fmt.Sprint(pages), fmt.Sprint(tableEnd)
+	for _, imp := range compiled.Imports() {
+		if imp.ModuleName() != "env" {
+			continue
+		}
+		switch def := imp.Definition().(type) {
+		case api.MemoryDefinition:
+			memory = limits(uint64(def.Min()), pages, def.Max)
+		case api.TableDefinition:
+			table = limits(uint64(def.Min()), uint64(tableEnd), def.Max)
+		}
+	}
+	if memory == "" || table == "" {
+		return "", errors.New("main module doesn't fit in the memory or table it imports")
+	}
+	return fmt.Sprintf(`(module
+  (memory (export "memory") %s)
+  (table (export "__indirect_function_table") %s funcref)
+  (global (export "__stack_pointer") (mut i32) (i32.const %[3]d))
+  (global (export "__stack_low") i32 (i32.const %d))
+  (global (export "__stack_high") i32 (i32.const %[3]d))
+  (global (export "__heap_base") i32 (i32.const %[3]d)))`, memory, table, stackHigh, stackLow), nil
+}
+
+// limits returns the limits in the text format of a memory or table with
+// `min`, at least `size`, and the max returned by `max`, or an empty string
+// if `size` is above it.
+func limits(min, size uint64, max func() (uint32, bool)) string {
+	if size < min {
+		size = min
+	}
+	if max, ok := max(); ok {
+		if size > uint64(max) {
+			return ""
+		}
+		return fmt.Sprintf("%d %d", size, max)
+	}
+	return fmt.Sprint(size)
+}
+
+// Main returns the main module, or nil if not instantiated yet.
+func (l *Loader) Main() api.Module {
+	if len(l.symbols) == 0 {
+		return nil
+	}
+	return l.symbols[0].m
+}
+
+// Load instantiates the side module `binary` with `config`, after allocating
+// its data in the memory of the main module, and its functions in its table.
+//
+// Once its GOT entries are patched, this calls its "__wasm_apply_data_relocs"
+// and "__wasm_call_ctors" functions, if exported, instead of the start
+// functions of `config`. The module must be closed with Unload.
+//
+// This errs if `binary` has no "dylink.0" section, or an import isn't
+// exported by any loaded module, e.g. "undefined symbol: env.puts".
+func (l *Loader) Load(ctx context.Context, binary []byte, config wazero.ModuleConfig) (api.Module, error) {
+	if len(l.symbols) == 0 {
+		return nil, errors.New("main module not instantiated")
+	}
+	info, err := decodeMemInfo(binary)
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := l.r.CompileModule(ctx, binary)
+	if err != nil {
+		return nil, err
+	}
+
+	side := &loaded{}
+	if side.memoryBase, err = l.allocateMemory(ctx, info.memorySize, info.memoryAlign); err != nil {
+		return nil, err
+	}
+	var tableBase uint32
+	if info.tableSize > 0 {
+		if tableBase, err = l.growTable(info.tableSize, info.tableAlign); err != nil {
+			return nil, err
+		}
+	}
+
+	return l.link(ctx, compiled, config, side, tableBase)
+}
+
+// link instantiates `compiled` with `config` as the loaded module `m`,
+// satisfying its "env", "GOT.mem" and "GOT.func" imports, then patches its
+// GOT entries and calls its relocation and constructor functions.
+func (l *Loader) link(ctx context.Context, compiled wazero.CompiledModule, config wazero.ModuleConfig, m *loaded, tableBase uint32) (api.Module, error) {
+	imports := map[string][]api.Import{}
+	for _, imp := range compiled.Imports() {
+		switch name := imp.ModuleName(); name {
+		case "env", "GOT.mem", "GOT.func":
+			imports[name] = append(imports[name], imp)
+		}
+	}
+	shims := map[string]api.Module{}
+	var err error
+	for name, imps := range imports {
+		var shim string
+		if name == "env" {
+			shim, err = l.envShim(imps, m.memoryBase, tableBase)
+		} else {
+			shim, err = gotShim(name, imps)
+		}
+		if err == nil {
+			shims[name], err = l.instantiateShim(ctx, shim)
+		}
+		if err != nil {
+			closeAll(ctx, shims)
+			return nil, err
+		}
+		m.shims = append(m.shims, shims[name])
+	}
+
+	ctx = experimental.WithImportResolver(ctx, func(name string) api.Module { return shims[name] })
+	mod, err := l.r.InstantiateModule(ctx, compiled, config.WithStartFunctions())
+	if err != nil {
+		closeAll(ctx, shims)
+		return nil, err
+	}
+	m.m = mod.(*wasm.ModuleInstance)
+	l.symbols = append(l.symbols, m)
+
+	if err = l.patchGOT(shims, imports); err == nil {
+		err = callIfExported(ctx, mod, "__wasm_apply_data_relocs", "__wasm_call_ctors")
+	}
+	if err != nil {
+		if len(l.symbols) == 1 { // the main module
+			l.symbols = nil
+			closeAll(ctx, shims)
+			_ = mod.Close(ctx)
+		} else {
+			_ = l.Unload(ctx, mod)
+		}
+		return nil, err
+	}
+	return mod, nil
+}
+
+// Unload closes the side module `mod`, which was loaded by this Loader, so
+// that its exports no longer satisfy the imports of side modules loaded
+// after. Its memory and table space isn't reclaimed.
+func (l *Loader) Unload(ctx context.Context, mod api.Module) error {
+	for i, s := range l.symbols {
+		if i > 0 && s.m == mod {
+			l.symbols = append(l.symbols[:i:i], l.symbols[i+1:]...)
+			for _, shim := range s.shims {
+				_ = shim.Close(ctx)
+			}
+			return mod.Close(ctx)
+		}
+	}
+	return fmt.Errorf("module[%s] wasn't loaded", mod.Name())
+}
+
+// memInfo is the WASM_DYLINK_MEM_INFO subsection of the "dylink.0" section.
+type memInfo struct {
+	memorySize, memoryAlign, tableSize, tableAlign uint32
+}
+
+// decodeMemInfo decodes the memInfo of the side module `binary`, from its
+// "dylink.0" custom section, which must be its first section.
+func decodeMemInfo(binary []byte) (info memInfo, err error) {
+	if len(binary) < 8 || !bytes.Equal(binary[:4], []byte("\x00asm")) {
+		return info, errors.New("invalid magic number")
+	}
+	r := bytes.NewReader(binary[8:])
+	if id, err := r.ReadByte(); err != nil || id != wasm.SectionIDCustom {
+		return info, errors.New("missing dylink.0 section")
+	}
+	section, err := readBytes(r)
+	if err != nil {
+		return info, fmt.Errorf("invalid dylink.0 section: %w", err)
+	}
+	r = bytes.NewReader(section)
+	if name, err := readBytes(r); err != nil || string(name) != "dylink.0" {
+		return info, errors.New("missing dylink.0 section")
+	}
+
+	for r.Len() > 0 {
+		typ, _ := r.ReadByte()
+		subsection, err := readBytes(r)
+		if err != nil {
+			return info, fmt.Errorf("invalid dylink.0 section: %w", err)
+		}
+		if typ != 1 { // WASM_DYLINK_MEM_INFO
+			continue
+		}
+		sr := bytes.NewReader(subsection)
+		for _, v := range []*uint32{&info.memorySize, &info.memoryAlign, &info.tableSize, &info.tableAlign} {
+			if *v, _, err = leb128.DecodeUint32(sr); err != nil {
+				return info, fmt.Errorf("invalid dylink.0 section: %w", err)
+			}
+		}
+	}
+	if info.memoryAlign >= 32 || info.tableAlign >= 32 {
+		return info, errors.New("invalid dylink.0 section: alignment out of range")
+	}
+	return info, nil
+}
+
+// readBytes reads a vector of bytes prefixed by its size.
+func readBytes(r *bytes.Reader) ([]byte, error) {
+	size, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return nil, err
+	} else if uint64(size) > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	ret := make([]byte, size)
+	_, _ = r.Read(ret)
+	return ret, nil
+}
+
+// allocateMemory returns the address of `size` zeroed bytes in the memory
+// of the main module, aligned to 2^`align`.
+func (l *Loader) allocateMemory(ctx context.Context, size, align uint32) (uint32, error) {
+	if size == 0 {
+		return 0, nil
+	}
+	main := l.symbols[0].m
+	mem := main.Memory()
+	if mem == nil {
+		return 0, errors.New("main module has no memory")
+	}
+
+	alignment := uint64(1) << align
+	var base uint64
+	if malloc := main.ExportedFunction("malloc"); malloc != nil {
+		results, err := malloc.Call(ctx, uint64(size)+alignment-1)
+		if err != nil {
+			return 0, fmt.Errorf("malloc: %w", err)
+		} else if uint32(results[0]) == 0 {
+			return 0, errors.New("malloc: out of memory")
+		}
+		base = alignUp(uint64(uint32(results[0])), alignment)
+	} else {
+		end := uint64(mem.Size())
+		base = alignUp(end, alignment)
+		pages := (base + uint64(size) - end + uint64(wasm.MemoryPageSize) - 1) / uint64(wasm.MemoryPageSize)
+		if pages > uint64(wasm.MemoryLimitPages) {
+			return 0, errors.New("memory: out of memory")
+		} else if _, ok := mem.Grow(uint32(pages)); !ok {
+			return 0, errors.New("memory: out of memory")
+		}
+	}
+	mem.Write(uint32(base), make([]byte, size))
+	return uint32(base), nil
+}
+
+// growTable grows the table of the main module by `size` slots, aligned to
+// 2^`align`, returning the first.
+func (l *Loader) growTable(size, align uint32) (uint32, error) {
+	table := l.table()
+	if table == nil {
+		return 0, errors.New("main module doesn't export __indirect_function_table")
+	}
+	length := uint64(len(table.References))
+	base := alignUp(length, uint64(1)<<align)
+	if delta := base - length + uint64(size); delta > 0xffffffff ||
+		table.Grow(uint32(delta), 0) == 0xffffffff {
+		return 0, errors.New("__indirect_function_table: out of slots")
+	}
+	return uint32(base), nil
+}
+
+// table returns the indirect function table of the main module, or nil if
+// it isn't exported.
+func (l *Loader) table() *wasm.TableInstance {
+	if l.indirectTable != nil {
+		return l.indirectTable
+	}
+	main := l.symbols[0].m
+	if exp, ok := main.Exports["__indirect_function_table"]; ok && exp.Type == api.ExternTypeTable {
+		return main.Tables[exp.Index]
+	}
+	return nil
+}
+
+func alignUp(v, alignment uint64) uint64 {
+	return (v + alignment - 1) &^ (alignment - 1)
+}
+
+// modules returns the modules whose exports satisfy imports, in priority
+// order: symbols, then hosts.
+func (l *Loader) modules() []*loaded {
+	return append(l.symbols[:len(l.symbols):len(l.symbols)], l.hosts...)
+}
+
+// lookup returns the module exporting the symbol `name` of the given type,
+// and its index, or nil if no loaded or host module does.
+func (l *Loader) lookup(name string, typ api.ExternType) (*loaded, wasm.Index) {
+	for _, s := range l.modules() {
+		if s.m.IsClosed() {
+			continue
+		}
+		if exp, ok := s.m.Exports[name]; ok && exp.Type == typ {
+			return s, exp.Index
+		}
+	}
+	return nil, 0
+}
+
+// envShim returns a module in the text format satisfying the "env" `imports`
+// of a side module: "__memory_base" and "__table_base" are defined, and the
+// rest are imported from the loaded modules exporting them.
+func (l *Loader) envShim(imports []api.Import, memoryBase, tableBase uint32) (string, error) {
+	var imps, defs strings.Builder
+	imported := map[api.ExternType]int{}
+	for _, imp := range imports {
+		name, typ := imp.Name(), imp.Type()
+		var export string
+		if base := memoryBase; typ == api.ExternTypeGlobal && (name == "__memory_base" || name == "__table_base") {
+			if name == "__table_base" {
+				base = tableBase
+			}
+			fmt.Fprintf(&defs, "\n  (global $%s i32 (i32.const %d))", name, base)
+			export = "$" + name
+		} else if typ == wasm.ExternTypeTag {
+			return "", fmt.Errorf("unsupported import env.%s: tag", name)
+		} else {
+			s, index := l.lookup(name, typ)
+			if s == nil {
+				return "", fmt.Errorf("undefined symbol: env.%s", name)
+			}
+			fmt.Fprintf(&imps, "\n  (import \"%d\" %s %s)", l.index(s), quote(name), externType(s.m, typ, index))
+			export = fmt.Sprint(imported[typ]) // imports precede definitions in the index space
+			imported[typ]++
+		}
+		fmt.Fprintf(&defs, "\n  (export %s (%s %s))", quote(name), api.ExternTypeName(typ), export)
+	}
+	return "(module" + imps.String() + defs.String() + ")", nil
+}
+
+// gotShim returns a module in the text format satisfying the GOT `imports`
+// of a side module, with zeroed globals patched by patchGOT.
+func gotShim(moduleName string, imports []api.Import) (string, error) {
+	var defs strings.Builder
+	for _, imp := range imports {
+		if imp.Type() != api.ExternTypeGlobal {
+			return "", fmt.Errorf("invalid %s import %s: not a global", moduleName, imp.Name())
+		}
+		fmt.Fprintf(&defs, "\n  (global (export %s) (mut i32) (i32.const 0))", quote(imp.Name()))
+	}
+	return "(module" + defs.String() + ")", nil
+}
+
+// instantiateShim instantiates the module `shim` in the text format, which
+// imports the loaded and host modules by their index in modules.
+func (l *Loader) instantiateShim(ctx context.Context, shim string) (api.Module, error) {
+	compiled, err := l.r.CompileModule(ctx, []byte(shim))
+	if err != nil {
+		return nil, err
+	}
+	modules := l.modules()
+	ctx = experimental.WithImportResolver(ctx, func(name string) api.Module {
+		for i, s := range modules {
+			if name == fmt.Sprint(i) {
+				return s.m
+			}
+		}
+		return nil
+	})
+	return l.r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName(""))
+}
+
+// index returns the index of `s` in modules.
+func (l *Loader) index(s *loaded) int {
+	for i, other := range l.modules() {
+		if other == s {
+			return i
+		}
+	}
+	panic("BUG: not loaded")
+}
+
+// patchGOT sets the GOT entries of the module just loaded, which are the
+// globals of the GOT `shims` for its `imports`.
+func (l *Loader) patchGOT(shims map[string]api.Module, imports map[string][]api.Import) error {
+	for _, got := range [...]struct {
+		name string
+		typ  api.ExternType
+	}{{"GOT.mem", api.ExternTypeGlobal}, {"GOT.func", api.ExternTypeFunc}} {
+		name, typ := got.name, got.typ
+		for _, imp := range imports[name] {
+			s, index := l.lookup(imp.Name(), typ)
+			if s == nil {
+				return fmt.Errorf("undefined symbol: %s.%s", name, imp.Name())
+			}
+			addr, err := l.address(s, typ, index)
+			if err != nil {
+				return err
+			}
+			shims[name].ExportedGlobal(imp.Name()).(api.MutableGlobal).Set(uint64(addr))
+		}
+	}
+	return nil
+}
+
+// Address returns the address of the symbol `name` exported by `mod`, like
+// the GOT entry of a side module importing it: the table slot of a function,
+// added if needed, or the address of data. If `mod` is nil, this looks it up
+// in the main module, then in side modules in load order, like dlsym with
+// RTLD_DEFAULT.
+//
+// This errs if `mod` wasn't loaded by this Loader, or doesn't export `name`.
+func (l *Loader) Address(mod api.Module, name string) (uint32, error) {
+	for _, s := range l.symbols {
+		if mod != nil && s.m != mod {
+			continue
+		} else if exp, ok := s.m.Exports[name]; ok && (exp.Type == api.ExternTypeFunc || exp.Type == api.ExternTypeGlobal) {
+			return l.address(s, exp.Type, exp.Index)
+		} else if mod != nil {
+			break
+		}
+	}
+	return 0, fmt.Errorf("undefined symbol: %s", name)
+}
+
+// address returns the address of the function or global at `index` in the
+// loaded module `s`.
+func (l *Loader) address(s *loaded, typ api.ExternType, index wasm.Index) (uint32, error) {
+	if typ == api.ExternTypeFunc {
+		return l.slot(s.m.Engine.FunctionInstanceReference(index))
+	}
+	return uint32(s.m.Globals[index].Val) + s.memoryBase, nil
+}
+
+// slot returns the table slot of the function `ref`, adding it if needed.
+func (l *Loader) slot(ref wasm.Reference) (uint32, error) {
+	if slot, ok := l.slots[ref]; ok {
+		return slot, nil
+	}
+	slot, err := l.growTable(1, 0)
+	if err != nil {
+		return 0, err
+	}
+	l.table().References[slot] = ref
+	l.slots[ref] = slot
+	return slot, nil
+}
+
+// externType returns the text format of the type of the extern at `index`
+// in `m`, for a shim to import it.
+func externType(m *wasm.ModuleInstance, typ api.ExternType, index wasm.Index) string {
+	switch typ {
+	case api.ExternTypeFunc:
+		def := m.Source.FunctionDefinition(index)
+		return "(func" + valueTypes("param", def.ParamTypes()) + valueTypes("result", def.ResultTypes()) + ")"
+	case api.ExternTypeTable:
+		return fmt.Sprintf("(table 0 %s)", wasm.RefTypeName(m.Tables[index].Type))
+	case api.ExternTypeMemory:
+		if mem := m.MemoryInstance; mem.Shared {
+			return fmt.Sprintf("(memory 0 %d shared)", mem.Max)
+		}
+		return "(memory 0)"
+	case api.ExternTypeGlobal:
+		t := m.Globals[index].Type
+		if t.Mutable {
+			return fmt.Sprintf("(global (mut %s))", wasm.ValueTypeName(t.ValType))
+		}
+		return fmt.Sprintf("(global %s)", wasm.ValueTypeName(t.ValType))
+	}
+	panic(fmt.Errorf("BUG: unsupported extern type %s", api.ExternTypeName(typ)))
+}
+
+func valueTypes(keyword string, types []api.ValueType) string {
+	if len(types) == 0 {
+		return ""
+	}
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = wasm.ValueTypeName(t)
+	}
+	return fmt.Sprintf(" (%s %s)", keyword, strings.Join(names, " "))
+}
+
+// quote returns `s` as a string in the text format, escaping any byte which
+// isn't printable ASCII.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c >= 0x20 && c < 0x7f && c != '"' && c != '\\' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "\\%02x", c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func callIfExported(ctx context.Context, mod api.Module, names ...string) error {
+	for _, name := range names {
+		if f := mod.ExportedFunction(name); f != nil {
+			if _, err := f.Call(ctx); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func closeAll(ctx context.Context, mods map[string]api.Module) {
+	for _, m := range mods {
+		if m != nil {
+			_ = m.Close(ctx)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package dylink_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental/dylink"
+	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wat"
+)
+
+// testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
+var testCtx = context.WithValue(context.Background(), struct{}{}, "arbitrary")
+
+// mainWat exports its memory and table, "add", "counter", which is the
+// address of its data, and "call", which calls the function in the table
+// slot it is passed with the other params.
+const mainWat = `(module
+  (type $binop (func (param i32 i32) (result i32)))
+  (memory (export "memory") 1)
+  (table (export "__indirect_function_table") 1 funcref)
+  (global (export "counter") i32 (i32.const 16))
+  (func (export "add") (type $binop) (i32.add (local.get 0) (local.get 1)))
+  (func (export "call") (param i32 i32 i32) (result i32)
+    (call_indirect (type $binop) (local.get 1) (local.get 2) (local.get 0))))`
+
+// libWat is a side module with 8 bytes of data and 1 table slot, which takes
+// the address of its own data and functions, and of those of the main module.
+const libWat = `(module
+  (type $binop (func (param i32 i32) (result i32)))
+  (import "env" "memory" (memory 1))
+  (import "env" "__indirect_function_table" (table 1 funcref))
+  (import "env" "__memory_base" (global $memory_base i32))
+  (import "env" "__table_base" (global $table_base i32))
+  (import "env" "add" (func $add (type $binop)))
+  (import "GOT.mem" "counter" (global $counter (mut i32)))
+  (import "GOT.mem" "value" (global $value (mut i32)))
+  (import "GOT.func" "add" (global $add_slot (mut i32)))
+  (import "GOT.func" "mul" (global $mul_slot (mut i32)))
+  (data (global.get $memory_base) "\2a\00\00\00")
+  (elem (global.get $table_base) $sub)
+  (global (export "value") i32 (i32.const 0))
+  (func $sub (type $binop) (i32.sub (local.get 0) (local.get 1)))
+  (func (export "mul") (type $binop) (i32.mul (local.get 0) (local.get 1)))
+  (func (export "add_value") (param i32) (result i32)
+    (call $add (local.get 0) (i32.load (global.get $value))))
+  (func (export "__wasm_call_ctors")
+    (i32.store offset=4 (global.get $memory_base) (i32.const 1)))
+  (func (export "addresses") (result i32 i32 i32 i32 i32)
+    (global.get $counter) (global.get $value)
+    (global.get $add_slot) (global.get $mul_slot) (global.get $table_base)))`
+
+// pluginWat is a side module, with no data nor table slots, which calls and
+// takes the address of "mul" of libWat.
+const pluginWat = `(module
+  (type $binop (func (param i32 i32) (result i32)))
+  (import "env" "mul" (func $mul (type $binop)))
+  (import "GOT.func" "mul" (global $mul_slot (mut i32)))
+  (func (export "square") (param i32) (result i32) (call $mul (local.get 0) (local.get 0)))
+  (func (export "mul_slot") (result i32) (global.get $mul_slot)))`
+
+func TestLoader_Load(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	main, err := r.InstantiateWithConfig(testCtx, []byte(mainWat), wazero.NewModuleConfig())
+	require.NoError(t, err)
+	l := dylink.NewLoader(r, main)
+
+	lib, err := l.Load(testCtx, sideModule(t, libWat, 8, 2, 1, 0), wazero.NewModuleConfig().WithName("lib"))
+	require.NoError(t, err)
+
+	// The data of lib is allocated by growing the memory of main.
+	require.Equal(t, uint32(2*65536), main.Memory().Size())
+	const memoryBase = 65536
+	data, ok := main.Memory().Read(memoryBase, 8)
+	require.True(t, ok)
+	require.Equal(t, []byte{42, 0, 0, 0, 1, 0, 0, 0}, data) // with __wasm_call_ctors
+
+	// The GOT entries of lib are patched, including those of its own exports.
+	addresses := call(t, lib, "addresses")
+	counter, value, addSlot, mulSlot, subSlot := addresses[0], addresses[1], addresses[2], addresses[3], addresses[4]
+	require.Equal(t, []uint64{16, memoryBase}, []uint64{counter, value})
+	require.Equal(t, uint64(1), subSlot) // __table_base
+	require.Equal(t, []uint64{8, 5, 4}, []uint64{
+		call(t, main, "call", addSlot, 6, 2)[0],
+		call(t, main, "call", subSlot, 7, 2)[0],
+		call(t, main, "call", mulSlot, 2, 2)[0],
+	})
+	require.Equal(t, []uint64{43}, call(t, lib, "add_value", 1))
+
+	// Side modules can import the exports of those loaded before.
+	plugin, err := l.Load(testCtx, sideModule(t, pluginWat, 0, 0, 0, 0), wazero.NewModuleConfig())
+	require.NoError(t, err)
+	require.Equal(t, []uint64{9}, call(t, plugin, "square", 3))
+	require.Equal(t, []uint64{mulSlot}, call(t, plugin, "mul_slot"))
+
+	// Once unloaded, exports no longer satisfy imports.
+	require.NoError(t, l.Unload(testCtx, plugin))
+	require.NoError(t, l.Unload(testCtx, lib))
+	require.True(t, lib.IsClosed())
+	_, err = l.Load(testCtx, sideModule(t, pluginWat, 0, 0, 0, 0), wazero.NewModuleConfig())
+	require.EqualError(t, err, "undefined symbol: env.mul")
+
+	require.EqualError(t, l.Unload(testCtx, lib), "module[lib] wasn't loaded")
+}
+
+func TestLoader_Load_Malloc(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	// malloc is a bump allocator from address 1000.
+	main, err := r.InstantiateWithConfig(testCtx, []byte(`(module
+  (memory (export "memory") 1)
+  (table (export "__indirect_function_table") 0 funcref)
+  (global $next (mut i32) (i32.const 1001))
+  (func (export "malloc") (param i32) (result i32)
+    (global.get $next)
+    (global.set $next (i32.add (global.get $next) (local.get 0)))))`), wazero.NewModuleConfig())
+	require.NoError(t, err)
+	main.Memory().Write(1000, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+
+	lib, err := dylink.NewLoader(r, main).Load(testCtx, sideModule(t, `(module
+  (import "env" "__memory_base" (global $memory_base i32))
+  (func (export "memory_base") (result i32) (global.get $memory_base)))`, 8, 3, 0, 0), wazero.NewModuleConfig())
+	require.NoError(t, err)
+
+	// The allocation is aligned, and zeroed.
+	require.Equal(t, []uint64{1008}, call(t, lib, "memory_base"))
+	data, ok := main.Memory().Read(1008, 8)
+	require.True(t, ok)
+	require.Equal(t, make([]byte, 8), data)
+	require.Equal(t, uint32(65536), main.Memory().Size())
+}
+
+// picMainWat is a position independent main module, which imports its memory
+// and table, and "log" from the host. Its constructor logs its data.
+const picMainWat = `(module
+  (type $binop (func (param i32 i32) (result i32)))
+  (import "env" "memory" (memory 1))
+  (import "env" "__indirect_function_table" (table 1 funcref))
+  (import "env" "__memory_base" (global $memory_base i32))
+  (import "env" "__table_base" (global $table_base i32))
+  (import "env" "__stack_pointer" (global $sp (mut i32)))
+  (import "env" "log" (func $log (param i32)))
+  (import "GOT.mem" "counter" (global $counter (mut i32)))
+  (import "GOT.func" "add" (global $add_slot (mut i32)))
+  (data (global.get $memory_base) "\07\00\00\00")
+  (elem (global.get $table_base) $add)
+  (global (export "counter") i32 (i32.const 0))
+  (func $add (export "add") (type $binop) (i32.add (local.get 0) (local.get 1)))
+  (func (export "call") (param i32 i32 i32) (result i32)
+    (call_indirect (type $binop) (local.get 1) (local.get 2) (local.get 0)))
+  (func (export "addresses") (result i32 i32 i32)
+    (global.get $counter) (global.get $add_slot) (global.get $sp))
+  (func (export "__wasm_call_ctors") (call $log (i32.load (global.get $counter)))))`
+
+func TestLoader_InstantiateMain(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	var logged []uint32
+	env, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().WithFunc(func(v uint32) { logged = append(logged, v) }).Export("log").
+		Instantiate(testCtx)
+	require.NoError(t, err)
+
+	l := dylink.NewMainLoader(r, env)
+	_, err = l.Load(testCtx, sideModule(t, `(module)`, 0, 0, 0, 0), wazero.NewModuleConfig())
+	require.EqualError(t, err, "main module not instantiated")
+
+	main, err := l.InstantiateMain(testCtx, sideModule(t, picMainWat, 4, 2, 1, 0), wazero.NewModuleConfig())
+	require.NoError(t, err)
+	require.Equal(t, main, l.Main())
+
+	// The data is at 1024, followed by the stack, and the constructor ran.
+	require.Equal(t, []uint32{7}, logged)
+	addresses := call(t, main, "addresses")
+	counter, addSlot, sp := addresses[0], addresses[1], addresses[2]
+	require.Equal(t, uint64(1024), counter)
+	require.Equal(t, uint64(1040+65536), sp)
+	require.Equal(t, uint32(2*65536), main.Memory().Size())
+	require.Equal(t, []uint64{5}, call(t, main, "call", addSlot, 2, 3))
+	require.Equal(t, []uint64{5}, call(t, main, "call", 1, 2, 3)) // __table_base
+
+	// Side modules share the memory and table defined for the main module.
+	lib, err := l.Load(testCtx, sideModule(t, `(module
+  (import "env" "memory" (memory 0))
+  (import "env" "__indirect_function_table" (table 0 funcref))
+  (import "env" "__stack_pointer" (global $sp (mut i32)))
+  (import "GOT.mem" "counter" (global $counter (mut i32)))
+  (import "GOT.func" "add" (global $add_slot (mut i32)))
+  (func (export "sp") (result i32) (global.get $sp))
+  (func (export "add_slot") (result i32) (global.get $add_slot))
+  (func (export "counter") (result i32) (i32.load (global.get $counter))))`, 0, 0, 0, 0), wazero.NewModuleConfig())
+	require.NoError(t, err)
+	require.Equal(t, []uint64{sp}, call(t, lib, "sp"))
+	require.Equal(t, []uint64{addSlot}, call(t, lib, "add_slot"))
+	require.Equal(t, []uint64{7}, call(t, lib, "counter"))
+
+	// Addresses are those of GOT entries.
+	addr, err := l.Address(nil, "counter")
+	require.NoError(t, err)
+	require.Equal(t, uint32(counter), addr)
+	addr, err = l.Address(main, "add")
+	require.NoError(t, err)
+	require.Equal(t, uint32(addSlot), addr)
+	_, err = l.Address(lib, "add")
+	require.EqualError(t, err, "undefined symbol: add")
+
+	_, err = l.InstantiateMain(testCtx, sideModule(t, picMainWat, 4, 2, 1, 0), wazero.NewModuleConfig())
+	require.EqualError(t, err, "main module already instantiated")
+}
+
+func TestLoader_InstantiateMain_Errors(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	l := dylink.NewMainLoader(r, nil)
+	_, err := l.InstantiateMain(testCtx, sideModule(t, `(module (import "env" "memory" (memory 1 1)))`, 1, 0, 0, 0), wazero.NewModuleConfig())
+	require.EqualError(t, err, "main module doesn't fit in the memory or table it imports")
+
+	// Imports of "env" no module exports are undefined without a host module.
+	_, err = l.InstantiateMain(testCtx, sideModule(t, `(module (import "env" "log" (func (param i32))))`, 0, 0, 0, 0), wazero.NewModuleConfig())
+	require.EqualError(t, err, "undefined symbol: env.log")
+
+	// The Loader can be reused after a failure.
+	_, err = l.InstantiateMain(testCtx, sideModule(t, `(module)`, 0, 0, 0, 0), wazero.NewModuleConfig())
+	require.NoError(t, err)
+}
+
+func TestLoader_Load_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		binary      func(t *testing.T) []byte
+		expectedErr string
+	}{
+		{
+			name: "missing dylink.0",
+			binary: func(t *testing.T) []byte {
+				bin, err := wat.Compile([]byte(`(module)`))
+				require.NoError(t, err)
+				return bin
+			},
+			expectedErr: "missing dylink.0 section",
+		},
+		{
+			name: "undefined symbol",
+			binary: func(t *testing.T) []byte {
+				return sideModule(t, `(module (import "env" "puts" (func (param i32))))`, 0, 0, 0, 0)
+			},
+			expectedErr: "undefined symbol: env.puts",
+		},
+		{
+			name: "undefined GOT symbol",
+			binary: func(t *testing.T) []byte {
+				return sideModule(t, `(module (import "GOT.mem" "errno" (global (mut i32))))`, 0, 0, 0, 0)
+			},
+			expectedErr: "undefined symbol: GOT.mem.errno",
+		},
+		{
+			name: "function type mismatch",
+			binary: func(t *testing.T) []byte {
+				return sideModule(t, `(module (import "env" "add" (func (param i64))))`, 0, 0, 0, 0)
+			},
+			expectedErr: `import func[env.add]: signature mismatch: i64_v != i32i32_i32`,
+		},
+		{
+			name: "table full",
+			binary: func(t *testing.T) []byte {
+				return sideModule(t, `(module)`, 0, 0, 10, 0)
+			},
+			expectedErr: "__indirect_function_table: out of slots",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			r := wazero.NewRuntime(testCtx)
+			defer r.Close(testCtx)
+
+			main, err := r.InstantiateWithConfig(testCtx, []byte(`(module
+  (memory (export "memory") 1)
+  (table (export "__indirect_function_table") 1 2 funcref)
+  (func (export "add") (param i32 i32) (result i32) (i32.const 0)))`), wazero.NewModuleConfig())
+			require.NoError(t, err)
+
+			_, err = dylink.NewLoader(r, main).Load(testCtx, tc.binary(t), wazero.NewModuleConfig())
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+// sideModule compiles the module `source` in the text format, prefixed by a
+// "dylink.0" section with the given memory and table info.
+func sideModule(t *testing.T, source string, memorySize, memoryAlign, tableSize, tableAlign uint32) []byte {
+	bin, err := wat.Compile([]byte(source))
+	require.NoError(t, err)
+
+	var memInfo []byte
+	for _, v := range []uint32{memorySize, memoryAlign, tableSize, tableAlign} {
+		memInfo = append(memInfo, leb128.EncodeUint32(v)...)
+	}
+	section := append([]byte{8}, "dylink.0"...)
+	section = append(section, 1, byte(len(memInfo))) // WASM_DYLINK_MEM_INFO
+	section = append(section, memInfo...)
+
+	ret := append([]byte{}, bin[:8]...)
+	ret = append(ret, 0, byte(len(section)))
+	ret = append(ret, section...)
+	return append(ret, bin[8:]...)
+}
+
+func call(t *testing.T, mod api.Module, name string, params ...uint64) []uint64 {
+	results, err := mod.ExportedFunction(name).Call(testCtx, params...)
+	require.NoError(t, err)
+	return results
+}
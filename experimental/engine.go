@@ -0,0 +1,51 @@
+package experimental
+
+import (
+	"context"
+
+	"github.com/tetratelabs/wazero/internal/enginekind"
+)
+
+// EngineKind is the engine which executes modules compiled with a context
+// from WithEngineKind.
+type EngineKind int
+
+const (
+	// EngineKindTiered interprets anonymous instances of a module until it
+	// is compiled in the background. This is the default of
+	// wazero.NewRuntimeConfigTiered, which documents when instances wait for
+	// the compiler instead.
+	EngineKindTiered = EngineKind(enginekind.Tiered)
+
+	// EngineKindInterpreter only interprets a module, so that
+	// wazero.Runtime CompileModule is fast and uses little memory. This is
+	// useful for small or trusted modules, such as configuration scripts.
+	EngineKindInterpreter = EngineKind(enginekind.Interpreter)
+
+	// EngineKindCompiler compiles a module before wazero.Runtime
+	// CompileModule returns, so that all its instances are compiled. This is
+	// useful for hot modules, such as plugins.
+	EngineKindCompiler = EngineKind(enginekind.Compiler)
+)
+
+// WithEngineKind registers the given EngineKind into the given
+// context.Context. Modules compiled with it by a runtime configured with
+// wazero.NewRuntimeConfigTiered use that engine. This allows one runtime,
+// with its host modules and compilation cache, to both interpret and compile
+// modules.
+//
+// Notes:
+//   - This requires wazero.NewRuntimeConfigTiered. Runtimes configured with
+//     other engines, such as wazero.NewRuntimeConfigInterpreter, ignore it
+//     and use their own for all modules.
+//   - Host modules ignore this, as they are usable by modules of any
+//     EngineKind.
+//   - Guest modules which import from each other must use the same engine,
+//     as compiled code can't call into the interpreter and vice versa.
+//     Instantiating a module importing from a compiled module fails if it
+//     was compiled with EngineKindInterpreter. Conversely, a module compiled
+//     with EngineKindCompiler is interpreted when it imports from one
+//     compiled with EngineKindInterpreter.
+func WithEngineKind(ctx context.Context, kind EngineKind) context.Context {
+	return context.WithValue(ctx, enginekind.Key{}, enginekind.Kind(kind))
+}
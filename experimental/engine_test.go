@@ -0,0 +1,60 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/enginekind"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestWithEngineKind(t *testing.T) {
+	ctx := experimental.WithEngineKind(testCtx, experimental.EngineKindCompiler)
+
+	require.Equal(t, enginekind.Compiler, ctx.Value(enginekind.Key{}))
+}
+
+func TestWithEngineKind_imports(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+
+	r := wazero.NewRuntimeWithConfig(testCtx, wazero.NewRuntimeConfigTiered())
+	defer r.Close(testCtx)
+
+	compiled := experimental.WithEngineKind(testCtx, experimental.EngineKindCompiler)
+	interpreted := experimental.WithEngineKind(testCtx, experimental.EngineKindInterpreter)
+
+	instantiate := func(ctx context.Context, name, source string) error {
+		c, err := r.CompileModule(ctx, []byte(source))
+		require.NoError(t, err)
+		_, err = r.InstantiateModule(ctx, c, wazero.NewModuleConfig().WithName(name))
+		return err
+	}
+	const exporter = `(module (func (export "f") (result i32) (i32.const 42)))`
+	require.NoError(t, instantiate(compiled, "compiled", exporter))
+	require.NoError(t, instantiate(interpreted, "interpreted", exporter))
+
+	importer := func(module string) string {
+		return `(module (import "` + module + `" "f" (func $f (result i32)))
+  (func (export "g") (result i32) (call $f)))`
+	}
+
+	// Importing from an interpreted module interprets, regardless of kind.
+	require.NoError(t, instantiate(compiled, "a", importer("interpreted")))
+	require.NoError(t, instantiate(interpreted, "b", importer("interpreted")))
+
+	// Only a compiled module can import from a compiled module.
+	require.NoError(t, instantiate(compiled, "c", importer("compiled")))
+	err := instantiate(interpreted, "d", importer("compiled"))
+	require.EqualError(t, err, "cannot import from compiled module[compiled] into a module only interpreted")
+
+	for _, name := range []string{"a", "b", "c"} {
+		results, err := r.Module(name).ExportedFunction("g").Call(testCtx)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{42}, results)
+	}
+}
@@ -0,0 +1,23 @@
+package experimental
+
+import (
+	"context"
+
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
+)
+
+// WithEnvironProvider registers the given function into the given
+// context.Context. Functions called with it, such as WASI environ_get, see the
+// environment it returns instead of the one configured by
+// wazero.ModuleConfig WithEnv.
+//
+// The provider returns "key=value" entries like os.Environ. It is called on
+// first use, with the context of that call, so that values such as secrets
+// are only resolved when needed. Its result is reused for the rest of the
+// calls with the returned context, so register a new provider to change the
+// environment of later calls, without instantiating the module again.
+//
+// Note: Entries without a '=' or with a NUL character fail with EINVAL.
+func WithEnvironProvider(ctx context.Context, provider func(ctx context.Context) []string) context.Context {
+	return context.WithValue(ctx, internalsys.EnvironProviderKey{}, &internalsys.EnvironProvider{Provider: provider})
+}
@@ -0,0 +1,23 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero/experimental"
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestWithEnvironProvider(t *testing.T) {
+	ctx := experimental.WithEnvironProvider(testCtx, func(context.Context) []string {
+		return []string{"a=b"}
+	})
+
+	p, ok := ctx.Value(internalsys.EnvironProviderKey{}).(*internalsys.EnvironProvider)
+	require.True(t, ok)
+	environ, size, err := p.Environ(ctx)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("a=b")}, environ)
+	require.Equal(t, uint32(4), size)
+}
@@ -0,0 +1,91 @@
+// Package exception allows host functions to throw and inspect exceptions of
+// the exception handling proposal, enabled by
+// experimental.CoreFeaturesExceptionHandling. For example, a host function
+// throws an exception of a tag exported by the guest, which the guest catches:
+//
+//	tag, _ := exception.ExportedTag(mod, "error")
+//
+//	func fail(ctx context.Context, stack []uint64) {
+//		exception.Throw(tag, stack[0])
+//	}
+//
+// An exception which the guest doesn't catch fails the call, and can be read
+// from the error:
+//
+//	_, err := mod.ExportedFunction("run").Call(ctx)
+//	if e, ok := exception.Of(err); ok && e.Tag() == tag {
+//		code := e.Payload()[0]
+//	}
+//
+// # Notes
+//
+//   - This is an experimental API, and only supported by the interpreter,
+//     including modules the tiered runtime interprets. See
+//     experimental.CoreFeaturesExceptionHandling.
+package exception
+
+import (
+	"errors"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// Tag is a tag of a module instance, which types the payload of its
+// exceptions. Tags are comparable: a tag imported by another module is equal
+// to the one it imports.
+type Tag struct {
+	t *wasm.TagInstance
+}
+
+// ExportedTag returns the tag exported by `mod` as `name`, or false if there
+// is none.
+func ExportedTag(mod api.Module, name string) (Tag, bool) {
+	t := mod.(*wasm.ModuleInstance).ExportedTag(name)
+	return Tag{t: t}, t != nil
+}
+
+// ParamTypes are the types of the payload of the exceptions of the tag.
+func (t Tag) ParamTypes() []api.ValueType {
+	return t.t.Type.Params
+}
+
+// String implements fmt.Stringer.
+func (t Tag) String() string {
+	return t.t.String()
+}
+
+// Throw throws an exception of `tag` from a host function called by the
+// guest, which unwinds the call until it is caught. The payload is encoded
+// like api.Function params, and must match Tag.ParamTypes.
+//
+// This panics, so never returns. Call it after releasing any resources the
+// host function holds.
+func Throw(tag Tag, payload ...uint64) {
+	wasm.Throw(tag.t, payload)
+}
+
+// Exception is an exception which the guest didn't catch.
+type Exception struct {
+	e *wasm.Exception
+}
+
+// Of returns the exception which failed a call, or false if `err` is from
+// another failure.
+func Of(err error) (*Exception, bool) {
+	var e *wasm.Exception
+	if errors.As(err, &e) {
+		return &Exception{e: e}, true
+	}
+	return nil, false
+}
+
+// Tag is the tag of the exception.
+func (e *Exception) Tag() Tag {
+	return Tag{t: e.e.Tag}
+}
+
+// Payload is the payload of the exception, encoded like api.Function params.
+func (e *Exception) Payload() []uint64 {
+	return e.e.Payload
+}
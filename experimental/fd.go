@@ -0,0 +1,132 @@
+package experimental
+
+import (
+	"github.com/tetratelabs/wazero/api"
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
+	"github.com/tetratelabs/wazero/internal/sysfs"
+)
+
+// DupFD assigns the file of the descriptor `fd` of the guest module `mod` to
+// its lowest available descriptor, like POSIX dup, and returns it. Both
+// descriptors share the same file, which is closed when the last is.
+//
+// WASI has no function to duplicate a descriptor, so host functions can use
+// this to implement one for guests which need it, such as shells. Combined
+// with RenumberFD, this also implements dup2. Here's an example which
+// redirects stdout to a file opened by the guest at `fd`, then restores it:
+//
+//	saved, _ := experimental.DupFD(mod, 1)
+//	_ = experimental.RenumberFD(mod, fd, 1)
+//	// ... writes to stdout go to the file ...
+//	_ = experimental.RenumberFD(mod, saved, 1)
+//
+// # Errors
+//
+// The error is an experimentalsys.Errno:
+//   - experimentalsys.EBADF: `fd` isn't open, or `mod` is a host module.
+//   - experimentalsys.ENOTSUP: `fd` is a pre-opened directory.
+func DupFD(mod api.Module, fd int32) (int32, error) {
+	fsc := fsContext(mod)
+	if fsc == nil {
+		return 0, experimentalsys.EBADF
+	}
+	newFD, errno := fsc.Dup(fd)
+	if errno != 0 {
+		return 0, errno
+	}
+	return newFD, nil
+}
+
+// RenumberFD assigns the file of the descriptor `from` of the guest module
+// `mod` to the descriptor `to`, closing any file it had, like WASI
+// fd_renumber. `from` is no longer open after, unless it equals `to`.
+//
+// # Errors
+//
+// The error is an experimentalsys.Errno:
+//   - experimentalsys.EBADF: `from` isn't open, `to` is negative, or `mod` is
+//     a host module.
+//   - experimentalsys.ENOTSUP: `from` or `to` is a pre-opened directory.
+func RenumberFD(mod api.Module, from, to int32) error {
+	fsc := fsContext(mod)
+	if fsc == nil {
+		return experimentalsys.EBADF
+	}
+	if errno := fsc.Renumber(from, to); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// FDLock is the type of an advisory lock on a whole file, used by LockFD.
+type FDLock = sysfs.LockType
+
+const (
+	// FDUnlock means no lock is held, so locking with it unlocks the file.
+	FDUnlock = sysfs.LockNone
+	// FDLockShared is a lock which any number of holders can hold at the same
+	// time, usually to read.
+	FDLockShared = sysfs.LockShared
+	// FDLockExclusive is a lock which only one holder can hold, usually to
+	// write.
+	FDLockExclusive = sysfs.LockExclusive
+)
+
+// LockFD applies or removes an advisory lock on the file of the descriptor
+// `fd` of the guest module `mod`, like `flock` in BSD. When `nonblock` is
+// true, a conflicting lock fails instead of waiting for it to be released.
+//
+// WASI has no function to lock files, so host functions can use this to
+// implement one for guests which need it, such as databases like SQLite.
+// Descriptors from DupFD share the lock, which is released when the last is
+// closed.
+//
+// Files opened from a host directory use `flock` on darwin, freebsd and
+// linux, so the lock is coherent with other modules and processes locking
+// the same file. Otherwise, the lock only conflicts with locks of the same
+// module on the same path, and conflicts fail regardless of `nonblock`.
+//
+// # Errors
+//
+// The error is an experimentalsys.Errno:
+//   - experimentalsys.EBADF: `fd` isn't open, or `mod` is a host module.
+//   - experimentalsys.EINVAL: `lock` is invalid.
+//   - experimentalsys.EAGAIN: a conflicting lock is held.
+func LockFD(mod api.Module, fd int32, lock FDLock, nonblock bool) error {
+	fsc := fsContext(mod)
+	if fsc == nil {
+		return experimentalsys.EBADF
+	}
+	if errno := fsc.Lock(fd, lock, nonblock); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// FDLockState returns the advisory lock the file of the descriptor `fd` of
+// the guest module `mod` holds, set by LockFD.
+//
+// Note: This doesn't include locks held by other modules or processes.
+func FDLockState(mod api.Module, fd int32) (FDLock, error) {
+	fsc := fsContext(mod)
+	if fsc == nil {
+		return FDUnlock, experimentalsys.EBADF
+	}
+	lock, errno := fsc.LockState(fd)
+	if errno != 0 {
+		return FDUnlock, errno
+	}
+	return lock, nil
+}
+
+// fsContext returns the file table of the guest module `mod`, or nil if it
+// has none.
+func fsContext(mod api.Module) *internalsys.FSContext {
+	if m, ok := mod.(interface {
+		FSContext() *internalsys.FSContext
+	}); ok {
+		return m.FSContext()
+	}
+	return nil
+}
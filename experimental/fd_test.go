@@ -0,0 +1,141 @@
+package experimental_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+func TestDupFD_redirectStdout(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+	wasi_snapshot_preview1.MustInstantiate(testCtx, r)
+
+	// Host functions redirecting stdout of the guest to the file at `fd`,
+	// then restoring it, like a shell running "cmd > file".
+	var saved int32
+	_, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().WithFunc(func(_ context.Context, mod api.Module, fd int32) {
+		var err error
+		saved, err = experimental.DupFD(mod, 1)
+		require.NoError(t, err)
+		require.NoError(t, experimental.RenumberFD(mod, fd, 1))
+	}).Export("redirect").
+		NewFunctionBuilder().WithFunc(func(_ context.Context, mod api.Module) {
+		require.NoError(t, experimental.RenumberFD(mod, saved, 1))
+	}).Export("restore").
+		Instantiate(testCtx)
+	require.NoError(t, err)
+
+	// The guest opens "file" in the pre-opened directory, then writes "A"
+	// while stdout is redirected to it, and "B" after it's restored.
+	compiled, err := r.CompileModule(testCtx, []byte(`(module
+  (import "wasi_snapshot_preview1" "path_open"
+    (func $path_open (param i32 i32 i32 i32 i32 i64 i64 i32 i32) (result i32)))
+  (import "wasi_snapshot_preview1" "fd_write"
+    (func $fd_write (param i32 i32 i32 i32) (result i32)))
+  (import "env" "redirect" (func $redirect (param i32)))
+  (import "env" "restore" (func $restore))
+  (memory (export "memory") 1)
+  (data (i32.const 0) "file")
+  (data (i32.const 8) "A")
+  (data (i32.const 9) "B")
+  ;; iovecs of "A" at 16, and "B" at 24
+  (data (i32.const 16) "\08\00\00\00\01\00\00\00\09\00\00\00\01\00\00\00")
+  (func $write (param $iovec i32)
+    (drop (call $fd_write (i32.const 1) (local.get $iovec) (i32.const 1) (i32.const 32))))
+  (func (export "run")
+    ;; path_open(3, 0, "file", O_CREAT, fd_write rights, 0, 0, &fd)
+    (drop (call $path_open (i32.const 3) (i32.const 0) (i32.const 0) (i32.const 4)
+      (i32.const 1) (i64.const 64) (i64.const 0) (i32.const 0) (i32.const 40)))
+    (call $redirect (i32.load (i32.const 40)))
+    (call $write (i32.const 16))
+    (call $restore)
+    (call $write (i32.const 24))))`))
+	require.NoError(t, err)
+
+	tmpDir := t.TempDir()
+	var stdout bytes.Buffer
+	mod, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig().
+		WithStdout(&stdout).WithFSConfig(wazero.NewFSConfig().WithDirMount(tmpDir, "/")))
+	require.NoError(t, err)
+
+	_, err = mod.ExportedFunction("run").Call(testCtx)
+	require.NoError(t, err)
+
+	b, err := os.ReadFile(path.Join(tmpDir, "file"))
+	require.NoError(t, err)
+	require.Equal(t, "A", string(b))
+	require.Equal(t, "B", stdout.String())
+}
+
+func TestDupFD_errors(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	host, err := r.NewHostModuleBuilder("env").Instantiate(testCtx)
+	require.NoError(t, err)
+	_, err = experimental.DupFD(host, 1)
+	require.EqualErrno(t, experimentalsys.EBADF, err)
+	require.EqualErrno(t, experimentalsys.EBADF, experimental.RenumberFD(host, 1, 2))
+
+	mod, err := r.InstantiateWithConfig(testCtx, []byte(`(module)`),
+		wazero.NewModuleConfig().WithFSConfig(wazero.NewFSConfig().WithDirMount(t.TempDir(), "/")))
+	require.NoError(t, err)
+	_, err = experimental.DupFD(mod, 12345)
+	require.EqualErrno(t, experimentalsys.EBADF, err)
+	_, err = experimental.DupFD(mod, 3) // pre-opened directory
+	require.EqualErrno(t, experimentalsys.ENOTSUP, err)
+}
+
+func TestLockFD(t *testing.T) {
+	switch runtime.GOOS {
+	case "linux", "darwin", "freebsd":
+	default:
+		t.Skip("flock is unsupported on", runtime.GOOS)
+	}
+
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	// Two modules open the same host file, like two instances of a database.
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(path.Join(tmpDir, "db"), nil, 0o600))
+	config := wazero.NewModuleConfig().WithFSConfig(wazero.NewFSConfig().WithDirMount(tmpDir, "/"))
+	openDB := func(name string) (api.Module, int32) {
+		mod, err := r.InstantiateWithConfig(testCtx, []byte(`(module)`), config.WithName(name))
+		require.NoError(t, err)
+		fsc := mod.(*wasm.ModuleInstance).Sys.FS()
+		fd, errno := fsc.OpenFile(fsc.RootFS(), "db", experimentalsys.O_RDWR, 0)
+		require.EqualErrno(t, 0, errno)
+		return mod, fd
+	}
+	a, aFD := openDB("a")
+	b, bFD := openDB("b")
+
+	require.NoError(t, experimental.LockFD(a, aFD, experimental.FDLockExclusive, true))
+	lock, err := experimental.FDLockState(a, aFD)
+	require.NoError(t, err)
+	require.Equal(t, experimental.FDLockExclusive, lock)
+
+	err = experimental.LockFD(b, bFD, experimental.FDLockShared, true)
+	require.EqualErrno(t, experimentalsys.EAGAIN, err)
+	lock, err = experimental.FDLockState(b, bFD)
+	require.NoError(t, err)
+	require.Equal(t, experimental.FDUnlock, lock)
+
+	// Closing the module releases its locks.
+	require.NoError(t, a.Close(testCtx))
+	require.NoError(t, experimental.LockFD(b, bFD, experimental.FDLockShared, true))
+}
@@ -0,0 +1,150 @@
+package experimental
+
+import "github.com/tetratelabs/wazero/api"
+
+// CoreFeaturesMemory64 enables 64-bit linear memories, which are addressed
+// with i64 instead of i32 ("memory64"). This isn't included in
+// api.CoreFeaturesV2, so enable it explicitly:
+//
+//	features := api.CoreFeaturesV2 | experimental.CoreFeaturesMemory64
+//	rConfig = wazero.NewRuntimeConfig().WithCoreFeatures(features)
+//
+// Here are the notable effects:
+//   - Memory types can be encoded with 64-bit limits.
+//   - Instructions on such a memory use i64 addresses, offsets and sizes, and
+//     `memory.size` and `memory.grow` use i64 page counts.
+//   - Data segments of such a memory have i64 offsets.
+//
+// # Notes
+//
+//   - The size of a memory is still limited by
+//     wazero.RuntimeConfig WithMemoryLimitPages, which is at most 4GB. Any
+//     access above the size of the memory traps, as addresses are checked
+//     against it, instead of relying on guard regions.
+//   - A maximum above the limit is lowered to it, as memory.grow can fail.
+//
+// See https://github.com/WebAssembly/memory64/blob/main/proposals/memory64/Overview.md
+const CoreFeaturesMemory64 = api.CoreFeatureSIMD << 1
+
+// CoreFeaturesThreads enables shared memories and atomic instructions
+// ("threads"). This isn't included in api.CoreFeaturesV2, so enable it
+// explicitly:
+//
+//	features := api.CoreFeaturesV2 | experimental.CoreFeaturesThreads
+//	rConfig = wazero.NewRuntimeConfig().WithCoreFeatures(features)
+//
+// Here are the notable effects:
+//   - Memory types can be encoded as shared, which requires a maximum.
+//   - Atomic loads, stores and read-modify-write instructions, as well as
+//     `memory.atomic.wait32`, `memory.atomic.wait64`, `memory.atomic.notify`
+//     and `atomic.fence`, are valid.
+//
+// # Notes
+//
+//   - A shared memory allocates its maximum size when instantiated, so that it
+//     is never reallocated while other goroutines access it. Consider lowering
+//     it with wazero.RuntimeConfig WithMemoryLimitPages.
+//   - Wasm threads are goroutines calling functions of module instances which
+//     import the same shared memory, e.g. as implemented by wasi-threads in
+//     the wasithreads package.
+//   - Atomic instructions are serialized per memory, and the compiler calls
+//     into Go to execute them, so they are slower than other memory accesses.
+//   - `memory.atomic.wait32` and `memory.atomic.wait64` block the goroutine,
+//     until woken up, or the module waiting is closed, which fails the call
+//     with sys.ExitError.
+//
+// See https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md
+const CoreFeaturesThreads = api.CoreFeatureSIMD << 2
+
+// CoreFeaturesTailCall enables the `return_call` and `return_call_indirect`
+// instructions ("tail-call"), which return the results of a call that reuses
+// the frame of the caller. This isn't included in api.CoreFeaturesV2, so
+// enable it explicitly:
+//
+//	features := api.CoreFeaturesV2 | experimental.CoreFeaturesTailCall
+//	rConfig = wazero.NewRuntimeConfig().WithCoreFeatures(features)
+//
+// Tail calls don't grow the call stack, so recursion in tail position, e.g.
+// as compiled from Scheme or OCaml, runs in constant stack space.
+//
+// # Notes
+//
+//   - A tail call to a host function, or from or to a function with a
+//     function listener, may be executed as a call followed by a return, so it
+//     uses a frame until the callee returns.
+//
+// See https://github.com/WebAssembly/tail-call/blob/main/proposals/tail-call/Overview.md
+const CoreFeaturesTailCall = api.CoreFeatureSIMD << 3
+
+// CoreFeaturesExceptionHandling enables tags, exceptions and the exnref type
+// ("exception-handling"), as used by C++ and Kotlin/Wasm guests. This isn't
+// included in api.CoreFeaturesV2, so enable it explicitly:
+//
+//	features := api.CoreFeaturesV2 | experimental.CoreFeaturesExceptionHandling
+//	rConfig = wazero.NewRuntimeConfig().WithCoreFeatures(features)
+//
+// Here are the notable effects:
+//   - Modules can define, import and export tags, which type exceptions.
+//   - `throw`, `throw_ref` and `try_table` are valid, as well as `try`,
+//     `catch`, `catch_all`, `rethrow` and `delegate` from the legacy version
+//     of the proposal, which toolchains still emit.
+//   - Functions, blocks and locals can use the exnref type.
+//
+// Exceptions unwind through host functions, which can throw them too. An
+// uncaught exception fails the call, and its tag and payload can be read from
+// the error. See the exception package.
+//
+// # Notes
+//
+//   - This is only supported by the interpreter. Use it with
+//     wazero.NewRuntimeConfigInterpreter, or wazero.NewRuntimeConfigTiered,
+//     which interprets modules the compiler can't compile. Otherwise,
+//     Runtime.CompileModule fails for modules using exception handling
+//     instructions, while other modules are unaffected.
+//   - Globals and tables of exnref aren't supported.
+//   - An exnref returned from a call is only valid until the next call of
+//     the same api.Function.
+//
+// See https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/Exceptions.md
+const CoreFeaturesExceptionHandling = api.CoreFeatureSIMD << 4
+
+// CoreFeaturesFunctionReferences enables typed function references
+// ("function-references"), which toolchains emit even without the GC proposal.
+// This isn't included in api.CoreFeaturesV2, so enable it explicitly:
+//
+//	features := api.CoreFeaturesV2 | experimental.CoreFeaturesFunctionReferences
+//	rConfig = wazero.NewRuntimeConfig().WithCoreFeatures(features)
+//
+// Here are the notable effects:
+//   - Value types can be typed references, e.g. `(ref $t)` or
+//     `(ref null func)`, including in function types, locals and globals.
+//   - `call_ref`, `ref.as_non_null`, `br_on_null` and `br_on_non_null` are
+//     valid, as well as `return_call_ref` if CoreFeaturesTailCall is enabled.
+//
+// # Notes
+//
+//   - Typed references are represented as the funcref or externref they are
+//     subtypes of, e.g. in api.FunctionDefinition ParamTypes. So, validation
+//     doesn't distinguish references to functions of different types, nor
+//     non-null references from nullable ones. Instead, `call_ref` traps if the
+//     function is null or has another type, like `call_indirect`.
+//   - Tables of typed references aren't supported, nor `ref.null` of a type
+//     index in constant expressions, e.g. initializers of globals.
+//
+// See https://github.com/WebAssembly/function-references/blob/main/proposals/function-references/Overview.md
+const CoreFeaturesFunctionReferences = api.CoreFeatureSIMD << 5
+
+// CoreFeaturesExtendedConst enables `i32.add`, `i32.sub`, `i32.mul` and their
+// i64 counterparts in constant expressions ("extended-const"), as emitted by
+// LLVM for position-independent code. This isn't included in
+// api.CoreFeaturesV2, so enable it explicitly:
+//
+//	features := api.CoreFeaturesV2 | experimental.CoreFeaturesExtendedConst
+//	rConfig = wazero.NewRuntimeConfig().WithCoreFeatures(features)
+//
+// This applies to global initializers and the offsets of element and data
+// segments, e.g. `(global.get $__memory_base) (i32.const 16) (i32.add)`. Like
+// other constant expressions, they can only refer to imported globals.
+//
+// See https://github.com/WebAssembly/extended-const/blob/main/proposals/extended-const/Overview.md
+const CoreFeaturesExtendedConst = api.CoreFeatureSIMD << 6
@@ -0,0 +1,115 @@
+package experimental
+
+import (
+	"context"
+	"math"
+
+	"github.com/tetratelabs/wazero/internal/fuel"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
+)
+
+// ErrFuelExhausted is the cause of the error of a call which ran out of the
+// Fuel of its context. Use errors.Is to check for it.
+var ErrFuelExhausted error = wasmruntime.ErrRuntimeFuelExhausted
+
+// FuelCosts is the fuel charged to execute each Wasm instruction of modules
+// compiled with a context from WithFuelCosts.
+type FuelCosts struct {
+	// Default is the cost of instructions not in Instructions.
+	Default uint64
+
+	// Instructions are the costs of instructions by their name in the text
+	// format, such as "i32.add", "call" or "memory.grow".
+	Instructions map[string]uint64
+}
+
+// WithFuelCosts registers the given FuelCosts into the given
+// context.Context. Modules compiled with it by wazero.Runtime CompileModule
+// consume fuel when called with a context from WithFuel.
+//
+// Fuel is deterministic, unlike a deadline, as it only depends on the Wasm
+// instructions executed. This is useful to limit untrusted modules, such as
+// smart contracts or plugins of multiple tenants.
+//
+// Here's an example:
+//
+//	ctx = experimental.WithFuelCosts(ctx, experimental.FuelCosts{
+//		Default:      1,
+//		Instructions: map[string]uint64{"call": 10, "memory.grow": 1000},
+//	})
+//	compiled, _ := r.CompileModule(ctx, wasm)
+//	mod, _ := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig())
+//
+//	callCtx, fuel := experimental.WithFuel(ctx, 1_000_000)
+//	_, err := mod.ExportedFunction("run").Call(callCtx)
+//	if errors.Is(err, experimental.ErrFuelExhausted) {
+//		// handle running out of fuel.
+//	}
+//	used := 1_000_000 - fuel.Remaining()
+//
+// # Notes
+//
+//   - Each block of instructions up to the next branch target is charged
+//     when entering it, so a call which traps, including when it runs out
+//     of fuel, may be charged for instructions it didn't execute. Charges are
+//     the same in the interpreter and the compiler.
+//   - Host functions don't consume fuel, but can with Fuel.Consume.
+//   - The costs are part of the compiled module, so a compilation cache or
+//     wazero.Runtime UnmarshalCompiledModule only reuse code compiled with
+//     the same costs.
+func WithFuelCosts(ctx context.Context, costs FuelCosts) context.Context {
+	instructions := make(map[string]uint64, len(costs.Instructions))
+	for name, cost := range costs.Instructions {
+		instructions[name] = cost
+	}
+	return context.WithValue(ctx, fuel.CostsKey{}, &fuel.Costs{
+		Default:      costs.Default,
+		Instructions: instructions,
+	})
+}
+
+// Fuel is the fuel remaining to calls made with a context from WithFuel.
+//
+// Nested calls, such as from a host function, share the fuel of their
+// context. Fuel must not be used concurrently, except by host functions
+// called by a call it is limiting.
+type Fuel struct {
+	meter fuel.Meter
+}
+
+// WithFuel returns a context which limits calls made with it to `amount` of
+// fuel, and the Fuel to query or add to it.
+//
+// Calls made with another context are unlimited. Fuel has no effect on
+// modules compiled without a context from WithFuelCosts.
+func WithFuel(ctx context.Context, amount uint64) (context.Context, *Fuel) {
+	f := &Fuel{}
+	f.Add(amount)
+	return context.WithValue(ctx, fuel.MeterKey{}, &f.meter), f
+}
+
+// Remaining returns the fuel which calls can still consume. This is zero
+// after a call failed with ErrFuelExhausted.
+func (f *Fuel) Remaining() uint64 {
+	return uint64(f.meter.Remaining)
+}
+
+// Add adds `amount` of fuel. For example, a host function can add fuel to
+// let the call which called it continue.
+func (f *Fuel) Add(amount uint64) {
+	if amount > uint64(math.MaxInt64-f.meter.Remaining) {
+		f.meter.Remaining = math.MaxInt64
+	} else {
+		f.meter.Remaining += int64(amount)
+	}
+}
+
+// Consume consumes `amount` of fuel, such as for the work of a host function,
+// or returns ErrFuelExhausted without consuming any if there isn't enough.
+func (f *Fuel) Consume(amount uint64) error {
+	if amount > uint64(f.meter.Remaining) {
+		return ErrFuelExhausted
+	}
+	f.meter.Remaining -= int64(amount)
+	return nil
+}
@@ -0,0 +1,155 @@
+package experimental_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/binaryencoding"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// fuelWasm imports "charge" from "env", and exports "loop", which loops its
+// parameter times, and "call_charge", which calls "charge".
+var fuelWasm = binaryencoding.EncodeModule(&wasm.Module{
+	TypeSection: []wasm.FunctionType{
+		{},
+		{Params: []api.ValueType{api.ValueTypeI32}, ParamNumInUint64: 1},
+	},
+	ImportSection:   []wasm.Import{{Module: "env", Name: "charge", Type: wasm.ExternTypeFunc, DescFunc: 0}},
+	FunctionSection: []wasm.Index{1, 0},
+	CodeSection: []wasm.Code{
+		// With a Default cost of 1, the loop is charged 1, each iteration 5,
+		// and the end 2.
+		{Body: []byte{
+			wasm.OpcodeLoop, 0x40,
+			wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Sub, wasm.OpcodeLocalTee, 0,
+			wasm.OpcodeBrIf, 0,
+			wasm.OpcodeEnd,
+			wasm.OpcodeEnd,
+		}},
+		{Body: []byte{wasm.OpcodeCall, 0, wasm.OpcodeEnd}},
+	},
+	ExportSection: []wasm.Export{
+		{Name: "loop", Type: wasm.ExternTypeFunc, Index: 1},
+		{Name: "call_charge", Type: wasm.ExternTypeFunc, Index: 2},
+	},
+})
+
+func TestWithFuel(t *testing.T) {
+	configs := map[string]wazero.RuntimeConfig{"interpreter": wazero.NewRuntimeConfigInterpreter()}
+	if platform.CompilerSupported() {
+		configs["compiler"] = wazero.NewRuntimeConfigCompiler()
+		configs["tiered"] = wazero.NewRuntimeConfigTiered()
+	}
+	for n, c := range configs {
+		config := c
+		t.Run(n, func(t *testing.T) {
+			r := wazero.NewRuntimeWithConfig(testCtx, config)
+			defer r.Close(testCtx)
+
+			// charge is the implementation of "env.charge", set by each test.
+			var charge func(ctx context.Context)
+			_, err := r.NewHostModuleBuilder("env").NewFunctionBuilder().
+				WithFunc(func(ctx context.Context) { charge(ctx) }).Export("charge").
+				Instantiate(testCtx)
+			require.NoError(t, err)
+
+			ctx := experimental.WithFuelCosts(testCtx, experimental.FuelCosts{Default: 1})
+			compiled, err := r.CompileModule(ctx, fuelWasm)
+			require.NoError(t, err)
+			mod, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig())
+			require.NoError(t, err)
+
+			t.Run("loop", func(t *testing.T) {
+				ctx, fuel := experimental.WithFuel(testCtx, 100)
+				_, err := mod.ExportedFunction("loop").Call(ctx, 10)
+				require.NoError(t, err)
+				require.Equal(t, uint64(100-(1+10*5+2)), fuel.Remaining())
+			})
+
+			t.Run("exhausted", func(t *testing.T) {
+				ctx, fuel := experimental.WithFuel(testCtx, 1+10*5+1)
+				_, err := mod.ExportedFunction("loop").Call(ctx, 10)
+				require.True(t, errors.Is(err, experimental.ErrFuelExhausted))
+				require.Equal(t, uint64(0), fuel.Remaining())
+
+				// The same call succeeds with more fuel.
+				fuel.Add(1 + 10*5 + 2)
+				_, err = mod.ExportedFunction("loop").Call(ctx, 10)
+				require.NoError(t, err)
+			})
+
+			t.Run("unlimited", func(t *testing.T) {
+				_, err := mod.ExportedFunction("loop").Call(testCtx, 1000)
+				require.NoError(t, err)
+			})
+
+			t.Run("host function", func(t *testing.T) {
+				ctx, fuel := experimental.WithFuel(testCtx, 10)
+				charge = func(context.Context) {
+					// The call was charged for itself before calling this.
+					require.Equal(t, uint64(8), fuel.Remaining())
+					require.NoError(t, fuel.Consume(5))
+				}
+				_, err := mod.ExportedFunction("call_charge").Call(ctx)
+				require.NoError(t, err)
+				require.Equal(t, uint64(3), fuel.Remaining())
+
+				// Consuming more fuel than remaining fails.
+				require.Equal(t, experimental.ErrFuelExhausted, fuel.Consume(4))
+				require.Equal(t, uint64(3), fuel.Remaining())
+			})
+
+			t.Run("nested call", func(t *testing.T) {
+				ctx, fuel := experimental.WithFuel(testCtx, 100)
+				charge = func(ctx context.Context) {
+					_, err := mod.ExportedFunction("loop").Call(ctx, 1)
+					require.NoError(t, err)
+				}
+				_, err := mod.ExportedFunction("call_charge").Call(ctx)
+				require.NoError(t, err)
+				require.Equal(t, uint64(100-2-(1+5+2)), fuel.Remaining())
+			})
+
+			t.Run("large costs", func(t *testing.T) {
+				ctx := experimental.WithFuelCosts(testCtx, experimental.FuelCosts{Default: 1 << 40})
+				compiled, err := r.CompileModule(ctx, fuelWasm)
+				require.NoError(t, err)
+				mod, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig().WithName("large"))
+				require.NoError(t, err)
+
+				ctx, fuel := experimental.WithFuel(testCtx, 100<<40)
+				_, err = mod.ExportedFunction("loop").Call(ctx, 10)
+				require.NoError(t, err)
+				require.Equal(t, uint64(100-(1+10*5+2))<<40, fuel.Remaining())
+			})
+
+			t.Run("not metered", func(t *testing.T) {
+				unmetered, err := r.InstantiateWithConfig(testCtx, fuelWasm, wazero.NewModuleConfig().WithName("unmetered"))
+				require.NoError(t, err)
+
+				ctx, fuel := experimental.WithFuel(testCtx, 1)
+				_, err = unmetered.ExportedFunction("loop").Call(ctx, 10)
+				require.NoError(t, err)
+				require.Equal(t, uint64(1), fuel.Remaining())
+			})
+		})
+	}
+}
+
+func TestFuel_Add(t *testing.T) {
+	_, fuel := experimental.WithFuel(testCtx, 1)
+	fuel.Add(2)
+	require.Equal(t, uint64(3), fuel.Remaining())
+
+	// Fuel saturates.
+	fuel.Add(1 << 63)
+	fuel.Add(1 << 63)
+	require.Equal(t, uint64(1<<63-1), fuel.Remaining())
+}
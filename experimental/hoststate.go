@@ -0,0 +1,46 @@
+package experimental
+
+import (
+	"context"
+
+	"github.com/tetratelabs/wazero/api"
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
+)
+
+// WithHostState registers the given state into the given context.Context,
+// which attaches it to modules instantiated with it. Host functions read it
+// back with HostState, from the api.Module calling them.
+//
+// This is an alternative to passing state to host functions in the
+// context.Context of each api.Function Call, which is easy to get wrong when
+// modules of different plugins are called with the wrong context.
+//
+// Here's an example:
+//
+//	type plugin struct{ name string }
+//
+//	_, _ = r.NewHostModuleBuilder("env").
+//		NewFunctionBuilder().WithFunc(func(ctx context.Context, mod api.Module) {
+//			p, _ := experimental.HostState[*plugin](mod)
+//			log.Println("called by", p.name)
+//		}).Export("log").
+//		Instantiate(ctx)
+//
+//	ctx = experimental.WithHostState(ctx, &plugin{name: "a"})
+//	mod, _ := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig())
+//
+// Note: The state is only attached to guest modules, not host modules.
+func WithHostState(ctx context.Context, state interface{}) context.Context {
+	return context.WithValue(ctx, internalsys.HostStateKey{}, &internalsys.HostState{State: state})
+}
+
+// HostState returns the state attached to the module with WithHostState, or
+// false if there is none or it isn't of type T.
+//
+// Note: The state is no longer available after the module is closed.
+func HostState[T any](mod api.Module) (state T, ok bool) {
+	if m, isInstance := mod.(interface{ HostState() interface{} }); isInstance {
+		state, ok = m.HostState().(T)
+	}
+	return
+}
@@ -0,0 +1,57 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestHostState(t *testing.T) {
+	type plugin struct{ name string }
+
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	var called []string
+	_, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, mod api.Module) {
+		p, ok := experimental.HostState[*plugin](mod)
+		require.True(t, ok)
+		_, ok = experimental.HostState[string](mod)
+		require.False(t, ok)
+		called = append(called, p.name)
+	}).Export("log").
+		Instantiate(testCtx)
+	require.NoError(t, err)
+
+	compiled, err := r.CompileModule(testCtx, []byte(`(module
+  (import "env" "log" (func $log))
+  (func (export "run") (call $log)))`))
+	require.NoError(t, err)
+
+	config := wazero.NewModuleConfig()
+	a, err := r.InstantiateModule(experimental.WithHostState(testCtx, &plugin{name: "a"}), compiled, config.WithName("a"))
+	require.NoError(t, err)
+	b, err := r.InstantiateModule(experimental.WithHostState(testCtx, &plugin{name: "b"}), compiled, config.WithName("b"))
+	require.NoError(t, err)
+	none, err := r.InstantiateModule(testCtx, compiled, config.WithName("none"))
+	require.NoError(t, err)
+
+	// The state is from the module, regardless of the context of the call.
+	_, err = b.ExportedFunction("run").Call(testCtx)
+	require.NoError(t, err)
+	_, err = a.ExportedFunction("run").Call(experimental.WithHostState(testCtx, &plugin{name: "b"}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "a"}, called)
+
+	_, ok := experimental.HostState[*plugin](none)
+	require.False(t, ok)
+
+	require.NoError(t, a.Close(testCtx))
+	_, ok = experimental.HostState[*plugin](a)
+	require.False(t, ok)
+}
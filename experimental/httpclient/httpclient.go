@@ -0,0 +1,444 @@
+// Package httpclient contains host functions which allow guests to make HTTP
+// requests with the host's http.Client, under the module name "wazero_http".
+//
+// # Experimental
+//
+// WASI defines HTTP in wasi:http, which requires the component model, so
+// isn't supported by wazero. Until then, this is a simple bridge for plugin
+// systems, and its ABI may change from release to release.
+//
+// # Functions
+//
+// Each function returns a WASI errno, where zero is success. Strings are
+// passed as an offset and length in memory.
+//
+//   - "request" (method, method_len, url, url_len, headers, headers_len,
+//     body, body_len, result.handle) sends a request and writes a handle of
+//     its response. headers are "Name: value" lines separated by "\n".
+//   - "status" (handle, result.status) writes the status code.
+//   - "header" (handle, name, name_len, buf, buf_len, result.len) writes the
+//     values of a header, joined by ", ", and its length. If buf_len is too
+//     small, only the length is written, with errno ERANGE.
+//   - "read" (handle, buf, buf_len, result.nread) reads the body, where zero
+//     means it was fully read.
+//   - "close" (handle) closes the response. Responses a guest doesn't close
+//     are closed with it.
+//
+// # Permissions
+//
+// Requests are denied with errno EACCES unless their host is allowed by
+// Builder.WithAllowedHosts, including redirects, and their method by
+// Builder.WithAllowedMethods.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/internal/descriptor"
+	"github.com/tetratelabs/wazero/internal/wasip1"
+)
+
+// ModuleName is the module name the host functions are instantiated as.
+const ModuleName = "wazero_http"
+
+const i32 = api.ValueTypeI32
+
+// Builder configures the "wazero_http" module for later use via Instantiate.
+//
+// # Notes
+//
+//   - This is an interface for decoupling, not third-party implementations.
+//     All implementations are in wazero.
+//   - Instantiate a separate module per guest, e.g. with a different
+//     runtime, if guests need different permissions.
+type Builder interface {
+	// WithClient sets the client used for requests, which defaults to
+	// http.DefaultClient.
+	WithClient(client *http.Client) Builder
+
+	// WithAllowedHosts allows requests to the given hosts, e.g.
+	// "api.example.com", compared without regard to case or port. A host of
+	// "*" allows any host. By default, no hosts are allowed.
+	WithAllowedHosts(hosts ...string) Builder
+
+	// WithAllowedMethods restricts requests to the given methods, e.g. "GET".
+	// By default, any method is allowed.
+	WithAllowedMethods(methods ...string) Builder
+
+	// Instantiate instantiates the "wazero_http" module into the runtime.
+	//
+	// Closing the result also closes any responses the guest didn't.
+	Instantiate(context.Context) (api.Closer, error)
+}
+
+// NewBuilder returns a new Builder.
+func NewBuilder(r wazero.Runtime) Builder {
+	return &builder{r: r, client: http.DefaultClient}
+}
+
+type builder struct {
+	r       wazero.Runtime
+	client  *http.Client
+	hosts   []string
+	methods []string
+}
+
+// WithClient implements Builder.WithClient
+func (b *builder) WithClient(client *http.Client) Builder {
+	ret := *b
+	ret.client = client
+	return &ret
+}
+
+// WithAllowedHosts implements Builder.WithAllowedHosts
+func (b *builder) WithAllowedHosts(hosts ...string) Builder {
+	ret := *b
+	ret.hosts = append(append([]string(nil), b.hosts...), hosts...)
+	return &ret
+}
+
+// WithAllowedMethods implements Builder.WithAllowedMethods
+func (b *builder) WithAllowedMethods(methods ...string) Builder {
+	ret := *b
+	ret.methods = append(append([]string(nil), b.methods...), methods...)
+	return &ret
+}
+
+// Instantiate implements Builder.Instantiate
+func (b *builder) Instantiate(ctx context.Context) (api.Closer, error) {
+	h, hostModule := b.hostModuleBuilder()
+	mod, err := hostModule.Instantiate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &closer{Closer: mod, h: h}, nil
+}
+
+// hostModuleBuilder returns the state of a new module and a builder of its
+// functions.
+func (b *builder) hostModuleBuilder() (*httpClient, wazero.HostModuleBuilder) {
+	h := &httpClient{
+		hosts:     b.hosts,
+		methods:   b.methods,
+		responses: map[api.Module]*guestResponses{},
+	}
+
+	// Copy the client, so that redirects are checked against hosts.
+	client := *b.client
+	checkRedirect := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !h.allowedHost(req.URL) {
+			return errDenied
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= 10 { // Same as the default policy.
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	h.client = &client
+
+	hostModule := b.r.NewHostModuleBuilder(ModuleName)
+	h.export(hostModule, "request", h.request,
+		"method", "method_len", "url", "url_len", "headers", "headers_len", "body", "body_len", "result.handle")
+	h.export(hostModule, "status", h.status, "handle", "result.status")
+	h.export(hostModule, "header", h.header, "handle", "name", "name_len", "buf", "buf_len", "result.len")
+	h.export(hostModule, "read", h.read, "handle", "buf", "buf_len", "result.nread")
+	h.export(hostModule, "close", h.close, "handle")
+	return h, hostModule
+}
+
+// errDenied is returned by http.Client CheckRedirect when the host of a
+// redirect isn't allowed.
+var errDenied = errors.New("host not allowed")
+
+// httpClient holds the state of an instantiated "wazero_http" module.
+type httpClient struct {
+	client  *http.Client
+	hosts   []string
+	methods []string
+
+	// mu guards responses, as guests may call concurrently.
+	mu sync.Mutex
+	// responses are the open responses of each guest, so that one guest
+	// can't read another's. They are released when the guest closes them
+	// all, or is closed itself.
+	responses map[api.Module]*guestResponses
+}
+
+// guestResponses are the open responses of a guest.
+type guestResponses struct {
+	descriptor.Table[int32, *http.Response]
+
+	// released is closed when the responses are removed from httpClient, so
+	// that the goroutine waiting for the guest to close returns.
+	released chan struct{}
+}
+
+// closeAll closes all the responses.
+func (g *guestResponses) closeAll() {
+	g.Range(func(_ int32, resp *http.Response) bool {
+		_ = resp.Body.Close()
+		return true
+	})
+	close(g.released)
+}
+
+// export adds a function named `name`, which has i32 parameters named
+// `names`, and returns the errno of `fn`.
+func (h *httpClient) export(builder wazero.HostModuleBuilder, name string, fn func(context.Context, api.Module, []uint64) experimentalsys.Errno, names ...string) {
+	params := make([]api.ValueType, len(names))
+	for i := range params {
+		params[i] = i32
+	}
+	builder.NewFunctionBuilder().
+		WithGoModuleFunction(api.GoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
+			stack[0] = uint64(wasip1.ToErrno(fn(ctx, mod, stack)))
+		}), params, []api.ValueType{i32}).
+		WithParameterNames(names...).
+		WithResultNames("errno").
+		Export(name)
+}
+
+func (h *httpClient) allowedHost(u *url.URL) bool {
+	host := u.Hostname()
+	for _, allowed := range h.hosts {
+		if allowed == "*" || strings.EqualFold(allowed, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *httpClient) allowedMethod(method string) bool {
+	if len(h.methods) == 0 {
+		return true
+	}
+	for _, allowed := range h.methods {
+		if allowed == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *httpClient) request(ctx context.Context, mod api.Module, params []uint64) experimentalsys.Errno {
+	mem := mod.Memory()
+	method, ok := readString(mem, params[0], params[1])
+	if !ok {
+		return experimentalsys.EFAULT
+	}
+	rawURL, ok := readString(mem, params[2], params[3])
+	if !ok {
+		return experimentalsys.EFAULT
+	}
+	headers, ok := readString(mem, params[4], params[5])
+	if !ok {
+		return experimentalsys.EFAULT
+	}
+	body, ok := mem.Read(uint32(params[6]), uint32(params[7]))
+	if !ok {
+		return experimentalsys.EFAULT
+	}
+	resultHandle := uint32(params[8])
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return experimentalsys.EINVAL
+	} else if !h.allowedHost(u) || !h.allowedMethod(method) {
+		return experimentalsys.EACCES
+	}
+
+	// Copy the body, as the guest may change memory after this returns.
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), strings.NewReader(string(body)))
+	if err != nil {
+		return experimentalsys.EINVAL
+	}
+	for _, line := range strings.Split(headers, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return experimentalsys.EINVAL
+		}
+		req.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		if errors.Is(err, errDenied) {
+			return experimentalsys.EACCES
+		}
+		return experimentalsys.EIO
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	table, ok := h.responses[mod]
+	if !ok {
+		table = &guestResponses{released: make(chan struct{})}
+		h.responses[mod] = table
+		if m, ok := mod.(interface{ Done() <-chan struct{} }); ok {
+			go h.releaseOnClose(mod, table, m.Done())
+		}
+	}
+	handle, ok := table.Insert(resp)
+	if !ok {
+		_ = resp.Body.Close()
+		return experimentalsys.EBADF
+	}
+	if !mem.WriteUint32Le(resultHandle, uint32(handle)) {
+		table.Delete(handle)
+		_ = resp.Body.Close()
+		return experimentalsys.EFAULT
+	}
+	return 0
+}
+
+// lookup returns the response of `mod` at `handle`.
+func (h *httpClient) lookup(mod api.Module, handle uint64) (*http.Response, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if table, ok := h.responses[mod]; ok {
+		return table.Lookup(int32(handle))
+	}
+	return nil, false
+}
+
+func (h *httpClient) status(_ context.Context, mod api.Module, params []uint64) experimentalsys.Errno {
+	resp, ok := h.lookup(mod, params[0])
+	if !ok {
+		return experimentalsys.EBADF
+	}
+	if !mod.Memory().WriteUint32Le(uint32(params[1]), uint32(resp.StatusCode)) {
+		return experimentalsys.EFAULT
+	}
+	return 0
+}
+
+func (h *httpClient) header(_ context.Context, mod api.Module, params []uint64) experimentalsys.Errno {
+	resp, ok := h.lookup(mod, params[0])
+	if !ok {
+		return experimentalsys.EBADF
+	}
+	mem := mod.Memory()
+	name, ok := readString(mem, params[1], params[2])
+	if !ok {
+		return experimentalsys.EFAULT
+	}
+	buf, bufLen, resultLen := uint32(params[3]), uint32(params[4]), uint32(params[5])
+
+	values, ok := resp.Header[textproto.CanonicalMIMEHeaderKey(name)]
+	if !ok {
+		return experimentalsys.ENOENT
+	}
+	value := strings.Join(values, ", ")
+	if !mem.WriteUint32Le(resultLen, uint32(len(value))) {
+		return experimentalsys.EFAULT
+	} else if uint32(len(value)) > bufLen {
+		return experimentalsys.ERANGE
+	} else if !mem.WriteString(buf, value) {
+		return experimentalsys.EFAULT
+	}
+	return 0
+}
+
+func (h *httpClient) read(_ context.Context, mod api.Module, params []uint64) experimentalsys.Errno {
+	resp, ok := h.lookup(mod, params[0])
+	if !ok {
+		return experimentalsys.EBADF
+	}
+	mem := mod.Memory()
+	buf, ok := mem.Read(uint32(params[1]), uint32(params[2]))
+	if !ok {
+		return experimentalsys.EFAULT
+	}
+
+	n, err := io.ReadFull(resp.Body, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return experimentalsys.EIO
+	}
+	if !mem.WriteUint32Le(uint32(params[3]), uint32(n)) {
+		return experimentalsys.EFAULT
+	}
+	return 0
+}
+
+func (h *httpClient) close(_ context.Context, mod api.Module, params []uint64) experimentalsys.Errno {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	table, ok := h.responses[mod]
+	if !ok {
+		return experimentalsys.EBADF
+	}
+	handle := int32(params[0])
+	resp, ok := table.Lookup(handle)
+	if !ok {
+		return experimentalsys.EBADF
+	}
+	table.Delete(handle)
+	if table.Len() == 0 {
+		delete(h.responses, mod)
+		close(table.released)
+	}
+	return experimentalsys.UnwrapOSError(resp.Body.Close())
+}
+
+// releaseOnClose closes the responses of `mod` left open when it's closed,
+// unless they are released before.
+func (h *httpClient) releaseOnClose(mod api.Module, table *guestResponses, done <-chan struct{}) {
+	select {
+	case <-done:
+	case <-table.released:
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.responses[mod] == table {
+		delete(h.responses, mod)
+		table.closeAll()
+	}
+}
+
+// closeAll closes the responses of all guests.
+func (h *httpClient) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, table := range h.responses {
+		table.closeAll()
+	}
+	h.responses = map[api.Module]*guestResponses{}
+}
+
+// closer closes any open responses with the module.
+type closer struct {
+	api.Closer
+	h *httpClient
+}
+
+// Close implements api.Closer
+func (c *closer) Close(ctx context.Context) error {
+	c.h.closeAll()
+	return c.Closer.Close(ctx)
+}
+
+func readString(mem api.Memory, offset, byteCount uint64) (string, bool) {
+	b, ok := mem.Read(uint32(offset), uint32(byteCount))
+	if !ok {
+		return "", false
+	}
+	return string(b), true
+}
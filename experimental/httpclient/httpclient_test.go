@@ -0,0 +1,156 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/testing/proxy"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasip1"
+)
+
+// testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
+var testCtx = context.WithValue(context.Background(), struct{}{}, "arbitrary")
+
+func TestHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Add("X-Echo", r.Header.Get("X-Test"))
+		w.Header().Add("X-Echo", r.Method)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	mod, h := requireProxyModule(t, NewBuilder(nil).WithAllowedHosts("127.0.0.1"))
+
+	handle := requireRequest(t, mod, wasip1.ErrnoSuccess, "POST", server.URL, "X-Test: wazero\n", "hello")
+	require.Equal(t, uint32(0), handle)
+
+	requireErrno(t, wasip1.ErrnoSuccess, mod, "status", uint64(handle), 0)
+	status, _ := mod.Memory().ReadUint32Le(0)
+	require.Equal(t, uint32(http.StatusCreated), status)
+
+	// A buffer which is too small returns the length needed.
+	mod.Memory().WriteString(100, "x-echo")
+	requireErrno(t, wasip1.ErrnoRange, mod, "header", uint64(handle), 100, 6, 200, 4, 0)
+	n, _ := mod.Memory().ReadUint32Le(0)
+	require.Equal(t, uint32(len("wazero, POST")), n)
+	requireErrno(t, wasip1.ErrnoSuccess, mod, "header", uint64(handle), 100, 6, 200, uint64(n), 0)
+	value, _ := mod.Memory().Read(200, n)
+	require.Equal(t, "wazero, POST", string(value))
+
+	mod.Memory().WriteString(100, "x-none")
+	requireErrno(t, wasip1.ErrnoNoent, mod, "header", uint64(handle), 100, 6, 200, 16, 0)
+
+	// Read the body until there's nothing left.
+	var body []byte
+	for {
+		requireErrno(t, wasip1.ErrnoSuccess, mod, "read", uint64(handle), 200, 2, 0)
+		n, _ = mod.Memory().ReadUint32Le(0)
+		if n == 0 {
+			break
+		}
+		b, _ := mod.Memory().Read(200, n)
+		body = append(body, b...)
+	}
+	require.Equal(t, "hello", string(body))
+
+	requireErrno(t, wasip1.ErrnoSuccess, mod, "close", uint64(handle))
+	requireErrno(t, wasip1.ErrnoBadf, mod, "close", uint64(handle))
+	requireErrno(t, wasip1.ErrnoBadf, mod, "status", uint64(handle), 0)
+	require.Equal(t, 0, len(h.responses))
+}
+
+func TestHTTPClient_guestClosed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	mod, h := requireProxyModule(t, NewBuilder(nil).WithAllowedHosts("127.0.0.1"))
+
+	// Leave a response open, then close the guest.
+	handle := requireRequest(t, mod, wasip1.ErrnoSuccess, "GET", server.URL, "", "")
+	resp, ok := h.lookup(mod, uint64(handle))
+	require.True(t, ok)
+	require.NoError(t, mod.Close(testCtx))
+
+	// The response is released in the background.
+	for {
+		h.mu.Lock()
+		n := len(h.responses)
+		h.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		runtime.Gosched()
+	}
+	_, err := resp.Body.Read(make([]byte, 1))
+	require.Error(t, err)
+}
+
+func TestHTTPClient_denied(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://localhost/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	mod, _ := requireProxyModule(t, NewBuilder(nil).WithAllowedHosts("127.0.0.1").WithAllowedMethods("GET"))
+
+	// Redirects to hosts which aren't allowed are denied.
+	requireRequest(t, mod, wasip1.ErrnoAcces, "GET", server.URL, "", "")
+	requireRequest(t, mod, wasip1.ErrnoAcces, "DELETE", server.URL, "", "")
+	requireRequest(t, mod, wasip1.ErrnoAcces, "GET", "http://localhost/", "", "")
+	requireRequest(t, mod, wasip1.ErrnoInval, "GET", "file:///etc/passwd", "", "")
+	requireRequest(t, mod, wasip1.ErrnoInval, "GET", server.URL, "invalid header", "")
+}
+
+// requireProxyModule returns a guest which exports the functions of the
+// module configured by `b`, and its state.
+func requireProxyModule(t *testing.T, b Builder) (api.Module, *httpClient) {
+	r := wazero.NewRuntime(testCtx)
+	t.Cleanup(func() { _ = r.Close(testCtx) })
+
+	bld := b.(*builder)
+	bld.r = r
+	h, hostModule := bld.hostModuleBuilder()
+	compiled, err := hostModule.Compile(testCtx)
+	require.NoError(t, err)
+	_, err = r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig())
+	require.NoError(t, err)
+
+	proxyCompiled, err := r.CompileModule(testCtx, proxy.NewModuleBinary(ModuleName, compiled))
+	require.NoError(t, err)
+	mod, err := r.InstantiateModule(testCtx, proxyCompiled, wazero.NewModuleConfig())
+	require.NoError(t, err)
+	return mod, h
+}
+
+// requireRequest calls "request", and returns the handle on success.
+func requireRequest(t *testing.T, mod api.Module, expectedErrno wasip1.Errno, method, url, headers, body string) uint32 {
+	mem := mod.Memory()
+	var offset uint32 = 1024
+	var params []uint64
+	for _, s := range []string{method, url, headers, body} {
+		require.True(t, mem.WriteString(offset, s))
+		params = append(params, uint64(offset), uint64(len(s)))
+		offset += uint32(len(s))
+	}
+	requireErrno(t, expectedErrno, mod, "request", append(params, 0)...)
+	handle, _ := mem.ReadUint32Le(0)
+	return handle
+}
+
+func requireErrno(t *testing.T, expectedErrno wasip1.Errno, mod api.Module, funcName string, params ...uint64) {
+	results, err := mod.ExportedFunction(funcName).Call(testCtx, params...)
+	require.NoError(t, err)
+	errno := wasip1.Errno(results[0])
+	require.Equal(t, expectedErrno, errno, "want %s but have %s", wasip1.ErrnoName(expectedErrno), wasip1.ErrnoName(errno))
+}
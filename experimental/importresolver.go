@@ -0,0 +1,37 @@
+package experimental
+
+import (
+	"context"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/importresolver"
+)
+
+// ImportResolver returns the module to resolve the imports of `name` to, or
+// nil to fall back to the module instantiated with that name.
+//
+// The module must have been instantiated in the same wazero.Runtime, and not
+// closed. It needn't be named `name`, e.g. to satisfy imports of "env" with
+// a module instantiated as "env-for-test".
+type ImportResolver func(name string) api.Module
+
+// WithImportResolver registers the given ImportResolver into the given
+// context.Context, which changes how the imports of modules instantiated with
+// this context are resolved.
+//
+// Here's an example of instantiating a module with a mock of its "env"
+// imports, which are otherwise satisfied by the module named "env":
+//
+//	ctx = experimental.WithImportResolver(ctx, func(name string) api.Module {
+//		if name == "env" {
+//			return mockEnv
+//		}
+//		return nil
+//	})
+//	mod, _ := r.InstantiateModule(ctx, compiled, config)
+func WithImportResolver(ctx context.Context, resolver ImportResolver) context.Context {
+	if resolver != nil {
+		return context.WithValue(ctx, importresolver.Key{}, resolver)
+	}
+	return ctx
+}
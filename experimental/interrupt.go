@@ -0,0 +1,32 @@
+//go:build !plan9
+
+package experimental
+
+import (
+	"context"
+	"syscall"
+
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
+)
+
+// WithInterrupt returns a copy of the given context.Context, and a function
+// which cancels it as if the guest received a signal. For example, a CLI can
+// call it with syscall.SIGINT on Ctrl-C to stop the guest, instead of exiting
+// the host process.
+//
+// Functions called with the returned context fail with a sys.ExitError whose
+// exit code is 128+sig, the same as a shell reports a process killed by a
+// signal. Only the first signal is recorded.
+//
+// Note: This requires wazero.RuntimeConfig WithCloseOnContextDone to stop a
+// running function. The guest can't handle the signal, as WASI has no way to
+// deliver one. This is unavailable on plan9, which has notes instead of
+// signals.
+func WithInterrupt(ctx context.Context) (context.Context, func(sig syscall.Signal)) {
+	i := &internalsys.Interrupt{}
+	ctx, cancel := context.WithCancel(context.WithValue(ctx, internalsys.InterruptKey{}, i))
+	return ctx, func(sig syscall.Signal) {
+		i.Raise(uint32(sig))
+		cancel()
+	}
+}
@@ -0,0 +1,40 @@
+//go:build !plan9
+
+package experimental_test
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/binaryencoding"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+func TestWithInterrupt(t *testing.T) {
+	r := wazero.NewRuntimeWithConfig(testCtx, wazero.NewRuntimeConfig().WithCloseOnContextDone(true))
+	defer r.Close(testCtx)
+
+	bin := binaryencoding.EncodeModule(&wasm.Module{
+		TypeSection:     []wasm.FunctionType{{}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []wasm.Code{{Body: []byte{
+			wasm.OpcodeLoop, 0x40, wasm.OpcodeBr, 0, wasm.OpcodeEnd, wasm.OpcodeEnd,
+		}}},
+		ExportSection: []wasm.Export{{Name: "infinite_loop", Type: wasm.ExternTypeFunc, Index: 0}},
+	})
+	mod, err := r.Instantiate(testCtx, bin)
+	require.NoError(t, err)
+
+	ctx, interrupt := experimental.WithInterrupt(testCtx)
+	go func() {
+		interrupt(syscall.SIGINT)
+		interrupt(syscall.SIGTERM) // ignored, as SIGINT was first.
+	}()
+
+	_, err = mod.ExportedFunction("infinite_loop").Call(ctx)
+	require.Equal(t, sys.NewExitError(128+uint32(syscall.SIGINT)), err)
+}
@@ -0,0 +1,165 @@
+// Package linker instantiates modules which import each other, in the order
+// their imports require.
+//
+// A Linker defines modules by name, then instantiates any defined module
+// another imports before it, once:
+//
+//	l := linker.New(r).
+//		Define("env", env).
+//		Define("libc", libc). // imports "env"
+//		Define("libm", libm)  // imports "env" and "libc"
+//	mod, err := l.Instantiate(ctx, app, wazero.NewModuleConfig()) // imports "libc" and "libm"
+//
+// Memories and tables are shared like functions: each module importing the
+// memory of "env" above imports the one of its only instance.
+//
+// # Notes
+//
+//   - This is experimental, and likely to change.
+//   - Imports of modules which aren't defined resolve to the modules
+//     instantiated in the wazero.Runtime with that name, e.g. WASI.
+//   - A Linker isn't safe for concurrent use.
+package linker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+)
+
+// Linker defines the modules which other modules import by name, and
+// instantiates them in a wazero.Runtime.
+type Linker struct {
+	r           wazero.Runtime
+	definitions map[string]*definition
+}
+
+// definition is a module defined in a Linker.
+type definition struct {
+	compiled wazero.CompiledModule
+	config   wazero.ModuleConfig
+	// mod is the instance of compiled, once instantiated, or the module
+	// defined with DefineModule.
+	mod api.Module
+}
+
+// New returns a Linker instantiating modules in `r`.
+func New(r wazero.Runtime) *Linker {
+	return &Linker{r: r, definitions: map[string]*definition{}}
+}
+
+// Define defines `compiled` as the module imported as `name`, replacing any
+// previous definition of `name`. It is instantiated with that name when
+// first imported, e.g. by a module instantiated with Instantiate.
+//
+// `compiled` can be a host module, compiled with wazero.HostModuleBuilder.
+func (l *Linker) Define(name string, compiled wazero.CompiledModule) *Linker {
+	return l.DefineWithConfig(name, compiled, wazero.NewModuleConfig())
+}
+
+// DefineWithConfig is like Define, but instantiates `compiled` with `config`,
+// e.g. to configure its stdout. Its name is replaced by `name`.
+func (l *Linker) DefineWithConfig(name string, compiled wazero.CompiledModule, config wazero.ModuleConfig) *Linker {
+	l.definitions[name] = &definition{compiled: compiled, config: config.WithName(name)}
+	return l
+}
+
+// DefineModule defines the instantiated module `mod` as the module imported
+// as `name`, replacing any previous definition of `name`. `mod` needn't be
+// named `name`, but must have been instantiated in the same wazero.Runtime.
+func (l *Linker) DefineModule(name string, mod api.Module) *Linker {
+	l.definitions[name] = &definition{mod: mod}
+	return l
+}
+
+// Override returns a copy of this Linker, where `name` is defined as the
+// instantiated module `mod`, e.g. to link a module with a mock of one of its
+// imports:
+//
+//	mod, err := l.Override("env", mockEnv).Instantiate(ctx, compiled, config)
+//
+// The copy shares the definitions of this Linker, except `name`, including
+// the modules it instantiates for them. Define them in the copy for it to
+// instantiate its own.
+func (l *Linker) Override(name string, mod api.Module) *Linker {
+	definitions := make(map[string]*definition, len(l.definitions)+1)
+	for n, d := range l.definitions {
+		definitions[n] = d
+	}
+	definitions[name] = &definition{mod: mod}
+	return &Linker{r: l.r, definitions: definitions}
+}
+
+// Instantiate instantiates `compiled` with `config`, after any defined module
+// it imports, directly or not, which isn't instantiated yet.
+//
+// This errs if the defined modules import each other in a cycle, which
+// can't be instantiated, or if any fails to instantiate.
+func (l *Linker) Instantiate(ctx context.Context, compiled wazero.CompiledModule, config wazero.ModuleConfig) (api.Module, error) {
+	if err := l.link(ctx, compiled, nil); err != nil {
+		return nil, err
+	}
+	return l.r.InstantiateModule(experimental.WithImportResolver(ctx, l.resolve), compiled, config)
+}
+
+// Module returns the module defined as `name`, instantiating it like
+// Instantiate if it isn't yet, or nil if `name` isn't defined.
+func (l *Linker) Module(ctx context.Context, name string) (api.Module, error) {
+	return l.instantiate(ctx, name, nil)
+}
+
+// link instantiates the defined modules `compiled` imports, where `path` are
+// the names of the defined modules which import it, in order.
+func (l *Linker) link(ctx context.Context, compiled wazero.CompiledModule, path []string) error {
+	linked := map[string]struct{}{}
+	for _, imp := range compiled.Imports() {
+		name := imp.ModuleName()
+		if _, ok := linked[name]; ok {
+			continue
+		}
+		linked[name] = struct{}{}
+		if _, err := l.instantiate(ctx, name, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// instantiate returns the module defined as `name`, instantiating it if it
+// isn't yet, or nil if `name` isn't defined.
+func (l *Linker) instantiate(ctx context.Context, name string, path []string) (api.Module, error) {
+	d, ok := l.definitions[name]
+	if !ok {
+		return nil, nil // resolved by the runtime
+	} else if d.compiled == nil || (d.mod != nil && !d.mod.IsClosed()) {
+		return d.mod, nil
+	}
+
+	for i, p := range path {
+		if p == name {
+			return nil, fmt.Errorf("import cycle: %s", strings.Join(append(path[i:], name), " -> "))
+		}
+	}
+	if err := l.link(ctx, d.compiled, append(path, name)); err != nil {
+		return nil, err
+	}
+
+	mod, err := l.r.InstantiateModule(experimental.WithImportResolver(ctx, l.resolve), d.compiled, d.config)
+	if err != nil {
+		return nil, fmt.Errorf("module[%s]: %w", name, err)
+	}
+	d.mod = mod
+	return mod, nil
+}
+
+// resolve implements experimental.ImportResolver
+func (l *Linker) resolve(name string) api.Module {
+	if d, ok := l.definitions[name]; ok {
+		return d.mod
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package linker_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental/linker"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
+var testCtx = context.WithValue(context.Background(), struct{}{}, "arbitrary")
+
+const (
+	// memWat exports a memory and a table.
+	memWat = `(module
+  (memory (export "memory") 1)
+  (table (export "table") 1 funcref))`
+
+	// storeWat stores the value it is passed into the memory of "mem".
+	storeWat = `(module
+  (import "mem" "memory" (memory 1))
+  (func (export "store") (param i32) (i32.store (i32.const 0) (local.get 0))))`
+
+	// loadWat loads the value stored by "store" from the memory of "mem",
+	// after calling "store" with the value "env" returns.
+	loadWat = `(module
+  (import "env" "value" (func $value (result i32)))
+  (import "store" "store" (func $store (param i32)))
+  (import "mem" "memory" (memory 1))
+  (import "mem" "table" (table 1 funcref))
+  (func (export "load") (result i32)
+    (call $store (call $value))
+    (i32.load (i32.const 0))))`
+)
+
+func TestLinker_Instantiate(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	l := linker.New(r).
+		Define("mem", compile(t, r, memWat)).
+		Define("store", compile(t, r, storeWat)).
+		Define("env", env(t, r, 42))
+
+	load := compile(t, r, loadWat)
+	mod, err := l.Instantiate(testCtx, load, wazero.NewModuleConfig())
+	require.NoError(t, err)
+	require.Equal(t, []uint64{42}, call(t, mod, "load"))
+
+	// Dependencies are instantiated once, with the name they are defined as.
+	mem := r.Module("mem")
+	require.NotNil(t, mem)
+	v, ok := mem.Memory().ReadUint32Le(0)
+	require.True(t, ok)
+	require.Equal(t, uint32(42), v)
+
+	mod2, err := l.Instantiate(testCtx, load, wazero.NewModuleConfig().WithName("load2"))
+	require.NoError(t, err)
+	require.Equal(t, []uint64{42}, call(t, mod2, "load"))
+
+	m, err := l.Module(testCtx, "mem")
+	require.NoError(t, err)
+	require.Equal(t, mem, m)
+
+	m, err = l.Module(testCtx, "undefined")
+	require.NoError(t, err)
+	require.Nil(t, m)
+}
+
+func TestLinker_Instantiate_Runtime(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	// "env" isn't defined, so resolves to the module instantiated as "env".
+	_, err := r.InstantiateModule(testCtx, env(t, r, 7), wazero.NewModuleConfig())
+	require.NoError(t, err)
+
+	l := linker.New(r).
+		Define("mem", compile(t, r, memWat)).
+		Define("store", compile(t, r, storeWat))
+
+	mod, err := l.Instantiate(testCtx, compile(t, r, loadWat), wazero.NewModuleConfig())
+	require.NoError(t, err)
+	require.Equal(t, []uint64{7}, call(t, mod, "load"))
+}
+
+func TestLinker_DefineModule(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	mem, err := r.InstantiateModule(testCtx, compile(t, r, memWat), wazero.NewModuleConfig().WithName("shared"))
+	require.NoError(t, err)
+
+	l := linker.New(r).
+		DefineModule("mem", mem).
+		Define("store", compile(t, r, storeWat)).
+		Define("env", env(t, r, 3))
+
+	mod, err := l.Instantiate(testCtx, compile(t, r, loadWat), wazero.NewModuleConfig())
+	require.NoError(t, err)
+	require.Equal(t, []uint64{3}, call(t, mod, "load"))
+
+	v, ok := mem.Memory().ReadUint32Le(0)
+	require.True(t, ok)
+	require.Equal(t, uint32(3), v)
+}
+
+func TestLinker_Override(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	l := linker.New(r).
+		Define("mem", compile(t, r, memWat)).
+		Define("store", compile(t, r, storeWat)).
+		Define("env", env(t, r, 1))
+
+	mock, err := r.InstantiateModule(testCtx, env(t, r, 2), wazero.NewModuleConfig().WithName("mock"))
+	require.NoError(t, err)
+
+	load := compile(t, r, loadWat)
+	mod, err := l.Override("env", mock).Instantiate(testCtx, load, wazero.NewModuleConfig().WithName("mocked"))
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2}, call(t, mod, "load"))
+
+	// The override doesn't apply to the original linker.
+	mod, err = l.Instantiate(testCtx, load, wazero.NewModuleConfig())
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1}, call(t, mod, "load"))
+}
+
+func TestLinker_Instantiate_Closed(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	l := linker.New(r).
+		Define("mem", compile(t, r, memWat)).
+		Define("store", compile(t, r, storeWat)).
+		Define("env", env(t, r, 5))
+
+	load := compile(t, r, loadWat)
+	mod, err := l.Instantiate(testCtx, load, wazero.NewModuleConfig())
+	require.NoError(t, err)
+	require.NoError(t, mod.Close(testCtx))
+	require.NoError(t, r.Module("store").Close(testCtx))
+
+	// The closed dependency is instantiated again.
+	mod, err = l.Instantiate(testCtx, load, wazero.NewModuleConfig())
+	require.NoError(t, err)
+	require.Equal(t, []uint64{5}, call(t, mod, "load"))
+}
+
+func TestLinker_Instantiate_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		define      func(t *testing.T, r wazero.Runtime, l *linker.Linker)
+		expectedErr string
+	}{
+		{
+			name: "import cycle",
+			define: func(t *testing.T, r wazero.Runtime, l *linker.Linker) {
+				l.Define("env", compile(t, r, `(module
+  (import "store" "store" (func (param i32)))
+  (func (export "value") (result i32) (i32.const 1)))`)).
+					Define("mem", compile(t, r, `(module
+  (import "env" "value" (func (result i32)))
+  (memory (export "memory") 1)
+  (table (export "table") 1 funcref))`)).
+					Define("store", compile(t, r, storeWat))
+			},
+			expectedErr: "import cycle: env -> store -> mem -> env",
+		},
+		{
+			name: "dependency fails to instantiate",
+			define: func(t *testing.T, r wazero.Runtime, l *linker.Linker) {
+				l.Define("env", env(t, r, 1)).
+					Define("mem", compile(t, r, `(module (memory (export "memory") 1))`)).
+					Define("store", compile(t, r, storeWat))
+			},
+			expectedErr: `"table" is not exported in module "mem"`,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			r := wazero.NewRuntime(testCtx)
+			defer r.Close(testCtx)
+
+			l := linker.New(r)
+			tc.define(t, r, l)
+
+			_, err := l.Instantiate(testCtx, compile(t, r, loadWat), wazero.NewModuleConfig())
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.expectedErr)
+		})
+	}
+}
+
+func compile(t *testing.T, r wazero.Runtime, wat string) wazero.CompiledModule {
+	compiled, err := r.CompileModule(testCtx, []byte(wat))
+	require.NoError(t, err)
+	return compiled
+}
+
+// env compiles a host module exporting "value", which returns `v`.
+func env(t *testing.T, r wazero.Runtime, v uint32) wazero.CompiledModule {
+	compiled, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().WithFunc(func() uint32 { return v }).Export("value").
+		Compile(testCtx)
+	require.NoError(t, err)
+	return compiled
+}
+
+func call(t *testing.T, mod api.Module, name string) []uint64 {
+	results, err := mod.ExportedFunction(name).Call(testCtx)
+	require.NoError(t, err)
+	return results
+}
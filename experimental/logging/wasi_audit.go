@@ -0,0 +1,137 @@
+package logging
+
+import (
+	"context"
+	"strings"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/logging"
+	"github.com/tetratelabs/wazero/internal/wasip1"
+	wasilogging "github.com/tetratelabs/wazero/internal/wasip1/logging"
+)
+
+// WASICall is a call to a function in the "wasi_snapshot_preview1" module, as
+// reported by NewWASIAuditListenerFactory.
+type WASICall struct {
+	// Module is the name of the calling module.
+	Module string
+
+	// Function is the name of the function called. e.g. "path_open"
+	Function string
+
+	// Params are the decoded parameters, formatted the same as
+	// NewHostLoggingListenerFactory. e.g. "fd=3" or "path=foo.txt"
+	Params []string
+
+	// Results are the decoded values written to memory on success, excluding
+	// the errno. e.g. "opened_fd=4"
+	Results []string
+
+	// Errno is the name of the result. e.g. "ESUCCESS" or "ENOENT". This is
+	// empty when the function doesn't return one, e.g. "proc_exit", or it
+	// didn't return at all.
+	Errno string
+
+	// Err is non-nil when the call didn't return, such as a *sys.ExitError
+	// from "proc_exit".
+	Err error
+}
+
+// NewWASIAuditListenerFactory is an experimental.FunctionListenerFactory that
+// calls `audit` after each call to a function in the "wasi_snapshot_preview1"
+// module, with its decoded arguments and errno. Unlike
+// NewHostLoggingListenerFactory, this includes reads and writes to the
+// console, so is suitable for security auditing or debugging guest I/O.
+//
+// Parameters are decoded before the call, so paths are those the guest passed
+// even if the call fails. Other functions are not listened to.
+//
+// Note: Like NewLoggingListenerFactory, calls are tracked on a stack shared
+// by all modules compiled with this factory, so modules using it must not be
+// called concurrently.
+func NewWASIAuditListenerFactory(audit func(ctx context.Context, call *WASICall)) experimental.FunctionListenerFactory {
+	return &wasiAuditListenerFactory{audit: audit}
+}
+
+type wasiAuditListenerFactory struct {
+	audit func(ctx context.Context, call *WASICall)
+	stack []wasiAuditFrame
+}
+
+// wasiAuditFrame is a call in progress.
+type wasiAuditFrame struct {
+	call *WASICall
+	// params are kept, as result loggers read memory at offsets in them.
+	params []uint64
+}
+
+func (f *wasiAuditListenerFactory) push(call *WASICall, params []uint64) {
+	f.stack = append(f.stack, wasiAuditFrame{call: call, params: params})
+}
+
+func (f *wasiAuditListenerFactory) pop() wasiAuditFrame {
+	i := len(f.stack) - 1
+	frame := f.stack[i]
+	f.stack[i] = wasiAuditFrame{}
+	f.stack = f.stack[:i]
+	return frame
+}
+
+// NewFunctionListener implements the same method as documented on
+// experimental.FunctionListener.
+func (f *wasiAuditListenerFactory) NewFunctionListener(fnd api.FunctionDefinition) experimental.FunctionListener {
+	if fnd.ModuleName() != wasip1.InternalModuleName || fnd.GoFunction() == nil {
+		return nil
+	}
+	// The sampler is ignored, as it only excludes console I/O from logs.
+	_, pLoggers, rLoggers := wasilogging.Config(fnd)
+	return &wasiAuditListener{f: f, pLoggers: pLoggers, rLoggers: rLoggers}
+}
+
+// wasiAuditListener implements experimental.FunctionListener to report each
+// call to a WASI function.
+type wasiAuditListener struct {
+	f        *wasiAuditListenerFactory
+	pLoggers []logging.ParamLogger
+	rLoggers []logging.ResultLogger
+}
+
+// Before implements the same method as documented on
+// experimental.FunctionListener.
+func (l *wasiAuditListener) Before(ctx context.Context, mod api.Module, def api.FunctionDefinition, params []uint64, _ experimental.StackIterator) {
+	call := &WASICall{Module: mod.Name(), Function: def.Name()}
+	var b strings.Builder
+	for _, pLogger := range l.pLoggers {
+		pLogger(ctx, mod, &b, params)
+		call.Params = append(call.Params, b.String())
+		b.Reset()
+	}
+	l.f.push(call, append([]uint64{}, params...))
+}
+
+// After implements the same method as documented on
+// experimental.FunctionListener.
+func (l *wasiAuditListener) After(ctx context.Context, mod api.Module, _ api.FunctionDefinition, results []uint64) {
+	frame := l.f.pop()
+	call := frame.call
+	var b strings.Builder
+	for _, rLogger := range l.rLoggers {
+		rLogger(ctx, mod, &b, frame.params, results)
+		if s := b.String(); strings.HasPrefix(s, "errno=") {
+			call.Errno = s[len("errno="):]
+		} else {
+			call.Results = append(call.Results, s)
+		}
+		b.Reset()
+	}
+	l.f.audit(ctx, call)
+}
+
+// Abort implements the same method as documented on
+// experimental.FunctionListener.
+func (l *wasiAuditListener) Abort(ctx context.Context, _ api.Module, _ api.FunctionDefinition, err error) {
+	call := l.f.pop().call
+	call.Err = err
+	l.f.audit(ctx, call)
+}
@@ -0,0 +1,84 @@
+package logging_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/experimental/logging"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/internal/testing/proxy"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+func TestNewWASIAuditListenerFactory(t *testing.T) {
+	var calls []*logging.WASICall
+	ctx := context.WithValue(testCtx, experimental.FunctionListenerFactoryKey{},
+		logging.NewWASIAuditListenerFactory(func(_ context.Context, call *logging.WASICall) {
+			calls = append(calls, call)
+		}))
+
+	r := wazero.NewRuntime(ctx)
+	defer r.Close(ctx)
+
+	config := wazero.NewModuleConfig().
+		WithFS(fstest.MapFS{"a.txt": &fstest.MapFile{Data: []byte("hello")}})
+	compiled, err := wasi_snapshot_preview1.NewBuilder(r).Compile(ctx)
+	require.NoError(t, err)
+	_, err = r.InstantiateModule(ctx, compiled, config)
+	require.NoError(t, err)
+
+	proxyCompiled, err := r.CompileModule(ctx, proxy.NewModuleBinary(wasi_snapshot_preview1.ModuleName, compiled))
+	require.NoError(t, err)
+	mod, err := r.InstantiateModule(ctx, proxyCompiled, config.WithName("guest"))
+	require.NoError(t, err)
+
+	pathOpen := mod.ExportedFunction("path_open")
+	mod.Memory().WriteString(0, "a.txtmissing")
+	_, err = pathOpen.Call(ctx, 3, 0, 0, 5, 0, 2, 0, 0, 100)
+	require.NoError(t, err)
+	_, err = pathOpen.Call(ctx, 3, 0, 5, 7, 0, 2, 0, 0, 100)
+	require.NoError(t, err)
+
+	// Writes to the console are included, unlike the logging listener.
+	mod.Memory().WriteUint32Le(200, 0)
+	mod.Memory().WriteUint32Le(204, 0)
+	_, err = mod.ExportedFunction("fd_write").Call(ctx, 1, 200, 1, 300)
+	require.NoError(t, err)
+
+	_, err = mod.ExportedFunction("proc_exit").Call(ctx, 2)
+	require.Equal(t, uint32(2), err.(*sys.ExitError).ExitCode())
+
+	require.Equal(t, []*logging.WASICall{
+		{
+			Module:   "guest",
+			Function: "path_open",
+			Params:   []string{"fd=3", "dirflags=", "path=a.txt", "oflags=", "fs_rights_base=FD_READ", "fs_rights_inheriting=", "fdflags="},
+			Results:  []string{"opened_fd=4"},
+			Errno:    "ESUCCESS",
+		},
+		{
+			Module:   "guest",
+			Function: "path_open",
+			Params:   []string{"fd=3", "dirflags=", "path=missing", "oflags=", "fs_rights_base=FD_READ", "fs_rights_inheriting=", "fdflags="},
+			Results:  []string{"opened_fd="},
+			Errno:    "ENOENT",
+		},
+		{
+			Module:   "guest",
+			Function: "fd_write",
+			Params:   []string{"fd=1", "iovs=200", "iovs_len=1"},
+			Results:  []string{"nwritten=0"},
+			Errno:    "ESUCCESS",
+		},
+		{
+			Module:   "guest",
+			Function: "proc_exit",
+			Params:   []string{"rval=2"},
+			Err:      err,
+		},
+	}, calls)
+}
@@ -0,0 +1,64 @@
+package experimental
+
+import (
+	"context"
+
+	"github.com/tetratelabs/wazero/internal/memalloc"
+)
+
+// MemoryAllocator allocates the linear memory of modules, instead of the Go
+// heap. For example, it can use huge pages, memory pinned to a NUMA node, or
+// a pre-reserved arena shared by thousands of instances.
+type MemoryAllocator interface {
+	// Allocate returns a new LinearMemory, which should reserve `cap` bytes
+	// and grow up to `max` bytes. `cap` is a hint: it is the min of the
+	// memory, or its max with wazero.RuntimeConfig WithMemoryCapacityFromMax.
+	Allocate(cap, max uint64) LinearMemory
+}
+
+// MemoryAllocatorFunc is a convenience for defining a MemoryAllocator inline.
+type MemoryAllocatorFunc func(cap, max uint64) LinearMemory
+
+// Allocate implements MemoryAllocator.Allocate.
+func (f MemoryAllocatorFunc) Allocate(cap, max uint64) LinearMemory {
+	return f(cap, max)
+}
+
+// LinearMemory is the buffer of a linear memory from a MemoryAllocator.
+type LinearMemory interface {
+	// Reallocate returns a buffer of `size` bytes, with the contents of the
+	// previous buffer followed by zeros, or nil if it can't grow. It is first
+	// called with the min size of the memory, and `size` never decreases.
+	//
+	// The previous buffer is no longer used, so can be reused or released.
+	Reallocate(size uint64) []byte
+
+	// Free releases the memory when the module defining it is closed.
+	Free()
+}
+
+// WithMemoryAllocator registers the given MemoryAllocator into the given
+// context.Context. Modules instantiated with it by wazero.Runtime
+// InstantiateModule use it to allocate their memory. To use it for all
+// modules of a runtime, pass the context to wazero.NewRuntimeWithConfig.
+//
+// Here's an example:
+//
+//	ctx = experimental.WithMemoryAllocator(ctx, experimental.MemoryAllocatorFunc(
+//		func(cap, max uint64) experimental.LinearMemory {
+//			return arena.New(cap, max)
+//		}))
+//	mod, _ := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig())
+//
+// # Notes
+//
+//   - Shared memories, from the threads proposal, aren't allocated with the
+//     MemoryAllocator, as they can't be reallocated while other threads
+//     access them.
+//   - This takes precedence over wazero.RuntimeConfig WithMemoryMmap.
+func WithMemoryAllocator(ctx context.Context, allocator MemoryAllocator) context.Context {
+	if allocator == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, memalloc.AllocatorKey{}, allocator)
+}
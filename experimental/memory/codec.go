@@ -0,0 +1,191 @@
+package memory
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/leb128"
+)
+
+// The helpers below encode and decode common data types in memory, returning
+// false if out of range, instead of each embedder hand-rolling them. Numbers
+// are little-endian, like in WebAssembly.
+
+// ReadCString reads a NUL-terminated string at the offset, excluding the NUL,
+// or returns false if no NUL is found in the first maxLen bytes or the end of
+// memory.
+func ReadCString(mem api.Memory, offset, maxLen uint32) (string, bool) {
+	buf, ok := mem.Read(offset, available(mem, offset, maxLen))
+	if !ok {
+		return "", false
+	}
+	n := bytes.IndexByte(buf, 0)
+	if n < 0 {
+		return "", false
+	}
+	return string(buf[:n]), true
+}
+
+// WriteCString writes s followed by a NUL at the offset, or returns false if
+// out of range. This doesn't check s has no NUL itself.
+func WriteCString(mem api.Memory, offset uint32, s string) bool {
+	if uint64(offset)+uint64(len(s))+1 > uint64(mem.Size()) {
+		return false
+	}
+	return mem.WriteString(offset, s) && mem.WriteByte(offset+uint32(len(s)), 0)
+}
+
+// ReadPrefixedString reads a string at the offset, prefixed with its length
+// in bytes as a uint32, or returns false if out of range.
+func ReadPrefixedString(mem api.Memory, offset uint32) (string, bool) {
+	n, ok := mem.ReadUint32Le(offset)
+	if !ok {
+		return "", false
+	}
+	buf, ok := mem.Read(offset+4, n)
+	if !ok {
+		return "", false
+	}
+	return string(buf), true
+}
+
+// WritePrefixedString writes s at the offset, prefixed with its length in
+// bytes as a uint32, or returns false if out of range.
+func WritePrefixedString(mem api.Memory, offset uint32, s string) bool {
+	if uint64(offset)+4+uint64(len(s)) > uint64(mem.Size()) {
+		return false
+	}
+	return mem.WriteUint32Le(offset, uint32(len(s))) && mem.WriteString(offset+4, s)
+}
+
+// ReadUvarint reads an unsigned LEB128 value at the offset, and returns the
+// number of bytes read, or false if out of range or malformed.
+func ReadUvarint(mem api.Memory, offset uint32) (v uint64, n uint32, ok bool) {
+	buf, ok := mem.Read(offset, available(mem, offset, 10))
+	if !ok {
+		return 0, 0, false
+	}
+	v, read, err := leb128.LoadUint64(buf)
+	if err != nil {
+		return 0, 0, false
+	}
+	return v, uint32(read), true
+}
+
+// ReadVarint reads a signed LEB128 value at the offset, and returns the
+// number of bytes read, or false if out of range or malformed.
+func ReadVarint(mem api.Memory, offset uint32) (v int64, n uint32, ok bool) {
+	buf, ok := mem.Read(offset, available(mem, offset, 10))
+	if !ok {
+		return 0, 0, false
+	}
+	v, read, err := leb128.LoadInt64(buf)
+	if err != nil {
+		return 0, 0, false
+	}
+	return v, uint32(read), true
+}
+
+// WriteUvarint writes v as unsigned LEB128 at the offset, and returns the
+// number of bytes written, or false if out of range.
+func WriteUvarint(mem api.Memory, offset uint32, v uint64) (n uint32, ok bool) {
+	buf := leb128.EncodeUint64(v)
+	return uint32(len(buf)), mem.Write(offset, buf)
+}
+
+// WriteVarint writes v as signed LEB128 at the offset, and returns the number
+// of bytes written, or false if out of range.
+func WriteVarint(mem api.Memory, offset uint32, v int64) (n uint32, ok bool) {
+	buf := leb128.EncodeInt64(v)
+	return uint32(len(buf)), mem.Write(offset, buf)
+}
+
+// ReadValue decodes the memory at the offset into data, or returns false if
+// out of range. data must be a pointer to a fixed-size value or a slice of
+// fixed-size values, as defined by encoding/binary.
+//
+// Structs are packed, without padding between fields. For example:
+//
+//	var header struct {
+//		Magic   uint32
+//		Version uint16
+//		Flags   uint16
+//		Len     uint64
+//	}
+//	ok := memory.ReadValue(mem, offset, &header)
+//
+// Note: This panics if data isn't fixed-size, such as a struct with a string.
+func ReadValue(mem api.Memory, offset uint32, data interface{}) bool {
+	size := binarySize(data)
+	buf, ok := mem.Read(offset, size)
+	if !ok {
+		return false
+	}
+	if err := binary.Read(bytes.NewReader(buf), binary.LittleEndian, data); err != nil {
+		panic(err) // can't be short, as the size is checked.
+	}
+	return true
+}
+
+// WriteValue encodes data into the memory at the offset, or returns false if
+// out of range. data must be a fixed-size value, or a slice of fixed-size
+// values, or a pointer to one, as defined by encoding/binary. See ReadValue.
+//
+// Note: This panics if data isn't fixed-size, such as a struct with a string.
+func WriteValue(mem api.Memory, offset uint32, data interface{}) bool {
+	size := binarySize(data)
+	if uint64(offset)+uint64(size) > uint64(mem.Size()) {
+		return false
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, size))
+	if err := binary.Write(buf, binary.LittleEndian, data); err != nil {
+		panic(err)
+	}
+	return mem.Write(offset, buf.Bytes())
+}
+
+// Number is a numeric type supported by ReadSlice.
+type Number interface {
+	~int8 | ~uint8 | ~int16 | ~uint16 | ~int32 | ~uint32 | ~int64 | ~uint64 | ~float32 | ~float64
+}
+
+// ReadSlice reads `count` numbers at the offset into a new slice, or returns
+// false if out of range. For example, to read an array of 100 float64:
+//
+//	samples, ok := memory.ReadSlice[float64](mem, offset, 100)
+func ReadSlice[T Number](mem api.Memory, offset, count uint32) ([]T, bool) {
+	s := make([]T, count)
+	if !ReadValue(mem, offset, s) {
+		return nil, false
+	}
+	return s, true
+}
+
+// WriteSlice writes the numbers in s at the offset, or returns false if out
+// of range.
+func WriteSlice[T Number](mem api.Memory, offset uint32, s []T) bool {
+	return WriteValue(mem, offset, s)
+}
+
+// available returns the number of bytes in memory at the offset, up to max.
+func available(mem api.Memory, offset, max uint32) uint32 {
+	size := mem.Size()
+	if offset >= size {
+		return 0
+	}
+	if n := size - offset; n < max {
+		return n
+	}
+	return max
+}
+
+// binarySize returns the size of the encoding of data, or panics if it isn't
+// fixed-size.
+func binarySize(data interface{}) uint32 {
+	size := binary.Size(data)
+	if size < 0 {
+		panic("data isn't fixed-size")
+	}
+	return uint32(size)
+}
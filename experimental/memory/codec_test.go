@@ -0,0 +1,134 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental/memory"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func newMemory(t *testing.T) api.Memory {
+	r := wazero.NewRuntime(testCtx)
+	t.Cleanup(func() { r.Close(testCtx) })
+	mem, err := memory.New(testCtx, r, 1, 1)
+	require.NoError(t, err)
+	return mem
+}
+
+func TestCString(t *testing.T) {
+	mem := newMemory(t)
+
+	require.True(t, memory.WriteCString(mem, 10, "hello"))
+	buf, _ := mem.Read(10, 6)
+	require.Equal(t, "hello\x00", string(buf))
+
+	s, ok := memory.ReadCString(mem, 10, 100)
+	require.True(t, ok)
+	require.Equal(t, "hello", s)
+
+	_, ok = memory.ReadCString(mem, 10, 5) // NUL is past maxLen
+	require.False(t, ok)
+
+	require.True(t, mem.WriteByte(65535, 'a')) // no NUL before the end.
+	_, ok = memory.ReadCString(mem, 65535, 100)
+	require.False(t, ok)
+	_, ok = memory.ReadCString(mem, 65536, 100)
+	require.False(t, ok)
+
+	require.False(t, memory.WriteCString(mem, 65533, "abc"))
+	require.True(t, memory.WriteCString(mem, 65532, "abc"))
+}
+
+func TestPrefixedString(t *testing.T) {
+	mem := newMemory(t)
+
+	require.True(t, memory.WritePrefixedString(mem, 10, "hello"))
+	n, _ := mem.ReadUint32Le(10)
+	require.Equal(t, uint32(5), n)
+
+	s, ok := memory.ReadPrefixedString(mem, 10)
+	require.True(t, ok)
+	require.Equal(t, "hello", s)
+
+	require.True(t, mem.WriteUint32Le(20, 65536))
+	_, ok = memory.ReadPrefixedString(mem, 20)
+	require.False(t, ok)
+
+	require.False(t, memory.WritePrefixedString(mem, 65530, "abc"))
+}
+
+func TestVarint(t *testing.T) {
+	mem := newMemory(t)
+
+	n, ok := memory.WriteUvarint(mem, 0, 624485)
+	require.True(t, ok)
+	require.Equal(t, uint32(3), n)
+	buf, _ := mem.Read(0, 3)
+	require.Equal(t, []byte{0xe5, 0x8e, 0x26}, buf)
+
+	v, n, ok := memory.ReadUvarint(mem, 0)
+	require.True(t, ok)
+	require.Equal(t, uint64(624485), v)
+	require.Equal(t, uint32(3), n)
+
+	n, ok = memory.WriteVarint(mem, 10, -123456)
+	require.True(t, ok)
+	require.Equal(t, uint32(3), n)
+	sv, n, ok := memory.ReadVarint(mem, 10)
+	require.True(t, ok)
+	require.Equal(t, int64(-123456), sv)
+	require.Equal(t, uint32(3), n)
+
+	// A varint truncated by the end of memory.
+	require.True(t, mem.WriteByte(65535, 0x80))
+	_, _, ok = memory.ReadUvarint(mem, 65535)
+	require.False(t, ok)
+	_, ok = memory.WriteUvarint(mem, 65535, 128)
+	require.False(t, ok)
+}
+
+func TestValue(t *testing.T) {
+	mem := newMemory(t)
+
+	type header struct {
+		Magic   uint32
+		Version uint16
+		Flags   uint8
+		Len     uint64
+	}
+	in := header{Magic: 0xcafebabe, Version: 2, Flags: 1, Len: 1 << 40}
+	require.True(t, memory.WriteValue(mem, 100, &in))
+	buf, _ := mem.Read(100, 15) // packed
+	require.Equal(t, []byte{0xbe, 0xba, 0xfe, 0xca, 2, 0, 1, 0, 0, 0, 0, 0, 1, 0, 0}, buf)
+
+	var out header
+	require.True(t, memory.ReadValue(mem, 100, &out))
+	require.Equal(t, in, out)
+
+	require.False(t, memory.WriteValue(mem, 65530, in))
+	require.False(t, memory.ReadValue(mem, 65530, &out))
+
+	err := require.CapturePanic(func() {
+		memory.ReadValue(mem, 0, &struct{ s string }{})
+	})
+	require.EqualError(t, err, "data isn't fixed-size")
+}
+
+func TestSlice(t *testing.T) {
+	mem := newMemory(t)
+
+	require.True(t, memory.WriteSlice(mem, 8, []float64{1.5, -2}))
+	s, ok := memory.ReadSlice[float64](mem, 8, 2)
+	require.True(t, ok)
+	require.Equal(t, []float64{1.5, -2}, s)
+
+	u16, ok := memory.ReadSlice[uint16](mem, 8, 0)
+	require.True(t, ok)
+	require.Equal(t, 0, len(u16))
+
+	_, ok = memory.ReadSlice[uint32](mem, 65532, 2)
+	require.False(t, ok)
+	require.False(t, memory.WriteSlice(mem, 65532, []int32{1, 2}))
+}
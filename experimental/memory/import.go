@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/importresolver"
+)
+
+// New returns a new memory of `min` pages, which can grow up to `max` pages,
+// not exported by any module of the runtime. Use WithImport to import it into
+// modules.
+//
+// Note: The memory is released when the runtime is closed.
+func New(ctx context.Context, r wazero.Runtime, min, max uint32) (api.Memory, error) {
+	if min > max {
+		return nil, fmt.Errorf("min %d pages > max %d pages", min, max)
+	}
+	compiled, err := r.CompileModule(ctx, []byte(fmt.Sprintf(`(module (memory (export "memory") %d %d))`, min, max)))
+	if err != nil {
+		return nil, err
+	}
+	// The module is anonymous, so other modules can only import its memory
+	// with WithImport.
+	mod, err := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName(""))
+	if err != nil {
+		return nil, err
+	}
+	return mod.Memory(), nil
+}
+
+// WithImport returns a context, which makes modules instantiated with it by
+// wazero.Runtime InstantiateModule import the given memory as `name` from
+// `moduleName`, instead of the memory exported by the module of that name.
+//
+// The memory is from New, or exported by a module of the same runtime, such
+// as api.Module Memory. This allows several modules to share one heap, such as
+// a producer and a consumer module exchanging data without copying:
+//
+//	mem, _ := memory.New(ctx, r, 1, 100)
+//	ctx = memory.WithImport(ctx, "env", "memory", mem)
+//	producer, _ := r.InstantiateModule(ctx, producerWasm, wazero.NewModuleConfig())
+//	consumer, _ := r.InstantiateModule(ctx, consumerWasm, wazero.NewModuleConfig())
+//
+// Note: Other imports of `moduleName` are resolved as usual, so it doesn't
+// need to be instantiated if only the memory is imported from it.
+func WithImport(ctx context.Context, moduleName, name string, mem api.Memory) context.Context {
+	next, _ := ctx.Value(importresolver.MemoryKey{}).(*importresolver.MemoryImport)
+	return context.WithValue(ctx, importresolver.MemoryKey{}, &importresolver.MemoryImport{
+		Module: moduleName,
+		Name:   name,
+		Memory: mem,
+		Next:   next,
+	})
+}
@@ -0,0 +1,95 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental/memory"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestWithImport(t *testing.T) {
+	configs := map[string]wazero.RuntimeConfig{"interpreter": wazero.NewRuntimeConfigInterpreter()}
+	if platform.CompilerSupported() {
+		configs["compiler"] = wazero.NewRuntimeConfigCompiler()
+	}
+	for name, config := range configs {
+		config := config
+		t.Run(name, func(t *testing.T) {
+			r := wazero.NewRuntimeWithConfig(testCtx, config)
+			defer r.Close(testCtx)
+
+			// "env" also exports a function, which isn't overridden.
+			_, err := r.NewHostModuleBuilder("env").
+				NewFunctionBuilder().WithFunc(func() uint32 { return 42 }).Export("answer").
+				Instantiate(testCtx)
+			require.NoError(t, err)
+
+			mem, err := memory.New(testCtx, r, 1, 10)
+			require.NoError(t, err)
+			require.Equal(t, uint32(65536), mem.Size())
+
+			ctx := memory.WithImport(testCtx, "env", "memory", mem)
+			producer, err := r.InstantiateModule(ctx, compile(t, r, `(module
+  (import "env" "memory" (memory 1 10))
+  (import "env" "answer" (func $answer (result i32)))
+  (func (export "produce") (i32.store (i32.const 8) (call $answer)))
+  (func (export "grow") (result i32) (memory.grow (i32.const 1))))`), wazero.NewModuleConfig().WithName("producer"))
+			require.NoError(t, err)
+			consumer, err := r.InstantiateModule(ctx, compile(t, r, `(module
+  (import "mem" "heap" (memory 1 10))
+  (func (export "consume") (result i32) (i32.load (i32.const 8))))`), wazero.NewModuleConfig().WithName("consumer"))
+			require.Error(t, err) // "mem" isn't overridden, nor instantiated.
+			require.Nil(t, consumer)
+
+			consumer, err = r.InstantiateModule(memory.WithImport(ctx, "mem", "heap", producer.Memory()), compile(t, r, `(module
+  (import "mem" "heap" (memory 1 10))
+  (func (export "consume") (result i32) (i32.load (i32.const 8)))
+  (func (export "size") (result i32) (memory.size)))`), wazero.NewModuleConfig().WithName("consumer"))
+			require.NoError(t, err)
+
+			_, err = producer.ExportedFunction("produce").Call(testCtx)
+			require.NoError(t, err)
+			results, err := consumer.ExportedFunction("consume").Call(testCtx)
+			require.NoError(t, err)
+			require.Equal(t, uint64(42), results[0])
+
+			// Growth is visible to all modules.
+			_, err = producer.ExportedFunction("grow").Call(testCtx)
+			require.NoError(t, err)
+			results, err = consumer.ExportedFunction("size").Call(testCtx)
+			require.NoError(t, err)
+			require.Equal(t, uint64(2), results[0])
+			require.Equal(t, uint32(2*65536), mem.Size())
+		})
+	}
+}
+
+func TestWithImport_OtherRuntime(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+	other := wazero.NewRuntime(testCtx)
+	defer other.Close(testCtx)
+
+	mem, err := memory.New(testCtx, other, 1, 1)
+	require.NoError(t, err)
+
+	_, err = r.Instantiate(memory.WithImport(testCtx, "env", "memory", mem), []byte(`(module
+  (import "env" "memory" (memory 1 1)))`))
+	require.EqualError(t, err, "import memory[env.memory]: memory not instantiated in this runtime")
+}
+
+func TestNew_Invalid(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	_, err := memory.New(testCtx, r, 2, 1)
+	require.EqualError(t, err, "min 2 pages > max 1 pages")
+}
+
+func compile(t *testing.T, r wazero.Runtime, wat string) wazero.CompiledModule {
+	compiled, err := r.CompileModule(testCtx, []byte(wat))
+	require.NoError(t, err)
+	return compiled
+}
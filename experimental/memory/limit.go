@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// BeforeGrow registers a function called before the memory grows, with its
+// size in pages before and after. Returning false vetoes the growth, so
+// "memory.grow" returns -1 to the guest, and api.Memory Grow returns false.
+// The returned function removes it.
+//
+// For example, to implement a memory budget shared by the modules of all
+// tenants:
+//
+//	var budget atomic.Int64 // in pages
+//	memory.BeforeGrow(mem, func(previousPages, pages uint32) bool {
+//		delta := int64(pages - previousPages)
+//		if budget.Add(-delta) < 0 {
+//			budget.Add(delta)
+//			return false
+//		}
+//		return true
+//	})
+//
+// Note: fn is called by the goroutine growing the memory, after checking the
+// max of the memory and its limit from SetLimit. BeforeGrow and the function
+// it returns must not be called concurrently with functions of the module.
+func BeforeGrow(mem api.Memory, fn func(previousPages, pages uint32) (allow bool)) (remove func()) {
+	return mem.(*wasm.MemoryInstance).AddGrowChecker(fn)
+}
+
+// SetLimit sets the number of pages above which the memory can't grow, lower
+// than its max, or removes the limit if zero. Memory already grown above the
+// limit isn't shrunk, but can't grow further.
+//
+// Unlike wazero.RuntimeConfig WithMemoryLimitPages, this applies to one
+// memory, and can be changed at any time, including concurrently with
+// functions of the module. For example, a host can lower the limit of a
+// tenant when the host is short of memory.
+func SetLimit(mem api.Memory, pages uint32) {
+	mem.(*wasm.MemoryInstance).SetLimitPages(pages)
+}
+
+// Limit returns the limit set by SetLimit, or zero if there is none.
+func Limit(mem api.Memory) uint32 {
+	return mem.(*wasm.MemoryInstance).LimitPages()
+}
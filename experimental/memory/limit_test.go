@@ -0,0 +1,81 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental/memory"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestBeforeGrow(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	mod, err := r.Instantiate(testCtx, []byte(`(module
+  (memory (export "memory") 1)
+  (func (export "grow") (param i32) (result i32) (memory.grow (local.get 0))))`))
+	require.NoError(t, err)
+	mem, grow := mod.Memory(), mod.ExportedFunction("grow")
+
+	var requests [][2]uint32
+	budget := uint32(3)
+	remove := memory.BeforeGrow(mem, func(previousPages, pages uint32) bool {
+		requests = append(requests, [2]uint32{previousPages, pages})
+		if pages-previousPages > budget {
+			return false
+		}
+		budget -= pages - previousPages
+		return true
+	})
+
+	results, err := grow.Call(testCtx, 2)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), results[0])
+
+	// Vetoed, so fails like exceeding the max.
+	results, err = grow.Call(testCtx, 2)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0xffffffff), uint32(results[0]))
+	_, ok := mem.Grow(2)
+	require.False(t, ok)
+	require.Equal(t, uint32(3), mem.Size()/65536)
+
+	require.Equal(t, [][2]uint32{{1, 3}, {3, 5}, {3, 5}}, requests)
+
+	remove()
+	_, ok = mem.Grow(2)
+	require.True(t, ok)
+}
+
+func TestSetLimit(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	mod, err := r.Instantiate(testCtx, []byte(`(module
+  (memory (export "memory") 1)
+  (func (export "grow") (param i32) (result i32) (memory.grow (local.get 0))))`))
+	require.NoError(t, err)
+	mem, grow := mod.Memory(), mod.ExportedFunction("grow")
+
+	require.Equal(t, uint32(0), memory.Limit(mem))
+	memory.SetLimit(mem, 2)
+	require.Equal(t, uint32(2), memory.Limit(mem))
+
+	results, err := grow.Call(testCtx, 2)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0xffffffff), uint32(results[0]))
+	results, err = grow.Call(testCtx, 1)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), results[0])
+
+	// Lowering the limit doesn't shrink the memory.
+	memory.SetLimit(mem, 1)
+	require.Equal(t, uint32(2*65536), mem.Size())
+	_, ok := mem.Grow(1)
+	require.False(t, ok)
+
+	memory.SetLimit(mem, 0)
+	_, ok = mem.Grow(1)
+	require.True(t, ok)
+}
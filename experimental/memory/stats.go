@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// Stats are statistics of the usage of a memory, such as to attribute memory
+// to the tenant owning a module, and to alert before running out of memory.
+type Stats struct {
+	// Pages is the current size of the memory in pages.
+	Pages uint32
+
+	// PeakPages is the largest size of the memory in pages.
+	PeakPages uint32
+
+	// Grows is the count of successful memory growths, by "memory.grow" or
+	// api.Memory Grow.
+	Grows uint64
+
+	// FailedGrows is the count of memory growths which failed, such as above
+	// the limit from SetLimit. An increase is a sign the module is running
+	// out of memory.
+	FailedGrows uint64
+
+	// ResidentBytes estimates the bytes of host memory used by the memory.
+	// This is the capacity of its buffer, or only the pages grown into with
+	// wazero.RuntimeConfig WithMemoryMmap.
+	ResidentBytes uint64
+}
+
+// ReadStats returns the Stats of the memory, such as of the module from
+// api.Module Memory. This is safe to call concurrently with functions of the
+// module, such as from a metrics exporter.
+//
+// Note: The memory is shared by modules importing it, so its stats aren't
+// specific to a module instance.
+func ReadStats(mem api.Memory) Stats {
+	s := mem.(*wasm.MemoryInstance).Stats()
+	return Stats{
+		Pages:         s.Pages,
+		PeakPages:     s.PeakPages,
+		Grows:         s.Grows,
+		FailedGrows:   s.FailedGrows,
+		ResidentBytes: s.ResidentBytes,
+	}
+}
@@ -0,0 +1,53 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental/memory"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestReadStats(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	mod, err := r.Instantiate(testCtx, []byte(`(module
+  (memory (export "memory") 1 4)
+  (func (export "grow") (param i32) (result i32) (memory.grow (local.get 0))))`))
+	require.NoError(t, err)
+	mem, grow := mod.Memory(), mod.ExportedFunction("grow")
+
+	require.Equal(t, memory.Stats{Pages: 1, PeakPages: 1, ResidentBytes: 65536}, memory.ReadStats(mem))
+
+	_, err = grow.Call(testCtx, 2)
+	require.NoError(t, err)
+	_, err = grow.Call(testCtx, 0) // not a growth
+	require.NoError(t, err)
+	_, err = grow.Call(testCtx, 2) // above the max
+	require.NoError(t, err)
+
+	stats := memory.ReadStats(mem)
+	require.Equal(t, uint32(3), stats.Pages)
+	require.Equal(t, uint32(3), stats.PeakPages)
+	require.Equal(t, uint64(1), stats.Grows)
+	require.Equal(t, uint64(1), stats.FailedGrows)
+	require.True(t, stats.ResidentBytes >= 3*65536)
+}
+
+func TestReadStats_Mmap(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip() // mmap falls back to the heap.
+	}
+	r := wazero.NewRuntimeWithConfig(testCtx, wazero.NewRuntimeConfig().WithMemoryMmap(true))
+	defer r.Close(testCtx)
+
+	mem, err := memory.New(testCtx, r, 1, 100)
+	require.NoError(t, err)
+	_, ok := mem.Grow(1)
+	require.True(t, ok)
+
+	// Only the pages grown into are resident, not the reservation.
+	require.Equal(t, memory.Stats{Pages: 2, PeakPages: 2, Grows: 1, ResidentBytes: 2 * 65536}, memory.ReadStats(mem))
+}
@@ -0,0 +1,52 @@
+// Package memory gives host functions low-level access to the linear memory
+// of a module.
+//
+// Note: This is experimental, and likely to change.
+package memory
+
+import (
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// View returns a slice aliasing byteCount bytes of the memory at the offset,
+// or false if out of range. Unlike copying with api.Memory Read into a buffer,
+// this is free, so hosts can process megabytes per call, such as to hash or
+// compress them in place.
+//
+// Writes to the slice are visible to Wasm and vice versa.
+//
+// # Invalidation
+//
+// The slice is invalidated when the memory grows, such as from "memory.grow"
+// in a call to the module, as its buffer may be reallocated: the slice
+// then no longer aliases the memory and reads stale data. Don't retain it
+// across calls, or refresh it in a function registered with OnGrow. A view
+// is only stable when the memory can't grow, because its min and max pages
+// are equal.
+func View(mem api.Memory, offset, byteCount uint32) ([]byte, bool) {
+	m := mem.(*wasm.MemoryInstance)
+	if uint64(offset)+uint64(byteCount) > uint64(len(m.Buffer)) {
+		return nil, false
+	}
+	return m.Buffer[offset : offset+byteCount : offset+byteCount], true
+}
+
+// OnGrow registers a function called after the memory grows, with its size in
+// pages before and after, which invalidates slices returned by View. The
+// returned function removes it.
+//
+// For example, to keep a view of a buffer up to date:
+//
+//	view, _ := memory.View(mem, offset, size)
+//	remove := memory.OnGrow(mem, func(previousPages, pages uint32) {
+//		view, _ = memory.View(mem, offset, size)
+//	})
+//	defer remove()
+//
+// Note: fn is called by the goroutine growing the memory, before "memory.grow"
+// or api.Memory Grow returns. OnGrow and the function it returns must not
+// be called concurrently with functions of the module.
+func OnGrow(mem api.Memory, fn func(previousPages, pages uint32)) (remove func()) {
+	return mem.(*wasm.MemoryInstance).AddGrowListener(fn)
+}
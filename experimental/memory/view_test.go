@@ -0,0 +1,59 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental/memory"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+var testCtx = context.Background()
+
+func TestView(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	mod, err := r.Instantiate(testCtx, []byte(`(module
+  (memory (export "memory") 1 3)
+  (func (export "grow") (param i32) (result i32) (memory.grow (local.get 0))))`))
+	require.NoError(t, err)
+	mem := mod.Memory()
+
+	view, ok := memory.View(mem, 10, 3)
+	require.True(t, ok)
+	require.Equal(t, 3, cap(view))
+	copy(view, "abc")
+	buf, _ := mem.Read(10, 3)
+	require.Equal(t, "abc", string(buf))
+
+	_, ok = memory.View(mem, 65535, 2)
+	require.False(t, ok)
+	_, ok = memory.View(mem, 65535, 1)
+	require.True(t, ok)
+
+	var grows [][2]uint32
+	remove := memory.OnGrow(mem, func(previousPages, pages uint32) {
+		grows = append(grows, [2]uint32{previousPages, pages})
+		view, _ = memory.View(mem, 10, 3)
+	})
+
+	grow := mod.ExportedFunction("grow")
+	_, err = grow.Call(testCtx, 1)
+	require.NoError(t, err)
+	_, err = grow.Call(testCtx, 5) // fails, so isn't notified.
+	require.NoError(t, err)
+	_, _ = mem.Grow(1)
+	require.Equal(t, [][2]uint32{{1, 2}, {2, 3}}, grows)
+
+	// The view was refreshed on growth.
+	require.Equal(t, "abc", string(view))
+	view[0] = 'x'
+	buf, _ = mem.Read(10, 1)
+	require.Equal(t, "x", string(buf))
+
+	remove()
+	_, _ = mem.Grow(0)
+	require.Equal(t, 2, len(grows))
+}
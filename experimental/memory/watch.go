@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"errors"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// Access is a kind of memory access, which a watchpoint fires on.
+type Access uint8
+
+const (
+	// AccessRead is a read of memory, such as by "i32.load".
+	AccessRead Access = 1 << iota
+	// AccessWrite is a write of memory, such as by "i32.store".
+	AccessWrite
+)
+
+// ErrWatchUnsupported is returned by Watch when the module isn't interpreted,
+// e.g. it was compiled by wazero.NewRuntimeConfigCompiler.
+var ErrWatchUnsupported = errors.New("watchpoints are only supported by the interpreter")
+
+// Watch registers a watchpoint calling fn after each instruction of the
+// module which accesses memory overlapping byteCount bytes at the offset, if
+// the kind of access is in the given mask. The returned function removes it.
+//
+// This is useful to find which function corrupts a guest's memory, or for
+// research such as taint tracking. For example, to print the stack of writes
+// to a variable:
+//
+//	remove, err := memory.Watch(mod, offset, 4, memory.AccessWrite,
+//		func(access memory.Access, offset, byteCount uint32) {
+//			debug.PrintStack()
+//		})
+//
+// The offset and byteCount passed to fn are of the access, which can be
+// larger than the watched range. Accesses which are out of bounds trap
+// instead of calling fn.
+//
+// # Notes
+//
+//   - This errs with ErrWatchUnsupported unless the module is interpreted,
+//     as compiled code isn't instrumented. Use
+//     wazero.NewRuntimeConfigInterpreter, or compile the module with
+//     experimental.EngineKindInterpreter in a runtime configured with
+//     wazero.NewRuntimeConfigTiered, as its instances are otherwise compiled
+//     once the background compilation completes.
+//   - Instructions of the memory.atomic and SIMD lane families aren't
+//     watched, nor are accesses by host functions via api.Memory.
+//   - Watchpoints are on the memory, so fire for all modules importing it.
+//   - This and the function it returns must not be called concurrently with
+//     functions of the module.
+func Watch(mod api.Module, offset, byteCount uint32, access Access, fn func(access Access, offset, byteCount uint32)) (remove func(), err error) {
+	m := mod.(*wasm.ModuleInstance)
+	if _, ok := m.Engine.(wasm.MemoryWatcher); !ok {
+		return nil, ErrWatchUnsupported
+	}
+	mem := m.MemoryInstance
+	if mem == nil {
+		return nil, errors.New("module has no memory")
+	}
+	return mem.AddWatchpoint(&wasm.Watchpoint{
+		Offset:    offset,
+		ByteCount: byteCount,
+		Read:      access&AccessRead != 0,
+		Write:     access&AccessWrite != 0,
+		Fn: func(write bool, offset, byteCount uint32) {
+			if write {
+				fn(AccessWrite, offset, byteCount)
+			} else {
+				fn(AccessRead, offset, byteCount)
+			}
+		},
+	}), nil
+}
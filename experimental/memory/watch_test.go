@@ -0,0 +1,97 @@
+package memory_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/experimental/memory"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+const watchWat = `(module
+  (memory (export "memory") 1)
+  (func (export "store") (param i32 i32) (i32.store (local.get 0) (local.get 1)))
+  (func (export "load8") (param i32) (result i32) (i32.load8_u (local.get 0)))
+  (func (export "fill") (param i32 i32) (memory.fill (local.get 0) (i32.const 0) (local.get 1))))`
+
+func TestWatch(t *testing.T) {
+	t.Run("interpreter", func(t *testing.T) {
+		testWatch(t, wazero.NewRuntimeConfigInterpreter(), testCtx)
+	})
+	t.Run("tiered", func(t *testing.T) {
+		testWatch(t, wazero.NewRuntimeConfigTiered(), experimental.WithEngineKind(testCtx, experimental.EngineKindInterpreter))
+	})
+}
+
+func testWatch(t *testing.T, config wazero.RuntimeConfig, compileCtx context.Context) {
+	r := wazero.NewRuntimeWithConfig(testCtx, config)
+	defer r.Close(testCtx)
+
+	compiled, err := r.CompileModule(compileCtx, []byte(watchWat))
+	require.NoError(t, err)
+	mod, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig())
+	require.NoError(t, err)
+
+	var accesses []string
+	remove, err := memory.Watch(mod, 100, 4, memory.AccessWrite|memory.AccessRead,
+		func(access memory.Access, offset, byteCount uint32) {
+			accesses = append(accesses, fmt.Sprintf("%d %d+%d", access, offset, byteCount))
+		})
+	require.NoError(t, err)
+
+	call := func(name string, params ...uint64) {
+		_, err := mod.ExportedFunction(name).Call(testCtx, params...)
+		require.NoError(t, err)
+	}
+	call("store", 98, 1)  // overlaps
+	call("store", 104, 1) // doesn't overlap
+	call("load8", 103)    // overlaps
+	call("load8", 99)     // doesn't overlap
+	call("fill", 0, 1000) // overlaps
+	_, err = mod.ExportedFunction("store").Call(testCtx, 65535, 1)
+	require.Error(t, err) // traps without calling the watchpoint.
+
+	require.Equal(t, []string{"2 98+4", "1 103+1", "2 0+1000"}, accesses)
+
+	remove()
+	call("store", 100, 1)
+	require.Equal(t, 3, len(accesses))
+}
+
+func TestWatch_Unsupported(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+	tests := []struct {
+		name       string
+		config     wazero.RuntimeConfig
+		compileCtx context.Context
+	}{
+		{name: "compiler", config: wazero.NewRuntimeConfigCompiler(), compileCtx: testCtx},
+		{
+			name:       "tiered compiled",
+			config:     wazero.NewRuntimeConfigTiered(),
+			compileCtx: experimental.WithEngineKind(testCtx, experimental.EngineKindCompiler),
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			r := wazero.NewRuntimeWithConfig(testCtx, tc.config)
+			defer r.Close(testCtx)
+
+			compiled, err := r.CompileModule(tc.compileCtx, []byte(watchWat))
+			require.NoError(t, err)
+			mod, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig())
+			require.NoError(t, err)
+
+			_, err = memory.Watch(mod, 0, 1, memory.AccessRead, func(memory.Access, uint32, uint32) {})
+			require.Equal(t, memory.ErrWatchUnsupported, err)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package experimental_test
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// arenaMemory is an experimental.LinearMemory with a fixed capacity.
+type arenaMemory struct {
+	buf   []byte
+	freed bool
+}
+
+func (m *arenaMemory) Reallocate(size uint64) []byte {
+	if size > uint64(cap(m.buf)) {
+		return nil
+	}
+	m.buf = m.buf[:size]
+	return m.buf
+}
+
+func (m *arenaMemory) Free() {
+	m.freed = true
+}
+
+func TestWithMemoryAllocator(t *testing.T) {
+	const growWat = `(module
+  (memory (export "memory") 1)
+  (func (export "grow") (param i32) (result i32) (memory.grow (local.get 0))))`
+
+	var allocated []*arenaMemory
+	allocator := experimental.MemoryAllocatorFunc(func(cap, max uint64) experimental.LinearMemory {
+		m := &arenaMemory{buf: make([]byte, 0, 3*65536)}
+		allocated = append(allocated, m)
+		return m
+	})
+
+	for _, tc := range []struct {
+		name                string
+		runtimeCtx, instCtx bool
+		expectedAllocations int
+	}{
+		{name: "none"},
+		{name: "runtime", runtimeCtx: true, expectedAllocations: 1},
+		{name: "module", instCtx: true, expectedAllocations: 1},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			allocated = nil
+			ctx := experimental.WithMemoryAllocator(testCtx, allocator)
+
+			rCtx, iCtx := testCtx, testCtx
+			if tc.runtimeCtx {
+				rCtx = ctx
+			}
+			if tc.instCtx {
+				iCtx = ctx
+			}
+			r := wazero.NewRuntime(rCtx)
+			defer r.Close(testCtx)
+
+			compiled, err := r.CompileModule(testCtx, []byte(growWat))
+			require.NoError(t, err)
+			mod, err := r.InstantiateModule(iCtx, compiled, wazero.NewModuleConfig())
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedAllocations, len(allocated))
+			if tc.expectedAllocations == 0 {
+				return
+			}
+			arena := allocated[0]
+
+			grow := mod.ExportedFunction("grow")
+			results, err := grow.Call(testCtx, 2)
+			require.NoError(t, err)
+			require.Equal(t, uint64(1), results[0])
+			require.Equal(t, 3*65536, len(arena.buf))
+			require.True(t, mod.Memory().WriteByte(3*65536-1, 1))
+			require.Equal(t, byte(1), arena.buf[3*65536-1])
+
+			// The arena is full, so memory.grow fails.
+			results, err = grow.Call(testCtx, 1)
+			require.NoError(t, err)
+			require.Equal(t, uint32(0xffffffff), uint32(results[0]))
+
+			require.False(t, arena.freed)
+			require.NoError(t, mod.Close(testCtx))
+			require.True(t, arena.freed)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Package pool hands out pre-instantiated instances of a compiled module, such
+// as one per request to a plugin server.
+//
+// Note: This is experimental, and likely to change.
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// ErrClosed is returned by Pool.Do after Pool.Close.
+var ErrClosed = errors.New("pool closed")
+
+// Config configures a Pool.
+type Config struct {
+	// Size is the number of instances in the pool, which must be positive.
+	Size int
+
+	// ModuleConfig configures each instance. Defaults to
+	// wazero.NewModuleConfig. The name is cleared, as instances can't share
+	// one, so other modules can't import from them.
+	ModuleConfig wazero.ModuleConfig
+
+	// Reset, when non-nil, is called after an instance is used without error.
+	// It can reset the state of the instance for its next use, or check its
+	// health. When this returns an error, the instance is evicted.
+	Reset func(ctx context.Context, mod api.Module) error
+}
+
+// Pool is a fixed number of instances of a compiled module, which are used by
+// one caller at a time.
+//
+// Instances are evicted when they are not healthy: when a call to Do returns
+// an error, such as a trap, the instance is closed, or Config.Reset returns an
+// error. Evicted instances are replaced on demand, so one failure doesn't
+// affect later callers.
+type Pool struct {
+	r        wazero.Runtime
+	compiled wazero.CompiledModule
+	config   wazero.ModuleConfig
+	reset    func(ctx context.Context, mod api.Module) error
+
+	// idle are instances which are not in use. An evicted instance is nil,
+	// so is instantiated before its next use.
+	idle chan api.Module
+
+	// closed is closed by Close, to stop waiting for idle instances.
+	closed chan struct{}
+
+	// mux guards returning instances to idle, so none are returned after
+	// Close.
+	mux sync.Mutex
+}
+
+// New instantiates `compiled` Config.Size times into a new Pool, or returns
+// the first error instantiating.
+func New(ctx context.Context, r wazero.Runtime, compiled wazero.CompiledModule, config Config) (*Pool, error) {
+	if config.Size <= 0 {
+		return nil, errors.New("pool size must be positive")
+	}
+	moduleConfig := config.ModuleConfig
+	if moduleConfig == nil {
+		moduleConfig = wazero.NewModuleConfig()
+	}
+	p := &Pool{
+		r:        r,
+		compiled: compiled,
+		config:   moduleConfig.WithName(""),
+		reset:    config.Reset,
+		idle:     make(chan api.Module, config.Size),
+		closed:   make(chan struct{}),
+	}
+	for i := 0; i < config.Size; i++ {
+		mod, err := p.instantiate(ctx)
+		if err != nil {
+			_ = p.Close(ctx)
+			return nil, err
+		}
+		p.idle <- mod
+	}
+	return p, nil
+}
+
+func (p *Pool) instantiate(ctx context.Context) (api.Module, error) {
+	return p.r.InstantiateModule(ctx, p.compiled, p.config)
+}
+
+// Do calls `fn` with an idle instance, waiting until one is available, the
+// context is done or the pool is closed. The result is that of `fn`, or the
+// error waiting or replacing an evicted instance.
+//
+// The instance must not be used after `fn` returns. It is evicted when `fn`
+// returns an error, panics or closes it, otherwise it is reset and returned to
+// the pool.
+func (p *Pool) Do(ctx context.Context, fn func(ctx context.Context, mod api.Module) error) (err error) {
+	var mod api.Module
+	select {
+	case mod = <-p.idle:
+	case <-p.closed:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if mod == nil {
+		if mod, err = p.instantiate(ctx); err != nil {
+			p.put(ctx, nil)
+			return err
+		}
+	}
+
+	// The instance is returned even if `fn` panics, so that the pool doesn't
+	// shrink. It's evicted in this case, as its state may be inconsistent.
+	returned := false
+	defer func() {
+		if !returned || err != nil || mod.IsClosed() || (p.reset != nil && p.reset(ctx, mod) != nil) {
+			_ = mod.Close(ctx)
+			mod = nil
+		}
+		p.put(ctx, mod)
+	}()
+	err = fn(ctx, mod)
+	returned = true
+	return err
+}
+
+// put returns an instance, or nil if it was evicted, to the pool.
+func (p *Pool) put(ctx context.Context, mod api.Module) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	select {
+	case <-p.closed:
+		if mod != nil {
+			_ = mod.Close(ctx)
+		}
+	default:
+		p.idle <- mod // never blocks, as the capacity is the pool size.
+	}
+}
+
+// Close closes idle instances without waiting for those in use, which are
+// closed when their call to Do returns.
+func (p *Pool) Close(ctx context.Context) (err error) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	select {
+	case <-p.closed:
+		return nil // already closed
+	default:
+		close(p.closed)
+	}
+	for {
+		select {
+		case mod := <-p.idle:
+			if mod == nil {
+				continue
+			}
+			if e := mod.Close(ctx); e != nil && err == nil {
+				err = e
+			}
+		default:
+			return
+		}
+	}
+}
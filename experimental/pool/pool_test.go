@@ -0,0 +1,162 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental/pool"
+	"github.com/tetratelabs/wazero/internal/testing/binaryencoding"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
+var testCtx = context.WithValue(context.Background(), struct{}{}, "arbitrary")
+
+// counterWasm exports "inc", which increments a global and returns it, and
+// "trap", which is unreachable.
+var counterWasm = binaryencoding.EncodeModule(&wasm.Module{
+	TypeSection: []wasm.FunctionType{
+		{Results: []api.ValueType{api.ValueTypeI32}, ResultNumInUint64: 1},
+		{},
+	},
+	FunctionSection: []wasm.Index{0, 1},
+	GlobalSection: []wasm.Global{{
+		Type: wasm.GlobalType{ValType: api.ValueTypeI32, Mutable: true},
+		Init: wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+	}},
+	CodeSection: []wasm.Code{
+		{Body: []byte{
+			wasm.OpcodeGlobalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add, wasm.OpcodeGlobalSet, 0,
+			wasm.OpcodeGlobalGet, 0, wasm.OpcodeEnd,
+		}},
+		{Body: []byte{wasm.OpcodeUnreachable, wasm.OpcodeEnd}},
+	},
+	ExportSection: []wasm.Export{
+		{Name: "inc", Type: wasm.ExternTypeFunc, Index: 0},
+		{Name: "trap", Type: wasm.ExternTypeFunc, Index: 1},
+	},
+	NameSection: &wasm.NameSection{ModuleName: "counter"},
+})
+
+func newPool(t *testing.T, config pool.Config) *pool.Pool {
+	r := wazero.NewRuntime(testCtx)
+	t.Cleanup(func() { r.Close(testCtx) })
+
+	compiled, err := r.CompileModule(testCtx, counterWasm)
+	require.NoError(t, err)
+	p, err := pool.New(testCtx, r, compiled, config)
+	require.NoError(t, err)
+	t.Cleanup(func() { p.Close(testCtx) })
+	return p
+}
+
+// inc calls "inc" and returns its result.
+func inc(p *pool.Pool) (count uint64, err error) {
+	err = p.Do(testCtx, func(ctx context.Context, mod api.Module) error {
+		results, err := mod.ExportedFunction("inc").Call(ctx)
+		if err == nil {
+			count = results[0]
+		}
+		return err
+	})
+	return
+}
+
+func TestPool_Do(t *testing.T) {
+	p := newPool(t, pool.Config{Size: 1})
+
+	// The only instance is reused, so keeps its state.
+	for i := uint64(1); i <= 3; i++ {
+		count, err := inc(p)
+		require.NoError(t, err)
+		require.Equal(t, i, count)
+	}
+
+	// A trap evicts the instance, so the next call has a new one.
+	err := p.Do(testCtx, func(ctx context.Context, mod api.Module) error {
+		_, err := mod.ExportedFunction("trap").Call(ctx)
+		return err
+	})
+	require.Contains(t, err.Error(), "unreachable")
+	count, err := inc(p)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), count)
+
+	// Closing the instance evicts it as well.
+	require.NoError(t, p.Do(testCtx, func(ctx context.Context, mod api.Module) error {
+		return mod.Close(ctx)
+	}))
+	count, err = inc(p)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), count)
+
+	// A panic evicts the instance, which is returned to the pool anyway, so
+	// later calls don't wait forever.
+	require.Equal(t, "boom", func() (recovered interface{}) {
+		defer func() { recovered = recover() }()
+		_ = p.Do(testCtx, func(context.Context, api.Module) error { panic("boom") })
+		return
+	}())
+	count, err = inc(p)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), count)
+
+	require.NoError(t, p.Close(testCtx))
+	_, err = inc(p)
+	require.Equal(t, pool.ErrClosed, err)
+}
+
+func TestPool_Reset(t *testing.T) {
+	var resets int
+	p := newPool(t, pool.Config{Size: 1, Reset: func(ctx context.Context, mod api.Module) error {
+		// Evict the instance after two uses.
+		if resets++; resets%2 == 0 {
+			return errors.New("unhealthy")
+		}
+		return nil
+	}})
+
+	for _, expected := range []uint64{1, 2, 1, 2} {
+		count, err := inc(p)
+		require.NoError(t, err)
+		require.Equal(t, expected, count)
+	}
+	require.Equal(t, 4, resets)
+}
+
+func TestPool_Do_Waits(t *testing.T) {
+	p := newPool(t, pool.Config{Size: 1})
+
+	ctx, cancel := context.WithCancel(testCtx)
+	err := p.Do(testCtx, func(context.Context, api.Module) error {
+		// The only instance is in use, so this waits until canceled.
+		cancel()
+		return p.Do(ctx, func(context.Context, api.Module) error {
+			t.Fatal("unexpected call")
+			return nil
+		})
+	})
+	require.Equal(t, context.Canceled, err)
+}
+
+func TestNew(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	compiled, err := r.CompileModule(testCtx, counterWasm)
+	require.NoError(t, err)
+
+	_, err = pool.New(testCtx, r, compiled, pool.Config{})
+	require.EqualError(t, err, "pool size must be positive")
+
+	// The name is cleared, so instantiating doesn't conflict with this.
+	_, err = r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig())
+	require.NoError(t, err)
+	p, err := pool.New(testCtx, r, compiled, pool.Config{Size: 2})
+	require.NoError(t, err)
+	require.NoError(t, p.Close(testCtx))
+}
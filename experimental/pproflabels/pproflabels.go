@@ -0,0 +1,129 @@
+// Package pproflabels attributes the samples of Go profiles, e.g. those of
+// runtime/pprof.StartCPUProfile, to the Wasm functions running at the time.
+//
+// The machine code generated by the compiler isn't known to the Go runtime, so
+// the profiler can't symbolize the frames of Wasm functions, and the
+// interpreter only appears as its own Go functions. Instead, the listener of
+// this package sets the goroutine label LabelKey to the name of the Wasm
+// function being executed, which the profiler records with each sample.
+//
+// To show the Wasm function as a frame of the flame graph, on top of the Go
+// frames of the host, pass the label to pprof. e.g.
+//
+//	go tool pprof -tagleaf=wasm_function -http=:8080 cpu.pprof
+//
+// Note: Labels are set by a function listener on every call and return of a
+// Wasm function, which slows down the guest significantly. Listeners also
+// change the generated code, e.g. functions aren't inlined, so the profile is
+// that of a slightly different program. Only use this while profiling.
+package pproflabels
+
+import (
+	"context"
+	"runtime/pprof"
+	"sync"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+)
+
+// LabelKey is the key of the pprof label whose value is the
+// api.FunctionDefinition DebugName of the function being executed. e.g.
+// "env.fib".
+const LabelKey = "wasm_function"
+
+// NewFunctionListenerFactory returns an experimental.FunctionListenerFactory
+// which sets LabelKey to the Wasm function being executed on the calling
+// goroutine. Host functions keep the label of the Wasm function calling them.
+//
+// When the outermost function returns, the goroutine labels are reset to
+// those of the context.Context passed to api.Function Call. Therefore, use
+// pprof.Do on this context, as opposed to pprof.SetGoroutineLabels, to set
+// other labels.
+//
+// Note: The labels to restore on return are tracked per module instance, so
+// instances can be called concurrently. Functions called by the same
+// instance from multiple goroutines share its stack though, so their labels
+// may be mixed up.
+func NewFunctionListenerFactory() experimental.FunctionListenerFactory {
+	return &labelListenerFactory{stacks: map[api.Module][]context.Context{}}
+}
+
+type labelListenerFactory struct {
+	// stacks are the labels to restore when each function being executed
+	// returns, the innermost one last, per module instance. This is guarded
+	// by mux.
+	stacks map[api.Module][]context.Context
+	mux    sync.Mutex
+}
+
+// NewFunctionListener implements the same method as documented on
+// experimental.FunctionListenerFactory.
+func (f *labelListenerFactory) NewFunctionListener(def api.FunctionDefinition) experimental.FunctionListener {
+	// Host functions are already in the Go frames of the profile, so they are
+	// attributed to the Wasm function calling them.
+	if def.GoFunction() != nil {
+		return nil
+	}
+	return &labelListener{factory: f, name: def.DebugName()}
+}
+
+// push adds the labels to restore when the function called on `mod` returns.
+func (f *labelListenerFactory) push(mod api.Module, restore context.Context) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.stacks[mod] = append(f.stacks[mod], restore)
+}
+
+// pop returns the labels to restore when the function called on `mod`
+// returns.
+func (f *labelListenerFactory) pop(mod api.Module) context.Context {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	stack := f.stacks[mod]
+	i := len(stack) - 1
+	restore := stack[i]
+	if i == 0 {
+		delete(f.stacks, mod)
+	} else {
+		stack[i] = nil
+		f.stacks[mod] = stack[:i]
+	}
+	return restore
+}
+
+type labelListener struct {
+	factory *labelListenerFactory
+	name    string
+}
+
+// Before implements the same method as documented on
+// experimental.FunctionListener.
+func (l *labelListener) Before(ctx context.Context, mod api.Module, _ api.FunctionDefinition, _ []uint64, si experimental.StackIterator) {
+	l.factory.push(mod, callerLabels(ctx, si))
+	pprof.SetGoroutineLabels(pprof.WithLabels(ctx, pprof.Labels(LabelKey, l.name)))
+}
+
+// After implements the same method as documented on
+// experimental.FunctionListener.
+func (l *labelListener) After(_ context.Context, mod api.Module, _ api.FunctionDefinition, _ []uint64) {
+	pprof.SetGoroutineLabels(l.factory.pop(mod))
+}
+
+// Abort implements the same method as documented on
+// experimental.FunctionListener.
+func (l *labelListener) Abort(_ context.Context, mod api.Module, _ api.FunctionDefinition, _ error) {
+	pprof.SetGoroutineLabels(l.factory.pop(mod))
+}
+
+// callerLabels returns the labels of the Wasm function calling the current
+// one, which may be in another module, or those of ctx if there is none.
+func callerLabels(ctx context.Context, si experimental.StackIterator) context.Context {
+	si.Next() // The current function.
+	for si.Next() {
+		if def := si.Function().Definition(); def.GoFunction() == nil {
+			return pprof.WithLabels(ctx, pprof.Labels(LabelKey, def.DebugName()))
+		}
+	}
+	return ctx
+}
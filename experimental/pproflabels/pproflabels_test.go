@@ -0,0 +1,195 @@
+package pproflabels_test
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/experimental/pproflabels"
+	"github.com/tetratelabs/wazero/internal/testing/binaryencoding"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
+var testCtx = context.WithValue(context.Background(), struct{}{}, "arbitrary")
+
+func TestNewFunctionListenerFactory(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config wazero.RuntimeConfig
+	}{
+		{name: "interpreter", config: wazero.NewRuntimeConfigInterpreter()},
+		{name: "default", config: wazero.NewRuntimeConfig()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.WithValue(testCtx, experimental.FunctionListenerFactoryKey{}, pproflabels.NewFunctionListenerFactory())
+
+			r := wazero.NewRuntimeWithConfig(ctx, tc.config)
+			defer r.Close(ctx)
+
+			var labels []string
+			_, err := r.NewHostModuleBuilder("host").NewFunctionBuilder().WithFunc(func() {
+				labels = append(labels, goroutineLabels(t))
+			}).Export("record").Instantiate(ctx)
+			require.NoError(t, err)
+
+			bin := binaryencoding.EncodeModule(&wasm.Module{
+				TypeSection:     []wasm.FunctionType{{}},
+				ImportSection:   []wasm.Import{{Module: "host", Name: "record"}},
+				FunctionSection: []wasm.Index{0, 0},
+				CodeSection: []wasm.Code{
+					// fn1
+					{Body: []byte{
+						wasm.OpcodeCall, 0,
+						wasm.OpcodeCall, 2,
+						wasm.OpcodeCall, 0,
+						wasm.OpcodeEnd,
+					}},
+					// fn2
+					{Body: []byte{wasm.OpcodeCall, 0, wasm.OpcodeEnd}},
+				},
+				ExportSection: []wasm.Export{{Name: "fn1", Type: wasm.ExternTypeFunc, Index: 1}},
+				NameSection: &wasm.NameSection{
+					ModuleName:    "test",
+					FunctionNames: wasm.NameMap{{Index: 1, Name: "fn1"}, {Index: 2, Name: "fn2"}},
+				},
+			})
+			mod, err := r.Instantiate(ctx, bin)
+			require.NoError(t, err)
+
+			// The labels of the context passed to Call are kept, and restored on return.
+			pprof.Do(ctx, pprof.Labels("test", "value"), func(ctx context.Context) {
+				_, err = mod.ExportedFunction("fn1").Call(ctx)
+				require.NoError(t, err)
+				labels = append(labels, goroutineLabels(t))
+			})
+			require.Equal(t, []string{
+				`{"test":"value", "wasm_function":"test.fn1"}`,
+				`{"test":"value", "wasm_function":"test.fn2"}`,
+				`{"test":"value", "wasm_function":"test.fn1"}`,
+				`{"test":"value"}`,
+			}, labels)
+		})
+	}
+}
+
+func TestNewFunctionListenerFactory_importedGuest(t *testing.T) {
+	ctx := context.WithValue(testCtx, experimental.FunctionListenerFactoryKey{}, pproflabels.NewFunctionListenerFactory())
+
+	r := wazero.NewRuntime(ctx)
+	defer r.Close(ctx)
+
+	var labels []string
+	_, err := r.NewHostModuleBuilder("host").NewFunctionBuilder().WithFunc(func() {
+		labels = append(labels, goroutineLabels(t))
+	}).Export("record").Instantiate(ctx)
+	require.NoError(t, err)
+
+	_, err = r.Instantiate(ctx, binaryencoding.EncodeModule(&wasm.Module{
+		TypeSection:     []wasm.FunctionType{{}},
+		ImportSection:   []wasm.Import{{Module: "host", Name: "record"}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection:     []wasm.Code{{Body: []byte{wasm.OpcodeCall, 0, wasm.OpcodeEnd}}},
+		ExportSection:   []wasm.Export{{Name: "callee", Type: wasm.ExternTypeFunc, Index: 1}},
+		NameSection: &wasm.NameSection{
+			ModuleName:    "callee",
+			FunctionNames: wasm.NameMap{{Index: 1, Name: "callee"}},
+		},
+	}))
+	require.NoError(t, err)
+
+	mod, err := r.Instantiate(ctx, binaryencoding.EncodeModule(&wasm.Module{
+		TypeSection: []wasm.FunctionType{{}},
+		ImportSection: []wasm.Import{
+			{Module: "host", Name: "record"},
+			{Module: "callee", Name: "callee"},
+		},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []wasm.Code{
+			{Body: []byte{wasm.OpcodeCall, 1, wasm.OpcodeCall, 0, wasm.OpcodeEnd}},
+		},
+		ExportSection: []wasm.Export{{Name: "caller", Type: wasm.ExternTypeFunc, Index: 2}},
+		NameSection: &wasm.NameSection{
+			ModuleName:    "caller",
+			FunctionNames: wasm.NameMap{{Index: 2, Name: "caller"}},
+		},
+	}))
+	require.NoError(t, err)
+
+	// Returning from the other module restores the label of the caller.
+	_, err = mod.ExportedFunction("caller").Call(ctx)
+	require.NoError(t, err)
+	labels = append(labels, goroutineLabels(t))
+	require.Equal(t, []string{
+		`{"wasm_function":"callee.callee"}`,
+		`{"wasm_function":"caller.caller"}`,
+		"",
+	}, labels)
+}
+
+func TestNewFunctionListenerFactory_concurrent(t *testing.T) {
+	ctx := context.WithValue(testCtx, experimental.FunctionListenerFactoryKey{}, pproflabels.NewFunctionListenerFactory())
+
+	r := wazero.NewRuntime(ctx)
+	defer r.Close(ctx)
+
+	compiled, err := r.CompileModule(ctx, binaryencoding.EncodeModule(&wasm.Module{
+		TypeSection:     []wasm.FunctionType{{}},
+		FunctionSection: []wasm.Index{0, 0},
+		CodeSection: []wasm.Code{
+			{Body: []byte{wasm.OpcodeCall, 1, wasm.OpcodeCall, 1, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeEnd}},
+		},
+		ExportSection: []wasm.Export{{Name: "fn1", Type: wasm.ExternTypeFunc, Index: 0}},
+	}))
+	require.NoError(t, err)
+
+	// Instances compiled with the same factory can be called concurrently.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		mod, err := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName(""))
+		require.NoError(t, err)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := mod.ExportedFunction("fn1").Call(ctx); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// goroutineLabels returns the pprof labels of the calling goroutine, as
+// formatted in the goroutine profile. e.g. {"key":"value"}
+func goroutineLabels(t *testing.T) string {
+	// The labels of a goroutine can only be read from the profile, where the
+	// goroutines having the same labels and stack are grouped together.
+	// This marks the calling goroutine by running it in a unique closure.
+	var buf bytes.Buffer
+	func() {
+		require.NoError(t, pprof.Lookup("goroutine").WriteTo(&buf, 1))
+	}()
+	for _, record := range strings.Split(buf.String(), "\n\n") {
+		if !strings.Contains(record, "pproflabels_test.goroutineLabels.func1") {
+			continue
+		}
+		for _, line := range strings.Split(record, "\n") {
+			if strings.HasPrefix(line, "# labels: ") {
+				return strings.TrimPrefix(line, "# labels: ")
+			}
+		}
+		return ""
+	}
+	t.Fatal("goroutine not found in the profile")
+	return ""
+}
@@ -0,0 +1,32 @@
+package experimental
+
+import (
+	"context"
+
+	"github.com/tetratelabs/wazero/api"
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
+)
+
+// ProcExitConfig overrides the behavior of proc_exit in WASI, which by
+// default closes the module and unwinds the guest with a sys.ExitError.
+type ProcExitConfig struct {
+	// OnExit is called with the exit code before the guest unwinds. For
+	// example, a server can record the exit code of an embedded CLI.
+	OnExit func(ctx context.Context, mod api.Module, exitCode uint32)
+
+	// KeepOpen doesn't close the module on exit, so that its exports can be
+	// called again, like a reactor. The call to proc_exit still fails with a
+	// sys.ExitError, except during instantiation, where exit code zero
+	// returns the module.
+	//
+	// Note: The guest's memory is left as it was when it exited, so only set
+	// this when the guest supports being called after exit.
+	KeepOpen bool
+}
+
+// WithProcExitConfig registers the given ProcExitConfig into the given
+// context.Context, which applies to modules instantiated with it.
+func WithProcExitConfig(ctx context.Context, config ProcExitConfig) context.Context {
+	c := internalsys.ProcExitConfig(config)
+	return context.WithValue(ctx, internalsys.ProcExitConfigKey{}, &c)
+}
@@ -0,0 +1,15 @@
+package experimental_test
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/experimental"
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestWithProcExitConfig(t *testing.T) {
+	ctx := experimental.WithProcExitConfig(testCtx, experimental.ProcExitConfig{KeepOpen: true})
+
+	require.Equal(t, &internalsys.ProcExitConfig{KeepOpen: true}, ctx.Value(internalsys.ProcExitConfigKey{}))
+}
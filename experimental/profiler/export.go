@@ -0,0 +1,249 @@
+package profiler
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// foldedNameReplacer replaces the separators of the folded stacks format in
+// function names, as they can't be escaped.
+var foldedNameReplacer = strings.NewReplacer(";", ":", " ", "_")
+
+// WriteFolded writes the samples in the folded stacks format, which is a line
+// per call stack with the names of its functions, outermost first, separated
+// by semicolons, followed by the number of samples. e.g.
+//
+//	env._start;env.main;env.fib 42
+//
+// This may be called while sampling.
+func (p *Profiler) WriteFolded(w io.Writer) error {
+	p.mu.Lock()
+	stacks := p.stacks()
+	p.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, s := range stacks {
+		for i, name := range s.names {
+			if i > 0 {
+				bw.WriteByte(';') //nolint
+			}
+			bw.WriteString(foldedNameReplacer.Replace(name)) //nolint
+		}
+		bw.WriteByte(' ')                              //nolint
+		bw.WriteString(strconv.FormatInt(s.count, 10)) //nolint
+		bw.WriteByte('\n')                             //nolint
+	}
+	return bw.Flush()
+}
+
+// WritePprof writes the samples as a gzip-compressed protocol buffer in the
+// format of pprof, with the number of samples and the wall time they
+// represent.
+//
+// See https://github.com/google/pprof/blob/main/proto/profile.proto
+//
+// This may be called while sampling.
+func (p *Profiler) WritePprof(w io.Writer) error {
+	p.mu.Lock()
+	stacks := p.stacks()
+	start, duration := p.start, p.duration
+	if p.stop != nil {
+		duration += time.Since(p.start)
+	}
+	p.mu.Unlock()
+
+	var b, msg protobuf
+	st := stringTable{}
+	st.index("") // The first string must be empty.
+
+	msg.valueType(st.index("samples"), st.index("count"))
+	b.bytes(profileSampleType, msg)
+	msg = msg[:0]
+	msg.valueType(st.index("wall"), st.index("nanoseconds"))
+	b.bytes(profileSampleType, msg)
+
+	// Each function has a single location, with the same ID.
+	functionIDs := map[string]uint64{}
+	var functionNames []string
+	for _, s := range stacks {
+		locations := make([]uint64, len(s.names))
+		for i, name := range s.names {
+			id, ok := functionIDs[name]
+			if !ok {
+				functionNames = append(functionNames, name)
+				id = uint64(len(functionNames))
+				functionIDs[name] = id
+			}
+			// Locations of samples are innermost first.
+			locations[len(locations)-1-i] = id
+		}
+		msg = msg[:0]
+		msg.packed(sampleLocationID, locations)
+		msg.packed(sampleValue, []uint64{uint64(s.count), uint64(s.count * int64(p.period))})
+		b.bytes(profileSample, msg)
+	}
+
+	var line protobuf
+	for i, name := range functionNames {
+		id := uint64(i + 1)
+		line = line[:0]
+		line.varint(lineFunctionID, id)
+		msg = msg[:0]
+		msg.varint(locationID, id)
+		msg.bytes(locationLine, line)
+		b.bytes(profileLocation, msg)
+
+		msg = msg[:0]
+		msg.varint(functionID, id)
+		msg.varint(functionName, st.index(name))
+		msg.varint(functionSystemName, st.index(name))
+		b.bytes(profileFunction, msg)
+	}
+
+	if !start.IsZero() {
+		b.varint(profileTimeNanos, uint64(start.UnixNano()))
+	}
+	b.varint(profileDurationNanos, uint64(duration))
+	msg = msg[:0]
+	msg.valueType(st.index("wall"), st.index("nanoseconds"))
+	b.bytes(profilePeriodType, msg)
+	b.varint(profilePeriod, uint64(p.period))
+
+	// Strings are indexed above, so the table is written last.
+	for _, s := range st.strings {
+		b.bytes(profileStringTable, []byte(s))
+	}
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(b); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// namedStack is a call stack with the names of its functions.
+type namedStack struct {
+	// names are the api.FunctionDefinition DebugName of the functions,
+	// the outermost first.
+	names []string
+	count int64
+}
+
+// stacks returns the samples by call stack, sorted by the names of their
+// functions. Stacks of different functions with the same names are merged.
+//
+// This must be called with the lock held.
+func (p *Profiler) stacks() []*namedStack {
+	byName := map[string]*namedStack{}
+	for _, s := range p.samples {
+		names := make([]string, len(s.stack))
+		for i, id := range s.stack {
+			names[i] = p.functions[id].DebugName()
+		}
+		key := strings.Join(names, "\x00")
+		if ns, ok := byName[key]; ok {
+			ns.count += s.count
+		} else {
+			byName[key] = &namedStack{names: names, count: s.count}
+		}
+	}
+	keys := make([]string, 0, len(byName))
+	for key := range byName {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	ret := make([]*namedStack, len(keys))
+	for i, key := range keys {
+		ret[i] = byName[key]
+	}
+	return ret
+}
+
+// Field numbers of the messages in profile.proto.
+const (
+	profileSampleType    = 1
+	profileSample        = 2
+	profileLocation      = 4
+	profileFunction      = 5
+	profileStringTable   = 6
+	profileTimeNanos     = 9
+	profileDurationNanos = 10
+	profilePeriodType    = 11
+	profilePeriod        = 12
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationID = 1
+	sampleValue      = 2
+
+	locationID   = 1
+	locationLine = 4
+
+	lineFunctionID = 1
+
+	functionID         = 1
+	functionName       = 2
+	functionSystemName = 3
+)
+
+// protobuf encodes the fields of a protocol buffer message.
+type protobuf []byte
+
+func (b *protobuf) tag(field, wireType uint64) {
+	*b = binary.AppendUvarint(*b, field<<3|wireType)
+}
+
+// varint encodes an integer field. Signed ones are converted to uint64, as
+// int64 fields aren't zigzag encoded.
+func (b *protobuf) varint(field, v uint64) {
+	b.tag(field, 0)
+	*b = binary.AppendUvarint(*b, v)
+}
+
+// bytes encodes a length-delimited field, such as a string or a message.
+func (b *protobuf) bytes(field uint64, v []byte) {
+	b.tag(field, 2)
+	*b = binary.AppendUvarint(*b, uint64(len(v)))
+	*b = append(*b, v...)
+}
+
+// packed encodes a repeated integer field.
+func (b *protobuf) packed(field uint64, vs []uint64) {
+	var buf []byte
+	for _, v := range vs {
+		buf = binary.AppendUvarint(buf, v)
+	}
+	b.bytes(field, buf)
+}
+
+// valueType encodes the fields of a ValueType message.
+func (b *protobuf) valueType(typ, unit uint64) {
+	b.varint(valueTypeType, typ)
+	b.varint(valueTypeUnit, unit)
+}
+
+// stringTable assigns the index of strings in the string table of a profile.
+type stringTable struct {
+	indexes map[string]uint64
+	strings []string
+}
+
+func (t *stringTable) index(s string) uint64 {
+	if i, ok := t.indexes[s]; ok {
+		return i
+	}
+	if t.indexes == nil {
+		t.indexes = map[string]uint64{}
+	}
+	i := uint64(len(t.strings))
+	t.indexes[s] = i
+	t.strings = append(t.strings, s)
+	return i
+}
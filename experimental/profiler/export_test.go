@@ -0,0 +1,122 @@
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// testDefinition is an api.FunctionDefinition with only a name.
+type testDefinition struct {
+	api.FunctionDefinition
+	name string
+}
+
+func (d *testDefinition) DebugName() string { return d.name }
+
+func TestProfiler_WriteFolded(t *testing.T) {
+	p := NewProfiler(time.Millisecond)
+	for _, name := range []string{"env.a", "env.b", "env.c d;e"} {
+		p.NewFunctionListener(&testDefinition{name: name})
+	}
+	for _, stack := range [][]uint32{{0, 1}, {0}, {0, 1}, {0, 2}, {0, 1}} {
+		p.record(stack)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, p.WriteFolded(&buf))
+	require.Equal(t, `env.a 1
+env.a;env.b 3
+env.a;env.c_d:e 1
+`, buf.String())
+}
+
+func TestProfiler_WritePprof(t *testing.T) {
+	p := NewProfiler(time.Millisecond)
+	for _, name := range []string{"env.a", "env.b"} {
+		p.NewFunctionListener(&testDefinition{name: name})
+	}
+	for _, stack := range [][]uint32{{0, 1}, {0}, {0, 1}} {
+		p.record(stack)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, p.WritePprof(&buf))
+	gz, err := gzip.NewReader(&buf)
+	require.NoError(t, err)
+	b, err := io.ReadAll(gz)
+	require.NoError(t, err)
+
+	profile := decodeMessage(t, b)
+	var strings []string
+	for _, s := range profile[profileStringTable] {
+		strings = append(strings, string(s.([]byte)))
+	}
+	require.Equal(t, []string{"", "samples", "count", "wall", "nanoseconds", "env.a", "env.b"}, strings)
+	require.Equal(t, []interface{}{uint64(time.Millisecond)}, profile[profilePeriod])
+	require.Equal(t, 2, len(profile[profileSampleType]))
+
+	// Samples are sorted by stack, with the locations innermost first.
+	var samples [][2][]uint64
+	for _, s := range profile[profileSample] {
+		sample := decodeMessage(t, s.([]byte))
+		samples = append(samples, [2][]uint64{
+			decodePacked(t, sample[sampleLocationID][0].([]byte)),
+			decodePacked(t, sample[sampleValue][0].([]byte)),
+		})
+	}
+	require.Equal(t, [][2][]uint64{
+		{{1}, {1, uint64(time.Millisecond)}},
+		{{2, 1}, {2, 2 * uint64(time.Millisecond)}},
+	}, samples)
+
+	// Each function has a location with the same ID.
+	var functions []string
+	for _, f := range profile[profileFunction] {
+		function := decodeMessage(t, f.([]byte))
+		functions = append(functions, strings[function[functionName][0].(uint64)])
+		location := decodeMessage(t, profile[profileLocation][len(functions)-1].([]byte))
+		require.Equal(t, function[functionID], location[locationID])
+	}
+	require.Equal(t, []string{"env.a", "env.b"}, functions)
+}
+
+// decodeMessage returns the values of the fields of a protocol buffer
+// message: uint64 for varints, and []byte for length-delimited ones.
+func decodeMessage(t *testing.T, b []byte) map[uint64][]interface{} {
+	fields := map[uint64][]interface{}{}
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		require.True(t, n > 0)
+		b = b[n:]
+		v, n := binary.Uvarint(b)
+		require.True(t, n > 0)
+		b = b[n:]
+		switch tag & 7 {
+		case 0:
+			fields[tag>>3] = append(fields[tag>>3], v)
+		case 2:
+			fields[tag>>3] = append(fields[tag>>3], b[:v])
+			b = b[v:]
+		default:
+			t.Fatalf("unexpected wire type %d", tag&7)
+		}
+	}
+	return fields
+}
+
+func decodePacked(t *testing.T, b []byte) (vs []uint64) {
+	for len(b) > 0 {
+		v, n := binary.Uvarint(b)
+		require.True(t, n > 0)
+		vs = append(vs, v)
+		b = b[n:]
+	}
+	return
+}
@@ -0,0 +1,289 @@
+// Package profiler samples the call stack of guest functions, to find where
+// they spend time without instrumenting the guest.
+//
+// A Profiler is an experimental.FunctionListenerFactory, so it works the same
+// with the interpreter and the compiler. Here's an example:
+//
+//	p := profiler.NewProfiler(0)
+//	ctx = context.WithValue(ctx, experimental.FunctionListenerFactoryKey{}, p)
+//	compiled, _ := r.CompileModule(ctx, wasm)
+//	mod, _ := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig())
+//
+//	p.Start()
+//	_, err := mod.ExportedFunction("run").Call(ctx)
+//	p.Stop()
+//	err = p.WritePprof(f) // or WriteFolded
+//
+// The profile can then be viewed with `go tool pprof -http=:8080 cpu.pprof`,
+// or the folded stacks turned into a flame graph by tools such as
+// flamegraph.pl or speedscope.
+package profiler
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+)
+
+// DefaultPeriod is the period between samples of NewProfiler when zero.
+const DefaultPeriod = 10 * time.Millisecond
+
+// Profiler records the functions being executed by modules compiled with it,
+// and samples them periodically between Start and Stop.
+//
+// Samples are taken on the wall clock, so the time a guest spends in host
+// functions, including waiting in them, is attributed to these host functions
+// at the top of the guest stack. Nothing is sampled while no guest function
+// is being executed.
+//
+// Each module instance has its own call stack, and all of them are sampled,
+// so instances can be called concurrently. Functions called by the same
+// instance from multiple goroutines share its stack though, so their samples
+// may be mixed up.
+type Profiler struct {
+	period time.Duration
+
+	// mu guards the fields below, which are shared between the listeners and
+	// the goroutine sampling the stack.
+	mu sync.Mutex
+	// functions are the definitions of the functions, by the ID of their
+	// listener, and ids are the reverse.
+	functions []api.FunctionDefinition
+	ids       map[api.FunctionDefinition]uint32
+	// frames are the functions being executed, the innermost one last, keyed
+	// by the calling module passed to the listener.
+	frames map[api.Module][]*frame
+	// samples are the samples of each stack, by stackKey.
+	samples  map[string]*sample
+	start    time.Time
+	duration time.Duration
+	// stop is closed to stop the sampling goroutine, which closes done when
+	// it returns. Both are nil when not started.
+	stop, done chan struct{}
+}
+
+// frame is a function being executed.
+type frame struct {
+	// stack are the IDs of the functions in the call stack, the innermost one
+	// last, which is this function.
+	stack []uint32
+	// calling is true while this function calls one tracked under another
+	// module, which is sampled instead.
+	calling bool
+	// caller is the frame tracked under another module which called this one,
+	// if any. Its calling field is true until this returns.
+	caller *frame
+}
+
+type sample struct {
+	// stack are the IDs of the functions being executed, the innermost one
+	// last.
+	stack []uint32
+	count int64
+}
+
+// NewProfiler returns a Profiler which samples the call stack every period,
+// or DefaultPeriod if zero.
+func NewProfiler(period time.Duration) *Profiler {
+	if period <= 0 {
+		period = DefaultPeriod
+	}
+	return &Profiler{
+		period:  period,
+		ids:     map[api.FunctionDefinition]uint32{},
+		frames:  map[api.Module][]*frame{},
+		samples: map[string]*sample{},
+	}
+}
+
+// NewFunctionListener implements the same method as documented on
+// experimental.FunctionListenerFactory.
+func (p *Profiler) NewFunctionListener(def api.FunctionDefinition) experimental.FunctionListener {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	id := uint32(len(p.functions))
+	p.functions = append(p.functions, def)
+	p.ids[def] = id
+	return &listener{p: p, id: id}
+}
+
+// Start starts sampling the call stack, until Stop is called. Samples are
+// added to those of previous calls to Start, if any. This has no effect if
+// already started.
+func (p *Profiler) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		return
+	}
+	p.start = time.Now()
+	p.stop, p.done = make(chan struct{}), make(chan struct{})
+	go p.run(p.stop, p.done)
+}
+
+// Stop stops sampling the call stack. This has no effect if not started.
+func (p *Profiler) Stop() {
+	p.mu.Lock()
+	stop, done := p.stop, p.done
+	p.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.duration += time.Since(p.start)
+	p.stop, p.done = nil, nil
+}
+
+// Reset discards the samples taken so far.
+func (p *Profiler) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.samples = map[string]*sample{}
+	p.duration = 0
+	p.start = time.Now()
+}
+
+func (p *Profiler) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(p.period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			p.sample()
+		}
+	}
+}
+
+// sample records the current call stacks, if any.
+func (p *Profiler) sample() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, frames := range p.frames {
+		if f := frames[len(frames)-1]; !f.calling {
+			p.record(f.stack)
+		}
+	}
+}
+
+// record adds a sample of the call stack.
+func (p *Profiler) record(stack []uint32) {
+	key := stackKey(stack)
+	s, ok := p.samples[key]
+	if !ok {
+		s = &sample{stack: stack}
+		p.samples[key] = s
+	}
+	s.count++
+}
+
+// push tracks a call to the function `id` by `mod`, whose caller is the
+// function `callerDef`, if non-nil.
+//
+// The caller is the innermost frame of the same module, unless the function
+// is imported from another module. In that case, it's the innermost frame of
+// another module which is executing the caller, which is equivalent to any
+// other for sampling.
+func (p *Profiler) push(mod api.Module, id uint32, callerDef api.FunctionDefinition) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	frames := p.frames[mod]
+	var caller *frame
+	if callerID, ok := p.ids[callerDef]; ok {
+		if n := len(frames); n > 0 && isFrameOf(frames[n-1], callerID) {
+			caller = frames[n-1]
+		} else {
+			for m, other := range p.frames {
+				if f := other[len(other)-1]; m != mod && isFrameOf(f, callerID) {
+					caller = f
+					break
+				}
+			}
+		}
+	}
+
+	f := &frame{}
+	if caller != nil {
+		f.stack = append(append(make([]uint32, 0, len(caller.stack)+1), caller.stack...), id)
+		if n := len(frames); n == 0 || frames[n-1] != caller {
+			caller.calling = true
+			f.caller = caller
+		}
+	} else {
+		f.stack = []uint32{id}
+	}
+	p.frames[mod] = append(frames, f)
+}
+
+// pop tracks the return of the innermost function called by `mod`.
+func (p *Profiler) pop(mod api.Module) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	frames := p.frames[mod]
+	i := len(frames) - 1
+	if caller := frames[i].caller; caller != nil {
+		caller.calling = false
+	}
+	if i == 0 {
+		delete(p.frames, mod)
+	} else {
+		frames[i] = nil
+		p.frames[mod] = frames[:i]
+	}
+}
+
+// isFrameOf returns true if `f` is executing the function `id`, and not
+// calling another one tracked under another module.
+func isFrameOf(f *frame, id uint32) bool {
+	return !f.calling && f.stack[len(f.stack)-1] == id
+}
+
+// stackKey returns a map key unique to the stack.
+func stackKey(stack []uint32) string {
+	buf := make([]byte, 4*len(stack))
+	for i, id := range stack {
+		binary.LittleEndian.PutUint32(buf[4*i:], id)
+	}
+	return string(buf)
+}
+
+// listener implements experimental.FunctionListener to track the call stacks
+// of the Profiler.
+type listener struct {
+	p *Profiler
+	// id is the index of the function in Profiler.functions.
+	id uint32
+}
+
+// Before implements the same method as documented on
+// experimental.FunctionListener.
+func (l *listener) Before(_ context.Context, mod api.Module, _ api.FunctionDefinition, _ []uint64, si experimental.StackIterator) {
+	var callerDef api.FunctionDefinition
+	si.Next() // The called function.
+	if si.Next() {
+		callerDef = si.Function().Definition()
+	}
+	l.p.push(mod, l.id, callerDef)
+}
+
+// After implements the same method as documented on
+// experimental.FunctionListener.
+func (l *listener) After(_ context.Context, mod api.Module, _ api.FunctionDefinition, _ []uint64) {
+	l.p.pop(mod)
+}
+
+// Abort implements the same method as documented on
+// experimental.FunctionListener.
+func (l *listener) Abort(_ context.Context, mod api.Module, _ api.FunctionDefinition, _ error) {
+	l.p.pop(mod)
+}
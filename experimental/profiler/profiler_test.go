@@ -0,0 +1,198 @@
+package profiler_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/experimental/profiler"
+	"github.com/tetratelabs/wazero/internal/testing/binaryencoding"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
+var testCtx = context.WithValue(context.Background(), struct{}{}, "arbitrary")
+
+func TestProfiler(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config wazero.RuntimeConfig
+	}{
+		{name: "interpreter", config: wazero.NewRuntimeConfigInterpreter()},
+		{name: "default", config: wazero.NewRuntimeConfig()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := profiler.NewProfiler(time.Millisecond)
+			ctx := context.WithValue(testCtx, experimental.FunctionListenerFactoryKey{}, p)
+
+			r := wazero.NewRuntimeWithConfig(ctx, tc.config)
+			defer r.Close(ctx)
+
+			_, err := r.NewHostModuleBuilder("host").NewFunctionBuilder().WithFunc(func() {
+				time.Sleep(50 * time.Millisecond)
+			}).Export("sleep").Instantiate(ctx)
+			require.NoError(t, err)
+
+			bin := binaryencoding.EncodeModule(&wasm.Module{
+				TypeSection:     []wasm.FunctionType{{}},
+				ImportSection:   []wasm.Import{{Module: "host", Name: "sleep"}},
+				FunctionSection: []wasm.Index{0, 0},
+				CodeSection: []wasm.Code{
+					{Body: []byte{wasm.OpcodeCall, 2, wasm.OpcodeEnd}},
+					{Body: []byte{wasm.OpcodeCall, 0, wasm.OpcodeEnd}},
+				},
+				ExportSection: []wasm.Export{{Name: "fn1", Type: wasm.ExternTypeFunc, Index: 1}},
+				NameSection: &wasm.NameSection{
+					ModuleName:    "test",
+					FunctionNames: wasm.NameMap{{Index: 1, Name: "fn1"}, {Index: 2, Name: "fn2"}},
+				},
+			})
+			mod, err := r.Instantiate(ctx, bin)
+			require.NoError(t, err)
+
+			// Nothing is sampled until started.
+			_, err = mod.ExportedFunction("fn1").Call(ctx)
+			require.NoError(t, err)
+			var buf bytes.Buffer
+			require.NoError(t, p.WriteFolded(&buf))
+			require.Equal(t, "", buf.String())
+
+			p.Start()
+			_, err = mod.ExportedFunction("fn1").Call(ctx)
+			require.NoError(t, err)
+			p.Stop()
+
+			// The host function sleeps for many periods, so it's sampled
+			// regardless of the scheduling of the sampling goroutine.
+			buf.Reset()
+			require.NoError(t, p.WriteFolded(&buf))
+			require.True(t, strings.HasPrefix(buf.String(), "test.fn1;test.fn2;host.sleep "), buf.String())
+
+			p.Reset()
+			buf.Reset()
+			require.NoError(t, p.WriteFolded(&buf))
+			require.Equal(t, "", buf.String())
+		})
+	}
+}
+
+func TestProfiler_concurrent(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config wazero.RuntimeConfig
+	}{
+		{name: "interpreter", config: wazero.NewRuntimeConfigInterpreter()},
+		{name: "default", config: wazero.NewRuntimeConfig()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := profiler.NewProfiler(time.Millisecond)
+			ctx := context.WithValue(testCtx, experimental.FunctionListenerFactoryKey{}, p)
+
+			r := wazero.NewRuntimeWithConfig(ctx, tc.config)
+			defer r.Close(ctx)
+
+			// Both instances sleep at once, until released.
+			var entered, wg sync.WaitGroup
+			entered.Add(2)
+			release := make(chan struct{})
+			_, err := r.NewHostModuleBuilder("host").NewFunctionBuilder().WithFunc(func() {
+				entered.Done()
+				<-release
+			}).Export("sleep").Instantiate(ctx)
+			require.NoError(t, err)
+
+			var mods []api.Module
+			for _, name := range []string{"a", "b"} {
+				mod, err := r.Instantiate(ctx, sleepModule(name, "host", "sleep"))
+				require.NoError(t, err)
+				mods = append(mods, mod)
+			}
+
+			p.Start()
+			for _, mod := range mods {
+				wg.Add(1)
+				go func(mod api.Module) {
+					defer wg.Done()
+					_, err := mod.ExportedFunction("fn").Call(ctx)
+					require.NoError(t, err)
+				}(mod)
+			}
+			entered.Wait()
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+			p.Stop()
+
+			// The call stacks of both are sampled.
+			var buf bytes.Buffer
+			require.NoError(t, p.WriteFolded(&buf))
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			require.Equal(t, 2, len(lines), buf.String())
+			require.True(t, strings.HasPrefix(lines[0], "a.fn;host.sleep "), buf.String())
+			require.True(t, strings.HasPrefix(lines[1], "b.fn;host.sleep "), buf.String())
+		})
+	}
+}
+
+func TestProfiler_importedGuest(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config wazero.RuntimeConfig
+	}{
+		{name: "interpreter", config: wazero.NewRuntimeConfigInterpreter()},
+		{name: "default", config: wazero.NewRuntimeConfig()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := profiler.NewProfiler(time.Millisecond)
+			ctx := context.WithValue(testCtx, experimental.FunctionListenerFactoryKey{}, p)
+
+			r := wazero.NewRuntimeWithConfig(ctx, tc.config)
+			defer r.Close(ctx)
+
+			_, err := r.NewHostModuleBuilder("host").NewFunctionBuilder().WithFunc(func() {
+				time.Sleep(50 * time.Millisecond)
+			}).Export("sleep").Instantiate(ctx)
+			require.NoError(t, err)
+			_, err = r.Instantiate(ctx, sleepModule("callee", "host", "sleep"))
+			require.NoError(t, err)
+			mod, err := r.Instantiate(ctx, sleepModule("caller", "callee", "fn"))
+			require.NoError(t, err)
+
+			p.Start()
+			_, err = mod.ExportedFunction("fn").Call(ctx)
+			require.NoError(t, err)
+			p.Stop()
+
+			// Functions of the imported module are sampled in the call stack
+			// of the caller, which isn't sampled on its own meanwhile.
+			var buf bytes.Buffer
+			require.NoError(t, p.WriteFolded(&buf))
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			require.Equal(t, 1, len(lines), buf.String())
+			require.True(t, strings.HasPrefix(lines[0], "caller.fn;callee.fn;host.sleep "), buf.String())
+		})
+	}
+}
+
+// sleepModule returns a module named `name` exporting "fn", which calls the
+// function `importName` imported from `importModule`.
+func sleepModule(name, importModule, importName string) []byte {
+	return binaryencoding.EncodeModule(&wasm.Module{
+		TypeSection:     []wasm.FunctionType{{}},
+		ImportSection:   []wasm.Import{{Module: importModule, Name: importName}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection:     []wasm.Code{{Body: []byte{wasm.OpcodeCall, 0, wasm.OpcodeEnd}}},
+		ExportSection:   []wasm.Export{{Name: "fn", Type: wasm.ExternTypeFunc, Index: 1}},
+		NameSection: &wasm.NameSection{
+			ModuleName:    name,
+			FunctionNames: wasm.NameMap{{Index: 1, Name: "fn"}},
+		},
+	})
+}
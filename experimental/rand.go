@@ -0,0 +1,21 @@
+package experimental
+
+import (
+	"context"
+
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
+)
+
+// WithRandSeed registers the given seed into the given context.Context. Each
+// guest module instantiated with it, which doesn't configure
+// wazero.ModuleConfig WithRandSource, gets its own deterministic source of
+// random bytes, keyed by the seed and the module name.
+//
+// This allows replaying an execution byte-for-byte, as reads by one module,
+// e.g. via WASI random_get, don't change the values another module reads.
+//
+// Note: The source is not cryptographically secure. Use distinct module
+// names to get distinct values when instantiating the same module many times.
+func WithRandSeed(ctx context.Context, seed int64) context.Context {
+	return context.WithValue(ctx, internalsys.RandSeedKey{}, seed)
+}
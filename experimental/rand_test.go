@@ -0,0 +1,15 @@
+package experimental_test
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/experimental"
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestWithRandSeed(t *testing.T) {
+	ctx := experimental.WithRandSeed(testCtx, 7)
+
+	require.Equal(t, int64(7), ctx.Value(internalsys.RandSeedKey{}))
+}
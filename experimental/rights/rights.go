@@ -0,0 +1,78 @@
+// Package rights allows the host to restrict the WASI rights of pre-opened
+// directories, such as whether a guest can open files in them or write to
+// those files.
+//
+// By default, wazero ignores rights, as they were removed from WASI. When
+// restricted, each operation requires its right, and files opened from a
+// directory can't have rights it doesn't pass on. For example, a directory
+// without PathOpen can't be traversed. Operations on file descriptors fail
+// with EBADF and path operations with EACCES, as wasi-libc converts
+// ENOTCAPABLE to similar errors.
+package rights
+
+import (
+	"context"
+
+	"github.com/tetratelabs/wazero/internal/sys"
+)
+
+// Rights are the bit flags defined by WASI as "rights".
+//
+// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-rights-flagsu64
+type Rights uint64
+
+const (
+	FdDatasync Rights = 1 << iota
+	FdRead
+	FdSeek
+	FdFdstatSetFlags
+	FdSync
+	FdTell
+	FdWrite
+	FdAdvise
+	FdAllocate
+	PathCreateDirectory
+	PathCreateFile
+	PathLinkSource
+	PathLinkTarget
+	PathOpen
+	FdReaddir
+	PathReadlink
+	PathRenameSource
+	PathRenameTarget
+	PathFilestatGet
+	PathFilestatSetSize
+	PathFilestatSetTimes
+	FdFilestatGet
+	FdFilestatSetSize
+	FdFilestatSetTimes
+	PathSymlink
+	PathRemoveDirectory
+	PathUnlinkFile
+	PollFdReadwrite
+	SockShutdown
+
+	// All are all rights, which is the default for pre-opened directories.
+	All = SockShutdown<<1 - 1
+
+	// ReadOnly are the rights to traverse a directory and read its files,
+	// without changing them.
+	ReadOnly = FdRead | FdSeek | FdTell | FdAdvise | FdFilestatGet |
+		FdReaddir | PathOpen | PathReadlink | PathFilestatGet | PollFdReadwrite
+)
+
+// WithPreopens registers the rights of pre-opened directories, keyed by guest
+// path, into the given context.Context. This applies to modules instantiated
+// with it. Directories not in `preopens` have All rights, but files opened
+// from them still only have the rights the guest requested.
+//
+// For example, this restricts "/data" to reading, even when mounted writable:
+//
+//	ctx = rights.WithPreopens(ctx, map[string]rights.Rights{"/data": rights.ReadOnly})
+func WithPreopens(ctx context.Context, preopens map[string]Rights) context.Context {
+	config := make(sys.RightsConfig, len(preopens))
+	for guestPath, r := range preopens {
+		config[guestPath] = uint64(r)
+	}
+	return context.WithValue(ctx, sys.RightsConfigKey{}, config)
+}
@@ -0,0 +1,24 @@
+package rights_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero/experimental/rights"
+	"github.com/tetratelabs/wazero/internal/sys"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasip1"
+)
+
+func TestRights(t *testing.T) {
+	// Ensure the flags are the same as WASI defines.
+	require.Equal(t, uint64(wasip1.RIGHT_FD_READ), uint64(rights.FdRead))
+	require.Equal(t, uint64(wasip1.RIGHT_SOCK_SHUTDOWN), uint64(rights.SockShutdown))
+	require.Equal(t, uint64(wasip1.RIGHT_SOCK_SHUTDOWN<<1-1), uint64(rights.All))
+}
+
+func TestWithPreopens(t *testing.T) {
+	ctx := rights.WithPreopens(context.Background(), map[string]rights.Rights{"/": rights.ReadOnly})
+
+	require.Equal(t, sys.RightsConfig{"/": uint64(rights.ReadOnly)}, ctx.Value(sys.RightsConfigKey{}))
+}
@@ -2,6 +2,7 @@ package sock
 
 import (
 	"context"
+	"net"
 
 	"github.com/tetratelabs/wazero/internal/sock"
 )
@@ -14,6 +15,25 @@ import (
 type Config interface {
 	// WithTCPListener configures the host to set up the given host:port listener.
 	WithTCPListener(host string, port int) Config
+
+	// WithListener pre-opens a listener the host already created, after any
+	// from WithTCPListener. This allows the host to choose how it is created,
+	// e.g. to use systemd socket activation. Only *net.TCPListener is
+	// supported, otherwise module instantiation fails.
+	//
+	// Note: Use a listener per module instance, as it may be closed when the
+	// guest closes its file descriptor.
+	WithListener(ln net.Listener) Config
+
+	// WithSockOpen allows the guest to create TCP or UDP sockets with the
+	// WasmEdge compatible sock_open function, then bind, listen or connect
+	// them. Without this, sock_open fails with ENOTSUP.
+	//
+	// `permit` is called with the operation "bind", "connect" or "send_to",
+	// the network "tcp4", "tcp6", "udp4" or "udp6" and the "host:port"
+	// address. When it returns false, the operation fails with EACCES. A nil
+	// `permit` allows any address.
+	WithSockOpen(permit func(op, network, address string) bool) Config
 }
 
 // NewConfig returns a Config for module instantiation.
@@ -33,9 +53,21 @@ func (c *internalSockConfig) WithTCPListener(host string, port int) Config {
 	return &internalSockConfig{cNew}
 }
 
+// WithListener implements Config.WithListener
+func (c *internalSockConfig) WithListener(ln net.Listener) Config {
+	cNew := c.c.WithListener(ln)
+	return &internalSockConfig{cNew}
+}
+
+// WithSockOpen implements Config.WithSockOpen
+func (c *internalSockConfig) WithSockOpen(permit func(op, network, address string) bool) Config {
+	cNew := c.c.WithSockOpen(permit)
+	return &internalSockConfig{cNew}
+}
+
 // WithConfig registers the given Config into the given context.Context.
 func WithConfig(ctx context.Context, config Config) context.Context {
-	if config, ok := config.(*internalSockConfig); ok && len(config.c.TCPAddresses) > 0 {
+	if config, ok := config.(*internalSockConfig); ok && (len(config.c.TCPAddresses) > 0 || len(config.c.TCPListeners) > 0 || config.c.Permit != nil) {
 		return context.WithValue(ctx, sock.ConfigKey{}, config.c)
 	}
 	return ctx
@@ -2,6 +2,7 @@ package sock_test
 
 import (
 	"context"
+	"net"
 	"testing"
 
 	"github.com/tetratelabs/wazero/experimental/sock"
@@ -32,6 +33,11 @@ func TestWithSockConfig(t *testing.T) {
 			sockCfg:  sock.NewConfig().WithTCPListener("", 0),
 			expected: true,
 		},
+		{
+			name:     "decorates with listener",
+			sockCfg:  sock.NewConfig().WithListener(&net.TCPListener{}),
+			expected: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,57 @@
+package experimental
+
+import (
+	"context"
+
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
+)
+
+// StdioType is the type of file a guest sees for stdin, stdout or stderr, for
+// example via fd_fdstat_get in WASI.
+type StdioType = internalsys.StdioType
+
+const (
+	// StdioTypeDetect detects the type from the host. This is the default,
+	// where only an os.File can be a terminal or pipe.
+	StdioTypeDetect = internalsys.StdioTypeDetect
+	// StdioTypeTerminal is a character device, so isatty is true.
+	StdioTypeTerminal = internalsys.StdioTypeTerminal
+	// StdioTypePipe is a named pipe (FIFO).
+	StdioTypePipe = internalsys.StdioTypePipe
+	// StdioTypeFile is a regular file.
+	StdioTypeFile = internalsys.StdioTypeFile
+)
+
+// StdioConfig overrides the type of file a guest sees for stdio. This allows
+// guests which decide behavior with isatty, such as line buffering or colored
+// output, to behave correctly when stdio isn't an os.File. For example, when
+// stdout is a bytes.Buffer or a network stream to a terminal.
+//
+// Note: WASI has no block size in file stats, so it can't be overridden.
+type StdioConfig struct {
+	Stdin, Stdout, Stderr StdioType
+}
+
+// WithStdioConfig registers the given StdioConfig into the given
+// context.Context, which applies to modules instantiated with it.
+func WithStdioConfig(ctx context.Context, config StdioConfig) context.Context {
+	c := internalsys.StdioConfig(config)
+	return context.WithValue(ctx, internalsys.StdioConfigKey{}, &c)
+}
+
+// WithRawStdin returns a context which puts stdin into raw mode while modules
+// instantiated with it are open, if stdin is an os.File for a terminal, such
+// as os.Stdin. The previous mode is restored when the module is closed, or if
+// instantiation fails. This has no effect on other stdin, or on platforms
+// where raw mode isn't yet supported: only darwin, freebsd and linux.
+//
+// In raw mode, each keystroke is readable as soon as it's typed, without echo
+// or line editing, and keys such as Ctrl-C are read instead of signalling the
+// host. This is useful for guests implementing terminal UIs or line editors.
+// To also read without blocking, guests can set the non-blocking flag on
+// stdin, e.g. with fd_fdstat_set_flags in WASI, or poll it before reading.
+//
+// Note: As a terminal has one mode, only use this for one module at a time.
+func WithRawStdin(ctx context.Context) context.Context {
+	return context.WithValue(ctx, internalsys.RawStdinKey{}, true)
+}
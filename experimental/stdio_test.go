@@ -0,0 +1,21 @@
+package experimental_test
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/experimental"
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestWithStdioConfig(t *testing.T) {
+	ctx := experimental.WithStdioConfig(testCtx, experimental.StdioConfig{Stdout: experimental.StdioTypeTerminal})
+
+	require.Equal(t, &internalsys.StdioConfig{Stdout: internalsys.StdioTypeTerminal}, ctx.Value(internalsys.StdioConfigKey{}))
+}
+
+func TestWithRawStdin(t *testing.T) {
+	ctx := experimental.WithRawStdin(testCtx)
+
+	require.Equal(t, true, ctx.Value(internalsys.RawStdinKey{}))
+}
@@ -0,0 +1,79 @@
+package suspend
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// Await suspends the call which called the current host function, which then
+// returns an error holding a Pending call. See PendingOf.
+//
+// Unlike Suspend, this doesn't capture the state of the module, as the call
+// is resumed in the same module instance. This allows a host function to
+// start asynchronous work, e.g. an HTTP request, without blocking a goroutine
+// until it completes. For example:
+//
+//	func fetch(ctx context.Context, mod api.Module, stack []uint64) {
+//		ptr, size := api.DecodeU32(stack[0]), api.DecodeU32(stack[1])
+//		url, _ := mod.Memory().Read(ptr, size)
+//		url = append([]byte(nil), url...)
+//		go func() { responses <- doFetch(string(url)) }()
+//		suspend.Await()
+//	}
+//
+// Note: `stack` and the memory are only valid until Await returns control to
+// the caller, which may grow the memory or make other calls. Copy the values
+// the asynchronous work needs before calling Await, as above.
+//
+// The caller resumes the call with the result when the work completes:
+//
+//	_, err := mod.ExportedFunction("run").Call(ctx)
+//	for {
+//		p, ok := suspend.PendingOf(err)
+//		if !ok {
+//			break
+//		}
+//		_, err = p.Resume(ctx, <-responses)
+//	}
+//
+// This panics, so never returns. Call it from a host function called by the
+// guest. Unlike Suspend, this is supported by the compiler, which keeps the
+// stack of the call until it is resumed.
+func Await() {
+	wasm.Await()
+}
+
+// Pending is a call suspended by Await, which can be resumed once.
+//
+// Other calls to the module can be made before it is resumed, e.g. to run
+// other pending calls. They share the module's memory and globals, but each
+// call has its own stack.
+type Pending struct {
+	m       *wasm.ModuleInstance
+	call    *wasm.CallState
+	resumed atomic.Bool
+}
+
+// PendingOf returns the Pending call of a call which failed as it was
+// suspended by Await, or false if `err` is from another failure.
+func PendingOf(err error) (*Pending, bool) {
+	var suspended *wasm.SuspendedError
+	if errors.As(err, &suspended) && suspended.Call != nil {
+		return &Pending{m: suspended.Module, call: suspended.Call}, true
+	}
+	return nil, false
+}
+
+// Resume resumes the call as if the host function which suspended it returned
+// `results`. This returns the results of the call, or an error like
+// api.Function Call, which may hold another Pending call if it was suspended
+// again.
+func (p *Pending) Resume(ctx context.Context, results ...uint64) ([]uint64, error) {
+	if !p.resumed.CompareAndSwap(false, true) {
+		return nil, errors.New("call already resumed")
+	}
+	return p.m.ResumeCall(ctx, p.call, results)
+}
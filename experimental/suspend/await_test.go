@@ -0,0 +1,92 @@
+package suspend_test
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental/suspend"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// instantiateAwait instantiates workflowWasm, where "env.await" sends its
+// parameter to `awaited`, and suspends with Await.
+func instantiateAwait(t *testing.T, config wazero.RuntimeConfig, awaited chan<- uint64) api.Module {
+	r := wazero.NewRuntimeWithConfig(testCtx, config)
+	t.Cleanup(func() { r.Close(testCtx) })
+
+	_, err := r.NewHostModuleBuilder("env").NewFunctionBuilder().
+		WithFunc(func(v uint32) uint32 {
+			awaited <- uint64(v)
+			suspend.Await()
+			return 0
+		}).Export("await").
+		Instantiate(testCtx)
+	require.NoError(t, err)
+
+	mod, err := r.Instantiate(testCtx, workflowWasm)
+	require.NoError(t, err)
+	return mod
+}
+
+func TestAwait(t *testing.T) {
+	t.Run("interpreter", func(t *testing.T) {
+		testAwait(t, wazero.NewRuntimeConfigInterpreter())
+	})
+	if platform.CompilerSupported() {
+		t.Run("compiler", func(t *testing.T) {
+			testAwait(t, wazero.NewRuntimeConfigCompiler())
+		})
+	}
+}
+
+func testAwait(t *testing.T, config wazero.RuntimeConfig) {
+	awaited := make(chan uint64, 1)
+	mod := instantiateAwait(t, config, awaited)
+
+	// Start two calls, which are pending at the same time.
+	_, err := mod.ExportedFunction("run").Call(testCtx, 2)
+	first, ok := suspend.PendingOf(err)
+	require.True(t, ok)
+	require.Equal(t, uint64(2), <-awaited)
+
+	_, err = mod.ExportedFunction("run").Call(testCtx, 1)
+	second, ok := suspend.PendingOf(err)
+	require.True(t, ok)
+	require.Equal(t, uint64(1), <-awaited)
+	_, ok = suspend.CheckpointOf(err)
+	require.False(t, ok)
+
+	// Complete the second call, then the first, which awaits again.
+	results, err := second.Resume(testCtx, 5)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{10}, results)
+
+	_, err = first.Resume(testCtx, 10)
+	first2, ok := suspend.PendingOf(err)
+	require.True(t, ok)
+	require.Equal(t, uint64(1), <-awaited)
+
+	results, err = first2.Resume(testCtx, 20)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{(10 + 20) * 2}, results)
+
+	// Each call counted its steps in the shared global.
+	require.Equal(t, uint64(3), mod.ExportedGlobal("steps").Get())
+
+	t.Run("resumed twice", func(t *testing.T) {
+		_, err := first.Resume(testCtx, 10)
+		require.EqualError(t, err, "call already resumed")
+	})
+
+	t.Run("wrong results", func(t *testing.T) {
+		_, err := mod.ExportedFunction("run").Call(testCtx, 1)
+		p, ok := suspend.PendingOf(err)
+		require.True(t, ok)
+		<-awaited
+
+		_, err = p.Resume(testCtx)
+		require.EqualError(t, err, "expected 1 results, but passed 0")
+	})
+}
@@ -0,0 +1,105 @@
+// Package suspend allows suspending a call to a module at a host function,
+// capturing the state of the module and of the call in a Checkpoint, and
+// resuming it later, possibly in another process. To resume the call in the
+// same process, e.g. when asynchronous work completes, see Await.
+//
+// This is useful for durable execution, such as workflow engines, where a
+// guest waits for an external event, e.g. the result of an activity, which may
+// take longer than the process runs. For example, a host function which waits
+// for an activity suspends the call:
+//
+//	func awaitActivity(ctx context.Context, stack []uint64) {
+//		suspend.Suspend()
+//	}
+//
+// The caller gets the checkpoint from the error of the call and persists it:
+//
+//	_, err := mod.ExportedFunction("run").Call(ctx)
+//	if c, ok := suspend.CheckpointOf(err); ok {
+//		data, err := c.Marshal()
+//		// persist data
+//	}
+//
+// Later, the caller resumes the call in a new instance of the same module,
+// passing the results of the host function:
+//
+//	c, err := suspend.UnmarshalCheckpoint(data)
+//	results, err := suspend.Resume(ctx, mod, c, activityResult)
+//
+// # Notes
+//
+//   - This is an experimental API. Suspend is only supported by the
+//     interpreter, as the compiler can't capture a call running on its stack
+//     into a Checkpoint. Await is supported by both.
+//   - Only calls within a single module can be suspended, e.g. not through a
+//     function imported from another Wasm module.
+//   - A host function which calls back into the guest can't be suspended, as
+//     its Go stack can't be captured.
+//   - The state of imported memories, globals and tables isn't captured.
+//   - Function listeners aren't notified of the frames which are resumed.
+package suspend
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// Suspend suspends the call which called the current host function, which
+// then returns an error holding its Checkpoint. See CheckpointOf.
+//
+// This panics, so never returns. Call it from a host function called by the
+// guest, after releasing any resources the host function holds.
+func Suspend() {
+	wasm.Suspend()
+}
+
+// Checkpoint is the state of a module instance, including its memory and
+// globals, and of a call to it, including its value stack and the position in
+// each function, which was suspended by Suspend.
+type Checkpoint struct {
+	c *wasm.Checkpoint
+}
+
+// CheckpointOf returns the Checkpoint of a call which failed as it was
+// suspended, or false if `err` is from another failure.
+func CheckpointOf(err error) (*Checkpoint, bool) {
+	var suspended *wasm.SuspendedError
+	if errors.As(err, &suspended) && suspended.Checkpoint != nil {
+		return &Checkpoint{c: suspended.Checkpoint}, true
+	}
+	return nil, false
+}
+
+// Marshal encodes the checkpoint, so that UnmarshalCheckpoint can decode it in
+// a process running the same version of wazero.
+//
+// This errs if the globals or tables of the module hold a non-null externref,
+// as it is a host pointer.
+func (c *Checkpoint) Marshal() ([]byte, error) {
+	return c.c.Marshal()
+}
+
+// UnmarshalCheckpoint decodes a checkpoint encoded by Checkpoint.Marshal.
+func UnmarshalCheckpoint(data []byte) (*Checkpoint, error) {
+	c, err := wasm.UnmarshalCheckpoint(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Checkpoint{c: c}, nil
+}
+
+// Resume restores the state of `mod` from the checkpoint, and resumes the
+// suspended call as if the host function which suspended it returned
+// `results`. This returns the results of the call, or an error like
+// api.Function Call, which may hold another Checkpoint if it was suspended
+// again.
+//
+// `mod` must be an instance of the same module as the one of the checkpoint,
+// compiled with the same configuration, e.g. function listeners. It is
+// usually a new instance, as its state is overwritten.
+func Resume(ctx context.Context, mod api.Module, c *Checkpoint, results ...uint64) ([]uint64, error) {
+	return mod.(*wasm.ModuleInstance).Resume(ctx, c.c, results)
+}
@@ -0,0 +1,163 @@
+package suspend_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental/suspend"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/binaryencoding"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
+var testCtx = context.WithValue(context.Background(), struct{}{}, "arbitrary")
+
+// workflowWasm exports "run", which sums twice the results of "env.await" for
+// each of its parameter down to 1, counting the steps in the global "steps".
+var workflowWasm = binaryencoding.EncodeModule(&wasm.Module{
+	TypeSection: []wasm.FunctionType{{
+		Params:            []api.ValueType{api.ValueTypeI32},
+		Results:           []api.ValueType{api.ValueTypeI32},
+		ParamNumInUint64:  1,
+		ResultNumInUint64: 1,
+	}},
+	ImportSection:   []wasm.Import{{Module: "env", Name: "await", Type: wasm.ExternTypeFunc, DescFunc: 0}},
+	FunctionSection: []wasm.Index{0, 0},
+	GlobalSection: []wasm.Global{{
+		Type: wasm.GlobalType{ValType: api.ValueTypeI32, Mutable: true},
+		Init: wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+	}},
+	CodeSection: []wasm.Code{
+		// step returns twice the result of await.
+		{Body: []byte{
+			wasm.OpcodeLocalGet, 0, wasm.OpcodeCall, 0, wasm.OpcodeI32Const, 2, wasm.OpcodeI32Mul, wasm.OpcodeEnd,
+		}},
+		// run loops down to 1, adding the results of step to a local.
+		{LocalTypes: []api.ValueType{api.ValueTypeI32}, Body: []byte{
+			wasm.OpcodeLoop, 0x40,
+			wasm.OpcodeLocalGet, 1, wasm.OpcodeLocalGet, 0, wasm.OpcodeCall, 1, wasm.OpcodeI32Add, wasm.OpcodeLocalSet, 1,
+			wasm.OpcodeGlobalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add, wasm.OpcodeGlobalSet, 0,
+			wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Sub, wasm.OpcodeLocalTee, 0,
+			wasm.OpcodeBrIf, 0,
+			wasm.OpcodeEnd,
+			wasm.OpcodeLocalGet, 1,
+			wasm.OpcodeEnd,
+		}},
+	},
+	ExportSection: []wasm.Export{
+		{Name: "run", Type: wasm.ExternTypeFunc, Index: 2},
+		{Name: "steps", Type: wasm.ExternTypeGlobal, Index: 0},
+	},
+})
+
+// instantiate instantiates workflowWasm in a new runtime, so that each call to
+// it is like a new process. "env.await" records its parameter, and suspends.
+func instantiate(t *testing.T, config wazero.RuntimeConfig, awaited *uint64) api.Module {
+	r := wazero.NewRuntimeWithConfig(testCtx, config)
+	t.Cleanup(func() { r.Close(testCtx) })
+
+	_, err := r.NewHostModuleBuilder("env").NewFunctionBuilder().
+		WithFunc(func(v uint32) uint32 {
+			*awaited = uint64(v)
+			suspend.Suspend()
+			return 0
+		}).Export("await").
+		Instantiate(testCtx)
+	require.NoError(t, err)
+
+	mod, err := r.Instantiate(testCtx, workflowWasm)
+	require.NoError(t, err)
+	return mod
+}
+
+func TestResume(t *testing.T) {
+	config := wazero.NewRuntimeConfigInterpreter()
+	var awaited uint64
+
+	mod := instantiate(t, config, &awaited)
+	_, err := mod.ExportedFunction("run").Call(testCtx, 3)
+	c, ok := suspend.CheckpointOf(err)
+	require.True(t, ok)
+	require.Equal(t, uint64(3), awaited)
+
+	// Resume each step in a new runtime, as if persisted by another process.
+	for _, step := range []struct{ result, awaited uint64 }{{10, 2}, {20, 1}} {
+		data, err := c.Marshal()
+		require.NoError(t, err)
+		c, err = suspend.UnmarshalCheckpoint(data)
+		require.NoError(t, err)
+
+		mod = instantiate(t, config, &awaited)
+		_, err = suspend.Resume(testCtx, mod, c, step.result)
+		c, ok = suspend.CheckpointOf(err)
+		require.True(t, ok)
+		require.Equal(t, step.awaited, awaited)
+	}
+
+	mod = instantiate(t, config, &awaited)
+	results, err := suspend.Resume(testCtx, mod, c, 30)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{(10 + 20 + 30) * 2}, results)
+	require.Equal(t, uint64(3), mod.ExportedGlobal("steps").Get())
+
+	// The module can be called as usual after resuming.
+	_, err = mod.ExportedFunction("run").Call(testCtx, 1)
+	_, ok = suspend.CheckpointOf(err)
+	require.True(t, ok)
+}
+
+func TestResume_Errors(t *testing.T) {
+	config := wazero.NewRuntimeConfigInterpreter()
+	var awaited uint64
+	mod := instantiate(t, config, &awaited)
+	_, err := mod.ExportedFunction("run").Call(testCtx, 1)
+	c, ok := suspend.CheckpointOf(err)
+	require.True(t, ok)
+
+	t.Run("not suspended", func(t *testing.T) {
+		_, ok := suspend.CheckpointOf(errors.New("wasm error: unreachable"))
+		require.False(t, ok)
+	})
+
+	t.Run("wrong results", func(t *testing.T) {
+		_, err := suspend.Resume(testCtx, instantiate(t, config, &awaited), c)
+		require.EqualError(t, err, "expected 1 results, but passed 0")
+	})
+
+	t.Run("another module", func(t *testing.T) {
+		r := wazero.NewRuntimeWithConfig(testCtx, config)
+		defer r.Close(testCtx)
+		other, err := r.Instantiate(testCtx, binaryencoding.EncodeModule(&wasm.Module{}))
+		require.NoError(t, err)
+
+		_, err = suspend.Resume(testCtx, other, c, 1)
+		require.EqualError(t, err, "checkpoint is of another module")
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		data, err := c.Marshal()
+		require.NoError(t, err)
+
+		_, err = suspend.UnmarshalCheckpoint(data[:len(data)-1])
+		require.EqualError(t, err, "invalid checkpoint: unexpected EOF")
+		_, err = suspend.UnmarshalCheckpoint(append(data, 0))
+		require.EqualError(t, err, "invalid checkpoint: unexpected trailing bytes")
+		_, err = suspend.UnmarshalCheckpoint([]byte("WASM"))
+		require.EqualError(t, err, "invalid checkpoint: invalid header")
+	})
+
+	if platform.CompilerSupported() {
+		t.Run("compiler", func(t *testing.T) {
+			mod := instantiate(t, wazero.NewRuntimeConfigCompiler(), &awaited)
+			_, err := mod.ExportedFunction("run").Call(testCtx, 1)
+			require.Contains(t, err.Error(), "wasm error: suspending a call to a checkpoint is only supported by the interpreter")
+			_, ok := suspend.CheckpointOf(err)
+			require.False(t, ok)
+		})
+	}
+}
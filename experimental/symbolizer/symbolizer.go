@@ -0,0 +1,51 @@
+// Package symbolizer resolves offsets in the code section of a module to
+// source locations, using the DWARF custom sections of the module, e.g. for
+// listeners or profilers.
+//
+// Note: DWARF isn't read if RuntimeConfig.WithDebugInfoEnabled is false.
+package symbolizer
+
+import (
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// Location is a position in a source file.
+type Location struct {
+	// Function is the name of the function at this location in the source,
+	// or empty if unknown, e.g. "main.main".
+	Function string
+	// File is the path of the source file.
+	File string
+	// Line is the 1-based line number, or zero if unknown.
+	Line int64
+	// Column is the 1-based column number, or zero if unknown.
+	Column int64
+	// Inlined is true when Function was inlined into the function of the next
+	// Location.
+	Inlined bool
+}
+
+// Symbolize returns the source locations of `sourceOffset`, an offset in the
+// code section of the module defining `def`, or nil if it has no DWARF data
+// for it. When functions were inlined, the first Location is in the
+// innermost one, followed by those they were called from.
+//
+// For example, in experimental.FunctionListener Before:
+//
+//	for si.Next() {
+//		fn := si.Function()
+//		locations := symbolizer.Symbolize(fn.Definition(), fn.SourceOffsetForPC(si.ProgramCounter()))
+//		// ...
+//	}
+func Symbolize(def api.FunctionDefinition, sourceOffset uint64) []Location {
+	d, ok := def.(*wasm.FunctionDefinition)
+	if !ok || d.DWARFLines() == nil {
+		return nil
+	}
+	var ret []Location
+	for _, l := range d.DWARFLines().Locations(sourceOffset) {
+		ret = append(ret, Location(l))
+	}
+	return ret
+}
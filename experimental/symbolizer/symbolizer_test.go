@@ -0,0 +1,45 @@
+package symbolizer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental/symbolizer"
+	"github.com/tetratelabs/wazero/internal/testing/dwarftestdata"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
+var testCtx = context.WithValue(context.Background(), struct{}{}, "arbitrary")
+
+func TestSymbolize(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	compiled, err := r.CompileModule(testCtx, dwarftestdata.ZigWasm)
+	require.NoError(t, err)
+	def := compiled.ExportedFunctions()["_start"]
+
+	// 0x46 is the beginning of the code section, see TestDWARFLines_Line_Zig.
+	locations := symbolizer.Symbolize(def, 0xb0-0x46)
+	require.Equal(t, 2, len(locations))
+	require.Equal(t, "callMain", locations[0].Function)
+	require.Contains(t, locations[0].File, "lib/std/start.zig")
+	require.Equal(t, int64(609), locations[0].Line)
+	require.Equal(t, int64(37), locations[0].Column)
+	require.True(t, locations[0].Inlined)
+	require.Equal(t, int64(224), locations[1].Line)
+	require.False(t, locations[1].Inlined)
+
+	require.Nil(t, symbolizer.Symbolize(def, 1<<20))
+}
+
+func TestSymbolize_DebugInfoDisabled(t *testing.T) {
+	r := wazero.NewRuntimeWithConfig(testCtx, wazero.NewRuntimeConfig().WithDebugInfoEnabled(false))
+	defer r.Close(testCtx)
+
+	compiled, err := r.CompileModule(testCtx, dwarftestdata.ZigWasm)
+	require.NoError(t, err)
+	require.Nil(t, symbolizer.Symbolize(compiled.ExportedFunctions()["_start"], 0xb0-0x46))
+}
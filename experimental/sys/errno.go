@@ -24,6 +24,7 @@ const (
 	EACCES Errno = iota + 1
 	EAGAIN
 	EBADF
+	EDQUOT
 	EEXIST
 	EFAULT
 	EINTR
@@ -33,6 +34,7 @@ const (
 	ELOOP
 	ENAMETOOLONG
 	ENOENT
+	ENOSPC
 	ENOSYS
 	ENOTDIR
 	ERANGE
@@ -58,6 +60,8 @@ func (e Errno) Error() string {
 		return "resource unavailable, try again"
 	case EBADF:
 		return "bad file descriptor"
+	case EDQUOT:
+		return "disk quota exceeded"
 	case EEXIST:
 		return "file exists"
 	case EFAULT:
@@ -76,6 +80,8 @@ func (e Errno) Error() string {
 		return "filename too long"
 	case ENOENT:
 		return "no such file or directory"
+	case ENOSPC:
+		return "no space left on device"
 	case ENOSYS:
 		return "functionality not supported"
 	case ENOTDIR:
@@ -10,7 +10,7 @@ import (
 // including WASI and runtime.GOOS=js.
 //
 // Implementations should embed UnimplementedFS for forward compatability. Any
-// unsupported method or parameter should return ENO
+// unsupported method or parameter should return ENOSYS.
 //
 // # Errors
 //
@@ -18,6 +18,8 @@ func syscallToErrno(err error) (Errno, bool) {
 		return EAGAIN, true
 	case syscall.EBADF:
 		return EBADF, true
+	case syscall.EDQUOT:
+		return EDQUOT, true
 	case syscall.EEXIST:
 		return EEXIST, true
 	case syscall.EFAULT:
@@ -36,6 +38,8 @@ func syscallToErrno(err error) (Errno, bool) {
 		return ENAMETOOLONG, true
 	case syscall.ENOENT:
 		return ENOENT, true
+	case syscall.ENOSPC:
+		return ENOSPC, true
 	case syscall.ENOSYS:
 		return ENOSYS, true
 	case syscall.ENOTDIR:
@@ -68,6 +72,8 @@ func (e Errno) Unwrap() error {
 		return syscall.EAGAIN
 	case EBADF:
 		return syscall.EBADF
+	case EDQUOT:
+		return syscall.EDQUOT
 	case EEXIST:
 		return syscall.EEXIST
 	case EFAULT:
@@ -86,6 +92,8 @@ func (e Errno) Unwrap() error {
 		return syscall.ENAMETOOLONG
 	case ENOENT:
 		return syscall.ENOENT
+	case ENOSPC:
+		return syscall.ENOSPC
 	case ENOSYS:
 		return syscall.ENOSYS
 	case ENOTDIR:
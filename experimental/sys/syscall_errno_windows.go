@@ -11,6 +11,10 @@ const (
 	// instead of syscall.EBADF
 	_ERROR_INVALID_HANDLE = syscall.Errno(6)
 
+	// _ERROR_DISK_FULL is a Windows error returned by write instead of
+	// syscall.ENOSPC
+	_ERROR_DISK_FULL = syscall.Errno(0x70)
+
 	// _ERROR_INVALID_NAME is a Windows error returned by open when a file
 	// path has a trailing slash
 	_ERROR_INVALID_NAME = syscall.Errno(0x7B)
@@ -53,6 +57,8 @@ func errorToErrno(err error) Errno {
 			return EPERM
 		case _ERROR_NEGATIVE_SEEK, _ERROR_INVALID_NAME:
 			return EINVAL
+		case _ERROR_DISK_FULL:
+			return ENOSPC
 		}
 		errno, _ := syscallToErrno(err)
 		return errno
@@ -13,4 +13,11 @@ type FSConfig interface {
 	//
 	// This is an alternative to WithFSMount, allowing more features.
 	WithSysFSMount(fs experimentalsys.FS, guestPath string) wazero.FSConfig
+
+	// WithFSListener assigns a listener notified before and after each
+	// operation on any mounted filesystem, for example to audit which files a
+	// plugin accesses. Passing nil removes any listener.
+	//
+	// Note: Operations on open files, such as reads, are not notified.
+	WithFSListener(listener FSListener) wazero.FSConfig
 }
@@ -1,10 +1,14 @@
 package sysfs_test
 
 import (
+	"crypto/rand"
 	"io/fs"
+	"log"
 	"testing/fstest"
+	"time"
 
 	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental/sys"
 	"github.com/tetratelabs/wazero/experimental/sysfs"
 )
 
@@ -30,6 +34,29 @@ func ExampleDirFS() {
 		WithFSConfig(wazero.NewFSConfig().(sysfs.FSConfig).WithSysFSMount(root, "/"))
 }
 
+// This example shows how to mount a sysfs.MemFS as scratch space
+func ExampleMemFS() {
+	root := sysfs.DirFS(".")
+	tmp := sysfs.MemFS()
+
+	fsConfig := wazero.NewFSConfig().(sysfs.FSConfig).WithSysFSMount(root, "/")
+	fsConfig = fsConfig.(sysfs.FSConfig).WithSysFSMount(tmp, "/tmp")
+
+	moduleConfig = wazero.NewModuleConfig().WithFSConfig(fsConfig)
+}
+
+// This example shows how to let the guest modify a copy of a fs.FS
+func ExampleOverlayFS() {
+	m := fstest.MapFS{
+		"a/b.txt": &fstest.MapFile{Mode: 0o666},
+		".":       &fstest.MapFile{Mode: 0o777 | fs.ModeDir},
+	}
+	root := sysfs.OverlayFS(sysfs.MemFS(), &sysfs.AdaptFS{FS: m})
+
+	moduleConfig = wazero.NewModuleConfig().
+		WithFSConfig(wazero.NewFSConfig().(sysfs.FSConfig).WithSysFSMount(root, "/"))
+}
+
 // This example shows how to configure a sysfs.ReadFS
 func ExampleReadFS() {
 	root := sysfs.DirFS(".")
@@ -38,3 +65,68 @@ func ExampleReadFS() {
 	moduleConfig = wazero.NewModuleConfig().
 		WithFSConfig(wazero.NewFSConfig().(sysfs.FSConfig).WithSysFSMount(readOnly, "/"))
 }
+
+// This example shows how to limit what a guest can write with sysfs.QuotaFS
+func ExampleQuotaFS() {
+	root := sysfs.QuotaFS(sysfs.DirFS("/tmp/guest"), sysfs.Quota{
+		MaxBytes: 64 << 20, // 64 MiB
+		MaxFiles: 1000,
+		MaxDepth: 16,
+	})
+
+	moduleConfig = wazero.NewModuleConfig().
+		WithFSConfig(wazero.NewFSConfig().(sysfs.FSConfig).WithSysFSMount(root, "/"))
+}
+
+type auditListener struct{}
+
+func (auditListener) Before(sysfs.FSEvent) {}
+
+func (auditListener) After(e sysfs.FSEvent, errno sys.Errno, elapsed time.Duration) {
+	log.Printf("%s %s %s: %v (%s)", e.Op, e.GuestPath, e.Path, errno, elapsed)
+}
+
+// This example shows how to mount a host directory, for a guest which expects
+// case-insensitive paths like on macOS or Windows.
+func ExampleCaseInsensitiveFS() {
+	root := &sysfs.ReadFS{FS: sysfs.CaseInsensitiveFS(sysfs.DirFS("/tmp/guest"))}
+
+	moduleConfig = wazero.NewModuleConfig().
+		WithFSConfig(wazero.NewFSConfig().(sysfs.FSConfig).WithSysFSMount(root, "/"))
+}
+
+// This example shows how to reduce host syscalls for a guest which stats the
+// same paths repeatedly.
+func ExampleNewStatCacheFS() {
+	root := sysfs.NewStatCacheFS(sysfs.DirFS("/usr/lib/python3"), sysfs.StatCache{
+		MaxEntries: 4096,
+		TTL:        time.Minute,
+	})
+
+	moduleConfig = wazero.NewModuleConfig().
+		WithFSConfig(wazero.NewFSConfig().(sysfs.FSConfig).WithSysFSMount(root, "/usr/lib/python3"))
+
+	// After running the guest, report how effective the cache was.
+	m := root.Metrics()
+	log.Printf("stat cache: %d hits, %d misses", m.Hits, m.Misses)
+}
+
+// This example shows how to mount "/dev/null" and "/dev/urandom" without
+// access to the host. "/dev/urandom" reads from the module's random source.
+func ExampleDevFS() {
+	fsConfig := wazero.NewFSConfig().WithDirMount("/tmp/guest", "/").(sysfs.FSConfig).
+		WithSysFSMount(&sysfs.DevFS{}, "/dev")
+
+	moduleConfig = wazero.NewModuleConfig().
+		WithRandSource(rand.Reader).
+		WithFSConfig(fsConfig)
+}
+
+// This example shows how to audit which files a guest accesses with a
+// sysfs.FSListener
+func ExampleFSListener() {
+	fsConfig := wazero.NewFSConfig().WithDirMount(".", "/")
+
+	moduleConfig = wazero.NewModuleConfig().
+		WithFSConfig(fsConfig.(sysfs.FSConfig).WithFSListener(auditListener{}))
+}
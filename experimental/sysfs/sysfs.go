@@ -15,10 +15,12 @@ import (
 // AdaptFS adapts the input to sys.FS. Use DirFS instead of adapting an
 // os.DirFS as it handles interop issues such as windows support.
 //
-// Note: This performs no flag verification on OpenFile. sys.FS cannot read
-// flags as there is no parameter to pass them through with. Moreover, sys.FS
+// Note: This performs no flag verification on OpenFile. fs.FS cannot read
+// flags as there is no parameter to pass them through with. Moreover, fs.FS
 // documentation does not require the file to be present. In summary, we can't
-// enforce flag behavior.
+// enforce flag behavior. The exception is a fs.FS which also implements
+// `OpenFile(name string, flag int, perm fs.FileMode) (fs.File, error)`, which
+// is passed os.OpenFile style flags instead of calling Open.
 type AdaptFS = sysfs.AdaptFS
 
 // DirFS is like os.DirFS except it returns sys.FS, which has more features.
@@ -26,6 +28,40 @@ func DirFS(dir string) experimentalsys.FS {
 	return sysfs.DirFS(dir)
 }
 
+// MemFS returns a new writable sys.FS held entirely in memory, which is empty
+// except for its root directory. This is useful for scratch space such as
+// "/tmp", without giving the guest access to the host filesystem.
+//
+// Note: Nothing is persisted, so contents are lost once the result is no
+// longer referenced. Wrap it with ReadFS to prevent writes after populating.
+func MemFS() experimentalsys.FS {
+	return sysfs.MemFS()
+}
+
+// OverlayFS returns a sys.FS which layers a writable `upper` over a `lower`
+// sys.FS, which is never written. This allows a guest to modify files shipped
+// read-only, for example in an embed.FS, without changing them.
+//
+// Files in `upper` shadow those in `lower`, and directories in both are
+// merged. Writing a file only in `lower` first copies it to `upper`, and
+// removing it records a whiteout that hides it from then on.
+//
+// Note: Whiteouts are held in memory, so reusing `upper` with a new OverlayFS
+// shows files previously removed from `lower`.
+func OverlayFS(upper, lower experimentalsys.FS) experimentalsys.FS {
+	return sysfs.OverlayFS(upper, lower)
+}
+
+// CaseInsensitiveFS returns a sys.FS which looks up paths in `fs` without
+// regard to case, while preserving the case of created files. This allows
+// guests developed on macOS or Windows, which open "README.md" as "readme.md",
+// to work on a case-sensitive host directory, such as on Linux.
+//
+// Note: Wrap the result with ReadFS, not the reverse, for a read-only mount.
+func CaseInsensitiveFS(fs experimentalsys.FS) experimentalsys.FS {
+	return sysfs.CaseInsensitiveFS(fs)
+}
+
 // ReadFS is used to mask an existing sys.FS for reads. Notably, this allows
 // the CLI to do read-only mounts of directories the host user can write, but
 // doesn't want the guest wasm to. For example, Python libraries shouldn't be
@@ -34,3 +70,74 @@ func DirFS(dir string) experimentalsys.FS {
 // Note: This implements read-only by returning sys.EROFS or sys.EBADF,
 // depending on the operation that require write access.
 type ReadFS = sysfs.ReadFS
+
+// Quota limits how much a guest can write to a sys.FS returned by QuotaFS.
+// A zero field means unlimited.
+type Quota = sysfs.Quota
+
+// QuotaFS returns a sys.FS which limits the bytes written, files created and
+// path depth of `fs`, returning sys.ENOSPC or sys.EDQUOT when exceeded. This
+// prevents a guest from filling the host disk, for example in a multi-tenant
+// plugin host.
+//
+// Note: Usage is tracked in the result, so mount a new QuotaFS per module
+// instance to limit each separately.
+func QuotaFS(fs experimentalsys.FS, quota Quota) experimentalsys.FS {
+	return sysfs.QuotaFS(fs, quota)
+}
+
+// DevFS is a read-only sys.FS of the character devices "null", "zero",
+// "random", "urandom" and "tty", usually mounted at "/dev". This allows guests
+// which open "/dev/urandom" to run without access to the host filesystem.
+//
+// When mounted with FSConfig.WithSysFSMount, nil fields default to the
+// module's random source, stdin and stdout. For example:
+//
+//	fsConfig = fsConfig.WithSysFSMount(&sysfs.DevFS{}, "/dev")
+type DevFS = sysfs.DevFS
+
+// StatCache configures NewStatCacheFS. A zero MaxEntries defaults to 1024,
+// and a zero TTL keeps results until evicted or invalidated.
+type StatCache = sysfs.StatCache
+
+// StatCacheMetrics are the hit, miss, eviction and invalidation counts of a
+// StatCacheFS.
+type StatCacheMetrics = sysfs.StatCacheMetrics
+
+// StatCacheFS caches Stat and Lstat results of a sys.FS, to reduce host
+// syscalls for guests which stat the same paths repeatedly, such as python or
+// clang while starting. Writes through it clear the cache, and Invalidate
+// clears it after changes made outside the guest.
+type StatCacheFS = sysfs.StatCacheFS
+
+// NewStatCacheFS returns a StatCacheFS of `fs`.
+//
+// Note: Mount a new StatCacheFS per module instance, as results are shared.
+func NewStatCacheFS(fs experimentalsys.FS, cache StatCache) *StatCacheFS {
+	return sysfs.NewStatCacheFS(fs, cache)
+}
+
+// FSOp is an operation on a sys.FS, observed by FSListener.
+type FSOp = sysfs.FSOp
+
+const (
+	FSOpOpenFile = sysfs.FSOpOpenFile
+	FSOpLstat    = sysfs.FSOpLstat
+	FSOpStat     = sysfs.FSOpStat
+	FSOpMkdir    = sysfs.FSOpMkdir
+	FSOpChmod    = sysfs.FSOpChmod
+	FSOpRename   = sysfs.FSOpRename
+	FSOpRmdir    = sysfs.FSOpRmdir
+	FSOpUnlink   = sysfs.FSOpUnlink
+	FSOpLink     = sysfs.FSOpLink
+	FSOpSymlink  = sysfs.FSOpSymlink
+	FSOpReadlink = sysfs.FSOpReadlink
+	FSOpUtimens  = sysfs.FSOpUtimens
+)
+
+// FSEvent describes an operation on a mounted sys.FS.
+type FSEvent = sysfs.FSEvent
+
+// FSListener observes operations on a sys.FS mounted with
+// FSConfig.WithFSListener, for example to audit which files a guest accesses.
+type FSListener = sysfs.FSListener
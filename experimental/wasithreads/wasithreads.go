@@ -0,0 +1,240 @@
+// Package wasithreads runs modules built for wasi-threads, such as with
+// wasi-sdk's wasm32-wasi-threads target, by implementing its "wasi"
+// "thread-spawn" import.
+//
+// Such a module imports a shared memory, e.g. as "env" "memory", and exports
+// "wasi_thread_start". Instantiate instantiates it as the main thread, with a
+// new shared memory. Each thread it spawns is a new instance of the same
+// module, importing the same memory, which calls "wasi_thread_start" with its
+// thread ID and the argument passed to "thread-spawn" on a new goroutine.
+//
+// A thread ends when "wasi_thread_start" returns, and its instance is closed.
+// If it fails, e.g. with a trap or by calling "proc_exit", the main thread
+// and all others are closed with its exit code, as a process would. Closing
+// the main thread, e.g. when its "_start" calls "proc_exit", also closes the
+// threads.
+//
+// See https://github.com/WebAssembly/wasi-threads
+//
+// # Notes
+//
+//   - This is experimental, and likely to change.
+//   - The runtime must enable experimental.CoreFeaturesThreads.
+//   - Threads are instantiated with the wazero.ModuleConfig of the main
+//     thread, without start functions. So, each thread has its own file
+//     descriptors, opened from the same wazero.FSConfig, and writes to the
+//     same stdout and stderr.
+//   - The module the memory is imported from can't have other imports, as it
+//     is only satisfied by the memory.
+package wasithreads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// ModuleName is the module name the "thread-spawn" function is imported from.
+const ModuleName = "wasi"
+
+// maxThreadID is the maximum thread ID, as IDs are positive and the top three
+// bits are reserved.
+const maxThreadID = 0x1fffffff
+
+// program is a module instantiated by Instantiate, and its threads.
+type program struct {
+	r        wazero.Runtime
+	compiled wazero.CompiledModule
+	config   wazero.ModuleConfig
+	// imports satisfy the imports of ModuleName and of the memory.
+	imports map[string]api.Module
+	main    *wasm.ModuleInstance
+
+	mux     sync.Mutex
+	nextID  uint32
+	threads map[uint32]api.Module
+	closed  bool
+	// running counts the threads which haven't returned, so that the memory
+	// is only released once no thread accesses it.
+	running sync.WaitGroup
+}
+
+// Instantiate instantiates `compiled` with `config` as the main thread of a
+// wasi-threads program in `r`, with a new shared memory satisfying its
+// memory import, and the "thread-spawn" function satisfying its ModuleName
+// import. Other imports, e.g. of WASI, are satisfied by the modules
+// instantiated in `r` with that name.
+//
+// This errs if `compiled` doesn't import a memory. Closing the result also
+// closes the threads it spawned, and the modules satisfying its imports.
+func Instantiate(ctx context.Context, r wazero.Runtime, compiled wazero.CompiledModule, config wazero.ModuleConfig) (api.Module, error) {
+	var memory api.Import
+	for _, imp := range compiled.Imports() {
+		if imp.Type() == api.ExternTypeMemory {
+			memory = imp
+		}
+	}
+	if memory == nil {
+		return nil, errors.New("module doesn't import a shared memory")
+	} else if memory.ModuleName() == ModuleName {
+		return nil, fmt.Errorf("unsupported memory import %s.%s", ModuleName, memory.Name())
+	}
+	for _, imp := range compiled.Imports() {
+		if imp.Type() != api.ExternTypeMemory && imp.ModuleName() == memory.ModuleName() {
+			return nil, fmt.Errorf("unsupported import %s.%s: only the memory can be imported from %s",
+				imp.ModuleName(), imp.Name(), memory.ModuleName())
+		}
+	}
+
+	p := &program{r: r, compiled: compiled, config: config, imports: map[string]api.Module{}, threads: map[uint32]api.Module{}}
+	var err error
+	if p.imports[memory.ModuleName()], err = instantiateMemory(ctx, r, memory); err != nil {
+		return nil, err
+	}
+	if p.imports[ModuleName], err = p.instantiateSpawn(ctx); err != nil {
+		p.closeImports(ctx)
+		return nil, err
+	}
+
+	mod, err := r.InstantiateModule(p.withImports(ctx), compiled, config)
+	if err != nil {
+		p.closeImports(ctx)
+		return nil, err
+	}
+	p.main = mod.(*wasm.ModuleInstance)
+	go p.closeOnExit(ctx)
+	return mod, nil
+}
+
+// instantiateMemory instantiates a module exporting a shared memory of the
+// type of the `memory` import.
+func instantiateMemory(ctx context.Context, r wazero.Runtime, memory api.Import) (api.Module, error) {
+	def := memory.Definition().(api.MemoryDefinition)
+	max, ok := def.Max()
+	if !ok {
+		return nil, fmt.Errorf("invalid memory import %s.%s: not shared", memory.ModuleName(), memory.Name())
+	}
+	shim := fmt.Sprintf("(module (memory (export %s) %d %d shared))", strconv.Quote(memory.Name()), def.Min(), max)
+	compiled, err := r.CompileModule(ctx, []byte(shim))
+	if err != nil {
+		return nil, err
+	}
+	return r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName(""))
+}
+
+// instantiateSpawn instantiates a module exporting "thread-spawn", which
+// spawns threads of this program.
+func (p *program) instantiateSpawn(ctx context.Context) (api.Module, error) {
+	compiled, err := p.r.NewHostModuleBuilder(ModuleName).
+		NewFunctionBuilder().
+		WithGoModuleFunction(api.GoModuleFunc(p.threadSpawn), []api.ValueType{api.ValueTypeI32}, []api.ValueType{api.ValueTypeI32}).
+		WithParameterNames("start_arg").
+		WithResultNames("tid").
+		Export("thread-spawn").
+		Compile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName(""))
+}
+
+// withImports returns `ctx` resolving the imports satisfied by this program.
+func (p *program) withImports(ctx context.Context) context.Context {
+	return experimental.WithImportResolver(ctx, func(name string) api.Module {
+		return p.imports[name]
+	})
+}
+
+// threadSpawn implements "thread-spawn", which returns the ID of the new
+// thread, or a negative number if it couldn't be spawned.
+func (p *program) threadSpawn(ctx context.Context, _ api.Module, stack []uint64) {
+	startArg := api.DecodeI32(stack[0])
+	stack[0] = api.EncodeI32(-1)
+
+	p.mux.Lock()
+	if p.closed || p.nextID == maxThreadID {
+		p.mux.Unlock()
+		return
+	}
+	p.nextID++
+	id := p.nextID
+	p.mux.Unlock()
+
+	mod, err := p.r.InstantiateModule(p.withImports(ctx), p.compiled, p.config.WithName("").WithStartFunctions())
+	if err != nil {
+		return
+	}
+	start := mod.ExportedFunction("wasi_thread_start")
+	if start == nil {
+		_ = mod.Close(ctx)
+		return
+	}
+
+	p.mux.Lock()
+	if p.closed { // closed while instantiating
+		p.mux.Unlock()
+		_ = mod.Close(ctx)
+		return
+	}
+	p.threads[id] = mod
+	p.running.Add(1)
+	p.mux.Unlock()
+
+	go p.run(ctx, id, mod, start, startArg)
+	stack[0] = api.EncodeI32(int32(id))
+}
+
+// run calls `start` of the thread `mod`, then closes it. If it fails, the
+// whole program is closed.
+func (p *program) run(ctx context.Context, id uint32, mod api.Module, start api.Function, startArg int32) {
+	defer p.running.Done()
+	_, err := start.Call(ctx, api.EncodeI32(int32(id)), api.EncodeI32(startArg))
+
+	p.mux.Lock()
+	delete(p.threads, id)
+	p.mux.Unlock()
+	_ = mod.Close(ctx)
+
+	if err != nil {
+		exitCode := uint32(1)
+		var exitErr *sys.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		}
+		_ = p.main.CloseWithExitCode(ctx, exitCode)
+	}
+}
+
+// closeOnExit closes the threads of this program and its imports when the
+// main thread is closed.
+func (p *program) closeOnExit(ctx context.Context) {
+	<-p.main.Done()
+	exitCode := uint32(p.main.Closed.Load() >> 32)
+
+	p.mux.Lock()
+	p.closed = true
+	threads := p.threads
+	p.threads = nil
+	p.mux.Unlock()
+
+	for _, mod := range threads {
+		_ = mod.CloseWithExitCode(ctx, exitCode)
+	}
+	p.running.Wait()
+	p.closeImports(ctx)
+}
+
+// closeImports closes the modules satisfying the imports of this program.
+func (p *program) closeImports(ctx context.Context) {
+	for _, mod := range p.imports {
+		_ = mod.Close(ctx)
+	}
+}
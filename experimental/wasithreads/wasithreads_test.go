@@ -0,0 +1,133 @@
+package wasithreads_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/experimental/wasithreads"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
+var testCtx = context.WithValue(context.Background(), struct{}{}, "arbitrary")
+
+// threadsWat exports "spawn", which spawns a thread with the address it is
+// passed, and waits until the thread stored its ID there. The thread traps
+// instead if the address is zero.
+const threadsWat = `(module
+  (import "env" "memory" (memory 1 1 shared))
+  (import "wasi" "thread-spawn" (func $spawn (param i32) (result i32)))
+  (func (export "wasi_thread_start") (param $tid i32) (param $addr i32)
+    (if (i32.eqz (local.get $addr)) (then unreachable))
+    (i32.atomic.store (local.get $addr) (local.get $tid))
+    (drop (memory.atomic.notify (local.get $addr) (i32.const 1))))
+  (func (export "spawn") (param $addr i32) (result i32 i32)
+    (local $tid i32)
+    (local.set $tid (call $spawn (local.get $addr)))
+    (block $done
+      (loop $wait
+        (br_if $done (i32.atomic.load (local.get $addr)))
+        (drop (memory.atomic.wait32 (local.get $addr) (i32.const 0) (i64.const -1)))
+        (br $wait)))
+    (local.get $tid)
+    (i32.atomic.load (local.get $addr))))`
+
+func TestInstantiate(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config wazero.RuntimeConfig
+	}{
+		{name: "interpreter", config: wazero.NewRuntimeConfigInterpreter()},
+		{name: "compiler", config: wazero.NewRuntimeConfigCompiler()},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.name == "compiler" && !platform.CompilerSupported() {
+				t.Skip()
+			}
+
+			r := wazero.NewRuntimeWithConfig(testCtx, tc.config.
+				WithCoreFeatures(api.CoreFeaturesV2|experimental.CoreFeaturesThreads))
+			defer r.Close(testCtx)
+
+			compiled, err := r.CompileModule(testCtx, []byte(threadsWat))
+			require.NoError(t, err)
+
+			t.Run("spawn", func(t *testing.T) {
+				mod, err := wasithreads.Instantiate(testCtx, r, compiled, wazero.NewModuleConfig())
+				require.NoError(t, err)
+				defer mod.Close(testCtx)
+
+				for i, addr := range []uint64{16, 32} {
+					results, err := mod.ExportedFunction("spawn").Call(testCtx, addr)
+					require.NoError(t, err)
+					// The thread stored its ID, which was returned by thread-spawn.
+					require.Equal(t, []uint64{uint64(i + 1), uint64(i + 1)}, results)
+				}
+			})
+
+			t.Run("trap closes the program", func(t *testing.T) {
+				mod, err := wasithreads.Instantiate(testCtx, r, compiled, wazero.NewModuleConfig())
+				require.NoError(t, err)
+
+				// The main thread waits forever, unless it is closed.
+				_, err = mod.ExportedFunction("spawn").Call(testCtx, 0)
+				require.Equal(t, sys.NewExitError(1), err)
+				require.True(t, mod.IsClosed())
+			})
+
+			t.Run("programs have their own memory", func(t *testing.T) {
+				mod1, err := wasithreads.Instantiate(testCtx, r, compiled, wazero.NewModuleConfig())
+				require.NoError(t, err)
+				defer mod1.Close(testCtx)
+				mod2, err := wasithreads.Instantiate(testCtx, r, compiled, wazero.NewModuleConfig())
+				require.NoError(t, err)
+				defer mod2.Close(testCtx)
+
+				require.True(t, mod1.Memory().WriteUint32Le(16, 42))
+				v, _ := mod2.Memory().ReadUint32Le(16)
+				require.Equal(t, uint32(0), v)
+			})
+		})
+	}
+}
+
+func TestInstantiate_errors(t *testing.T) {
+	r := wazero.NewRuntimeWithConfig(testCtx, wazero.NewRuntimeConfigInterpreter().
+		WithCoreFeatures(api.CoreFeaturesV2|experimental.CoreFeaturesThreads))
+	defer r.Close(testCtx)
+
+	for _, tc := range []struct {
+		name, wat, expectedErr string
+	}{
+		{
+			name:        "no memory import",
+			wat:         `(module (memory 1 1 shared))`,
+			expectedErr: "module doesn't import a shared memory",
+		},
+		{
+			name:        "other import from the memory module",
+			wat:         `(module (import "env" "memory" (memory 1 1 shared)) (import "env" "f" (func)))`,
+			expectedErr: "unsupported import env.f: only the memory can be imported from env",
+		},
+		{
+			name:        "memory without max",
+			wat:         `(module (import "env" "memory" (memory 1)))`,
+			expectedErr: "invalid memory import env.memory: not shared",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			compiled, err := r.CompileModule(testCtx, []byte(tc.wat))
+			require.NoError(t, err)
+
+			_, err = wasithreads.Instantiate(testCtx, r, compiled, wazero.NewModuleConfig())
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+// Package wat converts between the WebAssembly text format (%.wat) and the
+// binary format (%.wasm), e.g. to write modules in tests without an external
+// wat2wasm step, or to inspect or diff modules without wasm2wat.
+//
+// wazero.Runtime CompileModule and Instantiate accept the text format as is,
+// so Compile is only needed to use the binary format otherwise, e.g. to save
+// it.
+//
+// See https://webassembly.github.io/spec/core/text/index.html
+package wat
+
+import "github.com/tetratelabs/wazero/internal/wat"
+
+// Compile returns the binary format of `text`, which is either a module,
+// e.g. `(module (func (export "f")))`, or only its fields, e.g.
+// `(func (export "f"))`.
+//
+// Errors are prefixed by the line and column of the source, e.g.
+// "2:9: unknown operator \"i32.ad\"". Otherwise, the module isn't validated
+// until it is compiled by a wazero.Runtime.
+func Compile(text []byte) ([]byte, error) {
+	return wat.Compile(text)
+}
+
+// IsText returns true if `source` is likely in the text format, i.e. it
+// begins with a parenthesis after any whitespace and comments, as opposed to
+// the magic number of the binary format.
+func IsText(source []byte) bool {
+	return wat.IsText(source)
+}
+
+// Disassemble returns the text format of the module `binary`, with one
+// instruction per line, e.g. "local.get $x". Indexes are printed as the
+// identifiers of the name section, if any, e.g. "call $add", or otherwise
+// as numbers, and the result compiles to an equivalent module.
+//
+// An error is returned if `binary` can't be decoded, but the module isn't
+// validated.
+func Disassemble(binary []byte) ([]byte, error) {
+	return wat.Disassemble(binary, false)
+}
+
+// DisassembleFolded is like Disassemble, except instructions are folded
+// into their operands, e.g. "(i32.add (local.get $x) (local.get $y))", on
+// separate lines.
+func DisassembleFolded(binary []byte) ([]byte, error) {
+	return wat.Disassemble(binary, true)
+}
@@ -0,0 +1,70 @@
+package wat_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental/wat"
+)
+
+const addWat = `(module
+  (func (export "add") (param $x i32) (param $y i32) (result i32)
+    local.get $x
+    local.get $y
+    i32.add))`
+
+// This shows how to compile the text format to the binary format.
+func ExampleCompile() {
+	bin, err := wat.Compile([]byte(addWat))
+	if err != nil {
+		log.Panicln(err)
+	}
+	fmt.Printf("%q %v\n", bin[:4], wat.IsText(bin))
+
+	// Output:
+	// "\x00asm" false
+}
+
+// This shows how to print a module in the binary format as text.
+func ExampleDisassemble() {
+	bin, err := wat.Compile([]byte(addWat))
+	if err != nil {
+		log.Panicln(err)
+	}
+	text, err := wat.DisassembleFolded(bin)
+	if err != nil {
+		log.Panicln(err)
+	}
+	fmt.Print(string(text))
+
+	// Output:
+	// (module
+	//   (type (;0;) (func (param i32 i32) (result i32)))
+	//   (func (;0;) (type 0) (param $x i32) (param $y i32) (result i32)
+	//     (i32.add
+	//       (local.get $x)
+	//       (local.get $y)))
+	//   (export "add" (func 0)))
+}
+
+// This shows how to instantiate a module in the text format directly.
+func Example_instantiate() {
+	ctx := context.Background()
+	r := wazero.NewRuntime(ctx)
+	defer r.Close(ctx)
+
+	mod, err := r.Instantiate(ctx, []byte(addWat))
+	if err != nil {
+		log.Panicln(err)
+	}
+	results, err := mod.ExportedFunction("add").Call(ctx, 1, 2)
+	if err != nil {
+		log.Panicln(err)
+	}
+	fmt.Println(results[0])
+
+	// Output:
+	// 3
+}
@@ -431,6 +431,10 @@ func (def functionDefinition) ParamNames() []string {
 	return def.function.ParamNames
 }
 
+func (def functionDefinition) LocalNames() []string {
+	return nil
+}
+
 func (def functionDefinition) ResultTypes() []api.ValueType {
 	return def.function.ResultTypes
 }
@@ -30,8 +30,9 @@ import (
 // current directory prior to requesting files.
 //
 // More notes on `guestPath`
-//   - Go compiled with runtime.GOOS=js do not pay attention to this value.
-//     It only works with root mounts ("").
+//   - Go compiled with runtime.GOOS=js resolves absolute paths on the host
+//     to the longest matching `guestPath`. Renames and links across
+//     different `guestPath` values are not supported.
 //   - Working directories are typically tracked in wasm, though possible some
 //     relative paths are requested. For example, TinyGo may attempt to resolve
 //     a path "../.." in unit tests.
@@ -112,6 +113,14 @@ type FSConfig interface {
 	// built-in file-systems are not jailed (chroot). See
 	// https://github.com/golang/go/issues/42322
 	//
+	// # Writable mounts
+	//
+	// fs.FS is read-only by design. However, if `fs` also implements the
+	// below method, it is used instead of Open, passing open flags such as
+	// os.O_RDWR and os.O_CREATE. This allows a guest to create and write files.
+	//
+	//	OpenFile(name string, flag int, perm fs.FileMode) (fs.File, error)
+	//
 	// # os.DirFS
 	//
 	// Due to limited control and functionality available in os.DirFS, we
@@ -142,6 +151,8 @@ type fsConfig struct {
 	// guestPathToFS are the normalized paths to the currently configured
 	// filesystems, used for de-duplicating.
 	guestPathToFS map[string]int
+	// listener is notified of operations on all filesystems, if not nil.
+	listener sysfs.FSListener
 }
 
 // NewFSConfig returns a FSConfig that can be used for configuring module instantiation.
@@ -201,8 +212,9 @@ func (c *fsConfig) WithSysFSMount(fs experimentalsys.FS, guestPath string) FSCon
 }
 
 // preopens returns the possible nil index-correlated preopened filesystems
-// with guest paths.
-func (c *fsConfig) preopens() ([]experimentalsys.FS, []string) {
+// with guest paths. Nil fields of any sysfs.DevFS default to those in `dev`,
+// if not nil.
+func (c *fsConfig) preopens(dev *sysfs.DevFS) ([]experimentalsys.FS, []string) {
 	preopenCount := len(c.fs)
 	if preopenCount == 0 {
 		return nil, nil
@@ -211,5 +223,38 @@ func (c *fsConfig) preopens() ([]experimentalsys.FS, []string) {
 	copy(fs, c.fs)
 	guestPaths := make([]string, len(c.guestPaths))
 	copy(guestPaths, c.guestPaths)
+	for i := range fs {
+		if d, ok := fs[i].(*sysfs.DevFS); ok && dev != nil {
+			fs[i] = withDevDefaults(d, dev)
+		}
+	}
+	if l := c.listener; l != nil {
+		for i := range fs {
+			fs[i] = sysfs.ListenerFS(fs[i], guestPaths[i], l)
+		}
+	}
 	return fs, guestPaths
 }
+
+// withDevDefaults returns a copy of `d`, replacing any nil field with the
+// corresponding one in `defaults`.
+func withDevDefaults(d, defaults *sysfs.DevFS) *sysfs.DevFS {
+	ret := *d
+	if ret.Rand == nil {
+		ret.Rand = defaults.Rand
+	}
+	if ret.Stdin == nil {
+		ret.Stdin = defaults.Stdin
+	}
+	if ret.Stdout == nil {
+		ret.Stdout = defaults.Stdout
+	}
+	return &ret
+}
+
+// WithFSListener implements sysfs.FSConfig
+func (c *fsConfig) WithFSListener(listener sysfs.FSListener) FSConfig {
+	ret := c.clone()
+	ret.listener = listener
+	return ret
+}
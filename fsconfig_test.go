@@ -1,7 +1,10 @@
 package wazero
 
 import (
+	"bytes"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/tetratelabs/wazero/experimental/sys"
 	"github.com/tetratelabs/wazero/internal/sysfs"
@@ -54,13 +57,25 @@ func TestFSConfig(t *testing.T) {
 			expectedFS:         []sys.FS{&sysfs.ReadFS{FS: sysfs.DirFS(".")}, sysfs.DirFS("/tmp")},
 			expectedGuestPaths: []string{"/", "/tmp"},
 		},
+		{
+			name:               "WithFSListener",
+			input:              base.WithDirMount(".", "/").(*fsConfig).WithFSListener(nopFSListener{}),
+			expectedFS:         []sys.FS{sysfs.ListenerFS(sysfs.DirFS("."), "/", nopFSListener{})},
+			expectedGuestPaths: []string{"/"},
+		},
+		{
+			name:               "WithFSListener nil",
+			input:              base.(*fsConfig).WithFSListener(nopFSListener{}).(*fsConfig).WithFSListener(nil).WithDirMount(".", "/"),
+			expectedFS:         []sys.FS{sysfs.DirFS(".")},
+			expectedGuestPaths: []string{"/"},
+		},
 	}
 
 	for _, tt := range tests {
 		tc := tt
 
 		t.Run(tc.name, func(t *testing.T) {
-			fs, guestPaths := tc.input.(*fsConfig).preopens()
+			fs, guestPaths := tc.input.(*fsConfig).preopens(nil)
 			require.Equal(t, tc.expectedFS, fs)
 			require.Equal(t, tc.expectedGuestPaths, guestPaths)
 		})
@@ -84,3 +99,22 @@ func TestFSConfig_clone(t *testing.T) {
 	// Ensure the guestPaths slice is not shared
 	require.Zero(t, len(cloned.guestPaths))
 }
+
+func TestFSConfig_preopens_DevFS(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	dev := &sysfs.DevFS{Stdout: stdout}
+	fc := NewFSConfig().(*fsConfig).WithSysFSMount(dev, "/dev").(*fsConfig)
+
+	defaults := &sysfs.DevFS{Rand: strings.NewReader("rand"), Stdin: strings.NewReader("stdin"), Stdout: &bytes.Buffer{}}
+	fs, _ := fc.preopens(defaults)
+
+	// Only nil fields are defaulted, and the configured DevFS isn't changed.
+	require.Equal(t, &sysfs.DevFS{Rand: defaults.Rand, Stdin: defaults.Stdin, Stdout: stdout}, fs[0])
+	require.Equal(t, &sysfs.DevFS{Stdout: stdout}, dev)
+}
+
+type nopFSListener struct{}
+
+func (nopFSListener) Before(sysfs.FSEvent) {}
+
+func (nopFSListener) After(sysfs.FSEvent, sys.Errno, time.Duration) {}
@@ -0,0 +1,397 @@
+package wazero
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// Param are the Go types of the params of typed functions, such as Func2.
+//
+// Numbers and bools are the WebAssembly value types they convert to, bools
+// being i32. Strings and byte slices are copied into the memory of the
+// module, allocated with its exported "malloc", or "cabi_realloc" otherwise,
+// and each passed as two i32 params: their offset and their length. Copies
+// are freed after the call with the exported "free", if any.
+type Param interface {
+	int32 | uint32 | int64 | uint64 | float32 | float64 | bool | string | []byte
+}
+
+// Result are the Go types of the results of typed functions, such as Func2.
+//
+// Numbers and bools are like Param. Strings and byte slices are copied from
+// the memory of the module, at the offset and length returned as two i32
+// results, or as an i64 packing the offset in its high 32 bits. Void is for
+// functions without results.
+type Result interface {
+	Param | Void
+}
+
+// Void is the Result of typed functions without results.
+type Void struct{}
+
+// Func0 is a typed function with no params, returning R.
+type Func0[R Result] struct{ f *typedFunc }
+
+// NewFunc0 returns the function exported by `mod` as `name`, or errs if it
+// doesn't exist or its type isn't () -> R.
+func NewFunc0[R Result](mod api.Module, name string) (*Func0[R], error) {
+	f, err := newTypedFunc(mod, name, nil, resultTypes[R]())
+	if err != nil {
+		return nil, err
+	}
+	return &Func0[R]{f}, nil
+}
+
+// Call calls the function, like api.Function Call.
+func (f *Func0[R]) Call(ctx context.Context) (ret R, err error) {
+	results, err := f.f.call(ctx, nil)
+	if err == nil {
+		ret, err = lift[R](f.f, results)
+	}
+	return
+}
+
+// Func1 is a typed function with a param P1, returning R.
+type Func1[P1 Param, R Result] struct{ f *typedFunc }
+
+// NewFunc1 returns the function exported by `mod` as `name`, or errs if it
+// doesn't exist or its type isn't (P1) -> R.
+func NewFunc1[P1 Param, R Result](mod api.Module, name string) (*Func1[P1, R], error) {
+	f, err := newTypedFunc(mod, name, paramTypes[P1](nil), resultTypes[R]())
+	if err != nil {
+		return nil, err
+	}
+	return &Func1[P1, R]{f}, nil
+}
+
+// Call calls the function, like api.Function Call.
+func (f *Func1[P1, R]) Call(ctx context.Context, p1 P1) (ret R, err error) {
+	results, err := f.f.call(ctx, func(l *lowering) { lower(l, p1) })
+	if err == nil {
+		ret, err = lift[R](f.f, results)
+	}
+	return
+}
+
+// Func2 is a typed function with params P1 and P2, returning R, e.g.
+//
+//	greet, err := wazero.NewFunc2[string, bool, uint32](mod, "greet")
+//	if err != nil {
+//		return err // e.g. "greet" doesn't have the type (i32, i32, i32) -> i32
+//	}
+//	n, err := greet.Call(ctx, "wazero", true)
+type Func2[P1, P2 Param, R Result] struct{ f *typedFunc }
+
+// NewFunc2 returns the function exported by `mod` as `name`, or errs if it
+// doesn't exist or its type isn't (P1, P2) -> R.
+func NewFunc2[P1, P2 Param, R Result](mod api.Module, name string) (*Func2[P1, P2, R], error) {
+	f, err := newTypedFunc(mod, name, paramTypes[P2](paramTypes[P1](nil)), resultTypes[R]())
+	if err != nil {
+		return nil, err
+	}
+	return &Func2[P1, P2, R]{f}, nil
+}
+
+// Call calls the function, like api.Function Call.
+func (f *Func2[P1, P2, R]) Call(ctx context.Context, p1 P1, p2 P2) (ret R, err error) {
+	results, err := f.f.call(ctx, func(l *lowering) { lower(l, p1); lower(l, p2) })
+	if err == nil {
+		ret, err = lift[R](f.f, results)
+	}
+	return
+}
+
+// Func3 is a typed function with params P1, P2 and P3, returning R.
+type Func3[P1, P2, P3 Param, R Result] struct{ f *typedFunc }
+
+// NewFunc3 returns the function exported by `mod` as `name`, or errs if it
+// doesn't exist or its type isn't (P1, P2, P3) -> R.
+func NewFunc3[P1, P2, P3 Param, R Result](mod api.Module, name string) (*Func3[P1, P2, P3, R], error) {
+	params := paramTypes[P3](paramTypes[P2](paramTypes[P1](nil)))
+	f, err := newTypedFunc(mod, name, params, resultTypes[R]())
+	if err != nil {
+		return nil, err
+	}
+	return &Func3[P1, P2, P3, R]{f}, nil
+}
+
+// Call calls the function, like api.Function Call.
+func (f *Func3[P1, P2, P3, R]) Call(ctx context.Context, p1 P1, p2 P2, p3 P3) (ret R, err error) {
+	results, err := f.f.call(ctx, func(l *lowering) { lower(l, p1); lower(l, p2); lower(l, p3) })
+	if err == nil {
+		ret, err = lift[R](f.f, results)
+	}
+	return
+}
+
+// Func4 is a typed function with params P1, P2, P3 and P4, returning R.
+type Func4[P1, P2, P3, P4 Param, R Result] struct{ f *typedFunc }
+
+// NewFunc4 returns the function exported by `mod` as `name`, or errs if it
+// doesn't exist or its type isn't (P1, P2, P3, P4) -> R.
+func NewFunc4[P1, P2, P3, P4 Param, R Result](mod api.Module, name string) (*Func4[P1, P2, P3, P4, R], error) {
+	params := paramTypes[P4](paramTypes[P3](paramTypes[P2](paramTypes[P1](nil))))
+	f, err := newTypedFunc(mod, name, params, resultTypes[R]())
+	if err != nil {
+		return nil, err
+	}
+	return &Func4[P1, P2, P3, P4, R]{f}, nil
+}
+
+// Call calls the function, like api.Function Call.
+func (f *Func4[P1, P2, P3, P4, R]) Call(ctx context.Context, p1 P1, p2 P2, p3 P3, p4 P4) (ret R, err error) {
+	results, err := f.f.call(ctx, func(l *lowering) { lower(l, p1); lower(l, p2); lower(l, p3); lower(l, p4) })
+	if err == nil {
+		ret, err = lift[R](f.f, results)
+	}
+	return
+}
+
+// typedFunc is the untyped implementation of typed functions.
+type typedFunc struct {
+	mod  api.Module
+	name string
+	fn   api.Function
+	// packed is true if a string or byte slice result is an i64.
+	packed bool
+}
+
+// newTypedFunc returns the function exported by `mod` as `name`, if it has
+// the given params, and the given results, which are nil for a string or
+// byte slice.
+func newTypedFunc(mod api.Module, name string, params, results []api.ValueType) (*typedFunc, error) {
+	fn := mod.ExportedFunction(name)
+	if fn == nil {
+		return nil, fmt.Errorf("%s is not exported in module %s", name, mod.Name())
+	}
+	f := &typedFunc{mod: mod, name: name, fn: fn}
+	def := fn.Definition()
+	if results == nil { // string or byte slice
+		if r := def.ResultTypes(); len(r) == 1 && r[0] == api.ValueTypeI64 {
+			f.packed, results = true, r
+		} else {
+			results = []api.ValueType{api.ValueTypeI32, api.ValueTypeI32}
+		}
+	}
+	if !equalTypes(params, def.ParamTypes()) || !equalTypes(results, def.ResultTypes()) {
+		return nil, fmt.Errorf("%s has type %s, not %s", name, signature(def.ParamTypes(), def.ResultTypes()), signature(params, results))
+	}
+	return f, nil
+}
+
+// lowering are the params of a call, and the memory allocated for them.
+type lowering struct {
+	f      *typedFunc
+	ctx    context.Context
+	params []uint64
+	allocs []uint32
+	err    error
+}
+
+// call calls the function with the params `lowerParams` appends.
+func (f *typedFunc) call(ctx context.Context, lowerParams func(*lowering)) ([]uint64, error) {
+	l := &lowering{f: f, ctx: ctx}
+	if lowerParams != nil {
+		lowerParams(l)
+	}
+	defer l.free()
+	if l.err != nil {
+		return nil, fmt.Errorf("%s: %w", f.name, l.err)
+	}
+	return f.fn.Call(ctx, l.params...)
+}
+
+// alloc copies `buf` into the memory of the module, returning its offset.
+func (l *lowering) alloc(buf []byte) uint32 {
+	if l.err != nil || len(buf) == 0 {
+		return 0
+	}
+	mem := l.f.mod.Memory()
+	if mem == nil {
+		l.err = errors.New("module has no memory")
+		return 0
+	}
+
+	var results []uint64
+	if malloc := l.f.mod.ExportedFunction("malloc"); malloc != nil {
+		results, l.err = malloc.Call(l.ctx, uint64(len(buf)))
+	} else if realloc := l.f.mod.ExportedFunction("cabi_realloc"); realloc != nil {
+		results, l.err = realloc.Call(l.ctx, 0, 0, 1, uint64(len(buf)))
+	} else {
+		l.err = errors.New("module exports neither malloc nor cabi_realloc")
+	}
+	if l.err != nil {
+		return 0
+	}
+
+	offset := api.DecodeU32(results[0])
+	if offset == 0 {
+		l.err = fmt.Errorf("allocation of %d bytes failed", len(buf))
+		return 0
+	} else if !mem.Write(offset, buf) {
+		l.err = fmt.Errorf("allocation of %d bytes out of memory", len(buf))
+		return 0
+	}
+	l.allocs = append(l.allocs, offset)
+	return offset
+}
+
+// free frees the memory allocated for the params, if the module exports
+// "free".
+func (l *lowering) free() {
+	free := l.f.mod.ExportedFunction("free")
+	if free == nil {
+		return
+	}
+	for _, offset := range l.allocs {
+		_, _ = free.Call(l.ctx, uint64(offset))
+	}
+}
+
+// lower appends the params for `v`.
+func lower[P Param](l *lowering, v P) {
+	switch v := any(v).(type) {
+	case int32:
+		l.params = append(l.params, api.EncodeI32(v))
+	case uint32:
+		l.params = append(l.params, api.EncodeU32(v))
+	case int64:
+		l.params = append(l.params, api.EncodeI64(v))
+	case uint64:
+		l.params = append(l.params, v)
+	case float32:
+		l.params = append(l.params, api.EncodeF32(v))
+	case float64:
+		l.params = append(l.params, api.EncodeF64(v))
+	case bool:
+		l.params = append(l.params, encodeBool(v))
+	case string:
+		l.params = append(l.params, uint64(l.alloc([]byte(v))), uint64(len(v)))
+	case []byte:
+		l.params = append(l.params, uint64(l.alloc(v)), uint64(len(v)))
+	}
+}
+
+// lift returns the result R of `results`.
+func lift[R Result](f *typedFunc, results []uint64) (ret R, err error) {
+	switch r := any(&ret).(type) {
+	case *int32:
+		*r = api.DecodeI32(results[0])
+	case *uint32:
+		*r = api.DecodeU32(results[0])
+	case *int64:
+		*r = int64(results[0])
+	case *uint64:
+		*r = results[0]
+	case *float32:
+		*r = api.DecodeF32(results[0])
+	case *float64:
+		*r = api.DecodeF64(results[0])
+	case *bool:
+		*r = api.DecodeU32(results[0]) != 0
+	case *string:
+		var buf []byte
+		if buf, err = f.liftBytes(results); err == nil {
+			*r = string(buf)
+		}
+	case *[]byte:
+		var buf []byte
+		if buf, err = f.liftBytes(results); err == nil {
+			*r = append([]byte{}, buf...)
+		}
+	}
+	return
+}
+
+// liftBytes returns the view of the memory at the offset and length in
+// `results`.
+func (f *typedFunc) liftBytes(results []uint64) ([]byte, error) {
+	offset, length := api.DecodeU32(results[0]), uint32(0)
+	if f.packed {
+		offset, length = uint32(results[0]>>32), uint32(results[0])
+	} else {
+		length = api.DecodeU32(results[1])
+	}
+	if mem := f.mod.Memory(); mem != nil {
+		if buf, ok := mem.Read(offset, length); ok {
+			return buf, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: result out of memory: offset=%d, length=%d", f.name, offset, length)
+}
+
+// paramTypes appends the value types of P to `types`.
+func paramTypes[P Param](types []api.ValueType) []api.ValueType {
+	var v P
+	switch any(v).(type) {
+	case string, []byte:
+		return append(types, api.ValueTypeI32, api.ValueTypeI32)
+	}
+	return append(types, valueType(any(v)))
+}
+
+// resultTypes returns the value types of R, or nil if it is a string or
+// byte slice, which have two encodings.
+func resultTypes[R Result]() []api.ValueType {
+	var v R
+	switch any(v).(type) {
+	case Void:
+		return []api.ValueType{}
+	case string, []byte:
+		return nil
+	}
+	return []api.ValueType{valueType(any(v))}
+}
+
+// valueType returns the value type of the number or bool `v`.
+func valueType(v any) api.ValueType {
+	switch v.(type) {
+	case int32, uint32, bool:
+		return api.ValueTypeI32
+	case int64, uint64:
+		return api.ValueTypeI64
+	case float32:
+		return api.ValueTypeF32
+	case float64:
+		return api.ValueTypeF64
+	}
+	panic(fmt.Errorf("BUG: unexpected type %T", v))
+}
+
+func encodeBool(v bool) uint64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func equalTypes(a, b []api.ValueType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// signature formats a function type like "(i32, i32) -> i64".
+func signature(params, results []api.ValueType) string {
+	return valueTypeNames(params) + " -> " + valueTypeNames(results)
+}
+
+func valueTypeNames(types []api.ValueType) string {
+	ret := "("
+	for i, t := range types {
+		if i > 0 {
+			ret += ", "
+		}
+		ret += api.ValueTypeName(t)
+	}
+	return ret + ")"
+}
@@ -0,0 +1,138 @@
+package wazero
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// funcWat has a bump allocator, and a free counting its calls.
+const funcWat = `(module $test
+  (memory (export "memory") 1)
+  (global $next (mut i32) (i32.const 1024))
+  (global $frees (export "frees") (mut i32) (i32.const 0))
+  (func (export "malloc") (param i32) (result i32)
+    (global.get $next)
+    (global.set $next (i32.add (global.get $next) (local.get 0))))
+  (func (export "free") (param i32)
+    (global.set $frees (i32.add (global.get $frees) (i32.const 1))))
+  (func (export "nop"))
+  (func (export "add") (param i32 i32) (result i32) (i32.add (local.get 0) (local.get 1)))
+  (func (export "is_even") (param i64) (result i32) (i64.eqz (i64.rem_u (local.get 0) (i64.const 2))))
+  (func (export "mix") (param f32 f64 i32) (result f64)
+    (f64.add (f64.promote_f32 (local.get 0)) (local.get 1))
+    (if (param f64) (result f64) (local.get 2) (then (f64.neg))))
+  (func (export "sum") (param i64 i64 i64 i64) (result i64)
+    (i64.add (i64.add (local.get 0) (local.get 1)) (i64.add (local.get 2) (local.get 3))))
+  (func (export "len") (param i32 i32) (result i32) (local.get 1))
+  (func (export "echo") (param i32 i32) (result i32 i32) (local.get 0) (local.get 1))
+  (func (export "echo_packed") (param i32 i32) (result i64)
+    (i64.or (i64.shl (i64.extend_i32_u (local.get 0)) (i64.const 32)) (i64.extend_i32_u (local.get 1)))))`
+
+func TestFunc(t *testing.T) {
+	r := NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	mod, err := r.Instantiate(testCtx, []byte(funcWat))
+	require.NoError(t, err)
+
+	nop, err := NewFunc0[Void](mod, "nop")
+	require.NoError(t, err)
+	_, err = nop.Call(testCtx)
+	require.NoError(t, err)
+
+	isEven, err := NewFunc1[uint64, bool](mod, "is_even")
+	require.NoError(t, err)
+	even, err := isEven.Call(testCtx, 4)
+	require.NoError(t, err)
+	require.True(t, even)
+
+	add, err := NewFunc2[int32, int32, int32](mod, "add")
+	require.NoError(t, err)
+	sum, err := add.Call(testCtx, -3, 1)
+	require.NoError(t, err)
+	require.Equal(t, int32(-2), sum)
+
+	mix, err := NewFunc3[float32, float64, bool, float64](mod, "mix")
+	require.NoError(t, err)
+	mixed, err := mix.Call(testCtx, 1.5, 2, true)
+	require.NoError(t, err)
+	require.Equal(t, -3.5, mixed)
+
+	sum4, err := NewFunc4[int64, int64, int64, int64, int64](mod, "sum")
+	require.NoError(t, err)
+	sum64, err := sum4.Call(testCtx, 1, 2, 3, -10)
+	require.NoError(t, err)
+	require.Equal(t, int64(-4), sum64)
+
+	// Strings and byte slices are copied into memory, then freed.
+	length, err := NewFunc1[string, uint32](mod, "len")
+	require.NoError(t, err)
+	n, err := length.Call(testCtx, "wazero")
+	require.NoError(t, err)
+	require.Equal(t, uint32(6), n)
+	require.Equal(t, uint64(1), mod.ExportedGlobal("frees").Get())
+
+	echo, err := NewFunc1[[]byte, string](mod, "echo")
+	require.NoError(t, err)
+	s, err := echo.Call(testCtx, []byte("hello"))
+	require.NoError(t, err)
+	require.Equal(t, "hello", s)
+
+	echoPacked, err := NewFunc1[string, []byte](mod, "echo_packed")
+	require.NoError(t, err)
+	b, err := echoPacked.Call(testCtx, "packed")
+	require.NoError(t, err)
+	require.Equal(t, []byte("packed"), b)
+
+	// Empty strings aren't allocated.
+	s, err = echo.Call(testCtx, nil)
+	require.NoError(t, err)
+	require.Equal(t, "", s)
+	require.Equal(t, uint64(3), mod.ExportedGlobal("frees").Get())
+}
+
+func TestFunc_Errors(t *testing.T) {
+	r := NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	mod, err := r.Instantiate(testCtx, []byte(funcWat))
+	require.NoError(t, err)
+
+	_, err = NewFunc0[Void](mod, "nope")
+	require.EqualError(t, err, "nope is not exported in module test")
+
+	_, err = NewFunc2[int64, int32, int32](mod, "add")
+	require.EqualError(t, err, "add has type (i32, i32) -> (i32), not (i64, i32) -> (i32)")
+
+	_, err = NewFunc1[string, Void](mod, "len")
+	require.EqualError(t, err, "len has type (i32, i32) -> (i32), not (i32, i32) -> ()")
+
+	_, err = NewFunc1[string, string](mod, "len")
+	require.EqualError(t, err, "len has type (i32, i32) -> (i32), not (i32, i32) -> (i32, i32)")
+
+	// Byte slices need an allocator.
+	noAlloc, err := r.Instantiate(testCtx, []byte(`(module $no_alloc
+  (memory 1)
+  (func (export "len") (param i32 i32) (result i32) (local.get 1)))`))
+	require.NoError(t, err)
+	length, err := NewFunc1[[]byte, uint32](noAlloc, "len")
+	require.NoError(t, err)
+	_, err = length.Call(testCtx, []byte{1})
+	require.EqualError(t, err, "len: module exports neither malloc nor cabi_realloc")
+
+	// Results must be in memory.
+	outOfRange, err := NewFunc2[uint32, uint32, string](mod, "echo")
+	require.NoError(t, err)
+	_, err = outOfRange.Call(testCtx, 65535, 2)
+	require.EqualError(t, err, "echo: result out of memory: offset=65535, length=2")
+
+	// Errors calling the function are returned as is.
+	trap, err := r.Instantiate(testCtx, []byte(`(module (func (export "trap") unreachable))`))
+	require.NoError(t, err)
+	f, err := NewFunc0[Void](trap, "trap")
+	require.NoError(t, err)
+	_, err = f.Call(testCtx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unreachable")
+}
@@ -12,6 +12,9 @@
 //   - "seed" - uses wazero.ModuleConfig WithRandSource as the source of seed
 //     values.
 //
+// To handle these in Go instead, such as to route diagnostics to your own
+// logging, use FunctionExporter WithAbortFunc, WithTraceFunc or WithSeedFunc.
+//
 // See https://www.assemblyscript.org/concepts.html#special-imports
 //
 // # Relationship to WASI
@@ -93,33 +96,97 @@ type FunctionExporter interface {
 	// appropriate to use WithTraceToStdout instead.
 	WithTraceToStderr() FunctionExporter
 
+	// WithAbortFunc configures the AssemblyScript abort function to call `fn`
+	// with the decoded message, instead of writing it to Stderr. The module
+	// exits with 255 after `fn` returns.
+	WithAbortFunc(fn AbortFunc) FunctionExporter
+
+	// WithTraceFunc configures the AssemblyScript trace function to call `fn`
+	// with the decoded message and arguments, instead of writing them.
+	WithTraceFunc(fn TraceFunc) FunctionExporter
+
+	// WithSeedFunc configures the AssemblyScript seed function to return the
+	// result of `fn`, instead of reading wazero.ModuleConfig WithRandSource.
+	WithSeedFunc(fn SeedFunc) FunctionExporter
+
 	// ExportFunctions builds functions to export with a wazero.HostModuleBuilder
 	// named "env".
 	ExportFunctions(wazero.HostModuleBuilder)
 }
 
+// AbortMessage is the decoded parameters of the AssemblyScript abort
+// function. The strings are empty if they couldn't be decoded.
+type AbortMessage struct {
+	// Message is the reason for the abort. e.g. "assertion failed"
+	Message string
+	// FileName is the source file which aborted. e.g. "assembly/index.ts"
+	FileName string
+	// LineNumber is the one-based line in FileName.
+	LineNumber uint32
+	// ColumnNumber is the one-based column in FileName.
+	ColumnNumber uint32
+}
+
+// AbortFunc is called with the message of an AssemblyScript abort, before
+// the module exits.
+type AbortFunc func(ctx context.Context, mod api.Module, msg AbortMessage)
+
+// TraceFunc is called with the decoded message and arguments of an
+// AssemblyScript trace. `args` has a length between zero and five.
+type TraceFunc func(ctx context.Context, mod api.Module, message string, args []float64)
+
+// SeedFunc returns the seed of the AssemblyScript random number generator.
+type SeedFunc func(ctx context.Context, mod api.Module) float64
+
 // NewFunctionExporter returns a FunctionExporter object with trace disabled.
 func NewFunctionExporter() FunctionExporter {
-	return &functionExporter{abortFn: abortMessageEnabled, traceFn: traceDisabled}
+	return &functionExporter{abortFn: abortMessageEnabled, traceFn: traceDisabled, seedFn: seed}
 }
 
 type functionExporter struct {
-	abortFn, traceFn *wasm.HostFunc
+	abortFn, traceFn, seedFn *wasm.HostFunc
 }
 
 // WithAbortMessageDisabled implements FunctionExporter.WithAbortMessageDisabled
 func (e *functionExporter) WithAbortMessageDisabled() FunctionExporter {
-	return &functionExporter{abortFn: abortMessageDisabled, traceFn: e.traceFn}
+	return &functionExporter{abortFn: abortMessageDisabled, traceFn: e.traceFn, seedFn: e.seedFn}
 }
 
 // WithTraceToStdout implements FunctionExporter.WithTraceToStdout
 func (e *functionExporter) WithTraceToStdout() FunctionExporter {
-	return &functionExporter{abortFn: e.abortFn, traceFn: traceStdout}
+	return &functionExporter{abortFn: e.abortFn, traceFn: traceStdout, seedFn: e.seedFn}
 }
 
 // WithTraceToStderr implements FunctionExporter.WithTraceToStderr
 func (e *functionExporter) WithTraceToStderr() FunctionExporter {
-	return &functionExporter{abortFn: e.abortFn, traceFn: traceStderr}
+	return &functionExporter{abortFn: e.abortFn, traceFn: traceStderr, seedFn: e.seedFn}
+}
+
+// WithAbortFunc implements FunctionExporter.WithAbortFunc
+func (e *functionExporter) WithAbortFunc(fn AbortFunc) FunctionExporter {
+	abortFn := abortMessageEnabled.WithGoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
+		fn(ctx, mod, readAbortMessage(mod.Memory(), stack))
+		abort(ctx, mod, stack)
+	})
+	return &functionExporter{abortFn: abortFn, traceFn: e.traceFn, seedFn: e.seedFn}
+}
+
+// WithTraceFunc implements FunctionExporter.WithTraceFunc
+func (e *functionExporter) WithTraceFunc(fn TraceFunc) FunctionExporter {
+	traceFn := traceStdout.WithGoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
+		if msg, args, ok := readTrace(mod.Memory(), stack); ok {
+			fn(ctx, mod, msg, args)
+		}
+	})
+	return &functionExporter{abortFn: e.abortFn, traceFn: traceFn, seedFn: e.seedFn}
+}
+
+// WithSeedFunc implements FunctionExporter.WithSeedFunc
+func (e *functionExporter) WithSeedFunc(fn SeedFunc) FunctionExporter {
+	seedFn := seed.WithGoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
+		stack[0] = api.EncodeF64(fn(ctx, mod))
+	})
+	return &functionExporter{abortFn: e.abortFn, traceFn: e.traceFn, seedFn: seedFn}
 }
 
 // ExportFunctions implements FunctionExporter.ExportFunctions
@@ -127,7 +194,7 @@ func (e *functionExporter) ExportFunctions(builder wazero.HostModuleBuilder) {
 	exporter := builder.(wasm.HostFuncExporter)
 	exporter.ExportHostFunc(e.abortFn)
 	exporter.ExportHostFunc(e.traceFn)
-	exporter.ExportHostFunc(seed)
+	exporter.ExportHostFunc(e.seedFn)
 }
 
 // abort is called on unrecoverable errors. This is typically present in Wasm
@@ -153,6 +220,18 @@ var abortMessageEnabled = &wasm.HostFunc{
 
 var abortMessageDisabled = abortMessageEnabled.WithGoModuleFunc(abort)
 
+// readAbortMessage decodes the parameters of AbortName.
+func readAbortMessage(mem api.Memory, stack []uint64) AbortMessage {
+	msg, _ := readAssemblyScriptString(mem, uint32(stack[0]))
+	fileName, _ := readAssemblyScriptString(mem, uint32(stack[1]))
+	return AbortMessage{
+		Message:      msg,
+		FileName:     fileName,
+		LineNumber:   uint32(stack[2]),
+		ColumnNumber: uint32(stack[3]),
+	}
+}
+
 // abortWithMessage implements AbortName
 func abortWithMessage(ctx context.Context, mod api.Module, stack []uint64) {
 	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
@@ -226,43 +305,41 @@ var traceStderr = traceStdout.WithGoModuleFunc(func(_ context.Context, mod api.M
 //
 // See https://github.com/AssemblyScript/assemblyscript/blob/fa14b3b03bd4607efa52aaff3132bea0c03a7989/std/assembly/wasi/index.ts#L61
 func traceTo(mod api.Module, params []uint64, file experimentalsys.File) {
-	message := uint32(params[0])
-	nArgs := uint32(params[1])
-	arg0 := api.DecodeF64(params[2])
-	arg1 := api.DecodeF64(params[3])
-	arg2 := api.DecodeF64(params[4])
-	arg3 := api.DecodeF64(params[5])
-	arg4 := api.DecodeF64(params[6])
-
-	msg, ok := readAssemblyScriptString(mod.Memory(), message)
+	msg, args, ok := readTrace(mod.Memory(), params)
 	if !ok {
 		return // don't panic if unable to trace
 	}
 	var ret strings.Builder
 	ret.WriteString("trace: ")
 	ret.WriteString(msg)
-	if nArgs >= 1 {
-		ret.WriteString(" ")
-		ret.WriteString(formatFloat(arg0))
-	}
-	if nArgs >= 2 {
-		ret.WriteString(",")
-		ret.WriteString(formatFloat(arg1))
+	for i, arg := range args {
+		if i == 0 {
+			ret.WriteString(" ")
+		} else {
+			ret.WriteString(",")
+		}
+		ret.WriteString(formatFloat(arg))
 	}
-	if nArgs >= 3 {
-		ret.WriteString(",")
-		ret.WriteString(formatFloat(arg2))
+	ret.WriteByte('\n')
+	_, _ = file.Write([]byte(ret.String())) // don't crash if trace logging fails
+}
+
+// readTrace decodes the message and the arguments used of the parameters of
+// TraceName, or returns false if the message couldn't be read.
+func readTrace(mem api.Memory, params []uint64) (string, []float64, bool) {
+	msg, ok := readAssemblyScriptString(mem, uint32(params[0]))
+	if !ok {
+		return "", nil, false
 	}
-	if nArgs >= 4 {
-		ret.WriteString(",")
-		ret.WriteString(formatFloat(arg3))
+	nArgs := uint32(params[1])
+	if nArgs > 5 {
+		nArgs = 5
 	}
-	if nArgs >= 5 {
-		ret.WriteString(",")
-		ret.WriteString(formatFloat(arg4))
+	args := make([]float64, nArgs)
+	for i := range args {
+		args[i] = api.DecodeF64(params[2+i])
 	}
-	ret.WriteByte('\n')
-	_, _ = file.Write([]byte(ret.String())) // don't crash if trace logging fails
+	return msg, args, true
 }
 
 func formatFloat(f float64) string {
@@ -322,6 +322,42 @@ func TestFunctionExporter_Trace(t *testing.T) {
 	}
 }
 
+func TestFunctionExporter_Funcs(t *testing.T) {
+	var abortMsg AbortMessage
+	var traceMsg string
+	var traceArgs []float64
+	exporter := NewFunctionExporter().
+		WithAbortFunc(func(_ context.Context, _ api.Module, msg AbortMessage) {
+			abortMsg = msg
+		}).
+		WithTraceFunc(func(_ context.Context, _ api.Module, message string, args []float64) {
+			traceMsg, traceArgs = message, args
+		}).
+		WithSeedFunc(func(context.Context, api.Module) float64 {
+			return 42
+		})
+
+	var stderr bytes.Buffer
+	mod, r, _ := requireProxyModule(t, exporter, wazero.NewModuleConfig().WithStderr(&stderr), logging.LogScopeNone)
+	defer r.Close(testCtx)
+
+	messageOff, filenameOff := writeAbortMessageAndFileName(t, mod.Memory(), encodeUTF16("hello"), encodeUTF16("index.ts"))
+
+	_, err := mod.ExportedFunction(TraceName).Call(testCtx, uint64(messageOff), 2, api.EncodeF64(1), api.EncodeF64(2.5), 0, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, "hello", traceMsg)
+	require.Equal(t, []float64{1, 2.5}, traceArgs)
+
+	results, err := mod.ExportedFunction(SeedName).Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, float64(42), api.DecodeF64(results[0]))
+
+	_, err = mod.ExportedFunction(AbortName).Call(testCtx, uint64(messageOff), uint64(filenameOff), 3, 4)
+	require.Equal(t, uint32(255), err.(*sys.ExitError).ExitCode())
+	require.Equal(t, AbortMessage{Message: "hello", FileName: "index.ts", LineNumber: 3, ColumnNumber: 4}, abortMsg)
+	require.Zero(t, stderr.Len())
+}
+
 func Test_readAssemblyScriptString(t *testing.T) {
 	tests := []struct {
 		name       string
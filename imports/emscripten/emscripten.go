@@ -4,6 +4,11 @@
 // Emscripten has many imports which are triggered on build flags. Use
 // FunctionExporter, instead of Instantiate, to define more "env" functions.
 //
+// # Dynamic linking
+//
+// Main modules built with MAIN_MODULE, which load side modules with dlopen,
+// are instantiated with InstantiateMainModule.
+//
 // # Relationship to WASI
 //
 // Emscripten typically requires wasi_snapshot_preview1 to implement exit.
@@ -18,6 +23,7 @@ import (
 
 	"github.com/tetratelabs/wazero"
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental/dylink"
 	internal "github.com/tetratelabs/wazero/internal/emscripten"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
@@ -98,13 +104,39 @@ func InstantiateForModule(ctx context.Context, r wazero.Runtime, guest wazero.Co
 
 // NewFunctionExporterForModule returns a guest-specific FunctionExporter,
 // populated with any known functions used in emscripten.
+//
+// Besides `invoke_` trampolines, this includes common functions otherwise
+// implemented by the JS glue of Emscripten, when not built with
+// STANDALONE_WASM:
+//   - emscripten_resize_heap and emscripten_memcpy_js grow and copy memory.
+//   - emscripten_date_now, emscripten_get_now and clock_gettime read the
+//     clocks configured by wazero.ModuleConfig.
+//   - _tzset_js reports UTC, as the host time zone isn't visible.
+//   - _mmap_js and _munmap_js copy files into memory allocated by the guest,
+//     writing shared mappings back on unmap.
+//   - _emscripten_out, _emscripten_err and emscripten_console_log write lines
+//     to the configured stdout or stderr.
+//
+// Imports whose signature doesn't match the implementation are skipped, so
+// fail instantiation as missing.
+//
+// Functions loading side modules, such as dlopen, are also included, but
+// fail unless the main module is instantiated with InstantiateMainModule.
 func NewFunctionExporterForModule(guest wazero.CompiledModule) (FunctionExporter, error) {
+	return newFunctionExporter(guest, &internal.DynamicLinking{}), nil
+}
+
+func newFunctionExporter(guest wazero.CompiledModule, dl *internal.DynamicLinking) emscriptenFns {
 	ret := emscriptenFns{}
 	for _, fn := range guest.ImportedFunctions() {
 		importModule, importName, isImport := fn.Import()
 		if !isImport || importModule != "env" {
 			continue // not emscripten
 		}
+		if hf := dl.HostFunc(importName, fn.ParamTypes(), fn.ResultTypes()); hf != nil {
+			ret = append(ret, hf)
+			continue
+		}
 		if importName == internal.FunctionNotifyMemoryGrowth {
 			ret = append(ret, internal.NotifyMemoryGrowth)
 			continue
@@ -113,6 +145,10 @@ func NewFunctionExporterForModule(guest wazero.CompiledModule) (FunctionExporter
 			ret = append(ret, internal.ThrowLongjmp)
 			continue
 		}
+		if hf := internal.Shim(importName, fn.ParamTypes(), fn.ResultTypes()); hf != nil {
+			ret = append(ret, hf)
+			continue
+		}
 		if !strings.HasPrefix(importName, internal.InvokePrefix) {
 			continue // not invoke, and maybe not emscripten
 		}
@@ -120,7 +156,59 @@ func NewFunctionExporterForModule(guest wazero.CompiledModule) (FunctionExporter
 		hf := internal.NewInvokeFunc(importName, fn.ParamTypes(), fn.ResultTypes())
 		ret = append(ret, hf)
 	}
-	return ret, nil
+	return ret
+}
+
+// InstantiateMainModule instantiates `binary`, a main module built with
+// Emscripten's MAIN_MODULE, with `config`, and returns a loader of side
+// modules into it, e.g. built with SIDE_MODULE. Its main module is the
+// result of dylink.Loader Main.
+//
+// Its "env" imports are the functions of NewFunctionExporterForModule, and
+// the memory, table and globals shared with side modules. The main module
+// loads side modules from its filesystem with dlopen, and looks up their
+// symbols with dlsym. Other imports, e.g. of WASI, are satisfied by the
+// modules instantiated in `r` with that name.
+//
+// Like dylink.Loader InstantiateMain, the start functions of `config` aren't
+// called. Call "main" on the main module instead, or "_start" if built with
+// STANDALONE_WASM.
+//
+// # Notes
+//
+//   - This is experimental, and likely to change.
+//   - Side modules are instantiated with `config`, so have their own file
+//     descriptors, opened from the same wazero.FSConfig.
+//   - All symbols are global, as with RTLD_GLOBAL, and dlclose doesn't
+//     unload side modules.
+//   - Side modules can only import the functions of the Emscripten JS
+//     library the main module imports, or which it exports.
+//   - Threads aren't supported, nor dlopen of libraries needed by another,
+//     which must be loaded by the main module first.
+func InstantiateMainModule(ctx context.Context, r wazero.Runtime, binary []byte, config wazero.ModuleConfig) (*dylink.Loader, error) {
+	guest, err := r.CompileModule(ctx, binary)
+	if err != nil {
+		return nil, err
+	}
+	dl := &internal.DynamicLinking{Config: config}
+	env := r.NewHostModuleBuilder("env")
+	newFunctionExporter(guest, dl).ExportFunctions(env)
+	compiled, err := env.Compile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	host, err := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName(""))
+	if err != nil {
+		return nil, err
+	}
+
+	l := dylink.NewMainLoader(r, host)
+	if _, err = l.InstantiateMain(ctx, binary, config); err != nil {
+		_ = host.Close(ctx)
+		return nil, err
+	}
+	dl.Loader = l
+	return l, nil
 }
 
 // ExportFunctions implements FunctionExporter.ExportFunctions
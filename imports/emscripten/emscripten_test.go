@@ -4,17 +4,26 @@ import (
 	"bytes"
 	"context"
 	_ "embed"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
 	"testing"
+	"testing/fstest"
 
 	"github.com/tetratelabs/wazero"
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/experimental/logging"
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
 	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
 	internal "github.com/tetratelabs/wazero/internal/emscripten"
 	"github.com/tetratelabs/wazero/internal/testing/binaryencoding"
 	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasip1"
 	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
+	"github.com/tetratelabs/wazero/internal/wat"
 )
 
 const (
@@ -340,6 +349,142 @@ func TestNewFunctionExporterForModule(t *testing.T) {
 	}
 }
 
+func TestNewFunctionExporterForModule_dynamicLinking(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	guest, err := r.CompileModule(testCtx, binaryencoding.EncodeModule(&wasm.Module{
+		TypeSection:   []wasm.FunctionType{{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}}},
+		ImportSection: []wasm.Import{{Module: "env", Name: "_dlopen_js", Type: wasm.ExternTypeFunc}},
+	}))
+	require.NoError(t, err)
+
+	exporter, err := NewFunctionExporterForModule(guest)
+	require.NoError(t, err)
+	actual := exporter.(emscriptenFns)
+	require.Equal(t, 1, len(actual))
+	require.Equal(t, "_dlopen_js", actual[0].ExportName)
+}
+
+// mainModuleWat is like a main module built with MAIN_MODULE, which exports
+// functions calling the dynamic linking imports, a bump allocator, and
+// "dlerror", which returns the address of the last error.
+const mainModuleWat = `(module
+  (type $callback (func (param i32 i32)))
+  (type $ret (func (result i32)))
+  (import "env" "memory" (memory 1))
+  (import "env" "__indirect_function_table" (table 1 funcref))
+  (import "env" "__table_base" (global $table_base i32))
+  (import "env" "_dlinit" (func $dlinit (param i32)))
+  (import "env" "_dlopen_js" (func $dlopen (param i32) (result i32)))
+  (import "env" "_emscripten_dlopen_js" (func $dlopen_async (param i32 i32 i32 i32)))
+  (import "env" "_dlsym_js" (func $dlsym (param i32 i32 i32) (result i32)))
+  (global $result (mut i32) (i32.const 0))
+  (global $heap (mut i32) (i32.const 0x12000))
+  (global $error (mut i32) (i32.const 0))
+  (elem (global.get $table_base) $onsuccess $onerror)
+  (func $onsuccess (type $callback) (global.set $result (local.get 0)))
+  (func $onerror (type $callback) (global.set $result (i32.const -1)))
+  (func (export "__wasm_call_ctors") (call $dlinit (i32.const 0x10f00)))
+  (func (export "dlopen") (param i32) (result i32) (call $dlopen (local.get 0)))
+  (func (export "dlopen_async") (param i32) (result i32)
+    (call $dlopen_async (local.get 0)
+      (global.get $table_base) (i32.add (global.get $table_base) (i32.const 1)) (i32.const 0))
+    (global.get $result))
+  (func (export "dlsym") (param i32 i32) (result i32)
+    (call $dlsym (local.get 0) (local.get 1) (i32.const 0)))
+  (func (export "call") (param i32) (result i32) (call_indirect (type $ret) (local.get 0)))
+  (func (export "malloc") (param i32) (result i32)
+    (global.get $heap)
+    (global.set $heap (i32.add (global.get $heap) (local.get 0))))
+  (func (export "free") (param i32))
+  (func (export "__dl_seterr") (param i32 i32) (global.set $error (local.get 0)))
+  (func (export "dlerror") (result i32) (global.get $error)))`
+
+// sideModuleWat is like a side module built with SIDE_MODULE.
+const sideModuleWat = `(module
+  (import "env" "memory" (memory 0))
+  (import "env" "__memory_base" (global $memory_base i32))
+  (data (global.get $memory_base) "\2a\00\00\00")
+  (global (export "answer") i32 (i32.const 0))
+  (func (export "forty_two") (result i32) (i32.const 42)))`
+
+func TestInstantiateMainModule(t *testing.T) {
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	fsys := fstest.MapFS{"lib.so": {Data: dylinkModule(t, sideModuleWat, 4, 2, 0)}}
+	config := wazero.NewModuleConfig().WithFSConfig(wazero.NewFSConfig().WithFSMount(fsys, "/"))
+	l, err := InstantiateMainModule(testCtx, r, dylinkModule(t, mainModuleWat, 0, 0, 2), config)
+	require.NoError(t, err)
+	main := l.Main()
+	mem := main.Memory()
+
+	// dso writes a struct dso with the library `name` at `handle`.
+	dso := func(handle uint32, name string) uint64 {
+		mem.WriteString(handle+48, name+"\x00")
+		return uint64(handle)
+	}
+	// cstring writes a C string at 0x11f00, returning its address.
+	cstring := func(s string) uint64 {
+		mem.WriteString(0x11f00, s+"\x00")
+		return 0x11f00
+	}
+	dlerror := func() string {
+		b, _ := mem.Read(uint32(callExported(t, main, "dlerror")[0]), 128)
+		return string(b[:bytes.IndexByte(b, 0)])
+	}
+
+	lib := dso(0x11000, "/lib.so")
+	require.Equal(t, []uint64{lib}, callExported(t, main, "dlopen", lib))
+
+	// Symbols are the table slots of functions, and the address of data.
+	slot := callExported(t, main, "dlsym", lib, cstring("forty_two"))[0]
+	require.Equal(t, []uint64{42}, callExported(t, main, "call", slot))
+	answer := callExported(t, main, "dlsym", lib, cstring("answer"))[0]
+	v, _ := mem.ReadUint32Le(uint32(answer))
+	require.Equal(t, uint32(42), v)
+
+	// RTLD_DEFAULT looks up symbols in all modules, and the main module has
+	// the handle passed to _dlinit.
+	require.Equal(t, []uint64{slot}, callExported(t, main, "dlsym", 0, cstring("forty_two")))
+	require.NotEqual(t, uint64(0), callExported(t, main, "dlsym", 0x10f00, cstring("malloc"))[0])
+	require.Equal(t, []uint64{0}, callExported(t, main, "dlsym", 0x10f00, cstring("forty_two")))
+	require.Equal(t, `tried to lookup unknown symbol "forty_two"`, dlerror())
+
+	missing := dso(0x11100, "/missing.so")
+	require.Equal(t, []uint64{0}, callExported(t, main, "dlopen", missing))
+	require.Equal(t, "could not load dynamic lib /missing.so: no such file or directory", dlerror())
+
+	// The callbacks of emscripten_dlopen are called before it returns.
+	lib = dso(0x11200, "/lib.so")
+	require.Equal(t, []uint64{lib}, callExported(t, main, "dlopen_async", lib))
+	require.Equal(t, []uint64{api.EncodeI32(-1)}, callExported(t, main, "dlopen_async", missing))
+}
+
+// dylinkModule compiles the module `source` in the text format, prefixed by a
+// "dylink.0" section with the given memory and table sizes.
+func dylinkModule(t *testing.T, source string, memorySize, memoryAlign, tableSize uint32) []byte {
+	bin, err := wat.Compile([]byte(source))
+	require.NoError(t, err)
+
+	memInfo := []byte{byte(memorySize), byte(memoryAlign), byte(tableSize), 0}
+	section := append([]byte{8}, "dylink.0"...)
+	section = append(section, 1, byte(len(memInfo))) // WASM_DYLINK_MEM_INFO
+	section = append(section, memInfo...)
+
+	ret := append([]byte{}, bin[:8]...)
+	ret = append(ret, 0, byte(len(section)))
+	ret = append(ret, section...)
+	return append(ret, bin[8:]...)
+}
+
+func callExported(t *testing.T, mod api.Module, name string, params ...uint64) []uint64 {
+	results, err := mod.ExportedFunction(name).Call(testCtx, params...)
+	require.NoError(t, err)
+	return results
+}
+
 func TestInstantiateForModule(t *testing.T) {
 	var log bytes.Buffer
 
@@ -560,3 +705,152 @@ func TestInstantiateForModule(t *testing.T) {
 		})
 	}
 }
+
+func TestInstantiateForModule_shims(t *testing.T) {
+	mmapType := wasm.FunctionType{Params: []wasm.ValueType{i32, i32, i32, i32, i64, i32, i32}, Results: []wasm.ValueType{i32}}
+	munmapType := wasm.FunctionType{Params: []wasm.ValueType{i32, i32, i32, i32, i32, i64}, Results: []wasm.ValueType{i32}}
+	guest := newShimsGuest(map[string]wasm.FunctionType{
+		"emscripten_resize_heap": {Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}},
+		"_emscripten_memcpy_js":  {Params: []wasm.ValueType{i32, i32, i32}},
+		"emscripten_get_now":     {Results: []wasm.ValueType{f64}},
+		"clock_gettime":          {Params: []wasm.ValueType{i32, i32}, Results: []wasm.ValueType{i32}},
+		"_tzset_js":              {Params: []wasm.ValueType{i32, i32, i32, i32}},
+		"_mmap_js":               mmapType,
+		"_munmap_js":             munmapType,
+		"_emscripten_out":        {Params: []wasm.ValueType{i32}},
+	})
+
+	r := wazero.NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	compiled, err := r.CompileModule(testCtx, guest)
+	require.NoError(t, err)
+	_, err = InstantiateForModule(testCtx, r, compiled)
+	require.NoError(t, err)
+
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "file"), []byte("wazero"), 0o600))
+
+	var stdout bytes.Buffer
+	mod, err := r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig().
+		WithStdout(&stdout).WithSysNanotime().
+		WithFSConfig(wazero.NewFSConfig().WithDirMount(tmpDir, "/")))
+	require.NoError(t, err)
+	mem := mod.Memory()
+
+	call := func(name string, params ...uint64) uint64 {
+		results, err := mod.ExportedFunction(name).Call(testCtx, params...)
+		require.NoError(t, err)
+		if len(results) == 0 {
+			return 0
+		}
+		return results[0]
+	}
+
+	t.Run("emscripten_resize_heap", func(t *testing.T) {
+		require.Equal(t, uint64(1), call("emscripten_resize_heap", 3*65536+1))
+		require.Equal(t, uint32(4*65536), mem.Size())
+		require.Equal(t, uint64(1), call("emscripten_resize_heap", 10))
+		require.Equal(t, uint64(0), call("emscripten_resize_heap", math.MaxUint32))
+	})
+
+	t.Run("_emscripten_memcpy_js", func(t *testing.T) {
+		mem.WriteString(100, "hello")
+		call("_emscripten_memcpy_js", 102, 100, 5)
+		b, _ := mem.Read(100, 7)
+		require.Equal(t, "hehello", string(b))
+
+		_, err := mod.ExportedFunction("_emscripten_memcpy_js").Call(testCtx, 0, uint64(mem.Size()), 1)
+		require.ErrorIs(t, err, wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+	})
+
+	t.Run("clocks", func(t *testing.T) {
+		require.True(t, api.DecodeF64(call("emscripten_get_now")) > 0)
+
+		require.Equal(t, uint64(0), call("clock_gettime", 0, 200))
+		sec, _ := mem.ReadUint32Le(200)
+		require.Equal(t, uint32(1640995200), sec) // fake walltime
+
+		require.Equal(t, uint32(math.MaxUint32), uint32(call("clock_gettime", 99, 200)))
+	})
+
+	t.Run("_tzset_js", func(t *testing.T) {
+		mem.WriteUint32Le(300, 1)
+		call("_tzset_js", 300, 304, 308, 316)
+		tz, _ := mem.ReadUint64Le(300)
+		require.Zero(t, tz)
+		names, _ := mem.Read(308, 12)
+		require.Equal(t, "UTC\x00\x00\x00\x00\x00UTC\x00", string(names))
+	})
+
+	t.Run("_mmap_js _munmap_js", func(t *testing.T) {
+		fsc := mod.(*wasm.ModuleInstance).Sys.FS()
+		preopen, _ := fsc.LookupFile(3)
+		fd, errno := fsc.OpenFile(preopen.FS, "file", experimentalsys.O_RDWR, 0)
+		require.EqualErrno(t, 0, errno)
+
+		require.Equal(t, uint64(0), call("_mmap_js", 10, 3, 1, uint64(fd), 2, 400, 404))
+		allocated, _ := mem.ReadUint32Le(400)
+		require.Equal(t, uint32(1), allocated)
+		addr, _ := mem.ReadUint32Le(404)
+		require.Equal(t, uint32(shimsGuestHeap), addr)
+		b, _ := mem.Read(addr, 10)
+		require.Equal(t, "zero\x00\x00\x00\x00\x00\x00", string(b))
+
+		// A shared, writable mapping is written back to the file.
+		mem.WriteString(addr, "ZERO")
+		require.Equal(t, uint64(0), call("_munmap_js", uint64(addr), 4, 3, 1, uint64(fd), 2))
+		data, err := os.ReadFile(filepath.Join(tmpDir, "file"))
+		require.NoError(t, err)
+		require.Equal(t, "waZERO", string(data))
+
+		require.Equal(t, -int32(wasip1.ErrnoBadf), int32(call("_mmap_js", 10, 3, 1, 42, 0, 400, 404)))
+	})
+
+	t.Run("_emscripten_out", func(t *testing.T) {
+		mem.WriteString(500, "hello\x00")
+		call("_emscripten_out", 500)
+		require.Equal(t, "hello\n", stdout.String())
+	})
+}
+
+// shimsGuestHeap is the address returned by emscripten_builtin_memalign in
+// the module returned by newShimsGuest.
+const shimsGuestHeap = 65536
+
+// newShimsGuest returns a module which imports the given functions from
+// "env", and exports functions of the same name which call them. It also
+// exports emscripten_builtin_memalign, which always returns shimsGuestHeap.
+func newShimsGuest(imports map[string]wasm.FunctionType) []byte {
+	names := make([]string, 0, len(imports))
+	for name := range imports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	m := &wasm.Module{
+		TypeSection:   []wasm.FunctionType{{Params: []wasm.ValueType{i32, i32}, Results: []wasm.ValueType{i32}}},
+		MemorySection: &wasm.Memory{Min: 2, Max: 4, IsMaxEncoded: true},
+		ExportSection: []wasm.Export{{Name: "memory", Type: api.ExternTypeMemory}},
+	}
+	importCount := wasm.Index(len(names))
+	for i, name := range names {
+		typeIndex := wasm.Index(len(m.TypeSection))
+		m.TypeSection = append(m.TypeSection, imports[name])
+		m.ImportSection = append(m.ImportSection, wasm.Import{Module: "env", Name: name, Type: wasm.ExternTypeFunc, DescFunc: typeIndex})
+
+		var body []byte
+		for j := range imports[name].Params {
+			body = append(body, wasm.OpcodeLocalGet, byte(j))
+		}
+		body = append(body, wasm.OpcodeCall, byte(i), wasm.OpcodeEnd)
+		m.FunctionSection = append(m.FunctionSection, typeIndex)
+		m.CodeSection = append(m.CodeSection, wasm.Code{Body: body})
+		m.ExportSection = append(m.ExportSection, wasm.Export{Name: name, Type: wasm.ExternTypeFunc, Index: importCount + wasm.Index(i)})
+	}
+
+	m.FunctionSection = append(m.FunctionSection, 0)
+	m.CodeSection = append(m.CodeSection, wasm.Code{Body: []byte{wasm.OpcodeI32Const, 0x80, 0x80, 0x04, wasm.OpcodeEnd}})
+	m.ExportSection = append(m.ExportSection, wasm.Export{Name: "emscripten_builtin_memalign", Type: wasm.ExternTypeFunc, Index: importCount + wasm.Index(len(names))})
+	return binaryencoding.EncodeModule(m)
+}
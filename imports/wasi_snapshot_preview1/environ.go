@@ -5,6 +5,7 @@ import (
 
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/experimental/sys"
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
 	"github.com/tetratelabs/wazero/internal/wasip1"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
@@ -43,11 +44,14 @@ import (
 // See https://en.wikipedia.org/wiki/Null-terminated_string
 var environGet = newHostFunc(wasip1.EnvironGetName, environGetFn, []api.ValueType{i32, i32}, "environ", "environ_buf")
 
-func environGetFn(_ context.Context, mod api.Module, params []uint64) sys.Errno {
-	sysCtx := mod.(*wasm.ModuleInstance).Sys
+func environGetFn(ctx context.Context, mod api.Module, params []uint64) sys.Errno {
 	environ, environBuf := uint32(params[0]), uint32(params[1])
 
-	return writeOffsetsAndNullTerminatedValues(mod.Memory(), sysCtx.Environ(), environ, environBuf, sysCtx.EnvironSize())
+	values, size, errno := environOf(ctx, mod)
+	if errno != 0 {
+		return errno
+	}
+	return writeOffsetsAndNullTerminatedValues(mod.Memory(), values, environ, environBuf, size)
 }
 
 // environSizesGet is the WASI function named EnvironSizesGetName that
@@ -83,18 +87,36 @@ func environGetFn(_ context.Context, mod api.Module, params []uint64) sys.Errno
 // and https://en.wikipedia.org/wiki/Null-terminated_string
 var environSizesGet = newHostFunc(wasip1.EnvironSizesGetName, environSizesGetFn, []api.ValueType{i32, i32}, "result.environc", "result.environv_len")
 
-func environSizesGetFn(_ context.Context, mod api.Module, params []uint64) sys.Errno {
-	sysCtx := mod.(*wasm.ModuleInstance).Sys
+func environSizesGetFn(ctx context.Context, mod api.Module, params []uint64) sys.Errno {
 	mem := mod.Memory()
 	resultEnvironc, resultEnvironvLen := uint32(params[0]), uint32(params[1])
 
+	values, size, errno := environOf(ctx, mod)
+	if errno != 0 {
+		return errno
+	}
+
 	// environc and environv_len offsets are not necessarily sequential, so we
 	// have to write them independently.
-	if !mem.WriteUint32Le(resultEnvironc, uint32(len(sysCtx.Environ()))) {
+	if !mem.WriteUint32Le(resultEnvironc, uint32(len(values))) {
 		return sys.EFAULT
 	}
-	if !mem.WriteUint32Le(resultEnvironvLen, sysCtx.EnvironSize()) {
+	if !mem.WriteUint32Le(resultEnvironvLen, size) {
 		return sys.EFAULT
 	}
 	return 0
 }
+
+// environOf returns the environment of `mod` and its size as null-terminated
+// strings, preferring any provider set by experimental.WithEnvironProvider.
+func environOf(ctx context.Context, mod api.Module) ([][]byte, uint32, sys.Errno) {
+	if p, ok := ctx.Value(internalsys.EnvironProviderKey{}).(*internalsys.EnvironProvider); ok {
+		environ, size, err := p.Environ(ctx)
+		if err != nil {
+			return nil, 0, sys.EINVAL
+		}
+		return environ, size, 0
+	}
+	sysCtx := mod.(*wasm.ModuleInstance).Sys
+	return sysCtx.Environ(), sysCtx.EnvironSize(), 0
+}
@@ -1,9 +1,11 @@
 package wasi_snapshot_preview1_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasip1"
 )
@@ -104,6 +106,39 @@ func Test_environGet_Errors(t *testing.T) {
 	}
 }
 
+func Test_environGet_WithEnvironProvider(t *testing.T) {
+	mod, r, _ := requireProxyModule(t, wazero.NewModuleConfig().WithEnv("a", "b"))
+	defer r.Close(testCtx)
+
+	var calls int
+	ctx := experimental.WithEnvironProvider(testCtx, func(context.Context) []string {
+		calls++
+		return []string{"secret=c"}
+	})
+
+	// The provider is called once, so that both functions see the same value.
+	results, err := mod.ExportedFunction(wasip1.EnvironSizesGetName).Call(ctx, 0, 4)
+	require.NoError(t, err)
+	require.Equal(t, uint64(wasip1.ErrnoSuccess), results[0])
+	results, err = mod.ExportedFunction(wasip1.EnvironGetName).Call(ctx, 8, 16)
+	require.NoError(t, err)
+	require.Equal(t, uint64(wasip1.ErrnoSuccess), results[0])
+	require.Equal(t, 1, calls)
+
+	environc, _ := mod.Memory().ReadUint32Le(0)
+	require.Equal(t, uint32(1), environc)
+	environ, _ := mod.Memory().Read(16, 9)
+	require.Equal(t, "secret=c\x00", string(environ))
+
+	// Invalid entries fail.
+	ctx = experimental.WithEnvironProvider(testCtx, func(context.Context) []string {
+		return []string{"secret"}
+	})
+	results, err = mod.ExportedFunction(wasip1.EnvironSizesGetName).Call(ctx, 0, 4)
+	require.NoError(t, err)
+	require.Equal(t, uint64(wasip1.ErrnoInval), results[0])
+}
+
 func Test_environSizesGet(t *testing.T) {
 	mod, r, log := requireProxyModule(t, wazero.NewModuleConfig().
 		WithEnv("a", "b").WithEnv("b", "cd"))
@@ -13,6 +13,7 @@ import (
 	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
 	socketapi "github.com/tetratelabs/wazero/internal/sock"
 	"github.com/tetratelabs/wazero/internal/sys"
+	"github.com/tetratelabs/wazero/internal/sysfs"
 	"github.com/tetratelabs/wazero/internal/wasip1"
 	"github.com/tetratelabs/wazero/internal/wasm"
 	sysapi "github.com/tetratelabs/wazero/sys"
@@ -30,12 +31,12 @@ var fdAdvise = newHostFunc(
 
 func fdAdviseFn(_ context.Context, mod api.Module, params []uint64) experimentalsys.Errno {
 	fd := int32(params[0])
-	_ = params[1]
-	_ = params[2]
+	offset := int64(params[1])
+	length := int64(params[2])
 	advice := byte(params[3])
 	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
 
-	_, ok := fsc.LookupFile(fd)
+	f, ok := lookupFile(fsc, fd, wasip1.RIGHT_FD_ADVISE)
 	if !ok {
 		return experimentalsys.EBADF
 	}
@@ -53,11 +54,10 @@ func fdAdviseFn(_ context.Context, mod api.Module, params []uint64) experimental
 
 	// FdAdvice corresponds to posix_fadvise, but it can only be supported on linux.
 	// However, the purpose of the call is just to do best-effort optimization on OS kernels,
-	// so just making this noop rather than returning NoSup error makes sense and doesn't affect
-	// the semantics of Wasm applications.
-	// TODO: invoke posix_fadvise on linux, and partially on darwin.
-	// - https://gitlab.com/cznic/fileutil/-/blob/v1.1.2/fileutil_linux.go#L87-95
+	// so ignoring errors rather than returning them makes sense and doesn't affect
+	// the semantics of Wasm applications. For example, pipes don't support advice.
 	// - https://github.com/bytecodealliance/system-interface/blob/62b97f9776b86235f318c3a6e308395a1187439b/src/fs/file_io_ext.rs#L430-L442
+	_ = sysfs.Advise(f.File, offset, length, sysfs.Advice(advice))
 	return 0
 }
 
@@ -77,7 +77,7 @@ func fdAllocateFn(_ context.Context, mod api.Module, params []uint64) experiment
 	length := params[2]
 
 	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
-	f, ok := fsc.LookupFile(fd)
+	f, ok := lookupFile(fsc, fd, wasip1.RIGHT_FD_ALLOCATE)
 	if !ok {
 		return experimentalsys.EBADF
 	}
@@ -135,7 +135,7 @@ func fdDatasyncFn(_ context.Context, mod api.Module, params []uint64) experiment
 	fd := int32(params[0])
 
 	// Check to see if the file descriptor is available
-	if f, ok := fsc.LookupFile(fd); !ok {
+	if f, ok := lookupFile(fsc, fd, wasip1.RIGHT_FD_DATASYNC); !ok {
 		return experimentalsys.EBADF
 	} else {
 		return f.File.Datasync()
@@ -160,8 +160,8 @@ func fdDatasyncFn(_ context.Context, mod api.Module, params []uint64) experiment
 //   - fs_filetype 1 byte: the file type
 //   - fs_flags 2 bytes: the file descriptor flag
 //   - 5 pad bytes
-//   - fs_right_base 8 bytes: ignored as rights were removed from WASI.
-//   - fs_right_inheriting 8 bytes: ignored as rights were removed from WASI.
+//   - fs_right_base 8 bytes: the rights of the file descriptor.
+//   - fs_right_inheriting 8 bytes: the rights of files opened from it.
 //
 // For example, with a file corresponding with `fd` was a directory (=3) opened
 // with `fd_read` right (=1) and no fs_flags (=0), parameter resultFdstat=1,
@@ -229,6 +229,11 @@ func fdFdstatGetFn(_ context.Context, mod api.Module, params []uint64) experimen
 		fsRightsBase = fileRightsBase
 	}
 
+	if r := f.Rights; r != nil {
+		fsRightsBase &= uint32(r.Base)
+		fsRightsInheriting &= uint32(r.Inheriting)
+	}
+
 	writeFdstat(buf, fileType, fdflags, fsRightsBase, fsRightsInheriting)
 	return 0
 }
@@ -307,7 +312,7 @@ func fdFdstatSetFlagsFn(_ context.Context, mod api.Module, params []uint64) expe
 		return experimentalsys.EINVAL
 	}
 
-	if f, ok := fsc.LookupFile(fd); !ok {
+	if f, ok := lookupFile(fsc, fd, wasip1.RIGHT_FDSTAT_SET_FLAGS); !ok {
 		return experimentalsys.EBADF
 	} else {
 		nonblock := wasip1.FD_NONBLOCK&wasiFlag != 0
@@ -398,7 +403,7 @@ func fdFilestatGetFunc(mod api.Module, fd int32, resultBuf uint32) experimentals
 		return experimentalsys.EFAULT
 	}
 
-	f, ok := fsc.LookupFile(fd)
+	f, ok := lookupFile(fsc, fd, wasip1.RIGHT_FD_FILESTAT_GET)
 	if !ok {
 		return experimentalsys.EBADF
 	}
@@ -469,7 +474,7 @@ func fdFilestatSetSizeFn(_ context.Context, mod api.Module, params []uint64) exp
 	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
 
 	// Check to see if the file descriptor is available
-	if f, ok := fsc.LookupFile(fd); !ok {
+	if f, ok := lookupFile(fsc, fd, wasip1.RIGHT_FD_FILESTAT_SET_SIZE); !ok {
 		return experimentalsys.EBADF
 	} else {
 		return f.File.Truncate(int64(size))
@@ -495,7 +500,7 @@ func fdFilestatSetTimesFn(_ context.Context, mod api.Module, params []uint64) ex
 	sys := mod.(*wasm.ModuleInstance).Sys
 	fsc := sys.FS()
 
-	f, ok := fsc.LookupFile(fd)
+	f, ok := lookupFile(fsc, fd, wasip1.RIGHT_FD_FILESTAT_SET_TIMES)
 	if !ok {
 		return experimentalsys.EBADF
 	}
@@ -774,9 +779,14 @@ func fdReadOrPread(mod api.Module, params []uint64, isPread bool) experimentalsy
 	iovs := uint32(params[1])
 	iovsCount := uint32(params[2])
 
+	rights := wasip1.RIGHT_FD_READ
+	if isPread {
+		rights |= wasip1.RIGHT_FD_SEEK
+	}
+
 	var resultNread uint32
 	var reader func(buf []byte) (n int, errno experimentalsys.Errno)
-	if f, ok := fsc.LookupFile(fd); !ok {
+	if f, ok := lookupFile(fsc, fd, rights); !ok {
 		return experimentalsys.EBADF
 	} else if isPread {
 		offset := int64(params[3])
@@ -1033,7 +1043,7 @@ func writeDirent(buf []byte, dNext uint64, ino sysapi.Inode, dNamlen uint32, dTy
 // direntCache lazy opens a sys.DirentCache for this directory or returns an
 // error.
 func direntCache(fsc *sys.FSContext, fd int32) (*sys.DirentCache, experimentalsys.Errno) {
-	if f, ok := fsc.LookupFile(fd); !ok {
+	if f, ok := lookupFile(fsc, fd, wasip1.RIGHT_FD_READDIR); !ok {
 		return nil, experimentalsys.EBADF
 	} else if dir, errno := f.DirentCache(); errno == 0 {
 		return dir, 0
@@ -1119,7 +1129,12 @@ func fdSeekFn(_ context.Context, mod api.Module, params []uint64) experimentalsy
 	whence := uint32(params[2])
 	resultNewoffset := uint32(params[3])
 
-	if f, ok := fsc.LookupFile(fd); !ok {
+	rights := wasip1.RIGHT_FD_SEEK
+	if offset == 0 && whence == io.SeekCurrent {
+		rights = wasip1.RIGHT_FD_TELL // only reads the offset
+	}
+
+	if f, ok := lookupFile(fsc, fd, rights); !ok {
 		return experimentalsys.EBADF
 	} else if isDir, _ := f.File.IsDir(); isDir {
 		return experimentalsys.EISDIR // POSIX doesn't forbid seeking a directory, but wasi-testsuite does.
@@ -1142,7 +1157,7 @@ func fdSyncFn(_ context.Context, mod api.Module, params []uint64) experimentalsy
 	fd := int32(params[0])
 
 	// Check to see if the file descriptor is available
-	if f, ok := fsc.LookupFile(fd); !ok {
+	if f, ok := lookupFile(fsc, fd, wasip1.RIGHT_FD_SYNC); !ok {
 		return experimentalsys.EBADF
 	} else {
 		return f.File.Sync()
@@ -1258,9 +1273,14 @@ func fdWriteOrPwrite(mod api.Module, params []uint64, isPwrite bool) experimenta
 	iovs := uint32(params[1])
 	iovsCount := uint32(params[2])
 
+	rights := wasip1.RIGHT_FD_WRITE
+	if isPwrite {
+		rights |= wasip1.RIGHT_FD_SEEK
+	}
+
 	var resultNwritten uint32
 	var writer func(buf []byte) (n int, errno experimentalsys.Errno)
-	if f, ok := fsc.LookupFile(fd); !ok {
+	if f, ok := lookupFile(fsc, fd, rights); !ok {
 		return experimentalsys.EBADF
 	} else if isPwrite {
 		offset := int64(params[3])
@@ -1343,7 +1363,7 @@ func pathCreateDirectoryFn(_ context.Context, mod api.Module, params []uint64) e
 	path := uint32(params[1])
 	pathLen := uint32(params[2])
 
-	preopen, pathName, errno := atPath(fsc, mod.Memory(), fd, path, pathLen)
+	preopen, pathName, errno := atPath(fsc, mod.Memory(), fd, path, pathLen, wasip1.RIGHT_PATH_CREATE_DIRECTORY)
 	if errno != 0 {
 		return errno
 	}
@@ -1397,7 +1417,7 @@ func pathFilestatGetFn(_ context.Context, mod api.Module, params []uint64) exper
 	path := uint32(params[2])
 	pathLen := uint32(params[3])
 
-	preopen, pathName, errno := atPath(fsc, mod.Memory(), fd, path, pathLen)
+	preopen, pathName, errno := atPath(fsc, mod.Memory(), fd, path, pathLen, wasip1.RIGHT_PATH_FILESTAT_GET)
 	if errno != 0 {
 		return errno
 	}
@@ -1452,26 +1472,18 @@ func pathFilestatSetTimesFn(_ context.Context, mod api.Module, params []uint64)
 		return errno
 	}
 
-	preopen, pathName, errno := atPath(fsc, mod.Memory(), fd, path, pathLen)
+	preopen, pathName, errno := atPath(fsc, mod.Memory(), fd, path, pathLen, wasip1.RIGHT_PATH_FILESTAT_SET_TIMES)
 	if errno != 0 {
 		return errno
 	}
 
 	symlinkFollow := flags&wasip1.LOOKUP_SYMLINK_FOLLOW != 0
-	if symlinkFollow {
-		return preopen.Utimens(pathName, atim, mtim)
-	}
-	// Otherwise, we need to emulate don't follow by opening the file by path.
-	if f, errno := preopen.OpenFile(pathName, experimentalsys.O_WRONLY, 0); errno != 0 {
-		return errno
-	} else {
-		defer f.Close()
-		return f.Utimens(atim, mtim)
-	}
+	return fsc.Utimens(preopen, pathName, atim, mtim, symlinkFollow)
 }
 
-// pathLink is the WASI function named PathLinkName which adjusts the
-// timestamps of a file or directory.
+// pathLink is the WASI function named PathLinkName which creates a hard link.
+// When `old_flags` includes LOOKUP_SYMLINK_FOLLOW, a symbolic link at
+// `old_path` is followed, linking its target instead.
 //
 // See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#path_link
 var pathLink = newHostFunc(
@@ -1485,12 +1497,11 @@ func pathLinkFn(_ context.Context, mod api.Module, params []uint64) experimental
 	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
 
 	oldFD := int32(params[0])
-	// TODO: use old_flags?
-	_ = uint32(params[1])
+	oldFlags := uint16(params[1])
 	oldPath := uint32(params[2])
 	oldPathLen := uint32(params[3])
 
-	oldFS, oldName, errno := atPath(fsc, mem, oldFD, oldPath, oldPathLen)
+	oldFS, oldName, errno := atPath(fsc, mem, oldFD, oldPath, oldPathLen, wasip1.RIGHT_PATH_LINK_SOURCE)
 	if errno != 0 {
 		return errno
 	}
@@ -1499,16 +1510,13 @@ func pathLinkFn(_ context.Context, mod api.Module, params []uint64) experimental
 	newPath := uint32(params[5])
 	newPathLen := uint32(params[6])
 
-	newFS, newName, errno := atPath(fsc, mem, newFD, newPath, newPathLen)
+	newFS, newName, errno := atPath(fsc, mem, newFD, newPath, newPathLen, wasip1.RIGHT_PATH_LINK_TARGET)
 	if errno != 0 {
 		return errno
 	}
 
-	if oldFS != newFS { // TODO: handle link across filesystems
-		return experimentalsys.ENOSYS
-	}
-
-	return oldFS.Link(oldName, newName)
+	symlinkFollow := oldFlags&wasip1.LOOKUP_SYMLINK_FOLLOW != 0
+	return fsc.Link(oldFS, oldName, newFS, newName, symlinkFollow)
 }
 
 // pathOpen is the WASI function named PathOpenName which opens a file or
@@ -1522,13 +1530,17 @@ func pathLinkFn(_ context.Context, mod api.Module, params []uint64) experimental
 //   - pathLen: length of `path`
 //   - oFlags: open flags to indicate the method by which to open the file
 //   - fsRightsBase: interpret RIGHT_FD_WRITE to set O_RDWR
-//   - fsRightsInheriting: ignored as rights were removed from WASI.
+//   - fsRightsInheriting: rights of files opened from the newly
 //     created file descriptor for `path`
 //   - fdFlags: file descriptor flags
 //   - resultOpenedFD: offset in api.Memory to write the newly created file
 //     descriptor to.
 //   - The result FD value is guaranteed to be less than 2**31
 //
+// Note: Rights are only enforced when the host restricts pre-opened
+// directories with experimental/rights. Otherwise, only the above
+// interpretation of fsRightsBase applies, as rights were removed from WASI.
+//
 // Result (Errno)
 //
 // The return value is 0 except the following error conditions:
@@ -1585,13 +1597,20 @@ func pathOpenFn(_ context.Context, mod api.Module, params []uint64) experimental
 	oflags := uint16(params[4])
 
 	rights := uint32(params[5])
-	// inherited rights aren't used
-	_ = params[6]
+	inheriting := params[6]
 
 	fdflags := uint16(params[7])
 	resultOpenedFD := uint32(params[8])
 
-	preopen, pathName, errno := atPath(fsc, mod.Memory(), preopenFD, path, pathLen)
+	dirRights := wasip1.RIGHT_PATH_OPEN
+	if oflags&wasip1.O_CREAT != 0 {
+		dirRights |= wasip1.RIGHT_PATH_CREATE_FILE
+	}
+	if oflags&wasip1.O_TRUNC != 0 {
+		dirRights |= wasip1.RIGHT_PATH_FILESTAT_SET_SIZE
+	}
+
+	preopen, pathName, errno := atPath(fsc, mod.Memory(), preopenFD, path, pathLen, dirRights)
 	if errno != 0 {
 		return errno
 	}
@@ -1608,6 +1627,15 @@ func pathOpenFn(_ context.Context, mod api.Module, params []uint64) experimental
 		return errno
 	}
 
+	// Restrict the rights of the new file to those its directory can pass on.
+	if dir, _ := fsc.LookupFile(preopenFD); dir.Rights != nil {
+		f, _ := fsc.LookupFile(newFD)
+		f.Rights = &sys.Rights{
+			Base:       uint64(rights) & dir.Rights.Inheriting,
+			Inheriting: inheriting & dir.Rights.Inheriting,
+		}
+	}
+
 	// Check any flags that require the file to evaluate.
 	if isDir {
 		if f, ok := fsc.LookupFile(newFD); !ok {
@@ -1628,7 +1656,8 @@ func pathOpenFn(_ context.Context, mod api.Module, params []uint64) experimental
 	return 0
 }
 
-// atPath returns the pre-open specific path after verifying it is a directory.
+// atPath returns the pre-open specific path after verifying it is a directory
+// with `rights`.
 //
 // # Notes
 //
@@ -1644,7 +1673,7 @@ func pathOpenFn(_ context.Context, mod api.Module, params []uint64) experimental
 //
 // See https://github.com/WebAssembly/wasi-libc/blob/659ff414560721b1660a19685110e484a081c3d4/libc-bottom-half/sources/at_fdcwd.c
 // See https://linux.die.net/man/2/openat
-func atPath(fsc *sys.FSContext, mem api.Memory, fd int32, p, pathLen uint32) (experimentalsys.FS, string, experimentalsys.Errno) {
+func atPath(fsc *sys.FSContext, mem api.Memory, fd int32, p, pathLen, rights uint32) (experimentalsys.FS, string, experimentalsys.Errno) {
 	b, ok := mem.Read(p, pathLen)
 	if !ok {
 		return nil, "", experimentalsys.EFAULT
@@ -1672,6 +1701,8 @@ func atPath(fsc *sys.FSContext, mem api.Memory, fd int32, p, pathLen uint32) (ex
 
 	if f, ok := fsc.LookupFile(fd); !ok {
 		return nil, "", experimentalsys.EBADF // closed or invalid
+	} else if !f.HasRights(uint64(rights)) {
+		return nil, "", experimentalsys.EACCES
 	} else if isDir, errno := f.File.IsDir(); errno != 0 {
 		return nil, "", errno
 	} else if !isDir {
@@ -1684,6 +1715,17 @@ func atPath(fsc *sys.FSContext, mem api.Memory, fd int32, p, pathLen uint32) (ex
 	}
 }
 
+// lookupFile is like sys.FSContext LookupFile, except it also fails when the
+// file lacks any of `rights`. Callers return sys.EBADF in either case, as
+// wasi-libc converts ENOTCAPABLE to it for most calls.
+func lookupFile(fsc *sys.FSContext, fd int32, rights uint32) (*sys.FileEntry, bool) {
+	f, ok := fsc.LookupFile(fd)
+	if ok && !f.HasRights(uint64(rights)) {
+		return nil, false
+	}
+	return f, ok
+}
+
 func preopenPath(fsc *sys.FSContext, fd int32) (string, experimentalsys.Errno) {
 	if f, ok := fsc.LookupFile(fd); !ok {
 		return "", experimentalsys.EBADF // closed
@@ -1786,7 +1828,7 @@ func pathReadlinkFn(_ context.Context, mod api.Module, params []uint64) experime
 	}
 
 	mem := mod.Memory()
-	preopen, p, errno := atPath(fsc, mem, fd, path, pathLen)
+	preopen, p, errno := atPath(fsc, mem, fd, path, pathLen, wasip1.RIGHT_PATH_READLINK)
 	if errno != 0 {
 		return errno
 	}
@@ -1845,7 +1887,7 @@ func pathRemoveDirectoryFn(_ context.Context, mod api.Module, params []uint64) e
 	path := uint32(params[1])
 	pathLen := uint32(params[2])
 
-	preopen, pathName, errno := atPath(fsc, mod.Memory(), fd, path, pathLen)
+	preopen, pathName, errno := atPath(fsc, mod.Memory(), fd, path, pathLen, wasip1.RIGHT_PATH_REMOVE_DIRECTORY)
 	if errno != 0 {
 		return errno
 	}
@@ -1872,10 +1914,14 @@ func pathRemoveDirectoryFn(_ context.Context, mod api.Module, params []uint64) e
 //   - sys.ENOENT: `old_path` does not exist.
 //   - sys.ENOTDIR: `old` is a directory and `new` exists, but is a file.
 //   - sys.EISDIR: `old` is a file and `new` exists, but is a directory.
+//   - sys.ENOSYS: `old` is not a regular file, and `fd` and `new_fd` are in
+//     different pre-opens.
 //
 // # Notes
 //   - This is similar to unlinkat in POSIX.
 //     See https://linux.die.net/man/2/renameat
+//   - Regular files are moved between pre-opens by copying, then removing
+//     the original.
 //
 // See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-path_renamefd-fd-old_path-string-new_fd-fd-new_path-string---errno
 var pathRename = newHostFunc(
@@ -1895,21 +1941,17 @@ func pathRenameFn(_ context.Context, mod api.Module, params []uint64) experiment
 	newPath := uint32(params[4])
 	newPathLen := uint32(params[5])
 
-	oldFS, oldPathName, errno := atPath(fsc, mod.Memory(), fd, oldPath, oldPathLen)
+	oldFS, oldPathName, errno := atPath(fsc, mod.Memory(), fd, oldPath, oldPathLen, wasip1.RIGHT_PATH_RENAME_SOURCE)
 	if errno != 0 {
 		return errno
 	}
 
-	newFS, newPathName, errno := atPath(fsc, mod.Memory(), newFD, newPath, newPathLen)
+	newFS, newPathName, errno := atPath(fsc, mod.Memory(), newFD, newPath, newPathLen, wasip1.RIGHT_PATH_RENAME_TARGET)
 	if errno != 0 {
 		return errno
 	}
 
-	if oldFS != newFS { // TODO: handle renames across filesystems
-		return experimentalsys.ENOSYS
-	}
-
-	return oldFS.Rename(oldPathName, newPathName)
+	return fsc.Rename(oldFS, oldPathName, newFS, newPathName)
 }
 
 // pathSymlink is the WASI function named PathSymlinkName which creates a
@@ -1951,17 +1993,14 @@ func pathSymlinkFn(_ context.Context, mod api.Module, params []uint64) experimen
 		return experimentalsys.EFAULT
 	}
 
-	newPathBuf, ok := mem.Read(newPath, newPathLen)
-	if !ok {
-		return experimentalsys.EFAULT
+	preopen, linkName, errno := atPath(fsc, mem, fd, newPath, newPathLen, wasip1.RIGHT_PATH_SYMLINK)
+	if errno != 0 {
+		return errno
 	}
 
-	return dir.FS.Symlink(
-		// Do not join old path since it's only resolved when dereference the link created here.
-		// And the dereference result depends on the opening directory's file descriptor at that point.
-		bufToStr(oldPathBuf),
-		path.Join(dir.Name, bufToStr(newPathBuf)),
-	)
+	// Do not join old path since it's only resolved when dereference the link created here.
+	// And the dereference result depends on the opening directory's file descriptor at that point.
+	return fsc.Symlink(preopen, bufToStr(oldPathBuf), linkName)
 }
 
 // bufToStr converts the given byte slice as string unsafely.
@@ -2004,7 +2043,7 @@ func pathUnlinkFileFn(_ context.Context, mod api.Module, params []uint64) experi
 	path := uint32(params[1])
 	pathLen := uint32(params[2])
 
-	preopen, pathName, errno := atPath(fsc, mod.Memory(), fd, path, pathLen)
+	preopen, pathName, errno := atPath(fsc, mod.Memory(), fd, path, pathLen, wasip1.RIGHT_PATH_UNLINK_FILE)
 	if errno != 0 {
 		return errno
 	}
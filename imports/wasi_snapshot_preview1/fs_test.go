@@ -16,6 +16,7 @@ import (
 
 	"github.com/tetratelabs/wazero"
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental/rights"
 	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
 	"github.com/tetratelabs/wazero/internal/fsapi"
 	"github.com/tetratelabs/wazero/internal/fstest"
@@ -3356,6 +3357,8 @@ func Test_pathFilestatSetTimes(t *testing.T) {
 	writeFile(t, tmpDir, file, []byte("012"))
 	link := file + "-link"
 	require.NoError(t, os.Symlink(joinPath(tmpDir, file), joinPath(tmpDir, link)))
+	dir := "dir"
+	require.NoError(t, os.Mkdir(joinPath(tmpDir, dir), 0o700))
 
 	mod, r, log := requireProxyModule(t, wazero.NewModuleConfig().
 		WithSysWalltime().
@@ -3480,6 +3483,18 @@ func Test_pathFilestatSetTimes(t *testing.T) {
 `,
 			expectedErrno: wasip1.ErrnoNoent,
 		},
+		{
+			name:     "no_symlink_follow dir",
+			pathName: dir,
+			flags:    0,
+			atime:    1234, // Must be ignored.
+			mtime:    55555500,
+			fstFlags: wasip1.FstflagsMtim,
+			expectedLog: `
+==> wasi_snapshot_preview1.path_filestat_set_times(fd=3,flags=,path=dir,atim=1234,mtim=55555500,fst_flags=MTIM)
+<== errno=ESUCCESS
+`,
+		},
 		{
 			name:     "no_symlink_follow",
 			pathName: link,
@@ -3494,8 +3509,9 @@ func Test_pathFilestatSetTimes(t *testing.T) {
 		},
 	}
 
-	if runtime.GOOS == "windows" && !platform.IsAtLeastGo120 {
-		// Windows 1.19 returns ENOSYS on no_symlink_follow
+	if runtime.GOOS == "windows" {
+		// Windows can't update the times of a symbolic link, so returns
+		// ENOSYS on no_symlink_follow.
 		tests = tests[:len(tests)-1]
 	}
 
@@ -3612,6 +3628,34 @@ func Test_pathLink(t *testing.T) {
 		require.Equal(t, uint64(2), st.Nlink)
 	})
 
+	t.Run("LOOKUP_SYMLINK_FOLLOW", func(t *testing.T) {
+		symlinkName := "symlink"
+		require.NoError(t, os.Symlink(fileName, joinPath(oldDirPath, symlinkName)))
+		symlink := uint32(0x200)
+		ok := mem.Write(symlink, []byte(symlinkName))
+		require.True(t, ok)
+
+		for _, tc := range []struct {
+			name            string
+			flags           uint16
+			expectedSymlink bool
+		}{
+			{name: "nofollow", flags: 0, expectedSymlink: true},
+			{name: "follow", flags: wasip1.LOOKUP_SYMLINK_FOLLOW},
+		} {
+			ok = mem.Write(destination, []byte(tc.name))
+			require.True(t, ok)
+
+			requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.PathLinkName,
+				uint64(oldFd), uint64(tc.flags), uint64(symlink), uint64(len(symlinkName)),
+				uint64(newFd), uint64(destination), uint64(len(tc.name)))
+
+			st, err := os.Lstat(joinPath(newDirPath, tc.name))
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedSymlink, st.Mode()&os.ModeSymlink == os.ModeSymlink, tc.name)
+		}
+	})
+
 	t.Run("errors", func(t *testing.T) {
 		for _, tc := range []struct {
 			errno wasip1.Errno
@@ -3713,10 +3757,10 @@ func Test_pathOpen(t *testing.T) {
 			fs:            readFS,
 			fdflags:       wasip1.FD_APPEND,
 			path:          func(t *testing.T) (file string) { return appendName },
-			expectedErrno: wasip1.ErrnoNosys,
+			expectedErrno: wasip1.ErrnoRofs,
 			expectedLog: `
 ==> wasi_snapshot_preview1.path_open(fd=3,dirflags=,path=append,oflags=,fs_rights_base=,fs_rights_inheriting=,fdflags=APPEND)
-<== (opened_fd=,errno=ENOSYS)
+<== (opened_fd=,errno=EROFS)
 `,
 		},
 		{
@@ -3740,11 +3784,11 @@ func Test_pathOpen(t *testing.T) {
 			name:          "sysfs.ReadFS O_CREAT",
 			fs:            readFS,
 			oflags:        wasip1.O_CREAT,
-			expectedErrno: wasip1.ErrnoNosys,
+			expectedErrno: wasip1.ErrnoRofs,
 			path:          func(*testing.T) string { return "creat" },
 			expectedLog: `
 ==> wasi_snapshot_preview1.path_open(fd=3,dirflags=,path=creat,oflags=CREAT,fs_rights_base=,fs_rights_inheriting=,fdflags=)
-<== (opened_fd=,errno=ENOSYS)
+<== (opened_fd=,errno=EROFS)
 `,
 		},
 		{
@@ -3769,11 +3813,11 @@ func Test_pathOpen(t *testing.T) {
 			name:          "sysfs.ReadFS O_CREAT O_TRUNC",
 			fs:            readFS,
 			oflags:        wasip1.O_CREAT | wasip1.O_TRUNC,
-			expectedErrno: wasip1.ErrnoNosys,
+			expectedErrno: wasip1.ErrnoRofs,
 			path:          func(t *testing.T) (file string) { return joinPath(dirName, "O_CREAT-O_TRUNC") },
 			expectedLog: `
 ==> wasi_snapshot_preview1.path_open(fd=3,dirflags=,path=dir/O_CREAT-O_TRUNC,oflags=CREAT|TRUNC,fs_rights_base=,fs_rights_inheriting=,fdflags=)
-<== (opened_fd=,errno=ENOSYS)
+<== (opened_fd=,errno=EROFS)
 `,
 		},
 		{
@@ -3832,11 +3876,11 @@ func Test_pathOpen(t *testing.T) {
 			name:          "sysfs.ReadFS O_TRUNC",
 			fs:            readFS,
 			oflags:        wasip1.O_TRUNC,
-			expectedErrno: wasip1.ErrnoNosys,
+			expectedErrno: wasip1.ErrnoRofs,
 			path:          func(*testing.T) string { return "trunc" },
 			expectedLog: `
 ==> wasi_snapshot_preview1.path_open(fd=3,dirflags=,path=trunc,oflags=TRUNC,fs_rights_base=,fs_rights_inheriting=,fdflags=)
-<== (opened_fd=,errno=ENOSYS)
+<== (opened_fd=,errno=EROFS)
 `,
 		},
 		{
@@ -3966,6 +4010,46 @@ func writeFile(t *testing.T, tmpDir, file string, contents []byte) {
 	require.NoError(t, err)
 }
 
+func Test_pathOpen_rights(t *testing.T) {
+	tmpDir := t.TempDir() // open before loop to ensure no locking problems.
+	writeFile(t, tmpDir, "file", []byte("012"))
+	require.NoError(t, os.Mkdir(joinPath(tmpDir, "locked"), 0o700))
+
+	fsConfig := wazero.NewFSConfig().WithDirMount(tmpDir, "/").WithDirMount(joinPath(tmpDir, "locked"), "/locked")
+	ctx := rights.WithPreopens(testCtx, map[string]rights.Rights{
+		"/":       rights.ReadOnly,
+		"/locked": rights.FdReaddir,
+	})
+	mod, r, _ := requireProxyModuleWithContext(ctx, t, wazero.NewModuleConfig().WithFSConfig(fsConfig))
+	defer r.Close(testCtx)
+
+	mem := mod.Memory()
+	ok := mem.WriteString(0, "file")
+	require.True(t, ok)
+	resultOpenedFd := uint32(8)
+	rw := uint64(wasip1.RIGHT_FD_READ | wasip1.RIGHT_FD_WRITE)
+
+	// Files only get the rights the directory passes on, so can't be written.
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.PathOpenName,
+		uint64(sys.FdPreopen), 0, 0, 4, 0, rw, rw, 0, uint64(resultOpenedFd))
+	fd, _ := mem.ReadUint32Le(resultOpenedFd)
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.FdReadName, uint64(fd), 16, 0, 24)
+	requireErrnoResult(t, wasip1.ErrnoBadf, mod, wasip1.FdWriteName, uint64(fd), 16, 0, 24)
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.FdFdstatGetName, uint64(fd), 32)
+	rightsBase, _ := mem.ReadUint64Le(32 + 8)
+	require.Equal(t, uint64(wasip1.RIGHT_FD_READ), rightsBase)
+
+	// Operations the directory has no right to fail.
+	requireErrnoResult(t, wasip1.ErrnoAcces, mod, wasip1.PathCreateDirectoryName,
+		uint64(sys.FdPreopen), 0, 4)
+	requireErrnoResult(t, wasip1.ErrnoAcces, mod, wasip1.PathOpenName,
+		uint64(sys.FdPreopen), 0, 0, 4, uint64(wasip1.O_TRUNC), rw, rw, 0, uint64(resultOpenedFd))
+
+	// A directory without RIGHT_PATH_OPEN can't be traversed.
+	requireErrnoResult(t, wasip1.ErrnoAcces, mod, wasip1.PathOpenName,
+		uint64(sys.FdPreopen+1), 0, 0, 4, 0, rw, rw, 0, uint64(resultOpenedFd))
+}
+
 func Test_pathOpen_Errors(t *testing.T) {
 	tmpDir := t.TempDir() // open before loop to ensure no locking problems.
 	fsConfig := wazero.NewFSConfig().WithDirMount(tmpDir, "/")
@@ -4465,6 +4549,18 @@ func Test_pathSymlink_errors(t *testing.T) {
 		require.Equal(t, st.Mode()&os.ModeSymlink, os.ModeSymlink)
 	})
 
+	t.Run("EPERM on target outside the directory", func(t *testing.T) {
+		for _, targetName := range []string{"../../file", "/etc/passwd"} {
+			target := uint32(0x200)
+			ok := mem.Write(target, []byte(targetName))
+			require.True(t, ok)
+
+			requireErrnoResult(t, wasip1.ErrnoPerm, mod, wasip1.PathSymlinkName,
+				uint64(target), uint64(len(targetName)), uint64(fd), uint64(link), uint64(len(linkName)))
+			require.Contains(t, log.String(), wasip1.ErrnoName(wasip1.ErrnoPerm))
+		}
+	})
+
 	t.Run("errors", func(t *testing.T) {
 		for _, tc := range []struct {
 			errno               wasip1.Errno
@@ -4545,6 +4641,38 @@ func Test_pathRename(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func Test_pathRename_acrossPreopens(t *testing.T) {
+	oldDir, newDir := t.TempDir(), t.TempDir()
+	fsConfig := wazero.NewFSConfig().WithDirMount(oldDir, "/").WithDirMount(newDir, "/tmp")
+	mod, r, log := requireProxyModule(t, wazero.NewModuleConfig().WithFSConfig(fsConfig))
+	defer r.Close(testCtx)
+
+	oldPathName, newPathName := "wazero", "wahzero"
+	realOldPath, realNewPath := joinPath(oldDir, oldPathName), joinPath(newDir, newPathName)
+	require.NoError(t, os.WriteFile(realOldPath, []byte("wazero"), 0o600))
+
+	oldPath, newPath := uint32(0), uint32(16)
+	ok := mod.Memory().Write(oldPath, []byte(oldPathName))
+	require.True(t, ok)
+	ok = mod.Memory().Write(newPath, []byte(newPathName))
+	require.True(t, ok)
+
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.PathRenameName,
+		uint64(sys.FdPreopen), uint64(oldPath), uint64(len(oldPathName)),
+		uint64(sys.FdPreopen+1), uint64(newPath), uint64(len(newPathName)))
+	require.Equal(t, `
+==> wasi_snapshot_preview1.path_rename(fd=3,old_path=wazero,new_fd=4,new_path=wahzero)
+<== errno=ESUCCESS
+`, "\n"+log.String())
+
+	// ensure the file was moved
+	_, err := os.Stat(realOldPath)
+	require.Error(t, err)
+	b, err := os.ReadFile(realNewPath)
+	require.NoError(t, err)
+	require.Equal(t, "wazero", string(b))
+}
+
 func Test_pathRename_Errors(t *testing.T) {
 	tmpDir := t.TempDir() // open before loop to ensure no locking problems.
 	fsConfig := wazero.NewFSConfig().WithDirMount(tmpDir, "/")
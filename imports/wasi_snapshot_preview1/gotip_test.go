@@ -13,10 +13,11 @@ import (
 )
 
 func TestMain(m *testing.M) {
-	// Find the gotip binary (if present), and compile the Wasm binary.
-	if gotipBin, err := findGotipBin(); err != nil {
+	// Find a Go binary which supports wasip1 (if present), and compile the
+	// Wasm binary.
+	if goBin, err := findWasip1GoBin(); err != nil {
 		println("gotip: skipping due missing binary:", err)
-	} else if wasmGotip, err = compileWasip1Wasm(gotipBin); err != nil {
+	} else if wasmGotip, err = compileWasip1Wasm(goBin); err != nil {
 		println("gotip: skipping due compilation error:", err)
 	}
 	os.Exit(m.Run())
@@ -24,8 +25,8 @@ func TestMain(m *testing.M) {
 
 // compileWasip1Wasm allows us to generate a binary with runtime.GOOS=wasip1
 // and runtime.GOARCH=wasm. This intentionally does so on-demand, because the
-// wasm is too big to check in. Pls, not everyone will have gotip.
-func compileWasip1Wasm(gotipBin string) ([]byte, error) {
+// wasm is too big to check in. Pls, not everyone will have Go 1.21+.
+func compileWasip1Wasm(goBin string) ([]byte, error) {
 	// Prepare the working directory.
 	workdir, err := os.MkdirTemp("", "wasi")
 	if err != nil {
@@ -37,7 +38,7 @@ func compileWasip1Wasm(gotipBin string) ([]byte, error) {
 	defer cancel()
 
 	bin := path.Join(workdir, "wasi.wasm")
-	cmd := exec.CommandContext(ctx, gotipBin, "build", "-o", bin, ".") //nolint:gosec
+	cmd := exec.CommandContext(ctx, goBin, "build", "-o", bin, ".") //nolint:gosec
 	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
 	cmd.Dir = "testdata/gotip"
 	out, err := cmd.CombinedOutput()
@@ -48,14 +49,34 @@ func compileWasip1Wasm(gotipBin string) ([]byte, error) {
 	return os.ReadFile(bin) //nolint:gosec
 }
 
-func findGotipBin() (string, error) {
-	binName := "gotip"
+// findWasip1GoBin returns the Go binary running this test if it supports
+// GOOS=wasip1 (Go 1.21+), or otherwise gotip.
+func findWasip1GoBin() (string, error) {
+	if supportsWasip1(runtime.Version()) {
+		if goBin, err := findGoBin("go"); err == nil {
+			return goBin, nil
+		}
+	}
+	return findGoBin("gotip")
+}
+
+// supportsWasip1 returns true if `version`, formatted like runtime.Version,
+// is a release of Go 1.21 or later.
+func supportsWasip1(version string) bool {
+	var minor int
+	if _, err := fmt.Sscanf(version, "go1.%d", &minor); err != nil {
+		return false // e.g. a development version
+	}
+	return minor >= 21
+}
+
+func findGoBin(binName string) (string, error) {
 	if runtime.GOOS == "windows" {
 		binName += ".exe"
 	}
-	gotipBin := filepath.Join(runtime.GOROOT(), "bin", binName)
-	if _, err := os.Stat(gotipBin); err == nil {
-		return gotipBin, nil
+	goBin := filepath.Join(runtime.GOROOT(), "bin", binName)
+	if _, err := os.Stat(goBin); err == nil {
+		return goBin, nil
 	}
 	// Now, search the path
 	return exec.LookPath(binName)
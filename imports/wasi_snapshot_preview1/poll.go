@@ -2,6 +2,7 @@ package wasi_snapshot_preview1
 
 import (
 	"context"
+	"math"
 	"time"
 
 	"github.com/tetratelabs/wazero/api"
@@ -34,6 +35,11 @@ import (
 //
 //   - Since the `out` pointer nests Errno, the result is always 0.
 //   - This is similar to `poll` in POSIX.
+//   - fd_read and fd_write on regular files and directories are always ready.
+//     Others, such as stdin or pipes, block on the host until ready or the
+//     shortest clock subscription expires.
+//   - With wazero.ModuleConfig WithSysNanosleep, clock subscriptions return
+//     early when the context is done or the module is closed.
 //
 // See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#poll_oneoff
 // See https://linux.die.net/man/3/poll
@@ -49,7 +55,7 @@ type event struct {
 	errno     wasip1.Errno
 }
 
-func pollOneoffFn(_ context.Context, mod api.Module, params []uint64) sys.Errno {
+func pollOneoffFn(ctx context.Context, mod api.Module, params []uint64) sys.Errno {
 	in := uint32(params[0])
 	out := uint32(params[1])
 	nsubscriptions := uint32(params[2])
@@ -86,14 +92,17 @@ func pollOneoffFn(_ context.Context, mod api.Module, params []uint64) sys.Errno
 
 	// Extract FS context, used in the body of the for loop for FS access.
 	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
-	// Slice of events that are processed out of the loop (blocking stdin subscribers).
-	var blockingStdinSubs []*event
+	// Slice of events that are processed out of the loop (blocking files such
+	// as stdin, pipes or sockets).
+	var blockingSubs []*blockingSub
 	// The timeout is initialized at max Duration, the loop will find the minimum.
 	var timeout time.Duration = 1<<63 - 1
 	// Count of all the subscriptions that have been already written back to outBuf.
 	// nevents*32 returns at all times the offset where the next event should be written:
 	// this way we ensure that there are no gaps between records.
 	nevents := uint32(0)
+	// Count of clock events, which are acknowledged without waiting.
+	nclocks := uint32(0)
 
 	// Layout is subscription_u: Union
 	// https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#subscription_u
@@ -125,67 +134,58 @@ func pollOneoffFn(_ context.Context, mod api.Module, params []uint64) sys.Errno
 			// Ack the clock event to the outBuf.
 			writeEvent(outBuf[outOffset:], evt)
 			nevents++
-		case wasip1.EventTypeFdRead:
+			nclocks++
+		case wasip1.EventTypeFdRead, wasip1.EventTypeFdWrite:
 			fd := int32(le.Uint32(argBuf))
 			if fd < 0 {
 				return sys.EBADF
 			}
+			flag := fsapi.POLLIN
+			if eventType == wasip1.EventTypeFdWrite {
+				flag = fsapi.POLLOUT
+			}
 			if file, ok := fsc.LookupFile(fd); !ok {
 				evt.errno = wasip1.ErrnoBadf
 				writeEvent(outBuf[outOffset:], evt)
 				nevents++
-			} else if fd != internalsys.FdStdin && file.File.IsNonblock() {
+			} else if alwaysReady(fd, file.File) {
 				writeEvent(outBuf[outOffset:], evt)
 				nevents++
 			} else {
-				// if the fd is Stdin, and it is in blocking mode,
-				// do not ack yet, append to a slice for delayed evaluation.
-				blockingStdinSubs = append(blockingStdinSubs, evt)
-			}
-		case wasip1.EventTypeFdWrite:
-			fd := int32(le.Uint32(argBuf))
-			if fd < 0 {
-				return sys.EBADF
-			}
-			if _, ok := fsc.LookupFile(fd); ok {
-				evt.errno = wasip1.ErrnoNotsup
-			} else {
-				evt.errno = wasip1.ErrnoBadf
+				// If the file may block, do not ack yet, append to a slice
+				// for delayed evaluation.
+				blockingSubs = append(blockingSubs, &blockingSub{evt: evt, file: file.File, flag: flag})
 			}
-			nevents++
-			writeEvent(outBuf[outOffset:], evt)
 		default:
 			return sys.EINVAL
 		}
 	}
 
-	sysCtx := mod.(*wasm.ModuleInstance).Sys
+	m := mod.(*wasm.ModuleInstance)
+	sysCtx := m.Sys
 	if nevents == nsubscriptions {
 		// We already wrote back all the results. We already wrote this number
 		// earlier to offset `resultNevents`.
 		// We only need to observe the timeout (nonzero if there are clock subscriptions)
 		// and return.
 		if timeout > 0 {
-			sysCtx.Nanosleep(int64(timeout))
+			sysCtx.NanosleepUntil(int64(timeout), ctx.Done(), m.Done())
 		}
 		return 0
 	}
 
-	// If there are blocking stdin subscribers, check for data with given timeout.
-	stdin, ok := fsc.LookupFile(internalsys.FdStdin)
-	if !ok {
-		return sys.EBADF
+	// Like POSIX poll, don't wait if a file event is already ready.
+	if nevents > nclocks {
+		timeout = 0
 	}
-	// Wait for the timeout to expire, or for some data to become available on Stdin.
 
-	if stdinReady, errno := stdin.File.Poll(fsapi.POLLIN, int32(timeout.Milliseconds())); errno != 0 {
+	// Wait for the timeout to expire, or for any blocking file to be ready.
+	if errno := pollBlockingSubs(ctx, m, blockingSubs, timeout); errno != 0 {
 		return errno
-	} else if stdinReady {
-		// stdin has data ready to for reading, write back all the events
-		for i := range blockingStdinSubs {
-			evt := blockingStdinSubs[i]
-			evt.errno = 0
-			writeEvent(outBuf[nevents*32:], evt)
+	}
+	for _, sub := range blockingSubs {
+		if sub.ready {
+			writeEvent(outBuf[nevents*32:], sub.evt)
 			nevents++
 		}
 	}
@@ -199,6 +199,96 @@ func pollOneoffFn(_ context.Context, mod api.Module, params []uint64) sys.Errno
 	return 0
 }
 
+// blockingSub is a subscription to a file which may not be ready.
+type blockingSub struct {
+	evt   *event
+	file  fsapi.File
+	flag  fsapi.Pflag
+	ready bool
+}
+
+// alwaysReady returns true if a read or write on the file would not block.
+// Like POSIX poll, this is the case for regular files and directories.
+// Non-blocking files are also ready, as the guest handles sys.EAGAIN.
+func alwaysReady(fd int32, f fsapi.File) bool {
+	if fd != internalsys.FdStdin && f.IsNonblock() {
+		return true
+	}
+	st, errno := f.Stat()
+	if errno != 0 {
+		return false
+	}
+	return st.Mode.IsRegular() || st.Mode.IsDir()
+}
+
+// pollInterval is the maximum time to wait on one of several blocking files,
+// before checking the others.
+const pollInterval = 10 * time.Millisecond
+
+// pollBlockingSubs marks which subscriptions are ready, waiting up to
+// `timeout` for at least one to be. A file which can't be polled is reported
+// ready, so that the guest blocks in the read or write instead. Waiting stops
+// early if interrupted, as documented on internalsys.Context NanosleepUntil.
+func pollBlockingSubs(ctx context.Context, m *wasm.ModuleInstance, subs []*blockingSub, timeout time.Duration) sys.Errno {
+	sysCtx := m.Sys
+	if len(subs) == 1 {
+		// Block on the host until the file is ready or the timeout expires.
+		ready, errno := pollReady(subs[0], pollTimeoutMillis(timeout))
+		subs[0].ready = ready
+		return errno
+	}
+
+	for {
+		var anyReady bool
+		for _, sub := range subs {
+			ready, errno := pollReady(sub, 0)
+			if errno != 0 {
+				return errno
+			}
+			sub.ready = ready
+			anyReady = anyReady || ready
+		}
+		if anyReady || timeout <= 0 {
+			return 0
+		}
+
+		wait := pollInterval
+		if timeout < wait {
+			wait = timeout
+		}
+		if !sysCtx.NanosleepUntil(int64(wait), ctx.Done(), m.Done()) {
+			return 0
+		}
+		timeout -= wait
+	}
+}
+
+// pollReady polls the file of `sub`, treating files which don't support
+// polling as ready.
+func pollReady(sub *blockingSub, timeoutMillis int32) (bool, sys.Errno) {
+	ready, errno := sub.file.Poll(sub.flag, timeoutMillis)
+	switch errno {
+	case 0:
+		return ready, 0
+	case sys.ENOSYS, sys.ENOTSUP:
+		return true, 0
+	default:
+		return false, errno
+	}
+}
+
+// pollTimeoutMillis converts `timeout` to milliseconds for fsapi.File Poll,
+// where a negative value means wait indefinitely.
+func pollTimeoutMillis(timeout time.Duration) int32 {
+	if timeout == 1<<63-1 { // no clock subscription
+		return -1
+	} else if ms := timeout.Milliseconds(); ms > math.MaxInt32 {
+		return math.MaxInt32
+	} else {
+		return int32(ms)
+	}
+}
+
 // processClockEvent supports only relative name events, as that's what's used
 // to implement sleep in various compilers including Rust, Zig and TinyGo.
 func processClockEvent(inBuf []byte) (time.Duration, sys.Errno) {
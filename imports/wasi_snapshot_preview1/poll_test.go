@@ -1,6 +1,7 @@
 package wasi_snapshot_preview1_test
 
 import (
+	"context"
 	"io/fs"
 	"os"
 	"strings"
@@ -64,6 +65,43 @@ func Test_pollOneoff(t *testing.T) {
 	require.Equal(t, nsubscriptions, nevents)
 }
 
+func Test_pollOneoff_interruptedSleep(t *testing.T) {
+	tests := []struct {
+		name      string
+		interrupt func(cancel context.CancelFunc, mod api.Module)
+	}{
+		{
+			name:      "context canceled",
+			interrupt: func(cancel context.CancelFunc, _ api.Module) { cancel() },
+		},
+		{
+			name:      "module closed",
+			interrupt: func(_ context.CancelFunc, mod api.Module) { _ = mod.Close(testCtx) },
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			mod, r, _ := requireProxyModule(t, wazero.NewModuleConfig().WithSysNanosleep())
+			defer r.Close(testCtx)
+
+			mod.Memory().Write(0, clockNsSub(uint64(time.Hour)))
+
+			ctx, cancel := context.WithCancel(testCtx)
+			defer cancel()
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				tc.interrupt(cancel, mod)
+			}()
+
+			start := time.Now()
+			_, _ = mod.ExportedFunction(wasip1.PollOneoffName).Call(ctx, 0, 128, 1, 512)
+			require.True(t, time.Since(start) < time.Minute)
+		})
+	}
+}
+
 func Test_pollOneoff_Errors(t *testing.T) {
 	mod, r, log := requireProxyModule(t, wazero.NewModuleConfig())
 	defer r.Close(testCtx)
@@ -443,6 +481,59 @@ func Test_pollOneoff_Stdin(t *testing.T) {
 	}
 }
 
+func Test_pollOneoff_FdWrite(t *testing.T) {
+	mod, r, log := requireProxyModule(t, wazero.NewModuleConfig())
+	defer r.Close(testCtx)
+	defer log.Reset()
+
+	maskMemory(t, mod, 1024)
+	mod.Memory().Write(0, fdWriteSubFd(byte(sys.FdStdout)))
+
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.PollOneoffName, uint64(0), uint64(128), uint64(1), uint64(512))
+
+	// Writing to stdout is ready, as opposed to not supported.
+	nevents, ok := mod.Memory().ReadUint32Le(512)
+	require.True(t, ok)
+	require.Equal(t, uint32(1), nevents)
+	out, ok := mod.Memory().Read(128, 12)
+	require.True(t, ok)
+	require.Equal(t, []byte{
+		0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, // userdata
+		byte(wasip1.ErrnoSuccess), 0x0, // errno is 16 bit
+		wasip1.EventTypeFdWrite, 0x0, // first 2 bytes of type enum
+	}, out)
+}
+
+// Test_pollOneoff_multipleBlocking ensures each blocking file is polled, not
+// only stdin.
+func Test_pollOneoff_multipleBlocking(t *testing.T) {
+	mod, r, log := requireProxyModule(t, wazero.NewModuleConfig())
+	defer r.Close(testCtx)
+	defer log.Reset()
+
+	setStdin(t, mod, &neverReadyTtyStdinFile{StdinFile: sys.StdinFile{Reader: newBlockingReader(t)}})
+	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
+	f, ok := fsc.LookupFile(sys.FdStderr)
+	require.True(t, ok)
+	f.File = &pollStdinFile{StdinFile: sys.StdinFile{Reader: strings.NewReader("test")}, ready: true}
+
+	maskMemory(t, mod, 1024)
+	mod.Memory().Write(0, concat(
+		fdReadSub,
+		fdReadSubFdWithUserData(byte(sys.FdStderr), []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77}),
+	))
+
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.PollOneoffName, uint64(0), uint64(128), uint64(2), uint64(512))
+
+	// Only the ready file is written, without waiting for stdin.
+	nevents, ok := mod.Memory().ReadUint32Le(512)
+	require.True(t, ok)
+	require.Equal(t, uint32(1), nevents)
+	out, ok := mod.Memory().Read(128, 8)
+	require.True(t, ok)
+	require.Equal(t, []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77}, out)
+}
+
 func setStdin(t *testing.T, mod api.Module, stdin fsapi.File) {
 	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
 	f, ok := fsc.LookupFile(sys.FdStdin)
@@ -588,6 +679,13 @@ func fdReadSubFdWithUserData(fd byte, userdata []byte) []byte {
 // subscription for an EventTypeFdRead on stdin
 var fdReadSub = fdReadSubFd(byte(sys.FdStdin))
 
+// subscription for an EventTypeFdWrite on a given fd
+func fdWriteSubFd(fd byte) []byte {
+	sub := fdReadSubFd(fd)
+	sub[8] = wasip1.EventTypeFdWrite
+	return sub
+}
+
 // ttyStat returns fs.ModeCharDevice | fs.ModeCharDevice as an approximation
 // for isatty.
 //
@@ -4,15 +4,19 @@ import (
 	"context"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental/sys"
 	"github.com/tetratelabs/wazero/internal/wasip1"
 	"github.com/tetratelabs/wazero/internal/wasm"
-	"github.com/tetratelabs/wazero/sys"
+	sysapi "github.com/tetratelabs/wazero/sys"
 )
 
 // procExit is the WASI function named ProcExitName that terminates the
 // execution of the module with an exit code. The only successful exit code is
 // zero.
 //
+// Note: experimental.WithProcExitConfig can observe the exit code, or keep the
+// module open, so that it can be called again.
+//
 // # Parameters
 //
 //   - exitCode: exit code.
@@ -29,16 +33,60 @@ var procExit = &wasm.HostFunc{
 func procExitFn(ctx context.Context, mod api.Module, params []uint64) {
 	exitCode := uint32(params[0])
 
+	keepOpen := false
+	// Sys is nil when the module was already closed.
+	if sysCtx := mod.(*wasm.ModuleInstance).Sys; sysCtx != nil {
+		if c := sysCtx.ProcExitConfig(); c != nil {
+			if c.OnExit != nil {
+				c.OnExit(ctx, mod, exitCode)
+			}
+			keepOpen = c.KeepOpen
+		}
+	}
+
 	// Ensure other callers see the exit code.
-	_ = mod.CloseWithExitCode(ctx, exitCode)
+	if !keepOpen {
+		_ = mod.CloseWithExitCode(ctx, exitCode)
+	}
 
 	// Prevent any code from executing after this function. For example, LLVM
 	// inserts unreachable instructions after calls to exit.
 	// See: https://github.com/emscripten-core/emscripten/issues/12322
-	panic(sys.NewExitError(exitCode))
+	panic(sysapi.NewExitError(exitCode))
 }
 
-// procRaise is stubbed and will never be supported, as it was removed.
+// procRaise is the WASI function named ProcRaiseName which sends a signal
+// to the module. Signals whose default action terminates a process close the
+// module with exit code 128+sig, the same as a shell reports a process killed
+// by a signal. The others are ignored, as there is no handler to deliver them
+// to, and a module cannot be stopped.
+//
+// # Parameters
+//
+//   - sig: the signal, e.g. wasip1.SignalInt
+//
+// Result (Errno)
+//
+// The return value is 0 if the signal was ignored, or an error if `sig` is
+// not a known signal.
+//
+// Note: This was removed from later versions of WASI, as signals are emulated
+// in the guest, e.g. by wasi-libc. Guests such as GrainLang still call it.
 //
 // See https://github.com/WebAssembly/WASI/pull/136
-var procRaise = stubFunction(wasip1.ProcRaiseName, []api.ValueType{i32}, "sig")
+var procRaise = newHostFunc(wasip1.ProcRaiseName, procRaiseFn, []api.ValueType{i32}, "sig")
+
+func procRaiseFn(ctx context.Context, mod api.Module, params []uint64) sys.Errno {
+	sig := uint32(params[0])
+	switch sig {
+	case wasip1.SignalNone, wasip1.SignalChld, wasip1.SignalCont, wasip1.SignalStop,
+		wasip1.SignalTstp, wasip1.SignalTtin, wasip1.SignalTtou, wasip1.SignalUrg,
+		wasip1.SignalWinch:
+		return 0
+	}
+	if sig > wasip1.SignalSys {
+		return sys.EINVAL
+	}
+	procExitFn(ctx, mod, []uint64{uint64(128 + sig)})
+	return 0 // unreachable, as procExitFn panics.
+}
@@ -1,11 +1,17 @@
 package wasi_snapshot_preview1_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/internal/testing/binaryencoding"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasip1"
+	"github.com/tetratelabs/wazero/internal/wasm"
 	"github.com/tetratelabs/wazero/sys"
 )
 
@@ -51,11 +57,60 @@ func Test_procExit(t *testing.T) {
 	}
 }
 
-// Test_procRaise only tests it is stubbed for GrainLang per #271
+func Test_procExit_ProcExitConfig(t *testing.T) {
+	var exitCodes []uint32
+	ctx := experimental.WithProcExitConfig(testCtx, experimental.ProcExitConfig{
+		OnExit: func(_ context.Context, _ api.Module, exitCode uint32) {
+			exitCodes = append(exitCodes, exitCode)
+		},
+		KeepOpen: true,
+	})
+
+	mod, r, _ := requireProxyModuleWithContext(ctx, t, wazero.NewModuleConfig())
+	defer r.Close(testCtx)
+
+	_, err := mod.ExportedFunction(wasip1.ProcExitName).Call(testCtx, 42)
+	require.Equal(t, sys.NewExitError(42), err)
+	require.Equal(t, []uint32{42}, exitCodes)
+	require.False(t, mod.IsClosed())
+
+	// Exit code zero during instantiation returns the module.
+	exitOnStartWithZero := binaryencoding.EncodeModule(&wasm.Module{
+		TypeSection:     []wasm.FunctionType{{Params: []wasm.ValueType{wasm.ValueTypeI32}}, {}},
+		ImportSection:   []wasm.Import{{Module: wasi_snapshot_preview1.ModuleName, Name: wasip1.ProcExitName, Type: wasm.ExternTypeFunc, DescFunc: 0}},
+		FunctionSection: []wasm.Index{1},
+		CodeSection:     []wasm.Code{{Body: []byte{wasm.OpcodeI32Const, 0, wasm.OpcodeCall, 0, wasm.OpcodeEnd}}},
+		ExportSection:   []wasm.Export{{Name: "_start", Type: wasm.ExternTypeFunc, Index: 1}},
+	})
+	started, err := r.(wazero.Runtime).Instantiate(ctx, exitOnStartWithZero)
+	require.NoError(t, err)
+	require.False(t, started.IsClosed())
+	require.Equal(t, []uint32{42, 0}, exitCodes)
+}
+
 func Test_procRaise(t *testing.T) {
-	log := requireErrnoNosys(t, wasip1.ProcRaiseName, 0)
+	mod, r, log := requireProxyModule(t, wazero.NewModuleConfig())
+	defer r.Close(testCtx)
+
+	// Signals which are ignored by default return success.
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.ProcRaiseName, uint64(wasip1.SignalChld))
+	require.Equal(t, `
+==> wasi_snapshot_preview1.proc_raise(sig=16)
+<== errno=ESUCCESS
+`, "\n"+log.String())
+	log.Reset()
+
+	requireErrnoResult(t, wasip1.ErrnoInval, mod, wasip1.ProcRaiseName, 31)
+	require.Equal(t, `
+==> wasi_snapshot_preview1.proc_raise(sig=31)
+<== errno=EINVAL
+`, "\n"+log.String())
+	log.Reset()
+
+	// Other signals close the module, like a process killed by a signal.
+	_, err := mod.ExportedFunction(wasip1.ProcRaiseName).Call(testCtx, uint64(wasip1.SignalTerm))
+	require.Equal(t, sys.NewExitError(128+wasip1.SignalTerm), err)
 	require.Equal(t, `
-==> wasi_snapshot_preview1.proc_raise(sig=0)
-<== errno=ENOSYS
-`, log)
+==> wasi_snapshot_preview1.proc_raise(sig=15)
+`, "\n"+log.String())
 }
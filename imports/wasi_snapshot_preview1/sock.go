@@ -2,6 +2,8 @@ package wasi_snapshot_preview1
 
 import (
 	"context"
+	"net"
+	"strconv"
 
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/experimental/sys"
@@ -61,17 +63,22 @@ func sockRecvFn(_ context.Context, mod api.Module, params []uint64) sys.Errno {
 	resultRoDatalen := uint32(params[4])
 	resultRoFlags := uint32(params[5])
 
+	if riFlags & ^(wasip1.RI_RECV_PEEK|wasip1.RI_RECV_WAITALL) != 0 {
+		return sys.ENOTSUP
+	}
+
 	var conn socketapi.TCPConn
 	if e, ok := fsc.LookupFile(fd); !ok {
 		return sys.EBADF // Not open
+	} else if uc, ok := e.File.(socketapi.UDPConn); ok {
+		if riFlags&wasip1.RI_RECV_PEEK != 0 {
+			return sys.ENOTSUP
+		}
+		return recvDatagram(mem, riData, riDataCount, resultRoDatalen, resultRoFlags, uc.Read)
 	} else if conn, ok = e.File.(socketapi.TCPConn); !ok {
 		return sys.EBADF // Not a conn
 	}
 
-	if riFlags & ^(wasip1.RI_RECV_PEEK|wasip1.RI_RECV_WAITALL) != 0 {
-		return sys.ENOTSUP
-	}
-
 	if riFlags&wasip1.RI_RECV_PEEK != 0 {
 		// Each record in riData is of the form:
 		// type iovec struct { buf *uint8; bufLen uint32 }
@@ -139,6 +146,8 @@ func sockSendFn(_ context.Context, mod api.Module, params []uint64) sys.Errno {
 	var conn socketapi.TCPConn
 	if e, ok := fsc.LookupFile(fd); !ok {
 		return sys.EBADF // Not open
+	} else if uc, ok := e.File.(socketapi.UDPConn); ok {
+		return sendDatagram(mem, siData, siDataCount, resultSoDatalen, uc.Write)
 	} else if conn, ok = e.File.(socketapi.TCPConn); !ok {
 		return sys.EBADF // Not a conn
 	}
@@ -186,3 +195,300 @@ func sockShutdownFn(_ context.Context, mod api.Module, params []uint64) sys.Errn
 	// TODO: Map this instead of relying on syscall symbols.
 	return conn.Shutdown(sysHow)
 }
+
+// sockOpen is the WasmEdge extension function named SockOpenName which
+// creates a socket, for use with sock_bind, sock_listen or sock_connect.
+//
+// The address family is ADDRESS_FAMILY_INET4 or ADDRESS_FAMILY_INET6. The
+// socket type is SOCK_TYPE_STREAM or SOCK_TYPE_ANY for TCP, or
+// SOCK_TYPE_DGRAM for UDP.
+//
+// This fails with ENOTSUP unless sock.Config WithSockOpen was used.
+//
+// See: https://github.com/second-state/wasmedge_wasi_socket
+var sockOpen = newHostFunc(
+	wasip1.SockOpenName,
+	sockOpenFn,
+	[]wasm.ValueType{i32, i32, i32},
+	"af", "socktype", "result.fd",
+)
+
+func sockOpenFn(_ context.Context, mod api.Module, params []uint64) (errno sys.Errno) {
+	mem := mod.Memory()
+	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
+
+	af := uint8(params[0])
+	socktype := uint8(params[1])
+	resultFd := uint32(params[2])
+
+	var network string
+	switch socktype {
+	case wasip1.SOCK_TYPE_ANY, wasip1.SOCK_TYPE_STREAM:
+		network = "tcp"
+	case wasip1.SOCK_TYPE_DGRAM:
+		network = "udp"
+	default:
+		return sys.EINVAL
+	}
+
+	switch af {
+	case wasip1.ADDRESS_FAMILY_INET4:
+		network += "4"
+	case wasip1.ADDRESS_FAMILY_INET6:
+		network += "6"
+	default:
+		return sys.EINVAL
+	}
+
+	var sockFD int32
+	if sockFD, errno = fsc.SockOpen(network); errno == 0 {
+		mem.WriteUint32Le(resultFd, uint32(sockFD))
+	}
+	return
+}
+
+// sockBind is the WasmEdge extension function named SockBindName which sets
+// the local address of a socket from sock_open.
+//
+// `addr` points to a struct { buf *uint8; buf_len uint32 }, where buf is the
+// IP address in network byte order: 4 bytes for IPv4 or 16 bytes for IPv6.
+//
+// See: https://github.com/second-state/wasmedge_wasi_socket
+var sockBind = newHostFunc(
+	wasip1.SockBindName,
+	sockBindFn,
+	[]wasm.ValueType{i32, i32, i32},
+	"fd", "addr", "port",
+)
+
+func sockBindFn(_ context.Context, mod api.Module, params []uint64) sys.Errno {
+	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
+
+	fd := int32(params[0])
+	address, errno := readSockAddress(mod.Memory(), uint32(params[1]), uint32(params[2]))
+	if errno != 0 {
+		return errno
+	}
+	return fsc.SockBind(fd, address)
+}
+
+// sockListen is the WasmEdge extension function named SockListenName which
+// listens on a socket from sock_open, after sock_bind. Connections are then
+// accepted with sock_accept.
+//
+// Note: The backlog is ignored, as Go uses the system default.
+//
+// See: https://github.com/second-state/wasmedge_wasi_socket
+var sockListen = newHostFunc(
+	wasip1.SockListenName,
+	sockListenFn,
+	[]wasm.ValueType{i32, i32},
+	"fd", "backlog",
+)
+
+func sockListenFn(_ context.Context, mod api.Module, params []uint64) sys.Errno {
+	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
+
+	fd := int32(params[0])
+	backlog := int(int32(params[1]))
+	return fsc.SockListen(fd, backlog)
+}
+
+// sockConnect is the WasmEdge extension function named SockConnectName which
+// connects a socket from sock_open to a remote address. The connection is then
+// used with sock_send, sock_recv and sock_shutdown.
+//
+// `addr` has the same layout as sock_bind.
+//
+// See: https://github.com/second-state/wasmedge_wasi_socket
+var sockConnect = newHostFunc(
+	wasip1.SockConnectName,
+	sockConnectFn,
+	[]wasm.ValueType{i32, i32, i32},
+	"fd", "addr", "port",
+)
+
+func sockConnectFn(_ context.Context, mod api.Module, params []uint64) sys.Errno {
+	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
+
+	fd := int32(params[0])
+	address, errno := readSockAddress(mod.Memory(), uint32(params[1]), uint32(params[2]))
+	if errno != 0 {
+		return errno
+	}
+	return fsc.SockConnect(fd, address)
+}
+
+// sockSendTo is the WasmEdge extension function named SockSendToName which
+// sends a datagram to an address from a UDP socket from sock_open.
+//
+// `addr` has the same layout as sock_bind. Unlike sock_send, all of `si_data`
+// is sent as one datagram.
+//
+// See: https://github.com/second-state/wasmedge_wasi_socket
+var sockSendTo = newHostFunc(
+	wasip1.SockSendToName,
+	sockSendToFn,
+	[]wasm.ValueType{i32, i32, i32, i32, i32, i32, i32},
+	"fd", "si_data", "si_data_len", "addr", "port", "si_flags", "result.so_datalen",
+)
+
+func sockSendToFn(_ context.Context, mod api.Module, params []uint64) sys.Errno {
+	mem := mod.Memory()
+	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
+
+	fd := int32(params[0])
+	siData := uint32(params[1])
+	siDataCount := uint32(params[2])
+	addr := uint32(params[3])
+	port := uint32(params[4])
+	siFlags := uint32(params[5])
+	resultSoDatalen := uint32(params[6])
+
+	if siFlags != 0 {
+		return sys.ENOTSUP
+	}
+
+	address, errno := readSockAddress(mem, addr, port)
+	if errno != 0 {
+		return errno
+	}
+	return sendDatagram(mem, siData, siDataCount, resultSoDatalen, func(p []byte) (int, sys.Errno) {
+		return fsc.SockSendTo(fd, p, address)
+	})
+}
+
+// sockRecvFrom is the WasmEdge extension function named SockRecvFromName
+// which receives a datagram from a UDP socket from sock_open, and its source
+// address.
+//
+// `addr` has the same layout as sock_bind, where the IP address is written to
+// buf and its length to buf_len. The port is written to `result.port`. Any
+// remainder of a datagram larger than `ri_data` is discarded.
+//
+// See: https://github.com/second-state/wasmedge_wasi_socket
+var sockRecvFrom = newHostFunc(
+	wasip1.SockRecvFromName,
+	sockRecvFromFn,
+	[]wasm.ValueType{i32, i32, i32, i32, i32, i32, i32, i32},
+	"fd", "ri_data", "ri_data_len", "addr", "ri_flags", "result.port", "result.ro_datalen", "result.ro_flags",
+)
+
+func sockRecvFromFn(_ context.Context, mod api.Module, params []uint64) sys.Errno {
+	mem := mod.Memory()
+	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
+
+	fd := int32(params[0])
+	riData := uint32(params[1])
+	riDataCount := uint32(params[2])
+	addr := uint32(params[3])
+	riFlags := uint8(params[4])
+	resultPort := uint32(params[5])
+	resultRoDatalen := uint32(params[6])
+	resultRoFlags := uint32(params[7])
+
+	if riFlags & ^wasip1.RI_RECV_WAITALL != 0 {
+		return sys.ENOTSUP // MSG_PEEK isn't supported by net.UDPConn
+	}
+
+	buf, ok := mem.ReadUint32Le(addr)
+	if !ok {
+		return sys.EFAULT
+	}
+	bufLen, ok := mem.ReadUint32Le(addr + 4)
+	if !ok {
+		return sys.EFAULT
+	}
+
+	var from *net.UDPAddr
+	errno := recvDatagram(mem, riData, riDataCount, resultRoDatalen, resultRoFlags, func(p []byte) (n int, errno sys.Errno) {
+		n, from, errno = fsc.SockRecvFrom(fd, p)
+		return
+	})
+	if errno != 0 {
+		return errno
+	}
+
+	ip := from.IP
+	if ip4 := ip.To4(); ip4 != nil && bufLen < net.IPv6len {
+		ip = ip4
+	}
+	if bufLen < uint32(len(ip)) {
+		return sys.EINVAL
+	} else if !mem.Write(buf, ip) {
+		return sys.EFAULT
+	}
+	mem.WriteUint32Le(addr+4, uint32(len(ip)))
+	mem.WriteUint32Le(resultPort, uint32(from.Port))
+	return 0
+}
+
+// sendDatagram writes the iovecs at `iovs` as one datagram with `write`, as
+// writev would send a datagram per iovec.
+func sendDatagram(mem api.Memory, iovs, iovsCount, resultSoDatalen uint32, write func([]byte) (int, sys.Errno)) sys.Errno {
+	var p []byte
+	if _, errno := writev(mem, iovs, iovsCount, func(b []byte) (int, sys.Errno) {
+		p = append(p, b...)
+		return len(b), 0
+	}); errno != 0 {
+		return errno
+	}
+	n, errno := write(p)
+	if errno != 0 {
+		return errno
+	}
+	mem.WriteUint32Le(resultSoDatalen, uint32(n))
+	return 0
+}
+
+// recvDatagram reads one datagram with `read` into the iovecs at `iovs`, as
+// readv would discard the remainder of a datagram after the first iovec.
+func recvDatagram(mem api.Memory, iovs, iovsCount, resultRoDatalen, resultRoFlags uint32, read func([]byte) (int, sys.Errno)) sys.Errno {
+	var size uint32
+	if _, errno := readv(mem, iovs, iovsCount, func(b []byte) (int, sys.Errno) {
+		size += uint32(len(b))
+		return len(b), 0
+	}); errno != 0 {
+		return errno
+	}
+
+	p := make([]byte, size)
+	n, errno := read(p)
+	if errno != 0 {
+		return errno
+	}
+
+	// Copy the datagram into the iovecs, which we already know are valid.
+	p = p[:n]
+	_, _ = readv(mem, iovs, iovsCount, func(b []byte) (int, sys.Errno) {
+		copied := copy(b, p)
+		p = p[copied:]
+		return copied, 0
+	})
+	mem.WriteUint32Le(resultRoDatalen, uint32(n))
+	mem.WriteUint16Le(resultRoFlags, 0)
+	return 0
+}
+
+// readSockAddress returns the "host:port" of the address struct at `addr`
+// used by sock_bind and sock_connect.
+func readSockAddress(mem api.Memory, addr, port uint32) (string, sys.Errno) {
+	buf, ok := mem.ReadUint32Le(addr)
+	if !ok {
+		return "", sys.EFAULT
+	}
+	bufLen, ok := mem.ReadUint32Le(addr + 4)
+	if !ok {
+		return "", sys.EFAULT
+	}
+	if bufLen != net.IPv4len && bufLen != net.IPv6len {
+		return "", sys.EINVAL
+	} else if port > 0xffff {
+		return "", sys.EINVAL
+	}
+	ip, ok := mem.Read(buf, bufLen)
+	if !ok {
+		return "", sys.EFAULT
+	}
+	return net.JoinHostPort(net.IP(ip).String(), strconv.Itoa(int(port))), 0
+}
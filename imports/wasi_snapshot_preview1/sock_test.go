@@ -3,6 +3,7 @@ package wasi_snapshot_preview1_test
 import (
 	"bytes"
 	"net"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -65,6 +66,41 @@ func Test_sockAccept(t *testing.T) {
 	}
 }
 
+func Test_sockAccept_WithListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	config := experimentalsock.NewConfig().WithTCPListener("127.0.0.1", 0).WithListener(ln)
+	mod, r, _ := requireProxyModuleWithContext(experimentalsock.WithConfig(testCtx, config), t, wazero.NewModuleConfig())
+	defer r.Close(testCtx)
+
+	// The listener is pre-opened after the one configured by address.
+	lnFd := sys.FdPreopen + 1
+	sock, ok := mod.(*wasm.ModuleInstance).Sys.FS().LookupFile(lnFd)
+	require.True(t, ok)
+	require.True(t, sock.IsPreopen)
+	require.Equal(t, ln.Addr().String(), sock.File.(addr).Addr().String())
+
+	tcp, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+	defer tcp.Close() //nolint
+
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.SockAcceptName, uint64(lnFd), 0, 128)
+	connFd, _ := mod.Memory().ReadUint32Le(128)
+	require.Equal(t, uint32(lnFd+1), connFd)
+
+	_, err = tcp.Write([]byte("wazero"))
+	require.NoError(t, err)
+	ok = mod.Memory().Write(0, []byte{16, 0, 0, 0, 6, 0, 0, 0}) // iovs[0]
+	require.True(t, ok)
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.SockRecvName, uint64(connFd), 0, 1, uint64(wasip1.RI_RECV_WAITALL), 32, 36)
+	buf, _ := mod.Memory().Read(16, 6)
+	require.Equal(t, "wazero", string(buf))
+
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.SockShutdownName, uint64(connFd), uint64(wasip1.SD_RD|wasip1.SD_WR))
+}
+
 func Test_sockShutdown(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -367,6 +403,184 @@ func Test_sockSend(t *testing.T) {
 	}
 }
 
+func Test_sockOpen_notAllowed(t *testing.T) {
+	mod, r, log := requireProxyModule(t, wazero.NewModuleConfig())
+	defer r.Close(testCtx)
+
+	requireErrnoResult(t, wasip1.ErrnoNotsup, mod, wasip1.SockOpenName, uint64(wasip1.ADDRESS_FAMILY_INET4), uint64(wasip1.SOCK_TYPE_STREAM), 128)
+	require.Equal(t, `
+==> wasi_snapshot_preview1.sock_open(af=1,socktype=2)
+<== (fd=,errno=ENOTSUP)
+`, "\n"+log.String())
+}
+
+func Test_sockConnect(t *testing.T) {
+	listen, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listen.Close()
+	port := listen.Addr().(*net.TCPAddr).Port
+
+	var permitted []string
+	config := experimentalsock.NewConfig().WithSockOpen(func(op, network, address string) bool {
+		permitted = append(permitted, op+" "+network+" "+address)
+		return true
+	})
+	mod, r, log := requireProxyModuleWithContext(experimentalsock.WithConfig(testCtx, config), t, wazero.NewModuleConfig())
+	defer r.Close(testCtx)
+
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.SockOpenName, uint64(wasip1.ADDRESS_FAMILY_INET4), uint64(wasip1.SOCK_TYPE_STREAM), 128)
+	sockFd, _ := mod.Memory().ReadUint32Le(128)
+	require.Equal(t, uint32(3), sockFd)
+
+	address := writeSockAddress(t, mod, net.IPv4(127, 0, 0, 1).To4())
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.SockConnectName, uint64(sockFd), uint64(address), uint64(port))
+	require.Equal(t, []string{"connect tcp4 " + listen.Addr().String()}, permitted)
+
+	// Connecting again fails, as the socket is now a connection.
+	requireErrnoResult(t, wasip1.ErrnoInval, mod, wasip1.SockConnectName, uint64(sockFd), uint64(address), uint64(port))
+
+	conn, err := listen.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("wazero"))
+	require.NoError(t, err)
+
+	ok := mod.Memory().Write(0, []byte{16, 0, 0, 0, 6, 0, 0, 0}) // iovs[0]
+	require.True(t, ok)
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.SockRecvName, uint64(sockFd), 0, 1, uint64(wasip1.RI_RECV_WAITALL), 32, 36)
+	buf, _ := mod.Memory().Read(16, 6)
+	require.Equal(t, "wazero", string(buf))
+
+	require.Equal(t, `
+==> wasi_snapshot_preview1.sock_open(af=1,socktype=2)
+<== (fd=3,errno=ESUCCESS)
+==> wasi_snapshot_preview1.sock_connect(fd=3,addr=0,port=`+strconv.Itoa(port)+`)
+<== errno=ESUCCESS
+==> wasi_snapshot_preview1.sock_connect(fd=3,addr=0,port=`+strconv.Itoa(port)+`)
+<== errno=EINVAL
+==> wasi_snapshot_preview1.sock_recv(fd=3,ri_data=0,ri_data_len=1,ri_flags=RECV_WAITALL)
+<== (ro_datalen=6,ro_flags=,errno=ESUCCESS)
+`, "\n"+log.String())
+}
+
+func Test_sockListen(t *testing.T) {
+	config := experimentalsock.NewConfig().WithSockOpen(nil)
+	mod, r, _ := requireProxyModuleWithContext(experimentalsock.WithConfig(testCtx, config), t, wazero.NewModuleConfig())
+	defer r.Close(testCtx)
+
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.SockOpenName, uint64(wasip1.ADDRESS_FAMILY_INET4), uint64(wasip1.SOCK_TYPE_ANY), 128)
+	sockFd, _ := mod.Memory().ReadUint32Le(128)
+
+	// Listening requires a bound address.
+	requireErrnoResult(t, wasip1.ErrnoInval, mod, wasip1.SockListenName, uint64(sockFd), 1)
+
+	address := writeSockAddress(t, mod, net.IPv4(127, 0, 0, 1).To4())
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.SockBindName, uint64(sockFd), uint64(address), 0)
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.SockListenName, uint64(sockFd), 1)
+
+	sock, ok := mod.(*wasm.ModuleInstance).Sys.FS().LookupFile(int32(sockFd))
+	require.True(t, ok)
+	tcp, err := net.DialTCP("tcp", nil, sock.File.(addr).Addr())
+	require.NoError(t, err)
+	defer tcp.Close() //nolint
+
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.SockAcceptName, uint64(sockFd), 0, 128)
+	connFd, _ := mod.Memory().ReadUint32Le(128)
+	require.Equal(t, sockFd+1, connFd)
+}
+
+func Test_sockOpen_denied(t *testing.T) {
+	config := experimentalsock.NewConfig().WithSockOpen(func(op, network, address string) bool {
+		return false
+	})
+	mod, r, _ := requireProxyModuleWithContext(experimentalsock.WithConfig(testCtx, config), t, wazero.NewModuleConfig())
+	defer r.Close(testCtx)
+
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.SockOpenName, uint64(wasip1.ADDRESS_FAMILY_INET6), uint64(wasip1.SOCK_TYPE_STREAM), 128)
+	sockFd, _ := mod.Memory().ReadUint32Le(128)
+
+	address := writeSockAddress(t, mod, net.IPv6loopback)
+	requireErrnoResult(t, wasip1.ErrnoAcces, mod, wasip1.SockBindName, uint64(sockFd), uint64(address), 8080)
+	requireErrnoResult(t, wasip1.ErrnoAcces, mod, wasip1.SockConnectName, uint64(sockFd), uint64(address), 8080)
+
+	// Invalid addresses fail before asking for permission.
+	requireErrnoResult(t, wasip1.ErrnoInval, mod, wasip1.SockConnectName, uint64(sockFd), uint64(address), 1<<16)
+	requireErrnoResult(t, wasip1.ErrnoInval, mod, wasip1.SockOpenName, uint64(wasip1.ADDRESS_FAMILY_UNSPEC), uint64(wasip1.SOCK_TYPE_DGRAM), 128)
+}
+
+func Test_sockSendTo_sockRecvFrom(t *testing.T) {
+	host, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer host.Close()
+	hostAddr := host.LocalAddr().(*net.UDPAddr)
+
+	config := experimentalsock.NewConfig().WithSockOpen(nil)
+	mod, r, log := requireProxyModuleWithContext(experimentalsock.WithConfig(testCtx, config), t, wazero.NewModuleConfig())
+	defer r.Close(testCtx)
+
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.SockOpenName, uint64(wasip1.ADDRESS_FAMILY_INET4), uint64(wasip1.SOCK_TYPE_DGRAM), 128)
+	sockFd, _ := mod.Memory().ReadUint32Le(128)
+	require.Equal(t, uint32(3), sockFd)
+
+	// Send a datagram split across two iovecs.
+	mem := mod.Memory()
+	address := writeSockAddress(t, mod, hostAddr.IP.To4())
+	require.True(t, mem.Write(32, []byte{
+		48, 0, 0, 0, 4, 0, 0, 0, // iovs[0]
+		52, 0, 0, 0, 2, 0, 0, 0, // iovs[1]
+		'w', 'a', 'z', 'e', 'r', 'o',
+	}))
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.SockSendToName, uint64(sockFd), 32, 2, uint64(address), uint64(hostAddr.Port), 0, 64)
+	n, _ := mem.ReadUint32Le(64)
+	require.Equal(t, uint32(6), n)
+
+	buf := make([]byte, 16)
+	n2, guestAddr, err := host.ReadFromUDP(buf)
+	require.NoError(t, err)
+	require.Equal(t, "wazero", string(buf[:n2]))
+
+	// Reply, and receive the datagram across two iovecs.
+	_, err = host.WriteToUDP([]byte("pong"), guestAddr)
+	require.NoError(t, err)
+
+	require.True(t, mem.Write(8, make([]byte, 4)))  // clear the IP
+	require.True(t, mem.Write(48, make([]byte, 8))) // clear the data
+	require.True(t, mem.Write(32, []byte{
+		48, 0, 0, 0, 3, 0, 0, 0, // iovs[0]
+		52, 0, 0, 0, 3, 0, 0, 0, // iovs[1]
+	}))
+	requireErrnoResult(t, wasip1.ErrnoSuccess, mod, wasip1.SockRecvFromName, uint64(sockFd), 32, 2, uint64(address), 0, 64, 68, 72)
+	data, _ := mem.Read(48, 7)
+	require.Equal(t, "pon\x00g\x00\x00", string(data))
+	ip, _ := mem.Read(8, 4)
+	require.Equal(t, hostAddr.IP.To4(), net.IP(ip))
+	port, _ := mem.ReadUint32Le(64)
+	require.Equal(t, uint32(hostAddr.Port), port)
+
+	require.Equal(t, `
+==> wasi_snapshot_preview1.sock_open(af=1,socktype=1)
+<== (fd=3,errno=ESUCCESS)
+==> wasi_snapshot_preview1.sock_send_to(fd=3,si_data=32,si_data_len=2,addr=0,port=`+strconv.Itoa(hostAddr.Port)+`,si_flags=)
+<== (so_datalen=6,errno=ESUCCESS)
+==> wasi_snapshot_preview1.sock_recv_from(fd=3,ri_data=32,ri_data_len=2,addr=0,ri_flags=)
+<== (port=`+strconv.Itoa(hostAddr.Port)+`,ro_datalen=4,ro_flags=,errno=ESUCCESS)
+`, "\n"+log.String())
+
+	// The socket was bound on first use, so can no longer be connected.
+	requireErrnoResult(t, wasip1.ErrnoInval, mod, wasip1.SockConnectName, uint64(sockFd), uint64(address), uint64(hostAddr.Port))
+}
+
+// writeSockAddress writes the address struct of sock_bind and sock_connect
+// at offset zero, followed by `ip`, and returns its offset.
+func writeSockAddress(t *testing.T, mod api.Module, ip net.IP) uint32 {
+	mem := mod.Memory()
+	require.True(t, mem.WriteUint32Le(0, 8))
+	require.True(t, mem.WriteUint32Le(4, uint32(len(ip))))
+	require.True(t, mem.Write(8, ip))
+	return 0
+}
+
 type addr interface {
 	Addr() *net.TCPAddr
 }
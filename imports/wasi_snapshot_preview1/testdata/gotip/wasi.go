@@ -58,6 +58,8 @@ func main() {
 		mainStdout()
 	case "largestdout":
 		mainLargeStdout()
+	case "timers":
+		mainTimers()
 	}
 }
 
@@ -265,6 +267,37 @@ func mainStdout() {
 	os.Stdout.WriteString("test")
 }
 
+// mainTimers ensures goroutines blocked on timers are woken in order, while
+// another goroutine sleeps.
+func mainTimers() {
+	var wg sync.WaitGroup
+	results := make(chan int, 3)
+	for _, i := range []int{3, 1, 2} {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-time.After(time.Duration(i) * 20 * time.Millisecond)
+			results <- i
+		}(i)
+	}
+
+	ticker := time.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+	var ticks int
+	go func() {
+		for range ticker.C {
+			ticks++
+		}
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	wg.Wait()
+	close(results)
+	for i := range results {
+		fmt.Println(i)
+	}
+}
+
 func mainLargeStdout() {
 	const ntest = 1024
 
@@ -224,6 +224,12 @@ func exportFunctions(builder wazero.HostModuleBuilder) {
 	exporter.ExportHostFunc(sockRecv)
 	exporter.ExportHostFunc(sockSend)
 	exporter.ExportHostFunc(sockShutdown)
+	exporter.ExportHostFunc(sockOpen)
+	exporter.ExportHostFunc(sockBind)
+	exporter.ExportHostFunc(sockListen)
+	exporter.ExportHostFunc(sockConnect)
+	exporter.ExportHostFunc(sockSendTo)
+	exporter.ExportHostFunc(sockRecvFrom)
 }
 
 // writeOffsetsAndNullTerminatedValues is used to write NUL-terminated values
@@ -402,6 +402,16 @@ func Test_Sleep(t *testing.T) {
 	require.Equal(t, "OK\n", console)
 }
 
+func Test_Timers(t *testing.T) {
+	if wasmGotip == nil {
+		t.Skip("skipping because wasi.go was not compiled (gotip missing or compilation error)")
+	}
+	moduleConfig := wazero.NewModuleConfig().WithArgs("wasi", "timers").
+		WithSysNanotime().WithSysNanosleep()
+	console := compileAndRun(t, testCtx, moduleConfig, wasmGotip)
+	require.Equal(t, "1\n2\n3\n", console)
+}
+
 func Test_Open(t *testing.T) {
 	for toolchain, bin := range map[string][]byte{
 		"zig-cc": wasmZigCc,
@@ -624,10 +634,10 @@ func testLargeStdout(t *testing.T, tname string, bin []byte) {
 		_, _ = temp.Write(buf)
 		_ = temp.Close()
 
-		gotipBin, err := findGotipBin()
+		goBin, err := findWasip1GoBin()
 		require.NoError(t, err)
 
-		cmd := exec.CommandContext(testCtx, gotipBin, "build", "-o",
+		cmd := exec.CommandContext(testCtx, goBin, "build", "-o",
 			joinPath(tempDir, "outbin"), temp.Name())
 		require.NoError(t, err)
 		output, err := cmd.CombinedOutput()
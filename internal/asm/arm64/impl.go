@@ -905,7 +905,7 @@ func checkRegisterToRegisterType(src, dst asm.Register, requireSrcInt, requireDs
 
 func (a *AssemblerImpl) encodeRegisterToRegister(buf asm.Buffer, n *nodeImpl) (err error) {
 	switch inst := n.instruction; inst {
-	case ADD, ADDW, SUB:
+	case ADD, ADDS, ADDW, SUB:
 		if err = checkRegisterToRegisterType(n.srcReg, n.dstReg, true, true); err != nil {
 			return
 		}
@@ -915,6 +915,8 @@ func (a *AssemblerImpl) encodeRegisterToRegister(buf asm.Buffer, n *nodeImpl) (e
 		switch inst {
 		case ADD:
 			sfops = 0b100
+		case ADDS:
+			sfops = 0b101
 		case ADDW:
 		case SUB:
 			sfops = 0b110
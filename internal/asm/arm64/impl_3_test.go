@@ -746,6 +746,8 @@ func TestAssemblerImpl_EncodeRegisterToRegister(t *testing.T) {
 		{name: "ADD/src=R30,dst=RZR", inst: ADD, src: RegR30, dst: RegRZR, exp: []byte{0xff, 0x3, 0x1e, 0x8b}},
 		{name: "ADD/src=R30,dst=R10", inst: ADD, src: RegR30, dst: RegR10, exp: []byte{0x4a, 0x1, 0x1e, 0x8b}},
 		{name: "ADD/src=R30,dst=R30", inst: ADD, src: RegR30, dst: RegR30, exp: []byte{0xde, 0x3, 0x1e, 0x8b}},
+		{name: "ADDS/src=R10,dst=R30", inst: ADDS, src: RegR10, dst: RegR30, exp: []byte{0xde, 0x3, 0xa, 0xab}},
+		{name: "ADDS/src=R30,dst=R10", inst: ADDS, src: RegR30, dst: RegR10, exp: []byte{0x4a, 0x1, 0x1e, 0xab}},
 		{name: "ADDW/src=RZR,dst=RZR", inst: ADDW, src: RegRZR, dst: RegRZR, exp: []byte{0xff, 0x3, 0x1f, 0xb}},
 		{name: "ADDW/src=RZR,dst=R10", inst: ADDW, src: RegRZR, dst: RegR10, exp: []byte{0x4a, 0x1, 0x1f, 0xb}},
 		{name: "ADDW/src=RZR,dst=R30", inst: ADDW, src: RegRZR, dst: RegR30, exp: []byte{0xde, 0x3, 0x1f, 0xb}},
@@ -0,0 +1,37 @@
+// Package callstack contains internal symbols shared between the engines, and
+// the wazero and experimental packages, to limit the call stack.
+package callstack
+
+import "context"
+
+// LimitsKey is a context.Context Value key. Its associated value should be a
+// Limits, which applies to modules instantiated with the context.
+type LimitsKey struct{}
+
+// Limits limit the call stack of calls to a module instance. Zero fields use
+// the defaults of the engine.
+type Limits struct {
+	// MaxDepth is the maximum number of nested function calls, enforced by
+	// the interpreter.
+	MaxDepth uint32
+	// MaxSize is the maximum size in bytes of the stack, enforced by the
+	// compiler.
+	MaxSize uint64
+}
+
+// With returns a copy of the limits, overridden by the non-zero fields of
+// the limits of the context, if any.
+func (l Limits) With(ctx context.Context) Limits {
+	if ctx == nil {
+		return l
+	}
+	if override, ok := ctx.Value(LimitsKey{}).(Limits); ok {
+		if override.MaxDepth != 0 {
+			l.MaxDepth = override.MaxDepth
+		}
+		if override.MaxSize != 0 {
+			l.MaxSize = override.MaxSize
+		}
+	}
+	return l
+}
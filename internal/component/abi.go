@@ -0,0 +1,414 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"unicode/utf8"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// Limits of the canonical ABI on the count of core params and results, above
+// which values are passed in memory instead.
+const (
+	MaxFlatParams  = 16
+	MaxFlatResults = 1
+)
+
+// flatTypes appends the core value types of `t` to `dst`.
+func flatTypes(dst []api.ValueType, t ValueType) []api.ValueType {
+	switch t {
+	case ValueTypeS64, ValueTypeU64:
+		return append(dst, api.ValueTypeI64)
+	case ValueTypeF32:
+		return append(dst, api.ValueTypeF32)
+	case ValueTypeF64:
+		return append(dst, api.ValueTypeF64)
+	case ValueTypeString:
+		return append(dst, api.ValueTypeI32, api.ValueTypeI32) // ptr, len
+	}
+	return append(dst, api.ValueTypeI32)
+}
+
+func (t *FuncType) flatParams() (ret []api.ValueType) {
+	for _, p := range t.Params {
+		ret = flatTypes(ret, p.Type)
+	}
+	return
+}
+
+func (t *FuncType) flatResults() (ret []api.ValueType) {
+	for _, r := range t.Results {
+		ret = flatTypes(ret, r)
+	}
+	return
+}
+
+// CoreType returns the type of the core function of a function of this type,
+// which is the core function of a lifted function, or the one a lowered
+// function is lowered to. Results which don't fit in core results are
+// returned in memory: a lifted function returns their address, and a lowered
+// function is passed the address to store them at.
+func (t *FuncType) CoreType(lift bool) (params, results []api.ValueType, err error) {
+	if t.Err != nil {
+		return nil, nil, t.Err
+	}
+	params, results = t.flatParams(), t.flatResults()
+	if len(params) > MaxFlatParams {
+		return nil, nil, fmt.Errorf("more than %d core params aren't supported", MaxFlatParams)
+	}
+	if len(results) > MaxFlatResults {
+		if lift {
+			results = []api.ValueType{api.ValueTypeI32}
+		} else {
+			params, results = append(params, api.ValueTypeI32), nil
+		}
+	}
+	return
+}
+
+// RequiresMemory returns true if the memory option is required to lift or
+// lower a function of this type.
+func (t *FuncType) RequiresMemory() bool {
+	return t.paramsHaveString() || hasString(t.Results) || len(t.flatResults()) > MaxFlatResults
+}
+
+// RequiresRealloc returns true if the realloc option is required to lift or
+// lower a function of this type, which is when strings are passed to the core
+// function.
+func (t *FuncType) RequiresRealloc(lift bool) bool {
+	if lift {
+		return t.paramsHaveString()
+	}
+	return hasString(t.Results)
+}
+
+func (t *FuncType) paramsHaveString() bool {
+	for _, p := range t.Params {
+		if p.Type == ValueTypeString {
+			return true
+		}
+	}
+	return false
+}
+
+func hasString(types []ValueType) bool {
+	for _, t := range types {
+		if t == ValueTypeString {
+			return true
+		}
+	}
+	return false
+}
+
+// GoType returns the Go type of values of `t`, e.g. uint32 for
+// ValueTypeU32, or rune for ValueTypeChar.
+func GoType(t ValueType) reflect.Type {
+	switch t {
+	case ValueTypeBool:
+		return reflect.TypeOf(false)
+	case ValueTypeS8:
+		return reflect.TypeOf(int8(0))
+	case ValueTypeU8:
+		return reflect.TypeOf(uint8(0))
+	case ValueTypeS16:
+		return reflect.TypeOf(int16(0))
+	case ValueTypeU16:
+		return reflect.TypeOf(uint16(0))
+	case ValueTypeS32, ValueTypeChar:
+		return reflect.TypeOf(int32(0))
+	case ValueTypeU32:
+		return reflect.TypeOf(uint32(0))
+	case ValueTypeS64:
+		return reflect.TypeOf(int64(0))
+	case ValueTypeU64:
+		return reflect.TypeOf(uint64(0))
+	case ValueTypeF32:
+		return reflect.TypeOf(float32(0))
+	case ValueTypeF64:
+		return reflect.TypeOf(float64(0))
+	case ValueTypeString:
+		return reflect.TypeOf("")
+	}
+	panic(fmt.Errorf("invalid value type: %s", t))
+}
+
+// Options are the canonical options of a lifted or lowered function, resolved
+// in an instance of its component.
+type Options struct {
+	// Memory is nil unless the memory option is set.
+	Memory api.Memory
+	// Realloc is nil unless the realloc option is set.
+	Realloc api.Function
+}
+
+// LowerFlat appends the core values of the Go values `values`, of types
+// `types`, to `dst`. Strings are copied to memory allocated with Realloc.
+func (o *Options) LowerFlat(ctx context.Context, dst []uint64, types []ValueType, values []interface{}) ([]uint64, error) {
+	for i, t := range types {
+		v, err := checkGoValue(t, values[i])
+		if err != nil {
+			return nil, err
+		}
+		switch t {
+		case ValueTypeBool:
+			if v.Bool() {
+				dst = append(dst, 1)
+			} else {
+				dst = append(dst, 0)
+			}
+		case ValueTypeS8, ValueTypeS16, ValueTypeS32, ValueTypeChar:
+			dst = append(dst, api.EncodeI32(int32(v.Int())))
+		case ValueTypeS64:
+			dst = append(dst, api.EncodeI64(v.Int()))
+		case ValueTypeU8, ValueTypeU16, ValueTypeU32, ValueTypeU64:
+			dst = append(dst, v.Uint())
+		case ValueTypeF32:
+			dst = append(dst, api.EncodeF32(float32(v.Float())))
+		case ValueTypeF64:
+			dst = append(dst, api.EncodeF64(v.Float()))
+		case ValueTypeString:
+			ptr, err := o.lowerString(ctx, v.String())
+			if err != nil {
+				return nil, err
+			}
+			dst = append(dst, uint64(ptr), uint64(len(v.String())))
+		}
+	}
+	return dst, nil
+}
+
+// LiftFlat returns the Go values of types `types` from their core values in
+// `flat`, reading strings from memory.
+func (o *Options) LiftFlat(types []ValueType, flat []uint64) ([]interface{}, error) {
+	ret := make([]interface{}, len(types))
+	for i, t := range types {
+		var err error
+		if t == ValueTypeString {
+			ret[i], err = o.liftString(uint32(flat[0]), uint32(flat[1]))
+			flat = flat[2:]
+		} else {
+			ret[i], err = liftScalar(t, flat[0])
+			flat = flat[1:]
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// Store stores the Go values `values`, of types `types`, in memory at `ptr`,
+// like the fields of a record.
+func (o *Options) Store(ctx context.Context, ptr uint32, types []ValueType, values []interface{}) error {
+	if ptr%alignment(types) != 0 {
+		return errors.New("unaligned pointer")
+	} else if !o.hasSize(ptr, size(types)) {
+		return errOutOfBounds
+	}
+
+	offset := ptr
+	for i, t := range types {
+		v, err := checkGoValue(t, values[i])
+		if err != nil {
+			return err
+		}
+		offset = alignTo(offset, alignment([]ValueType{t}))
+		switch t {
+		case ValueTypeString:
+			ptr, err := o.lowerString(ctx, v.String())
+			if err != nil {
+				return err
+			}
+			o.Memory.WriteUint32Le(offset, ptr)
+			o.Memory.WriteUint32Le(offset+4, uint32(len(v.String())))
+		default:
+			flat, err := o.LowerFlat(ctx, nil, []ValueType{t}, []interface{}{v.Interface()})
+			if err != nil {
+				return err
+			}
+			switch size([]ValueType{t}) {
+			case 1:
+				o.Memory.WriteByte(offset, byte(flat[0]))
+			case 2:
+				o.Memory.WriteUint16Le(offset, uint16(flat[0]))
+			case 4:
+				o.Memory.WriteUint32Le(offset, uint32(flat[0]))
+			default:
+				o.Memory.WriteUint64Le(offset, flat[0])
+			}
+		}
+		offset += size([]ValueType{t})
+	}
+	return nil
+}
+
+// Load returns the Go values of types `types` from memory at `ptr`, where
+// Store stores them.
+func (o *Options) Load(ptr uint32, types []ValueType) ([]interface{}, error) {
+	if ptr%alignment(types) != 0 {
+		return nil, errors.New("unaligned pointer")
+	} else if !o.hasSize(ptr, size(types)) {
+		return nil, errOutOfBounds
+	}
+
+	ret := make([]interface{}, len(types))
+	offset := ptr
+	for i, t := range types {
+		offset = alignTo(offset, alignment([]ValueType{t}))
+		var err error
+		switch t {
+		case ValueTypeString:
+			p, _ := o.Memory.ReadUint32Le(offset)
+			l, _ := o.Memory.ReadUint32Le(offset + 4)
+			ret[i], err = o.liftString(p, l)
+		default:
+			var v uint64
+			switch size([]ValueType{t}) {
+			case 1:
+				b, _ := o.Memory.ReadByte(offset)
+				v = uint64(b)
+			case 2:
+				u, _ := o.Memory.ReadUint16Le(offset)
+				v = uint64(u)
+			case 4:
+				u, _ := o.Memory.ReadUint32Le(offset)
+				v = uint64(u)
+			default:
+				v, _ = o.Memory.ReadUint64Le(offset)
+			}
+			ret[i], err = liftScalar(t, v)
+		}
+		if err != nil {
+			return nil, err
+		}
+		offset += size([]ValueType{t})
+	}
+	return ret, nil
+}
+
+var errOutOfBounds = errors.New("out of bounds memory access")
+
+func (o *Options) hasSize(ptr, size uint32) bool {
+	return uint64(ptr)+uint64(size) <= uint64(o.Memory.Size())
+}
+
+// lowerString copies `s` to memory allocated with Realloc, returning its
+// address.
+func (o *Options) lowerString(ctx context.Context, s string) (uint32, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+	results, err := o.Realloc.Call(ctx, 0, 0, 1, uint64(len(s)))
+	if err != nil {
+		return 0, err
+	}
+	ptr := uint32(results[0])
+	if !o.Memory.WriteString(ptr, s) {
+		return 0, errOutOfBounds
+	}
+	return ptr, nil
+}
+
+func (o *Options) liftString(ptr, length uint32) (string, error) {
+	b, ok := o.Memory.Read(ptr, length)
+	if !ok {
+		return "", errOutOfBounds
+	} else if !utf8.Valid(b) {
+		return "", errors.New("string isn't valid UTF-8")
+	}
+	return string(b), nil
+}
+
+// liftScalar returns the Go value of type `t`, other than a string, from its
+// core value `v`.
+func liftScalar(t ValueType, v uint64) (interface{}, error) {
+	switch t {
+	case ValueTypeBool:
+		return uint32(v) != 0, nil
+	case ValueTypeS8:
+		return int8(v), nil
+	case ValueTypeU8:
+		return uint8(v), nil
+	case ValueTypeS16:
+		return int16(v), nil
+	case ValueTypeU16:
+		return uint16(v), nil
+	case ValueTypeS32:
+		return int32(v), nil
+	case ValueTypeU32:
+		return uint32(v), nil
+	case ValueTypeS64:
+		return int64(v), nil
+	case ValueTypeU64:
+		return v, nil
+	case ValueTypeF32:
+		return math.Float32frombits(uint32(v)), nil
+	case ValueTypeF64:
+		return math.Float64frombits(v), nil
+	case ValueTypeChar:
+		if r := rune(uint32(v)); utf8.ValidRune(r) {
+			return r, nil
+		}
+		return nil, fmt.Errorf("invalid char: 0x%x", uint32(v))
+	}
+	return nil, fmt.Errorf("invalid value type: %s", t)
+}
+
+// checkGoValue returns the reflect.Value of `v`, if it is a Go value of type
+// `t`.
+func checkGoValue(t ValueType, v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	if expected := GoType(t); !rv.IsValid() || rv.Type() != expected {
+		return rv, fmt.Errorf("expected %s (%s), but was %T", t, expected, v)
+	} else if t == ValueTypeChar && !utf8.ValidRune(rune(rv.Int())) {
+		return rv, fmt.Errorf("invalid char: 0x%x", rv.Int())
+	}
+	return rv, nil
+}
+
+func size(types []ValueType) (ret uint32) {
+	for _, t := range types {
+		var s uint32
+		switch t {
+		case ValueTypeBool, ValueTypeS8, ValueTypeU8:
+			s = 1
+		case ValueTypeS16, ValueTypeU16:
+			s = 2
+		case ValueTypeS64, ValueTypeU64, ValueTypeF64, ValueTypeString:
+			s = 8
+		default:
+			s = 4
+		}
+		ret = alignTo(ret, alignment([]ValueType{t})) + s
+	}
+	return alignTo(ret, alignment(types))
+}
+
+func alignment(types []ValueType) (ret uint32) {
+	ret = 1
+	for _, t := range types {
+		var a uint32
+		switch t {
+		case ValueTypeBool, ValueTypeS8, ValueTypeU8:
+			a = 1
+		case ValueTypeS16, ValueTypeU16:
+			a = 2
+		case ValueTypeS64, ValueTypeU64, ValueTypeF64:
+			a = 8
+		default:
+			a = 4
+		}
+		if a > ret {
+			ret = a
+		}
+	}
+	return
+}
+
+func alignTo(offset, alignment uint32) uint32 {
+	return (offset + alignment - 1) / alignment * alignment
+}
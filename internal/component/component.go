@@ -0,0 +1,276 @@
+// Package component decodes components of the component model, which link
+// core modules with the canonical ABI, and lifts and lowers the values of
+// their functions.
+//
+// Only a subset is supported: components without nested components, whose
+// functions have params and results of primitive types or strings.
+//
+// See https://github.com/WebAssembly/component-model/blob/main/design/mvp/Explainer.md
+package component
+
+import "fmt"
+
+// ValueType is a primitive value type, encoded like in the binary format.
+type ValueType byte
+
+const (
+	ValueTypeBool   ValueType = 0x7f
+	ValueTypeS8     ValueType = 0x7e
+	ValueTypeU8     ValueType = 0x7d
+	ValueTypeS16    ValueType = 0x7c
+	ValueTypeU16    ValueType = 0x7b
+	ValueTypeS32    ValueType = 0x7a
+	ValueTypeU32    ValueType = 0x79
+	ValueTypeS64    ValueType = 0x78
+	ValueTypeU64    ValueType = 0x77
+	ValueTypeF32    ValueType = 0x76
+	ValueTypeF64    ValueType = 0x75
+	ValueTypeChar   ValueType = 0x74
+	ValueTypeString ValueType = 0x73
+)
+
+// String implements fmt.Stringer, returning the name of the type in WIT.
+func (t ValueType) String() string {
+	switch t {
+	case ValueTypeBool:
+		return "bool"
+	case ValueTypeS8:
+		return "s8"
+	case ValueTypeU8:
+		return "u8"
+	case ValueTypeS16:
+		return "s16"
+	case ValueTypeU16:
+		return "u16"
+	case ValueTypeS32:
+		return "s32"
+	case ValueTypeU32:
+		return "u32"
+	case ValueTypeS64:
+		return "s64"
+	case ValueTypeU64:
+		return "u64"
+	case ValueTypeF32:
+		return "f32"
+	case ValueTypeF64:
+		return "f64"
+	case ValueTypeChar:
+		return "char"
+	case ValueTypeString:
+		return "string"
+	}
+	return fmt.Sprintf("unknown(0x%x)", byte(t))
+}
+
+// TypeKind is the kind of a Type.
+type TypeKind byte
+
+const (
+	// TypeKindValue is a primitive value type.
+	TypeKindValue TypeKind = iota
+	// TypeKindFunc is a function type.
+	TypeKindFunc
+	// TypeKindInstance is an instance type.
+	TypeKindInstance
+	// TypeKindComponent is a component type, e.g. of a WIT world.
+	TypeKindComponent
+	// TypeKindUnsupported is a type which is decoded, but can't be used, e.g.
+	// a record or a resource.
+	TypeKindUnsupported
+)
+
+// Type is a type in the type index space of a component.
+type Type struct {
+	Kind TypeKind
+	// Value is set when Kind is TypeKindValue.
+	Value ValueType
+	// Func is set when Kind is TypeKindFunc.
+	Func *FuncType
+	// Instance is set when Kind is TypeKindInstance.
+	Instance *InstanceType
+	// Component is set when Kind is TypeKindComponent.
+	Component *ComponentType
+	// Name is the name of an unsupported type, e.g. "record", set when Kind
+	// is TypeKindUnsupported.
+	Name string
+}
+
+// Param is a named param of a FuncType.
+type Param struct {
+	Name string
+	Type ValueType
+}
+
+// FuncType is the type of a component function.
+type FuncType struct {
+	Params  []Param
+	Results []ValueType
+	// Err is set when a param or a result has an unsupported type, in which
+	// case the function can't be lifted nor lowered.
+	Err error
+}
+
+// String implements fmt.Stringer, returning the type in WIT.
+func (t *FuncType) String() string {
+	ret := "func("
+	for i, p := range t.Params {
+		if i > 0 {
+			ret += ", "
+		}
+		ret += p.Name + ": " + p.Type.String()
+	}
+	ret += ")"
+	switch len(t.Results) {
+	case 0:
+	case 1:
+		ret += " -> " + t.Results[0].String()
+	default:
+		ret += " -> ("
+		for i, r := range t.Results {
+			if i > 0 {
+				ret += ", "
+			}
+			ret += r.String()
+		}
+		ret += ")"
+	}
+	return ret
+}
+
+// InstanceType is the type of an instance, e.g. of an imported interface.
+type InstanceType struct {
+	// Funcs are the types of the functions the instance exports.
+	Funcs map[string]*FuncType
+	// Types are the types the instance exports, which can be aliased.
+	Types map[string]Type
+}
+
+// ComponentType is the type of a component, which describes what it imports
+// and exports, e.g. a WIT world.
+type ComponentType struct {
+	// Imports are the functions, instances and components the component
+	// imports, keyed by name.
+	Imports map[string]Type
+	// Exports are the functions, instances and components the component
+	// exports, keyed by name.
+	Exports map[string]Type
+}
+
+// CoreSort is the sort of a core item, encoded like in the binary format.
+type CoreSort byte
+
+const (
+	CoreSortFunc     CoreSort = 0x00
+	CoreSortTable    CoreSort = 0x01
+	CoreSortMemory   CoreSort = 0x02
+	CoreSortGlobal   CoreSort = 0x03
+	CoreSortType     CoreSort = 0x10
+	CoreSortModule   CoreSort = 0x11
+	CoreSortInstance CoreSort = 0x12
+)
+
+// CoreExport is an export of a CoreInstance defined by inline exports.
+type CoreExport struct {
+	Name  string
+	Sort  CoreSort
+	Index uint32
+}
+
+// CoreInstance is an instance in the core instance index space.
+type CoreInstance struct {
+	// Module is the index of the instantiated core module, unless Exports is
+	// set.
+	Module uint32
+	// Args maps the module names of the imports of Module to the index of the
+	// core instance satisfying them. This is nil when Exports is set.
+	Args map[string]uint32
+	// Exports are the inline exports of an instance which isn't instantiated
+	// from a module. Each export is an item in the index space of its sort.
+	Exports []CoreExport
+}
+
+// CoreAlias is the export of a core instance, by its name.
+type CoreAlias struct {
+	Instance uint32
+	Name     string
+}
+
+// CoreFunc is a function in the core function index space.
+type CoreFunc struct {
+	// Alias is set for the export of a core instance.
+	Alias *CoreAlias
+	// Lower is set for a component function lowered to a core function, in
+	// which case Lower.Func is its index in Component.Funcs.
+	Lower *Canon
+}
+
+// String encodings of canonical options.
+const (
+	StringEncodingUTF8 byte = iota
+	StringEncodingUTF16
+	StringEncodingLatin1UTF16
+)
+
+// Canon is a function lifted or lowered with the canonical ABI.
+type Canon struct {
+	// Func is the index of the core function of a lifted function, or of the
+	// component function of a lowered one.
+	Func uint32
+	// StringEncoding is the encoding of strings, e.g. StringEncodingUTF8.
+	StringEncoding byte
+	// Memory is the index of the core memory holding strings and results
+	// which don't fit in a core result, if set.
+	Memory *uint32
+	// Realloc is the index of the core function allocating memory, if set.
+	Realloc *uint32
+	// PostReturn is the index of the core function called with the results
+	// of a lifted function once they are read, if set.
+	PostReturn *uint32
+}
+
+// Func is a function in the function index space of a component.
+type Func struct {
+	Type *FuncType
+	// Lift is set for a core function lifted to a component function.
+	Lift *Canon
+	// ImportInstance is the name of the imported instance exporting the
+	// function, or empty if the function is imported directly.
+	ImportInstance string
+	// ImportName is the name of an imported function, unless Lift is set.
+	ImportName string
+}
+
+// Instance is an instance in the instance index space of a component.
+type Instance struct {
+	// Import is the name of an imported instance, of type Type.
+	Import string
+	Type   *InstanceType
+	// Funcs are the function exports of an instance defined by inline
+	// exports, by their index in Component.Funcs.
+	Funcs map[string]uint32
+}
+
+// Component is a decoded component, whose index spaces are resolved.
+//
+// Core modules are only compiled when the component is instantiated.
+type Component struct {
+	// CoreModules are the binaries of the core modules.
+	CoreModules [][]byte
+	// CoreInstances are in the order they are instantiated.
+	CoreInstances []CoreInstance
+	CoreFuncs     []CoreFunc
+	CoreMemories  []CoreAlias
+	CoreTables    []CoreAlias
+	CoreGlobals   []CoreAlias
+
+	Types     []Type
+	Funcs     []Func
+	Instances []Instance
+
+	// FuncExports maps the names of exported functions to their index in
+	// Funcs.
+	FuncExports map[string]uint32
+	// InstanceExports maps the names of exported instances to their index in
+	// Instances.
+	InstanceExports map[string]uint32
+}
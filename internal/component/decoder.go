@@ -0,0 +1,1064 @@
+package component
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/tetratelabs/wazero/internal/leb128"
+)
+
+// preamble is the magic number, followed by the version and the layer 1 of
+// components.
+var preamble = []byte{0x00, 0x61, 0x73, 0x6d, 0x0d, 0x00, 0x01, 0x00}
+
+// Section IDs of a component.
+const (
+	sectionIDCustom       = 0
+	sectionIDCoreModule   = 1
+	sectionIDCoreInstance = 2
+	sectionIDCoreType     = 3
+	sectionIDComponent    = 4
+	sectionIDInstance     = 5
+	sectionIDAlias        = 6
+	sectionIDType         = 7
+	sectionIDCanon        = 8
+	sectionIDStart        = 9
+	sectionIDImport       = 10
+	sectionIDExport       = 11
+	sectionIDValue        = 12
+)
+
+// Sorts of items, other than the core sort 0x00, which is followed by a
+// CoreSort.
+const (
+	sortCore      = 0x00
+	sortFunc      = 0x01
+	sortValue     = 0x02
+	sortType      = 0x03
+	sortComponent = 0x04
+	sortInstance  = 0x05
+)
+
+// IsComponent returns true if `bin` starts with the preamble of a component,
+// instead of the one of a core module.
+func IsComponent(bin []byte) bool {
+	return bytes.HasPrefix(bin, preamble)
+}
+
+// Decode decodes the component `bin`, resolving its index spaces.
+//
+// This errs on features which aren't supported, e.g. nested components, but
+// not on types which aren't, e.g. records, until a function using them is
+// lifted or lowered.
+func Decode(bin []byte) (*Component, error) {
+	if !IsComponent(bin) {
+		return nil, errors.New("invalid magic number or version")
+	}
+	r := bytes.NewReader(bin[len(preamble):])
+	d := &decoder{c: &Component{FuncExports: map[string]uint32{}, InstanceExports: map[string]uint32{}}}
+	for {
+		id, err := r.ReadByte()
+		if err == io.EOF {
+			return d.c, nil
+		}
+
+		size, _, err := leb128.DecodeUint32(r)
+		if err != nil {
+			return nil, fmt.Errorf("get size of section %d: %v", id, err)
+		} else if uint64(size) > uint64(r.Len()) {
+			return nil, fmt.Errorf("section %d: %w", id, io.ErrUnexpectedEOF)
+		}
+		payload := make([]byte, size)
+		_, _ = r.Read(payload)
+
+		sr := bytes.NewReader(payload)
+		if err = d.decodeSection(id, sr, payload); err != nil {
+			return nil, fmt.Errorf("section %d: %w", id, err)
+		} else if id != sectionIDCoreModule && id != sectionIDCustom && sr.Len() != 0 {
+			return nil, fmt.Errorf("section %d: invalid size: %d bytes left", id, sr.Len())
+		}
+	}
+}
+
+type decoder struct {
+	c *Component
+}
+
+func (d *decoder) decodeSection(id byte, r *bytes.Reader, payload []byte) error {
+	switch id {
+	case sectionIDCustom:
+		return nil
+	case sectionIDCoreModule:
+		d.c.CoreModules = append(d.c.CoreModules, payload)
+		return nil
+	case sectionIDCoreType:
+		return errors.New("core types aren't supported")
+	case sectionIDComponent:
+		return errors.New("nested components aren't supported")
+	case sectionIDStart:
+		return errors.New("start functions aren't supported")
+	case sectionIDValue:
+		return errors.New("values aren't supported")
+	}
+
+	var decode func(*bytes.Reader) error
+	switch id {
+	case sectionIDCoreInstance:
+		decode = d.decodeCoreInstance
+	case sectionIDInstance:
+		decode = d.decodeInstance
+	case sectionIDAlias:
+		decode = d.decodeAlias
+	case sectionIDType:
+		decode = d.decodeType
+	case sectionIDCanon:
+		decode = d.decodeCanon
+	case sectionIDImport:
+		decode = d.decodeImport
+	case sectionIDExport:
+		decode = d.decodeExport
+	default:
+		return fmt.Errorf("invalid section id: %d", id)
+	}
+
+	count, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return fmt.Errorf("get count: %w", err)
+	}
+	for i := uint32(0); i < count; i++ {
+		if err = decode(r); err != nil {
+			return fmt.Errorf("item[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (d *decoder) decodeCoreInstance(r *bytes.Reader) error {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	var inst CoreInstance
+	switch kind {
+	case 0x00: // instantiate
+		if inst.Module, err = decodeIndex(r, len(d.c.CoreModules), "core module"); err != nil {
+			return err
+		}
+		count, _, err := leb128.DecodeUint32(r)
+		if err != nil {
+			return err
+		}
+		inst.Args = make(map[string]uint32, count)
+		for i := uint32(0); i < count; i++ {
+			name, err := decodeName(r)
+			if err != nil {
+				return err
+			}
+			if sort, err := r.ReadByte(); err != nil {
+				return err
+			} else if CoreSort(sort) != CoreSortInstance {
+				return fmt.Errorf("invalid sort of argument %q: 0x%x", name, sort)
+			}
+			if inst.Args[name], err = decodeIndex(r, len(d.c.CoreInstances), "core instance"); err != nil {
+				return err
+			}
+		}
+	case 0x01: // inline exports
+		count, _, err := leb128.DecodeUint32(r)
+		if err != nil {
+			return err
+		}
+		inst.Exports = make([]CoreExport, 0, count)
+		for i := uint32(0); i < count; i++ {
+			name, err := decodeName(r)
+			if err != nil {
+				return err
+			}
+			sort, err := r.ReadByte()
+			if err != nil {
+				return err
+			}
+			n, err := d.coreCount(CoreSort(sort))
+			if err != nil {
+				return err
+			}
+			index, err := decodeIndex(r, n, "core item")
+			if err != nil {
+				return err
+			}
+			inst.Exports = append(inst.Exports, CoreExport{Name: name, Sort: CoreSort(sort), Index: index})
+		}
+	default:
+		return fmt.Errorf("invalid core instance kind: 0x%x", kind)
+	}
+	d.c.CoreInstances = append(d.c.CoreInstances, inst)
+	return nil
+}
+
+// coreCount returns the size of the index space of the core sort `sort`.
+func (d *decoder) coreCount(sort CoreSort) (int, error) {
+	switch sort {
+	case CoreSortFunc:
+		return len(d.c.CoreFuncs), nil
+	case CoreSortTable:
+		return len(d.c.CoreTables), nil
+	case CoreSortMemory:
+		return len(d.c.CoreMemories), nil
+	case CoreSortGlobal:
+		return len(d.c.CoreGlobals), nil
+	}
+	return 0, fmt.Errorf("core sort 0x%x isn't supported", byte(sort))
+}
+
+func (d *decoder) decodeInstance(r *bytes.Reader) error {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return err
+	} else if kind == 0x00 {
+		return errors.New("nested components aren't supported")
+	} else if kind != 0x01 {
+		return fmt.Errorf("invalid instance kind: 0x%x", kind)
+	}
+
+	count, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return err
+	}
+	inst := Instance{Funcs: make(map[string]uint32, count)}
+	for i := uint32(0); i < count; i++ {
+		name, err := decodeExternName(r)
+		if err != nil {
+			return err
+		}
+		if sort, err := r.ReadByte(); err != nil {
+			return err
+		} else if sort != sortFunc {
+			return fmt.Errorf("export %q: sort 0x%x isn't supported", name, sort)
+		}
+		if inst.Funcs[name], err = decodeIndex(r, len(d.c.Funcs), "func"); err != nil {
+			return err
+		}
+	}
+	d.c.Instances = append(d.c.Instances, inst)
+	return nil
+}
+
+func (d *decoder) decodeAlias(r *bytes.Reader) error {
+	sort, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	var coreSort CoreSort
+	if sort == sortCore {
+		b, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		coreSort = CoreSort(b)
+	}
+	target, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case sort == sortCore && target == 0x01: // core export
+		inst, err := decodeIndex(r, len(d.c.CoreInstances), "core instance")
+		if err != nil {
+			return err
+		}
+		name, err := decodeName(r)
+		if err != nil {
+			return err
+		}
+		return d.aliasCoreExport(coreSort, inst, name)
+	case sort == sortFunc && target == 0x00: // export
+		inst, err := decodeIndex(r, len(d.c.Instances), "instance")
+		if err != nil {
+			return err
+		}
+		name, err := decodeName(r)
+		if err != nil {
+			return err
+		}
+		f, err := d.c.instanceFunc(inst, name)
+		if err != nil {
+			return err
+		}
+		d.c.Funcs = append(d.c.Funcs, f)
+	case sort == sortType && target == 0x00: // export
+		inst, err := decodeIndex(r, len(d.c.Instances), "instance")
+		if err != nil {
+			return err
+		}
+		name, err := decodeName(r)
+		if err != nil {
+			return err
+		}
+		var t Type
+		ok := false
+		if typ := d.c.Instances[inst].Type; typ != nil {
+			t, ok = typ.Types[name]
+		}
+		if !ok {
+			return fmt.Errorf("instance[%d] doesn't export type %q", inst, name)
+		}
+		d.c.Types = append(d.c.Types, t)
+	case sort == sortType && target == 0x02: // outer
+		if ct, _, err := leb128.DecodeUint32(r); err != nil {
+			return err
+		} else if ct != 0 {
+			return fmt.Errorf("invalid outer count: %d", ct)
+		}
+		index, err := decodeIndex(r, len(d.c.Types), "type")
+		if err != nil {
+			return err
+		}
+		d.c.Types = append(d.c.Types, d.c.Types[index])
+	default:
+		return fmt.Errorf("alias of sort 0x%x with target 0x%x isn't supported", sort, target)
+	}
+	return nil
+}
+
+// aliasCoreExport appends the export `name` of the core instance `inst` to
+// the index space of `sort`. The exports of an instance defined by inline
+// exports are resolved to the items they export.
+func (d *decoder) aliasCoreExport(sort CoreSort, inst uint32, name string) error {
+	alias := CoreAlias{Instance: inst, Name: name}
+	var index *uint32
+	if exports := d.c.CoreInstances[inst].Exports; d.c.CoreInstances[inst].Args == nil {
+		for i := range exports {
+			if exports[i].Name == name && exports[i].Sort == sort {
+				index = &exports[i].Index
+				break
+			}
+		}
+		if index == nil {
+			return fmt.Errorf("core instance[%d] doesn't export %q", inst, name)
+		}
+	}
+
+	switch sort {
+	case CoreSortFunc:
+		f := CoreFunc{Alias: &alias}
+		if index != nil {
+			f = d.c.CoreFuncs[*index]
+		}
+		d.c.CoreFuncs = append(d.c.CoreFuncs, f)
+	case CoreSortTable:
+		if index != nil {
+			alias = d.c.CoreTables[*index]
+		}
+		d.c.CoreTables = append(d.c.CoreTables, alias)
+	case CoreSortMemory:
+		if index != nil {
+			alias = d.c.CoreMemories[*index]
+		}
+		d.c.CoreMemories = append(d.c.CoreMemories, alias)
+	case CoreSortGlobal:
+		if index != nil {
+			alias = d.c.CoreGlobals[*index]
+		}
+		d.c.CoreGlobals = append(d.c.CoreGlobals, alias)
+	default:
+		return fmt.Errorf("core sort 0x%x isn't supported", byte(sort))
+	}
+	return nil
+}
+
+// instanceFunc returns the function exported as `name` by the instance
+// `inst`.
+func (c *Component) instanceFunc(inst uint32, name string) (Func, error) {
+	i := &c.Instances[inst]
+	if i.Type != nil {
+		if ft, ok := i.Type.Funcs[name]; ok {
+			return Func{Type: ft, ImportInstance: i.Import, ImportName: name}, nil
+		}
+	} else if index, ok := i.Funcs[name]; ok {
+		return c.Funcs[index], nil
+	}
+	return Func{}, fmt.Errorf("instance[%d] doesn't export func %q", inst, name)
+}
+
+func (d *decoder) decodeType(r *bytes.Reader) error {
+	t, err := decodeDefType(r, d.c.Types)
+	if err != nil {
+		return err
+	}
+	d.c.Types = append(d.c.Types, t)
+	return nil
+}
+
+// decodeDefType decodes a type definition, whose type indexes are in `types`.
+func decodeDefType(r *bytes.Reader, types []Type) (Type, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return Type{}, err
+	}
+	switch {
+	case b >= byte(ValueTypeString) && b <= byte(ValueTypeBool):
+		return Type{Kind: TypeKindValue, Value: ValueType(b)}, nil
+	case b >= 0x64 && b < byte(ValueTypeString):
+		name, err := skipDefValType(r, b, types)
+		return Type{Kind: TypeKindUnsupported, Name: name}, err
+	case b == 0x40:
+		ft, err := decodeFuncType(r, types)
+		return Type{Kind: TypeKindFunc, Func: ft}, err
+	case b == 0x42:
+		it, err := decodeInstanceType(r, types)
+		return Type{Kind: TypeKindInstance, Instance: it}, err
+	case b == 0x3f:
+		if rep, err := r.ReadByte(); err != nil {
+			return Type{}, err
+		} else if rep != 0x7f {
+			return Type{}, fmt.Errorf("invalid resource representation: 0x%x", rep)
+		}
+		if _, err = decodeOptionalIndex(r); err != nil {
+			return Type{}, err
+		}
+		return Type{Kind: TypeKindUnsupported, Name: "resource"}, nil
+	case b == 0x41:
+		ct, err := decodeComponentType(r, types)
+		return Type{Kind: TypeKindComponent, Component: ct}, err
+	}
+	return Type{}, fmt.Errorf("type 0x%x isn't supported", b)
+}
+
+// skipDefValType decodes a compound value type which isn't supported, after
+// its first byte `b`, returning its name.
+func skipDefValType(r *bytes.Reader, b byte, types []Type) (string, error) {
+	var err error
+	switch b {
+	case 0x72:
+		err = decodeVec(r, func() error { return skipLabeledValType(r, types) })
+		return "record", err
+	case 0x71:
+		err = decodeVec(r, func() error {
+			if _, err := decodeName(r); err != nil {
+				return err
+			}
+			if err := skipOptionalValType(r, types); err != nil {
+				return err
+			}
+			_, err := decodeOptionalIndex(r) // refines
+			return err
+		})
+		return "variant", err
+	case 0x70:
+		_, err = decodeValType(r, types)
+		return "list", err
+	case 0x6f:
+		err = decodeVec(r, func() error {
+			_, err := decodeValType(r, types)
+			return err
+		})
+		return "tuple", err
+	case 0x6e, 0x6d:
+		err = decodeVec(r, func() error {
+			_, err := decodeName(r)
+			return err
+		})
+		if b == 0x6e {
+			return "flags", err
+		}
+		return "enum", err
+	case 0x6b:
+		_, err = decodeValType(r, types)
+		return "option", err
+	case 0x6a:
+		if err = skipOptionalValType(r, types); err == nil {
+			err = skipOptionalValType(r, types)
+		}
+		return "result", err
+	case 0x69, 0x68:
+		_, err = decodeIndex(r, len(types), "type")
+		if b == 0x69 {
+			return "own", err
+		}
+		return "borrow", err
+	case 0x67:
+		if _, err = decodeValType(r, types); err == nil {
+			_, _, err = leb128.DecodeUint32(r)
+		}
+		return "list", err
+	case 0x66, 0x65:
+		err = skipOptionalValType(r, types)
+		if b == 0x66 {
+			return "stream", err
+		}
+		return "future", err
+	case 0x64:
+		return "error-context", nil
+	}
+	return "", fmt.Errorf("invalid value type: 0x%x", b)
+}
+
+func skipLabeledValType(r *bytes.Reader, types []Type) error {
+	if _, err := decodeName(r); err != nil {
+		return err
+	}
+	_, err := decodeValType(r, types)
+	return err
+}
+
+func skipOptionalValType(r *bytes.Reader, types []Type) error {
+	if present, err := r.ReadByte(); err != nil {
+		return err
+	} else if present == 0x01 {
+		_, err = decodeValType(r, types)
+		return err
+	} else if present != 0x00 {
+		return fmt.Errorf("invalid optional value type: 0x%x", present)
+	}
+	return nil
+}
+
+// decodeValType decodes a value type, which is either primitive, or the index
+// of a defined value type in `types`.
+func decodeValType(r *bytes.Reader, types []Type) (Type, error) {
+	v, _, err := leb128.DecodeInt33AsInt64(r)
+	if err != nil {
+		return Type{}, err
+	} else if v < 0 {
+		if t := ValueType(byte(v) & 0x7f); t >= ValueTypeString {
+			return Type{Kind: TypeKindValue, Value: t}, nil
+		}
+		return Type{}, fmt.Errorf("invalid value type: %d", v)
+	} else if v >= int64(len(types)) {
+		return Type{}, fmt.Errorf("type index out of range: %d", v)
+	}
+	if t := types[v]; t.Kind == TypeKindValue || t.Kind == TypeKindUnsupported {
+		return t, nil
+	}
+	return Type{}, fmt.Errorf("type[%d] isn't a value type", v)
+}
+
+func decodeFuncType(r *bytes.Reader, types []Type) (*FuncType, error) {
+	ft := &FuncType{}
+	err := decodeVec(r, func() error {
+		name, err := decodeName(r)
+		if err != nil {
+			return err
+		}
+		t, err := decodeValType(r, types)
+		if err != nil {
+			return err
+		} else if t.Kind == TypeKindUnsupported && ft.Err == nil {
+			ft.Err = fmt.Errorf("param %q has unsupported type %s", name, t.Name)
+		}
+		ft.Params = append(ft.Params, Param{Name: name, Type: t.Value})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := func(t Type) {
+		if t.Kind == TypeKindUnsupported && ft.Err == nil {
+			ft.Err = fmt.Errorf("result has unsupported type %s", t.Name)
+		}
+		ft.Results = append(ft.Results, t.Value)
+	}
+	kind, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case 0x00:
+		t, err := decodeValType(r, types)
+		if err != nil {
+			return nil, err
+		}
+		result(t)
+	case 0x01: // named results, which are empty since they were removed.
+		err = decodeVec(r, func() error {
+			if _, err := decodeName(r); err != nil {
+				return err
+			}
+			t, err := decodeValType(r, types)
+			if err != nil {
+				return err
+			}
+			result(t)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid result list: 0x%x", kind)
+	}
+	return ft, nil
+}
+
+// decodeInstanceType decodes the declarations of an instance type, which
+// have their own type index space, where outer aliases refer to `outer`.
+func decodeInstanceType(r *bytes.Reader, outer []Type) (*InstanceType, error) {
+	it := &InstanceType{Funcs: map[string]*FuncType{}, Types: map[string]Type{}}
+	var types []Type
+	err := decodeVec(r, func() error {
+		kind, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case 0x00:
+			return errors.New("core types aren't supported")
+		case 0x01:
+			t, err := decodeDefType(r, types)
+			if err != nil {
+				return err
+			}
+			types = append(types, t)
+		case 0x02:
+			var b [3]byte
+			if _, err = io.ReadFull(r, b[:]); err != nil {
+				return err
+			} else if b[0] != sortType || b[1] != 0x02 || b[2] != 0x01 {
+				return fmt.Errorf("alias 0x%x isn't supported in instance types", b)
+			}
+			index, err := decodeIndex(r, len(outer), "outer type")
+			if err != nil {
+				return err
+			}
+			types = append(types, outer[index])
+		case 0x04:
+			name, err := decodeExternName(r)
+			if err != nil {
+				return err
+			}
+			desc, err := decodeExternDesc(r)
+			if err != nil {
+				return err
+			}
+			switch {
+			case desc.sort == sortFunc:
+				if desc.index >= uint32(len(types)) || types[desc.index].Kind != TypeKindFunc {
+					return fmt.Errorf("export %q: invalid func type: %d", name, desc.index)
+				}
+				it.Funcs[name] = types[desc.index].Func
+			case desc.sort == sortType:
+				t := Type{Kind: TypeKindUnsupported, Name: "resource"}
+				if !desc.subResource {
+					if desc.index >= uint32(len(types)) {
+						return fmt.Errorf("export %q: type index out of range: %d", name, desc.index)
+					}
+					t = types[desc.index]
+				}
+				types = append(types, t)
+				it.Types[name] = t
+			default:
+				return fmt.Errorf("export %q: sort 0x%x isn't supported", name, desc.sort)
+			}
+		default:
+			return fmt.Errorf("invalid instance type declaration: 0x%x", kind)
+		}
+		return nil
+	})
+	return it, err
+}
+
+// decodeComponentType decodes the declarations of a component type, which
+// have their own type and instance index spaces, where outer aliases refer to
+// `outer`.
+func decodeComponentType(r *bytes.Reader, outer []Type) (*ComponentType, error) {
+	ct := &ComponentType{Imports: map[string]Type{}, Exports: map[string]Type{}}
+	var types []Type
+	var instances []*InstanceType
+	err := decodeVec(r, func() error {
+		kind, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case 0x00:
+			return errors.New("core types aren't supported")
+		case 0x01:
+			t, err := decodeDefType(r, types)
+			if err != nil {
+				return err
+			}
+			types = append(types, t)
+		case 0x02:
+			t, err := decodeTypeAlias(r, outer, instances)
+			if err != nil {
+				return err
+			}
+			types = append(types, t)
+		case 0x03, 0x04:
+			name, err := decodeExternName(r)
+			if err != nil {
+				return err
+			}
+			desc, err := decodeExternDesc(r)
+			if err != nil {
+				return err
+			}
+			var t Type
+			switch desc.sort {
+			case sortFunc, sortInstance, sortComponent:
+				if desc.index >= uint32(len(types)) {
+					return fmt.Errorf("%q: type index out of range: %d", name, desc.index)
+				}
+				t = types[desc.index]
+				if expected := externTypeKind(desc.sort); t.Kind != expected {
+					return fmt.Errorf("%q: invalid type: %d", name, desc.index)
+				}
+				if t.Kind == TypeKindInstance {
+					instances = append(instances, t.Instance)
+				}
+			case sortType:
+				t = Type{Kind: TypeKindUnsupported, Name: "resource"}
+				if !desc.subResource {
+					if desc.index >= uint32(len(types)) {
+						return fmt.Errorf("%q: type index out of range: %d", name, desc.index)
+					}
+					t = types[desc.index]
+				}
+				types = append(types, t)
+				return nil
+			default: // e.g. a core module, which doesn't describe functions.
+				return nil
+			}
+			if kind == 0x03 {
+				ct.Imports[name] = t
+			} else {
+				ct.Exports[name] = t
+			}
+		default:
+			return fmt.Errorf("invalid component type declaration: 0x%x", kind)
+		}
+		return nil
+	})
+	return ct, err
+}
+
+// decodeTypeAlias decodes an alias of a type in a component type, which is
+// either exported by one of its `instances`, or in the enclosing `outer`
+// type index space.
+func decodeTypeAlias(r *bytes.Reader, outer []Type, instances []*InstanceType) (Type, error) {
+	var b [2]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return Type{}, err
+	} else if b[0] != sortType {
+		return Type{}, fmt.Errorf("alias of sort 0x%x isn't supported in component types", b[0])
+	}
+	switch b[1] {
+	case 0x00: // export of an instance
+		index, err := decodeIndex(r, len(instances), "instance")
+		if err != nil {
+			return Type{}, err
+		}
+		name, err := decodeName(r)
+		if err != nil {
+			return Type{}, err
+		}
+		t, ok := instances[index].Types[name]
+		if !ok {
+			return Type{}, fmt.Errorf("instance[%d] doesn't export type %q", index, name)
+		}
+		return t, nil
+	case 0x02: // outer
+		if count, _, err := leb128.DecodeUint32(r); err != nil {
+			return Type{}, err
+		} else if count != 1 {
+			return Type{}, fmt.Errorf("outer alias of count %d isn't supported", count)
+		}
+		index, err := decodeIndex(r, len(outer), "outer type")
+		if err != nil {
+			return Type{}, err
+		}
+		return outer[index], nil
+	}
+	return Type{}, fmt.Errorf("alias target 0x%x isn't supported in component types", b[1])
+}
+
+// externTypeKind returns the kind of the type of an import or an export of
+// the given sort.
+func externTypeKind(sort byte) TypeKind {
+	switch sort {
+	case sortFunc:
+		return TypeKindFunc
+	case sortInstance:
+		return TypeKindInstance
+	}
+	return TypeKindComponent
+}
+
+// externDesc is the type of an import or an export.
+type externDesc struct {
+	sort  byte
+	index uint32
+	// subResource is set for a type bound to a fresh resource.
+	subResource bool
+}
+
+func decodeExternDesc(r *bytes.Reader) (desc externDesc, err error) {
+	if desc.sort, err = r.ReadByte(); err != nil {
+		return
+	}
+	switch desc.sort {
+	case sortCore:
+		if b, err := r.ReadByte(); err != nil {
+			return desc, err
+		} else if CoreSort(b) != CoreSortModule {
+			return desc, fmt.Errorf("invalid core sort: 0x%x", b)
+		}
+	case sortType:
+		var bound byte
+		if bound, err = r.ReadByte(); err != nil {
+			return
+		} else if bound == 0x01 {
+			desc.subResource = true
+			return
+		} else if bound != 0x00 {
+			return desc, fmt.Errorf("invalid type bound: 0x%x", bound)
+		}
+	case sortValue:
+		return desc, errors.New("values aren't supported")
+	case sortFunc, sortComponent, sortInstance:
+	default:
+		return desc, fmt.Errorf("invalid sort: 0x%x", desc.sort)
+	}
+	desc.index, _, err = leb128.DecodeUint32(r)
+	return
+}
+
+func (d *decoder) decodeImport(r *bytes.Reader) error {
+	name, err := decodeExternName(r)
+	if err != nil {
+		return err
+	}
+	desc, err := decodeExternDesc(r)
+	if err != nil {
+		return err
+	}
+
+	c := d.c
+	switch {
+	case desc.sort == sortFunc:
+		if desc.index >= uint32(len(c.Types)) || c.Types[desc.index].Kind != TypeKindFunc {
+			return fmt.Errorf("import %q: invalid func type: %d", name, desc.index)
+		}
+		c.Funcs = append(c.Funcs, Func{Type: c.Types[desc.index].Func, ImportName: name})
+	case desc.sort == sortInstance:
+		if desc.index >= uint32(len(c.Types)) || c.Types[desc.index].Kind != TypeKindInstance {
+			return fmt.Errorf("import %q: invalid instance type: %d", name, desc.index)
+		}
+		c.Instances = append(c.Instances, Instance{Import: name, Type: c.Types[desc.index].Instance})
+	case desc.sort == sortType:
+		t := Type{Kind: TypeKindUnsupported, Name: "resource"}
+		if !desc.subResource {
+			if desc.index >= uint32(len(c.Types)) {
+				return fmt.Errorf("import %q: type index out of range: %d", name, desc.index)
+			}
+			t = c.Types[desc.index]
+		}
+		c.Types = append(c.Types, t)
+	default:
+		return fmt.Errorf("import %q: sort 0x%x isn't supported", name, desc.sort)
+	}
+	return nil
+}
+
+func (d *decoder) decodeExport(r *bytes.Reader) error {
+	name, err := decodeExternName(r)
+	if err != nil {
+		return err
+	}
+	sort, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	index, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return err
+	}
+	// The optional type ascription doesn't change the export.
+	if present, err := r.ReadByte(); err != nil {
+		return err
+	} else if present == 0x01 {
+		if _, err = decodeExternDesc(r); err != nil {
+			return err
+		}
+	} else if present != 0x00 {
+		return fmt.Errorf("invalid optional type of export %q: 0x%x", name, present)
+	}
+
+	c := d.c
+	switch sort {
+	case sortFunc:
+		if index >= uint32(len(c.Funcs)) {
+			return fmt.Errorf("export %q: func index out of range: %d", name, index)
+		}
+		c.FuncExports[name] = index
+		c.Funcs = append(c.Funcs, c.Funcs[index])
+	case sortInstance:
+		if index >= uint32(len(c.Instances)) {
+			return fmt.Errorf("export %q: instance index out of range: %d", name, index)
+		}
+		c.InstanceExports[name] = index
+		c.Instances = append(c.Instances, c.Instances[index])
+	case sortType:
+		if index >= uint32(len(c.Types)) {
+			return fmt.Errorf("export %q: type index out of range: %d", name, index)
+		}
+		c.Types = append(c.Types, c.Types[index])
+	default:
+		return fmt.Errorf("export %q: sort 0x%x isn't supported", name, sort)
+	}
+	return nil
+}
+
+func (d *decoder) decodeCanon(r *bytes.Reader) error {
+	var b [2]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return err
+	}
+	c := d.c
+	switch {
+	case b == [2]byte{0x00, 0x00}: // lift
+		f, err := decodeIndex(r, len(c.CoreFuncs), "core func")
+		if err != nil {
+			return err
+		}
+		canon, err := d.decodeCanonOpts(r, f)
+		if err != nil {
+			return err
+		}
+		index, err := decodeIndex(r, len(c.Types), "type")
+		if err != nil {
+			return err
+		} else if c.Types[index].Kind != TypeKindFunc {
+			return fmt.Errorf("type[%d] isn't a func type", index)
+		}
+		c.Funcs = append(c.Funcs, Func{Type: c.Types[index].Func, Lift: canon})
+	case b == [2]byte{0x01, 0x00}: // lower
+		f, err := decodeIndex(r, len(c.Funcs), "func")
+		if err != nil {
+			return err
+		}
+		canon, err := d.decodeCanonOpts(r, f)
+		if err != nil {
+			return err
+		}
+		c.CoreFuncs = append(c.CoreFuncs, CoreFunc{Lower: canon})
+	default:
+		return fmt.Errorf("canonical built-in 0x%x isn't supported", b[0])
+	}
+	return nil
+}
+
+func (d *decoder) decodeCanonOpts(r *bytes.Reader, f uint32) (*Canon, error) {
+	canon := &Canon{Func: f}
+	err := decodeVec(r, func() error {
+		opt, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		switch opt {
+		case 0x00, 0x01, 0x02:
+			canon.StringEncoding = opt
+		case 0x03:
+			index, err := decodeIndex(r, len(d.c.CoreMemories), "core memory")
+			if err != nil {
+				return err
+			}
+			canon.Memory = &index
+		case 0x04, 0x05:
+			index, err := decodeIndex(r, len(d.c.CoreFuncs), "core func")
+			if err != nil {
+				return err
+			}
+			if opt == 0x04 {
+				canon.Realloc = &index
+			} else {
+				canon.PostReturn = &index
+			}
+		default:
+			return fmt.Errorf("canonical option 0x%x isn't supported", opt)
+		}
+		return nil
+	})
+	return canon, err
+}
+
+// decodeVec calls `decode` for each item of a vector.
+func decodeVec(r *bytes.Reader, decode func() error) error {
+	count, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < count; i++ {
+		if err = decode(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeIndex decodes an index, which must be less than `count`.
+func decodeIndex(r *bytes.Reader, count int, kind string) (uint32, error) {
+	index, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return 0, err
+	} else if uint64(index) >= uint64(count) {
+		return 0, fmt.Errorf("%s index out of range: %d", kind, index)
+	}
+	return index, nil
+}
+
+// decodeOptionalIndex decodes an optional index, returning nil if absent.
+func decodeOptionalIndex(r *bytes.Reader) (*uint32, error) {
+	if present, err := r.ReadByte(); err != nil {
+		return nil, err
+	} else if present == 0x00 {
+		return nil, nil
+	} else if present != 0x01 {
+		return nil, fmt.Errorf("invalid optional index: 0x%x", present)
+	}
+	index, _, err := leb128.DecodeUint32(r)
+	return &index, err
+}
+
+func decodeName(r *bytes.Reader) (string, error) {
+	size, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return "", err
+	} else if uint64(size) > uint64(r.Len()) {
+		return "", io.ErrUnexpectedEOF
+	}
+	buf := make([]byte, size)
+	_, _ = r.Read(buf)
+	if !utf8.Valid(buf) {
+		return "", errors.New("name isn't valid UTF-8")
+	}
+	return string(buf), nil
+}
+
+// decodeExternName decodes the name of an import or an export, ignoring the
+// URL or the version suffix which may follow it.
+func decodeExternName(r *bytes.Reader) (string, error) {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	name, err := decodeName(r)
+	if err != nil {
+		return "", err
+	}
+	switch kind {
+	case 0x00:
+	case 0x01:
+		if _, err = decodeName(r); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("invalid name kind: 0x%x", kind)
+	}
+	return name, nil
+}
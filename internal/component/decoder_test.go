@@ -0,0 +1,97 @@
+package component
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestDecode(t *testing.T) {
+	bin := append(append([]byte{}, preamble...),
+		7, 23, 3, // type section of 3 types
+		0x72, 1, 1, 'x', 0x79, // 0: record { x: u32 }
+		0x40, 1, 1, 'r', 0, 0x00, 0x79, // 1: func(r: 0) -> u32
+		0x40, 2, 1, 'a', 0x7f, 1, 'b', 0x73, 0x01, 0x00, // 2: func(a: bool, b: string)
+		10, 6, 1, 0x00, 1, 'f', 0x01, 2, // import "f" of type 2
+		11, 7, 1, 0x00, 1, 'g', 0x01, 0, 0x00, // export it as "g"
+	)
+	c, err := Decode(bin)
+	require.NoError(t, err)
+
+	require.Equal(t, TypeKindUnsupported, c.Types[0].Kind)
+	require.Equal(t, "record", c.Types[0].Name)
+	require.EqualError(t, c.Types[1].Func.Err, `param "r" has unsupported type record`)
+	_, _, err = c.Types[1].Func.CoreType(true)
+	require.EqualError(t, err, `param "r" has unsupported type record`)
+
+	ft := c.Types[2].Func
+	require.Equal(t, "func(a: bool, b: string)", ft.String())
+	require.True(t, ft.RequiresMemory())
+	require.True(t, ft.RequiresRealloc(true))
+	require.False(t, ft.RequiresRealloc(false))
+
+	require.Equal(t, []Func{{Type: ft, ImportName: "f"}, {Type: ft, ImportName: "f"}}, c.Funcs)
+	require.Equal(t, map[string]uint32{"g": 0}, c.FuncExports)
+}
+
+func TestDecode_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []byte
+		expectedErr string
+	}{
+		{
+			name:        "core module",
+			input:       []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00},
+			expectedErr: "invalid magic number or version",
+		},
+		{
+			name:        "section too large",
+			input:       []byte{7, 2, 0},
+			expectedErr: "section 7: unexpected EOF",
+		},
+		{
+			name:        "section size mismatch",
+			input:       []byte{7, 2, 0, 0},
+			expectedErr: "section 7: invalid size: 1 bytes left",
+		},
+		{
+			name:        "nested component",
+			input:       []byte{4, 0},
+			expectedErr: "section 4: nested components aren't supported",
+		},
+		{
+			name:        "func type index out of range",
+			input:       []byte{10, 6, 1, 0x00, 1, 'f', 0x01, 0},
+			expectedErr: `section 10: item[0]: import "f": invalid func type: 0`,
+		},
+		{
+			name:        "core instance of unknown module",
+			input:       []byte{2, 3, 1, 0x00, 0},
+			expectedErr: "section 2: item[0]: core module index out of range: 0",
+		},
+		{
+			name:        "alias of unknown core instance",
+			input:       []byte{6, 6, 1, 0x00, 0x00, 0x01, 0, 0},
+			expectedErr: "section 6: item[0]: core instance index out of range: 0",
+		},
+		{
+			name:        "resource built-in",
+			input:       []byte{8, 3, 1, 0x02, 0},
+			expectedErr: "section 8: item[0]: canonical built-in 0x2 isn't supported",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			input := tc.input
+			if !bytes.HasPrefix(input, preamble[:4]) {
+				input = append(append([]byte{}, preamble...), input...)
+			}
+			_, err := Decode(input)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
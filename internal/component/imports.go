@@ -0,0 +1,136 @@
+package component
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/tetratelabs/wazero/internal/leb128"
+)
+
+// RenameImports returns a copy of the core module `bin` whose imports are
+// renamed by `rename`, e.g. to the names of the module instances satisfying
+// them. Other sections are copied as is, so the module isn't validated.
+func RenameImports(bin []byte, rename func(module, name string) (string, string, error)) ([]byte, error) {
+	if len(bin) < 8 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	ret := append([]byte{}, bin[:8]...) // magic and version
+	r := bytes.NewReader(bin[8:])
+	for r.Len() > 0 {
+		id, _ := r.ReadByte()
+		size, _, err := leb128.DecodeUint32(r)
+		if err != nil {
+			return nil, fmt.Errorf("get size of section %d: %v", id, err)
+		} else if uint64(size) > uint64(r.Len()) {
+			return nil, fmt.Errorf("section %d: %w", id, io.ErrUnexpectedEOF)
+		}
+		payload := make([]byte, size)
+		_, _ = r.Read(payload)
+
+		if id == 2 { // import
+			if payload, err = renameImports(payload, rename); err != nil {
+				return nil, fmt.Errorf("import section: %w", err)
+			}
+		}
+		ret = append(ret, id)
+		ret = append(ret, leb128.EncodeUint32(uint32(len(payload)))...)
+		ret = append(ret, payload...)
+	}
+	return ret, nil
+}
+
+func renameImports(payload []byte, rename func(module, name string) (string, string, error)) ([]byte, error) {
+	r := bytes.NewReader(payload)
+	count, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	ret := leb128.EncodeUint32(count)
+	for i := uint32(0); i < count; i++ {
+		module, err := decodeName(r)
+		if err != nil {
+			return nil, err
+		}
+		name, err := decodeName(r)
+		if err != nil {
+			return nil, err
+		}
+		start := len(payload) - r.Len()
+		if err = skipImportDesc(r); err != nil {
+			return nil, fmt.Errorf("import[%d]: %w", i, err)
+		}
+		desc := payload[start : len(payload)-r.Len()]
+
+		if module, name, err = rename(module, name); err != nil {
+			return nil, err
+		}
+		ret = appendName(ret, module)
+		ret = appendName(ret, name)
+		ret = append(ret, desc...)
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("invalid size: %d bytes left", r.Len())
+	}
+	return ret, nil
+}
+
+func appendName(dst []byte, name string) []byte {
+	dst = append(dst, leb128.EncodeUint32(uint32(len(name)))...)
+	return append(dst, name...)
+}
+
+// skipImportDesc decodes the description of an import, as it may include
+// typed references or 64-bit limits.
+func skipImportDesc(r *bytes.Reader) error {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case 0x00: // func
+		_, _, err = leb128.DecodeUint32(r)
+	case 0x01: // table
+		if err = skipCoreValueType(r); err == nil {
+			err = skipLimits(r)
+		}
+	case 0x02: // memory
+		err = skipLimits(r)
+	case 0x03: // global
+		if err = skipCoreValueType(r); err == nil {
+			_, err = r.ReadByte() // mutability
+		}
+	case 0x04: // tag
+		if _, err = r.ReadByte(); err == nil {
+			_, _, err = leb128.DecodeUint32(r)
+		}
+	default:
+		err = fmt.Errorf("invalid import kind: 0x%x", kind)
+	}
+	return err
+}
+
+func skipCoreValueType(r *bytes.Reader) error {
+	b, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if b == 0x63 || b == 0x64 { // typed reference
+		_, _, err = leb128.DecodeInt33AsInt64(r)
+	}
+	return err
+}
+
+func skipLimits(r *bytes.Reader) error {
+	flags, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if _, _, err = leb128.DecodeUint64(r); err != nil {
+		return err
+	}
+	if flags&0x01 != 0 {
+		_, _, err = leb128.DecodeUint64(r)
+	}
+	return err
+}
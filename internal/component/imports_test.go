@@ -0,0 +1,41 @@
+package component
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/testing/binaryencoding"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+func TestRenameImports(t *testing.T) {
+	module := &wasm.Module{
+		TypeSection: []wasm.FunctionType{{}},
+		ImportSection: []wasm.Import{
+			{Module: "env", Name: "memory", Type: wasm.ExternTypeMemory, DescMem: &wasm.Memory{Min: 1, Max: 2, IsMaxEncoded: true}},
+			{Module: "env", Name: "f", Type: wasm.ExternTypeFunc, DescFunc: 0},
+			{Module: "env", Name: "g", Type: wasm.ExternTypeGlobal, DescGlobal: wasm.GlobalType{ValType: api.ValueTypeI64}},
+			{Module: "env", Name: "t", Type: wasm.ExternTypeTable, DescTable: wasm.Table{Type: wasm.RefTypeFuncref}},
+		},
+		FunctionSection: []wasm.Index{0},
+		CodeSection:     []wasm.Code{{Body: []byte{wasm.OpcodeEnd}}},
+		ExportSection:   []wasm.Export{{Name: "run", Type: wasm.ExternTypeFunc, Index: 1}},
+	}
+	bin, err := RenameImports(binaryencoding.EncodeModule(module), func(module, name string) (string, string, error) {
+		return "instance/" + module, name + "2", nil
+	})
+	require.NoError(t, err)
+
+	for i := range module.ImportSection {
+		imp := &module.ImportSection[i]
+		imp.Module, imp.Name = "instance/env", imp.Name+"2"
+	}
+	require.Equal(t, binaryencoding.EncodeModule(module), bin)
+
+	_, err = RenameImports(bin, func(module, name string) (string, string, error) {
+		return "", "", errors.New("no")
+	})
+	require.EqualError(t, err, "import section: no")
+}
@@ -0,0 +1,191 @@
+package component
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// NameParams names the params of the functions of the core module `m` from
+// its WIT metadata, i.e. the "component-type" custom sections embedded by
+// bindings generators such as wit-bindgen, which describe its world.
+//
+// Functions already named by the name section are left as is, as are those
+// whose params don't each lower to exactly one core param, e.g. strings.
+// Metadata which can't be decoded is ignored, as it is only informative.
+func NameParams(m *wasm.Module) {
+	for _, c := range m.CustomSections {
+		if c.Name != "component-type" && !strings.HasPrefix(c.Name, "component-type:") {
+			continue
+		}
+		worlds, err := decodeWorlds(c.Data)
+		if err != nil {
+			continue
+		}
+		for _, w := range worlds {
+			nameWorldParams(m, w)
+		}
+	}
+}
+
+// decodeWorlds decodes the worlds described by the component `bin`, which
+// are its component types, or the ones they export, e.g. when wrapped in a
+// package.
+func decodeWorlds(bin []byte) (worlds []*ComponentType, err error) {
+	if !IsComponent(bin) {
+		return nil, errors.New("invalid magic number or version")
+	}
+	r := bytes.NewReader(bin[len(preamble):])
+	var types []Type
+	for {
+		id, err := r.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		size, _, err := leb128.DecodeUint32(r)
+		if err != nil {
+			return nil, fmt.Errorf("get size of section %d: %v", id, err)
+		} else if uint64(size) > uint64(r.Len()) {
+			return nil, fmt.Errorf("section %d: %w", id, io.ErrUnexpectedEOF)
+		}
+		payload := make([]byte, size)
+		_, _ = r.Read(payload)
+		if id != sectionIDType {
+			continue // e.g. the export of the world, which doesn't matter.
+		}
+
+		sr := bytes.NewReader(payload)
+		err = decodeVec(sr, func() error {
+			t, err := decodeDefType(sr, types)
+			types = append(types, t)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("section %d: %w", id, err)
+		}
+	}
+
+	for _, t := range types {
+		if t.Kind != TypeKindComponent {
+			continue
+		}
+		nested := false
+		for _, e := range t.Component.Exports {
+			if e.Kind == TypeKindComponent {
+				worlds, nested = append(worlds, e.Component), true
+			}
+		}
+		if !nested {
+			worlds = append(worlds, t.Component)
+		}
+	}
+	return worlds, nil
+}
+
+// nameWorldParams names the params of the functions of `m` which lower the
+// imports or lift the exports of the world `w`, according to the canonical
+// ABI naming of core functions.
+func nameWorldParams(m *wasm.Module, w *ComponentType) {
+	for name, t := range w.Imports {
+		switch t.Kind {
+		case TypeKindFunc:
+			nameFuncParams(m, importedFunc(m, "$root", name), t.Func)
+		case TypeKindInstance:
+			for f, ft := range t.Instance.Funcs {
+				nameFuncParams(m, importedFunc(m, name, f), ft)
+			}
+		}
+	}
+	for name, t := range w.Exports {
+		switch t.Kind {
+		case TypeKindFunc:
+			nameFuncParams(m, exportedFunc(m, name), t.Func)
+		case TypeKindInstance:
+			for f, ft := range t.Instance.Funcs {
+				nameFuncParams(m, exportedFunc(m, name+"#"+f), ft)
+			}
+		}
+	}
+}
+
+// nameFuncParams adds the names of the params of `ft` to the name section of
+// `m` for the function at `index`, unless it can't or already has some.
+func nameFuncParams(m *wasm.Module, index *wasm.Index, ft *FuncType) {
+	if index == nil || ft.Err != nil || len(ft.flatParams()) != len(ft.Params) {
+		return
+	}
+	if typ := funcType(m, *index); typ == nil || len(typ.Params) != len(ft.Params) {
+		return
+	}
+
+	if m.NameSection == nil {
+		m.NameSection = &wasm.NameSection{}
+	}
+	for i := range m.NameSection.LocalNames {
+		if m.NameSection.LocalNames[i].Index == *index {
+			return
+		}
+	}
+	names := make(wasm.NameMap, 0, len(ft.Params))
+	for i, p := range ft.Params {
+		names = append(names, wasm.NameAssoc{Index: wasm.Index(i), Name: p.Name})
+	}
+	localNames := append(m.NameSection.LocalNames, wasm.NameMapAssoc{Index: *index, NameMap: names})
+	sort.Slice(localNames, func(i, j int) bool { return localNames[i].Index < localNames[j].Index })
+	m.NameSection.LocalNames = localNames
+}
+
+// importedFunc returns the index of the function imported as `module` and
+// `name`, or nil if there is none.
+func importedFunc(m *wasm.Module, module, name string) *wasm.Index {
+	for i := range m.ImportSection {
+		if imp := &m.ImportSection[i]; imp.Type == wasm.ExternTypeFunc && imp.Module == module && imp.Name == name {
+			return &imp.IndexPerType
+		}
+	}
+	return nil
+}
+
+// exportedFunc returns the index of the function exported as `name`, or nil
+// if there is none.
+func exportedFunc(m *wasm.Module, name string) *wasm.Index {
+	for i := range m.ExportSection {
+		if exp := &m.ExportSection[i]; exp.Type == wasm.ExternTypeFunc && exp.Name == name {
+			return &exp.Index
+		}
+	}
+	return nil
+}
+
+// funcType returns the type of the function at `index`, or nil if it is out
+// of range.
+func funcType(m *wasm.Module, index wasm.Index) *wasm.FunctionType {
+	typeIndex := wasm.Index(len(m.TypeSection))
+	if index < m.ImportFunctionCount {
+		if imp := importedFuncAt(m, index); imp != nil {
+			typeIndex = imp.DescFunc
+		}
+	} else if i := index - m.ImportFunctionCount; i < wasm.Index(len(m.FunctionSection)) {
+		typeIndex = m.FunctionSection[i]
+	}
+	if typeIndex >= wasm.Index(len(m.TypeSection)) {
+		return nil
+	}
+	return &m.TypeSection[typeIndex]
+}
+
+// importedFuncAt returns the import of the function at `index`.
+func importedFuncAt(m *wasm.Module, index wasm.Index) *wasm.Import {
+	for i := range m.ImportSection {
+		if imp := &m.ImportSection[i]; imp.Type == wasm.ExternTypeFunc && imp.IndexPerType == index {
+			return imp
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package component
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+func TestNameParams(t *testing.T) {
+	world := concat([]byte{0x41, 8}, // component type of 8 declarations
+		// 0: func(a: u32, b: f32) -> s32
+		[]byte{0x01, 0x40, 2}, name("a"), []byte{0x79}, name("b"), []byte{0x76, 0x00, 0x7a},
+		// import "log" of type 0
+		[]byte{0x03, 0x00}, name("log"), []byte{0x01, 0},
+		// 1: instance type of func(x: s64) exported as "tick"
+		[]byte{0x01, 0x42, 2},
+		[]byte{0x01, 0x40, 1}, name("x"), []byte{0x78, 0x01, 0},
+		[]byte{0x04, 0x00}, name("tick"), []byte{0x01, 0},
+		// import "ns:pkg/clock" of type 1
+		[]byte{0x03, 0x00}, name("ns:pkg/clock"), []byte{0x05, 1},
+		// 2: func(msg: string)
+		[]byte{0x01, 0x40, 1}, name("msg"), []byte{0x73, 0x01, 0},
+		// export "print" of type 2, "add" of type 0 and "ns:pkg/api" of type 1
+		[]byte{0x04, 0x00}, name("print"), []byte{0x01, 2},
+		[]byte{0x04, 0x00}, name("add"), []byte{0x01, 0},
+		[]byte{0x04, 0x00}, name("ns:pkg/api"), []byte{0x05, 1},
+	)
+	// The world is wrapped in a package, which exports it.
+	pkg := concat([]byte{1, 0x41, 2}, // type section of a component type of 2 declarations
+		[]byte{0x01}, world,
+		[]byte{0x04, 0x00}, name("ns:pkg/world"), []byte{0x04, 0},
+	)
+	metadata := concat(preamble,
+		[]byte{sectionIDCustom}, sized(name("wit-component-encoding"), []byte{4, 0}),
+		[]byte{sectionIDType}, sized(pkg),
+		[]byte{sectionIDExport}, sized([]byte{1, 0x00}, name("world"), []byte{0x03, 0, 0x00}),
+	)
+
+	i32, i64, f32 := wasm.ValueTypeI32, wasm.ValueTypeI64, wasm.ValueTypeF32
+	m := &wasm.Module{
+		TypeSection: []wasm.FunctionType{
+			{Params: []wasm.ValueType{i32, f32}, Results: []wasm.ValueType{i32}},
+			{Params: []wasm.ValueType{i64}},
+			{Params: []wasm.ValueType{i32, i32}},
+		},
+		ImportSection: []wasm.Import{
+			{Type: wasm.ExternTypeFunc, Module: "$root", Name: "log", DescFunc: 0, IndexPerType: 0},
+			{Type: wasm.ExternTypeFunc, Module: "ns:pkg/clock", Name: "tick", DescFunc: 1, IndexPerType: 1},
+		},
+		ImportFunctionCount: 2,
+		FunctionSection:     []wasm.Index{0, 1, 2},
+		ExportSection: []wasm.Export{
+			{Type: wasm.ExternTypeFunc, Name: "add", Index: 2},
+			{Type: wasm.ExternTypeFunc, Name: "ns:pkg/api#tick", Index: 3},
+			{Type: wasm.ExternTypeFunc, Name: "print", Index: 4},
+		},
+		NameSection: &wasm.NameSection{
+			LocalNames: wasm.IndirectNameMap{{Index: 3, NameMap: wasm.NameMap{{Index: 0, Name: "now"}}}},
+		},
+		CustomSections: []*wasm.CustomSection{{Name: "component-type:world", Data: metadata}},
+	}
+
+	NameParams(m)
+
+	require.Equal(t, wasm.IndirectNameMap{
+		{Index: 0, NameMap: wasm.NameMap{{Index: 0, Name: "a"}, {Index: 1, Name: "b"}}},
+		{Index: 1, NameMap: wasm.NameMap{{Index: 0, Name: "x"}}},
+		{Index: 2, NameMap: wasm.NameMap{{Index: 0, Name: "a"}, {Index: 1, Name: "b"}}},
+		{Index: 3, NameMap: wasm.NameMap{{Index: 0, Name: "now"}}}, // from the name section
+	}, m.NameSection.LocalNames)
+}
+
+func TestNameParams_Invalid(t *testing.T) {
+	m := &wasm.Module{
+		CustomSections: []*wasm.CustomSection{{Name: "component-type", Data: []byte("pooh")}},
+	}
+	NameParams(m)
+	require.Nil(t, m.NameSection)
+}
+
+func concat(parts ...[]byte) (ret []byte) {
+	for _, p := range parts {
+		ret = append(ret, p...)
+	}
+	return
+}
+
+func name(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+// sized prefixes the contents of a section with its size.
+func sized(parts ...[]byte) []byte {
+	contents := concat(parts...)
+	return append([]byte{byte(len(contents))}, contents...)
+}
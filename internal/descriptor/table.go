@@ -59,6 +59,10 @@ func (t *Table[Key, Item]) grow(n int) {
 // Insert inserts the given item to the table, returning the key that it is
 // mapped to or false if the table was full.
 //
+// Keys are allocated lowest-first, so a key removed with Delete is reused by
+// the next Insert. This matches POSIX, which requires open to return the
+// lowest file descriptor not currently open.
+//
 // The method does not perform deduplication, it is possible for the same item
 // to be inserted multiple times, each insertion will return a different key.
 func (t *Table[Key, Item]) Insert(item Item) (key Key, ok bool) {
@@ -72,9 +76,12 @@ insert:
 			shift := bits.TrailingZeros64(^mask)
 			index += offset
 			key = Key(index)*64 + Key(shift)
+			if key < 0 { // overflow
+				return 0, false
+			}
 			t.items[key] = item
 			t.masks[index] = mask | uint64(1<<shift)
-			return key, key >= 0
+			return key, true
 		}
 	}
 
@@ -109,8 +116,8 @@ func (t *Table[Key, Item]) InsertAt(item Item, key Key) bool {
 	if key < 0 {
 		return false
 	}
-	if diff := int(key) - t.Len(); diff > 0 {
-		t.grow(diff)
+	if n := int(key)/64 + 1; n > len(t.masks) {
+		t.grow(n)
 	}
 	index := uint(key) / 64
 	shift := uint(key) % 64
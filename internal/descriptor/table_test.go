@@ -98,6 +98,56 @@ func TestFileTable(t *testing.T) {
 	}
 }
 
+// TestFileTable_Reuse ensures the lowest free key is reused after Delete, as
+// POSIX requires for file descriptors.
+func TestFileTable_Reuse(t *testing.T) {
+	table := new(sys.FileTable)
+	entry := new(sys.FileEntry)
+
+	// Fill more than one mask, so that reuse crosses a mask boundary.
+	for i := int32(0); i < 130; i++ {
+		k, ok := table.Insert(entry)
+		require.True(t, ok)
+		require.Equal(t, i, k)
+	}
+
+	table.Delete(100)
+	table.Delete(3)
+	table.Delete(70)
+
+	for _, want := range []int32{3, 70, 100, 130} {
+		k, ok := table.Insert(entry)
+		require.True(t, ok)
+		require.Equal(t, want, k)
+	}
+	require.Equal(t, 131, table.Len())
+}
+
+// TestFileTable_InsertAt ensures InsertAt grows the table when the key is past
+// its capacity, even when the table is full.
+func TestFileTable_InsertAt(t *testing.T) {
+	table := new(sys.FileTable)
+	entry := &sys.FileEntry{Name: "entry"}
+
+	for i := 0; i < 64; i++ {
+		_, ok := table.Insert(new(sys.FileEntry))
+		require.True(t, ok)
+	}
+
+	for _, key := range []int32{64, 1000} {
+		require.True(t, table.InsertAt(entry, key))
+		v, ok := table.Lookup(key)
+		require.True(t, ok)
+		require.Equal(t, entry, v)
+	}
+	require.Equal(t, 66, table.Len())
+
+	// The gap is still free.
+	k, ok := table.Insert(entry)
+	require.True(t, ok)
+	require.Equal(t, int32(65), k)
+}
+
 func BenchmarkFileTableInsert(b *testing.B) {
 	table := new(sys.FileTable)
 	entry := new(sys.FileEntry)
@@ -0,0 +1,242 @@
+package emscripten
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// dsoName is the offset of the name in `struct dso` of Emscripten's
+// dynlink.c, which describes a library to load, as of Emscripten 3.1.
+const dsoName = 48
+
+// rtldDefault is the handle of RTLD_DEFAULT in Emscripten.
+const rtldDefault = 0
+
+// SideModuleLoader loads side modules into the main module, as implemented by
+// dylink.Loader.
+type SideModuleLoader interface {
+	// Main returns the main module.
+	Main() api.Module
+
+	// Load instantiates the side module `binary` with `config`.
+	Load(ctx context.Context, binary []byte, config wazero.ModuleConfig) (api.Module, error)
+
+	// Address returns the address of the symbol `name` exported by `mod`, or
+	// by any loaded module if nil.
+	Address(mod api.Module, name string) (uint32, error)
+}
+
+// DynamicLinking implements the functions of the Emscripten JS library which
+// load side modules, e.g. "_dlopen_js", with a SideModuleLoader.
+type DynamicLinking struct {
+	// Loader is nil unless the main module was instantiated with it, in
+	// which case loading fails.
+	Loader SideModuleLoader
+	// Config configures the side modules, which are anonymous.
+	Config wazero.ModuleConfig
+
+	// handles are the side modules loaded, by the address of their dso.
+	handles map[uint32]api.Module
+	// mainHandle is the address of the dso of the main module.
+	mainHandle uint32
+}
+
+// HostFunc returns the host function implementing `importName` with the
+// given signature, or nil if there is none.
+//
+// Note: "_dlsym_catchup_js", which is only imported with threads, isn't
+// implemented, so such modules fail to instantiate.
+func (d *DynamicLinking) HostFunc(importName string, params, results []api.ValueType) *wasm.HostFunc {
+	var fn *wasm.HostFunc
+	switch importName {
+	case "_dlinit":
+		fn = d.hostFunc(importName, []string{"main_dso_handle"}, nil, func(_ context.Context, _ api.Module, stack []uint64) {
+			d.mainHandle = api.DecodeU32(stack[0])
+		})
+	case "_dlopen_js":
+		fn = d.hostFunc(importName, []string{"handle"}, []api.ValueType{i32}, func(ctx context.Context, mod api.Module, stack []uint64) {
+			handle := api.DecodeU32(stack[0])
+			if err := d.dlopen(ctx, mod, handle); err != nil {
+				d.setError(ctx, mod, err)
+				handle = 0
+			}
+			stack[0] = api.EncodeU32(handle)
+		})
+	case "_emscripten_dlopen_js":
+		fn = d.hostFunc(importName, []string{"handle", "onsuccess", "onerror", "user_data"}, nil, func(ctx context.Context, mod api.Module, stack []uint64) {
+			handle, callback := api.DecodeU32(stack[0]), stack[1]
+			if err := d.dlopen(ctx, mod, handle); err != nil {
+				d.setError(ctx, mod, err)
+				callback = stack[2]
+			}
+			callTable(ctx, mod, uint32(callback), uint64(handle), stack[3])
+		})
+	case "_dlsym_js":
+		// Emscripten before 3.1.31 doesn't pass symbol_index.
+		names := []string{"handle", "symbol", "symbol_index"}
+		if len(params) == 2 {
+			names = names[:2]
+		}
+		fn = d.hostFunc(importName, names, []api.ValueType{i32}, func(ctx context.Context, mod api.Module, stack []uint64) {
+			addr, err := d.dlsym(mod, api.DecodeU32(stack[0]), api.DecodeU32(stack[1]))
+			if err != nil {
+				d.setError(ctx, mod, err)
+			}
+			stack[0] = api.EncodeU32(addr)
+		})
+	default:
+		return nil
+	}
+	if !bytes.Equal(fn.ParamTypes, params) || !bytes.Equal(fn.ResultTypes, results) {
+		return nil
+	}
+	return fn
+}
+
+func (d *DynamicLinking) hostFunc(name string, paramNames []string, results []api.ValueType, fn api.GoModuleFunc) *wasm.HostFunc {
+	params := make([]api.ValueType, len(paramNames))
+	for i := range params {
+		params[i] = i32
+	}
+	return &wasm.HostFunc{
+		ExportName:  name,
+		Name:        name,
+		ParamTypes:  params,
+		ParamNames:  paramNames,
+		ResultTypes: results,
+		Code:        wasm.Code{GoFunc: fn},
+	}
+}
+
+// dlopen loads the side module named by the dso at `handle`, reading it from
+// the filesystem of `mod`.
+func (d *DynamicLinking) dlopen(ctx context.Context, mod api.Module, handle uint32) error {
+	if d.Loader == nil {
+		return errors.New("dynamic linking requires the main module to be instantiated with InstantiateMainModule")
+	}
+	name, ok := readCString(mod.Memory(), handle+dsoName)
+	if !ok {
+		return errors.New("invalid dso")
+	}
+	binary, errno := readFile(mod, name)
+	if errno != 0 {
+		return fmt.Errorf("could not load dynamic lib %s: %w", name, errno)
+	}
+	side, err := d.Loader.Load(ctx, binary, d.Config.WithName(""))
+	if err != nil {
+		return fmt.Errorf("could not load dynamic lib %s: %w", name, err)
+	}
+	if d.handles == nil {
+		d.handles = map[uint32]api.Module{}
+	}
+	d.handles[handle] = side
+	return nil
+}
+
+// dlsym returns the address of the symbol at `symbol` in the module of
+// `handle`.
+func (d *DynamicLinking) dlsym(mod api.Module, handle, symbol uint32) (uint32, error) {
+	name, ok := readCString(mod.Memory(), symbol)
+	if !ok {
+		return 0, errors.New("invalid symbol")
+	} else if d.Loader == nil {
+		return 0, fmt.Errorf("tried to lookup unknown symbol %q", name)
+	}
+
+	var lib api.Module
+	switch side, ok := d.handles[handle]; {
+	case ok:
+		lib = side
+	case handle == d.mainHandle:
+		lib = d.Loader.Main()
+	case handle != rtldDefault:
+		return 0, errors.New("invalid handle")
+	}
+	addr, err := d.Loader.Address(lib, name)
+	if err != nil {
+		return 0, fmt.Errorf("tried to lookup unknown symbol %q", name)
+	}
+	return addr, nil
+}
+
+// setError sets the error returned by dlerror to `err`, if the main module
+// exports the functions needed.
+func (d *DynamicLinking) setError(ctx context.Context, mod api.Module, err error) {
+	if d.Loader != nil {
+		mod = d.Loader.Main()
+	}
+	setErr, malloc, free := mod.ExportedFunction("__dl_seterr"), mod.ExportedFunction("malloc"), mod.ExportedFunction("free")
+	if setErr == nil || malloc == nil || free == nil {
+		return
+	}
+	// __dl_seterr formats its first parameter.
+	msg := strings.ReplaceAll(err.Error(), "%", "%%") + "\x00"
+	results, e := malloc.Call(ctx, uint64(len(msg)))
+	if e != nil {
+		panic(e)
+	} else if results[0] == 0 {
+		return
+	}
+	mod.Memory().WriteString(uint32(results[0]), msg)
+	if _, e = setErr.Call(ctx, results[0], 0); e == nil {
+		_, e = free.Call(ctx, results[0])
+	}
+	if e != nil {
+		panic(e)
+	}
+}
+
+// callTable calls the function of type (i32, i32) -> () in the table slot
+// `index` of `mod`.
+func callTable(ctx context.Context, mod api.Module, index uint32, params ...uint64) {
+	m := mod.(*wasm.ModuleInstance)
+	typeID := m.GetFunctionTypeID(&wasm.FunctionType{Params: []api.ValueType{i32, i32}})
+	if _, err := m.LookupFunction(m.Tables[0], typeID, index).Call(ctx, params...); err != nil {
+		panic(err)
+	}
+}
+
+// readCString reads the NUL-terminated string at `offset`.
+func readCString(mem api.Memory, offset uint32) (string, bool) {
+	var b strings.Builder
+	for {
+		c, ok := mem.ReadByte(offset)
+		if !ok {
+			return "", false
+		} else if c == 0 {
+			return b.String(), true
+		}
+		b.WriteByte(c)
+		offset++
+	}
+}
+
+// readFile reads the file at `path` in the filesystem of `mod`.
+func readFile(mod api.Module, path string) ([]byte, experimentalsys.Errno) {
+	fsys, rel := mod.(*wasm.ModuleInstance).Sys.FS().ResolveFS(path)
+	f, errno := fsys.OpenFile(rel, experimentalsys.O_RDONLY, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+	defer f.Close()
+
+	var ret bytes.Buffer
+	buf := make([]byte, 32*1024)
+	for {
+		n, errno := f.Read(buf)
+		if errno != 0 {
+			return nil, errno
+		} else if n == 0 {
+			return ret.Bytes(), 0
+		}
+		ret.Write(buf[:n])
+	}
+}
@@ -0,0 +1,365 @@
+package emscripten
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+
+	"github.com/tetratelabs/wazero/api"
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
+	"github.com/tetratelabs/wazero/internal/wasip1"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
+)
+
+var le = binary.LittleEndian
+
+const (
+	i32 = wasm.ValueTypeI32
+	i64 = wasm.ValueTypeI64
+	f64 = wasm.ValueTypeF64
+)
+
+// Shim returns a host function implementing the Emscripten JS library
+// function `importName`, or nil if there is none with the same signature.
+//
+// Emscripten imports these from "env" when not built with STANDALONE_WASM.
+// Some have more than one signature, depending on the Emscripten version or
+// whether it was built with WASM_BIGINT.
+func Shim(importName string, params, results []api.ValueType) *wasm.HostFunc {
+	for _, fn := range shims[importName] {
+		if bytes.Equal(fn.ParamTypes, params) && bytes.Equal(fn.ResultTypes, results) {
+			return fn
+		}
+	}
+	return nil
+}
+
+var shims = map[string][]*wasm.HostFunc{}
+
+func init() {
+	for _, fn := range []*wasm.HostFunc{
+		resizeHeap,
+		memcpy("emscripten_memcpy_big"),
+		memcpy("emscripten_memcpy_js"),
+		memcpy("_emscripten_memcpy_js"),
+		dateNow,
+		getNow,
+		getNowIsMonotonic("emscripten_get_now_is_monotonic"),
+		getNowIsMonotonic("_emscripten_get_now_is_monotonic"),
+		clockGettime,
+		tzsetJs,
+		mmapJs,
+		mmapJsLegalized,
+		munmapJs,
+		munmapJsLegalized,
+		console("_emscripten_out", internalsys.FdStdout),
+		console("emscripten_console_log", internalsys.FdStdout),
+		console("_emscripten_err", internalsys.FdStderr),
+		console("emscripten_console_warn", internalsys.FdStderr),
+		console("emscripten_console_error", internalsys.FdStderr),
+	} {
+		shims[fn.ExportName] = append(shims[fn.ExportName], fn)
+	}
+}
+
+// resizeHeap grows memory to at least `requested_size` bytes, returning one
+// on success or zero if it can't.
+var resizeHeap = &wasm.HostFunc{
+	ExportName:  "emscripten_resize_heap",
+	Name:        "emscripten_resize_heap",
+	ParamTypes:  []api.ValueType{i32},
+	ParamNames:  []string{"requested_size"},
+	ResultTypes: []api.ValueType{i32},
+	Code: wasm.Code{GoFunc: api.GoModuleFunc(func(_ context.Context, mod api.Module, stack []uint64) {
+		mem, requested := mod.Memory(), uint64(uint32(stack[0]))
+		stack[0] = 1
+		if size := uint64(mem.Size()); requested > size {
+			pageSize := uint64(wasm.MemoryPageSize)
+			delta := (requested - size + pageSize - 1) / pageSize
+			if _, ok := mem.Grow(uint32(delta)); !ok {
+				stack[0] = 0
+			}
+		}
+	})},
+}
+
+// memcpy returns a function which copies `num` bytes from `src` to `dest`,
+// which may overlap.
+func memcpy(name string) *wasm.HostFunc {
+	return &wasm.HostFunc{
+		ExportName: name,
+		Name:       name,
+		ParamTypes: []api.ValueType{i32, i32, i32},
+		ParamNames: []string{"dest", "src", "num"},
+		Code: wasm.Code{GoFunc: api.GoModuleFunc(func(_ context.Context, mod api.Module, stack []uint64) {
+			dest, src, num := uint32(stack[0]), uint32(stack[1]), uint32(stack[2])
+			copy(mustRead(mod.Memory(), dest, num), mustRead(mod.Memory(), src, num))
+		})},
+	}
+}
+
+// dateNow returns the wall clock in milliseconds since the epoch.
+var dateNow = &wasm.HostFunc{
+	ExportName:  "emscripten_date_now",
+	Name:        "emscripten_date_now",
+	ResultTypes: []api.ValueType{f64},
+	Code: wasm.Code{GoFunc: api.GoModuleFunc(func(_ context.Context, mod api.Module, stack []uint64) {
+		ns := mod.(*wasm.ModuleInstance).Sys.WalltimeNanos()
+		stack[0] = api.EncodeF64(float64(ns) / 1e6)
+	})},
+}
+
+// getNow returns the monotonic clock in milliseconds.
+var getNow = &wasm.HostFunc{
+	ExportName:  "emscripten_get_now",
+	Name:        "emscripten_get_now",
+	ResultTypes: []api.ValueType{f64},
+	Code: wasm.Code{GoFunc: api.GoModuleFunc(func(_ context.Context, mod api.Module, stack []uint64) {
+		ns := mod.(*wasm.ModuleInstance).Sys.Nanotime()
+		stack[0] = api.EncodeF64(float64(ns) / 1e6)
+	})},
+}
+
+// getNowIsMonotonic returns a function which returns one, as getNow uses
+// sys.Nanotime.
+func getNowIsMonotonic(name string) *wasm.HostFunc {
+	return &wasm.HostFunc{
+		ExportName:  name,
+		Name:        name,
+		ResultTypes: []api.ValueType{i32},
+		Code: wasm.Code{GoFunc: api.GoModuleFunc(func(_ context.Context, _ api.Module, stack []uint64) {
+			stack[0] = 1
+		})},
+	}
+}
+
+// Clock IDs supported by clockGettime.
+const (
+	clockRealtime     = 0
+	clockMonotonic    = 1
+	clockMonotonicRaw = 4
+)
+
+// clockGettime implements clock_gettime as defined in the JS library of
+// Emscripten before 3.0, which writes a struct timespec of two i32 fields.
+var clockGettime = &wasm.HostFunc{
+	ExportName:  "clock_gettime",
+	Name:        "clock_gettime",
+	ParamTypes:  []api.ValueType{i32, i32},
+	ParamNames:  []string{"clk_id", "tp"},
+	ResultTypes: []api.ValueType{i32},
+	Code: wasm.Code{GoFunc: api.GoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
+		clkID, tp := uint32(stack[0]), uint32(stack[1])
+		sysCtx := mod.(*wasm.ModuleInstance).Sys
+
+		var ns int64
+		switch clkID {
+		case clockRealtime:
+			ns = sysCtx.WalltimeNanos()
+		case clockMonotonic, clockMonotonicRaw:
+			ns = sysCtx.Nanotime()
+		default:
+			setErrno(ctx, mod, wasip1.ErrnoInval)
+			stack[0] = api.EncodeI32(-1)
+			return
+		}
+		buf := mustRead(mod.Memory(), tp, 8)
+		le.PutUint32(buf, uint32(ns/1e9))
+		le.PutUint32(buf[4:], uint32(ns%1e9))
+		stack[0] = 0
+	})},
+}
+
+// setErrno sets the errno of the guest, if it exports its location.
+func setErrno(ctx context.Context, mod api.Module, errno wasip1.Errno) {
+	if f := mod.ExportedFunction("__errno_location"); f != nil {
+		if results, err := f.Call(ctx); err == nil {
+			mod.Memory().WriteUint32Le(uint32(results[0]), uint32(errno))
+		}
+	}
+}
+
+// tzsetJs implements _tzset_js, reporting UTC as the host time zone isn't
+// visible to the guest.
+var tzsetJs = &wasm.HostFunc{
+	ExportName: "_tzset_js",
+	Name:       "_tzset_js",
+	ParamTypes: []api.ValueType{i32, i32, i32, i32},
+	ParamNames: []string{"timezone", "daylight", "std_name", "dst_name"},
+	Code: wasm.Code{GoFunc: api.GoModuleFunc(func(_ context.Context, mod api.Module, stack []uint64) {
+		mem := mod.Memory()
+		timezone, daylight, stdName, dstName := uint32(stack[0]), uint32(stack[1]), uint32(stack[2]), uint32(stack[3])
+		le.PutUint32(mustRead(mem, timezone, 4), 0)
+		le.PutUint32(mustRead(mem, daylight, 4), 0)
+		copy(mustRead(mem, stdName, 4), "UTC\x00")
+		copy(mustRead(mem, dstName, 4), "UTC\x00")
+	})},
+}
+
+// Flags of mmap used by mmapJs and munmapJs.
+const (
+	protWrite  = 2
+	mapPrivate = 2
+)
+
+// mmapAlign is the alignment Emscripten uses for memory backing a mapping.
+const mmapAlign = 65536
+
+// mmapJs implements _mmap_js, which Emscripten calls for mappings of a file.
+// Like Emscripten, this copies the file into memory allocated by the guest.
+var mmapJs = &wasm.HostFunc{
+	ExportName:  "_mmap_js",
+	Name:        "_mmap_js",
+	ParamTypes:  []api.ValueType{i32, i32, i32, i32, i64, i32, i32},
+	ParamNames:  []string{"len", "prot", "flags", "fd", "offset", "allocated", "addr"},
+	ResultTypes: []api.ValueType{i32},
+	Code: wasm.Code{GoFunc: api.GoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
+		offset := int64(stack[4])
+		stack[0] = api.EncodeI32(mmap(ctx, mod, uint32(stack[0]), uint32(stack[3]), offset, uint32(stack[5]), uint32(stack[6])))
+	})},
+}
+
+// mmapJsLegalized is mmapJs when not built with WASM_BIGINT, which splits
+// the offset into low and high bits.
+var mmapJsLegalized = &wasm.HostFunc{
+	ExportName:  "_mmap_js",
+	Name:        "_mmap_js",
+	ParamTypes:  []api.ValueType{i32, i32, i32, i32, i32, i32, i32, i32},
+	ParamNames:  []string{"len", "prot", "flags", "fd", "offset_low", "offset_high", "allocated", "addr"},
+	ResultTypes: []api.ValueType{i32},
+	Code: wasm.Code{GoFunc: api.GoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
+		offset := int64(uint32(stack[4])) | int64(uint32(stack[5]))<<32
+		stack[0] = api.EncodeI32(mmap(ctx, mod, uint32(stack[0]), uint32(stack[3]), offset, uint32(stack[6]), uint32(stack[7])))
+	})},
+}
+
+// mmap allocates memory for `length` bytes of the file `fd` at `offset`,
+// writing its address to `addrPtr`. This returns zero on success or a
+// negative errno.
+func mmap(ctx context.Context, mod api.Module, length, fd uint32, offset int64, allocatedPtr, addrPtr uint32) int32 {
+	if offset < 0 {
+		return -int32(wasip1.ErrnoInval)
+	}
+	f, ok := mod.(*wasm.ModuleInstance).Sys.FS().LookupFile(int32(fd))
+	if !ok {
+		return -int32(wasip1.ErrnoBadf)
+	} else if isDir, _ := f.File.IsDir(); isDir {
+		return -int32(wasip1.ErrnoNodev)
+	}
+
+	memalign := mod.ExportedFunction("emscripten_builtin_memalign")
+	if memalign == nil {
+		return -int32(wasip1.ErrnoNomem)
+	}
+	size := (uint64(length) + mmapAlign - 1) &^ (mmapAlign - 1)
+	if size > math.MaxUint32 {
+		return -int32(wasip1.ErrnoNomem)
+	}
+	results, err := memalign.Call(ctx, mmapAlign, size)
+	if err != nil {
+		panic(err)
+	} else if results[0] == 0 {
+		return -int32(wasip1.ErrnoNomem)
+	}
+	ptr := uint32(results[0])
+
+	buf := mustRead(mod.Memory(), ptr, uint32(size))
+	for i := range buf {
+		buf[i] = 0
+	}
+	for buf = buf[:length]; len(buf) > 0; {
+		n, errno := f.File.Pread(buf, offset)
+		if errno != 0 {
+			return -int32(wasip1.ToErrno(errno))
+		} else if n == 0 {
+			break // EOF: the rest is zero.
+		}
+		buf, offset = buf[n:], offset+int64(n)
+	}
+
+	mod.Memory().WriteUint32Le(allocatedPtr, 1)
+	mod.Memory().WriteUint32Le(addrPtr, ptr)
+	return 0
+}
+
+// munmapJs implements _munmap_js, which writes shared, writable mappings
+// back to the file. The guest frees the memory.
+var munmapJs = &wasm.HostFunc{
+	ExportName:  "_munmap_js",
+	Name:        "_munmap_js",
+	ParamTypes:  []api.ValueType{i32, i32, i32, i32, i32, i64},
+	ParamNames:  []string{"addr", "len", "prot", "flags", "fd", "offset"},
+	ResultTypes: []api.ValueType{i32},
+	Code: wasm.Code{GoFunc: api.GoModuleFunc(func(_ context.Context, mod api.Module, stack []uint64) {
+		offset := int64(stack[5])
+		stack[0] = api.EncodeI32(munmap(mod, uint32(stack[0]), uint32(stack[1]), uint32(stack[2]), uint32(stack[3]), uint32(stack[4]), offset))
+	})},
+}
+
+// munmapJsLegalized is munmapJs when not built with WASM_BIGINT.
+var munmapJsLegalized = &wasm.HostFunc{
+	ExportName:  "_munmap_js",
+	Name:        "_munmap_js",
+	ParamTypes:  []api.ValueType{i32, i32, i32, i32, i32, i32, i32},
+	ParamNames:  []string{"addr", "len", "prot", "flags", "fd", "offset_low", "offset_high"},
+	ResultTypes: []api.ValueType{i32},
+	Code: wasm.Code{GoFunc: api.GoModuleFunc(func(_ context.Context, mod api.Module, stack []uint64) {
+		offset := int64(uint32(stack[5])) | int64(uint32(stack[6]))<<32
+		stack[0] = api.EncodeI32(munmap(mod, uint32(stack[0]), uint32(stack[1]), uint32(stack[2]), uint32(stack[3]), uint32(stack[4]), offset))
+	})},
+}
+
+// munmap writes `length` bytes at `addr` to the file `fd` at `offset`, if the
+// mapping was shared and writable. This returns zero on success or a negative
+// errno.
+func munmap(mod api.Module, addr, length, prot, flags, fd uint32, offset int64) int32 {
+	if prot&protWrite == 0 || flags&mapPrivate != 0 {
+		return 0
+	}
+	f, ok := mod.(*wasm.ModuleInstance).Sys.FS().LookupFile(int32(fd))
+	if !ok {
+		return -int32(wasip1.ErrnoBadf)
+	}
+	for buf := mustRead(mod.Memory(), addr, length); len(buf) > 0; {
+		n, errno := f.File.Pwrite(buf, offset)
+		if errno != 0 {
+			return -int32(wasip1.ToErrno(errno))
+		}
+		buf, offset = buf[n:], offset+int64(n)
+	}
+	return 0
+}
+
+// console returns a function which writes a NUL-terminated string and a
+// newline to the file `fd`, like the JS console.
+func console(name string, fd int32) *wasm.HostFunc {
+	return &wasm.HostFunc{
+		ExportName: name,
+		Name:       name,
+		ParamTypes: []api.ValueType{i32},
+		ParamNames: []string{"str"},
+		Code: wasm.Code{GoFunc: api.GoModuleFunc(func(_ context.Context, mod api.Module, stack []uint64) {
+			mem, str := mod.Memory(), uint32(stack[0])
+			buf := mustRead(mem, str, mem.Size()-str)
+			if i := bytes.IndexByte(buf, 0); i >= 0 {
+				buf = buf[:i]
+			}
+			fsc := mod.(*wasm.ModuleInstance).Sys.FS()
+			if f, ok := fsc.LookupFile(fd); ok {
+				_, _ = f.File.Write(append(append([]byte{}, buf...), '\n'))
+			}
+		})},
+	}
+}
+
+// mustRead returns a view of memory, or panics if out of range like an
+// instruction would.
+func mustRead(mem api.Memory, offset, byteCount uint32) []byte {
+	buf, ok := mem.Read(offset, byteCount)
+	if !ok {
+		panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+	}
+	return buf
+}
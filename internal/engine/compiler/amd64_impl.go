@@ -93,7 +93,7 @@ type amd64Compiler struct {
 	stackPointerCeil uint64
 	// assignStackPointerCeilNeeded holds an asm.Node whose AssignDestinationConstant must be called with the determined stack pointer ceiling.
 	assignStackPointerCeilNeeded asm.Node
-	compiledTrapTargets          [nativeCallStatusModuleClosed]asm.Node
+	compiledTrapTargets          [nativeCallStatusCodeNullReference + 1]asm.Node
 	withListener                 bool
 	typ                          *wasm.FunctionType
 	// locationStackForEntrypoint is the initial location stack for all functions. To reuse the allocated stack,
@@ -125,11 +125,19 @@ type amd64Compiler struct {
 	float64ForMaximumSigned64bitIntPlusOne *asm.StaticConst
 }
 
-func newAmd64Compiler() compiler {
+// amd64Arch implements arch for the amd64 architecture.
+var amd64Arch = &arch{
+	goarch:                            "amd64",
+	registerName:                      amd64.RegisterName,
+	unreservedGeneralPurposeRegisters: amd64UnreservedGeneralPurposeRegisters,
+	unreservedVectorRegisters:         amd64UnreservedVectorRegisters,
+}
+
+func newAmd64Compiler(cpuFeatures platform.CpuFeatureFlags) compiler {
 	c := &amd64Compiler{
 		assembler:                  amd64.NewAssembler(),
-		locationStackForEntrypoint: newRuntimeValueLocationStack(),
-		cpuFeatures:                platform.CpuFeatures,
+		locationStackForEntrypoint: newRuntimeValueLocationStack(amd64Arch),
+		cpuFeatures:                cpuFeatures,
 	}
 
 	c.fourZeros = asm.NewStaticConst([]byte{0, 0, 0, 0})
@@ -261,7 +269,7 @@ func (c *amd64Compiler) label(label wazeroir.Label) *amd64LabelInfo {
 	// so that we could reduce the allocation in the subsequent compilation.
 	if diff := frameID - len(frames) + 1; diff > 0 {
 		for i := 0; i < diff; i++ {
-			frames = append(frames, amd64LabelInfo{initialStack: newRuntimeValueLocationStack()})
+			frames = append(frames, amd64LabelInfo{initialStack: newRuntimeValueLocationStack(amd64Arch)})
 		}
 		c.labels[kind] = frames
 	}
@@ -280,6 +288,36 @@ func (c *amd64Compiler) compileBuiltinFunctionCheckExitCode() error {
 	return nil
 }
 
+// compileConsumeFuel implements compiler.compileConsumeFuel for the amd64 architecture.
+func (c *amd64Compiler) compileConsumeFuel(o *wazeroir.UnionOperation) error {
+	remaining, err := c.allocateRegister(registerTypeGeneralPurpose)
+	if err != nil {
+		return err
+	}
+	c.locationStack.markRegisterUsed(remaining)
+
+	c.assembler.CompileMemoryToRegister(amd64.MOVQ,
+		amd64ReservedRegisterForCallEngine, callEngineFuelContextRemainingFuelOffset, remaining)
+	if cost := int64(o.U1); cost <= math.MaxInt32 {
+		c.assembler.CompileConstToRegister(amd64.ADDQ, -cost, remaining)
+	} else {
+		// ADDQ only takes a 32-bit immediate, so load the cost into a register.
+		costReg, err := c.allocateRegister(registerTypeGeneralPurpose)
+		if err != nil {
+			return err
+		}
+		c.assembler.CompileConstToRegister(amd64.MOVQ, cost, costReg)
+		c.assembler.CompileRegisterToRegister(amd64.SUBQ, costReg, remaining)
+	}
+	// MOVQ doesn't modify the flags set by ADDQ or SUBQ.
+	c.assembler.CompileRegisterToMemory(amd64.MOVQ,
+		remaining, amd64ReservedRegisterForCallEngine, callEngineFuelContextRemainingFuelOffset)
+	c.locationStack.markRegisterUnused(remaining)
+
+	c.compileMaybeExitFromNativeCode(amd64.JPL, nativeCallStatusCodeFuelExhausted)
+	return nil
+}
+
 // compileGoDefinedHostFunction constructs the entire code to enter the host function implementation,
 // and return to the caller.
 func (c *amd64Compiler) compileGoDefinedHostFunction() error {
@@ -816,6 +854,16 @@ func (c *amd64Compiler) compileLabel(o *wazeroir.UnionOperation) (skipLabel bool
 
 // compileCall implements compiler.compileCall for the amd64 architecture.
 func (c *amd64Compiler) compileCall(o *wazeroir.UnionOperation) error {
+	return c.compileDirectCallImpl(o, false)
+}
+
+// compileTailCall implements compiler.compileTailCall for the amd64 architecture.
+func (c *amd64Compiler) compileTailCall(o *wazeroir.UnionOperation) error {
+	return c.compileDirectCallImpl(o, true)
+}
+
+// compileDirectCallImpl implements compileCall, or compileTailCall if `tail` is true.
+func (c *amd64Compiler) compileDirectCallImpl(o *wazeroir.UnionOperation, tail bool) error {
 	if err := c.maybeCompileMoveTopConditionalToGeneralPurposeRegister(); err != nil {
 		return err
 	}
@@ -838,6 +886,9 @@ func (c *amd64Compiler) compileCall(o *wazeroir.UnionOperation) error {
 	c.assembler.CompileMemoryToRegister(amd64.ADDQ, amd64ReservedRegisterForCallEngine,
 		callEngineModuleContextFunctionsElement0AddressOffset, targetAddressRegister)
 
+	if tail {
+		return c.compileTailCallFunctionImpl(targetAddressRegister, targetType)
+	}
 	if err := c.compileCallFunctionImpl(targetAddressRegister, targetType); err != nil {
 		return err
 	}
@@ -846,6 +897,16 @@ func (c *amd64Compiler) compileCall(o *wazeroir.UnionOperation) error {
 
 // compileCallIndirect implements compiler.compileCallIndirect for the amd64 architecture.
 func (c *amd64Compiler) compileCallIndirect(o *wazeroir.UnionOperation) error {
+	return c.compileCallIndirectImpl(o, false)
+}
+
+// compileTailCallIndirect implements compiler.compileTailCallIndirect for the amd64 architecture.
+func (c *amd64Compiler) compileTailCallIndirect(o *wazeroir.UnionOperation) error {
+	return c.compileCallIndirectImpl(o, true)
+}
+
+// compileCallIndirectImpl implements compileCallIndirect, or compileTailCallIndirect if `tail` is true.
+func (c *amd64Compiler) compileCallIndirectImpl(o *wazeroir.UnionOperation, tail bool) error {
 	offset := c.locationStack.pop()
 	if err := c.compileEnsureOnRegister(offset); err != nil {
 		return nil
@@ -907,7 +968,12 @@ func (c *amd64Compiler) compileCallIndirect(o *wazeroir.UnionOperation) error {
 	c.assembler.CompileMemoryToRegister(amd64.CMPL, offset.register, functionTypeIDOffset, tmp2)
 	c.compileMaybeExitFromNativeCode(amd64.JEQ, nativeCallStatusCodeTypeMismatchOnIndirectCall)
 	targetFunctionType := &c.ir.Types[typeIndex]
-	if err = c.compileCallFunctionImpl(offset.register, targetFunctionType); err != nil {
+	if tail {
+		err = c.compileTailCallFunctionImpl(offset.register, targetFunctionType)
+	} else {
+		err = c.compileCallFunctionImpl(offset.register, targetFunctionType)
+	}
+	if err != nil {
 		return nil
 	}
 
@@ -916,6 +982,70 @@ func (c *amd64Compiler) compileCallIndirect(o *wazeroir.UnionOperation) error {
 	return nil
 }
 
+// compileCallRef implements compiler.compileCallRef for the amd64 architecture.
+func (c *amd64Compiler) compileCallRef(o *wazeroir.UnionOperation) error {
+	return c.compileCallRefImpl(o, false)
+}
+
+// compileTailCallRef implements compiler.compileTailCallRef for the amd64 architecture.
+func (c *amd64Compiler) compileTailCallRef(o *wazeroir.UnionOperation) error {
+	return c.compileCallRefImpl(o, true)
+}
+
+// compileCallRefImpl implements compileCallRef, or compileTailCallRef if `tail` is true.
+func (c *amd64Compiler) compileCallRefImpl(o *wazeroir.UnionOperation, tail bool) error {
+	ref := c.locationStack.pop()
+	if err := c.compileEnsureOnRegister(ref); err != nil {
+		return err
+	}
+	typeIndex := o.U1
+
+	tmp, err := c.allocateRegister(registerTypeGeneralPurpose)
+	if err != nil {
+		return err
+	}
+	c.locationStack.markRegisterUsed(tmp)
+
+	// The reference is the address of the function, so trap if it is null.
+	c.assembler.CompileRegisterToRegister(amd64.TESTQ, ref.register, ref.register)
+	c.compileMaybeExitFromNativeCode(amd64.JNE, nativeCallStatusCodeNullFunctionReference)
+
+	// Like call_indirect, check the type matches: "tmp = moduleInstance.TypeIDs[index]"
+	c.assembler.CompileMemoryToRegister(amd64.MOVQ,
+		amd64ReservedRegisterForCallEngine, callEngineModuleContextTypeIDsElement0AddressOffset,
+		tmp)
+	c.assembler.CompileMemoryToRegister(amd64.MOVL, tmp, int64(typeIndex)*4, tmp)
+
+	// Skipped if the type matches.
+	c.assembler.CompileMemoryToRegister(amd64.CMPL, ref.register, functionTypeIDOffset, tmp)
+	c.compileMaybeExitFromNativeCode(amd64.JEQ, nativeCallStatusCodeTypeMismatchOnIndirectCall)
+	targetFunctionType := &c.ir.Types[typeIndex]
+	if tail {
+		err = c.compileTailCallFunctionImpl(ref.register, targetFunctionType)
+	} else {
+		err = c.compileCallFunctionImpl(ref.register, targetFunctionType)
+	}
+	if err != nil {
+		return err
+	}
+
+	// The ref register should be marked as un-used as we consumed in the function call.
+	c.locationStack.markRegisterUnused(ref.register, tmp)
+	return nil
+}
+
+// compileRefAsNonNull implements compiler.compileRefAsNonNull for the amd64 architecture.
+func (c *amd64Compiler) compileRefAsNonNull() error {
+	ref := c.locationStack.peek()
+	if err := c.compileEnsureOnRegister(ref); err != nil {
+		return err
+	}
+	// Skipped if the reference isn't null.
+	c.assembler.CompileRegisterToRegister(amd64.TESTQ, ref.register, ref.register)
+	c.compileMaybeExitFromNativeCode(amd64.JNE, nativeCallStatusCodeNullReference)
+	return nil
+}
+
 // compileDrop implements compiler.compileDrop for the amd64 architecture.
 func (c *amd64Compiler) compileDrop(o *wazeroir.UnionOperation) error {
 	return compileDropRange(c, o.U1)
@@ -3258,7 +3388,7 @@ func (c *amd64Compiler) compileLoad(o *wazeroir.UnionOperation) error {
 	)
 
 	unsignedType := wazeroir.UnsignedType(o.B1)
-	offset := uint32(o.U2)
+	offset := o.U2
 
 	switch unsignedType {
 	case wazeroir.UnsignedTypeI32:
@@ -3316,7 +3446,7 @@ func (c *amd64Compiler) compileLoad(o *wazeroir.UnionOperation) error {
 // compileLoad8 implements compiler.compileLoad8 for the amd64 architecture.
 func (c *amd64Compiler) compileLoad8(o *wazeroir.UnionOperation) error {
 	const targetSizeInBytes = 1
-	offset := uint32(o.U2)
+	offset := o.U2
 	reg, err := c.compileMemoryAccessCeilSetup(offset, targetSizeInBytes)
 	if err != nil {
 		return err
@@ -3354,7 +3484,7 @@ func (c *amd64Compiler) compileLoad8(o *wazeroir.UnionOperation) error {
 // compileLoad16 implements compiler.compileLoad16 for the amd64 architecture.
 func (c *amd64Compiler) compileLoad16(o *wazeroir.UnionOperation) error {
 	const targetSizeInBytes = 16 / 8
-	offset := uint32(o.U2)
+	offset := o.U2
 	reg, err := c.compileMemoryAccessCeilSetup(offset, targetSizeInBytes)
 	if err != nil {
 		return err
@@ -3392,7 +3522,7 @@ func (c *amd64Compiler) compileLoad16(o *wazeroir.UnionOperation) error {
 // compileLoad32 implements compiler.compileLoad32 for the amd64 architecture.
 func (c *amd64Compiler) compileLoad32(o *wazeroir.UnionOperation) error {
 	const targetSizeInBytes = 32 / 8
-	offset := uint32(o.U2)
+	offset := o.U2
 	reg, err := c.compileMemoryAccessCeilSetup(offset, targetSizeInBytes)
 	if err != nil {
 		return err
@@ -3420,15 +3550,20 @@ func (c *amd64Compiler) compileLoad32(o *wazeroir.UnionOperation) error {
 //
 // Note: this also emits the instructions to check the out-of-bounds memory access.
 // In other words, if the ceil exceeds the memory size, the code exits with nativeCallStatusCodeMemoryOutOfBounds status.
-func (c *amd64Compiler) compileMemoryAccessCeilSetup(offsetArg uint32, targetSizeInBytes int64) (asm.Register, error) {
+func (c *amd64Compiler) compileMemoryAccessCeilSetup(offsetArg uint64, targetSizeInBytes int64) (asm.Register, error) {
 	base := c.locationStack.pop()
 	if err := c.compileEnsureOnRegister(base); err != nil {
 		return asm.NilRegister, err
 	}
 
 	result := base.register
-	if offsetConst := int64(offsetArg) + targetSizeInBytes; offsetConst <= math.MaxInt32 {
+	if offsetArg > math.MaxUint32 {
+		// The offset of a 64-bit memory can exceed the memory size, which is at most 4GiB.
+		c.compileExitFromNativeCode(nativeCallStatusCodeMemoryOutOfBounds)
+		return result, nil
+	} else if offsetConst := int64(offsetArg) + targetSizeInBytes; offsetConst <= math.MaxInt32 {
 		c.assembler.CompileConstToRegister(amd64.ADDQ, offsetConst, result)
+		c.compileMaybeExitOnMemory64Overflow()
 	} else if offsetConst <= math.MaxUint32 {
 		// Note: in practice, this branch rarely happens as in this case, the wasm binary know that
 		// memory has more than 1 GBi or at least tries to access above 1 GBi memory region.
@@ -3442,6 +3577,7 @@ func (c *amd64Compiler) compileMemoryAccessCeilSetup(offsetArg uint32, targetSiz
 		}
 		c.assembler.CompileConstToRegister(amd64.MOVL, int64(uint32(offsetConst)), tmp)
 		c.assembler.CompileRegisterToRegister(amd64.ADDQ, tmp, result)
+		c.compileMaybeExitOnMemory64Overflow()
 	} else {
 		// If the offset const is too large, we exit with nativeCallStatusCodeMemoryOutOfBounds.
 		c.compileExitFromNativeCode(nativeCallStatusCodeMemoryOutOfBounds)
@@ -3459,12 +3595,20 @@ func (c *amd64Compiler) compileMemoryAccessCeilSetup(offsetArg uint32, targetSiz
 	return result, nil
 }
 
+// compileMaybeExitOnMemory64Overflow exits with nativeCallStatusCodeMemoryOutOfBounds if the previous ADDQ of
+// addresses, offsets or sizes of a 64-bit memory overflowed. The i32 ones of a 32-bit memory can't overflow.
+func (c *amd64Compiler) compileMaybeExitOnMemory64Overflow() {
+	if c.ir.Memory64 {
+		c.compileMaybeExitFromNativeCode(amd64.JCC, nativeCallStatusCodeMemoryOutOfBounds)
+	}
+}
+
 // compileStore implements compiler.compileStore for the amd64 architecture.
 func (c *amd64Compiler) compileStore(o *wazeroir.UnionOperation) error {
 	var movInst asm.Instruction
 	var targetSizeInByte int64
 	unsignedType := wazeroir.UnsignedType(o.B1)
-	offset := uint32(o.U2)
+	offset := o.U2
 	switch unsignedType {
 	case wazeroir.UnsignedTypeI32, wazeroir.UnsignedTypeF32:
 		movInst = amd64.MOVL
@@ -3478,20 +3622,20 @@ func (c *amd64Compiler) compileStore(o *wazeroir.UnionOperation) error {
 
 // compileStore8 implements compiler.compileStore8 for the amd64 architecture.
 func (c *amd64Compiler) compileStore8(o *wazeroir.UnionOperation) error {
-	return c.compileStoreImpl(uint32(o.U2), amd64.MOVB, 1)
+	return c.compileStoreImpl(o.U2, amd64.MOVB, 1)
 }
 
 // compileStore32 implements compiler.compileStore32 for the amd64 architecture.
 func (c *amd64Compiler) compileStore16(o *wazeroir.UnionOperation) error {
-	return c.compileStoreImpl(uint32(o.U2), amd64.MOVW, 16/8)
+	return c.compileStoreImpl(o.U2, amd64.MOVW, 16/8)
 }
 
 // compileStore32 implements compiler.compileStore32 for the amd64 architecture.
 func (c *amd64Compiler) compileStore32(o *wazeroir.UnionOperation) error {
-	return c.compileStoreImpl(uint32(o.U2), amd64.MOVL, 32/8)
+	return c.compileStoreImpl(o.U2, amd64.MOVL, 32/8)
 }
 
-func (c *amd64Compiler) compileStoreImpl(offsetConst uint32, inst asm.Instruction, targetSizeInBytes int64) error {
+func (c *amd64Compiler) compileStoreImpl(offsetConst uint64, inst asm.Instruction, targetSizeInBytes int64) error {
 	val := c.locationStack.pop()
 	if err := c.compileEnsureOnRegister(val); err != nil {
 		return err
@@ -3539,7 +3683,11 @@ func (c *amd64Compiler) compileMemorySize() error {
 	if err != nil {
 		return err
 	}
-	loc := c.pushRuntimeValueLocationOnRegister(reg, runtimeValueTypeI32)
+	vt := runtimeValueTypeI32
+	if c.ir.Memory64 {
+		vt = runtimeValueTypeI64
+	}
+	loc := c.pushRuntimeValueLocationOnRegister(reg, vt)
 
 	c.assembler.CompileMemoryToRegister(amd64.MOVQ, amd64ReservedRegisterForCallEngine, callEngineModuleContextMemorySliceLenOffset, loc.register)
 
@@ -3602,6 +3750,9 @@ func (c *amd64Compiler) compileInitImpl(isTable bool, index, tableIndex uint32)
 	c.assembler.CompileRegisterToRegister(amd64.ADDQ, copySize.register, sourceOffset.register)
 	// destinationOffset += size.
 	c.assembler.CompileRegisterToRegister(amd64.ADDQ, copySize.register, destinationOffset.register)
+	if !isTable {
+		c.compileMaybeExitOnMemory64Overflow()
+	}
 
 	// Check instance bounds and if exceeds the length, exit with out of bounds error.
 	c.assembler.CompileMemoryToRegister(amd64.CMPQ,
@@ -3831,8 +3982,10 @@ func (c *amd64Compiler) compileMemoryCopy() error {
 
 	// sourceOffset += size.
 	c.assembler.CompileRegisterToRegister(amd64.ADDQ, copySize.register, sourceOffset.register)
+	c.compileMaybeExitOnMemory64Overflow()
 	// destinationOffset += size.
 	c.assembler.CompileRegisterToRegister(amd64.ADDQ, copySize.register, destinationOffset.register)
+	c.compileMaybeExitOnMemory64Overflow()
 	// tmp = max(sourceOffset, destinationOffset).
 	c.assembler.CompileRegisterToRegister(amd64.CMPQ, sourceOffset.register, destinationOffset.register)
 	c.assembler.CompileRegisterToRegister(amd64.MOVQ, sourceOffset.register, tmp)
@@ -3941,6 +4094,9 @@ func (c *amd64Compiler) compileFillImpl(isTable bool, tableIndex uint32) error {
 
 	// destinationOffset += size.
 	c.assembler.CompileRegisterToRegister(amd64.ADDQ, copySize.register, destinationOffset.register)
+	if !isTable {
+		c.compileMaybeExitOnMemory64Overflow()
+	}
 
 	// Check destination bounds and if exceeds the length, exit with out of bounds error.
 	if isTable {
@@ -4283,6 +4439,38 @@ func (c *amd64Compiler) compileTableGrow(o *wazeroir.UnionOperation) error {
 	return nil
 }
 
+// compileAtomic implements compiler.compileAtomic for the amd64 architecture.
+func (c *amd64Compiler) compileAtomic(o *wazeroir.UnionOperation) error {
+	descriptor, operands, result, hasResult := atomicOperation(o)
+
+	// Pushes the offset and the descriptor of the operation.
+	for _, v := range [2]uint64{o.U2, descriptor} {
+		op := wazeroir.NewOperationConstI64(v)
+		if err := c.compileConstI64(&op); err != nil {
+			return err
+		}
+	}
+
+	// Atomic operations are serialized by wasm.MemoryInstance, and wait can block,
+	// so call out to the builtin function for this purpose.
+	if err := c.compileCallBuiltinFunction(builtinFunctionIndexAtomic); err != nil {
+		return err
+	}
+
+	for i := 0; i < operands+2; i++ {
+		c.locationStack.pop()
+	}
+	if hasResult {
+		loc := c.locationStack.pushRuntimeValueLocationOnStack()
+		loc.valueType = result
+	}
+
+	// After return, we re-initialize reserved registers just like preamble of functions.
+	c.compileReservedStackBasePointerInitialization()
+	c.compileReservedMemoryPointerInitialization()
+	return nil
+}
+
 // compileTableSize implements compiler.compileTableSize for the amd64 architecture.
 func (c *amd64Compiler) compileTableSize(o *wazeroir.UnionOperation) error {
 	if err := c.maybeCompileMoveTopConditionalToGeneralPurposeRegister(); err != nil {
@@ -4672,6 +4860,106 @@ func (c *amd64Compiler) compileCallFunctionImpl(functionAddressRegister asm.Regi
 	return nil
 }
 
+// compileTailCallFunctionImpl adds instructions to replace the call frame of the current function with the one of the
+// function whose address equals the value on functionAddressRegister, and jump into it, so that it returns its results
+// to the caller of the current function.
+//
+// The stack should look like:
+//
+//	,param0, ..., paramN, ..., _, .returnAddress, .returnStackBasePointerInBytes, .function, ..., arg0, ..., argM
+//
+// where the arguments are moved to the bottom, and the call frame is moved after them or the slots reserved for the
+// results of the target function, as callFunction would place them.
+func (c *amd64Compiler) compileTailCallFunctionImpl(functionAddressRegister asm.Register, functype *wasm.FunctionType) error {
+	if c.withListener {
+		// The listener observes the return of the current function.
+		return c.compileCallAndReturnFunction(functionAddressRegister, functype)
+	}
+
+	// Release all the registers as the arguments must be on the stack to be moved.
+	if err := c.compileReleaseAllRegistersToStack(); err != nil {
+		return err
+	}
+	c.locationStack.markRegisterUsed(functionAddressRegister)
+
+	var regs [callFrameDataSizeInUint64 + 1]asm.Register
+	for i := range regs {
+		reg, found := c.locationStack.takeFreeRegister(registerTypeGeneralPurpose)
+		if !found {
+			// This in theory never happen as all the registers must be free except functionAddressRegister.
+			return fmt.Errorf("could not find enough free registers")
+		}
+		c.locationStack.markRegisterUsed(reg)
+		regs[i] = reg
+	}
+	tmpRegister := regs[callFrameDataSizeInUint64]
+
+	// A host function gets its caller from the call frame, which the tail call would replace, so jump to a regular
+	// call instead if the target function is defined in Go.
+	c.assembler.CompileMemoryToRegister(amd64.MOVQ, functionAddressRegister, functionParentOffset, tmpRegister)
+	c.assembler.CompileMemoryToRegister(amd64.MOVQ, tmpRegister, compiledFunctionGoFuncOffset, tmpRegister)
+	c.assembler.CompileRegisterToRegister(amd64.TESTQ, tmpRegister, tmpRegister)
+	jmpIfHostFunction := c.assembler.CompileJump(amd64.JNE)
+
+	// First, load the call frame of the current function, as the arguments might overwrite it.
+	returnAddress, _, _ := c.locationStack.getCallFrameLocations(c.typ)
+	for i := int64(0); i < callFrameDataSizeInUint64; i++ {
+		c.assembler.CompileMemoryToRegister(amd64.MOVQ,
+			amd64ReservedRegisterForStackBasePointerAddress, (int64(returnAddress.stackPointer)+i)*8, regs[i])
+	}
+
+	// Next, move the arguments to the bottom of the stack of the current function.
+	argsOffset := int64(c.locationStack.sp) - int64(functype.ParamNumInUint64)
+	for i := int64(0); i < int64(functype.ParamNumInUint64); i++ {
+		c.assembler.CompileMemoryToRegister(amd64.MOVQ,
+			amd64ReservedRegisterForStackBasePointerAddress, (argsOffset+i)*8, tmpRegister)
+		c.assembler.CompileRegisterToMemory(amd64.MOVQ,
+			tmpRegister, amd64ReservedRegisterForStackBasePointerAddress, i*8)
+	}
+
+	// Then, write the call frame where the target function expects it.
+	callFrameOffset := int64(callFrameOffset(functype))
+	for i := int64(0); i < callFrameDataSizeInUint64; i++ {
+		c.assembler.CompileRegisterToMemory(amd64.MOVQ,
+			regs[i], amd64ReservedRegisterForStackBasePointerAddress, (callFrameOffset+i)*8)
+	}
+
+	// Set callEngine.moduleContext.fn to the next *function.
+	c.assembler.CompileRegisterToMemory(amd64.MOVQ, functionAddressRegister,
+		amd64ReservedRegisterForCallEngine, callEngineModuleContextFnOffset)
+
+	if amd64CallingConventionDestinationFunctionModuleInstanceAddressRegister == functionAddressRegister {
+		// See the same case in compileCallFunctionImpl.
+		c.assembler.CompileRegisterToRegister(amd64.MOVQ, functionAddressRegister, tmpRegister)
+		functionAddressRegister = tmpRegister
+	}
+
+	// Put the target function's *wasm.ModuleInstance into amd64CallingConventionDestinationFunctionModuleInstanceAddressRegister,
+	// and jump into the initial address of the target function. The stack base pointer is unchanged.
+	c.assembler.CompileMemoryToRegister(amd64.MOVQ, functionAddressRegister, functionModuleInstanceOffset,
+		amd64CallingConventionDestinationFunctionModuleInstanceAddressRegister)
+	c.assembler.CompileJumpToMemory(amd64.JMP, functionAddressRegister, functionCodeInitialAddressOffset)
+
+	// The location stack is unchanged, as all the values are on the stack.
+	c.locationStack.markRegisterUnused(regs[:]...)
+	c.assembler.SetJumpTargetOnNext(jmpIfHostFunction)
+	return c.compileCallAndReturnFunction(functionAddressRegister, functype)
+}
+
+// compileCallAndReturnFunction calls the target function, and returns its results, which are on top of the parameters
+// and the call frame of the current function. This executes a tail call where the frame can't be reused.
+func (c *amd64Compiler) compileCallAndReturnFunction(functionAddressRegister asm.Register, functype *wasm.FunctionType) error {
+	if err := c.compileCallFunctionImpl(functionAddressRegister, functype); err != nil {
+		return err
+	}
+	results := functype.ResultNumInUint64
+	below := int(c.locationStack.sp) - results
+	if err := compileDropRange(c, wazeroir.InclusiveRange{Start: int32(results), End: int32(results + below - 1)}.AsU64()); err != nil {
+		return err
+	}
+	return c.compileReturnFunction()
+}
+
 // returnFunction adds instructions to return from the current callframe back to the caller's frame.
 // If this is the current one is the origin, we return to the callEngine.execWasmFunction with the Returned status.
 // Otherwise, we jump into the callers' return address stored in callFrame.returnAddress while setting
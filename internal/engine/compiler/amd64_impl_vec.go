@@ -123,7 +123,7 @@ func (c *amd64Compiler) compileV128Load(o *wazeroir.UnionOperation) error {
 		return err
 	}
 
-	offset := uint32(o.U2)
+	offset := o.U2
 	loadType := wazeroir.V128LoadType(o.B1)
 
 	switch loadType {
@@ -208,7 +208,7 @@ func (c *amd64Compiler) compileV128Load(o *wazeroir.UnionOperation) error {
 	return nil
 }
 
-func (c *amd64Compiler) compileV128LoadImpl(inst asm.Instruction, offset uint32, targetSizeInBytes int64, dst asm.Register) error {
+func (c *amd64Compiler) compileV128LoadImpl(inst asm.Instruction, offset uint64, targetSizeInBytes int64, dst asm.Register) error {
 	offsetReg, err := c.compileMemoryAccessCeilSetup(offset, targetSizeInBytes)
 	if err != nil {
 		return err
@@ -226,7 +226,7 @@ func (c *amd64Compiler) compileV128LoadLane(o *wazeroir.UnionOperation) error {
 	}
 
 	laneSize, laneIndex := o.B1, o.B2
-	offset := uint32(o.U2)
+	offset := o.U2
 
 	var insertInst asm.Instruction
 	switch laneSize {
@@ -260,7 +260,7 @@ func (c *amd64Compiler) compileV128Store(o *wazeroir.UnionOperation) error {
 	}
 
 	const targetSizeInBytes = 16
-	offset := uint32(o.U2)
+	offset := o.U2
 	offsetReg, err := c.compileMemoryAccessCeilSetup(offset, targetSizeInBytes)
 	if err != nil {
 		return err
@@ -278,7 +278,7 @@ func (c *amd64Compiler) compileV128StoreLane(o *wazeroir.UnionOperation) error {
 	var storeInst asm.Instruction
 	laneSize := o.B1
 	laneIndex := o.B2
-	offset := uint32(o.U2)
+	offset := o.U2
 	switch laneSize {
 	case 8:
 		storeInst = amd64.PEXTRB
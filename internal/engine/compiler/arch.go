@@ -1,7 +1,11 @@
 package compiler
 
 import (
+	"fmt"
+	"runtime"
+
 	"github.com/tetratelabs/wazero/internal/asm"
+	"github.com/tetratelabs/wazero/internal/platform"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
 
@@ -15,5 +19,47 @@ var newArchContext func() archContext
 // Note: this is implemented in per-arch Go assembler file. For example, arch_amd64.s implements this for amd64.
 func nativecall(codeSegment uintptr, ce *callEngine, moduleInstanceAddress *wasm.ModuleInstance)
 
-// registerNameFn is used for debugging purpose to have register symbols in the string of runtimeValueLocation.
-var registerNameFn func(register asm.Register) string
+// arch holds what the compiler needs to generate code for an architecture.
+// Unlike archContext, this isn't guarded by build tags, so that modules can
+// be compiled for an architecture other than runtime.GOARCH.
+type arch struct {
+	// goarch is the GOARCH of this architecture, e.g. "arm64".
+	goarch string
+	// registerName is used for debugging purpose to have register symbols in the string of runtimeValueLocation.
+	registerName func(register asm.Register) string
+	// unreservedGeneralPurposeRegisters contains unreserved general purpose registers of integer type.
+	unreservedGeneralPurposeRegisters []asm.Register
+	// unreservedVectorRegisters contains unreserved vector registers.
+	unreservedVectorRegisters []asm.Register
+}
+
+// newCompiler returns a new compiler interface which can be used to compile the given function instance.
+// cpuFeatures are the capabilities of the target CPU, only used by amd64.
+//
+// Note: This panics if `a` is nil, i.e. the nativeArch of an unsupported
+// runtime.GOARCH.
+func (a *arch) newCompiler(cpuFeatures platform.CpuFeatureFlags) compiler {
+	if a == nil {
+		panic(fmt.Sprintf("unsupported GOARCH %s", runtime.GOARCH))
+	} else if a.goarch == "amd64" {
+		return newAmd64Compiler(cpuFeatures)
+	}
+	return newArm64Compiler()
+}
+
+// archs are the architectures the compiler can generate code for, keyed by
+// GOARCH.
+var archs = map[string]*arch{amd64Arch.goarch: amd64Arch, arm64Arch.goarch: arm64Arch}
+
+// nativeArch is the arch of runtime.GOARCH, or nil if unsupported.
+var nativeArch = archs[runtime.GOARCH]
+
+// nativeCpuFeatures are the capabilities of this CPU. This is overwritten in
+// arch_amd64.go, as other architectures don't use it.
+var nativeCpuFeatures = platform.BaselineCpuFeatures
+
+// newCompiler returns a new compiler for runtime.GOARCH, or panics if it is
+// unsupported.
+func newCompiler() compiler {
+	return nativeArch.newCompiler(nativeCpuFeatures)
+}
@@ -2,17 +2,11 @@ package compiler
 
 import (
 	"math"
-
-	"github.com/tetratelabs/wazero/internal/asm"
-	"github.com/tetratelabs/wazero/internal/asm/arm64"
 )
 
 // init initializes variables for the arm64 architecture
 func init() {
 	newArchContext = newArchContextImpl
-	registerNameFn = arm64.RegisterName
-	unreservedGeneralPurposeRegisters = arm64UnreservedGeneralPurposeRegisters
-	unreservedVectorRegisters = arm64UnreservedVectorRegisters
 }
 
 // archContext is embedded in callEngine in order to store architecture-specific data.
@@ -44,27 +38,3 @@ func newArchContextImpl() archContext {
 		minimum64BitSignedInt: math.MinInt64,
 	}
 }
-
-// newCompiler returns a new compiler interface which can be used to compile the given function instance.
-// Note: ir param can be nil for host functions.
-func newCompiler() compiler {
-	return newArm64Compiler()
-}
-
-func registerMaskShift(r asm.Register) (ret int) {
-	ret = int(r - arm64.RegR0)
-	if r > arm64.RegSP {
-		// Skips arm64.RegSP which is not a real register.
-		ret--
-	}
-	return
-}
-
-func registerFromMaskShift(s int) asm.Register {
-	if s < 32 {
-		return arm64.RegR0 + asm.Register(s)
-	} else {
-		// Skips arm64.RegSP which is not a real register.
-		return arm64.RegR0 + asm.Register(s) + 1
-	}
-}
@@ -27,7 +27,7 @@ type arm64Compiler struct {
 	stackPointerCeil uint64
 	// assignStackPointerCeilNeeded holds an asm.Node whose AssignDestinationConstant must be called with the determined stack pointer ceiling.
 	assignStackPointerCeilNeeded asm.Node
-	compiledTrapTargets          [nativeCallStatusModuleClosed]asm.Node
+	compiledTrapTargets          [nativeCallStatusCodeNullReference + 1]asm.Node
 	withListener                 bool
 	typ                          *wasm.FunctionType
 	br                           *bytes.Reader
@@ -39,10 +39,18 @@ type arm64Compiler struct {
 	brTableTmp []runtimeValueLocation
 }
 
+// arm64Arch implements arch for the arm64 architecture.
+var arm64Arch = &arch{
+	goarch:                            "arm64",
+	registerName:                      arm64.RegisterName,
+	unreservedGeneralPurposeRegisters: arm64UnreservedGeneralPurposeRegisters,
+	unreservedVectorRegisters:         arm64UnreservedVectorRegisters,
+}
+
 func newArm64Compiler() compiler {
 	return &arm64Compiler{
 		assembler:                  arm64.NewAssembler(arm64ReservedRegisterForTemporary),
-		locationStackForEntrypoint: newRuntimeValueLocationStack(),
+		locationStackForEntrypoint: newRuntimeValueLocationStack(arm64Arch),
 		br:                         bytes.NewReader(nil),
 	}
 }
@@ -124,11 +132,11 @@ var arm64CallingConventionModuleInstanceAddressRegister = arm64.RegR29
 
 const (
 	// arm64CallEngineArchContextCompilerCallReturnAddressOffset is the offset of archContext.nativeCallReturnAddress in callEngine.
-	arm64CallEngineArchContextCompilerCallReturnAddressOffset = 144
+	arm64CallEngineArchContextCompilerCallReturnAddressOffset = 152
 	// arm64CallEngineArchContextMinimum32BitSignedIntOffset is the offset of archContext.minimum32BitSignedIntAddress in callEngine.
-	arm64CallEngineArchContextMinimum32BitSignedIntOffset = 152
+	arm64CallEngineArchContextMinimum32BitSignedIntOffset = 160
 	// arm64CallEngineArchContextMinimum64BitSignedIntOffset is the offset of archContext.minimum64BitSignedIntAddress in callEngine.
-	arm64CallEngineArchContextMinimum64BitSignedIntOffset = 160
+	arm64CallEngineArchContextMinimum64BitSignedIntOffset = 168
 )
 
 func isZeroRegister(r asm.Register) bool {
@@ -185,7 +193,7 @@ func (c *arm64Compiler) label(label wazeroir.Label) *arm64LabelInfo {
 	// so that we could reduce the allocation in the subsequent compilation.
 	if diff := frameID - len(frames) + 1; diff > 0 {
 		for i := 0; i < diff; i++ {
-			frames = append(frames, arm64LabelInfo{initialStack: newRuntimeValueLocationStack()})
+			frames = append(frames, arm64LabelInfo{initialStack: newRuntimeValueLocationStack(arm64Arch)})
 		}
 		c.labels[kind] = frames
 	}
@@ -516,6 +524,24 @@ func (c *arm64Compiler) compileBuiltinFunctionCheckExitCode() error {
 	return nil
 }
 
+// compileConsumeFuel implements compiler.compileConsumeFuel for the arm64 architecture.
+func (c *arm64Compiler) compileConsumeFuel(o *wazeroir.UnionOperation) error {
+	remaining, err := c.allocateRegister(registerTypeGeneralPurpose)
+	if err != nil {
+		return err
+	}
+
+	c.assembler.CompileMemoryToRegister(arm64.LDRD,
+		arm64ReservedRegisterForCallEngine, callEngineFuelContextRemainingFuelOffset, remaining)
+	c.assembler.CompileConstToRegister(arm64.SUBS, int64(o.U1), remaining)
+	// STRD doesn't modify the flags set by SUBS.
+	c.assembler.CompileRegisterToMemory(arm64.STRD,
+		remaining, arm64ReservedRegisterForCallEngine, callEngineFuelContextRemainingFuelOffset)
+
+	c.compileMaybeExitFromNativeCode(arm64.BCONDPL, nativeCallStatusCodeFuelExhausted)
+	return nil
+}
+
 // compileLabel implements compiler.compileLabel for the arm64 architecture.
 func (c *arm64Compiler) compileLabel(o *wazeroir.UnionOperation) (skipThisLabel bool) {
 	labelKey := wazeroir.Label(o.U1)
@@ -986,6 +1012,16 @@ func (c *arm64Compiler) getSavedTemporaryLocationStack() runtimeValueLocationSta
 
 // compileCall implements compiler.compileCall for the arm64 architecture.
 func (c *arm64Compiler) compileCall(o *wazeroir.UnionOperation) error {
+	return c.compileDirectCallImpl(o, false)
+}
+
+// compileTailCall implements compiler.compileTailCall for the arm64 architecture.
+func (c *arm64Compiler) compileTailCall(o *wazeroir.UnionOperation) error {
+	return c.compileDirectCallImpl(o, true)
+}
+
+// compileDirectCallImpl implements compileCall, or compileTailCall if `tail` is true.
+func (c *arm64Compiler) compileDirectCallImpl(o *wazeroir.UnionOperation, tail bool) error {
 	if err := c.maybeCompileMoveTopConditionalToGeneralPurposeRegister(); err != nil {
 		return err
 	}
@@ -1014,6 +1050,9 @@ func (c *arm64Compiler) compileCall(o *wazeroir.UnionOperation) error {
 		int64(functionIndex)*functionSize, // * 8 because the size of *function equals 8 bytes.
 		targetFunctionAddressReg)
 
+	if tail {
+		return c.compileTailCallImpl(targetFunctionAddressReg, tp)
+	}
 	return c.compileCallImpl(targetFunctionAddressReg, tp)
 }
 
@@ -1129,8 +1168,118 @@ func (c *arm64Compiler) compileCallImpl(targetFunctionAddressRegister asm.Regist
 	return nil
 }
 
+// compileTailCallImpl adds instructions to replace the call frame of the current function with the one of the function
+// whose address equals the value on targetFunctionAddressRegister, and jump into it, so that it returns its results to
+// the caller of the current function.
+//
+// See the same method on amd64Compiler for the layout of the stack.
+func (c *arm64Compiler) compileTailCallImpl(targetFunctionAddressRegister asm.Register, functype *wasm.FunctionType) error {
+	if c.withListener {
+		// The listener observes the return of the current function.
+		return c.compileCallAndReturnImpl(targetFunctionAddressRegister, functype)
+	}
+
+	// Release all the registers as the arguments must be on the stack to be moved.
+	if err := c.compileReleaseAllRegistersToStack(); err != nil {
+		return err
+	}
+	c.markRegisterUsed(targetFunctionAddressRegister)
+
+	var regs [callFrameDataSizeInUint64 + 1]asm.Register
+	for i := range regs {
+		reg, ok := c.locationStack.takeFreeRegister(registerTypeGeneralPurpose)
+		if !ok {
+			panic("BUG: cannot take a free register")
+		}
+		c.markRegisterUsed(reg)
+		regs[i] = reg
+	}
+	tmp := regs[callFrameDataSizeInUint64]
+
+	// A host function gets its caller from the call frame, which the tail call would replace, so branch to a regular
+	// call instead if the target function is defined in Go.
+	c.assembler.CompileMemoryToRegister(arm64.LDRD, targetFunctionAddressRegister, functionParentOffset, tmp)
+	c.assembler.CompileMemoryToRegister(arm64.LDRD, tmp, compiledFunctionGoFuncOffset, tmp)
+	c.assembler.CompileTwoRegistersToNone(arm64.CMP, arm64.RegRZR, tmp)
+	brIfHostFunction := c.assembler.CompileJump(arm64.BCONDNE)
+
+	// First, load the call frame of the current function, as the arguments might overwrite it.
+	returnAddress, _, _ := c.locationStack.getCallFrameLocations(c.typ)
+	for i := int64(0); i < callFrameDataSizeInUint64; i++ {
+		c.assembler.CompileMemoryToRegister(arm64.LDRD,
+			arm64ReservedRegisterForStackBasePointerAddress, (int64(returnAddress.stackPointer)+i)*8, regs[i])
+	}
+
+	// Next, move the arguments to the bottom of the stack of the current function.
+	argsOffset := int64(c.locationStack.sp) - int64(functype.ParamNumInUint64)
+	for i := int64(0); i < int64(functype.ParamNumInUint64); i++ {
+		c.assembler.CompileMemoryToRegister(arm64.LDRD,
+			arm64ReservedRegisterForStackBasePointerAddress, (argsOffset+i)*8, tmp)
+		c.assembler.CompileRegisterToMemory(arm64.STRD,
+			tmp, arm64ReservedRegisterForStackBasePointerAddress, i*8)
+	}
+
+	// Then, write the call frame where the target function expects it.
+	callFrameOffset := int64(callFrameOffset(functype))
+	for i := int64(0); i < callFrameDataSizeInUint64; i++ {
+		c.assembler.CompileRegisterToMemory(arm64.STRD,
+			regs[i], arm64ReservedRegisterForStackBasePointerAddress, (callFrameOffset+i)*8)
+	}
+
+	// Set callEngine.moduleContext.fn to the next *function.
+	c.assembler.CompileRegisterToMemory(arm64.STRD,
+		targetFunctionAddressRegister,
+		arm64ReservedRegisterForCallEngine, callEngineModuleContextFnOffset)
+
+	if targetFunctionAddressRegister == arm64CallingConventionModuleInstanceAddressRegister {
+		// See the same case in compileCallImpl.
+		c.assembler.CompileRegisterToRegister(arm64.MOVD, targetFunctionAddressRegister, tmp)
+		targetFunctionAddressRegister = tmp
+	}
+
+	// Put the code's moduleInstance address into arm64CallingConventionModuleInstanceAddressRegister, and br into the
+	// target function's initial address. The stack base pointer is unchanged.
+	c.assembler.CompileMemoryToRegister(arm64.LDRD,
+		targetFunctionAddressRegister, functionModuleInstanceOffset,
+		arm64CallingConventionModuleInstanceAddressRegister,
+	)
+	c.assembler.CompileMemoryToRegister(arm64.LDRD,
+		targetFunctionAddressRegister, functionCodeInitialAddressOffset,
+		tmp)
+	c.assembler.CompileJumpToRegister(arm64.B, tmp)
+
+	// The location stack is unchanged, as all the values are on the stack.
+	c.markRegisterUnused(regs[:]...)
+	c.assembler.SetJumpTargetOnNext(brIfHostFunction)
+	return c.compileCallAndReturnImpl(targetFunctionAddressRegister, functype)
+}
+
+// compileCallAndReturnImpl calls the target function, and returns its results, which are on top of the parameters and
+// the call frame of the current function. This executes a tail call where the frame can't be reused.
+func (c *arm64Compiler) compileCallAndReturnImpl(targetFunctionAddressRegister asm.Register, functype *wasm.FunctionType) error {
+	if err := c.compileCallImpl(targetFunctionAddressRegister, functype); err != nil {
+		return err
+	}
+	results := functype.ResultNumInUint64
+	below := int(c.locationStack.sp) - results
+	if err := compileDropRange(c, wazeroir.InclusiveRange{Start: int32(results), End: int32(results + below - 1)}.AsU64()); err != nil {
+		return err
+	}
+	return c.compileReturnFunction()
+}
+
 // compileCallIndirect implements compiler.compileCallIndirect for the arm64 architecture.
-func (c *arm64Compiler) compileCallIndirect(o *wazeroir.UnionOperation) (err error) {
+func (c *arm64Compiler) compileCallIndirect(o *wazeroir.UnionOperation) error {
+	return c.compileCallIndirectImpl(o, false)
+}
+
+// compileTailCallIndirect implements compiler.compileTailCallIndirect for the arm64 architecture.
+func (c *arm64Compiler) compileTailCallIndirect(o *wazeroir.UnionOperation) error {
+	return c.compileCallIndirectImpl(o, true)
+}
+
+// compileCallIndirectImpl implements compileCallIndirect, or compileTailCallIndirect if `tail` is true.
+func (c *arm64Compiler) compileCallIndirectImpl(o *wazeroir.UnionOperation, tail bool) (err error) {
 	offset := c.locationStack.pop()
 	if err = c.compileEnsureOnRegister(offset); err != nil {
 		return err
@@ -1228,7 +1377,12 @@ func (c *arm64Compiler) compileCallIndirect(o *wazeroir.UnionOperation) (err err
 	c.compileMaybeExitFromNativeCode(arm64.BCONDEQ, nativeCallStatusCodeTypeMismatchOnIndirectCall)
 
 	targetFunctionType := &c.ir.Types[typeIndex]
-	if err := c.compileCallImpl(offsetReg, targetFunctionType); err != nil {
+	if tail {
+		err = c.compileTailCallImpl(offsetReg, targetFunctionType)
+	} else {
+		err = c.compileCallImpl(offsetReg, targetFunctionType)
+	}
+	if err != nil {
 		return err
 	}
 
@@ -1237,6 +1391,92 @@ func (c *arm64Compiler) compileCallIndirect(o *wazeroir.UnionOperation) (err err
 	return nil
 }
 
+// compileCallRef implements compiler.compileCallRef for the arm64 architecture.
+func (c *arm64Compiler) compileCallRef(o *wazeroir.UnionOperation) error {
+	return c.compileCallRefImpl(o, false)
+}
+
+// compileTailCallRef implements compiler.compileTailCallRef for the arm64 architecture.
+func (c *arm64Compiler) compileTailCallRef(o *wazeroir.UnionOperation) error {
+	return c.compileCallRefImpl(o, true)
+}
+
+// compileCallRefImpl implements compileCallRef, or compileTailCallRef if `tail` is true.
+func (c *arm64Compiler) compileCallRefImpl(o *wazeroir.UnionOperation, tail bool) (err error) {
+	ref := c.locationStack.pop()
+	if err = c.compileEnsureOnRegister(ref); err != nil {
+		return err
+	}
+	typeIndex := o.U1
+
+	refReg := ref.register
+	if isZeroRegister(refReg) {
+		refReg, err = c.allocateRegister(registerTypeGeneralPurpose)
+		if err != nil {
+			return err
+		}
+		c.markRegisterUsed(refReg)
+
+		// Zero the value on a picked register.
+		c.assembler.CompileRegisterToRegister(arm64.MOVD, arm64.RegRZR, refReg)
+	}
+
+	tmp, err := c.allocateRegister(registerTypeGeneralPurpose)
+	if err != nil {
+		return err
+	}
+	c.markRegisterUsed(tmp)
+
+	tmp2, err := c.allocateRegister(registerTypeGeneralPurpose)
+	if err != nil {
+		return err
+	}
+	c.markRegisterUsed(tmp2)
+
+	// The reference is the address of the function, so trap if it is null.
+	c.assembler.CompileTwoRegistersToNone(arm64.CMP, arm64.RegRZR, refReg)
+	c.compileMaybeExitFromNativeCode(arm64.BCONDNE, nativeCallStatusCodeNullFunctionReference)
+
+	// Like call_indirect, check the type matches.
+	// "tmp = ref.typeID"
+	c.assembler.CompileMemoryToRegister(arm64.LDRD, refReg, functionTypeIDOffset, tmp)
+	// "tmp2 = ModuleInstance.TypeIDs[index]"
+	c.assembler.CompileMemoryToRegister(arm64.LDRD,
+		arm64ReservedRegisterForCallEngine, callEngineModuleContextTypeIDsElement0AddressOffset,
+		tmp2)
+	c.assembler.CompileMemoryToRegister(arm64.LDRW, tmp2, int64(typeIndex)*4, tmp2)
+
+	// Skipped if the type matches.
+	c.assembler.CompileTwoRegistersToNone(arm64.CMPW, tmp, tmp2)
+	c.compileMaybeExitFromNativeCode(arm64.BCONDEQ, nativeCallStatusCodeTypeMismatchOnIndirectCall)
+
+	targetFunctionType := &c.ir.Types[typeIndex]
+	if tail {
+		err = c.compileTailCallImpl(refReg, targetFunctionType)
+	} else {
+		err = c.compileCallImpl(refReg, targetFunctionType)
+	}
+	if err != nil {
+		return err
+	}
+
+	// The ref register should be marked as un-used as we consumed in the function call.
+	c.markRegisterUnused(refReg, tmp, tmp2)
+	return nil
+}
+
+// compileRefAsNonNull implements compiler.compileRefAsNonNull for the arm64 architecture.
+func (c *arm64Compiler) compileRefAsNonNull() error {
+	ref := c.locationStack.peek()
+	if err := c.compileEnsureOnRegister(ref); err != nil {
+		return err
+	}
+	// Skipped if the reference isn't null.
+	c.assembler.CompileTwoRegistersToNone(arm64.CMP, arm64.RegRZR, ref.register)
+	c.compileMaybeExitFromNativeCode(arm64.BCONDNE, nativeCallStatusCodeNullReference)
+	return nil
+}
+
 // compileDrop implements compiler.compileDrop for the arm64 architecture.
 func (c *arm64Compiler) compileDrop(o *wazeroir.UnionOperation) error {
 	return compileDropRange(c, o.U1)
@@ -2640,7 +2880,7 @@ func (c *arm64Compiler) compileLoad(o *wazeroir.UnionOperation) error {
 	)
 
 	unsignedType := wazeroir.UnsignedType(o.B1)
-	offset := uint32(o.U2)
+	offset := o.U2
 
 	switch unsignedType {
 	case wazeroir.UnsignedTypeI32:
@@ -2671,7 +2911,7 @@ func (c *arm64Compiler) compileLoad8(o *wazeroir.UnionOperation) error {
 	var vt runtimeValueType
 
 	signedInt := wazeroir.SignedInt(o.B1)
-	offset := uint32(o.U2)
+	offset := o.U2
 
 	switch signedInt {
 	case wazeroir.SignedInt32:
@@ -2696,7 +2936,7 @@ func (c *arm64Compiler) compileLoad16(o *wazeroir.UnionOperation) error {
 	var vt runtimeValueType
 
 	signedInt := wazeroir.SignedInt(o.B1)
-	offset := uint32(o.U2)
+	offset := o.U2
 
 	switch signedInt {
 	case wazeroir.SignedInt32:
@@ -2719,7 +2959,7 @@ func (c *arm64Compiler) compileLoad16(o *wazeroir.UnionOperation) error {
 func (c *arm64Compiler) compileLoad32(o *wazeroir.UnionOperation) error {
 	var loadInst asm.Instruction
 	signed := o.B1 == 1
-	offset := uint32(o.U2)
+	offset := o.U2
 
 	if signed {
 		loadInst = arm64.LDRSW
@@ -2730,7 +2970,7 @@ func (c *arm64Compiler) compileLoad32(o *wazeroir.UnionOperation) error {
 }
 
 // compileLoadImpl implements compileLoadImpl* variants for arm64 architecture.
-func (c *arm64Compiler) compileLoadImpl(offsetArg uint32, loadInst asm.Instruction,
+func (c *arm64Compiler) compileLoadImpl(offsetArg uint64, loadInst asm.Instruction,
 	targetSizeInBytes int64, isFloat bool, resultRuntimeValueType runtimeValueType,
 ) error {
 	offsetReg, err := c.compileMemoryAccessOffsetSetup(offsetArg, targetSizeInBytes)
@@ -2763,7 +3003,7 @@ func (c *arm64Compiler) compileStore(o *wazeroir.UnionOperation) error {
 	var movInst asm.Instruction
 	var targetSizeInBytes int64
 	unsignedType := wazeroir.UnsignedType(o.B1)
-	offset := uint32(o.U2)
+	offset := o.U2
 	switch unsignedType {
 	case wazeroir.UnsignedTypeI32:
 		movInst = arm64.STRW
@@ -2783,21 +3023,21 @@ func (c *arm64Compiler) compileStore(o *wazeroir.UnionOperation) error {
 
 // compileStore8 implements compiler.compileStore8 for the arm64 architecture.
 func (c *arm64Compiler) compileStore8(o *wazeroir.UnionOperation) error {
-	return c.compileStoreImpl(uint32(o.U2), arm64.STRB, 1)
+	return c.compileStoreImpl(o.U2, arm64.STRB, 1)
 }
 
 // compileStore16 implements compiler.compileStore16 for the arm64 architecture.
 func (c *arm64Compiler) compileStore16(o *wazeroir.UnionOperation) error {
-	return c.compileStoreImpl(uint32(o.U2), arm64.STRH, 16/8)
+	return c.compileStoreImpl(o.U2, arm64.STRH, 16/8)
 }
 
 // compileStore32 implements compiler.compileStore32 for the arm64 architecture.
 func (c *arm64Compiler) compileStore32(o *wazeroir.UnionOperation) error {
-	return c.compileStoreImpl(uint32(o.U2), arm64.STRW, 32/8)
+	return c.compileStoreImpl(o.U2, arm64.STRW, 32/8)
 }
 
 // compileStoreImpl implements compleStore* variants for arm64 architecture.
-func (c *arm64Compiler) compileStoreImpl(offsetArg uint32, storeInst asm.Instruction, targetSizeInBytes int64) error {
+func (c *arm64Compiler) compileStoreImpl(offsetArg uint64, storeInst asm.Instruction, targetSizeInBytes int64) error {
 	val, err := c.popValueOnRegister()
 	if err != nil {
 		return err
@@ -2827,7 +3067,7 @@ func (c *arm64Compiler) compileStoreImpl(offsetArg uint32, storeInst asm.Instruc
 //
 // Note: this also emits the instructions to check the out of bounds memory access.
 // In other words, if the offset+targetSizeInBytes exceeds the memory size, the code exits with nativeCallStatusCodeMemoryOutOfBounds status.
-func (c *arm64Compiler) compileMemoryAccessOffsetSetup(offsetArg uint32, targetSizeInBytes int64) (offsetRegister asm.Register, err error) {
+func (c *arm64Compiler) compileMemoryAccessOffsetSetup(offsetArg uint64, targetSizeInBytes int64) (offsetRegister asm.Register, err error) {
 	base, err := c.popValueOnRegister()
 	if err != nil {
 		return 0, err
@@ -2842,9 +3082,14 @@ func (c *arm64Compiler) compileMemoryAccessOffsetSetup(offsetArg uint32, targetS
 		c.assembler.CompileRegisterToRegister(arm64.MOVD, arm64.RegRZR, offsetRegister)
 	}
 
-	if offsetConst := int64(offsetArg) + targetSizeInBytes; offsetConst <= math.MaxUint32 {
+	if offsetArg > math.MaxUint32 {
+		// If the offset const is too large, we exit with nativeCallStatusCodeMemoryOutOfBounds.
+		c.compileExitFromNativeCode(nativeCallStatusCodeMemoryOutOfBounds)
+		return
+	} else if offsetConst := int64(offsetArg) + targetSizeInBytes; offsetConst <= math.MaxUint32 {
 		// "offsetRegister = base + offsetArg + targetSizeInBytes"
-		c.assembler.CompileConstToRegister(arm64.ADD, offsetConst, offsetRegister)
+		c.assembler.CompileConstToRegister(c.addInstructionForAddresses(), offsetConst, offsetRegister)
+		c.compileMaybeExitOnMemory64Overflow()
 	} else {
 		// If the offset const is too large, we exit with nativeCallStatusCodeMemoryOutOfBounds.
 		c.compileExitFromNativeCode(nativeCallStatusCodeMemoryOutOfBounds)
@@ -2868,6 +3113,23 @@ func (c *arm64Compiler) compileMemoryAccessOffsetSetup(offsetArg uint32, targetS
 	return offsetRegister, nil
 }
 
+// addInstructionForAddresses returns the instruction to add addresses, offsets or sizes of the memory. For a 64-bit
+// memory, this is ADDS so that compileMaybeExitOnMemory64Overflow can check the carry flag.
+func (c *arm64Compiler) addInstructionForAddresses() asm.Instruction {
+	if c.ir.Memory64 {
+		return arm64.ADDS
+	}
+	return arm64.ADD
+}
+
+// compileMaybeExitOnMemory64Overflow exits with nativeCallStatusCodeMemoryOutOfBounds if the previous ADDS of
+// addresses, offsets or sizes of a 64-bit memory overflowed. The i32 ones of a 32-bit memory can't overflow.
+func (c *arm64Compiler) compileMaybeExitOnMemory64Overflow() {
+	if c.ir.Memory64 {
+		c.compileMaybeExitFromNativeCode(arm64.BCONDLO, nativeCallStatusCodeMemoryOutOfBounds)
+	}
+}
+
 // compileMemoryGrow implements compileMemoryGrow variants for arm64 architecture.
 func (c *arm64Compiler) compileMemoryGrow() error {
 	if err := c.maybeCompileMoveTopConditionalToGeneralPurposeRegister(); err != nil {
@@ -2910,7 +3172,11 @@ func (c *arm64Compiler) compileMemorySize() error {
 		reg,
 	)
 
-	c.pushRuntimeValueLocationOnRegister(reg, runtimeValueTypeI32)
+	vt := runtimeValueTypeI32
+	if c.ir.Memory64 {
+		vt = runtimeValueTypeI64
+	}
+	c.pushRuntimeValueLocationOnRegister(reg, vt)
 	return nil
 }
 
@@ -3101,7 +3367,12 @@ func (c *arm64Compiler) compileInitImpl(isTable bool, index, tableIndex uint32)
 		// sourceOffset += size.
 		c.assembler.CompileRegisterToRegister(arm64.ADD, copySize.register, sourceOffset.register)
 		// destinationOffset += size.
-		c.assembler.CompileRegisterToRegister(arm64.ADD, copySize.register, destinationOffset.register)
+		if isTable {
+			c.assembler.CompileRegisterToRegister(arm64.ADD, copySize.register, destinationOffset.register)
+		} else {
+			c.assembler.CompileRegisterToRegister(c.addInstructionForAddresses(), copySize.register, destinationOffset.register)
+			c.compileMaybeExitOnMemory64Overflow()
+		}
 	}
 
 	instanceAddr, err := c.allocateRegister(registerTypeGeneralPurpose)
@@ -3305,10 +3576,20 @@ func (c *arm64Compiler) compileCopyImpl(isTable bool, srcTableIndex, dstTableInd
 	c.markRegisterUsed(destinationOffset.register)
 
 	if !isZeroRegister(copySize.register) {
-		// sourceOffset += size.
-		c.assembler.CompileRegisterToRegister(arm64.ADD, copySize.register, sourceOffset.register)
-		// destinationOffset += size.
-		c.assembler.CompileRegisterToRegister(arm64.ADD, copySize.register, destinationOffset.register)
+		if isTable {
+			// sourceOffset += size.
+			c.assembler.CompileRegisterToRegister(arm64.ADD, copySize.register, sourceOffset.register)
+			// destinationOffset += size.
+			c.assembler.CompileRegisterToRegister(arm64.ADD, copySize.register, destinationOffset.register)
+		} else {
+			addInst := c.addInstructionForAddresses()
+			// sourceOffset += size.
+			c.assembler.CompileRegisterToRegister(addInst, copySize.register, sourceOffset.register)
+			c.compileMaybeExitOnMemory64Overflow()
+			// destinationOffset += size.
+			c.assembler.CompileRegisterToRegister(addInst, copySize.register, destinationOffset.register)
+			c.compileMaybeExitOnMemory64Overflow()
+		}
 	}
 
 	if isTable {
@@ -3558,7 +3839,12 @@ func (c *arm64Compiler) compileFillImpl(isTable bool, tableIndex uint32) error {
 	c.markRegisterUsed(destinationOffset.register)
 
 	// destinationOffset += size.
-	c.assembler.CompileRegisterToRegister(arm64.ADD, fillSize.register, destinationOffset.register)
+	if isTable {
+		c.assembler.CompileRegisterToRegister(arm64.ADD, fillSize.register, destinationOffset.register)
+	} else {
+		c.assembler.CompileRegisterToRegister(c.addInstructionForAddresses(), fillSize.register, destinationOffset.register)
+		c.compileMaybeExitOnMemory64Overflow()
+	}
 
 	if isTable {
 		// arm64ReservedRegisterForTemporary = &tables[0]
@@ -3874,6 +4160,40 @@ func (c *arm64Compiler) compileTableGrow(o *wazeroir.UnionOperation) error {
 	return nil
 }
 
+// compileAtomic implements compiler.compileAtomic for the arm64 architecture.
+func (c *arm64Compiler) compileAtomic(o *wazeroir.UnionOperation) error {
+	if err := c.maybeCompileMoveTopConditionalToGeneralPurposeRegister(); err != nil {
+		return err
+	}
+	descriptor, operands, result, hasResult := atomicOperation(o)
+
+	// Pushes the offset and the descriptor of the operation.
+	for _, v := range [2]uint64{o.U2, descriptor} {
+		if err := c.compileIntConstant(false, v); err != nil {
+			return err
+		}
+	}
+
+	// Atomic operations are serialized by wasm.MemoryInstance, and wait can block,
+	// so call out to the builtin function for this purpose.
+	if err := c.compileCallGoFunction(nativeCallStatusCodeCallBuiltInFunction, builtinFunctionIndexAtomic); err != nil {
+		return err
+	}
+
+	for i := 0; i < operands+2; i++ {
+		c.locationStack.pop()
+	}
+	if hasResult {
+		v := c.locationStack.pushRuntimeValueLocationOnStack()
+		v.valueType = result
+	}
+
+	// After return, we re-initialize reserved registers just like preamble of functions.
+	c.compileReservedStackBasePointerRegisterInitialization()
+	c.compileReservedMemoryRegisterInitialization()
+	return nil
+}
+
 // compileTableSize implements compiler.compileTableSize for the arm64 architecture.
 func (c *arm64Compiler) compileTableSize(o *wazeroir.UnionOperation) error {
 	if err := c.maybeCompileMoveTopConditionalToGeneralPurposeRegister(); err != nil {
@@ -144,7 +144,7 @@ func (c *arm64Compiler) compileV128Load(o *wazeroir.UnionOperation) (err error)
 		return err
 	}
 
-	offset := uint32(o.U2)
+	offset := o.U2
 	loadType := wazeroir.V128LoadType(o.B1)
 
 	switch loadType {
@@ -274,7 +274,7 @@ func (c *arm64Compiler) compileV128LoadLane(o *wazeroir.UnionOperation) (err err
 	}
 
 	laneSize, laneIndex := o.B1, o.B2
-	offset := uint32(o.U2)
+	offset := o.U2
 
 	targetSizeInBytes := int64(laneSize / 8)
 	source, err := c.compileMemoryAccessOffsetSetup(offset, targetSizeInBytes)
@@ -315,7 +315,7 @@ func (c *arm64Compiler) compileV128Store(o *wazeroir.UnionOperation) (err error)
 	}
 
 	const targetSizeInBytes = 16
-	offset := uint32(o.U2)
+	offset := o.U2
 	offsetReg, err := c.compileMemoryAccessOffsetSetup(offset, targetSizeInBytes)
 	if err != nil {
 		return err
@@ -334,7 +334,7 @@ func (c *arm64Compiler) compileV128StoreLane(o *wazeroir.UnionOperation) (err er
 	var storeInst asm.Instruction
 	laneSize := o.B1
 	laneIndex := o.B2
-	offset := uint32(o.U2)
+	offset := o.U2
 	switch laneSize {
 	case 8:
 		storeInst = arm64.STRB
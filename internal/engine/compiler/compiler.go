@@ -44,6 +44,16 @@ type compiler interface {
 	compileCall(o *wazeroir.UnionOperation) error
 	// compileCallIndirect adds instructions to perform wazeroir.OperationCallIndirect.
 	compileCallIndirect(o *wazeroir.UnionOperation) error
+	// compileTailCall adds instructions to perform wazeroir.NewOperationTailCall.
+	compileTailCall(o *wazeroir.UnionOperation) error
+	// compileTailCallIndirect adds instructions to perform wazeroir.NewOperationTailCallIndirect.
+	compileTailCallIndirect(o *wazeroir.UnionOperation) error
+	// compileCallRef adds instructions to perform wazeroir.NewOperationCallRef.
+	compileCallRef(o *wazeroir.UnionOperation) error
+	// compileTailCallRef adds instructions to perform wazeroir.NewOperationTailCallRef.
+	compileTailCallRef(o *wazeroir.UnionOperation) error
+	// compileRefAsNonNull adds instructions to perform wazeroir.NewOperationRefAsNonNull.
+	compileRefAsNonNull() error
 	// compileDrop adds instructions to perform wazeroir.NewOperationDrop.
 	compileDrop(o *wazeroir.UnionOperation) error
 	// compileSelect adds instructions to perform wazeroir.OperationSelect.
@@ -303,6 +313,10 @@ type compiler interface {
 
 	// compileBuiltinFunctionCheckExitCode adds instructions to perform wazeroir.OperationBuiltinFunctionCheckExitCode.
 	compileBuiltinFunctionCheckExitCode() error
+	// compileConsumeFuel adds instructions to perform wazeroir.NewOperationConsumeFuel.
+	compileConsumeFuel(o *wazeroir.UnionOperation) error
+	// compileAtomic adds instructions to perform the atomic operations, e.g. wazeroir.NewOperationAtomicLoad.
+	compileAtomic(o *wazeroir.UnionOperation) error
 
 	// compileReleaseRegisterToStack adds instructions to write the value on a register back to memory stack region.
 	compileReleaseRegisterToStack(loc *runtimeValueLocation)
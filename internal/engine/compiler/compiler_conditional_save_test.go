@@ -22,7 +22,7 @@ func TestCompiler_conditional_value_saving(t *testing.T) {
 	require.NoError(t, err)
 
 	// Generate constants to occupy all the unreserved GP registers.
-	for i := 0; i < len(unreservedGeneralPurposeRegisters); i++ {
+	for i := 0; i < len(nativeArch.unreservedGeneralPurposeRegisters); i++ {
 		err = compiler.compileConstI32(operationPtr(wazeroir.NewOperationConstI32(100)))
 		require.NoError(t, err)
 	}
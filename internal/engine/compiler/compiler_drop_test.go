@@ -23,10 +23,10 @@ func Test_compileDropRange(t *testing.T) {
 		c.Init(&wasm.FunctionType{}, nil, false)
 
 		// Use up all unreserved registers.
-		for _, reg := range unreservedGeneralPurposeRegisters {
+		for _, reg := range nativeArch.unreservedGeneralPurposeRegisters {
 			c.pushRuntimeValueLocationOnRegister(reg, runtimeValueTypeI32)
 		}
-		for i, vreg := range unreservedVectorRegisters {
+		for i, vreg := range nativeArch.unreservedVectorRegisters {
 			// Mix and match scalar float and vector values.
 			if i%2 == 0 {
 				c.pushVectorRuntimeValueLocationOnRegister(vreg)
@@ -35,7 +35,7 @@ func Test_compileDropRange(t *testing.T) {
 			}
 		}
 
-		unreservedRegisterTotal := len(unreservedGeneralPurposeRegisters) + len(unreservedVectorRegisters)
+		unreservedRegisterTotal := len(nativeArch.unreservedGeneralPurposeRegisters) + len(nativeArch.unreservedVectorRegisters)
 		ls := c.runtimeValueLocationStack()
 		require.Equal(t, unreservedRegisterTotal, len(ls.usedRegisters.list()))
 
@@ -118,12 +118,12 @@ func Test_getTemporariesForStackedLiveValues(t *testing.T) {
 
 				if !freeRegisterExists {
 					// Use up all the unreserved gp registers.
-					for _, reg := range unreservedGeneralPurposeRegisters {
+					for _, reg := range nativeArch.unreservedGeneralPurposeRegisters {
 						c.pushRuntimeValueLocationOnRegister(reg, runtimeValueTypeI32)
 					}
 					// Ensures actually we used them up all.
 					require.Equal(t, len(c.runtimeValueLocationStack().usedRegisters.list()),
-						len(unreservedGeneralPurposeRegisters))
+						len(nativeArch.unreservedGeneralPurposeRegisters))
 				}
 
 				gpTmp, vecTmp, err := getTemporariesForStackedLiveValues(c, liveValues)
@@ -132,7 +132,7 @@ func Test_getTemporariesForStackedLiveValues(t *testing.T) {
 				if !freeRegisterExists {
 					// At this point, one register should be marked as unused.
 					require.Equal(t, len(c.runtimeValueLocationStack().usedRegisters.list()),
-						len(unreservedGeneralPurposeRegisters)-1)
+						len(nativeArch.unreservedGeneralPurposeRegisters)-1)
 				}
 
 				require.NotEqual(t, asm.NilRegister, gpTmp)
@@ -159,12 +159,12 @@ func Test_getTemporariesForStackedLiveValues(t *testing.T) {
 
 				if !freeRegisterExists {
 					// Use up all the unreserved gp registers.
-					for _, reg := range unreservedVectorRegisters {
+					for _, reg := range nativeArch.unreservedVectorRegisters {
 						c.pushVectorRuntimeValueLocationOnRegister(reg)
 					}
 					// Ensures actually we used them up all.
 					require.Equal(t, len(c.runtimeValueLocationStack().usedRegisters.list()),
-						len(unreservedVectorRegisters))
+						len(nativeArch.unreservedVectorRegisters))
 				}
 
 				gpTmp, vecTmp, err := getTemporariesForStackedLiveValues(c, liveValues)
@@ -173,7 +173,7 @@ func Test_getTemporariesForStackedLiveValues(t *testing.T) {
 				if !freeRegisterExists {
 					// At this point, one register should be marked as unused.
 					require.Equal(t, len(c.runtimeValueLocationStack().usedRegisters.list()),
-						len(unreservedVectorRegisters)-1)
+						len(nativeArch.unreservedVectorRegisters)-1)
 				}
 
 				require.Equal(t, asm.NilRegister, gpTmp)
@@ -197,8 +197,8 @@ func Test_migrateLiveValue(t *testing.T) {
 			_ = c.runtimeValueLocationStack().pushRuntimeValueLocationOnStack()
 		}
 
-		gpReg := unreservedGeneralPurposeRegisters[0]
-		vReg := unreservedVectorRegisters[0]
+		gpReg := nativeArch.unreservedGeneralPurposeRegisters[0]
+		vReg := nativeArch.unreservedVectorRegisters[0]
 		c.pushRuntimeValueLocationOnRegister(gpReg, runtimeValueTypeI64)
 		c.pushVectorRuntimeValueLocationOnRegister(vReg)
 
@@ -38,9 +38,9 @@ func TestCompiler_compileGlobalGet(t *testing.T) {
 			require.Equal(t, 1, len(compiler.runtimeValueLocationStack().usedRegisters.list()))
 			switch tp {
 			case wasm.ValueTypeF32, wasm.ValueTypeF64:
-				require.True(t, isVectorRegister(global.register))
+				require.True(t, nativeArch.isVectorRegister(global.register))
 			case wasm.ValueTypeI32, wasm.ValueTypeI64:
-				require.True(t, isGeneralPurposeRegister(global.register))
+				require.True(t, nativeArch.isGeneralPurposeRegister(global.register))
 			}
 			err = compiler.compileReturnFunction()
 			require.NoError(t, err)
@@ -85,7 +85,7 @@ func TestCompiler_compileGlobalGet_v128(t *testing.T) {
 	global := compiler.runtimeValueLocationStack().peek()
 	require.True(t, global.onRegister())
 	require.Equal(t, 1, len(compiler.runtimeValueLocationStack().usedRegisters.list()))
-	require.True(t, isVectorRegister(global.register))
+	require.True(t, nativeArch.isVectorRegister(global.register))
 	err = compiler.compileReturnFunction()
 	require.NoError(t, err)
 
@@ -86,7 +86,7 @@ func TestCompiler_compileLoad(t *testing.T) {
 	loadTargetValue := uint64(0x12_34_56_78_9a_bc_ef_fe)
 	baseOffset := uint32(100)
 	arg := wazeroir.MemoryArg{Offset: 361}
-	offset := baseOffset + arg.Offset
+	offset := baseOffset + uint32(arg.Offset)
 
 	tests := []struct {
 		name                string
@@ -288,7 +288,7 @@ func TestCompiler_compileStore(t *testing.T) {
 	storeTargetValue := uint64(math.MaxUint64)
 	baseOffset := uint32(100)
 	arg := wazeroir.MemoryArg{Offset: 361}
-	offset := arg.Offset + baseOffset
+	offset := uint32(arg.Offset) + baseOffset
 
 	tests := []struct {
 		name                string
@@ -458,7 +458,7 @@ func TestCompiler_MemoryOutOfBounds(t *testing.T) {
 					err = compiler.compileConstI32(operationPtr(wazeroir.NewOperationConstI32(base)))
 					require.NoError(t, err)
 
-					arg := wazeroir.MemoryArg{Offset: offset}
+					arg := wazeroir.MemoryArg{Offset: uint64(offset)}
 
 					switch targetSizeInByte {
 					case 1:
@@ -36,10 +36,9 @@ func TestCompiler_releaseRegisterToStack(t *testing.T) {
 
 			// Set up the location stack so that we push the const on the specified height.
 			s := &runtimeValueLocationStack{
-				sp:                                tc.stackPointer,
-				stack:                             make([]runtimeValueLocation, tc.stackPointer),
-				unreservedVectorRegisters:         unreservedVectorRegisters,
-				unreservedGeneralPurposeRegisters: unreservedGeneralPurposeRegisters,
+				sp:    tc.stackPointer,
+				stack: make([]runtimeValueLocation, tc.stackPointer),
+				arch:  nativeArch,
 			}
 			// Peek must be non-nil. Otherwise, compileConst* would fail.
 			compiler.setRuntimeValueLocationStack(s)
@@ -61,6 +61,9 @@ func init() {
 	requireEqual(int(unsafe.Offsetof(ce.returnAddress)), callEngineExitContextReturnAddressOffset, "callEngineExitContextReturnAddressOffset")
 	requireEqual(int(unsafe.Offsetof(ce.callerModuleInstance)), callEngineExitContextCallerModuleInstanceOffset, "callEngineExitContextCallerModuleInstanceOffset")
 
+	// Offsets for callEngine.fuelContext.
+	requireEqual(int(unsafe.Offsetof(ce.remainingFuel)), callEngineFuelContextRemainingFuelOffset, "callEngineFuelContextRemainingFuelOffset")
+
 	// Size and offsets for callFrame.
 	var frame callFrame
 	requireEqual(int(unsafe.Sizeof(frame))/8, callFrameDataSizeInUint64, "callFrameDataSize")
@@ -70,8 +73,13 @@ func init() {
 	requireEqual(int(unsafe.Offsetof(f.codeInitialAddress)), functionCodeInitialAddressOffset, "functionCodeInitialAddressOffset")
 	requireEqual(int(unsafe.Offsetof(f.moduleInstance)), functionModuleInstanceOffset, "functionModuleInstanceOffset")
 	requireEqual(int(unsafe.Offsetof(f.typeID)), functionTypeIDOffset, "functionTypeIDOffset")
+	requireEqual(int(unsafe.Offsetof(f.parent)), functionParentOffset, "functionParentOffset")
 	requireEqual(int(unsafe.Sizeof(f)), functionSize, "functionModuleInstanceOffset")
 
+	// Offsets for compiledFunction.
+	var cf compiledFunction
+	requireEqual(int(unsafe.Offsetof(cf.goFunc)), compiledFunctionGoFuncOffset, "compiledFunctionGoFuncOffset")
+
 	// Offsets for wasm.ModuleInstance.
 	var moduleInstance wasm.ModuleInstance
 	requireEqual(int(unsafe.Offsetof(moduleInstance.Globals)), moduleInstanceGlobalsOffset, "moduleInstanceGlobalsOffset")
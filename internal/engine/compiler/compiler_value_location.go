@@ -8,20 +8,12 @@ import (
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
 
-var (
-	// unreservedGeneralPurposeRegisters contains unreserved general purpose registers of integer type.
-	unreservedGeneralPurposeRegisters []asm.Register
-
-	// unreservedVectorRegisters contains unreserved vector registers.
-	unreservedVectorRegisters []asm.Register
-)
-
-func isGeneralPurposeRegister(r asm.Register) bool {
-	return unreservedGeneralPurposeRegisters[0] <= r && r <= unreservedGeneralPurposeRegisters[len(unreservedGeneralPurposeRegisters)-1]
+func (a *arch) isGeneralPurposeRegister(r asm.Register) bool {
+	return a.unreservedGeneralPurposeRegisters[0] <= r && r <= a.unreservedGeneralPurposeRegisters[len(a.unreservedGeneralPurposeRegisters)-1]
 }
 
-func isVectorRegister(r asm.Register) bool {
-	return unreservedVectorRegisters[0] <= r && r <= unreservedVectorRegisters[len(unreservedVectorRegisters)-1]
+func (a *arch) isVectorRegister(r asm.Register) bool {
+	return a.unreservedVectorRegisters[0] <= r && r <= a.unreservedVectorRegisters[len(a.unreservedVectorRegisters)-1]
 }
 
 // runtimeValueLocation corresponds to each variable pushed onto the wazeroir (virtual) stack,
@@ -97,23 +89,20 @@ func (v *runtimeValueLocation) onConditionalRegister() bool {
 	return v.conditionalRegister != asm.ConditionalRegisterStateUnset
 }
 
-func (v *runtimeValueLocation) String() string {
+func (v *runtimeValueLocation) format(a *arch) string {
 	var location string
 	if v.onStack() {
 		location = fmt.Sprintf("stack(%d)", v.stackPointer)
 	} else if v.onConditionalRegister() {
 		location = fmt.Sprintf("conditional(%d)", v.conditionalRegister)
 	} else if v.onRegister() {
-		location = fmt.Sprintf("register(%s)", registerNameFn(v.register))
+		location = fmt.Sprintf("register(%s)", a.registerName(v.register))
 	}
 	return fmt.Sprintf("{type=%s,location=%s}", v.valueType, location)
 }
 
-func newRuntimeValueLocationStack() runtimeValueLocationStack {
-	return runtimeValueLocationStack{
-		unreservedVectorRegisters:         unreservedVectorRegisters,
-		unreservedGeneralPurposeRegisters: unreservedGeneralPurposeRegisters,
-	}
+func newRuntimeValueLocationStack(a *arch) runtimeValueLocationStack {
+	return runtimeValueLocationStack{arch: a}
 }
 
 // runtimeValueLocationStack represents the wazeroir virtual stack
@@ -135,26 +124,24 @@ type runtimeValueLocationStack struct {
 	usedRegisters usedRegistersMask
 	// stackPointerCeil tracks max(.sp) across the lifespan of this struct.
 	stackPointerCeil uint64
-	// unreservedGeneralPurposeRegisters and unreservedVectorRegisters hold
-	// architecture dependent unreserved register list.
-	unreservedGeneralPurposeRegisters, unreservedVectorRegisters []asm.Register
+	// arch holds the architecture dependent unreserved register list.
+	arch *arch
 }
 
 func (v *runtimeValueLocationStack) reset() {
 	stack := v.stack[:0]
-	*v = runtimeValueLocationStack{
-		unreservedVectorRegisters:         unreservedVectorRegisters,
-		unreservedGeneralPurposeRegisters: unreservedGeneralPurposeRegisters,
-		stack:                             stack,
-	}
+	*v = runtimeValueLocationStack{arch: v.arch, stack: stack}
 }
 
 func (v *runtimeValueLocationStack) String() string {
 	var stackStr []string
 	for i := uint64(0); i < v.sp; i++ {
-		stackStr = append(stackStr, v.stack[i].String())
+		stackStr = append(stackStr, v.stack[i].format(v.arch))
+	}
+	var usedRegisters []string
+	for _, r := range v.usedRegisters.list() {
+		usedRegisters = append(usedRegisters, v.arch.registerName(r))
 	}
-	usedRegisters := v.usedRegisters.list()
 	return fmt.Sprintf("sp=%d, stack=[%s], used_registers=[%s]", v.sp, strings.Join(stackStr, ","), strings.Join(usedRegisters, ","))
 }
 
@@ -280,9 +267,9 @@ func (v *runtimeValueLocationStack) takeFreeRegister(tp registerType) (reg asm.R
 	var targetRegs []asm.Register
 	switch tp {
 	case registerTypeVector:
-		targetRegs = v.unreservedVectorRegisters
+		targetRegs = v.arch.unreservedVectorRegisters
 	case registerTypeGeneralPurpose:
-		targetRegs = v.unreservedGeneralPurposeRegisters
+		targetRegs = v.arch.unreservedGeneralPurposeRegisters
 	}
 	for _, candidate := range targetRegs {
 		if v.usedRegisters.exist(candidate) {
@@ -304,11 +291,11 @@ func (v *runtimeValueLocationStack) takeStealTargetFromUsedRegister(tp registerT
 				if loc.valueType == runtimeValueTypeV128Hi {
 					panic("BUG: V128Hi must be above the corresponding V128Lo")
 				}
-				if isVectorRegister(loc.register) {
+				if v.arch.isVectorRegister(loc.register) {
 					return loc, true
 				}
 			case registerTypeGeneralPurpose:
-				if isGeneralPurposeRegister(loc.register) {
+				if v.arch.isGeneralPurposeRegister(loc.register) {
 					return loc, true
 				}
 			}
@@ -393,32 +380,32 @@ func (v *runtimeValueLocationStack) pushCallFrame(callTargetFunctionType *wasm.F
 	return
 }
 
-// usedRegistersMask tracks the used registers in its bits.
-type usedRegistersMask uint64
+// usedRegistersMask tracks the used registers in its bits, indexed by
+// asm.Register. This is independent of the architecture, as registers of
+// both amd64 and arm64 are less than 128.
+type usedRegistersMask [2]uint64
 
 // add adds the given `r` to the mask.
 func (u *usedRegistersMask) add(r asm.Register) {
-	*u = *u | (1 << registerMaskShift(r))
+	u[r/64] |= 1 << (r % 64)
 }
 
 // remove drops the given `r` from the mask.
 func (u *usedRegistersMask) remove(r asm.Register) {
-	*u = *u & ^(1 << registerMaskShift(r))
+	u[r/64] &^= 1 << (r % 64)
 }
 
 // exist returns true if the given `r` is used.
 func (u *usedRegistersMask) exist(r asm.Register) bool {
-	shift := registerMaskShift(r)
-	return (*u & (1 << shift)) > 0
+	return u[r/64]&(1<<(r%64)) != 0
 }
 
-// list returns the list of debug string of used registers.
+// list returns the used registers.
 // Only used for debugging and testing.
-func (u *usedRegistersMask) list() (ret []string) {
-	mask := *u
-	for i := 0; i < 64; i++ {
-		if mask&(1<<i) > 0 {
-			ret = append(ret, registerNameFn(registerFromMaskShift(i)))
+func (u *usedRegistersMask) list() (ret []asm.Register) {
+	for i := 0; i < 128; i++ {
+		if u.exist(asm.Register(i)) {
+			ret = append(ret, asm.Register(i))
 		}
 	}
 	return
@@ -9,32 +9,32 @@ import (
 )
 
 func Test_isIntRegister(t *testing.T) {
-	for _, r := range unreservedGeneralPurposeRegisters {
-		require.True(t, isGeneralPurposeRegister(r))
+	for _, r := range nativeArch.unreservedGeneralPurposeRegisters {
+		require.True(t, nativeArch.isGeneralPurposeRegister(r))
 	}
 }
 
 func Test_isVectorRegister(t *testing.T) {
-	for _, r := range unreservedVectorRegisters {
-		require.True(t, isVectorRegister(r))
+	for _, r := range nativeArch.unreservedVectorRegisters {
+		require.True(t, nativeArch.isVectorRegister(r))
 	}
 }
 
 func TestRuntimeValueLocationStack_basic(t *testing.T) {
-	s := newRuntimeValueLocationStack()
+	s := newRuntimeValueLocationStack(nativeArch)
 	// Push stack value.
 	loc := s.pushRuntimeValueLocationOnStack()
 	require.Equal(t, uint64(1), s.sp)
 	require.Equal(t, uint64(0), loc.stackPointer)
 	// Push the register value.
-	tmpReg := unreservedGeneralPurposeRegisters[0]
+	tmpReg := nativeArch.unreservedGeneralPurposeRegisters[0]
 	loc = s.pushRuntimeValueLocationOnRegister(tmpReg, runtimeValueTypeI64)
 	require.Equal(t, uint64(2), s.sp)
 	require.Equal(t, uint64(1), loc.stackPointer)
 	require.Equal(t, tmpReg, loc.register)
 	require.Equal(t, loc.valueType, runtimeValueTypeI64)
 	// markRegisterUsed.
-	tmpReg2 := unreservedGeneralPurposeRegisters[1]
+	tmpReg2 := nativeArch.unreservedGeneralPurposeRegisters[1]
 	s.markRegisterUsed(tmpReg2)
 	require.True(t, s.usedRegisters.exist(tmpReg2))
 	// releaseRegister.
@@ -52,13 +52,13 @@ func TestRuntimeValueLocationStack_basic(t *testing.T) {
 }
 
 func TestRuntimeValueLocationStack_takeFreeRegister(t *testing.T) {
-	s := newRuntimeValueLocationStack()
+	s := newRuntimeValueLocationStack(nativeArch)
 	// For int registers.
 	r, ok := s.takeFreeRegister(registerTypeGeneralPurpose)
 	require.True(t, ok)
-	require.True(t, isGeneralPurposeRegister(r))
+	require.True(t, nativeArch.isGeneralPurposeRegister(r))
 	// Mark all the int registers used.
-	for _, r := range unreservedGeneralPurposeRegisters {
+	for _, r := range nativeArch.unreservedGeneralPurposeRegisters {
 		s.markRegisterUsed(r)
 	}
 	// Now we cannot take free ones for int.
@@ -67,9 +67,9 @@ func TestRuntimeValueLocationStack_takeFreeRegister(t *testing.T) {
 	// But we still should be able to take float regs.
 	r, ok = s.takeFreeRegister(registerTypeVector)
 	require.True(t, ok)
-	require.True(t, isVectorRegister(r))
+	require.True(t, nativeArch.isVectorRegister(r))
 	// Mark all the float registers used.
-	for _, r := range unreservedVectorRegisters {
+	for _, r := range nativeArch.unreservedVectorRegisters {
 		s.markRegisterUsed(r)
 	}
 	// Now we cannot take free ones for floats.
@@ -78,9 +78,9 @@ func TestRuntimeValueLocationStack_takeFreeRegister(t *testing.T) {
 }
 
 func TestRuntimeValueLocationStack_takeStealTargetFromUsedRegister(t *testing.T) {
-	s := newRuntimeValueLocationStack()
-	intReg := unreservedGeneralPurposeRegisters[0]
-	floatReg := unreservedVectorRegisters[0]
+	s := newRuntimeValueLocationStack(nativeArch)
+	intReg := nativeArch.unreservedGeneralPurposeRegisters[0]
+	floatReg := nativeArch.unreservedVectorRegisters[0]
 	intLocation := s.push(intReg, asm.ConditionalRegisterStateUnset)
 	floatLocation := s.push(floatReg, asm.ConditionalRegisterStateUnset)
 	// Take for float.
@@ -170,7 +170,7 @@ func TestRuntimeValueLocationStack_setupInitialStack(t *testing.T) {
 	for _, tc := range tests {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			s := newRuntimeValueLocationStack()
+			s := newRuntimeValueLocationStack(nativeArch)
 			s.init(tc.sig)
 			require.Equal(t, tc.expectedSP, s.sp)
 
@@ -193,7 +193,7 @@ func TestRuntimeValueLocation_pushCallFrame(t *testing.T) {
 	} {
 		sig := sig
 		t.Run(sig.String(), func(t *testing.T) {
-			s := newRuntimeValueLocationStack()
+			s := newRuntimeValueLocationStack(nativeArch)
 			// pushCallFrame assumes that the parameters are already pushed.
 			for i := 0; i < sig.ParamNumInUint64; i++ {
 				_ = s.pushRuntimeValueLocationOnStack()
@@ -210,13 +210,14 @@ func TestRuntimeValueLocation_pushCallFrame(t *testing.T) {
 }
 
 func Test_usedRegistersMask(t *testing.T) {
-	for _, r := range append(unreservedVectorRegisters, unreservedGeneralPurposeRegisters...) {
-		mask := usedRegistersMask(0)
+	for _, r := range append(nativeArch.unreservedVectorRegisters, nativeArch.unreservedGeneralPurposeRegisters...) {
+		mask := usedRegistersMask{}
 		mask.add(r)
-		require.False(t, mask == 0)
+		require.False(t, mask == usedRegistersMask{})
 		require.True(t, mask.exist(r))
+		require.Equal(t, []asm.Register{r}, mask.list())
 		mask.remove(r)
-		require.True(t, mask == 0)
+		require.True(t, mask == usedRegistersMask{})
 		require.False(t, mask.exist(r))
 	}
 }
@@ -225,11 +226,11 @@ func TestRuntimeValueLocation_cloneFrom(t *testing.T) {
 	t.Run("sp<cap", func(t *testing.T) {
 		v := runtimeValueLocationStack{sp: 7, stack: make([]runtimeValueLocation, 5, 10)}
 		orig := v.stack
-		v.cloneFrom(runtimeValueLocationStack{sp: 3, usedRegisters: 0xffff, stack: []runtimeValueLocation{
+		v.cloneFrom(runtimeValueLocationStack{sp: 3, usedRegisters: usedRegistersMask{0xffff}, stack: []runtimeValueLocation{
 			{register: 3}, {register: 2}, {register: 1},
 		}})
 		require.Equal(t, uint64(3), v.sp)
-		require.Equal(t, usedRegistersMask(0xffff), v.usedRegisters)
+		require.Equal(t, usedRegistersMask{0xffff}, v.usedRegisters)
 		// Underlying stack shouldn't have changed since sp=3 < cap(v.stack).
 		require.Equal(t, &orig[0], &v.stack[0])
 		require.Equal(t, v.stack[0].register, asm.Register(3))
@@ -239,11 +240,11 @@ func TestRuntimeValueLocation_cloneFrom(t *testing.T) {
 	t.Run("sp=cap", func(t *testing.T) {
 		v := runtimeValueLocationStack{stack: make([]runtimeValueLocation, 0, 3)}
 		orig := v.stack[:cap(v.stack)]
-		v.cloneFrom(runtimeValueLocationStack{sp: 3, usedRegisters: 0xffff, stack: []runtimeValueLocation{
+		v.cloneFrom(runtimeValueLocationStack{sp: 3, usedRegisters: usedRegistersMask{0xffff}, stack: []runtimeValueLocation{
 			{register: 3}, {register: 2}, {register: 1},
 		}})
 		require.Equal(t, uint64(3), v.sp)
-		require.Equal(t, usedRegistersMask(0xffff), v.usedRegisters)
+		require.Equal(t, usedRegistersMask{0xffff}, v.usedRegisters)
 		// Underlying stack shouldn't have changed since sp=3==cap(v.stack).
 		require.Equal(t, &orig[0], &v.stack[0])
 		require.Equal(t, v.stack[0].register, asm.Register(3))
@@ -253,11 +254,11 @@ func TestRuntimeValueLocation_cloneFrom(t *testing.T) {
 	t.Run("sp>cap", func(t *testing.T) {
 		v := runtimeValueLocationStack{stack: make([]runtimeValueLocation, 0, 3)}
 		orig := v.stack[:cap(v.stack)]
-		v.cloneFrom(runtimeValueLocationStack{sp: 5, usedRegisters: 0xffff, stack: []runtimeValueLocation{
+		v.cloneFrom(runtimeValueLocationStack{sp: 5, usedRegisters: usedRegistersMask{0xffff}, stack: []runtimeValueLocation{
 			{register: 5}, {register: 4}, {register: 3}, {register: 2}, {register: 1},
 		}})
 		require.Equal(t, uint64(5), v.sp)
-		require.Equal(t, usedRegistersMask(0xffff), v.usedRegisters)
+		require.Equal(t, usedRegistersMask{0xffff}, v.usedRegisters)
 		// Underlying stack should have changed since sp=5>cap(v.stack).
 		require.NotEqual(t, &orig[0], &v.stack[0])
 		require.Equal(t, v.stack[0].register, asm.Register(5))
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"runtime"
 	"sort"
@@ -15,6 +16,7 @@ import (
 	"github.com/tetratelabs/wazero/internal/asm"
 	"github.com/tetratelabs/wazero/internal/bitpack"
 	"github.com/tetratelabs/wazero/internal/filecache"
+	"github.com/tetratelabs/wazero/internal/fuel"
 	"github.com/tetratelabs/wazero/internal/internalapi"
 	"github.com/tetratelabs/wazero/internal/platform"
 	"github.com/tetratelabs/wazero/internal/version"
@@ -37,6 +39,11 @@ type (
 		// setFinalizer defaults to runtime.SetFinalizer, but overridable for tests.
 		setFinalizer  func(obj interface{}, finalizer interface{})
 		wazeroVersion string
+		// arch is the architecture to generate code for, which is only
+		// runtime.GOARCH if modules can be instantiated.
+		arch *arch
+		// cpuFeatures are the capabilities of the target CPU.
+		cpuFeatures platform.CpuFeatureFlags
 	}
 
 	// moduleEngine implements wasm.ModuleEngine
@@ -69,6 +76,7 @@ type (
 		moduleContext
 		stackContext
 		exitContext
+		fuelContext
 		archContext
 
 		// The following fields are not accessed by compiled code directly.
@@ -141,6 +149,21 @@ type (
 		// stackIterator provides a way to iterate over the stack for Listeners.
 		// It is setup and valid only during a call to a Listener hook.
 		stackIterator stackIterator
+
+		// fuelMeter is the meter of the current call, or nil if it is unlimited.
+		fuelMeter *fuel.Meter
+
+		// done is the Done channel of the context of the current call if it
+		// fails when the context is done, instead of closing the module.
+		done <-chan struct{}
+
+		// stackCeiling is the maximum length of stack, which is
+		// callStackCeiling unless the module instance of initialFn limits it.
+		stackCeiling uint64
+
+		// resumed is set by callEngine.Resume for the call to continue after
+		// the host function which suspended it, instead of calling initialFn.
+		resumed bool
 	}
 
 	// moduleContext holds the per-function call specific module information.
@@ -233,6 +256,16 @@ type (
 		callerModuleInstance *wasm.ModuleInstance
 	}
 
+	// fuelContext holds the fuel consumed by wazeroir.OperationConsumeFuel.
+	fuelContext struct {
+		// See note at top of file before modifying this struct.
+
+		// remainingFuel is the fuel remaining to the current call, which is
+		// stored to callEngine.fuelMeter before calling host functions and
+		// returning.
+		remainingFuel int64
+	}
+
 	// callFrame holds the information to which the caller function can return.
 	// This is mixed in callEngine.stack with other Wasm values just like any other
 	// native program (where the stack is the system stack though), and we retrieve the struct
@@ -369,12 +402,19 @@ const (
 	callEngineExitContextReturnAddressOffset            = 128
 	callEngineExitContextCallerModuleInstanceOffset     = 136
 
+	// Offsets for callEngine fuelContext.
+	callEngineFuelContextRemainingFuelOffset = 144
+
 	// Offsets for function.
 	functionCodeInitialAddressOffset = 0
 	functionModuleInstanceOffset     = 8
 	functionTypeIDOffset             = 16
+	functionParentOffset             = 32
 	functionSize                     = 40
 
+	// Offsets for compiledFunction.
+	compiledFunctionGoFuncOffset = 24
+
 	// Offsets for wasm.ModuleInstance.
 	moduleInstanceGlobalsOffset          = 24
 	moduleInstanceMemoryOffset           = 48
@@ -438,6 +478,12 @@ const (
 	nativeCallStatusIntegerOverflow
 	nativeCallStatusIntegerDivisionByZero
 	nativeCallStatusModuleClosed
+	// nativeCallStatusCodeFuelExhausted means the remaining fuel became negative.
+	nativeCallStatusCodeFuelExhausted
+	// nativeCallStatusCodeNullFunctionReference means call_ref was executed on a null function reference.
+	nativeCallStatusCodeNullFunctionReference
+	// nativeCallStatusCodeNullReference means ref.as_non_null was executed on a null reference.
+	nativeCallStatusCodeNullReference
 )
 
 // causePanic causes a panic with the corresponding error to the nativeCallStatusCode.
@@ -458,6 +504,12 @@ func (s nativeCallStatusCode) causePanic() {
 		err = wasmruntime.ErrRuntimeInvalidTableAccess
 	case nativeCallStatusCodeTypeMismatchOnIndirectCall:
 		err = wasmruntime.ErrRuntimeIndirectCallTypeMismatch
+	case nativeCallStatusCodeFuelExhausted:
+		err = wasmruntime.ErrRuntimeFuelExhausted
+	case nativeCallStatusCodeNullFunctionReference:
+		err = wasmruntime.ErrRuntimeNullFunctionReference
+	case nativeCallStatusCodeNullReference:
+		err = wasmruntime.ErrRuntimeNullReference
 	}
 	panic(err)
 }
@@ -486,6 +538,12 @@ func (s nativeCallStatusCode) String() (ret string) {
 		ret = "integer division by zero"
 	case nativeCallStatusModuleClosed:
 		ret = "module closed"
+	case nativeCallStatusCodeFuelExhausted:
+		ret = "fuel exhausted"
+	case nativeCallStatusCodeNullFunctionReference:
+		ret = "null function reference"
+	case nativeCallStatusCodeNullReference:
+		ret = "null reference"
 	default:
 		panic("BUG")
 	}
@@ -521,15 +579,24 @@ func (e *engine) Close() (err error) {
 	return
 }
 
+// errExceptionHandling is returned when compiling a module which uses
+// experimental.CoreFeaturesExceptionHandling, as only the interpreter
+// implements unwinding to a catch.
+var errExceptionHandling = errors.New("exception handling is only supported by the interpreter")
+
 // CompileModule implements the same method as documented on wasm.Engine.
-func (e *engine) CompileModule(_ context.Context, module *wasm.Module, listeners []experimental.FunctionListener, ensureTermination bool) error {
+func (e *engine) CompileModule(ctx context.Context, module *wasm.Module, listeners []experimental.FunctionListener, ensureTermination bool) error {
 	if _, ok, err := e.getCompiledModule(module, listeners); ok { // cache hit!
 		return nil
 	} else if err != nil {
 		return err
 	}
 
-	irCompiler, err := wazeroir.NewCompiler(e.enabledFeatures, callFrameDataSizeInUint64, module, ensureTermination)
+	if module.UsesExceptionHandling {
+		return errExceptionHandling
+	}
+
+	irCompiler, err := wazeroir.NewCompiler(e.enabledFeatures, callFrameDataSizeInUint64, module, ensureTermination, fuel.CostsFromContext(ctx))
 	if err != nil {
 		return err
 	}
@@ -551,7 +618,7 @@ func (e *engine) CompileModule(_ context.Context, module *wasm.Module, listeners
 	// As this uses mmap, we need to munmap on the compiled machine code when it's GCed.
 	e.setFinalizer(cm, releaseCompiledModule)
 	ln := len(listeners)
-	cmp := newCompiler()
+	cmp := e.arch.newCompiler(e.cpuFeatures)
 	asmNodes := new(asmNodes)
 	offsets := new(offsets)
 
@@ -616,6 +683,9 @@ func (e *engine) CompileModule(_ context.Context, module *wasm.Module, listeners
 
 // NewModuleEngine implements the same method as documented on wasm.Engine.
 func (e *engine) NewModuleEngine(module *wasm.Module, instance *wasm.ModuleInstance) (wasm.ModuleEngine, error) {
+	if e.arch != nativeArch {
+		return nil, fmt.Errorf("cannot instantiate a module compiled for %s on %s", e.arch.goarch, runtime.GOARCH)
+	}
 	me := &moduleEngine{
 		functions: make([]function, len(module.FunctionSection)+int(module.ImportFunctionCount)),
 	}
@@ -740,9 +810,14 @@ func (ce *callEngine) CallWithStack(ctx context.Context, stack []uint64) error {
 
 func (ce *callEngine) call(ctx context.Context, params, results []uint64) (_ []uint64, err error) {
 	m := ce.initialFn.moduleInstance
+	ce.done = nil
 	if ce.module.ensureTermination {
+		ce.done = wasm.CancelCallDone(ctx)
 		select {
 		case <-ctx.Done():
+			if ce.done != nil {
+				return nil, wasmruntime.NewContextDone(ctx.Err())
+			}
 			// If the provided context is already done, close the call context
 			// and return the error.
 			m.CloseWithCtxErr(ctx)
@@ -755,7 +830,11 @@ func (ce *callEngine) call(ctx context.Context, params, results []uint64) (_ []u
 	// this Call method is indirectly invoked by embedders via store.CallFunction,
 	// and we have to make sure that all the runtime errors, including the one happening inside
 	// host functions, will be captured as errors, not panics.
+	ce.fuelMeter = fuel.MeterFromContext(ctx)
+	ce.remainingFuel = ce.fuelMeter.Load()
+
 	defer func() {
+		ce.fuelMeter.Store(ce.remainingFuel)
 		err = ce.deferredOnCall(ctx, m, recover())
 		if err == nil {
 			// If the module closed during the call, and the call didn't err for another reason, set an ExitError.
@@ -766,14 +845,24 @@ func (ce *callEngine) call(ctx context.Context, params, results []uint64) (_ []u
 	}()
 
 	ft := ce.initialFn.funcType
-	ce.initializeStack(ft, params)
+	codeAddr, modAddr := ce.initialFn.codeInitialAddress, ce.initialFn.moduleInstance
+	if ce.resumed {
+		// params are the results of the host function which suspended the
+		// call, so place them as execWasmFunction would, and return to its
+		// caller.
+		ce.resumed = false
+		copy(ce.stack[ce.stackBasePointerInBytes>>3:], params)
+		codeAddr, modAddr = ce.returnAddress, ce.moduleInstance
+	} else {
+		ce.initializeStack(ft, params)
+	}
 
-	if ce.module.ensureTermination {
+	if ce.module.ensureTermination && ce.done == nil {
 		done := m.CloseModuleOnCanceledOrTimeout(ctx)
 		defer done()
 	}
 
-	ce.execWasmFunction(ctx, m)
+	ce.execWasmFunction(ctx, m, codeAddr, modAddr)
 
 	// This returns a safe copy of the results, instead of a slice view. If we
 	// returned a re-slice, the caller could accidentally or purposefully
@@ -839,7 +928,13 @@ func callFrameOffset(funcType *wasm.FunctionType) (ret int) {
 //
 // This is defined for testability.
 func (ce *callEngine) deferredOnCall(ctx context.Context, m *wasm.ModuleInstance, recovered interface{}) (err error) {
+	if wasm.IsAwait(recovered) {
+		err, recovered = ce.suspend(m, recovered), nil
+	}
 	if recovered != nil {
+		if wasm.IsSuspend(recovered) {
+			recovered = wasm.ErrRuntimeSuspendUnsupported
+		}
 		builder := wasmdebug.NewErrorBuilder()
 
 		// Unwinds call frames from the values stack, starting from the
@@ -861,7 +956,7 @@ func (ce *callEngine) deferredOnCall(ctx context.Context, m *wasm.ModuleInstance
 					sources = p.parent.source.DWARFLines.Line(offset)
 				}
 			}
-			builder.AddFrame(def.DebugName(), def.ParamTypes(), def.ResultTypes(), sources)
+			builder.AddFrame(def.DebugName(), def.ParamTypes(), def.ParamNames(), def.ResultTypes(), sources)
 
 			if fn.parent.listener != nil {
 				functionListeners = append(functionListeners, functionListenerInvocation{
@@ -893,6 +988,38 @@ func (ce *callEngine) deferredOnCall(ctx context.Context, m *wasm.ModuleInstance
 	return
 }
 
+// suspend returns a wasm.SuspendedError with the state of the call, which a
+// host function suspended by panicking `recovered` from wasm.Await.
+//
+// Native code points into the stack of the call, so this moves the callEngine
+// with it to the wasm.CallState, and gives the callEngine a new stack for the
+// subsequent calls.
+func (ce *callEngine) suspend(m *wasm.ModuleInstance, recovered interface{}) error {
+	suspended := *ce
+	host := ce.moduleContext.fn
+	call := &wasm.CallState{
+		FuncIndex:             ce.initialFn.parent.index,
+		HostParamNumInUint64:  host.funcType.ParamNumInUint64,
+		HostResultNumInUint64: host.funcType.ResultNumInUint64,
+		Engine:                &suspended,
+	}
+
+	ce.stack = make([]uint64, len(ce.stack))
+	stackHeader := (*reflect.SliceHeader)(unsafe.Pointer(&ce.stack))
+	ce.stackContext = stackContext{
+		stackElement0Address: stackHeader.Data,
+		stackLenInBytes:      uint64(stackHeader.Len) << 3,
+	}
+	return wasm.NewSuspendedError(m, call, recovered)
+}
+
+// Resume implements wasm.Resumer for the call suspended by wasm.Await, as
+// returned by suspend.
+func (ce *callEngine) Resume(ctx context.Context, _ *wasm.CallState, results []uint64) ([]uint64, error) {
+	ce.resumed = true
+	return ce.call(ctx, results, nil)
+}
+
 // getSourceOffsetInWasmBinary returns the corresponding offset in the original Wasm binary's code section
 // for the given pc (which is an absolute address in the memory).
 // If needPreviousInstr equals true, this returns the previous instruction's offset for the given pc.
@@ -940,6 +1067,22 @@ func NewEngine(_ context.Context, enabledFeatures api.CoreFeatures, fileCache fi
 	return newEngine(enabledFeatures, fileCache)
 }
 
+// NewEngineForTarget returns an engine which compiles modules for `goarch`,
+// without a file cache. Modules compiled for an architecture other than
+// runtime.GOARCH can be serialized, but not instantiated.
+//
+// Code generated for amd64 only uses the CPU features required by wazero, as
+// the target CPU is unknown.
+func NewEngineForTarget(_ context.Context, enabledFeatures api.CoreFeatures, goarch string) (wasm.Engine, error) {
+	a, ok := archs[goarch]
+	if !ok {
+		return nil, fmt.Errorf("unsupported GOARCH %s", goarch)
+	}
+	e := newEngine(enabledFeatures, nil)
+	e.arch, e.cpuFeatures = a, platform.BaselineCpuFeatures
+	return e, nil
+}
+
 func newEngine(enabledFeatures api.CoreFeatures, fileCache filecache.Cache) *engine {
 	return &engine{
 		enabledFeatures: enabledFeatures,
@@ -947,6 +1090,8 @@ func newEngine(enabledFeatures api.CoreFeatures, fileCache filecache.Cache) *eng
 		setFinalizer:    runtime.SetFinalizer,
 		fileCache:       fileCache,
 		wazeroVersion:   version.GetWazeroVersion(),
+		arch:            nativeArch,
+		cpuFeatures:     nativeCpuFeatures,
 	}
 }
 
@@ -986,6 +1131,10 @@ func (e *moduleEngine) newCallEngine(stackSize uint64, fn *function) *callEngine
 		initialFn:     fn,
 		moduleContext: moduleContext{fn: fn},
 		module:        e.module,
+		stackCeiling:  callStackCeiling,
+	}
+	if m := fn.moduleInstance; m != nil && m.CallStackLimits.MaxSize != 0 {
+		ce.stackCeiling = m.CallStackLimits.MaxSize >> 3
 	}
 
 	stackHeader := (*reflect.SliceHeader)(unsafe.Pointer(&ce.stack))
@@ -1018,14 +1167,12 @@ const (
 	builtinFunctionIndexFunctionListenerBefore
 	builtinFunctionIndexFunctionListenerAfter
 	builtinFunctionIndexCheckExitCode
+	builtinFunctionIndexAtomic
 	// builtinFunctionIndexBreakPoint is internal (only for wazero developers). Disabled by default.
 	builtinFunctionIndexBreakPoint
 )
 
-func (ce *callEngine) execWasmFunction(ctx context.Context, m *wasm.ModuleInstance) {
-	codeAddr := ce.initialFn.codeInitialAddress
-	modAddr := ce.initialFn.moduleInstance
-
+func (ce *callEngine) execWasmFunction(ctx context.Context, m *wasm.ModuleInstance, codeAddr uintptr, modAddr *wasm.ModuleInstance) {
 entry:
 	{
 		// Call into the native code.
@@ -1047,6 +1194,8 @@ entry:
 			}
 			stack := ce.stack[base : base+stackLen]
 
+			// The host function can query or add fuel, or make nested calls.
+			ce.fuelMeter.Store(ce.remainingFuel)
 			fn := calleeHostFunction.parent.goFunc
 			switch fn := fn.(type) {
 			case api.GoModuleFunction:
@@ -1054,6 +1203,7 @@ entry:
 			case api.GoFunction:
 				fn.Call(ctx, stack)
 			}
+			ce.remainingFuel = ce.fuelMeter.Load()
 
 			codeAddr, modAddr = ce.returnAddress, ce.moduleInstance
 			goto entry
@@ -1077,6 +1227,13 @@ entry:
 				if err := m.FailIfClosed(); err != nil {
 					panic(err)
 				}
+				select {
+				case <-ce.done:
+					panic(wasmruntime.NewContextDone(ctx.Err()))
+				default:
+				}
+			case builtinFunctionIndexAtomic:
+				ce.builtinFunctionAtomic(caller.moduleInstance)
 			}
 			if false {
 				if ce.exitContext.builtinFunctionCallIndex == builtinFunctionIndexBreakPoint {
@@ -1095,15 +1252,14 @@ entry:
 // callStackCeiling is the maximum WebAssembly call frame stack height. This allows wazero to raise
 // wasm.ErrCallStackOverflow instead of overflowing the Go runtime.
 //
-// The default value should suffice for most use cases. Those wishing to change this can via `go build -ldflags`.
-//
-// TODO: allows to configure this via context?
+// The default value should suffice for most use cases. Those wishing to change this can via `go build -ldflags`, or
+// per module with wasm.ModuleInstance CallStackLimits.
 var callStackCeiling = uint64(5000000) // in uint64 (8 bytes) == 40000000 bytes in total == 40mb.
 
 func (ce *callEngine) builtinFunctionGrowStack(stackPointerCeil uint64) {
 	oldLen := uint64(len(ce.stack))
-	if callStackCeiling < oldLen {
-		panic(wasmruntime.ErrRuntimeStackOverflow)
+	if ce.stackCeiling < oldLen {
+		panic(wasmruntime.NewStackOverflow(fmt.Sprintf("maximum stack size of %d bytes", ce.stackCeiling<<3)))
 	}
 
 	// Extends the stack's length to oldLen*2+stackPointerCeil.
@@ -1120,7 +1276,15 @@ func (ce *callEngine) builtinFunctionGrowStack(stackPointerCeil uint64) {
 func (ce *callEngine) builtinFunctionMemoryGrow(mem *wasm.MemoryInstance) {
 	newPages := ce.popValue()
 
-	if res, ok := mem.Grow(uint32(newPages)); !ok {
+	if mem.Is64 {
+		if newPages > math.MaxUint32 {
+			ce.pushValue(math.MaxUint64) // = -1 in signed 64-bit integer.
+		} else if res, ok := mem.Grow(uint32(newPages)); !ok {
+			ce.pushValue(math.MaxUint64)
+		} else {
+			ce.pushValue(uint64(res))
+		}
+	} else if res, ok := mem.Grow(uint32(newPages)); !ok {
 		ce.pushValue(uint64(0xffffffff)) // = -1 in signed 32-bit integer.
 	} else {
 		ce.pushValue(uint64(res))
@@ -1141,6 +1305,101 @@ func (ce *callEngine) builtinFunctionTableGrow(tables []*wasm.TableInstance) {
 	ce.pushValue(uint64(res))
 }
 
+// atomicOperation returns the descriptor of the atomic operation `o`, which
+// compileAtomic pushes for builtinFunctionAtomic, followed by the number of
+// operands it pops, including the address, and the type of its result.
+func atomicOperation(o *wazeroir.UnionOperation) (descriptor uint64, operands int, result runtimeValueType, hasResult bool) {
+	descriptor = uint64(o.Kind) | uint64(o.B1)<<16 | uint64(o.B2)<<24 | o.U3<<32
+	switch o.Kind {
+	case wazeroir.OperationKindAtomicMemoryWait:
+		return descriptor, 3, runtimeValueTypeI32, true
+	case wazeroir.OperationKindAtomicMemoryNotify:
+		return descriptor, 2, runtimeValueTypeI32, true
+	case wazeroir.OperationKindAtomicFence:
+		return descriptor, 0, 0, false
+	case wazeroir.OperationKindAtomicStore:
+		return descriptor, 2, 0, false
+	}
+
+	result = runtimeValueTypeI32
+	if wazeroir.UnsignedType(o.B1) == wazeroir.UnsignedTypeI64 {
+		result = runtimeValueTypeI64
+	}
+	switch o.Kind {
+	case wazeroir.OperationKindAtomicLoad:
+		operands = 1
+	case wazeroir.OperationKindAtomicRMW:
+		operands = 2
+	default: // wazeroir.OperationKindAtomicRMWCmpxchg
+		operands = 3
+	}
+	return descriptor, operands, result, true
+}
+
+// builtinFunctionAtomic executes the atomic operation on the memory of `m`
+// whose offset and descriptor (see atomicOperation) are on top of its
+// operands, as atomic instructions are serialized by wasm.MemoryInstance.
+func (ce *callEngine) builtinFunctionAtomic(m *wasm.ModuleInstance) {
+	mem := m.MemoryInstance
+	descriptor := ce.popValue()
+	offset := ce.popValue()
+	kind := wazeroir.OperationKind(uint16(descriptor))
+	is32 := wazeroir.UnsignedType(descriptor>>16) == wazeroir.UnsignedTypeI32
+	size := uint32(byte(descriptor >> 24))
+
+	switch kind {
+	case wazeroir.OperationKindAtomicMemoryWait:
+		timeout := int64(ce.popValue())
+		expected := ce.popValue()
+		size = 8
+		if is32 {
+			expected, size = uint64(uint32(expected)), 4
+		}
+		addr := ce.popAtomicAddress(mem, offset)
+		ce.pushValue(mem.Wait(addr, size, expected, timeout, m.Done()))
+		if err := m.FailIfClosed(); err != nil {
+			panic(err)
+		}
+	case wazeroir.OperationKindAtomicMemoryNotify:
+		count := uint32(ce.popValue())
+		addr := ce.popAtomicAddress(mem, offset)
+		ce.pushValue(uint64(mem.Notify(addr, count)))
+	case wazeroir.OperationKindAtomicFence:
+		if mem != nil {
+			mem.AtomicFence()
+		}
+	case wazeroir.OperationKindAtomicLoad:
+		addr := ce.popAtomicAddress(mem, offset)
+		ce.pushValue(mem.AtomicLoad(addr, size))
+	case wazeroir.OperationKindAtomicStore:
+		v := ce.popValue()
+		addr := ce.popAtomicAddress(mem, offset)
+		mem.AtomicStore(addr, size, v)
+	case wazeroir.OperationKindAtomicRMW:
+		v := ce.popValue()
+		addr := ce.popAtomicAddress(mem, offset)
+		ce.pushValue(mem.AtomicRMW(wasm.AtomicRMWOp(descriptor>>32), addr, size, v))
+	case wazeroir.OperationKindAtomicRMWCmpxchg:
+		replacement := ce.popValue()
+		expected := ce.popValue()
+		addr := ce.popAtomicAddress(mem, offset)
+		ce.pushValue(mem.AtomicCmpxchg(addr, size, expected, replacement))
+	}
+}
+
+// popAtomicAddress pops the address of an atomic operation, and returns it
+// plus `offset`, or panics if the sum is out of the range of memory addresses.
+func (ce *callEngine) popAtomicAddress(mem *wasm.MemoryInstance, offset uint64) uint32 {
+	addr := ce.popValue()
+	if !mem.Is64 {
+		addr = uint64(uint32(addr))
+	}
+	if addr += offset; addr > math.MaxUint32 || addr < offset {
+		panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+	}
+	return uint32(addr)
+}
+
 // stackIterator implements experimental.StackIterator.
 type stackIterator struct {
 	stack   []uint64
@@ -1295,6 +1554,16 @@ func compileWasmFunction(buf asm.Buffer, cmp compiler, ir *wazeroir.CompilationR
 			err = cmp.compileCall(op)
 		case wazeroir.OperationKindCallIndirect:
 			err = cmp.compileCallIndirect(op)
+		case wazeroir.OperationKindTailCall:
+			err = cmp.compileTailCall(op)
+		case wazeroir.OperationKindTailCallIndirect:
+			err = cmp.compileTailCallIndirect(op)
+		case wazeroir.OperationKindCallRef:
+			err = cmp.compileCallRef(op)
+		case wazeroir.OperationKindTailCallRef:
+			err = cmp.compileTailCallRef(op)
+		case wazeroir.OperationKindRefAsNonNull:
+			err = cmp.compileRefAsNonNull()
 		case wazeroir.OperationKindDrop:
 			err = cmp.compileDrop(op)
 		case wazeroir.OperationKindSelect:
@@ -1559,6 +1828,12 @@ func compileWasmFunction(buf asm.Buffer, cmp compiler, ir *wazeroir.CompilationR
 			err = cmp.compileV128ITruncSatFromF(op)
 		case wazeroir.OperationKindBuiltinFunctionCheckExitCode:
 			err = cmp.compileBuiltinFunctionCheckExitCode()
+		case wazeroir.OperationKindConsumeFuel:
+			err = cmp.compileConsumeFuel(op)
+		case wazeroir.OperationKindAtomicMemoryWait, wazeroir.OperationKindAtomicMemoryNotify,
+			wazeroir.OperationKindAtomicFence, wazeroir.OperationKindAtomicLoad, wazeroir.OperationKindAtomicStore,
+			wazeroir.OperationKindAtomicRMW, wazeroir.OperationKindAtomicRMWCmpxchg:
+			err = cmp.compileAtomic(op)
 		default:
 			err = errors.New("unsupported")
 		}
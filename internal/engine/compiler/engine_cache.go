@@ -3,11 +3,13 @@ package compiler
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"runtime"
 
 	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/perfmap"
 	"github.com/tetratelabs/wazero/internal/platform"
 	"github.com/tetratelabs/wazero/internal/u32"
 	"github.com/tetratelabs/wazero/internal/u64"
@@ -57,6 +59,20 @@ func (e *engine) addCompiledModuleToMemory(module *wasm.Module, cm *compiledModu
 	e.mux.Lock()
 	defer e.mux.Unlock()
 	e.codes[module.ID] = cm
+	if perfmap.Enabled {
+		addCompiledModuleToPerfMap(module, cm)
+	}
+}
+
+// addCompiledModuleToPerfMap writes the functions of cm to the perf map.
+func addCompiledModuleToPerfMap(module *wasm.Module, cm *compiledModule) {
+	offsets := make([]int, len(cm.functions))
+	for i := range cm.functions {
+		offsets[i] = int(cm.functions[i].executableOffset)
+	}
+	perfmap.AddModule(cm.executable.Addr(), int(cm.executable.Size()), offsets, func(i int) string {
+		return module.FunctionDefinition(cm.functions[i].index).DebugName()
+	})
 }
 
 func (e *engine) getCompiledModuleFromMemory(module *wasm.Module) (cm *compiledModule, ok bool) {
@@ -102,6 +118,45 @@ func (e *engine) getCompiledModuleFromCache(module *wasm.Module) (cm *compiledMo
 	return
 }
 
+// SerializeCompiledModule implements the same method as documented on
+// wasm.EngineSerializer.
+func (e *engine) SerializeCompiledModule(module *wasm.Module) ([]byte, error) {
+	cm, ok := e.getCompiledModuleFromMemory(module)
+	if !ok {
+		return nil, errors.New("source module must be compiled before serialization")
+	}
+	for i := range cm.functions {
+		if cm.functions[i].goFunc != nil {
+			return nil, errors.New("cannot serialize a module with host functions")
+		}
+	}
+	return io.ReadAll(serializeCompiledModule(e.wazeroVersion, cm))
+}
+
+// DeserializeCompiledModule implements the same method as documented on
+// wasm.EngineSerializer.
+func (e *engine) DeserializeCompiledModule(module *wasm.Module, serialized []byte, listeners []experimental.FunctionListener) error {
+	if _, ok := e.getCompiledModuleFromMemory(module); ok {
+		return nil
+	}
+	cm, stale, err := deserializeCompiledModule(e.wazeroVersion, io.NopCloser(bytes.NewReader(serialized)), module)
+	if err != nil {
+		return err
+	} else if stale {
+		return fmt.Errorf("compiled module is not from wazero %s", e.wazeroVersion)
+	} else if len(cm.functions) != len(module.FunctionSection) {
+		return fmt.Errorf("compiled module has %d functions, but the source has %d", len(cm.functions), len(module.FunctionSection))
+	}
+	cm.source = module
+	for i := range listeners {
+		cm.functions[i].listener = listeners[i]
+	}
+	e.addCompiledModuleToMemory(module, cm)
+	// As this uses mmap, we need to munmap on the compiled machine code when it's GCed.
+	e.setFinalizer(cm, releaseCompiledModule)
+	return nil
+}
+
 var wazeroMagic = "WAZERO" // version must be synced with the tag of the wazero library.
 
 func serializeCompiledModule(wazeroVersion string, cm *compiledModule) io.Reader {
@@ -91,6 +91,56 @@ func TestCompiler_CompileModule(t *testing.T) {
 		_, ok := e.codes[errModule.ID]
 		require.False(t, ok)
 	})
+
+	t.Run("exception handling", func(t *testing.T) {
+		ehModule := &wasm.Module{
+			TypeSection:           []wasm.FunctionType{{}},
+			FunctionSection:       []wasm.Index{0},
+			CodeSection:           []wasm.Code{{Body: []byte{wasm.OpcodeEnd}}},
+			UsesExceptionHandling: true,
+		}
+
+		e := NewEngine(testCtx, api.CoreFeaturesV2, nil).(*engine)
+		err := e.CompileModule(testCtx, ehModule, nil, false)
+		require.Equal(t, errExceptionHandling, err)
+	})
+}
+
+func TestNewEngineForTarget(t *testing.T) {
+	requireSupportedOSArch(t)
+
+	m := &wasm.Module{
+		TypeSection:     []wasm.FunctionType{{Params: []wasm.ValueType{wasm.ValueTypeI32}, Results: []wasm.ValueType{wasm.ValueTypeI32}, ParamNumInUint64: 1, ResultNumInUint64: 1}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []wasm.Code{
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add, wasm.OpcodeEnd}},
+		},
+	}
+
+	executables := map[string][]byte{}
+	for goarch := range archs {
+		goarch := goarch
+		t.Run(goarch, func(t *testing.T) {
+			e, err := NewEngineForTarget(testCtx, api.CoreFeaturesV2, goarch)
+			require.NoError(t, err)
+			defer e.Close()
+
+			require.NoError(t, e.CompileModule(testCtx, m, nil, false))
+			cm := e.(*engine).codes[m.ID]
+			executables[goarch] = append([]byte{}, cm.executable.Bytes()...)
+
+			if goarch != runtime.GOARCH {
+				_, err = e.NewModuleEngine(m, nil)
+				require.EqualError(t, err, fmt.Sprintf("cannot instantiate a module compiled for %s on %s", goarch, runtime.GOARCH))
+			}
+		})
+	}
+	require.NotEqual(t, executables["amd64"], executables["arm64"])
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := NewEngineForTarget(testCtx, api.CoreFeaturesV2, "riscv64")
+		require.EqualError(t, err, "unsupported GOARCH riscv64")
+	})
 }
 
 func TestCompiler_Releasecode_Panic(t *testing.T) {
@@ -140,7 +140,7 @@ func TestAmd64Compiler_compile_Mul_Div_Rem(t *testing.T) {
 						const x2Value uint32 = 51
 						const dxValue uint64 = 111111
 
-						compiler := env.requireNewCompiler(t, &wasm.FunctionType{}, newAmd64Compiler, nil).(*amd64Compiler)
+						compiler := env.requireNewCompiler(t, &wasm.FunctionType{}, newCompiler, nil).(*amd64Compiler)
 
 						// To make the assertion below stable, we preallocate the underlying stack,
 						// so that the pointer to the entry will be stale.
@@ -273,7 +273,7 @@ func TestAmd64Compiler_compile_Mul_Div_Rem(t *testing.T) {
 						const dxValue uint64 = 111111
 
 						env := newCompilerEnvironment()
-						compiler := env.requireNewCompiler(t, &wasm.FunctionType{}, newAmd64Compiler, nil).(*amd64Compiler)
+						compiler := env.requireNewCompiler(t, &wasm.FunctionType{}, newCompiler, nil).(*amd64Compiler)
 
 						// To make the assertion below stable, we preallocate the underlying stack,
 						// so that the pointer to the entry will be stale.
@@ -361,7 +361,7 @@ func TestAmd64Compiler_compile_Mul_Div_Rem(t *testing.T) {
 func TestAmd64Compiler_readInstructionAddress(t *testing.T) {
 	t.Run("invalid", func(t *testing.T) {
 		env := newCompilerEnvironment()
-		compiler := env.requireNewCompiler(t, &wasm.FunctionType{}, newAmd64Compiler, nil).(*amd64Compiler)
+		compiler := env.requireNewCompiler(t, &wasm.FunctionType{}, newCompiler, nil).(*amd64Compiler)
 
 		err := compiler.compilePreamble()
 		require.NoError(t, err)
@@ -380,7 +380,7 @@ func TestAmd64Compiler_readInstructionAddress(t *testing.T) {
 
 	t.Run("ok", func(t *testing.T) {
 		env := newCompilerEnvironment()
-		compiler := env.requireNewCompiler(t, &wasm.FunctionType{}, newAmd64Compiler, nil).(*amd64Compiler)
+		compiler := env.requireNewCompiler(t, &wasm.FunctionType{}, newCompiler, nil).(*amd64Compiler)
 
 		err := compiler.compilePreamble()
 		require.NoError(t, err)
@@ -424,7 +424,7 @@ func TestAmd64Compiler_readInstructionAddress(t *testing.T) {
 
 func TestAmd64Compiler_preventCrossedTargetdRegisters(t *testing.T) {
 	env := newCompilerEnvironment()
-	compiler := env.requireNewCompiler(t, &wasm.FunctionType{}, newAmd64Compiler, nil).(*amd64Compiler)
+	compiler := env.requireNewCompiler(t, &wasm.FunctionType{}, newCompiler, nil).(*amd64Compiler)
 
 	tests := []struct {
 		initial           []*runtimeValueLocation
@@ -633,7 +633,7 @@ func TestAmd64Compiler_label(t *testing.T) {
 
 func TestAmd64Compiler_Init(t *testing.T) {
 	c := &amd64Compiler{
-		locationStackForEntrypoint: newRuntimeValueLocationStack(),
+		locationStackForEntrypoint: newRuntimeValueLocationStack(nativeArch),
 		assembler:                  amd64.NewAssembler(),
 	}
 	const stackCap = 12345
@@ -650,7 +650,7 @@ func TestAmd64Compiler_Init(t *testing.T) {
 }
 
 func TestAmd64Compiler_resetLabels(t *testing.T) {
-	c := newAmd64Compiler().(*amd64Compiler)
+	c := newCompiler().(*amd64Compiler)
 	nop := c.compileNOP()
 
 	const (
@@ -664,7 +664,7 @@ func TestAmd64Compiler_resetLabels(t *testing.T) {
 		for j := 0; j <= frameIDMax; j++ {
 			ifs[j].stackInitialized = true
 			ifs[j].initialInstruction = nop
-			ifs[j].initialStack = newRuntimeValueLocationStack()
+			ifs[j].initialStack = newRuntimeValueLocationStack(nativeArch)
 			ifs[j].initialStack.sp = 5555 // should be cleared via runtimeLocationStack.Reset().
 			ifs[j].initialStack.stack = make([]runtimeValueLocation, 0, capacity)
 		}
@@ -688,7 +688,7 @@ func TestAmd64Compiler_resetLabels(t *testing.T) {
 
 func TestAmd64Compiler_getSavedTemporaryLocationStack(t *testing.T) {
 	t.Run("len(brTableTmp)<len(current)", func(t *testing.T) {
-		st := newRuntimeValueLocationStack()
+		st := newRuntimeValueLocationStack(nativeArch)
 		c := &amd64Compiler{locationStack: &st}
 
 		c.locationStack.sp = 3
@@ -700,7 +700,7 @@ func TestAmd64Compiler_getSavedTemporaryLocationStack(t *testing.T) {
 		require.Equal(t, c.locationStack.stack[:3], actual.stack)
 	})
 	t.Run("len(brTableTmp)==len(current)", func(t *testing.T) {
-		st := newRuntimeValueLocationStack()
+		st := newRuntimeValueLocationStack(nativeArch)
 		c := &amd64Compiler{locationStack: &st, brTableTmp: make([]runtimeValueLocation, 3)}
 		initSlicePtr := &c.brTableTmp
 
@@ -717,7 +717,7 @@ func TestAmd64Compiler_getSavedTemporaryLocationStack(t *testing.T) {
 
 	t.Run("len(brTableTmp)>len(current)", func(t *testing.T) {
 		const temporarySliceSize = 100
-		st := newRuntimeValueLocationStack()
+		st := newRuntimeValueLocationStack(nativeArch)
 		c := &amd64Compiler{locationStack: &st, brTableTmp: make([]runtimeValueLocation, temporarySliceSize)}
 
 		c.locationStack.sp = 3
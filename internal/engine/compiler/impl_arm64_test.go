@@ -126,7 +126,7 @@ func TestArm64Compiler_label(t *testing.T) {
 
 func TestArm64Compiler_Init(t *testing.T) {
 	c := &arm64Compiler{
-		locationStackForEntrypoint: newRuntimeValueLocationStack(),
+		locationStackForEntrypoint: newRuntimeValueLocationStack(nativeArch),
 		assembler:                  arm64.NewAssembler(0),
 	}
 	const stackCap = 12345
@@ -157,7 +157,7 @@ func TestArm64Compiler_resetLabels(t *testing.T) {
 		for j := 0; j <= frameIDMax; j++ {
 			ifs[j].stackInitialized = true
 			ifs[j].initialInstruction = nop
-			ifs[j].initialStack = newRuntimeValueLocationStack()
+			ifs[j].initialStack = newRuntimeValueLocationStack(nativeArch)
 			ifs[j].initialStack.sp = 5555 // should be cleared via runtimeLocationStack.Reset().
 			ifs[j].initialStack.stack = make([]runtimeValueLocation, 0, capacity)
 		}
@@ -181,7 +181,7 @@ func TestArm64Compiler_resetLabels(t *testing.T) {
 
 func TestArm64Compiler_getSavedTemporaryLocationStack(t *testing.T) {
 	t.Run("len(brTableTmp)<len(current)", func(t *testing.T) {
-		st := newRuntimeValueLocationStack()
+		st := newRuntimeValueLocationStack(nativeArch)
 		c := &arm64Compiler{locationStack: &st}
 
 		c.locationStack.sp = 3
@@ -193,7 +193,7 @@ func TestArm64Compiler_getSavedTemporaryLocationStack(t *testing.T) {
 		require.Equal(t, c.locationStack.stack[:3], actual.stack)
 	})
 	t.Run("len(brTableTmp)==len(current)", func(t *testing.T) {
-		st := newRuntimeValueLocationStack()
+		st := newRuntimeValueLocationStack(nativeArch)
 		c := &arm64Compiler{locationStack: &st, brTableTmp: make([]runtimeValueLocation, 3)}
 		initSlicePtr := &c.brTableTmp
 
@@ -210,7 +210,7 @@ func TestArm64Compiler_getSavedTemporaryLocationStack(t *testing.T) {
 
 	t.Run("len(brTableTmp)>len(current)", func(t *testing.T) {
 		const temporarySliceSize = 100
-		st := newRuntimeValueLocationStack()
+		st := newRuntimeValueLocationStack(nativeArch)
 		c := &arm64Compiler{locationStack: &st, brTableTmp: make([]runtimeValueLocation, temporarySliceSize)}
 
 		c.locationStack.sp = 3
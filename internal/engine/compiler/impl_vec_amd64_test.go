@@ -103,8 +103,8 @@ func TestAmd64Compiler_compileV128ShrI64x2SignedImpl(t *testing.T) {
 				c.locationStack.markRegisterUsed(newReg)
 
 				// Ensures that the next free becomes CX.
-				newUnreservedRegs := make([]asm.Register, len(c.locationStack.unreservedVectorRegisters))
-				copy(newUnreservedRegs, c.locationStack.unreservedGeneralPurposeRegisters)
+				newUnreservedRegs := make([]asm.Register, len(c.locationStack.arch.unreservedVectorRegisters))
+				copy(newUnreservedRegs, c.locationStack.arch.unreservedGeneralPurposeRegisters)
 				for i, r := range newUnreservedRegs {
 					// If CX register is found, we swap it with the first register in the list.
 					// This forces runtimeLocationStack to take CX as a first free register.
@@ -112,7 +112,9 @@ func TestAmd64Compiler_compileV128ShrI64x2SignedImpl(t *testing.T) {
 						newUnreservedRegs[0], newUnreservedRegs[i] = newUnreservedRegs[i], newUnreservedRegs[0]
 					}
 				}
-				c.locationStack.unreservedGeneralPurposeRegisters = newUnreservedRegs
+				a := *c.locationStack.arch
+				a.unreservedGeneralPurposeRegisters = newUnreservedRegs
+				c.locationStack.arch = &a
 			},
 			verifyFn: func(t *testing.T, env *compilerEnv) {},
 		},
@@ -13,6 +13,7 @@ import (
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/filecache"
+	"github.com/tetratelabs/wazero/internal/fuel"
 	"github.com/tetratelabs/wazero/internal/internalapi"
 	"github.com/tetratelabs/wazero/internal/moremath"
 	"github.com/tetratelabs/wazero/internal/wasm"
@@ -24,7 +25,8 @@ import (
 // callStackCeiling is the maximum WebAssembly call frame stack height. This allows wazero to raise
 // wasm.ErrCallStackOverflow instead of overflowing the Go runtime.
 //
-// The default value should suffice for most use cases. Those wishing to change this can via `go build -ldflags`.
+// The default value should suffice for most use cases. Those wishing to change this can via `go build -ldflags`, or
+// per module with wasm.ModuleInstance CallStackLimits.
 var callStackCeiling = 2000
 
 // engine is an interpreter implementation of wasm.Engine
@@ -77,6 +79,9 @@ func (e *engine) getCompiledFunctions(module *wasm.Module) (fs []compiledFunctio
 	return
 }
 
+// compile-time check to ensure moduleEngine implements wasm.MemoryWatcher
+var _ wasm.MemoryWatcher = (*moduleEngine)(nil)
+
 // moduleEngine implements wasm.ModuleEngine
 type moduleEngine struct {
 	// codes are the compiled functions in a module instances.
@@ -106,10 +111,42 @@ type callEngine struct {
 
 	// stackiterator for Listeners to walk frames and stack.
 	stackIterator stackIterator
+
+	// fuel is the fuel remaining to the current call, which is stored to
+	// fuelMeter before calling host functions and returning.
+	fuel      int64
+	fuelMeter *fuel.Meter
+
+	// done is the Done channel of the context of the current call if it
+	// fails when the context is done, instead of closing the module.
+	done <-chan struct{}
+
+	// resumed is set by moduleEngine.Resume for the call to resume it, instead
+	// of calling f.
+	resumed *resumedCall
+
+	// maxDepth is the maximum height of frames if the module instance of f
+	// limits it, or zero to use callStackCeiling.
+	maxDepth int
+
+	// exceptions keeps the exceptions referenced by exnref values alive until
+	// the next call, as an exnref is a pointer to a wasm.Exception.
+	exceptions []*wasm.Exception
+}
+
+// resumedCall is a call suspended by wasm.Suspend, which is resumed as if the
+// host function which suspended it returned results.
+type resumedCall struct {
+	state   *wasm.CallState
+	results []uint64
 }
 
 func (e *moduleEngine) newCallEngine(compiled *function) *callEngine {
-	return &callEngine{f: compiled}
+	ce := &callEngine{f: compiled}
+	if m := compiled.moduleInstance; m != nil {
+		ce.maxDepth = int(m.CallStackLimits.MaxDepth)
+	}
+	return ce
 }
 
 func (ce *callEngine) pushValue(v uint64) {
@@ -162,8 +199,12 @@ func (ce *callEngine) drop(raw uint64) {
 }
 
 func (ce *callEngine) pushFrame(frame *callFrame) {
-	if callStackCeiling <= len(ce.frames) {
-		panic(wasmruntime.ErrRuntimeStackOverflow)
+	maxDepth := ce.maxDepth
+	if maxDepth == 0 {
+		maxDepth = callStackCeiling
+	}
+	if maxDepth <= len(ce.frames) {
+		panic(wasmruntime.NewStackOverflow(fmt.Sprintf("maximum call depth of %d", maxDepth)))
 	}
 	ce.frames = append(ce.frames, frame)
 }
@@ -195,13 +236,26 @@ type compiledFunction struct {
 	hostFn              interface{}
 	ensureTermination   bool
 	index               wasm.Index
+	// exceptionHandlers are the handlers of the try and try_table blocks of
+	// the body, whose catch clauses target addresses in it.
+	exceptionHandlers []wazeroir.ExceptionHandler
 }
 
 type function struct {
 	funcType       *wasm.FunctionType
 	moduleInstance *wasm.ModuleInstance
-	typeID         wasm.FunctionTypeID
-	parent         *compiledFunction
+	// moduleEngine holds the functions of moduleInstance, which are called by
+	// index. This isn't read from moduleInstance.Engine, as that may wrap it.
+	moduleEngine *moduleEngine
+	typeID       wasm.FunctionTypeID
+	parent       *compiledFunction
+}
+
+// exceptionFromUintptr resurrects the *wasm.Exception of an exnref, like
+// functionFromUintptr.
+func exceptionFromUintptr(ptr uintptr) *wasm.Exception {
+	var wrapped *uintptr = &ptr
+	return *(**wasm.Exception)(unsafe.Pointer(wrapped))
 }
 
 // functionFromUintptr resurrects the original *function from the given uintptr
@@ -294,13 +348,13 @@ func (f internalFunction) SourceOffsetForPC(pc experimental.ProgramCounter) uint
 const callFrameStackSize = 0
 
 // CompileModule implements the same method as documented on wasm.Engine.
-func (e *engine) CompileModule(_ context.Context, module *wasm.Module, listeners []experimental.FunctionListener, ensureTermination bool) error {
+func (e *engine) CompileModule(ctx context.Context, module *wasm.Module, listeners []experimental.FunctionListener, ensureTermination bool) error {
 	if _, ok := e.getCompiledFunctions(module); ok { // cache hit!
 		return nil
 	}
 
 	funcs := make([]compiledFunction, len(module.FunctionSection))
-	irCompiler, err := wazeroir.NewCompiler(e.enabledFeatures, callFrameStackSize, module, ensureTermination)
+	irCompiler, err := wazeroir.NewCompiler(e.enabledFeatures, callFrameStackSize, module, ensureTermination, fuel.CostsFromContext(ctx))
 	if err != nil {
 		return err
 	}
@@ -355,6 +409,7 @@ func (e *engine) NewModuleEngine(module *wasm.Module, instance *wasm.ModuleInsta
 		typeIndex := module.FunctionSection[i]
 		me.functions[offset] = function{
 			moduleInstance: instance,
+			moduleEngine:   me,
 			typeID:         instance.TypeIDs[typeIndex],
 			funcType:       &module.TypeSection[typeIndex],
 			parent:         c,
@@ -380,7 +435,8 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult, ret *compiledFunction)
 		switch op.Kind {
 		case wazeroir.OperationKindLabel:
 			label := wazeroir.Label(op.U1)
-			address := uint64(i)
+			// Labels are no-ops, so branches skip them to save the dispatch.
+			address := uint64(i + 1)
 
 			kind, fid := label.Kind(), label.FrameID()
 			frameToAddresses := e.labelAddressResolutionCache[label.Kind()]
@@ -395,6 +451,18 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult, ret *compiledFunction)
 		}
 	}
 
+	if handlers := ir.ExceptionHandlers; len(handlers) > 0 {
+		ret.exceptionHandlers = make([]wazeroir.ExceptionHandler, len(handlers))
+		for i, h := range handlers {
+			h.Catches = append([]wazeroir.Catch(nil), h.Catches...)
+			for j := range h.Catches {
+				c := &h.Catches[j]
+				e.setLabelAddress((*uint64)(&c.Target), c.Target)
+			}
+			ret.exceptionHandlers[i] = h
+		}
+	}
+
 	// Then resolve the label as the index to the body.
 	for i := range ret.body {
 		op := &ret.body[i]
@@ -416,6 +484,8 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult, ret *compiledFunction)
 	for i := range e.labelAddressResolutionCache {
 		e.labelAddressResolutionCache[i] = e.labelAddressResolutionCache[i][:0]
 	}
+
+	fuseOperations(ret.body)
 	return nil
 }
 
@@ -437,6 +507,9 @@ func (e *moduleEngine) ResolveImportedFunction(index, indexInImportedModule wasm
 // ResolveImportedMemory implements wasm.ModuleEngine.
 func (e *moduleEngine) ResolveImportedMemory(wasm.ModuleEngine) {}
 
+// WatchesMemory implements wasm.MemoryWatcher.
+func (e *moduleEngine) WatchesMemory() {}
+
 // DoneInstantiation implements wasm.ModuleEngine.
 func (e *moduleEngine) DoneInstantiation() {}
 
@@ -501,9 +574,14 @@ func (ce *callEngine) CallWithStack(ctx context.Context, stack []uint64) error {
 
 func (ce *callEngine) call(ctx context.Context, params, results []uint64) (_ []uint64, err error) {
 	m := ce.f.moduleInstance
+	ce.done = nil
 	if ce.f.parent.ensureTermination {
+		ce.done = wasm.CancelCallDone(ctx)
 		select {
 		case <-ctx.Done():
+			if ce.done != nil {
+				return nil, wasmruntime.NewContextDone(ctx.Err())
+			}
 			// If the provided context is already done, close the call context
 			// and return the error.
 			m.CloseWithCtxErr(ctx)
@@ -512,7 +590,13 @@ func (ce *callEngine) call(ctx context.Context, params, results []uint64) (_ []u
 		}
 	}
 
+	ce.fuelMeter = fuel.MeterFromContext(ctx)
+	ce.fuel = ce.fuelMeter.Load()
+	ce.exceptions = nil
+
 	defer func() {
+		ce.fuelMeter.Store(ce.fuel)
+
 		// If the module closed during the call, and the call didn't err for another reason, set an ExitError.
 		if err == nil {
 			err = m.FailIfClosed()
@@ -520,18 +604,26 @@ func (ce *callEngine) call(ctx context.Context, params, results []uint64) (_ []u
 		// TODO: ^^ Will not fail if the function was imported from a closed module.
 
 		if v := recover(); v != nil {
-			err = ce.recoverOnCall(ctx, m, v)
+			if wasm.IsSuspend(v) {
+				err = ce.suspend(m, v)
+			} else {
+				err = ce.recoverOnCall(ctx, m, v)
+			}
 		}
 	}()
 
-	ce.pushValues(params)
-
-	if ce.f.parent.ensureTermination {
+	if ce.f.parent.ensureTermination && ce.done == nil {
 		done := m.CloseModuleOnCanceledOrTimeout(ctx)
 		defer done()
 	}
 
-	ce.callFunction(ctx, m, ce.f)
+	if resumed := ce.resumed; resumed != nil {
+		ce.resumed = nil
+		ce.resume(ctx, m, resumed)
+	} else {
+		ce.pushValues(params)
+		ce.callFunction(ctx, m, ce.f)
+	}
 
 	// This returns a safe copy of the results, instead of a slice view. If we
 	// returned a re-slice, the caller could accidentally or purposefully
@@ -567,7 +659,7 @@ func (ce *callEngine) recoverOnCall(ctx context.Context, m *wasm.ModuleInstance,
 		if parent := frame.f.parent; parent.body != nil && len(parent.offsetsInWasmBinary) > 0 {
 			sources = parent.source.DWARFLines.Line(parent.offsetsInWasmBinary[frame.pc])
 		}
-		builder.AddFrame(def.DebugName(), def.ParamTypes(), def.ResultTypes(), sources)
+		builder.AddFrame(def.DebugName(), def.ParamTypes(), def.ParamNames(), def.ResultTypes(), sources)
 		if f.parent.listener != nil {
 			functionListeners = append(functionListeners, functionListenerInvocation{
 				FunctionListener: f.parent.listener,
@@ -586,6 +678,111 @@ func (ce *callEngine) recoverOnCall(ctx context.Context, m *wasm.ModuleInstance,
 	return
 }
 
+// suspend returns a wasm.SuspendedError with the state of the call, which a
+// host function suspended by panicking `recovered`, and resets the callEngine.
+func (ce *callEngine) suspend(m *wasm.ModuleInstance, recovered interface{}) error {
+	defer func() {
+		ce.stack, ce.frames = ce.stack[:0], ce.frames[:0]
+	}()
+
+	last := len(ce.frames) - 1
+	host := ce.frames[last].f
+	state := &wasm.CallState{
+		FuncIndex:             ce.f.parent.index,
+		Stack:                 append([]uint64(nil), ce.stack...),
+		HostParamNumInUint64:  host.funcType.ParamNumInUint64,
+		HostResultNumInUint64: host.funcType.ResultNumInUint64,
+	}
+	for _, frame := range ce.frames[:last] {
+		if frame.f.moduleInstance != m {
+			return errors.New("cannot suspend a call through another module")
+		}
+		state.Frames = append(state.Frames, wasm.CallFrame{FuncIndex: frame.f.parent.index, PC: frame.pc, Base: uint64(frame.base)})
+	}
+	return wasm.NewSuspendedError(m, state, recovered)
+}
+
+// Resume implements wasm.Resumer.
+func (e *moduleEngine) Resume(ctx context.Context, state *wasm.CallState, results []uint64) ([]uint64, error) {
+	if err := e.validateCallState(state); err != nil {
+		return nil, err
+	}
+	ce := e.newCallEngine(&e.functions[state.FuncIndex])
+	ce.resumed = &resumedCall{state: state, results: results}
+	return ce.call(ctx, nil, nil)
+}
+
+// validateCallState returns an error if the call state can't be resumed by
+// this moduleEngine, e.g. as it was corrupted.
+func (e *moduleEngine) validateCallState(state *wasm.CallState) error {
+	errInvalid := errors.New("invalid checkpoint")
+	if len(state.Frames) == 0 || state.Frames[0].FuncIndex != state.FuncIndex ||
+		state.HostParamNumInUint64 < 0 || state.HostResultNumInUint64 < 0 || len(state.Frames) >= callStackCeiling {
+		return errInvalid
+	}
+	var base uint64
+	for _, frame := range state.Frames {
+		if frame.FuncIndex >= uint32(len(e.functions)) || frame.Base < base || frame.Base > uint64(len(state.Stack)) {
+			return errInvalid
+		}
+		f := &e.functions[frame.FuncIndex]
+		if f.moduleEngine != e || f.parent.hostFn != nil || frame.PC >= uint64(len(f.parent.body)) {
+			return errInvalid
+		}
+		// The frame must have been calling a function.
+		if kind := f.parent.body[frame.PC].Kind; kind != wazeroir.OperationKindCall && kind != wazeroir.OperationKindCallIndirect && kind != wazeroir.OperationKindCallRef {
+			return errInvalid
+		}
+		base = frame.Base
+	}
+	hostStackLen := state.HostParamNumInUint64
+	if state.HostResultNumInUint64 > hostStackLen {
+		hostStackLen = state.HostResultNumInUint64
+	}
+	if uint64(hostStackLen) > uint64(len(state.Stack))-base {
+		return errInvalid
+	}
+	return nil
+}
+
+// resume restores the value and call stacks of the suspended call, and
+// continues it.
+func (ce *callEngine) resume(ctx context.Context, m *wasm.ModuleInstance, resumed *resumedCall) {
+	state := resumed.state
+	functions := ce.f.moduleEngine.functions
+	ce.stack = append(ce.stack[:0], state.Stack...)
+	frames := make([]*callFrame, len(state.Frames))
+	for i, frame := range state.Frames {
+		frames[i] = &callFrame{f: &functions[frame.FuncIndex], pc: frame.PC, base: int(frame.Base)}
+	}
+	ce.resumeFrames(ctx, m, frames, resumed)
+}
+
+// resumeFrames pushes the first frame, and resumes the remaining ones, which
+// it was calling, before continuing it.
+func (ce *callEngine) resumeFrames(ctx context.Context, m *wasm.ModuleInstance, frames []*callFrame, resumed *resumedCall) {
+	frame := frames[0]
+	ce.pushFrame(frame)
+	if len(frames) > 1 {
+		ce.resumeFrames(ctx, m, frames[1:], resumed)
+	} else {
+		// The frame called the host function which suspended the call, so
+		// place its results, as callGoFuncWithStack would.
+		state := resumed.state
+		paramLen, resultLen := state.HostParamNumInUint64, state.HostResultNumInUint64
+		stackLen := paramLen
+		if resultLen > stackLen {
+			stackLen = resultLen
+		}
+		copy(ce.stack[len(ce.stack)-stackLen:], resumed.results)
+		if shrinkLen := paramLen - resultLen; shrinkLen > 0 {
+			ce.stack = ce.stack[0 : len(ce.stack)-shrinkLen]
+		}
+	}
+	frame.pc++ // Skip the call, which returned.
+	ce.execFrame(ctx, m, frame)
+}
+
 func (ce *callEngine) callFunction(ctx context.Context, m *wasm.ModuleInstance, f *function) {
 	if f.parent.hostFn != nil {
 		ce.callGoFuncWithStack(ctx, m, f)
@@ -608,6 +805,8 @@ func (ce *callEngine) callGoFunc(ctx context.Context, m *wasm.ModuleInstance, f
 	frame := &callFrame{f: f, base: len(ce.stack)}
 	ce.pushFrame(frame)
 
+	// The host function can query or add fuel, or make nested calls.
+	ce.fuelMeter.Store(ce.fuel)
 	fn := f.parent.hostFn
 	switch fn := fn.(type) {
 	case api.GoModuleFunction:
@@ -615,6 +814,7 @@ func (ce *callEngine) callGoFunc(ctx context.Context, m *wasm.ModuleInstance, f
 	case api.GoFunction:
 		fn.Call(ctx, stack)
 	}
+	ce.fuel = ce.fuelMeter.Load()
 
 	ce.popFrame()
 	if lsn != nil {
@@ -626,15 +826,108 @@ func (ce *callEngine) callGoFunc(ctx context.Context, m *wasm.ModuleInstance, f
 
 func (ce *callEngine) callNativeFunc(ctx context.Context, m *wasm.ModuleInstance, f *function) {
 	frame := &callFrame{f: f, base: len(ce.stack)}
+	ce.pushFrame(frame)
+	ce.execFrame(ctx, m, frame)
+}
+
+// execFrame executes the function of `frame`, which is the top of the call
+// stack, from its pc, and pops it when the function returns.
+func (ce *callEngine) execFrame(ctx context.Context, m *wasm.ModuleInstance, frame *callFrame) {
+	if frame.f.parent.exceptionHandlers == nil {
+		ce.execBody(ctx, m, frame)
+	} else {
+		for !ce.execBodyCatching(ctx, m, frame) {
+		}
+	}
+	ce.popFrame()
+}
+
+// execBodyCatching is like execBody, but catches the exceptions thrown in the
+// try and try_table blocks of the function of `frame`. This returns false if
+// it caught one, so that the body continues from the catch clause.
+func (ce *callEngine) execBodyCatching(ctx context.Context, m *wasm.ModuleInstance, frame *callFrame) (done bool) {
+	defer func() {
+		if done {
+			return
+		}
+		if v := recover(); v != nil && !ce.catch(ctx, frame, v) {
+			panic(v)
+		}
+	}()
+	ce.execBody(ctx, m, frame)
+	return true
+}
+
+// catch returns true if the handlers of the function of `frame` catch the
+// recovered value `v`, which is an exception when thrown. In that case, this
+// unwinds the frames above `frame`, and branches to the catch clause.
+func (ce *callEngine) catch(ctx context.Context, frame *callFrame, v interface{}) bool {
+	exc, ok := v.(*wasm.Exception)
+	if !ok {
+		return false
+	}
+
+	// The innermost handler of pc is the last one whose range includes it.
+	f := frame.f
+	handlers := f.parent.exceptionHandlers
+	h := len(handlers) - 1
+	for ; h >= 0; h-- {
+		if handlers[h].Start <= frame.pc && frame.pc < handlers[h].End {
+			break
+		}
+	}
+	var catch *wazeroir.Catch
+	for ; h >= 0 && catch == nil; h = handlers[h].Outer {
+		for i := range handlers[h].Catches {
+			if c := &handlers[h].Catches[i]; c.All || f.moduleInstance.Tags[c.Tag] == exc.Tag {
+				catch = c
+				break
+			}
+		}
+	}
+	if catch == nil {
+		return false
+	}
+
+	for top := ce.frames[len(ce.frames)-1]; top != frame; top = ce.frames[len(ce.frames)-1] {
+		ce.popFrame()
+		if top.f.parent.hostFn != nil {
+			// The host function stored the fuel before calling back.
+			ce.fuel = ce.fuelMeter.Load()
+		}
+		if lsn := top.f.parent.listener; lsn != nil {
+			lsn.Abort(ctx, top.f.moduleInstance, top.f.definition(), exc)
+		}
+	}
+
+	ce.stack = ce.stack[:frame.base-f.funcType.ParamNumInUint64+int(catch.StackHeight)]
+	ce.exceptions = append(ce.exceptions, exc)
+	ref := uint64(uintptr(unsafe.Pointer(exc)))
+	if catch.RefBelowPayload {
+		ce.pushValue(ref)
+	}
+	if !catch.All {
+		ce.pushValues(exc.Payload)
+	}
+	if catch.Ref {
+		ce.pushValue(ref)
+	}
+	frame.pc = uint64(catch.Target)
+	return true
+}
+
+// execBody executes the function of `frame` from its pc until it returns.
+func (ce *callEngine) execBody(ctx context.Context, m *wasm.ModuleInstance, frame *callFrame) {
+entry: // A tail call replaces the function of `frame`, and executes it from here.
+	f := frame.f
 	moduleInst := f.moduleInstance
-	functions := moduleInst.Engine.(*moduleEngine).functions
+	functions := f.moduleEngine.functions
 	memoryInst := moduleInst.MemoryInstance
 	globals := moduleInst.Globals
 	tables := moduleInst.Tables
 	typeIDs := moduleInst.TypeIDs
 	dataInstances := moduleInst.DataInstances
 	elementInstances := moduleInst.ElementInstances
-	ce.pushFrame(frame)
 	body := frame.f.parent.body
 	bodyLen := uint64(len(body))
 	for frame.pc < bodyLen {
@@ -647,6 +940,59 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, m *wasm.ModuleInstance
 			if err := m.FailIfClosed(); err != nil {
 				panic(err)
 			}
+			select {
+			case <-ce.done:
+				panic(wasmruntime.NewContextDone(ctx.Err()))
+			default:
+			}
+			frame.pc++
+		case wazeroir.OperationKindConsumeFuel:
+			if ce.fuel -= int64(op.U1); ce.fuel < 0 {
+				panic(wasmruntime.ErrRuntimeFuelExhausted)
+			}
+			frame.pc++
+		case wazeroir.OperationKindAtomicMemoryWait:
+			timeout := int64(ce.popValue())
+			expected := ce.popValue()
+			size := uint32(8)
+			if wazeroir.UnsignedType(op.B1) == wazeroir.UnsignedTypeI32 {
+				expected, size = uint64(uint32(expected)), 4
+			}
+			offset := ce.popMemoryOffset(op)
+			ce.pushValue(memoryInst.Wait(offset, size, expected, timeout, moduleInst.Done()))
+			if err := moduleInst.FailIfClosed(); err != nil {
+				panic(err)
+			}
+			frame.pc++
+		case wazeroir.OperationKindAtomicMemoryNotify:
+			count := uint32(ce.popValue())
+			offset := ce.popMemoryOffset(op)
+			ce.pushValue(uint64(memoryInst.Notify(offset, count)))
+			frame.pc++
+		case wazeroir.OperationKindAtomicFence:
+			if memoryInst != nil {
+				memoryInst.AtomicFence()
+			}
+			frame.pc++
+		case wazeroir.OperationKindAtomicLoad:
+			offset := ce.popMemoryOffset(op)
+			ce.pushValue(memoryInst.AtomicLoad(offset, uint32(op.B2)))
+			frame.pc++
+		case wazeroir.OperationKindAtomicStore:
+			val := ce.popValue()
+			offset := ce.popMemoryOffset(op)
+			memoryInst.AtomicStore(offset, uint32(op.B2), val)
+			frame.pc++
+		case wazeroir.OperationKindAtomicRMW:
+			val := ce.popValue()
+			offset := ce.popMemoryOffset(op)
+			ce.pushValue(memoryInst.AtomicRMW(wasm.AtomicRMWOp(op.U3), offset, uint32(op.B2), val))
+			frame.pc++
+		case wazeroir.OperationKindAtomicRMWCmpxchg:
+			replacement := ce.popValue()
+			expected := ce.popValue()
+			offset := ce.popMemoryOffset(op)
+			ce.pushValue(memoryInst.AtomicCmpxchg(offset, uint32(op.B2), expected, replacement))
 			frame.pc++
 		case wazeroir.OperationKindUnreachable:
 			panic(wasmruntime.ErrRuntimeUnreachable)
@@ -672,22 +1018,48 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, m *wasm.ModuleInstance
 			ce.callFunction(ctx, f.moduleInstance, &functions[op.U1])
 			frame.pc++
 		case wazeroir.OperationKindCallIndirect:
-			offset := ce.popValue()
-			table := tables[op.U2]
-			if offset >= uint64(len(table.References)) {
-				panic(wasmruntime.ErrRuntimeInvalidTableAccess)
-			}
-			rawPtr := table.References[offset]
-			if rawPtr == 0 {
-				panic(wasmruntime.ErrRuntimeInvalidTableAccess)
+			tf := ce.popIndirectCallee(op, tables, typeIDs)
+			ce.callFunction(ctx, f.moduleInstance, tf)
+			frame.pc++
+		case wazeroir.OperationKindCallRef:
+			tf := ce.popRefCallee(op, typeIDs)
+			ce.callFunction(ctx, f.moduleInstance, tf)
+			frame.pc++
+		case wazeroir.OperationKindTailCall, wazeroir.OperationKindTailCallIndirect, wazeroir.OperationKindTailCallRef:
+			var tf *function
+			switch op.Kind {
+			case wazeroir.OperationKindTailCall:
+				tf = &functions[op.U1]
+			case wazeroir.OperationKindTailCallIndirect:
+				tf = ce.popIndirectCallee(op, tables, typeIDs)
+			default:
+				tf = ce.popRefCallee(op, typeIDs)
 			}
-
-			tf := functionFromUintptr(rawPtr)
-			if tf.typeID != typeIDs[op.U1] {
-				panic(wasmruntime.ErrRuntimeIndirectCallTypeMismatch)
+			// The parameters of tf are on top of the stack, so replace the frame
+			// unless a host function or a listener observes the call, or tf
+			// catches exceptions, which execFrame sets up.
+			if tf.parent.hostFn == nil && tf.parent.listener == nil && f.parent.listener == nil && tf.parent.exceptionHandlers == nil {
+				frame.f, frame.pc, frame.base = tf, 0, len(ce.stack)
+				m = moduleInst
+				goto entry
 			}
-
 			ce.callFunction(ctx, f.moduleInstance, tf)
+			frame.pc = bodyLen // Return its results.
+		case wazeroir.OperationKindThrow:
+			tag := moduleInst.Tags[op.U1]
+			payload := make([]uint64, tag.Type.ParamNumInUint64)
+			ce.popValues(payload)
+			panic(&wasm.Exception{Tag: tag, Payload: payload})
+		case wazeroir.OperationKindThrowRef:
+			ref := ce.popValue()
+			if ref == 0 {
+				panic(wasmruntime.ErrRuntimeNullExceptionReference)
+			}
+			panic(exceptionFromUintptr(uintptr(ref)))
+		case wazeroir.OperationKindRefAsNonNull:
+			if ce.stack[len(ce.stack)-1] == 0 {
+				panic(wasmruntime.ErrRuntimeNullReference)
+			}
 			frame.pc++
 		case wazeroir.OperationKindDrop:
 			ce.drop(op.U1)
@@ -741,6 +1113,11 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, m *wasm.ModuleInstance
 			}
 			g.Val = ce.popValue()
 			frame.pc++
+		case operationKindPickLoad:
+			ce.pushValue(ce.stack[len(ce.stack)-1-int(op.U1)])
+			frame.pc++
+			op = &body[frame.pc]
+			fallthrough
 		case wazeroir.OperationKindLoad:
 			offset := ce.popMemoryOffset(op)
 			switch wazeroir.UnsignedType(op.B1) {
@@ -757,12 +1134,19 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, m *wasm.ModuleInstance
 					ce.pushValue(val)
 				}
 			}
+			if len(memoryInst.Watchpoints) > 0 {
+				memoryInst.Watch(false, uint64(offset), unsignedTypeSize(op.B1))
+			}
 			frame.pc++
 		case wazeroir.OperationKindLoad8:
-			val, ok := memoryInst.ReadByte(ce.popMemoryOffset(op))
+			offset := ce.popMemoryOffset(op)
+			val, ok := memoryInst.ReadByte(offset)
 			if !ok {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			}
+			if len(memoryInst.Watchpoints) > 0 {
+				memoryInst.Watch(false, uint64(offset), 1)
+			}
 
 			switch wazeroir.SignedInt(op.B1) {
 			case wazeroir.SignedInt32:
@@ -775,10 +1159,14 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, m *wasm.ModuleInstance
 			frame.pc++
 		case wazeroir.OperationKindLoad16:
 
-			val, ok := memoryInst.ReadUint16Le(ce.popMemoryOffset(op))
+			offset := ce.popMemoryOffset(op)
+			val, ok := memoryInst.ReadUint16Le(offset)
 			if !ok {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			}
+			if len(memoryInst.Watchpoints) > 0 {
+				memoryInst.Watch(false, uint64(offset), 2)
+			}
 
 			switch wazeroir.SignedInt(op.B1) {
 			case wazeroir.SignedInt32:
@@ -790,10 +1178,14 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, m *wasm.ModuleInstance
 			}
 			frame.pc++
 		case wazeroir.OperationKindLoad32:
-			val, ok := memoryInst.ReadUint32Le(ce.popMemoryOffset(op))
+			offset := ce.popMemoryOffset(op)
+			val, ok := memoryInst.ReadUint32Le(offset)
 			if !ok {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			}
+			if len(memoryInst.Watchpoints) > 0 {
+				memoryInst.Watch(false, uint64(offset), 4)
+			}
 
 			if op.B1 == 1 { // Signed
 				ce.pushValue(uint64(int32(val)))
@@ -801,6 +1193,11 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, m *wasm.ModuleInstance
 				ce.pushValue(uint64(val))
 			}
 			frame.pc++
+		case operationKindPickStore:
+			ce.pushValue(ce.stack[len(ce.stack)-1-int(op.U1)])
+			frame.pc++
+			op = &body[frame.pc]
+			fallthrough
 		case wazeroir.OperationKindStore:
 			val := ce.popValue()
 			offset := ce.popMemoryOffset(op)
@@ -814,6 +1211,9 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, m *wasm.ModuleInstance
 					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 				}
 			}
+			if len(memoryInst.Watchpoints) > 0 {
+				memoryInst.Watch(true, uint64(offset), unsignedTypeSize(op.B1))
+			}
 			frame.pc++
 		case wazeroir.OperationKindStore8:
 			val := byte(ce.popValue())
@@ -821,6 +1221,9 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, m *wasm.ModuleInstance
 			if !memoryInst.WriteByte(offset, val) {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			}
+			if len(memoryInst.Watchpoints) > 0 {
+				memoryInst.Watch(true, uint64(offset), 1)
+			}
 			frame.pc++
 		case wazeroir.OperationKindStore16:
 			val := uint16(ce.popValue())
@@ -828,6 +1231,9 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, m *wasm.ModuleInstance
 			if !memoryInst.WriteUint16Le(offset, val) {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			}
+			if len(memoryInst.Watchpoints) > 0 {
+				memoryInst.Watch(true, uint64(offset), 2)
+			}
 			frame.pc++
 		case wazeroir.OperationKindStore32:
 			val := uint32(ce.popValue())
@@ -835,13 +1241,24 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, m *wasm.ModuleInstance
 			if !memoryInst.WriteUint32Le(offset, val) {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			}
+			if len(memoryInst.Watchpoints) > 0 {
+				memoryInst.Watch(true, uint64(offset), 4)
+			}
 			frame.pc++
 		case wazeroir.OperationKindMemorySize:
 			ce.pushValue(uint64(memoryInst.PageSize()))
 			frame.pc++
 		case wazeroir.OperationKindMemoryGrow:
 			n := ce.popValue()
-			if res, ok := memoryInst.Grow(uint32(n)); !ok {
+			if memoryInst.Is64 {
+				if n > math.MaxUint32 {
+					ce.pushValue(math.MaxUint64) // = -1 in signed 64-bit integer.
+				} else if res, ok := memoryInst.Grow(uint32(n)); !ok {
+					ce.pushValue(math.MaxUint64)
+				} else {
+					ce.pushValue(uint64(res))
+				}
+			} else if res, ok := memoryInst.Grow(uint32(n)); !ok {
 				ce.pushValue(uint64(0xffffffff)) // = -1 in signed 32-bit integer.
 			} else {
 				ce.pushValue(uint64(res))
@@ -1610,10 +2027,13 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, m *wasm.ModuleInstance
 			inDataOffset := ce.popValue()
 			inMemoryOffset := ce.popValue()
 			if inDataOffset+copySize > uint64(len(dataInstance)) ||
-				inMemoryOffset+copySize > uint64(len(memoryInst.Buffer)) {
+				outOfBounds(inMemoryOffset, copySize, uint64(len(memoryInst.Buffer))) {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			} else if copySize != 0 {
 				copy(memoryInst.Buffer[inMemoryOffset:inMemoryOffset+copySize], dataInstance[inDataOffset:])
+				if len(memoryInst.Watchpoints) > 0 {
+					memoryInst.Watch(true, inMemoryOffset, copySize)
+				}
 			}
 			frame.pc++
 		case wazeroir.OperationKindDataDrop:
@@ -1624,18 +2044,22 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, m *wasm.ModuleInstance
 			copySize := ce.popValue()
 			sourceOffset := ce.popValue()
 			destinationOffset := ce.popValue()
-			if sourceOffset+copySize > memLen || destinationOffset+copySize > memLen {
+			if outOfBounds(sourceOffset, copySize, memLen) || outOfBounds(destinationOffset, copySize, memLen) {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			} else if copySize != 0 {
 				copy(memoryInst.Buffer[destinationOffset:],
 					memoryInst.Buffer[sourceOffset:sourceOffset+copySize])
+				if len(memoryInst.Watchpoints) > 0 {
+					memoryInst.Watch(false, sourceOffset, copySize)
+					memoryInst.Watch(true, destinationOffset, copySize)
+				}
 			}
 			frame.pc++
 		case wazeroir.OperationKindMemoryFill:
 			fillSize := ce.popValue()
 			value := byte(ce.popValue())
 			offset := ce.popValue()
-			if fillSize+offset > uint64(len(memoryInst.Buffer)) {
+			if outOfBounds(offset, fillSize, uint64(len(memoryInst.Buffer))) {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			} else if fillSize != 0 {
 				// Uses the copy trick for faster filling buffer.
@@ -1645,6 +2069,9 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, m *wasm.ModuleInstance
 				for i := 1; i < len(buf); i *= 2 {
 					copy(buf[i:], buf[:i])
 				}
+				if len(memoryInst.Watchpoints) > 0 {
+					memoryInst.Watch(true, offset, fillSize)
+				}
 			}
 			frame.pc++
 		case wazeroir.OperationKindTableInit:
@@ -1939,6 +2366,9 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, m *wasm.ModuleInstance
 				ce.pushValue(lo)
 				ce.pushValue(0)
 			}
+			if len(memoryInst.Watchpoints) > 0 {
+				memoryInst.Watch(false, uint64(offset), v128LoadTypeSize(op.B1))
+			}
 			frame.pc++
 		case wazeroir.OperationKindV128LoadLane:
 			hi, lo := ce.popValue(), ce.popValue()
@@ -2003,6 +2433,9 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, m *wasm.ModuleInstance
 			if ok := memoryInst.WriteUint64Le(offset+8, hi); !ok {
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			}
+			if len(memoryInst.Watchpoints) > 0 {
+				memoryInst.Watch(true, uint64(offset), 16)
+			}
 			frame.pc++
 		case wazeroir.OperationKindV128StoreLane:
 			hi, lo := ce.popValue(), ce.popValue()
@@ -3889,11 +4322,45 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, m *wasm.ModuleInstance
 			ce.pushValue(retLo)
 			ce.pushValue(retHi)
 			frame.pc++
+		case operationKindPickPick:
+			next := &body[frame.pc+1]
+			ce.pushValue(ce.stack[len(ce.stack)-1-int(op.U1)])
+			ce.pushValue(ce.stack[len(ce.stack)-1-int(next.U1)])
+			frame.pc += 2
+		case operationKindPickConst:
+			ce.pushValue(ce.stack[len(ce.stack)-1-int(op.U1)])
+			ce.pushValue(body[frame.pc+1].U1)
+			frame.pc += 2
+		case operationKindPickSet:
+			// The picked value would be at len(ce.stack), so Set's index is one more than usual.
+			ce.stack[len(ce.stack)-int(body[frame.pc+1].U1)] = ce.stack[len(ce.stack)-1-int(op.U1)]
+			frame.pc += 2
+		case operationKindConstAddI32:
+			top := &ce.stack[len(ce.stack)-1]
+			*top = uint64(uint32(*top) + uint32(op.U1))
+			frame.pc += 2
+		case operationKindConstAddI64:
+			ce.stack[len(ce.stack)-1] += op.U1
+			frame.pc += 2
+		case operationKindConstSubI32:
+			top := &ce.stack[len(ce.stack)-1]
+			*top = uint64(uint32(*top) - uint32(op.U1))
+			frame.pc += 2
+		case operationKindConstSubI64:
+			ce.stack[len(ce.stack)-1] -= op.U1
+			frame.pc += 2
+		case operationKindEqzBrIf:
+			next := &body[frame.pc+1]
+			if ce.popValue() == 0 {
+				ce.drop(next.U3)
+				frame.pc = next.U1
+			} else {
+				frame.pc = next.U2
+			}
 		default:
 			frame.pc++
 		}
 	}
-	ce.popFrame()
 }
 
 func WasmCompatMax32bits(v1, v2 uint32) uint64 {
@@ -4103,11 +4570,85 @@ func (ce *callEngine) callNativeFuncWithListener(ctx context.Context, m *wasm.Mo
 	return ctx
 }
 
+// popIndirectCallee takes the offset of a call_indirect off the stack, and
+// returns the function at that offset in the table, or panics if it doesn't
+// exist or has another type.
+func (ce *callEngine) popIndirectCallee(op *wazeroir.UnionOperation, tables []*wasm.TableInstance, typeIDs []wasm.FunctionTypeID) *function {
+	offset := ce.popValue()
+	table := tables[op.U2]
+	if offset >= uint64(len(table.References)) {
+		panic(wasmruntime.ErrRuntimeInvalidTableAccess)
+	}
+	rawPtr := table.References[offset]
+	if rawPtr == 0 {
+		panic(wasmruntime.ErrRuntimeInvalidTableAccess)
+	}
+
+	tf := functionFromUintptr(rawPtr)
+	if tf.typeID != typeIDs[op.U1] {
+		panic(wasmruntime.ErrRuntimeIndirectCallTypeMismatch)
+	}
+	return tf
+}
+
+// popRefCallee pops the function reference of call_ref, and returns its
+// function, which must have the type of the operation.
+func (ce *callEngine) popRefCallee(op *wazeroir.UnionOperation, typeIDs []wasm.FunctionTypeID) *function {
+	ref := ce.popValue()
+	if ref == 0 {
+		panic(wasmruntime.ErrRuntimeNullFunctionReference)
+	}
+
+	tf := functionFromUintptr(uintptr(ref))
+	if tf.typeID != typeIDs[op.U1] {
+		panic(wasmruntime.ErrRuntimeIndirectCallTypeMismatch)
+	}
+	return tf
+}
+
+// outOfBounds returns true if the range of size at offset exceeds the length.
+// Unlike offset+size > length, this doesn't overflow with the i64 offsets and
+// sizes of a 64-bit memory.
+func outOfBounds(offset, size, length uint64) bool {
+	return offset > length || size > length-offset
+}
+
 // popMemoryOffset takes a memory offset off the stack for use in load and store instructions.
 // As the top of stack value is 64-bit, this ensures it is in range before returning it.
+//
+// Note: The address is an i64 for a 64-bit memory, so the sum with the offset may overflow.
+// unsignedTypeSize returns the size in bytes of the given
+// wazeroir.UnsignedType of a load or store.
+func unsignedTypeSize(t byte) uint64 {
+	switch wazeroir.UnsignedType(t) {
+	case wazeroir.UnsignedTypeI64, wazeroir.UnsignedTypeF64:
+		return 8
+	default:
+		return 4
+	}
+}
+
+// v128LoadTypeSize returns the size in bytes read by the given
+// wazeroir.V128LoadType.
+func v128LoadTypeSize(t wazeroir.V128LoadType) uint64 {
+	switch t {
+	case wazeroir.V128LoadType128:
+		return 16
+	case wazeroir.V128LoadType8Splat:
+		return 1
+	case wazeroir.V128LoadType16Splat:
+		return 2
+	case wazeroir.V128LoadType32Splat, wazeroir.V128LoadType32zero:
+		return 4
+	default: // the extending loads, 64splat and 64zero
+		return 8
+	}
+}
+
 func (ce *callEngine) popMemoryOffset(op *wazeroir.UnionOperation) uint32 {
-	offset := op.U2 + ce.popValue()
-	if offset > math.MaxUint32 {
+	addr := ce.popValue()
+	offset := op.U2 + addr
+	if offset > math.MaxUint32 || offset < addr {
 		panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 	}
 	return uint32(offset)
@@ -10,6 +10,7 @@ import (
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
 	"github.com/tetratelabs/wazero/internal/wazeroir"
 )
 
@@ -40,23 +41,19 @@ func TestInterpreter_CallEngine_PushFrame(t *testing.T) {
 }
 
 func TestInterpreter_CallEngine_PushFrame_StackOverflow(t *testing.T) {
-	saved := callStackCeiling
-	defer func() { callStackCeiling = saved }()
-
-	callStackCeiling = 3
-
 	f1 := &callFrame{}
 	f2 := &callFrame{}
 	f3 := &callFrame{}
 	f4 := &callFrame{}
 
-	vm := callEngine{}
+	vm := callEngine{maxDepth: 3}
 	vm.pushFrame(f1)
 	vm.pushFrame(f2)
 	vm.pushFrame(f3)
 
 	captured := require.CapturePanic(func() { vm.pushFrame(f4) })
-	require.EqualError(t, captured, "stack overflow")
+	require.EqualError(t, captured, "stack overflow: exceeded the maximum call depth of 3")
+	require.ErrorIs(t, captured.(error), wasmruntime.ErrRuntimeStackOverflow)
 }
 
 func TestInterpreter_NonTrappingFloatToIntConversion(t *testing.T) {
@@ -243,7 +240,8 @@ func TestInterpreter_NonTrappingFloatToIntConversion(t *testing.T) {
 
 					ce := &callEngine{}
 					f := &function{
-						moduleInstance: &wasm.ModuleInstance{Engine: &moduleEngine{}},
+						moduleInstance: &wasm.ModuleInstance{},
+						moduleEngine:   &moduleEngine{},
 						parent:         &compiledFunction{body: body},
 					}
 					ce.callNativeFunc(testCtx, &wasm.ModuleInstance{}, f)
@@ -306,7 +304,8 @@ func TestInterpreter_CallEngine_callNativeFunc_signExtend(t *testing.T) {
 			t.Run(fmt.Sprintf("%s(i32.const(0x%x))", wasm.InstructionName(tc.opcode), tc.in), func(t *testing.T) {
 				ce := &callEngine{}
 				f := &function{
-					moduleInstance: &wasm.ModuleInstance{Engine: &moduleEngine{}},
+					moduleInstance: &wasm.ModuleInstance{},
+					moduleEngine:   &moduleEngine{},
 					parent: &compiledFunction{body: []wazeroir.UnionOperation{
 						{Kind: wazeroir.OperationKindConstI32, U1: uint64(uint32(tc.in))},
 						{Kind: translateToIROperationKind(tc.opcode)},
@@ -360,7 +359,8 @@ func TestInterpreter_CallEngine_callNativeFunc_signExtend(t *testing.T) {
 			t.Run(fmt.Sprintf("%s(i64.const(0x%x))", wasm.InstructionName(tc.opcode), tc.in), func(t *testing.T) {
 				ce := &callEngine{}
 				f := &function{
-					moduleInstance: &wasm.ModuleInstance{Engine: &moduleEngine{}},
+					moduleInstance: &wasm.ModuleInstance{},
+					moduleEngine:   &moduleEngine{},
 					parent: &compiledFunction{body: []wazeroir.UnionOperation{
 						{Kind: wazeroir.OperationKindConstI64, U1: uint64(tc.in)},
 						{Kind: translateToIROperationKind(tc.opcode)},
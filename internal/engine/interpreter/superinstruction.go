@@ -0,0 +1,103 @@
+package interpreter
+
+import (
+	"math"
+
+	"github.com/tetratelabs/wazero/internal/wazeroir"
+)
+
+// Superinstructions fuse the common pairs of operations into one, so that the interpreter loop dispatches once
+// for both. They are never emitted by wazeroir, so the kinds are numbered down from the top of
+// wazeroir.OperationKind to not collide with it.
+//
+// fuseOperations only replaces the Kind of the first operation of a pair, and leaves the second one as is.
+// Therefore, the indexes in the body, which labels, source offsets and call frames refer to, don't change, and
+// branching into the second operation still executes it alone. The handler of a superinstruction reads the
+// operands of the second operation from the body, and advances pc past it.
+const (
+	// operationKindPickPick is Pick followed by Pick, both of which aren't vectors.
+	operationKindPickPick = wazeroir.OperationKind(math.MaxUint16 - iota)
+	// operationKindPickConst is Pick, which isn't a vector, followed by ConstI32 or ConstI64.
+	operationKindPickConst
+	// operationKindPickSet is Pick followed by Set, both of which aren't vectors, which copies a local to another.
+	operationKindPickSet
+	// operationKindPickLoad is Pick, which isn't a vector, followed by Load.
+	operationKindPickLoad
+	// operationKindPickStore is Pick, which isn't a vector, followed by Store.
+	operationKindPickStore
+	// operationKindConstAddI32 is ConstI32 followed by Add of i32.
+	operationKindConstAddI32
+	// operationKindConstAddI64 is ConstI64 followed by Add of i64.
+	operationKindConstAddI64
+	// operationKindConstSubI32 is ConstI32 followed by Sub of i32.
+	operationKindConstSubI32
+	// operationKindConstSubI64 is ConstI64 followed by Sub of i64.
+	operationKindConstSubI64
+	// operationKindEqzBrIf is Eqz followed by BrIf.
+	operationKindEqzBrIf
+)
+
+// fuseOperations replaces the first operation of the pairs in body, which have a superinstruction, with it.
+// This must be called after the label addresses are resolved.
+func fuseOperations(body []wazeroir.UnionOperation) {
+	for i := 0; i+1 < len(body); i++ {
+		op, next := &body[i], &body[i+1]
+		if kind := fusedKind(op, next); kind != op.Kind {
+			op.Kind = kind
+			// The second operation isn't fused with the following one, as it's executed by this superinstruction.
+			i++
+		}
+	}
+}
+
+// fusedKind returns the kind of superinstruction which op and next are fused into, or op.Kind if there's none.
+func fusedKind(op, next *wazeroir.UnionOperation) wazeroir.OperationKind {
+	switch op.Kind {
+	case wazeroir.OperationKindPick:
+		if op.B3 { // Vector.
+			break
+		}
+		switch next.Kind {
+		case wazeroir.OperationKindPick:
+			if !next.B3 {
+				return operationKindPickPick
+			}
+		case wazeroir.OperationKindConstI32, wazeroir.OperationKindConstI64:
+			return operationKindPickConst
+		case wazeroir.OperationKindSet:
+			// Set's depth is zero only if it sets the value to itself, which isn't worth fusing.
+			if !next.B3 && next.U1 > 0 {
+				return operationKindPickSet
+			}
+		case wazeroir.OperationKindLoad:
+			return operationKindPickLoad
+		case wazeroir.OperationKindStore:
+			return operationKindPickStore
+		}
+	case wazeroir.OperationKindConstI32:
+		if wazeroir.UnsignedType(next.B1) != wazeroir.UnsignedTypeI32 {
+			break
+		}
+		switch next.Kind {
+		case wazeroir.OperationKindAdd:
+			return operationKindConstAddI32
+		case wazeroir.OperationKindSub:
+			return operationKindConstSubI32
+		}
+	case wazeroir.OperationKindConstI64:
+		if wazeroir.UnsignedType(next.B1) != wazeroir.UnsignedTypeI64 {
+			break
+		}
+		switch next.Kind {
+		case wazeroir.OperationKindAdd:
+			return operationKindConstAddI64
+		case wazeroir.OperationKindSub:
+			return operationKindConstSubI64
+		}
+	case wazeroir.OperationKindEqz:
+		if next.Kind == wazeroir.OperationKindBrIf {
+			return operationKindEqzBrIf
+		}
+	}
+	return op.Kind
+}
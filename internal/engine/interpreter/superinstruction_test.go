@@ -0,0 +1,190 @@
+package interpreter
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wazeroir"
+)
+
+func TestFuseOperations(t *testing.T) {
+	ret := wazeroir.UnionOperation{Kind: wazeroir.OperationKindBr, U1: math.MaxUint64}
+	for _, tc := range []struct {
+		name     string
+		stack    []uint64
+		body     []wazeroir.UnionOperation
+		expKinds []wazeroir.OperationKind
+		expStack []uint64
+	}{
+		{
+			name:  "pick pick",
+			stack: []uint64{1, 2},
+			body: []wazeroir.UnionOperation{
+				wazeroir.NewOperationPick(1, false),
+				wazeroir.NewOperationPick(1, false),
+				ret,
+			},
+			expKinds: []wazeroir.OperationKind{operationKindPickPick, wazeroir.OperationKindPick, wazeroir.OperationKindBr},
+			expStack: []uint64{1, 2, 1, 2},
+		},
+		{
+			name:  "pick vector",
+			stack: []uint64{1, 2},
+			body: []wazeroir.UnionOperation{
+				wazeroir.NewOperationPick(1, true),
+				wazeroir.NewOperationPick(1, false),
+				ret,
+			},
+			expKinds: []wazeroir.OperationKind{wazeroir.OperationKindPick, wazeroir.OperationKindPick, wazeroir.OperationKindBr},
+			expStack: []uint64{1, 2, 1, 2, 1},
+		},
+		{
+			name:  "pick const",
+			stack: []uint64{1},
+			body: []wazeroir.UnionOperation{
+				wazeroir.NewOperationPick(0, false),
+				wazeroir.NewOperationConstI64(10),
+				ret,
+			},
+			expKinds: []wazeroir.OperationKind{operationKindPickConst, wazeroir.OperationKindConstI64, wazeroir.OperationKindBr},
+			expStack: []uint64{1, 1, 10},
+		},
+		{
+			name:  "pick set",
+			stack: []uint64{1, 2, 3},
+			body: []wazeroir.UnionOperation{
+				wazeroir.NewOperationPick(0, false),
+				wazeroir.NewOperationSet(3, false),
+				ret,
+			},
+			expKinds: []wazeroir.OperationKind{operationKindPickSet, wazeroir.OperationKindSet, wazeroir.OperationKindBr},
+			expStack: []uint64{3, 2, 3},
+		},
+		{
+			name:  "const add i32",
+			stack: []uint64{0xffffffff},
+			body: []wazeroir.UnionOperation{
+				wazeroir.NewOperationConstI32(2),
+				wazeroir.NewOperationAdd(wazeroir.UnsignedTypeI32),
+				ret,
+			},
+			expKinds: []wazeroir.OperationKind{operationKindConstAddI32, wazeroir.OperationKindAdd, wazeroir.OperationKindBr},
+			expStack: []uint64{1},
+		},
+		{
+			name:  "const add i64",
+			stack: []uint64{0xffffffff},
+			body: []wazeroir.UnionOperation{
+				wazeroir.NewOperationConstI64(2),
+				wazeroir.NewOperationAdd(wazeroir.UnsignedTypeI64),
+				ret,
+			},
+			expKinds: []wazeroir.OperationKind{operationKindConstAddI64, wazeroir.OperationKindAdd, wazeroir.OperationKindBr},
+			expStack: []uint64{0x100000001},
+		},
+		{
+			name:  "const sub i32",
+			stack: []uint64{1},
+			body: []wazeroir.UnionOperation{
+				wazeroir.NewOperationConstI32(2),
+				wazeroir.NewOperationSub(wazeroir.UnsignedTypeI32),
+				ret,
+			},
+			expKinds: []wazeroir.OperationKind{operationKindConstSubI32, wazeroir.OperationKindSub, wazeroir.OperationKindBr},
+			expStack: []uint64{0xffffffff},
+		},
+		{
+			name:  "const sub i64",
+			stack: []uint64{1},
+			body: []wazeroir.UnionOperation{
+				wazeroir.NewOperationConstI64(2),
+				wazeroir.NewOperationSub(wazeroir.UnsignedTypeI64),
+				ret,
+			},
+			expKinds: []wazeroir.OperationKind{operationKindConstSubI64, wazeroir.OperationKindSub, wazeroir.OperationKindBr},
+			expStack: []uint64{math.MaxUint64},
+		},
+		{
+			name:  "const add f64",
+			stack: []uint64{math.Float64bits(1)},
+			body: []wazeroir.UnionOperation{
+				wazeroir.NewOperationConstF64(2),
+				wazeroir.NewOperationAdd(wazeroir.UnsignedTypeF64),
+				ret,
+			},
+			expKinds: []wazeroir.OperationKind{wazeroir.OperationKindConstF64, wazeroir.OperationKindAdd, wazeroir.OperationKindBr},
+			expStack: []uint64{math.Float64bits(3)},
+		},
+		{
+			name:  "eqz br_if taken",
+			stack: []uint64{0},
+			body: []wazeroir.UnionOperation{
+				wazeroir.NewOperationEqz(wazeroir.UnsignedInt32),
+				{Kind: wazeroir.OperationKindBrIf, U1: 3, U2: 2, U3: wazeroir.NopInclusiveRange.AsU64()},
+				wazeroir.NewOperationConstI32(1),
+				wazeroir.NewOperationConstI32(2),
+				ret,
+			},
+			expKinds: []wazeroir.OperationKind{
+				operationKindEqzBrIf, wazeroir.OperationKindBrIf,
+				wazeroir.OperationKindConstI32, wazeroir.OperationKindConstI32, wazeroir.OperationKindBr,
+			},
+			expStack: []uint64{2},
+		},
+		{
+			name:  "eqz br_if not taken",
+			stack: []uint64{5},
+			body: []wazeroir.UnionOperation{
+				wazeroir.NewOperationEqz(wazeroir.UnsignedInt32),
+				{Kind: wazeroir.OperationKindBrIf, U1: 3, U2: 2, U3: wazeroir.NopInclusiveRange.AsU64()},
+				wazeroir.NewOperationConstI32(1),
+				wazeroir.NewOperationConstI32(2),
+				ret,
+			},
+			expKinds: []wazeroir.OperationKind{
+				operationKindEqzBrIf, wazeroir.OperationKindBrIf,
+				wazeroir.OperationKindConstI32, wazeroir.OperationKindConstI32, wazeroir.OperationKindBr,
+			},
+			expStack: []uint64{1, 2},
+		},
+		{
+			name:  "pairs don't overlap",
+			stack: []uint64{1},
+			body: []wazeroir.UnionOperation{
+				wazeroir.NewOperationPick(0, false),
+				wazeroir.NewOperationPick(0, false),
+				wazeroir.NewOperationPick(0, false),
+				ret,
+			},
+			expKinds: []wazeroir.OperationKind{
+				operationKindPickPick, wazeroir.OperationKindPick, wazeroir.OperationKindPick, wazeroir.OperationKindBr,
+			},
+			expStack: []uint64{1, 1, 1, 1},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// Executing the body must have the same result with or without the superinstructions.
+			for _, fuse := range []bool{false, true} {
+				body := append([]wazeroir.UnionOperation(nil), tc.body...)
+				if fuse {
+					fuseOperations(body)
+					kinds := make([]wazeroir.OperationKind, len(body))
+					for i := range body {
+						kinds[i] = body[i].Kind
+					}
+					require.Equal(t, tc.expKinds, kinds)
+				}
+				ce := &callEngine{stack: append([]uint64(nil), tc.stack...)}
+				f := &function{
+					moduleInstance: &wasm.ModuleInstance{},
+					moduleEngine:   &moduleEngine{},
+					parent:         &compiledFunction{body: body},
+				}
+				ce.callNativeFunc(testCtx, &wasm.ModuleInstance{}, f)
+				require.Equal(t, tc.expStack, ce.stack, "fuse=%v", fuse)
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+// Package tiered implements a wasm.Engine which executes modules with the
+// interpreter until they are compiled in the background.
+package tiered
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/engine/compiler"
+	"github.com/tetratelabs/wazero/internal/engine/interpreter"
+	"github.com/tetratelabs/wazero/internal/enginekind"
+	"github.com/tetratelabs/wazero/internal/filecache"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// engine implements wasm.Engine by interpreting modules until the compiler
+// completes. Modules are compiled by the interpreter synchronously, so can be
+// instantiated immediately, and by the compiler in the background.
+//
+// The tier of a module instance is chosen in NewModuleEngine and never
+// changes: instances created before compilation completes keep interpreting.
+// This is because the compiler and interpreter have different calling
+// conventions, and table elements are engine-specific, so code can only be
+// swapped while nothing references it.
+//
+// Only the tier of instances which can't be linked with other guest modules
+// depends on whether the compiler completed. Others wait for it, so that
+// whether an import graph links doesn't depend on timing.
+type engine struct {
+	interpreter, compiler wasm.Engine
+
+	// compilations are the modules being compiled, or compiled by the
+	// compiler, keyed by wasm.Module ID. This is guarded by mux.
+	compilations map[wasm.ModuleID]*compilation
+	// interpreted are modules compiled with enginekind.Interpreter, which are
+	// interpreted even when compiled by another call. This is guarded by mux.
+	interpreted map[*wasm.Module]struct{}
+	// refs counts the guest modules compiled per wasm.Module ID. These share
+	// code in both engines, so it is deleted with the last of them. This is
+	// guarded by mux.
+	refs map[wasm.ModuleID]int
+	mux  sync.Mutex
+
+	// wg tracks background compilation, so that Close waits for it.
+	wg sync.WaitGroup
+}
+
+// compilation is the state of compiling a module by the compiler.
+type compilation struct {
+	// done is closed when the compiler completes.
+	done chan struct{}
+	// err is the result of the compiler, only valid after done is closed.
+	err error
+}
+
+// ready returns true if the compiler completed without error.
+func (c *compilation) ready() bool {
+	select {
+	case <-c.done:
+		return c.err == nil
+	default:
+		return false
+	}
+}
+
+// NewEngine returns a tiered engine, or the interpreter if the compiler isn't
+// supported on this platform.
+func NewEngine(ctx context.Context, enabledFeatures api.CoreFeatures, fileCache filecache.Cache) wasm.Engine {
+	if !platform.CompilerSupported() {
+		return interpreter.NewEngine(ctx, enabledFeatures, fileCache)
+	}
+	return &engine{
+		interpreter:  interpreter.NewEngine(ctx, enabledFeatures, fileCache),
+		compiler:     compiler.NewEngine(ctx, enabledFeatures, fileCache),
+		compilations: map[wasm.ModuleID]*compilation{},
+		interpreted:  map[*wasm.Module]struct{}{},
+		refs:         map[wasm.ModuleID]int{},
+	}
+}
+
+// Close implements the same method as documented on wasm.Engine.
+func (e *engine) Close() error {
+	e.wg.Wait()
+	err := e.interpreter.Close()
+	if compilerErr := e.compiler.Close(); err == nil {
+		err = compilerErr
+	}
+	return err
+}
+
+// CompileModule implements the same method as documented on wasm.Engine.
+//
+// The enginekind.Kind in the context selects whether the compiler runs in
+// the background, synchronously or not at all.
+func (e *engine) CompileModule(ctx context.Context, module *wasm.Module, listeners []experimental.FunctionListener, ensureTermination bool) error {
+	// Host modules are compiled synchronously by both engines, as they are
+	// imported by modules in either tier, and only contain trampolines to Go.
+	if module.IsHostModule {
+		if err := e.interpreter.CompileModule(ctx, module, listeners, ensureTermination); err != nil {
+			return err
+		}
+		return e.compiler.CompileModule(ctx, module, listeners, ensureTermination)
+	}
+
+	// Count the module before compiling it, so that another module with the
+	// same ID can't delete the code meanwhile.
+	e.mux.Lock()
+	e.refs[module.ID]++
+	e.mux.Unlock()
+	if err := e.interpreter.CompileModule(ctx, module, listeners, ensureTermination); err != nil {
+		e.DeleteCompiledModule(module)
+		return err
+	}
+
+	kind, _ := ctx.Value(enginekind.Key{}).(enginekind.Kind)
+	e.mux.Lock()
+	if kind == enginekind.Interpreter {
+		e.interpreted[module] = struct{}{}
+		e.mux.Unlock()
+		return nil
+	}
+	c, ok := e.compilations[module.ID]
+	if !ok {
+		c = &compilation{done: make(chan struct{})}
+		e.compilations[module.ID] = c
+		e.wg.Add(1)
+		go func() {
+			defer e.wg.Done()
+			c.err = e.compiler.CompileModule(ctx, module, listeners, ensureTermination)
+			close(c.done)
+		}()
+	}
+	e.mux.Unlock()
+
+	if kind == enginekind.Compiler {
+		<-c.done
+		return c.err
+	}
+	return nil
+}
+
+// CompiledModuleCount implements the same method as documented on wasm.Engine.
+func (e *engine) CompiledModuleCount() uint32 {
+	// All modules are compiled by the interpreter, so it has the same count.
+	return e.interpreter.CompiledModuleCount()
+}
+
+// DeleteCompiledModule implements the same method as documented on wasm.Engine.
+func (e *engine) DeleteCompiledModule(module *wasm.Module) {
+	if module.IsHostModule {
+		e.interpreter.DeleteCompiledModule(module)
+		e.compiler.DeleteCompiledModule(module)
+		return
+	}
+
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	delete(e.interpreted, module)
+	if e.refs[module.ID]--; e.refs[module.ID] > 0 {
+		return // Another module with the same ID still uses the code.
+	}
+	delete(e.refs, module.ID)
+	e.interpreter.DeleteCompiledModule(module)
+	c, ok := e.compilations[module.ID]
+	if !ok {
+		return
+	}
+	delete(e.compilations, module.ID)
+
+	// Wait for the compiler in the background, so that it is deleted after it
+	// completes, unless the module was compiled again meanwhile.
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		<-c.done
+		e.mux.Lock()
+		defer e.mux.Unlock()
+		if _, ok := e.compilations[module.ID]; !ok {
+			e.compiler.DeleteCompiledModule(module)
+		}
+	}()
+}
+
+// NewModuleEngine implements the same method as documented on wasm.Engine.
+//
+// Instances of host modules and compiled modules use the compiler's
+// wasm.ModuleEngine as is. Otherwise, the interpreter's is wrapped by
+// interpretedModuleEngine, which resolves imports from host modules.
+//
+// The tier of an instance must match the guest modules it imports from, as
+// each engine calls functions and reads tables in its own convention. So, an
+// instance importing from a compiled instance waits for the compiler, and one
+// importing from an interpreted instance is interpreted. When that's not
+// possible, this returns an error instead.
+//
+// For this to not depend on timing, only anonymous instances which import no
+// guest modules are interpreted until the compiler completes. Named ones can
+// be imported by name, so wait for the compiler, and are only interpreted
+// when it fails or the module was compiled with enginekind.Interpreter.
+func (e *engine) NewModuleEngine(module *wasm.Module, instance *wasm.ModuleInstance) (wasm.ModuleEngine, error) {
+	if module.IsHostModule {
+		return e.compiler.NewModuleEngine(module, instance)
+	}
+
+	e.mux.Lock()
+	c, ok := e.compilations[module.ID]
+	_, interpreted := e.interpreted[module]
+	e.mux.Unlock()
+	compilable := ok && !interpreted
+
+	var hosts []*wasm.ModuleInstance
+	var compiledImport, interpretedImport string
+	for moduleName := range module.ImportPerModule {
+		imported, err := instance.ImportedModule(moduleName)
+		if err != nil {
+			// Interpret, so that resolving imports returns the error.
+			compiledImport, interpretedImport = "", moduleName
+			break
+		}
+		if imported.Source.IsHostModule {
+			hosts = append(hosts, imported)
+		} else if _, ok := imported.Engine.(*interpretedModuleEngine); ok {
+			interpretedImport = moduleName
+		} else {
+			compiledImport = moduleName
+		}
+	}
+
+	var compiled bool
+	switch {
+	case compiledImport != "" && interpretedImport != "":
+		return nil, fmt.Errorf("cannot import from compiled module[%s] and interpreted module[%s] at once",
+			compiledImport, interpretedImport)
+	case compiledImport != "" && !compilable:
+		return nil, fmt.Errorf("cannot import from compiled module[%s] into a module only interpreted", compiledImport)
+	case compiledImport != "":
+		<-c.done
+		if c.err != nil {
+			return nil, fmt.Errorf("cannot import from compiled module[%s]: %w", compiledImport, c.err)
+		}
+		compiled = true
+	case interpretedImport != "" || !compilable:
+	case instance.ModuleName != "":
+		<-c.done
+		compiled = c.err == nil
+	default:
+		compiled = c.ready()
+	}
+
+	if compiled {
+		return e.compiler.NewModuleEngine(module, instance)
+	}
+
+	me, err := e.interpreter.NewModuleEngine(module, instance)
+	if err != nil {
+		return nil, err
+	}
+	ret := &interpretedModuleEngine{ModuleEngine: me}
+	if len(hosts) > 0 {
+		// Imported host modules have the compiler's wasm.ModuleEngine, so
+		// create the interpreter's to resolve functions from.
+		ret.hosts = make(map[wasm.ModuleEngine]wasm.ModuleEngine, len(hosts))
+		for _, host := range hosts {
+			hostMe, err := e.interpreter.NewModuleEngine(host.Source, host)
+			if err != nil {
+				return nil, err
+			}
+			hostMe.DoneInstantiation()
+			ret.hosts[host.Engine] = hostMe
+		}
+	}
+	return ret, nil
+}
+
+// interpretedModuleEngine implements wasm.ModuleEngine for a module instance
+// executed by the interpreter.
+type interpretedModuleEngine struct {
+	wasm.ModuleEngine
+
+	// hosts are the interpreter's wasm.ModuleEngine of imported host modules,
+	// keyed by their wasm.ModuleInstance Engine. This is nil after
+	// DoneInstantiation.
+	hosts map[wasm.ModuleEngine]wasm.ModuleEngine
+}
+
+// DoneInstantiation implements the same method as documented on wasm.ModuleEngine.
+func (e *interpretedModuleEngine) DoneInstantiation() {
+	e.hosts = nil
+	e.ModuleEngine.DoneInstantiation()
+}
+
+// ResolveImportedFunction implements the same method as documented on wasm.ModuleEngine.
+func (e *interpretedModuleEngine) ResolveImportedFunction(index, indexInImportedModule wasm.Index, importedModuleEngine wasm.ModuleEngine) {
+	e.ModuleEngine.ResolveImportedFunction(index, indexInImportedModule, e.interpreted(importedModuleEngine))
+}
+
+// ResolveImportedMemory implements the same method as documented on wasm.ModuleEngine.
+func (e *interpretedModuleEngine) ResolveImportedMemory(importedModuleEngine wasm.ModuleEngine) {
+	e.ModuleEngine.ResolveImportedMemory(e.interpreted(importedModuleEngine))
+}
+
+// Resume implements wasm.Resumer.
+func (e *interpretedModuleEngine) Resume(ctx context.Context, call *wasm.CallState, results []uint64) ([]uint64, error) {
+	return e.ModuleEngine.(wasm.Resumer).Resume(ctx, call, results)
+}
+
+// WatchesMemory implements wasm.MemoryWatcher.
+func (e *interpretedModuleEngine) WatchesMemory() {}
+
+// interpreted returns the interpreter's wasm.ModuleEngine of an imported
+// module.
+func (e *interpretedModuleEngine) interpreted(importedModuleEngine wasm.ModuleEngine) wasm.ModuleEngine {
+	if me, ok := importedModuleEngine.(*interpretedModuleEngine); ok {
+		return me.ModuleEngine
+	}
+	return e.hosts[importedModuleEngine]
+}
@@ -0,0 +1,372 @@
+package tiered
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/enginekind"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/binaryencoding"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+// testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
+var testCtx = context.WithValue(context.Background(), struct{}{}, "arbitrary")
+
+// guestModule imports "inc" from `importModule`, re-exports it, and exports
+// "answer", which returns inc(41).
+func guestModule(t *testing.T, importModule string) *wasm.Module {
+	i32 := []api.ValueType{api.ValueTypeI32}
+	bin := binaryencoding.EncodeModule(&wasm.Module{
+		TypeSection: []wasm.FunctionType{
+			{Params: i32, Results: i32},
+			{Results: i32},
+		},
+		ImportSection:   []wasm.Import{{Module: importModule, Name: "inc", Type: wasm.ExternTypeFunc, DescFunc: 0}},
+		FunctionSection: []wasm.Index{1},
+		CodeSection: []wasm.Code{
+			{Body: []byte{wasm.OpcodeI32Const, 41, wasm.OpcodeCall, 0, wasm.OpcodeEnd}},
+		},
+		ExportSection: []wasm.Export{
+			{Name: "inc", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "answer", Type: wasm.ExternTypeFunc, Index: 1},
+		},
+	})
+	m, err := binary.DecodeModule(bin, api.CoreFeaturesV2, wasm.MemoryLimitPages, false, false, false)
+	require.NoError(t, err)
+	require.NoError(t, m.Validate(api.CoreFeaturesV2))
+	m.AssignModuleID(bin, nil, false, nil)
+	return m
+}
+
+// hostModule exports "inc", which increments its parameter.
+func hostModule(t *testing.T) *wasm.Module {
+	m, err := wasm.NewHostModule("env", []string{"inc"}, map[string]*wasm.HostFunc{
+		"inc": {
+			ExportName:  "inc",
+			ParamTypes:  []api.ValueType{api.ValueTypeI32},
+			ResultTypes: []api.ValueType{api.ValueTypeI32},
+			Code: wasm.Code{GoFunc: api.GoFunc(func(_ context.Context, stack []uint64) {
+				stack[0]++
+			})},
+		},
+	}, api.CoreFeaturesV2)
+	require.NoError(t, err)
+	require.NoError(t, m.Validate(api.CoreFeaturesV2))
+	return m
+}
+
+// instantiator instantiates modules compiled by an engine.
+type instantiator func(m *wasm.Module, name string) (*wasm.ModuleInstance, error)
+
+func newInstantiator(e *engine) instantiator {
+	s := wasm.NewStore(api.CoreFeaturesV2, e)
+	return func(m *wasm.Module, name string) (*wasm.ModuleInstance, error) {
+		typeIDs, err := s.GetFunctionTypeIDs(m.TypeSection)
+		if err != nil {
+			return nil, err
+		}
+		return s.Instantiate(testCtx, m, name, nil, typeIDs)
+	}
+}
+
+// must instantiates `m`, requiring no error.
+func (i instantiator) must(t *testing.T, m *wasm.Module, name string) *wasm.ModuleInstance {
+	mi, err := i(m, name)
+	require.NoError(t, err)
+	return mi
+}
+
+// compilationOf returns the compilation of `m`, waiting for the compiler.
+func compilationOf(t *testing.T, e *engine, m *wasm.Module) *compilation {
+	e.mux.Lock()
+	c := e.compilations[m.ID]
+	e.mux.Unlock()
+	require.NotNil(t, c)
+	<-c.done
+	require.NoError(t, c.err)
+	return c
+}
+
+// setCompilation replaces the compilation of `m`, for example to pretend the
+// compiler is still in progress.
+func setCompilation(e *engine, m *wasm.Module, c *compilation) {
+	e.mux.Lock()
+	e.compilations[m.ID] = c
+	e.mux.Unlock()
+}
+
+// instantiateWhileCompiling pretends the compiler of `m` is in progress
+// until after `instantiate` began, and requires that it waits for the
+// compiler.
+func instantiateWhileCompiling(t *testing.T, e *engine, m *wasm.Module, instantiate func() (*wasm.ModuleInstance, error)) (*wasm.ModuleInstance, error) {
+	compilationOf(t, e, m)
+	done := make(chan struct{})
+	setCompilation(e, m, &compilation{done: done})
+
+	type result struct {
+		mi  *wasm.ModuleInstance
+		err error
+	}
+	instantiated := make(chan result)
+	go func() {
+		mi, err := instantiate()
+		instantiated <- result{mi, err}
+	}()
+	select {
+	case <-instantiated:
+		t.Fatal("instantiated before the compiler completed")
+	default:
+	}
+	close(done)
+	r := <-instantiated
+	return r.mi, r.err
+}
+
+func TestEngine(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+
+	e := NewEngine(testCtx, api.CoreFeaturesV2, nil).(*engine)
+	instantiate := newInstantiator(e)
+
+	host := hostModule(t)
+	require.NoError(t, e.CompileModule(testCtx, host, nil, false))
+	instantiate.must(t, host, "env")
+
+	guest := guestModule(t, "env")
+	require.NoError(t, e.CompileModule(testCtx, guest, nil, false))
+	require.Equal(t, uint32(2), e.CompiledModuleCount())
+
+	// Wait for the compiler, then pretend it is still in progress. Only
+	// anonymous instances are interpreted meanwhile, as others may be
+	// imported by name.
+	c := compilationOf(t, e, guest)
+	setCompilation(e, guest, &compilation{done: make(chan struct{})})
+	interpreted := instantiate.must(t, guest, "")
+	setCompilation(e, guest, c)
+	compiled, err := instantiateWhileCompiling(t, e, guest, func() (*wasm.ModuleInstance, error) {
+		return instantiate(guest, "compiled")
+	})
+	require.NoError(t, err)
+
+	// The tier of an instance importing from another follows it.
+	interpretedGuest := guestModule(t, "env")
+	ctx := context.WithValue(testCtx, enginekind.Key{}, enginekind.Interpreter)
+	require.NoError(t, e.CompileModule(ctx, interpretedGuest, nil, false))
+	instantiate.must(t, interpretedGuest, "interpreted")
+	chained := guestModule(t, "interpreted")
+	require.NoError(t, e.CompileModule(testCtx, chained, nil, false))
+	compilationOf(t, e, chained)
+	chainedInterpreted := instantiate.must(t, chained, "chained-interpreted")
+	chainedToCompiled := guestModule(t, "compiled")
+	require.NoError(t, e.CompileModule(testCtx, chainedToCompiled, nil, false))
+	chainedCompiled := instantiate.must(t, chainedToCompiled, "chained-compiled")
+
+	tests := []struct {
+		name        string
+		mi          *wasm.ModuleInstance
+		interpreted bool
+	}{
+		{name: "interpreted", mi: interpreted, interpreted: true},
+		{name: "compiled", mi: compiled, interpreted: false},
+		{name: "chained interpreted", mi: chainedInterpreted, interpreted: true},
+		{name: "chained compiled", mi: chainedCompiled, interpreted: false},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			_, ok := tc.mi.Engine.(*interpretedModuleEngine)
+			require.Equal(t, tc.interpreted, ok)
+
+			results, err := tc.mi.ExportedFunction("answer").Call(testCtx)
+			require.NoError(t, err)
+			require.Equal(t, []uint64{42}, results)
+		})
+	}
+
+	e.DeleteCompiledModule(guest)
+	e.DeleteCompiledModule(interpretedGuest)
+	e.DeleteCompiledModule(chained)
+	e.DeleteCompiledModule(chainedToCompiled)
+	e.DeleteCompiledModule(host)
+	require.NoError(t, e.Close())
+	require.Equal(t, uint32(0), e.CompiledModuleCount())
+	require.Equal(t, uint32(0), e.compiler.CompiledModuleCount())
+}
+
+func TestEngine_DeleteCompiledModule(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+
+	e := NewEngine(testCtx, api.CoreFeaturesV2, nil).(*engine)
+	guest := guestModule(t, "env")
+
+	// Delete while the compiler may still be in progress.
+	require.NoError(t, e.CompileModule(testCtx, guest, nil, false))
+	e.DeleteCompiledModule(guest)
+	require.NoError(t, e.Close())
+	require.Equal(t, uint32(0), e.CompiledModuleCount())
+	require.Equal(t, uint32(0), e.compiler.CompiledModuleCount())
+}
+
+func TestEngine_DeleteCompiledModule_sameID(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+
+	e := NewEngine(testCtx, api.CoreFeaturesV2, nil).(*engine)
+	instantiate := newInstantiator(e)
+
+	host := hostModule(t)
+	require.NoError(t, e.CompileModule(testCtx, host, nil, false))
+	instantiate.must(t, host, "env")
+
+	// Compile the same source twice, so both have the same ID, then delete
+	// the first.
+	deleted, kept := guestModule(t, "env"), guestModule(t, "env")
+	require.NoError(t, e.CompileModule(testCtx, deleted, nil, false))
+	require.NoError(t, e.CompileModule(testCtx, kept, nil, false))
+	e.DeleteCompiledModule(deleted)
+
+	compilationOf(t, e, kept)
+	mi := instantiate.must(t, kept, "kept")
+	_, ok := mi.Engine.(*interpretedModuleEngine)
+	require.False(t, ok)
+	results, err := mi.ExportedFunction("answer").Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{42}, results)
+
+	e.DeleteCompiledModule(kept)
+	e.DeleteCompiledModule(host)
+	require.NoError(t, e.Close())
+	require.Equal(t, uint32(0), e.CompiledModuleCount())
+	require.Equal(t, uint32(0), e.compiler.CompiledModuleCount())
+}
+
+func TestEngine_EngineKind(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+
+	e := NewEngine(testCtx, api.CoreFeaturesV2, nil).(*engine)
+	defer e.Close()
+	instantiate := newInstantiator(e)
+
+	host := hostModule(t)
+	require.NoError(t, e.CompileModule(testCtx, host, nil, false))
+	instantiate.must(t, host, "env")
+
+	// Compile the same source with each kind, so both have the same ID.
+	interpreted := guestModule(t, "env")
+	ctx := context.WithValue(testCtx, enginekind.Key{}, enginekind.Interpreter)
+	require.NoError(t, e.CompileModule(ctx, interpreted, nil, false))
+	e.mux.Lock()
+	_, ok := e.compilations[interpreted.ID]
+	e.mux.Unlock()
+	require.False(t, ok)
+
+	compiled := guestModule(t, "env")
+	ctx = context.WithValue(testCtx, enginekind.Key{}, enginekind.Compiler)
+	require.NoError(t, e.CompileModule(ctx, compiled, nil, false))
+	e.mux.Lock()
+	c := e.compilations[compiled.ID]
+	e.mux.Unlock()
+	require.True(t, c.ready())
+
+	tests := []struct {
+		name        string
+		module      *wasm.Module
+		interpreted bool
+	}{
+		{name: "interpreter", module: interpreted, interpreted: true},
+		{name: "compiler", module: compiled, interpreted: false},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			mi := instantiate.must(t, tc.module, tc.name)
+			_, ok := mi.Engine.(*interpretedModuleEngine)
+			require.Equal(t, tc.interpreted, ok)
+
+			results, err := mi.ExportedFunction("answer").Call(testCtx)
+			require.NoError(t, err)
+			require.Equal(t, []uint64{42}, results)
+		})
+	}
+}
+
+func TestEngine_importCompiled(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+
+	e := NewEngine(testCtx, api.CoreFeaturesV2, nil).(*engine)
+	defer e.Close()
+	instantiate := newInstantiator(e)
+
+	host := hostModule(t)
+	require.NoError(t, e.CompileModule(testCtx, host, nil, false))
+	_, err := instantiate(host, "env")
+	require.NoError(t, err)
+
+	// Instantiate a guest after the compiler completed, and one before.
+	compiledGuest := guestModule(t, "env")
+	require.NoError(t, e.CompileModule(testCtx, compiledGuest, nil, false))
+	compilationOf(t, e, compiledGuest)
+	compiled, err := instantiate(compiledGuest, "compiled")
+	require.NoError(t, err)
+	_, ok := compiled.Engine.(*interpretedModuleEngine)
+	require.False(t, ok)
+
+	interpretedGuest := guestModule(t, "env")
+	ctx := context.WithValue(testCtx, enginekind.Key{}, enginekind.Interpreter)
+	require.NoError(t, e.CompileModule(ctx, interpretedGuest, nil, false))
+	_, err = instantiate(interpretedGuest, "interpreted")
+	require.NoError(t, err)
+
+	t.Run("waits for the compiler", func(t *testing.T) {
+		importer := guestModule(t, "compiled")
+		require.NoError(t, e.CompileModule(testCtx, importer, nil, false))
+
+		mi, err := instantiateWhileCompiling(t, e, importer, func() (*wasm.ModuleInstance, error) {
+			return instantiate(importer, "importer")
+		})
+		require.NoError(t, err)
+		_, ok := mi.Engine.(*interpretedModuleEngine)
+		require.False(t, ok)
+		results, err := mi.ExportedFunction("answer").Call(testCtx)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{42}, results)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		importer := guestModule(t, "compiled")
+		require.NoError(t, e.CompileModule(ctx, importer, nil, false))
+		_, err := instantiate(importer, "interpreted-importer")
+		require.EqualError(t, err, "cannot import from compiled module[compiled] into a module only interpreted")
+
+		// Import the same function from both tiers.
+		i32 := []api.ValueType{api.ValueTypeI32}
+		bin := binaryencoding.EncodeModule(&wasm.Module{
+			TypeSection: []wasm.FunctionType{{Params: i32, Results: i32}},
+			ImportSection: []wasm.Import{
+				{Module: "compiled", Name: "inc", Type: wasm.ExternTypeFunc, DescFunc: 0},
+				{Module: "interpreted", Name: "inc", Type: wasm.ExternTypeFunc, DescFunc: 0},
+			},
+		})
+		both, err := binary.DecodeModule(bin, api.CoreFeaturesV2, wasm.MemoryLimitPages, false, false, false)
+		require.NoError(t, err)
+		both.AssignModuleID(bin, nil, false, nil)
+		require.NoError(t, e.CompileModule(testCtx, both, nil, false))
+		_, err = instantiate(both, "both")
+		require.EqualError(t, err, "cannot import from compiled module[compiled] and interpreted module[interpreted] at once")
+	})
+}
@@ -277,26 +277,20 @@ L2 (SSA Block: blk1):
 			afterLoweringARM64: `
 L1 (SSA Block: blk0):
 	mov x128?, x0
-	mov x131?, xzr
-	cbnz w131?, L2
-L3 (SSA Block: blk2):
-	movz x132?, #0x3, lsl 0
-	str w132?, [x128?]
-	mov x133?, sp
-	str x133?, [x128?, #0x38]
-	adr x134?, #0x0
-	str x134?, [x128?, #0x30]
+L2 (SSA Block: blk2):
+	movz x130?, #0x3, lsl 0
+	str w130?, [x128?]
+	mov x131?, sp
+	str x131?, [x128?, #0x38]
+	adr x132?, #0x0
+	str x132?, [x128?, #0x30]
 	exit_sequence x128?
-L2 (SSA Block: blk1):
-	ret
 `,
 			afterFinalizeARM64: `
 L1 (SSA Block: blk0):
 	stp x30, xzr, [sp, #-0x10]!
 	str xzr, [sp, #-0x10]!
-	mov x8, xzr
-	cbnz w8, #0x34 (L2)
-L3 (SSA Block: blk2):
+L2 (SSA Block: blk2):
 	movz x8, #0x3, lsl 0
 	str w8, [x0]
 	mov x8, sp
@@ -304,10 +298,6 @@ L3 (SSA Block: blk2):
 	adr x8, #0x0
 	str x8, [x0, #0x30]
 	exit_sequence x0
-L2 (SSA Block: blk1):
-	add sp, sp, #0x10
-	ldr x30, [sp], #0x10
-	ret
 `,
 		},
 		{
@@ -329,32 +319,15 @@ L2 (SSA Block: blk1):
 			name: "loop_with_param_results", m: testcases.LoopBrWithParamResults.Module,
 			afterLoweringARM64: `
 L1 (SSA Block: blk0):
-	mov x130?, x2
 L2 (SSA Block: blk1):
-	orr w133?, wzr, #0x1
-	cbz w133?, (L3)
-L4 (SSA Block: blk4):
 	b L2
-L3 (SSA Block: blk3):
-L5 (SSA Block: blk2):
-	mov x0, x130?
-	ret
 `,
 			afterFinalizeARM64: `
 L1 (SSA Block: blk0):
 	stp x30, xzr, [sp, #-0x10]!
 	str xzr, [sp, #-0x10]!
 L2 (SSA Block: blk1):
-	orr w8, wzr, #0x1
-	cbz w8, #0x8 L3
-L4 (SSA Block: blk4):
-	b #-0x8 (L2)
-L3 (SSA Block: blk3):
-L5 (SSA Block: blk2):
-	mov x0, x2
-	add sp, sp, #0x10
-	ldr x30, [sp], #0x10
-	ret
+	b #0x0 (L2)
 `,
 		},
 		{
@@ -362,26 +335,14 @@ L5 (SSA Block: blk2):
 			afterLoweringARM64: `
 L1 (SSA Block: blk0):
 L2 (SSA Block: blk1):
-	orr w131?, wzr, #0x1
-	cbz w131?, (L3)
-L4 (SSA Block: blk4):
 	b L2
-L3 (SSA Block: blk3):
-	ret
 `,
 			afterFinalizeARM64: `
 L1 (SSA Block: blk0):
 	stp x30, xzr, [sp, #-0x10]!
 	str xzr, [sp, #-0x10]!
 L2 (SSA Block: blk1):
-	orr w8, wzr, #0x1
-	cbz w8, #0x8 L3
-L4 (SSA Block: blk4):
-	b #-0x8 (L2)
-L3 (SSA Block: blk3):
-	add sp, sp, #0x10
-	ldr x30, [sp], #0x10
-	ret
+	b #0x0 (L2)
 `,
 		},
 		{
@@ -408,24 +369,16 @@ L2 (SSA Block: blk1):
 			// Instead, we can do it during the code generation phase where we actually resolve the label offsets.
 			afterLoweringARM64: `
 L1 (SSA Block: blk0):
-	mov x131?, xzr
-	cbz w131?, (L2)
-L3 (SSA Block: blk1):
-	b L4
 L2 (SSA Block: blk2):
-L4 (SSA Block: blk3):
+L3 (SSA Block: blk3):
 	ret
 `,
 			afterFinalizeARM64: `
 L1 (SSA Block: blk0):
 	stp x30, xzr, [sp, #-0x10]!
 	str xzr, [sp, #-0x10]!
-	mov x8, xzr
-	cbz w8, #0x8 L2
-L3 (SSA Block: blk1):
-	b #0x4 (L4)
 L2 (SSA Block: blk2):
-L4 (SSA Block: blk3):
+L3 (SSA Block: blk3):
 	add sp, sp, #0x10
 	ldr x30, [sp], #0x10
 	ret
@@ -435,11 +388,6 @@ L4 (SSA Block: blk3):
 			name: "if_else", m: testcases.IfElse.Module,
 			afterLoweringARM64: `
 L1 (SSA Block: blk0):
-	mov x131?, xzr
-	cbz w131?, (L2)
-L3 (SSA Block: blk1):
-L4 (SSA Block: blk3):
-	ret
 L2 (SSA Block: blk2):
 	ret
 `,
@@ -447,13 +395,6 @@ L2 (SSA Block: blk2):
 L1 (SSA Block: blk0):
 	stp x30, xzr, [sp, #-0x10]!
 	str xzr, [sp, #-0x10]!
-	mov x8, xzr
-	cbz w8, #0x10 L2
-L3 (SSA Block: blk1):
-L4 (SSA Block: blk3):
-	add sp, sp, #0x10
-	ldr x30, [sp], #0x10
-	ret
 L2 (SSA Block: blk2):
 	add sp, sp, #0x10
 	ldr x30, [sp], #0x10
@@ -464,14 +405,8 @@ L2 (SSA Block: blk2):
 			name: "single_predecessor_local_refs", m: testcases.SinglePredecessorLocalRefs.Module,
 			afterLoweringARM64: `
 L1 (SSA Block: blk0):
-	mov x132?, xzr
-	cbz w132?, (L2)
-L3 (SSA Block: blk1):
-	mov x131?, xzr
-	mov x0, x131?
-	ret
 L2 (SSA Block: blk2):
-L4 (SSA Block: blk3):
+L3 (SSA Block: blk3):
 	mov x130?, xzr
 	mov x0, x130?
 	ret
@@ -480,16 +415,8 @@ L4 (SSA Block: blk3):
 L1 (SSA Block: blk0):
 	stp x30, xzr, [sp, #-0x10]!
 	str xzr, [sp, #-0x10]!
-	mov x8, xzr
-	cbz w8, #0x18 L2
-L3 (SSA Block: blk1):
-	mov x8, xzr
-	mov x0, x8
-	add sp, sp, #0x10
-	ldr x30, [sp], #0x10
-	ret
 L2 (SSA Block: blk2):
-L4 (SSA Block: blk3):
+L3 (SSA Block: blk3):
 	mov x8, xzr
 	mov x0, x8
 	add sp, sp, #0x10
@@ -659,8 +586,7 @@ L1 (SSA Block: blk0):
 	str x1, [x0, #0x8]
 	bl f1
 	str w0, [sp, #0x20]
-	ldr x8, [sp, #0x10]
-	ldr x9, [sp, #0x18]
+	ldp x8, x9, [sp, #0x10]
 	str x8, [x9, #0x8]
 	mov x0, x9
 	mov x1, x8
@@ -670,8 +596,7 @@ L1 (SSA Block: blk0):
 	mov x3, x10
 	bl f2
 	str w0, [sp, #0x24]
-	ldr x8, [sp, #0x10]
-	ldr x9, [sp, #0x18]
+	ldp x8, x9, [sp, #0x10]
 	str x8, [x9, #0x8]
 	mov x0, x9
 	mov x1, x8
@@ -1394,126 +1319,124 @@ L1 (SSA Block: blk0):
 L1 (SSA Block: blk0):
 	mov x130?, x2
 	mov v131?.8b, v0.8b
-	orr w289?, wzr, #0x1
-	madd w133?, w130?, w289?, wzr
-	orr w288?, wzr, #0x2
-	madd w135?, w130?, w288?, wzr
-	orr w287?, wzr, #0x3
-	madd w137?, w130?, w287?, wzr
-	orr w286?, wzr, #0x4
-	madd w139?, w130?, w286?, wzr
-	movz w285?, #0x5, lsl 0
-	madd w141?, w130?, w285?, wzr
-	orr w284?, wzr, #0x6
-	madd w143?, w130?, w284?, wzr
-	orr w283?, wzr, #0x7
-	madd w145?, w130?, w283?, wzr
-	orr w282?, wzr, #0x8
-	madd w147?, w130?, w282?, wzr
-	movz w281?, #0x9, lsl 0
-	madd w149?, w130?, w281?, wzr
-	movz w280?, #0xa, lsl 0
-	madd w151?, w130?, w280?, wzr
-	movz w279?, #0xb, lsl 0
-	madd w153?, w130?, w279?, wzr
-	orr w278?, wzr, #0xc
-	madd w155?, w130?, w278?, wzr
-	movz w277?, #0xd, lsl 0
-	madd w157?, w130?, w277?, wzr
-	orr w276?, wzr, #0xe
-	madd w159?, w130?, w276?, wzr
-	orr w275?, wzr, #0xf
-	madd w161?, w130?, w275?, wzr
-	orr w274?, wzr, #0x10
-	madd w163?, w130?, w274?, wzr
-	movz w273?, #0x11, lsl 0
-	madd w165?, w130?, w273?, wzr
-	movz w272?, #0x12, lsl 0
-	madd w167?, w130?, w272?, wzr
-	movz w271?, #0x13, lsl 0
-	madd w169?, w130?, w271?, wzr
-	movz w270?, #0x14, lsl 0
-	madd w171?, w130?, w270?, wzr
-	add w172?, w169?, w171?
-	add w173?, w167?, w172?
-	add w174?, w165?, w173?
-	add w175?, w163?, w174?
-	add w176?, w161?, w175?
-	add w177?, w159?, w176?
-	add w178?, w157?, w177?
-	add w179?, w155?, w178?
-	add w180?, w153?, w179?
-	add w181?, w151?, w180?
-	add w182?, w149?, w181?
-	add w183?, w147?, w182?
-	add w184?, w145?, w183?
-	add w185?, w143?, w184?
-	add w186?, w141?, w185?
-	add w187?, w139?, w186?
-	add w188?, w137?, w187?
-	add w189?, w135?, w188?
-	add w190?, w133?, w189?
-	ldr s269?, #8; b 8; data.f32 1.000000
-	fmul s192?, s131?, s269?
-	ldr s268?, #8; b 8; data.f32 2.000000
-	fmul s194?, s131?, s268?
-	ldr s267?, #8; b 8; data.f32 3.000000
-	fmul s196?, s131?, s267?
-	ldr s266?, #8; b 8; data.f32 4.000000
-	fmul s198?, s131?, s266?
-	ldr s265?, #8; b 8; data.f32 5.000000
-	fmul s200?, s131?, s265?
-	ldr s264?, #8; b 8; data.f32 6.000000
-	fmul s202?, s131?, s264?
-	ldr s263?, #8; b 8; data.f32 7.000000
-	fmul s204?, s131?, s263?
-	ldr s262?, #8; b 8; data.f32 8.000000
-	fmul s206?, s131?, s262?
-	ldr s261?, #8; b 8; data.f32 9.000000
-	fmul s208?, s131?, s261?
-	ldr s260?, #8; b 8; data.f32 10.000000
-	fmul s210?, s131?, s260?
-	ldr s259?, #8; b 8; data.f32 11.000000
-	fmul s212?, s131?, s259?
-	ldr s258?, #8; b 8; data.f32 12.000000
-	fmul s214?, s131?, s258?
-	ldr s257?, #8; b 8; data.f32 13.000000
-	fmul s216?, s131?, s257?
-	ldr s256?, #8; b 8; data.f32 14.000000
-	fmul s218?, s131?, s256?
-	ldr s255?, #8; b 8; data.f32 15.000000
-	fmul s220?, s131?, s255?
-	ldr s254?, #8; b 8; data.f32 16.000000
-	fmul s222?, s131?, s254?
-	ldr s253?, #8; b 8; data.f32 17.000000
-	fmul s224?, s131?, s253?
-	ldr s252?, #8; b 8; data.f32 18.000000
-	fmul s226?, s131?, s252?
-	ldr s251?, #8; b 8; data.f32 19.000000
-	fmul s228?, s131?, s251?
-	ldr s250?, #8; b 8; data.f32 20.000000
-	fmul s230?, s131?, s250?
-	fadd s231?, s228?, s230?
-	fadd s232?, s226?, s231?
-	fadd s233?, s224?, s232?
-	fadd s234?, s222?, s233?
-	fadd s235?, s220?, s234?
-	fadd s236?, s218?, s235?
-	fadd s237?, s216?, s236?
-	fadd s238?, s214?, s237?
-	fadd s239?, s212?, s238?
-	fadd s240?, s210?, s239?
-	fadd s241?, s208?, s240?
-	fadd s242?, s206?, s241?
-	fadd s243?, s204?, s242?
-	fadd s244?, s202?, s243?
-	fadd s245?, s200?, s244?
-	fadd s246?, s198?, s245?
-	fadd s247?, s196?, s246?
-	fadd s248?, s194?, s247?
-	fadd s249?, s192?, s248?
-	mov v0.8b, v249?.8b
-	mov x0, x190?
+	orr w286?, wzr, #0x2
+	madd w133?, w130?, w286?, wzr
+	orr w285?, wzr, #0x3
+	madd w135?, w130?, w285?, wzr
+	orr w284?, wzr, #0x4
+	madd w137?, w130?, w284?, wzr
+	movz w283?, #0x5, lsl 0
+	madd w139?, w130?, w283?, wzr
+	orr w282?, wzr, #0x6
+	madd w141?, w130?, w282?, wzr
+	orr w281?, wzr, #0x7
+	madd w143?, w130?, w281?, wzr
+	orr w280?, wzr, #0x8
+	madd w145?, w130?, w280?, wzr
+	movz w279?, #0x9, lsl 0
+	madd w147?, w130?, w279?, wzr
+	movz w278?, #0xa, lsl 0
+	madd w149?, w130?, w278?, wzr
+	movz w277?, #0xb, lsl 0
+	madd w151?, w130?, w277?, wzr
+	orr w276?, wzr, #0xc
+	madd w153?, w130?, w276?, wzr
+	movz w275?, #0xd, lsl 0
+	madd w155?, w130?, w275?, wzr
+	orr w274?, wzr, #0xe
+	madd w157?, w130?, w274?, wzr
+	orr w273?, wzr, #0xf
+	madd w159?, w130?, w273?, wzr
+	orr w272?, wzr, #0x10
+	madd w161?, w130?, w272?, wzr
+	movz w271?, #0x11, lsl 0
+	madd w163?, w130?, w271?, wzr
+	movz w270?, #0x12, lsl 0
+	madd w165?, w130?, w270?, wzr
+	movz w269?, #0x13, lsl 0
+	madd w167?, w130?, w269?, wzr
+	movz w268?, #0x14, lsl 0
+	madd w169?, w130?, w268?, wzr
+	add w170?, w167?, w169?
+	add w171?, w165?, w170?
+	add w172?, w163?, w171?
+	add w173?, w161?, w172?
+	add w174?, w159?, w173?
+	add w175?, w157?, w174?
+	add w176?, w155?, w175?
+	add w177?, w153?, w176?
+	add w178?, w151?, w177?
+	add w179?, w149?, w178?
+	add w180?, w147?, w179?
+	add w181?, w145?, w180?
+	add w182?, w143?, w181?
+	add w183?, w141?, w182?
+	add w184?, w139?, w183?
+	add w185?, w137?, w184?
+	add w186?, w135?, w185?
+	add w187?, w133?, w186?
+	add w188?, w130?, w187?
+	ldr s267?, #8; b 8; data.f32 1.000000
+	fmul s190?, s131?, s267?
+	ldr s266?, #8; b 8; data.f32 2.000000
+	fmul s192?, s131?, s266?
+	ldr s265?, #8; b 8; data.f32 3.000000
+	fmul s194?, s131?, s265?
+	ldr s264?, #8; b 8; data.f32 4.000000
+	fmul s196?, s131?, s264?
+	ldr s263?, #8; b 8; data.f32 5.000000
+	fmul s198?, s131?, s263?
+	ldr s262?, #8; b 8; data.f32 6.000000
+	fmul s200?, s131?, s262?
+	ldr s261?, #8; b 8; data.f32 7.000000
+	fmul s202?, s131?, s261?
+	ldr s260?, #8; b 8; data.f32 8.000000
+	fmul s204?, s131?, s260?
+	ldr s259?, #8; b 8; data.f32 9.000000
+	fmul s206?, s131?, s259?
+	ldr s258?, #8; b 8; data.f32 10.000000
+	fmul s208?, s131?, s258?
+	ldr s257?, #8; b 8; data.f32 11.000000
+	fmul s210?, s131?, s257?
+	ldr s256?, #8; b 8; data.f32 12.000000
+	fmul s212?, s131?, s256?
+	ldr s255?, #8; b 8; data.f32 13.000000
+	fmul s214?, s131?, s255?
+	ldr s254?, #8; b 8; data.f32 14.000000
+	fmul s216?, s131?, s254?
+	ldr s253?, #8; b 8; data.f32 15.000000
+	fmul s218?, s131?, s253?
+	ldr s252?, #8; b 8; data.f32 16.000000
+	fmul s220?, s131?, s252?
+	ldr s251?, #8; b 8; data.f32 17.000000
+	fmul s222?, s131?, s251?
+	ldr s250?, #8; b 8; data.f32 18.000000
+	fmul s224?, s131?, s250?
+	ldr s249?, #8; b 8; data.f32 19.000000
+	fmul s226?, s131?, s249?
+	ldr s248?, #8; b 8; data.f32 20.000000
+	fmul s228?, s131?, s248?
+	fadd s229?, s226?, s228?
+	fadd s230?, s224?, s229?
+	fadd s231?, s222?, s230?
+	fadd s232?, s220?, s231?
+	fadd s233?, s218?, s232?
+	fadd s234?, s216?, s233?
+	fadd s235?, s214?, s234?
+	fadd s236?, s212?, s235?
+	fadd s237?, s210?, s236?
+	fadd s238?, s208?, s237?
+	fadd s239?, s206?, s238?
+	fadd s240?, s204?, s239?
+	fadd s241?, s202?, s240?
+	fadd s242?, s200?, s241?
+	fadd s243?, s198?, s242?
+	fadd s244?, s196?, s243?
+	fadd s245?, s194?, s244?
+	fadd s246?, s192?, s245?
+	fadd s247?, s190?, s246?
+	mov v0.8b, v247?.8b
+	mov x0, x188?
 	ret
 `,
 			afterFinalizeARM64: `
@@ -1539,47 +1462,44 @@ L1 (SSA Block: blk0):
 	str q27, [sp, #-0x10]!
 	movz x27, #0x120, lsl 0
 	str x27, [sp, #-0x10]!
-	orr w8, wzr, #0x1
+	orr w8, wzr, #0x2
 	madd w8, w2, w8, wzr
-	orr w9, wzr, #0x2
+	orr w9, wzr, #0x3
 	madd w9, w2, w9, wzr
-	orr w10, wzr, #0x3
+	orr w10, wzr, #0x4
 	madd w10, w2, w10, wzr
-	orr w11, wzr, #0x4
+	movz w11, #0x5, lsl 0
 	madd w11, w2, w11, wzr
-	movz w12, #0x5, lsl 0
+	orr w12, wzr, #0x6
 	madd w12, w2, w12, wzr
-	orr w13, wzr, #0x6
+	orr w13, wzr, #0x7
 	madd w13, w2, w13, wzr
-	orr w14, wzr, #0x7
+	orr w14, wzr, #0x8
 	madd w14, w2, w14, wzr
-	orr w15, wzr, #0x8
+	movz w15, #0x9, lsl 0
 	madd w15, w2, w15, wzr
-	movz w16, #0x9, lsl 0
+	movz w16, #0xa, lsl 0
 	madd w16, w2, w16, wzr
-	movz w17, #0xa, lsl 0
+	movz w17, #0xb, lsl 0
 	madd w17, w2, w17, wzr
-	movz w19, #0xb, lsl 0
+	orr w19, wzr, #0xc
 	madd w19, w2, w19, wzr
-	orr w20, wzr, #0xc
+	movz w20, #0xd, lsl 0
 	madd w20, w2, w20, wzr
-	movz w21, #0xd, lsl 0
+	orr w21, wzr, #0xe
 	madd w21, w2, w21, wzr
-	orr w22, wzr, #0xe
+	orr w22, wzr, #0xf
 	madd w22, w2, w22, wzr
-	orr w23, wzr, #0xf
+	orr w23, wzr, #0x10
 	madd w23, w2, w23, wzr
-	orr w24, wzr, #0x10
+	movz w24, #0x11, lsl 0
 	madd w24, w2, w24, wzr
-	movz w25, #0x11, lsl 0
+	movz w25, #0x12, lsl 0
 	madd w25, w2, w25, wzr
-	movz w26, #0x12, lsl 0
+	movz w26, #0x13, lsl 0
 	madd w26, w2, w26, wzr
-	movz w29, #0x13, lsl 0
+	movz w29, #0x14, lsl 0
 	madd w29, w2, w29, wzr
-	movz w30, #0x14, lsl 0
-	madd w30, w2, w30, wzr
-	add w29, w29, w30
 	add w26, w26, w29
 	add w25, w25, w26
 	add w24, w24, w25
@@ -1598,6 +1518,7 @@ L1 (SSA Block: blk0):
 	add w10, w10, w11
 	add w9, w9, w10
 	add w8, w8, w9
+	add w8, w2, w8
 	ldr s8, #8; b 8; data.f32 1.000000
 	fmul s8, s0, s8
 	ldr s9, #8; b 8; data.f32 2.000000
@@ -1709,8 +1630,7 @@ L1 (SSA Block: blk0):
 	str x27, [sp, #-0x10]!
 	str w2, [sp, #0x10]
 	str x1, [x0, #0x8]
-	ldr x8, [x1, #0x8]
-	ldr x9, [x1, #0x10]
+	ldp x8, x9, [x1, #0x8]
 	mov x1, x9
 	ldr w9, [sp, #0x10]
 	mov x3, x9
@@ -1780,151 +1700,16 @@ L2:
 L1 (SSA Block: blk0):
 	stp x30, xzr, [sp, #-0x10]!
 	str xzr, [sp, #-0x10]!
-	mov x8, xzr
-	uxtw x8, w8
-	ldr w9, [x1, #0x10]
-	add x10, x8, #0x4
-	subs xzr, x9, x10
-	mov x10, x0
-	b.hs #0x34, (L10)
-	movz x11, #0x4, lsl 0
-	str w11, [x10]
-	mov x11, sp
-	str x11, [x10, #0x38]
-	adr x11, #0x0
-	str x11, [x10, #0x30]
-	exit_sequence x10
-L10:
-	ldr x10, [x1, #0x8]
-	add x8, x10, x8
+	ldr x8, [x1, #0x8]
 	str w2, [x8]
-	orr w8, wzr, #0x8
-	uxtw x8, w8
-	add x11, x8, #0x8
-	subs xzr, x9, x11
-	mov x11, x0
-	b.hs #0x34, (L9)
-	movz x12, #0x4, lsl 0
-	str w12, [x11]
-	mov x12, sp
-	str x12, [x11, #0x38]
-	adr x12, #0x0
-	str x12, [x11, #0x30]
-	exit_sequence x11
-L9:
-	add x8, x10, x8
-	str x3, [x8]
-	orr w8, wzr, #0x10
-	uxtw x8, w8
-	add x11, x8, #0x4
-	subs xzr, x9, x11
-	mov x11, x0
-	b.hs #0x34, (L8)
-	movz x12, #0x4, lsl 0
-	str w12, [x11]
-	mov x12, sp
-	str x12, [x11, #0x38]
-	adr x12, #0x0
-	str x12, [x11, #0x30]
-	exit_sequence x11
-L8:
-	add x8, x10, x8
-	str s0, [x8]
-	orr w8, wzr, #0x18
-	uxtw x8, w8
-	add x11, x8, #0x8
-	subs xzr, x9, x11
-	mov x11, x0
-	b.hs #0x34, (L7)
-	movz x12, #0x4, lsl 0
-	str w12, [x11]
-	mov x12, sp
-	str x12, [x11, #0x38]
-	adr x12, #0x0
-	str x12, [x11, #0x30]
-	exit_sequence x11
-L7:
-	add x8, x10, x8
-	str d1, [x8]
-	orr w8, wzr, #0x20
-	uxtw x8, w8
-	add x11, x8, #0x1
-	subs xzr, x9, x11
-	mov x11, x0
-	b.hs #0x34, (L6)
-	movz x12, #0x4, lsl 0
-	str w12, [x11]
-	mov x12, sp
-	str x12, [x11, #0x38]
-	adr x12, #0x0
-	str x12, [x11, #0x30]
-	exit_sequence x11
-L6:
-	add x8, x10, x8
-	strb w2, [x8]
-	movz w8, #0x28, lsl 0
-	uxtw x8, w8
-	add x11, x8, #0x2
-	subs xzr, x9, x11
-	mov x11, x0
-	b.hs #0x34, (L5)
-	movz x12, #0x4, lsl 0
-	str w12, [x11]
-	mov x12, sp
-	str x12, [x11, #0x38]
-	adr x12, #0x0
-	str x12, [x11, #0x30]
-	exit_sequence x11
-L5:
-	add x8, x10, x8
-	strh w2, [x8]
-	orr w8, wzr, #0x30
-	uxtw x8, w8
-	add x11, x8, #0x1
-	subs xzr, x9, x11
-	mov x11, x0
-	b.hs #0x34, (L4)
-	movz x12, #0x4, lsl 0
-	str w12, [x11]
-	mov x12, sp
-	str x12, [x11, #0x38]
-	adr x12, #0x0
-	str x12, [x11, #0x30]
-	exit_sequence x11
-L4:
-	add x8, x10, x8
-	strb w3, [x8]
-	orr w8, wzr, #0x38
-	uxtw x8, w8
-	add x11, x8, #0x2
-	subs xzr, x9, x11
-	mov x11, x0
-	b.hs #0x34, (L3)
-	movz x12, #0x4, lsl 0
-	str w12, [x11]
-	mov x12, sp
-	str x12, [x11, #0x38]
-	adr x12, #0x0
-	str x12, [x11, #0x30]
-	exit_sequence x11
-L3:
-	add x8, x10, x8
-	strh w3, [x8]
-	orr w8, wzr, #0x40
-	uxtw x8, w8
-	add x11, x8, #0x4
-	subs xzr, x9, x11
-	b.hs #0x34, (L2)
-	movz x9, #0x4, lsl 0
-	str w9, [x0]
-	mov x9, sp
-	str x9, [x0, #0x38]
-	adr x9, #0x0
-	str x9, [x0, #0x30]
-	exit_sequence x0
-L2:
-	add x8, x10, x8
-	str w3, [x8]
+	str x3, [x8, #0x8]
+	str s0, [x8, #0x10]
+	str d1, [x8, #0x18]
+	strb w2, [x8, #0x20]
+	strh w2, [x8, #0x28]
+	strb w3, [x8, #0x30]
+	strh w3, [x8, #0x38]
+	str w3, [x8, #0x40]
 	add sp, sp, #0x10
 	ldr x30, [sp], #0x10
 	ret
@@ -2181,7 +1966,7 @@ L1 (SSA Block: blk0):
 		t.Run(tc.name, func(t *testing.T) {
 			ssab := ssa.NewBuilder()
 			offset := wazevoapi.NewModuleContextOffsetData(tc.m, false)
-			fc := frontend.NewFrontendCompiler(tc.m, ssab, &offset, false, false, false)
+			fc := frontend.NewFrontendCompiler(tc.m, ssab, &offset, false, false, false, false)
 			machine := newMachine()
 			machine.DisableStackCheck()
 			be := backend.NewCompiler(context.Background(), machine, ssab)
@@ -192,6 +192,7 @@ func (c *compiler) RegAlloc() {
 func (c *compiler) Finalize() {
 	c.mach.SetupPrologue()
 	c.mach.SetupEpilogue()
+	c.mach.PostRegAlloc()
 	c.mach.ResolveRelativeAddresses()
 }
 
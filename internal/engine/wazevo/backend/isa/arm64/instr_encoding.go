@@ -91,15 +91,7 @@ func (i *instruction) encode(c backend.Compiler) {
 		rt, rt2 := regNumberInEncoding[i.rn.realReg()], regNumberInEncoding[i.rm.realReg()]
 		amode := i.amode
 		rn := regNumberInEncoding[amode.rn.RealReg()]
-		var pre bool
-		switch amode.kind {
-		case addressModeKindPostIndex:
-		case addressModeKindPreIndex:
-			pre = true
-		default:
-			panic("BUG")
-		}
-		c.Emit4Bytes(encodePreOrPostIndexLoadStorePair64(pre, kind == loadP64, rn, rt, rt2, amode.imm))
+		c.Emit4Bytes(encodeLoadOrStorePair64(amode.kind, kind == loadP64, rn, rt, rt2, amode.imm))
 	case loadFpuConst32:
 		rd := regNumberInEncoding[i.rd.realReg()]
 		if i.u1 == 0 {
@@ -1673,10 +1665,10 @@ func encodeAddSubtractImmediate(sf_op_s uint32, sh uint32, imm12 uint32, rn, rd
 	return
 }
 
-// encodePreOrPostIndexLoadStorePair64 encodes as Load/store pair (pre/post-indexed) in
+// encodeLoadOrStorePair64 encodes as Load/store pair (pre/post-indexed or signed offset) in
 // https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/LDP--Load-Pair-of-Registers-
 // https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/STP--Store-Pair-of-Registers-
-func encodePreOrPostIndexLoadStorePair64(pre bool, load bool, rn, rt, rt2 uint32, imm7 int64) (ret uint32) {
+func encodeLoadOrStorePair64(kind addressModeKind, load bool, rn, rt, rt2 uint32, imm7 int64) (ret uint32) {
 	if imm7%8 != 0 {
 		panic("imm7 for pair load/store must be a multiple of 8")
 	}
@@ -1688,9 +1680,16 @@ func encodePreOrPostIndexLoadStorePair64(pre bool, load bool, rn, rt, rt2 uint32
 	if load {
 		ret |= 0b1 << 22
 	}
-	ret |= 0b101010001 << 23
-	if pre {
-		ret |= 0b1 << 24
+	ret |= 0b10101 << 27
+	switch kind {
+	case addressModeKindPostIndex:
+		ret |= 0b01 << 23
+	case addressModeKindPreIndex:
+		ret |= 0b11 << 23
+	case addressModeKindRegSignedImm9:
+		ret |= 0b10 << 23
+	default:
+		panic("BUG")
 	}
 	return
 }
@@ -1278,6 +1278,15 @@ func TestInstruction_encode(t *testing.T) {
 		{want: "ff7f81a9", setup: func(i *instruction) {
 			i.asStorePair64(xzrVReg, xzrVReg, addressModePreOrPostIndex(spVReg, 16, true))
 		}},
+		{want: "e17b41a9", setup: func(i *instruction) {
+			i.asLoadPair64(x1VReg, x30VReg, addressMode{kind: addressModeKindRegSignedImm9, rn: spVReg, imm: 16})
+		}},
+		{want: "e17b01a9", setup: func(i *instruction) {
+			i.asStorePair64(x1VReg, x30VReg, addressMode{kind: addressModeKindRegSignedImm9, rn: spVReg, imm: 16})
+		}},
+		{want: "e17b3fa9", setup: func(i *instruction) {
+			i.asStorePair64(x1VReg, x30VReg, addressMode{kind: addressModeKindRegSignedImm9, rn: spVReg, imm: -16})
+		}},
 		{want: "20000014", setup: func(i *instruction) {
 			i.asBr(dummyLabel)
 			i.brOffsetResolved(0x80)
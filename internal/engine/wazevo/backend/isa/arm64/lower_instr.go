@@ -85,6 +85,20 @@ func (m *machine) LowerConditionalBranch(b *ssa.Instruction) {
 	cvalDef := m.compiler.ValueDefinition(cval)
 
 	switch {
+	case m.compiler.MatchInstr(cvalDef, ssa.OpcodeIcmp) && m.icmpWithZero(cvalDef.Instr):
+		// This case, we can fuse the comparison into CBZ/CBNZ, e.g. `Brnz (Icmp eq x, 0)` is `cbz x`.
+		x, _, c := cvalDef.Instr.IcmpData()
+		rn := m.getOperand_NR(m.compiler.ValueDefinition(x), extModeNone)
+		var cnd cond
+		if (c == ssa.IntegerCmpCondEqual) == (b.Opcode() == ssa.OpcodeBrnz) {
+			cnd = registerAsRegZeroCond(rn.nr())
+		} else {
+			cnd = registerAsRegNotZeroCond(rn.nr())
+		}
+		cbr := m.allocateInstr()
+		cbr.asCondBr(cnd, target, x.Type().Bits() == 64)
+		m.insert(cbr)
+		cvalDef.Instr.MarkLowered()
 	case m.compiler.MatchInstr(cvalDef, ssa.OpcodeIcmp): // This case, we can use the ALU flag set by SUBS instruction.
 		cvalInstr := cvalDef.Instr
 		x, y, c := cvalInstr.IcmpData()
@@ -124,6 +138,16 @@ func (m *machine) LowerConditionalBranch(b *ssa.Instruction) {
 	}
 }
 
+// icmpWithZero returns true if the Icmp instruction is either `x == 0` or `x != 0`.
+func (m *machine) icmpWithZero(icmp *ssa.Instruction) bool {
+	_, y, c := icmp.IcmpData()
+	if c != ssa.IntegerCmpCondEqual && c != ssa.IntegerCmpCondNotEqual {
+		return false
+	}
+	yDef := m.compiler.ValueDefinition(y)
+	return yDef.IsFromInstr() && yDef.Instr.Constant() && yDef.Instr.ConstantVal() == 0
+}
+
 // LowerInstr implements backend.Machine.
 func (m *machine) LowerInstr(instr *ssa.Instruction) {
 	if l := instr.SourceOffset(); l.Valid() {
@@ -62,13 +62,13 @@ func TestMachine_LowerConditionalBranch(t *testing.T) {
 		}
 	}
 
-	icmpInSameGroupFromParamAndImm12 := func(brz bool, ctx *mockCompiler, builder ssa.Builder, m *machine) (instr *ssa.Instruction, verify func(t *testing.T)) {
+	icmpInSameGroupFromParamAndImm12 := func(brz bool, imm uint32, c ssa.IntegerCmpCond, ctx *mockCompiler, builder ssa.Builder, m *machine) (instr *ssa.Instruction, verify func(t *testing.T)) {
 		m.StartLoweringFunction(10)
 		entry := builder.CurrentBlock()
 		v1 := entry.AddParam(builder, ssa.TypeI32)
 
 		iconst := builder.AllocateInstruction()
-		iconst.AsIconst32(0x4d2)
+		iconst.AsIconst32(imm)
 		builder.InsertInstruction(iconst)
 		v2 := iconst.Return()
 
@@ -76,7 +76,7 @@ func TestMachine_LowerConditionalBranch(t *testing.T) {
 		builder.SetCurrentBlock(builder.AllocateBasicBlock())
 
 		icmp := builder.AllocateInstruction()
-		icmp.AsIcmp(v1, v2, ssa.IntegerCmpCondEqual)
+		icmp.AsIcmp(v1, v2, c)
 		builder.InsertInstruction(icmp)
 		icmpVal := icmp.Return()
 		ctx.definitions[v1] = &backend.SSAValueDefinition{BlkParamVReg: intToVReg(1), BlockParamValue: v1}
@@ -169,7 +169,7 @@ func TestMachine_LowerConditionalBranch(t *testing.T) {
 		{
 			name: "brz / icmp in the same group / params",
 			setup: func(ctx *mockCompiler, builder ssa.Builder, m *machine) (instr *ssa.Instruction, verify func(t *testing.T)) {
-				return icmpInSameGroupFromParamAndImm12(true, ctx, builder, m)
+				return icmpInSameGroupFromParamAndImm12(true, 0x4d2, ssa.IntegerCmpCondEqual, ctx, builder, m)
 			},
 			instructions: []string{
 				"subs wzr, w1?, #0x4d2",
@@ -179,13 +179,34 @@ func TestMachine_LowerConditionalBranch(t *testing.T) {
 		{
 			name: "brz / icmp in the same group / params",
 			setup: func(ctx *mockCompiler, builder ssa.Builder, m *machine) (instr *ssa.Instruction, verify func(t *testing.T)) {
-				return icmpInSameGroupFromParamAndImm12(false, ctx, builder, m)
+				return icmpInSameGroupFromParamAndImm12(false, 0x4d2, ssa.IntegerCmpCondEqual, ctx, builder, m)
 			},
 			instructions: []string{
 				"subs wzr, w1?, #0x4d2",
 				"b.eq L1",
 			},
 		},
+		{
+			name: "brz / icmp eq with zero",
+			setup: func(ctx *mockCompiler, builder ssa.Builder, m *machine) (instr *ssa.Instruction, verify func(t *testing.T)) {
+				return icmpInSameGroupFromParamAndImm12(true, 0, ssa.IntegerCmpCondEqual, ctx, builder, m)
+			},
+			instructions: []string{"cbnz w1?, L1"},
+		},
+		{
+			name: "brnz / icmp eq with zero",
+			setup: func(ctx *mockCompiler, builder ssa.Builder, m *machine) (instr *ssa.Instruction, verify func(t *testing.T)) {
+				return icmpInSameGroupFromParamAndImm12(false, 0, ssa.IntegerCmpCondEqual, ctx, builder, m)
+			},
+			instructions: []string{"cbz w1?, (L1)"},
+		},
+		{
+			name: "brnz / icmp ne with zero",
+			setup: func(ctx *mockCompiler, builder ssa.Builder, m *machine) (instr *ssa.Instruction, verify func(t *testing.T)) {
+				return icmpInSameGroupFromParamAndImm12(false, 0, ssa.IntegerCmpCondNotEqual, ctx, builder, m)
+			},
+			instructions: []string{"cbnz w1?, L1"},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			ctx, b, m := newSetupWithMockContext()
@@ -3,6 +3,7 @@ package arm64
 import (
 	"fmt"
 
+	"github.com/tetratelabs/wazero/internal/engine/wazevo/backend"
 	"github.com/tetratelabs/wazero/internal/engine/wazevo/backend/regalloc"
 	"github.com/tetratelabs/wazero/internal/engine/wazevo/ssa"
 )
@@ -51,6 +52,9 @@ const (
 	// The immediate will be sign-extended, and be added to the base register.
 	// This is a.k.a. "unscaled" since the immediate is not scaled.
 	// https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/LDUR--Load-Register--unscaled--
+	//
+	// Note that when this is used for pair load/store, the offset will be 7-bit "signed" immediate offset scaled by 8.
+	// See "Signed offset" in https://developer.arm.com/documentation/ddi0596/2021-12/Base-Instructions/LDP--Load-Pair-of-Registers-
 	addressModeKindRegSignedImm9
 
 	// addressModeKindRegUnsignedImm12 takes a base register and a 12-bit "unsigned" immediate offset.  scaled by
@@ -243,7 +247,8 @@ func (m *machine) lowerLoadSplat(ptr ssa.Value, offset uint32, lane ssa.VecLane,
 	case ssa.VecLaneI64x2:
 		opSize = 64
 	}
-	amode := m.lowerToAddressMode(ptr, offset, opSize)
+	// vecLoad1R doesn't have the scaled addressing modes.
+	amode := m.lowerToUnscaledAddressMode(ptr, offset, opSize)
 	rd := operandNR(m.compiler.VRegOf(ret))
 	m.lowerLoadSplatFromAddressMode(rd, amode, opSize, lane)
 }
@@ -282,7 +287,6 @@ func (m *machine) lowerLoadSplatFromAddressMode(rd operand, amode addressMode, o
 }
 
 func (m *machine) lowerStore(si *ssa.Instruction) {
-	// TODO: merge consecutive stores into a single pair store instruction.
 	value, ptr, offset, storeSizeInBits := si.StoreData()
 	amode := m.lowerToAddressMode(ptr, offset, storeSizeInBits)
 
@@ -294,15 +298,80 @@ func (m *machine) lowerStore(si *ssa.Instruction) {
 
 // lowerToAddressMode converts a pointer to an addressMode that can be used as an operand for load/store instructions.
 func (m *machine) lowerToAddressMode(ptr ssa.Value, offsetBase uint32, size byte) (amode addressMode) {
-	// TODO: currently the instruction selection logic doesn't support addressModeKindRegScaledExtended and
-	// addressModeKindRegScaled since collectAddends doesn't take ssa.OpcodeIshl into account. This should be fixed
-	// to support more efficient address resolution.
+	if amode, ok := m.lowerToScaledAddressMode(ptr, offsetBase, size); ok {
+		return amode
+	}
+	return m.lowerToUnscaledAddressMode(ptr, offsetBase, size)
+}
 
+// lowerToUnscaledAddressMode is the same as lowerToAddressMode, but never returns the scaled addressing modes.
+func (m *machine) lowerToUnscaledAddressMode(ptr ssa.Value, offsetBase uint32, size byte) (amode addressMode) {
 	a32s, a64s, offset := m.collectAddends(ptr)
 	offset += int64(offsetBase)
 	return m.lowerToAddressModeFromAddends(a32s, a64s, size, offset)
 }
 
+// lowerToScaledAddressMode lowers `ptr` of the form `base + (index << log2(size/8))` into the scaled addressing
+// modes, e.g. `ldr x1, [x2, w3, UXTW #3]`, which is the case of accessing the elements of tables. This returns false
+// if it doesn't match the pattern.
+func (m *machine) lowerToScaledAddressMode(ptr ssa.Value, offsetBase uint32, size byte) (amode addressMode, ok bool) {
+	if offsetBase != 0 || size == 8 || size == 128 {
+		return
+	}
+	ptrDef := m.compiler.ValueDefinition(ptr)
+	if !m.compiler.MatchInstr(ptrDef, ssa.OpcodeIadd) {
+		return
+	}
+	base, shifted := ptrDef.Instr.Arg2()
+	shiftedDef := m.compiler.ValueDefinition(shifted)
+	if !m.isScaledIndex(shiftedDef, size) {
+		if base, shifted = shifted, base; !m.isScaledIndex(m.compiler.ValueDefinition(shifted), size) {
+			return
+		}
+		shiftedDef = m.compiler.ValueDefinition(shifted)
+	}
+	ptrDef.Instr.MarkLowered()
+	shiftedDef.Instr.MarkLowered()
+
+	index, _ := shiftedDef.Instr.Arg2()
+	indexDef := m.compiler.ValueDefinition(index)
+	switch op := m.compiler.MatchInstrOneOf(indexDef, extendMatchOpcodes[:]); {
+	case op != ssa.OpcodeInvalid && indexDef.Instr.Arg().Type().Bits() == 32:
+		// The extension of the 32-bit index can be merged into the addressing mode.
+		ext := extendOpUXTW
+		if op == ssa.OpcodeSExtend {
+			ext = extendOpSXTW
+		}
+		indexDef.Instr.MarkLowered()
+		rm := m.getOperand_NR(m.compiler.ValueDefinition(indexDef.Instr.Arg()), extModeNone).nr()
+		amode = addressMode{kind: addressModeKindRegScaledExtended, rm: rm, extOp: ext}
+	default:
+		rm := m.getOperand_NR(indexDef, extModeNone).nr()
+		amode = addressMode{kind: addressModeKindRegScaled, rm: rm, extOp: extendOpUXTX /* indicates index reg is 64-bit */}
+	}
+	amode.rn = m.getOperand_NR(m.compiler.ValueDefinition(base), extModeNone).nr()
+	return amode, true
+}
+
+var extendMatchOpcodes = [2]ssa.Opcode{ssa.OpcodeUExtend, ssa.OpcodeSExtend}
+
+// isScaledIndex returns true if `def` is a 64-bit Ishl by the constant amount which equals log2(size/8).
+func (m *machine) isScaledIndex(def *backend.SSAValueDefinition, size byte) bool {
+	if !m.compiler.MatchInstr(def, ssa.OpcodeIshl) {
+		return false
+	}
+	x, amount := def.Instr.Arg2()
+	if x.Type() != ssa.TypeI64 {
+		return false
+	}
+	amountDef := m.compiler.ValueDefinition(amount)
+	if !amountDef.IsFromInstr() || !amountDef.Instr.Constant() {
+		return false
+	}
+	var a addressMode
+	return amountDef.Instr.ConstantVal() == uint64(a.sizeInBitsToShiftAmount(size))
+}
+
 // lowerToAddressModeFromAddends creates an addressMode from a list of addends collected by collectAddends.
 // During the construction, this might emit additional instructions.
 //
@@ -481,6 +481,77 @@ func TestMachine_collectAddends(t *testing.T) {
 	}
 }
 
+func TestMachine_lowerToScaledAddressMode(t *testing.T) {
+	v1000, v2000 := regalloc.VReg(1000).SetRegType(regalloc.RegTypeInt), regalloc.VReg(2000).SetRegType(regalloc.RegTypeInt)
+	insert := func(ctx *mockCompiler, b ssa.Builder, inst *ssa.Instruction) *ssa.Instruction {
+		b.InsertInstruction(inst)
+		ctx.definitions[inst.Return()] = &backend.SSAValueDefinition{Instr: inst}
+		return inst
+	}
+
+	for _, tc := range []struct {
+		name  string
+		size  byte
+		setup func(*mockCompiler, ssa.Builder) (ptr ssa.Value)
+		exp   string
+		ok    bool
+	}{
+		{
+			name: "64-bit index",
+			size: 64,
+			setup: func(ctx *mockCompiler, b ssa.Builder) (ptr ssa.Value) {
+				base, index := b.CurrentBlock().AddParam(b, ssa.TypeI64), b.CurrentBlock().AddParam(b, ssa.TypeI64)
+				ctx.definitions[base] = &backend.SSAValueDefinition{BlockParamValue: base, BlkParamVReg: v1000}
+				ctx.definitions[index] = &backend.SSAValueDefinition{BlockParamValue: index, BlkParamVReg: v2000}
+				three := insert(ctx, b, b.AllocateInstruction().AsIconst64(3))
+				shl := insert(ctx, b, b.AllocateInstruction().AsIshl(index, three.Return()))
+				return insert(ctx, b, b.AllocateInstruction().AsIadd(base, shl.Return())).Return()
+			},
+			exp: "[x1000?, x2000?, lsl #0x3]",
+			ok:  true,
+		},
+		{
+			name: "zero-extended 32-bit index",
+			size: 32,
+			setup: func(ctx *mockCompiler, b ssa.Builder) (ptr ssa.Value) {
+				base, index := b.CurrentBlock().AddParam(b, ssa.TypeI64), b.CurrentBlock().AddParam(b, ssa.TypeI32)
+				ctx.definitions[base] = &backend.SSAValueDefinition{BlockParamValue: base, BlkParamVReg: v1000}
+				ctx.definitions[index] = &backend.SSAValueDefinition{BlockParamValue: index, BlkParamVReg: v2000}
+				ext := insert(ctx, b, b.AllocateInstruction().AsUExtend(index, 32, 64))
+				two := insert(ctx, b, b.AllocateInstruction().AsIconst64(2))
+				shl := insert(ctx, b, b.AllocateInstruction().AsIshl(ext.Return(), two.Return()))
+				// Commutative.
+				return insert(ctx, b, b.AllocateInstruction().AsIadd(shl.Return(), base)).Return()
+			},
+			exp: "[x1000?, w2000?, UXTW #0x2]",
+			ok:  true,
+		},
+		{
+			name: "shift amount doesn't match the size",
+			size: 32,
+			setup: func(ctx *mockCompiler, b ssa.Builder) (ptr ssa.Value) {
+				base, index := b.CurrentBlock().AddParam(b, ssa.TypeI64), b.CurrentBlock().AddParam(b, ssa.TypeI64)
+				ctx.definitions[base] = &backend.SSAValueDefinition{BlockParamValue: base, BlkParamVReg: v1000}
+				ctx.definitions[index] = &backend.SSAValueDefinition{BlockParamValue: index, BlkParamVReg: v2000}
+				three := insert(ctx, b, b.AllocateInstruction().AsIconst64(3))
+				shl := insert(ctx, b, b.AllocateInstruction().AsIshl(index, three.Return()))
+				return insert(ctx, b, b.AllocateInstruction().AsIadd(base, shl.Return())).Return()
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, b, m := newSetupWithMockContext()
+			ptr := tc.setup(ctx, b)
+			amode, ok := m.lowerToScaledAddressMode(ptr, 0, tc.size)
+			require.Equal(t, tc.ok, ok)
+			if ok {
+				require.Equal(t, tc.exp, amode.format(tc.size))
+			}
+		})
+	}
+}
+
 func TestMachine_addConstToReg64(t *testing.T) {
 	const nextVRegID = 100
 	t.Run("positive imm12", func(t *testing.T) {
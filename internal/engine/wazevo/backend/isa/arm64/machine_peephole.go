@@ -0,0 +1,84 @@
+package arm64
+
+import "github.com/tetratelabs/wazero/internal/engine/wazevo/backend/regalloc"
+
+// PostRegAlloc implements backend.Machine.
+func (m *machine) PostRegAlloc() {
+	for cur := m.rootInstr; cur != nil; cur = cur.next {
+		switch cur.kind {
+		case mov64, fpuMov64, fpuMov128:
+			// Removes the redundant copy instruction.
+			if cur.rn.realReg() == cur.rd.realReg() {
+				prev, next := cur.prev, cur.next
+				prev.next = next
+				if next != nil {
+					next.prev = prev
+				}
+				cur = prev
+			}
+		case store64, uLoad64:
+			if next := cur.next; next != nil && next.kind == cur.kind {
+				m.mergeLoadStorePair(cur, next)
+			}
+		}
+	}
+}
+
+// mergeLoadStorePair merges `first` and `second`, which are adjacent 64-bit integer loads (or stores), into a
+// single ldp (or stp) if they access the adjacent slots of the same base register, e.g.
+//
+//	str x1, [sp, #0x10]
+//	str x2, [sp, #0x18]
+//
+// becomes `stp x1, x2, [sp, #0x10]`. This is common in the spills and reloads around calls.
+func (m *machine) mergeLoadStorePair(first, second *instruction) {
+	base, offset1, ok := pairableAddressMode(first)
+	if !ok {
+		return
+	}
+	base2, offset2, ok := pairableAddressMode(second)
+	if !ok || base != base2 {
+		return
+	}
+
+	lo, hi := first, second
+	switch offset2 - offset1 {
+	case 8:
+	case -8:
+		lo, hi = second, first
+	default:
+		return
+	}
+	offset := lo.amode.imm
+	if offset%8 != 0 || offset < -512 || offset > 504 {
+		return // Out of the range of 7-bit signed immediate scaled by 8.
+	}
+
+	amode := addressMode{kind: addressModeKindRegSignedImm9, rn: lo.amode.rn, imm: offset}
+	if first.kind == uLoad64 {
+		rd1, rd2 := first.rd.realReg(), second.rd.realReg()
+		// The first load must not overwrite the base of the second one, and the destinations must differ.
+		if rd1 == base || rd1 == rd2 {
+			return
+		}
+		first.asLoadPair64(lo.rd.nr(), hi.rd.nr(), amode)
+	} else {
+		first.asStorePair64(lo.rn.nr(), hi.rn.nr(), amode)
+	}
+
+	// Remove the second instruction.
+	first.next = second.next
+	if next := second.next; next != nil {
+		next.prev = first
+	}
+}
+
+// pairableAddressMode returns the base register and the offset of the load or store `i` if it is of the form
+// [base, #offset], which can be merged into a pair.
+func pairableAddressMode(i *instruction) (base regalloc.RealReg, offset int64, ok bool) {
+	switch i.amode.kind {
+	case addressModeKindRegUnsignedImm12, addressModeKindRegSignedImm9:
+		return i.amode.rn.RealReg(), i.amode.imm, true
+	}
+	return
+}
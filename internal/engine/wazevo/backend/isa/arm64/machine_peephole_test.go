@@ -0,0 +1,92 @@
+package arm64
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestMachine_PostRegAlloc(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		setup func(i1, i2 *instruction)
+		exp   string
+	}{
+		{
+			name: "redundant copy",
+			setup: func(i1, i2 *instruction) {
+				i1.asMove64(x1VReg, x1VReg)
+				i2.asMove64(x1VReg, x2VReg)
+			},
+			exp: `
+	mov x1, x2
+`,
+		},
+		{
+			name: "store pair",
+			setup: func(i1, i2 *instruction) {
+				i1.asStore(operandNR(x1VReg), addressMode{kind: addressModeKindRegUnsignedImm12, rn: spVReg, imm: 0x10}, 64)
+				i2.asStore(operandNR(x2VReg), addressMode{kind: addressModeKindRegUnsignedImm12, rn: spVReg, imm: 0x18}, 64)
+			},
+			exp: `
+	stp x1, x2, [sp, #0x10]
+`,
+		},
+		{
+			name: "load pair in descending order",
+			setup: func(i1, i2 *instruction) {
+				i1.asULoad(operandNR(x1VReg), addressMode{kind: addressModeKindRegSignedImm9, rn: x5VReg, imm: -0x8}, 64)
+				i2.asULoad(operandNR(x2VReg), addressMode{kind: addressModeKindRegSignedImm9, rn: x5VReg, imm: -0x10}, 64)
+			},
+			exp: `
+	ldp x2, x1, [x5, #-0x10]
+`,
+		},
+		{
+			name: "load overwriting the base",
+			setup: func(i1, i2 *instruction) {
+				i1.asULoad(operandNR(x5VReg), addressMode{kind: addressModeKindRegUnsignedImm12, rn: x5VReg, imm: 0x8}, 64)
+				i2.asULoad(operandNR(x2VReg), addressMode{kind: addressModeKindRegUnsignedImm12, rn: x5VReg, imm: 0x10}, 64)
+			},
+			exp: `
+	ldr x5, [x5, #0x8]
+	ldr x2, [x5, #0x10]
+`,
+		},
+		{
+			name: "different bases",
+			setup: func(i1, i2 *instruction) {
+				i1.asStore(operandNR(x1VReg), addressMode{kind: addressModeKindRegUnsignedImm12, rn: x5VReg, imm: 0x10}, 64)
+				i2.asStore(operandNR(x2VReg), addressMode{kind: addressModeKindRegUnsignedImm12, rn: x6VReg, imm: 0x18}, 64)
+			},
+			exp: `
+	str x1, [x5, #0x10]
+	str x2, [x6, #0x18]
+`,
+		},
+		{
+			name: "offset out of range",
+			setup: func(i1, i2 *instruction) {
+				i1.asStore(operandNR(x1VReg), addressMode{kind: addressModeKindRegUnsignedImm12, rn: spVReg, imm: 0x200}, 64)
+				i2.asStore(operandNR(x2VReg), addressMode{kind: addressModeKindRegUnsignedImm12, rn: spVReg, imm: 0x208}, 64)
+			},
+			exp: `
+	str x1, [sp, #0x200]
+	str x2, [sp, #0x208]
+`,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, m := newSetupWithMockContext()
+			root := m.allocateNop()
+			m.rootInstr = root
+			i1, i2 := m.allocateInstr(), m.allocateInstr()
+			tc.setup(i1, i2)
+			linkInstr(linkInstr(root, i1), i2)
+
+			m.PostRegAlloc()
+			require.Equal(t, tc.exp, m.Format())
+		})
+	}
+}
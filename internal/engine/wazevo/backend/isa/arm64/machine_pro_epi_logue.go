@@ -189,18 +189,6 @@ func (m *machine) SetupEpilogue() {
 	for cur := m.rootInstr; cur != nil; cur = cur.next {
 		if cur.kind == ret {
 			m.setupEpilogueAfter(cur.prev)
-			continue
-		}
-
-		// Removes the redundant copy instruction.
-		// TODO: doing this in `SetupEpilogue` seems weird. Find a better home.
-		if cur.IsCopy() && cur.rn.realReg() == cur.rd.realReg() {
-			prev, next := cur.prev, cur.next
-			// Remove the copy instruction.
-			prev.next = next
-			if next != nil {
-				next.prev = prev
-			}
 		}
 	}
 }
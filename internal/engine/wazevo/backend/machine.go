@@ -89,6 +89,10 @@ type (
 		// This sets up the instructions for the inverse of SetupPrologue right before
 		SetupEpilogue()
 
+		// PostRegAlloc does the peephole optimizations on the register-allocated instructions after the prologue
+		// and epilogue are set up, e.g. removing redundant copies and merging adjacent loads and stores.
+		PostRegAlloc()
+
 		// ResolveRelativeAddresses resolves the relative addresses after register allocations and prologue/epilogue setup.
 		// After this, the compiler is finally ready to emit machine code.
 		ResolveRelativeAddresses()
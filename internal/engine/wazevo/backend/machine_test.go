@@ -49,6 +49,9 @@ func (m mockMachine) SetupPrologue() {}
 // SetupEpilogue implements Machine.SetupEpilogue.
 func (m mockMachine) SetupEpilogue() {}
 
+// PostRegAlloc implements Machine.PostRegAlloc.
+func (m mockMachine) PostRegAlloc() {}
+
 // ResolveRelativeAddresses implements Machine.ResolveRelativeAddresses.
 func (m mockMachine) ResolveRelativeAddresses() {}
 
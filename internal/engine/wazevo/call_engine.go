@@ -145,7 +145,7 @@ func (c *callEngine) addFrame(builder wasmdebug.ErrorBuilder, addr uintptr) (def
 			sourceOffset := cm.getSourceOffset(addr)
 			sources = dw.Line(sourceOffset)
 		}
-		builder.AddFrame(def.DebugName(), def.ParamTypes(), def.ResultTypes(), sources)
+		builder.AddFrame(def.DebugName(), def.ParamTypes(), def.ParamNames(), def.ResultTypes(), sources)
 		if len(cm.listeners) > 0 {
 			listener = cm.listeners[index]
 		}
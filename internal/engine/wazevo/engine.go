@@ -179,6 +179,8 @@ func (e *engine) compileModule(ctx context.Context, module *wasm.Module, listene
 
 	if module.IsHostModule {
 		return e.compileHostModule(ctx, module, listeners)
+	} else if module.UsesExceptionHandling {
+		return nil, errors.New("exception handling is only supported by the interpreter")
 	}
 
 	importedFns, localFns := int(module.ImportFunctionCount), len(module.FunctionSection)
@@ -195,7 +197,7 @@ func (e *engine) compileModule(ctx context.Context, module *wasm.Module, listene
 
 	// Creates new compiler instances which are reused for each function.
 	ssaBuilder := ssa.NewBuilder()
-	fe := frontend.NewFrontendCompiler(module, ssaBuilder, &cm.offsets, ensureTermination, withListener, needSourceInfo)
+	fe := frontend.NewFrontendCompiler(module, ssaBuilder, &cm.offsets, ensureTermination, withListener, needSourceInfo, true)
 	machine := newMachine()
 	be := backend.NewCompiler(ctx, machine, ssaBuilder)
 
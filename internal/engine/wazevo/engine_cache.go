@@ -15,6 +15,7 @@ import (
 	"github.com/tetratelabs/wazero/internal/engine/wazevo/ssa"
 	"github.com/tetratelabs/wazero/internal/engine/wazevo/wazevoapi"
 	"github.com/tetratelabs/wazero/internal/filecache"
+	"github.com/tetratelabs/wazero/internal/perfmap"
 	"github.com/tetratelabs/wazero/internal/platform"
 	"github.com/tetratelabs/wazero/internal/u32"
 	"github.com/tetratelabs/wazero/internal/u64"
@@ -81,9 +82,20 @@ func (e *engine) addCompiledModuleToMemory(m *wasm.Module, cm *compiledModule) {
 	e.compiledModules[m.ID] = cm
 	if len(cm.executable) > 0 {
 		e.addCompiledModuleToSortedList(cm)
+		if perfmap.Enabled {
+			addCompiledModuleToPerfMap(m, cm)
+		}
 	}
 }
 
+// addCompiledModuleToPerfMap writes the local functions of cm to the perf map.
+func addCompiledModuleToPerfMap(m *wasm.Module, cm *compiledModule) {
+	addr := uintptr(unsafe.Pointer(&cm.executable[0]))
+	perfmap.AddModule(addr, len(cm.executable), cm.functionOffsets, func(i int) string {
+		return m.FunctionDefinition(m.ImportFunctionCount + wasm.Index(i)).DebugName()
+	})
+}
+
 func (e *engine) getCompiledModuleFromMemory(module *wasm.Module) (cm *compiledModule, ok bool) {
 	e.mux.RLock()
 	defer e.mux.RUnlock()
@@ -0,0 +1,83 @@
+package frontend
+
+import (
+	"github.com/tetratelabs/wazero/internal/engine/wazevo/ssa"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// boundsCheck records that `base + ceil` is in bounds of the memory, which is
+// the case in any block in the linear path of the block where `variable` is
+// defined, as memories never shrink.
+type boundsCheck struct {
+	ceil     uint64
+	variable ssa.Variable
+}
+
+// boundsCheckState tracks the memory accesses whose bounds are known, so that
+// redundant checks are eliminated. This is reset per function.
+//
+// Note: Unlike runtimes which handle faults with signals, checks can't be
+// replaced with guard pages, as Go can't recover from a fault in generated
+// code.
+type boundsCheckState struct {
+	// checked holds the bounds checks per base address.
+	checked map[ssa.Value][]boundsCheck
+	// constants holds the value of i32 constants, so that accesses within
+	// the min size of the memory aren't checked.
+	constants map[ssa.Value]uint32
+}
+
+func (s *boundsCheckState) reset() {
+	for k := range s.checked {
+		delete(s.checked, k)
+	}
+	for k := range s.constants {
+		delete(s.constants, k)
+	}
+}
+
+// memoryMinBytes returns the size in bytes the memory of the module has at
+// least, or zero if it has none.
+func memoryMinBytes(m *wasm.Module) uint64 {
+	if m.MemorySection != nil {
+		return wasm.MemoryPagesToBytesNum(m.MemorySection.Min)
+	}
+	for i := range m.ImportSection {
+		if imp := &m.ImportSection[i]; imp.Type == wasm.ExternTypeMemory {
+			return wasm.MemoryPagesToBytesNum(imp.DescMem.Min)
+		}
+	}
+	return 0
+}
+
+// recordI32Const records the value of an i32.const when the module has
+// memory, so that accesses at constant addresses can skip bounds checks.
+func (c *Compiler) recordI32Const(v ssa.Value, value uint32) {
+	if c.memoryMinBytes > 0 {
+		c.boundsChecks.constants[v] = value
+	}
+}
+
+// boundsCheckRedundant returns true if `baseAddr + ceil` is known to be in
+// bounds of the memory, either because it's a constant within the min size of
+// the memory, or a dominating access checked the bounds of at least `ceil`
+// from the same `baseAddr`.
+func (c *Compiler) boundsCheckRedundant(baseAddr ssa.Value, ceil uint64) bool {
+	if k, ok := c.boundsChecks.constants[baseAddr]; ok && uint64(k)+ceil <= c.memoryMinBytes {
+		return true
+	}
+	for _, check := range c.boundsChecks.checked[baseAddr] {
+		if check.ceil >= ceil && c.ssaBuilder.FindValueInLinearPath(check.variable).Valid() {
+			return true
+		}
+	}
+	return false
+}
+
+// recordBoundsCheck records that `baseAddr + ceil` was checked in the current
+// block.
+func (c *Compiler) recordBoundsCheck(baseAddr ssa.Value, ceil uint64) {
+	variable := c.ssaBuilder.DeclareVariable(ssa.TypeI32)
+	c.ssaBuilder.DefineVariableInCurrentBB(variable, baseAddr)
+	c.boundsChecks.checked[baseAddr] = append(c.boundsChecks.checked[baseAddr], boundsCheck{ceil: ceil, variable: variable})
+}
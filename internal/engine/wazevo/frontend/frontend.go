@@ -27,6 +27,16 @@ type Compiler struct {
 	memmoveSig             ssa.Signature
 	checkModuleExitCodeArg [1]ssa.Value
 	ensureTermination      bool
+	// inlining is true when calls to small functions are inlined.
+	inlining bool
+	// inlinables caches isInlinable per local function.
+	inlinables []inlinable
+	// inlinedLocalToVariable replaces wasmLocalToVariable while lowering an
+	// inlined function.
+	inlinedLocalToVariable map[wasm.Index]ssa.Variable
+	// memoryMinBytes is the size the memory has at least, so accesses below
+	// don't need bounds checks.
+	memoryMinBytes uint64
 
 	// Followings are reset by per function.
 
@@ -46,6 +56,7 @@ type Compiler struct {
 	mutableGlobalVariablesIndexes         []wasm.Index // index to ^.
 	needListener                          bool
 	needSourceOffsetInfo                  bool
+	boundsChecks                          boundsCheckState
 	// br is reused during lowering.
 	br            *bytes.Reader
 	loweringState loweringState
@@ -54,15 +65,25 @@ type Compiler struct {
 }
 
 // NewFrontendCompiler returns a frontend Compiler.
-func NewFrontendCompiler(m *wasm.Module, ssaBuilder ssa.Builder, offset *wazevoapi.ModuleContextOffsetData, ensureTermination bool, listenerOn bool, sourceInfo bool) *Compiler {
+//
+// When `inlining` is true, calls to small functions are inlined, unless
+// `listenerOn` is true, as listeners observe each call.
+func NewFrontendCompiler(m *wasm.Module, ssaBuilder ssa.Builder, offset *wazevoapi.ModuleContextOffsetData, ensureTermination bool, listenerOn bool, sourceInfo bool, inlining bool) *Compiler {
 	c := &Compiler{
-		m:                    m,
-		ssaBuilder:           ssaBuilder,
-		br:                   bytes.NewReader(nil),
-		wasmLocalToVariable:  make(map[wasm.Index]ssa.Variable),
-		offset:               offset,
-		ensureTermination:    ensureTermination,
-		needSourceOffsetInfo: sourceInfo,
+		m:                      m,
+		ssaBuilder:             ssaBuilder,
+		br:                     bytes.NewReader(nil),
+		wasmLocalToVariable:    make(map[wasm.Index]ssa.Variable),
+		inlinedLocalToVariable: make(map[wasm.Index]ssa.Variable),
+		offset:                 offset,
+		ensureTermination:      ensureTermination,
+		needSourceOffsetInfo:   sourceInfo,
+		inlining:               inlining && !listenerOn,
+		memoryMinBytes:         memoryMinBytes(m),
+		boundsChecks: boundsCheckState{
+			checked:   make(map[ssa.Value][]boundsCheck),
+			constants: make(map[ssa.Value]uint32),
+		},
 	}
 	c.declareSignatures(listenerOn)
 	return c
@@ -157,6 +178,7 @@ func SignatureForWasmFunctionType(typ *wasm.FunctionType) ssa.Signature {
 func (c *Compiler) Init(idx, typIndex wasm.Index, typ *wasm.FunctionType, localTypes []wasm.ValueType, body []byte, needListener bool, bodyOffsetInCodeSection uint64) {
 	c.ssaBuilder.Init(c.signatures[typ])
 	c.loweringState.reset()
+	c.boundsChecks.reset()
 
 	c.wasmFunctionTypeIndex = typIndex
 	c.wasmLocalFunctionIndex = idx
@@ -230,24 +252,7 @@ func (c *Compiler) declareWasmLocals(entry ssa.BasicBlock) {
 		variable := c.ssaBuilder.DeclareVariable(st)
 		c.wasmLocalToVariable[wasm.Index(i)+localCount] = variable
 
-		zeroInst := c.ssaBuilder.AllocateInstruction()
-		switch st {
-		case ssa.TypeI32:
-			zeroInst.AsIconst32(0)
-		case ssa.TypeI64:
-			zeroInst.AsIconst64(0)
-		case ssa.TypeF32:
-			zeroInst.AsF32const(0)
-		case ssa.TypeF64:
-			zeroInst.AsF64const(0)
-		case ssa.TypeV128:
-			zeroInst.AsVconst(0, 0)
-		default:
-			panic("TODO: " + wasm.ValueTypeName(typ))
-		}
-
-		c.ssaBuilder.InsertInstruction(zeroInst)
-		value := zeroInst.Return()
+		value := c.zeroValue(st)
 		c.ssaBuilder.DefineVariable(variable, value, entry)
 	}
 }
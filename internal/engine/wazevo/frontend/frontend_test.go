@@ -19,6 +19,7 @@ func TestCompiler_LowerToSSA(t *testing.T) {
 		name              string
 		ensureTermination bool
 		needListener      bool
+		inlining          bool
 		// m is the *wasm.Module to be compiled in this test.
 		m *wasm.Module
 		// targetIndex is the index of a local function to be compiled in this test.
@@ -297,12 +298,7 @@ blk0: (exec_ctx:i64, module_ctx:i64)
 	Jump blk1
 
 blk1: () <-- (blk0,blk1)
-	v2:i32 = Iconst_32 0x1
-	Brnz v2, blk1
-	Jump blk3
-
-blk3: () <-- (blk1)
-	Return
+	Jump blk1
 `,
 		},
 		{
@@ -386,12 +382,7 @@ blk3: () <-- (blk2)
 			expAfterOpt: `
 blk0: (exec_ctx:i64, module_ctx:i64)
 	v2:i32 = Iconst_32 0x0
-	v4:i32 = Iconst_32 0x0
-	Brz v2, blk2
-	Jump blk1
-
-blk1: () <-- (blk0)
-	Return v4
+	Jump blk2
 
 blk2: () <-- (blk0)
 	Jump blk3
@@ -548,6 +539,18 @@ blk0: (exec_ctx:i64, module_ctx:i64)
 	Store module_ctx, exec_ctx, 0x8
 	v5:i32, v6:i32 = Call f3:sig3, exec_ctx, module_ctx, v4
 	Jump blk_ret, v5, v6
+`,
+		},
+		{
+			name:     "call inlined",
+			m:        testcases.Call.Module,
+			inlining: true,
+			exp: `
+blk0: (exec_ctx:i64, module_ctx:i64)
+	v2:i32 = Iconst_32 0x28
+	v3:i32 = Iconst_32 0x5
+	v4:i32 = Iadd v2, v3
+	Jump blk_ret, v4, v4
 `,
 		},
 		{
@@ -771,15 +774,7 @@ blk0: (exec_ctx:i64, module_ctx:i64, v2:i32, v3:i32)
 	Jump blk1
 
 blk1: () <-- (blk0,blk1)
-	v7:i32 = Iconst_32 0x1
-	Brnz v7, blk1
-	Jump blk3
-
-blk2: () <-- (blk3)
-	Jump blk_ret, v2
-
-blk3: () <-- (blk1)
-	Jump blk2
+	Jump blk1
 `,
 		},
 		{
@@ -1019,14 +1014,10 @@ blk0: (exec_ctx:i64, module_ctx:i64, v2:i32, v3:i32)
 	v9:i64 = Load module_ctx, 0x8
 	v10:i64 = Iadd v9, v5
 	Store v3, v10, 0x0
-	v11:i64 = Iconst_64 0x4
-	v12:i64 = UExtend v2, 32->64
-	v13:i64 = Iadd v12, v11
-	v14:i32 = Icmp lt_u, v6, v13
-	ExitIfTrue v14, exec_ctx, memory_out_of_bounds
-	v15:i64 = Iadd v9, v12
-	v16:i32 = Load v15, 0x0
-	Jump blk_ret, v16
+	v11:i64 = UExtend v2, 32->64
+	v12:i64 = Iadd v9, v11
+	v13:i32 = Load v12, 0x0
+	Jump blk_ret, v13
 `,
 		},
 		{
@@ -1122,6 +1113,74 @@ blk0: (exec_ctx:i64, module_ctx:i64, v2:i32)
 	v4:i64 = Load module_ctx, 0x10
 	v5:i32 = CallIndirect v3:sig1, exec_ctx, v4, v2, v2
 	Jump blk_ret, v5
+`,
+		},
+		{
+			name: "memory_bounds_check_elimination",
+			m: &wasm.Module{
+				TypeSection:     []wasm.FunctionType{{Params: []wasm.ValueType{wasm.ValueTypeI32}, Results: []wasm.ValueType{wasm.ValueTypeI32}}},
+				FunctionSection: []wasm.Index{0},
+				MemorySection:   &wasm.Memory{Min: 1, Max: 1},
+				CodeSection: []wasm.Code{{Body: []byte{
+					// Constant address within the min size of the memory.
+					wasm.OpcodeI32Const, 8,
+					wasm.OpcodeI32Load, 0x2, 0x0,
+					wasm.OpcodeDrop,
+					// Checked once with the largest offset...
+					wasm.OpcodeLocalGet, 0,
+					wasm.OpcodeI32Load, 0x2, 0x8,
+					wasm.OpcodeDrop,
+					wasm.OpcodeLocalGet, 0,
+					wasm.OpcodeIf, 0x40,
+					// ...so that accesses in dominated blocks aren't.
+					wasm.OpcodeLocalGet, 0,
+					wasm.OpcodeI32Load, 0x2, 0x4,
+					wasm.OpcodeDrop,
+					wasm.OpcodeEnd,
+					// Not dominated by the block above.
+					wasm.OpcodeLocalGet, 0,
+					wasm.OpcodeI32Load, 0x2, 0x0,
+					wasm.OpcodeEnd,
+				}}},
+			},
+			exp: `
+blk0: (exec_ctx:i64, module_ctx:i64, v2:i32)
+	v3:i32 = Iconst_32 0x8
+	v4:i64 = UExtend v3, 32->64
+	v5:i64 = Load module_ctx, 0x8
+	v6:i64 = Iadd v5, v4
+	v7:i32 = Load v6, 0x0
+	v8:i64 = Iconst_64 0xc
+	v9:i64 = UExtend v2, 32->64
+	v10:i64 = Uload32 module_ctx, 0x10
+	v11:i64 = Iadd v9, v8
+	v12:i32 = Icmp lt_u, v10, v11
+	ExitIfTrue v12, exec_ctx, memory_out_of_bounds
+	v13:i64 = Iadd v5, v9
+	v14:i32 = Load v13, 0x8
+	Brz v2, blk2
+	Jump blk1
+
+blk1: () <-- (blk0)
+	v15:i64 = UExtend v2, 32->64
+	v16:i64 = Iadd v5, v15
+	v17:i32 = Load v16, 0x4
+	Jump blk3, v2
+
+blk2: () <-- (blk0)
+	Jump blk3, v2
+
+blk3: (v18:i32) <-- (blk1,blk2)
+	v19:i64 = Iconst_64 0x4
+	v20:i64 = UExtend v18, 32->64
+	v21:i64 = Uload32 module_ctx, 0x10
+	v22:i64 = Iadd v20, v19
+	v23:i32 = Icmp lt_u, v21, v22
+	ExitIfTrue v23, exec_ctx, memory_out_of_bounds
+	v24:i64 = Load module_ctx, 0x8
+	v25:i64 = Iadd v24, v20
+	v26:i32 = Load v25, 0x0
+	Jump blk_ret, v26
 `,
 		},
 		{
@@ -1144,189 +1203,93 @@ blk0: (exec_ctx:i64, module_ctx:i64, v2:i32)
 	ExitIfTrue v14, exec_ctx, memory_out_of_bounds
 	v15:i64 = Iadd v8, v12
 	v16:i64 = Load v15, 0x0
-	v17:i64 = Iconst_64 0x4
-	v18:i64 = UExtend v2, 32->64
-	v19:i64 = Iadd v18, v17
-	v20:i32 = Icmp lt_u, v5, v19
-	ExitIfTrue v20, exec_ctx, memory_out_of_bounds
-	v21:i64 = Iadd v8, v18
-	v22:f32 = Load v21, 0x0
-	v23:i64 = Iconst_64 0x8
+	v17:i64 = UExtend v2, 32->64
+	v18:i64 = Iadd v8, v17
+	v19:f32 = Load v18, 0x0
+	v20:i64 = UExtend v2, 32->64
+	v21:i64 = Iadd v8, v20
+	v22:f64 = Load v21, 0x0
+	v23:i64 = Iconst_64 0x13
 	v24:i64 = UExtend v2, 32->64
 	v25:i64 = Iadd v24, v23
 	v26:i32 = Icmp lt_u, v5, v25
 	ExitIfTrue v26, exec_ctx, memory_out_of_bounds
 	v27:i64 = Iadd v8, v24
-	v28:f64 = Load v27, 0x0
-	v29:i64 = Iconst_64 0x13
+	v28:i32 = Load v27, 0xf
+	v29:i64 = Iconst_64 0x17
 	v30:i64 = UExtend v2, 32->64
 	v31:i64 = Iadd v30, v29
 	v32:i32 = Icmp lt_u, v5, v31
 	ExitIfTrue v32, exec_ctx, memory_out_of_bounds
 	v33:i64 = Iadd v8, v30
-	v34:i32 = Load v33, 0xf
-	v35:i64 = Iconst_64 0x17
-	v36:i64 = UExtend v2, 32->64
-	v37:i64 = Iadd v36, v35
-	v38:i32 = Icmp lt_u, v5, v37
-	ExitIfTrue v38, exec_ctx, memory_out_of_bounds
-	v39:i64 = Iadd v8, v36
-	v40:i64 = Load v39, 0xf
-	v41:i64 = Iconst_64 0x13
-	v42:i64 = UExtend v2, 32->64
-	v43:i64 = Iadd v42, v41
-	v44:i32 = Icmp lt_u, v5, v43
-	ExitIfTrue v44, exec_ctx, memory_out_of_bounds
-	v45:i64 = Iadd v8, v42
-	v46:f32 = Load v45, 0xf
-	v47:i64 = Iconst_64 0x17
-	v48:i64 = UExtend v2, 32->64
-	v49:i64 = Iadd v48, v47
-	v50:i32 = Icmp lt_u, v5, v49
-	ExitIfTrue v50, exec_ctx, memory_out_of_bounds
-	v51:i64 = Iadd v8, v48
-	v52:f64 = Load v51, 0xf
-	v53:i64 = Iconst_64 0x1
-	v54:i64 = UExtend v2, 32->64
-	v55:i64 = Iadd v54, v53
-	v56:i32 = Icmp lt_u, v5, v55
-	ExitIfTrue v56, exec_ctx, memory_out_of_bounds
-	v57:i64 = Iadd v8, v54
-	v58:i32 = Sload8 v57, 0x0
-	v59:i64 = Iconst_64 0x10
-	v60:i64 = UExtend v2, 32->64
-	v61:i64 = Iadd v60, v59
-	v62:i32 = Icmp lt_u, v5, v61
-	ExitIfTrue v62, exec_ctx, memory_out_of_bounds
-	v63:i64 = Iadd v8, v60
-	v64:i32 = Sload8 v63, 0xf
-	v65:i64 = Iconst_64 0x1
-	v66:i64 = UExtend v2, 32->64
-	v67:i64 = Iadd v66, v65
-	v68:i32 = Icmp lt_u, v5, v67
-	ExitIfTrue v68, exec_ctx, memory_out_of_bounds
-	v69:i64 = Iadd v8, v66
-	v70:i32 = Uload8 v69, 0x0
-	v71:i64 = Iconst_64 0x10
-	v72:i64 = UExtend v2, 32->64
-	v73:i64 = Iadd v72, v71
-	v74:i32 = Icmp lt_u, v5, v73
-	ExitIfTrue v74, exec_ctx, memory_out_of_bounds
-	v75:i64 = Iadd v8, v72
-	v76:i32 = Uload8 v75, 0xf
-	v77:i64 = Iconst_64 0x2
-	v78:i64 = UExtend v2, 32->64
-	v79:i64 = Iadd v78, v77
-	v80:i32 = Icmp lt_u, v5, v79
-	ExitIfTrue v80, exec_ctx, memory_out_of_bounds
-	v81:i64 = Iadd v8, v78
-	v82:i32 = Sload16 v81, 0x0
-	v83:i64 = Iconst_64 0x11
-	v84:i64 = UExtend v2, 32->64
-	v85:i64 = Iadd v84, v83
-	v86:i32 = Icmp lt_u, v5, v85
-	ExitIfTrue v86, exec_ctx, memory_out_of_bounds
-	v87:i64 = Iadd v8, v84
-	v88:i32 = Sload16 v87, 0xf
-	v89:i64 = Iconst_64 0x2
-	v90:i64 = UExtend v2, 32->64
-	v91:i64 = Iadd v90, v89
-	v92:i32 = Icmp lt_u, v5, v91
-	ExitIfTrue v92, exec_ctx, memory_out_of_bounds
-	v93:i64 = Iadd v8, v90
-	v94:i32 = Uload16 v93, 0x0
-	v95:i64 = Iconst_64 0x11
-	v96:i64 = UExtend v2, 32->64
-	v97:i64 = Iadd v96, v95
-	v98:i32 = Icmp lt_u, v5, v97
-	ExitIfTrue v98, exec_ctx, memory_out_of_bounds
-	v99:i64 = Iadd v8, v96
-	v100:i32 = Uload16 v99, 0xf
-	v101:i64 = Iconst_64 0x1
-	v102:i64 = UExtend v2, 32->64
-	v103:i64 = Iadd v102, v101
-	v104:i32 = Icmp lt_u, v5, v103
-	ExitIfTrue v104, exec_ctx, memory_out_of_bounds
-	v105:i64 = Iadd v8, v102
-	v106:i64 = Sload8 v105, 0x0
-	v107:i64 = Iconst_64 0x10
-	v108:i64 = UExtend v2, 32->64
-	v109:i64 = Iadd v108, v107
-	v110:i32 = Icmp lt_u, v5, v109
-	ExitIfTrue v110, exec_ctx, memory_out_of_bounds
-	v111:i64 = Iadd v8, v108
-	v112:i64 = Sload8 v111, 0xf
-	v113:i64 = Iconst_64 0x1
-	v114:i64 = UExtend v2, 32->64
-	v115:i64 = Iadd v114, v113
-	v116:i32 = Icmp lt_u, v5, v115
-	ExitIfTrue v116, exec_ctx, memory_out_of_bounds
-	v117:i64 = Iadd v8, v114
-	v118:i64 = Uload8 v117, 0x0
-	v119:i64 = Iconst_64 0x10
-	v120:i64 = UExtend v2, 32->64
-	v121:i64 = Iadd v120, v119
-	v122:i32 = Icmp lt_u, v5, v121
-	ExitIfTrue v122, exec_ctx, memory_out_of_bounds
-	v123:i64 = Iadd v8, v120
-	v124:i64 = Uload8 v123, 0xf
-	v125:i64 = Iconst_64 0x2
-	v126:i64 = UExtend v2, 32->64
-	v127:i64 = Iadd v126, v125
-	v128:i32 = Icmp lt_u, v5, v127
-	ExitIfTrue v128, exec_ctx, memory_out_of_bounds
-	v129:i64 = Iadd v8, v126
-	v130:i64 = Sload16 v129, 0x0
-	v131:i64 = Iconst_64 0x11
-	v132:i64 = UExtend v2, 32->64
-	v133:i64 = Iadd v132, v131
-	v134:i32 = Icmp lt_u, v5, v133
-	ExitIfTrue v134, exec_ctx, memory_out_of_bounds
-	v135:i64 = Iadd v8, v132
-	v136:i64 = Sload16 v135, 0xf
-	v137:i64 = Iconst_64 0x2
-	v138:i64 = UExtend v2, 32->64
-	v139:i64 = Iadd v138, v137
-	v140:i32 = Icmp lt_u, v5, v139
-	ExitIfTrue v140, exec_ctx, memory_out_of_bounds
-	v141:i64 = Iadd v8, v138
-	v142:i64 = Uload16 v141, 0x0
-	v143:i64 = Iconst_64 0x11
-	v144:i64 = UExtend v2, 32->64
-	v145:i64 = Iadd v144, v143
-	v146:i32 = Icmp lt_u, v5, v145
-	ExitIfTrue v146, exec_ctx, memory_out_of_bounds
-	v147:i64 = Iadd v8, v144
-	v148:i64 = Uload16 v147, 0xf
-	v149:i64 = Iconst_64 0x4
-	v150:i64 = UExtend v2, 32->64
-	v151:i64 = Iadd v150, v149
-	v152:i32 = Icmp lt_u, v5, v151
-	ExitIfTrue v152, exec_ctx, memory_out_of_bounds
-	v153:i64 = Iadd v8, v150
-	v154:i64 = Sload32 v153, 0x0
-	v155:i64 = Iconst_64 0x13
-	v156:i64 = UExtend v2, 32->64
-	v157:i64 = Iadd v156, v155
-	v158:i32 = Icmp lt_u, v5, v157
-	ExitIfTrue v158, exec_ctx, memory_out_of_bounds
-	v159:i64 = Iadd v8, v156
-	v160:i64 = Sload32 v159, 0xf
-	v161:i64 = Iconst_64 0x4
-	v162:i64 = UExtend v2, 32->64
-	v163:i64 = Iadd v162, v161
-	v164:i32 = Icmp lt_u, v5, v163
-	ExitIfTrue v164, exec_ctx, memory_out_of_bounds
-	v165:i64 = Iadd v8, v162
-	v166:i64 = Uload32 v165, 0x0
-	v167:i64 = Iconst_64 0x13
-	v168:i64 = UExtend v2, 32->64
-	v169:i64 = Iadd v168, v167
-	v170:i32 = Icmp lt_u, v5, v169
-	ExitIfTrue v170, exec_ctx, memory_out_of_bounds
-	v171:i64 = Iadd v8, v168
-	v172:i64 = Uload32 v171, 0xf
-	Jump blk_ret, v10, v16, v22, v28, v34, v40, v46, v52, v58, v64, v70, v76, v82, v88, v94, v100, v106, v112, v118, v124, v130, v136, v142, v148, v154, v160, v166, v172
+	v34:i64 = Load v33, 0xf
+	v35:i64 = UExtend v2, 32->64
+	v36:i64 = Iadd v8, v35
+	v37:f32 = Load v36, 0xf
+	v38:i64 = UExtend v2, 32->64
+	v39:i64 = Iadd v8, v38
+	v40:f64 = Load v39, 0xf
+	v41:i64 = UExtend v2, 32->64
+	v42:i64 = Iadd v8, v41
+	v43:i32 = Sload8 v42, 0x0
+	v44:i64 = UExtend v2, 32->64
+	v45:i64 = Iadd v8, v44
+	v46:i32 = Sload8 v45, 0xf
+	v47:i64 = UExtend v2, 32->64
+	v48:i64 = Iadd v8, v47
+	v49:i32 = Uload8 v48, 0x0
+	v50:i64 = UExtend v2, 32->64
+	v51:i64 = Iadd v8, v50
+	v52:i32 = Uload8 v51, 0xf
+	v53:i64 = UExtend v2, 32->64
+	v54:i64 = Iadd v8, v53
+	v55:i32 = Sload16 v54, 0x0
+	v56:i64 = UExtend v2, 32->64
+	v57:i64 = Iadd v8, v56
+	v58:i32 = Sload16 v57, 0xf
+	v59:i64 = UExtend v2, 32->64
+	v60:i64 = Iadd v8, v59
+	v61:i32 = Uload16 v60, 0x0
+	v62:i64 = UExtend v2, 32->64
+	v63:i64 = Iadd v8, v62
+	v64:i32 = Uload16 v63, 0xf
+	v65:i64 = UExtend v2, 32->64
+	v66:i64 = Iadd v8, v65
+	v67:i64 = Sload8 v66, 0x0
+	v68:i64 = UExtend v2, 32->64
+	v69:i64 = Iadd v8, v68
+	v70:i64 = Sload8 v69, 0xf
+	v71:i64 = UExtend v2, 32->64
+	v72:i64 = Iadd v8, v71
+	v73:i64 = Uload8 v72, 0x0
+	v74:i64 = UExtend v2, 32->64
+	v75:i64 = Iadd v8, v74
+	v76:i64 = Uload8 v75, 0xf
+	v77:i64 = UExtend v2, 32->64
+	v78:i64 = Iadd v8, v77
+	v79:i64 = Sload16 v78, 0x0
+	v80:i64 = UExtend v2, 32->64
+	v81:i64 = Iadd v8, v80
+	v82:i64 = Sload16 v81, 0xf
+	v83:i64 = UExtend v2, 32->64
+	v84:i64 = Iadd v8, v83
+	v85:i64 = Uload16 v84, 0x0
+	v86:i64 = UExtend v2, 32->64
+	v87:i64 = Iadd v8, v86
+	v88:i64 = Uload16 v87, 0xf
+	v89:i64 = UExtend v2, 32->64
+	v90:i64 = Iadd v8, v89
+	v91:i64 = Sload32 v90, 0x0
+	v92:i64 = UExtend v2, 32->64
+	v93:i64 = Iadd v8, v92
+	v94:i64 = Sload32 v93, 0xf
+	v95:i64 = UExtend v2, 32->64
+	v96:i64 = Iadd v8, v95
+	v97:i64 = Uload32 v96, 0x0
+	v98:i64 = UExtend v2, 32->64
+	v99:i64 = Iadd v8, v98
+	v100:i64 = Uload32 v99, 0xf
+	Jump blk_ret, v10, v16, v19, v22, v28, v34, v37, v40, v43, v46, v49, v52, v55, v58, v61, v64, v67, v70, v73, v76, v79, v82, v85, v88, v91, v94, v97, v100
 `,
 		},
 		{
@@ -1547,23 +1510,24 @@ blk0: (exec_ctx:i64, module_ctx:i64, v2:i32)
 	v5:i32 = Icmp ge_u, v2, v4
 	ExitIfTrue v5, exec_ctx, table_out_of_bounds
 	v6:i64 = Load v3, 0x0
-	v7:i64 = Iconst_64 0x3
-	v8:i32 = Ishl v2, v7
-	v9:i64 = Iadd v6, v8
-	v10:i64 = Load v9, 0x0
-	v11:i64 = Iconst_64 0x0
-	v12:i32 = Icmp eq, v10, v11
-	ExitIfTrue v12, exec_ctx, indirect_call_null_pointer
-	v13:i32 = Load v10, 0x10
-	v14:i64 = Load module_ctx, 0x8
-	v15:i32 = Load v14, 0x8
-	v16:i32 = Icmp neq, v13, v15
-	ExitIfTrue v16, exec_ctx, indirect_call_type_mismatch
-	v17:i64 = Load v10, 0x0
-	v18:i64 = Load v10, 0x8
+	v7:i64 = UExtend v2, 32->64
+	v8:i64 = Iconst_64 0x3
+	v9:i64 = Ishl v7, v8
+	v10:i64 = Iadd v6, v9
+	v11:i64 = Load v10, 0x0
+	v12:i64 = Iconst_64 0x0
+	v13:i32 = Icmp eq, v11, v12
+	ExitIfTrue v13, exec_ctx, indirect_call_null_pointer
+	v14:i32 = Load v11, 0x10
+	v15:i64 = Load module_ctx, 0x8
+	v16:i32 = Load v15, 0x8
+	v17:i32 = Icmp neq, v14, v16
+	ExitIfTrue v17, exec_ctx, indirect_call_type_mismatch
+	v18:i64 = Load v11, 0x0
+	v19:i64 = Load v11, 0x8
 	Store module_ctx, exec_ctx, 0x8
-	v19:i32 = CallIndirect v17:sig2, exec_ctx, v18
-	Jump blk_ret, v19
+	v20:i32 = CallIndirect v18:sig2, exec_ctx, v19
+	Jump blk_ret, v20
 `,
 		},
 		{
@@ -1800,7 +1764,7 @@ blk0: (exec_ctx:i64, module_ctx:i64, v2:v128, v3:v128)
 			b := ssa.NewBuilder()
 
 			offset := wazevoapi.NewModuleContextOffsetData(tc.m, tc.needListener)
-			fc := NewFrontendCompiler(tc.m, b, &offset, tc.ensureTermination, tc.needListener, false)
+			fc := NewFrontendCompiler(tc.m, b, &offset, tc.ensureTermination, tc.needListener, false, tc.inlining)
 			typeIndex := tc.m.FunctionSection[tc.targetIndex]
 			code := &tc.m.CodeSection[tc.targetIndex]
 			fc.Init(tc.targetIndex, typeIndex, &tc.m.TypeSection[typeIndex], code.LocalTypes, code.Body, tc.needListener, 0)
@@ -0,0 +1,184 @@
+package frontend
+
+import (
+	"github.com/tetratelabs/wazero/internal/engine/wazevo/ssa"
+	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// inliningBudget is the maximum number of instructions, excluding the final
+// end, of a function inlined into its callers.
+const inliningBudget = 16
+
+// inlinable is the result of the analysis of a function for inlining, cached
+// per function of the module.
+type inlinable byte
+
+const (
+	inlinableUnknown inlinable = iota
+	inlinableNo
+	inlinableYes
+)
+
+// isInlinable returns true if the local function `fnIndex` can be inlined
+// into its callers.
+//
+// Only small functions with straight-line code, which neither call nor trap,
+// are inlined: they can't recurse, and stack traces are unaffected. That
+// still covers accessors and shims like wasi-libc's, whose call overhead
+// dominates.
+func (c *Compiler) isInlinable(fnIndex wasm.Index) bool {
+	if !c.inlining || fnIndex < c.m.ImportFunctionCount {
+		return false
+	}
+	localIndex := fnIndex - c.m.ImportFunctionCount
+	if localIndex == c.wasmLocalFunctionIndex {
+		return false // Recursion guard, though recursive functions call so aren't inlinable anyway.
+	}
+	if c.inlinables == nil {
+		c.inlinables = make([]inlinable, len(c.m.CodeSection))
+	}
+	if c.inlinables[localIndex] == inlinableUnknown {
+		c.inlinables[localIndex] = inlinableNo
+		code := &c.m.CodeSection[localIndex]
+		if isInlinableBody(code.Body) && hasZeroValues(code.LocalTypes) {
+			c.inlinables[localIndex] = inlinableYes
+		}
+	}
+	return c.inlinables[localIndex] == inlinableYes
+}
+
+// isInlinableBody returns true if `body` is at most inliningBudget
+// instructions, which don't branch, call or trap.
+func isInlinableBody(body []byte) bool {
+	instructions := 0
+	for pc := 0; pc < len(body); pc++ {
+		op := body[pc]
+		switch {
+		case op == wasm.OpcodeEnd:
+			return pc == len(body)-1 // The only block is the function itself.
+		case op == wasm.OpcodeLocalGet, op == wasm.OpcodeLocalSet, op == wasm.OpcodeLocalTee,
+			op == wasm.OpcodeGlobalGet, op == wasm.OpcodeGlobalSet:
+			_, n, err := leb128.LoadUint32(body[pc+1:])
+			if err != nil {
+				return false
+			}
+			pc += int(n)
+		case op == wasm.OpcodeI32Const:
+			_, n, err := leb128.LoadInt32(body[pc+1:])
+			if err != nil {
+				return false
+			}
+			pc += int(n)
+		case op == wasm.OpcodeI64Const:
+			_, n, err := leb128.LoadInt64(body[pc+1:])
+			if err != nil {
+				return false
+			}
+			pc += int(n)
+		case op == wasm.OpcodeF32Const:
+			pc += 4
+		case op == wasm.OpcodeF64Const:
+			pc += 8
+		case op == wasm.OpcodeDrop, op == wasm.OpcodeSelect:
+		case op >= wasm.OpcodeI32Eqz && op <= wasm.OpcodeI64Extend32S:
+			if isTrappingNumericOpcode(op) {
+				return false
+			}
+		default:
+			return false
+		}
+		if instructions++; instructions > inliningBudget {
+			return false
+		}
+	}
+	return false // Unterminated, which validation rejects.
+}
+
+// hasZeroValues returns true if zeroValue supports all the `localTypes`, which
+// are initialized to zero when inlined.
+func hasZeroValues(localTypes []wasm.ValueType) bool {
+	for _, lt := range localTypes {
+		switch lt {
+		case wasm.ValueTypeI32, wasm.ValueTypeI64, wasm.ValueTypeF32, wasm.ValueTypeF64, wasm.ValueTypeV128,
+			wasm.ValueTypeExternref, wasm.ValueTypeFuncref:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isTrappingNumericOpcode returns true if the numeric instruction `op` traps
+// on some operands, such as integer division by zero.
+func isTrappingNumericOpcode(op wasm.Opcode) bool {
+	switch op {
+	case wasm.OpcodeI32DivS, wasm.OpcodeI32DivU, wasm.OpcodeI32RemS, wasm.OpcodeI32RemU,
+		wasm.OpcodeI64DivS, wasm.OpcodeI64DivU, wasm.OpcodeI64RemS, wasm.OpcodeI64RemU,
+		wasm.OpcodeI32TruncF32S, wasm.OpcodeI32TruncF32U, wasm.OpcodeI32TruncF64S, wasm.OpcodeI32TruncF64U,
+		wasm.OpcodeI64TruncF32S, wasm.OpcodeI64TruncF32U, wasm.OpcodeI64TruncF64S, wasm.OpcodeI64TruncF64U:
+		return true
+	}
+	return false
+}
+
+// lowerInlinedCall lowers the body of the local function `fnIndex`, which
+// isInlinable, in place of a call to it: the arguments on the stack are
+// replaced with its results.
+func (c *Compiler) lowerInlinedCall(fnIndex wasm.Index) {
+	builder, state := c.ssaBuilder, c.state()
+	localIndex := fnIndex - c.m.ImportFunctionCount
+	typ := &c.m.TypeSection[c.m.FunctionSection[localIndex]]
+	code := &c.m.CodeSection[localIndex]
+
+	// The callee has its own locals, which start with the arguments.
+	callerLocals := c.wasmLocalToVariable
+	c.wasmLocalToVariable = c.inlinedLocalToVariable
+	for k := range c.wasmLocalToVariable {
+		delete(c.wasmLocalToVariable, k)
+	}
+	args := state.values[len(state.values)-len(typ.Params):]
+	for i, arg := range args {
+		variable := builder.DeclareVariable(WasmTypeToSSAType(typ.Params[i]))
+		builder.DefineVariableInCurrentBB(variable, arg)
+		c.wasmLocalToVariable[wasm.Index(i)] = variable
+	}
+	state.values = state.values[:len(state.values)-len(args)]
+	for i, lt := range code.LocalTypes {
+		st := WasmTypeToSSAType(lt)
+		variable := builder.DeclareVariable(st)
+		builder.DefineVariableInCurrentBB(variable, c.zeroValue(st))
+		c.wasmLocalToVariable[wasm.Index(len(typ.Params)+i)] = variable
+	}
+
+	callerBody, callerPC, callerBodyOffset := c.wasmFunctionBody, state.pc, c.wasmFunctionBodyOffsetInCodeSection
+	c.wasmFunctionBody, c.wasmFunctionBodyOffsetInCodeSection = code.Body, code.BodyOffsetInCodeSection
+	// Lowers all but the final end, leaving the results on the stack.
+	for state.pc = 0; state.pc < len(code.Body)-1; {
+		c.lowerCurrentOpcode()
+	}
+	c.wasmFunctionBody, state.pc, c.wasmFunctionBodyOffsetInCodeSection = callerBody, callerPC, callerBodyOffset
+	c.wasmLocalToVariable = callerLocals
+}
+
+// zeroValue inserts the zero constant of the type `st`. Callees with locals of
+// other types aren't inlined, see hasZeroValues.
+func (c *Compiler) zeroValue(st ssa.Type) ssa.Value {
+	zeroInst := c.ssaBuilder.AllocateInstruction()
+	switch st {
+	case ssa.TypeI32:
+		zeroInst.AsIconst32(0)
+	case ssa.TypeI64:
+		zeroInst.AsIconst64(0)
+	case ssa.TypeF32:
+		zeroInst.AsF32const(0)
+	case ssa.TypeF64:
+		zeroInst.AsF64const(0)
+	case ssa.TypeV128:
+		zeroInst.AsVconst(0, 0)
+	default:
+		panic("BUG: zero value of type " + st.String() + " is not supported, see hasZeroValues")
+	}
+	c.ssaBuilder.InsertInstruction(zeroInst)
+	return zeroInst.Return()
+}
@@ -0,0 +1,56 @@
+package frontend
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+func Test_isInlinableBody(t *testing.T) {
+	tooLong := make([]byte, 0, 2*inliningBudget+2)
+	for i := 0; i <= inliningBudget/2; i++ {
+		tooLong = append(tooLong, wasm.OpcodeLocalGet, 0, wasm.OpcodeDrop)
+	}
+	tooLong = append(tooLong, wasm.OpcodeEnd)
+
+	for _, tc := range []struct {
+		name string
+		body []byte
+		exp  bool
+	}{
+		{name: "empty", body: []byte{wasm.OpcodeEnd}, exp: true},
+		{name: "accessor", body: []byte{wasm.OpcodeGlobalGet, 0, wasm.OpcodeEnd}, exp: true},
+		{
+			name: "arithmetic",
+			body: []byte{
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeI64Const, 0x80, 0x01, wasm.OpcodeI64Add,
+				wasm.OpcodeF64Const, 0, 0, 0, 0, 0, 0, 0, 0, wasm.OpcodeDrop, wasm.OpcodeEnd,
+			},
+			exp: true,
+		},
+		{name: "too long", body: tooLong},
+		{name: "call", body: []byte{wasm.OpcodeCall, 0, wasm.OpcodeEnd}},
+		{name: "block", body: []byte{wasm.OpcodeBlock, 0x40, wasm.OpcodeEnd, wasm.OpcodeEnd}},
+		{name: "load", body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Load, 2, 0, wasm.OpcodeEnd}},
+		{
+			name: "division",
+			body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeI32DivU, wasm.OpcodeEnd},
+		},
+		{name: "unreachable", body: []byte{wasm.OpcodeUnreachable, wasm.OpcodeEnd}},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.exp, isInlinableBody(tc.body))
+		})
+	}
+}
+
+func Test_hasZeroValues(t *testing.T) {
+	require.True(t, hasZeroValues(nil))
+	require.True(t, hasZeroValues([]wasm.ValueType{
+		wasm.ValueTypeI32, wasm.ValueTypeI64, wasm.ValueTypeF32, wasm.ValueTypeF64, wasm.ValueTypeV128,
+		wasm.ValueTypeExternref, wasm.ValueTypeFuncref,
+	}))
+	require.False(t, hasZeroValues([]wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeExnref}))
+}
@@ -187,14 +187,15 @@ func (c *Compiler) lowerCurrentOpcode() {
 	state := c.state()
 	switch op {
 	case wasm.OpcodeI32Const:
-		c := c.readI32s()
+		v := c.readI32s()
 		if state.unreachable {
 			break
 		}
 
-		iconst := builder.AllocateInstruction().AsIconst32(uint32(c)).Insert(builder)
+		iconst := builder.AllocateInstruction().AsIconst32(uint32(v)).Insert(builder)
 		value := iconst.Return()
 		state.push(value)
+		c.recordI32Const(value, uint32(v))
 	case wasm.OpcodeI64Const:
 		c := c.readI64s()
 		if state.unreachable {
@@ -1578,6 +1579,11 @@ func (c *Compiler) lowerCurrentOpcode() {
 			break
 		}
 
+		if c.isInlinable(fnIndex) {
+			c.lowerInlinedCall(fnIndex)
+			break
+		}
+
 		// Before transfer the control to the callee, we have to store the current module's moduleContextPtr
 		// into execContext.callerModuleContextPtr in case when the callee is a Go function.
 		//
@@ -3266,12 +3272,14 @@ func (c *Compiler) lowerAccessTableWithBoundsCheck(tableIndex uint32, elementOff
 	builder.InsertInstruction(loadTableBaseAddress)
 	tableBase := loadTableBaseAddress.Return()
 
-	// Calculate the address of the target function. First we need to multiply targetOffsetInTable by 8 (pointer size).
+	// Calculate the address of the target function. First we need to multiply targetOffsetInTable by 8 (pointer size)
+	// in 64-bit, which backends can fold into the scaled addressing mode.
+	elementOffsetInTableExt := builder.AllocateInstruction().AsUExtend(elementOffsetInTable, 32, 64).Insert(builder).Return()
 	multiplyBy8 := builder.AllocateInstruction()
 	three := builder.AllocateInstruction()
 	three.AsIconst64(3)
 	builder.InsertInstruction(three)
-	multiplyBy8.AsIshl(elementOffsetInTable, three.Return())
+	multiplyBy8.AsIshl(elementOffsetInTableExt, three.Return())
 	builder.InsertInstruction(multiplyBy8)
 	targetOffsetInTableMultipliedBy8 := multiplyBy8.Return()
 
@@ -3371,6 +3379,13 @@ func (c *Compiler) memOpSetup(baseAddr ssa.Value, constOffset, operationSizeInBy
 	builder := c.ssaBuilder
 
 	ceil := constOffset + operationSizeInBytes
+	if c.boundsCheckRedundant(baseAddr, ceil) {
+		extBaseAddr := builder.AllocateInstruction()
+		extBaseAddr.AsUExtend(baseAddr, 32, 64)
+		builder.InsertInstruction(extBaseAddr)
+		return c.memOpAddress(extBaseAddr.Return())
+	}
+
 	ceilConst := builder.AllocateInstruction()
 	ceilConst.AsIconst64(ceil)
 	builder.InsertInstruction(ceilConst)
@@ -3395,11 +3410,19 @@ func (c *Compiler) memOpSetup(baseAddr ssa.Value, constOffset, operationSizeInBy
 	exitIfNZ := builder.AllocateInstruction()
 	exitIfNZ.AsExitIfTrueWithCode(c.execCtxPtrValue, cmp.Return(), wazevoapi.ExitCodeMemoryOutOfBounds)
 	builder.InsertInstruction(exitIfNZ)
+	c.recordBoundsCheck(baseAddr, ceil)
+
+	return c.memOpAddress(extBaseAddr.Return())
+}
 
+// memOpAddress returns the address of the access at `extBaseAddr` in the
+// memory, whose bounds are checked.
+func (c *Compiler) memOpAddress(extBaseAddr ssa.Value) ssa.Value {
+	builder := c.ssaBuilder
 	// Load the value from memBase + extBaseAddr.
 	memBase := c.getMemoryBaseValue(false)
 	addrCalc := builder.AllocateInstruction()
-	addrCalc.AsIadd(memBase, extBaseAddr.Return())
+	addrCalc.AsIadd(memBase, extBaseAddr)
 	builder.InsertInstruction(addrCalc)
 	return addrCalc.Return()
 }
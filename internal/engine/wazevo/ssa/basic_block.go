@@ -277,6 +277,36 @@ func (bb *basicBlock) Tail() *Instruction {
 	return bb.currentInstr
 }
 
+// removeSucc removes the edge from this block to `succ` by the branch instruction.
+func (bb *basicBlock) removeSucc(succ *basicBlock, branch *Instruction) {
+	for i := range succ.preds {
+		if succ.preds[i].branch == branch {
+			succ.preds = append(succ.preds[:i], succ.preds[i+1:]...)
+			break
+		}
+	}
+	for i, s := range bb.success {
+		if s == succ {
+			bb.success = append(bb.success[:i], bb.success[i+1:]...)
+			break
+		}
+	}
+}
+
+// removeInstruction removes the instruction from this block.
+func (bb *basicBlock) removeInstruction(instr *Instruction) {
+	if prev := instr.prev; prev != nil {
+		prev.next = instr.next
+	} else {
+		bb.rootInstr = instr.next
+	}
+	if next := instr.next; next != nil {
+		next.prev = instr.prev
+	} else {
+		bb.currentInstr = instr.prev
+	}
+}
+
 // reset resets the basicBlock to its initial state so that it can be reused for another function.
 func resetBasicBlock(bb *basicBlock) {
 	bb.params = bb.params[:0]
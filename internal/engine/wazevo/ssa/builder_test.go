@@ -329,10 +329,8 @@ func TestBuilder_LayoutBlocks(t *testing.T) {
 			// 2 ---------
 			setup: func(b *builder) {
 				b0, b1, b2, b3 := b.allocateBasicBlock(), b.allocateBasicBlock(), b.allocateBasicBlock(), b.allocateBasicBlock()
-				b.SetCurrentBlock(b0)
-				c := b.AllocateInstruction().AsIconst32(0)
-				b.InsertInstruction(c)
-				insertBrz(b, b0, b2, c.Return())
+				cond := b0.AddParam(b, TypeI32)
+				insertBrz(b, b0, b2, cond)
 				insertJump(b, b0, b1)
 				insertJump(b, b1, b3)
 				insertJump(b, b2, b3)
@@ -364,12 +362,10 @@ func TestBuilder_LayoutBlocks(t *testing.T) {
 			//    3
 			setup: func(b *builder) {
 				b0, b1, b2, b3 := b.allocateBasicBlock(), b.allocateBasicBlock(), b.allocateBasicBlock(), b.allocateBasicBlock()
+				cond := b0.AddParam(b, TypeI32)
 				insertJump(b, b0, b1)
 				insertJump(b, b1, b2)
-				b.SetCurrentBlock(b2)
-				c := b.AllocateInstruction().AsIconst32(0)
-				b.InsertInstruction(c)
-				insertBrz(b, b2, b1, c.Return())
+				insertBrz(b, b2, b1, cond)
 				insertJump(b, b2, b3)
 				b.Seal(b0)
 				b.Seal(b1)
@@ -400,12 +396,10 @@ func TestBuilder_LayoutBlocks(t *testing.T) {
 			//    3
 			setup: func(b *builder) {
 				b0, b1, b2, b3 := b.allocateBasicBlock(), b.allocateBasicBlock(), b.allocateBasicBlock(), b.allocateBasicBlock()
+				cond := b0.AddParam(b, TypeI32)
 				insertJump(b, b0, b1)
 				insertJump(b, b1, b2)
-				b.SetCurrentBlock(b2)
-				c := b.AllocateInstruction().AsIconst32(0)
-				b.InsertInstruction(c)
-				insertBrz(b, b2, b3, c.Return())
+				insertBrz(b, b2, b3, cond)
 				insertJump(b, b2, b1)
 				b.Seal(b0)
 				b.Seal(b1)
@@ -441,18 +435,13 @@ func TestBuilder_LayoutBlocks(t *testing.T) {
 			setup: func(b *builder) {
 				b0, b1, b2, b3, b4, b5 := b.allocateBasicBlock(), b.allocateBasicBlock(), b.allocateBasicBlock(),
 					b.allocateBasicBlock(), b.allocateBasicBlock(), b.allocateBasicBlock()
+				cond := b0.AddParam(b, TypeI32)
 				insertJump(b, b0, b1)
-				b.SetCurrentBlock(b0)
-				c1 := b.AllocateInstruction().AsIconst32(0)
-				b.InsertInstruction(c1)
-				insertBrz(b, b1, b2, c1.Return())
+				insertBrz(b, b1, b2, cond)
 				insertJump(b, b1, b3)
 				insertJump(b, b3, b4)
 				insertJump(b, b2, b4)
-				b.SetCurrentBlock(b4)
-				c2 := b.AllocateInstruction().AsIconst32(0)
-				b.InsertInstruction(c2)
-				insertBrz(b, b4, b1, c2.Return())
+				insertBrz(b, b4, b1, cond)
 				insertJump(b, b4, b5)
 				b.Seal(b0)
 				b.Seal(b1)
@@ -489,17 +478,12 @@ func TestBuilder_LayoutBlocks(t *testing.T) {
 			setup: func(b *builder) {
 				b0, b1, b2, b3, b4 := b.allocateBasicBlock(), b.allocateBasicBlock(), b.allocateBasicBlock(),
 					b.allocateBasicBlock(), b.allocateBasicBlock()
+				cond := b0.AddParam(b, TypeI32)
 				insertJump(b, b0, b1)
-				b.SetCurrentBlock(b1)
-				c1 := b.AllocateInstruction().AsIconst32(0)
-				b.InsertInstruction(c1)
-				insertBrz(b, b1, b2, c1.Return())
+				insertBrz(b, b1, b2, cond)
 				insertJump(b, b1, b3)
 
-				b.SetCurrentBlock(b2)
-				c2 := b.AllocateInstruction().AsIconst32(0)
-				b.InsertInstruction(c2)
-				insertBrz(b, b2, b1, c2.Return())
+				insertBrz(b, b2, b1, cond)
 				insertJump(b, b2, b3)
 				insertJump(b, b3, b4)
 
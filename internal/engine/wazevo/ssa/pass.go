@@ -19,6 +19,13 @@ func (b *builder) RunPasses() {
 	passRedundantPhiEliminationOpt(b)
 	// The result of passCalculateImmediateDominators will be used by various passes below.
 	passCalculateImmediateDominators(b)
+	passConstFoldingOpt(b)
+	if passConstBranchEliminationOpt(b) {
+		// Some blocks may be unreachable now, so prune them and recalculate the CFG info.
+		passDeadBlockEliminationOpt(b)
+		passRedundantPhiEliminationOpt(b)
+		passCalculateImmediateDominators(b)
+	}
 	passNopInstElimination(b)
 
 	// TODO: implement either conversion of irreducible CFG into reducible one, or irreducible CFG detection where we panic.
@@ -28,7 +35,6 @@ func (b *builder) RunPasses() {
 	// TODO: implement more optimization passes like:
 	// 	block coalescing.
 	// 	Copy-propagation.
-	// 	Constant folding.
 	// 	Common subexpression elimination.
 	// 	Arithmetic simplifications.
 	// 	and more!
@@ -69,6 +75,52 @@ func passDeadBlockEliminationOpt(b *builder) {
 			blk.invalid = true
 		}
 	}
+
+	// Remove the edges from the invalid blocks, so that the following passes and backends only see reachable ones.
+	for blk := b.blockIteratorBegin(); blk != nil; blk = b.blockIteratorNext() {
+		var cur int
+		for _, pred := range blk.preds {
+			if !pred.blk.invalid {
+				blk.preds[cur] = pred
+				cur++
+			}
+		}
+		blk.preds = blk.preds[:cur]
+	}
+}
+
+// passConstBranchEliminationOpt replaces the conditional branches on constants, which are folded by
+// passConstFoldingOpt, with unconditional ones, and removes ExitIfTrueWithCode on zero. This returns true if
+// any branch was eliminated, in which case blocks may be unreachable now.
+func passConstBranchEliminationOpt(b *builder) (changed bool) {
+	for blk := b.blockIteratorBegin(); blk != nil; blk = b.blockIteratorNext() {
+		for cur := blk.rootInstr; cur != nil; cur = cur.next {
+			switch cur.opcode {
+			case OpcodeExitIfTrueWithCode:
+				if c, ok := b.constantValue(cur.v2); ok && c == 0 {
+					blk.removeInstruction(cur)
+				}
+			case OpcodeBrz, OpcodeBrnz:
+				c, ok := b.constantValue(cur.v)
+				if !ok {
+					continue
+				}
+				changed = true
+				if taken := (c == 0) == (cur.opcode == OpcodeBrz); taken {
+					// The unconditional jump following this branch is never reached.
+					if next := cur.next; next != nil {
+						blk.removeSucc(next.blk.(*basicBlock), next)
+						blk.removeInstruction(next)
+					}
+					cur.opcode, cur.v = OpcodeJump, ValueInvalid
+				} else {
+					blk.removeSucc(cur.blk.(*basicBlock), cur)
+					blk.removeInstruction(cur)
+				}
+			}
+		}
+	}
+	return
 }
 
 // passRedundantPhiEliminationOpt eliminates the redundant PHIs (in our terminology, parameters of a block).
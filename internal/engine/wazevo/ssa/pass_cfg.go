@@ -153,6 +153,7 @@ func intersect(doms []*basicBlock, blk1 *basicBlock, blk2 *basicBlock) *basicBlo
 // This is run at the last of passCalculateImmediateDominators.
 func subPassLoopDetection(b *builder) {
 	for blk := b.blockIteratorBegin(); blk != nil; blk = b.blockIteratorNext() {
+		blk.loopHeader = false // Reset in case the CFG is recalculated.
 		for i := range blk.preds {
 			pred := blk.preds[i].blk
 			if pred.invalid {
@@ -0,0 +1,266 @@
+package ssa
+
+import "math/bits"
+
+// passConstFoldingOpt folds the integer instructions whose arguments are constants into constants, and propagates
+// the value of trivial ones, like `x + 0` or a Select on a constant, to their users via aliases. This also
+// reassociates `(x + c1) + c2` into `x + (c1 + c2)`, which is common in address arithmetic.
+//
+// This must run after passCalculateImmediateDominators, as blocks are visited in reverse post-order, so that the
+// definitions of values are visited before their uses except for block params, which are never constants.
+// The instructions folded away are removed later by passDeadCodeEliminationOpt.
+func passConstFoldingOpt(b *builder) {
+	if int(b.nextValueID) >= len(b.valueIDToInstruction) {
+		b.valueIDToInstruction = append(b.valueIDToInstruction, make([]*Instruction, b.nextValueID)...)
+	}
+	for blk := b.blockIteratorBegin(); blk != nil; blk = b.blockIteratorNext() {
+		for cur := blk.rootInstr; cur != nil; cur = cur.next {
+			if r := cur.rValue; r.Valid() {
+				b.valueIDToInstruction[r.ID()] = cur
+			}
+		}
+	}
+
+	for blk := b.blockIteratorReversePostOrderBegin(); blk != nil; blk = b.blockIteratorReversePostOrderNext() {
+		for cur := blk.rootInstr; cur != nil; cur = cur.next {
+			b.resolveArgumentAlias(cur)
+			switch cur.opcode {
+			case OpcodeIadd, OpcodeIsub, OpcodeImul, OpcodeBand, OpcodeBor, OpcodeBxor,
+				OpcodeIshl, OpcodeUshr, OpcodeSshr, OpcodeRotl, OpcodeRotr:
+				if typ := cur.typ; typ != TypeI32 && typ != TypeI64 {
+					continue
+				}
+				x, y := cur.Arg2()
+				xc, xok := b.constantValue(x)
+				yc, yok := b.constantValue(y)
+				switch {
+				case xok && yok:
+					foldIntoIconst(cur, foldBinaryOp(cur.opcode, cur.typ, xc, yc))
+				case yok && isRightIdentity(cur.opcode, cur.typ, yc):
+					b.alias(cur.Return(), x)
+				case xok && isLeftIdentity(cur.opcode, xc):
+					b.alias(cur.Return(), y)
+				case yok && cur.opcode == OpcodeIadd:
+					b.reassociateIadd(blk, cur, x, yc)
+				}
+			case OpcodeIcmp:
+				x, y, cond := cur.IcmpData()
+				if typ := x.Type(); typ != TypeI32 && typ != TypeI64 {
+					continue
+				}
+				xc, xok := b.constantValue(x)
+				yc, yok := b.constantValue(y)
+				if xok && yok {
+					var ret uint64
+					if foldIcmp(cond, x.Type(), xc, yc) {
+						ret = 1
+					}
+					foldIntoIconst(cur, ret)
+				}
+			case OpcodeUExtend, OpcodeSExtend:
+				if xc, ok := b.constantValue(cur.v); ok {
+					from, _, signed := cur.ExtendData()
+					foldIntoIconst(cur, foldExtend(xc, from, signed))
+				}
+			case OpcodeIreduce:
+				if xc, ok := b.constantValue(cur.v); ok {
+					foldIntoIconst(cur, xc)
+				}
+			case OpcodeSelect:
+				c, x, y := cur.SelectData()
+				if cc, ok := b.constantValue(c); ok {
+					if cc != 0 {
+						b.alias(cur.Return(), x)
+					} else {
+						b.alias(cur.Return(), y)
+					}
+				}
+			}
+		}
+	}
+}
+
+// constantValue returns the value of `v` if it's defined by OpcodeIconst.
+func (b *builder) constantValue(v Value) (uint64, bool) {
+	if inst := b.valueIDToInstruction[v.ID()]; inst != nil && inst.opcode == OpcodeIconst {
+		return inst.u1, true
+	}
+	return 0, false
+}
+
+// reassociateIadd rewrites `cur = (x + c1) + c2` into `cur = x + (c1 + c2)`, so that the inner addition may be
+// dead afterward.
+func (b *builder) reassociateIadd(blk *basicBlock, cur *Instruction, x Value, c2 uint64) {
+	inner := b.valueIDToInstruction[x.ID()]
+	if inner == nil || inner.opcode != OpcodeIadd {
+		return
+	}
+	ix, iy := inner.Arg2()
+	c1, ok := b.constantValue(iy)
+	if !ok {
+		if c1, ok = b.constantValue(ix); !ok {
+			return
+		}
+		ix = iy
+	}
+
+	c := b.AllocateInstruction()
+	if cur.typ == TypeI32 {
+		c.AsIconst32(uint32(c1 + c2))
+	} else {
+		c.AsIconst64(c1 + c2)
+	}
+	c.rValue = b.allocateValue(cur.typ)
+	for int(c.rValue.ID()) >= len(b.valueIDToInstruction) {
+		b.valueIDToInstruction = append(b.valueIDToInstruction, nil)
+	}
+	b.valueIDToInstruction[c.rValue.ID()] = c
+	// Insert the constant right before cur.
+	c.prev, c.next = cur.prev, cur
+	if prev := cur.prev; prev != nil {
+		prev.next = c
+	} else {
+		blk.rootInstr = c
+	}
+	cur.prev = c
+
+	cur.v, cur.v2 = ix, c.rValue
+}
+
+// foldIntoIconst replaces the instruction with the constant `v` of the same type.
+func foldIntoIconst(i *Instruction, v uint64) {
+	i.v, i.v2, i.v3 = ValueInvalid, ValueInvalid, ValueInvalid
+	if i.rValue.Type() == TypeI32 {
+		i.AsIconst32(uint32(v))
+	} else {
+		i.AsIconst64(v)
+	}
+}
+
+// foldBinaryOp returns the result of the integer binary instruction `op` on constants.
+func foldBinaryOp(op Opcode, typ Type, x, y uint64) uint64 {
+	if typ == TypeI32 {
+		x32, y32 := uint32(x), uint32(y)
+		switch op {
+		case OpcodeIadd:
+			return uint64(x32 + y32)
+		case OpcodeIsub:
+			return uint64(x32 - y32)
+		case OpcodeImul:
+			return uint64(x32 * y32)
+		case OpcodeBand:
+			return uint64(x32 & y32)
+		case OpcodeBor:
+			return uint64(x32 | y32)
+		case OpcodeBxor:
+			return uint64(x32 ^ y32)
+		case OpcodeIshl:
+			return uint64(x32 << (y32 % 32))
+		case OpcodeUshr:
+			return uint64(x32 >> (y32 % 32))
+		case OpcodeSshr:
+			return uint64(uint32(int32(x32) >> (y32 % 32)))
+		case OpcodeRotl:
+			return uint64(bits.RotateLeft32(x32, int(y32%32)))
+		case OpcodeRotr:
+			return uint64(bits.RotateLeft32(x32, -int(y32%32)))
+		}
+	} else {
+		switch op {
+		case OpcodeIadd:
+			return x + y
+		case OpcodeIsub:
+			return x - y
+		case OpcodeImul:
+			return x * y
+		case OpcodeBand:
+			return x & y
+		case OpcodeBor:
+			return x | y
+		case OpcodeBxor:
+			return x ^ y
+		case OpcodeIshl:
+			return x << (y % 64)
+		case OpcodeUshr:
+			return x >> (y % 64)
+		case OpcodeSshr:
+			return uint64(int64(x) >> (y % 64))
+		case OpcodeRotl:
+			return bits.RotateLeft64(x, int(y%64))
+		case OpcodeRotr:
+			return bits.RotateLeft64(x, -int(y%64))
+		}
+	}
+	panic("BUG: unsupported opcode " + op.String())
+}
+
+// isRightIdentity returns true if `x op c` is always x.
+func isRightIdentity(op Opcode, typ Type, c uint64) bool {
+	switch op {
+	case OpcodeIadd, OpcodeIsub, OpcodeBor, OpcodeBxor:
+		return c == 0
+	case OpcodeImul:
+		return c == 1
+	case OpcodeBand:
+		if typ == TypeI32 {
+			return uint32(c) == 0xffffffff
+		}
+		return c == 0xffffffffffffffff
+	}
+	return false
+}
+
+// isLeftIdentity returns true if `c op y` is always y.
+func isLeftIdentity(op Opcode, c uint64) bool {
+	switch op {
+	case OpcodeIadd, OpcodeBor, OpcodeBxor:
+		return c == 0
+	case OpcodeImul:
+		return c == 1
+	}
+	return false
+}
+
+// foldIcmp returns the result of the integer comparison on constants.
+func foldIcmp(cond IntegerCmpCond, typ Type, x, y uint64) bool {
+	var sx, sy int64
+	if typ == TypeI32 {
+		x, y = uint64(uint32(x)), uint64(uint32(y))
+		sx, sy = int64(int32(x)), int64(int32(y))
+	} else {
+		sx, sy = int64(x), int64(y)
+	}
+	switch cond {
+	case IntegerCmpCondEqual:
+		return x == y
+	case IntegerCmpCondNotEqual:
+		return x != y
+	case IntegerCmpCondSignedLessThan:
+		return sx < sy
+	case IntegerCmpCondSignedGreaterThanOrEqual:
+		return sx >= sy
+	case IntegerCmpCondSignedGreaterThan:
+		return sx > sy
+	case IntegerCmpCondSignedLessThanOrEqual:
+		return sx <= sy
+	case IntegerCmpCondUnsignedLessThan:
+		return x < y
+	case IntegerCmpCondUnsignedGreaterThanOrEqual:
+		return x >= y
+	case IntegerCmpCondUnsignedGreaterThan:
+		return x > y
+	case IntegerCmpCondUnsignedLessThanOrEqual:
+		return x <= y
+	default:
+		panic("invalid integer comparison condition")
+	}
+}
+
+// foldExtend returns the constant `x` of `from` bits extended.
+func foldExtend(x uint64, from byte, signed bool) uint64 {
+	shift := 64 - from
+	if signed {
+		return uint64(int64(x<<shift) >> shift)
+	}
+	return x << shift >> shift
+}
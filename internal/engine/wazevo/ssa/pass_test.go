@@ -3,6 +3,7 @@ package ssa
 import (
 	"testing"
 
+	"github.com/tetratelabs/wazero/internal/engine/wazevo/wazevoapi"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 )
 
@@ -334,6 +335,172 @@ blk0: (v0:i32, v1:i64)
 	v8:i64 = Iconst_64 0x3d41
 	v9:i64 = Sshr v1, v8
 	Return v0, v1, v7, v9
+`,
+		},
+		{
+			name: "constant folding",
+			pass: func(b *builder) {
+				passCalculateImmediateDominators(b)
+				passConstFoldingOpt(b)
+			},
+			postPass: passDeadCodeEliminationOpt,
+			setup: func(b *builder) (verifier func(t *testing.T)) {
+				entry := b.AllocateBasicBlock()
+				b.SetCurrentBlock(entry)
+
+				i32Param := entry.AddParam(b, TypeI32)
+				i64Param := entry.AddParam(b, TypeI64)
+
+				// (8 - 3) * 4 == 20.
+				eight := b.AllocateInstruction().AsIconst32(8).Insert(b).Return()
+				three := b.AllocateInstruction().AsIconst32(3).Insert(b).Return()
+				sub := b.AllocateInstruction().AsIsub(eight, three).Insert(b).Return()
+				four := b.AllocateInstruction().AsIconst32(4).Insert(b).Return()
+				mul := b.AllocateInstruction().AsImul(sub, four).Insert(b).Return()
+
+				// Wraps around in 32-bit.
+				max := b.AllocateInstruction().AsIconst32(0xffffffff).Insert(b).Return()
+				one := b.AllocateInstruction().AsIconst32(1).Insert(b).Return()
+				wrap := b.AllocateInstruction().AsIadd(max, one).Insert(b).Return()
+
+				// -1 < 1 when signed.
+				cmp := b.AllocateInstruction().AsIcmp(max, one, IntegerCmpCondSignedLessThan).Insert(b).Return()
+				// Select on a constant is one of its arguments.
+				sel := b.AllocateInstruction().AsSelect(cmp, i32Param, mul).Insert(b).Return()
+				// Identity.
+				identity := b.AllocateInstruction().AsImul(one, i32Param).Insert(b).Return()
+
+				// Sign extension.
+				ext := b.AllocateInstruction().AsSExtend(max, 32, 64).Insert(b).Return()
+
+				// (x + 8) + 16 == x + 24.
+				eight64 := b.AllocateInstruction().AsIconst64(8).Insert(b).Return()
+				add1 := b.AllocateInstruction().AsIadd(i64Param, eight64).Insert(b).Return()
+				sixteen64 := b.AllocateInstruction().AsIconst64(16).Insert(b).Return()
+				add2 := b.AllocateInstruction().AsIadd(add1, sixteen64).Insert(b).Return()
+
+				ret := b.AllocateInstruction()
+				ret.AsReturn([]Value{mul, wrap, sel, identity, ext, add2})
+				b.InsertInstruction(ret)
+				return nil
+			},
+			before: `
+blk0: (v0:i32, v1:i64)
+	v2:i32 = Iconst_32 0x8
+	v3:i32 = Iconst_32 0x3
+	v4:i32 = Isub v2, v3
+	v5:i32 = Iconst_32 0x4
+	v6:i32 = Imul v4, v5
+	v7:i32 = Iconst_32 0xffffffff
+	v8:i32 = Iconst_32 0x1
+	v9:i32 = Iadd v7, v8
+	v10:i32 = Icmp lt_s, v7, v8
+	v11:i32 = Select v10, v0, v6
+	v12:i32 = Imul v8, v0
+	v13:i64 = SExtend v7, 32->64
+	v14:i64 = Iconst_64 0x8
+	v15:i64 = Iadd v1, v14
+	v16:i64 = Iconst_64 0x10
+	v17:i64 = Iadd v15, v16
+	Return v6, v9, v11, v12, v13, v17
+`,
+			after: `
+blk0: (v0:i32, v1:i64)
+	v6:i32 = Iconst_32 0x14
+	v9:i32 = Iconst_32 0x0
+	v13:i64 = Iconst_64 0xffffffffffffffff
+	v18:i64 = Iconst_64 0x18
+	v17:i64 = Iadd v1, v18
+	Return v6, v9, v0, v0, v13, v17
+`,
+		},
+		{
+			name: "constant branch elimination",
+			pass: func(b *builder) {
+				passCalculateImmediateDominators(b)
+				passConstFoldingOpt(b)
+				if passConstBranchEliminationOpt(b) {
+					passDeadBlockEliminationOpt(b)
+				}
+			},
+			postPass: passDeadCodeEliminationOpt,
+			setup: func(b *builder) (verifier func(t *testing.T)) {
+				entry, taken, notTaken, end := b.AllocateBasicBlock(), b.AllocateBasicBlock(), b.AllocateBasicBlock(), b.AllocateBasicBlock()
+				ctx := entry.AddParam(b, TypeI64)
+
+				b.SetCurrentBlock(entry)
+				{
+					zero := b.AllocateInstruction().AsIconst32(0).Insert(b).Return()
+					one := b.AllocateInstruction().AsIconst32(1).Insert(b).Return()
+					// Never exits.
+					b.AllocateInstruction().AsExitIfTrueWithCode(ctx, zero, wazevoapi.ExitCodeUnreachable).Insert(b)
+					// 1 == 0 is false, so always branches to `taken`.
+					cmp := b.AllocateInstruction().AsIcmp(one, zero, IntegerCmpCondEqual).Insert(b).Return()
+					brz := b.AllocateInstruction()
+					brz.AsBrz(cmp, nil, taken)
+					b.InsertInstruction(brz)
+					jmp := b.AllocateInstruction()
+					jmp.AsJump(nil, notTaken)
+					b.InsertInstruction(jmp)
+				}
+
+				b.SetCurrentBlock(taken)
+				{
+					one := b.AllocateInstruction().AsIconst32(1).Insert(b).Return()
+					// Never branches.
+					brz := b.AllocateInstruction()
+					brz.AsBrz(one, nil, notTaken)
+					b.InsertInstruction(brz)
+					jmp := b.AllocateInstruction()
+					jmp.AsJump(nil, end)
+					b.InsertInstruction(jmp)
+				}
+
+				b.SetCurrentBlock(notTaken)
+				{
+					jmp := b.AllocateInstruction()
+					jmp.AsJump(nil, end)
+					b.InsertInstruction(jmp)
+				}
+
+				b.SetCurrentBlock(end)
+				b.AllocateInstruction().AsReturn(nil).Insert(b)
+
+				b.Seal(entry)
+				b.Seal(taken)
+				b.Seal(notTaken)
+				b.Seal(end)
+				return nil
+			},
+			before: `
+blk0: (v0:i64)
+	v1:i32 = Iconst_32 0x0
+	v2:i32 = Iconst_32 0x1
+	ExitIfTrue v1, v0, unreachable
+	v3:i32 = Icmp eq, v2, v1
+	Brz v3, blk1
+	Jump blk2
+
+blk1: () <-- (blk0)
+	v4:i32 = Iconst_32 0x1
+	Brz v4, blk2
+	Jump blk3
+
+blk2: () <-- (blk0,blk1)
+	Jump blk3
+
+blk3: () <-- (blk1,blk2)
+	Return
+`,
+			after: `
+blk0: (v0:i64)
+	Jump blk1
+
+blk1: () <-- (blk0)
+	Jump blk3
+
+blk3: () <-- (blk1)
+	Return
 `,
 		},
 	} {
@@ -0,0 +1,18 @@
+// Package enginekind allows experimental.WithEngineKind without introducing a
+// package cycle.
+package enginekind
+
+// Key is a context.Context Value key. Its associated value should be a Kind.
+type Key struct{}
+
+// Kind is the engine which executes a module compiled by the tiered engine.
+type Kind int
+
+const (
+	// Tiered interprets a module until it is compiled in the background.
+	Tiered Kind = iota
+	// Interpreter only interprets a module.
+	Interpreter
+	// Compiler compiles a module before it is instantiated.
+	Compiler
+)
@@ -0,0 +1,98 @@
+// Package fuel contains internal symbols shared between the engines and the
+// experimental package for fuel metering.
+package fuel
+
+import (
+	"context"
+	"encoding/binary"
+	"hash"
+	"math"
+	"sort"
+)
+
+// Unlimited is the fuel of calls made without a Meter.
+const Unlimited = math.MaxInt64
+
+// CostsKey is a context.Context key for *Costs, which is read when compiling
+// modules.
+type CostsKey struct{}
+
+// Costs is the fuel charged for each instruction.
+type Costs struct {
+	// Default is charged for instructions not in Instructions.
+	Default uint64
+	// Instructions are the costs of instructions by name, e.g. "i32.add".
+	Instructions map[string]uint64
+}
+
+// CostsFromContext returns the Costs of the context, or nil if modules
+// compiled with it aren't metered.
+func CostsFromContext(ctx context.Context) *Costs {
+	costs, _ := ctx.Value(CostsKey{}).(*Costs)
+	return costs
+}
+
+// Of returns the cost of the instruction with the given name.
+func (c *Costs) Of(instruction string) uint64 {
+	if cost, ok := c.Instructions[instruction]; ok {
+		return cost
+	}
+	return c.Default
+}
+
+// Hash writes the costs to the hash in a deterministic order, so that the
+// ID of a module depends on them.
+func (c *Costs) Hash(h hash.Hash) {
+	names := make([]string, 0, len(c.Instructions))
+	for name := range c.Instructions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], c.Default)
+	h.Write(buf[:])
+	for _, name := range names {
+		h.Write([]byte(name))
+		binary.LittleEndian.PutUint64(buf[:], c.Instructions[name])
+		h.Write(buf[:])
+	}
+}
+
+// MeterKey is a context.Context key for *Meter, which is read when calling
+// functions.
+type MeterKey struct{}
+
+// Meter holds the fuel remaining to calls made with a context including it.
+type Meter struct {
+	// Remaining is never negative. Engines consume fuel from a copy, which
+	// they store before calling host functions and returning, so that nested
+	// calls share it.
+	Remaining int64
+}
+
+// MeterFromContext returns the Meter of the context, or nil if calls made
+// with it are unlimited.
+func MeterFromContext(ctx context.Context) *Meter {
+	m, _ := ctx.Value(MeterKey{}).(*Meter)
+	return m
+}
+
+// Load returns the remaining fuel, or Unlimited if `m` is nil.
+func (m *Meter) Load() int64 {
+	if m == nil {
+		return Unlimited
+	}
+	return m.Remaining
+}
+
+// Store sets the remaining fuel, or zero if it is negative as a call
+// exhausted it. This has no effect if `m` is nil.
+func (m *Meter) Store(remaining int64) {
+	if m == nil {
+		return
+	} else if remaining < 0 {
+		remaining = 0
+	}
+	m.Remaining = remaining
+}
@@ -27,6 +27,8 @@ var (
 	ErrnoAgain = &Errno{"EAGAIN"}
 	// ErrnoBadf Bad file descriptor.
 	ErrnoBadf = &Errno{"EBADF"}
+	// ErrnoDquot Disk quota exceeded.
+	ErrnoDquot = &Errno{"EDQUOT"}
 	// ErrnoExist File exists.
 	ErrnoExist = &Errno{"EEXIST"}
 	// ErrnoFault Bad address.
@@ -45,6 +47,8 @@ var (
 	ErrnoNametoolong = &Errno{"ENAMETOOLONG"}
 	// ErrnoNoent No such file or directory.
 	ErrnoNoent = &Errno{"ENOENT"}
+	// ErrnoNospc No space left on device.
+	ErrnoNospc = &Errno{"ENOSPC"}
 	// ErrnoNosys function not supported.
 	ErrnoNosys = &Errno{"ENOSYS"}
 	// ErrnoNotdir Not a directory or a symbolic link to a directory.
@@ -76,6 +80,8 @@ func ToErrno(err error) *Errno {
 		return ErrnoAgain
 	case sys.EBADF:
 		return ErrnoBadf
+	case sys.EDQUOT:
+		return ErrnoDquot
 	case sys.EEXIST:
 		return ErrnoExist
 	case sys.EFAULT:
@@ -94,6 +100,8 @@ func ToErrno(err error) *Errno {
 		return ErrnoNametoolong
 	case sys.ENOENT:
 		return ErrnoNoent
+	case sys.ENOSPC:
+		return ErrnoNospc
 	case sys.ENOSYS:
 		return ErrnoNosys
 	case sys.ENOTDIR:
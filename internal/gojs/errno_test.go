@@ -35,6 +35,11 @@ func TestToErrno(t *testing.T) {
 			input:    sys.EBADF,
 			expected: ErrnoBadf,
 		},
+		{
+			name:     "sys.EDQUOT",
+			input:    sys.EDQUOT,
+			expected: ErrnoDquot,
+		},
 		{
 			name:     "sys.EEXIST",
 			input:    sys.EEXIST,
@@ -80,6 +85,11 @@ func TestToErrno(t *testing.T) {
 			input:    sys.ENOENT,
 			expected: ErrnoNoent,
 		},
+		{
+			name:     "sys.ENOSPC",
+			input:    sys.ENOSPC,
+			expected: ErrnoNospc,
+		},
 		{
 			name:     "sys.ENOSYS",
 			input:    sys.ENOSYS,
@@ -98,7 +98,8 @@ func (o *jsfsOpen) invoke(ctx context.Context, mod api.Module, args ...interface
 
 	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
 
-	fd, errno := fsc.OpenFile(fsc.RootFS(), path, flags, perm)
+	root, path := fsc.ResolveFS(path)
+	fd, errno := fsc.OpenFile(root, path, flags, perm)
 
 	return callback.invoke(ctx, mod, goos.RefJsfs, maybeError(errno), fd) // note: error first
 }
@@ -122,7 +123,8 @@ func (s *jsfsStat) invoke(ctx context.Context, mod api.Module, args ...interface
 func syscallStat(mod api.Module, path string) (*jsSt, error) {
 	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
 
-	if st, errno := fsc.RootFS().Stat(path); errno != 0 {
+	root, path := fsc.ResolveFS(path)
+	if st, errno := root.Stat(path); errno != 0 {
 		return nil, errno
 	} else {
 		return newJsSt(st), nil
@@ -149,7 +151,8 @@ func (l *jsfsLstat) invoke(ctx context.Context, mod api.Module, args ...interfac
 func syscallLstat(mod api.Module, path string) (*jsSt, error) {
 	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
 
-	if st, errno := fsc.RootFS().Lstat(path); errno != 0 {
+	root, path := fsc.ResolveFS(path)
+	if st, errno := root.Lstat(path); errno != 0 {
 		return nil, errno
 	} else {
 		return newJsSt(st), nil
@@ -318,7 +321,8 @@ func syscallReaddir(_ context.Context, mod api.Module, name string) (*objectArra
 	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
 
 	// don't allocate a file descriptor
-	f, errno := fsc.RootFS().OpenFile(name, experimentalsys.O_RDONLY, 0)
+	root, name := fsc.ResolveFS(name)
+	f, errno := root.OpenFile(name, experimentalsys.O_RDONLY, 0)
 	if errno != 0 {
 		return nil, errno
 	}
@@ -348,7 +352,7 @@ func (m *jsfsMkdir) invoke(ctx context.Context, mod api.Module, args ...interfac
 	callback := args[2].(funcWrapper)
 
 	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
-	root := fsc.RootFS()
+	root, path := fsc.ResolveFS(path)
 
 	var fd int32
 	var errno experimentalsys.Errno
@@ -375,7 +379,8 @@ func (r *jsfsRmdir) invoke(ctx context.Context, mod api.Module, args ...interfac
 	callback := args[1].(funcWrapper)
 
 	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
-	errno := fsc.RootFS().Rmdir(path)
+	root, path := fsc.ResolveFS(path)
+	errno := root.Rmdir(path)
 
 	return jsfsInvoke(ctx, mod, callback, errno)
 }
@@ -394,7 +399,10 @@ func (r *jsfsRename) invoke(ctx context.Context, mod api.Module, args ...interfa
 	callback := args[2].(funcWrapper)
 
 	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
-	errno := fsc.RootFS().Rename(from, to)
+	fromFS, from := fsc.ResolveFS(from)
+	toFS, to := fsc.ResolveFS(to)
+
+	errno := fsc.Rename(fromFS, from, toFS, to)
 
 	return jsfsInvoke(ctx, mod, callback, errno)
 }
@@ -411,7 +419,8 @@ func (u *jsfsUnlink) invoke(ctx context.Context, mod api.Module, args ...interfa
 	callback := args[1].(funcWrapper)
 
 	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
-	errno := fsc.RootFS().Unlink(path)
+	root, path := fsc.ResolveFS(path)
+	errno := root.Unlink(path)
 
 	return jsfsInvoke(ctx, mod, callback, errno)
 }
@@ -430,7 +439,8 @@ func (u *jsfsUtimes) invoke(ctx context.Context, mod api.Module, args ...interfa
 	callback := args[3].(funcWrapper)
 
 	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
-	errno := fsc.RootFS().Utimens(path, atimeSec*1e9, mtimeSec*1e9)
+	root, path := fsc.ResolveFS(path)
+	errno := root.Utimens(path, atimeSec*1e9, mtimeSec*1e9)
 
 	return jsfsInvoke(ctx, mod, callback, errno)
 }
@@ -448,7 +458,8 @@ func (c *jsfsChmod) invoke(ctx context.Context, mod api.Module, args ...interfac
 	callback := args[2].(funcWrapper)
 
 	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
-	errno := fsc.RootFS().Chmod(path, mode)
+	root, path := fsc.ResolveFS(path)
+	errno := root.Chmod(path, mode)
 
 	return jsfsInvoke(ctx, mod, callback, errno)
 }
@@ -585,7 +596,8 @@ func (r *jsfsReadlink) invoke(ctx context.Context, mod api.Module, args ...inter
 	callback := args[1].(funcWrapper)
 
 	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
-	dst, errno := fsc.RootFS().Readlink(path)
+	root, path := fsc.ResolveFS(path)
+	dst, errno := root.Readlink(path)
 
 	return callback.invoke(ctx, mod, goos.RefJsfs, maybeError(errno), dst) // note: error first
 }
@@ -604,7 +616,15 @@ func (l *jsfsLink) invoke(ctx context.Context, mod api.Module, args ...interface
 	callback := args[2].(funcWrapper)
 
 	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
-	errno := fsc.RootFS().Link(path, link)
+	pathFS, path := fsc.ResolveFS(path)
+	linkFS, link := fsc.ResolveFS(link)
+
+	var errno experimentalsys.Errno
+	if pathFS != linkFS { // TODO: handle links across filesystems
+		errno = experimentalsys.ENOSYS
+	} else {
+		errno = pathFS.Link(path, link)
+	}
 
 	return jsfsInvoke(ctx, mod, callback, errno)
 }
@@ -622,7 +642,8 @@ func (s *jsfsSymlink) invoke(ctx context.Context, mod api.Module, args ...interf
 	callback := args[2].(funcWrapper)
 
 	fsc := mod.(*wasm.ModuleInstance).Sys.FS()
-	errno := fsc.RootFS().Symlink(dst, link)
+	root, link := fsc.ResolveFS(link)
+	errno := root.Symlink(dst, link)
 
 	return jsfsInvoke(ctx, mod, callback, errno)
 }
@@ -0,0 +1,7 @@
+// Package importresolver allows experimental.ImportResolver without
+// introducing a package cycle.
+package importresolver
+
+// Key is a context.Context Value key. Its associated value should be an
+// experimental.ImportResolver.
+type Key struct{}
@@ -0,0 +1,26 @@
+package importresolver
+
+import "github.com/tetratelabs/wazero/api"
+
+// MemoryKey is a context.Context Value key. Its associated value should be a
+// *MemoryImport.
+type MemoryKey struct{}
+
+// MemoryImport overrides the resolution of the memory imported as Name from
+// Module, to Memory. Next is the previous override in the context, if any.
+type MemoryImport struct {
+	Module, Name string
+	Memory       api.Memory
+	Next         *MemoryImport
+}
+
+// Lookup returns the memory overriding the import of `name` from
+// `moduleName`, or nil if there is none.
+func (i *MemoryImport) Lookup(moduleName, name string) api.Memory {
+	for ; i != nil; i = i.Next {
+		if i.Module == moduleName && i.Name == name {
+			return i.Memory
+		}
+	}
+	return nil
+}
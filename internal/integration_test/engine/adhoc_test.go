@@ -85,6 +85,10 @@ func TestEngineInterpreter(t *testing.T) {
 	runAllTests(t, tests, wazero.NewRuntimeConfigInterpreter().WithCloseOnContextDone(true), false)
 }
 
+func TestEngineTiered(t *testing.T) {
+	runAllTests(t, tests, wazero.NewRuntimeConfigTiered().WithCloseOnContextDone(true), false)
+}
+
 // testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
 var testCtx = context.WithValue(context.Background(), struct{}{}, "arbitrary")
 
@@ -1188,7 +1192,11 @@ func testTwoIndirection(t *testing.T, r wazero.Runtime) {
 		FunctionSection:     []wasm.Index{0},
 		CodeSection:         []wasm.Code{{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeCall, 0, wasm.OpcodeEnd}}},
 		ExportSection:       []wasm.Export{{Name: "main", Type: wasm.ExternTypeFunc, Index: 1}},
-		NameSection:         &wasm.NameSection{ModuleName: "main", FunctionNames: wasm.NameMap{{Index: wasm.Index(1), Name: "main"}}},
+		NameSection: &wasm.NameSection{
+			ModuleName:    "main",
+			FunctionNames: wasm.NameMap{{Index: wasm.Index(1), Name: "main"}},
+			LocalNames:    wasm.IndirectNameMap{{Index: wasm.Index(1), NameMap: wasm.NameMap{{Index: wasm.Index(0), Name: "n"}}}},
+		},
 	})
 
 	inst, err := r.Instantiate(ctx, main)
@@ -1218,12 +1226,12 @@ func testTwoIndirection(t *testing.T, r wazero.Runtime) {
 wasm stack trace:
 	host.div(i32) i32
 	host_importer.call_host_div(i32) i32
-	main.main(i32) i32`},
+	main.main(n i32) i32`},
 			{name: "go runtime panic", input: 0, expErr: `runtime error: integer divide by zero (recovered by wazero)
 wasm stack trace:
 	host.div(i32) i32
 	host_importer.call_host_div(i32) i32
-	main.main(i32) i32`},
+	main.main(n i32) i32`},
 		} {
 			tc := tc
 			t.Run(tc.name, func(t *testing.T) {
@@ -1244,22 +1252,22 @@ wasm stack trace:
 	})
 
 	require.Equal(t, `
---> main.main(1)
+--> main.main(n=1)
 	--> host_importer.call_host_div(1)
 		==> host.div(1)
 		<== 1
 	<-- 1
 <-- 1
---> main.main(2)
+--> main.main(n=2)
 	--> host_importer.call_host_div(2)
 		==> host.div(2)
 		<== 0
 	<-- 0
 <-- 0
---> main.main(-1)
+--> main.main(n=-1)
 	--> host_importer.call_host_div(-1)
 		==> host.div(-1)
---> main.main(0)
+--> main.main(n=0)
 	--> host_importer.call_host_div(0)
 		==> host.div(0)
 `, "\n"+buf.String())
@@ -0,0 +1,323 @@
+package adhoc
+
+import (
+	"context"
+	_ "embed"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/experimental/exception"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
+)
+
+// proposalFeatures enables the proposals of proposalTests, which both the
+// interpreter and the compiler implement.
+const proposalFeatures = api.CoreFeaturesV2 | experimental.CoreFeaturesMemory64 | experimental.CoreFeaturesThreads |
+	experimental.CoreFeaturesTailCall | experimental.CoreFeaturesFunctionReferences | experimental.CoreFeaturesExtendedConst
+
+var proposalTests = map[string]testCase{
+	"memory64":            {f: testMemory64},
+	"threads":             {f: testThreads},
+	"tail call":           {f: testTailCall},
+	"function references": {f: testFunctionReferences},
+	"extended const":      {f: testExtendedConst},
+}
+
+func TestProposalsCompiler(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+	runAllTests(t, proposalTests, wazero.NewRuntimeConfigCompiler().WithCoreFeatures(proposalFeatures), false)
+}
+
+func TestProposalsInterpreter(t *testing.T) {
+	runAllTests(t, proposalTests, wazero.NewRuntimeConfigInterpreter().WithCoreFeatures(proposalFeatures), false)
+}
+
+func TestProposalsTiered(t *testing.T) {
+	runAllTests(t, proposalTests, wazero.NewRuntimeConfigTiered().WithCoreFeatures(proposalFeatures), false)
+}
+
+// exceptionHandlingFeatures enables the exception handling proposal, which
+// only the interpreter implements.
+const exceptionHandlingFeatures = api.CoreFeaturesV2 | experimental.CoreFeaturesExceptionHandling
+
+func TestExceptionHandlingCompiler(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+	r := wazero.NewRuntimeWithConfig(testCtx, wazero.NewRuntimeConfigCompiler().WithCoreFeatures(exceptionHandlingFeatures))
+	defer r.Close(testCtx)
+
+	_, err := r.CompileModule(testCtx, exceptionHandlingWasm)
+	require.EqualError(t, err, "exception handling is only supported by the interpreter")
+}
+
+func TestExceptionHandlingInterpreter(t *testing.T) {
+	testExceptionHandling(t, wazero.NewRuntimeWithConfig(testCtx, wazero.NewRuntimeConfigInterpreter().WithCoreFeatures(exceptionHandlingFeatures)))
+}
+
+func TestExceptionHandlingTiered(t *testing.T) {
+	// Modules the compiler can't compile are interpreted.
+	testExceptionHandling(t, wazero.NewRuntimeWithConfig(testCtx, wazero.NewRuntimeConfigTiered().WithCoreFeatures(exceptionHandlingFeatures)))
+}
+
+var (
+	//go:embed testdata/memory64.wasm
+	memory64Wasm []byte
+	//go:embed testdata/threads_memory.wasm
+	threadsMemoryWasm []byte
+	//go:embed testdata/threads.wasm
+	threadsWasm []byte
+	//go:embed testdata/tail_call.wasm
+	tailCallWasm []byte
+	//go:embed testdata/exception_handling.wasm
+	exceptionHandlingWasm []byte
+	//go:embed testdata/function_references.wasm
+	functionReferencesWasm []byte
+	//go:embed testdata/extended_const_base.wasm
+	extendedConstBaseWasm []byte
+	//go:embed testdata/extended_const.wasm
+	extendedConstWasm []byte
+)
+
+func testMemory64(t *testing.T, r wazero.Runtime) {
+	defer r.Close(testCtx)
+
+	mod, err := r.Instantiate(testCtx, memory64Wasm)
+	require.NoError(t, err)
+
+	const pageSize = uint64(wasm.MemoryPageSize)
+	call := func(name string, params ...uint64) ([]uint64, error) {
+		return mod.ExportedFunction(name).Call(testCtx, params...)
+	}
+	requireOutOfBounds := func(name string, params ...uint64) {
+		_, err := call(name, params...)
+		require.ErrorIs(t, err, wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+	}
+
+	results, err := call("load", 7)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{'a'}, results)
+
+	_, err = call("store", pageSize-1, 'z')
+	require.NoError(t, err)
+	results, err = call("load", pageSize-2)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{'z'}, results)
+
+	results, err = call("size")
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1}, results)
+	results, err = call("grow", 1)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1}, results)
+	results, err = call("grow", math.MaxUint32+1)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{math.MaxUint64}, results)
+	results, err = call("grow", 2)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{math.MaxUint64}, results)
+	results, err = call("size")
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2}, results)
+
+	_, err = call("fill", 100, 'x', 2)
+	require.NoError(t, err)
+	_, err = call("copy", 2*pageSize-2, 100, 2)
+	require.NoError(t, err)
+	results, err = call("load", 2*pageSize-2)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{'x'}, results)
+
+	requireOutOfBounds("load", 2*pageSize-1)
+	requireOutOfBounds("load", 1<<32)
+	// The address plus the offset overflows.
+	requireOutOfBounds("load", math.MaxUint64)
+	requireOutOfBounds("store", 1<<32, 'z')
+	requireOutOfBounds("fill", 1<<32, 'x', 1)
+	requireOutOfBounds("fill", 1, 'x', math.MaxUint64)
+	requireOutOfBounds("copy", 0, 1<<32, 1)
+	requireOutOfBounds("copy", 1, 0, math.MaxUint64)
+	require.Equal(t, uint32(2*wasm.MemoryPageSize), mod.Memory().Size())
+}
+
+func testThreads(t *testing.T, r wazero.Runtime) {
+	defer r.Close(testCtx)
+
+	_, err := r.InstantiateWithConfig(testCtx, threadsMemoryWasm, wazero.NewModuleConfig().WithName("env"))
+	require.NoError(t, err)
+	compiled, err := r.CompileModule(testCtx, threadsWasm)
+	require.NoError(t, err)
+
+	// Each thread is an instance importing the shared memory.
+	const threads, adds = 4, 1000
+	mods := make([]api.Module, threads)
+	for i := range mods {
+		mods[i], err = r.InstantiateModule(testCtx, compiled, wazero.NewModuleConfig().WithName(""))
+		require.NoError(t, err)
+	}
+	call := func(mod api.Module, name string, params ...uint64) uint64 {
+		results, err := mod.ExportedFunction(name).Call(testCtx, params...)
+		require.NoError(t, err)
+		return results[0]
+	}
+
+	var wg sync.WaitGroup
+	for _, mod := range mods {
+		wg.Add(1)
+		go func(mod api.Module) {
+			defer wg.Done()
+			for i := 0; i < adds; i++ {
+				if _, err := mod.ExportedFunction("add").Call(testCtx, 8, 1); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(mod)
+	}
+	wg.Wait()
+	require.Equal(t, uint64(threads*adds), call(mods[0], "load", 8))
+
+	require.Equal(t, uint64(0), call(mods[0], "cmpxchg", 16, 0x100, 'a'))
+	require.Equal(t, uint64('a'), call(mods[1], "cmpxchg", 16, 'b', 'c'))
+	require.Equal(t, uint64('a'), call(mods[1], "load", 16))
+
+	// The value doesn't equal the expected one.
+	require.Equal(t, uint64(1), call(mods[0], "wait", 24, 1, math.MaxUint64))
+	// The timeout elapses.
+	require.Equal(t, uint64(2), call(mods[0], "wait", 24, 0, uint64(time.Millisecond)))
+	require.Equal(t, uint64(0), call(mods[1], "notify", 24, 1))
+
+	woken := make(chan []uint64)
+	go func() {
+		results, err := mods[0].ExportedFunction("wait").Call(testCtx, 24, 0, math.MaxUint64)
+		if err != nil {
+			t.Error(err)
+		}
+		woken <- results
+	}()
+	for call(mods[1], "notify", 24, 1) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	require.Equal(t, []uint64{0}, <-woken)
+
+	_, err = mods[0].ExportedFunction("add").Call(testCtx, 9, 1)
+	require.ErrorIs(t, err, wasmruntime.ErrRuntimeUnalignedAtomic)
+	_, err = mods[0].ExportedFunction("load").Call(testCtx, uint64(wasm.MemoryPageSize))
+	require.ErrorIs(t, err, wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+}
+
+func testTailCall(t *testing.T, r wazero.Runtime) {
+	defer r.Close(testCtx)
+
+	_, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().WithFunc(func(x uint32) uint32 { return x + 1 }).Export("inc").
+		Instantiate(testCtx)
+	require.NoError(t, err)
+	mod, err := r.Instantiate(testCtx, tailCallWasm)
+	require.NoError(t, err)
+
+	call := func(name string, params ...uint64) []uint64 {
+		results, err := mod.ExportedFunction(name).Call(testCtx, params...)
+		require.NoError(t, err)
+		return results
+	}
+
+	// The calls are deeper than the call stack allows, unless frames are reused.
+	const depth = 1_000_000
+	require.Equal(t, []uint64{depth + 1}, call("count", depth, 1))
+	require.Equal(t, []uint64{1}, call("even", depth))
+	require.Equal(t, []uint64{0}, call("even", depth+1))
+	require.Equal(t, []uint64{0, 42}, call("pair", depth))
+	require.Equal(t, []uint64{42}, call("inc", 41))
+}
+
+func testExceptionHandling(t *testing.T, r wazero.Runtime) {
+	defer r.Close(testCtx)
+
+	_, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().WithGoModuleFunction(api.GoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
+		tag, ok := exception.ExportedTag(mod, "error")
+		require.True(t, ok)
+		exception.Throw(tag, stack[0]*10)
+	}), []api.ValueType{api.ValueTypeI32}, nil).Export("fail").
+		Instantiate(testCtx)
+	require.NoError(t, err)
+	mod, err := r.Instantiate(testCtx, exceptionHandlingWasm)
+	require.NoError(t, err)
+
+	call := func(name string, params ...uint64) []uint64 {
+		results, err := mod.ExportedFunction(name).Call(testCtx, params...)
+		require.NoError(t, err)
+		return results
+	}
+
+	require.Equal(t, []uint64{8}, call("try", 7))
+	require.Equal(t, []uint64{14}, call("try_table", 7))
+	require.Equal(t, []uint64{10}, call("throw_ref", 7))
+	require.Equal(t, []uint64{11}, call("rethrow", 7))
+	require.Equal(t, []uint64{12}, call("delegate", 7))
+	require.Equal(t, []uint64{70}, call("host", 7))
+
+	// An uncaught exception fails the call.
+	_, err = mod.ExportedFunction("throw").Call(testCtx, 7)
+	e, ok := exception.Of(err)
+	require.True(t, ok)
+	tag, _ := exception.ExportedTag(mod, "error")
+	require.Equal(t, tag, e.Tag())
+	require.Equal(t, []uint64{7}, e.Payload())
+}
+
+func testFunctionReferences(t *testing.T, r wazero.Runtime) {
+	defer r.Close(testCtx)
+
+	mod, err := r.Instantiate(testCtx, functionReferencesWasm)
+	require.NoError(t, err)
+
+	call := func(name string, params ...uint64) []uint64 {
+		results, err := mod.ExportedFunction(name).Call(testCtx, params...)
+		require.NoError(t, err)
+		return results
+	}
+
+	require.Equal(t, []uint64{8}, call("call_inc", 7))
+	require.Equal(t, []uint64{8}, call("tail_inc", 7))
+	require.Equal(t, []uint64{1}, call("is_null", 0))
+	require.Equal(t, []uint64{0}, call("is_null", 7))
+	require.Equal(t, []uint64{8}, call("call_non_null", 7))
+	require.Equal(t, []uint64{math.MaxUint32}, call("call_non_null", 0))
+	require.Equal(t, []uint64{7}, call("as_non_null", 7))
+
+	_, err = mod.ExportedFunction("call_null").Call(testCtx, 7)
+	require.ErrorIs(t, err, wasmruntime.ErrRuntimeNullFunctionReference)
+	_, err = mod.ExportedFunction("as_non_null").Call(testCtx, 0)
+	require.ErrorIs(t, err, wasmruntime.ErrRuntimeNullReference)
+}
+
+func testExtendedConst(t *testing.T, r wazero.Runtime) {
+	defer r.Close(testCtx)
+
+	_, err := r.InstantiateWithConfig(testCtx, extendedConstBaseWasm, wazero.NewModuleConfig().WithName("env"))
+	require.NoError(t, err)
+	mod, err := r.Instantiate(testCtx, extendedConstWasm)
+	require.NoError(t, err)
+
+	require.Equal(t, uint64(2048), mod.ExportedGlobal("g32").Get())
+	require.Equal(t, uint64(math.MaxUint64-1), mod.ExportedGlobal("g64").Get())
+	data, ok := mod.Memory().Read(1028, 2)
+	require.True(t, ok)
+	require.Equal(t, "hi", string(data))
+
+	results, err := mod.ExportedFunction("call").Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{42}, results)
+}
@@ -0,0 +1,64 @@
+;; exception_handling imports "fail" from "env", which throws an exception of
+;; the exported tag "error", and exports functions which throw and catch them.
+(module
+  (import "env" "fail" (func $fail (param i32)))
+  (tag $error (export "error") (param i32))
+
+  ;; throw throws its parameter.
+  (func $throw (export "throw") (param i32) (result i32)
+    (throw $error (local.get 0)))
+
+  ;; try returns its parameter plus 1, catching it from throw.
+  (func (export "try") (param i32) (result i32)
+    (try (result i32)
+      (do (i32.add (i32.const 9) (call $throw (local.get 0))))
+      (catch $error (i32.add (i32.const 1)))))
+
+  ;; try_table returns its parameter times 2, catching it in a block.
+  (func (export "try_table") (param i32) (result i32)
+    (block $caught (result i32)
+      (try_table (catch $error $caught)
+        (throw $error (local.get 0)))
+      (return (i32.const 0)))
+    (i32.mul (i32.const 2)))
+
+  ;; throw_ref returns its parameter plus 3, catching the exnref of the
+  ;; exception from throw, and throwing it again.
+  (func (export "throw_ref") (param i32) (result i32)
+    (block $caught (result i32)
+      (try_table (catch $error $caught)
+        (block $ref (result exnref)
+          (try_table (catch_all_ref $ref)
+            (drop (call $throw (local.get 0))))
+          (unreachable))
+        (throw_ref))
+      (unreachable))
+    (i32.add (i32.const 3)))
+
+  ;; rethrow returns its parameter plus 4, throwing it again from catch_all.
+  (func (export "rethrow") (param i32) (result i32)
+    (try (result i32)
+      (do
+        (try
+          (do (throw $error (local.get 0)))
+          (catch_all (rethrow 0)))
+        (i32.const 0))
+      (catch $error (i32.add (i32.const 4)))))
+
+  ;; delegate returns its parameter plus 5, delegating it past a block.
+  (func (export "delegate") (param i32) (result i32)
+    (try (result i32)
+      (do
+        (block
+          (try
+            (do (throw $error (local.get 0)))
+            (delegate 1)))
+        (i32.const 0))
+      (catch $error (i32.add (i32.const 5)))))
+
+  ;; host returns the payload of the exception thrown by fail.
+  (func (export "host") (param i32) (result i32)
+    (try (result i32)
+      (do (call $fail (local.get 0)) (i32.const 0))
+      (catch $error)))
+)
@@ -0,0 +1,19 @@
+;; extended_const imports the global of extended_const_base, and uses it in
+;; extended constant expressions: its globals, and the offsets of its element
+;; and data segments.
+(module
+  (type $v_i32 (func (result i32)))
+  (import "env" "base" (global $base i32))
+  (table 4 funcref)
+  (memory (export "memory") 1)
+
+  (elem (offset (i32.sub (global.get $base) (i32.const 1021))) $answer)
+  (data (offset (i32.add (global.get $base) (i32.const 4))) "hi")
+  (global (export "g32") i32 (i32.mul (global.get $base) (i32.const 2)))
+  (global (export "g64") i64 (i64.sub (i64.const 5) (i64.const 7)))
+
+  (func $answer (type $v_i32)
+    (i32.const 42))
+  (func (export "call") (type $v_i32)
+    (call_indirect (type $v_i32) (i32.const 3)))
+)
@@ -0,0 +1,4 @@
+;; extended_const_base exports the global "base" of 1024.
+(module
+  (global (export "base") i32 (i32.const 1024))
+)
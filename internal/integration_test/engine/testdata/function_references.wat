@@ -0,0 +1,49 @@
+;; function_references exports functions which call "inc" through typed
+;; function references, or branch on whether they are null.
+(module
+  (type $i32_i32 (func (param i32) (result i32)))
+  (table 1 funcref)
+  ;; The element declares inc, so that ref.func can refer to it.
+  (elem (i32.const 0) $inc)
+
+  (func $inc (type $i32_i32)
+    (i32.add (local.get 0) (i32.const 1)))
+  (func (export "call_inc") (type $i32_i32)
+    (call_ref $i32_i32 (local.get 0) (ref.func $inc)))
+  (func (export "tail_inc") (type $i32_i32)
+    (return_call_ref $i32_i32 (local.get 0) (ref.func $inc)))
+
+  ;; call_null calls a null reference of the type of inc, so traps.
+  (func (export "call_null") (type $i32_i32)
+    (call_ref $i32_i32 (local.get 0) (ref.null $i32_i32)))
+
+  ;; is_null returns 1 if its parameter is zero, as it selects a null
+  ;; `(ref null $i32_i32)` then.
+  (func (export "is_null") (type $i32_i32)
+    (block $null (result i32)
+      (i32.const 1)
+      (select (result (ref null $i32_i32)) (ref.func $inc) (ref.null $i32_i32) (local.get 0))
+      (br_on_null $null)
+      (drop)
+      (drop)
+      (i32.const 0)))
+
+  ;; call_non_null calls inc with its parameter, unless it is zero, as the
+  ;; reference is null then, so it returns -1.
+  (func (export "call_non_null") (type $i32_i32) (local funcref)
+    (block $non_null (result (ref $i32_i32))
+      (if (result (ref null $i32_i32)) (local.get 0)
+        (then (ref.func $inc))
+        (else (ref.null $i32_i32)))
+      (br_on_non_null $non_null)
+      (return (i32.const -1)))
+    (local.set 1)
+    (call_ref $i32_i32 (local.get 0) (local.get 1)))
+
+  ;; as_non_null returns its parameter, unless it is zero, as it traps on a
+  ;; null reference then.
+  (func (export "as_non_null") (type $i32_i32)
+    (drop (ref.as_non_null
+      (select (result funcref) (ref.func $inc) (ref.null func) (local.get 0))))
+    (local.get 0))
+)
@@ -0,0 +1,20 @@
+;; memory64 has a 64-bit memory of 1 to 3 pages, with "abc" at address 8, and
+;; exports a function for each instruction using it.
+(module
+  (memory i64 1 3)
+  (data (i64.const 8) "abc")
+
+  ;; load returns the byte at its parameter + 1, to cover the offset.
+  (func (export "load") (param i64) (result i32)
+    (i32.load8_u offset=1 (local.get 0)))
+  (func (export "store") (param i64 i32)
+    (i32.store8 (local.get 0) (local.get 1)))
+  (func (export "size") (result i64)
+    (memory.size))
+  (func (export "grow") (param i64) (result i64)
+    (memory.grow (local.get 0)))
+  (func (export "fill") (param i64 i32 i64)
+    (memory.fill (local.get 0) (local.get 1) (local.get 2)))
+  (func (export "copy") (param i64 i64 i64)
+    (memory.copy (local.get 0) (local.get 1) (local.get 2)))
+)
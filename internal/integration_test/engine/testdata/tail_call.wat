@@ -0,0 +1,49 @@
+;; tail_call imports "inc" from "env", and exports functions which make tail
+;; calls, between functions with different parameters and results.
+(module
+  (type $i32_i32 (func (param i32) (result i32)))
+  (import "env" "inc" (func $host_inc (type $i32_i32)))
+  (table 1 funcref)
+  (elem (i32.const 0) $even)
+
+  ;; count returns its second parameter plus the first.
+  (func $count (export "count") (param i64 i64) (result i64)
+    (if (i64.eqz (local.get 0)) (then (return (local.get 1))))
+    (return_call $count
+      (i64.sub (local.get 0) (i64.const 1))
+      (i64.add (local.get 1) (i64.const 1))))
+
+  ;; even returns 1 if its parameter is even, calling odd, which has more
+  ;; parameters, and is called with a local.
+  (func $even (export "even") (type $i32_i32) (local i64)
+    (if (i32.eqz (local.get 0)) (then (return (i32.const 1))))
+    (local.set 1 (i64.const 9))
+    (return_call $odd
+      (i32.sub (local.get 0) (i32.const 1))
+      (i64.const 7)
+      (local.get 1)))
+
+  ;; odd returns 1 if its first parameter is odd, and traps unless the others
+  ;; are 7 and 9, calling even through the table.
+  (func $odd (param i32 i64 i64) (result i32)
+    (if (i32.eqz (local.get 0)) (then (return (i32.const 0))))
+    (if (i64.ne (local.get 1) (i64.const 7)) (then (unreachable)))
+    (if (i64.ne (local.get 2) (i64.const 9)) (then (unreachable)))
+    (return_call_indirect (type $i32_i32)
+      (i32.sub (local.get 0) (i32.const 1))
+      (i32.const 0)))
+
+  ;; inc calls the host function.
+  (func (export "inc") (type $i32_i32)
+    (return_call $host_inc (local.get 0)))
+
+  ;; pair returns 0 and 42 after calling pair3 its parameter times.
+  (func $pair (export "pair") (param i64) (result i64 i64)
+    (if (i64.eqz (local.get 0)) (then (return (local.get 0) (i64.const 42))))
+    (return_call $pair3
+      (i64.sub (local.get 0) (i64.const 1))
+      (i64.const 1)
+      (i64.const 2)))
+  (func $pair3 (param i64 i64 i64) (result i64 i64)
+    (return_call $pair (local.get 0)))
+)
@@ -0,0 +1,17 @@
+;; threads imports a shared memory, and exports a function for some of the
+;; atomic instructions.
+(module
+  (import "env" "memory" (memory 1 1 shared))
+
+  (func (export "add") (param i32 i32) (result i32)
+    (i32.atomic.rmw.add (local.get 0) (local.get 1)))
+  (func (export "load") (param i32) (result i64)
+    (atomic.fence)
+    (i64.atomic.load (local.get 0)))
+  (func (export "cmpxchg") (param i32 i32 i32) (result i32)
+    (i32.atomic.rmw8.cmpxchg_u (local.get 0) (local.get 1) (local.get 2)))
+  (func (export "wait") (param i32 i32 i64) (result i32)
+    (memory.atomic.wait32 (local.get 0) (local.get 1) (local.get 2)))
+  (func (export "notify") (param i32 i32) (result i32)
+    (memory.atomic.notify (local.get 0) (local.get 1)))
+)
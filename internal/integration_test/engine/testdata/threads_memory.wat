@@ -0,0 +1,4 @@
+;; threads_memory exports a shared memory of one page.
+(module
+  (memory (export "memory") 1 1 shared)
+)
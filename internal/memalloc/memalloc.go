@@ -0,0 +1,7 @@
+// Package memalloc contains internal symbols shared between the wazero and
+// experimental packages, to allocate linear memory.
+package memalloc
+
+// AllocatorKey is a context.Context Value key. Its associated value should be
+// an experimental.MemoryAllocator.
+type AllocatorKey struct{}
@@ -0,0 +1,96 @@
+// Package perfmap writes the perf map of the machine code compiled from Wasm
+// functions, so that `perf report` on Linux attributes the samples in it to
+// the function names instead of an anonymous memory mapping.
+//
+// This is enabled by setting the environment variable WAZERO_PERFMAP to 1,
+// in which case the functions of each compiled module are appended to
+// /tmp/perf-<pid>.map. See
+// https://github.com/torvalds/linux/blob/v6.5/tools/perf/Documentation/jit-interface.txt
+package perfmap
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// EnvKey is the environment variable which enables the perf map when "1".
+const EnvKey = "WAZERO_PERFMAP"
+
+// Enabled is true if the perf map is written, which is only on Linux.
+var Enabled = runtime.GOOS == "linux" && os.Getenv(EnvKey) == "1"
+
+var (
+	mux sync.Mutex
+	// out is the perf map opened on the first AddModule, or nil if it
+	// failed to open.
+	out    io.Writer
+	opened bool
+)
+
+// AddModule appends the functions of a compiled module to the perf map if
+// Enabled. The functions start at `offsets` from `addr`, and span up to the
+// next one or `size`. name returns the name of the function at offsets[i].
+//
+// Errors are ignored, as the perf map only helps profiling. Addresses of a
+// module which is released may be reused by another one, in which case perf
+// uses the latest entry.
+func AddModule(addr uintptr, size int, offsets []int, name func(i int) string) {
+	if !Enabled || addr == 0 || len(offsets) == 0 {
+		return
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+	if !opened {
+		opened = true
+		path := fmt.Sprintf("/tmp/perf-%d.map", os.Getpid())
+		if f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644); err == nil {
+			out = f
+		}
+	}
+	if out != nil {
+		_, _ = out.Write(format(addr, size, offsets, name))
+	}
+}
+
+// format returns the lines of the perf map for AddModule, which are
+// "START SIZE NAME" with START and SIZE in hex.
+func format(addr uintptr, size int, offsets []int, name func(i int) string) []byte {
+	// The offsets aren't necessarily sorted, e.g. when functions are compiled
+	// in random order, so sort their indexes to find the end of each.
+	indexes := make([]int, len(offsets))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool { return offsets[indexes[i]] < offsets[indexes[j]] })
+
+	var buf bytes.Buffer
+	for k, i := range indexes {
+		end := size
+		if k+1 < len(indexes) {
+			end = offsets[indexes[k+1]]
+		}
+		if end <= offsets[i] {
+			continue // Empty, so perf never attributes a sample to it.
+		}
+		fmt.Fprintf(&buf, "%x %x %s\n", addr+uintptr(offsets[i]), end-offsets[i], sanitize(name(i)))
+	}
+	return buf.Bytes()
+}
+
+// sanitize replaces the control characters of name, which may be arbitrary
+// in the name section, so that it stays on one line of the perf map.
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r < ' ' || r == 0x7f {
+			return '?'
+		}
+		return r
+	}, name)
+}
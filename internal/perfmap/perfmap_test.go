@@ -0,0 +1,73 @@
+package perfmap
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestAddModule(t *testing.T) {
+	defer func(enabled bool) {
+		Enabled, out, opened = enabled, nil, false
+	}(Enabled)
+
+	var buf bytes.Buffer
+	Enabled, out, opened = true, &buf, true
+
+	name := func(i int) string { return fmt.Sprintf("mod.f%d", i) }
+	AddModule(0x1000, 0x80, []int{0, 0x30}, name)
+	AddModule(0x2000, 0x40, []int{0x20, 0}, name)
+	require.Equal(t, `1000 30 mod.f0
+1030 50 mod.f1
+2000 20 mod.f1
+2020 20 mod.f0
+`, buf.String())
+
+	// Nothing is written when disabled.
+	buf.Reset()
+	Enabled = false
+	AddModule(0x1000, 0x80, []int{0, 0x30}, name)
+	require.Equal(t, "", buf.String())
+}
+
+func Test_format(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		offsets  []int
+		names    []string
+		expected string
+	}{
+		{
+			name:     "one",
+			size:     0x10,
+			offsets:  []int{0},
+			names:    []string{"a"},
+			expected: "100 10 a\n",
+		},
+		{
+			name:     "empty function",
+			size:     0x20,
+			offsets:  []int{0, 0, 0x10},
+			names:    []string{"a", "b", "c"},
+			expected: "100 10 b\n110 10 c\n",
+		},
+		{
+			name:     "control characters",
+			size:     0x10,
+			offsets:  []int{0},
+			names:    []string{"a\nb\tc d"},
+			expected: "100 10 a?b?c d\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			actual := format(0x100, tc.size, tc.offsets, func(i int) string { return tc.names[i] })
+			require.Equal(t, tc.expected, string(actual))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package platform
+
+// CopyOnWriteImage is a copy of a linear memory, which memories reserved by
+// MmapLinearMemory can map copy-on-write, so that they share its pages until
+// written instead of copying it.
+type CopyOnWriteImage struct {
+	fd   int
+	size int
+}
+
+// NewCopyOnWriteImage returns an image with a copy of `b`, whose length must
+// be a multiple of the page size.
+//
+// This returns an error on platforms without support, in which case callers
+// should copy the memory instead. The image is released when garbage
+// collected or closed, but memories mapping it keep its pages.
+func NewCopyOnWriteImage(b []byte) (*CopyOnWriteImage, error) {
+	if len(b) == 0 {
+		panic("BUG: NewCopyOnWriteImage with zero length")
+	}
+	return newCopyOnWriteImage(b)
+}
+
+// Len returns the size of the image in bytes.
+func (img *CopyOnWriteImage) Len() int {
+	return img.size
+}
+
+// MapInto maps the image copy-on-write over the start of `b`, which must be
+// the start of a region of memory reserved by MmapLinearMemory and at least
+// as long as the image. Bytes in `b` after the image are unchanged.
+func (img *CopyOnWriteImage) MapInto(b []byte) error {
+	if len(b) < img.size {
+		panic("BUG: MapInto with a region shorter than the image")
+	}
+	return img.mapInto(b)
+}
+
+// Dirty returns true when any page of `b`, which was mapped with MapInto,
+// was written since, or that can't be determined.
+func (img *CopyOnWriteImage) Dirty(b []byte) bool {
+	if len(b) != img.size {
+		return true
+	}
+	return img.dirty(b)
+}
+
+// Close releases the image. Memories already mapping it are unaffected.
+func (img *CopyOnWriteImage) Close() error {
+	return img.close()
+}
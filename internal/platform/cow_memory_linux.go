@@ -0,0 +1,97 @@
+//go:build amd64 || arm64
+
+package platform
+
+import (
+	"encoding/binary"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// mfdCloexec is MFD_CLOEXEC of memfd_create, so that the image isn't leaked
+// to child processes.
+const mfdCloexec = 0x1
+
+func newCopyOnWriteImage(b []byte) (*CopyOnWriteImage, error) {
+	name := [...]byte{'w', 'a', 'z', 'e', 'r', 'o', 0}
+	fd, _, e1 := syscall.Syscall(sysMemfdCreate, uintptr(unsafe.Pointer(&name[0])), mfdCloexec, 0)
+	if e1 != 0 {
+		return nil, e1
+	}
+	img := &CopyOnWriteImage{fd: int(fd), size: len(b)}
+	runtime.SetFinalizer(img, (*CopyOnWriteImage).close)
+
+	for written := 0; written < len(b); {
+		n, err := syscall.Write(img.fd, b[written:])
+		if err != nil {
+			_ = img.close()
+			return nil, err
+		}
+		written += n
+	}
+	return img, nil
+}
+
+func (img *CopyOnWriteImage) mapInto(b []byte) error {
+	const prot = syscall.PROT_READ | syscall.PROT_WRITE
+	const flags = syscall.MAP_PRIVATE | syscall.MAP_FIXED
+	_, _, e1 := syscall.Syscall6(syscall.SYS_MMAP, uintptr(unsafe.Pointer(&b[0])), uintptr(img.size), prot, flags, uintptr(img.fd), 0)
+	runtime.KeepAlive(img)
+	if e1 != 0 {
+		return e1
+	}
+	return nil
+}
+
+// Bits of a /proc/self/pagemap entry, documented in
+// https://www.kernel.org/doc/Documentation/vm/pagemap.txt
+const (
+	pagemapFile    = 1 << 61
+	pagemapSwapped = 1 << 62
+	pagemapPresent = 1 << 63
+)
+
+// dirty reads the pagemap of `b`: pages written since mapInto are anonymous
+// copies, while the others are still pages of the image file.
+func (img *CopyOnWriteImage) dirty(b []byte) bool {
+	f, err := os.Open("/proc/self/pagemap")
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+
+	pageSize := uintptr(syscall.Getpagesize())
+	start := uintptr(unsafe.Pointer(&b[0])) / pageSize
+	pages := (uintptr(len(b)) + pageSize - 1) / pageSize
+
+	buf := make([]byte, 8*1024)
+	for i := uintptr(0); i < pages; {
+		n := pages - i
+		if max := uintptr(len(buf) / 8); n > max {
+			n = max
+		}
+		if _, err = f.ReadAt(buf[:n*8], int64((start+i)*8)); err != nil {
+			return true
+		}
+		for j := uintptr(0); j < n; j++ {
+			entry := binary.LittleEndian.Uint64(buf[j*8:])
+			if entry&(pagemapPresent|pagemapSwapped) != 0 && entry&pagemapFile == 0 {
+				return true
+			}
+		}
+		i += n
+	}
+	return false
+}
+
+func (img *CopyOnWriteImage) close() error {
+	if img.fd < 0 {
+		return nil
+	}
+	runtime.SetFinalizer(img, nil)
+	err := syscall.Close(img.fd)
+	img.fd = -1
+	return err
+}
@@ -0,0 +1,4 @@
+package platform
+
+// sysMemfdCreate is the memfd_create syscall, missing in package syscall.
+const sysMemfdCreate = 319
@@ -0,0 +1,62 @@
+package platform
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestCopyOnWriteImage(t *testing.T) {
+	if runtime.GOOS != "linux" || !CompilerSupported() {
+		t.Skip()
+	}
+
+	const size = 1 << 20
+	src, err := MmapLinearMemory(size, 0)
+	require.NoError(t, err)
+	defer MunmapLinearMemory(src)
+	require.NoError(t, CommitLinearMemory(src))
+	src[0], src[size-1] = 1, 2
+
+	img, err := NewCopyOnWriteImage(src)
+	require.NoError(t, err)
+	defer img.Close()
+	require.Equal(t, size, img.Len())
+
+	// Remapping the source keeps its contents.
+	require.NoError(t, img.MapInto(src))
+	require.False(t, img.Dirty(src))
+	require.Equal(t, byte(1), src[0])
+	require.False(t, img.Dirty(src)) // reads don't copy.
+
+	dst, err := MmapLinearMemory(2*size, 0)
+	require.NoError(t, err)
+	defer MunmapLinearMemory(dst)
+	require.NoError(t, CommitLinearMemory(dst))
+	dst[size] = 3
+
+	require.NoError(t, img.MapInto(dst))
+	require.Equal(t, byte(1), dst[0])
+	require.Equal(t, byte(2), dst[size-1])
+	require.Equal(t, byte(3), dst[size]) // after the image.
+
+	// Writes are private.
+	dst[0] = 4
+	require.Equal(t, byte(1), src[0])
+	require.True(t, img.Dirty(dst[:size]))
+	require.False(t, img.Dirty(src))
+
+	src[1] = 5
+	require.Equal(t, byte(0), dst[1])
+	require.True(t, img.Dirty(src))
+
+	require.True(t, img.Dirty(src[:size/2])) // different length.
+
+	t.Run("panic on zero length", func(t *testing.T) {
+		captured := require.CapturePanic(func() {
+			_, _ = NewCopyOnWriteImage(nil)
+		})
+		require.EqualError(t, captured, "BUG: NewCopyOnWriteImage with zero length")
+	})
+}
@@ -0,0 +1,26 @@
+//go:build !linux || !(amd64 || arm64)
+
+package platform
+
+import (
+	"fmt"
+	"runtime"
+)
+
+var errCopyOnWriteUnsupported = fmt.Errorf("copy-on-write memory unsupported on GOOS=%s GOARCH=%s", runtime.GOOS, runtime.GOARCH)
+
+func newCopyOnWriteImage([]byte) (*CopyOnWriteImage, error) {
+	return nil, errCopyOnWriteUnsupported
+}
+
+func (img *CopyOnWriteImage) mapInto([]byte) error {
+	return errCopyOnWriteUnsupported
+}
+
+func (img *CopyOnWriteImage) dirty([]byte) bool {
+	return true
+}
+
+func (img *CopyOnWriteImage) close() error {
+	return nil
+}
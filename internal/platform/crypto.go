@@ -1,6 +1,7 @@
 package platform
 
 import (
+	"hash/fnv"
 	"io"
 	"math/rand"
 )
@@ -15,3 +16,12 @@ const seed = int64(42)
 func NewFakeRandSource() io.Reader {
 	return rand.New(rand.NewSource(seed))
 }
+
+// NewSeededRandSource returns a deterministic source of random values for the
+// module named `moduleName`, keyed by `seed`. Modules with different names
+// read different values, even when they share the same seed.
+func NewSeededRandSource(seed int64, moduleName string) io.Reader {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(moduleName))
+	return rand.New(rand.NewSource(seed ^ int64(h.Sum64())))
+}
@@ -0,0 +1,29 @@
+package platform
+
+// MmapLinearMemory reserves the address space of a linear memory of up to
+// `reserve` bytes, followed by `guard` bytes which are never accessible. The
+// returned slice covers the reservation and guard, and none of it is
+// accessible until committed with CommitLinearMemory.
+//
+// This returns an error on platforms without mmap, in which case callers
+// should allocate the memory on the heap instead.
+func MmapLinearMemory(reserve, guard int) ([]byte, error) {
+	if reserve+guard == 0 {
+		panic("BUG: MmapLinearMemory with zero length")
+	}
+	return mmapLinearMemory(reserve + guard)
+}
+
+// CommitLinearMemory makes the given region of memory reserved by
+// MmapLinearMemory readable and writable. Newly committed bytes are zero.
+func CommitLinearMemory(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+	return commitLinearMemory(b)
+}
+
+// MunmapLinearMemory releases memory returned by MmapLinearMemory.
+func MunmapLinearMemory(b []byte) error {
+	return munmapLinearMemory(b)
+}
@@ -0,0 +1,37 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func Test_MmapLinearMemory(t *testing.T) {
+	if !CompilerSupported() {
+		t.Skip()
+	}
+
+	const reserve, guard = 1 << 20, 1 << 16
+	b, err := MmapLinearMemory(reserve, guard)
+	require.NoError(t, err)
+	require.Equal(t, reserve+guard, len(b))
+
+	require.NoError(t, CommitLinearMemory(b[:4096]))
+	require.NoError(t, CommitLinearMemory(b[4096:4096])) // empty
+	b[4095] = 1
+	require.Equal(t, byte(1), b[4095])
+
+	// Committing more pages keeps the existing ones.
+	require.NoError(t, CommitLinearMemory(b[4096:reserve]))
+	require.Equal(t, byte(1), b[4095])
+	require.Equal(t, byte(0), b[reserve-1])
+
+	require.NoError(t, MunmapLinearMemory(b))
+
+	t.Run("panic on zero length", func(t *testing.T) {
+		captured := require.CapturePanic(func() {
+			_, _ = MmapLinearMemory(0, 0)
+		})
+		require.EqualError(t, captured, "BUG: MmapLinearMemory with zero length")
+	})
+}
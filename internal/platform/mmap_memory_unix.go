@@ -0,0 +1,28 @@
+//go:build darwin || linux || freebsd
+
+package platform
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func mmapLinearMemory(size int) ([]byte, error) {
+	// Reserve address space only: pages are backed when committed.
+	return syscall.Mmap(-1, 0, size, syscall.PROT_NONE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+}
+
+// commitLinearMemory is like syscall.Mprotect with RW permission, defined
+// locally so that freebsd compiles.
+func commitLinearMemory(b []byte) (err error) {
+	const prot = syscall.PROT_READ | syscall.PROT_WRITE
+	_, _, e1 := syscall.Syscall(syscall.SYS_MPROTECT, uintptr(unsafe.Pointer(&b[0])), uintptr(len(b)), uintptr(prot))
+	if e1 != 0 {
+		err = syscall.Errno(e1)
+	}
+	return
+}
+
+func munmapLinearMemory(b []byte) error {
+	return syscall.Munmap(b)
+}
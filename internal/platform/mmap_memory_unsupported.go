@@ -0,0 +1,15 @@
+//go:build !(darwin || linux || freebsd || windows)
+
+package platform
+
+func mmapLinearMemory(int) ([]byte, error) {
+	return nil, errUnsupported
+}
+
+func commitLinearMemory([]byte) error {
+	return errUnsupported
+}
+
+func munmapLinearMemory([]byte) error {
+	return errUnsupported
+}
@@ -0,0 +1,39 @@
+package platform
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+const (
+	windows_MEM_RESERVE   uintptr = 0x00002000
+	windows_PAGE_NOACCESS uintptr = 0x00000001
+)
+
+func mmapLinearMemory(size int) ([]byte, error) {
+	// Reserve address space only: pages are backed when committed.
+	p, _, err := procVirtualAlloc.Call(0, uintptr(size), windows_MEM_RESERVE, windows_PAGE_NOACCESS)
+	if p == 0 {
+		return nil, fmt.Errorf("VirtualAlloc error: %w", ensureErr(err))
+	}
+
+	var mem []byte
+	sh := (*reflect.SliceHeader)(unsafe.Pointer(&mem))
+	sh.Data = p
+	sh.Len = size
+	sh.Cap = size
+	return mem, nil
+}
+
+func commitLinearMemory(b []byte) error {
+	address := uintptr(unsafe.Pointer(&b[0]))
+	if r, _, err := procVirtualAlloc.Call(address, uintptr(len(b)), windows_MEM_COMMIT, windows_PAGE_READWRITE); r == 0 {
+		return fmt.Errorf("VirtualAlloc error: %w", ensureErr(err))
+	}
+	return nil
+}
+
+func munmapLinearMemory(b []byte) error {
+	return freeMemory(b)
+}
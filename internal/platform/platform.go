@@ -22,13 +22,23 @@ var archRequirementsVerified bool
 
 // CompilerSupported is exported for tests and includes constraints here and also the assembler.
 func CompilerSupported() bool {
-	switch runtime.GOOS {
+	if !CompilerTargetSupported(runtime.GOOS, runtime.GOARCH) {
+		return false
+	}
+
+	return archRequirementsVerified
+}
+
+// CompilerTargetSupported returns true if the compiler can generate code for
+// the given GOOS and GOARCH, regardless of the current platform.
+func CompilerTargetSupported(goos, goarch string) bool {
+	switch goos {
 	case "darwin", "windows", "linux", "freebsd":
 	default:
 		return false
 	}
 
-	return archRequirementsVerified
+	return goarch == "amd64" || goarch == "arm64"
 }
 
 // MmapCodeSegment copies the code into the executable region and returns the byte slice of the region.
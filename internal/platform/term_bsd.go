@@ -0,0 +1,10 @@
+//go:build darwin || freebsd
+
+package platform
+
+import "syscall"
+
+const (
+	ioctlGetTermios = syscall.TIOCGETA
+	ioctlSetTermios = syscall.TIOCSETA
+)
@@ -0,0 +1,54 @@
+//go:build darwin || linux || freebsd
+
+package platform
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// MakeRaw puts the terminal at file descriptor `fd` into raw mode, returning
+// a function which restores its previous state. This returns syscall.ENOTTY if
+// `fd` is not a terminal.
+//
+// In raw mode, input is available a byte at a time, without echo or line
+// editing, and without signals for keys such as Ctrl-C. Output processing is
+// also disabled, so "\n" doesn't imply "\r". This is the same as cfmakeraw(3).
+func MakeRaw(fd uintptr) (restore func() error, err error) {
+	var old syscall.Termios
+	if err = ioctlTermios(fd, ioctlGetTermios, &old); err != nil {
+		return nil, err
+	}
+
+	raw := old
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	// Reads return as soon as a byte is available.
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+
+	if err = ioctlTermios(fd, ioctlSetTermios, &raw); err != nil {
+		return nil, err
+	}
+	return func() error {
+		return ioctlTermios(fd, ioctlSetTermios, &old)
+	}, nil
+}
+
+// IsNotTerminal returns true if `err` from MakeRaw means that the terminal
+// mode is left as is, because the file isn't a terminal. Callers usually
+// ignore this error, as only a terminal has a mode to change.
+func IsNotTerminal(err error) bool {
+	return err == syscall.ENOTTY
+}
+
+func ioctlTermios(fd, req uintptr, termios *syscall.Termios) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(unsafe.Pointer(termios))); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+//go:build darwin || linux || freebsd
+
+package platform
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestMakeRaw_notTerminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+
+	_, err = MakeRaw(r.Fd())
+	require.Equal(t, syscall.ENOTTY, err)
+	require.True(t, IsNotTerminal(err))
+}
@@ -0,0 +1,24 @@
+//go:build !(darwin || linux || freebsd)
+
+package platform
+
+import (
+	"errors"
+	"runtime"
+)
+
+// errRawUnsupported is returned by MakeRaw, as it's not yet supported on this
+// platform.
+var errRawUnsupported = errors.New("raw terminal mode unsupported on GOOS=" + runtime.GOOS)
+
+// MakeRaw is not yet supported on this platform, so leaves the terminal as is
+// and returns an error for which IsNotTerminal is true.
+func MakeRaw(uintptr) (restore func() error, err error) {
+	return nil, errRawUnsupported
+}
+
+// IsNotTerminal returns true if `err` from MakeRaw means that the terminal
+// mode is left as is, which is always the case on this platform.
+func IsNotTerminal(err error) bool {
+	return err == errRawUnsupported
+}
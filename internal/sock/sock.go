@@ -26,6 +26,22 @@ type TCPConn interface {
 	Shutdown(how int) sys.Errno
 }
 
+// UDPConn is a pseudo-file representing a UDP socket, which is connected if
+// created by sock_connect. Read and Write are only valid when connected.
+type UDPConn interface {
+	sys.File
+
+	// Network returns "udp4" or "udp6".
+	Network() string
+
+	// ReadFrom reads a datagram into `p`, returning its source address. Any
+	// remainder of a datagram larger than `p` is discarded.
+	ReadFrom(p []byte) (n int, addr *net.UDPAddr, errno sys.Errno)
+
+	// WriteTo writes `p` as a datagram to `addr`.
+	WriteTo(p []byte, addr *net.UDPAddr) (n int, errno sys.Errno)
+}
+
 // ConfigKey is a context.Context Value key. Its associated value should be a Config.
 type ConfigKey struct{}
 
@@ -34,8 +50,21 @@ type ConfigKey struct{}
 type Config struct {
 	// TCPAddresses is a slice of the configured host:port pairs.
 	TCPAddresses []TCPAddress
+
+	// TCPListeners are listeners opened by the host, pre-opened after any
+	// TCPAddresses.
+	TCPListeners []net.Listener
+
+	// Permit is non-nil when the guest may open its own sockets, and decides
+	// which addresses they may bind or connect to.
+	Permit Permit
 }
 
+// Permit decides if a socket opened by the guest may perform `op`, which is
+// "bind", "connect" or "send_to", given a network such as "tcp4" and a
+// "host:port" address.
+type Permit func(op, network, address string) bool
+
 // TCPAddress is a host:port pair to pre-open.
 type TCPAddress struct {
 	// Host is the host name for this listener.
@@ -54,15 +83,37 @@ func (c *Config) WithTCPListener(host string, port int) *Config {
 	return &ret
 }
 
+// WithListener implements the method of the same name in experimental/sock/Config.
+func (c *Config) WithListener(ln net.Listener) *Config {
+	ret := c.clone()
+	ret.TCPListeners = append(ret.TCPListeners, ln)
+	return &ret
+}
+
+// WithSockOpen implements the method of the same name in experimental/sock/Config.
+func (c *Config) WithSockOpen(permit Permit) *Config {
+	ret := c.clone()
+	if permit == nil {
+		permit = func(op, network, address string) bool { return true }
+	}
+	ret.Permit = permit
+	return &ret
+}
+
 // Makes a deep copy of this sockConfig.
 func (c *Config) clone() Config {
 	ret := *c
 	ret.TCPAddresses = make([]TCPAddress, 0, len(c.TCPAddresses))
 	ret.TCPAddresses = append(ret.TCPAddresses, c.TCPAddresses...)
+	ret.TCPListeners = make([]net.Listener, 0, len(c.TCPListeners))
+	ret.TCPListeners = append(ret.TCPListeners, c.TCPListeners...)
 	return ret
 }
 
 // BuildTCPListeners build listeners from the current configuration.
+//
+// Note: Listeners from WithListener are returned as-is, so are not closed on
+// error.
 func (c *Config) BuildTCPListeners() (tcpListeners []*net.TCPListener, err error) {
 	for _, tcpAddr := range c.TCPAddresses {
 		var ln net.Listener
@@ -79,7 +130,17 @@ func (c *Config) BuildTCPListeners() (tcpListeners []*net.TCPListener, err error
 		for _, l := range tcpListeners {
 			_ = l.Close() // Ignore errors, we are already cleaning.
 		}
-		tcpListeners = nil
+		return nil, err
+	}
+	for _, ln := range c.TCPListeners {
+		tcpln, ok := ln.(*net.TCPListener)
+		if !ok {
+			for _, l := range tcpListeners[:len(c.TCPAddresses)] {
+				_ = l.Close() // Ignore errors, we are already cleaning.
+			}
+			return nil, fmt.Errorf("unsupported listener %T: only *net.TCPListener is supported", ln)
+		}
+		tcpListeners = append(tcpListeners, tcpln)
 	}
 	return
 }
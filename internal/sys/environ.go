@@ -0,0 +1,45 @@
+package sys
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+)
+
+// EnvironProviderKey is a context.Context Value key. Its associated value
+// should be an *EnvironProvider.
+type EnvironProviderKey struct{}
+
+// EnvironProvider resolves environment variables on first use, so that they
+// are the same for the rest of the calls with the same context.Context.
+type EnvironProvider struct {
+	// Provider returns "key=value" entries like os.Environ.
+	Provider func(ctx context.Context) []string
+
+	once        sync.Once
+	environ     [][]byte
+	environSize uint32
+	err         error
+}
+
+// Environ returns the result of Provider and its size as null-terminated
+// strings, or an error if an entry is invalid.
+func (p *EnvironProvider) Environ(ctx context.Context) ([][]byte, uint32, error) {
+	p.once.Do(func() {
+		entries := p.Provider(ctx)
+		environ := make([][]byte, 0, len(entries))
+		for _, e := range entries {
+			if !strings.Contains(e, "=") {
+				p.err = errors.New("environ invalid: entry doesn't contain '=' character")
+				return
+			}
+			environ = append(environ, []byte(e))
+		}
+		if p.environSize, p.err = nullTerminatedByteCount(math.MaxUint32, environ); p.err == nil {
+			p.environ = environ
+		}
+	})
+	return p.environ, p.environSize, p.err
+}
@@ -4,6 +4,9 @@ import (
 	"io"
 	"io/fs"
 	"net"
+	"path"
+	"sort"
+	"strings"
 
 	"github.com/tetratelabs/wazero/experimental/sys"
 	"github.com/tetratelabs/wazero/internal/descriptor"
@@ -53,8 +56,36 @@ type FileEntry struct {
 	// File is always non-nil.
 	File fsapi.File
 
+	// Rights are nil unless restricted by FSContext.RestrictPreopens, which
+	// also restricts files opened from pre-opened directories.
+	Rights *Rights
+
 	// direntCache is nil until DirentCache was called.
 	direntCache *DirentCache
+
+	// dups is the count of file descriptors, besides the first, which refer
+	// to this entry via FSContext.Dup. File is closed when the last is.
+	dups uint32
+
+	// lock is the advisory lock held via FSContext.Lock.
+	lock sysfs.LockType
+}
+
+// isPreopenDir returns true if this is a pre-opened directory. Unlike other
+// files, including stdio, these keep their file descriptor, as guests find
+// them by it, e.g. with fd_prestat_get in WASI.
+func (f *FileEntry) isPreopenDir() bool {
+	return f.IsPreopen && f.FS != nil
+}
+
+// release drops a file descriptor's reference to this entry, closing File when
+// it was the last.
+func (f *FileEntry) release() sys.Errno {
+	if f.dups > 0 {
+		f.dups--
+		return 0
+	}
+	return f.File.Close()
 }
 
 // DirentCache gets or creates a DirentCache for this file or returns an error.
@@ -158,8 +189,8 @@ var exhaustedDirents = [0]sys.Dirent{}
 //
 // When non-zero, `pos` is the zero based index of all dirents returned since
 // last rewind. Only entries beginning at `pos` are cached for subsequent
-// calls. A non-zero `pos` before the cache returns sys.ENOENT for reasons
-// described on DirentCache documentation.
+// calls. A non-zero `pos` before the cache rewinds the underlying sys.File and
+// skips entries until `pos`, without caching them.
 //
 // Up to `n` entries are cached and returned. When `n` exceeds the cache, the
 // difference are read from the underlying sys.File via `Readdir`. EOF is
@@ -204,14 +235,16 @@ func (d *DirentCache) Read(pos uint64, n uint32) (dirents []sys.Dirent, errno sy
 	// Reset our cache to the first entry being read.
 	cacheStart := d.countRead - uint64(len(d.dirents))
 	if pos < cacheStart {
-		// We don't currently allow reads before our cache because Seek(0) is
-		// the only portable way. Doing otherwise requires skipping, which we
-		// won't do unless wasi-testsuite starts requiring it. Implementing
-		// this would allow re-reading a large directory, so care would be
-		// needed to not buffer the entire directory in memory while skipping.
-		errno = sys.ENOENT
-		return
-	} else if posInCache := pos - cacheStart; posInCache != 0 {
+		// Seek(0) is the only portable way to move backwards, so rewind and
+		// skip to the position. This happens when a guest restarts iteration
+		// from a cookie it saved, such as via telldir and seekdir.
+		if errno = d.skipTo(pos); errno != 0 {
+			return
+		}
+		cacheStart = d.countRead - uint64(len(d.dirents))
+	}
+
+	if posInCache := pos - cacheStart; posInCache != 0 {
 		if uint64(len(d.dirents)) == posInCache {
 			// Avoid allocation re-slicing to zero length.
 			d.dirents = exhaustedDirents[:]
@@ -238,6 +271,48 @@ func (d *DirentCache) Read(pos uint64, n uint32) (dirents []sys.Dirent, errno sy
 	return d.cachedDirents(n), 0
 }
 
+// direntSkipBatch is the maximum count of dirents read at a time by skipTo,
+// which avoids buffering large directories in memory while skipping.
+const direntSkipBatch = 64
+
+// skipTo rewinds the underlying sys.File and reads, without caching, the
+// dirents before `pos`. The dot entries are cached when `pos` is less than
+// two, as they are never read from the file.
+//
+// If the directory shrunk since it was last read, `pos` is left at EOF.
+func (d *DirentCache) skipTo(pos uint64) sys.Errno {
+	if _, errno := d.f.Seek(0, io.SeekStart); errno != 0 {
+		return errno
+	}
+	d.dirents = d.dotEntries
+	d.countRead = 2
+	d.eof = false
+
+	if pos < d.countRead {
+		return 0
+	}
+
+	d.dirents = exhaustedDirents[:]
+	for d.countRead < pos {
+		countToRead := pos - d.countRead
+		if countToRead > direntSkipBatch {
+			countToRead = direntSkipBatch
+		}
+		dirents, errno := d.f.Readdir(int(countToRead))
+		if errno != 0 {
+			return errno
+		}
+		if uint64(len(dirents)) < countToRead {
+			// Treat the missing entries as read, so `pos` is at EOF.
+			d.countRead = pos
+			d.eof = true
+			break
+		}
+		d.countRead += countToRead
+	}
+	return 0
+}
+
 // cachedDirents returns up to `n` dirents from the cache.
 func (d *DirentCache) cachedDirents(n uint32) []sys.Dirent {
 	direntCount := uint32(len(d.dirents))
@@ -254,10 +329,18 @@ type FSContext struct {
 	// rootFS is the root ("/") mount.
 	rootFS sys.FS
 
+	// mounts are the pre-opened filesystems, ordered by descending length of
+	// their guest path. This allows ResolveFS to return the first match as
+	// the longest prefix.
+	mounts []mount
+
 	// openedFiles is a map of file descriptor numbers (>=FdPreopen) to open files
 	// (or directories) and defaults to empty.
 	// TODO: This is unguarded, so not goroutine-safe!
 	openedFiles FileTable
+
+	// sockPermit is non-nil when SockOpen is allowed. See AllowSockOpen.
+	sockPermit socketapi.Permit
 }
 
 // FileTable is a specialization of the descriptor.Table type used to map file
@@ -277,6 +360,61 @@ func (c *FSContext) RootFS() sys.FS {
 	}
 }
 
+// mount is a pre-opened filesystem and its guest path.
+type mount struct {
+	// guestPath is the guest path without leading or trailing slashes, so
+	// empty for the root mount.
+	guestPath string
+	fs        sys.FS
+
+	// readOnly is true when the filesystem was mounted read-only, via
+	// sysfs.ReadFS. Mutations return sys.EROFS before reaching it.
+	//
+	// Note: The filesystem may be wrapped, for example by sysfs.ListenerFS,
+	// so isn't necessarily a sysfs.ReadFS.
+	readOnly bool
+}
+
+// isReadOnly returns true if `fsys` is mounted read-only.
+func (c *FSContext) isReadOnly(fsys sys.FS) bool {
+	for i := range c.mounts {
+		// Check readOnly first, as only read-only mounts are known comparable.
+		if m := &c.mounts[i]; m.readOnly && m.fs == fsys {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveFS returns the filesystem mounted at the longest prefix of the
+// absolute guest `path`, and the path relative to that filesystem. If no
+// mount matches, this returns sys.UnimplementedFS.
+//
+// For example, if "/tmp" and "/" were pre-opened, "/tmp/foo" resolves to the
+// filesystem mounted at "/tmp" and the path "/foo".
+//
+// Note: This is only used by ABI that resolve absolute paths on the host,
+// such as GOOS=js. WASI resolves pre-opens in the guest.
+func (c *FSContext) ResolveFS(path string) (sys.FS, string) {
+	cleaned := path
+	for len(cleaned) > 0 && cleaned[0] == '/' {
+		cleaned = cleaned[1:]
+	}
+	for i := range c.mounts {
+		m := &c.mounts[i]
+		if m.guestPath == "" {
+			return m.fs, path
+		} else if !strings.HasPrefix(cleaned, m.guestPath) {
+			continue
+		} else if rest := cleaned[len(m.guestPath):]; rest == "" {
+			return m.fs, "/"
+		} else if rest[0] == '/' {
+			return m.fs, rest
+		}
+	}
+	return sys.UnimplementedFS{}, path
+}
+
 // LookupFile returns a file if it is in the table.
 func (c *FSContext) LookupFile(fd int32) (*FileEntry, bool) {
 	return c.openedFiles.Lookup(fd)
@@ -284,7 +422,16 @@ func (c *FSContext) LookupFile(fd int32) (*FileEntry, bool) {
 
 // OpenFile opens the file into the table and returns its file descriptor.
 // The result must be closed by CloseFile or Close.
+//
+// Opening a file for write, or with sys.O_TRUNC, on a read-only mount returns
+// sys.EROFS.
 func (c *FSContext) OpenFile(fs sys.FS, path string, flag sys.Oflag, perm fs.FileMode) (int32, sys.Errno) {
+	if c.isReadOnly(fs) && flag&(sys.O_WRONLY|sys.O_RDWR|sys.O_TRUNC) != 0 {
+		if flag&sys.O_DIRECTORY != 0 {
+			return 0, sys.EISDIR
+		}
+		return 0, sys.EROFS
+	}
 	if f, errno := fs.OpenFile(path, flag, perm); errno != 0 {
 		return 0, errno
 	} else {
@@ -302,13 +449,183 @@ func (c *FSContext) OpenFile(fs sys.FS, path string, flag sys.Oflag, perm fs.Fil
 	}
 }
 
+// Rename renames `oldPath` in `oldFS` to `newPath` in `newFS`.
+//
+// When the filesystems differ, a regular file is moved by copying it, then
+// unlinking the original, similar to mv(1). Moving other types of files
+// across filesystems returns sys.ENOSYS.
+//
+// Renaming to or from a read-only mount returns sys.EROFS.
+func (c *FSContext) Rename(oldFS sys.FS, oldPath string, newFS sys.FS, newPath string) sys.Errno {
+	if c.isReadOnly(oldFS) || c.isReadOnly(newFS) {
+		return sys.EROFS
+	} else if oldFS == newFS {
+		return oldFS.Rename(oldPath, newPath)
+	}
+
+	st, errno := oldFS.Lstat(oldPath)
+	if errno != 0 {
+		return errno
+	} else if !st.Mode.IsRegular() { // TODO: handle directories across filesystems
+		return sys.ENOSYS
+	} else if newSt, errno := newFS.Stat(newPath); errno == 0 && newSt.Mode.IsDir() {
+		return sys.EISDIR
+	}
+
+	if errno = copyFile(oldFS, oldPath, newFS, newPath); errno != 0 {
+		return errno
+	}
+	return oldFS.Unlink(oldPath)
+}
+
+// copyFile copies the contents, permissions and timestamps of a regular file,
+// replacing any file at `newPath`. On error, the partial copy is removed.
+func copyFile(oldFS sys.FS, oldPath string, newFS sys.FS, newPath string) (errno sys.Errno) {
+	src, errno := oldFS.OpenFile(oldPath, sys.O_RDONLY, 0)
+	if errno != 0 {
+		return errno
+	}
+	defer src.Close()
+
+	st, errno := src.Stat()
+	if errno != 0 {
+		return errno
+	}
+
+	dst, errno := newFS.OpenFile(newPath, sys.O_WRONLY|sys.O_CREAT|sys.O_TRUNC, st.Mode.Perm())
+	if errno != 0 {
+		return errno
+	}
+	defer func() {
+		if closeErrno := dst.Close(); errno == 0 {
+			errno = closeErrno
+		}
+		if errno != 0 {
+			_ = newFS.Unlink(newPath)
+		}
+	}()
+
+	buf := make([]byte, 32*1024)
+	for {
+		var n int
+		if n, errno = src.Read(buf); errno != 0 {
+			return
+		} else if n == 0 {
+			break
+		} else if _, errno = dst.Write(buf[:n]); errno != 0 {
+			return
+		}
+	}
+	return dst.Utimens(st.Atim, st.Mtim)
+}
+
+// maxSymlinks is the maximum symbolic links followed by Link, matching
+// MAXSYMLINKS on Linux.
+const maxSymlinks = 40
+
+// Symlink creates a symbolic link named `linkName` in the filesystem, whose
+// contents are `target`. The target isn't required to exist.
+//
+// This returns sys.EPERM if `target` is absolute, or would resolve outside
+// the filesystem from the directory containing `linkName`. Otherwise, a
+// filesystem such as sysfs.DirFS could follow it to a path outside the host
+// directory mounted. A read-only mount returns sys.EROFS.
+func (c *FSContext) Symlink(fsys sys.FS, target, linkName string) sys.Errno {
+	if c.isReadOnly(fsys) {
+		return sys.EROFS
+	} else if escapes(linkName, target) {
+		return sys.EPERM
+	}
+	return fsys.Symlink(target, linkName)
+}
+
+// Link creates a hard link named `newPath` in `newFS` to the file at
+// `oldPath` in `oldFS`. When `follow` is true and `oldPath` is a symbolic
+// link, this links its target instead, like linkat with AT_SYMLINK_FOLLOW.
+//
+// Notes:
+//   - Symbolic links that resolve outside `oldFS` return sys.EPERM, and more
+//     than 40 links return sys.ELOOP.
+//   - Linking across filesystems is not supported, so returns sys.ENOSYS.
+//   - Linking into a read-only mount returns sys.EROFS.
+func (c *FSContext) Link(oldFS sys.FS, oldPath string, newFS sys.FS, newPath string, follow bool) sys.Errno {
+	if c.isReadOnly(newFS) {
+		return sys.EROFS
+	} else if oldFS != newFS { // TODO: handle link across filesystems
+		return sys.ENOSYS
+	}
+
+	if follow {
+		var errno sys.Errno
+		if oldPath, errno = followSymlinks(oldFS, oldPath); errno != 0 {
+			return errno
+		}
+	}
+	return oldFS.Link(oldPath, newPath)
+}
+
+// Utimens sets the access and modification times of the file at `path` in
+// `fsys`. When `follow` is false and `path` is a symbolic link, the link
+// itself is updated, like utimensat with AT_SYMLINK_NOFOLLOW.
+//
+// Notes:
+//   - Either time can be sys.UTIME_OMIT to retain it.
+//   - Updating a symbolic link returns sys.ENOSYS when unsupported by `fsys`
+//     or the host, for example on Windows.
+//   - A read-only mount returns sys.EROFS.
+func (c *FSContext) Utimens(fsys sys.FS, path string, atim, mtim int64, follow bool) sys.Errno {
+	if c.isReadOnly(fsys) {
+		return sys.EROFS
+	} else if follow {
+		return fsys.Utimens(path, atim, mtim)
+	}
+	return sysfs.Lutimens(fsys, path, atim, mtim)
+}
+
+// followSymlinks returns the path after following any symbolic links at the
+// last path component.
+func followSymlinks(fsys sys.FS, p string) (string, sys.Errno) {
+	for i := 0; i <= maxSymlinks; i++ {
+		if st, errno := fsys.Lstat(p); errno != 0 {
+			return "", errno
+		} else if st.Mode&fs.ModeSymlink == 0 {
+			return p, 0
+		}
+
+		target, errno := fsys.Readlink(p)
+		if errno != 0 {
+			return "", errno
+		} else if escapes(p, target) {
+			return "", sys.EPERM
+		}
+		p = path.Join(path.Dir(strings.TrimLeft(p, "/")), target)
+	}
+	return "", sys.ELOOP
+}
+
+// escapes returns true if the symbolic link `target` at `linkName` is
+// absolute or resolves above the root of its filesystem.
+func escapes(linkName, target string) bool {
+	if path.IsAbs(target) {
+		return true
+	}
+	dir := path.Dir(strings.TrimLeft(linkName, "/"))
+	return !fs.ValidPath(path.Join(dir, target))
+}
+
 // Renumber assigns the file pointed by the descriptor `from` to `to`.
+//
+// Combined with Dup, this implements POSIX dup2: Dup `from`, then Renumber
+// the result to `to`, e.g. to redirect stdout to a file. Pre-opened
+// directories can't be renumbered or replaced.
 func (c *FSContext) Renumber(from, to int32) sys.Errno {
 	fromFile, ok := c.openedFiles.Lookup(from)
 	if !ok || to < 0 {
 		return sys.EBADF
-	} else if fromFile.IsPreopen {
+	} else if fromFile.isPreopenDir() {
 		return sys.ENOTSUP
+	} else if from == to {
+		return 0 // Like dup2, this is a no-op.
 	}
 
 	// If toFile is already open, we close it to prevent windows lock issues.
@@ -317,10 +634,10 @@ func (c *FSContext) Renumber(from, to int32) sys.Errno {
 	// https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-fd_renumberfd-fd-to-fd---errno
 	// https://github.com/bytecodealliance/wasmtime/blob/main/crates/wasi-common/src/snapshots/preview_1.rs#L531-L546
 	if toFile, ok := c.openedFiles.Lookup(to); ok {
-		if toFile.IsPreopen {
+		if toFile.isPreopenDir() {
 			return sys.ENOTSUP
 		}
-		_ = toFile.File.Close()
+		_ = toFile.release()
 	}
 
 	c.openedFiles.Delete(from)
@@ -330,12 +647,33 @@ func (c *FSContext) Renumber(from, to int32) sys.Errno {
 	return 0
 }
 
+// Dup assigns the file pointed by the descriptor `fd` to the lowest available
+// descriptor, and returns it. Both descriptors share the same file, including
+// its offset and flags, which is closed when the last descriptor is. Stdio can
+// be duplicated, e.g. to restore it after redirecting it, but pre-opened
+// directories can't.
+func (c *FSContext) Dup(fd int32) (int32, sys.Errno) {
+	f, ok := c.openedFiles.Lookup(fd)
+	if !ok {
+		return 0, sys.EBADF
+	} else if f.isPreopenDir() {
+		return 0, sys.ENOTSUP
+	}
+
+	if newFD, ok := c.openedFiles.Insert(f); !ok {
+		return 0, sys.EBADF
+	} else {
+		f.dups++
+		return newFD, 0
+	}
+}
+
 // SockAccept accepts a sock.TCPConn into the file table and returns its file
 // descriptor.
 func (c *FSContext) SockAccept(sockFD int32, nonblock bool) (int32, sys.Errno) {
 	var sock socketapi.TCPSock
-	if e, ok := c.LookupFile(sockFD); !ok || !e.IsPreopen {
-		return 0, sys.EBADF // Not a preopen
+	if e, ok := c.LookupFile(sockFD); !ok {
+		return 0, sys.EBADF // Not open
 	} else if sock, ok = e.File.(socketapi.TCPSock); !ok {
 		return 0, sys.EBADF // Not a sock
 	}
@@ -361,13 +699,208 @@ func (c *FSContext) SockAccept(sockFD int32, nonblock bool) (int32, sys.Errno) {
 	}
 }
 
+// AllowSockOpen allows SockOpen, where `permit` decides which addresses
+// SockBind and SockConnect may use.
+func (c *FSContext) AllowSockOpen(permit socketapi.Permit) {
+	c.sockPermit = permit
+}
+
+// SockOpen inserts a socket for the `network` "tcp4", "tcp6", "udp4" or
+// "udp6" into the file table and returns its file descriptor. The socket is a
+// sysfs.OpenedSock until SockListen or SockConnect, or for UDP, SockBind.
+//
+// This returns sys.ENOTSUP unless AllowSockOpen was called.
+func (c *FSContext) SockOpen(network string) (int32, sys.Errno) {
+	if c.sockPermit == nil {
+		return 0, sys.ENOTSUP
+	}
+	if newFD, ok := c.openedFiles.Insert(&FileEntry{File: &sysfs.OpenedSock{Network: network}}); !ok {
+		return 0, sys.EBADF
+	} else {
+		return newFD, 0
+	}
+}
+
+// SockBind sets the local "host:port" `address` of a socket from SockOpen.
+func (c *FSContext) SockBind(sockFD int32, address string) sys.Errno {
+	sock, errno := c.lookupOpenedSock(sockFD)
+	if errno != 0 {
+		return errno
+	} else if sock.Addr != "" {
+		return sys.EINVAL // Already bound
+	} else if !c.sockPermit("bind", sock.Network, address) {
+		return sys.EACCES
+	} else if !isUDP(sock.Network) {
+		sock.Addr = address
+		return 0
+	}
+	laddr, err := net.ResolveUDPAddr(sock.Network, address)
+	if err != nil {
+		return sys.EINVAL
+	}
+	uc, err := net.ListenUDP(sock.Network, laddr)
+	if err != nil {
+		return sys.UnwrapOSError(err)
+	}
+	return c.replaceSock(sockFD, sysfs.NewUDPConnFile(sock.Network, uc))
+}
+
+// SockListen replaces a bound socket from SockOpen with a listener, which
+// can then be used with SockAccept.
+//
+// Note: `backlog` is ignored, as Go uses the system default.
+func (c *FSContext) SockListen(sockFD int32, backlog int) sys.Errno {
+	sock, errno := c.lookupOpenedSock(sockFD)
+	if errno != 0 {
+		return errno
+	} else if isUDP(sock.Network) {
+		return sys.ENOTSUP
+	} else if sock.Addr == "" {
+		return sys.EINVAL // Go doesn't listen on an ephemeral port when unbound.
+	}
+	ln, err := net.Listen(sock.Network, sock.Addr)
+	if err != nil {
+		return sys.UnwrapOSError(err)
+	}
+	return c.replaceSock(sockFD, sysfs.NewTCPListenerFile(ln.(*net.TCPListener)))
+}
+
+// SockConnect replaces a socket from SockOpen with a connection to the
+// "host:port" `address`, which can then be used with sock_send and sock_recv.
+//
+// Note: A UDP socket can't be connected after SockBind, as it is already
+// open.
+func (c *FSContext) SockConnect(sockFD int32, address string) sys.Errno {
+	sock, errno := c.lookupOpenedSock(sockFD)
+	if errno != 0 {
+		return errno
+	} else if !c.sockPermit("connect", sock.Network, address) {
+		return sys.EACCES
+	}
+	if isUDP(sock.Network) {
+		raddr, err := net.ResolveUDPAddr(sock.Network, address)
+		if err != nil {
+			return sys.EINVAL
+		}
+		uc, err := net.DialUDP(sock.Network, nil, raddr)
+		if err != nil {
+			return sys.UnwrapOSError(err)
+		}
+		return c.replaceSock(sockFD, sysfs.NewUDPConnFile(sock.Network, uc))
+	}
+	var dialer net.Dialer
+	if sock.Addr != "" {
+		laddr, err := net.ResolveTCPAddr(sock.Network, sock.Addr)
+		if err != nil {
+			return sys.EINVAL
+		}
+		dialer.LocalAddr = laddr
+	}
+	conn, err := dialer.Dial(sock.Network, address)
+	if err != nil {
+		return sys.UnwrapOSError(err)
+	}
+	return c.replaceSock(sockFD, sysfs.NewTCPConnFile(conn.(*net.TCPConn)))
+}
+
+// SockSendTo sends `p` as a datagram to the "host:port" `address` from a UDP
+// socket from SockOpen. An unbound socket is bound to an ephemeral port.
+func (c *FSContext) SockSendTo(sockFD int32, p []byte, address string) (int, sys.Errno) {
+	uc, network, errno := c.lookupUDPSock(sockFD)
+	if errno != 0 {
+		return 0, errno
+	} else if !c.sockPermit("send_to", network, address) {
+		return 0, sys.EACCES
+	}
+	raddr, err := net.ResolveUDPAddr(network, address)
+	if err != nil {
+		return 0, sys.EINVAL
+	}
+	return uc.WriteTo(p, raddr)
+}
+
+// SockRecvFrom receives a datagram into `p` from a UDP socket from SockOpen,
+// returning its source address. An unbound socket is bound to an ephemeral
+// port.
+func (c *FSContext) SockRecvFrom(sockFD int32, p []byte) (int, *net.UDPAddr, sys.Errno) {
+	uc, _, errno := c.lookupUDPSock(sockFD)
+	if errno != 0 {
+		return 0, nil, errno
+	}
+	return uc.ReadFrom(p)
+}
+
+// lookupUDPSock returns the UDP socket at `sockFD` and its network, opening
+// it if it is still a sysfs.OpenedSock.
+func (c *FSContext) lookupUDPSock(sockFD int32) (socketapi.UDPConn, string, sys.Errno) {
+	e, ok := c.LookupFile(sockFD)
+	if !ok {
+		return nil, "", sys.EBADF // Not open
+	}
+	switch f := e.File.(type) {
+	case socketapi.UDPConn:
+		return f, f.Network(), 0
+	case *sysfs.OpenedSock:
+		if !isUDP(f.Network) {
+			return nil, "", sys.ENOTSUP
+		}
+		uc, err := net.ListenUDP(f.Network, nil)
+		if err != nil {
+			return nil, "", sys.UnwrapOSError(err)
+		}
+		conn := sysfs.NewUDPConnFile(f.Network, uc)
+		if errno := c.replaceSock(sockFD, conn); errno != 0 {
+			return nil, "", errno
+		}
+		return conn, f.Network, 0
+	case socketapi.TCPSock, socketapi.TCPConn:
+		return nil, "", sys.ENOTSUP
+	default:
+		return nil, "", sys.ENOTSOCK
+	}
+}
+
+// isUDP returns true if `network` is "udp4" or "udp6".
+func isUDP(network string) bool {
+	return strings.HasPrefix(network, "udp")
+}
+
+// lookupOpenedSock returns the socket from SockOpen at `sockFD`, or
+// sys.EINVAL if it is already listening or connected.
+func (c *FSContext) lookupOpenedSock(sockFD int32) (*sysfs.OpenedSock, sys.Errno) {
+	e, ok := c.LookupFile(sockFD)
+	if !ok {
+		return nil, sys.EBADF // Not open
+	}
+	switch f := e.File.(type) {
+	case *sysfs.OpenedSock:
+		return f, 0
+	case socketapi.TCPSock, socketapi.TCPConn, socketapi.UDPConn:
+		return nil, sys.EINVAL // Already listening or connected
+	default:
+		return nil, sys.ENOTSOCK
+	}
+}
+
+// replaceSock replaces the socket from SockOpen at `sockFD` with `f`,
+// preserving its non-blocking mode.
+func (c *FSContext) replaceSock(sockFD int32, f sys.File) sys.Errno {
+	e, _ := c.LookupFile(sockFD)
+	nonblock := e.File.IsNonblock()
+	e.File = fsapi.Adapt(f)
+	if nonblock {
+		return e.File.SetNonblock(true)
+	}
+	return 0
+}
+
 // CloseFile returns any error closing the existing file.
 func (c *FSContext) CloseFile(fd int32) (errno sys.Errno) {
 	f, ok := c.openedFiles.Lookup(fd)
 	if !ok {
 		return sys.EBADF
 	}
-	if errno = f.File.Close(); errno != 0 {
+	if errno = f.release(); errno != 0 {
 		return errno
 	}
 	c.openedFiles.Delete(fd)
@@ -378,13 +911,14 @@ func (c *FSContext) CloseFile(fd int32) (errno sys.Errno) {
 func (c *FSContext) Close() (err error) {
 	// Close any files opened in this context
 	c.openedFiles.Range(func(fd int32, entry *FileEntry) bool {
-		if errno := entry.File.Close(); errno != 0 {
+		if errno := entry.release(); errno != 0 {
 			err = errno // This means err returned == the last non-nil error.
 		}
 		return true
 	})
 	// A closed FSContext cannot be reused so clear the state.
 	c.openedFiles = FileTable{}
+	c.mounts = nil
 	return
 }
 
@@ -415,11 +949,13 @@ func (c *Context) InitFSContext(
 	for i, fs := range fs {
 		guestPath := guestPaths[i]
 
-		if StripPrefixesAndTrailingSlash(guestPath) == "" {
+		cleaned := StripPrefixesAndTrailingSlash(guestPath)
+		if cleaned == "" {
 			// Default to bind to '/' when guestPath is effectively empty.
 			guestPath = "/"
 			c.fsc.rootFS = fs
 		}
+		c.fsc.mounts = append(c.fsc.mounts, mount{guestPath: cleaned, fs: fs, readOnly: sysfs.IsReadOnly(fs)})
 		c.fsc.openedFiles.Insert(&FileEntry{
 			FS:        fs,
 			Name:      guestPath,
@@ -428,6 +964,10 @@ func (c *Context) InitFSContext(
 		})
 	}
 
+	sort.SliceStable(c.fsc.mounts, func(i, j int) bool {
+		return len(c.fsc.mounts[i].guestPath) > len(c.fsc.mounts[j].guestPath)
+	})
+
 	for _, tl := range tcpListeners {
 		c.fsc.openedFiles.Insert(&FileEntry{IsPreopen: true, File: fsapi.Adapt(sysfs.NewTCPListenerFile(tl))})
 	}
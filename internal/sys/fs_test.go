@@ -1,12 +1,14 @@
 package sys
 
 import (
+	"bytes"
 	"embed"
 	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path"
+	"runtime"
 	"testing"
 	gofstest "testing/fstest"
 
@@ -129,6 +131,299 @@ func TestFSContext_CloseFile(t *testing.T) {
 	})
 }
 
+func TestFSContext_ResolveFS(t *testing.T) {
+	// Use different files, so that the filesystems are not equal.
+	rootFS := &sysfs.AdaptFS{FS: gofstest.MapFS{"root": &gofstest.MapFile{}}}
+	tmpFS := &sysfs.AdaptFS{FS: gofstest.MapFS{"tmp": &gofstest.MapFile{}}}
+	tmpSubFS := &sysfs.AdaptFS{FS: gofstest.MapFS{"sub": &gofstest.MapFile{}}}
+
+	c := Context{}
+	// Add the root first to ensure order of configuration doesn't matter.
+	err := c.InitFSContext(nil, nil, nil,
+		[]sys.FS{rootFS, tmpFS, tmpSubFS},
+		[]string{"/", "/tmp", "tmp/sub/"}, nil)
+	require.NoError(t, err)
+	fsc := c.fsc
+	defer fsc.Close()
+
+	tests := []struct {
+		path, expectedPath string
+		expectedFS         sys.FS
+	}{
+		{path: "/", expectedFS: rootFS, expectedPath: "/"},
+		{path: "/a/b", expectedFS: rootFS, expectedPath: "/a/b"},
+		{path: "/tmpfile", expectedFS: rootFS, expectedPath: "/tmpfile"},
+		{path: "/tmp", expectedFS: tmpFS, expectedPath: "/"},
+		{path: "/tmp/", expectedFS: tmpFS, expectedPath: "/"},
+		{path: "/tmp/a", expectedFS: tmpFS, expectedPath: "/a"},
+		{path: "/tmp/subdir", expectedFS: tmpFS, expectedPath: "/subdir"},
+		{path: "/tmp/sub", expectedFS: tmpSubFS, expectedPath: "/"},
+		{path: "/tmp/sub/a/", expectedFS: tmpSubFS, expectedPath: "/a/"},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.path, func(t *testing.T) {
+			fs, path := fsc.ResolveFS(tc.path)
+			require.Equal(t, tc.expectedFS, fs)
+			require.Equal(t, tc.expectedPath, path)
+		})
+	}
+
+	t.Run("no root", func(t *testing.T) {
+		c := Context{}
+		err := c.InitFSContext(nil, nil, nil, []sys.FS{tmpFS}, []string{"/tmp"}, nil)
+		require.NoError(t, err)
+		fsc := c.fsc
+		defer fsc.Close()
+
+		fs, path := fsc.ResolveFS("/a")
+		require.Equal(t, sys.UnimplementedFS{}, fs)
+		require.Equal(t, "/a", path)
+	})
+}
+
+func TestFSContext_Symlink(t *testing.T) {
+	c := &FSContext{}
+	testFS := sysfs.MemFS()
+	require.EqualErrno(t, 0, testFS.Mkdir("sub", 0o700))
+
+	tests := []struct {
+		name             string
+		target, linkName string
+		expected         sys.Errno
+	}{
+		{name: "same dir", target: "foo", linkName: "link"},
+		{name: "parent", target: "../foo", linkName: "sub/link"},
+		{name: "dot", target: ".", linkName: "sub/dot"},
+		{name: "escapes", target: "../foo", linkName: "link", expected: sys.EPERM},
+		{name: "escapes from sub", target: "../../foo", linkName: "sub/escape", expected: sys.EPERM},
+		{name: "absolute", target: "/etc/passwd", linkName: "link", expected: sys.EPERM},
+		{name: "exists", target: "foo", linkName: "sub", expected: sys.EEXIST},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			require.EqualErrno(t, tc.expected, c.Symlink(testFS, tc.target, tc.linkName))
+		})
+	}
+}
+
+func TestFSContext_Link(t *testing.T) {
+	c := &FSContext{}
+	testFS := sysfs.MemFS()
+	f, errno := testFS.OpenFile("file", sys.O_RDWR|sys.O_CREAT, 0o600)
+	require.EqualErrno(t, 0, errno)
+	require.EqualErrno(t, 0, f.Close())
+
+	require.EqualErrno(t, 0, testFS.Mkdir("sub", 0o700))
+	require.EqualErrno(t, 0, testFS.Symlink("../file", "sub/link"))
+	require.EqualErrno(t, 0, testFS.Symlink("sub/link", "link-link"))
+	require.EqualErrno(t, 0, testFS.Symlink("../../file", "sub/escape"))
+	require.EqualErrno(t, 0, testFS.Symlink("loop", "loop"))
+
+	t.Run("nofollow", func(t *testing.T) {
+		require.EqualErrno(t, 0, c.Link(testFS, "link-link", testFS, "nofollow", false))
+
+		st, errno := testFS.Lstat("nofollow")
+		require.EqualErrno(t, 0, errno)
+		require.Equal(t, fs.ModeSymlink, st.Mode.Type())
+	})
+
+	t.Run("follow", func(t *testing.T) {
+		require.EqualErrno(t, 0, c.Link(testFS, "link-link", testFS, "follow", true))
+
+		st, errno := testFS.Lstat("follow")
+		require.EqualErrno(t, 0, errno)
+		require.Zero(t, st.Mode.Type())
+		require.Equal(t, uint64(2), st.Nlink)
+	})
+
+	t.Run("follow escapes", func(t *testing.T) {
+		require.EqualErrno(t, sys.EPERM, c.Link(testFS, "sub/escape", testFS, "escape", true))
+	})
+
+	t.Run("follow loop", func(t *testing.T) {
+		require.EqualErrno(t, sys.ELOOP, c.Link(testFS, "loop", testFS, "loop2", true))
+	})
+
+	t.Run("across filesystems", func(t *testing.T) {
+		require.EqualErrno(t, sys.ENOSYS, c.Link(testFS, "file", sysfs.MemFS(), "file", false))
+	})
+}
+
+func TestFSContext_Utimens(t *testing.T) {
+	c := &FSContext{}
+	memFS := sysfs.MemFS()
+	f, errno := memFS.OpenFile("file", sys.O_RDWR|sys.O_CREAT, 0o600)
+	require.EqualErrno(t, 0, errno)
+	require.EqualErrno(t, 0, f.Close())
+	require.EqualErrno(t, 0, memFS.Mkdir("dir", 0o700))
+	require.EqualErrno(t, 0, memFS.Symlink("file", "link"))
+
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(path.Join(tmpDir, "file"), nil, 0o600))
+	require.NoError(t, os.Mkdir(path.Join(tmpDir, "dir"), 0o700))
+	require.NoError(t, os.Symlink("file", path.Join(tmpDir, "link")))
+	dirFS := sysfs.DirFS(tmpDir)
+
+	tests := []struct {
+		name string
+		fs   sys.FS
+		// expectedErrno is the result of updating "link" without following.
+		expectedErrno sys.Errno
+	}{
+		{name: "MemFS", fs: memFS},
+		{name: "DirFS", fs: dirFS, expectedErrno: func() sys.Errno {
+			if runtime.GOOS == "windows" {
+				return sys.ENOSYS
+			}
+			return 0
+		}()},
+		// Embedding hides Lutimens, so only Utimens is available.
+		{name: "Utimens only", fs: struct{ sys.FS }{memFS}, expectedErrno: sys.ENOSYS},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			mtim := int64(1e9)
+			for _, p := range []string{"file", "dir"} {
+				for _, follow := range []bool{true, false} {
+					mtim += 1e9
+					require.EqualErrno(t, 0, c.Utimens(tc.fs, p, sys.UTIME_OMIT, mtim, follow))
+					st, errno := tc.fs.Stat(p)
+					require.EqualErrno(t, 0, errno)
+					require.Equal(t, mtim, st.Mtim)
+				}
+			}
+
+			// Following updates the target.
+			mtim += 1e9
+			require.EqualErrno(t, 0, c.Utimens(tc.fs, "link", sys.UTIME_OMIT, mtim, true))
+			st, errno := tc.fs.Stat("file")
+			require.EqualErrno(t, 0, errno)
+			require.Equal(t, mtim, st.Mtim)
+
+			// Otherwise, the link itself is updated.
+			errno = c.Utimens(tc.fs, "link", sys.UTIME_OMIT, mtim+1e9, false)
+			require.EqualErrno(t, tc.expectedErrno, errno)
+			if errno != 0 {
+				return
+			}
+			st, errno = tc.fs.Lstat("link")
+			require.EqualErrno(t, 0, errno)
+			require.Equal(t, mtim+1e9, st.Mtim)
+			st, errno = tc.fs.Stat("file")
+			require.EqualErrno(t, 0, errno)
+			require.Equal(t, mtim, st.Mtim)
+		})
+	}
+}
+
+func TestFSContext_Rename(t *testing.T) {
+	c := &FSContext{}
+	oldFS, newFS := sysfs.MemFS(), sysfs.MemFS()
+
+	f, errno := oldFS.OpenFile("file", sys.O_RDWR|sys.O_CREAT, 0o640)
+	require.EqualErrno(t, 0, errno)
+	_, errno = f.Write([]byte("wazero"))
+	require.EqualErrno(t, 0, errno)
+	require.EqualErrno(t, 0, f.Utimens(1, 2))
+	require.EqualErrno(t, 0, f.Close())
+
+	require.EqualErrno(t, 0, oldFS.Mkdir("dir", 0o700))
+	require.EqualErrno(t, 0, newFS.Mkdir("dir", 0o700))
+
+	t.Run("same filesystem", func(t *testing.T) {
+		require.EqualErrno(t, 0, c.Rename(oldFS, "file", oldFS, "renamed"))
+		require.EqualErrno(t, 0, c.Rename(oldFS, "renamed", oldFS, "file"))
+	})
+
+	t.Run("file across filesystems", func(t *testing.T) {
+		require.EqualErrno(t, 0, c.Rename(oldFS, "file", newFS, "moved"))
+
+		_, errno := oldFS.Lstat("file")
+		require.EqualErrno(t, sys.ENOENT, errno)
+
+		st, errno := newFS.Lstat("moved")
+		require.EqualErrno(t, 0, errno)
+		require.Equal(t, fs.FileMode(0o640), st.Mode)
+		require.Equal(t, int64(len("wazero")), st.Size)
+		require.Equal(t, int64(1), st.Atim)
+		require.Equal(t, int64(2), st.Mtim)
+	})
+
+	t.Run("file to dir across filesystems", func(t *testing.T) {
+		require.EqualErrno(t, sys.EISDIR, c.Rename(newFS, "moved", oldFS, "dir"))
+	})
+
+	t.Run("dir across filesystems", func(t *testing.T) {
+		require.EqualErrno(t, sys.ENOSYS, c.Rename(oldFS, "dir", newFS, "dir2"))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		require.EqualErrno(t, sys.ENOENT, c.Rename(oldFS, "file", newFS, "file"))
+	})
+}
+
+func TestFSContext_ReadOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(path.Join(tmpDir, "file"), []byte("data"), 0o600))
+	require.NoError(t, os.Mkdir(path.Join(tmpDir, "dir"), 0o700))
+	readFS := &sysfs.ReadFS{FS: sysfs.DirFS(tmpDir)}
+	memFS := sysfs.MemFS()
+
+	c := Context{}
+	err := c.InitFSContext(nil, nil, nil, []sys.FS{readFS, memFS}, []string{"/", "/tmp"}, nil)
+	require.NoError(t, err)
+	fsc := &c.fsc
+	defer fsc.Close()
+
+	t.Run("OpenFile", func(t *testing.T) {
+		for _, flag := range []sys.Oflag{sys.O_WRONLY, sys.O_RDWR, sys.O_RDONLY | sys.O_TRUNC} {
+			_, errno := fsc.OpenFile(readFS, "file", flag, 0)
+			require.EqualErrno(t, sys.EROFS, errno)
+		}
+		_, errno := fsc.OpenFile(readFS, "dir", sys.O_RDWR|sys.O_DIRECTORY, 0)
+		require.EqualErrno(t, sys.EISDIR, errno)
+
+		fd, errno := fsc.OpenFile(readFS, "file", sys.O_RDONLY, 0)
+		require.EqualErrno(t, 0, errno)
+		require.EqualErrno(t, 0, fsc.CloseFile(fd))
+
+		// The writeable mount is unaffected.
+		fd, errno = fsc.OpenFile(memFS, "file", sys.O_RDWR|sys.O_CREAT, 0o600)
+		require.EqualErrno(t, 0, errno)
+		require.EqualErrno(t, 0, fsc.CloseFile(fd))
+	})
+
+	t.Run("Rename", func(t *testing.T) {
+		require.EqualErrno(t, sys.EROFS, fsc.Rename(readFS, "file", readFS, "file2"))
+		require.EqualErrno(t, sys.EROFS, fsc.Rename(memFS, "file", readFS, "file2"))
+
+		// Moving a file off a read-only mount doesn't leave a copy behind.
+		require.EqualErrno(t, sys.EROFS, fsc.Rename(readFS, "file", memFS, "file2"))
+		_, errno := memFS.Stat("file2")
+		require.EqualErrno(t, sys.ENOENT, errno)
+	})
+
+	t.Run("Link", func(t *testing.T) {
+		require.EqualErrno(t, sys.EROFS, fsc.Link(readFS, "file", readFS, "file2", false))
+	})
+
+	t.Run("Symlink", func(t *testing.T) {
+		require.EqualErrno(t, sys.EROFS, fsc.Symlink(readFS, "file", "link"))
+	})
+
+	t.Run("Utimens", func(t *testing.T) {
+		for _, follow := range []bool{true, false} {
+			require.EqualErrno(t, sys.EROFS, fsc.Utimens(readFS, "file", sys.UTIME_OMIT, 1e9, follow))
+		}
+	})
+}
+
 func TestFSContext_noPreopens(t *testing.T) {
 	c := Context{}
 	err := c.InitFSContext(nil, nil, nil, nil, nil, nil)
@@ -248,6 +543,172 @@ func TestFSContext_Renumber(t *testing.T) {
 		// Both are preopen.
 		require.Equal(t, sys.ENOTSUP, fsc.Renumber(3, 3))
 	})
+
+	t.Run("to itself", func(t *testing.T) {
+		fd, errno := fsc.OpenFile(dirFS, dirName, sys.O_RDONLY, 0)
+		require.EqualErrno(t, 0, errno)
+		defer fsc.CloseFile(fd) // nolint
+
+		require.EqualErrno(t, 0, fsc.Renumber(fd, fd))
+
+		f, ok := fsc.LookupFile(fd)
+		require.True(t, ok)
+		_, errno = f.File.Stat()
+		require.EqualErrno(t, 0, errno) // not closed
+	})
+}
+
+func TestFSContext_Dup(t *testing.T) {
+	tmpDir := t.TempDir()
+	dirFS := sysfs.DirFS(tmpDir)
+	require.NoError(t, os.WriteFile(path.Join(tmpDir, "file"), []byte("wazero"), 0o600))
+
+	c := Context{}
+	err := c.InitFSContext(nil, nil, nil, []sys.FS{dirFS}, []string{"/"}, nil)
+	require.NoError(t, err)
+	fsc := c.fsc
+	defer fsc.Close()
+
+	fd, errno := fsc.OpenFile(dirFS, "file", sys.O_RDONLY, 0)
+	require.EqualErrno(t, 0, errno)
+
+	dupFD, errno := fsc.Dup(fd)
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, fd+1, dupFD)
+
+	f, ok := fsc.LookupFile(fd)
+	require.True(t, ok)
+	dup, ok := fsc.LookupFile(dupFD)
+	require.True(t, ok)
+	require.Equal(t, f, dup)
+
+	// Both descriptors share the same offset.
+	buf := make([]byte, 3)
+	_, errno = f.File.Read(buf)
+	require.EqualErrno(t, 0, errno)
+	_, errno = dup.File.Read(buf)
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, "ero", string(buf))
+
+	// Closing one descriptor doesn't close the file.
+	require.EqualErrno(t, 0, fsc.CloseFile(fd))
+	_, errno = dup.File.Stat()
+	require.EqualErrno(t, 0, errno)
+
+	// Renumbering onto another descriptor of the same file doesn't close it.
+	fd, errno = fsc.Dup(dupFD)
+	require.EqualErrno(t, 0, errno)
+	require.EqualErrno(t, 0, fsc.Renumber(fd, dupFD))
+	_, errno = dup.File.Stat()
+	require.EqualErrno(t, 0, errno)
+
+	// Closing the last descriptor closes the file.
+	require.EqualErrno(t, 0, fsc.CloseFile(dupFD))
+	_, errno = dup.File.Stat()
+	require.EqualErrno(t, sys.EBADF, errno)
+
+	t.Run("errors", func(t *testing.T) {
+		_, errno := fsc.Dup(12345)
+		require.EqualErrno(t, sys.EBADF, errno)
+
+		_, errno = fsc.Dup(3) // preopen
+		require.EqualErrno(t, sys.ENOTSUP, errno)
+	})
+}
+
+func TestFSContext_Dup_redirectStdout(t *testing.T) {
+	tmpDir := t.TempDir()
+	dirFS := sysfs.DirFS(tmpDir)
+
+	var stdout bytes.Buffer
+	c := Context{}
+	err := c.InitFSContext(nil, &stdout, nil, []sys.FS{dirFS}, []string{"/"}, nil)
+	require.NoError(t, err)
+	fsc := c.fsc
+	defer fsc.Close()
+
+	write := func(s string) {
+		f, ok := fsc.LookupFile(FdStdout)
+		require.True(t, ok)
+		_, errno := f.File.Write([]byte(s))
+		require.EqualErrno(t, 0, errno)
+	}
+
+	// Like a shell running "cmd > file", keep stdout to restore it later, then
+	// replace it with the file.
+	savedFD, errno := fsc.Dup(FdStdout)
+	require.EqualErrno(t, 0, errno)
+	fd, errno := fsc.OpenFile(dirFS, "file", sys.O_WRONLY|sys.O_CREAT, 0o600)
+	require.EqualErrno(t, 0, errno)
+	require.EqualErrno(t, 0, fsc.Renumber(fd, FdStdout))
+	write("to file")
+
+	// Restoring stdout closes the file.
+	require.EqualErrno(t, 0, fsc.Renumber(savedFD, FdStdout))
+	write("to stdout")
+
+	b, err := os.ReadFile(path.Join(tmpDir, "file"))
+	require.NoError(t, err)
+	require.Equal(t, "to file", string(b))
+	require.Equal(t, "to stdout", stdout.String())
+	_, ok := fsc.LookupFile(savedFD)
+	require.False(t, ok)
+}
+
+func TestFSContext_Lock(t *testing.T) {
+	// Files of this FS aren't backed by host files, so locks are only tracked
+	// by the FSContext.
+	memFS := sysfs.MemFS()
+	c := Context{}
+	err := c.InitFSContext(nil, nil, nil, []sys.FS{memFS}, []string{"/"}, nil)
+	require.NoError(t, err)
+	fsc := c.fsc
+	defer fsc.Close()
+
+	open := func(name string) int32 {
+		fd, errno := fsc.OpenFile(memFS, name, sys.O_RDWR|sys.O_CREAT, 0o600)
+		require.EqualErrno(t, 0, errno)
+		return fd
+	}
+	requireLock := func(fd int32, expected sysfs.LockType) {
+		lock, errno := fsc.LockState(fd)
+		require.EqualErrno(t, 0, errno)
+		require.Equal(t, expected, lock)
+	}
+
+	a, b, other := open("file"), open("file"), open("other")
+	requireLock(a, sysfs.LockNone)
+
+	require.EqualErrno(t, 0, fsc.Lock(a, sysfs.LockShared, false))
+	require.EqualErrno(t, 0, fsc.Lock(b, sysfs.LockShared, false))
+	requireLock(a, sysfs.LockShared)
+	requireLock(b, sysfs.LockShared)
+
+	// Conflicts fail, even when blocking.
+	require.EqualErrno(t, sys.EAGAIN, fsc.Lock(b, sysfs.LockExclusive, false))
+	requireLock(b, sysfs.LockShared)
+	require.EqualErrno(t, 0, fsc.Lock(a, sysfs.LockNone, false))
+	require.EqualErrno(t, 0, fsc.Lock(b, sysfs.LockExclusive, false))
+	require.EqualErrno(t, sys.EAGAIN, fsc.Lock(a, sysfs.LockShared, true))
+
+	// Locks of other paths don't conflict.
+	require.EqualErrno(t, 0, fsc.Lock(other, sysfs.LockExclusive, false))
+
+	// A duplicate shares the lock, which is released by closing the last.
+	dup, errno := fsc.Dup(b)
+	require.EqualErrno(t, 0, errno)
+	requireLock(dup, sysfs.LockExclusive)
+	require.EqualErrno(t, 0, fsc.CloseFile(b))
+	require.EqualErrno(t, sys.EAGAIN, fsc.Lock(a, sysfs.LockShared, true))
+	require.EqualErrno(t, 0, fsc.CloseFile(dup))
+	require.EqualErrno(t, 0, fsc.Lock(a, sysfs.LockExclusive, true))
+
+	t.Run("errors", func(t *testing.T) {
+		require.EqualErrno(t, sys.EBADF, fsc.Lock(12345, sysfs.LockShared, true))
+		_, errno := fsc.LockState(12345)
+		require.EqualErrno(t, sys.EBADF, errno)
+		require.EqualErrno(t, sys.EINVAL, fsc.Lock(a, sysfs.LockExclusive+1, true))
+	})
 }
 
 func TestDirentCache_Read(t *testing.T) {
@@ -385,6 +846,32 @@ func TestDirentCache_Read(t *testing.T) {
 			n:               5,
 			expectedDirents: testDirents,
 		},
+		{
+			name:       "read before cache",
+			initialDir: "dir",
+			dir: func(fd int32) {
+				f, _ := fsc.LookupFile(fd)
+				rdd, _ := f.DirentCache()
+				_, _ = rdd.Read(0, 5)
+				_, _ = rdd.Read(4, 1)
+			},
+			pos:             1,
+			n:               2,
+			expectedDirents: testDirents[1:3],
+		},
+		{
+			name:       "read after dot entries before cache",
+			initialDir: "dir",
+			dir: func(fd int32) {
+				f, _ := fsc.LookupFile(fd)
+				rdd, _ := f.DirentCache()
+				_, _ = rdd.Read(0, 5)
+				_, _ = rdd.Read(4, 1)
+			},
+			pos:             3,
+			n:               5,
+			expectedDirents: testDirents[3:],
+		},
 		{
 			name:          "DirentCache: not a dir",
 			initialDir:    "dir/-",
@@ -469,6 +956,57 @@ func TestDirentCache_ReadNewFile(t *testing.T) {
 	require.Equal(t, "file", dirents[0].Name)
 }
 
+// TestDirentCache_ReadBeforeCache ensures a position before the cache is
+// re-read from the start of the directory, even if it changed.
+func TestDirentCache_ReadBeforeCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a", "b", "c"} {
+		require.NoError(t, os.WriteFile(path.Join(tmpDir, name), nil, 0o0666))
+	}
+
+	c := Context{}
+	err := c.InitFSContext(nil, nil, nil, []sys.FS{sysfs.DirFS(tmpDir)}, []string{"/"}, nil)
+	require.NoError(t, err)
+	fsc := c.fsc
+	defer fsc.Close()
+
+	fd, errno := fsc.OpenFile(fsc.RootFS(), ".", sys.O_RDONLY, 0)
+	require.EqualErrno(t, 0, errno)
+	defer fsc.CloseFile(fd) // nolint
+	f, _ := fsc.LookupFile(fd)
+
+	dir, errno := f.DirentCache()
+	require.EqualErrno(t, 0, errno)
+
+	// Read all entries, one at a time, so that only the last is cached.
+	var names []string
+	for pos := uint64(0); pos < 5; pos++ {
+		dirents, errno := dir.Read(pos, 1)
+		require.EqualErrno(t, 0, errno)
+		require.Equal(t, 1, len(dirents))
+		names = append(names, dirents[0].Name)
+	}
+
+	// Read the entries after the dot entries again.
+	dirents, errno := dir.Read(2, 5)
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, 3, len(dirents))
+	for i, d := range dirents {
+		require.Equal(t, names[i+2], d.Name)
+	}
+
+	// Move the cache to the end, then shrink the directory.
+	_, errno = dir.Read(5, 1)
+	require.EqualErrno(t, 0, errno)
+	require.NoError(t, os.Remove(path.Join(tmpDir, "a")))
+	require.NoError(t, os.Remove(path.Join(tmpDir, "b")))
+
+	// Reading a position past the new end is EOF, not an error.
+	dirents, errno = dir.Read(4, 5)
+	require.EqualErrno(t, 0, errno)
+	require.Zero(t, len(dirents))
+}
+
 func TestStripPrefixesAndTrailingSlash(t *testing.T) {
 	tests := []struct {
 		path, expected string
@@ -0,0 +1,21 @@
+package sys
+
+// HostState holds the state of the embedder attached to a module.
+type HostState struct {
+	State interface{}
+}
+
+// HostStateKey is a context.Context Value key. Its associated value should be
+// a *HostState.
+type HostStateKey struct{}
+
+// HostState returns the state attached to the module, or nil if there is
+// none.
+func (c *Context) HostState() interface{} {
+	return c.hostState
+}
+
+// SetHostState attaches the given state to the module.
+func (c *Context) SetHostState(state interface{}) {
+	c.hostState = state
+}
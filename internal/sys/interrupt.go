@@ -0,0 +1,33 @@
+package sys
+
+import "sync/atomic"
+
+// InterruptKey is a context.Context Value key. Its associated value should be
+// an *Interrupt.
+type InterruptKey struct{}
+
+// CancelCallKey is a context.Context Value key. When its associated value is
+// true, calls made with the context fail when it is done, instead of closing
+// the module.
+type CancelCallKey struct{}
+
+// Interrupt records the signal which canceled a context.Context, so that
+// modules closed on context done exit with 128+signal instead of
+// sys.ExitCodeContextCanceled.
+type Interrupt struct {
+	signal atomic.Uint32
+}
+
+// Raise records `signal`, unless one was already raised.
+func (i *Interrupt) Raise(signal uint32) {
+	i.signal.CompareAndSwap(0, signal)
+}
+
+// ExitCode returns 128+signal, the same as a shell reports a process killed
+// by a signal, or false if Raise wasn't called.
+func (i *Interrupt) ExitCode() (uint32, bool) {
+	if signal := i.signal.Load(); signal != 0 {
+		return 128 + signal, true
+	}
+	return 0, false
+}
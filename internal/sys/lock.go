@@ -0,0 +1,66 @@
+package sys
+
+import (
+	"github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/internal/sysfs"
+)
+
+// Lock applies or removes an advisory lock on the whole file of `fd`, like
+// `flock` in BSD. Descriptors from Dup share the lock, which is released when
+// the last is closed.
+//
+// Files backed by a host file use `flock` where the platform supports it, so
+// locks are coherent with other FSContexts and processes using it on the same
+// file. Otherwise, the lock is only tracked in this FSContext: it conflicts
+// with locks on other descriptors of the same path of the same sys.FS. As a
+// guest can't release a lock while it waits, conflicts fail with sys.EAGAIN
+// regardless of `nonblock`.
+//
+// # Errors
+//
+// A zero sys.Errno is success. The below are expected otherwise:
+//   - sys.EBADF: `fd` isn't open.
+//   - sys.EINVAL: `typ` is invalid.
+//   - sys.EAGAIN: a conflicting lock is held, and `nonblock` is true or the
+//     host can't lock the file.
+func (c *FSContext) Lock(fd int32, typ sysfs.LockType, nonblock bool) sys.Errno {
+	f, ok := c.openedFiles.Lookup(fd)
+	if !ok {
+		return sys.EBADF
+	}
+	errno := sysfs.Lock(f.File, typ, nonblock)
+	if errno == sys.ENOSYS && c.hasConflictingLock(f, typ) {
+		errno = sys.EAGAIN
+	} else if errno == sys.ENOSYS {
+		errno = 0
+	}
+	if errno == 0 {
+		f.lock = typ
+	}
+	return errno
+}
+
+// LockState returns the advisory lock the file of `fd` holds, set by Lock.
+func (c *FSContext) LockState(fd int32) (sysfs.LockType, sys.Errno) {
+	if f, ok := c.openedFiles.Lookup(fd); !ok {
+		return sysfs.LockNone, sys.EBADF
+	} else {
+		return f.lock, 0
+	}
+}
+
+// hasConflictingLock returns true if another file entry of the same path as
+// `f` holds a lock which conflicts with `typ`.
+func (c *FSContext) hasConflictingLock(f *FileEntry, typ sysfs.LockType) (conflict bool) {
+	if typ == sysfs.LockNone || f.FS == nil {
+		return false
+	}
+	c.openedFiles.Range(func(_ int32, other *FileEntry) bool {
+		if other == f || other.lock == sysfs.LockNone || other.FS != f.FS || other.Name != f.Name {
+			return true
+		}
+		conflict = typ == sysfs.LockExclusive || other.lock == sysfs.LockExclusive
+		return !conflict
+	})
+	return
+}
@@ -0,0 +1,28 @@
+package sys
+
+import (
+	"context"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// ProcExitConfig configures the behavior of proc_exit in WASI.
+type ProcExitConfig struct {
+	OnExit   func(ctx context.Context, mod api.Module, exitCode uint32)
+	KeepOpen bool
+}
+
+// ProcExitConfigKey is a context.Context Value key. Its associated value
+// should be a *ProcExitConfig.
+type ProcExitConfigKey struct{}
+
+// ProcExitConfig returns the configuration of proc_exit, or nil for the
+// default behavior.
+func (c *Context) ProcExitConfig() *ProcExitConfig {
+	return c.procExitConfig
+}
+
+// SetProcExitConfig overrides the behavior of proc_exit.
+func (c *Context) SetProcExitConfig(config *ProcExitConfig) {
+	c.procExitConfig = config
+}
@@ -0,0 +1,43 @@
+package sys
+
+// Rights are the WASI rights of a file descriptor, as bit flags defined by
+// wasip1, e.g. wasip1.RIGHT_FD_READ.
+type Rights struct {
+	// Base are the operations allowed on the file descriptor.
+	Base uint64
+
+	// Inheriting are the rights of files opened from the file descriptor,
+	// when it is a directory.
+	Inheriting uint64
+}
+
+// RightsConfig are the rights of pre-opened directories, by guest path.
+type RightsConfig map[string]uint64
+
+// RightsConfigKey is a context.Context Value key. Its associated value should
+// be a RightsConfig.
+type RightsConfigKey struct{}
+
+// RestrictPreopens sets the Rights of pre-opened directories to those in
+// `config`, or all rights when absent. Files opened from these directories
+// inherit a subset of their rights.
+func (c *FSContext) RestrictPreopens(config RightsConfig) {
+	for fd := int32(FdPreopen); ; fd++ {
+		f, ok := c.LookupFile(fd)
+		if !ok || !f.IsPreopen {
+			return
+		} else if f.FS == nil {
+			continue // not a directory, e.g. a socket.
+		}
+		rights, ok := config[f.Name]
+		if !ok {
+			rights = ^uint64(0)
+		}
+		f.Rights = &Rights{Base: rights, Inheriting: rights}
+	}
+}
+
+// HasRights returns true unless Rights are set and exclude any of `rights`.
+func (f *FileEntry) HasRights(rights uint64) bool {
+	return f.Rights == nil || f.Rights.Base&rights == rights
+}
@@ -2,6 +2,7 @@ package sys
 
 import (
 	"io"
+	"io/fs"
 	"os"
 
 	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
@@ -126,3 +127,69 @@ func stdioWriterFileEntry(name string, w io.Writer) (*FileEntry, error) {
 		return &FileEntry{Name: name, IsPreopen: true, File: &writerFile{w: w}}, nil
 	}
 }
+
+// StdioType is the type of file a guest sees for stdin, stdout or stderr.
+type StdioType uint8
+
+const (
+	// StdioTypeDetect detects the type from the host, for example a
+	// terminal when an os.File is a character device.
+	StdioTypeDetect StdioType = iota
+	// StdioTypeTerminal is a character device, so isatty is true.
+	StdioTypeTerminal
+	// StdioTypePipe is a named pipe (FIFO).
+	StdioTypePipe
+	// StdioTypeFile is a regular file.
+	StdioTypeFile
+)
+
+// StdioConfig overrides the type of file a guest sees for stdio.
+type StdioConfig struct {
+	Stdin, Stdout, Stderr StdioType
+}
+
+// StdioConfigKey is a context.Context Value key. Its associated value should
+// be a *StdioConfig.
+type StdioConfigKey struct{}
+
+// RawStdinKey is a context.Context Value key. Its associated value should be
+// true to put stdin into raw mode while the module is open, if it's a
+// terminal.
+type RawStdinKey struct{}
+
+// OverrideStdio changes the file type returned by Stat of stdio files which
+// aren't StdioTypeDetect in `config`.
+func (c *FSContext) OverrideStdio(config StdioConfig) {
+	for fd, typ := range [...]StdioType{config.Stdin, config.Stdout, config.Stderr} {
+		var mode fs.FileMode
+		switch typ {
+		case StdioTypeDetect:
+			continue
+		case StdioTypeTerminal:
+			mode = fs.ModeDevice | fs.ModeCharDevice
+		case StdioTypePipe:
+			mode = fs.ModeNamedPipe
+		}
+		if f, ok := c.LookupFile(int32(fd)); ok {
+			f.File = &stdioTypeFile{File: f.File, mode: mode}
+		}
+	}
+}
+
+// stdioTypeFile overrides the file type returned by Stat.
+type stdioTypeFile struct {
+	fsapi.File
+
+	// mode is the type bits of the file, without permissions.
+	mode fs.FileMode
+}
+
+// Stat implements the same method as documented on sys.File
+func (f *stdioTypeFile) Stat() (sys.Stat_t, experimentalsys.Errno) {
+	st, errno := f.File.Stat()
+	if errno != 0 {
+		return st, errno
+	}
+	st.Mode = f.mode | st.Mode.Perm()
+	return st, 0
+}
@@ -1,6 +1,7 @@
 package sys
 
 import (
+	"bytes"
 	"io/fs"
 	"os"
 	"testing"
@@ -136,3 +137,27 @@ func TestStdio(t *testing.T) {
 		}
 	}
 }
+
+func TestFSContext_OverrideStdio(t *testing.T) {
+	var c Context
+	require.NoError(t, c.InitFSContext(nil, &bytes.Buffer{}, &bytes.Buffer{}, nil, nil, nil))
+	fsc := c.FS()
+
+	fsc.OverrideStdio(StdioConfig{Stdout: StdioTypeTerminal, Stderr: StdioTypePipe})
+
+	for _, tc := range []struct {
+		fd           int32
+		expectedType fs.FileMode
+	}{
+		{fd: FdStdin, expectedType: fs.ModeDevice}, // detected
+		{fd: FdStdout, expectedType: fs.ModeDevice | fs.ModeCharDevice},
+		{fd: FdStderr, expectedType: fs.ModeNamedPipe},
+	} {
+		f, ok := fsc.LookupFile(tc.fd)
+		require.True(t, ok)
+		st, errno := f.File.Stat()
+		require.EqualErrno(t, 0, errno)
+		require.Equal(t, tc.expectedType, st.Mode.Type())
+		require.Equal(t, fs.FileMode(0o640), st.Mode.Perm())
+	}
+}
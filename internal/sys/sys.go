@@ -23,9 +23,12 @@ type Context struct {
 	nanotime           sys.Nanotime
 	nanotimeResolution sys.ClockResolution
 	nanosleep          sys.Nanosleep
+	timerNanosleep     bool
 	osyield            sys.Osyield
 	randSource         io.Reader
 	fsc                FSContext
+	procExitConfig     *ProcExitConfig
+	hostState          interface{}
 }
 
 // Args is like os.Args and defaults to nil.
@@ -93,6 +96,33 @@ func (c *Context) Nanosleep(ns int64) {
 	c.nanosleep(ns)
 }
 
+// NanosleepUntil is like Nanosleep, except it returns false if `ctxDone` or
+// `moduleDone` are closed first.
+//
+// Note: Sleeps can only be interrupted after UseTimerNanosleep, as a custom
+// sys.Nanosleep can't be.
+func (c *Context) NanosleepUntil(ns int64, ctxDone, moduleDone <-chan struct{}) bool {
+	if !c.timerNanosleep {
+		c.nanosleep(ns)
+		return true
+	}
+	t := time.NewTimer(time.Duration(ns))
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctxDone:
+	case <-moduleDone:
+	}
+	return false
+}
+
+// UseTimerNanosleep makes NanosleepUntil use a timer instead of Nanosleep.
+// This is only valid when Nanosleep is platform.Nanosleep.
+func (c *Context) UseTimerNanosleep() {
+	c.timerNanosleep = true
+}
+
 // Osyield implements sys.Osyield.
 func (c *Context) Osyield() {
 	c.osyield()
@@ -109,6 +139,10 @@ func (c *Context) RandSource() io.Reader {
 	return c.randSource
 }
 
+// RandSeedKey is a context.Context Value key. Its associated value should be
+// an int64 seed for NewSeededRandSource in the platform package.
+type RandSeedKey struct{}
+
 // DefaultContext returns Context with no values set except a possible nil
 // sys.FS.
 //
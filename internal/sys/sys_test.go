@@ -265,6 +265,23 @@ func TestNewContext_Nanosleep(t *testing.T) {
 	require.Equal(t, aNs, sysCtx.nanosleep)
 }
 
+func TestContext_NanosleepUntil(t *testing.T) {
+	var slept int64
+	sysCtx, err := NewContext(0, nil, nil, nil, nil, nil, nil, nil, 0, nil, 0, func(ns int64) { slept = ns }, nil, nil, nil, nil)
+	require.Nil(t, err)
+
+	// A custom sys.Nanosleep is called even if done.
+	done := make(chan struct{})
+	close(done)
+	require.True(t, sysCtx.NanosleepUntil(5, done, nil))
+	require.Equal(t, int64(5), slept)
+
+	sysCtx.UseTimerNanosleep()
+	require.True(t, sysCtx.NanosleepUntil(int64(time.Millisecond), nil, nil))
+	require.False(t, sysCtx.NanosleepUntil(int64(time.Hour), nil, done))
+	require.False(t, sysCtx.NanosleepUntil(int64(time.Hour), done, nil))
+}
+
 func TestNewContext_Osyield(t *testing.T) {
 	var oy sys.Osyield = func() {}
 	sysCtx, err := NewContext(0, nil, nil, nil, nil, nil, nil, nil, 0, nil, 0, nil, oy, nil, nil, nil)
@@ -159,6 +159,34 @@ func TestAdaptFS_HackedWrites(t *testing.T) {
 	testOpen_O_RDWR(t, tmpDir, testFS)
 }
 
+// flagFS implements openFileFS, so can be opened for write without cheating
+// the fs.FS contract.
+type flagFS string
+
+// Open implements the same method as documented on fs.FS
+func (dir flagFS) Open(name string) (fs.File, error) {
+	return dir.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// OpenFile implements the same method as documented on openFileFS
+func (dir flagFS) OpenFile(name string, flag int, perm fs.FileMode) (fs.File, error) {
+	return os.OpenFile(ensureTrailingPathSeparator(string(dir))+name, flag, perm)
+}
+
+// TestAdaptFS_OpenFileFS ensures open flags are passed to a fs.FS which
+// implements OpenFile.
+func TestAdaptFS_OpenFileFS(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFS := &AdaptFS{FS: flagFS(tmpDir)}
+
+	testOpen_O_RDWR(t, tmpDir, testFS)
+
+	t.Run("O_EXCL", func(t *testing.T) {
+		_, errno := testFS.OpenFile("file", experimentalsys.O_RDWR|experimentalsys.O_CREAT|experimentalsys.O_EXCL, 0o600)
+		require.EqualErrno(t, experimentalsys.EEXIST, errno)
+	})
+}
+
 // MaskOsFS helps prove that the fsFile implementation behaves the same way
 // when a fs.FS returns an os.File or methods we use from it.
 type MaskOsFS struct {
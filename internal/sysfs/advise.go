@@ -0,0 +1,46 @@
+package sysfs
+
+import (
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+)
+
+// Advice is a hint about the access pattern of a range of a file, used by
+// Advise. The values are the same as wasi_snapshot_preview1 `advice`.
+type Advice uint8
+
+const (
+	// AdviceNormal means there is no advice about the access pattern.
+	AdviceNormal Advice = iota
+	// AdviceSequential means the data will be accessed sequentially.
+	AdviceSequential
+	// AdviceRandom means the data will be accessed in random order.
+	AdviceRandom
+	// AdviceWillNeed means the data will be accessed in the near future.
+	AdviceWillNeed
+	// AdviceDontNeed means the data will not be accessed in the near future.
+	AdviceDontNeed
+	// AdviceNoReuse means the data will be accessed only once.
+	AdviceNoReuse
+)
+
+// adviseFile is implemented by files which can pass access pattern advice to
+// the host, such as osFile on Linux.
+type adviseFile interface {
+	advise(offset, length int64, advice Advice) experimentalsys.Errno
+}
+
+// Advise passes access pattern advice for the range of the file beginning at
+// `offset`, to the host. A zero `length` means until the end of the file.
+//
+// Advice is only a hint, so files which can't use it return zero, like
+// `posix_fadvise` on platforms which don't support it.
+//
+// See https://pubs.opengroup.org/onlinepubs/9699919799/functions/posix_fadvise.html
+func Advise(f experimentalsys.File, offset, length int64, advice Advice) experimentalsys.Errno {
+	if advice > AdviceNoReuse {
+		return experimentalsys.EINVAL
+	} else if af, ok := f.(adviseFile); ok {
+		return af.advise(offset, length, advice)
+	}
+	return 0
+}
@@ -0,0 +1,178 @@
+package sysfs
+
+import (
+	"io/fs"
+	"strings"
+
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// CaseInsensitiveFS returns a sys.FS which looks up paths in `fsys` without
+// regard to case, while preserving the case of created files. This allows
+// guests which assume macOS or Windows behavior, e.g. opening "README.md" as
+// "readme.md", to work on a case-sensitive host directory.
+//
+// When a directory has several entries which only differ in case, an exact
+// match is preferred, then the lowest by byte order.
+//
+// Note: Each path component which doesn't match exactly requires reading its
+// parent directory, so this is slower than `fsys` for such lookups.
+func CaseInsensitiveFS(fsys experimentalsys.FS) experimentalsys.FS {
+	return &caseFS{FS: fsys}
+}
+
+type caseFS struct {
+	experimentalsys.FS
+}
+
+// String implements fmt.Stringer
+func (c *caseFS) String() string {
+	if s, ok := c.FS.(interface{ String() string }); ok {
+		return s.String()
+	}
+	return "case-insensitive"
+}
+
+// resolve returns `path` with each component replaced by the case of an
+// existing entry, if any. Components which don't exist are unchanged, so that
+// they are created with the case the guest chose.
+func (c *caseFS) resolve(path string) string {
+	// Avoid reading directories when the path exists as-is.
+	if _, errno := c.FS.Lstat(path); errno == 0 {
+		return path
+	}
+
+	var dir string
+	components := splitPath(nil, path)
+	for i, name := range components {
+		if name == ".." {
+			dir = joinCasePath(dir, name)
+			continue
+		}
+		match, ok := c.lookup(dir, name)
+		if !ok {
+			// Nothing below a missing component can exist.
+			for _, name := range components[i:] {
+				dir = joinCasePath(dir, name)
+			}
+			break
+		}
+		dir = joinCasePath(dir, match)
+	}
+	if dir == "" {
+		return path
+	}
+	return dir
+}
+
+// lookup returns the entry in `dir` which equals `name` without regard to
+// case, or false if there is none.
+func (c *caseFS) lookup(dir, name string) (string, bool) {
+	open := dir
+	if open == "" {
+		open = "."
+	}
+	f, errno := c.FS.OpenFile(open, experimentalsys.O_RDONLY|experimentalsys.O_DIRECTORY, 0)
+	if errno != 0 {
+		return "", false
+	}
+	defer f.Close()
+
+	dirents, errno := f.Readdir(-1)
+	if errno != 0 {
+		return "", false
+	}
+
+	var match string
+	var ok bool
+	for _, e := range dirents {
+		if e.Name == name {
+			return name, true
+		} else if strings.EqualFold(e.Name, name) && (!ok || e.Name < match) {
+			match, ok = e.Name, true
+		}
+	}
+	return match, ok
+}
+
+// joinCasePath appends `name` to the slash-separated `dir`.
+func joinCasePath(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
+
+// OpenFile implements the same method as documented on sys.FS
+func (c *caseFS) OpenFile(path string, flag experimentalsys.Oflag, perm fs.FileMode) (experimentalsys.File, experimentalsys.Errno) {
+	return c.FS.OpenFile(c.resolve(path), flag, perm)
+}
+
+// Lstat implements the same method as documented on sys.FS
+func (c *caseFS) Lstat(path string) (sys.Stat_t, experimentalsys.Errno) {
+	return c.FS.Lstat(c.resolve(path))
+}
+
+// Stat implements the same method as documented on sys.FS
+func (c *caseFS) Stat(path string) (sys.Stat_t, experimentalsys.Errno) {
+	return c.FS.Stat(c.resolve(path))
+}
+
+// Mkdir implements the same method as documented on sys.FS
+func (c *caseFS) Mkdir(path string, perm fs.FileMode) experimentalsys.Errno {
+	return c.FS.Mkdir(c.resolve(path), perm)
+}
+
+// Chmod implements the same method as documented on sys.FS
+func (c *caseFS) Chmod(path string, perm fs.FileMode) experimentalsys.Errno {
+	return c.FS.Chmod(c.resolve(path), perm)
+}
+
+// Rename implements the same method as documented on sys.FS
+//
+// Note: Renaming a file to a name which only differs in case changes its case.
+func (c *caseFS) Rename(from, to string) experimentalsys.Errno {
+	from = c.resolve(from)
+	if resolved := c.resolve(to); !strings.EqualFold(resolved, from) {
+		to = resolved
+	}
+	return c.FS.Rename(from, to)
+}
+
+// Rmdir implements the same method as documented on sys.FS
+func (c *caseFS) Rmdir(path string) experimentalsys.Errno {
+	return c.FS.Rmdir(c.resolve(path))
+}
+
+// Unlink implements the same method as documented on sys.FS
+func (c *caseFS) Unlink(path string) experimentalsys.Errno {
+	return c.FS.Unlink(c.resolve(path))
+}
+
+// Link implements the same method as documented on sys.FS
+func (c *caseFS) Link(oldPath, newPath string) experimentalsys.Errno {
+	return c.FS.Link(c.resolve(oldPath), c.resolve(newPath))
+}
+
+// Symlink implements the same method as documented on sys.FS
+//
+// Note: The target is stored as-is, so following the link is case-sensitive.
+func (c *caseFS) Symlink(oldPath, linkName string) experimentalsys.Errno {
+	return c.FS.Symlink(oldPath, c.resolve(linkName))
+}
+
+// Readlink implements the same method as documented on sys.FS
+func (c *caseFS) Readlink(path string) (string, experimentalsys.Errno) {
+	return c.FS.Readlink(c.resolve(path))
+}
+
+// Utimens implements the same method as documented on sys.FS
+func (c *caseFS) Utimens(path string, atim, mtim int64) experimentalsys.Errno {
+	return c.FS.Utimens(c.resolve(path), atim, mtim)
+}
+
+// Lutimens implements the same method as documented on lutimensFS
+func (c *caseFS) Lutimens(path string, atim, mtim int64) experimentalsys.Errno {
+	return Lutimens(c.FS, c.resolve(path), atim, mtim)
+}
@@ -0,0 +1,97 @@
+package sysfs
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestCaseInsensitiveFS(t *testing.T) {
+	mem := MemFS()
+	require.EqualErrno(t, 0, mem.Mkdir("Dir", 0o700))
+	writeMemFile(t, mem, "Dir/README.md", "readme")
+
+	testFS := CaseInsensitiveFS(mem)
+
+	for _, path := range []string{"Dir/README.md", "dir/readme.md", "DIR/ReadMe.MD"} {
+		st, errno := testFS.Stat(path)
+		require.EqualErrno(t, 0, errno, path)
+		require.Equal(t, int64(len("readme")), st.Size, path)
+	}
+
+	_, errno := testFS.Stat("dir/missing")
+	require.EqualErrno(t, sys.ENOENT, errno)
+
+	// Creating a file preserves the case chosen by the guest.
+	writeMemFile(t, testFS, "dir/New.txt", "new")
+	_, errno = mem.Stat("Dir/New.txt")
+	require.EqualErrno(t, 0, errno)
+
+	// Opening with a different case doesn't create another file.
+	writeMemFile(t, testFS, "DIR/NEW.TXT", "NEW")
+	requireMemFile(t, mem, "Dir/New.txt", "NEW")
+	_, errno = mem.Stat("Dir/NEW.TXT")
+	require.EqualErrno(t, sys.ENOENT, errno)
+
+	require.EqualErrno(t, sys.EEXIST, testFS.Mkdir("dir", 0o700))
+	require.EqualErrno(t, 0, testFS.Unlink("dir/new.txt"))
+	_, errno = mem.Stat("Dir/New.txt")
+	require.EqualErrno(t, sys.ENOENT, errno)
+}
+
+func TestCaseInsensitiveFS_Rename(t *testing.T) {
+	mem := MemFS()
+	writeMemFile(t, mem, "file", "data")
+
+	testFS := CaseInsensitiveFS(mem)
+
+	// Renaming to a name which only differs in case changes its case.
+	require.EqualErrno(t, 0, testFS.Rename("FILE", "File"))
+	requireMemFile(t, mem, "File", "data")
+	_, errno := mem.Stat("file")
+	require.EqualErrno(t, sys.ENOENT, errno)
+
+	// Renaming over an existing file replaces it, whatever its case.
+	writeMemFile(t, mem, "other", "other")
+	require.EqualErrno(t, 0, testFS.Rename("OTHER", "FILE"))
+	requireMemFile(t, mem, "File", "other")
+}
+
+func TestCaseInsensitiveFS_ambiguous(t *testing.T) {
+	mem := MemFS()
+	writeMemFile(t, mem, "b", "lower")
+	writeMemFile(t, mem, "B", "upper")
+
+	testFS := CaseInsensitiveFS(mem)
+
+	// An exact match is preferred.
+	requireMemFile(t, testFS, "b", "lower")
+	requireMemFile(t, testFS, "B", "upper")
+
+	// Otherwise, the lowest by byte order.
+	require.EqualErrno(t, 0, mem.Mkdir("dir", 0o700))
+	writeMemFile(t, mem, "dir/Ab", "Ab")
+	writeMemFile(t, mem, "dir/aB", "aB")
+	requireMemFile(t, testFS, "dir/ab", "Ab")
+}
+
+func writeMemFile(t *testing.T, fsys sys.FS, path, data string) {
+	f, errno := fsys.OpenFile(path, sys.O_WRONLY|sys.O_CREAT|sys.O_TRUNC, 0o600)
+	require.EqualErrno(t, 0, errno)
+	defer f.Close()
+
+	_, errno = f.Write([]byte(data))
+	require.EqualErrno(t, 0, errno)
+}
+
+func requireMemFile(t *testing.T, fsys sys.FS, path, expected string) {
+	f, errno := fsys.OpenFile(path, sys.O_RDONLY, 0)
+	require.EqualErrno(t, 0, errno)
+	defer f.Close()
+
+	buf := make([]byte, len(expected)+1)
+	n, errno := f.Read(buf)
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, expected, string(buf[:n]))
+}
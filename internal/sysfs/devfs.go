@@ -0,0 +1,312 @@
+package sysfs
+
+import (
+	"io"
+	"io/fs"
+
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// DevFS is a read-only directory of character devices, usually mounted at
+// "/dev". This allows code that opens "/dev/null" or "/dev/urandom" to work
+// without access to the host:
+//
+//   - null: reads are at EOF and writes are discarded.
+//   - zero: reads return zeros and writes are discarded.
+//   - random and urandom: reads return bytes from Rand.
+//   - tty: reads from Stdin and writes to Stdout.
+//
+// Nil fields default to the sources of the module, when mounted with
+// FSConfig, or otherwise behave like "null".
+type DevFS struct {
+	// Rand is the source of "random" and "urandom".
+	Rand io.Reader
+
+	// Stdin is read by "tty".
+	Stdin io.Reader
+
+	// Stdout is written by "tty".
+	Stdout io.Writer
+}
+
+// devNames are the devices in DevFS, in directory order.
+var devNames = [...]string{"null", "random", "tty", "urandom", "zero"}
+
+const (
+	devDirMode  = fs.ModeDir | 0o755
+	devFileMode = fs.ModeDevice | fs.ModeCharDevice | 0o666
+)
+
+// String implements fmt.Stringer
+func (d *DevFS) String() string {
+	return "dev"
+}
+
+// devIno returns the inode of `name`, or zero if it isn't in DevFS.
+func devIno(name string) sys.Inode {
+	switch name {
+	case "", ".", "/":
+		return 1
+	}
+	for i, n := range devNames {
+		if n == name {
+			return sys.Inode(i + 2)
+		}
+	}
+	return 0
+}
+
+// devPath returns `path` without leading or trailing slashes.
+func devPath(path string) string {
+	for len(path) > 0 && path[0] == '/' {
+		path = path[1:]
+	}
+	for len(path) > 0 && path[len(path)-1] == '/' {
+		path = path[:len(path)-1]
+	}
+	return path
+}
+
+// OpenFile implements the same method as documented on sys.FS
+func (d *DevFS) OpenFile(path string, flag experimentalsys.Oflag, perm fs.FileMode) (experimentalsys.File, experimentalsys.Errno) {
+	name := devPath(path)
+	ino := devIno(name)
+	switch {
+	case ino == 0 && flag&experimentalsys.O_CREAT != 0:
+		return nil, experimentalsys.EROFS
+	case ino == 0:
+		return nil, experimentalsys.ENOENT
+	case flag&(experimentalsys.O_CREAT|experimentalsys.O_EXCL) == experimentalsys.O_CREAT|experimentalsys.O_EXCL:
+		return nil, experimentalsys.EEXIST
+	case ino == 1:
+		if flag&(experimentalsys.O_WRONLY|experimentalsys.O_RDWR) != 0 {
+			return nil, experimentalsys.EISDIR
+		}
+		return &devDir{}, 0
+	case flag&experimentalsys.O_DIRECTORY != 0:
+		return nil, experimentalsys.ENOTDIR
+	}
+
+	f := &devFile{ino: ino, flag: flag}
+	switch name {
+	case "random", "urandom":
+		f.r = d.Rand
+	case "tty":
+		f.r, f.w = d.Stdin, d.Stdout
+	case "zero":
+		f.r = zeroReader{}
+	}
+	return f, 0
+}
+
+// Lstat implements the same method as documented on sys.FS
+func (d *DevFS) Lstat(path string) (sys.Stat_t, experimentalsys.Errno) {
+	return d.Stat(path)
+}
+
+// Stat implements the same method as documented on sys.FS
+func (d *DevFS) Stat(path string) (sys.Stat_t, experimentalsys.Errno) {
+	switch ino := devIno(devPath(path)); ino {
+	case 0:
+		return sys.Stat_t{}, experimentalsys.ENOENT
+	case 1:
+		return sys.Stat_t{Ino: ino, Mode: devDirMode, Nlink: 2}, 0
+	default:
+		return sys.Stat_t{Ino: ino, Mode: devFileMode, Nlink: 1}, 0
+	}
+}
+
+// Readlink implements the same method as documented on sys.FS
+func (d *DevFS) Readlink(path string) (string, experimentalsys.Errno) {
+	if devIno(devPath(path)) == 0 {
+		return "", experimentalsys.ENOENT
+	}
+	return "", experimentalsys.EINVAL
+}
+
+// Mkdir implements the same method as documented on sys.FS
+func (d *DevFS) Mkdir(string, fs.FileMode) experimentalsys.Errno {
+	return experimentalsys.EROFS
+}
+
+// Chmod implements the same method as documented on sys.FS
+func (d *DevFS) Chmod(string, fs.FileMode) experimentalsys.Errno {
+	return experimentalsys.EROFS
+}
+
+// Rename implements the same method as documented on sys.FS
+func (d *DevFS) Rename(string, string) experimentalsys.Errno {
+	return experimentalsys.EROFS
+}
+
+// Rmdir implements the same method as documented on sys.FS
+func (d *DevFS) Rmdir(string) experimentalsys.Errno {
+	return experimentalsys.EROFS
+}
+
+// Link implements the same method as documented on sys.FS
+func (d *DevFS) Link(string, string) experimentalsys.Errno {
+	return experimentalsys.EROFS
+}
+
+// Symlink implements the same method as documented on sys.FS
+func (d *DevFS) Symlink(string, string) experimentalsys.Errno {
+	return experimentalsys.EROFS
+}
+
+// Unlink implements the same method as documented on sys.FS
+func (d *DevFS) Unlink(string) experimentalsys.Errno {
+	return experimentalsys.EROFS
+}
+
+// Utimens implements the same method as documented on sys.FS
+func (d *DevFS) Utimens(string, int64, int64) experimentalsys.Errno {
+	return experimentalsys.EROFS
+}
+
+// devDir is the root directory of DevFS.
+type devDir struct {
+	experimentalsys.UnimplementedFile
+
+	// pos is the index into devNames of the next entry to read.
+	pos int
+}
+
+// Ino implements the same method as documented on sys.File
+func (f *devDir) Ino() (sys.Inode, experimentalsys.Errno) {
+	return 1, 0
+}
+
+// IsDir implements the same method as documented on sys.File
+func (f *devDir) IsDir() (bool, experimentalsys.Errno) {
+	return true, 0
+}
+
+// Stat implements the same method as documented on sys.File
+func (f *devDir) Stat() (sys.Stat_t, experimentalsys.Errno) {
+	return sys.Stat_t{Ino: 1, Mode: devDirMode, Nlink: 2}, 0
+}
+
+// Read implements the same method as documented on sys.File
+func (f *devDir) Read([]byte) (int, experimentalsys.Errno) {
+	return 0, experimentalsys.EISDIR
+}
+
+// Pread implements the same method as documented on sys.File
+func (f *devDir) Pread([]byte, int64) (int, experimentalsys.Errno) {
+	return 0, experimentalsys.EISDIR
+}
+
+// Seek implements the same method as documented on sys.File
+func (f *devDir) Seek(offset int64, whence int) (int64, experimentalsys.Errno) {
+	if offset != 0 || whence != io.SeekStart {
+		return 0, experimentalsys.EINVAL // only rewind is supported
+	}
+	f.pos = 0
+	return 0, 0
+}
+
+// Readdir implements the same method as documented on sys.File
+func (f *devDir) Readdir(n int) (dirents []experimentalsys.Dirent, errno experimentalsys.Errno) {
+	remaining := devNames[f.pos:]
+	if n <= 0 || n > len(remaining) {
+		n = len(remaining)
+	}
+	dirents = make([]experimentalsys.Dirent, 0, n)
+	for _, name := range remaining[:n] {
+		dirents = append(dirents, experimentalsys.Dirent{Name: name, Ino: devIno(name), Type: devFileMode.Type()})
+	}
+	f.pos += n
+	return
+}
+
+// devFile is a character device in DevFS. Reads are at EOF when r is nil,
+// and writes are discarded when w is nil.
+type devFile struct {
+	experimentalsys.UnimplementedFile
+
+	ino  sys.Inode
+	flag experimentalsys.Oflag
+	r    io.Reader
+	w    io.Writer
+}
+
+func (f *devFile) readable() bool {
+	return f.flag&(experimentalsys.O_RDONLY|experimentalsys.O_RDWR|experimentalsys.O_WRONLY) != experimentalsys.O_WRONLY
+}
+
+func (f *devFile) writable() bool {
+	return f.flag&(experimentalsys.O_RDWR|experimentalsys.O_WRONLY) != 0
+}
+
+// Ino implements the same method as documented on sys.File
+func (f *devFile) Ino() (sys.Inode, experimentalsys.Errno) {
+	return f.ino, 0
+}
+
+// Stat implements the same method as documented on sys.File
+func (f *devFile) Stat() (sys.Stat_t, experimentalsys.Errno) {
+	return sys.Stat_t{Ino: f.ino, Mode: devFileMode, Nlink: 1}, 0
+}
+
+// Read implements the same method as documented on sys.File
+func (f *devFile) Read(buf []byte) (int, experimentalsys.Errno) {
+	if !f.readable() {
+		return 0, experimentalsys.EBADF
+	} else if f.r == nil || len(buf) == 0 {
+		return 0, 0
+	}
+	n, err := f.r.Read(buf)
+	if err == io.EOF {
+		err = nil // EOF is not an error, rather a zero length read.
+	}
+	return n, experimentalsys.UnwrapOSError(err)
+}
+
+// Pread implements the same method as documented on sys.File
+//
+// Note: Like Linux, the offset is ignored, as devices have no position.
+func (f *devFile) Pread(buf []byte, _ int64) (int, experimentalsys.Errno) {
+	return f.Read(buf)
+}
+
+// Seek implements the same method as documented on sys.File
+func (f *devFile) Seek(int64, int) (int64, experimentalsys.Errno) {
+	return 0, 0 // like Linux, seeking a character device succeeds.
+}
+
+// Write implements the same method as documented on sys.File
+func (f *devFile) Write(buf []byte) (int, experimentalsys.Errno) {
+	if !f.writable() {
+		return 0, experimentalsys.EBADF
+	} else if f.w == nil {
+		return len(buf), 0 // same as io.Discard
+	}
+	n, err := f.w.Write(buf)
+	return n, experimentalsys.UnwrapOSError(err)
+}
+
+// Pwrite implements the same method as documented on sys.File
+func (f *devFile) Pwrite(buf []byte, _ int64) (int, experimentalsys.Errno) {
+	return f.Write(buf)
+}
+
+// Truncate implements the same method as documented on sys.File
+func (f *devFile) Truncate(int64) experimentalsys.Errno {
+	if !f.writable() {
+		return experimentalsys.EBADF
+	}
+	return 0 // like Linux, truncating a character device is ignored.
+}
+
+// zeroReader fills any buffer with zeros, like "/dev/zero".
+type zeroReader struct{}
+
+// Read implements io.Reader
+func (zeroReader) Read(buf []byte) (int, error) {
+	for i := range buf {
+		buf[i] = 0
+	}
+	return len(buf), nil
+}
@@ -0,0 +1,140 @@
+package sysfs
+
+import (
+	"bytes"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestDevFS_Readdir(t *testing.T) {
+	testFS := &DevFS{}
+
+	d, errno := testFS.OpenFile(".", sys.O_RDONLY|sys.O_DIRECTORY, 0)
+	require.EqualErrno(t, 0, errno)
+	defer d.Close()
+
+	dirents, errno := d.Readdir(2)
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, 2, len(dirents))
+
+	rest, errno := d.Readdir(-1)
+	require.EqualErrno(t, 0, errno)
+
+	var names []string
+	for _, e := range append(dirents, rest...) {
+		require.Equal(t, fs.ModeDevice|fs.ModeCharDevice, e.Type)
+		names = append(names, e.Name)
+	}
+	require.Equal(t, []string{"null", "random", "tty", "urandom", "zero"}, names)
+
+	// Rewinding reads the entries again.
+	_, errno = d.Seek(0, 0)
+	require.EqualErrno(t, 0, errno)
+	dirents, errno = d.Readdir(-1)
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, 5, len(dirents))
+}
+
+func TestDevFS_Stat(t *testing.T) {
+	testFS := &DevFS{}
+
+	st, errno := testFS.Stat("/")
+	require.EqualErrno(t, 0, errno)
+	require.True(t, st.Mode.IsDir())
+
+	st, errno = testFS.Stat("null")
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, fs.ModeDevice|fs.ModeCharDevice|0o666, st.Mode)
+
+	_, errno = testFS.Stat("sda")
+	require.EqualErrno(t, sys.ENOENT, errno)
+}
+
+func TestDevFS_null(t *testing.T) {
+	f, errno := (&DevFS{}).OpenFile("null", sys.O_RDWR, 0)
+	require.EqualErrno(t, 0, errno)
+	defer f.Close()
+
+	n, errno := f.Write([]byte("hello"))
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, 5, n)
+
+	n, errno = f.Read(make([]byte, 5))
+	require.EqualErrno(t, 0, errno)
+	require.Zero(t, n)
+}
+
+func TestDevFS_zero(t *testing.T) {
+	f, errno := (&DevFS{}).OpenFile("zero", sys.O_RDONLY, 0)
+	require.EqualErrno(t, 0, errno)
+	defer f.Close()
+
+	buf := []byte{1, 2, 3}
+	n, errno := f.Read(buf)
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, 3, n)
+	require.Equal(t, []byte{0, 0, 0}, buf)
+
+	// Writing isn't allowed when opened read-only.
+	_, errno = f.Write(buf)
+	require.EqualErrno(t, sys.EBADF, errno)
+}
+
+func TestDevFS_urandom(t *testing.T) {
+	testFS := &DevFS{Rand: strings.NewReader("abcdef")}
+
+	for _, name := range []string{"random", "urandom"} {
+		f, errno := testFS.OpenFile(name, sys.O_RDONLY, 0)
+		require.EqualErrno(t, 0, errno)
+
+		buf := make([]byte, 3)
+		n, errno := f.Read(buf)
+		require.EqualErrno(t, 0, errno)
+		require.Equal(t, 3, n)
+		require.EqualErrno(t, 0, f.Close())
+	}
+
+	// Both devices read from the same source.
+	require.Zero(t, testFS.Rand.(*strings.Reader).Len())
+}
+
+func TestDevFS_tty(t *testing.T) {
+	var stdout bytes.Buffer
+	testFS := &DevFS{Stdin: strings.NewReader("input"), Stdout: &stdout}
+
+	f, errno := testFS.OpenFile("tty", sys.O_RDWR, 0)
+	require.EqualErrno(t, 0, errno)
+	defer f.Close()
+
+	buf := make([]byte, 5)
+	n, errno := f.Read(buf)
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, "input", string(buf[:n]))
+
+	_, errno = f.Write([]byte("output"))
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, "output", stdout.String())
+}
+
+func TestDevFS_readOnly(t *testing.T) {
+	testFS := &DevFS{}
+
+	_, errno := testFS.OpenFile("file", sys.O_RDWR|sys.O_CREAT, 0o600)
+	require.EqualErrno(t, sys.EROFS, errno)
+	_, errno = testFS.OpenFile("null", sys.O_RDWR|sys.O_CREAT|sys.O_EXCL, 0o600)
+	require.EqualErrno(t, sys.EEXIST, errno)
+	_, errno = testFS.OpenFile(".", sys.O_RDWR, 0)
+	require.EqualErrno(t, sys.EISDIR, errno)
+	_, errno = testFS.OpenFile("null", sys.O_RDONLY|sys.O_DIRECTORY, 0)
+	require.EqualErrno(t, sys.ENOTDIR, errno)
+
+	require.EqualErrno(t, sys.EROFS, testFS.Mkdir("dir", 0o700))
+	require.EqualErrno(t, sys.EROFS, testFS.Unlink("null"))
+	require.EqualErrno(t, sys.EROFS, testFS.Rename("null", "void"))
+	require.EqualErrno(t, sys.EROFS, testFS.Symlink("null", "void"))
+	require.EqualErrno(t, sys.EROFS, testFS.Chmod("null", 0o600))
+}
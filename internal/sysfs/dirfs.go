@@ -3,6 +3,8 @@ package sysfs
 import (
 	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 
 	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
 	"github.com/tetratelabs/wazero/internal/platform"
@@ -41,22 +43,42 @@ func (d *dirFS) String() string {
 
 // OpenFile implements the same method as documented on sys.FS
 func (d *dirFS) OpenFile(path string, flag experimentalsys.Oflag, perm fs.FileMode) (experimentalsys.File, experimentalsys.Errno) {
-	return OpenOSFile(d.join(path), flag, perm)
+	// Like open(2), O_CREAT|O_EXCL doesn't follow a symbolic link, even if it
+	// is dangling.
+	follow := flag&experimentalsys.O_NOFOLLOW == 0 &&
+		flag&(experimentalsys.O_CREAT|experimentalsys.O_EXCL) != experimentalsys.O_CREAT|experimentalsys.O_EXCL
+	hostPath, errno := d.resolve(path, follow)
+	if errno != 0 {
+		return nil, errno
+	}
+	return OpenOSFile(hostPath, flag, perm)
 }
 
 // Lstat implements the same method as documented on sys.FS
 func (d *dirFS) Lstat(path string) (sys.Stat_t, experimentalsys.Errno) {
-	return lstat(d.join(path))
+	hostPath, errno := d.resolve(path, false)
+	if errno != 0 {
+		return sys.Stat_t{}, errno
+	}
+	return lstat(hostPath)
 }
 
 // Stat implements the same method as documented on sys.FS
 func (d *dirFS) Stat(path string) (sys.Stat_t, experimentalsys.Errno) {
-	return stat(d.join(path))
+	hostPath, errno := d.resolve(path, true)
+	if errno != 0 {
+		return sys.Stat_t{}, errno
+	}
+	return stat(hostPath)
 }
 
 // Mkdir implements the same method as documented on sys.FS
 func (d *dirFS) Mkdir(path string, perm fs.FileMode) (errno experimentalsys.Errno) {
-	err := os.Mkdir(d.join(path), perm)
+	hostPath, errno := d.resolve(path, false)
+	if errno != 0 {
+		return errno
+	}
+	err := os.Mkdir(hostPath, perm)
 	if errno = experimentalsys.UnwrapOSError(err); errno == experimentalsys.ENOTDIR {
 		errno = experimentalsys.ENOENT
 	}
@@ -65,21 +87,33 @@ func (d *dirFS) Mkdir(path string, perm fs.FileMode) (errno experimentalsys.Errn
 
 // Chmod implements the same method as documented on sys.FS
 func (d *dirFS) Chmod(path string, perm fs.FileMode) experimentalsys.Errno {
-	err := os.Chmod(d.join(path), perm)
+	hostPath, errno := d.resolve(path, true)
+	if errno != 0 {
+		return errno
+	}
+	err := os.Chmod(hostPath, perm)
 	return experimentalsys.UnwrapOSError(err)
 }
 
 // Rename implements the same method as documented on sys.FS
-func (d *dirFS) Rename(from, to string) experimentalsys.Errno {
-	from, to = d.join(from), d.join(to)
+func (d *dirFS) Rename(from, to string) (errno experimentalsys.Errno) {
+	if from, errno = d.resolve(from, false); errno != 0 {
+		return
+	} else if to, errno = d.resolve(to, false); errno != 0 {
+		return
+	}
 	return rename(from, to)
 }
 
 // Readlink implements the same method as documented on sys.FS
 func (d *dirFS) Readlink(path string) (string, experimentalsys.Errno) {
+	hostPath, errno := d.resolve(path, false)
+	if errno != 0 {
+		return "", errno
+	}
 	// Note: do not use syscall.Readlink as that causes race on Windows.
 	// In any case, syscall.Readlink does almost the same logic as os.Readlink.
-	dst, err := os.Readlink(d.join(path))
+	dst, err := os.Readlink(hostPath)
 	if err != nil {
 		return "", experimentalsys.UnwrapOSError(err)
 	}
@@ -87,33 +121,160 @@ func (d *dirFS) Readlink(path string) (string, experimentalsys.Errno) {
 }
 
 // Link implements the same method as documented on sys.FS
-func (d *dirFS) Link(oldName, newName string) experimentalsys.Errno {
-	err := os.Link(d.join(oldName), d.join(newName))
+func (d *dirFS) Link(oldName, newName string) (errno experimentalsys.Errno) {
+	if oldName, errno = d.resolve(oldName, false); errno != 0 {
+		return
+	} else if newName, errno = d.resolve(newName, false); errno != 0 {
+		return
+	}
+	err := os.Link(oldName, newName)
 	return experimentalsys.UnwrapOSError(err)
 }
 
 // Rmdir implements the same method as documented on sys.FS
 func (d *dirFS) Rmdir(path string) experimentalsys.Errno {
-	return rmdir(d.join(path))
+	hostPath, errno := d.resolve(path, false)
+	if errno != 0 {
+		return errno
+	}
+	return rmdir(hostPath)
 }
 
 // Unlink implements the same method as documented on sys.FS
 func (d *dirFS) Unlink(path string) (err experimentalsys.Errno) {
-	return unlink(d.join(path))
+	hostPath, errno := d.resolve(path, false)
+	if errno != 0 {
+		return errno
+	}
+	return unlink(hostPath)
 }
 
 // Symlink implements the same method as documented on sys.FS
 func (d *dirFS) Symlink(oldName, link string) experimentalsys.Errno {
+	hostPath, errno := d.resolve(link, false)
+	if errno != 0 {
+		return errno
+	}
 	// Note: do not resolve `oldName` relative to this dirFS. The link result is always resolved
 	// when dereference the `link` on its usage (e.g. readlink, read, etc).
 	// https://github.com/bytecodealliance/cap-std/blob/v1.0.4/cap-std/src/fs/dir.rs#L404-L409
-	err := os.Symlink(oldName, d.join(link))
+	err := os.Symlink(oldName, hostPath)
 	return experimentalsys.UnwrapOSError(err)
 }
 
 // Utimens implements the same method as documented on sys.FS
 func (d *dirFS) Utimens(path string, atim, mtim int64) experimentalsys.Errno {
-	return utimens(d.join(path), atim, mtim)
+	hostPath, errno := d.resolve(path, true)
+	if errno != 0 {
+		return errno
+	}
+	return utimens(hostPath, atim, mtim)
+}
+
+// Lutimens implements the same method as documented on lutimensFS
+func (d *dirFS) Lutimens(path string, atim, mtim int64) experimentalsys.Errno {
+	hostPath, errno := d.resolve(path, false)
+	if errno != 0 {
+		return errno
+	}
+	return lutimens(hostPath, atim, mtim)
+}
+
+// resolve returns the host path of `path`, after following any symbolic
+// links in its parent directories, and the last component when `followLast`
+// is set. Links are followed manually, so that they can't escape the host
+// directory: sys.EPERM is returned for any path or link target that would
+// resolve outside it, including absolute targets elsewhere on the host.
+// sys.EINVAL is returned for names the host would misinterpret, such as "C:"
+// or "NUL" on Windows.
+//
+// Note: This doesn't protect against a concurrent host process replacing a
+// directory with a symbolic link after it was resolved.
+func (d *dirFS) resolve(path string, followLast bool) (string, experimentalsys.Errno) {
+	trailingSlash := strings.HasSuffix(path, "/")
+	if trailingSlash {
+		followLast = true // e.g. "link/" refers to the directory, not the link.
+	}
+
+	var resolved []string
+	queue := splitPath(nil, path)
+	links := 0
+	// depth is the count of directories below the host directory. Once a
+	// component doesn't exist, the rest are kept as is, so that the host
+	// returns the same error as before, for example sys.ENOENT.
+	depth, exists := 0, true
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if c == ".." {
+			if depth == 0 {
+				return "", experimentalsys.EPERM
+			}
+			depth--
+			if exists {
+				resolved = resolved[:len(resolved)-1]
+				continue
+			}
+		} else if !validHostName(c) {
+			return "", experimentalsys.EINVAL
+		} else {
+			depth++
+		}
+
+		resolved = append(resolved, c)
+		if !exists || (len(queue) == 0 && !followLast) {
+			continue
+		}
+
+		hostPath := d.join(strings.Join(resolved, "/"))
+		if st, err := os.Lstat(hostPath); err != nil {
+			exists = false // let the caller return the error, or create it.
+			continue
+		} else if st.Mode()&fs.ModeSymlink == 0 {
+			continue
+		}
+
+		if links++; links > maxSymlinks {
+			return "", experimentalsys.ELOOP
+		}
+		target, err := os.Readlink(hostPath)
+		if err != nil {
+			return "", experimentalsys.UnwrapOSError(err)
+		}
+
+		resolved, depth = resolved[:len(resolved)-1], depth-1
+		if filepath.IsAbs(target) {
+			// Allow absolute targets which are inside the host directory.
+			rel, errno := d.rel(target)
+			if errno != 0 {
+				return "", errno
+			}
+			resolved, depth, target = resolved[:0], 0, rel
+		} else if target = platform.ToPosixPath(target); strings.HasPrefix(target, "/") {
+			return "", experimentalsys.EPERM // e.g. a root-relative path on Windows.
+		}
+		queue = splitPath(splitPath(nil, target), strings.Join(queue, "/"))
+	}
+
+	p := strings.Join(resolved, "/")
+	if trailingSlash && p != "" {
+		p += "/"
+	}
+	return d.join(p), 0
+}
+
+// rel returns the slash-separated path of the absolute host path `target`,
+// relative to the host directory, or sys.EPERM if it is outside.
+func (d *dirFS) rel(target string) (string, experimentalsys.Errno) {
+	dir, err := filepath.Abs(d.dir)
+	if err != nil {
+		return "", experimentalsys.UnwrapOSError(err)
+	}
+	rel, err := filepath.Rel(dir, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", experimentalsys.EPERM
+	}
+	return filepath.ToSlash(rel), 0
 }
 
 func (d *dirFS) join(path string) string {
@@ -125,7 +286,5 @@ func (d *dirFS) join(path string) string {
 		// cleanedDir includes an unnecessary delimiter for the root path.
 		return d.cleanedDir[:len(d.cleanedDir)-1]
 	}
-	// TODO: Enforce similar to safefilepath.FromFS(path), but be careful as
-	// relative path inputs are allowed. e.g. dir or path == ../
 	return d.cleanedDir + path
 }
@@ -1,7 +1,6 @@
 package sysfs
 
 import (
-	"errors"
 	"fmt"
 	"io/fs"
 	"os"
@@ -644,11 +643,86 @@ func TestDirFS_OpenFile(t *testing.T) {
 
 	testOpen_O_RDWR(t, tmpDir, testFS)
 
-	t.Run("path outside root valid", func(t *testing.T) {
-		_, err := testFS.OpenFile("../foo", sys.O_RDONLY, 0)
+	t.Run("path outside root", func(t *testing.T) {
+		_, errno := testFS.OpenFile("../foo", sys.O_RDONLY, 0)
+		require.EqualErrno(t, sys.EPERM, errno)
+	})
+}
+
+func TestDirFS_symlinkEscape(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create a subdirectory, so that links can point outside the sys.FS root.
+	outside := path.Join(tmpDir, "secret")
+	require.NoError(t, os.WriteFile(outside, []byte("secret"), 0o600))
+	rootDir := path.Join(tmpDir, "root")
+	require.NoError(t, os.Mkdir(rootDir, 0o700))
+	require.NoError(t, fstest.WriteTestFiles(rootDir))
+
+	for link, target := range map[string]string{
+		"relative":     "../secret",
+		"absolute":     outside,
+		"parent":       "..",
+		"sub/relative": "../../secret",
+		"inside":       path.Join(rootDir, "animals.txt"),
+		"inside-dir":   "sub/..",
+		"loop":         "loop",
+	} {
+		require.NoError(t, os.Symlink(target, path.Join(rootDir, link)))
+	}
+
+	testFS := DirFS(rootDir)
+
+	tests := []struct {
+		name, path    string
+		expectedErrno sys.Errno
+	}{
+		{name: "relative link", path: "relative", expectedErrno: sys.EPERM},
+		{name: "absolute link", path: "absolute", expectedErrno: sys.EPERM},
+		{name: "link to parent", path: "parent/secret", expectedErrno: sys.EPERM},
+		{name: "link in subdirectory", path: "sub/relative", expectedErrno: sys.EPERM},
+		{name: "parent of missing directory", path: "missing/../../secret", expectedErrno: sys.EPERM},
+		{name: "absolute link inside", path: "inside"},
+		{name: "relative link inside", path: "inside-dir/animals.txt"},
+		{name: "loop", path: "loop", expectedErrno: sys.ELOOP},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			f, errno := testFS.OpenFile(tc.path, sys.O_RDONLY, 0)
+			require.EqualErrno(t, tc.expectedErrno, errno)
+			if errno == 0 {
+				require.EqualErrno(t, 0, f.Close())
+			}
+
+			_, errno = testFS.Stat(tc.path)
+			require.EqualErrno(t, tc.expectedErrno, errno)
+		})
+	}
+
+	t.Run("not followed", func(t *testing.T) {
+		// The link itself is inside the root, so can be read or removed.
+		st, errno := testFS.Lstat("absolute")
+		require.EqualErrno(t, 0, errno)
+		require.Equal(t, fs.ModeSymlink, st.Mode.Type())
+
+		target, errno := testFS.Readlink("absolute")
+		require.EqualErrno(t, 0, errno)
+		require.Equal(t, platform.ToPosixPath(outside), target)
+
+		require.EqualErrno(t, 0, testFS.Unlink("relative"))
+	})
+
+	t.Run("writes don't escape", func(t *testing.T) {
+		_, errno := testFS.OpenFile("parent/new", sys.O_RDWR|sys.O_CREAT, 0o600)
+		require.EqualErrno(t, sys.EPERM, errno)
+		require.EqualErrno(t, sys.EPERM, testFS.Mkdir("parent/new", 0o700))
+		require.EqualErrno(t, sys.EPERM, testFS.Chmod("absolute", 0o777))
+		require.EqualErrno(t, sys.EPERM, testFS.Rename("animals.txt", "parent/animals.txt"))
 
-		// sys.FS allows relative path lookups
-		require.True(t, errors.Is(err, fs.ErrNotExist))
+		_, err := os.Stat(path.Join(tmpDir, "new"))
+		require.ErrorIs(t, err, fs.ErrNotExist)
 	})
 }
 
@@ -0,0 +1,54 @@
+package sysfs
+
+import "strings"
+
+// validHostName returns false if the guest path component `name` would be
+// interpreted differently by Windows than a POSIX host. For example, "a\b"
+// is a single file on Linux, but two components on Windows, which could also
+// escape the host directory via "..\..". Likewise, "C:" switches drives and
+// "NUL" opens a device instead of a file.
+//
+// Windows also ignores trailing dots and spaces, so "foo." would refer to
+// the same file as "foo". These names are rejected, so that guest code sees
+// sys.EINVAL on Windows, instead of operating on an unexpected file.
+//
+// See https://learn.microsoft.com/en-us/windows/win32/fileio/naming-a-file
+func validHostName(name string) bool {
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; c {
+		case '\\', ':', '*', '?', '"', '<', '>', '|':
+			return false
+		default:
+			if c < 0x20 {
+				return false
+			}
+		}
+	}
+	switch name[len(name)-1] {
+	case '.', ' ':
+		return false
+	}
+	return !isReservedName(name)
+}
+
+// isReservedName returns true if `name` is a DOS device name, such as "CON",
+// including when it has an extension, such as "nul.txt".
+func isReservedName(name string) bool {
+	if i := strings.IndexByte(name, '.'); i != -1 {
+		name = name[:i]
+	}
+	name = strings.TrimRight(name, " ")
+	switch len(name) {
+	case 3:
+		switch strings.ToUpper(name) {
+		case "CON", "PRN", "AUX", "NUL":
+			return true
+		}
+	case 4:
+		switch strings.ToUpper(name[:3]) {
+		case "COM", "LPT":
+			return name[3] >= '1' && name[3] <= '9'
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package sysfs
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestValidHostName(t *testing.T) {
+	for _, name := range []string{"a", "a.txt", ".hidden", "console", "com0", "lpt", "nul-txt", "a b"} {
+		require.True(t, validHostName(name), name)
+	}
+	for _, name := range []string{
+		`a\b`, "C:", "file:stream", "a*", "a?", `a"`, "a<", "a>", "a|", "a\x01",
+		"a.", "a ", "CON", "con", "Nul.txt", "aux .tar.gz", "COM1", "lpt9.log",
+	} {
+		require.False(t, validHostName(name), name)
+	}
+}
+
+func TestDirFS_windowsNames(t *testing.T) {
+	tmpDir := t.TempDir()
+	rootDir := path.Join(tmpDir, "root")
+	require.NoError(t, os.Mkdir(rootDir, 0o700))
+	require.NoError(t, os.WriteFile(path.Join(tmpDir, "secret"), nil, 0o600))
+
+	testFS := DirFS(rootDir)
+
+	for _, p := range []string{`..\secret`, `sub\..\..\secret`, "C:/Windows", "NUL", "dir/con.txt", "file."} {
+		_, errno := testFS.OpenFile(p, sys.O_RDWR|sys.O_CREAT, 0o600)
+		require.EqualErrno(t, sys.EINVAL, errno, p)
+	}
+
+	// Forward slashes still work as a separator.
+	require.EqualErrno(t, 0, testFS.Mkdir("dir", 0o700))
+	f, errno := testFS.OpenFile("dir/file", sys.O_RDWR|sys.O_CREAT, 0o600)
+	require.EqualErrno(t, 0, errno)
+	require.EqualErrno(t, 0, f.Close())
+}
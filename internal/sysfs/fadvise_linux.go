@@ -0,0 +1,40 @@
+//go:build amd64 || arm64
+
+package sysfs
+
+import (
+	"syscall"
+
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+)
+
+// These are the values of POSIX_FADV_* on Linux, which differ in order from
+// Advice.
+const (
+	_POSIX_FADV_NORMAL     = 0
+	_POSIX_FADV_RANDOM     = 1
+	_POSIX_FADV_SEQUENTIAL = 2
+	_POSIX_FADV_WILLNEED   = 3
+	_POSIX_FADV_DONTNEED   = 4
+	_POSIX_FADV_NOREUSE    = 5
+)
+
+// posixFadvice maps Advice to the corresponding POSIX_FADV_* value.
+var posixFadvice = [...]uintptr{
+	AdviceNormal:     _POSIX_FADV_NORMAL,
+	AdviceSequential: _POSIX_FADV_SEQUENTIAL,
+	AdviceRandom:     _POSIX_FADV_RANDOM,
+	AdviceWillNeed:   _POSIX_FADV_WILLNEED,
+	AdviceDontNeed:   _POSIX_FADV_DONTNEED,
+	AdviceNoReuse:    _POSIX_FADV_NOREUSE,
+}
+
+func fadvise(fd uintptr, offset, length int64, advice Advice) experimentalsys.Errno {
+	// On 64-bit architectures, offset and length are each passed in a single
+	// register.
+	_, _, e1 := syscall.Syscall6(syscall.SYS_FADVISE64, fd, uintptr(offset), uintptr(length), posixFadvice[advice], 0, 0)
+	if e1 != 0 {
+		return experimentalsys.UnwrapOSError(e1)
+	}
+	return 0
+}
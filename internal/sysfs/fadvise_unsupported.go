@@ -0,0 +1,12 @@
+//go:build !(linux && (amd64 || arm64))
+
+package sysfs
+
+import (
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+)
+
+func fadvise(uintptr, int64, int64, Advice) experimentalsys.Errno {
+	// Advice is only a hint, so it is ok to ignore it.
+	return 0
+}
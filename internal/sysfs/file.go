@@ -56,13 +56,28 @@ func OpenFSFile(fs fs.FS, path string, flag experimentalsys.Oflag, perm fs.FileM
 	if flag&experimentalsys.O_DIRECTORY != 0 && flag&(experimentalsys.O_WRONLY|experimentalsys.O_RDWR) != 0 {
 		return nil, experimentalsys.EISDIR // invalid to open a directory writeable
 	}
-	f, err := fs.Open(path)
+	f, err := openFSFile(fs, path, flag, perm)
 	if errno := experimentalsys.UnwrapOSError(err); errno != 0 {
 		return nil, errno
 	}
 	// Don't return an os.File because the path is not absolute. osFile needs
 	// the path to be real and certain FS.File impls are subrooted.
-	return &fsFile{fs: fs, name: path, file: f}, 0
+	return &fsFile{fs: fs, name: path, flag: flag, file: f}, 0
+}
+
+// openFileFS is implemented by fs.FS types which can open files with flags,
+// similar to os.OpenFile. When present, OpenFSFile uses it instead of Open,
+// which allows writable mounts of a fs.FS.
+type openFileFS interface {
+	OpenFile(name string, flag int, perm fs.FileMode) (fs.File, error)
+}
+
+// openFSFile opens the path with flags when the fs.FS supports it.
+func openFSFile(fsys fs.FS, path string, flag experimentalsys.Oflag, perm fs.FileMode) (fs.File, error) {
+	if of, ok := fsys.(openFileFS); ok {
+		return of.OpenFile(path, toOsOpenFlag(flag), perm)
+	}
+	return fsys.Open(path)
 }
 
 type stdioFile struct {
@@ -104,6 +119,10 @@ type fsFile struct {
 	// name is what was used in fs for Open, so it may not be the actual path.
 	name string
 
+	// flag are the flags used to open the file, which are re-applied when it
+	// is re-opened.
+	flag experimentalsys.Oflag
+
 	// file is always set, possibly an os.File like os.Stdin.
 	file fs.File
 
@@ -116,6 +135,10 @@ type fsFile struct {
 	// closed is true when closed was called. This ensures proper sys.EBADF
 	closed bool
 
+	// offset is the count of bytes read since the file was opened or last
+	// seeked. This is only used when file is not an io.Seeker.
+	offset int64
+
 	// cachedStat includes fields that won't change while a file is open.
 	cachedSt *cachedStat
 }
@@ -162,12 +185,31 @@ func (f *fsFile) IsDir() (bool, experimentalsys.Errno) {
 
 // IsAppend implements the same method as documented on sys.File
 func (f *fsFile) IsAppend() bool {
-	return false
+	return f.flag&experimentalsys.O_APPEND == experimentalsys.O_APPEND
 }
 
 // SetAppend implements the same method as documented on sys.File
-func (f *fsFile) SetAppend(bool) (errno experimentalsys.Errno) {
-	return fileError(f, f.closed, experimentalsys.ENOSYS)
+//
+// Note: This is only supported when the fs.FS implements openFileFS, as the
+// file has to be re-opened to change its append mode.
+func (f *fsFile) SetAppend(enable bool) (errno experimentalsys.Errno) {
+	if errno = fileError(f, f.closed, 0); errno != 0 {
+		return
+	} else if enable == f.IsAppend() {
+		return 0 // no change
+	} else if _, ok := f.fs.(openFileFS); !ok {
+		return experimentalsys.ENOSYS
+	}
+
+	if enable {
+		f.flag |= experimentalsys.O_APPEND
+	} else {
+		f.flag &= ^experimentalsys.O_APPEND
+	}
+
+	// Like osFile, this resets the file offset.
+	f.offset = 0
+	return f.reopen()
 }
 
 // Stat implements the same method as documented on sys.File
@@ -192,6 +234,7 @@ func (f *fsFile) Read(buf []byte) (n int, errno experimentalsys.Errno) {
 		// Defer validation overhead until we've already had an error.
 		errno = fileError(f, f.closed, errno)
 	}
+	f.offset += int64(n)
 	return
 }
 
@@ -228,12 +271,35 @@ func (f *fsFile) Pread(buf []byte, off int64) (n int, errno experimentalsys.Errn
 			// Defer validation overhead until we've already had an error.
 			errno = fileError(f, f.closed, errno)
 		}
+	} else if f.fs != nil {
+		n, errno = f.preadReopen(buf, off)
 	} else {
 		errno = experimentalsys.ENOSYS // unsupported
 	}
 	return
 }
 
+// preadReopen implements Pread for a file that is neither an io.ReaderAt nor
+// an io.Seeker, by reading `off` bytes from a new instance of the file.
+func (f *fsFile) preadReopen(buf []byte, off int64) (n int, errno experimentalsys.Errno) {
+	if f.closed {
+		return 0, experimentalsys.EBADF
+	} else if off < 0 {
+		return 0, experimentalsys.EINVAL
+	}
+
+	file, err := f.fs.Open(f.name)
+	if err != nil {
+		return 0, experimentalsys.UnwrapOSError(err)
+	}
+	defer file.Close()
+
+	if _, errno = discard(file, off); errno != 0 {
+		return
+	}
+	return read(file, buf)
+}
+
 // Seek implements the same method as documented on sys.File
 func (f *fsFile) Seek(offset int64, whence int) (newOffset int64, errno experimentalsys.Errno) {
 	// If this is a directory, and we're attempting to seek to position zero,
@@ -252,11 +318,65 @@ func (f *fsFile) Seek(offset int64, whence int) (newOffset int64, errno experime
 			errno = fileError(f, f.closed, errno)
 		}
 	} else {
-		errno = experimentalsys.ENOSYS // unsupported
+		newOffset, errno = f.seekEmulated(offset, whence)
 	}
 	return
 }
 
+// seekEmulated implements Seek for a file that is not an io.Seeker. Seeking
+// forward reads and discards bytes. Seeking backward re-opens the file first,
+// so returns sys.ENOSYS when it can't be, such as for stdin.
+func (f *fsFile) seekEmulated(offset int64, whence int) (int64, experimentalsys.Errno) {
+	if f.closed {
+		return 0, experimentalsys.EBADF
+	}
+
+	var target int64
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = f.offset + offset
+	case io.SeekEnd:
+		st, errno := f.Stat()
+		if errno != 0 {
+			return 0, errno
+		}
+		target = st.Size + offset
+	default:
+		return 0, experimentalsys.EINVAL
+	}
+
+	if target < 0 {
+		return 0, experimentalsys.EINVAL
+	} else if target < f.offset {
+		if f.fs == nil {
+			return 0, experimentalsys.ENOSYS // unsupported
+		} else if errno := f.reopen(); errno != 0 {
+			return 0, errno
+		}
+		f.offset = 0
+	}
+
+	// Like lseek, seeking past the end of the file is not an error.
+	n, errno := discard(f.file, target-f.offset)
+	if errno != 0 {
+		f.offset += n
+		return 0, errno
+	}
+	f.offset = target
+	return target, 0
+}
+
+// discard reads and discards up to `n` bytes, stopping early at EOF.
+func discard(r io.Reader, n int64) (int64, experimentalsys.Errno) {
+	n, err := io.CopyN(io.Discard, r, n)
+	if err == io.EOF {
+		err = nil
+	}
+	return n, experimentalsys.UnwrapOSError(err)
+}
+
 // Readdir implements the same method as documented on sys.File
 //
 // Notably, this uses readdirFile or fs.ReadDirFile if available. This does not
@@ -325,12 +445,73 @@ func (f *fsFile) Pwrite(buf []byte, off int64) (n int, errno experimentalsys.Err
 			// Defer validation overhead until we've already had an error.
 			errno = fileError(f, f.closed, errno)
 		}
+		return
+	}
+
+	// Like Pread, fall back to io.Seeker, restoring the offset when complete.
+	if ws, ok := f.file.(io.WriteSeeker); ok {
+		if off < 0 {
+			return 0, experimentalsys.EINVAL
+		}
+
+		currentOffset, err := ws.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, fileError(f, f.closed, experimentalsys.UnwrapOSError(err))
+		}
+		defer func() { _, _ = ws.Seek(currentOffset, io.SeekStart) }()
+
+		if off != currentOffset {
+			if _, err = ws.Seek(off, io.SeekStart); err != nil {
+				return 0, fileError(f, f.closed, experimentalsys.UnwrapOSError(err))
+			}
+		}
+
+		if n, errno = write(ws, buf); errno != 0 {
+			// Defer validation overhead until we've already had an error.
+			errno = fileError(f, f.closed, errno)
+		}
 	} else {
 		errno = experimentalsys.ENOSYS // unsupported
 	}
 	return
 }
 
+// syncFile is implemented by fs.File types which can synchronize changes to
+// storage, such as os.File.
+type syncFile interface {
+	Sync() error
+}
+
+// Sync implements the same method as documented on sys.File.
+//
+// Note: This is a no-op unless the fs.File implements syncFile, which is the
+// case for os.File returned by os.DirFS or a writable fs.FS.
+func (f *fsFile) Sync() experimentalsys.Errno {
+	if f.closed {
+		return experimentalsys.EBADF
+	}
+
+	switch file := f.file.(type) {
+	case *os.File:
+		return fsync(file)
+	case syncFile:
+		return experimentalsys.UnwrapOSError(file.Sync())
+	}
+	return 0
+}
+
+// Datasync implements the same method as documented on sys.File.
+func (f *fsFile) Datasync() experimentalsys.Errno {
+	if f.closed {
+		return experimentalsys.EBADF
+	}
+
+	if file, ok := f.file.(*os.File); ok {
+		return datasync(file)
+	}
+	return f.Sync()
+}
+
 // Close implements the same method as documented on sys.File.
 func (f *fsFile) Close() experimentalsys.Errno {
 	if f.closed {
@@ -430,9 +611,13 @@ var _ reopenFile = (*fsFile)(nil).reopen
 
 // reopen implements the same method as documented on reopenFile.
 func (f *fsFile) reopen() experimentalsys.Errno {
+	// Clear any create or truncate flag, as we are re-opening, not
+	// re-creating.
+	f.flag &= ^(experimentalsys.O_CREAT | experimentalsys.O_EXCL | experimentalsys.O_TRUNC)
+
 	_ = f.close()
 	var err error
-	f.file, err = f.fs.Open(f.name)
+	f.file, err = openFSFile(f.fs, f.name, f.flag, 0)
 	return experimentalsys.UnwrapOSError(err)
 }
 
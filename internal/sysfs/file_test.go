@@ -153,6 +153,92 @@ func TestFileSetAppend(t *testing.T) {
 	requireFileContent("wazero6789wazero")
 }
 
+// TestFileSetAppend_O_TRUNC ensures re-opening a file to change its append
+// mode doesn't truncate it again.
+func TestFileSetAppend_O_TRUNC(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		openFile func(string, experimentalsys.Oflag) (experimentalsys.File, experimentalsys.Errno)
+	}{
+		{name: "osFile", openFile: func(name string, flag experimentalsys.Oflag) (experimentalsys.File, experimentalsys.Errno) {
+			return OpenOSFile(path.Join(tmpDir, name), flag, 0o600)
+		}},
+		{name: "fsFile", openFile: func(name string, flag experimentalsys.Oflag) (experimentalsys.File, experimentalsys.Errno) {
+			return OpenFSFile(flagFS(tmpDir), name, flag, 0o600)
+		}},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			fPath := path.Join(tmpDir, tc.name)
+			require.NoError(t, os.WriteFile(fPath, []byte("0123456789"), 0o600))
+
+			f, errno := tc.openFile(tc.name, experimentalsys.O_RDWR|experimentalsys.O_TRUNC)
+			require.EqualErrno(t, 0, errno)
+			defer f.Close()
+
+			_, errno = f.Write([]byte("wazero"))
+			require.EqualErrno(t, 0, errno)
+
+			require.EqualErrno(t, 0, f.SetAppend(true))
+			require.True(t, f.IsAppend())
+
+			_, errno = f.Write([]byte("wazero"))
+			require.EqualErrno(t, 0, errno)
+
+			buf, err := os.ReadFile(fPath)
+			require.NoError(t, err)
+			require.Equal(t, "wazerowazero", string(buf))
+		})
+	}
+}
+
+func TestFSFileSetAppend(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(path.Join(tmpDir, "file"), []byte("0123456789"), 0o600))
+
+	t.Run("read-only fs.FS", func(t *testing.T) {
+		f, errno := OpenFSFile(os.DirFS(tmpDir), "file", experimentalsys.O_RDONLY, 0)
+		require.EqualErrno(t, 0, errno)
+		defer f.Close()
+
+		// Clearing the append flag is a no-op, so succeeds.
+		require.EqualErrno(t, 0, f.SetAppend(false))
+		require.EqualErrno(t, experimentalsys.ENOSYS, f.SetAppend(true))
+		require.False(t, f.IsAppend())
+	})
+
+	t.Run("openFileFS", func(t *testing.T) {
+		f, errno := OpenFSFile(flagFS(tmpDir), "file", experimentalsys.O_RDWR|experimentalsys.O_APPEND, 0)
+		require.EqualErrno(t, 0, errno)
+		defer f.Close()
+		require.True(t, f.IsAppend())
+
+		_, errno = f.Write([]byte("wazero"))
+		require.EqualErrno(t, 0, errno)
+
+		require.EqualErrno(t, 0, f.SetAppend(false))
+		require.False(t, f.IsAppend())
+
+		_, errno = f.Write([]byte("wazero"))
+		require.EqualErrno(t, 0, errno)
+
+		buf, err := os.ReadFile(path.Join(tmpDir, "file"))
+		require.NoError(t, err)
+		require.Equal(t, "wazero6789wazero", string(buf))
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		f, errno := OpenFSFile(flagFS(tmpDir), "file", experimentalsys.O_RDWR, 0)
+		require.EqualErrno(t, 0, errno)
+		require.EqualErrno(t, 0, f.Close())
+		require.EqualErrno(t, experimentalsys.EBADF, f.SetAppend(true))
+	})
+}
+
 func TestStdioFile_SetAppend(t *testing.T) {
 	// SetAppend should not affect Stdio.
 	file, err := NewStdioFile(false, os.Stdout)
@@ -284,6 +370,7 @@ func TestFileReadAndPread(t *testing.T) {
 		{name: "os.DirFS", fs: dirFS},
 		{name: "embed.api.FS", fs: embedFS},
 		{name: "fstest.MapFS", fs: mapFS},
+		{name: "noSeek(embed.api.FS)", fs: &noSeekFS{embedFS}},
 	}
 
 	buf := make([]byte, 3)
@@ -367,10 +454,17 @@ func TestFilePoll_POLLOUT(t *testing.T) {
 	require.NoError(t, err)
 	timeout := int32(0) // return immediately
 
-	// We don't yet implement write blocking.
 	ready, errno := wF.Poll(pflag, timeout)
-	require.EqualErrno(t, experimentalsys.ENOTSUP, errno)
-	require.False(t, ready)
+	if runtime.GOOS == "windows" {
+		// We don't yet implement write blocking on Windows.
+		require.EqualErrno(t, experimentalsys.ENOTSUP, errno)
+		require.False(t, ready)
+		return
+	}
+
+	// An empty pipe has room to write.
+	require.EqualErrno(t, 0, errno)
+	require.True(t, ready)
 }
 
 func requireRead(t *testing.T, f experimentalsys.File, buf []byte) {
@@ -432,16 +526,32 @@ func (m *maskFS) Open(name string) (fs.File, error) {
 	return struct{ fs.File }{f}, err
 }
 
+// noSeekFS masks io.Seeker and io.ReaderAt on files it opens.
+type noSeekFS struct {
+	fs.FS
+}
+
+func (m *noSeekFS) Open(name string) (fs.File, error) {
+	f, err := m.FS.Open(name)
+	if d, ok := f.(fs.ReadDirFile); ok {
+		return struct{ fs.ReadDirFile }{d}, err
+	}
+	return struct{ fs.File }{f}, err
+}
+
+// TestFilePread_Unsupported ensures files that can't be re-opened, such as
+// stdin, don't emulate Pread.
 func TestFilePread_Unsupported(t *testing.T) {
 	embedFS, err := fs.Sub(testdata, "testdata")
 	require.NoError(t, err)
 
-	f, errno := OpenFSFile(&maskFS{embedFS}, emptyFile, experimentalsys.O_RDONLY, 0)
-	require.EqualErrno(t, 0, errno)
+	file, err := (&maskFS{embedFS}).Open(wazeroFile)
+	require.NoError(t, err)
+	f := &fsFile{file: file}
 	defer f.Close()
 
 	buf := make([]byte, 3)
-	_, errno = f.Pread(buf, 0)
+	_, errno := f.Pread(buf, 0)
 	require.EqualErrno(t, experimentalsys.ENOSYS, errno)
 }
 
@@ -500,6 +610,9 @@ func TestFileSeek(t *testing.T) {
 		{name: "fsFile fstest.MapFS", openFile: func(name string) (experimentalsys.File, experimentalsys.Errno) {
 			return OpenFSFile(mapFS, name, experimentalsys.O_RDONLY, 0)
 		}},
+		{name: "fsFile noSeek(embed.api.FS)", openFile: func(name string) (experimentalsys.File, experimentalsys.Errno) {
+			return OpenFSFile(&noSeekFS{embedFS}, name, experimentalsys.O_RDONLY, 0)
+		}},
 		{name: "osFile", openFile: func(name string) (experimentalsys.File, experimentalsys.Errno) {
 			return OpenOSFile(path.Join(tmpDir, name), experimentalsys.O_RDONLY, 0o666)
 		}},
@@ -629,15 +742,23 @@ func TestFileSeek_empty(t *testing.T) {
 	}
 }
 
+// TestFileSeek_Unsupported ensures files that can't be re-opened, such as
+// stdin, can only seek forward.
 func TestFileSeek_Unsupported(t *testing.T) {
 	embedFS, err := fs.Sub(testdata, "testdata")
 	require.NoError(t, err)
 
-	f, errno := OpenFSFile(&maskFS{embedFS}, emptyFile, experimentalsys.O_RDONLY, 0)
-	require.EqualErrno(t, 0, errno)
+	file, err := (&maskFS{embedFS}).Open(wazeroFile)
+	require.NoError(t, err)
+	f := &fsFile{file: file}
 	defer f.Close()
 
-	_, errno = f.Seek(0, io.SeekCurrent)
+	require.Equal(t, int64(2), requireSeek(t, f, 2, io.SeekCurrent))
+	buf := make([]byte, 3)
+	requireRead(t, f, buf)
+	require.Equal(t, "zer", string(buf))
+
+	_, errno := f.Seek(0, io.SeekStart)
 	require.EqualErrno(t, experimentalsys.ENOSYS, errno)
 }
 
@@ -682,6 +803,49 @@ func TestFileWriteAndPwrite(t *testing.T) {
 	require.Equal(t, "wazerowazeroero", string(b))
 }
 
+// TestFilePwrite_Seeker ensures Pwrite is emulated with io.Seeker when the
+// file isn't an io.WriterAt, without changing the offset of Write.
+func TestFilePwrite_Seeker(t *testing.T) {
+	file := &writeSeekFile{}
+	f := &fsFile{file: file}
+
+	requireWrite(t, f, []byte("waz"))
+	requirePwrite(t, f, []byte("waz"), 6)
+	requireWrite(t, f, []byte("ero"))
+	requirePwrite(t, f, []byte("ero"), 9)
+	require.Equal(t, "wazerowazero", string(file.buf))
+
+	_, errno := f.Pwrite([]byte("a"), -1)
+	require.EqualErrno(t, experimentalsys.EINVAL, errno)
+}
+
+// writeSeekFile is an in-memory io.WriteSeeker which isn't an io.WriterAt.
+type writeSeekFile struct {
+	fs.File
+	buf []byte
+	pos int64
+}
+
+func (w *writeSeekFile) Write(p []byte) (int, error) {
+	if end := w.pos + int64(len(p)); end > int64(len(w.buf)) {
+		w.buf = append(w.buf, make([]byte, end-int64(len(w.buf)))...)
+	}
+	n := copy(w.buf[w.pos:], p)
+	w.pos += int64(n)
+	return n, nil
+}
+
+func (w *writeSeekFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += w.pos
+	case io.SeekEnd:
+		offset += int64(len(w.buf))
+	}
+	w.pos = offset
+	return offset, nil
+}
+
 func requireWrite(t *testing.T, f experimentalsys.File, buf []byte) {
 	n, errno := f.Write(buf)
 	require.EqualErrno(t, 0, errno)
@@ -822,11 +986,16 @@ func testSync_NoError(t *testing.T, sync func(experimentalsys.File) experimental
 	require.EqualErrno(t, 0, errno)
 	defer ro.Close()
 
-	rwPath := path.Join(t.TempDir(), "datasync")
+	tmpDir := t.TempDir()
+	rwPath := path.Join(tmpDir, "datasync")
 	rw, errno := OpenOSFile(rwPath, experimentalsys.O_CREAT|experimentalsys.O_RDWR, 0o600)
 	require.EqualErrno(t, 0, errno)
 	defer rw.Close()
 
+	fsRW, errno := OpenFSFile(flagFS(tmpDir), "datasync", experimentalsys.O_RDWR, 0)
+	require.EqualErrno(t, 0, errno)
+	defer fsRW.Close()
+
 	tests := []struct {
 		name string
 		f    experimentalsys.File
@@ -834,6 +1003,7 @@ func testSync_NoError(t *testing.T, sync func(experimentalsys.File) experimental
 		{name: "UnimplementedFile", f: experimentalsys.UnimplementedFile{}},
 		{name: "File of read-only FS.File", f: ro},
 		{name: "File of os.File", f: rw},
+		{name: "File of writable FS.File", f: fsRW},
 	}
 
 	for _, tt := range tests {
@@ -845,6 +1015,97 @@ func testSync_NoError(t *testing.T, sync func(experimentalsys.File) experimental
 	}
 }
 
+func TestAdvise(t *testing.T) {
+	fPath := path.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(fPath, []byte("wazero"), 0o600))
+
+	osF, errno := OpenOSFile(fPath, experimentalsys.O_RDONLY, 0)
+	require.EqualErrno(t, 0, errno)
+	defer osF.Close()
+
+	fsF, errno := OpenFSFile(embedFS, "file_test.go", experimentalsys.O_RDONLY, 0)
+	require.EqualErrno(t, 0, errno)
+	defer fsF.Close()
+
+	for _, f := range []experimentalsys.File{osF, fsF} {
+		for advice := AdviceNormal; advice <= AdviceNoReuse; advice++ {
+			require.EqualErrno(t, 0, Advise(f, 0, 0, advice))
+			require.EqualErrno(t, 0, Advise(f, 2, 3, advice))
+		}
+		require.EqualErrno(t, experimentalsys.EINVAL, Advise(f, 0, 0, AdviceNoReuse+1))
+	}
+
+	require.EqualErrno(t, 0, osF.Close())
+	require.EqualErrno(t, experimentalsys.EBADF, Advise(osF, 0, 0, AdviceNormal))
+}
+
+func TestLock(t *testing.T) {
+	fPath := path.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(fPath, []byte("wazero"), 0o600))
+
+	fsF, errno := OpenFSFile(embedFS, "file_test.go", experimentalsys.O_RDONLY, 0)
+	require.EqualErrno(t, 0, errno)
+	defer fsF.Close()
+	require.EqualErrno(t, experimentalsys.ENOSYS, Lock(fsF, LockShared, true))
+	require.EqualErrno(t, experimentalsys.EINVAL, Lock(fsF, LockExclusive+1, true))
+
+	switch runtime.GOOS {
+	case "linux", "darwin", "freebsd":
+	default:
+		t.Skip("flock is unsupported on", runtime.GOOS)
+	}
+
+	// Locks of different open files of the same host file conflict.
+	a, errno := OpenOSFile(fPath, experimentalsys.O_RDONLY, 0)
+	require.EqualErrno(t, 0, errno)
+	defer a.Close()
+	b, errno := OpenOSFile(fPath, experimentalsys.O_RDONLY, 0)
+	require.EqualErrno(t, 0, errno)
+	defer b.Close()
+
+	require.EqualErrno(t, 0, Lock(a, LockShared, true))
+	require.EqualErrno(t, 0, Lock(b, LockShared, true))
+	require.EqualErrno(t, experimentalsys.EAGAIN, Lock(b, LockExclusive, true))
+
+	require.EqualErrno(t, 0, Lock(a, LockNone, true))
+	require.EqualErrno(t, 0, Lock(b, LockExclusive, true))
+	require.EqualErrno(t, experimentalsys.EAGAIN, Lock(a, LockShared, true))
+
+	// Closing the file releases its lock.
+	require.EqualErrno(t, 0, b.Close())
+	require.EqualErrno(t, experimentalsys.EBADF, Lock(b, LockNone, true))
+	require.EqualErrno(t, 0, Lock(a, LockExclusive, true))
+}
+
+// syncCountFile is a fs.File which counts calls to Sync.
+type syncCountFile struct {
+	fs.File
+	count int
+}
+
+// Sync implements the same method as documented on syncFile
+func (f *syncCountFile) Sync() error {
+	f.count++
+	return nil
+}
+
+func TestFSFileSync(t *testing.T) {
+	file, err := embedFS.Open("file_test.go")
+	require.NoError(t, err)
+
+	sf := &syncCountFile{File: file}
+	f := &fsFile{file: sf}
+
+	require.EqualErrno(t, 0, f.Sync())
+	require.EqualErrno(t, 0, f.Datasync())
+	require.Equal(t, 2, sf.count)
+
+	require.EqualErrno(t, 0, f.Close())
+	require.EqualErrno(t, experimentalsys.EBADF, f.Sync())
+	require.EqualErrno(t, experimentalsys.EBADF, f.Datasync())
+	require.Equal(t, 2, sf.count)
+}
+
 func TestFileSync(t *testing.T) {
 	testSync(t, experimentalsys.File.Sync)
 }
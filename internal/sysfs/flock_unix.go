@@ -0,0 +1,29 @@
+//go:build linux || darwin || freebsd
+
+package sysfs
+
+import (
+	"syscall"
+
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+)
+
+// flockHow maps LockType to the corresponding LOCK_* operation.
+var flockHow = [...]int{
+	LockNone:      syscall.LOCK_UN,
+	LockShared:    syscall.LOCK_SH,
+	LockExclusive: syscall.LOCK_EX,
+}
+
+func flock(fd uintptr, typ LockType, nonblock bool) experimentalsys.Errno {
+	how := flockHow[typ]
+	if nonblock {
+		how |= syscall.LOCK_NB
+	}
+	for {
+		err := syscall.Flock(int(fd), how)
+		if err != syscall.EINTR {
+			return experimentalsys.UnwrapOSError(err)
+		}
+	}
+}
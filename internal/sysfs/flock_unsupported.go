@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd)
+
+package sysfs
+
+import (
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+)
+
+func flock(uintptr, LockType, bool) experimentalsys.Errno {
+	return experimentalsys.ENOSYS
+}
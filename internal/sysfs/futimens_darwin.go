@@ -18,11 +18,20 @@ const (
 func utimensat(dirfd int, path string, times *[2]syscall.Timespec, flags int) error
 
 func utimens(path string, atim, mtim int64) experimentalsys.Errno {
+	return utimensPath(path, atim, mtim, 0)
+}
+
+// lutimens is like utimens, except it updates a symbolic link instead of its
+// target.
+func lutimens(path string, atim, mtim int64) experimentalsys.Errno {
+	return utimensPath(path, atim, mtim, _AT_SYMLINK_NOFOLLOW)
+}
+
+func utimensPath(path string, atim, mtim int64, flags int) experimentalsys.Errno {
 	times := timesToTimespecs(atim, mtim)
 	if times == nil {
 		return 0
 	}
-	var flags int
 	return experimentalsys.UnwrapOSError(utimensat(_AT_FDCWD, path, times, flags))
 }
 
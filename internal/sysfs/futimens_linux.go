@@ -9,17 +9,27 @@ import (
 )
 
 const (
-	_AT_FDCWD   = -0x64
-	_UTIME_OMIT = (1 << 30) - 2
+	_AT_FDCWD            = -0x64
+	_AT_SYMLINK_NOFOLLOW = 0x100
+	_UTIME_OMIT          = (1 << 30) - 2
 )
 
 func utimens(path string, atim, mtim int64) experimentalsys.Errno {
+	return utimensPath(path, atim, mtim, 0)
+}
+
+// lutimens is like utimens, except it updates a symbolic link instead of its
+// target.
+func lutimens(path string, atim, mtim int64) experimentalsys.Errno {
+	return utimensPath(path, atim, mtim, _AT_SYMLINK_NOFOLLOW)
+}
+
+func utimensPath(path string, atim, mtim int64, flags int) experimentalsys.Errno {
 	times := timesToTimespecs(atim, mtim)
 	if times == nil {
 		return 0
 	}
 
-	var flags int
 	var _p0 *byte
 	_p0, err := syscall.BytePtrFromString(path)
 	if err == nil {
@@ -3,6 +3,8 @@
 package sysfs
 
 import (
+	"io/fs"
+
 	"github.com/tetratelabs/wazero/experimental/sys"
 )
 
@@ -10,6 +12,16 @@ func utimens(path string, atim, mtim int64) sys.Errno {
 	return chtimes(path, atim, mtim)
 }
 
+func lutimens(path string, atim, mtim int64) sys.Errno {
+	// Go doesn't export a portable way to update a symbolic link's times.
+	if st, errno := lstat(path); errno != 0 {
+		return errno
+	} else if st.Mode&fs.ModeSymlink != 0 {
+		return sys.ENOSYS
+	}
+	return utimens(path, atim, mtim)
+}
+
 func futimens(fd uintptr, atim, mtim int64) error {
 	// Go exports syscall.Futimes, which is microsecond granularity, and
 	// WASI tests expect nanosecond. We don't yet have a way to invoke the
@@ -1,6 +1,7 @@
 package sysfs
 
 import (
+	"io/fs"
 	"syscall"
 
 	"github.com/tetratelabs/wazero/experimental/sys"
@@ -11,6 +12,16 @@ func utimens(path string, atim, mtim int64) sys.Errno {
 	return chtimes(path, atim, mtim)
 }
 
+func lutimens(path string, atim, mtim int64) sys.Errno {
+	// Go doesn't export a portable way to update a symbolic link's times.
+	if st, errno := lstat(path); errno != 0 {
+		return errno
+	} else if st.Mode&fs.ModeSymlink != 0 {
+		return sys.ENOSYS
+	}
+	return utimens(path, atim, mtim)
+}
+
 func futimens(fd uintptr, atim, mtim int64) error {
 	// Before Go 1.20, ERROR_INVALID_HANDLE was returned for too many reasons.
 	// Kick out so that callers can use path-based operations instead.
@@ -0,0 +1,255 @@
+package sysfs
+
+import (
+	"io/fs"
+	"strconv"
+	"time"
+
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// FSOp is an operation on a sys.FS, observed by FSListener.
+type FSOp uint8
+
+const (
+	FSOpOpenFile FSOp = iota
+	FSOpLstat
+	FSOpStat
+	FSOpMkdir
+	FSOpChmod
+	FSOpRename
+	FSOpRmdir
+	FSOpUnlink
+	FSOpLink
+	FSOpSymlink
+	FSOpReadlink
+	FSOpUtimens
+)
+
+// String returns the name of the sys.FS method, e.g. "OpenFile".
+func (o FSOp) String() string {
+	switch o {
+	case FSOpOpenFile:
+		return "OpenFile"
+	case FSOpLstat:
+		return "Lstat"
+	case FSOpStat:
+		return "Stat"
+	case FSOpMkdir:
+		return "Mkdir"
+	case FSOpChmod:
+		return "Chmod"
+	case FSOpRename:
+		return "Rename"
+	case FSOpRmdir:
+		return "Rmdir"
+	case FSOpUnlink:
+		return "Unlink"
+	case FSOpLink:
+		return "Link"
+	case FSOpSymlink:
+		return "Symlink"
+	case FSOpReadlink:
+		return "Readlink"
+	case FSOpUtimens:
+		return "Utimens"
+	}
+	return "FSOp(" + strconv.Itoa(int(o)) + ")"
+}
+
+// FSEvent describes an operation on a mounted sys.FS.
+type FSEvent struct {
+	// Op is the operation.
+	Op FSOp
+
+	// GuestPath is the guest path the sys.FS is mounted at, e.g. "/tmp".
+	GuestPath string
+
+	// Path is the path in the sys.FS, or the link target for FSOpSymlink.
+	Path string
+
+	// NewPath is the second path of FSOpRename or FSOpLink, or the link name
+	// for FSOpSymlink.
+	NewPath string
+
+	// Flag is the open flag of FSOpOpenFile, or sys.O_NOFOLLOW when
+	// FSOpUtimens doesn't follow a symbolic link.
+	Flag experimentalsys.Oflag
+}
+
+// FSListener observes operations on a sys.FS, for example to audit which
+// files a guest accesses.
+//
+// Note: Operations on an open file, such as Read, are not observed.
+type FSListener interface {
+	// Before is called before the operation is attempted.
+	Before(event FSEvent)
+
+	// After is called after the operation, with its result and latency.
+	After(event FSEvent, errno experimentalsys.Errno, elapsed time.Duration)
+}
+
+// ListenerFS returns a sys.FS which notifies `listener` before and after
+// each operation on `fsys`, mounted at `guestPath`.
+func ListenerFS(fsys experimentalsys.FS, guestPath string, listener FSListener) experimentalsys.FS {
+	return &listenerFS{FS: fsys, guestPath: guestPath, listener: listener}
+}
+
+type listenerFS struct {
+	experimentalsys.FS
+
+	guestPath string
+	listener  FSListener
+}
+
+// String implements fmt.Stringer
+func (l *listenerFS) String() string {
+	if s, ok := l.FS.(interface{ String() string }); ok {
+		return s.String()
+	}
+	return l.guestPath
+}
+
+// before notifies the listener and returns the time to pass to after.
+func (l *listenerFS) before(event FSEvent) time.Time {
+	event.GuestPath = l.guestPath
+	l.listener.Before(event)
+	return time.Now()
+}
+
+// after notifies the listener of the result of the operation begun at start.
+func (l *listenerFS) after(event FSEvent, start time.Time, errno experimentalsys.Errno) {
+	elapsed := time.Since(start)
+	event.GuestPath = l.guestPath
+	l.listener.After(event, errno, elapsed)
+}
+
+// OpenFile implements the same method as documented on sys.FS
+func (l *listenerFS) OpenFile(path string, flag experimentalsys.Oflag, perm fs.FileMode) (experimentalsys.File, experimentalsys.Errno) {
+	event := FSEvent{Op: FSOpOpenFile, Path: path, Flag: flag}
+	start := l.before(event)
+	f, errno := l.FS.OpenFile(path, flag, perm)
+	l.after(event, start, errno)
+	return f, errno
+}
+
+// Lstat implements the same method as documented on sys.FS
+func (l *listenerFS) Lstat(path string) (sys.Stat_t, experimentalsys.Errno) {
+	event := FSEvent{Op: FSOpLstat, Path: path}
+	start := l.before(event)
+	st, errno := l.FS.Lstat(path)
+	l.after(event, start, errno)
+	return st, errno
+}
+
+// Stat implements the same method as documented on sys.FS
+func (l *listenerFS) Stat(path string) (sys.Stat_t, experimentalsys.Errno) {
+	event := FSEvent{Op: FSOpStat, Path: path}
+	start := l.before(event)
+	st, errno := l.FS.Stat(path)
+	l.after(event, start, errno)
+	return st, errno
+}
+
+// Mkdir implements the same method as documented on sys.FS
+func (l *listenerFS) Mkdir(path string, perm fs.FileMode) experimentalsys.Errno {
+	event := FSEvent{Op: FSOpMkdir, Path: path}
+	start := l.before(event)
+	errno := l.FS.Mkdir(path, perm)
+	l.after(event, start, errno)
+	return errno
+}
+
+// Chmod implements the same method as documented on sys.FS
+func (l *listenerFS) Chmod(path string, perm fs.FileMode) experimentalsys.Errno {
+	event := FSEvent{Op: FSOpChmod, Path: path}
+	start := l.before(event)
+	errno := l.FS.Chmod(path, perm)
+	l.after(event, start, errno)
+	return errno
+}
+
+// Rename implements the same method as documented on sys.FS
+func (l *listenerFS) Rename(from, to string) experimentalsys.Errno {
+	event := FSEvent{Op: FSOpRename, Path: from, NewPath: to}
+	start := l.before(event)
+	errno := l.FS.Rename(from, to)
+	l.after(event, start, errno)
+	return errno
+}
+
+// Rmdir implements the same method as documented on sys.FS
+func (l *listenerFS) Rmdir(path string) experimentalsys.Errno {
+	event := FSEvent{Op: FSOpRmdir, Path: path}
+	start := l.before(event)
+	errno := l.FS.Rmdir(path)
+	l.after(event, start, errno)
+	return errno
+}
+
+// Unlink implements the same method as documented on sys.FS
+func (l *listenerFS) Unlink(path string) experimentalsys.Errno {
+	event := FSEvent{Op: FSOpUnlink, Path: path}
+	start := l.before(event)
+	errno := l.FS.Unlink(path)
+	l.after(event, start, errno)
+	return errno
+}
+
+// Link implements the same method as documented on sys.FS
+func (l *listenerFS) Link(oldPath, newPath string) experimentalsys.Errno {
+	event := FSEvent{Op: FSOpLink, Path: oldPath, NewPath: newPath}
+	start := l.before(event)
+	errno := l.FS.Link(oldPath, newPath)
+	l.after(event, start, errno)
+	return errno
+}
+
+// Symlink implements the same method as documented on sys.FS
+func (l *listenerFS) Symlink(oldPath, linkName string) experimentalsys.Errno {
+	event := FSEvent{Op: FSOpSymlink, Path: oldPath, NewPath: linkName}
+	start := l.before(event)
+	errno := l.FS.Symlink(oldPath, linkName)
+	l.after(event, start, errno)
+	return errno
+}
+
+// Readlink implements the same method as documented on sys.FS
+func (l *listenerFS) Readlink(path string) (string, experimentalsys.Errno) {
+	event := FSEvent{Op: FSOpReadlink, Path: path}
+	start := l.before(event)
+	dst, errno := l.FS.Readlink(path)
+	l.after(event, start, errno)
+	return dst, errno
+}
+
+// Utimens implements the same method as documented on sys.FS
+func (l *listenerFS) Utimens(path string, atim, mtim int64) experimentalsys.Errno {
+	event := FSEvent{Op: FSOpUtimens, Path: path}
+	start := l.before(event)
+	errno := l.FS.Utimens(path, atim, mtim)
+	l.after(event, start, errno)
+	return errno
+}
+
+// Lutimens implements the same method as documented on lutimensFS
+func (l *listenerFS) Lutimens(path string, atim, mtim int64) experimentalsys.Errno {
+	event := FSEvent{Op: FSOpUtimens, Path: path, Flag: experimentalsys.O_NOFOLLOW}
+	start := l.before(event)
+	errno := Lutimens(l.FS, path, atim, mtim)
+	l.after(event, start, errno)
+	return errno
+}
+
+// IsReadOnly returns true if `fsys` is a ReadFS, even if it was wrapped by
+// ListenerFS.
+func IsReadOnly(fsys experimentalsys.FS) bool {
+	switch f := fsys.(type) {
+	case *ReadFS:
+		return true
+	case *listenerFS:
+		return IsReadOnly(f.FS)
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package sysfs
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+type recordingFSListener struct {
+	log strings.Builder
+}
+
+func (l *recordingFSListener) Before(e FSEvent) {
+	fmt.Fprintf(&l.log, "--> %s(%s,%s,%s)\n", e.Op, e.GuestPath, e.Path, e.NewPath)
+}
+
+func (l *recordingFSListener) After(e FSEvent, errno sys.Errno, elapsed time.Duration) {
+	if elapsed < 0 {
+		panic("negative elapsed")
+	}
+	fmt.Fprintf(&l.log, "<-- %s(%s,%s,%s)=%s\n", e.Op, e.GuestPath, e.Path, e.NewPath, errno)
+}
+
+type flagFSListener struct {
+	flags []sys.Oflag
+}
+
+func (l *flagFSListener) Before(e FSEvent) {}
+
+func (l *flagFSListener) After(e FSEvent, _ sys.Errno, _ time.Duration) {
+	l.flags = append(l.flags, e.Flag)
+}
+
+func TestListenerFS(t *testing.T) {
+	l := &recordingFSListener{}
+	testFS := ListenerFS(MemFS(), "/tmp", l)
+
+	f, errno := testFS.OpenFile("file", sys.O_RDWR|sys.O_CREAT, 0o600)
+	require.EqualErrno(t, 0, errno)
+	require.EqualErrno(t, 0, f.Close())
+	require.EqualErrno(t, 0, testFS.Mkdir("dir", 0o700))
+	require.EqualErrno(t, 0, testFS.Chmod("file", 0o400))
+	_, errno = testFS.Stat("file")
+	require.EqualErrno(t, 0, errno)
+	require.EqualErrno(t, 0, testFS.Symlink("file", "link"))
+	_, errno = testFS.Lstat("link")
+	require.EqualErrno(t, 0, errno)
+	_, errno = testFS.Readlink("link")
+	require.EqualErrno(t, 0, errno)
+	require.EqualErrno(t, 0, testFS.Utimens("file", sys.UTIME_OMIT, sys.UTIME_OMIT))
+	require.EqualErrno(t, 0, testFS.Link("file", "hard"))
+	require.EqualErrno(t, 0, testFS.Rename("hard", "dir/hard"))
+	require.EqualErrno(t, 0, testFS.Unlink("dir/hard"))
+	require.EqualErrno(t, 0, testFS.Rmdir("dir"))
+	require.EqualErrno(t, sys.ENOENT, testFS.Rmdir("dir"))
+
+	require.Equal(t, `--> OpenFile(/tmp,file,)
+<-- OpenFile(/tmp,file,)=success
+--> Mkdir(/tmp,dir,)
+<-- Mkdir(/tmp,dir,)=success
+--> Chmod(/tmp,file,)
+<-- Chmod(/tmp,file,)=success
+--> Stat(/tmp,file,)
+<-- Stat(/tmp,file,)=success
+--> Symlink(/tmp,file,link)
+<-- Symlink(/tmp,file,link)=success
+--> Lstat(/tmp,link,)
+<-- Lstat(/tmp,link,)=success
+--> Readlink(/tmp,link,)
+<-- Readlink(/tmp,link,)=success
+--> Utimens(/tmp,file,)
+<-- Utimens(/tmp,file,)=success
+--> Link(/tmp,file,hard)
+<-- Link(/tmp,file,hard)=success
+--> Rename(/tmp,hard,dir/hard)
+<-- Rename(/tmp,hard,dir/hard)=success
+--> Unlink(/tmp,dir/hard,)
+<-- Unlink(/tmp,dir/hard,)=success
+--> Rmdir(/tmp,dir,)
+<-- Rmdir(/tmp,dir,)=success
+--> Rmdir(/tmp,dir,)
+<-- Rmdir(/tmp,dir,)=no such file or directory
+`, l.log.String())
+}
+
+func TestListenerFS_Flag(t *testing.T) {
+	l := &flagFSListener{}
+	testFS := ListenerFS(MemFS(), "/", l)
+
+	f, errno := testFS.OpenFile("file", sys.O_WRONLY|sys.O_CREAT|sys.O_TRUNC, 0o600)
+	require.EqualErrno(t, 0, errno)
+	require.EqualErrno(t, 0, f.Close())
+	require.EqualErrno(t, 0, testFS.Utimens("file", sys.UTIME_OMIT, sys.UTIME_OMIT))
+	require.EqualErrno(t, 0, Lutimens(testFS, "file", sys.UTIME_OMIT, sys.UTIME_OMIT))
+
+	require.Equal(t, []sys.Oflag{sys.O_WRONLY | sys.O_CREAT | sys.O_TRUNC, 0, sys.O_NOFOLLOW}, l.flags)
+}
+
+func TestIsReadOnly(t *testing.T) {
+	readFS := &ReadFS{FS: MemFS()}
+	require.True(t, IsReadOnly(readFS))
+	require.True(t, IsReadOnly(ListenerFS(readFS, "/", &recordingFSListener{})))
+	require.False(t, IsReadOnly(MemFS()))
+	require.False(t, IsReadOnly(ListenerFS(MemFS(), "/", &recordingFSListener{})))
+}
@@ -0,0 +1,50 @@
+package sysfs
+
+import (
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+)
+
+// LockType is the type of an advisory lock on a whole file, used by Lock.
+type LockType uint8
+
+const (
+	// LockNone means no lock is held, so locking with it unlocks the file.
+	LockNone LockType = iota
+	// LockShared is a lock which any number of holders can hold at the same
+	// time, usually to read.
+	LockShared
+	// LockExclusive is a lock which only one holder can hold, usually to
+	// write.
+	LockExclusive
+)
+
+// lockFile is implemented by files which can lock the host file, such as
+// osFile on platforms with flock.
+type lockFile interface {
+	lock(typ LockType, nonblock bool) experimentalsys.Errno
+}
+
+// Lock applies or removes an advisory lock on the whole file, like `flock` in
+// BSD. The lock belongs to the open file, so it conflicts with locks of other
+// open files of the same host file, including in other processes.
+//
+// When `nonblock` is true, a conflicting lock fails with EAGAIN instead of
+// waiting for it to be released.
+//
+// # Errors
+//
+// A zero Errno is success. The below are expected otherwise:
+//   - ENOSYS: the file isn't backed by a host file which can be locked.
+//   - EBADF: the file was closed.
+//   - EINVAL: `typ` is invalid.
+//   - EAGAIN: `nonblock` is true and a conflicting lock is held.
+//
+// See https://man.freebsd.org/cgi/man.cgi?query=flock&sektion=2
+func Lock(f experimentalsys.File, typ LockType, nonblock bool) experimentalsys.Errno {
+	if typ > LockExclusive {
+		return experimentalsys.EINVAL
+	} else if lf, ok := f.(lockFile); ok {
+		return lf.lock(typ, nonblock)
+	}
+	return experimentalsys.ENOSYS
+}
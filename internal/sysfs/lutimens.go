@@ -0,0 +1,33 @@
+package sysfs
+
+import (
+	"io/fs"
+
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+)
+
+// lutimensFS is implemented by sys.FS types which can update the times of a
+// symbolic link, as opposed to its target.
+type lutimensFS interface {
+	// Lutimens is like sys.FS Utimens, except if the path is a symbolic link,
+	// the link itself is updated.
+	Lutimens(path string, atim, mtim int64) experimentalsys.Errno
+}
+
+// Lutimens is like sys.FS Utimens, except if the path is a symbolic link, the
+// link itself is updated. This returns sys.ENOSYS for a symbolic link when
+// the sys.FS doesn't implement lutimensFS.
+//
+// Note: This is like `utimensat` with `AT_SYMLINK_NOFOLLOW` in POSIX.
+func Lutimens(fsys experimentalsys.FS, path string, atim, mtim int64) experimentalsys.Errno {
+	if l, ok := fsys.(lutimensFS); ok {
+		return l.Lutimens(path, atim, mtim)
+	}
+
+	if st, errno := fsys.Lstat(path); errno != 0 {
+		return errno
+	} else if st.Mode&fs.ModeSymlink != 0 {
+		return experimentalsys.ENOSYS
+	}
+	return fsys.Utimens(path, atim, mtim)
+}
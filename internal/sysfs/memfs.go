@@ -0,0 +1,803 @@
+package sysfs
+
+import (
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/internal/fsapi"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// maxSymlinks is the maximum symbolic links followed while resolving a path,
+// matching MAXSYMLINKS on Linux.
+const maxSymlinks = 40
+
+// memFSDev is incremented for each MemFS, so that Stat_t.Dev can be used
+// with Stat_t.Ino to determine if two files are the same.
+var memFSDev uint64
+
+// MemFS returns a new writable filesystem held entirely in memory. It is
+// empty except for its root directory.
+//
+// The result is safe for concurrent use, so can be mounted by multiple
+// modules. Timestamps are taken from the host clock.
+func MemFS() experimentalsys.FS {
+	m := &memFS{dev: atomic.AddUint64(&memFSDev, 1)}
+	m.root = m.newNode(fs.ModeDir | 0o755)
+	return m
+}
+
+// memFS is not exported because the root must be initialized by MemFS.
+type memFS struct {
+	experimentalsys.UnimplementedFS
+
+	// mu guards all nodes and their contents, including those referenced by
+	// open files.
+	mu sync.Mutex
+
+	dev     uint64
+	lastIno sys.Inode
+	root    *memNode
+}
+
+// memNode is a directory, regular file or symbolic link.
+type memNode struct {
+	ino  sys.Inode
+	mode fs.FileMode
+
+	// nlink is the count of directory entries referencing this node. This
+	// is only tracked for non-directories, as directories cannot be linked.
+	nlink uint64
+
+	atim, mtim, ctim int64
+
+	// data is the contents of a regular file.
+	data []byte
+
+	// target is the contents of a symbolic link.
+	target string
+
+	// entries are the children of a directory, by base name.
+	entries map[string]*memNode
+}
+
+func (n *memNode) isDir() bool {
+	return n.mode.IsDir()
+}
+
+func (n *memNode) isSymlink() bool {
+	return n.mode&fs.ModeSymlink != 0
+}
+
+// String implements fmt.Stringer
+func (m *memFS) String() string {
+	return "memfs"
+}
+
+// newNode returns a new node with the given mode. The caller must hold mu.
+func (m *memFS) newNode(mode fs.FileMode) *memNode {
+	m.lastIno++
+	now := time.Now().UnixNano()
+	n := &memNode{ino: m.lastIno, mode: mode, nlink: 1, atim: now, mtim: now, ctim: now}
+	if n.isDir() {
+		n.entries = map[string]*memNode{}
+	}
+	return n
+}
+
+// walk resolves `path` to its parent directory, base name and node. When the
+// node doesn't exist, but its parent does, `n` is nil and `errno` is zero.
+// When `path` resolves to the root or ends with "..", `dir` is nil and
+// `name` is empty. The caller must hold mu.
+//
+// Symbolic links are followed in all but the last path component, which is
+// only followed when `followLast` is set. Link targets are resolved relative
+// to the directory containing the link, or the root if absolute.
+func (m *memFS) walk(path string, followLast bool) (dir *memNode, name string, n *memNode, errno experimentalsys.Errno) {
+	trailingSlash := strings.HasSuffix(path, "/")
+	if trailingSlash {
+		followLast = true // e.g. "link/" refers to the directory, not the link.
+	}
+
+	stack := []*memNode{m.root}
+	queue := splitPath(nil, path)
+	links := 0
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if c == ".." {
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+
+		cur := stack[len(stack)-1]
+		if !cur.isDir() {
+			return nil, "", nil, experimentalsys.ENOTDIR
+		}
+
+		isLast := len(queue) == 0
+		child, ok := cur.entries[c]
+		switch {
+		case !ok && isLast:
+			return cur, c, nil, 0
+		case !ok:
+			return nil, "", nil, experimentalsys.ENOENT
+		case child.isSymlink() && (!isLast || followLast):
+			if links++; links > maxSymlinks {
+				return nil, "", nil, experimentalsys.ELOOP
+			}
+			if strings.HasPrefix(child.target, "/") {
+				stack = stack[:1]
+			}
+			queue = splitPath(splitPath(nil, child.target), strings.Join(queue, "/"))
+			if len(queue) == 0 { // e.g. the target was "."
+				dir, name, n = nil, "", stack[len(stack)-1]
+				return
+			}
+		case isLast:
+			if trailingSlash && !child.isDir() {
+				return nil, "", nil, experimentalsys.ENOTDIR
+			}
+			return cur, c, child, 0
+		default:
+			stack = append(stack, child)
+		}
+	}
+	// The path was the root, or ended with "..".
+	return nil, "", stack[len(stack)-1], 0
+}
+
+// splitPath appends the non-empty components of `path` to `dst`, skipping
+// any "." components.
+func splitPath(dst []string, path string) []string {
+	for _, c := range strings.Split(path, "/") {
+		if c != "" && c != "." {
+			dst = append(dst, c)
+		}
+	}
+	return dst
+}
+
+// OpenFile implements the same method as documented on sys.FS
+func (m *memFS) OpenFile(path string, flag experimentalsys.Oflag, perm fs.FileMode) (experimentalsys.File, experimentalsys.Errno) {
+	if flag&experimentalsys.O_DIRECTORY != 0 && flag&(experimentalsys.O_WRONLY|experimentalsys.O_RDWR) != 0 {
+		return nil, experimentalsys.EISDIR // invalid to open a directory writeable
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dir, name, n, errno := m.walk(path, flag&experimentalsys.O_NOFOLLOW == 0)
+	if errno != 0 {
+		return nil, errno
+	}
+
+	f := &memFile{fs: m, flag: flag}
+	if n == nil {
+		if flag&experimentalsys.O_CREAT == 0 {
+			return nil, experimentalsys.ENOENT
+		} else if flag&experimentalsys.O_DIRECTORY != 0 {
+			return nil, experimentalsys.ENOENT
+		}
+		n = m.newNode(perm & fs.ModePerm)
+		dir.entries[name] = n
+		dir.mtim, dir.ctim = n.mtim, n.ctim
+	} else if flag&(experimentalsys.O_CREAT|experimentalsys.O_EXCL) == experimentalsys.O_CREAT|experimentalsys.O_EXCL {
+		return nil, experimentalsys.EEXIST
+	} else if n.isSymlink() {
+		return nil, experimentalsys.ELOOP // O_NOFOLLOW
+	} else if flag&experimentalsys.O_DIRECTORY != 0 && !n.isDir() {
+		return nil, experimentalsys.ENOTDIR
+	} else if n.isDir() && f.writable() {
+		return nil, experimentalsys.EISDIR
+	} else if flag&experimentalsys.O_TRUNC != 0 && f.writable() {
+		n.data = nil
+		n.mtim = time.Now().UnixNano()
+		n.ctim = n.mtim
+	}
+	f.node = n
+	return f, 0
+}
+
+// Lstat implements the same method as documented on sys.FS
+func (m *memFS) Lstat(path string) (sys.Stat_t, experimentalsys.Errno) {
+	return m.stat(path, false)
+}
+
+// Stat implements the same method as documented on sys.FS
+func (m *memFS) Stat(path string) (sys.Stat_t, experimentalsys.Errno) {
+	return m.stat(path, true)
+}
+
+func (m *memFS) stat(path string, follow bool) (sys.Stat_t, experimentalsys.Errno) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, _, n, errno := m.walk(path, follow); errno != 0 {
+		return sys.Stat_t{}, errno
+	} else if n == nil {
+		return sys.Stat_t{}, experimentalsys.ENOENT
+	} else {
+		return m.statNode(n), 0
+	}
+}
+
+// statNode returns the status of the node. The caller must hold mu.
+func (m *memFS) statNode(n *memNode) sys.Stat_t {
+	st := sys.Stat_t{
+		Dev:   m.dev,
+		Ino:   n.ino,
+		Mode:  n.mode,
+		Nlink: n.nlink,
+		Atim:  n.atim,
+		Mtim:  n.mtim,
+		Ctim:  n.ctim,
+	}
+	switch {
+	case n.isDir():
+		st.Nlink = 2 // "." and the entry in its parent
+		for _, e := range n.entries {
+			if e.isDir() {
+				st.Nlink++ // ".." of the subdirectory
+			}
+		}
+	case n.isSymlink():
+		st.Size = int64(len(n.target))
+	default:
+		st.Size = int64(len(n.data))
+	}
+	return st
+}
+
+// Mkdir implements the same method as documented on sys.FS
+func (m *memFS) Mkdir(path string, perm fs.FileMode) experimentalsys.Errno {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dir, name, n, errno := m.walk(path, false)
+	if errno != 0 {
+		return errno
+	} else if n != nil {
+		return experimentalsys.EEXIST
+	}
+	n = m.newNode(fs.ModeDir | perm&fs.ModePerm)
+	dir.entries[name] = n
+	dir.mtim, dir.ctim = n.mtim, n.ctim
+	return 0
+}
+
+// Chmod implements the same method as documented on sys.FS
+func (m *memFS) Chmod(path string, perm fs.FileMode) experimentalsys.Errno {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, _, n, errno := m.walk(path, true)
+	if errno != 0 {
+		return errno
+	} else if n == nil {
+		return experimentalsys.ENOENT
+	}
+	n.mode = n.mode&^fs.ModePerm | perm&fs.ModePerm
+	n.ctim = time.Now().UnixNano()
+	return 0
+}
+
+// Rename implements the same method as documented on sys.FS
+func (m *memFS) Rename(from, to string) experimentalsys.Errno {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fromDir, fromName, fromN, errno := m.walk(from, false)
+	if errno != 0 {
+		return errno
+	} else if fromN == nil {
+		return experimentalsys.ENOENT
+	} else if fromDir == nil {
+		return experimentalsys.EINVAL // e.g. the root
+	}
+
+	toDir, toName, toN, errno := m.walk(to, false)
+	if errno != 0 {
+		return errno
+	} else if toDir == nil {
+		return experimentalsys.EINVAL // e.g. the root
+	} else if toN == fromN {
+		return 0 // same file
+	}
+
+	if fromN.isDir() {
+		if toN != nil && !toN.isDir() {
+			return experimentalsys.ENOTDIR
+		} else if toN != nil && len(toN.entries) > 0 {
+			return experimentalsys.ENOTEMPTY
+		} else if toDir == fromN || containsDir(fromN, toDir) {
+			return experimentalsys.EINVAL // can't move a directory into itself
+		}
+	} else if toN != nil && toN.isDir() {
+		return experimentalsys.EISDIR
+	}
+
+	if toN != nil {
+		toN.nlink--
+	}
+	delete(fromDir.entries, fromName)
+	toDir.entries[toName] = fromN
+
+	now := time.Now().UnixNano()
+	fromDir.mtim, fromDir.ctim = now, now
+	toDir.mtim, toDir.ctim = now, now
+	fromN.ctim = now
+	return 0
+}
+
+// containsDir returns true if `dir` is a descendant of `parent`.
+func containsDir(parent, dir *memNode) bool {
+	for _, e := range parent.entries {
+		if e == dir || (e.isDir() && containsDir(e, dir)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rmdir implements the same method as documented on sys.FS
+func (m *memFS) Rmdir(path string) experimentalsys.Errno {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dir, name, n, errno := m.walk(path, false)
+	if errno != 0 {
+		return errno
+	} else if n == nil {
+		return experimentalsys.ENOENT
+	} else if !n.isDir() {
+		return experimentalsys.ENOTDIR
+	} else if dir == nil {
+		return experimentalsys.EINVAL // e.g. the root
+	} else if len(n.entries) > 0 {
+		return experimentalsys.ENOTEMPTY
+	}
+	delete(dir.entries, name)
+	dir.mtim = time.Now().UnixNano()
+	dir.ctim = dir.mtim
+	return 0
+}
+
+// Unlink implements the same method as documented on sys.FS
+func (m *memFS) Unlink(path string) experimentalsys.Errno {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dir, name, n, errno := m.walk(path, false)
+	if errno != 0 {
+		return errno
+	} else if n == nil {
+		return experimentalsys.ENOENT
+	} else if n.isDir() {
+		return experimentalsys.EISDIR
+	}
+	delete(dir.entries, name)
+	n.nlink--
+	dir.mtim = time.Now().UnixNano()
+	dir.ctim, n.ctim = dir.mtim, dir.mtim
+	return 0
+}
+
+// Link implements the same method as documented on sys.FS
+func (m *memFS) Link(oldPath, newPath string) experimentalsys.Errno {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, _, oldN, errno := m.walk(oldPath, false)
+	if errno != 0 {
+		return errno
+	} else if oldN == nil {
+		return experimentalsys.ENOENT
+	} else if oldN.isDir() {
+		return experimentalsys.EPERM
+	}
+
+	newDir, newName, newN, errno := m.walk(newPath, false)
+	if errno != 0 {
+		return errno
+	} else if newN != nil {
+		return experimentalsys.EEXIST
+	}
+	newDir.entries[newName] = oldN
+	oldN.nlink++
+	newDir.mtim = time.Now().UnixNano()
+	newDir.ctim, oldN.ctim = newDir.mtim, newDir.mtim
+	return 0
+}
+
+// Symlink implements the same method as documented on sys.FS
+func (m *memFS) Symlink(oldPath, linkName string) experimentalsys.Errno {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dir, name, n, errno := m.walk(linkName, false)
+	if errno != 0 {
+		return errno
+	} else if n != nil {
+		return experimentalsys.EEXIST
+	}
+	n = m.newNode(fs.ModeSymlink | 0o777)
+	n.target = oldPath
+	dir.entries[name] = n
+	dir.mtim, dir.ctim = n.mtim, n.ctim
+	return 0
+}
+
+// Readlink implements the same method as documented on sys.FS
+func (m *memFS) Readlink(path string) (string, experimentalsys.Errno) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, _, n, errno := m.walk(path, false)
+	if errno != 0 {
+		return "", errno
+	} else if n == nil {
+		return "", experimentalsys.ENOENT
+	} else if !n.isSymlink() {
+		return "", experimentalsys.EINVAL
+	}
+	return n.target, 0
+}
+
+// Utimens implements the same method as documented on sys.FS
+func (m *memFS) Utimens(path string, atim, mtim int64) experimentalsys.Errno {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, _, n, errno := m.walk(path, true)
+	if errno != 0 {
+		return errno
+	} else if n == nil {
+		return experimentalsys.ENOENT
+	}
+	n.utimens(atim, mtim)
+	return 0
+}
+
+// Lutimens implements the same method as documented on lutimensFS
+func (m *memFS) Lutimens(path string, atim, mtim int64) experimentalsys.Errno {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, _, n, errno := m.walk(path, false)
+	if errno != 0 {
+		return errno
+	} else if n == nil {
+		return experimentalsys.ENOENT
+	}
+	n.utimens(atim, mtim)
+	return 0
+}
+
+func (n *memNode) utimens(atim, mtim int64) {
+	if atim != experimentalsys.UTIME_OMIT {
+		n.atim = atim
+	}
+	if mtim != experimentalsys.UTIME_OMIT {
+		n.mtim = mtim
+	}
+	n.ctim = time.Now().UnixNano()
+}
+
+// compile-time check to ensure memFile implements fsapi.File.
+var _ fsapi.File = (*memFile)(nil)
+
+// memFile is an open regular file or directory in a memFS.
+type memFile struct {
+	fs   *memFS
+	node *memNode
+	flag experimentalsys.Oflag
+
+	// offset is the position of the next Read or Write.
+	offset int64
+
+	// dirents are the directory entries not yet read by Readdir, or nil if
+	// the directory wasn't read since it was opened or rewound.
+	dirents []experimentalsys.Dirent
+
+	nonblock bool
+	closed   bool
+}
+
+func (f *memFile) readable() bool {
+	return f.flag&(experimentalsys.O_RDONLY|experimentalsys.O_RDWR|experimentalsys.O_WRONLY) != experimentalsys.O_WRONLY
+}
+
+func (f *memFile) writable() bool {
+	switch f.flag & (experimentalsys.O_RDONLY | experimentalsys.O_RDWR | experimentalsys.O_WRONLY) {
+	case experimentalsys.O_RDWR, experimentalsys.O_WRONLY:
+		return true
+	}
+	return false
+}
+
+// Dev implements the same method as documented on sys.File
+func (f *memFile) Dev() (uint64, experimentalsys.Errno) {
+	return f.fs.dev, 0
+}
+
+// Ino implements the same method as documented on sys.File
+func (f *memFile) Ino() (sys.Inode, experimentalsys.Errno) {
+	return f.node.ino, 0
+}
+
+// IsDir implements the same method as documented on sys.File
+func (f *memFile) IsDir() (bool, experimentalsys.Errno) {
+	return f.node.isDir(), 0
+}
+
+// IsAppend implements the same method as documented on sys.File
+func (f *memFile) IsAppend() bool {
+	return f.flag&experimentalsys.O_APPEND != 0
+}
+
+// SetAppend implements the same method as documented on sys.File
+func (f *memFile) SetAppend(enable bool) experimentalsys.Errno {
+	if f.closed {
+		return experimentalsys.EBADF
+	}
+	if enable {
+		f.flag |= experimentalsys.O_APPEND
+	} else {
+		f.flag &= ^experimentalsys.O_APPEND
+	}
+	return 0
+}
+
+// IsNonblock implements the same method as documented on fsapi.File
+func (f *memFile) IsNonblock() bool {
+	return f.nonblock
+}
+
+// SetNonblock implements the same method as documented on fsapi.File
+func (f *memFile) SetNonblock(enable bool) experimentalsys.Errno {
+	if f.closed {
+		return experimentalsys.EBADF
+	}
+	// Reads and writes never block, so there's nothing else to do.
+	f.nonblock = enable
+	return 0
+}
+
+// Poll implements the same method as documented on fsapi.File
+func (f *memFile) Poll(fsapi.Pflag, int32) (ready bool, errno experimentalsys.Errno) {
+	if f.closed {
+		return false, experimentalsys.EBADF
+	}
+	return true, 0 // Like regular files, data is always ready.
+}
+
+// Stat implements the same method as documented on sys.File
+func (f *memFile) Stat() (sys.Stat_t, experimentalsys.Errno) {
+	if f.closed {
+		return sys.Stat_t{}, experimentalsys.EBADF
+	}
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	return f.fs.statNode(f.node), 0
+}
+
+// Read implements the same method as documented on sys.File
+func (f *memFile) Read(buf []byte) (n int, errno experimentalsys.Errno) {
+	if n, errno = f.Pread(buf, f.offset); errno == 0 {
+		f.offset += int64(n)
+	}
+	return
+}
+
+// Pread implements the same method as documented on sys.File
+func (f *memFile) Pread(buf []byte, off int64) (n int, errno experimentalsys.Errno) {
+	if errno = f.validateIO(f.readable()); errno != 0 {
+		return
+	} else if off < 0 {
+		return 0, experimentalsys.EINVAL
+	}
+
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if data := f.node.data; off < int64(len(data)) {
+		n = copy(buf, data[off:])
+	}
+	return
+}
+
+// Seek implements the same method as documented on sys.File
+func (f *memFile) Seek(offset int64, whence int) (newOffset int64, errno experimentalsys.Errno) {
+	if f.closed {
+		return 0, experimentalsys.EBADF
+	}
+
+	if f.node.isDir() {
+		if offset != 0 || whence != io.SeekStart {
+			return 0, experimentalsys.EISDIR
+		}
+		f.dirents = nil // rewind
+		return 0, 0
+	}
+
+	switch whence {
+	case io.SeekStart:
+		newOffset = offset
+	case io.SeekCurrent:
+		newOffset = f.offset + offset
+	case io.SeekEnd:
+		f.fs.mu.Lock()
+		newOffset = int64(len(f.node.data)) + offset
+		f.fs.mu.Unlock()
+	default:
+		return 0, experimentalsys.EINVAL
+	}
+	if newOffset < 0 {
+		return 0, experimentalsys.EINVAL
+	}
+	f.offset = newOffset
+	return
+}
+
+// Readdir implements the same method as documented on sys.File
+func (f *memFile) Readdir(n int) (dirents []experimentalsys.Dirent, errno experimentalsys.Errno) {
+	if f.closed || !f.node.isDir() {
+		return nil, experimentalsys.EBADF
+	}
+
+	if f.dirents == nil {
+		f.fs.mu.Lock()
+		f.dirents = make([]experimentalsys.Dirent, 0, len(f.node.entries))
+		for name, e := range f.node.entries {
+			f.dirents = append(f.dirents, experimentalsys.Dirent{Name: name, Ino: e.ino, Type: e.mode.Type()})
+		}
+		f.fs.mu.Unlock()
+		sort.Slice(f.dirents, func(i, j int) bool { return f.dirents[i].Name < f.dirents[j].Name })
+	}
+
+	if n <= 0 || n > len(f.dirents) {
+		n = len(f.dirents)
+	}
+	dirents = f.dirents[:n:n]
+	f.dirents = f.dirents[n:]
+	return
+}
+
+// Write implements the same method as documented on sys.File
+func (f *memFile) Write(buf []byte) (n int, errno experimentalsys.Errno) {
+	if f.node.isDir() {
+		return 0, experimentalsys.EBADF // not writeable
+	} else if errno = f.validateIO(f.writable()); errno != 0 {
+		return
+	}
+
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.IsAppend() {
+		f.offset = int64(len(f.node.data))
+	}
+	n = f.node.writeAt(buf, f.offset)
+	f.offset += int64(n)
+	return
+}
+
+// Pwrite implements the same method as documented on sys.File
+func (f *memFile) Pwrite(buf []byte, off int64) (n int, errno experimentalsys.Errno) {
+	if errno = f.validateIO(f.writable()); errno != 0 {
+		return
+	} else if off < 0 {
+		return 0, experimentalsys.EINVAL
+	}
+
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	return f.node.writeAt(buf, off), 0
+}
+
+// writeAt writes `buf` at the offset, growing the file as needed. The caller
+// must hold mu.
+func (n *memNode) writeAt(buf []byte, off int64) int {
+	if len(buf) == 0 {
+		return 0
+	}
+	if end := off + int64(len(buf)); end > int64(len(n.data)) {
+		n.resize(end)
+	}
+	copy(n.data[off:], buf)
+	n.mtim = time.Now().UnixNano()
+	n.ctim = n.mtim
+	return len(buf)
+}
+
+// resize grows or shrinks data to the size, zero-filling any new bytes.
+func (n *memNode) resize(size int64) {
+	if size <= int64(len(n.data)) {
+		n.data = n.data[:size]
+		return
+	}
+	if size <= int64(cap(n.data)) {
+		grown := n.data[:size]
+		for i := len(n.data); i < len(grown); i++ {
+			grown[i] = 0
+		}
+		n.data = grown
+		return
+	}
+	grown := make([]byte, size, size+size/4)
+	copy(grown, n.data)
+	n.data = grown
+}
+
+// Truncate implements the same method as documented on sys.File
+func (f *memFile) Truncate(size int64) experimentalsys.Errno {
+	if errno := f.validateIO(f.writable()); errno != 0 {
+		return errno
+	} else if size < 0 {
+		return experimentalsys.EINVAL
+	}
+
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	f.node.resize(size)
+	f.node.mtim = time.Now().UnixNano()
+	f.node.ctim = f.node.mtim
+	return 0
+}
+
+// Sync implements the same method as documented on sys.File
+func (f *memFile) Sync() experimentalsys.Errno {
+	if f.closed {
+		return experimentalsys.EBADF
+	}
+	return 0 // There is no durable storage to synchronize with.
+}
+
+// Datasync implements the same method as documented on sys.File
+func (f *memFile) Datasync() experimentalsys.Errno {
+	return f.Sync()
+}
+
+// Utimens implements the same method as documented on sys.File
+func (f *memFile) Utimens(atim, mtim int64) experimentalsys.Errno {
+	if f.closed {
+		return experimentalsys.EBADF
+	}
+
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	f.node.utimens(atim, mtim)
+	return 0
+}
+
+// Close implements the same method as documented on sys.File
+func (f *memFile) Close() experimentalsys.Errno {
+	f.closed = true
+	f.dirents = nil
+	return 0
+}
+
+// validateIO returns EBADF when closed or the operation isn't allowed by the
+// open flags. A directory returns EISDIR.
+func (f *memFile) validateIO(allowed bool) experimentalsys.Errno {
+	if f.closed {
+		return experimentalsys.EBADF
+	} else if f.node.isDir() {
+		return experimentalsys.EISDIR
+	} else if !allowed {
+		return experimentalsys.EBADF
+	}
+	return 0
+}
@@ -0,0 +1,384 @@
+package sysfs
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"testing"
+
+	"github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/internal/fstest"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// newTestMemFS returns a MemFS populated with fstest.FS.
+func newTestMemFS(t *testing.T) sys.FS {
+	testFS := MemFS()
+	err := fs.WalkDir(fstest.FS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == "." {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			require.EqualErrno(t, 0, testFS.Mkdir(path, info.Mode()))
+			return nil
+		}
+		f, errno := testFS.OpenFile(path, sys.O_WRONLY|sys.O_CREAT|sys.O_EXCL, info.Mode())
+		require.EqualErrno(t, 0, errno)
+		defer f.Close()
+		_, errno = f.Write(fstest.FS[path].Data)
+		require.EqualErrno(t, 0, errno)
+		return nil
+	})
+	require.NoError(t, err)
+	return testFS
+}
+
+func TestMemFS_String(t *testing.T) {
+	require.Equal(t, "memfs", MemFS().(fmt.Stringer).String())
+}
+
+func TestMemFS_OpenFile(t *testing.T) {
+	testFS := newTestMemFS(t)
+
+	testOpen_Read(t, testFS, true, true)
+
+	t.Run("O_CREAT", func(t *testing.T) {
+		f, errno := testFS.OpenFile("file", sys.O_RDWR|sys.O_CREAT, 0o600)
+		require.EqualErrno(t, 0, errno)
+		defer f.Close()
+
+		_, errno = f.Write([]byte{1, 2, 3, 4})
+		require.EqualErrno(t, 0, errno)
+
+		st, errno := testFS.Stat("file")
+		require.EqualErrno(t, 0, errno)
+		require.Equal(t, fs.FileMode(0o600), st.Mode)
+		require.Equal(t, int64(4), st.Size)
+	})
+
+	t.Run("O_EXCL", func(t *testing.T) {
+		_, errno := testFS.OpenFile("animals.txt", sys.O_RDWR|sys.O_CREAT|sys.O_EXCL, 0o600)
+		require.EqualErrno(t, sys.EEXIST, errno)
+	})
+
+	t.Run("O_TRUNC", func(t *testing.T) {
+		f, errno := testFS.OpenFile("file", sys.O_WRONLY|sys.O_TRUNC, 0)
+		require.EqualErrno(t, 0, errno)
+		defer f.Close()
+
+		st, errno := f.Stat()
+		require.EqualErrno(t, 0, errno)
+		require.Zero(t, st.Size)
+	})
+
+	t.Run("O_APPEND", func(t *testing.T) {
+		f, errno := testFS.OpenFile("file", sys.O_RDWR|sys.O_APPEND, 0)
+		require.EqualErrno(t, 0, errno)
+		defer f.Close()
+
+		for _, b := range []byte{1, 2} {
+			_, errno = f.Seek(0, io.SeekStart)
+			require.EqualErrno(t, 0, errno)
+			_, errno = f.Write([]byte{b})
+			require.EqualErrno(t, 0, errno)
+		}
+
+		buf := make([]byte, 3)
+		n, errno := f.Pread(buf, 0)
+		require.EqualErrno(t, 0, errno)
+		require.Equal(t, []byte{1, 2}, buf[:n])
+	})
+
+	t.Run("O_DIRECTORY on file is ENOTDIR", func(t *testing.T) {
+		_, errno := testFS.OpenFile("animals.txt", sys.O_DIRECTORY, 0)
+		require.EqualErrno(t, sys.ENOTDIR, errno)
+	})
+
+	t.Run("O_NOFOLLOW on symlink is ELOOP", func(t *testing.T) {
+		require.EqualErrno(t, 0, testFS.Symlink("animals.txt", "nofollow"))
+		_, errno := testFS.OpenFile("nofollow", sys.O_RDONLY|sys.O_NOFOLLOW, 0)
+		require.EqualErrno(t, sys.ELOOP, errno)
+	})
+
+	t.Run("symlink loop is ELOOP", func(t *testing.T) {
+		require.EqualErrno(t, 0, testFS.Symlink("loop", "loop"))
+		_, errno := testFS.OpenFile("loop", sys.O_RDONLY, 0)
+		require.EqualErrno(t, sys.ELOOP, errno)
+	})
+
+	t.Run("path outside root is the root", func(t *testing.T) {
+		f, errno := testFS.OpenFile("../sub/test.txt", sys.O_RDONLY, 0)
+		require.EqualErrno(t, 0, errno)
+		require.EqualErrno(t, 0, f.Close())
+	})
+
+	t.Run("closed file is EBADF", func(t *testing.T) {
+		f, errno := testFS.OpenFile("animals.txt", sys.O_RDONLY, 0)
+		require.EqualErrno(t, 0, errno)
+		require.EqualErrno(t, 0, f.Close())
+
+		_, errno = f.Read(make([]byte, 1))
+		require.EqualErrno(t, sys.EBADF, errno)
+	})
+}
+
+func TestMemFS_File(t *testing.T) {
+	testFS := MemFS()
+
+	f, errno := testFS.OpenFile("file", sys.O_RDWR|sys.O_CREAT, 0o600)
+	require.EqualErrno(t, 0, errno)
+	defer f.Close()
+
+	// Writing past the end leaves a hole of zeros.
+	n, errno := f.Pwrite([]byte{1}, 2)
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, 1, n)
+
+	buf := make([]byte, 4)
+	n, errno = f.Read(buf)
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, []byte{0, 0, 1}, buf[:n])
+
+	// EOF is not an error
+	n, errno = f.Read(buf)
+	require.EqualErrno(t, 0, errno)
+	require.Zero(t, n)
+
+	require.EqualErrno(t, 0, f.Truncate(1))
+	require.EqualErrno(t, 0, f.Truncate(2))
+	n, errno = f.Pread(buf, 0)
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, []byte{0, 0}, buf[:n])
+
+	require.EqualErrno(t, sys.EINVAL, f.Truncate(-1))
+	_, errno = f.Pread(buf, -1)
+	require.EqualErrno(t, sys.EINVAL, errno)
+	_, errno = f.Seek(-1, io.SeekStart)
+	require.EqualErrno(t, sys.EINVAL, errno)
+
+	offset, errno := f.Seek(-1, io.SeekEnd)
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, int64(1), offset)
+
+	require.EqualErrno(t, 0, f.Utimens(1, sys.UTIME_OMIT))
+	st, errno := f.Stat()
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, int64(1), st.Atim)
+	require.NotEqual(t, int64(1), st.Mtim)
+}
+
+func TestMemFS_Dir(t *testing.T) {
+	testFS := newTestMemFS(t)
+
+	f, errno := testFS.OpenFile("dir", sys.O_RDONLY, 0)
+	require.EqualErrno(t, 0, errno)
+	defer f.Close()
+
+	_, errno = f.Read(make([]byte, 1))
+	require.EqualErrno(t, sys.EISDIR, errno)
+	_, errno = f.Write([]byte{1})
+	require.EqualErrno(t, sys.EBADF, errno)
+
+	dirents, errno := f.Readdir(-1)
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, 3, len(dirents))
+
+	// Rewinding picks up new entries.
+	require.EqualErrno(t, 0, testFS.Mkdir("dir/b", 0o700))
+	_, errno = f.Seek(0, io.SeekStart)
+	require.EqualErrno(t, 0, errno)
+	dirents, errno = f.Readdir(-1)
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, 4, len(dirents))
+
+	_, errno = f.Seek(1, io.SeekStart)
+	require.EqualErrno(t, sys.EISDIR, errno)
+
+	st, errno := f.Stat()
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, uint64(4), st.Nlink) // ".", parent entry, "a-" and "b"
+}
+
+func TestMemFS_Stat(t *testing.T) {
+	testStat(t, newTestMemFS(t))
+}
+
+func TestMemFS_Lstat(t *testing.T) {
+	testFS := newTestMemFS(t)
+	for _, path := range []string{"animals.txt", "sub", "sub-link"} {
+		require.EqualErrno(t, 0, testFS.Symlink(path, path+"-link"))
+	}
+
+	testLstat(t, testFS)
+}
+
+func TestMemFS_Readlink(t *testing.T) {
+	testFS := newTestMemFS(t)
+	testReadlink(t, testFS, testFS)
+}
+
+func TestMemFS_Mkdir(t *testing.T) {
+	testFS := MemFS()
+
+	require.EqualErrno(t, 0, testFS.Mkdir("mkdir", 0o700))
+	require.EqualErrno(t, sys.EEXIST, testFS.Mkdir("mkdir", 0o700))
+	require.EqualErrno(t, sys.ENOENT, testFS.Mkdir("non-existing-dir/foo", 0o700))
+
+	st, errno := testFS.Stat("mkdir")
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, fs.ModeDir|0o700, st.Mode)
+
+	require.EqualErrno(t, 0, testFS.Chmod("mkdir", 0o500))
+	requireMode(t, testFS, "mkdir", 0o500)
+}
+
+func TestMemFS_Rename(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		expected sys.Errno
+	}{
+		{name: "from doesn't exist", from: "cat", to: "animals.txt", expected: sys.ENOENT},
+		{name: "file to non-exist", from: "animals.txt", to: "zoo.txt"},
+		{name: "file to file", from: "animals.txt", to: "empty.txt"},
+		{name: "file to itself", from: "animals.txt", to: "animals.txt"},
+		{name: "dir to non-exist", from: "sub", to: "dir/sub"},
+		{name: "dir to empty dir", from: "sub", to: "emptydir"},
+		{name: "dir to file", from: "sub", to: "animals.txt", expected: sys.ENOTDIR},
+		{name: "file to dir", from: "animals.txt", to: "sub", expected: sys.EISDIR},
+		{name: "dir to non-empty dir", from: "sub", to: "dir", expected: sys.ENOTEMPTY},
+		{name: "dir to its subdirectory", from: "dir", to: "dir/a-/dir", expected: sys.EINVAL},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			testFS := newTestMemFS(t)
+			before, _ := testFS.Lstat(tc.from)
+
+			errno := testFS.Rename(tc.from, tc.to)
+			require.EqualErrno(t, tc.expected, errno)
+			if errno != 0 {
+				return
+			}
+
+			after, errno := testFS.Lstat(tc.to)
+			require.EqualErrno(t, 0, errno)
+			require.Equal(t, before.Ino, after.Ino)
+			if tc.from != tc.to {
+				_, errno = testFS.Lstat(tc.from)
+				require.EqualErrno(t, sys.ENOENT, errno)
+			}
+		})
+	}
+}
+
+func TestMemFS_Rmdir(t *testing.T) {
+	testFS := newTestMemFS(t)
+
+	require.EqualErrno(t, sys.ENOENT, testFS.Rmdir("rmdir"))
+	require.EqualErrno(t, sys.ENOTEMPTY, testFS.Rmdir("sub"))
+	require.EqualErrno(t, sys.ENOTDIR, testFS.Rmdir("animals.txt"))
+	require.EqualErrno(t, sys.EINVAL, testFS.Rmdir("."))
+
+	// Removing an open directory is allowed.
+	f, errno := testFS.OpenFile("emptydir", sys.O_DIRECTORY, 0)
+	require.EqualErrno(t, 0, errno)
+	defer f.Close()
+
+	require.EqualErrno(t, 0, testFS.Rmdir("emptydir"))
+	_, errno = testFS.Stat("emptydir")
+	require.EqualErrno(t, sys.ENOENT, errno)
+}
+
+func TestMemFS_Unlink(t *testing.T) {
+	testFS := newTestMemFS(t)
+
+	require.EqualErrno(t, sys.ENOENT, testFS.Unlink("cat"))
+	require.EqualErrno(t, sys.EISDIR, testFS.Unlink("sub"))
+
+	// Removing an open file leaves it readable.
+	f, errno := testFS.OpenFile("sub/test.txt", sys.O_RDONLY, 0)
+	require.EqualErrno(t, 0, errno)
+	defer f.Close()
+
+	require.EqualErrno(t, 0, testFS.Unlink("sub/test.txt"))
+	_, errno = testFS.Stat("sub/test.txt")
+	require.EqualErrno(t, sys.ENOENT, errno)
+
+	buf := make([]byte, 5)
+	n, errno := f.Read(buf)
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, "greet", string(buf[:n]))
+
+	st, errno := f.Stat()
+	require.EqualErrno(t, 0, errno)
+	require.Zero(t, st.Nlink)
+}
+
+func TestMemFS_Link(t *testing.T) {
+	testFS := newTestMemFS(t)
+
+	require.EqualErrno(t, sys.ENOENT, testFS.Link("cat", ""))
+	require.EqualErrno(t, sys.EEXIST, testFS.Link("sub/test.txt", "sub/test.txt"))
+	require.EqualErrno(t, sys.EEXIST, testFS.Link("sub/test.txt", "."))
+	require.EqualErrno(t, sys.EEXIST, testFS.Link("sub/test.txt", ""))
+	require.EqualErrno(t, sys.EEXIST, testFS.Link("sub/test.txt", "/"))
+	require.EqualErrno(t, sys.EPERM, testFS.Link("sub", "foo"))
+	require.EqualErrno(t, 0, testFS.Link("sub/test.txt", "foo"))
+
+	st, errno := testFS.Stat("foo")
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, uint64(2), st.Nlink)
+
+	stOld, errno := testFS.Stat("sub/test.txt")
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, stOld.Ino, st.Ino)
+}
+
+func TestMemFS_Symlink(t *testing.T) {
+	testFS := newTestMemFS(t)
+
+	require.EqualErrno(t, sys.EEXIST, testFS.Symlink("sub/test.txt", "sub/test.txt"))
+	// Non-existing old name is allowed.
+	require.EqualErrno(t, 0, testFS.Symlink("non-existing", "aa"))
+	require.EqualErrno(t, 0, testFS.Symlink("sub/", "symlinked-subdir"))
+	require.EqualErrno(t, 0, testFS.Symlink("/sub/test.txt", "sub/absolute"))
+	require.EqualErrno(t, 0, testFS.Symlink("../animals.txt", "sub/relative"))
+
+	_, errno := testFS.Stat("aa")
+	require.EqualErrno(t, sys.ENOENT, errno)
+
+	st, errno := testFS.Stat("symlinked-subdir/test.txt")
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, int64(14), st.Size)
+
+	st, errno = testFS.Stat("sub/absolute")
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, int64(14), st.Size)
+
+	st, errno = testFS.Stat("sub/relative")
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, int64(30), st.Size)
+
+	// A trailing slash follows the link.
+	st, errno = testFS.Lstat("symlinked-subdir/")
+	require.EqualErrno(t, 0, errno)
+	require.True(t, st.Mode.IsDir())
+}
+
+func TestMemFS_Utimens(t *testing.T) {
+	testFS := newTestMemFS(t)
+
+	require.EqualErrno(t, sys.ENOENT, testFS.Utimens("cat", 1, 2))
+	require.EqualErrno(t, 0, testFS.Utimens("animals.txt", sys.UTIME_OMIT, 2))
+
+	st, errno := testFS.Stat("animals.txt")
+	require.EqualErrno(t, 0, errno)
+	require.NotEqual(t, int64(0), st.Atim)
+	require.Equal(t, int64(2), st.Mtim)
+}
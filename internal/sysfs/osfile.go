@@ -83,26 +83,42 @@ func (f *osFile) SetAppend(enable bool) (errno experimentalsys.Errno) {
 		f.flag &= ^experimentalsys.O_APPEND
 	}
 
-	// Clear any create flag, as we are re-opening, not re-creating.
-	f.flag &= ^experimentalsys.O_CREAT
-
 	// appendMode (bool) cannot be changed later, so we have to re-open the
 	// file. https://github.com/golang/go/blob/go1.20/src/os/file_unix.go#L60
 	return fileError(f, f.closed, f.reopen())
 }
 
 // compile-time check to ensure osFile.reopen implements reopenFile.
-var _ reopenFile = (*fsFile)(nil).reopen
+var _ reopenFile = (*osFile)(nil).reopen
 
 func (f *osFile) reopen() (errno experimentalsys.Errno) {
-	// Clear any create flag, as we are re-opening, not re-creating.
-	f.flag &= ^experimentalsys.O_CREAT
+	// Clear any create or truncate flag, as we are re-opening, not
+	// re-creating. Otherwise, toggling append would erase the file.
+	f.flag &= ^(experimentalsys.O_CREAT | experimentalsys.O_EXCL | experimentalsys.O_TRUNC)
 
 	_ = f.close()
-	f.file, errno = OpenFile(f.path, f.flag, f.perm)
+	if f.file, errno = OpenFile(f.path, f.flag, f.perm); errno == 0 {
+		f.fd = f.file.Fd()
+	}
 	return
 }
 
+// advise implements the same method as documented on adviseFile
+func (f *osFile) advise(offset, length int64, advice Advice) experimentalsys.Errno {
+	if f.closed {
+		return experimentalsys.EBADF
+	}
+	return fadvise(f.fd, offset, length, advice)
+}
+
+// lock implements the same method as documented on lockFile
+func (f *osFile) lock(typ LockType, nonblock bool) experimentalsys.Errno {
+	if f.closed {
+		return experimentalsys.EBADF
+	}
+	return flock(f.fd, typ, nonblock)
+}
+
 // IsNonblock implements the same method as documented on fsapi.File
 func (f *osFile) IsNonblock() bool {
 	return isNonblock(f)
@@ -0,0 +1,662 @@
+package sysfs
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// OverlayFS returns a filesystem which layers a writable `upper` filesystem
+// over a `lower` one, which is never written. For example, `upper` could be
+// MemFS and `lower` an embed.FS adapted with AdaptFS.
+//
+// Files in `upper` shadow those at the same path in `lower`, and directories
+// present in both are merged. Modifying a file only in `lower` first copies it
+// (and its parent directories) to `upper`. Removing a file in `lower` records
+// a whiteout, which hides it until something is created at the same path.
+//
+// Notes:
+//   - Whiteouts are held in memory, so aren't visible to a later OverlayFS
+//     using the same `upper`.
+//   - Symbolic links are resolved within the layer that holds them.
+//   - Renaming a directory in `lower` copies its whole tree to `upper`.
+func OverlayFS(upper, lower experimentalsys.FS) experimentalsys.FS {
+	return &overlayFS{
+		upper:     upper,
+		lower:     lower,
+		whiteouts: map[string]struct{}{},
+		opaque:    map[string]struct{}{},
+	}
+}
+
+type overlayFS struct {
+	experimentalsys.UnimplementedFS
+
+	upper, lower experimentalsys.FS
+
+	// mu guards whiteouts and opaque, and serializes operations that need
+	// to copy up from the lower layer.
+	mu sync.Mutex
+
+	// whiteouts are cleaned paths removed from the lower layer.
+	whiteouts map[string]struct{}
+
+	// opaque are cleaned paths of directories re-created in the upper layer
+	// after removal. The contents of the lower layer are hidden beneath them.
+	opaque map[string]struct{}
+}
+
+// String implements fmt.Stringer
+func (o *overlayFS) String() string {
+	return "overlay"
+}
+
+// overlayPath returns the path relative to the root of the filesystem,
+// clamping any leading ".." components. The root is ".".
+func overlayPath(p string) string {
+	if p = path.Clean("/" + p)[1:]; p == "" {
+		return "."
+	}
+	return p
+}
+
+// hidden returns true if the path in the lower layer was removed, or is
+// beneath a directory that was. The caller must hold mu.
+func (o *overlayFS) hidden(p string) bool {
+	if p == "." {
+		return false
+	}
+	for i := 0; ; i++ {
+		j := strings.IndexByte(p[i:], '/')
+		if j == -1 {
+			_, ok := o.whiteouts[p]
+			return ok
+		}
+		i += j
+		if _, ok := o.whiteouts[p[:i]]; ok {
+			return true
+		} else if _, ok = o.opaque[p[:i]]; ok {
+			return true
+		}
+	}
+}
+
+// unhide clears any whiteouts at or below the path, as it now exists in
+// the upper layer. The caller must hold mu.
+func (o *overlayFS) unhide(p string, isDir bool) {
+	if _, ok := o.whiteouts[p]; ok {
+		delete(o.whiteouts, p)
+		if isDir {
+			o.opaque[p] = struct{}{}
+		}
+	}
+	o.clearBelow(p)
+}
+
+// clearBelow deletes any whiteouts or opaque directories beneath the path.
+// The caller must hold mu.
+func (o *overlayFS) clearBelow(p string) {
+	prefix := p + "/"
+	for k := range o.whiteouts {
+		if strings.HasPrefix(k, prefix) {
+			delete(o.whiteouts, k)
+		}
+	}
+	for k := range o.opaque {
+		if strings.HasPrefix(k, prefix) {
+			delete(o.opaque, k)
+		}
+	}
+}
+
+// inLower returns true if the path is visible in the lower layer. The caller
+// must hold mu.
+func (o *overlayFS) inLower(p string) bool {
+	if o.hidden(p) {
+		return false
+	}
+	_, errno := o.lower.Lstat(p)
+	return errno == 0
+}
+
+// lstat returns the status of the path and whether it is in the upper layer.
+// The caller must hold mu.
+func (o *overlayFS) lstat(p string) (st sys.Stat_t, inUpper bool, errno experimentalsys.Errno) {
+	if st, errno = o.upper.Lstat(p); errno == 0 {
+		return st, true, 0
+	} else if errno != experimentalsys.ENOENT {
+		return
+	} else if o.hidden(p) {
+		return st, false, experimentalsys.ENOENT
+	}
+	st, errno = o.lower.Lstat(p)
+	return
+}
+
+// layer returns the filesystem holding the path. The caller must hold mu.
+func (o *overlayFS) layer(p string) (experimentalsys.FS, experimentalsys.Errno) {
+	if _, inUpper, errno := o.lstat(p); errno != 0 {
+		return nil, errno
+	} else if inUpper {
+		return o.upper, 0
+	}
+	return o.lower, 0
+}
+
+// copyUpParent ensures the parent directory of the path exists in the upper
+// layer. The caller must hold mu.
+func (o *overlayFS) copyUpParent(p string) experimentalsys.Errno {
+	parent := path.Dir(p)
+	if parent == "." {
+		return 0
+	}
+	st, inUpper, errno := o.lstat(parent)
+	if errno != 0 {
+		return errno
+	} else if inUpper {
+		return 0
+	} else if !st.Mode.IsDir() {
+		return experimentalsys.ENOTDIR
+	}
+	return o.copyUp(parent, st)
+}
+
+// copyUp copies the path from the lower layer to the upper one, without the
+// contents of directories. The caller must hold mu.
+func (o *overlayFS) copyUp(p string, st sys.Stat_t) experimentalsys.Errno {
+	if errno := o.copyUpParent(p); errno != 0 {
+		return errno
+	}
+
+	switch st.Mode.Type() {
+	case fs.ModeDir:
+		return o.upper.Mkdir(p, st.Mode.Perm())
+	case fs.ModeSymlink:
+		target, errno := o.lower.Readlink(p)
+		if errno != 0 {
+			return errno
+		}
+		return o.upper.Symlink(target, p)
+	}
+
+	src, errno := o.lower.OpenFile(p, experimentalsys.O_RDONLY, 0)
+	if errno != 0 {
+		return errno
+	}
+	defer src.Close()
+
+	dst, errno := o.upper.OpenFile(p, experimentalsys.O_WRONLY|experimentalsys.O_CREAT|experimentalsys.O_EXCL, st.Mode.Perm())
+	if errno != 0 {
+		return errno
+	}
+	defer dst.Close()
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, errno := src.Read(buf)
+		if errno != 0 {
+			return errno
+		} else if n == 0 {
+			break
+		}
+		if _, errno = dst.Write(buf[:n]); errno != 0 {
+			return errno
+		}
+	}
+	return dst.Utimens(st.Atim, st.Mtim)
+}
+
+// copyUpTree is like copyUp, except it also copies the contents of
+// directories. The caller must hold mu.
+func (o *overlayFS) copyUpTree(p string, st sys.Stat_t, inUpper bool) experimentalsys.Errno {
+	if !inUpper {
+		if errno := o.copyUp(p, st); errno != 0 {
+			return errno
+		}
+	}
+	if !st.Mode.IsDir() {
+		return 0
+	}
+	dirents, errno := o.readdir(p)
+	if errno != 0 {
+		return errno
+	}
+	for _, d := range dirents {
+		child := path.Join(p, d.Name)
+		st, inUpper, errno := o.lstat(child)
+		if errno != 0 {
+			return errno
+		} else if errno = o.copyUpTree(child, st, inUpper); errno != 0 {
+			return errno
+		}
+	}
+	return 0
+}
+
+// readdir returns the merged entries of the directory, sorted by name. The
+// caller must hold mu.
+func (o *overlayFS) readdir(p string) (dirents []experimentalsys.Dirent, errno experimentalsys.Errno) {
+	seen := map[string]struct{}{}
+	if st, errno := o.upper.Lstat(p); errno == 0 && st.Mode.IsDir() {
+		if dirents, errno = readAll(o.upper, p); errno != 0 {
+			return nil, errno
+		}
+		for _, d := range dirents {
+			seen[d.Name] = struct{}{}
+		}
+	}
+
+	if _, ok := o.opaque[p]; ok || o.hidden(p) {
+		return
+	}
+	if st, errno := o.lower.Lstat(p); errno != 0 || !st.Mode.IsDir() {
+		return dirents, 0
+	}
+	lower, errno := readAll(o.lower, p)
+	if errno != 0 {
+		return nil, errno
+	}
+	for _, d := range lower {
+		if _, ok := seen[d.Name]; ok {
+			continue
+		} else if _, ok = o.whiteouts[path.Join(p, d.Name)]; ok {
+			continue
+		}
+		dirents = append(dirents, d)
+	}
+	sort.Slice(dirents, func(i, j int) bool { return dirents[i].Name < dirents[j].Name })
+	return dirents, 0
+}
+
+// readAll returns all entries of the directory in the filesystem.
+func readAll(fsys experimentalsys.FS, p string) ([]experimentalsys.Dirent, experimentalsys.Errno) {
+	f, errno := fsys.OpenFile(p, experimentalsys.O_RDONLY|experimentalsys.O_DIRECTORY, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+	defer f.Close()
+	return f.Readdir(-1)
+}
+
+// OpenFile implements the same method as documented on sys.FS
+func (o *overlayFS) OpenFile(path string, flag experimentalsys.Oflag, perm fs.FileMode) (experimentalsys.File, experimentalsys.Errno) {
+	p := overlayPath(path)
+	write := flag&(experimentalsys.O_WRONLY|experimentalsys.O_RDWR) != 0 || flag&experimentalsys.O_TRUNC != 0
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	st, inUpper, errno := o.lstat(p)
+	switch {
+	case errno == experimentalsys.ENOENT && flag&experimentalsys.O_CREAT != 0:
+		if errno = o.copyUpParent(p); errno != 0 {
+			return nil, errno
+		}
+		f, errno := o.upper.OpenFile(p, flag, perm)
+		if errno == 0 {
+			o.unhide(p, false)
+		}
+		return f, errno
+	case errno != 0:
+		return nil, errno
+	case flag&(experimentalsys.O_CREAT|experimentalsys.O_EXCL) == experimentalsys.O_CREAT|experimentalsys.O_EXCL:
+		return nil, experimentalsys.EEXIST
+	case st.Mode.IsDir() && !write:
+		primary := o.lower
+		if inUpper {
+			primary = o.upper
+		}
+		f, errno := primary.OpenFile(p, flag, perm)
+		if errno != 0 {
+			return nil, errno
+		}
+		return &overlayDir{File: f, fs: o, path: p}, 0
+	case st.Mode.IsDir():
+		return nil, experimentalsys.EISDIR // invalid to open a directory writeable
+	case !inUpper && write:
+		if errno = o.copyUp(p, st); errno != 0 {
+			return nil, errno
+		}
+	case !inUpper:
+		return o.lower.OpenFile(p, flag, perm)
+	}
+	return o.upper.OpenFile(p, flag, perm)
+}
+
+// Lstat implements the same method as documented on sys.FS
+func (o *overlayFS) Lstat(path string) (sys.Stat_t, experimentalsys.Errno) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	st, _, errno := o.lstat(overlayPath(path))
+	return st, errno
+}
+
+// Stat implements the same method as documented on sys.FS
+func (o *overlayFS) Stat(path string) (sys.Stat_t, experimentalsys.Errno) {
+	p := overlayPath(path)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	layer, errno := o.layer(p)
+	if errno != 0 {
+		return sys.Stat_t{}, errno
+	}
+	return layer.Stat(p)
+}
+
+// Readlink implements the same method as documented on sys.FS
+func (o *overlayFS) Readlink(path string) (string, experimentalsys.Errno) {
+	p := overlayPath(path)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	layer, errno := o.layer(p)
+	if errno != 0 {
+		return "", errno
+	}
+	return layer.Readlink(p)
+}
+
+// Mkdir implements the same method as documented on sys.FS
+func (o *overlayFS) Mkdir(path string, perm fs.FileMode) experimentalsys.Errno {
+	p := overlayPath(path)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, _, errno := o.lstat(p); errno == 0 {
+		return experimentalsys.EEXIST
+	} else if errno != experimentalsys.ENOENT {
+		return errno
+	} else if errno = o.copyUpParent(p); errno != 0 {
+		return errno
+	} else if errno = o.upper.Mkdir(p, perm); errno != 0 {
+		return errno
+	}
+	o.unhide(p, true)
+	return 0
+}
+
+// Chmod implements the same method as documented on sys.FS
+func (o *overlayFS) Chmod(path string, perm fs.FileMode) experimentalsys.Errno {
+	p := overlayPath(path)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if errno := o.copyUpPath(p); errno != 0 {
+		return errno
+	}
+	return o.upper.Chmod(p, perm)
+}
+
+// Utimens implements the same method as documented on sys.FS
+func (o *overlayFS) Utimens(path string, atim, mtim int64) experimentalsys.Errno {
+	p := overlayPath(path)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if errno := o.copyUpPath(p); errno != 0 {
+		return errno
+	}
+	return o.upper.Utimens(p, atim, mtim)
+}
+
+// Lutimens implements the same method as documented on lutimensFS
+func (o *overlayFS) Lutimens(path string, atim, mtim int64) experimentalsys.Errno {
+	p := overlayPath(path)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if errno := o.copyUpPath(p); errno != 0 {
+		return errno
+	}
+	return Lutimens(o.upper, p, atim, mtim)
+}
+
+// copyUpPath copies the path to the upper layer, unless it is already there.
+// The caller must hold mu.
+func (o *overlayFS) copyUpPath(p string) experimentalsys.Errno {
+	if st, inUpper, errno := o.lstat(p); errno != 0 {
+		return errno
+	} else if !inUpper {
+		return o.copyUp(p, st)
+	}
+	return 0
+}
+
+// Rmdir implements the same method as documented on sys.FS
+func (o *overlayFS) Rmdir(path string) experimentalsys.Errno {
+	p := overlayPath(path)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	st, inUpper, errno := o.lstat(p)
+	if errno != 0 {
+		return errno
+	} else if !st.Mode.IsDir() {
+		return experimentalsys.ENOTDIR
+	} else if p == "." {
+		return experimentalsys.EINVAL
+	} else if dirents, errno := o.readdir(p); errno != 0 {
+		return errno
+	} else if len(dirents) > 0 {
+		return experimentalsys.ENOTEMPTY
+	}
+
+	if inUpper {
+		if errno = o.upper.Rmdir(p); errno != 0 {
+			return errno
+		}
+	}
+	o.removed(p)
+	return 0
+}
+
+// Unlink implements the same method as documented on sys.FS
+func (o *overlayFS) Unlink(path string) experimentalsys.Errno {
+	p := overlayPath(path)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	st, inUpper, errno := o.lstat(p)
+	if errno != 0 {
+		return errno
+	} else if st.Mode.IsDir() {
+		return experimentalsys.EISDIR
+	}
+
+	if inUpper {
+		if errno = o.upper.Unlink(p); errno != 0 {
+			return errno
+		}
+	}
+	o.removed(p)
+	return 0
+}
+
+// removed records a whiteout if the path removed from the upper layer is
+// still visible in the lower one. The caller must hold mu.
+func (o *overlayFS) removed(p string) {
+	if o.inLower(p) {
+		o.whiteouts[p] = struct{}{}
+	}
+	delete(o.opaque, p)
+	o.clearBelow(p)
+}
+
+// Rename implements the same method as documented on sys.FS
+func (o *overlayFS) Rename(from, to string) experimentalsys.Errno {
+	from, to = overlayPath(from), overlayPath(to)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	fromSt, fromUpper, errno := o.lstat(from)
+	if errno != 0 {
+		return errno
+	} else if from == "." || to == "." {
+		return experimentalsys.EINVAL
+	} else if from == to {
+		return 0
+	}
+
+	toSt, _, errno := o.lstat(to)
+	toExists := errno == 0
+	if errno != 0 && errno != experimentalsys.ENOENT {
+		return errno
+	}
+
+	if fromSt.Mode.IsDir() {
+		if strings.HasPrefix(to, from+"/") {
+			return experimentalsys.EINVAL // can't move a directory into itself
+		} else if toExists && !toSt.Mode.IsDir() {
+			return experimentalsys.ENOTDIR
+		} else if toExists {
+			if dirents, errno := o.readdir(to); errno != 0 {
+				return errno
+			} else if len(dirents) > 0 {
+				return experimentalsys.ENOTEMPTY
+			}
+		}
+	} else if toExists && toSt.Mode.IsDir() {
+		return experimentalsys.EISDIR
+	}
+
+	if errno = o.copyUpTree(from, fromSt, fromUpper); errno != 0 {
+		return errno
+	} else if errno = o.copyUpParent(to); errno != 0 {
+		return errno
+	} else if errno = o.upper.Rename(from, to); errno != 0 {
+		return errno
+	}
+
+	o.removed(from)
+	delete(o.whiteouts, to)
+	o.clearBelow(to)
+	if fromSt.Mode.IsDir() {
+		o.opaque[to] = struct{}{} // the whole tree is now in the upper layer
+	}
+	return 0
+}
+
+// Link implements the same method as documented on sys.FS
+func (o *overlayFS) Link(oldPath, newPath string) experimentalsys.Errno {
+	oldPath, newPath = overlayPath(oldPath), overlayPath(newPath)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	st, inUpper, errno := o.lstat(oldPath)
+	if errno != 0 {
+		return errno
+	} else if st.Mode.IsDir() {
+		return experimentalsys.EPERM
+	} else if _, _, errno = o.lstat(newPath); errno == 0 {
+		return experimentalsys.EEXIST
+	} else if errno != experimentalsys.ENOENT {
+		return errno
+	}
+
+	if !inUpper {
+		if errno = o.copyUp(oldPath, st); errno != 0 {
+			return errno
+		}
+	}
+	if errno = o.copyUpParent(newPath); errno != 0 {
+		return errno
+	} else if errno = o.upper.Link(oldPath, newPath); errno != 0 {
+		return errno
+	}
+	o.unhide(newPath, false)
+	return 0
+}
+
+// Symlink implements the same method as documented on sys.FS
+func (o *overlayFS) Symlink(oldPath, linkName string) experimentalsys.Errno {
+	p := overlayPath(linkName)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, _, errno := o.lstat(p); errno == 0 {
+		return experimentalsys.EEXIST
+	} else if errno != experimentalsys.ENOENT {
+		return errno
+	} else if errno = o.copyUpParent(p); errno != 0 {
+		return errno
+	} else if errno = o.upper.Symlink(oldPath, p); errno != 0 {
+		return errno
+	}
+	o.unhide(p, false)
+	return 0
+}
+
+// overlayDir is a directory opened read-only from an overlayFS. It returns
+// the entries of both layers from Readdir. Other methods use the directory
+// in the upper layer if it exists, or otherwise the lower one.
+type overlayDir struct {
+	experimentalsys.File
+
+	fs   *overlayFS
+	path string
+
+	// dirents are the directory entries not yet read by Readdir, or nil if
+	// the directory wasn't read since it was opened or rewound.
+	dirents []experimentalsys.Dirent
+
+	closed bool
+}
+
+// Seek implements the same method as documented on sys.File
+func (d *overlayDir) Seek(offset int64, whence int) (int64, experimentalsys.Errno) {
+	if offset == 0 && whence == io.SeekStart {
+		d.dirents = nil // rewind
+	}
+	return d.File.Seek(offset, whence)
+}
+
+// Readdir implements the same method as documented on sys.File
+func (d *overlayDir) Readdir(n int) (dirents []experimentalsys.Dirent, errno experimentalsys.Errno) {
+	if d.closed {
+		return nil, experimentalsys.EBADF
+	}
+
+	if d.dirents == nil {
+		d.fs.mu.Lock()
+		d.dirents, errno = d.fs.readdir(d.path)
+		d.fs.mu.Unlock()
+		if errno != 0 {
+			return nil, errno
+		} else if d.dirents == nil {
+			d.dirents = []experimentalsys.Dirent{}
+		}
+	}
+
+	if n <= 0 || n > len(d.dirents) {
+		n = len(d.dirents)
+	}
+	dirents = d.dirents[:n:n]
+	d.dirents = d.dirents[n:]
+	return
+}
+
+// Close implements the same method as documented on sys.File
+func (d *overlayDir) Close() experimentalsys.Errno {
+	d.closed = true
+	d.dirents = nil
+	return d.File.Close()
+}
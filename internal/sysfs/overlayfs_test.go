@@ -0,0 +1,235 @@
+package sysfs
+
+import (
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// newTestOverlayFS returns an OverlayFS over a read-only MemFS populated with
+// fstest.FS, and also the upper layer.
+func newTestOverlayFS(t *testing.T) (testFS, upper sys.FS) {
+	upper = MemFS()
+	return OverlayFS(upper, &ReadFS{FS: newTestMemFS(t)}), upper
+}
+
+func readdirNames(t *testing.T, testFS sys.FS, path string) (names []string) {
+	f, errno := testFS.OpenFile(path, sys.O_RDONLY, 0)
+	require.EqualErrno(t, 0, errno)
+	defer f.Close()
+
+	dirents := requireReaddir(t, f, -1, true)
+	for _, d := range dirents {
+		names = append(names, d.Name)
+	}
+	return
+}
+
+func TestOverlayFS_String(t *testing.T) {
+	require.Equal(t, "overlay", OverlayFS(MemFS(), MemFS()).(fmt.Stringer).String())
+}
+
+func TestOverlayFS_OpenFile(t *testing.T) {
+	testFS, upper := newTestOverlayFS(t)
+
+	testOpen_Read(t, testFS, true, true)
+
+	t.Run("reading doesn't copy up", func(t *testing.T) {
+		_, errno := upper.Lstat("animals.txt")
+		require.EqualErrno(t, sys.ENOENT, errno)
+	})
+
+	t.Run("writing copies up", func(t *testing.T) {
+		f, errno := testFS.OpenFile("sub/test.txt", sys.O_RDWR, 0)
+		require.EqualErrno(t, 0, errno)
+		defer f.Close()
+
+		_, errno = f.Pwrite([]byte("G"), 0)
+		require.EqualErrno(t, 0, errno)
+
+		buf := make([]byte, 14)
+		_, errno = f.Pread(buf, 0)
+		require.EqualErrno(t, 0, errno)
+		require.Equal(t, "Greet sub dir\n", string(buf))
+
+		st, errno := upper.Stat("sub")
+		require.EqualErrno(t, 0, errno)
+		require.Equal(t, fs.ModeDir|0o755, st.Mode)
+	})
+
+	t.Run("O_CREAT creates in upper", func(t *testing.T) {
+		f, errno := testFS.OpenFile("dir/a-/new", sys.O_RDWR|sys.O_CREAT, 0o600)
+		require.EqualErrno(t, 0, errno)
+		require.EqualErrno(t, 0, f.Close())
+
+		_, errno = upper.Stat("dir/a-/new")
+		require.EqualErrno(t, 0, errno)
+		require.Equal(t, []string{"-", "a-", "ab-"}, readdirNames(t, testFS, "dir"))
+	})
+
+	t.Run("O_EXCL on lower is EEXIST", func(t *testing.T) {
+		_, errno := testFS.OpenFile("animals.txt", sys.O_RDWR|sys.O_CREAT|sys.O_EXCL, 0o600)
+		require.EqualErrno(t, sys.EEXIST, errno)
+	})
+}
+
+func TestOverlayFS_Readdir(t *testing.T) {
+	testFS, upper := newTestOverlayFS(t)
+
+	require.EqualErrno(t, 0, upper.Mkdir("dir", 0o755))
+	f, errno := upper.OpenFile("dir/new", sys.O_RDWR|sys.O_CREAT, 0o600)
+	require.EqualErrno(t, 0, errno)
+	require.EqualErrno(t, 0, f.Close())
+
+	require.Equal(t, []string{"-", "a-", "ab-", "new"}, readdirNames(t, testFS, "dir"))
+}
+
+func TestOverlayFS_Stat(t *testing.T) {
+	testFS, _ := newTestOverlayFS(t)
+	testStat(t, testFS)
+}
+
+func TestOverlayFS_Unlink(t *testing.T) {
+	testFS, _ := newTestOverlayFS(t)
+
+	require.EqualErrno(t, sys.ENOENT, testFS.Unlink("cat"))
+	require.EqualErrno(t, sys.EISDIR, testFS.Unlink("sub"))
+
+	require.EqualErrno(t, 0, testFS.Unlink("animals.txt"))
+	_, errno := testFS.Stat("animals.txt")
+	require.EqualErrno(t, sys.ENOENT, errno)
+	require.EqualErrno(t, sys.ENOENT, testFS.Unlink("animals.txt"))
+	require.Equal(t, []string{"dir", "empty.txt", "emptydir", "sub"}, readdirNames(t, testFS, "."))
+
+	// Creating a file at the same path shows it again, without the lower
+	// contents.
+	f, errno := testFS.OpenFile("animals.txt", sys.O_RDWR|sys.O_CREAT|sys.O_EXCL, 0o600)
+	require.EqualErrno(t, 0, errno)
+	defer f.Close()
+
+	st, errno := f.Stat()
+	require.EqualErrno(t, 0, errno)
+	require.Zero(t, st.Size)
+}
+
+func TestOverlayFS_Rmdir(t *testing.T) {
+	testFS, _ := newTestOverlayFS(t)
+
+	require.EqualErrno(t, sys.ENOENT, testFS.Rmdir("rmdir"))
+	require.EqualErrno(t, sys.ENOTDIR, testFS.Rmdir("animals.txt"))
+	require.EqualErrno(t, sys.ENOTEMPTY, testFS.Rmdir("sub"))
+
+	require.EqualErrno(t, 0, testFS.Unlink("sub/test.txt"))
+	require.EqualErrno(t, 0, testFS.Rmdir("sub"))
+	_, errno := testFS.Stat("sub/test.txt")
+	require.EqualErrno(t, sys.ENOENT, errno)
+
+	// A directory re-created at the same path doesn't show the lower
+	// contents.
+	require.EqualErrno(t, 0, testFS.Mkdir("sub", 0o700))
+	require.Zero(t, len(readdirNames(t, testFS, "sub")))
+	_, errno = testFS.Stat("sub/test.txt")
+	require.EqualErrno(t, sys.ENOENT, errno)
+}
+
+func TestOverlayFS_Mkdir(t *testing.T) {
+	testFS, upper := newTestOverlayFS(t)
+
+	require.EqualErrno(t, sys.EEXIST, testFS.Mkdir("sub", 0o700))
+	require.EqualErrno(t, sys.ENOENT, testFS.Mkdir("non-existing-dir/foo", 0o700))
+	require.EqualErrno(t, 0, testFS.Mkdir("dir/a-/new", 0o700))
+
+	st, errno := upper.Stat("dir/a-/new")
+	require.EqualErrno(t, 0, errno)
+	require.True(t, st.Mode.IsDir())
+}
+
+func TestOverlayFS_Rename(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		expected sys.Errno
+	}{
+		{name: "from doesn't exist", from: "cat", to: "animals.txt", expected: sys.ENOENT},
+		{name: "file to non-exist", from: "animals.txt", to: "zoo.txt"},
+		{name: "file to file", from: "animals.txt", to: "empty.txt"},
+		{name: "file to itself", from: "animals.txt", to: "animals.txt"},
+		{name: "dir to non-exist", from: "dir", to: "sub/dir"},
+		{name: "dir to empty dir", from: "dir", to: "emptydir"},
+		{name: "dir to file", from: "sub", to: "animals.txt", expected: sys.ENOTDIR},
+		{name: "file to dir", from: "animals.txt", to: "sub", expected: sys.EISDIR},
+		{name: "dir to non-empty dir", from: "sub", to: "dir", expected: sys.ENOTEMPTY},
+		{name: "dir to its subdirectory", from: "dir", to: "dir/a-/dir", expected: sys.EINVAL},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			testFS, _ := newTestOverlayFS(t)
+			before, _ := testFS.Lstat(tc.from)
+
+			errno := testFS.Rename(tc.from, tc.to)
+			require.EqualErrno(t, tc.expected, errno)
+			if errno != 0 {
+				return
+			}
+
+			after, errno := testFS.Lstat(tc.to)
+			require.EqualErrno(t, 0, errno)
+			require.Equal(t, before.Mode, after.Mode)
+			require.Equal(t, before.Size, after.Size)
+			if tc.from == tc.to {
+				return
+			}
+
+			_, errno = testFS.Lstat(tc.from)
+			require.EqualErrno(t, sys.ENOENT, errno)
+			if before.Mode.IsDir() {
+				require.Equal(t, []string{"-", "a-", "ab-"}, readdirNames(t, testFS, tc.to))
+			}
+		})
+	}
+}
+
+func TestOverlayFS_Link(t *testing.T) {
+	testFS, _ := newTestOverlayFS(t)
+
+	require.EqualErrno(t, sys.ENOENT, testFS.Link("cat", "foo"))
+	require.EqualErrno(t, sys.EEXIST, testFS.Link("sub/test.txt", "animals.txt"))
+	require.EqualErrno(t, sys.EPERM, testFS.Link("sub", "foo"))
+	require.EqualErrno(t, 0, testFS.Link("sub/test.txt", "foo"))
+
+	st, errno := testFS.Stat("foo")
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, uint64(2), st.Nlink)
+}
+
+func TestOverlayFS_Symlink(t *testing.T) {
+	testFS, upper := newTestOverlayFS(t)
+
+	require.EqualErrno(t, sys.EEXIST, testFS.Symlink("sub/test.txt", "animals.txt"))
+	require.EqualErrno(t, 0, testFS.Symlink("test.txt", "sub/link"))
+
+	target, errno := testFS.Readlink("sub/link")
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, "test.txt", target)
+
+	_, errno = upper.Lstat("sub/link")
+	require.EqualErrno(t, 0, errno)
+}
+
+func TestOverlayFS_Chmod(t *testing.T) {
+	testFS, upper := newTestOverlayFS(t)
+
+	require.EqualErrno(t, 0, testFS.Chmod("sub/test.txt", 0o600))
+	requireMode(t, testFS, "sub/test.txt", 0o600)
+	requireMode(t, upper, "sub/test.txt", 0o600)
+
+	require.EqualErrno(t, 0, testFS.Utimens("animals.txt", sys.UTIME_OMIT, 2))
+	st, errno := testFS.Stat("animals.txt")
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, int64(2), st.Mtim)
+}
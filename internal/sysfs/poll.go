@@ -9,10 +9,19 @@ import (
 
 // poll implements `Poll` as documented on sys.File via a file descriptor.
 func poll(fd uintptr, flag fsapi.Pflag, timeoutMillis int32) (ready bool, errno sys.Errno) {
-	if flag != fsapi.POLLIN {
+	var events int16
+	switch flag {
+	case fsapi.POLLIN:
+		events = _POLLIN
+	case fsapi.POLLOUT:
+		if _POLLOUT == 0 {
+			return false, sys.ENOTSUP // not supported on this platform.
+		}
+		events = _POLLOUT
+	default:
 		return false, sys.ENOTSUP
 	}
-	fds := []pollFd{newPollFd(fd, _POLLIN, 0)}
+	fds := []pollFd{newPollFd(fd, events, 0)}
 	count, errno := _poll(fds, timeoutMillis)
 	return count > 0, errno
 }
@@ -23,16 +23,22 @@ func newPollFd(fd uintptr, events, revents int16) pollFd {
 	return pollFd{fd: int32(fd), events: events, revents: revents}
 }
 
-// _POLLIN subscribes a notification when any readable data is available.
-const _POLLIN = 0x0001
+const (
+	// _POLLIN subscribes a notification when any readable data is available.
+	_POLLIN = 0x0001
+	// _POLLOUT subscribes a notification when data can be written.
+	_POLLOUT = 0x0004
+)
 
 // _poll implements poll on Linux via ppoll.
 func _poll(fds []pollFd, timeoutMillis int32) (n int, errno sys.Errno) {
-	var ts syscall.Timespec
+	// A nil timespec blocks indefinitely, which is the case when negative.
+	var ts *syscall.Timespec
 	if timeoutMillis >= 0 {
-		ts = syscall.NsecToTimespec(int64(time.Duration(timeoutMillis) * time.Millisecond))
+		t := syscall.NsecToTimespec(int64(time.Duration(timeoutMillis) * time.Millisecond))
+		ts = &t
 	}
-	return ppoll(fds, &ts)
+	return ppoll(fds, ts)
 }
 
 // ppoll is a poll variant that allows to subscribe to a mask of signals.
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/internal/fsapi"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 )
 
@@ -82,4 +83,19 @@ func Test_poll(t *testing.T) {
 			return
 		}
 	})
+
+	t.Run("should be ready to write to a pipe", func(t *testing.T) {
+		rr, ww, err := os.Pipe()
+		require.NoError(t, err)
+		defer rr.Close()
+		defer ww.Close()
+
+		ready, errno := poll(ww.Fd(), fsapi.POLLOUT, 0)
+		if runtime.GOOS == "windows" {
+			require.EqualErrno(t, sys.ENOTSUP, errno)
+		} else {
+			require.EqualErrno(t, 0, errno)
+			require.True(t, ready)
+		}
+	})
 }
@@ -20,6 +20,8 @@ const (
 	_POLLRDBAND = 0x0200
 	// _POLLIN subscribes a notification when any readable data is available.
 	_POLLIN = (_POLLRDNORM | _POLLRDBAND)
+	// _POLLOUT is zero as _poll only supports _POLLIN.
+	_POLLOUT = 0
 )
 
 // pollFd is the struct to query for file descriptor events using poll.
@@ -0,0 +1,253 @@
+package sysfs
+
+import (
+	"io/fs"
+	"sync"
+
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+)
+
+// Quota limits how much a guest can write to a filesystem returned by
+// QuotaFS. A zero field means unlimited.
+type Quota struct {
+	// MaxBytes is the total bytes that can be written to files, including
+	// bytes added by extending a file with Truncate. Writes beyond this
+	// return sys.ENOSPC.
+	//
+	// Note: Bytes are counted when written, so overwriting or removing a file
+	// doesn't free space.
+	MaxBytes int64
+
+	// MaxFiles is the count of files, directories and links that can be
+	// created. Creating more returns sys.EDQUOT.
+	MaxFiles int64
+
+	// MaxDepth is the count of path components allowed in the path of a
+	// created file, directory or link, for example 2 for "dir/file". Deeper
+	// paths return sys.EDQUOT.
+	MaxDepth int
+}
+
+// QuotaFS returns a filesystem which enforces `quota` on writes to `fsys`.
+// Reads and other operations are passed through unchanged.
+//
+// Note: Usage is tracked in the result, so use a new QuotaFS per module
+// instance, for example, in a multi-tenant host.
+func QuotaFS(fsys experimentalsys.FS, quota Quota) experimentalsys.FS {
+	return &quotaFS{FS: fsys, quota: quota}
+}
+
+type quotaFS struct {
+	experimentalsys.FS
+
+	quota Quota
+
+	// mu guards written and files, as files opened from this filesystem
+	// share the same quota.
+	mu      sync.Mutex
+	written int64
+	files   int64
+}
+
+// OpenFile implements the same method as documented on sys.FS
+func (q *quotaFS) OpenFile(path string, flag experimentalsys.Oflag, perm fs.FileMode) (experimentalsys.File, experimentalsys.Errno) {
+	var create bool
+	if flag&experimentalsys.O_CREAT != 0 {
+		if _, errno := q.FS.Stat(path); errno == experimentalsys.ENOENT {
+			if errno = q.reserveFile(path); errno != 0 {
+				return nil, errno
+			}
+			create = true
+		}
+	}
+
+	f, errno := q.FS.OpenFile(path, flag, perm)
+	if errno != 0 {
+		if create {
+			q.releaseFile()
+		}
+		return nil, errno
+	}
+	return &quotaFile{File: f, fs: q}, 0
+}
+
+// Mkdir implements the same method as documented on sys.FS
+func (q *quotaFS) Mkdir(path string, perm fs.FileMode) experimentalsys.Errno {
+	if errno := q.reserveFile(path); errno != 0 {
+		return errno
+	}
+	errno := q.FS.Mkdir(path, perm)
+	if errno != 0 {
+		q.releaseFile()
+	}
+	return errno
+}
+
+// Rename implements the same method as documented on sys.FS
+func (q *quotaFS) Rename(from, to string) experimentalsys.Errno {
+	if q.tooDeep(to) {
+		return experimentalsys.EDQUOT
+	}
+	return q.FS.Rename(from, to)
+}
+
+// Link implements the same method as documented on sys.FS
+func (q *quotaFS) Link(oldPath, newPath string) experimentalsys.Errno {
+	if errno := q.reserveFile(newPath); errno != 0 {
+		return errno
+	}
+	errno := q.FS.Link(oldPath, newPath)
+	if errno != 0 {
+		q.releaseFile()
+	}
+	return errno
+}
+
+// Symlink implements the same method as documented on sys.FS
+func (q *quotaFS) Symlink(oldPath, linkName string) experimentalsys.Errno {
+	if errno := q.reserveFile(linkName); errno != 0 {
+		return errno
+	}
+	errno := q.FS.Symlink(oldPath, linkName)
+	if errno != 0 {
+		q.releaseFile()
+	}
+	return errno
+}
+
+// Lutimens implements the same method as documented on lutimensFS
+func (q *quotaFS) Lutimens(path string, atim, mtim int64) experimentalsys.Errno {
+	return Lutimens(q.FS, path, atim, mtim)
+}
+
+// reserveFile counts a file about to be created at `path`, or returns
+// sys.EDQUOT if that would exceed the quota.
+func (q *quotaFS) reserveFile(path string) experimentalsys.Errno {
+	if q.tooDeep(path) {
+		return experimentalsys.EDQUOT
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if max := q.quota.MaxFiles; max > 0 && q.files >= max {
+		return experimentalsys.EDQUOT
+	}
+	q.files++
+	return 0
+}
+
+// releaseFile undoes reserveFile when creating the file failed.
+func (q *quotaFS) releaseFile() {
+	q.mu.Lock()
+	q.files--
+	q.mu.Unlock()
+}
+
+// tooDeep returns true if `path` has more components than allowed.
+func (q *quotaFS) tooDeep(path string) bool {
+	max := q.quota.MaxDepth
+	if max <= 0 {
+		return false
+	}
+	depth := 0
+	for _, c := range splitPath(nil, path) {
+		if c == ".." {
+			if depth > 0 {
+				depth--
+			}
+		} else {
+			depth++
+		}
+	}
+	return depth > max
+}
+
+// reserveBytes returns up to `n` bytes that can be written, or sys.ENOSPC if
+// none remain.
+func (q *quotaFS) reserveBytes(n int64) (int64, experimentalsys.Errno) {
+	if n == 0 {
+		return 0, 0
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if max := q.quota.MaxBytes; max > 0 {
+		if remaining := max - q.written; remaining <= 0 {
+			return 0, experimentalsys.ENOSPC
+		} else if n > remaining {
+			n = remaining
+		}
+	}
+	q.written += n
+	return n, 0
+}
+
+// releaseBytes undoes reserveBytes for bytes which weren't written.
+func (q *quotaFS) releaseBytes(n int64) {
+	if n == 0 {
+		return
+	}
+	q.mu.Lock()
+	q.written -= n
+	q.mu.Unlock()
+}
+
+// compile-time check to ensure quotaFile implements sys.File.
+var _ experimentalsys.File = (*quotaFile)(nil)
+
+type quotaFile struct {
+	experimentalsys.File
+
+	fs *quotaFS
+}
+
+// Write implements the same method as documented on sys.File.
+func (f *quotaFile) Write(buf []byte) (int, experimentalsys.Errno) {
+	n, errno := f.fs.reserveBytes(int64(len(buf)))
+	if errno != 0 {
+		return 0, errno
+	}
+	written, errno := f.File.Write(buf[:n])
+	f.fs.releaseBytes(n - int64(written))
+	return written, errno
+}
+
+// Pwrite implements the same method as documented on sys.File.
+func (f *quotaFile) Pwrite(buf []byte, off int64) (int, experimentalsys.Errno) {
+	n, errno := f.fs.reserveBytes(int64(len(buf)))
+	if errno != 0 {
+		return 0, errno
+	}
+	written, errno := f.File.Pwrite(buf[:n], off)
+	f.fs.releaseBytes(n - int64(written))
+	return written, errno
+}
+
+// Truncate implements the same method as documented on sys.File.
+func (f *quotaFile) Truncate(size int64) experimentalsys.Errno {
+	st, errno := f.File.Stat()
+	if errno != 0 {
+		return errno
+	}
+
+	grow := size - st.Size
+	if grow <= 0 {
+		return f.File.Truncate(size)
+	}
+
+	// Extending a file must fit entirely, as a partial truncate isn't
+	// possible.
+	n, errno := f.fs.reserveBytes(grow)
+	if errno != 0 {
+		return errno
+	} else if n < grow {
+		f.fs.releaseBytes(n)
+		return experimentalsys.ENOSPC
+	}
+	if errno = f.File.Truncate(size); errno != 0 {
+		f.fs.releaseBytes(n)
+	}
+	return errno
+}
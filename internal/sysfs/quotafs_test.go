@@ -0,0 +1,109 @@
+package sysfs
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestQuotaFS_MaxBytes(t *testing.T) {
+	testFS := QuotaFS(MemFS(), Quota{MaxBytes: 10})
+
+	f, errno := testFS.OpenFile("file", sys.O_RDWR|sys.O_CREAT, 0o600)
+	require.EqualErrno(t, 0, errno)
+	defer f.Close()
+
+	n, errno := f.Write([]byte("hello"))
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, 5, n)
+
+	// Only the remaining bytes are written.
+	n, errno = f.Pwrite([]byte("world!"), 5)
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, 5, n)
+
+	_, errno = f.Write([]byte("!"))
+	require.EqualErrno(t, sys.ENOSPC, errno)
+
+	// Overwriting doesn't free space.
+	_, errno = f.Pwrite([]byte("H"), 0)
+	require.EqualErrno(t, sys.ENOSPC, errno)
+
+	// Shrinking is allowed, but growing isn't.
+	require.EqualErrno(t, 0, f.Truncate(5))
+	require.EqualErrno(t, sys.ENOSPC, f.Truncate(6))
+
+	// The quota is shared with other files.
+	f2, errno := testFS.OpenFile("file2", sys.O_RDWR|sys.O_CREAT, 0o600)
+	require.EqualErrno(t, 0, errno)
+	defer f2.Close()
+	_, errno = f2.Write([]byte("a"))
+	require.EqualErrno(t, sys.ENOSPC, errno)
+}
+
+func TestQuotaFS_Truncate(t *testing.T) {
+	testFS := QuotaFS(MemFS(), Quota{MaxBytes: 10})
+
+	f, errno := testFS.OpenFile("file", sys.O_RDWR|sys.O_CREAT, 0o600)
+	require.EqualErrno(t, 0, errno)
+	defer f.Close()
+
+	// Growing counts, and must fit entirely.
+	require.EqualErrno(t, sys.ENOSPC, f.Truncate(11))
+	require.EqualErrno(t, 0, f.Truncate(8))
+
+	n, errno := f.Write([]byte("abcd"))
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, 2, n)
+}
+
+func TestQuotaFS_MaxFiles(t *testing.T) {
+	memFS := MemFS()
+	testFS := QuotaFS(memFS, Quota{MaxFiles: 3})
+
+	f, errno := testFS.OpenFile("file", sys.O_RDWR|sys.O_CREAT, 0o600)
+	require.EqualErrno(t, 0, errno)
+	require.EqualErrno(t, 0, f.Close())
+
+	// Opening an existing file doesn't count.
+	f, errno = testFS.OpenFile("file", sys.O_RDWR|sys.O_CREAT, 0o600)
+	require.EqualErrno(t, 0, errno)
+	require.EqualErrno(t, 0, f.Close())
+
+	// Failures don't count.
+	require.EqualErrno(t, sys.ENOENT, testFS.Mkdir("missing/dir", 0o700))
+
+	require.EqualErrno(t, 0, testFS.Mkdir("dir", 0o700))
+	require.EqualErrno(t, 0, testFS.Symlink("file", "link"))
+
+	require.EqualErrno(t, sys.EDQUOT, testFS.Link("file", "link2"))
+	require.EqualErrno(t, sys.EDQUOT, testFS.Mkdir("dir2", 0o700))
+	_, errno = testFS.OpenFile("file2", sys.O_RDWR|sys.O_CREAT, 0o600)
+	require.EqualErrno(t, sys.EDQUOT, errno)
+
+	_, errno = memFS.Stat("file2")
+	require.EqualErrno(t, sys.ENOENT, errno)
+}
+
+func TestQuotaFS_MaxDepth(t *testing.T) {
+	testFS := QuotaFS(MemFS(), Quota{MaxDepth: 2})
+
+	require.EqualErrno(t, 0, testFS.Mkdir("a", 0o700))
+	require.EqualErrno(t, 0, testFS.Mkdir("a/b", 0o700))
+	require.EqualErrno(t, sys.EDQUOT, testFS.Mkdir("a/b/c", 0o700))
+	require.EqualErrno(t, sys.EDQUOT, testFS.Symlink("..", "a/b/c"))
+	_, errno := testFS.OpenFile("a/b/c", sys.O_RDWR|sys.O_CREAT, 0o600)
+	require.EqualErrno(t, sys.EDQUOT, errno)
+
+	// ".." is accounted for.
+	require.EqualErrno(t, 0, testFS.Mkdir("a/b/../c", 0o700))
+
+	require.EqualErrno(t, sys.EDQUOT, testFS.Rename("a/c", "a/b/c"))
+	require.EqualErrno(t, 0, testFS.Rename("a/c", "c"))
+
+	st, errno := testFS.Stat("c")
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, fs.ModeDir, st.Mode.Type())
+}
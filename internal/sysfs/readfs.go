@@ -19,13 +19,28 @@ func (r *ReadFS) OpenFile(path string, flag experimentalsys.Oflag, perm fs.FileM
 		if flag&experimentalsys.O_DIRECTORY != 0 {
 			return nil, experimentalsys.EISDIR
 		}
-		return nil, experimentalsys.ENOSYS
+		return nil, experimentalsys.EROFS
 	default: // sys.O_RDONLY (integer zero) so we are ok!
 	}
 
+	// Like open(2), O_CREAT is only an error when the file doesn't exist, as
+	// otherwise nothing would be written.
+	if flag&experimentalsys.O_TRUNC != 0 {
+		return nil, experimentalsys.EROFS
+	}
+	create := flag&experimentalsys.O_CREAT != 0
+	excl := flag&experimentalsys.O_EXCL != 0
+	flag &= ^(experimentalsys.O_CREAT | experimentalsys.O_EXCL)
+
 	f, errno := r.FS.OpenFile(path, flag, perm)
-	if errno != 0 {
+	switch {
+	case errno == experimentalsys.ENOENT && create:
+		return nil, experimentalsys.EROFS
+	case errno != 0:
 		return nil, errno
+	case create && excl:
+		_ = f.Close()
+		return nil, experimentalsys.EEXIST
 	}
 	return &readFile{f}, 0
 }
@@ -70,6 +85,11 @@ func (r *ReadFS) Utimens(path string, atim, mtim int64) experimentalsys.Errno {
 	return experimentalsys.EROFS
 }
 
+// Lutimens implements the same method as documented on lutimensFS
+func (r *ReadFS) Lutimens(path string, atim, mtim int64) experimentalsys.Errno {
+	return experimentalsys.EROFS
+}
+
 // compile-time check to ensure readFile implements api.File.
 var _ experimentalsys.File = (*readFile)(nil)
 
@@ -104,7 +124,7 @@ func (r *readFile) Datasync() experimentalsys.Errno {
 
 // Utimens implements the same method as documented on sys.File.
 func (r *readFile) Utimens(int64, int64) experimentalsys.Errno {
-	return experimentalsys.EBADF
+	return experimentalsys.EROFS
 }
 
 func (r *readFile) writeErr() experimentalsys.Errno {
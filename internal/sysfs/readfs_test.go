@@ -3,6 +3,7 @@ package sysfs
 import (
 	"io/fs"
 	"os"
+	"path"
 	"runtime"
 	"testing"
 
@@ -180,6 +181,48 @@ func TestReadFS_Open_Read(t *testing.T) {
 	}
 }
 
+func TestReadFS_OpenFile_flags(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, fstest.WriteTestFiles(tmpDir))
+
+	testFS := &ReadFS{FS: DirFS(tmpDir)}
+
+	tests := []struct {
+		name          string
+		path          string
+		flag          sys.Oflag
+		expectedErrno sys.Errno
+	}{
+		{name: "O_WRONLY", path: "animals.txt", flag: sys.O_WRONLY, expectedErrno: sys.EROFS},
+		{name: "O_RDWR", path: "animals.txt", flag: sys.O_RDWR, expectedErrno: sys.EROFS},
+		{name: "O_CREAT existing", path: "animals.txt", flag: sys.O_RDONLY | sys.O_CREAT},
+		{name: "O_CREAT non-existing", path: "zoo.txt", flag: sys.O_RDONLY | sys.O_CREAT, expectedErrno: sys.EROFS},
+		{name: "O_EXCL existing", path: "animals.txt", flag: sys.O_RDONLY | sys.O_CREAT | sys.O_EXCL, expectedErrno: sys.EEXIST},
+		{name: "O_EXCL non-existing", path: "zoo.txt", flag: sys.O_RDONLY | sys.O_CREAT | sys.O_EXCL, expectedErrno: sys.EROFS},
+		{name: "O_TRUNC", path: "animals.txt", flag: sys.O_RDONLY | sys.O_TRUNC, expectedErrno: sys.EROFS},
+		{name: "O_DIRECTORY file", path: "animals.txt", flag: sys.O_RDONLY | sys.O_DIRECTORY, expectedErrno: sys.ENOTDIR},
+		{name: "O_DIRECTORY writeable", path: "sub", flag: sys.O_RDWR | sys.O_DIRECTORY, expectedErrno: sys.EISDIR},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			f, errno := testFS.OpenFile(tc.path, tc.flag, 0o600)
+			require.EqualErrno(t, tc.expectedErrno, errno)
+			if errno == 0 {
+				require.EqualErrno(t, 0, f.Close())
+			}
+		})
+	}
+
+	// Nothing was created or truncated.
+	_, err := os.Stat(path.Join(tmpDir, "zoo.txt"))
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	st, err := os.Stat(path.Join(tmpDir, "animals.txt"))
+	require.NoError(t, err)
+	require.NotEqual(t, int64(0), st.Size())
+}
+
 func TestReadFS_Stat(t *testing.T) {
 	tmpDir := t.TempDir()
 	require.NoError(t, fstest.WriteTestFiles(tmpDir))
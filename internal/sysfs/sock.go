@@ -5,6 +5,7 @@ import (
 	"os"
 
 	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/internal/fsapi"
 	socketapi "github.com/tetratelabs/wazero/internal/sock"
 	"github.com/tetratelabs/wazero/sys"
 )
@@ -14,6 +15,50 @@ func NewTCPListenerFile(tl *net.TCPListener) socketapi.TCPSock {
 	return newTCPListenerFile(tl)
 }
 
+// NewTCPConnFile creates a socketapi.TCPConn for a given *net.TCPConn.
+func NewTCPConnFile(tc *net.TCPConn) socketapi.TCPConn {
+	return newTcpConn(tc)
+}
+
+// OpenedSock is a socket the guest created with sock_open, before it is
+// listening or connected. As Go doesn't expose unconnected sockets, the
+// address is recorded until sock_listen or sock_connect replaces this with a
+// socketapi.TCPSock or socketapi.TCPConn.
+//
+// UDP sockets are instead replaced with a socketapi.UDPConn on sock_bind,
+// sock_connect or their first use.
+type OpenedSock struct {
+	baseSockFile
+
+	// Network is "tcp4", "tcp6", "udp4" or "udp6".
+	Network string
+
+	// Addr is the "host:port" set by sock_bind, or empty if not yet bound.
+	Addr string
+
+	nonblock bool
+}
+
+var _ fsapi.File = (*OpenedSock)(nil)
+
+// IsNonblock implements the same method as documented on fsapi.File
+func (f *OpenedSock) IsNonblock() bool {
+	return f.nonblock
+}
+
+// SetNonblock implements the same method as documented on fsapi.File
+//
+// Note: This is applied to the socket which replaces this one.
+func (f *OpenedSock) SetNonblock(enabled bool) experimentalsys.Errno {
+	f.nonblock = enabled
+	return 0
+}
+
+// Poll implements the same method as documented on fsapi.File
+func (f *OpenedSock) Poll(fsapi.Pflag, int32) (ready bool, errno experimentalsys.Errno) {
+	return false, experimentalsys.ENOTSUP
+}
+
 // baseSockFile implements base behavior for all TCPSock, TCPConn files,
 // regardless the platform.
 type baseSockFile struct {
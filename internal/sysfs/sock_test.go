@@ -132,3 +132,38 @@ func TestTcpConnFile_SetNonblock(t *testing.T) {
 	require.EqualErrno(t, 0, errno)
 	require.True(t, file.IsNonblock())
 }
+
+func TestUdpConnFile(t *testing.T) {
+	host, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer host.Close()
+	hostAddr := host.LocalAddr().(*net.UDPAddr)
+
+	uc, err := net.DialUDP("udp4", nil, hostAddr)
+	require.NoError(t, err)
+	file := NewUDPConnFile("udp4", uc)
+	defer file.Close()
+	require.Equal(t, "udp4", file.Network())
+
+	// Non-blocking mode isn't supported.
+	require.EqualErrno(t, sys.ENOTSUP, fsapi.Adapt(file).SetNonblock(true))
+
+	_, errno := file.Write([]byte("wazero"))
+	require.EqualErrno(t, 0, errno)
+
+	buf := make([]byte, 16)
+	n, from, err := host.ReadFromUDP(buf)
+	require.NoError(t, err)
+	require.Equal(t, "wazero", string(buf[:n]))
+
+	_, err = host.WriteToUDP([]byte("pong"), from)
+	require.NoError(t, err)
+
+	n, addr, errno := file.ReadFrom(buf)
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, "pong", string(buf[:n]))
+	require.Equal(t, hostAddr.String(), addr.String())
+
+	require.EqualErrno(t, 0, file.Close())
+	require.EqualErrno(t, 0, file.Close()) // idempotent
+}
@@ -0,0 +1,97 @@
+package sysfs
+
+import (
+	"net"
+
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/internal/fsapi"
+	socketapi "github.com/tetratelabs/wazero/internal/sock"
+)
+
+// NewUDPConnFile creates a socketapi.UDPConn for a given *net.UDPConn, opened
+// with the `network` "udp4" or "udp6".
+//
+// Note: Unlike TCP, this delegates to the Go standard library on all
+// platforms, so doesn't support non-blocking mode or Poll.
+func NewUDPConnFile(network string, uc *net.UDPConn) socketapi.UDPConn {
+	return &udpConnFile{network: network, uc: uc}
+}
+
+var (
+	_ socketapi.UDPConn = (*udpConnFile)(nil)
+	_ fsapi.File        = (*udpConnFile)(nil)
+)
+
+type udpConnFile struct {
+	baseSockFile
+
+	network string
+	uc      *net.UDPConn
+
+	// closed is true when closed was called. This ensures proper sys.EBADF
+	closed bool
+}
+
+// Network implements the same method as documented on socketapi.UDPConn
+func (f *udpConnFile) Network() string {
+	return f.network
+}
+
+// LocalAddr is exposed for testing.
+func (f *udpConnFile) LocalAddr() *net.UDPAddr {
+	return f.uc.LocalAddr().(*net.UDPAddr)
+}
+
+// Read implements the same method as documented on sys.File
+func (f *udpConnFile) Read(buf []byte) (int, experimentalsys.Errno) {
+	if len(buf) == 0 {
+		return 0, 0 // Short-circuit 0-len reads.
+	}
+	n, err := f.uc.Read(buf)
+	return n, experimentalsys.UnwrapOSError(err)
+}
+
+// Write implements the same method as documented on sys.File
+func (f *udpConnFile) Write(buf []byte) (int, experimentalsys.Errno) {
+	n, err := f.uc.Write(buf)
+	return n, experimentalsys.UnwrapOSError(err)
+}
+
+// ReadFrom implements the same method as documented on socketapi.UDPConn
+func (f *udpConnFile) ReadFrom(p []byte) (int, *net.UDPAddr, experimentalsys.Errno) {
+	n, addr, err := f.uc.ReadFromUDP(p)
+	return n, addr, experimentalsys.UnwrapOSError(err)
+}
+
+// WriteTo implements the same method as documented on socketapi.UDPConn
+func (f *udpConnFile) WriteTo(p []byte, addr *net.UDPAddr) (int, experimentalsys.Errno) {
+	n, err := f.uc.WriteToUDP(p, addr)
+	return n, experimentalsys.UnwrapOSError(err)
+}
+
+// Close implements the same method as documented on sys.File
+func (f *udpConnFile) Close() experimentalsys.Errno {
+	if f.closed {
+		return 0
+	}
+	f.closed = true
+	return experimentalsys.UnwrapOSError(f.uc.Close())
+}
+
+// IsNonblock implements the same method as documented on fsapi.File
+func (f *udpConnFile) IsNonblock() bool {
+	return false
+}
+
+// SetNonblock implements the same method as documented on fsapi.File
+func (f *udpConnFile) SetNonblock(enabled bool) experimentalsys.Errno {
+	if enabled {
+		return experimentalsys.ENOTSUP
+	}
+	return 0
+}
+
+// Poll implements the same method as documented on fsapi.File
+func (f *udpConnFile) Poll(fsapi.Pflag, int32) (ready bool, errno experimentalsys.Errno) {
+	return false, experimentalsys.ENOTSUP
+}
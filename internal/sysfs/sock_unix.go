@@ -100,7 +100,15 @@ func newTcpConn(tc *net.TCPConn) socketapi.TCPConn {
 	if err != nil {
 		panic(err)
 	}
-	return &tcpConnFile{fd: f.Fd()}
+	defer f.Close()
+	// Like newTCPListenerFile, duplicate the file handle, so that closing
+	// the os.File or the TCPConn doesn't close the connection.
+	sysfd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		panic(err)
+	}
+	_ = tc.Close()
+	return &tcpConnFile{fd: uintptr(sysfd)}
 }
 
 // Read implements the same method as documented on sys.File
@@ -16,6 +16,10 @@ func newTCPListenerFile(tl *net.TCPListener) socketapi.TCPSock {
 	return &unsupportedSockFile{}
 }
 
+func newTcpConn(tc *net.TCPConn) socketapi.TCPConn {
+	return &unsupportedSockFile{}
+}
+
 type unsupportedSockFile struct {
 	baseSockFile
 }
@@ -24,3 +28,13 @@ type unsupportedSockFile struct {
 func (f *unsupportedSockFile) Accept() (socketapi.TCPConn, sys.Errno) {
 	return nil, sys.ENOSYS
 }
+
+// Recvfrom implements the same method as documented on socketapi.TCPConn
+func (f *unsupportedSockFile) Recvfrom([]byte, int) (int, sys.Errno) {
+	return 0, sys.ENOSYS
+}
+
+// Shutdown implements the same method as documented on socketapi.TCPConn
+func (f *unsupportedSockFile) Shutdown(int) sys.Errno {
+	return sys.ENOSYS
+}
@@ -0,0 +1,286 @@
+package sysfs
+
+import (
+	"container/list"
+	"io/fs"
+	"sync"
+	"time"
+
+	experimentalsys "github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// StatCache configures NewStatCacheFS.
+type StatCache struct {
+	// MaxEntries is the count of results kept, after which the least recently
+	// used are evicted. Zero defaults to 1024.
+	MaxEntries int
+
+	// TTL is how long a result is kept, or zero to keep it until evicted or
+	// invalidated. Set this when the host may change files while the guest
+	// runs.
+	TTL time.Duration
+}
+
+// StatCacheMetrics are counters of a StatCacheFS, since it was created.
+type StatCacheMetrics struct {
+	// Hits is the count of Stat or Lstat calls answered from the cache.
+	Hits uint64
+
+	// Misses is the count of Stat or Lstat calls passed to the underlying
+	// filesystem.
+	Misses uint64
+
+	// Evictions is the count of results removed to stay within MaxEntries.
+	Evictions uint64
+
+	// Invalidations is the count of times the cache was cleared due to a
+	// write or a call to Invalidate.
+	Invalidations uint64
+}
+
+// StatCacheFS caches the results of Stat and Lstat, including sys.ENOENT, as
+// guests such as python stat the same paths many times while starting.
+//
+// Any operation which could change a result, such as Mkdir or writing to an
+// open file, clears the cache. Call Invalidate if the host changes files in
+// the underlying filesystem.
+//
+// Note: Use a new StatCacheFS per module instance, as results are shared by
+// all guests using it.
+type StatCacheFS struct {
+	experimentalsys.FS
+
+	maxEntries int
+	ttl        time.Duration
+	now        func() time.Time
+
+	// mu guards the below fields, as files opened from this filesystem
+	// invalidate the cache concurrently.
+	mu      sync.Mutex
+	entries map[statCacheKey]*list.Element
+	lru     list.List // of *statCacheEntry, most recently used first.
+	metrics StatCacheMetrics
+}
+
+type statCacheKey struct {
+	path   string
+	follow bool
+}
+
+type statCacheEntry struct {
+	key     statCacheKey
+	st      sys.Stat_t
+	errno   experimentalsys.Errno
+	expires time.Time
+}
+
+// NewStatCacheFS returns a StatCacheFS which caches `fsys` as configured by
+// `cache`.
+func NewStatCacheFS(fsys experimentalsys.FS, cache StatCache) *StatCacheFS {
+	maxEntries := cache.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = 1024
+	}
+	return &StatCacheFS{
+		FS:         fsys,
+		maxEntries: maxEntries,
+		ttl:        cache.TTL,
+		now:        time.Now,
+		entries:    map[statCacheKey]*list.Element{},
+	}
+}
+
+// String implements fmt.Stringer
+func (c *StatCacheFS) String() string {
+	if s, ok := c.FS.(interface{ String() string }); ok {
+		return s.String()
+	}
+	return "stat-cache"
+}
+
+// Metrics returns a snapshot of the counters of this cache.
+func (c *StatCacheFS) Metrics() StatCacheMetrics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.metrics
+}
+
+// Invalidate clears the cache.
+func (c *StatCacheFS) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidate()
+}
+
+// invalidate clears the cache while holding mu.
+func (c *StatCacheFS) invalidate() {
+	if len(c.entries) > 0 {
+		c.entries = map[statCacheKey]*list.Element{}
+		c.lru.Init()
+	}
+	c.metrics.Invalidations++
+}
+
+// stat returns the cached result of `key`, or calls `fn` and caches its
+// result.
+func (c *StatCacheFS) stat(key statCacheKey, fn func(string) (sys.Stat_t, experimentalsys.Errno)) (sys.Stat_t, experimentalsys.Errno) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		entry := e.Value.(*statCacheEntry)
+		if entry.expires.IsZero() || c.now().Before(entry.expires) {
+			c.lru.MoveToFront(e)
+			c.metrics.Hits++
+			c.mu.Unlock()
+			return entry.st, entry.errno
+		}
+		c.remove(e)
+	}
+	c.metrics.Misses++
+	invalidations := c.metrics.Invalidations
+	c.mu.Unlock()
+
+	st, errno := fn(key.path)
+
+	// Only cache definite results, and not if a write happened meanwhile.
+	if errno != 0 && errno != experimentalsys.ENOENT {
+		return st, errno
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.metrics.Invalidations != invalidations {
+		return st, errno
+	} else if e, ok := c.entries[key]; ok {
+		c.remove(e) // raced with another miss for the same key.
+	}
+
+	entry := &statCacheEntry{key: key, st: st, errno: errno}
+	if c.ttl > 0 {
+		entry.expires = c.now().Add(c.ttl)
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+		c.metrics.Evictions++
+	}
+	return st, errno
+}
+
+// remove deletes an element of lru while holding mu.
+func (c *StatCacheFS) remove(e *list.Element) {
+	delete(c.entries, e.Value.(*statCacheEntry).key)
+	c.lru.Remove(e)
+}
+
+// written invalidates the cache after an operation which may have changed
+// any result, returning `errno` for convenience.
+func (c *StatCacheFS) written(errno experimentalsys.Errno) experimentalsys.Errno {
+	c.Invalidate()
+	return errno
+}
+
+// Lstat implements the same method as documented on sys.FS
+func (c *StatCacheFS) Lstat(path string) (sys.Stat_t, experimentalsys.Errno) {
+	return c.stat(statCacheKey{path: path}, c.FS.Lstat)
+}
+
+// Stat implements the same method as documented on sys.FS
+func (c *StatCacheFS) Stat(path string) (sys.Stat_t, experimentalsys.Errno) {
+	return c.stat(statCacheKey{path: path, follow: true}, c.FS.Stat)
+}
+
+// OpenFile implements the same method as documented on sys.FS
+//
+// Note: The cache is cleared when the file may be created or truncated, and
+// on any change to the file after.
+func (c *StatCacheFS) OpenFile(path string, flag experimentalsys.Oflag, perm fs.FileMode) (experimentalsys.File, experimentalsys.Errno) {
+	f, errno := c.FS.OpenFile(path, flag, perm)
+	if flag&(experimentalsys.O_CREAT|experimentalsys.O_TRUNC) != 0 {
+		c.Invalidate()
+	}
+	if errno != 0 {
+		return nil, errno
+	}
+	return &statCacheFile{File: f, fs: c}, 0
+}
+
+// Mkdir implements the same method as documented on sys.FS
+func (c *StatCacheFS) Mkdir(path string, perm fs.FileMode) experimentalsys.Errno {
+	return c.written(c.FS.Mkdir(path, perm))
+}
+
+// Chmod implements the same method as documented on sys.FS
+func (c *StatCacheFS) Chmod(path string, perm fs.FileMode) experimentalsys.Errno {
+	return c.written(c.FS.Chmod(path, perm))
+}
+
+// Rename implements the same method as documented on sys.FS
+func (c *StatCacheFS) Rename(from, to string) experimentalsys.Errno {
+	return c.written(c.FS.Rename(from, to))
+}
+
+// Rmdir implements the same method as documented on sys.FS
+func (c *StatCacheFS) Rmdir(path string) experimentalsys.Errno {
+	return c.written(c.FS.Rmdir(path))
+}
+
+// Unlink implements the same method as documented on sys.FS
+func (c *StatCacheFS) Unlink(path string) experimentalsys.Errno {
+	return c.written(c.FS.Unlink(path))
+}
+
+// Link implements the same method as documented on sys.FS
+func (c *StatCacheFS) Link(oldPath, newPath string) experimentalsys.Errno {
+	return c.written(c.FS.Link(oldPath, newPath))
+}
+
+// Symlink implements the same method as documented on sys.FS
+func (c *StatCacheFS) Symlink(oldPath, linkName string) experimentalsys.Errno {
+	return c.written(c.FS.Symlink(oldPath, linkName))
+}
+
+// Utimens implements the same method as documented on sys.FS
+func (c *StatCacheFS) Utimens(path string, atim, mtim int64) experimentalsys.Errno {
+	return c.written(c.FS.Utimens(path, atim, mtim))
+}
+
+// Lutimens implements the same method as documented on lutimensFS
+func (c *StatCacheFS) Lutimens(path string, atim, mtim int64) experimentalsys.Errno {
+	return c.written(Lutimens(c.FS, path, atim, mtim))
+}
+
+// compile-time check to ensure statCacheFile implements sys.File.
+var _ experimentalsys.File = (*statCacheFile)(nil)
+
+// statCacheFile invalidates the cache of its filesystem when changed, as
+// that changes the size or times of the file.
+type statCacheFile struct {
+	experimentalsys.File
+
+	fs *StatCacheFS
+}
+
+// Write implements the same method as documented on sys.File.
+func (f *statCacheFile) Write(buf []byte) (int, experimentalsys.Errno) {
+	n, errno := f.File.Write(buf)
+	f.fs.Invalidate()
+	return n, errno
+}
+
+// Pwrite implements the same method as documented on sys.File.
+func (f *statCacheFile) Pwrite(buf []byte, off int64) (int, experimentalsys.Errno) {
+	n, errno := f.File.Pwrite(buf, off)
+	f.fs.Invalidate()
+	return n, errno
+}
+
+// Truncate implements the same method as documented on sys.File.
+func (f *statCacheFile) Truncate(size int64) experimentalsys.Errno {
+	return f.fs.written(f.File.Truncate(size))
+}
+
+// Utimens implements the same method as documented on sys.File.
+func (f *statCacheFile) Utimens(atim, mtim int64) experimentalsys.Errno {
+	return f.fs.written(f.File.Utimens(atim, mtim))
+}
@@ -0,0 +1,101 @@
+package sysfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestStatCacheFS(t *testing.T) {
+	mem := MemFS()
+	writeMemFile(t, mem, "file", "data")
+
+	testFS := NewStatCacheFS(mem, StatCache{})
+
+	for i := 0; i < 3; i++ {
+		st, errno := testFS.Stat("file")
+		require.EqualErrno(t, 0, errno)
+		require.Equal(t, int64(4), st.Size)
+
+		_, errno = testFS.Stat("missing")
+		require.EqualErrno(t, sys.ENOENT, errno)
+	}
+	require.Equal(t, StatCacheMetrics{Hits: 4, Misses: 2}, testFS.Metrics())
+
+	// Stat and Lstat are cached separately.
+	_, errno := testFS.Lstat("file")
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, uint64(3), testFS.Metrics().Misses)
+}
+
+func TestStatCacheFS_invalidation(t *testing.T) {
+	mem := MemFS()
+	testFS := NewStatCacheFS(mem, StatCache{})
+
+	_, errno := testFS.Stat("file")
+	require.EqualErrno(t, sys.ENOENT, errno)
+
+	// Creating a file clears the negative result.
+	f, errno := testFS.OpenFile("file", sys.O_RDWR|sys.O_CREAT, 0o600)
+	require.EqualErrno(t, 0, errno)
+	defer f.Close()
+
+	st, errno := testFS.Stat("file")
+	require.EqualErrno(t, 0, errno)
+	require.Zero(t, st.Size)
+
+	// Writing to an open file clears its size.
+	_, errno = f.Write([]byte("data"))
+	require.EqualErrno(t, 0, errno)
+	st, errno = testFS.Stat("file")
+	require.EqualErrno(t, 0, errno)
+	require.Equal(t, int64(4), st.Size)
+
+	require.EqualErrno(t, 0, testFS.Rename("file", "renamed"))
+	_, errno = testFS.Stat("file")
+	require.EqualErrno(t, sys.ENOENT, errno)
+	_, errno = testFS.Stat("renamed")
+	require.EqualErrno(t, 0, errno)
+
+	// Changes to the underlying filesystem require Invalidate.
+	require.EqualErrno(t, 0, mem.Unlink("renamed"))
+	_, errno = testFS.Stat("renamed")
+	require.EqualErrno(t, 0, errno)
+	testFS.Invalidate()
+	_, errno = testFS.Stat("renamed")
+	require.EqualErrno(t, sys.ENOENT, errno)
+}
+
+func TestStatCacheFS_MaxEntries(t *testing.T) {
+	testFS := NewStatCacheFS(MemFS(), StatCache{MaxEntries: 2})
+
+	for _, path := range []string{"a", "b", "a", "c", "a", "b"} {
+		_, errno := testFS.Stat(path)
+		require.EqualErrno(t, sys.ENOENT, errno)
+	}
+
+	// "b" was least recently used when "c" was added.
+	require.Equal(t, StatCacheMetrics{Hits: 2, Misses: 4, Evictions: 2}, testFS.Metrics())
+}
+
+func TestStatCacheFS_TTL(t *testing.T) {
+	testFS := NewStatCacheFS(MemFS(), StatCache{TTL: time.Second})
+
+	now := time.Unix(0, 0)
+	testFS.now = func() time.Time { return now }
+
+	_, errno := testFS.Stat("file")
+	require.EqualErrno(t, sys.ENOENT, errno)
+
+	now = now.Add(time.Second - 1)
+	_, errno = testFS.Stat("file")
+	require.EqualErrno(t, sys.ENOENT, errno)
+	require.Equal(t, uint64(1), testFS.Metrics().Hits)
+
+	now = now.Add(1)
+	_, errno = testFS.Stat("file")
+	require.EqualErrno(t, sys.ENOENT, errno)
+	require.Equal(t, uint64(2), testFS.Metrics().Misses)
+}
@@ -5,7 +5,9 @@ import (
 )
 
 func encodeConstantExpression(expr wasm.ConstantExpression) (ret []byte) {
-	ret = append(ret, expr.Opcode)
+	if !wasm.IsExtendedConstOpcode(expr.Opcode) { // Otherwise, Data includes the opcode.
+		ret = append(ret, expr.Opcode)
+	}
 	ret = append(ret, expr.Data...)
 	ret = append(ret, wasm.OpcodeEnd)
 	return
@@ -13,3 +13,29 @@ func EncodeLimitsType(min uint32, max *uint32) []byte {
 	}
 	return append(leb128.EncodeUint32(0x01), append(leb128.EncodeUint32(min), leb128.EncodeUint32(*max)...)...)
 }
+
+// encodeMemoryLimitsType returns the `limitsType` of a memory, which is 64-bit
+// in the memory64 proposal, or shared in the threads proposal.
+//
+// See https://github.com/WebAssembly/memory64/blob/main/proposals/memory64/Overview.md#binary-format
+// See https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md#spec-changes
+func encodeMemoryLimitsType(min uint32, max *uint32, is64, isShared bool) []byte {
+	encode := leb128.EncodeUint32
+	var flag byte
+	if max != nil {
+		flag |= 0x01
+	}
+	if isShared {
+		flag |= 0x02
+	}
+	if is64 {
+		flag |= 0x04
+		encode = func(v uint32) []byte { return leb128.EncodeUint64(uint64(v)) }
+	}
+
+	ret := append([]byte{flag}, encode(min)...)
+	if max != nil {
+		ret = append(ret, encode(*max)...)
+	}
+	return ret
+}
@@ -12,5 +12,8 @@ func EncodeMemory(i *wasm.Memory) []byte {
 	if !i.IsMaxEncoded {
 		maxPtr = nil
 	}
+	if i.Is64 || i.IsShared {
+		return encodeMemoryLimitsType(i.Min, maxPtr, i.Is64, i.IsShared)
+	}
 	return EncodeLimitsType(i.Min, maxPtr)
 }
@@ -13,6 +13,15 @@ const (
 	// subsectionIDLocalNames contain a map of function indices to a map of local indices to their names, in ascending
 	// order by function and local index
 	subsectionIDLocalNames = uint8(2)
+	// The below are from the extended-name-section proposal, except tags from the exception-handling proposal.
+	subsectionIDLabelNames   = uint8(3)
+	subsectionIDTypeNames    = uint8(4)
+	subsectionIDTableNames   = uint8(5)
+	subsectionIDMemoryNames  = uint8(6)
+	subsectionIDGlobalNames  = uint8(7)
+	subsectionIDElementNames = uint8(8)
+	subsectionIDDataNames    = uint8(9)
+	subsectionIDTagNames     = uint8(11)
 )
 
 // EncodeNameSectionData serializes the data for the "name" key in wasm.SectionIDCustom according to the
@@ -28,9 +37,28 @@ func EncodeNameSectionData(n *wasm.NameSection) (data []byte) {
 	if fd := encodeFunctionNameData(n); len(fd) > 0 {
 		data = append(data, encodeNameSubsection(subsectionIDFunctionNames, fd)...)
 	}
-	if ld := encodeLocalNameData(n); len(ld) > 0 {
+	if ld := encodeIndirectNameMap(n.LocalNames); len(ld) > 0 {
 		data = append(data, encodeNameSubsection(subsectionIDLocalNames, ld)...)
 	}
+	if ld := encodeIndirectNameMap(n.LabelNames); len(ld) > 0 {
+		data = append(data, encodeNameSubsection(subsectionIDLabelNames, ld)...)
+	}
+	for _, s := range []struct {
+		id    uint8
+		names wasm.NameMap
+	}{
+		{subsectionIDTypeNames, n.TypeNames},
+		{subsectionIDTableNames, n.TableNames},
+		{subsectionIDMemoryNames, n.MemoryNames},
+		{subsectionIDGlobalNames, n.GlobalNames},
+		{subsectionIDElementNames, n.ElementNames},
+		{subsectionIDDataNames, n.DataNames},
+		{subsectionIDTagNames, n.TagNames},
+	} {
+		if len(s.names) > 0 {
+			data = append(data, encodeNameSubsection(s.id, encodeNameMap(s.names))...)
+		}
+	}
 	return
 }
 
@@ -53,17 +81,17 @@ func encodeNameMap(m wasm.NameMap) []byte {
 	return data
 }
 
-// encodeLocalNameData encodes the data for the local name subsection.
+// encodeIndirectNameMap encodes the data for the local or label name subsection.
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#binary-localnamesec
-func encodeLocalNameData(n *wasm.NameSection) []byte {
-	if len(n.LocalNames) == 0 {
+func encodeIndirectNameMap(m wasm.IndirectNameMap) []byte {
+	if len(m) == 0 {
 		return nil
 	}
 
-	funcNameCount := uint32(len(n.LocalNames))
+	funcNameCount := uint32(len(m))
 	subsection := leb128.EncodeUint32(funcNameCount)
 
-	for _, na := range n.LocalNames {
+	for _, na := range m {
 		locals := encodeNameMap(na.NameMap)
 		subsection = append(subsection, append(leb128.EncodeUint32(na.Index), locals...)...)
 	}
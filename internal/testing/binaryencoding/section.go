@@ -89,6 +89,22 @@ func encodeMemorySection(memory *wasm.Memory) []byte {
 	return encodeSection(wasm.SectionIDMemory, contents)
 }
 
+// encodeTagSection encodes a wasm.SectionIDTag for the given tag types, as defined by the exception handling proposal.
+//
+// See https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/Exceptions.md#tag-section
+func encodeTagSection(tags []wasm.Index) []byte {
+	contents := leb128.EncodeUint32(uint32(len(tags)))
+	for _, typeIndex := range tags {
+		contents = append(contents, encodeTag(typeIndex)...)
+	}
+	return encodeSection(wasm.SectionIDTag, contents)
+}
+
+// encodeTag returns a tag of the type at `typeIndex`, which has the exception attribute.
+func encodeTag(typeIndex wasm.Index) []byte {
+	return append([]byte{0x00}, leb128.EncodeUint32(typeIndex)...)
+}
+
 // encodeGlobalSection encodes a wasm.SectionIDGlobal for the given globals in WebAssembly 1.0 (20191205) Binary
 // Format.
 //
@@ -45,14 +45,14 @@ func TestEqualErrno(t *testing.T) {
 			require: func(t TestingT) {
 				EqualErrno(t, sys.ENOENT, sys.EIO)
 			},
-			expectedLog: `expected Errno 0xc(no such file or directory), but was 0x8(input/output error)`,
+			expectedLog: `expected Errno 0xd(no such file or directory), but was 0x9(input/output error)`,
 		},
 		{
 			name: "EqualErrno fails on not equal with format",
 			require: func(t TestingT) {
 				EqualErrno(t, sys.ENOENT, sys.EIO, "pay me %d", 5)
 			},
-			expectedLog: `expected Errno 0xc(no such file or directory), but was 0x8(input/output error): pay me 5`,
+			expectedLog: `expected Errno 0xd(no such file or directory), but was 0x9(input/output error): pay me 5`,
 		},
 	}
 
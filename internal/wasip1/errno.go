@@ -274,6 +274,8 @@ func ToErrno(errno sys.Errno) Errno {
 		return ErrnoAgain
 	case sys.EBADF:
 		return ErrnoBadf
+	case sys.EDQUOT:
+		return ErrnoDquot
 	case sys.EEXIST:
 		return ErrnoExist
 	case sys.EFAULT:
@@ -292,6 +294,8 @@ func ToErrno(errno sys.Errno) Errno {
 		return ErrnoNametoolong
 	case sys.ENOENT:
 		return ErrnoNoent
+	case sys.ENOSPC:
+		return ErrnoNospc
 	case sys.ENOSYS:
 		return ErrnoNosys
 	case sys.ENOTDIR:
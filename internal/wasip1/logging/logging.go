@@ -158,7 +158,7 @@ func Config(fnd api.FunctionDefinition) (pSampler logging.ParamSampler, pLoggers
 				logger = logSiFlags(idx).Log
 			case "how":
 				logger = logSdFlags(idx).Log
-			case "result.fd", "result.ro_datalen", "result.so_datalen":
+			case "result.fd", "result.ro_datalen", "result.so_datalen", "result.port":
 				name = resultParamName(name)
 				logger = logMemI32(idx).Log
 				rLoggers = append(rLoggers, resultParamLogger(name, logger))
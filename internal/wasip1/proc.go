@@ -4,3 +4,38 @@ const (
 	ProcExitName  = "proc_exit"
 	ProcRaiseName = "proc_raise"
 )
+
+// https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-signal-enumu8
+const (
+	SignalNone = iota
+	SignalHup
+	SignalInt
+	SignalQuit
+	SignalIll
+	SignalTrap
+	SignalAbrt
+	SignalBus
+	SignalFpe
+	SignalKill
+	SignalUsr1
+	SignalSegv
+	SignalUsr2
+	SignalPipe
+	SignalAlrm
+	SignalTerm
+	SignalChld
+	SignalCont
+	SignalStop
+	SignalTstp
+	SignalTtin
+	SignalTtou
+	SignalUrg
+	SignalXcpu
+	SignalXfsz
+	SignalVtalrm
+	SignalProf
+	SignalWinch
+	SignalPoll
+	SignalPwr
+	SignalSys
+)
@@ -9,6 +9,32 @@ const (
 	SockShutdownName = "sock_shutdown"
 )
 
+// The below functions are WasmEdge extensions, which allow the guest to open
+// its own sockets.
+// See https://github.com/second-state/wasmedge_wasi_socket
+const (
+	SockOpenName     = "sock_open"
+	SockBindName     = "sock_bind"
+	SockListenName   = "sock_listen"
+	SockConnectName  = "sock_connect"
+	SockSendToName   = "sock_send_to"
+	SockRecvFromName = "sock_recv_from"
+)
+
+// Address families of sock_open.
+const (
+	ADDRESS_FAMILY_UNSPEC uint8 = iota //nolint
+	ADDRESS_FAMILY_INET4
+	ADDRESS_FAMILY_INET6
+)
+
+// Socket types of sock_open.
+const (
+	SOCK_TYPE_ANY uint8 = iota //nolint
+	SOCK_TYPE_DGRAM
+	SOCK_TYPE_STREAM
+)
+
 // SD Flags indicate which channels on a socket to shut down.
 // https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-sdflags-flagsu8
 const (
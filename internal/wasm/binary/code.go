@@ -6,11 +6,12 @@ import (
 	"io"
 	"math"
 
+	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
 
-func decodeCode(r *bytes.Reader, codeSectionStart uint64, ret *wasm.Code) (err error) {
+func decodeCode(r *bytes.Reader, codeSectionStart uint64, enabledFeatures api.CoreFeatures, typeCount int, ret *wasm.Code) (err error) {
 	ss, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return fmt.Errorf("get the size of code: %w", err)
@@ -27,10 +28,10 @@ func decodeCode(r *bytes.Reader, codeSectionStart uint64, ret *wasm.Code) (err e
 	}
 
 	// Validate the locals.
-	bytesRead = 0
+	localsStart := r.Len()
 	var sum uint64
 	for i := uint32(0); i < ls; i++ {
-		num, n, err := leb128.DecodeUint32(r)
+		num, _, err := leb128.DecodeUint32(r)
 		if err != nil {
 			return fmt.Errorf("read n of locals: %v", err)
 		} else if remaining < 0 {
@@ -39,16 +40,18 @@ func decodeCode(r *bytes.Reader, codeSectionStart uint64, ret *wasm.Code) (err e
 
 		sum += uint64(num)
 
-		b, err := r.ReadByte()
+		vt, err := decodeLocalType(r, enabledFeatures, typeCount)
 		if err != nil {
-			return fmt.Errorf("read type of local: %v", err)
+			return err
 		}
 
-		bytesRead += n + 1
-		switch vt := b; vt {
+		switch vt {
 		case wasm.ValueTypeI32, wasm.ValueTypeF32, wasm.ValueTypeI64, wasm.ValueTypeF64,
-			wasm.ValueTypeFuncref, wasm.ValueTypeExternref, wasm.ValueTypeV128:
+			wasm.ValueTypeFuncref, wasm.ValueTypeExternref, wasm.ValueTypeV128, wasm.ValueTypeExnref:
 		default:
+			if name, ok := wasm.GCValueTypeName(vt); ok {
+				return fmt.Errorf("local type %s invalid as %w", name, wasm.ErrGCUnsupported)
+			}
 			return fmt.Errorf("invalid local type: 0x%x", vt)
 		}
 	}
@@ -58,28 +61,29 @@ func decodeCode(r *bytes.Reader, codeSectionStart uint64, ret *wasm.Code) (err e
 	}
 
 	// Rewind the buffer.
-	_, err = r.Seek(-int64(bytesRead), io.SeekCurrent)
+	_, err = r.Seek(-int64(localsStart-r.Len()), io.SeekCurrent)
 	if err != nil {
 		return err
 	}
 
 	localTypes := make([]wasm.ValueType, 0, sum)
 	for i := uint32(0); i < ls; i++ {
-		num, bytesRead, err := leb128.DecodeUint32(r)
-		remaining -= int64(bytesRead) + 1 // +1 for the subsequent ReadByte
+		before := r.Len()
+		num, _, err := leb128.DecodeUint32(r)
 		if err != nil {
 			return fmt.Errorf("read n of locals: %v", err)
-		} else if remaining < 0 {
-			return io.EOF
 		}
 
-		b, err := r.ReadByte()
+		vt, err := decodeLocalType(r, enabledFeatures, typeCount)
 		if err != nil {
-			return fmt.Errorf("read type of local: %v", err)
+			return err
+		}
+		if remaining -= int64(before - r.Len()); remaining < 0 {
+			return io.EOF
 		}
 
 		for j := uint32(0); j < num; j++ {
-			localTypes = append(localTypes, b)
+			localTypes = append(localTypes, vt)
 		}
 	}
 
@@ -98,3 +102,18 @@ func decodeCode(r *bytes.Reader, codeSectionStart uint64, ret *wasm.Code) (err e
 	ret.Body = body
 	return nil
 }
+
+// decodeLocalType decodes the type of a local, which is a typed reference if
+// its leading byte is wasm.RefTypeNullablePrefix or
+// wasm.RefTypeNonNullablePrefix.
+func decodeLocalType(r *bytes.Reader, enabledFeatures api.CoreFeatures, typeCount int) (wasm.ValueType, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, fmt.Errorf("read type of local: %v", err)
+	}
+	vt, _, err := wasm.DecodeRefType(r, b, enabledFeatures, typeCount)
+	if err != nil {
+		return 0, fmt.Errorf("read type of local: %v", err)
+	}
+	return vt, nil
+}
@@ -6,12 +6,14 @@ import (
 	"io"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/ieee754"
 	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
 
 func decodeConstantExpression(r *bytes.Reader, enabledFeatures api.CoreFeatures, ret *wasm.ConstantExpression) error {
+	offsetAtOpcode := r.Size() - int64(r.Len())
 	b, err := r.ReadByte()
 	if err != nil {
 		return fmt.Errorf("read opcode: %v", err)
@@ -93,7 +95,10 @@ func decodeConstantExpression(r *bytes.Reader, enabledFeatures api.CoreFeatures,
 	}
 
 	if b != wasm.OpcodeEnd {
-		return fmt.Errorf("constant expression has been not terminated")
+		if !enabledFeatures.IsEnabled(experimental.CoreFeaturesExtendedConst) {
+			return fmt.Errorf("constant expression has been not terminated")
+		}
+		return decodeExtendedConstantExpression(r, b, offsetAtOpcode, ret)
 	}
 
 	ret.Data = make([]byte, remainingBeforeData-int64(r.Len())-1)
@@ -103,3 +108,39 @@ func decodeConstantExpression(r *bytes.Reader, enabledFeatures api.CoreFeatures,
 	ret.Opcode = opcode
 	return nil
 }
+
+// decodeExtendedConstantExpression decodes the rest of a constant expression
+// of more than one instruction, which starts at `offsetAtOpcode`, after the
+// first one, from its second opcode `b`. Its Data are all its instructions,
+// which are validated when the module is.
+func decodeExtendedConstantExpression(r *bytes.Reader, b byte, offsetAtOpcode int64, ret *wasm.ConstantExpression) (err error) {
+	for ; b != wasm.OpcodeEnd; b, err = r.ReadByte() {
+		if err != nil {
+			return fmt.Errorf("look for end opcode: %v", err)
+		}
+		switch b {
+		case wasm.OpcodeI32Const:
+			_, _, err = leb128.DecodeInt32(r)
+		case wasm.OpcodeI64Const:
+			_, _, err = leb128.DecodeInt64(r)
+		case wasm.OpcodeGlobalGet:
+			_, _, err = leb128.DecodeUint32(r)
+		case wasm.OpcodeI32Add, wasm.OpcodeI32Sub, wasm.OpcodeI32Mul, wasm.OpcodeI64Add, wasm.OpcodeI64Sub, wasm.OpcodeI64Mul:
+		default:
+			return fmt.Errorf("%v for const expression opt code: %#x", ErrInvalidByte, b)
+		}
+		if err != nil {
+			return fmt.Errorf("read value: %v", err)
+		}
+		ret.Opcode = b
+	}
+
+	if !wasm.IsExtendedConstOpcode(ret.Opcode) {
+		return fmt.Errorf("constant expression must end with an arithmetic instruction, but ends with %s", wasm.InstructionName(ret.Opcode))
+	}
+	ret.Data = make([]byte, r.Size()-int64(r.Len())-1-offsetAtOpcode)
+	if _, err = r.ReadAt(ret.Data, offsetAtOpcode); err != nil {
+		return fmt.Errorf("error re-buffering ConstantExpression.Data")
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/wasm"
 	"github.com/tetratelabs/wazero/internal/wasmdebug"
@@ -31,7 +32,14 @@ func DecodeModule(
 	}
 
 	// Version.
-	if _, err := io.ReadFull(r, buf); err != nil || !bytes.Equal(buf, version) {
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, ErrInvalidVersion
+	} else if !bytes.Equal(buf, version) {
+		// A component has a different version, followed by the layer 1.
+		// See https://github.com/WebAssembly/component-model/blob/main/design/mvp/Binary.md
+		if buf[2] == 1 && buf[3] == 0 {
+			return nil, ErrComponent
+		}
 		return nil, ErrInvalidVersion
 	}
 
@@ -51,10 +59,13 @@ func DecodeModule(
 
 		sectionSize, _, err := leb128.DecodeUint32(r)
 		if err != nil {
-			return nil, fmt.Errorf("get size of section %s: %v", wasm.SectionIDName(sectionID), err)
+			return nil, sectionError(sectionID, binary, r, fmt.Errorf("get size of section %s: %v", wasm.SectionIDName(sectionID), err))
 		}
 
 		sectionContentStart := r.Len()
+		if sectionID != wasm.SectionIDCustom && int(sectionID) < len(m.SectionOffsets) {
+			m.SectionOffsets[sectionID] = uint64(len(binary) - sectionContentStart)
+		}
 		switch sectionID {
 		case wasm.SectionIDCustom:
 			// First, validate the section and determine if the section for this name has already been set
@@ -100,42 +111,55 @@ func DecodeModule(
 						return nil, fmt.Errorf("failed to skip name[%s]: %w", name, err)
 					}
 				}
+			} else if storeCustomSections {
+				// Retain the raw section as well, e.g. for tools reading it.
+				var c *wasm.CustomSection
+				if c, err = decodeCustomSection(r, name, uint64(limit)); err != nil {
+					return nil, fmt.Errorf("failed to read custom section name[%s]: %w", name, err)
+				}
+				m.NameSectionData = c.Data
+				m.NameSection, err = decodeNameSection(bytes.NewReader(c.Data), uint64(limit))
 			} else {
 				m.NameSection, err = decodeNameSection(r, uint64(limit))
 			}
 		case wasm.SectionIDType:
 			m.TypeSection, err = decodeTypeSection(enabledFeatures, r)
 		case wasm.SectionIDImport:
-			m.ImportSection, m.ImportPerModule, m.ImportFunctionCount, m.ImportGlobalCount, m.ImportMemoryCount, m.ImportTableCount, err = decodeImportSection(r, memSizer, memoryLimitPages, enabledFeatures)
+			m.ImportSection, m.ImportPerModule, m.ImportFunctionCount, m.ImportGlobalCount, m.ImportMemoryCount, m.ImportTableCount, m.ImportTagCount, err = decodeImportSection(r, memSizer, memoryLimitPages, enabledFeatures, len(m.TypeSection))
 			if err != nil {
-				return nil, err // avoid re-wrapping the error.
+				return nil, sectionError(sectionID, binary, r, err) // avoid re-wrapping the error.
 			}
 		case wasm.SectionIDFunction:
 			m.FunctionSection, err = decodeFunctionSection(r)
 		case wasm.SectionIDTable:
 			m.TableSection, err = decodeTableSection(r, enabledFeatures)
 		case wasm.SectionIDMemory:
-			m.MemorySection, err = decodeMemorySection(r, memSizer, memoryLimitPages)
+			m.MemorySection, err = decodeMemorySection(r, enabledFeatures, memSizer, memoryLimitPages)
+		case wasm.SectionIDTag:
+			if !enabledFeatures.IsEnabled(experimental.CoreFeaturesExceptionHandling) {
+				return nil, sectionError(sectionID, binary, r, errors.New(`tag section invalid as feature "exception-handling" is disabled`))
+			}
+			m.TagSection, err = decodeTagSection(r)
 		case wasm.SectionIDGlobal:
-			if m.GlobalSection, err = decodeGlobalSection(r, enabledFeatures); err != nil {
-				return nil, err // avoid re-wrapping the error.
+			if m.GlobalSection, err = decodeGlobalSection(r, enabledFeatures, len(m.TypeSection)); err != nil {
+				return nil, sectionError(sectionID, binary, r, err) // avoid re-wrapping the error.
 			}
 		case wasm.SectionIDExport:
 			m.ExportSection, m.Exports, err = decodeExportSection(r)
 		case wasm.SectionIDStart:
 			if m.StartSection != nil {
-				return nil, errors.New("multiple start sections are invalid")
+				return nil, sectionError(sectionID, binary, r, errors.New("multiple start sections are invalid"))
 			}
 			m.StartSection, err = decodeStartSection(r)
 		case wasm.SectionIDElement:
 			m.ElementSection, err = decodeElementSection(r, enabledFeatures)
 		case wasm.SectionIDCode:
-			m.CodeSection, err = decodeCodeSection(r)
+			m.CodeSection, err = decodeCodeSection(r, enabledFeatures, len(m.TypeSection))
 		case wasm.SectionIDData:
 			m.DataSection, err = decodeDataSection(r, enabledFeatures)
 		case wasm.SectionIDDataCount:
 			if err := enabledFeatures.RequireEnabled(api.CoreFeatureBulkMemoryOperations); err != nil {
-				return nil, fmt.Errorf("data count section not supported as %v", err)
+				return nil, sectionError(sectionID, binary, r, fmt.Errorf("data count section not supported as %v", err))
 			}
 			m.DataCountSection, err = decodeDataCountSection(r)
 		default:
@@ -148,7 +172,7 @@ func DecodeModule(
 		}
 
 		if err != nil {
-			return nil, fmt.Errorf("section %s: %v", wasm.SectionIDName(sectionID), err)
+			return nil, sectionError(sectionID, binary, r, fmt.Errorf("section %s: %v", wasm.SectionIDName(sectionID), err))
 		}
 	}
 
@@ -159,11 +183,21 @@ func DecodeModule(
 
 	functionCount, codeCount := m.SectionElementCount(wasm.SectionIDFunction), m.SectionElementCount(wasm.SectionIDCode)
 	if functionCount != codeCount {
-		return nil, fmt.Errorf("function and code section have inconsistent lengths: %d != %d", functionCount, codeCount)
+		return nil, &wasm.SectionError{
+			ID:     wasm.SectionIDCode,
+			Offset: m.SectionOffsets[wasm.SectionIDCode],
+			Err:    fmt.Errorf("function and code section have inconsistent lengths: %d != %d", functionCount, codeCount),
+		}
 	}
 	return m, nil
 }
 
+// sectionError attributes err to the section being read by r, at the offset
+// where reading stopped.
+func sectionError(sectionID wasm.SectionID, binary []byte, r *bytes.Reader, err error) error {
+	return &wasm.SectionError{ID: sectionID, Offset: uint64(len(binary) - r.Len()), Err: err}
+}
+
 // memorySizer derives min, capacity and max pages from decoded wasm.
 type memorySizer func(minPages uint32, maxPages *uint32) (min uint32, capacity uint32, max uint32)
 
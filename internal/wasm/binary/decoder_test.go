@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/testing/binaryencoding"
 	"github.com/tetratelabs/wazero/internal/testing/dwarftestdata"
 	"github.com/tetratelabs/wazero/internal/testing/require"
@@ -136,6 +137,8 @@ func TestDecodeModule(t *testing.T) {
 				}
 				tc.input.ImportPerModule = expImportPerModule
 			}
+			// Section offsets depend on the encoding, so are tested separately.
+			tc.input.SectionOffsets = m.SectionOffsets
 			require.Equal(t, tc.input, m)
 		})
 	}
@@ -195,7 +198,8 @@ func TestDecodeModule(t *testing.T) {
 		m, e := DecodeModule(input, api.CoreFeaturesV2, wasm.MemoryLimitPages, false, false, true)
 		require.NoError(t, e)
 		require.Equal(t, &wasm.Module{
-			NameSection: &wasm.NameSection{ModuleName: "simple"},
+			NameSection:     &wasm.NameSection{ModuleName: "simple"},
+			NameSectionData: []byte{subsectionIDModuleName, 0x07, 0x06, 's', 'i', 'm', 'p', 'l', 'e'},
 			CustomSections: []*wasm.CustomSection{
 				{
 					Name: "meme",
@@ -223,6 +227,50 @@ func TestDecodeModule(t *testing.T) {
 		_, e := DecodeModule(input, api.CoreFeaturesV1, wasm.MemoryLimitPages, false, false, false)
 		require.EqualError(t, e, `data count section not supported as feature "bulk-memory-operations" is disabled`)
 	})
+
+	t.Run("section offsets", func(t *testing.T) {
+		input := append(append(Magic, version...),
+			wasm.SectionIDCustom, 2, 1, 'a', // ignored
+			wasm.SectionIDType, 4, 1, 0x60, 0, 0,
+			wasm.SectionIDFunction, 2, 1, 0,
+			wasm.SectionIDCode, 4, 1, 2, 0, wasm.OpcodeEnd)
+		m, e := DecodeModule(input, api.CoreFeaturesV1, wasm.MemoryLimitPages, false, false, false)
+		require.NoError(t, e)
+
+		var expected [wasm.SectionIDTag + 1]uint64
+		expected[wasm.SectionIDType] = 14
+		expected[wasm.SectionIDFunction] = 20
+		expected[wasm.SectionIDCode] = 24
+		require.Equal(t, expected, m.SectionOffsets)
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		input := &wasm.Module{
+			TypeSection:   []wasm.FunctionType{{}, {Params: []wasm.ValueType{wasm.ValueTypeI32}}},
+			ImportSection: []wasm.Import{{Module: "env", Name: "error", Type: wasm.ExternTypeTag, DescTag: 1}},
+			TagSection:    []wasm.Index{0, 1},
+			ExportSection: []wasm.Export{{Name: "exit", Type: wasm.ExternTypeTag, Index: 1}},
+		}
+		features := api.CoreFeaturesV2 | experimental.CoreFeaturesExceptionHandling
+		m, e := DecodeModule(binaryencoding.EncodeModule(input), features, wasm.MemoryLimitPages, false, false, false)
+		require.NoError(t, e)
+		require.Equal(t, input.ImportSection, m.ImportSection)
+		require.Equal(t, wasm.Index(1), m.ImportTagCount)
+		require.Equal(t, input.TagSection, m.TagSection)
+		require.Equal(t, input.ExportSection, m.ExportSection)
+
+		_, e = DecodeModule(binaryencoding.EncodeModule(input), api.CoreFeaturesV2, wasm.MemoryLimitPages, false, false, false)
+		require.EqualError(t, e, `import[0] tag[env.error]: tag import invalid as feature "exception-handling" is disabled`)
+	})
+
+	t.Run("tag attribute", func(t *testing.T) {
+		input := append(append(Magic, version...),
+			wasm.SectionIDType, 4, 1, 0x60, 0, 0,
+			wasm.SectionIDTag, 3, 1, 1, 0)
+		features := api.CoreFeaturesV2 | experimental.CoreFeaturesExceptionHandling
+		_, e := DecodeModule(input, features, wasm.MemoryLimitPages, false, false, false)
+		require.EqualError(t, e, "section tag: tag[0]: invalid byte for tag attribute: 0x1 != 0x00")
+	})
 }
 
 func TestDecodeModule_Errors(t *testing.T) {
@@ -241,6 +289,11 @@ func TestDecodeModule_Errors(t *testing.T) {
 			input:       []byte("\x00asm\x01\x00\x00\x01"),
 			expectedErr: "invalid version header",
 		},
+		{
+			name:        "component",
+			input:       []byte("\x00asm\x0d\x00\x01\x00"),
+			expectedErr: "component model binaries are not supported: compile a core module instead, e.g. for wasm32-wasip1, or see experimental/component",
+		},
 		{
 			name: "multiple start sections",
 			input: append(append(Magic, version...),
@@ -264,6 +317,12 @@ func TestDecodeModule_Errors(t *testing.T) {
 				subsectionIDModuleName, 0x02, 0x01, 'x'),
 			expectedErr: "section custom: redundant custom section name",
 		},
+		{
+			name: "tag section when exception handling is disabled",
+			input: append(append(Magic, version...),
+				wasm.SectionIDTag, 3, 1, 0, 0),
+			expectedErr: `tag section invalid as feature "exception-handling" is disabled`,
+		},
 	}
 
 	for _, tt := range tests {
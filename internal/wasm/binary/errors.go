@@ -6,6 +6,7 @@ var (
 	ErrInvalidByte           = errors.New("invalid byte")
 	ErrInvalidMagicNumber    = errors.New("invalid magic number")
 	ErrInvalidVersion        = errors.New("invalid version header")
+	ErrComponent             = errors.New("component model binaries are not supported: compile a core module instead, e.g. for wasm32-wasip1, or see experimental/component")
 	ErrInvalidSectionID      = errors.New("invalid section id")
 	ErrCustomSectionNotFound = errors.New("custom section not found")
 )
@@ -9,13 +9,18 @@ import (
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
 
-func decodeFunctionType(enabledFeatures api.CoreFeatures, r *bytes.Reader, ret *wasm.FunctionType) (err error) {
+// decodeFunctionType decodes a function type, whose param and result types can
+// refer to `typeCount` types.
+func decodeFunctionType(enabledFeatures api.CoreFeatures, r *bytes.Reader, typeCount int, ret *wasm.FunctionType) (err error) {
 	b, err := r.ReadByte()
 	if err != nil {
 		return fmt.Errorf("read leading byte: %w", err)
 	}
 
 	if b != 0x60 {
+		if name, ok := gcCompositeTypeName(b); ok {
+			return fmt.Errorf("%s type invalid as %w", name, wasm.ErrGCUnsupported)
+		}
 		return fmt.Errorf("%w: %#x != 0x60", ErrInvalidByte, b)
 	}
 
@@ -24,7 +29,7 @@ func decodeFunctionType(enabledFeatures api.CoreFeatures, r *bytes.Reader, ret *
 		return fmt.Errorf("could not read parameter count: %w", err)
 	}
 
-	paramTypes, err := decodeValueTypes(r, paramCount)
+	paramTypes, err := decodeValueTypes(r, paramCount, enabledFeatures, typeCount)
 	if err != nil {
 		return fmt.Errorf("could not read parameter types: %w", err)
 	}
@@ -41,7 +46,7 @@ func decodeFunctionType(enabledFeatures api.CoreFeatures, r *bytes.Reader, ret *
 		}
 	}
 
-	resultTypes, err := decodeValueTypes(r, resultCount)
+	resultTypes, err := decodeValueTypes(r, resultCount, enabledFeatures, typeCount)
 	if err != nil {
 		return fmt.Errorf("could not read result types: %w", err)
 	}
@@ -54,3 +59,21 @@ func decodeFunctionType(enabledFeatures api.CoreFeatures, r *bytes.Reader, ret *
 
 	return nil
 }
+
+// gcCompositeTypeName returns the name of the type whose leading byte is `b`,
+// if it is defined by the GC proposal, or false otherwise.
+func gcCompositeTypeName(b byte) (string, bool) {
+	switch b {
+	case 0x4e:
+		return "rec", true
+	case 0x50:
+		return "sub", true
+	case 0x4f:
+		return "sub final", true
+	case 0x5f:
+		return "struct", true
+	case 0x5e:
+		return "array", true
+	}
+	return "", false
+}
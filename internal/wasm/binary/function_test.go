@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/testing/binaryencoding"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
@@ -85,7 +86,7 @@ func TestFunctionType(t *testing.T) {
 
 		t.Run(fmt.Sprintf("decode - %s", tc.name), func(t *testing.T) {
 			var actual wasm.FunctionType
-			err := decodeFunctionType(api.CoreFeaturesV2, bytes.NewReader(b), &actual)
+			err := decodeFunctionType(api.CoreFeaturesV2, bytes.NewReader(b), 0, &actual)
 			require.NoError(t, err)
 			// Set the FunctionType key on the input.
 			_ = tc.input.String()
@@ -104,18 +105,38 @@ func TestDecodeFunctionType_Errors(t *testing.T) {
 	}{
 		{
 			name:        "undefined param no result",
-			input:       []byte{0x60, 1, 0x6e, 0},
-			expectedErr: "could not read parameter types: invalid value type: 110",
+			input:       []byte{0x60, 1, 0x10, 0},
+			expectedErr: "could not read parameter types: invalid value type: 16",
 		},
 		{
 			name:        "no param undefined result",
-			input:       []byte{0x60, 0, 1, 0x6e},
-			expectedErr: "could not read result types: invalid value type: 110",
+			input:       []byte{0x60, 0, 1, 0x10},
+			expectedErr: "could not read result types: invalid value type: 16",
 		},
 		{
 			name:        "undefined param undefined result",
-			input:       []byte{0x60, 1, 0x6e, 1, 0x6e},
-			expectedErr: "could not read parameter types: invalid value type: 110",
+			input:       []byte{0x60, 1, 0x10, 1, 0x10},
+			expectedErr: "could not read parameter types: invalid value type: 16",
+		},
+		{
+			name:        "GC param",
+			input:       []byte{0x60, 1, 0x6e, 0},
+			expectedErr: "could not read parameter types: value type anyref invalid as the GC proposal isn't supported",
+		},
+		{
+			name:        "typed reference param",
+			input:       []byte{0x60, 1, wasm.RefTypeNonNullablePrefix, 0x70, 0},
+			expectedErr: "could not read parameter types: typed reference invalid as feature \"function-references\" is disabled",
+		},
+		{
+			name:        "GC struct type",
+			input:       []byte{0x5f, 1, i32, 1},
+			expectedErr: "struct type invalid as the GC proposal isn't supported",
+		},
+		{
+			name:        "GC rec group",
+			input:       []byte{0x4e, 1, 0x5e, i32, 1},
+			expectedErr: "rec type invalid as the GC proposal isn't supported",
 		},
 		{
 			name:        "no param two results - multi-value not enabled",
@@ -139,8 +160,33 @@ func TestDecodeFunctionType_Errors(t *testing.T) {
 
 		t.Run(tc.name, func(t *testing.T) {
 			var actual wasm.FunctionType
-			err := decodeFunctionType(api.CoreFeaturesV1, bytes.NewReader(tc.input), &actual)
+			err := decodeFunctionType(api.CoreFeaturesV1, bytes.NewReader(tc.input), 0, &actual)
 			require.EqualError(t, err, tc.expectedErr)
 		})
 	}
 }
+
+func TestDecodeFunctionType_FunctionReferences(t *testing.T) {
+	features := api.CoreFeaturesV2 | experimental.CoreFeaturesFunctionReferences
+
+	// (func (param (ref 1) (ref null extern)) (result (ref null func)))
+	input := []byte{
+		0x60,
+		2, wasm.RefTypeNonNullablePrefix, 1, wasm.RefTypeNullablePrefix, 0x6f,
+		1, wasm.RefTypeNullablePrefix, 0x70,
+	}
+	var actual wasm.FunctionType
+	err := decodeFunctionType(features, bytes.NewReader(input), 2, &actual)
+	require.NoError(t, err)
+	require.Equal(t, []wasm.ValueType{wasm.ValueTypeFuncref, wasm.ValueTypeExternref}, actual.Params)
+	require.Equal(t, []wasm.ValueType{wasm.ValueTypeFuncref}, actual.Results)
+
+	t.Run("type index out of range", func(t *testing.T) {
+		err := decodeFunctionType(features, bytes.NewReader(input), 1, &actual)
+		require.EqualError(t, err, "could not read parameter types: heap type index out of range: 1")
+	})
+	t.Run("GC heap type", func(t *testing.T) {
+		err := decodeFunctionType(features, bytes.NewReader([]byte{0x60, 1, wasm.RefTypeNullablePrefix, 0x6e, 0}), 1, &actual)
+		require.EqualError(t, err, "could not read parameter types: heap type of anyref invalid as the GC proposal isn't supported")
+	})
+}
@@ -2,6 +2,7 @@ package binary
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 
 	"github.com/tetratelabs/wazero/api"
@@ -11,8 +12,8 @@ import (
 // decodeGlobal returns the api.Global decoded with the WebAssembly 1.0 (20191205) Binary Format.
 //
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#binary-global
-func decodeGlobal(r *bytes.Reader, enabledFeatures api.CoreFeatures, ret *wasm.Global) (err error) {
-	ret.Type, err = decodeGlobalType(r)
+func decodeGlobal(r *bytes.Reader, enabledFeatures api.CoreFeatures, typeCount int, ret *wasm.Global) (err error) {
+	ret.Type, err = decodeGlobalType(r, enabledFeatures, typeCount)
 	if err != nil {
 		return err
 	}
@@ -24,12 +25,16 @@ func decodeGlobal(r *bytes.Reader, enabledFeatures api.CoreFeatures, ret *wasm.G
 // decodeGlobalType returns the wasm.GlobalType decoded with the WebAssembly 1.0 (20191205) Binary Format.
 //
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#binary-globaltype
-func decodeGlobalType(r *bytes.Reader) (wasm.GlobalType, error) {
-	vt, err := decodeValueTypes(r, 1)
+func decodeGlobalType(r *bytes.Reader, enabledFeatures api.CoreFeatures, typeCount int) (wasm.GlobalType, error) {
+	vt, err := decodeValueTypes(r, 1, enabledFeatures, typeCount)
 	if err != nil {
 		return wasm.GlobalType{}, fmt.Errorf("read value type: %w", err)
 	}
 
+	if vt[0] == wasm.ValueTypeExnref {
+		return wasm.GlobalType{}, errors.New("exnref globals are not supported")
+	}
+
 	ret := wasm.GlobalType{
 		ValType: vt[0],
 	}
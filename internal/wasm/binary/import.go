@@ -2,9 +2,11 @@ package binary
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
@@ -15,6 +17,7 @@ func decodeImport(
 	memorySizer memorySizer,
 	memoryLimitPages uint32,
 	enabledFeatures api.CoreFeatures,
+	typeCount int,
 	ret *wasm.Import,
 ) (err error) {
 	if ret.Module, _, err = decodeUTF8(r, "import module"); err != nil {
@@ -39,9 +42,15 @@ func decodeImport(
 	case wasm.ExternTypeTable:
 		err = decodeTable(r, enabledFeatures, &ret.DescTable)
 	case wasm.ExternTypeMemory:
-		ret.DescMem, err = decodeMemory(r, memorySizer, memoryLimitPages)
+		ret.DescMem, err = decodeMemory(r, enabledFeatures, memorySizer, memoryLimitPages)
 	case wasm.ExternTypeGlobal:
-		ret.DescGlobal, err = decodeGlobalType(r)
+		ret.DescGlobal, err = decodeGlobalType(r, enabledFeatures, typeCount)
+	case wasm.ExternTypeTag:
+		if !enabledFeatures.IsEnabled(experimental.CoreFeaturesExceptionHandling) {
+			err = errors.New(`tag import invalid as feature "exception-handling" is disabled`)
+		} else {
+			ret.DescTag, err = decodeTag(r)
+		}
 	default:
 		err = fmt.Errorf("%w: invalid byte for importdesc: %#x", ErrInvalidByte, b)
 	}
@@ -2,25 +2,120 @@ package binary
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
 
+// memory64MaxPages is the maximum count of pages of a 64-bit memory: 2^48,
+// which is 2^64 bytes.
+const memory64MaxPages = 1 << 48
+
 // decodeMemory returns the api.Memory decoded with the WebAssembly 1.0 (20191205) Binary Format.
 //
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#binary-memory
 func decodeMemory(
 	r *bytes.Reader,
+	enabledFeatures api.CoreFeatures,
 	memorySizer func(minPages uint32, maxPages *uint32) (min, capacity, max uint32),
 	memoryLimitPages uint32,
 ) (*wasm.Memory, error) {
-	min, maxP, err := decodeLimitsType(r)
+	min, maxP, is64, isShared, err := decodeMemoryLimitsType(r, enabledFeatures, memoryLimitPages)
 	if err != nil {
 		return nil, err
 	}
 
 	min, capacity, max := memorySizer(min, maxP)
-	mem := &wasm.Memory{Min: min, Cap: capacity, Max: max, IsMaxEncoded: maxP != nil}
+	mem := &wasm.Memory{Min: min, Cap: capacity, Max: max, IsMaxEncoded: maxP != nil, Is64: is64, IsShared: isShared}
 
 	return mem, mem.Validate(memoryLimitPages)
 }
+
+// decodeMemoryLimitsType is like decodeLimitsType, except it also decodes the
+// 64-bit limits of the memory64 proposal, and the shared limits of the threads
+// proposal.
+//
+// As the size of a memory can't exceed memoryLimitPages, a 64-bit maximum is
+// lowered to it, since memory.grow can fail, but a 64-bit minimum over it errs.
+//
+// See https://github.com/WebAssembly/memory64/blob/main/proposals/memory64/Overview.md#binary-format
+// See https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md#spec-changes
+func decodeMemoryLimitsType(r *bytes.Reader, enabledFeatures api.CoreFeatures, memoryLimitPages uint32) (min uint32, max *uint32, is64, isShared bool, err error) {
+	var flag byte
+	if flag, err = r.ReadByte(); err != nil {
+		err = fmt.Errorf("read leading byte: %v", err)
+		return
+	}
+
+	// The flag is a bit field of "has max" (0x01), "shared" (0x02) and "64-bit" (0x04).
+	if flag > 0x07 || flag&0x06 == 0 {
+		_ = r.UnreadByte()
+		min, max, err = decodeLimitsType(r)
+		return
+	}
+	hasMax := flag&0x01 != 0
+	isShared = flag&0x02 != 0
+	is64 = flag&0x04 != 0
+
+	if is64 && !enabledFeatures.IsEnabled(experimental.CoreFeaturesMemory64) {
+		err = errors.New(`64-bit memory invalid as feature "memory64" is disabled`)
+		return
+	} else if isShared && !enabledFeatures.IsEnabled(experimental.CoreFeaturesThreads) {
+		err = errors.New(`shared memory invalid as feature "threads" is disabled`)
+		return
+	} else if isShared && !hasMax {
+		err = errors.New("shared memory must have a max")
+		return
+	}
+
+	if !is64 {
+		if min, _, err = leb128.DecodeUint32(r); err != nil {
+			err = fmt.Errorf("read min of limit: %v", err)
+			return
+		}
+		if hasMax {
+			var m uint32
+			if m, _, err = leb128.DecodeUint32(r); err != nil {
+				err = fmt.Errorf("read max of limit: %v", err)
+				return
+			}
+			max = &m
+		}
+		return
+	}
+
+	min64, _, err := leb128.DecodeUint64(r)
+	if err != nil {
+		err = fmt.Errorf("read min of limit: %v", err)
+		return
+	} else if min64 > uint64(memoryLimitPages) {
+		err = fmt.Errorf("min %d pages over limit of %d pages (%s)",
+			min64, memoryLimitPages, wasm.PagesToUnitOfBytes(memoryLimitPages))
+		return
+	}
+	min = uint32(min64)
+
+	if hasMax {
+		var max64 uint64
+		if max64, _, err = leb128.DecodeUint64(r); err != nil {
+			err = fmt.Errorf("read max of limit: %v", err)
+			return
+		} else if max64 > memory64MaxPages {
+			err = fmt.Errorf("max %d pages over limit of 2^48 pages", max64)
+			return
+		} else if max64 < min64 {
+			err = fmt.Errorf("min %d pages > max %d pages", min64, max64)
+			return
+		}
+		m := uint32(max64)
+		if max64 > uint64(memoryLimitPages) {
+			m = memoryLimitPages
+		}
+		max = &m
+	}
+	return
+}
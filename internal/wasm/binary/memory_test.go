@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/testing/binaryencoding"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
@@ -163,6 +165,16 @@ func TestMemoryType(t *testing.T) {
 			memoryLimitPages: 512,
 			expected:         []byte{0x1, 0, 0x80, 0x80, 0x4},
 		},
+		{
+			name:     "64-bit min 1 default max",
+			input:    &wasm.Memory{Min: 1, Cap: 1, Max: max, Is64: true},
+			expected: []byte{0x4, 1},
+		},
+		{
+			name:     "64-bit min=max",
+			input:    &wasm.Memory{Min: 1, Cap: 1, Max: 1, IsMaxEncoded: true, Is64: true},
+			expected: []byte{0x5, 1, 1},
+		},
 	}
 
 	for _, tt := range tests {
@@ -182,7 +194,7 @@ func TestMemoryType(t *testing.T) {
 				expectedDecoded.Max = tmax
 			}
 
-			binary, err := decodeMemory(bytes.NewReader(b), newMemorySizer(tmax, false), tmax)
+			binary, err := decodeMemory(bytes.NewReader(b), api.CoreFeaturesV2|experimental.CoreFeaturesMemory64, newMemorySizer(tmax, false), tmax)
 			require.NoError(t, err)
 			require.Equal(t, binary, expectedDecoded)
 		})
@@ -212,14 +224,95 @@ func TestDecodeMemoryType_Errors(t *testing.T) {
 			input:       []byte{0x1, 0, 0xff, 0xff, 0xff, 0xff, 0xf},
 			expectedErr: "max 4294967295 pages (3 Ti) over limit of 65536 pages (4 Gi)",
 		},
+		{
+			name:        "shared",
+			input:       []byte{0x3, 0x1, 0x1},
+			expectedErr: `shared memory invalid as feature "threads" is disabled`,
+		},
 	}
 
 	for _, tt := range tests {
 		tc := tt
 
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := decodeMemory(bytes.NewReader(tc.input), newMemorySizer(max, false), max)
+			_, err := decodeMemory(bytes.NewReader(tc.input), api.CoreFeaturesV2, newMemorySizer(max, false), max)
 			require.EqualError(t, err, tc.expectedErr)
 		})
 	}
 }
+
+func TestDecodeMemoryType_Memory64(t *testing.T) {
+	max := wasm.MemoryLimitPages
+	features := api.CoreFeaturesV2 | experimental.CoreFeaturesMemory64
+
+	t.Run("max over limit is lowered", func(t *testing.T) {
+		// min 1, max 2^48 pages
+		input := []byte{0x5, 1, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x40}
+		mem, err := decodeMemory(bytes.NewReader(input), features, newMemorySizer(max, false), max)
+		require.NoError(t, err)
+		require.Equal(t, &wasm.Memory{Min: 1, Cap: 1, Max: max, IsMaxEncoded: true, Is64: true}, mem)
+	})
+
+	tests := []struct {
+		name        string
+		features    api.CoreFeatures
+		input       []byte
+		expectedErr string
+	}{
+		{
+			name:        "disabled",
+			features:    api.CoreFeaturesV2,
+			input:       []byte{0x4, 1},
+			expectedErr: `64-bit memory invalid as feature "memory64" is disabled`,
+		},
+		{
+			name:        "min > limit",
+			features:    features,
+			input:       []byte{0x4, 0x81, 0x80, 0x4},
+			expectedErr: "min 65537 pages over limit of 65536 pages (4 Gi)",
+		},
+		{
+			name:        "max < min",
+			features:    features,
+			input:       []byte{0x5, 2, 1},
+			expectedErr: "min 2 pages > max 1 pages",
+		},
+		{
+			name:        "max > 2^48",
+			features:    features,
+			input:       []byte{0x5, 0, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x1},
+			expectedErr: "max 562949953421312 pages over limit of 2^48 pages",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := decodeMemory(bytes.NewReader(tc.input), tc.features, newMemorySizer(max, false), max)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestDecodeMemoryType_Threads(t *testing.T) {
+	max := wasm.MemoryLimitPages
+	features := api.CoreFeaturesV2 | experimental.CoreFeaturesThreads
+
+	t.Run("shared", func(t *testing.T) {
+		mem, err := decodeMemory(bytes.NewReader([]byte{0x3, 1, 2}), features, newMemorySizer(max, false), max)
+		require.NoError(t, err)
+		require.Equal(t, &wasm.Memory{Min: 1, Cap: 1, Max: 2, IsMaxEncoded: true, IsShared: true}, mem)
+	})
+
+	t.Run("shared 64-bit", func(t *testing.T) {
+		mem, err := decodeMemory(bytes.NewReader([]byte{0x7, 1, 2}), features|experimental.CoreFeaturesMemory64, newMemorySizer(max, false), max)
+		require.NoError(t, err)
+		require.Equal(t, &wasm.Memory{Min: 1, Cap: 1, Max: 2, IsMaxEncoded: true, Is64: true, IsShared: true}, mem)
+	})
+
+	t.Run("no max", func(t *testing.T) {
+		_, err := decodeMemory(bytes.NewReader([]byte{0x2, 1}), features, newMemorySizer(max, false), max)
+		require.EqualError(t, err, "shared memory must have a max")
+	})
+}
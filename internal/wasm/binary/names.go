@@ -17,14 +17,35 @@ const (
 	// subsectionIDLocalNames contain a map of function indices to a map of local indices to their names, in ascending
 	// order by function and local index
 	subsectionIDLocalNames = uint8(2)
+
+	// The below are from the extended-name-section proposal, and are maps of indices to names, except labels which
+	// are by function index and label index.
+	// See https://github.com/WebAssembly/extended-name-section/blob/main/proposals/extended-name-section/Overview.md
+
+	subsectionIDLabelNames   = uint8(3)
+	subsectionIDTypeNames    = uint8(4)
+	subsectionIDTableNames   = uint8(5)
+	subsectionIDMemoryNames  = uint8(6)
+	subsectionIDGlobalNames  = uint8(7)
+	subsectionIDElementNames = uint8(8)
+	subsectionIDDataNames    = uint8(9)
+	// subsectionIDTagNames is from the exception-handling proposal, as 10 is for fields in the GC proposal.
+	subsectionIDTagNames = uint8(11)
 )
 
+// DecodeNameSection deserializes the data of the "name" custom section, e.g. as retained in wasm.Module
+// NameSectionData.
+func DecodeNameSection(data []byte) (*wasm.NameSection, error) {
+	return decodeNameSection(bytes.NewReader(data), uint64(len(data)))
+}
+
 // decodeNameSection deserializes the data associated with the "name" key in SectionIDCustom according to the
 // standard:
 //
 // * ModuleName decode from subsection 0
 // * FunctionNames decode from subsection 1
 // * LocalNames decode from subsection 2
+// * LabelNames to TagNames decode from subsections 3 to 11 of the extended-name-section proposal
 //
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#binary-namesec
 func decodeNameSection(r *bytes.Reader, limit uint64) (result *wasm.NameSection, err error) {
@@ -62,7 +83,39 @@ func decodeNameSection(r *bytes.Reader, limit uint64) (result *wasm.NameSection,
 				return nil, err
 			}
 		case subsectionIDLocalNames:
-			if result.LocalNames, err = decodeLocalNames(r); err != nil {
+			if result.LocalNames, err = decodeIndirectNames(r, subsectionIDLocalNames, "local"); err != nil {
+				return nil, err
+			}
+		case subsectionIDLabelNames:
+			if result.LabelNames, err = decodeIndirectNames(r, subsectionIDLabelNames, "label"); err != nil {
+				return nil, err
+			}
+		case subsectionIDTypeNames:
+			if result.TypeNames, err = decodeNameMap(r, subsectionIDTypeNames, "type"); err != nil {
+				return nil, err
+			}
+		case subsectionIDTableNames:
+			if result.TableNames, err = decodeNameMap(r, subsectionIDTableNames, "table"); err != nil {
+				return nil, err
+			}
+		case subsectionIDMemoryNames:
+			if result.MemoryNames, err = decodeNameMap(r, subsectionIDMemoryNames, "memory"); err != nil {
+				return nil, err
+			}
+		case subsectionIDGlobalNames:
+			if result.GlobalNames, err = decodeNameMap(r, subsectionIDGlobalNames, "global"); err != nil {
+				return nil, err
+			}
+		case subsectionIDElementNames:
+			if result.ElementNames, err = decodeNameMap(r, subsectionIDElementNames, "element segment"); err != nil {
+				return nil, err
+			}
+		case subsectionIDDataNames:
+			if result.DataNames, err = decodeNameMap(r, subsectionIDDataNames, "data segment"); err != nil {
+				return nil, err
+			}
+		case subsectionIDTagNames:
+			if result.TagNames, err = decodeNameMap(r, subsectionIDTagNames, "tag"); err != nil {
 				return nil, err
 			}
 		default: // Skip other subsections.
@@ -98,38 +151,62 @@ func decodeFunctionNames(r *bytes.Reader) (wasm.NameMap, error) {
 	return result, nil
 }
 
-func decodeLocalNames(r *bytes.Reader) (wasm.IndirectNameMap, error) {
-	functionCount, err := decodeFunctionCount(r, subsectionIDLocalNames)
+// decodeNameMap decodes the names of `kind` in a subsection, e.g. "table", by their index.
+func decodeNameMap(r *bytes.Reader, subsectionID uint8, kind string) (wasm.NameMap, error) {
+	count, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the %s count of subsection[%d]: %w", kind, subsectionID, err)
+	}
+
+	result := make(wasm.NameMap, count)
+	for i := uint32(0); i < count; i++ {
+		index, _, err := leb128.DecodeUint32(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the %s index in subsection[%d]: %w", kind, subsectionID, err)
+		}
+
+		name, _, err := decodeUTF8(r, "%s[%d] name", kind, index)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = wasm.NameAssoc{Index: index, Name: name}
+	}
+	return result, nil
+}
+
+// decodeIndirectNames decodes the names of `kind` in a subsection, e.g. "local", by function index and their index.
+func decodeIndirectNames(r *bytes.Reader, subsectionID uint8, kind string) (wasm.IndirectNameMap, error) {
+	functionCount, err := decodeFunctionCount(r, subsectionID)
 	if err != nil {
 		return nil, err
 	}
 
 	result := make(wasm.IndirectNameMap, functionCount)
 	for i := uint32(0); i < functionCount; i++ {
-		functionIndex, err := decodeFunctionIndex(r, subsectionIDLocalNames)
+		functionIndex, err := decodeFunctionIndex(r, subsectionID)
 		if err != nil {
 			return nil, err
 		}
 
-		localCount, _, err := leb128.DecodeUint32(r)
+		count, _, err := leb128.DecodeUint32(r)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read the local count for function[%d]: %w", functionIndex, err)
+			return nil, fmt.Errorf("failed to read the %s count for function[%d]: %w", kind, functionIndex, err)
 		}
 
-		locals := make(wasm.NameMap, localCount)
-		for j := uint32(0); j < localCount; j++ {
-			localIndex, _, err := leb128.DecodeUint32(r)
+		names := make(wasm.NameMap, count)
+		for j := uint32(0); j < count; j++ {
+			index, _, err := leb128.DecodeUint32(r)
 			if err != nil {
-				return nil, fmt.Errorf("failed to read a local index of function[%d]: %w", functionIndex, err)
+				return nil, fmt.Errorf("failed to read a %s index of function[%d]: %w", kind, functionIndex, err)
 			}
 
-			name, _, err := decodeUTF8(r, "function[%d] local[%d] name", functionIndex, localIndex)
+			name, _, err := decodeUTF8(r, "function[%d] %s[%d] name", functionIndex, kind, index)
 			if err != nil {
 				return nil, err
 			}
-			locals[j] = wasm.NameAssoc{Index: localIndex, Name: name}
+			names[j] = wasm.NameAssoc{Index: index, Name: name}
 		}
-		result[i] = wasm.NameMapAssoc{Index: functionIndex, NameMap: locals}
+		result[i] = wasm.NameMapAssoc{Index: functionIndex, NameMap: names}
 	}
 	return result, nil
 }
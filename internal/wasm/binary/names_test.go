@@ -59,6 +59,21 @@ func TestDecodeNameSection(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "extended names",
+			input: &wasm.NameSection{
+				LabelNames: wasm.IndirectNameMap{
+					{Index: wasm.Index(1), NameMap: wasm.NameMap{{Index: wasm.Index(0), Name: "loop"}}},
+				},
+				TypeNames:    wasm.NameMap{{Index: wasm.Index(0), Name: "v_v"}},
+				TableNames:   wasm.NameMap{{Index: wasm.Index(0), Name: "funcs"}},
+				MemoryNames:  wasm.NameMap{{Index: wasm.Index(0), Name: "heap"}},
+				GlobalNames:  wasm.NameMap{{Index: wasm.Index(0), Name: "sp"}, {Index: wasm.Index(2), Name: "tp"}},
+				ElementNames: wasm.NameMap{{Index: wasm.Index(0), Name: "vtable"}},
+				DataNames:    wasm.NameMap{{Index: wasm.Index(1), Name: "rodata"}},
+				TagNames:     wasm.NameMap{{Index: wasm.Index(0), Name: "panic"}},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -98,8 +113,8 @@ func TestDecodeNameSection_Errors(t *testing.T) {
 		},
 		{
 			name:        "EOF after unknown subsection ID",
-			input:       []byte{4},
-			expectedErr: "failed to read the size of subsection[4]: EOF",
+			input:       []byte{12},
+			expectedErr: "failed to read the size of subsection[12]: EOF",
 		},
 		{
 			name:        "EOF after module name subsection size",
@@ -118,8 +133,8 @@ func TestDecodeNameSection_Errors(t *testing.T) {
 		},
 		{
 			name:        "EOF skipping unknown subsection size",
-			input:       []byte{4, 100},
-			expectedErr: "failed to skip subsection[4]: EOF",
+			input:       []byte{12, 100},
+			expectedErr: "failed to skip subsection[12]: EOF",
 		},
 		{
 			name:        "EOF after module name size",
@@ -161,6 +176,26 @@ func TestDecodeNameSection_Errors(t *testing.T) {
 			input:       []byte{subsectionIDLocalNames, ignoredSubsectionSize, 2, 0, 2, 1},
 			expectedErr: "failed to read function[0] local[1] name size: EOF",
 		},
+		{
+			name:        "EOF after label names count for a function index",
+			input:       []byte{subsectionIDLabelNames, ignoredSubsectionSize, 2, 0, 2},
+			expectedErr: "failed to read a label index of function[0]: EOF",
+		},
+		{
+			name:        "EOF after table names subsection size",
+			input:       []byte{subsectionIDTableNames, ignoredSubsectionSize},
+			expectedErr: "failed to read the table count of subsection[5]: EOF",
+		},
+		{
+			name:        "EOF after global name count",
+			input:       []byte{subsectionIDGlobalNames, ignoredSubsectionSize, 2},
+			expectedErr: "failed to read the global index in subsection[7]: EOF",
+		},
+		{
+			name:        "EOF after data segment name size",
+			input:       []byte{subsectionIDDataNames, ignoredSubsectionSize, 1, 0, 5},
+			expectedErr: "failed to read data segment[0] name: EOF",
+		},
 	}
 
 	for _, tt := range tests {
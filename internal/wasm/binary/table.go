@@ -2,6 +2,7 @@ package binary
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 
 	"github.com/tetratelabs/wazero/api"
@@ -17,6 +18,11 @@ func decodeTable(r *bytes.Reader, enabledFeatures api.CoreFeatures, ret *wasm.Ta
 		return fmt.Errorf("read leading byte: %v", err)
 	}
 
+	if ret.Type == wasm.RefTypeNullablePrefix || ret.Type == wasm.RefTypeNonNullablePrefix {
+		return errors.New("tables of typed references are not supported")
+	} else if name, ok := wasm.GCValueTypeName(ret.Type); ok {
+		return fmt.Errorf("table type %s invalid as %w", name, wasm.ErrGCUnsupported)
+	}
 	if ret.Type != wasm.RefTypeFuncref {
 		if err = enabledFeatures.RequireEnabled(api.CoreFeatureReferenceTypes); err != nil {
 			return fmt.Errorf("table type funcref is invalid: %w", err)
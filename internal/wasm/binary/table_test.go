@@ -81,6 +81,12 @@ func TestDecodeTableType_Errors(t *testing.T) {
 			input:       []byte{0x50, 0x1, 0x80, 0x80, 0x4, 0},
 			expectedErr: "table type funcref is invalid: feature \"reference-types\" is disabled",
 		},
+		{
+			name:        "typed reference",
+			input:       []byte{wasm.RefTypeNullablePrefix, 0x70, 0x0, 0x1},
+			expectedErr: "tables of typed references are not supported",
+			features:    api.CoreFeaturesV2,
+		},
 		{
 			name:        "max < min",
 			input:       []byte{wasm.RefTypeFuncref, 0x1, 0x80, 0x80, 0x4, 0},
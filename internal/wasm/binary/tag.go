@@ -0,0 +1,28 @@
+package binary
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// decodeTag returns the index in wasm.Module TypeSection of the type of a tag, decoded as defined by the exception
+// handling proposal.
+//
+// See https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/Exceptions.md#tag-section
+func decodeTag(r *bytes.Reader) (wasm.Index, error) {
+	attribute, err := r.ReadByte()
+	if err != nil {
+		return 0, fmt.Errorf("read attribute: %w", err)
+	}
+	if attribute != 0x00 { // exception
+		return 0, fmt.Errorf("%w for tag attribute: %#x != 0x00", ErrInvalidByte, attribute)
+	}
+	typeIndex, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return 0, fmt.Errorf("read type index: %w", err)
+	}
+	return typeIndex, nil
+}
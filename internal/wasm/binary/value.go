@@ -7,28 +7,48 @@ import (
 	"unicode/utf8"
 	"unsafe"
 
+	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
 
-func decodeValueTypes(r *bytes.Reader, num uint32) ([]wasm.ValueType, error) {
+// decodeValueTypes decodes `num` value types, which can be typed references if
+// experimental.CoreFeaturesFunctionReferences is enabled. `typeCount` is the
+// count of types their heap types can refer to.
+func decodeValueTypes(r *bytes.Reader, num uint32, enabledFeatures api.CoreFeatures, typeCount int) ([]wasm.ValueType, error) {
 	if num == 0 {
 		return nil, nil
 	}
 
-	ret := make([]wasm.ValueType, num)
-	_, err := io.ReadFull(r, ret)
-	if err != nil {
-		return nil, err
+	// Each value type is at least one byte, so don't allocate more than the
+	// remaining bytes.
+	if r.Len() == 0 {
+		return nil, io.EOF
+	} else if uint64(r.Len()) < uint64(num) {
+		return nil, io.ErrUnexpectedEOF
 	}
 
-	for _, v := range ret {
+	ret := make([]wasm.ValueType, num)
+	for i := range ret {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		v, _, err := wasm.DecodeRefType(r, b, enabledFeatures, typeCount)
+		if err != nil {
+			return nil, err
+		}
+
 		switch v {
 		case wasm.ValueTypeI32, wasm.ValueTypeF32, wasm.ValueTypeI64, wasm.ValueTypeF64,
-			wasm.ValueTypeExternref, wasm.ValueTypeFuncref, wasm.ValueTypeV128:
+			wasm.ValueTypeExternref, wasm.ValueTypeFuncref, wasm.ValueTypeV128, wasm.ValueTypeExnref:
 		default:
+			if name, ok := wasm.GCValueTypeName(v); ok {
+				return nil, fmt.Errorf("value type %s invalid as %w", name, wasm.ErrGCUnsupported)
+			}
 			return nil, fmt.Errorf("invalid value type: %d", v)
 		}
+		ret[i] = v
 	}
 	return ret, nil
 }
@@ -0,0 +1,106 @@
+package wasm
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/internal/leb128"
+)
+
+// IsExtendedConstOpcode returns true if `op` is an arithmetic instruction
+// allowed in constant expressions by the extended-const proposal.
+//
+// A ConstantExpression of more than one instruction has the last one as its
+// Opcode, which is always one of these, and all of them, including the last
+// one, as its Data.
+func IsExtendedConstOpcode(op Opcode) bool {
+	switch op {
+	case OpcodeI32Add, OpcodeI32Sub, OpcodeI32Mul, OpcodeI64Add, OpcodeI64Sub, OpcodeI64Mul:
+		return true
+	}
+	return false
+}
+
+// constValue is a value on the stack of an extended constant expression.
+type constValue struct {
+	t ValueType
+	v uint64
+}
+
+// evalExtendedConst evaluates the instructions `data` of an extended constant
+// expression, e.g. `global.get 0 i32.const 16 i32.add`, returning the type and
+// the value of its result. `global` returns the type and the value of the
+// global of an index, or an error if it is out of range.
+//
+// This errs if the expression is invalid, so also validates it.
+func evalExtendedConst(data []byte, global func(Index) (ValueType, uint64, error)) (ValueType, uint64, error) {
+	r := bytes.NewReader(data)
+	var stack []constValue
+	for r.Len() > 0 {
+		op, _ := r.ReadByte()
+		switch op {
+		case OpcodeI32Const:
+			v, _, err := leb128.DecodeInt32(r)
+			if err != nil {
+				return 0, 0, fmt.Errorf("read i32: %w", err)
+			}
+			stack = append(stack, constValue{t: ValueTypeI32, v: uint64(uint32(v))})
+		case OpcodeI64Const:
+			v, _, err := leb128.DecodeInt64(r)
+			if err != nil {
+				return 0, 0, fmt.Errorf("read i64: %w", err)
+			}
+			stack = append(stack, constValue{t: ValueTypeI64, v: uint64(v)})
+		case OpcodeGlobalGet:
+			index, _, err := leb128.DecodeUint32(r)
+			if err != nil {
+				return 0, 0, fmt.Errorf("read index of global: %w", err)
+			}
+			t, v, err := global(index)
+			if err != nil {
+				return 0, 0, err
+			}
+			stack = append(stack, constValue{t: t, v: v})
+		case OpcodeI32Add, OpcodeI32Sub, OpcodeI32Mul, OpcodeI64Add, OpcodeI64Sub, OpcodeI64Mul:
+			t := ValueTypeI32
+			if op >= OpcodeI64Add {
+				t = ValueTypeI64
+			}
+			if len(stack) < 2 || stack[len(stack)-1].t != t || stack[len(stack)-2].t != t {
+				return 0, 0, fmt.Errorf("type mismatch on %s: expected two %s values", InstructionName(op), ValueTypeName(t))
+			}
+			x1, x2 := stack[len(stack)-2].v, stack[len(stack)-1].v
+			var v uint64
+			switch op {
+			case OpcodeI32Add, OpcodeI64Add:
+				v = x1 + x2
+			case OpcodeI32Sub, OpcodeI64Sub:
+				v = x1 - x2
+			default:
+				v = x1 * x2
+			}
+			if t == ValueTypeI32 {
+				v = uint64(uint32(v))
+			}
+			stack = append(stack[:len(stack)-2], constValue{t: t, v: v})
+		default:
+			return 0, 0, fmt.Errorf("invalid opcode for const expression: 0x%x", op)
+		}
+	}
+	if len(stack) != 1 {
+		return 0, 0, fmt.Errorf("const expression must have one result, but has %d", len(stack))
+	}
+	return stack[0].t, stack[0].v, nil
+}
+
+// evalExtendedConstInstance evaluates the extended constant expression `expr`
+// in a module instance whose globals, as far as the expression can refer to
+// them, are `globals`.
+func evalExtendedConstInstance(globals []*GlobalInstance, expr *ConstantExpression) (ValueType, uint64) {
+	// Ignore error as it's already validated.
+	t, v, _ := evalExtendedConst(expr.Data, func(index Index) (ValueType, uint64, error) {
+		g := globals[index]
+		return g.Type.ValType, g.Val, nil
+	})
+	return t, v
+}
@@ -34,6 +34,21 @@ type Engine interface {
 	NewModuleEngine(module *Module, instance *ModuleInstance) (ModuleEngine, error)
 }
 
+// EngineSerializer is implemented by an Engine which can serialize compiled
+// modules, so that they can be loaded later without compiling them again.
+type EngineSerializer interface {
+	// SerializeCompiledModule returns the compiled code of the module, which
+	// must have been compiled by this engine.
+	SerializeCompiledModule(module *Module) ([]byte, error)
+
+	// DeserializeCompiledModule is like Engine.CompileModule, except the
+	// compiled code is loaded from the result of SerializeCompiledModule.
+	//
+	// Note: The caller must ensure `module` and `listeners` are the same as
+	// when the module was compiled, e.g. by comparing Module.ID.
+	DeserializeCompiledModule(module *Module, serialized []byte, listeners []experimental.FunctionListener) error
+}
+
 // ModuleEngine implements function calls for a given module.
 type ModuleEngine interface {
 	// DoneInstantiation is called at the end of the instantiation of the module.
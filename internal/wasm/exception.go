@@ -0,0 +1,73 @@
+package wasm
+
+import "fmt"
+
+// Catch clause kinds of OpcodeTryTable.
+const (
+	// CatchKindCatch catches exceptions of a tag, passing their payload to the label.
+	CatchKindCatch byte = iota
+	// CatchKindCatchRef is like CatchKindCatch, but also passes the exnref of the exception.
+	CatchKindCatchRef
+	// CatchKindCatchAll catches all exceptions, passing nothing to the label.
+	CatchKindCatchAll
+	// CatchKindCatchAllRef is like CatchKindCatchAll, but passes the exnref of the exception.
+	CatchKindCatchAllRef
+)
+
+// TagInstance is a tag of a module instance, defined by the exception
+// handling proposal. An exception is caught by a catch clause for its tag,
+// which is the same instance when the tag is imported from another module.
+type TagInstance struct {
+	// Type is the type of the tag, whose params are the payload of its
+	// exceptions.
+	Type *FunctionType
+	// ModuleName and Index identify the tag in the module which defines it.
+	ModuleName string
+	Index      Index
+}
+
+// String implements fmt.Stringer.
+func (t *TagInstance) String() string {
+	return fmt.Sprintf("tag[%d] of module %q", t.Index, t.ModuleName)
+}
+
+// Exception is an exception thrown by the `throw` instruction, or by a host
+// function with Throw. An exnref value is a pointer to an Exception.
+type Exception struct {
+	// Tag is the tag of the exception.
+	Tag *TagInstance
+	// Payload holds the values of the params of Tag.Type, encoded like
+	// api.Function params.
+	Payload []uint64
+}
+
+// Error implements error, as an uncaught exception fails the call.
+func (e *Exception) Error() string {
+	return fmt.Sprintf("uncaught exception of %s with payload %v", e.Tag, e.Payload)
+}
+
+// Throw throws an exception of `tag` from a host function, which unwinds the
+// call until it is caught. This panics, so never returns.
+func Throw(tag *TagInstance, payload []uint64) {
+	if len(payload) != tag.Type.ParamNumInUint64 {
+		panic(fmt.Errorf("invalid payload of %s: expected %d values, but got %d", tag, tag.Type.ParamNumInUint64, len(payload)))
+	}
+	panic(&Exception{Tag: tag, Payload: payload})
+}
+
+// buildTags creates the tags defined in `module`, after the imported ones.
+func (m *ModuleInstance) buildTags(module *Module) {
+	for i, typeIndex := range module.TagSection {
+		index := module.ImportTagCount + Index(i)
+		m.Tags[index] = &TagInstance{Type: &module.TypeSection[typeIndex], ModuleName: m.ModuleName, Index: index}
+	}
+}
+
+// ExportedTag returns the tag exported as `name`, or nil if there is none.
+func (m *ModuleInstance) ExportedTag(name string) *TagInstance {
+	exp, err := m.getExport(name, ExternTypeTag)
+	if err != nil {
+		return nil
+	}
+	return m.Tags[exp.Index]
+}
@@ -0,0 +1,254 @@
+package wasm
+
+import (
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/internalapi"
+)
+
+// ImportDefinitions implements wazero.CompiledModule Imports.
+func (m *Module) ImportDefinitions() []api.Import {
+	ret := make([]api.Import, 0, len(m.ImportSection))
+	for i := range m.ImportSection {
+		imp := &m.ImportSection[i]
+		ret = append(ret, &ImportDefinition{
+			typ:        imp.Type,
+			moduleName: imp.Module,
+			name:       imp.Name,
+			definition: m.externDefinition(imp.Type, imp.IndexPerType),
+		})
+	}
+	return ret
+}
+
+// ExportDefinitions implements wazero.CompiledModule Exports.
+func (m *Module) ExportDefinitions() []api.Export {
+	ret := make([]api.Export, 0, len(m.ExportSection))
+	for i := range m.ExportSection {
+		exp := &m.ExportSection[i]
+		ret = append(ret, &ExportDefinition{
+			typ:        exp.Type,
+			name:       exp.Name,
+			definition: m.externDefinition(exp.Type, exp.Index),
+		})
+	}
+	return ret
+}
+
+// externDefinition returns the definition at the index of the given type, or
+// nil if it has none, e.g. for a tag.
+func (m *Module) externDefinition(t ExternType, index Index) api.ExportDefinition {
+	switch t {
+	case ExternTypeFunc:
+		return m.FunctionDefinition(index)
+	case ExternTypeTable:
+		return m.tableDefinition(index)
+	case ExternTypeMemory:
+		if index < uint32(len(m.MemoryDefinitionSection)) {
+			return &m.MemoryDefinitionSection[index]
+		}
+	case ExternTypeGlobal:
+		return m.globalDefinition(index)
+	}
+	return nil
+}
+
+// tableDefinition returns the definition of the table at index, which must
+// be valid.
+func (m *Module) tableDefinition(index Index) *TableDefinition {
+	d := &TableDefinition{index: index}
+	d.moduleName, d.importDesc, d.exportNames = m.externDesc(ExternTypeTable, index)
+	if d.importDesc != nil {
+		d.table = &m.importDesc(ExternTypeTable, index).DescTable
+	} else {
+		d.table = &m.TableSection[index-m.ImportTableCount]
+	}
+	return d
+}
+
+// globalDefinition returns the definition of the global at index, which must
+// be valid.
+func (m *Module) globalDefinition(index Index) *GlobalDefinition {
+	d := &GlobalDefinition{index: index}
+	d.moduleName, d.importDesc, d.exportNames = m.externDesc(ExternTypeGlobal, index)
+	if d.importDesc != nil {
+		d.globalType = &m.importDesc(ExternTypeGlobal, index).DescGlobal
+	} else {
+		d.globalType = &m.GlobalSection[index-m.ImportGlobalCount].Type
+	}
+	return d
+}
+
+// externDesc returns the fields common to all definitions of the given type
+// and index.
+func (m *Module) externDesc(t ExternType, index Index) (moduleName string, importDesc *[2]string, exportNames []string) {
+	if m.NameSection != nil {
+		moduleName = m.NameSection.ModuleName
+	}
+	if imp := m.importDesc(t, index); imp != nil {
+		importDesc = &[2]string{imp.Module, imp.Name}
+	}
+	for i := range m.ExportSection {
+		e := &m.ExportSection[i]
+		if e.Type == t && e.Index == index {
+			exportNames = append(exportNames, e.Name)
+		}
+	}
+	return
+}
+
+// importDesc returns the import of the given type and index, or nil if the
+// index is defined in the module.
+func (m *Module) importDesc(t ExternType, index Index) *Import {
+	for i := range m.ImportSection {
+		if imp := &m.ImportSection[i]; imp.Type == t && imp.IndexPerType == index {
+			return imp
+		}
+	}
+	return nil
+}
+
+// ImportDefinition implements api.Import
+type ImportDefinition struct {
+	internalapi.WazeroOnlyType
+	typ        ExternType
+	moduleName string
+	name       string
+	definition api.ExportDefinition
+}
+
+// Type implements the same method as documented on api.Import.
+func (i *ImportDefinition) Type() api.ExternType {
+	return i.typ
+}
+
+// ModuleName implements the same method as documented on api.Import.
+func (i *ImportDefinition) ModuleName() string {
+	return i.moduleName
+}
+
+// Name implements the same method as documented on api.Import.
+func (i *ImportDefinition) Name() string {
+	return i.name
+}
+
+// Definition implements the same method as documented on api.Import.
+func (i *ImportDefinition) Definition() api.ExportDefinition {
+	return i.definition
+}
+
+// ExportDefinition implements api.Export
+type ExportDefinition struct {
+	internalapi.WazeroOnlyType
+	typ        ExternType
+	name       string
+	definition api.ExportDefinition
+}
+
+// Type implements the same method as documented on api.Export.
+func (e *ExportDefinition) Type() api.ExternType {
+	return e.typ
+}
+
+// Name implements the same method as documented on api.Export.
+func (e *ExportDefinition) Name() string {
+	return e.name
+}
+
+// Definition implements the same method as documented on api.Export.
+func (e *ExportDefinition) Definition() api.ExportDefinition {
+	return e.definition
+}
+
+// TableDefinition implements api.TableDefinition
+type TableDefinition struct {
+	internalapi.WazeroOnlyType
+	moduleName  string
+	index       Index
+	importDesc  *[2]string
+	exportNames []string
+	table       *Table
+}
+
+// ModuleName implements the same method as documented on api.TableDefinition.
+func (t *TableDefinition) ModuleName() string {
+	return t.moduleName
+}
+
+// Index implements the same method as documented on api.TableDefinition.
+func (t *TableDefinition) Index() uint32 {
+	return t.index
+}
+
+// Import implements the same method as documented on api.TableDefinition.
+func (t *TableDefinition) Import() (moduleName, name string, isImport bool) {
+	if importDesc := t.importDesc; importDesc != nil {
+		moduleName, name, isImport = importDesc[0], importDesc[1], true
+	}
+	return
+}
+
+// ExportNames implements the same method as documented on api.TableDefinition.
+func (t *TableDefinition) ExportNames() []string {
+	return t.exportNames
+}
+
+// Type implements the same method as documented on api.TableDefinition.
+func (t *TableDefinition) Type() api.ValueType {
+	return t.table.Type
+}
+
+// Min implements the same method as documented on api.TableDefinition.
+func (t *TableDefinition) Min() uint32 {
+	return t.table.Min
+}
+
+// Max implements the same method as documented on api.TableDefinition.
+func (t *TableDefinition) Max() (max uint32, encoded bool) {
+	if t.table.Max != nil {
+		max, encoded = *t.table.Max, true
+	}
+	return
+}
+
+// GlobalDefinition implements api.GlobalDefinition
+type GlobalDefinition struct {
+	internalapi.WazeroOnlyType
+	moduleName  string
+	index       Index
+	importDesc  *[2]string
+	exportNames []string
+	globalType  *GlobalType
+}
+
+// ModuleName implements the same method as documented on api.GlobalDefinition.
+func (g *GlobalDefinition) ModuleName() string {
+	return g.moduleName
+}
+
+// Index implements the same method as documented on api.GlobalDefinition.
+func (g *GlobalDefinition) Index() uint32 {
+	return g.index
+}
+
+// Import implements the same method as documented on api.GlobalDefinition.
+func (g *GlobalDefinition) Import() (moduleName, name string, isImport bool) {
+	if importDesc := g.importDesc; importDesc != nil {
+		moduleName, name, isImport = importDesc[0], importDesc[1], true
+	}
+	return
+}
+
+// ExportNames implements the same method as documented on api.GlobalDefinition.
+func (g *GlobalDefinition) ExportNames() []string {
+	return g.exportNames
+}
+
+// Type implements the same method as documented on api.GlobalDefinition.
+func (g *GlobalDefinition) Type() api.ValueType {
+	return g.globalType.ValType
+}
+
+// Mutable implements the same method as documented on api.GlobalDefinition.
+func (g *GlobalDefinition) Mutable() bool {
+	return g.globalType.Mutable
+}
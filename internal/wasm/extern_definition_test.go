@@ -0,0 +1,88 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestModule_ImportDefinitions(t *testing.T) {
+	max := uint32(10)
+	m := &Module{
+		NameSection: &NameSection{ModuleName: "test"},
+		ImportSection: []Import{
+			{Type: ExternTypeMemory, Module: "env", Name: "memory", DescMem: &Memory{Min: 1}},
+			{Type: ExternTypeTable, Module: "env", Name: "table", DescTable: Table{Min: 2, Max: &max, Type: RefTypeExternref}},
+			{Type: ExternTypeTag, Module: "env", Name: "error"},
+			{Type: ExternTypeGlobal, Module: "env", Name: "global", DescGlobal: GlobalType{ValType: ValueTypeF64}},
+		},
+		ExportSection:     []Export{{Type: ExternTypeGlobal, Name: "g", Index: 0}},
+		ImportMemoryCount: 1,
+		ImportTableCount:  1,
+		ImportTagCount:    1,
+		ImportGlobalCount: 1,
+	}
+	m.BuildMemoryDefinitions()
+
+	imports := m.ImportDefinitions()
+	require.Equal(t, []api.Import{
+		&ImportDefinition{
+			typ: ExternTypeMemory, moduleName: "env", name: "memory",
+			definition: &m.MemoryDefinitionSection[0],
+		},
+		&ImportDefinition{
+			typ: ExternTypeTable, moduleName: "env", name: "table",
+			definition: &TableDefinition{
+				moduleName: "test",
+				importDesc: &[2]string{"env", "table"},
+				table:      &m.ImportSection[1].DescTable,
+			},
+		},
+		&ImportDefinition{typ: ExternTypeTag, moduleName: "env", name: "error"},
+		&ImportDefinition{
+			typ: ExternTypeGlobal, moduleName: "env", name: "global",
+			definition: &GlobalDefinition{
+				moduleName:  "test",
+				importDesc:  &[2]string{"env", "global"},
+				exportNames: []string{"g"},
+				globalType:  &m.ImportSection[3].DescGlobal,
+			},
+		},
+	}, imports)
+
+	table := imports[1].Definition().(api.TableDefinition)
+	max, ok := table.Max()
+	require.True(t, ok)
+	require.Equal(t, uint32(10), max)
+}
+
+func TestModule_ExportDefinitions(t *testing.T) {
+	m := &Module{
+		ImportSection: []Import{
+			{Type: ExternTypeGlobal, Module: "env", Name: "global", DescGlobal: GlobalType{ValType: ValueTypeI32}},
+		},
+		GlobalSection: []Global{{Type: GlobalType{ValType: ValueTypeI64, Mutable: true}}},
+		TableSection:  []Table{{Min: 1, Type: RefTypeFuncref}},
+		ExportSection: []Export{
+			{Type: ExternTypeGlobal, Name: "counter", Index: 1},
+			{Type: ExternTypeTable, Name: "table", Index: 0},
+			{Type: ExternTypeGlobal, Name: "also counter", Index: 1},
+		},
+		ImportGlobalCount: 1,
+	}
+
+	counter := &GlobalDefinition{
+		index:       1,
+		exportNames: []string{"counter", "also counter"},
+		globalType:  &m.GlobalSection[0].Type,
+	}
+	require.Equal(t, []api.Export{
+		&ExportDefinition{typ: ExternTypeGlobal, name: "counter", definition: counter},
+		&ExportDefinition{typ: ExternTypeTable, name: "table", definition: &TableDefinition{
+			exportNames: []string{"table"},
+			table:       &m.TableSection[0],
+		}},
+		&ExportDefinition{typ: ExternTypeGlobal, name: "also counter", definition: counter},
+	}, m.ExportDefinitions())
+}
@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/leb128"
 )
 
@@ -32,7 +34,9 @@ func (m *Module) validateFunction(sts *stacks, enabledFeatures api.CoreFeatures,
 	return m.validateFunctionWithMaxStackValues(sts, enabledFeatures, idx, functions, globals, memory, tables, maximumValuesOnStack, declaredFunctionIndexes, br)
 }
 
-func readMemArg(pc uint64, body []byte) (align, offset uint32, read uint64, err error) {
+// readMemArg reads the memarg of a memory instruction, whose offset is an u64
+// if the memory is 64-bit, or an u32 otherwise.
+func readMemArg(pc uint64, body []byte, memory *Memory) (align uint32, offset uint64, read uint64, err error) {
 	align, num, err := leb128.LoadUint32(body[pc:])
 	if err != nil {
 		err = fmt.Errorf("read memory align: %v", err)
@@ -40,7 +44,13 @@ func readMemArg(pc uint64, body []byte) (align, offset uint32, read uint64, err
 	}
 	read += num
 
-	offset, num, err = leb128.LoadUint32(body[pc+num:])
+	if memory.Is64 {
+		offset, num, err = leb128.LoadUint64(body[pc+num:])
+	} else {
+		var offset32 uint32
+		offset32, num, err = leb128.LoadUint32(body[pc+num:])
+		offset = uint64(offset32)
+	}
 	if err != nil {
 		err = fmt.Errorf("read memory offset: %v", err)
 		return
@@ -50,6 +60,23 @@ func readMemArg(pc uint64, body []byte) (align, offset uint32, read uint64, err
 	return align, offset, read, nil
 }
 
+// instructionError is an error validating the instruction at pc in a
+// function body.
+type instructionError struct {
+	pc  uint64
+	err error
+}
+
+// Error implements error.Error
+func (e *instructionError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *instructionError) Unwrap() error {
+	return e.err
+}
+
 // validateFunctionWithMaxStackValues is like validateFunction, but allows overriding maxStackValues for testing.
 //
 // * stacks is to track the state of Wasm value and control frame stacks at anypoint of execution, and reused to reduce allocation.
@@ -65,12 +92,18 @@ func (m *Module) validateFunctionWithMaxStackValues(
 	maxStackValues int,
 	declaredFunctionIndexes map[Index]struct{},
 	br *bytes.Reader,
-) error {
+) (err error) {
 	functionType := &m.TypeSection[m.FunctionSection[idx]]
 	code := &m.CodeSection[idx]
 	body := code.Body
 	localTypes := code.LocalTypes
 
+	// addressType is the type of the addresses, offsets and sizes of the memory.
+	addressType := ValueTypeI32
+	if memory != nil {
+		addressType = memory.AddressType()
+	}
+
 	sts.reset(functionType)
 	valueTypeStack := &sts.vs
 	// We start with the outermost control block which is for function return if the code branches into it.
@@ -78,7 +111,14 @@ func (m *Module) validateFunctionWithMaxStackValues(
 
 	// Now start walking through all the instructions in the body while tracking
 	// control blocks and value types to check the validity of all instructions.
-	for pc := uint64(0); pc < uint64(len(body)); pc++ {
+	var pc, start uint64
+	defer func() {
+		if err != nil {
+			err = &instructionError{pc: start, err: err}
+		}
+	}()
+	for ; pc < uint64(len(body)); pc++ {
+		start = pc
 		op := body[pc]
 		if false {
 			var instName string
@@ -86,6 +126,8 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				instName = MiscInstructionName(body[pc+1])
 			} else if op == OpcodeVecPrefix {
 				instName = VectorInstructionName(body[pc+1])
+			} else if op == OpcodeAtomicPrefix {
+				instName = AtomicInstructionName(body[pc+1])
 			} else {
 				instName = InstructionName(op)
 			}
@@ -97,7 +139,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				return fmt.Errorf("memory must exist for %s", InstructionName(op))
 			}
 			pc++
-			align, _, read, err := readMemArg(pc, body)
+			align, _, read, err := readMemArg(pc, body, memory)
 			if err != nil {
 				return err
 			}
@@ -107,7 +149,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > 32/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI32)
@@ -115,7 +157,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > 32/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeF32)
@@ -126,7 +168,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeF32Store:
@@ -136,14 +178,14 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF32); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeI64Load:
 				if 1<<align > 64/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI64)
@@ -151,7 +193,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > 64/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeF64)
@@ -162,7 +204,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeF64Store:
@@ -172,14 +214,14 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF64); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeI32Load8S:
 				if 1<<align > 1 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI32)
@@ -187,7 +229,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > 1 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI32)
@@ -195,7 +237,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > 1 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI64)
@@ -206,7 +248,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeI64Store8:
@@ -216,14 +258,14 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeI32Load16S, OpcodeI32Load16U:
 				if 1<<align > 16/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI32)
@@ -231,7 +273,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > 16/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI64)
@@ -242,7 +284,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeI64Store16:
@@ -252,14 +294,14 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			case OpcodeI64Load32S, OpcodeI64Load32U:
 				if 1<<align > 32/8 {
 					return fmt.Errorf("invalid memory alignment")
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 				valueTypeStack.push(ValueTypeI64)
@@ -270,7 +312,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
 					return err
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
 			}
@@ -288,12 +330,12 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			}
 			switch Opcode(op) {
 			case OpcodeMemoryGrow:
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return err
 				}
-				valueTypeStack.push(ValueTypeI32)
+				valueTypeStack.push(addressType)
 			case OpcodeMemorySize:
-				valueTypeStack.push(ValueTypeI32)
+				valueTypeStack.push(addressType)
 			}
 			pc += num - 1
 		} else if OpcodeI32Const <= op && op <= OpcodeF64Const {
@@ -527,7 +569,10 @@ func (m *Module) validateFunctionWithMaxStackValues(
 
 			// br_table instruction is stack-polymorphic.
 			valueTypeStack.unreachable()
-		} else if op == OpcodeCall {
+		} else if op == OpcodeCall || op == OpcodeTailCallReturnCall {
+			if op == OpcodeTailCallReturnCall && !enabledFeatures.IsEnabled(experimental.CoreFeaturesTailCall) {
+				return fmt.Errorf(`%s invalid as feature "tail-call" is disabled`, OpcodeTailCallReturnCallName)
+			}
 			pc++
 			index, num, err := leb128.LoadUint32(body[pc:])
 			if err != nil {
@@ -540,13 +585,24 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			funcType := &m.TypeSection[functions[index]]
 			for i := 0; i < len(funcType.Params); i++ {
 				if err := valueTypeStack.popAndVerifyType(funcType.Params[len(funcType.Params)-1-i]); err != nil {
-					return fmt.Errorf("type mismatch on %s operation param type: %v", OpcodeCallName, err)
+					return fmt.Errorf("type mismatch on %s operation param type: %v", InstructionName(op), err)
 				}
 			}
-			for _, exp := range funcType.Results {
-				valueTypeStack.push(exp)
+			if op == OpcodeTailCallReturnCall {
+				if err := validateTailCallResults(funcType, functionType); err != nil {
+					return err
+				}
+				// return_call instruction is stack-polymorphic, like return.
+				valueTypeStack.unreachable()
+			} else {
+				for _, exp := range funcType.Results {
+					valueTypeStack.push(exp)
+				}
+			}
+		} else if op == OpcodeCallIndirect || op == OpcodeTailCallReturnCallIndirect {
+			if op == OpcodeTailCallReturnCallIndirect && !enabledFeatures.IsEnabled(experimental.CoreFeaturesTailCall) {
+				return fmt.Errorf(`%s invalid as feature "tail-call" is disabled`, OpcodeTailCallReturnCallIndirectName)
 			}
-		} else if op == OpcodeCallIndirect {
 			pc++
 			typeIndex, num, err := leb128.LoadUint32(body[pc:])
 			if err != nil {
@@ -555,7 +611,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			pc += num
 
 			if int(typeIndex) >= len(m.TypeSection) {
-				return fmt.Errorf("invalid type index at %s: %d", OpcodeCallIndirectName, typeIndex)
+				return fmt.Errorf("invalid type index at %s: %d", InstructionName(op), typeIndex)
 			}
 
 			tableIndex, num, err := leb128.LoadUint32(body[pc:])
@@ -575,20 +631,113 @@ func (m *Module) validateFunctionWithMaxStackValues(
 
 			table := tables[tableIndex]
 			if table.Type != RefTypeFuncref {
-				return fmt.Errorf("table is not funcref type but was %s for %s", RefTypeName(table.Type), OpcodeCallIndirectName)
+				return fmt.Errorf("table is not funcref type but was %s for %s", RefTypeName(table.Type), InstructionName(op))
 			}
 
 			if err = valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
-				return fmt.Errorf("cannot pop the offset in table for %s", OpcodeCallIndirectName)
+				return fmt.Errorf("cannot pop the offset in table for %s", InstructionName(op))
 			}
 			funcType := &m.TypeSection[typeIndex]
 			for i := 0; i < len(funcType.Params); i++ {
 				if err = valueTypeStack.popAndVerifyType(funcType.Params[len(funcType.Params)-1-i]); err != nil {
-					return fmt.Errorf("type mismatch on %s operation input type", OpcodeCallIndirectName)
+					return fmt.Errorf("type mismatch on %s operation input type", InstructionName(op))
 				}
 			}
-			for _, exp := range funcType.Results {
-				valueTypeStack.push(exp)
+			if op == OpcodeTailCallReturnCallIndirect {
+				if err := validateTailCallResults(funcType, functionType); err != nil {
+					return err
+				}
+				valueTypeStack.unreachable()
+			} else {
+				for _, exp := range funcType.Results {
+					valueTypeStack.push(exp)
+				}
+			}
+		} else if op == OpcodeCallRef || op == OpcodeTailCallReturnCallRef {
+			if !enabledFeatures.IsEnabled(experimental.CoreFeaturesFunctionReferences) {
+				return fmt.Errorf(`%s invalid as feature "function-references" is disabled`, InstructionName(op))
+			} else if op == OpcodeTailCallReturnCallRef && !enabledFeatures.IsEnabled(experimental.CoreFeaturesTailCall) {
+				return fmt.Errorf(`%s invalid as feature "tail-call" is disabled`, OpcodeTailCallReturnCallRefName)
+			}
+			pc++
+			typeIndex, num, err := leb128.LoadUint32(body[pc:])
+			if err != nil {
+				return fmt.Errorf("read immediate: %v", err)
+			}
+			pc += num - 1
+
+			if int(typeIndex) >= len(m.TypeSection) {
+				return fmt.Errorf("invalid type index at %s: %d", InstructionName(op), typeIndex)
+			}
+
+			if err = valueTypeStack.popAndVerifyType(ValueTypeFuncref); err != nil {
+				return fmt.Errorf("cannot pop the function reference for %s: %v", InstructionName(op), err)
+			}
+			funcType := &m.TypeSection[typeIndex]
+			for i := 0; i < len(funcType.Params); i++ {
+				if err = valueTypeStack.popAndVerifyType(funcType.Params[len(funcType.Params)-1-i]); err != nil {
+					return fmt.Errorf("type mismatch on %s operation input type", InstructionName(op))
+				}
+			}
+			if op == OpcodeTailCallReturnCallRef {
+				if err := validateTailCallResults(funcType, functionType); err != nil {
+					return err
+				}
+				valueTypeStack.unreachable()
+			} else {
+				for _, exp := range funcType.Results {
+					valueTypeStack.push(exp)
+				}
+			}
+		} else if op == OpcodeRefAsNonNull || op == OpcodeBrOnNull || op == OpcodeBrOnNonNull {
+			if !enabledFeatures.IsEnabled(experimental.CoreFeaturesFunctionReferences) {
+				return fmt.Errorf(`%s invalid as feature "function-references" is disabled`, InstructionName(op))
+			}
+			tp, err := valueTypeStack.pop()
+			if err != nil {
+				return fmt.Errorf("cannot pop the operand for %s: %v", InstructionName(op), err)
+			} else if !isReferenceValueType(tp) && tp != valueTypeUnknown {
+				return fmt.Errorf("type mismatch: expected reference type but was %s", ValueTypeName(tp))
+			}
+
+			if op == OpcodeRefAsNonNull {
+				valueTypeStack.push(tp)
+			} else {
+				pc++
+				index, num, err := leb128.LoadUint32(body[pc:])
+				if err != nil {
+					return fmt.Errorf("read immediate: %v", err)
+				} else if int(index) >= len(controlBlockStack.stack) {
+					return fmt.Errorf(
+						"invalid ln param given for %s: index=%d with %d for the current label stack length",
+						InstructionName(op), index, len(controlBlockStack.stack))
+				}
+				pc += num - 1
+				target := &controlBlockStack.stack[len(controlBlockStack.stack)-int(index)-1]
+				var targetResultType []ValueType
+				if target.op == OpcodeLoop {
+					targetResultType = target.blockType.Params
+				} else {
+					targetResultType = target.blockType.Results
+				}
+				// br_on_non_null passes the non-null reference to the label, so the
+				// last type of the label is the one of the reference.
+				if op == OpcodeBrOnNonNull {
+					last := len(targetResultType) - 1
+					if last < 0 || (targetResultType[last] != tp && tp != valueTypeUnknown) {
+						return fmt.Errorf("type mismatch on %s: label doesn't end with %s", OpcodeBrOnNonNullName, ValueTypeName(tp))
+					}
+					targetResultType = targetResultType[:last]
+				}
+				if err := valueTypeStack.popResults(op, targetResultType, false); err != nil {
+					return err
+				}
+				for _, t := range targetResultType {
+					valueTypeStack.push(t)
+				}
+				if op == OpcodeBrOnNull {
+					valueTypeStack.push(tp)
+				}
 			}
 		} else if OpcodeI32Eqz <= op && op <= OpcodeI64Extend32S {
 			switch op {
@@ -810,14 +959,13 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			switch op {
 			case OpcodeRefNull:
 				pc++
-				switch reftype := body[pc]; reftype {
-				case ValueTypeExternref:
-					valueTypeStack.push(ValueTypeExternref)
-				case ValueTypeFuncref:
-					valueTypeStack.push(ValueTypeFuncref)
-				default:
-					return fmt.Errorf("unknown type for ref.null: 0x%x", reftype)
+				br.Reset(body[pc:])
+				reftype, num, err := DecodeHeapType(br, enabledFeatures, len(m.TypeSection))
+				if err != nil {
+					return fmt.Errorf("unknown type for ref.null: %v", err)
 				}
+				pc += num - 1
+				valueTypeStack.push(reftype)
 			case OpcodeRefIsNull:
 				tp, err := valueTypeStack.pop()
 				if err != nil {
@@ -926,7 +1074,14 @@ func (m *Module) validateFunctionWithMaxStackValues(
 					if memory == nil {
 						return fmt.Errorf("memory must exist for %s", MiscInstructionName(miscOpcode))
 					}
-					params = []ValueType{ValueTypeI32, ValueTypeI32, ValueTypeI32}
+					switch miscOpcode {
+					case OpcodeMiscMemoryInit:
+						params = []ValueType{ValueTypeI32, ValueTypeI32, addressType}
+					case OpcodeMiscMemoryCopy:
+						params = []ValueType{addressType, addressType, addressType}
+					case OpcodeMiscMemoryFill:
+						params = []ValueType{addressType, ValueTypeI32, addressType}
+					}
 
 					if miscOpcode == OpcodeMiscMemoryInit {
 						if m.DataCountSection == nil {
@@ -1115,7 +1270,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 					return fmt.Errorf("memory must exist for %s", VectorInstructionName(vecOpcode))
 				}
 				pc++
-				align, _, read, err := readMemArg(pc, body)
+				align, _, read, err := readMemArg(pc, body, memory)
 				if err != nil {
 					return err
 				}
@@ -1144,7 +1299,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if 1<<align > maxAlign {
 					return fmt.Errorf("invalid memory alignment %d for %s", align, VectorInstructionName(vecOpcode))
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", VectorInstructionName(vecOpcode), err)
 				}
 				valueTypeStack.push(ValueTypeV128)
@@ -1153,7 +1308,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 					return fmt.Errorf("memory must exist for %s", VectorInstructionName(vecOpcode))
 				}
 				pc++
-				align, _, read, err := readMemArg(pc, body)
+				align, _, read, err := readMemArg(pc, body, memory)
 				if err != nil {
 					return err
 				}
@@ -1164,7 +1319,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeV128); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", OpcodeVecV128StoreName, err)
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", OpcodeVecV128StoreName, err)
 				}
 			case OpcodeVecV128Load8Lane, OpcodeVecV128Load16Lane, OpcodeVecV128Load32Lane, OpcodeVecV128Load64Lane:
@@ -1173,7 +1328,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				}
 				attr := vecLoadLanes[vecOpcode]
 				pc++
-				align, _, read, err := readMemArg(pc, body)
+				align, _, read, err := readMemArg(pc, body, memory)
 				if err != nil {
 					return err
 				}
@@ -1191,7 +1346,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeV128); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", vectorInstructionName[vecOpcode], err)
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", vectorInstructionName[vecOpcode], err)
 				}
 				valueTypeStack.push(ValueTypeV128)
@@ -1201,7 +1356,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				}
 				attr := vecStoreLanes[vecOpcode]
 				pc++
-				align, _, read, err := readMemArg(pc, body)
+				align, _, read, err := readMemArg(pc, body, memory)
 				if err != nil {
 					return err
 				}
@@ -1219,7 +1374,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				if err := valueTypeStack.popAndVerifyType(ValueTypeV128); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", vectorInstructionName[vecOpcode], err)
 				}
-				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
+				if err := valueTypeStack.popAndVerifyType(addressType); err != nil {
 					return fmt.Errorf("cannot pop the operand for %s: %v", vectorInstructionName[vecOpcode], err)
 				}
 			case OpcodeVecI8x16ExtractLaneS,
@@ -1388,6 +1543,78 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			default:
 				return fmt.Errorf("TODO: SIMD instruction %s will be implemented in #506", vectorInstructionName[vecOpcode])
 			}
+		} else if op == OpcodeAtomicPrefix {
+			pc++
+			// Atomic instructions come with two bytes where the first byte is always OpcodeAtomicPrefix,
+			// and the second byte determines the actual instruction.
+			atomicOpcode := body[pc]
+			instName := atomicInstructionNames[atomicOpcode]
+			if instName == "" {
+				return fmt.Errorf("invalid atomic opcode: %#x", atomicOpcode)
+			} else if !enabledFeatures.IsEnabled(experimental.CoreFeaturesThreads) {
+				return fmt.Errorf(`%s invalid as feature "threads" is disabled`, instName)
+			}
+			pc++
+
+			if atomicOpcode == OpcodeAtomicFence {
+				// atomic.fence has a reserved byte instead of a memarg.
+				if body[pc] != 0x00 {
+					return fmt.Errorf("invalid reserved byte for %s: %#x != 0x00", instName, body[pc])
+				}
+				continue
+			}
+
+			if memory == nil {
+				return fmt.Errorf("memory must exist for %s", instName)
+			}
+			align, _, read, err := readMemArg(pc, body, memory)
+			if err != nil {
+				return err
+			}
+			pc += read - 1
+
+			var params, results []ValueType
+			var size uint32
+			switch {
+			case atomicOpcode == OpcodeAtomicMemoryNotify:
+				size = 4
+				params, results = []ValueType{addressType, ValueTypeI32}, []ValueType{ValueTypeI32}
+			case atomicOpcode == OpcodeAtomicMemoryWait32:
+				size = 4
+				params, results = []ValueType{addressType, ValueTypeI32, ValueTypeI64}, []ValueType{ValueTypeI32}
+			case atomicOpcode == OpcodeAtomicMemoryWait64:
+				size = 8
+				params, results = []ValueType{addressType, ValueTypeI64, ValueTypeI64}, []ValueType{ValueTypeI32}
+			case atomicOpcode <= OpcodeAtomicI64Load32U:
+				var t ValueType
+				t, size = AtomicAccess(atomicOpcode)
+				params, results = []ValueType{addressType}, []ValueType{t}
+			case atomicOpcode <= OpcodeAtomicI64Store32:
+				var t ValueType
+				t, size = AtomicAccess(atomicOpcode)
+				params = []ValueType{addressType, t}
+			case atomicOpcode < OpcodeAtomicI32RmwCmpxchg:
+				var t ValueType
+				t, size = AtomicAccess(atomicOpcode)
+				params, results = []ValueType{addressType, t}, []ValueType{t}
+			default:
+				var t ValueType
+				t, size = AtomicAccess(atomicOpcode)
+				params, results = []ValueType{addressType, t, t}, []ValueType{t}
+			}
+
+			// Unlike other memory instructions, the alignment of atomic ones must be the natural one.
+			if 1<<align != size {
+				return fmt.Errorf("invalid memory alignment")
+			}
+			for i := len(params) - 1; i >= 0; i-- {
+				if err := valueTypeStack.popAndVerifyType(params[i]); err != nil {
+					return fmt.Errorf("cannot pop the operand for %s: %v", instName, err)
+				}
+			}
+			for _, r := range results {
+				valueTypeStack.push(r)
+			}
 		} else if op == OpcodeBlock {
 			br.Reset(body[pc+1:])
 			bt, num, err := DecodeBlockType(m.TypeSection, br, enabledFeatures)
@@ -1531,9 +1758,14 @@ func (m *Module) validateFunctionWithMaxStackValues(
 					return fmt.Errorf("too many type immediates for %s", InstructionName(op))
 				}
 				pc++
-				tp := body[pc]
+				br.Reset(body[pc+1:])
+				tp, _, err := DecodeRefType(br, body[pc], enabledFeatures, len(m.TypeSection))
+				if err != nil {
+					return fmt.Errorf("invalid type for %s: %v", OpcodeTypedSelectName, err)
+				}
+				pc += uint64(len(body[pc+1:]) - br.Len())
 				if tp != ValueTypeI32 && tp != ValueTypeI64 && tp != ValueTypeF32 && tp != ValueTypeF64 &&
-					tp != api.ValueTypeExternref && tp != ValueTypeFuncref && tp != ValueTypeV128 {
+					tp != api.ValueTypeExternref && tp != ValueTypeFuncref && tp != ValueTypeV128 && tp != ValueTypeExnref {
 					return fmt.Errorf("invalid type %s for %s", ValueTypeName(tp), OpcodeTypedSelectName)
 				}
 			} else if isReferenceValueType(v1) || isReferenceValueType(v2) {
@@ -1548,10 +1780,130 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			} else {
 				valueTypeStack.push(v1)
 			}
+		} else if op == OpcodeTry || op == OpcodeTryTable || op == OpcodeCatch || op == OpcodeCatchAll ||
+			op == OpcodeDelegate || op == OpcodeThrow || op == OpcodeRethrow || op == OpcodeThrowRef {
+			if !enabledFeatures.IsEnabled(experimental.CoreFeaturesExceptionHandling) {
+				return fmt.Errorf(`%s invalid as feature "exception-handling" is disabled`, InstructionName(op))
+			}
+			m.UsesExceptionHandling = true
+			switch op {
+			case OpcodeTry, OpcodeTryTable:
+				br.Reset(body[pc+1:])
+				bt, num, err := DecodeBlockType(m.TypeSection, br, enabledFeatures)
+				if err != nil {
+					return fmt.Errorf("read block: %w", err)
+				}
+				startAt := pc
+				pc += num
+				if op == OpcodeTryTable {
+					// The labels of the catch clauses are outside the try_table.
+					read, err := m.validateTryTableCatches(body[pc+1:], controlBlockStack)
+					if err != nil {
+						return err
+					}
+					pc += read
+				}
+				controlBlockStack.push(startAt, 0, 0, bt, num, op)
+				if err = valueTypeStack.popParams(op, bt.Params, false); err != nil {
+					return err
+				}
+				// Plus we have to push any block params again.
+				for _, p := range bt.Params {
+					valueTypeStack.push(p)
+				}
+				valueTypeStack.pushStackLimit(len(bt.Params))
+			case OpcodeCatch, OpcodeCatchAll:
+				bl := &controlBlockStack.stack[len(controlBlockStack.stack)-1]
+				if bl.op != OpcodeTry || bl.caught == OpcodeCatchAll {
+					return fmt.Errorf("%s must follow try, or catch of try", InstructionName(op))
+				}
+				bl.caught = op
+				// Check the type soundness of the instructions *before* entering this catch, like else.
+				if err := valueTypeStack.popResults(OpcodeTry, bl.blockType.Results, true); err != nil {
+					return err
+				}
+				valueTypeStack.resetAtStackLimit()
+				if op == OpcodeCatch {
+					pc++
+					index, num, err := leb128.LoadUint32(body[pc:])
+					if err != nil {
+						return fmt.Errorf("read immediate: %v", err)
+					}
+					pc += num - 1
+					tagType := m.tagType(index)
+					if tagType == nil {
+						return fmt.Errorf("invalid tag index for %s: %d", OpcodeCatchName, index)
+					}
+					// The payload of the exception is pushed to the stack.
+					for _, p := range tagType.Params {
+						valueTypeStack.push(p)
+					}
+				}
+			case OpcodeDelegate:
+				bl := controlBlockStack.pop()
+				if bl.op != OpcodeTry || bl.caught != 0 {
+					return fmt.Errorf("%s must end try without catch", OpcodeDelegateName)
+				}
+				pc++
+				index, num, err := leb128.LoadUint32(body[pc:])
+				if err != nil {
+					return fmt.Errorf("read immediate: %v", err)
+				} else if int(index) >= len(controlBlockStack.stack) {
+					return fmt.Errorf("invalid %s operation: index out of range", OpcodeDelegateName)
+				}
+				pc += num - 1
+				// Otherwise, delegate ends the try like end.
+				if err := valueTypeStack.requireStackValues(false, OpcodeTryName, bl.blockType.Results, true); err != nil {
+					return err
+				}
+				valueTypeStack.resetAtStackLimit()
+				for _, exp := range bl.blockType.Results {
+					valueTypeStack.push(exp)
+				}
+				valueTypeStack.popStackLimit()
+			case OpcodeThrow:
+				pc++
+				index, num, err := leb128.LoadUint32(body[pc:])
+				if err != nil {
+					return fmt.Errorf("read immediate: %v", err)
+				}
+				pc += num - 1
+				tagType := m.tagType(index)
+				if tagType == nil {
+					return fmt.Errorf("invalid tag index for %s: %d", OpcodeThrowName, index)
+				}
+				for i := len(tagType.Params) - 1; i >= 0; i-- {
+					if err := valueTypeStack.popAndVerifyType(tagType.Params[i]); err != nil {
+						return fmt.Errorf("type mismatch on %s operation param type: %v", OpcodeThrowName, err)
+					}
+				}
+				// throw instruction is stack-polymorphic.
+				valueTypeStack.unreachable()
+			case OpcodeRethrow:
+				pc++
+				index, num, err := leb128.LoadUint32(body[pc:])
+				if err != nil {
+					return fmt.Errorf("read immediate: %v", err)
+				} else if int(index) >= len(controlBlockStack.stack) {
+					return fmt.Errorf("invalid %s operation: index out of range", OpcodeRethrowName)
+				}
+				pc += num - 1
+				if target := &controlBlockStack.stack[len(controlBlockStack.stack)-int(index)-1]; target.op != OpcodeTry || target.caught == 0 {
+					return fmt.Errorf("invalid %s operation: label %d isn't a catch", OpcodeRethrowName, index)
+				}
+				valueTypeStack.unreachable()
+			case OpcodeThrowRef:
+				if err := valueTypeStack.popAndVerifyType(ValueTypeExnref); err != nil {
+					return fmt.Errorf("cannot pop the operand for %s: %v", OpcodeThrowRefName, err)
+				}
+				valueTypeStack.unreachable()
+			}
 		} else if op == OpcodeUnreachable {
 			// unreachable instruction is stack-polymorphic.
 			valueTypeStack.unreachable()
 		} else if op == OpcodeNop {
+		} else if op == OpcodeGCPrefix {
+			return fmt.Errorf("%s invalid as %w", OpcodeGCPrefixName, ErrGCUnsupported)
 		} else {
 			return fmt.Errorf("invalid instruction 0x%x", op)
 		}
@@ -1621,6 +1973,15 @@ var vecSplatValueTypes = [...]ValueType{
 	OpcodeVecF64x2Splat: ValueTypeF64,
 }
 
+// validateTailCallResults returns an error unless the callee of a tail call has the same results as the function
+// making it, as it returns them.
+func validateTailCallResults(callee, caller *FunctionType) error {
+	if !bytes.Equal(callee.Results, caller.Results) {
+		return fmt.Errorf("type mismatch on tail call: callee %s must have the results of caller %s", callee, caller)
+	}
+	return nil
+}
+
 type stacks struct {
 	vs valueTypeStack
 	cs controlBlockStack
@@ -1880,6 +2241,86 @@ type controlBlock struct {
 	blockTypeBytes         uint64
 	// op is zero when the outermost block
 	op Opcode
+	// caught is OpcodeCatch or OpcodeCatchAll after the last catch clause of
+	// an OpcodeTry, or zero in its body.
+	caught Opcode
+}
+
+// validateTryTableCatches validates the catch clauses of a try_table at the start of `body`, whose labels are relative
+// to `controlBlockStack`, and returns the number of bytes they span.
+func (m *Module) validateTryTableCatches(body []byte, controlBlockStack *controlBlockStack) (uint64, error) {
+	n, read, err := leb128.LoadUint32(body)
+	if err != nil {
+		return 0, fmt.Errorf("read the number of catch clauses: %v", err)
+	}
+	for i := uint32(0); i < n; i++ {
+		if read >= uint64(len(body)) {
+			return 0, fmt.Errorf("read catch clause %d: %v", i, io.ErrUnexpectedEOF)
+		}
+		kind := body[read]
+		read++
+
+		var payload []ValueType
+		switch kind {
+		case CatchKindCatch, CatchKindCatchRef:
+			index, num, err := leb128.LoadUint32(body[read:])
+			if err != nil {
+				return 0, fmt.Errorf("read tag index of catch clause %d: %v", i, err)
+			}
+			read += num
+			tagType := m.tagType(index)
+			if tagType == nil {
+				return 0, fmt.Errorf("invalid tag index for catch clause %d: %d", i, index)
+			}
+			payload = tagType.Params
+		case CatchKindCatchAll, CatchKindCatchAllRef:
+		default:
+			return 0, fmt.Errorf("invalid kind of catch clause %d: 0x%x", i, kind)
+		}
+		if kind == CatchKindCatchRef || kind == CatchKindCatchAllRef {
+			payload = append(payload[:len(payload):len(payload)], ValueTypeExnref)
+		}
+
+		index, num, err := leb128.LoadUint32(body[read:])
+		if err != nil {
+			return 0, fmt.Errorf("read label of catch clause %d: %v", i, err)
+		} else if int(index) >= len(controlBlockStack.stack) {
+			return 0, fmt.Errorf("invalid label of catch clause %d: index out of range", i)
+		}
+		read += num
+
+		target := &controlBlockStack.stack[len(controlBlockStack.stack)-int(index)-1]
+		targetTypes := target.blockType.Results
+		if target.op == OpcodeLoop {
+			targetTypes = target.blockType.Params
+		}
+		if !bytes.Equal(targetTypes, payload) {
+			var ret strings.Builder
+			fmt.Fprintf(&ret, "type mismatch on catch clause %d of %s\n\thave (", i, OpcodeTryTableName)
+			writeValueTypes(payload, &ret)
+			ret.WriteString(")\n\twant (")
+			writeValueTypes(targetTypes, &ret)
+			ret.WriteByte(')')
+			return 0, errors.New(ret.String())
+		}
+	}
+	return read, nil
+}
+
+// tagType returns the type of the tag at `index`, or nil if it doesn't exist.
+func (m *Module) tagType(index Index) *FunctionType {
+	if index < m.ImportTagCount {
+		for i := range m.ImportSection {
+			if imp := &m.ImportSection[i]; imp.Type == ExternTypeTag && imp.IndexPerType == index {
+				return &m.TypeSection[imp.DescTag]
+			}
+		}
+		return nil
+	}
+	if index -= m.ImportTagCount; index < uint32(len(m.TagSection)) {
+		return &m.TypeSection[m.TagSection[index]]
+	}
+	return nil
 }
 
 // DecodeBlockType decodes the type index from a positive 33-bit signed integer. Negative numbers indicate up to one
@@ -1912,6 +2353,28 @@ func DecodeBlockType(types []FunctionType, r *bytes.Reader, enabledFeatures api.
 		ret = blockType_v_funcref
 	case -17: // 0x6f in original byte = externref
 		ret = blockType_v_externref
+	case -23: // 0x69 in original byte = exnref
+		if !enabledFeatures.IsEnabled(experimental.CoreFeaturesExceptionHandling) {
+			return nil, num, errors.New(`block with exnref result invalid as feature "exception-handling" is disabled`)
+		}
+		ret = blockType_v_exnref
+	case -29, -28: // 0x63 and 0x64 in original byte = typed reference
+		if !enabledFeatures.IsEnabled(experimental.CoreFeaturesFunctionReferences) {
+			return nil, num, errors.New(`block with typed reference result invalid as feature "function-references" is disabled`)
+		}
+		vt, n, err := DecodeHeapType(r, enabledFeatures, len(types))
+		if err != nil {
+			return nil, 0, err
+		}
+		num += n
+		switch vt {
+		case ValueTypeFuncref:
+			ret = blockType_v_funcref
+		case ValueTypeExternref:
+			ret = blockType_v_externref
+		default:
+			ret = blockType_v_exnref
+		}
 	default:
 		if err = enabledFeatures.RequireEnabled(api.CoreFeatureMultiValue); err != nil {
 			return nil, num, fmt.Errorf("block with function type return invalid as %v", err)
@@ -1934,6 +2397,7 @@ var (
 	blockType_v_v128      = &FunctionType{Results: []ValueType{ValueTypeV128}, ResultNumInUint64: 2}
 	blockType_v_funcref   = &FunctionType{Results: []ValueType{ValueTypeFuncref}, ResultNumInUint64: 1}
 	blockType_v_externref = &FunctionType{Results: []ValueType{ValueTypeExternref}, ResultNumInUint64: 1}
+	blockType_v_exnref    = &FunctionType{Results: []ValueType{ValueTypeExnref}, ResultNumInUint64: 1}
 )
 
 // SplitCallStack returns the input stack resliced to the count of params and
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 )
@@ -689,6 +690,7 @@ var (
 	v_i32                               = initFt(nil, []ValueType{i32})
 	v_i32i32                            = initFt(nil, []ValueType{i32, i32})
 	v_i32i64                            = initFt(nil, []ValueType{i32, i64})
+	v_i64                               = initFt(nil, []ValueType{i64})
 	v_i64i64                            = initFt(nil, []ValueType{i64, i64})
 )
 
@@ -2697,6 +2699,425 @@ func TestModule_funcValidation_Select_error(t *testing.T) {
 	}
 }
 
+func TestModule_funcValidation_Memory64(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		expectedErr string
+	}{
+		{
+			name: "load and store",
+			body: []byte{
+				OpcodeI64Const, 0, OpcodeI64Const, 0, OpcodeI64Load, 0x3, 0x80, 0x80, 0x80, 0x80, 0x10, // offset 1<<32
+				OpcodeI64Store, 0x3, 0x0,
+				OpcodeEnd,
+			},
+		},
+		{
+			name: "size and grow",
+			body: []byte{
+				OpcodeMemorySize, 0, OpcodeMemoryGrow, 0, OpcodeDrop,
+				OpcodeEnd,
+			},
+		},
+		{
+			name: "fill and copy",
+			body: []byte{
+				OpcodeI64Const, 0, OpcodeI32Const, 0, OpcodeI64Const, 0, OpcodeMiscPrefix, OpcodeMiscMemoryFill, 0,
+				OpcodeI64Const, 0, OpcodeI64Const, 0, OpcodeI64Const, 0, OpcodeMiscPrefix, OpcodeMiscMemoryCopy, 0, 0,
+				OpcodeEnd,
+			},
+		},
+		{
+			name: "i32 address",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeI32Load, 0x2, 0x0, OpcodeDrop,
+				OpcodeEnd,
+			},
+			expectedErr: "type mismatch: expected i64, but was i32",
+		},
+		{
+			name: "i32 delta",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeMemoryGrow, 0, OpcodeDrop,
+				OpcodeEnd,
+			},
+			expectedErr: "type mismatch: expected i64, but was i32",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Module{
+				TypeSection:     []FunctionType{v_v},
+				FunctionSection: []Index{0},
+				CodeSection:     []Code{{Body: tc.body}},
+			}
+			err := m.validateFunction(&stacks{}, api.CoreFeaturesV2,
+				0, []Index{0}, nil, &Memory{Min: 1, Max: 1, Is64: true}, nil, nil, bytes.NewReader(nil))
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestModule_funcValidation_Threads(t *testing.T) {
+	tests := []struct {
+		name        string
+		features    api.CoreFeatures
+		noMemory    bool
+		body        []byte
+		expectedErr string
+	}{
+		{
+			name: "atomics",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeAtomicPrefix, OpcodeAtomicI64Load, 0x3, 0x8, OpcodeDrop,
+				OpcodeI32Const, 0, OpcodeI32Const, 1, OpcodeAtomicPrefix, OpcodeAtomicI32Store8, 0x0, 0x0,
+				OpcodeI32Const, 0, OpcodeI64Const, 1, OpcodeAtomicPrefix, OpcodeAtomicI64Rmw16AddU, 0x1, 0x0, OpcodeDrop,
+				OpcodeI32Const, 0, OpcodeI32Const, 1, OpcodeI32Const, 2, OpcodeAtomicPrefix, OpcodeAtomicI32RmwCmpxchg, 0x2, 0x0, OpcodeDrop,
+				OpcodeI32Const, 0, OpcodeI64Const, 1, OpcodeI64Const, 0, OpcodeAtomicPrefix, OpcodeAtomicMemoryWait64, 0x3, 0x0, OpcodeDrop,
+				OpcodeI32Const, 0, OpcodeI32Const, 1, OpcodeAtomicPrefix, OpcodeAtomicMemoryNotify, 0x2, 0x0, OpcodeDrop,
+				OpcodeAtomicPrefix, OpcodeAtomicFence, 0x0,
+				OpcodeEnd,
+			},
+		},
+		{
+			name:     "disabled",
+			features: api.CoreFeaturesV2,
+			body: []byte{
+				OpcodeAtomicPrefix, OpcodeAtomicFence, 0x0,
+				OpcodeEnd,
+			},
+			expectedErr: `atomic.fence invalid as feature "threads" is disabled`,
+		},
+		{
+			name: "invalid opcode",
+			body: []byte{
+				OpcodeAtomicPrefix, 0x4f,
+				OpcodeEnd,
+			},
+			expectedErr: "invalid atomic opcode: 0x4f",
+		},
+		{
+			name: "fence reserved byte",
+			body: []byte{
+				OpcodeAtomicPrefix, OpcodeAtomicFence, 0x1,
+				OpcodeEnd,
+			},
+			expectedErr: "invalid reserved byte for atomic.fence: 0x1 != 0x00",
+		},
+		{
+			name:     "no memory",
+			noMemory: true,
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeAtomicPrefix, OpcodeAtomicI32Load, 0x2, 0x0, OpcodeDrop,
+				OpcodeEnd,
+			},
+			expectedErr: "memory must exist for i32.atomic.load",
+		},
+		{
+			name: "unnatural alignment",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeAtomicPrefix, OpcodeAtomicI32Load, 0x1, 0x0, OpcodeDrop,
+				OpcodeEnd,
+			},
+			expectedErr: "invalid memory alignment",
+		},
+		{
+			name: "type mismatch",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeI32Const, 1, OpcodeAtomicPrefix, OpcodeAtomicI64RmwXchg, 0x3, 0x0, OpcodeDrop,
+				OpcodeEnd,
+			},
+			expectedErr: "cannot pop the operand for i64.atomic.rmw.xchg: type mismatch: expected i64, but was i32",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			features := tc.features
+			if features == 0 {
+				features = api.CoreFeaturesV2 | experimental.CoreFeaturesThreads
+			}
+			memory := &Memory{Min: 1, Max: 1, IsShared: true}
+			if tc.noMemory {
+				memory = nil
+			}
+			m := &Module{
+				TypeSection:     []FunctionType{v_v},
+				FunctionSection: []Index{0},
+				CodeSection:     []Code{{Body: tc.body}},
+			}
+			err := m.validateFunction(&stacks{}, features,
+				0, []Index{0}, nil, memory, nil, nil, bytes.NewReader(nil))
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestModule_funcValidation_TailCall(t *testing.T) {
+	tests := []struct {
+		name        string
+		features    api.CoreFeatures
+		body        []byte
+		expectedErr string
+	}{
+		{
+			name: "return_call",
+			body: []byte{
+				OpcodeLocalGet, 0, OpcodeTailCallReturnCall, 0,
+				OpcodeEnd,
+			},
+		},
+		{
+			name: "return_call_indirect",
+			body: []byte{
+				OpcodeLocalGet, 0, OpcodeI32Const, 0, OpcodeTailCallReturnCallIndirect, 0, 0,
+				OpcodeEnd,
+			},
+		},
+		{
+			name: "unreachable after",
+			body: []byte{
+				OpcodeLocalGet, 0, OpcodeTailCallReturnCall, 0,
+				OpcodeDrop, OpcodeI32Const, 0,
+				OpcodeEnd,
+			},
+		},
+		{
+			name:     "disabled",
+			features: api.CoreFeaturesV2,
+			body: []byte{
+				OpcodeLocalGet, 0, OpcodeTailCallReturnCall, 0,
+				OpcodeEnd,
+			},
+			expectedErr: `return_call invalid as feature "tail-call" is disabled`,
+		},
+		{
+			name: "results mismatch",
+			body: []byte{
+				OpcodeTailCallReturnCall, 1,
+				OpcodeEnd,
+			},
+			expectedErr: "type mismatch on tail call: callee v_i64 must have the results of caller i32_i32",
+		},
+		{
+			name: "indirect results mismatch",
+			body: []byte{
+				OpcodeI32Const, 0, OpcodeTailCallReturnCallIndirect, 1, 0,
+				OpcodeEnd,
+			},
+			expectedErr: "type mismatch on tail call: callee v_i64 must have the results of caller i32_i32",
+		},
+		{
+			name: "missing param",
+			body: []byte{
+				OpcodeTailCallReturnCall, 0,
+				OpcodeEnd,
+			},
+			expectedErr: "type mismatch on return_call operation param type: i32 missing",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			features := tc.features
+			if features == 0 {
+				features = api.CoreFeaturesV2 | experimental.CoreFeaturesTailCall
+			}
+			m := &Module{
+				TypeSection:     []FunctionType{i32_i32, v_i64},
+				FunctionSection: []Index{0, 1},
+				CodeSection:     []Code{{Body: tc.body}},
+			}
+			err := m.validateFunction(&stacks{}, features,
+				0, []Index{0, 1}, nil, nil, []Table{{Type: RefTypeFuncref}}, nil, bytes.NewReader(nil))
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestModule_funcValidation_ExceptionHandling(t *testing.T) {
+	tests := []struct {
+		name        string
+		features    api.CoreFeatures
+		body        []byte
+		expectedErr string
+	}{
+		{
+			name: "try catch",
+			body: []byte{
+				OpcodeTry, ValueTypeI32,
+				OpcodeLocalGet, 0, OpcodeThrow, 0,
+				OpcodeCatch, 0,
+				OpcodeCatchAll, OpcodeI32Const, 0,
+				OpcodeEnd,
+				OpcodeEnd,
+			},
+		},
+		{
+			name: "try_table",
+			body: []byte{
+				OpcodeBlock, ValueTypeI32,
+				OpcodeBlock, ValueTypeExnref,
+				OpcodeTryTable, 0x40, 2, CatchKindCatch, 0, 1, CatchKindCatchAllRef, 0,
+				OpcodeLocalGet, 0, OpcodeThrow, 0,
+				OpcodeEnd,
+				OpcodeUnreachable,
+				OpcodeEnd,
+				OpcodeThrowRef,
+				OpcodeEnd,
+				OpcodeEnd,
+			},
+		},
+		{
+			name: "rethrow",
+			body: []byte{
+				OpcodeTry, 0x40,
+				OpcodeCatchAll, OpcodeBlock, 0x40, OpcodeRethrow, 1, OpcodeEnd,
+				OpcodeEnd,
+				OpcodeLocalGet, 0,
+				OpcodeEnd,
+			},
+		},
+		{
+			name: "delegate",
+			body: []byte{
+				OpcodeBlock, 0x40,
+				OpcodeTry, 0x40, OpcodeDelegate, 0,
+				OpcodeEnd,
+				OpcodeLocalGet, 0,
+				OpcodeEnd,
+			},
+		},
+		{
+			name:     "disabled",
+			features: api.CoreFeaturesV2,
+			body: []byte{
+				OpcodeLocalGet, 0, OpcodeThrow, 0,
+				OpcodeEnd,
+			},
+			expectedErr: `throw invalid as feature "exception-handling" is disabled`,
+		},
+		{
+			name: "invalid tag",
+			body: []byte{
+				OpcodeLocalGet, 0, OpcodeThrow, 1,
+				OpcodeEnd,
+			},
+			expectedErr: "invalid tag index for throw: 1",
+		},
+		{
+			name: "payload mismatch",
+			body: []byte{
+				OpcodeI64Const, 0, OpcodeThrow, 0,
+				OpcodeEnd,
+			},
+			expectedErr: "type mismatch on throw operation param type: type mismatch: expected i32, but was i64",
+		},
+		{
+			name: "catch without try",
+			body: []byte{
+				OpcodeBlock, 0x40, OpcodeCatchAll, OpcodeEnd,
+				OpcodeLocalGet, 0,
+				OpcodeEnd,
+			},
+			expectedErr: "catch_all must follow try, or catch of try",
+		},
+		{
+			name: "catch after catch_all",
+			body: []byte{
+				OpcodeTry, 0x40, OpcodeCatchAll, OpcodeCatch, 0, OpcodeDrop, OpcodeEnd,
+				OpcodeLocalGet, 0,
+				OpcodeEnd,
+			},
+			expectedErr: "catch must follow try, or catch of try",
+		},
+		{
+			name: "delegate after catch",
+			body: []byte{
+				OpcodeTry, 0x40, OpcodeCatchAll, OpcodeDelegate, 0,
+				OpcodeLocalGet, 0,
+				OpcodeEnd,
+			},
+			expectedErr: "delegate must end try without catch",
+		},
+		{
+			name: "rethrow outside catch",
+			body: []byte{
+				OpcodeTry, 0x40, OpcodeRethrow, 0, OpcodeEnd,
+				OpcodeLocalGet, 0,
+				OpcodeEnd,
+			},
+			expectedErr: "invalid rethrow operation: label 0 isn't a catch",
+		},
+		{
+			name: "catch clause mismatch",
+			body: []byte{
+				OpcodeBlock, 0x40,
+				OpcodeTryTable, 0x40, 1, CatchKindCatch, 0, 0,
+				OpcodeEnd,
+				OpcodeEnd,
+				OpcodeLocalGet, 0,
+				OpcodeEnd,
+			},
+			expectedErr: "type mismatch on catch clause 0 of try_table\n\thave (i32)\n\twant ()",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			features := tc.features
+			if features == 0 {
+				features = api.CoreFeaturesV2 | experimental.CoreFeaturesExceptionHandling
+			}
+			m := &Module{
+				TypeSection:     []FunctionType{i32_i32, i32_v},
+				FunctionSection: []Index{0},
+				TagSection:      []Index{1},
+				CodeSection:     []Code{{Body: tc.body}},
+			}
+			err := m.validateFunction(&stacks{}, features,
+				0, []Index{0}, nil, nil, nil, nil, bytes.NewReader(nil))
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+				require.True(t, m.UsesExceptionHandling)
+			}
+		})
+	}
+}
+
+func TestModule_funcValidation_GC(t *testing.T) {
+	// ref.i31 of the GC proposal
+	body := []byte{OpcodeI32Const, 0, OpcodeGCPrefix, 0x1c, OpcodeDrop, OpcodeEnd}
+	m := &Module{
+		TypeSection:     []FunctionType{v_v},
+		FunctionSection: []Index{0},
+		CodeSection:     []Code{{Body: body}},
+	}
+	err := m.validateFunction(&stacks{}, api.CoreFeaturesV2, 0, []Index{0}, nil, nil, nil, nil, bytes.NewReader(nil))
+	require.EqualError(t, err, "gc_prefix invalid as the GC proposal isn't supported")
+	require.ErrorIs(t, err, ErrGCUnsupported)
+}
+
 func TestModule_funcValidation_SIMD(t *testing.T) {
 	addV128Const := func(in []byte) []byte {
 		return append(in, OpcodeVecPrefix,
@@ -3249,7 +3670,7 @@ func TestModule_funcValidation_SIMD_error(t *testing.T) {
 				OpcodeVecPrefix,
 				OpcodeVecV128i8x16Shuffle,
 			},
-			expectedErr: "16 lane indexes for v128.shuffle not found",
+			expectedErr: "16 lane indexes for i8x16.shuffle not found",
 		},
 		{
 			name: "shuffle lane index not found",
@@ -3260,7 +3681,7 @@ func TestModule_funcValidation_SIMD_error(t *testing.T) {
 				0xff, 0, 0, 0, 0, 0, 0, 0,
 				0, 0, 0, 0, 0, 0, 0, 0,
 			},
-			expectedErr: "invalid lane index[0] 255 >= 32 for v128.shuffle",
+			expectedErr: "invalid lane index[0] 255 >= 32 for i8x16.shuffle",
 		},
 	}
 
@@ -3678,3 +4099,116 @@ func Test_SplitCallStack(t *testing.T) {
 		})
 	}
 }
+
+func TestModule_funcValidation_FunctionReferences(t *testing.T) {
+	features := api.CoreFeaturesV2 | experimental.CoreFeaturesFunctionReferences
+	tests := []struct {
+		name        string
+		body        []byte
+		features    api.CoreFeatures
+		expectedErr string
+	}{
+		{
+			name:     "call_ref",
+			body:     []byte{OpcodeI32Const, 1, OpcodeRefFunc, 0, OpcodeCallRef, 0, OpcodeEnd},
+			features: features,
+		},
+		{
+			name:        "call_ref disabled",
+			body:        []byte{OpcodeI32Const, 1, OpcodeRefFunc, 0, OpcodeCallRef, 0, OpcodeEnd},
+			features:    api.CoreFeaturesV2,
+			expectedErr: `call_ref invalid as feature "function-references" is disabled`,
+		},
+		{
+			name:        "call_ref type index out of range",
+			body:        []byte{OpcodeI32Const, 1, OpcodeRefFunc, 0, OpcodeCallRef, 1, OpcodeEnd},
+			features:    features,
+			expectedErr: "invalid type index at call_ref: 1",
+		},
+		{
+			name:        "call_ref without reference",
+			body:        []byte{OpcodeI32Const, 1, OpcodeI32Const, 1, OpcodeCallRef, 0, OpcodeEnd},
+			features:    features,
+			expectedErr: "cannot pop the function reference for call_ref: type mismatch: expected funcref, but was i32",
+		},
+		{
+			name:        "return_call_ref without tail-call",
+			body:        []byte{OpcodeI32Const, 1, OpcodeRefFunc, 0, OpcodeTailCallReturnCallRef, 0, OpcodeEnd},
+			features:    features,
+			expectedErr: `return_call_ref invalid as feature "tail-call" is disabled`,
+		},
+		{
+			name:     "typed ref.null",
+			body:     []byte{OpcodeI32Const, 1, OpcodeRefNull, 0, OpcodeCallRef, 0, OpcodeEnd},
+			features: features,
+		},
+		{
+			name:        "typed ref.null out of range",
+			body:        []byte{OpcodeRefNull, 1, OpcodeDrop, OpcodeI32Const, 1, OpcodeEnd},
+			features:    features,
+			expectedErr: "unknown type for ref.null: heap type index out of range: 1",
+		},
+		{
+			name:     "ref.as_non_null",
+			body:     []byte{OpcodeRefNull, ValueTypeExternref, OpcodeRefAsNonNull, OpcodeDrop, OpcodeI32Const, 1, OpcodeEnd},
+			features: features,
+		},
+		{
+			name:        "ref.as_non_null on i32",
+			body:        []byte{OpcodeI32Const, 1, OpcodeRefAsNonNull, OpcodeEnd},
+			features:    features,
+			expectedErr: "type mismatch: expected reference type but was i32",
+		},
+		{
+			name: "br_on_null",
+			body: []byte{
+				OpcodeBlock, ValueTypeI32,
+				OpcodeI32Const, 1, OpcodeRefFunc, 0, OpcodeBrOnNull, 0, OpcodeDrop,
+				OpcodeEnd, OpcodeEnd,
+			},
+			features: features,
+		},
+		{
+			name: "br_on_non_null",
+			body: []byte{
+				OpcodeBlock, RefTypeNonNullablePrefix, 0,
+				OpcodeRefFunc, 0, OpcodeBrOnNonNull, 0, OpcodeUnreachable,
+				OpcodeEnd, OpcodeDrop, OpcodeI32Const, 1, OpcodeEnd,
+			},
+			features: features,
+		},
+		{
+			name: "br_on_non_null label without reference",
+			body: []byte{
+				OpcodeBlock, ValueTypeI32,
+				OpcodeI32Const, 1, OpcodeRefFunc, 0, OpcodeBrOnNonNull, 0,
+				OpcodeEnd, OpcodeEnd,
+			},
+			features:    features,
+			expectedErr: "type mismatch on br_on_non_null: label doesn't end with funcref",
+		},
+		{
+			name:        "typed block disabled",
+			body:        []byte{OpcodeBlock, RefTypeNullablePrefix, 0x70, OpcodeRefNull, 0x70, OpcodeEnd, OpcodeDrop, OpcodeI32Const, 1, OpcodeEnd},
+			features:    api.CoreFeaturesV2,
+			expectedErr: `read block: block with typed reference result invalid as feature "function-references" is disabled`,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Module{
+				TypeSection:     []FunctionType{i32_i32},
+				FunctionSection: []Index{0},
+				CodeSection:     []Code{{Body: tc.body}},
+			}
+			err := m.validateFunction(&stacks{}, tc.features, 0, []Index{0}, nil, nil, nil, map[Index]struct{}{0: {}}, bytes.NewReader(nil))
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
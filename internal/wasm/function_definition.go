@@ -77,6 +77,9 @@ func (m *Module) buildFunctionDefinitionsOnce() {
 		def.index = idx
 		def.Functype = &m.TypeSection[typeIndex]
 		def.goFunc = code.GoFunc
+		if len(code.LocalTypes) > 0 {
+			def.localNames = declaredLocalNames(localNames, idx, len(def.Functype.Params), len(code.LocalTypes))
+		}
 	}
 
 	n, nLen := 0, len(functionNames)
@@ -98,7 +101,13 @@ func (m *Module) buildFunctionDefinitionsOnce() {
 
 		d.moduleName = moduleName
 		d.name = funcName
-		d.Debugname = wasmdebug.FuncName(moduleName, funcName, funcIdx)
+		d.dwarfLines = m.DWARFLines
+		debugName := funcName
+		if debugName == "" && funcIdx >= importCount && m.DWARFLines != nil {
+			// The name section is often stripped while DWARF is kept.
+			debugName = m.DWARFLines.FunctionName(m.CodeSection[funcIdx-importCount].BodyOffsetInCodeSection)
+		}
+		d.Debugname = wasmdebug.FuncName(moduleName, debugName, funcIdx)
 		d.paramNames = paramNames(localNames, funcIdx, len(d.Functype.Params))
 		d.resultNames = paramNames(resultNames, funcIdx, len(d.Functype.Results))
 
@@ -125,7 +134,32 @@ type FunctionDefinition struct {
 	importDesc  *Import
 	exportNames []string
 	paramNames  []string
+	localNames  []string
 	resultNames []string
+	dwarfLines  *wasmdebug.DWARFLines
+}
+
+// declaredLocalNames returns the names of the `localLen` locals following
+// `paramLen` params of a function, or nil if none are named.
+func declaredLocalNames(localNames IndirectNameMap, funcIdx uint32, paramLen, localLen int) []string {
+	for i := range localNames {
+		nm := &localNames[i]
+		if nm.Index != funcIdx {
+			continue
+		}
+		var ret []string
+		for j := range nm.NameMap {
+			p := &nm.NameMap[j]
+			if idx := int(p.Index) - paramLen; idx >= 0 && idx < localLen {
+				if ret == nil {
+					ret = make([]string, localLen)
+				}
+				ret[idx] = p.Name
+			}
+		}
+		return ret
+	}
+	return nil
 }
 
 // ModuleName implements the same method as documented on api.FunctionDefinition.
@@ -177,6 +211,17 @@ func (f *FunctionDefinition) ParamNames() []string {
 	return f.paramNames
 }
 
+// LocalNames implements the same method as documented on api.FunctionDefinition.
+func (f *FunctionDefinition) LocalNames() []string {
+	return f.localNames
+}
+
+// DWARFLines returns the DWARF data of the module defining this function, or
+// nil if there is none.
+func (f *FunctionDefinition) DWARFLines() *wasmdebug.DWARFLines {
+	return f.dwarfLines
+}
+
 // ResultTypes implements api.FunctionDefinition ResultTypes.
 func (f *FunctionDefinition) ResultTypes() []ValueType {
 	return f.Functype.Results
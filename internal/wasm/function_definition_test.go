@@ -86,6 +86,30 @@ func TestModule_BuildFunctionDefinitions(t *testing.T) {
 			},
 			expectedExports: map[string]api.FunctionDefinition{},
 		},
+		{
+			name: "local names",
+			m: &Module{
+				TypeSection:     []FunctionType{i32_i32},
+				FunctionSection: []Index{0},
+				CodeSection:     []Code{{LocalTypes: []ValueType{ValueTypeI32, ValueTypeI64, ValueTypeI32}, Body: []byte{OpcodeEnd}}},
+				NameSection: &NameSection{
+					LocalNames: IndirectNameMap{{Index: Index(0), NameMap: NameMap{
+						{Index: Index(0), Name: "x"},
+						{Index: Index(1), Name: "i"},
+						{Index: Index(3), Name: "n"},
+					}}},
+				},
+			},
+			expected: []FunctionDefinition{
+				{
+					Debugname:  ".$0",
+					Functype:   &i32_i32,
+					paramNames: []string{"x"},
+					localNames: []string{"i", "", "n"},
+				},
+			},
+			expectedExports: map[string]api.FunctionDefinition{},
+		},
 		{
 			name: "without imports",
 			m: &Module{
@@ -9,6 +9,7 @@ import (
 	"reflect"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
 )
 
 type paramsKind byte
@@ -38,7 +39,7 @@ type reflectGoModuleFunction struct {
 
 // Call implements the same method as documented on api.GoModuleFunction.
 func (f *reflectGoModuleFunction) Call(ctx context.Context, mod api.Module, stack []uint64) {
-	callGoFunc(ctx, mod, f.fn, stack)
+	callGoFunc(ctx, mod, nil, f.fn, stack)
 }
 
 // EqualTo is exposed for testing.
@@ -76,12 +77,58 @@ func (f *reflectGoFunction) Call(ctx context.Context, stack []uint64) {
 	if f.pk == paramsKindNoContext {
 		ctx = nil
 	}
-	callGoFunc(ctx, nil, f.fn, stack)
+	callGoFunc(ctx, nil, nil, f.fn, stack)
+}
+
+// GoMethod is a method bound to its receiver, exported by
+// wazero.HostModuleBuilder ExportMethods.
+//
+// Unlike functions passed to WithFunc, its params can be strings and byte
+// slices, which are each an offset and a length in the memory of the calling
+// module, and its params and results can be bools, which are i32.
+type GoMethod struct {
+	Func reflect.Value
+}
+
+// compile-time check to ensure reflectGoMethod implements
+// api.GoModuleFunction.
+var _ api.GoModuleFunction = (*reflectGoMethod)(nil)
+
+type reflectGoMethod struct {
+	fn              *reflect.Value
+	pk              paramsKind
+	params, results []ValueType
+}
+
+// EqualTo is exposed for testing.
+func (f *reflectGoMethod) EqualTo(that interface{}) bool {
+	if f2, ok := that.(*reflectGoMethod); !ok {
+		return false
+	} else {
+		return f.pk == f2.pk &&
+			bytes.Equal(f.params, f2.params) && bytes.Equal(f.results, f2.results)
+	}
+}
+
+// Call implements the same method as documented on api.GoModuleFunction.
+func (f *reflectGoMethod) Call(ctx context.Context, mod api.Module, stack []uint64) {
+	mem := mod.Memory()
+	switch f.pk {
+	case paramsKindNoContext:
+		callGoFunc(nil, nil, mem, f.fn, stack)
+	case paramsKindContext:
+		callGoFunc(ctx, nil, mem, f.fn, stack)
+	default:
+		callGoFunc(ctx, mod, mem, f.fn, stack)
+	}
 }
 
 // callGoFunc executes the reflective function by converting params to Go
 // types. The results of the function call are converted back to api.ValueType.
-func callGoFunc(ctx context.Context, mod api.Module, fn *reflect.Value, stack []uint64) {
+//
+// `mem` is the memory strings and byte slices are read from, if params of a
+// GoMethod.
+func callGoFunc(ctx context.Context, mod api.Module, mem api.Memory, fn *reflect.Value, stack []uint64) {
 	tp := fn.Type()
 
 	var in []reflect.Value
@@ -115,6 +162,16 @@ func callGoFunc(ctx context.Context, mod api.Module, fn *reflect.Value, stack []
 				val.SetUint(raw)
 			case reflect.Int32, reflect.Int64:
 				val.SetInt(int64(raw))
+			case reflect.Bool:
+				val.SetBool(uint32(raw) != 0)
+			case reflect.String, reflect.Slice:
+				buf := readParamBytes(mem, uint32(raw), uint32(stack[j]))
+				j++
+				if k == reflect.String {
+					val.SetString(string(buf))
+				} else {
+					val.SetBytes(buf)
+				}
 			default:
 				panic(fmt.Errorf("BUG: param[%d] has an invalid type: %v", i, k))
 			}
@@ -133,12 +190,29 @@ func callGoFunc(ctx context.Context, mod api.Module, fn *reflect.Value, stack []
 			stack[i] = ret.Uint()
 		case reflect.Int32, reflect.Int64:
 			stack[i] = uint64(ret.Int())
+		case reflect.Bool:
+			if ret.Bool() {
+				stack[i] = 1
+			} else {
+				stack[i] = 0
+			}
 		default:
 			panic(fmt.Errorf("BUG: result[%d] has an invalid type: %v", i, ret.Kind()))
 		}
 	}
 }
 
+// readParamBytes returns the view of `byteCount` bytes of `mem` at `offset`,
+// or panics if out of range, as the memory access of an instruction would.
+func readParamBytes(mem api.Memory, offset, byteCount uint32) []byte {
+	if mem != nil {
+		if buf, ok := mem.Read(offset, byteCount); ok {
+			return buf
+		}
+	}
+	panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+}
+
 func newContextVal(ctx context.Context) reflect.Value {
 	val := reflect.New(goContextType).Elem()
 	val.Set(reflect.ValueOf(ctx))
@@ -164,7 +238,11 @@ func MustParseGoReflectFuncCode(fn interface{}) Code {
 }
 
 func parseGoReflectFunc(fn interface{}) (params, results []ValueType, code Code, err error) {
+	method, isMethod := fn.(*GoMethod)
 	fnV := reflect.ValueOf(fn)
+	if isMethod {
+		fnV = method.Func
+	}
 	p := fnV.Type()
 
 	if fnV.Kind() != reflect.Func {
@@ -187,15 +265,19 @@ func parseGoReflectFunc(fn interface{}) (params, results []ValueType, code Code,
 		pOffset = 2
 	}
 
-	pCount := p.NumIn() - pOffset
-	if pCount > 0 {
-		params = make([]ValueType, pCount)
-	}
-	for i := 0; i < len(params); i++ {
-		pI := p.In(i + pOffset)
+	for i := pOffset; i < p.NumIn(); i++ {
+		pI := p.In(i)
 		if t, ok := getTypeOf(pI.Kind()); ok {
-			params[i] = t
+			params = append(params, t)
 			continue
+		} else if isMethod {
+			if k := pI.Kind(); k == reflect.Bool {
+				params = append(params, ValueTypeI32)
+				continue
+			} else if k == reflect.String || (k == reflect.Slice && pI.Elem().Kind() == reflect.Uint8) {
+				params = append(params, ValueTypeI32, ValueTypeI32) // offset and length
+				continue
+			}
 		}
 
 		// Now, we will definitely err, decide which message is best
@@ -207,9 +289,9 @@ func parseGoReflectFunc(fn interface{}) (params, results []ValueType, code Code,
 		}
 
 		if arg0Type != nil {
-			err = fmt.Errorf("param[%d] is a %s, which may be defined only once as param[0]", i+pOffset, arg0Type)
+			err = fmt.Errorf("param[%d] is a %s, which may be defined only once as param[0]", i, arg0Type)
 		} else {
-			err = fmt.Errorf("param[%d] is unsupported: %s", i+pOffset, pI.Kind())
+			err = fmt.Errorf("param[%d] is unsupported: %s", i, pI.Kind())
 		}
 		return
 	}
@@ -223,6 +305,9 @@ func parseGoReflectFunc(fn interface{}) (params, results []ValueType, code Code,
 		if t, ok := getTypeOf(rI.Kind()); ok {
 			results[i] = t
 			continue
+		} else if isMethod && rI.Kind() == reflect.Bool {
+			results[i] = ValueTypeI32
+			continue
 		}
 
 		// Now, we will definitely err, decide which message is best
@@ -235,7 +320,9 @@ func parseGoReflectFunc(fn interface{}) (params, results []ValueType, code Code,
 	}
 
 	code = Code{}
-	if pk == paramsKindContextModule {
+	if isMethod {
+		code.GoFunc = &reflectGoMethod{pk: pk, fn: &fnV, params: params, results: results}
+	} else if pk == paramsKindContextModule {
 		code.GoFunc = &reflectGoModuleFunction{fn: &fnV, params: params, results: results}
 	} else {
 		code.GoFunc = &reflectGoFunction{pk: pk, fn: &fnV, params: params, results: results}
@@ -3,6 +3,7 @@ package wasm
 import (
 	"context"
 	"math"
+	"reflect"
 	"testing"
 	"unsafe"
 
@@ -243,3 +244,27 @@ func Test_callGoFunc(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseGoReflectFunc_GoMethod(t *testing.T) {
+	var got []interface{}
+	method := &GoMethod{Func: reflect.ValueOf(func(s string, b []byte, x uint32, ok bool) bool {
+		got = append(got, s, b, x, ok)
+		return !ok
+	})}
+
+	params, results, code, err := parseGoReflectFunc(method)
+	require.NoError(t, err)
+	require.Equal(t, []ValueType{ValueTypeI32, ValueTypeI32, ValueTypeI32, ValueTypeI32, ValueTypeI32, ValueTypeI32}, params)
+	require.Equal(t, []ValueType{ValueTypeI32}, results)
+
+	mem := &MemoryInstance{Buffer: []byte("wazero"), Min: 1}
+	inst := &ModuleInstance{MemoryInstance: mem}
+	stack := []uint64{0, 2, 2, 4, 42, 1}
+	code.GoFunc.(api.GoModuleFunction).Call(testCtx, inst, stack)
+	require.Equal(t, []interface{}{"wa", []byte("zero"), uint32(42), true}, got)
+	require.Equal(t, uint64(0), stack[0])
+
+	stack = []uint64{4, 3, 0, 0, 0, 0}
+	err = require.CapturePanic(func() { code.GoFunc.(api.GoModuleFunction).Call(testCtx, inst, stack) })
+	require.EqualError(t, err, "out of bounds memory access")
+}
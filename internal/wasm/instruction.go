@@ -20,6 +20,23 @@ const (
 	// OpcodeElse brackets a sequence of instructions enclosed by an OpcodeIf. A branch instruction on a then label
 	// breaks out to after the OpcodeEnd on the enclosing OpcodeIf.
 	OpcodeElse Opcode = 0x05
+
+	// OpcodeTry, OpcodeCatch, OpcodeThrow, OpcodeRethrow, OpcodeThrowRef, OpcodeDelegate, OpcodeCatchAll and
+	// OpcodeTryTable are part of the exception handling proposal, and enabled by
+	// experimental.CoreFeaturesExceptionHandling. OpcodeTry, OpcodeCatch, OpcodeRethrow, OpcodeDelegate and
+	// OpcodeCatchAll are from its legacy version, which toolchains still emit, while OpcodeTryTable and
+	// OpcodeThrowRef replace them in its current version.
+	//
+	// See https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/Exceptions.md
+	OpcodeTry      Opcode = 0x06
+	OpcodeCatch    Opcode = 0x07
+	OpcodeThrow    Opcode = 0x08
+	OpcodeRethrow  Opcode = 0x09
+	OpcodeThrowRef Opcode = 0x0a
+	OpcodeDelegate Opcode = 0x18
+	OpcodeCatchAll Opcode = 0x19
+	OpcodeTryTable Opcode = 0x1f
+
 	// OpcodeEnd terminates a control instruction OpcodeBlock, OpcodeLoop or OpcodeIf.
 	OpcodeEnd Opcode = 0x0b
 
@@ -42,6 +59,22 @@ const (
 	OpcodeCall         Opcode = 0x10
 	OpcodeCallIndirect Opcode = 0x11
 
+	// OpcodeTailCallReturnCall and OpcodeTailCallReturnCallIndirect are like OpcodeCall and OpcodeCallIndirect, except
+	// they return the results of the callee, which reuses the frame of the caller. These are part of the tail call
+	// proposal and enabled by experimental.CoreFeaturesTailCall.
+	//
+	// See https://github.com/WebAssembly/tail-call/blob/main/proposals/tail-call/Overview.md
+	OpcodeTailCallReturnCall         Opcode = 0x12
+	OpcodeTailCallReturnCallIndirect Opcode = 0x13
+
+	// OpcodeCallRef calls the function reference on top of the stack, whose type is the immediate, and
+	// OpcodeTailCallReturnCallRef is its tail call, which also requires experimental.CoreFeaturesTailCall. These are part
+	// of the function references proposal and enabled by experimental.CoreFeaturesFunctionReferences.
+	//
+	// See https://github.com/WebAssembly/function-references/blob/main/proposals/function-references/Overview.md
+	OpcodeCallRef               Opcode = 0x14
+	OpcodeTailCallReturnCallRef Opcode = 0x15
+
 	// parametric instructions
 
 	OpcodeDrop        Opcode = 0x1a
@@ -250,6 +283,13 @@ const (
 	// Currently, this is only supported in the constant expression in element segments.
 	OpcodeRefFunc = 0xd2
 
+	// OpcodeRefAsNonNull traps if the reference value on top of the stack is null, and OpcodeBrOnNull and
+	// OpcodeBrOnNonNull branch depending on whether it is null. These are part of the function references proposal and
+	// enabled by experimental.CoreFeaturesFunctionReferences.
+	OpcodeRefAsNonNull Opcode = 0xd4
+	OpcodeBrOnNull     Opcode = 0xd5
+	OpcodeBrOnNonNull  Opcode = 0xd6
+
 	// Below are toggled with CoreFeatureSignExtensionOps
 
 	// OpcodeI32Extend8S extends a signed 8-bit integer to a 32-bit integer.
@@ -272,6 +312,10 @@ const (
 	// Note: This is dependent on the flag CoreFeatureSignExtensionOps
 	OpcodeI64Extend32S Opcode = 0xc4
 
+	// OpcodeGCPrefix is the prefix of the instructions of the GC proposal,
+	// which isn't supported. See ErrGCUnsupported.
+	OpcodeGCPrefix Opcode = 0xfb
+
 	// OpcodeMiscPrefix is the prefix of various multi-byte opcodes.
 	// Introduced in CoreFeatureNonTrappingFloatToIntConversion, but used in other
 	// features, such as CoreFeatureBulkMemoryOperations.
@@ -280,6 +324,10 @@ const (
 	// OpcodeVecPrefix is the prefix of all vector isntructions introduced in
 	// CoreFeatureSIMD.
 	OpcodeVecPrefix Opcode = 0xfd
+
+	// OpcodeAtomicPrefix is the prefix of all atomic instructions introduced in
+	// experimental.CoreFeaturesThreads.
+	OpcodeAtomicPrefix Opcode = 0xfe
 )
 
 // OpcodeMisc represents opcodes of the miscellaneous operations.
@@ -623,183 +671,198 @@ const (
 )
 
 const (
-	OpcodeUnreachableName       = "unreachable"
-	OpcodeNopName               = "nop"
-	OpcodeBlockName             = "block"
-	OpcodeLoopName              = "loop"
-	OpcodeIfName                = "if"
-	OpcodeElseName              = "else"
-	OpcodeEndName               = "end"
-	OpcodeBrName                = "br"
-	OpcodeBrIfName              = "br_if"
-	OpcodeBrTableName           = "br_table"
-	OpcodeReturnName            = "return"
-	OpcodeCallName              = "call"
-	OpcodeCallIndirectName      = "call_indirect"
-	OpcodeDropName              = "drop"
-	OpcodeSelectName            = "select"
-	OpcodeTypedSelectName       = "typed_select"
-	OpcodeLocalGetName          = "local.get"
-	OpcodeLocalSetName          = "local.set"
-	OpcodeLocalTeeName          = "local.tee"
-	OpcodeGlobalGetName         = "global.get"
-	OpcodeGlobalSetName         = "global.set"
-	OpcodeI32LoadName           = "i32.load"
-	OpcodeI64LoadName           = "i64.load"
-	OpcodeF32LoadName           = "f32.load"
-	OpcodeF64LoadName           = "f64.load"
-	OpcodeI32Load8SName         = "i32.load8_s"
-	OpcodeI32Load8UName         = "i32.load8_u"
-	OpcodeI32Load16SName        = "i32.load16_s"
-	OpcodeI32Load16UName        = "i32.load16_u"
-	OpcodeI64Load8SName         = "i64.load8_s"
-	OpcodeI64Load8UName         = "i64.load8_u"
-	OpcodeI64Load16SName        = "i64.load16_s"
-	OpcodeI64Load16UName        = "i64.load16_u"
-	OpcodeI64Load32SName        = "i64.load32_s"
-	OpcodeI64Load32UName        = "i64.load32_u"
-	OpcodeI32StoreName          = "i32.store"
-	OpcodeI64StoreName          = "i64.store"
-	OpcodeF32StoreName          = "f32.store"
-	OpcodeF64StoreName          = "f64.store"
-	OpcodeI32Store8Name         = "i32.store8"
-	OpcodeI32Store16Name        = "i32.store16"
-	OpcodeI64Store8Name         = "i64.store8"
-	OpcodeI64Store16Name        = "i64.store16"
-	OpcodeI64Store32Name        = "i64.store32"
-	OpcodeMemorySizeName        = "memory.size"
-	OpcodeMemoryGrowName        = "memory.grow"
-	OpcodeI32ConstName          = "i32.const"
-	OpcodeI64ConstName          = "i64.const"
-	OpcodeF32ConstName          = "f32.const"
-	OpcodeF64ConstName          = "f64.const"
-	OpcodeI32EqzName            = "i32.eqz"
-	OpcodeI32EqName             = "i32.eq"
-	OpcodeI32NeName             = "i32.ne"
-	OpcodeI32LtSName            = "i32.lt_s"
-	OpcodeI32LtUName            = "i32.lt_u"
-	OpcodeI32GtSName            = "i32.gt_s"
-	OpcodeI32GtUName            = "i32.gt_u"
-	OpcodeI32LeSName            = "i32.le_s"
-	OpcodeI32LeUName            = "i32.le_u"
-	OpcodeI32GeSName            = "i32.ge_s"
-	OpcodeI32GeUName            = "i32.ge_u"
-	OpcodeI64EqzName            = "i64.eqz"
-	OpcodeI64EqName             = "i64.eq"
-	OpcodeI64NeName             = "i64.ne"
-	OpcodeI64LtSName            = "i64.lt_s"
-	OpcodeI64LtUName            = "i64.lt_u"
-	OpcodeI64GtSName            = "i64.gt_s"
-	OpcodeI64GtUName            = "i64.gt_u"
-	OpcodeI64LeSName            = "i64.le_s"
-	OpcodeI64LeUName            = "i64.le_u"
-	OpcodeI64GeSName            = "i64.ge_s"
-	OpcodeI64GeUName            = "i64.ge_u"
-	OpcodeF32EqName             = "f32.eq"
-	OpcodeF32NeName             = "f32.ne"
-	OpcodeF32LtName             = "f32.lt"
-	OpcodeF32GtName             = "f32.gt"
-	OpcodeF32LeName             = "f32.le"
-	OpcodeF32GeName             = "f32.ge"
-	OpcodeF64EqName             = "f64.eq"
-	OpcodeF64NeName             = "f64.ne"
-	OpcodeF64LtName             = "f64.lt"
-	OpcodeF64GtName             = "f64.gt"
-	OpcodeF64LeName             = "f64.le"
-	OpcodeF64GeName             = "f64.ge"
-	OpcodeI32ClzName            = "i32.clz"
-	OpcodeI32CtzName            = "i32.ctz"
-	OpcodeI32PopcntName         = "i32.popcnt"
-	OpcodeI32AddName            = "i32.add"
-	OpcodeI32SubName            = "i32.sub"
-	OpcodeI32MulName            = "i32.mul"
-	OpcodeI32DivSName           = "i32.div_s"
-	OpcodeI32DivUName           = "i32.div_u"
-	OpcodeI32RemSName           = "i32.rem_s"
-	OpcodeI32RemUName           = "i32.rem_u"
-	OpcodeI32AndName            = "i32.and"
-	OpcodeI32OrName             = "i32.or"
-	OpcodeI32XorName            = "i32.xor"
-	OpcodeI32ShlName            = "i32.shl"
-	OpcodeI32ShrSName           = "i32.shr_s"
-	OpcodeI32ShrUName           = "i32.shr_u"
-	OpcodeI32RotlName           = "i32.rotl"
-	OpcodeI32RotrName           = "i32.rotr"
-	OpcodeI64ClzName            = "i64.clz"
-	OpcodeI64CtzName            = "i64.ctz"
-	OpcodeI64PopcntName         = "i64.popcnt"
-	OpcodeI64AddName            = "i64.add"
-	OpcodeI64SubName            = "i64.sub"
-	OpcodeI64MulName            = "i64.mul"
-	OpcodeI64DivSName           = "i64.div_s"
-	OpcodeI64DivUName           = "i64.div_u"
-	OpcodeI64RemSName           = "i64.rem_s"
-	OpcodeI64RemUName           = "i64.rem_u"
-	OpcodeI64AndName            = "i64.and"
-	OpcodeI64OrName             = "i64.or"
-	OpcodeI64XorName            = "i64.xor"
-	OpcodeI64ShlName            = "i64.shl"
-	OpcodeI64ShrSName           = "i64.shr_s"
-	OpcodeI64ShrUName           = "i64.shr_u"
-	OpcodeI64RotlName           = "i64.rotl"
-	OpcodeI64RotrName           = "i64.rotr"
-	OpcodeF32AbsName            = "f32.abs"
-	OpcodeF32NegName            = "f32.neg"
-	OpcodeF32CeilName           = "f32.ceil"
-	OpcodeF32FloorName          = "f32.floor"
-	OpcodeF32TruncName          = "f32.trunc"
-	OpcodeF32NearestName        = "f32.nearest"
-	OpcodeF32SqrtName           = "f32.sqrt"
-	OpcodeF32AddName            = "f32.add"
-	OpcodeF32SubName            = "f32.sub"
-	OpcodeF32MulName            = "f32.mul"
-	OpcodeF32DivName            = "f32.div"
-	OpcodeF32MinName            = "f32.min"
-	OpcodeF32MaxName            = "f32.max"
-	OpcodeF32CopysignName       = "f32.copysign"
-	OpcodeF64AbsName            = "f64.abs"
-	OpcodeF64NegName            = "f64.neg"
-	OpcodeF64CeilName           = "f64.ceil"
-	OpcodeF64FloorName          = "f64.floor"
-	OpcodeF64TruncName          = "f64.trunc"
-	OpcodeF64NearestName        = "f64.nearest"
-	OpcodeF64SqrtName           = "f64.sqrt"
-	OpcodeF64AddName            = "f64.add"
-	OpcodeF64SubName            = "f64.sub"
-	OpcodeF64MulName            = "f64.mul"
-	OpcodeF64DivName            = "f64.div"
-	OpcodeF64MinName            = "f64.min"
-	OpcodeF64MaxName            = "f64.max"
-	OpcodeF64CopysignName       = "f64.copysign"
-	OpcodeI32WrapI64Name        = "i32.wrap_i64"
-	OpcodeI32TruncF32SName      = "i32.trunc_f32_s"
-	OpcodeI32TruncF32UName      = "i32.trunc_f32_u"
-	OpcodeI32TruncF64SName      = "i32.trunc_f64_s"
-	OpcodeI32TruncF64UName      = "i32.trunc_f64_u"
-	OpcodeI64ExtendI32SName     = "i64.extend_i32_s"
-	OpcodeI64ExtendI32UName     = "i64.extend_i32_u"
-	OpcodeI64TruncF32SName      = "i64.trunc_f32_s"
-	OpcodeI64TruncF32UName      = "i64.trunc_f32_u"
-	OpcodeI64TruncF64SName      = "i64.trunc_f64_s"
-	OpcodeI64TruncF64UName      = "i64.trunc_f64_u"
-	OpcodeF32ConvertI32SName    = "f32.convert_i32_s"
-	OpcodeF32ConvertI32UName    = "f32.convert_i32_u"
-	OpcodeF32ConvertI64SName    = "f32.convert_i64_s"
-	OpcodeF32ConvertI64UName    = "f32.convert_i64u"
-	OpcodeF32DemoteF64Name      = "f32.demote_f64"
-	OpcodeF64ConvertI32SName    = "f64.convert_i32_s"
-	OpcodeF64ConvertI32UName    = "f64.convert_i32_u"
-	OpcodeF64ConvertI64SName    = "f64.convert_i64_s"
-	OpcodeF64ConvertI64UName    = "f64.convert_i64_u"
-	OpcodeF64PromoteF32Name     = "f64.promote_f32"
-	OpcodeI32ReinterpretF32Name = "i32.reinterpret_f32"
-	OpcodeI64ReinterpretF64Name = "i64.reinterpret_f64"
-	OpcodeF32ReinterpretI32Name = "f32.reinterpret_i32"
-	OpcodeF64ReinterpretI64Name = "f64.reinterpret_i64"
-
-	OpcodeRefNullName   = "ref.null"
-	OpcodeRefIsNullName = "ref.is_null"
-	OpcodeRefFuncName   = "ref.func"
+	OpcodeUnreachableName                = "unreachable"
+	OpcodeNopName                        = "nop"
+	OpcodeBlockName                      = "block"
+	OpcodeLoopName                       = "loop"
+	OpcodeIfName                         = "if"
+	OpcodeElseName                       = "else"
+	OpcodeTryName                        = "try"
+	OpcodeCatchName                      = "catch"
+	OpcodeThrowName                      = "throw"
+	OpcodeRethrowName                    = "rethrow"
+	OpcodeThrowRefName                   = "throw_ref"
+	OpcodeDelegateName                   = "delegate"
+	OpcodeCatchAllName                   = "catch_all"
+	OpcodeTryTableName                   = "try_table"
+	OpcodeEndName                        = "end"
+	OpcodeBrName                         = "br"
+	OpcodeBrIfName                       = "br_if"
+	OpcodeBrTableName                    = "br_table"
+	OpcodeReturnName                     = "return"
+	OpcodeCallName                       = "call"
+	OpcodeCallIndirectName               = "call_indirect"
+	OpcodeTailCallReturnCallName         = "return_call"
+	OpcodeTailCallReturnCallIndirectName = "return_call_indirect"
+	OpcodeCallRefName                    = "call_ref"
+	OpcodeTailCallReturnCallRefName      = "return_call_ref"
+	OpcodeDropName                       = "drop"
+	OpcodeSelectName                     = "select"
+	OpcodeTypedSelectName                = "typed_select"
+	OpcodeLocalGetName                   = "local.get"
+	OpcodeLocalSetName                   = "local.set"
+	OpcodeLocalTeeName                   = "local.tee"
+	OpcodeGlobalGetName                  = "global.get"
+	OpcodeGlobalSetName                  = "global.set"
+	OpcodeI32LoadName                    = "i32.load"
+	OpcodeI64LoadName                    = "i64.load"
+	OpcodeF32LoadName                    = "f32.load"
+	OpcodeF64LoadName                    = "f64.load"
+	OpcodeI32Load8SName                  = "i32.load8_s"
+	OpcodeI32Load8UName                  = "i32.load8_u"
+	OpcodeI32Load16SName                 = "i32.load16_s"
+	OpcodeI32Load16UName                 = "i32.load16_u"
+	OpcodeI64Load8SName                  = "i64.load8_s"
+	OpcodeI64Load8UName                  = "i64.load8_u"
+	OpcodeI64Load16SName                 = "i64.load16_s"
+	OpcodeI64Load16UName                 = "i64.load16_u"
+	OpcodeI64Load32SName                 = "i64.load32_s"
+	OpcodeI64Load32UName                 = "i64.load32_u"
+	OpcodeI32StoreName                   = "i32.store"
+	OpcodeI64StoreName                   = "i64.store"
+	OpcodeF32StoreName                   = "f32.store"
+	OpcodeF64StoreName                   = "f64.store"
+	OpcodeI32Store8Name                  = "i32.store8"
+	OpcodeI32Store16Name                 = "i32.store16"
+	OpcodeI64Store8Name                  = "i64.store8"
+	OpcodeI64Store16Name                 = "i64.store16"
+	OpcodeI64Store32Name                 = "i64.store32"
+	OpcodeMemorySizeName                 = "memory.size"
+	OpcodeMemoryGrowName                 = "memory.grow"
+	OpcodeI32ConstName                   = "i32.const"
+	OpcodeI64ConstName                   = "i64.const"
+	OpcodeF32ConstName                   = "f32.const"
+	OpcodeF64ConstName                   = "f64.const"
+	OpcodeI32EqzName                     = "i32.eqz"
+	OpcodeI32EqName                      = "i32.eq"
+	OpcodeI32NeName                      = "i32.ne"
+	OpcodeI32LtSName                     = "i32.lt_s"
+	OpcodeI32LtUName                     = "i32.lt_u"
+	OpcodeI32GtSName                     = "i32.gt_s"
+	OpcodeI32GtUName                     = "i32.gt_u"
+	OpcodeI32LeSName                     = "i32.le_s"
+	OpcodeI32LeUName                     = "i32.le_u"
+	OpcodeI32GeSName                     = "i32.ge_s"
+	OpcodeI32GeUName                     = "i32.ge_u"
+	OpcodeI64EqzName                     = "i64.eqz"
+	OpcodeI64EqName                      = "i64.eq"
+	OpcodeI64NeName                      = "i64.ne"
+	OpcodeI64LtSName                     = "i64.lt_s"
+	OpcodeI64LtUName                     = "i64.lt_u"
+	OpcodeI64GtSName                     = "i64.gt_s"
+	OpcodeI64GtUName                     = "i64.gt_u"
+	OpcodeI64LeSName                     = "i64.le_s"
+	OpcodeI64LeUName                     = "i64.le_u"
+	OpcodeI64GeSName                     = "i64.ge_s"
+	OpcodeI64GeUName                     = "i64.ge_u"
+	OpcodeF32EqName                      = "f32.eq"
+	OpcodeF32NeName                      = "f32.ne"
+	OpcodeF32LtName                      = "f32.lt"
+	OpcodeF32GtName                      = "f32.gt"
+	OpcodeF32LeName                      = "f32.le"
+	OpcodeF32GeName                      = "f32.ge"
+	OpcodeF64EqName                      = "f64.eq"
+	OpcodeF64NeName                      = "f64.ne"
+	OpcodeF64LtName                      = "f64.lt"
+	OpcodeF64GtName                      = "f64.gt"
+	OpcodeF64LeName                      = "f64.le"
+	OpcodeF64GeName                      = "f64.ge"
+	OpcodeI32ClzName                     = "i32.clz"
+	OpcodeI32CtzName                     = "i32.ctz"
+	OpcodeI32PopcntName                  = "i32.popcnt"
+	OpcodeI32AddName                     = "i32.add"
+	OpcodeI32SubName                     = "i32.sub"
+	OpcodeI32MulName                     = "i32.mul"
+	OpcodeI32DivSName                    = "i32.div_s"
+	OpcodeI32DivUName                    = "i32.div_u"
+	OpcodeI32RemSName                    = "i32.rem_s"
+	OpcodeI32RemUName                    = "i32.rem_u"
+	OpcodeI32AndName                     = "i32.and"
+	OpcodeI32OrName                      = "i32.or"
+	OpcodeI32XorName                     = "i32.xor"
+	OpcodeI32ShlName                     = "i32.shl"
+	OpcodeI32ShrSName                    = "i32.shr_s"
+	OpcodeI32ShrUName                    = "i32.shr_u"
+	OpcodeI32RotlName                    = "i32.rotl"
+	OpcodeI32RotrName                    = "i32.rotr"
+	OpcodeI64ClzName                     = "i64.clz"
+	OpcodeI64CtzName                     = "i64.ctz"
+	OpcodeI64PopcntName                  = "i64.popcnt"
+	OpcodeI64AddName                     = "i64.add"
+	OpcodeI64SubName                     = "i64.sub"
+	OpcodeI64MulName                     = "i64.mul"
+	OpcodeI64DivSName                    = "i64.div_s"
+	OpcodeI64DivUName                    = "i64.div_u"
+	OpcodeI64RemSName                    = "i64.rem_s"
+	OpcodeI64RemUName                    = "i64.rem_u"
+	OpcodeI64AndName                     = "i64.and"
+	OpcodeI64OrName                      = "i64.or"
+	OpcodeI64XorName                     = "i64.xor"
+	OpcodeI64ShlName                     = "i64.shl"
+	OpcodeI64ShrSName                    = "i64.shr_s"
+	OpcodeI64ShrUName                    = "i64.shr_u"
+	OpcodeI64RotlName                    = "i64.rotl"
+	OpcodeI64RotrName                    = "i64.rotr"
+	OpcodeF32AbsName                     = "f32.abs"
+	OpcodeF32NegName                     = "f32.neg"
+	OpcodeF32CeilName                    = "f32.ceil"
+	OpcodeF32FloorName                   = "f32.floor"
+	OpcodeF32TruncName                   = "f32.trunc"
+	OpcodeF32NearestName                 = "f32.nearest"
+	OpcodeF32SqrtName                    = "f32.sqrt"
+	OpcodeF32AddName                     = "f32.add"
+	OpcodeF32SubName                     = "f32.sub"
+	OpcodeF32MulName                     = "f32.mul"
+	OpcodeF32DivName                     = "f32.div"
+	OpcodeF32MinName                     = "f32.min"
+	OpcodeF32MaxName                     = "f32.max"
+	OpcodeF32CopysignName                = "f32.copysign"
+	OpcodeF64AbsName                     = "f64.abs"
+	OpcodeF64NegName                     = "f64.neg"
+	OpcodeF64CeilName                    = "f64.ceil"
+	OpcodeF64FloorName                   = "f64.floor"
+	OpcodeF64TruncName                   = "f64.trunc"
+	OpcodeF64NearestName                 = "f64.nearest"
+	OpcodeF64SqrtName                    = "f64.sqrt"
+	OpcodeF64AddName                     = "f64.add"
+	OpcodeF64SubName                     = "f64.sub"
+	OpcodeF64MulName                     = "f64.mul"
+	OpcodeF64DivName                     = "f64.div"
+	OpcodeF64MinName                     = "f64.min"
+	OpcodeF64MaxName                     = "f64.max"
+	OpcodeF64CopysignName                = "f64.copysign"
+	OpcodeI32WrapI64Name                 = "i32.wrap_i64"
+	OpcodeI32TruncF32SName               = "i32.trunc_f32_s"
+	OpcodeI32TruncF32UName               = "i32.trunc_f32_u"
+	OpcodeI32TruncF64SName               = "i32.trunc_f64_s"
+	OpcodeI32TruncF64UName               = "i32.trunc_f64_u"
+	OpcodeI64ExtendI32SName              = "i64.extend_i32_s"
+	OpcodeI64ExtendI32UName              = "i64.extend_i32_u"
+	OpcodeI64TruncF32SName               = "i64.trunc_f32_s"
+	OpcodeI64TruncF32UName               = "i64.trunc_f32_u"
+	OpcodeI64TruncF64SName               = "i64.trunc_f64_s"
+	OpcodeI64TruncF64UName               = "i64.trunc_f64_u"
+	OpcodeF32ConvertI32SName             = "f32.convert_i32_s"
+	OpcodeF32ConvertI32UName             = "f32.convert_i32_u"
+	OpcodeF32ConvertI64SName             = "f32.convert_i64_s"
+	OpcodeF32ConvertI64UName             = "f32.convert_i64_u"
+	OpcodeF32DemoteF64Name               = "f32.demote_f64"
+	OpcodeF64ConvertI32SName             = "f64.convert_i32_s"
+	OpcodeF64ConvertI32UName             = "f64.convert_i32_u"
+	OpcodeF64ConvertI64SName             = "f64.convert_i64_s"
+	OpcodeF64ConvertI64UName             = "f64.convert_i64_u"
+	OpcodeF64PromoteF32Name              = "f64.promote_f32"
+	OpcodeI32ReinterpretF32Name          = "i32.reinterpret_f32"
+	OpcodeI64ReinterpretF64Name          = "i64.reinterpret_f64"
+	OpcodeF32ReinterpretI32Name          = "f32.reinterpret_i32"
+	OpcodeF64ReinterpretI64Name          = "f64.reinterpret_i64"
+
+	OpcodeRefNullName      = "ref.null"
+	OpcodeRefIsNullName    = "ref.is_null"
+	OpcodeRefFuncName      = "ref.func"
+	OpcodeRefAsNonNullName = "ref.as_non_null"
+	OpcodeBrOnNullName     = "br_on_null"
+	OpcodeBrOnNonNullName  = "br_on_non_null"
 
 	OpcodeTableGetName = "table.get"
 	OpcodeTableSetName = "table.set"
@@ -812,188 +875,205 @@ const (
 	OpcodeI64Extend16SName = "i64.extend16_s"
 	OpcodeI64Extend32SName = "i64.extend32_s"
 
-	OpcodeMiscPrefixName = "misc_prefix"
-	OpcodeVecPrefixName  = "vector_prefix"
+	OpcodeGCPrefixName     = "gc_prefix"
+	OpcodeMiscPrefixName   = "misc_prefix"
+	OpcodeVecPrefixName    = "vector_prefix"
+	OpcodeAtomicPrefixName = "atomic_prefix"
 )
 
 var instructionNames = [256]string{
-	OpcodeUnreachable:       OpcodeUnreachableName,
-	OpcodeNop:               OpcodeNopName,
-	OpcodeBlock:             OpcodeBlockName,
-	OpcodeLoop:              OpcodeLoopName,
-	OpcodeIf:                OpcodeIfName,
-	OpcodeElse:              OpcodeElseName,
-	OpcodeEnd:               OpcodeEndName,
-	OpcodeBr:                OpcodeBrName,
-	OpcodeBrIf:              OpcodeBrIfName,
-	OpcodeBrTable:           OpcodeBrTableName,
-	OpcodeReturn:            OpcodeReturnName,
-	OpcodeCall:              OpcodeCallName,
-	OpcodeCallIndirect:      OpcodeCallIndirectName,
-	OpcodeDrop:              OpcodeDropName,
-	OpcodeSelect:            OpcodeSelectName,
-	OpcodeTypedSelect:       OpcodeTypedSelectName,
-	OpcodeLocalGet:          OpcodeLocalGetName,
-	OpcodeLocalSet:          OpcodeLocalSetName,
-	OpcodeLocalTee:          OpcodeLocalTeeName,
-	OpcodeGlobalGet:         OpcodeGlobalGetName,
-	OpcodeGlobalSet:         OpcodeGlobalSetName,
-	OpcodeI32Load:           OpcodeI32LoadName,
-	OpcodeI64Load:           OpcodeI64LoadName,
-	OpcodeF32Load:           OpcodeF32LoadName,
-	OpcodeF64Load:           OpcodeF64LoadName,
-	OpcodeI32Load8S:         OpcodeI32Load8SName,
-	OpcodeI32Load8U:         OpcodeI32Load8UName,
-	OpcodeI32Load16S:        OpcodeI32Load16SName,
-	OpcodeI32Load16U:        OpcodeI32Load16UName,
-	OpcodeI64Load8S:         OpcodeI64Load8SName,
-	OpcodeI64Load8U:         OpcodeI64Load8UName,
-	OpcodeI64Load16S:        OpcodeI64Load16SName,
-	OpcodeI64Load16U:        OpcodeI64Load16UName,
-	OpcodeI64Load32S:        OpcodeI64Load32SName,
-	OpcodeI64Load32U:        OpcodeI64Load32UName,
-	OpcodeI32Store:          OpcodeI32StoreName,
-	OpcodeI64Store:          OpcodeI64StoreName,
-	OpcodeF32Store:          OpcodeF32StoreName,
-	OpcodeF64Store:          OpcodeF64StoreName,
-	OpcodeI32Store8:         OpcodeI32Store8Name,
-	OpcodeI32Store16:        OpcodeI32Store16Name,
-	OpcodeI64Store8:         OpcodeI64Store8Name,
-	OpcodeI64Store16:        OpcodeI64Store16Name,
-	OpcodeI64Store32:        OpcodeI64Store32Name,
-	OpcodeMemorySize:        OpcodeMemorySizeName,
-	OpcodeMemoryGrow:        OpcodeMemoryGrowName,
-	OpcodeI32Const:          OpcodeI32ConstName,
-	OpcodeI64Const:          OpcodeI64ConstName,
-	OpcodeF32Const:          OpcodeF32ConstName,
-	OpcodeF64Const:          OpcodeF64ConstName,
-	OpcodeI32Eqz:            OpcodeI32EqzName,
-	OpcodeI32Eq:             OpcodeI32EqName,
-	OpcodeI32Ne:             OpcodeI32NeName,
-	OpcodeI32LtS:            OpcodeI32LtSName,
-	OpcodeI32LtU:            OpcodeI32LtUName,
-	OpcodeI32GtS:            OpcodeI32GtSName,
-	OpcodeI32GtU:            OpcodeI32GtUName,
-	OpcodeI32LeS:            OpcodeI32LeSName,
-	OpcodeI32LeU:            OpcodeI32LeUName,
-	OpcodeI32GeS:            OpcodeI32GeSName,
-	OpcodeI32GeU:            OpcodeI32GeUName,
-	OpcodeI64Eqz:            OpcodeI64EqzName,
-	OpcodeI64Eq:             OpcodeI64EqName,
-	OpcodeI64Ne:             OpcodeI64NeName,
-	OpcodeI64LtS:            OpcodeI64LtSName,
-	OpcodeI64LtU:            OpcodeI64LtUName,
-	OpcodeI64GtS:            OpcodeI64GtSName,
-	OpcodeI64GtU:            OpcodeI64GtUName,
-	OpcodeI64LeS:            OpcodeI64LeSName,
-	OpcodeI64LeU:            OpcodeI64LeUName,
-	OpcodeI64GeS:            OpcodeI64GeSName,
-	OpcodeI64GeU:            OpcodeI64GeUName,
-	OpcodeF32Eq:             OpcodeF32EqName,
-	OpcodeF32Ne:             OpcodeF32NeName,
-	OpcodeF32Lt:             OpcodeF32LtName,
-	OpcodeF32Gt:             OpcodeF32GtName,
-	OpcodeF32Le:             OpcodeF32LeName,
-	OpcodeF32Ge:             OpcodeF32GeName,
-	OpcodeF64Eq:             OpcodeF64EqName,
-	OpcodeF64Ne:             OpcodeF64NeName,
-	OpcodeF64Lt:             OpcodeF64LtName,
-	OpcodeF64Gt:             OpcodeF64GtName,
-	OpcodeF64Le:             OpcodeF64LeName,
-	OpcodeF64Ge:             OpcodeF64GeName,
-	OpcodeI32Clz:            OpcodeI32ClzName,
-	OpcodeI32Ctz:            OpcodeI32CtzName,
-	OpcodeI32Popcnt:         OpcodeI32PopcntName,
-	OpcodeI32Add:            OpcodeI32AddName,
-	OpcodeI32Sub:            OpcodeI32SubName,
-	OpcodeI32Mul:            OpcodeI32MulName,
-	OpcodeI32DivS:           OpcodeI32DivSName,
-	OpcodeI32DivU:           OpcodeI32DivUName,
-	OpcodeI32RemS:           OpcodeI32RemSName,
-	OpcodeI32RemU:           OpcodeI32RemUName,
-	OpcodeI32And:            OpcodeI32AndName,
-	OpcodeI32Or:             OpcodeI32OrName,
-	OpcodeI32Xor:            OpcodeI32XorName,
-	OpcodeI32Shl:            OpcodeI32ShlName,
-	OpcodeI32ShrS:           OpcodeI32ShrSName,
-	OpcodeI32ShrU:           OpcodeI32ShrUName,
-	OpcodeI32Rotl:           OpcodeI32RotlName,
-	OpcodeI32Rotr:           OpcodeI32RotrName,
-	OpcodeI64Clz:            OpcodeI64ClzName,
-	OpcodeI64Ctz:            OpcodeI64CtzName,
-	OpcodeI64Popcnt:         OpcodeI64PopcntName,
-	OpcodeI64Add:            OpcodeI64AddName,
-	OpcodeI64Sub:            OpcodeI64SubName,
-	OpcodeI64Mul:            OpcodeI64MulName,
-	OpcodeI64DivS:           OpcodeI64DivSName,
-	OpcodeI64DivU:           OpcodeI64DivUName,
-	OpcodeI64RemS:           OpcodeI64RemSName,
-	OpcodeI64RemU:           OpcodeI64RemUName,
-	OpcodeI64And:            OpcodeI64AndName,
-	OpcodeI64Or:             OpcodeI64OrName,
-	OpcodeI64Xor:            OpcodeI64XorName,
-	OpcodeI64Shl:            OpcodeI64ShlName,
-	OpcodeI64ShrS:           OpcodeI64ShrSName,
-	OpcodeI64ShrU:           OpcodeI64ShrUName,
-	OpcodeI64Rotl:           OpcodeI64RotlName,
-	OpcodeI64Rotr:           OpcodeI64RotrName,
-	OpcodeF32Abs:            OpcodeF32AbsName,
-	OpcodeF32Neg:            OpcodeF32NegName,
-	OpcodeF32Ceil:           OpcodeF32CeilName,
-	OpcodeF32Floor:          OpcodeF32FloorName,
-	OpcodeF32Trunc:          OpcodeF32TruncName,
-	OpcodeF32Nearest:        OpcodeF32NearestName,
-	OpcodeF32Sqrt:           OpcodeF32SqrtName,
-	OpcodeF32Add:            OpcodeF32AddName,
-	OpcodeF32Sub:            OpcodeF32SubName,
-	OpcodeF32Mul:            OpcodeF32MulName,
-	OpcodeF32Div:            OpcodeF32DivName,
-	OpcodeF32Min:            OpcodeF32MinName,
-	OpcodeF32Max:            OpcodeF32MaxName,
-	OpcodeF32Copysign:       OpcodeF32CopysignName,
-	OpcodeF64Abs:            OpcodeF64AbsName,
-	OpcodeF64Neg:            OpcodeF64NegName,
-	OpcodeF64Ceil:           OpcodeF64CeilName,
-	OpcodeF64Floor:          OpcodeF64FloorName,
-	OpcodeF64Trunc:          OpcodeF64TruncName,
-	OpcodeF64Nearest:        OpcodeF64NearestName,
-	OpcodeF64Sqrt:           OpcodeF64SqrtName,
-	OpcodeF64Add:            OpcodeF64AddName,
-	OpcodeF64Sub:            OpcodeF64SubName,
-	OpcodeF64Mul:            OpcodeF64MulName,
-	OpcodeF64Div:            OpcodeF64DivName,
-	OpcodeF64Min:            OpcodeF64MinName,
-	OpcodeF64Max:            OpcodeF64MaxName,
-	OpcodeF64Copysign:       OpcodeF64CopysignName,
-	OpcodeI32WrapI64:        OpcodeI32WrapI64Name,
-	OpcodeI32TruncF32S:      OpcodeI32TruncF32SName,
-	OpcodeI32TruncF32U:      OpcodeI32TruncF32UName,
-	OpcodeI32TruncF64S:      OpcodeI32TruncF64SName,
-	OpcodeI32TruncF64U:      OpcodeI32TruncF64UName,
-	OpcodeI64ExtendI32S:     OpcodeI64ExtendI32SName,
-	OpcodeI64ExtendI32U:     OpcodeI64ExtendI32UName,
-	OpcodeI64TruncF32S:      OpcodeI64TruncF32SName,
-	OpcodeI64TruncF32U:      OpcodeI64TruncF32UName,
-	OpcodeI64TruncF64S:      OpcodeI64TruncF64SName,
-	OpcodeI64TruncF64U:      OpcodeI64TruncF64UName,
-	OpcodeF32ConvertI32S:    OpcodeF32ConvertI32SName,
-	OpcodeF32ConvertI32U:    OpcodeF32ConvertI32UName,
-	OpcodeF32ConvertI64S:    OpcodeF32ConvertI64SName,
-	OpcodeF32ConvertI64U:    OpcodeF32ConvertI64UName,
-	OpcodeF32DemoteF64:      OpcodeF32DemoteF64Name,
-	OpcodeF64ConvertI32S:    OpcodeF64ConvertI32SName,
-	OpcodeF64ConvertI32U:    OpcodeF64ConvertI32UName,
-	OpcodeF64ConvertI64S:    OpcodeF64ConvertI64SName,
-	OpcodeF64ConvertI64U:    OpcodeF64ConvertI64UName,
-	OpcodeF64PromoteF32:     OpcodeF64PromoteF32Name,
-	OpcodeI32ReinterpretF32: OpcodeI32ReinterpretF32Name,
-	OpcodeI64ReinterpretF64: OpcodeI64ReinterpretF64Name,
-	OpcodeF32ReinterpretI32: OpcodeF32ReinterpretI32Name,
-	OpcodeF64ReinterpretI64: OpcodeF64ReinterpretI64Name,
-
-	OpcodeRefNull:   OpcodeRefNullName,
-	OpcodeRefIsNull: OpcodeRefIsNullName,
-	OpcodeRefFunc:   OpcodeRefFuncName,
+	OpcodeUnreachable:                OpcodeUnreachableName,
+	OpcodeNop:                        OpcodeNopName,
+	OpcodeBlock:                      OpcodeBlockName,
+	OpcodeLoop:                       OpcodeLoopName,
+	OpcodeIf:                         OpcodeIfName,
+	OpcodeElse:                       OpcodeElseName,
+	OpcodeTry:                        OpcodeTryName,
+	OpcodeCatch:                      OpcodeCatchName,
+	OpcodeThrow:                      OpcodeThrowName,
+	OpcodeRethrow:                    OpcodeRethrowName,
+	OpcodeThrowRef:                   OpcodeThrowRefName,
+	OpcodeDelegate:                   OpcodeDelegateName,
+	OpcodeCatchAll:                   OpcodeCatchAllName,
+	OpcodeTryTable:                   OpcodeTryTableName,
+	OpcodeEnd:                        OpcodeEndName,
+	OpcodeBr:                         OpcodeBrName,
+	OpcodeBrIf:                       OpcodeBrIfName,
+	OpcodeBrTable:                    OpcodeBrTableName,
+	OpcodeReturn:                     OpcodeReturnName,
+	OpcodeCall:                       OpcodeCallName,
+	OpcodeCallIndirect:               OpcodeCallIndirectName,
+	OpcodeTailCallReturnCall:         OpcodeTailCallReturnCallName,
+	OpcodeTailCallReturnCallIndirect: OpcodeTailCallReturnCallIndirectName,
+	OpcodeCallRef:                    OpcodeCallRefName,
+	OpcodeTailCallReturnCallRef:      OpcodeTailCallReturnCallRefName,
+	OpcodeDrop:                       OpcodeDropName,
+	OpcodeSelect:                     OpcodeSelectName,
+	OpcodeTypedSelect:                OpcodeTypedSelectName,
+	OpcodeLocalGet:                   OpcodeLocalGetName,
+	OpcodeLocalSet:                   OpcodeLocalSetName,
+	OpcodeLocalTee:                   OpcodeLocalTeeName,
+	OpcodeGlobalGet:                  OpcodeGlobalGetName,
+	OpcodeGlobalSet:                  OpcodeGlobalSetName,
+	OpcodeI32Load:                    OpcodeI32LoadName,
+	OpcodeI64Load:                    OpcodeI64LoadName,
+	OpcodeF32Load:                    OpcodeF32LoadName,
+	OpcodeF64Load:                    OpcodeF64LoadName,
+	OpcodeI32Load8S:                  OpcodeI32Load8SName,
+	OpcodeI32Load8U:                  OpcodeI32Load8UName,
+	OpcodeI32Load16S:                 OpcodeI32Load16SName,
+	OpcodeI32Load16U:                 OpcodeI32Load16UName,
+	OpcodeI64Load8S:                  OpcodeI64Load8SName,
+	OpcodeI64Load8U:                  OpcodeI64Load8UName,
+	OpcodeI64Load16S:                 OpcodeI64Load16SName,
+	OpcodeI64Load16U:                 OpcodeI64Load16UName,
+	OpcodeI64Load32S:                 OpcodeI64Load32SName,
+	OpcodeI64Load32U:                 OpcodeI64Load32UName,
+	OpcodeI32Store:                   OpcodeI32StoreName,
+	OpcodeI64Store:                   OpcodeI64StoreName,
+	OpcodeF32Store:                   OpcodeF32StoreName,
+	OpcodeF64Store:                   OpcodeF64StoreName,
+	OpcodeI32Store8:                  OpcodeI32Store8Name,
+	OpcodeI32Store16:                 OpcodeI32Store16Name,
+	OpcodeI64Store8:                  OpcodeI64Store8Name,
+	OpcodeI64Store16:                 OpcodeI64Store16Name,
+	OpcodeI64Store32:                 OpcodeI64Store32Name,
+	OpcodeMemorySize:                 OpcodeMemorySizeName,
+	OpcodeMemoryGrow:                 OpcodeMemoryGrowName,
+	OpcodeI32Const:                   OpcodeI32ConstName,
+	OpcodeI64Const:                   OpcodeI64ConstName,
+	OpcodeF32Const:                   OpcodeF32ConstName,
+	OpcodeF64Const:                   OpcodeF64ConstName,
+	OpcodeI32Eqz:                     OpcodeI32EqzName,
+	OpcodeI32Eq:                      OpcodeI32EqName,
+	OpcodeI32Ne:                      OpcodeI32NeName,
+	OpcodeI32LtS:                     OpcodeI32LtSName,
+	OpcodeI32LtU:                     OpcodeI32LtUName,
+	OpcodeI32GtS:                     OpcodeI32GtSName,
+	OpcodeI32GtU:                     OpcodeI32GtUName,
+	OpcodeI32LeS:                     OpcodeI32LeSName,
+	OpcodeI32LeU:                     OpcodeI32LeUName,
+	OpcodeI32GeS:                     OpcodeI32GeSName,
+	OpcodeI32GeU:                     OpcodeI32GeUName,
+	OpcodeI64Eqz:                     OpcodeI64EqzName,
+	OpcodeI64Eq:                      OpcodeI64EqName,
+	OpcodeI64Ne:                      OpcodeI64NeName,
+	OpcodeI64LtS:                     OpcodeI64LtSName,
+	OpcodeI64LtU:                     OpcodeI64LtUName,
+	OpcodeI64GtS:                     OpcodeI64GtSName,
+	OpcodeI64GtU:                     OpcodeI64GtUName,
+	OpcodeI64LeS:                     OpcodeI64LeSName,
+	OpcodeI64LeU:                     OpcodeI64LeUName,
+	OpcodeI64GeS:                     OpcodeI64GeSName,
+	OpcodeI64GeU:                     OpcodeI64GeUName,
+	OpcodeF32Eq:                      OpcodeF32EqName,
+	OpcodeF32Ne:                      OpcodeF32NeName,
+	OpcodeF32Lt:                      OpcodeF32LtName,
+	OpcodeF32Gt:                      OpcodeF32GtName,
+	OpcodeF32Le:                      OpcodeF32LeName,
+	OpcodeF32Ge:                      OpcodeF32GeName,
+	OpcodeF64Eq:                      OpcodeF64EqName,
+	OpcodeF64Ne:                      OpcodeF64NeName,
+	OpcodeF64Lt:                      OpcodeF64LtName,
+	OpcodeF64Gt:                      OpcodeF64GtName,
+	OpcodeF64Le:                      OpcodeF64LeName,
+	OpcodeF64Ge:                      OpcodeF64GeName,
+	OpcodeI32Clz:                     OpcodeI32ClzName,
+	OpcodeI32Ctz:                     OpcodeI32CtzName,
+	OpcodeI32Popcnt:                  OpcodeI32PopcntName,
+	OpcodeI32Add:                     OpcodeI32AddName,
+	OpcodeI32Sub:                     OpcodeI32SubName,
+	OpcodeI32Mul:                     OpcodeI32MulName,
+	OpcodeI32DivS:                    OpcodeI32DivSName,
+	OpcodeI32DivU:                    OpcodeI32DivUName,
+	OpcodeI32RemS:                    OpcodeI32RemSName,
+	OpcodeI32RemU:                    OpcodeI32RemUName,
+	OpcodeI32And:                     OpcodeI32AndName,
+	OpcodeI32Or:                      OpcodeI32OrName,
+	OpcodeI32Xor:                     OpcodeI32XorName,
+	OpcodeI32Shl:                     OpcodeI32ShlName,
+	OpcodeI32ShrS:                    OpcodeI32ShrSName,
+	OpcodeI32ShrU:                    OpcodeI32ShrUName,
+	OpcodeI32Rotl:                    OpcodeI32RotlName,
+	OpcodeI32Rotr:                    OpcodeI32RotrName,
+	OpcodeI64Clz:                     OpcodeI64ClzName,
+	OpcodeI64Ctz:                     OpcodeI64CtzName,
+	OpcodeI64Popcnt:                  OpcodeI64PopcntName,
+	OpcodeI64Add:                     OpcodeI64AddName,
+	OpcodeI64Sub:                     OpcodeI64SubName,
+	OpcodeI64Mul:                     OpcodeI64MulName,
+	OpcodeI64DivS:                    OpcodeI64DivSName,
+	OpcodeI64DivU:                    OpcodeI64DivUName,
+	OpcodeI64RemS:                    OpcodeI64RemSName,
+	OpcodeI64RemU:                    OpcodeI64RemUName,
+	OpcodeI64And:                     OpcodeI64AndName,
+	OpcodeI64Or:                      OpcodeI64OrName,
+	OpcodeI64Xor:                     OpcodeI64XorName,
+	OpcodeI64Shl:                     OpcodeI64ShlName,
+	OpcodeI64ShrS:                    OpcodeI64ShrSName,
+	OpcodeI64ShrU:                    OpcodeI64ShrUName,
+	OpcodeI64Rotl:                    OpcodeI64RotlName,
+	OpcodeI64Rotr:                    OpcodeI64RotrName,
+	OpcodeF32Abs:                     OpcodeF32AbsName,
+	OpcodeF32Neg:                     OpcodeF32NegName,
+	OpcodeF32Ceil:                    OpcodeF32CeilName,
+	OpcodeF32Floor:                   OpcodeF32FloorName,
+	OpcodeF32Trunc:                   OpcodeF32TruncName,
+	OpcodeF32Nearest:                 OpcodeF32NearestName,
+	OpcodeF32Sqrt:                    OpcodeF32SqrtName,
+	OpcodeF32Add:                     OpcodeF32AddName,
+	OpcodeF32Sub:                     OpcodeF32SubName,
+	OpcodeF32Mul:                     OpcodeF32MulName,
+	OpcodeF32Div:                     OpcodeF32DivName,
+	OpcodeF32Min:                     OpcodeF32MinName,
+	OpcodeF32Max:                     OpcodeF32MaxName,
+	OpcodeF32Copysign:                OpcodeF32CopysignName,
+	OpcodeF64Abs:                     OpcodeF64AbsName,
+	OpcodeF64Neg:                     OpcodeF64NegName,
+	OpcodeF64Ceil:                    OpcodeF64CeilName,
+	OpcodeF64Floor:                   OpcodeF64FloorName,
+	OpcodeF64Trunc:                   OpcodeF64TruncName,
+	OpcodeF64Nearest:                 OpcodeF64NearestName,
+	OpcodeF64Sqrt:                    OpcodeF64SqrtName,
+	OpcodeF64Add:                     OpcodeF64AddName,
+	OpcodeF64Sub:                     OpcodeF64SubName,
+	OpcodeF64Mul:                     OpcodeF64MulName,
+	OpcodeF64Div:                     OpcodeF64DivName,
+	OpcodeF64Min:                     OpcodeF64MinName,
+	OpcodeF64Max:                     OpcodeF64MaxName,
+	OpcodeF64Copysign:                OpcodeF64CopysignName,
+	OpcodeI32WrapI64:                 OpcodeI32WrapI64Name,
+	OpcodeI32TruncF32S:               OpcodeI32TruncF32SName,
+	OpcodeI32TruncF32U:               OpcodeI32TruncF32UName,
+	OpcodeI32TruncF64S:               OpcodeI32TruncF64SName,
+	OpcodeI32TruncF64U:               OpcodeI32TruncF64UName,
+	OpcodeI64ExtendI32S:              OpcodeI64ExtendI32SName,
+	OpcodeI64ExtendI32U:              OpcodeI64ExtendI32UName,
+	OpcodeI64TruncF32S:               OpcodeI64TruncF32SName,
+	OpcodeI64TruncF32U:               OpcodeI64TruncF32UName,
+	OpcodeI64TruncF64S:               OpcodeI64TruncF64SName,
+	OpcodeI64TruncF64U:               OpcodeI64TruncF64UName,
+	OpcodeF32ConvertI32S:             OpcodeF32ConvertI32SName,
+	OpcodeF32ConvertI32U:             OpcodeF32ConvertI32UName,
+	OpcodeF32ConvertI64S:             OpcodeF32ConvertI64SName,
+	OpcodeF32ConvertI64U:             OpcodeF32ConvertI64UName,
+	OpcodeF32DemoteF64:               OpcodeF32DemoteF64Name,
+	OpcodeF64ConvertI32S:             OpcodeF64ConvertI32SName,
+	OpcodeF64ConvertI32U:             OpcodeF64ConvertI32UName,
+	OpcodeF64ConvertI64S:             OpcodeF64ConvertI64SName,
+	OpcodeF64ConvertI64U:             OpcodeF64ConvertI64UName,
+	OpcodeF64PromoteF32:              OpcodeF64PromoteF32Name,
+	OpcodeI32ReinterpretF32:          OpcodeI32ReinterpretF32Name,
+	OpcodeI64ReinterpretF64:          OpcodeI64ReinterpretF64Name,
+	OpcodeF32ReinterpretI32:          OpcodeF32ReinterpretI32Name,
+	OpcodeF64ReinterpretI64:          OpcodeF64ReinterpretI64Name,
+
+	OpcodeRefNull:      OpcodeRefNullName,
+	OpcodeRefIsNull:    OpcodeRefIsNullName,
+	OpcodeRefFunc:      OpcodeRefFuncName,
+	OpcodeRefAsNonNull: OpcodeRefAsNonNullName,
+	OpcodeBrOnNull:     OpcodeBrOnNullName,
+	OpcodeBrOnNonNull:  OpcodeBrOnNonNullName,
 
 	OpcodeTableGet: OpcodeTableGetName,
 	OpcodeTableSet: OpcodeTableSetName,
@@ -1006,8 +1086,10 @@ var instructionNames = [256]string{
 	OpcodeI64Extend16S: OpcodeI64Extend16SName,
 	OpcodeI64Extend32S: OpcodeI64Extend32SName,
 
-	OpcodeMiscPrefix: OpcodeMiscPrefixName,
-	OpcodeVecPrefix:  OpcodeVecPrefixName,
+	OpcodeGCPrefix:     OpcodeGCPrefixName,
+	OpcodeMiscPrefix:   OpcodeMiscPrefixName,
+	OpcodeVecPrefix:    OpcodeVecPrefixName,
+	OpcodeAtomicPrefix: OpcodeAtomicPrefixName,
 }
 
 // InstructionName returns the instruction corresponding to this binary Opcode.
@@ -1089,7 +1171,7 @@ const (
 	OpcodeVecV128Store32LaneName           = "v128.store32_lane"
 	OpcodeVecV128Store64LaneName           = "v128.store64_lane"
 	OpcodeVecV128ConstName                 = "v128.const"
-	OpcodeVecV128i8x16ShuffleName          = "v128.shuffle"
+	OpcodeVecV128i8x16ShuffleName          = "i8x16.shuffle"
 	OpcodeVecI8x16ExtractLaneSName         = "i8x16.extract_lane_s"
 	OpcodeVecI8x16ExtractLaneUName         = "i8x16.extract_lane_u"
 	OpcodeVecI8x16ReplaceLaneName          = "i8x16.replace_lane"
@@ -1143,10 +1225,10 @@ const (
 	OpcodeVecI32x4GeUName                  = "i32x4.ge_u"
 	OpcodeVecI64x2EqName                   = "i64x2.eq"
 	OpcodeVecI64x2NeName                   = "i64x2.ne"
-	OpcodeVecI64x2LtSName                  = "i64x2.lt"
-	OpcodeVecI64x2GtSName                  = "i64x2.gt"
-	OpcodeVecI64x2LeSName                  = "i64x2.le"
-	OpcodeVecI64x2GeSName                  = "i64x2.ge"
+	OpcodeVecI64x2LtSName                  = "i64x2.lt_s"
+	OpcodeVecI64x2GtSName                  = "i64x2.gt_s"
+	OpcodeVecI64x2LeSName                  = "i64x2.le_s"
+	OpcodeVecI64x2GeSName                  = "i64x2.ge_s"
 	OpcodeVecF32x4EqName                   = "f32x4.eq"
 	OpcodeVecF32x4NeName                   = "f32x4.ne"
 	OpcodeVecF32x4LtName                   = "f32x4.lt"
@@ -1180,8 +1262,8 @@ const (
 	OpcodeVecI8x16AddSatSName              = "i8x16.add_sat_s"
 	OpcodeVecI8x16AddSatUName              = "i8x16.add_sat_u"
 	OpcodeVecI8x16SubName                  = "i8x16.sub"
-	OpcodeVecI8x16SubSatSName              = "i8x16.sub_s"
-	OpcodeVecI8x16SubSatUName              = "i8x16.sub_u"
+	OpcodeVecI8x16SubSatSName              = "i8x16.sub_sat_s"
+	OpcodeVecI8x16SubSatUName              = "i8x16.sub_sat_u"
 	OpcodeVecI8x16MinSName                 = "i8x16.min_s"
 	OpcodeVecI8x16MinUName                 = "i8x16.min_u"
 	OpcodeVecI8x16MaxSName                 = "i8x16.max_s"
@@ -1547,3 +1629,241 @@ var vectorInstructionName = map[OpcodeVec]string{
 func VectorInstructionName(oc OpcodeVec) (ret string) {
 	return vectorInstructionName[oc]
 }
+
+// OpcodeAtomic represents an opcode of atomic instructions which has
+// multi-byte encoding and is prefixed by OpcodeAtomicPrefix.
+//
+// These opcodes are toggled with experimental.CoreFeaturesThreads.
+type OpcodeAtomic = byte
+
+const (
+	// Wait, notify and fence.
+
+	OpcodeAtomicMemoryNotify OpcodeAtomic = 0x00
+	OpcodeAtomicMemoryWait32 OpcodeAtomic = 0x01
+	OpcodeAtomicMemoryWait64 OpcodeAtomic = 0x02
+	OpcodeAtomicFence        OpcodeAtomic = 0x03
+
+	// Loads.
+
+	OpcodeAtomicI32Load    OpcodeAtomic = 0x10
+	OpcodeAtomicI64Load    OpcodeAtomic = 0x11
+	OpcodeAtomicI32Load8U  OpcodeAtomic = 0x12
+	OpcodeAtomicI32Load16U OpcodeAtomic = 0x13
+	OpcodeAtomicI64Load8U  OpcodeAtomic = 0x14
+	OpcodeAtomicI64Load16U OpcodeAtomic = 0x15
+	OpcodeAtomicI64Load32U OpcodeAtomic = 0x16
+
+	// Stores.
+
+	OpcodeAtomicI32Store   OpcodeAtomic = 0x17
+	OpcodeAtomicI64Store   OpcodeAtomic = 0x18
+	OpcodeAtomicI32Store8  OpcodeAtomic = 0x19
+	OpcodeAtomicI32Store16 OpcodeAtomic = 0x1a
+	OpcodeAtomicI64Store8  OpcodeAtomic = 0x1b
+	OpcodeAtomicI64Store16 OpcodeAtomic = 0x1c
+	OpcodeAtomicI64Store32 OpcodeAtomic = 0x1d
+
+	// Read-modify-write instructions, which return the previous value.
+
+	OpcodeAtomicI32RmwAdd    OpcodeAtomic = 0x1e
+	OpcodeAtomicI64RmwAdd    OpcodeAtomic = 0x1f
+	OpcodeAtomicI32Rmw8AddU  OpcodeAtomic = 0x20
+	OpcodeAtomicI32Rmw16AddU OpcodeAtomic = 0x21
+	OpcodeAtomicI64Rmw8AddU  OpcodeAtomic = 0x22
+	OpcodeAtomicI64Rmw16AddU OpcodeAtomic = 0x23
+	OpcodeAtomicI64Rmw32AddU OpcodeAtomic = 0x24
+
+	OpcodeAtomicI32RmwSub    OpcodeAtomic = 0x25
+	OpcodeAtomicI64RmwSub    OpcodeAtomic = 0x26
+	OpcodeAtomicI32Rmw8SubU  OpcodeAtomic = 0x27
+	OpcodeAtomicI32Rmw16SubU OpcodeAtomic = 0x28
+	OpcodeAtomicI64Rmw8SubU  OpcodeAtomic = 0x29
+	OpcodeAtomicI64Rmw16SubU OpcodeAtomic = 0x2a
+	OpcodeAtomicI64Rmw32SubU OpcodeAtomic = 0x2b
+
+	OpcodeAtomicI32RmwAnd    OpcodeAtomic = 0x2c
+	OpcodeAtomicI64RmwAnd    OpcodeAtomic = 0x2d
+	OpcodeAtomicI32Rmw8AndU  OpcodeAtomic = 0x2e
+	OpcodeAtomicI32Rmw16AndU OpcodeAtomic = 0x2f
+	OpcodeAtomicI64Rmw8AndU  OpcodeAtomic = 0x30
+	OpcodeAtomicI64Rmw16AndU OpcodeAtomic = 0x31
+	OpcodeAtomicI64Rmw32AndU OpcodeAtomic = 0x32
+
+	OpcodeAtomicI32RmwOr    OpcodeAtomic = 0x33
+	OpcodeAtomicI64RmwOr    OpcodeAtomic = 0x34
+	OpcodeAtomicI32Rmw8OrU  OpcodeAtomic = 0x35
+	OpcodeAtomicI32Rmw16OrU OpcodeAtomic = 0x36
+	OpcodeAtomicI64Rmw8OrU  OpcodeAtomic = 0x37
+	OpcodeAtomicI64Rmw16OrU OpcodeAtomic = 0x38
+	OpcodeAtomicI64Rmw32OrU OpcodeAtomic = 0x39
+
+	OpcodeAtomicI32RmwXor    OpcodeAtomic = 0x3a
+	OpcodeAtomicI64RmwXor    OpcodeAtomic = 0x3b
+	OpcodeAtomicI32Rmw8XorU  OpcodeAtomic = 0x3c
+	OpcodeAtomicI32Rmw16XorU OpcodeAtomic = 0x3d
+	OpcodeAtomicI64Rmw8XorU  OpcodeAtomic = 0x3e
+	OpcodeAtomicI64Rmw16XorU OpcodeAtomic = 0x3f
+	OpcodeAtomicI64Rmw32XorU OpcodeAtomic = 0x40
+
+	OpcodeAtomicI32RmwXchg    OpcodeAtomic = 0x41
+	OpcodeAtomicI64RmwXchg    OpcodeAtomic = 0x42
+	OpcodeAtomicI32Rmw8XchgU  OpcodeAtomic = 0x43
+	OpcodeAtomicI32Rmw16XchgU OpcodeAtomic = 0x44
+	OpcodeAtomicI64Rmw8XchgU  OpcodeAtomic = 0x45
+	OpcodeAtomicI64Rmw16XchgU OpcodeAtomic = 0x46
+	OpcodeAtomicI64Rmw32XchgU OpcodeAtomic = 0x47
+
+	OpcodeAtomicI32RmwCmpxchg    OpcodeAtomic = 0x48
+	OpcodeAtomicI64RmwCmpxchg    OpcodeAtomic = 0x49
+	OpcodeAtomicI32Rmw8CmpxchgU  OpcodeAtomic = 0x4a
+	OpcodeAtomicI32Rmw16CmpxchgU OpcodeAtomic = 0x4b
+	OpcodeAtomicI64Rmw8CmpxchgU  OpcodeAtomic = 0x4c
+	OpcodeAtomicI64Rmw16CmpxchgU OpcodeAtomic = 0x4d
+	OpcodeAtomicI64Rmw32CmpxchgU OpcodeAtomic = 0x4e
+)
+
+const (
+	OpcodeAtomicMemoryNotifyName     = "memory.atomic.notify"
+	OpcodeAtomicMemoryWait32Name     = "memory.atomic.wait32"
+	OpcodeAtomicMemoryWait64Name     = "memory.atomic.wait64"
+	OpcodeAtomicFenceName            = "atomic.fence"
+	OpcodeAtomicI32LoadName          = "i32.atomic.load"
+	OpcodeAtomicI64LoadName          = "i64.atomic.load"
+	OpcodeAtomicI32Load8UName        = "i32.atomic.load8_u"
+	OpcodeAtomicI32Load16UName       = "i32.atomic.load16_u"
+	OpcodeAtomicI64Load8UName        = "i64.atomic.load8_u"
+	OpcodeAtomicI64Load16UName       = "i64.atomic.load16_u"
+	OpcodeAtomicI64Load32UName       = "i64.atomic.load32_u"
+	OpcodeAtomicI32StoreName         = "i32.atomic.store"
+	OpcodeAtomicI64StoreName         = "i64.atomic.store"
+	OpcodeAtomicI32Store8Name        = "i32.atomic.store8"
+	OpcodeAtomicI32Store16Name       = "i32.atomic.store16"
+	OpcodeAtomicI64Store8Name        = "i64.atomic.store8"
+	OpcodeAtomicI64Store16Name       = "i64.atomic.store16"
+	OpcodeAtomicI64Store32Name       = "i64.atomic.store32"
+	OpcodeAtomicI32RmwAddName        = "i32.atomic.rmw.add"
+	OpcodeAtomicI64RmwAddName        = "i64.atomic.rmw.add"
+	OpcodeAtomicI32Rmw8AddUName      = "i32.atomic.rmw8.add_u"
+	OpcodeAtomicI32Rmw16AddUName     = "i32.atomic.rmw16.add_u"
+	OpcodeAtomicI64Rmw8AddUName      = "i64.atomic.rmw8.add_u"
+	OpcodeAtomicI64Rmw16AddUName     = "i64.atomic.rmw16.add_u"
+	OpcodeAtomicI64Rmw32AddUName     = "i64.atomic.rmw32.add_u"
+	OpcodeAtomicI32RmwSubName        = "i32.atomic.rmw.sub"
+	OpcodeAtomicI64RmwSubName        = "i64.atomic.rmw.sub"
+	OpcodeAtomicI32Rmw8SubUName      = "i32.atomic.rmw8.sub_u"
+	OpcodeAtomicI32Rmw16SubUName     = "i32.atomic.rmw16.sub_u"
+	OpcodeAtomicI64Rmw8SubUName      = "i64.atomic.rmw8.sub_u"
+	OpcodeAtomicI64Rmw16SubUName     = "i64.atomic.rmw16.sub_u"
+	OpcodeAtomicI64Rmw32SubUName     = "i64.atomic.rmw32.sub_u"
+	OpcodeAtomicI32RmwAndName        = "i32.atomic.rmw.and"
+	OpcodeAtomicI64RmwAndName        = "i64.atomic.rmw.and"
+	OpcodeAtomicI32Rmw8AndUName      = "i32.atomic.rmw8.and_u"
+	OpcodeAtomicI32Rmw16AndUName     = "i32.atomic.rmw16.and_u"
+	OpcodeAtomicI64Rmw8AndUName      = "i64.atomic.rmw8.and_u"
+	OpcodeAtomicI64Rmw16AndUName     = "i64.atomic.rmw16.and_u"
+	OpcodeAtomicI64Rmw32AndUName     = "i64.atomic.rmw32.and_u"
+	OpcodeAtomicI32RmwOrName         = "i32.atomic.rmw.or"
+	OpcodeAtomicI64RmwOrName         = "i64.atomic.rmw.or"
+	OpcodeAtomicI32Rmw8OrUName       = "i32.atomic.rmw8.or_u"
+	OpcodeAtomicI32Rmw16OrUName      = "i32.atomic.rmw16.or_u"
+	OpcodeAtomicI64Rmw8OrUName       = "i64.atomic.rmw8.or_u"
+	OpcodeAtomicI64Rmw16OrUName      = "i64.atomic.rmw16.or_u"
+	OpcodeAtomicI64Rmw32OrUName      = "i64.atomic.rmw32.or_u"
+	OpcodeAtomicI32RmwXorName        = "i32.atomic.rmw.xor"
+	OpcodeAtomicI64RmwXorName        = "i64.atomic.rmw.xor"
+	OpcodeAtomicI32Rmw8XorUName      = "i32.atomic.rmw8.xor_u"
+	OpcodeAtomicI32Rmw16XorUName     = "i32.atomic.rmw16.xor_u"
+	OpcodeAtomicI64Rmw8XorUName      = "i64.atomic.rmw8.xor_u"
+	OpcodeAtomicI64Rmw16XorUName     = "i64.atomic.rmw16.xor_u"
+	OpcodeAtomicI64Rmw32XorUName     = "i64.atomic.rmw32.xor_u"
+	OpcodeAtomicI32RmwXchgName       = "i32.atomic.rmw.xchg"
+	OpcodeAtomicI64RmwXchgName       = "i64.atomic.rmw.xchg"
+	OpcodeAtomicI32Rmw8XchgUName     = "i32.atomic.rmw8.xchg_u"
+	OpcodeAtomicI32Rmw16XchgUName    = "i32.atomic.rmw16.xchg_u"
+	OpcodeAtomicI64Rmw8XchgUName     = "i64.atomic.rmw8.xchg_u"
+	OpcodeAtomicI64Rmw16XchgUName    = "i64.atomic.rmw16.xchg_u"
+	OpcodeAtomicI64Rmw32XchgUName    = "i64.atomic.rmw32.xchg_u"
+	OpcodeAtomicI32RmwCmpxchgName    = "i32.atomic.rmw.cmpxchg"
+	OpcodeAtomicI64RmwCmpxchgName    = "i64.atomic.rmw.cmpxchg"
+	OpcodeAtomicI32Rmw8CmpxchgUName  = "i32.atomic.rmw8.cmpxchg_u"
+	OpcodeAtomicI32Rmw16CmpxchgUName = "i32.atomic.rmw16.cmpxchg_u"
+	OpcodeAtomicI64Rmw8CmpxchgUName  = "i64.atomic.rmw8.cmpxchg_u"
+	OpcodeAtomicI64Rmw16CmpxchgUName = "i64.atomic.rmw16.cmpxchg_u"
+	OpcodeAtomicI64Rmw32CmpxchgUName = "i64.atomic.rmw32.cmpxchg_u"
+)
+
+var atomicInstructionNames = [256]string{
+	OpcodeAtomicMemoryNotify:     OpcodeAtomicMemoryNotifyName,
+	OpcodeAtomicMemoryWait32:     OpcodeAtomicMemoryWait32Name,
+	OpcodeAtomicMemoryWait64:     OpcodeAtomicMemoryWait64Name,
+	OpcodeAtomicFence:            OpcodeAtomicFenceName,
+	OpcodeAtomicI32Load:          OpcodeAtomicI32LoadName,
+	OpcodeAtomicI64Load:          OpcodeAtomicI64LoadName,
+	OpcodeAtomicI32Load8U:        OpcodeAtomicI32Load8UName,
+	OpcodeAtomicI32Load16U:       OpcodeAtomicI32Load16UName,
+	OpcodeAtomicI64Load8U:        OpcodeAtomicI64Load8UName,
+	OpcodeAtomicI64Load16U:       OpcodeAtomicI64Load16UName,
+	OpcodeAtomicI64Load32U:       OpcodeAtomicI64Load32UName,
+	OpcodeAtomicI32Store:         OpcodeAtomicI32StoreName,
+	OpcodeAtomicI64Store:         OpcodeAtomicI64StoreName,
+	OpcodeAtomicI32Store8:        OpcodeAtomicI32Store8Name,
+	OpcodeAtomicI32Store16:       OpcodeAtomicI32Store16Name,
+	OpcodeAtomicI64Store8:        OpcodeAtomicI64Store8Name,
+	OpcodeAtomicI64Store16:       OpcodeAtomicI64Store16Name,
+	OpcodeAtomicI64Store32:       OpcodeAtomicI64Store32Name,
+	OpcodeAtomicI32RmwAdd:        OpcodeAtomicI32RmwAddName,
+	OpcodeAtomicI64RmwAdd:        OpcodeAtomicI64RmwAddName,
+	OpcodeAtomicI32Rmw8AddU:      OpcodeAtomicI32Rmw8AddUName,
+	OpcodeAtomicI32Rmw16AddU:     OpcodeAtomicI32Rmw16AddUName,
+	OpcodeAtomicI64Rmw8AddU:      OpcodeAtomicI64Rmw8AddUName,
+	OpcodeAtomicI64Rmw16AddU:     OpcodeAtomicI64Rmw16AddUName,
+	OpcodeAtomicI64Rmw32AddU:     OpcodeAtomicI64Rmw32AddUName,
+	OpcodeAtomicI32RmwSub:        OpcodeAtomicI32RmwSubName,
+	OpcodeAtomicI64RmwSub:        OpcodeAtomicI64RmwSubName,
+	OpcodeAtomicI32Rmw8SubU:      OpcodeAtomicI32Rmw8SubUName,
+	OpcodeAtomicI32Rmw16SubU:     OpcodeAtomicI32Rmw16SubUName,
+	OpcodeAtomicI64Rmw8SubU:      OpcodeAtomicI64Rmw8SubUName,
+	OpcodeAtomicI64Rmw16SubU:     OpcodeAtomicI64Rmw16SubUName,
+	OpcodeAtomicI64Rmw32SubU:     OpcodeAtomicI64Rmw32SubUName,
+	OpcodeAtomicI32RmwAnd:        OpcodeAtomicI32RmwAndName,
+	OpcodeAtomicI64RmwAnd:        OpcodeAtomicI64RmwAndName,
+	OpcodeAtomicI32Rmw8AndU:      OpcodeAtomicI32Rmw8AndUName,
+	OpcodeAtomicI32Rmw16AndU:     OpcodeAtomicI32Rmw16AndUName,
+	OpcodeAtomicI64Rmw8AndU:      OpcodeAtomicI64Rmw8AndUName,
+	OpcodeAtomicI64Rmw16AndU:     OpcodeAtomicI64Rmw16AndUName,
+	OpcodeAtomicI64Rmw32AndU:     OpcodeAtomicI64Rmw32AndUName,
+	OpcodeAtomicI32RmwOr:         OpcodeAtomicI32RmwOrName,
+	OpcodeAtomicI64RmwOr:         OpcodeAtomicI64RmwOrName,
+	OpcodeAtomicI32Rmw8OrU:       OpcodeAtomicI32Rmw8OrUName,
+	OpcodeAtomicI32Rmw16OrU:      OpcodeAtomicI32Rmw16OrUName,
+	OpcodeAtomicI64Rmw8OrU:       OpcodeAtomicI64Rmw8OrUName,
+	OpcodeAtomicI64Rmw16OrU:      OpcodeAtomicI64Rmw16OrUName,
+	OpcodeAtomicI64Rmw32OrU:      OpcodeAtomicI64Rmw32OrUName,
+	OpcodeAtomicI32RmwXor:        OpcodeAtomicI32RmwXorName,
+	OpcodeAtomicI64RmwXor:        OpcodeAtomicI64RmwXorName,
+	OpcodeAtomicI32Rmw8XorU:      OpcodeAtomicI32Rmw8XorUName,
+	OpcodeAtomicI32Rmw16XorU:     OpcodeAtomicI32Rmw16XorUName,
+	OpcodeAtomicI64Rmw8XorU:      OpcodeAtomicI64Rmw8XorUName,
+	OpcodeAtomicI64Rmw16XorU:     OpcodeAtomicI64Rmw16XorUName,
+	OpcodeAtomicI64Rmw32XorU:     OpcodeAtomicI64Rmw32XorUName,
+	OpcodeAtomicI32RmwXchg:       OpcodeAtomicI32RmwXchgName,
+	OpcodeAtomicI64RmwXchg:       OpcodeAtomicI64RmwXchgName,
+	OpcodeAtomicI32Rmw8XchgU:     OpcodeAtomicI32Rmw8XchgUName,
+	OpcodeAtomicI32Rmw16XchgU:    OpcodeAtomicI32Rmw16XchgUName,
+	OpcodeAtomicI64Rmw8XchgU:     OpcodeAtomicI64Rmw8XchgUName,
+	OpcodeAtomicI64Rmw16XchgU:    OpcodeAtomicI64Rmw16XchgUName,
+	OpcodeAtomicI64Rmw32XchgU:    OpcodeAtomicI64Rmw32XchgUName,
+	OpcodeAtomicI32RmwCmpxchg:    OpcodeAtomicI32RmwCmpxchgName,
+	OpcodeAtomicI64RmwCmpxchg:    OpcodeAtomicI64RmwCmpxchgName,
+	OpcodeAtomicI32Rmw8CmpxchgU:  OpcodeAtomicI32Rmw8CmpxchgUName,
+	OpcodeAtomicI32Rmw16CmpxchgU: OpcodeAtomicI32Rmw16CmpxchgUName,
+	OpcodeAtomicI64Rmw8CmpxchgU:  OpcodeAtomicI64Rmw8CmpxchgUName,
+	OpcodeAtomicI64Rmw16CmpxchgU: OpcodeAtomicI64Rmw16CmpxchgUName,
+	OpcodeAtomicI64Rmw32CmpxchgU: OpcodeAtomicI64Rmw32CmpxchgUName,
+}
+
+// AtomicInstructionName returns the instruction name corresponding to the atomic Opcode.
+func AtomicInstructionName(oc OpcodeAtomic) (ret string) {
+	return atomicInstructionNames[oc]
+}
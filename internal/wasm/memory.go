@@ -1,14 +1,19 @@
 package wasm
 
 import (
+	"container/list"
 	"encoding/binary"
 	"fmt"
 	"math"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"unsafe"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/internalapi"
+	"github.com/tetratelabs/wazero/internal/platform"
 )
 
 const (
@@ -36,19 +41,145 @@ type MemoryInstance struct {
 
 	Buffer        []byte
 	Min, Cap, Max uint32
+	// Is64 is true if the memory is addressed with i64. See Memory.Is64.
+	Is64 bool
+	// Shared is true if the memory can be shared between threads. See Memory.IsShared.
+	Shared bool
 	// definition is known at compile time.
 	definition api.MemoryDefinition
+
+	// mux serializes atomic instructions, and Grow if Shared.
+	mux sync.Mutex
+	// waiters are the channels of memory.atomic.wait32 and wait64 per address,
+	// guarded by mux.
+	waiters map[uint32]*list.List
+
+	// growListeners are called in order after the memory grows.
+	growListeners []*func(previousPages, pages uint32)
+	// growCheckers are called in order before the memory grows, which fails
+	// if any returns false.
+	growCheckers []*func(previousPages, pages uint32) bool
+	// limitPages, when non-zero, is the number of pages above which the
+	// memory can't grow. This can be changed concurrently with Grow.
+	limitPages atomic.Uint32
+
+	// Watchpoints are fired by engines implementing MemoryWatcher.
+	Watchpoints []*Watchpoint
+
+	// mmapped is non-nil when Buffer is the start of a reservation by
+	// platform.MmapLinearMemory, which includes guard pages after Max.
+	mmapped []byte
+	// cowImage is non-nil when Buffer is mapped copy-on-write from it, which
+	// is the case once a clone shared its pages. This is guarded by cowMux,
+	// as modules can be cloned from the same template concurrently.
+	cowImage *platform.CopyOnWriteImage
+	cowMux   sync.Mutex
+
+	// expBuffer is non-nil when Buffer is from an
+	// experimental.MemoryAllocator.
+	expBuffer experimental.LinearMemory
+
+	// owner is the module defining this memory, which is nil if not built by
+	// a module.
+	owner *ModuleInstance
+
+	// stats are updated on Grow, so that they can be read concurrently.
+	stats memoryStats
 }
 
 // NewMemoryInstance creates a new instance based on the parameters in the SectionIDMemory.
 func NewMemoryInstance(memSec *Memory) *MemoryInstance {
+	capPages := memSec.Cap
+	if memSec.IsShared {
+		// The buffer of a shared memory is never reallocated, as other threads may access it while it grows.
+		capPages = memSec.Max
+	}
 	min := MemoryPagesToBytesNum(memSec.Min)
-	capacity := MemoryPagesToBytesNum(memSec.Cap)
-	return &MemoryInstance{
+	capacity := MemoryPagesToBytesNum(capPages)
+	m := &MemoryInstance{
 		Buffer: make([]byte, min, capacity),
 		Min:    memSec.Min,
-		Cap:    memSec.Cap,
+		Cap:    capPages,
 		Max:    memSec.Max,
+		Is64:   memSec.Is64,
+		Shared: memSec.IsShared,
+	}
+	m.stats.init(m)
+	return m
+}
+
+// NewMemoryInstanceWithAllocator is like NewMemoryInstance, except the buffer
+// is from the given experimental.MemoryAllocator. This returns an error if
+// its buffer is shorter than the min of the memory.
+func NewMemoryInstanceWithAllocator(memSec *Memory, allocator experimental.MemoryAllocator) (*MemoryInstance, error) {
+	min := MemoryPagesToBytesNum(memSec.Min)
+	expBuffer := allocator.Allocate(MemoryPagesToBytesNum(memSec.Cap), MemoryPagesToBytesNum(memSec.Max))
+	buffer := expBuffer.Reallocate(min)
+	if uint64(len(buffer)) != min {
+		expBuffer.Free()
+		return nil, fmt.Errorf("memory allocator returned %d bytes, expected %d", len(buffer), min)
+	}
+	m := &MemoryInstance{
+		Buffer:    buffer,
+		Min:       memSec.Min,
+		Cap:       memoryBytesNumToPages(uint64(cap(buffer))),
+		Max:       memSec.Max,
+		Is64:      memSec.Is64,
+		expBuffer: expBuffer,
+	}
+	m.stats.init(m)
+	return m, nil
+}
+
+// MemoryGuardSize is the size in bytes of the inaccessible region following
+// a memory from NewMmapMemoryInstance, so that out of bounds accesses fault
+// instead of accessing other data.
+const MemoryGuardSize = int(MemoryPageSize)
+
+// NewMmapMemoryInstance is like NewMemoryInstance, except the address space
+// up to the max of the memory is reserved with mmap, so that Grow never
+// copies. This falls back to NewMemoryInstance when the platform doesn't
+// support mmap or the reservation fails, such as on 32-bit platforms.
+//
+// The reservation is released by the module defining the memory when it is
+// closed, see ModuleInstance.Close.
+func NewMmapMemoryInstance(memSec *Memory) *MemoryInstance {
+	reserve := int(MemoryPagesToBytesNum(memSec.Max))
+	mmapped, err := platform.MmapLinearMemory(reserve, MemoryGuardSize)
+	if err != nil {
+		return NewMemoryInstance(memSec)
+	}
+	min := int(MemoryPagesToBytesNum(memSec.Min))
+	if err = platform.CommitLinearMemory(mmapped[:min]); err != nil {
+		_ = platform.MunmapLinearMemory(mmapped)
+		return NewMemoryInstance(memSec)
+	}
+	m := &MemoryInstance{
+		Buffer:  mmapped[:min:reserve],
+		Min:     memSec.Min,
+		Cap:     memSec.Max, // so that Grow only commits.
+		Max:     memSec.Max,
+		Is64:    memSec.Is64,
+		Shared:  memSec.IsShared,
+		mmapped: mmapped,
+	}
+	m.stats.init(m)
+	return m
+}
+
+// unmap releases the reservation of a memory from NewMmapMemoryInstance and
+// its copy-on-write image, if any. The memory is empty afterwards.
+func (m *MemoryInstance) unmap() {
+	m.cowMux.Lock()
+	defer m.cowMux.Unlock()
+	if img := m.cowImage; img != nil {
+		_ = img.Close()
+		m.cowImage = nil
+	}
+	if m.mmapped != nil {
+		m.Buffer, m.Cap = nil, 0
+		_ = platform.MunmapLinearMemory(m.mmapped)
+		m.mmapped = nil
 	}
 }
 
@@ -177,6 +308,20 @@ func MemoryPagesToBytesNum(pages uint32) (bytesNum uint64) {
 
 // Grow implements the same method as documented on api.Memory.
 func (m *MemoryInstance) Grow(delta uint32) (result uint32, ok bool) {
+	if m.Shared {
+		m.mux.Lock()
+		defer m.mux.Unlock()
+	}
+
+	result, ok = m.grow(delta)
+	if delta != 0 {
+		m.stats.record(m, ok)
+	}
+	return
+}
+
+// grow implements Grow, without locking.
+func (m *MemoryInstance) grow(delta uint32) (result uint32, ok bool) {
 	currentPages := memoryBytesNumToPages(uint64(len(m.Buffer)))
 	if delta == 0 {
 		return currentPages, true
@@ -184,16 +329,86 @@ func (m *MemoryInstance) Grow(delta uint32) (result uint32, ok bool) {
 
 	// If exceeds the max of memory size, we push -1 according to the spec.
 	newPages := currentPages + delta
-	if newPages > m.Max {
+	if newPages > m.Max || newPages < currentPages { // the latter is an overflow
+		return 0, false
+	} else if limit := m.limitPages.Load(); limit != 0 && newPages > limit {
 		return 0, false
+	}
+	for _, c := range m.growCheckers {
+		if !(*c)(currentPages, newPages) {
+			return 0, false
+		}
+	}
+
+	if m.expBuffer != nil {
+		buffer := m.expBuffer.Reallocate(MemoryPagesToBytesNum(newPages))
+		if uint64(len(buffer)) != MemoryPagesToBytesNum(newPages) {
+			return 0, false
+		}
+		m.Buffer = buffer
+		m.Cap = memoryBytesNumToPages(uint64(cap(buffer)))
 	} else if newPages > m.Cap { // grow the memory.
 		m.Buffer = append(m.Buffer, make([]byte, MemoryPagesToBytesNum(delta))...)
 		m.Cap = newPages
-		return currentPages, true
 	} else { // We already have the capacity we need.
+		if m.mmapped != nil {
+			if err := platform.CommitLinearMemory(m.mmapped[len(m.Buffer):MemoryPagesToBytesNum(newPages)]); err != nil {
+				return 0, false
+			}
+		}
 		sp := (*reflect.SliceHeader)(unsafe.Pointer(&m.Buffer))
 		sp.Len = int(MemoryPagesToBytesNum(newPages))
-		return currentPages, true
+	}
+	for _, l := range m.growListeners {
+		(*l)(currentPages, newPages)
+	}
+	return currentPages, true
+}
+
+// AddGrowChecker registers a function called before the memory grows, with
+// its size in pages before and after, which fails the growth by returning
+// false. The returned function removes it.
+//
+// Note: This must not be called concurrently with Grow.
+func (m *MemoryInstance) AddGrowChecker(fn func(previousPages, pages uint32) bool) (remove func()) {
+	c := &fn
+	m.growCheckers = append(m.growCheckers, c)
+	return func() {
+		for i, other := range m.growCheckers {
+			if other == c {
+				m.growCheckers = append(m.growCheckers[:i:i], m.growCheckers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// LimitPages returns the number of pages above which the memory can't grow,
+// or zero if only limited by Max.
+func (m *MemoryInstance) LimitPages() uint32 {
+	return m.limitPages.Load()
+}
+
+// SetLimitPages sets the number of pages above which the memory can't grow,
+// or removes the limit if zero. This is safe to call concurrently with Grow.
+func (m *MemoryInstance) SetLimitPages(pages uint32) {
+	m.limitPages.Store(pages)
+}
+
+// AddGrowListener registers a function called after the memory grows, with
+// its size in pages before and after. The returned function removes it.
+//
+// Note: This must not be called concurrently with Grow.
+func (m *MemoryInstance) AddGrowListener(fn func(previousPages, pages uint32)) (remove func()) {
+	l := &fn
+	m.growListeners = append(m.growListeners, l)
+	return func() {
+		for i, other := range m.growListeners {
+			if other == l {
+				m.growListeners = append(m.growListeners[:i:i], m.growListeners[i+1:]...)
+				return
+			}
+		}
 	}
 }
 
@@ -0,0 +1,236 @@
+package wasm
+
+import (
+	"container/list"
+	"encoding/binary"
+	"time"
+
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
+)
+
+// AtomicRMWOp is the operation of an atomic read-modify-write instruction,
+// e.g. AtomicRMWOpAdd for i32.atomic.rmw.add.
+type AtomicRMWOp byte
+
+const (
+	AtomicRMWOpAdd AtomicRMWOp = iota
+	AtomicRMWOpSub
+	AtomicRMWOpAnd
+	AtomicRMWOpOr
+	AtomicRMWOpXor
+	AtomicRMWOpXchg
+)
+
+// AtomicAccess returns the type of the operands and results of the atomic
+// load, store or read-modify-write instruction `op`, and the size in bytes of
+// the memory it accesses. For example, i64.atomic.rmw16.add_u returns
+// ValueTypeI64 and 2.
+func AtomicAccess(op OpcodeAtomic) (ValueType, uint32) {
+	// Each kind of instruction has the same seven variants, in the same order.
+	switch (op - OpcodeAtomicI32Load) % 7 {
+	case 0:
+		return ValueTypeI32, 4
+	case 1:
+		return ValueTypeI64, 8
+	case 2:
+		return ValueTypeI32, 1
+	case 3:
+		return ValueTypeI32, 2
+	case 4:
+		return ValueTypeI64, 1
+	case 5:
+		return ValueTypeI64, 2
+	default:
+		return ValueTypeI64, 4
+	}
+}
+
+// AtomicRMWOpOf returns the operation of the atomic read-modify-write
+// instruction `op`, except cmpxchg.
+func AtomicRMWOpOf(op OpcodeAtomic) AtomicRMWOp {
+	return AtomicRMWOp((op - OpcodeAtomicI32RmwAdd) / 7)
+}
+
+// AtomicLoad returns the value of `size` bytes at `addr`, zero-extended.
+//
+// Like other atomic methods, this panics with
+// wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess or
+// wasmruntime.ErrRuntimeUnalignedAtomic if the access is invalid.
+func (m *MemoryInstance) AtomicLoad(addr, size uint32) uint64 {
+	b := m.lockAtomic(addr, size)
+	defer m.mux.Unlock()
+	return readAtomic(b)
+}
+
+// AtomicStore writes the `size` low bytes of `v` at `addr`.
+func (m *MemoryInstance) AtomicStore(addr, size uint32, v uint64) {
+	b := m.lockAtomic(addr, size)
+	defer m.mux.Unlock()
+	writeAtomic(b, v)
+}
+
+// AtomicRMW applies `op` to the value of `size` bytes at `addr` and `v`, and
+// returns the previous value, zero-extended.
+func (m *MemoryInstance) AtomicRMW(op AtomicRMWOp, addr, size uint32, v uint64) (old uint64) {
+	b := m.lockAtomic(addr, size)
+	defer m.mux.Unlock()
+	old = readAtomic(b)
+	switch op {
+	case AtomicRMWOpAdd:
+		v = old + v
+	case AtomicRMWOpSub:
+		v = old - v
+	case AtomicRMWOpAnd:
+		v = old & v
+	case AtomicRMWOpOr:
+		v = old | v
+	case AtomicRMWOpXor:
+		v = old ^ v
+	case AtomicRMWOpXchg:
+	}
+	writeAtomic(b, v)
+	return
+}
+
+// AtomicCmpxchg replaces the value of `size` bytes at `addr` with
+// `replacement` if it equals `expected`, wrapped to `size` bytes. This returns
+// the previous value, zero-extended.
+func (m *MemoryInstance) AtomicCmpxchg(addr, size uint32, expected, replacement uint64) (old uint64) {
+	b := m.lockAtomic(addr, size)
+	defer m.mux.Unlock()
+	old = readAtomic(b)
+	if size < 8 {
+		expected &= 1<<(size*8) - 1
+	}
+	if old == expected {
+		writeAtomic(b, replacement)
+	}
+	return
+}
+
+// AtomicFence implements atomic.fence, which orders memory accesses around it.
+func (m *MemoryInstance) AtomicFence() {
+	m.mux.Lock()
+	m.mux.Unlock() //nolint
+}
+
+// Wait implements memory.atomic.wait32 and wait64: if the value of `size`
+// bytes at `addr` equals `expected`, this blocks until Notify wakes it up,
+// returning 0, or `timeout` nanoseconds elapse, returning 2. Otherwise, this
+// returns 1. A negative `timeout` never elapses.
+//
+// This also returns 0 early once `done` is closed, which is the Done channel
+// of the module waiting, so callers must check whether it was closed.
+//
+// This panics with wasmruntime.ErrRuntimeExpectedSharedMemory if the memory
+// isn't Shared.
+func (m *MemoryInstance) Wait(addr, size uint32, expected uint64, timeout int64, done <-chan struct{}) uint64 {
+	b := m.lockAtomic(addr, size)
+	if !m.Shared {
+		m.mux.Unlock()
+		panic(wasmruntime.ErrRuntimeExpectedSharedMemory)
+	} else if readAtomic(b) != expected {
+		m.mux.Unlock()
+		return 1 // "not-equal"
+	}
+
+	if m.waiters == nil {
+		m.waiters = map[uint32]*list.List{}
+	}
+	waiters := m.waiters[addr]
+	if waiters == nil {
+		waiters = list.New()
+		m.waiters[addr] = waiters
+	}
+	ready := make(chan struct{})
+	e := waiters.PushBack(ready)
+	m.mux.Unlock()
+
+	var elapsed <-chan time.Time // never if timeout is negative.
+	if timeout >= 0 {
+		timer := time.NewTimer(time.Duration(timeout))
+		defer timer.Stop()
+		elapsed = timer.C
+	}
+	var result uint64
+	select {
+	case <-ready:
+		return 0 // "ok"
+	case <-done:
+	case <-elapsed:
+		result = 2 // "timed-out"
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	select {
+	case <-ready: // Notify woke it up in the meantime.
+		return 0
+	default:
+	}
+	waiters.Remove(e)
+	if waiters.Len() == 0 && m.waiters[addr] == waiters {
+		delete(m.waiters, addr)
+	}
+	return result
+}
+
+// Notify implements memory.atomic.notify: this wakes up at most `count`
+// callers of Wait on `addr`, in the order they waited, and returns how many
+// it woke up.
+func (m *MemoryInstance) Notify(addr, count uint32) (woken uint32) {
+	m.lockAtomic(addr, 4)
+	defer m.mux.Unlock()
+	waiters := m.waiters[addr]
+	if waiters == nil {
+		return 0
+	}
+	for ; woken < count && waiters.Len() > 0; woken++ {
+		close(waiters.Remove(waiters.Front()).(chan struct{}))
+	}
+	if waiters.Len() == 0 {
+		delete(m.waiters, addr)
+	}
+	return
+}
+
+// lockAtomic locks mux for an atomic access of `size` bytes at `addr`, and
+// returns the bytes accessed. If the access is invalid, this unlocks mux and
+// panics.
+func (m *MemoryInstance) lockAtomic(addr, size uint32) []byte {
+	m.mux.Lock()
+	if !m.hasSize(addr, uint64(size)) {
+		m.mux.Unlock()
+		panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+	} else if addr%size != 0 {
+		m.mux.Unlock()
+		panic(wasmruntime.ErrRuntimeUnalignedAtomic)
+	}
+	return m.Buffer[addr : addr+size]
+}
+
+func readAtomic(b []byte) uint64 {
+	switch len(b) {
+	case 1:
+		return uint64(b[0])
+	case 2:
+		return uint64(binary.LittleEndian.Uint16(b))
+	case 4:
+		return uint64(binary.LittleEndian.Uint32(b))
+	default:
+		return binary.LittleEndian.Uint64(b)
+	}
+}
+
+func writeAtomic(b []byte, v uint64) {
+	switch len(b) {
+	case 1:
+		b[0] = byte(v)
+	case 2:
+		binary.LittleEndian.PutUint16(b, uint16(v))
+	case 4:
+		binary.LittleEndian.PutUint32(b, uint32(v))
+	default:
+		binary.LittleEndian.PutUint64(b, v)
+	}
+}
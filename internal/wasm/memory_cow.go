@@ -0,0 +1,51 @@
+package wasm
+
+import "github.com/tetratelabs/wazero/internal/platform"
+
+// copyFrom copies the contents of `src` into the memory, which must have the
+// same size. When both are from NewMmapMemoryInstance, pages are shared
+// copy-on-write where the platform supports it, so that cloning a large
+// memory neither copies nor allocates it upfront.
+//
+// Note: `src` must not be written concurrently, as its pages may be
+// remapped. Concurrent copies from it are safe.
+func (m *MemoryInstance) copyFrom(src *MemoryInstance) {
+	if len(src.Buffer) == 0 {
+		return
+	}
+	if m.mmapped != nil && src.mmapped != nil && !src.Shared {
+		src.cowMux.Lock()
+		defer src.cowMux.Unlock()
+		if src.mmapped == nil { // unmapped concurrently by closing its module.
+			return
+		}
+		if img := src.copyOnWriteImage(); img != nil && img.MapInto(m.Buffer) == nil {
+			return
+		}
+	}
+	copy(m.Buffer, src.Buffer)
+}
+
+// copyOnWriteImage returns an image of the memory, remapping the memory from
+// it so that writes since can be detected. This returns nil if unsupported.
+//
+// Note: This must be called with cowMux held.
+func (m *MemoryInstance) copyOnWriteImage() *platform.CopyOnWriteImage {
+	if img := m.cowImage; img != nil {
+		if !img.Dirty(m.Buffer) {
+			return img
+		}
+		_ = img.Close()
+		m.cowImage = nil
+	}
+	img, err := platform.NewCopyOnWriteImage(m.Buffer)
+	if err != nil {
+		return nil
+	}
+	if err = img.MapInto(m.Buffer); err != nil {
+		_ = img.Close()
+		return nil
+	}
+	m.cowImage = img
+	return img
+}
@@ -0,0 +1,67 @@
+package wasm
+
+import "sync/atomic"
+
+// MemoryStats are statistics of the usage of a MemoryInstance.
+type MemoryStats struct {
+	// Pages is the current size of the memory in pages.
+	Pages uint32
+	// PeakPages is the largest size of the memory in pages.
+	PeakPages uint32
+	// Grows is the count of calls to Grow which grew the memory.
+	Grows uint64
+	// FailedGrows is the count of calls to Grow which failed.
+	FailedGrows uint64
+	// ResidentBytes estimates the bytes of memory allocated to the memory.
+	ResidentBytes uint64
+}
+
+// memoryStats holds the MemoryStats of a MemoryInstance, updated by Grow, so
+// that they can be read concurrently.
+type memoryStats struct {
+	pages, peakPages   atomic.Uint32
+	grows, failedGrows atomic.Uint64
+	residentBytes      atomic.Uint64
+}
+
+// init records the initial size of the memory.
+func (s *memoryStats) init(m *MemoryInstance) {
+	s.update(m)
+}
+
+// record records a call to Grow with a non-zero delta.
+func (s *memoryStats) record(m *MemoryInstance, ok bool) {
+	if !ok {
+		s.failedGrows.Add(1)
+		return
+	}
+	s.grows.Add(1)
+	s.update(m)
+}
+
+func (s *memoryStats) update(m *MemoryInstance) {
+	pages := memoryBytesNumToPages(uint64(len(m.Buffer)))
+	s.pages.Store(pages)
+	if pages > s.peakPages.Load() {
+		s.peakPages.Store(pages)
+	}
+	// Pages of a reservation are only allocated once committed, while the
+	// capacity of other buffers is allocated.
+	if m.mmapped != nil {
+		s.residentBytes.Store(uint64(len(m.Buffer)))
+	} else {
+		s.residentBytes.Store(uint64(cap(m.Buffer)))
+	}
+}
+
+// Stats returns the MemoryStats of the memory. This is safe to call
+// concurrently with Grow.
+func (m *MemoryInstance) Stats() MemoryStats {
+	return MemoryStats{
+		Pages:         m.stats.pages.Load(),
+		PeakPages:     m.stats.peakPages.Load(),
+		Grows:         m.stats.grows.Load(),
+		FailedGrows:   m.stats.failedGrows.Load(),
+		ResidentBytes: m.stats.residentBytes.Load(),
+	}
+}
@@ -1,13 +1,17 @@
 package wasm
 
 import (
+	"fmt"
 	"math"
 	"reflect"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
 	"unsafe"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/platform"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 )
 
@@ -797,3 +801,217 @@ func BenchmarkWriteString(b *testing.B) {
 		})
 	}
 }
+
+func TestMemoryInstance_AddGrowListener(t *testing.T) {
+	m := &MemoryInstance{Max: 10, Buffer: make([]byte, MemoryPageSize)}
+
+	var calls []string
+	removeA := m.AddGrowListener(func(previousPages, pages uint32) {
+		calls = append(calls, fmt.Sprintf("a %d->%d", previousPages, pages))
+	})
+	m.AddGrowListener(func(previousPages, pages uint32) {
+		calls = append(calls, fmt.Sprintf("b %d->%d", previousPages, pages))
+	})
+
+	_, ok := m.Grow(2)
+	require.True(t, ok)
+	_, ok = m.Grow(0) // no growth.
+	require.True(t, ok)
+	_, ok = m.Grow(10) // fails.
+	require.False(t, ok)
+	require.Equal(t, []string{"a 1->3", "b 1->3"}, calls)
+
+	removeA()
+	removeA() // idempotent
+	_, ok = m.Grow(1)
+	require.True(t, ok)
+	require.Equal(t, []string{"a 1->3", "b 1->3", "b 3->4"}, calls)
+}
+
+func TestNewMmapMemoryInstance(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+
+	m := NewMmapMemoryInstance(&Memory{Min: 1, Max: 4})
+	require.NotNil(t, m.mmapped)
+	require.Equal(t, int(MemoryPageSize), len(m.Buffer))
+	require.Equal(t, uint32(4), m.Cap)
+
+	require.True(t, m.WriteUint32Le(0, 0xdeadbeef))
+	base := &m.Buffer[0]
+
+	prev, ok := m.Grow(3)
+	require.True(t, ok)
+	require.Equal(t, uint32(1), prev)
+	require.Equal(t, 4*int(MemoryPageSize), len(m.Buffer))
+	// The buffer grew in place, so wasn't copied.
+	require.Equal(t, base, &m.Buffer[0])
+	v, _ := m.ReadUint32Le(0)
+	require.Equal(t, uint32(0xdeadbeef), v)
+	require.True(t, m.WriteByte(4*MemoryPageSize-1, 1))
+
+	_, ok = m.Grow(1)
+	require.False(t, ok)
+}
+
+func TestMemoryInstance_copyFrom(t *testing.T) {
+	t.Run("heap", func(t *testing.T) {
+		src := NewMemoryInstance(&Memory{Min: 1, Cap: 1, Max: 2})
+		dst := NewMemoryInstance(&Memory{Min: 1, Cap: 1, Max: 2})
+		src.Buffer[0] = 1
+		dst.copyFrom(src)
+		require.Equal(t, byte(1), dst.Buffer[0])
+		require.Nil(t, src.cowImage)
+	})
+
+	t.Run("mmap", func(t *testing.T) {
+		if !platform.CompilerSupported() {
+			t.Skip()
+		}
+
+		src := NewMmapMemoryInstance(&Memory{Min: 2, Max: 4})
+		src.Buffer[0], src.Buffer[len(src.Buffer)-1] = 1, 2
+
+		dst := NewMmapMemoryInstance(&Memory{Min: 2, Max: 4})
+		dst.copyFrom(src)
+		require.Equal(t, byte(1), dst.Buffer[0])
+		require.Equal(t, byte(2), dst.Buffer[len(dst.Buffer)-1])
+		img := src.cowImage
+		if runtime.GOOS != "linux" {
+			require.Nil(t, img)
+		} else {
+			require.NotNil(t, img)
+		}
+
+		// Writes to either memory are private.
+		dst.Buffer[0] = 3
+		require.Equal(t, byte(1), src.Buffer[0])
+		_, ok := dst.Grow(1)
+		require.True(t, ok)
+		require.Equal(t, byte(0), dst.Buffer[len(dst.Buffer)-1])
+
+		// The image is reused until the source is written.
+		other := NewMmapMemoryInstance(&Memory{Min: 2, Max: 4})
+		other.copyFrom(src)
+		require.Equal(t, img, src.cowImage)
+
+		src.Buffer[1] = 4
+		other = NewMmapMemoryInstance(&Memory{Min: 2, Max: 4})
+		other.copyFrom(src)
+		require.Equal(t, byte(4), other.Buffer[1])
+		require.Equal(t, byte(0), dst.Buffer[1])
+		if img != nil {
+			require.NotEqual(t, img, src.cowImage)
+		}
+	})
+
+	t.Run("mmap concurrently", func(t *testing.T) {
+		if !platform.CompilerSupported() {
+			t.Skip()
+		}
+
+		src := NewMmapMemoryInstance(&Memory{Min: 2, Max: 4})
+		src.Buffer[0] = 1
+
+		// Copies from the same source share one image.
+		dsts := make([]*MemoryInstance, 8)
+		var wg sync.WaitGroup
+		for i := range dsts {
+			dsts[i] = NewMmapMemoryInstance(&Memory{Min: 2, Max: 4})
+			wg.Add(1)
+			go func(dst *MemoryInstance) {
+				defer wg.Done()
+				dst.copyFrom(src)
+			}(dsts[i])
+		}
+		wg.Wait()
+		for _, dst := range dsts {
+			require.Equal(t, byte(1), dst.Buffer[0])
+		}
+		require.Equal(t, byte(1), src.Buffer[0])
+	})
+}
+
+func TestMemoryInstance_AddGrowChecker(t *testing.T) {
+	m := &MemoryInstance{Max: 10, Buffer: make([]byte, MemoryPageSize)}
+
+	allow := false
+	remove := m.AddGrowChecker(func(previousPages, pages uint32) bool {
+		require.Equal(t, uint32(1), previousPages)
+		require.Equal(t, uint32(2), pages)
+		return allow
+	})
+	var grown bool
+	m.AddGrowListener(func(uint32, uint32) { grown = true })
+
+	_, ok := m.Grow(1)
+	require.False(t, ok)
+	require.False(t, grown)
+
+	allow = true
+	_, ok = m.Grow(1)
+	require.True(t, ok)
+	require.True(t, grown)
+
+	remove()
+	_, ok = m.Grow(1)
+	require.True(t, ok)
+}
+
+func TestMemoryInstance_SetLimitPages(t *testing.T) {
+	m := &MemoryInstance{Max: 10, Buffer: make([]byte, MemoryPageSize)}
+
+	m.SetLimitPages(2)
+	require.Equal(t, uint32(2), m.LimitPages())
+	_, ok := m.Grow(2)
+	require.False(t, ok)
+	_, ok = m.Grow(1)
+	require.True(t, ok)
+
+	m.SetLimitPages(0)
+	_, ok = m.Grow(8)
+	require.True(t, ok)
+}
+
+func TestMemoryInstance_Stats(t *testing.T) {
+	m := NewMemoryInstance(&Memory{Min: 1, Cap: 2, Max: 3})
+	require.Equal(t, MemoryStats{Pages: 1, PeakPages: 1, ResidentBytes: 2 * uint64(MemoryPageSize)}, m.Stats())
+
+	_, ok := m.Grow(1)
+	require.True(t, ok)
+	_, ok = m.Grow(2)
+	require.False(t, ok)
+	_, ok = m.Grow(0)
+	require.True(t, ok)
+	require.Equal(t, MemoryStats{Pages: 2, PeakPages: 2, Grows: 1, FailedGrows: 1, ResidentBytes: 2 * uint64(MemoryPageSize)}, m.Stats())
+}
+
+func TestMemoryInstance_Wait(t *testing.T) {
+	mem := &MemoryInstance{Buffer: make([]byte, 8), Min: 1, Shared: true}
+
+	t.Run("not-equal", func(t *testing.T) {
+		require.Equal(t, uint64(1), mem.Wait(0, 4, 1, -1, nil))
+	})
+
+	t.Run("timed-out", func(t *testing.T) {
+		require.Equal(t, uint64(2), mem.Wait(0, 4, 0, 0, nil))
+		require.Equal(t, 0, len(mem.waiters))
+	})
+
+	t.Run("notified", func(t *testing.T) {
+		result := make(chan uint64)
+		go func() { result <- mem.Wait(0, 4, 0, -1, nil) }()
+		for mem.Notify(0, 1) == 0 {
+			runtime.Gosched()
+		}
+		require.Equal(t, uint64(0), <-result)
+	})
+
+	t.Run("done", func(t *testing.T) {
+		done := make(chan struct{})
+		close(done)
+		require.Equal(t, uint64(0), mem.Wait(0, 4, 0, -1, done))
+		require.Equal(t, 0, len(mem.waiters))
+	})
+}
@@ -0,0 +1,50 @@
+package wasm
+
+// Watchpoint calls Fn after an instruction accesses memory overlapping the
+// ByteCount bytes at Offset, if it reads and Read is set, or writes and Write
+// is set.
+type Watchpoint struct {
+	Offset, ByteCount uint32
+	Read, Write       bool
+	Fn                func(write bool, offset, byteCount uint32)
+}
+
+// MemoryWatcher is implemented by a ModuleEngine which fires the Watchpoints
+// of its memory, via MemoryInstance.Watch.
+type MemoryWatcher interface {
+	// WatchesMemory is a marker method.
+	WatchesMemory()
+}
+
+// AddWatchpoint adds the given Watchpoint to the memory. The returned
+// function removes it.
+//
+// Note: This must not be called concurrently with functions using the memory.
+func (m *MemoryInstance) AddWatchpoint(w *Watchpoint) (remove func()) {
+	m.Watchpoints = append(m.Watchpoints, w)
+	return func() {
+		for i, other := range m.Watchpoints {
+			if other == w {
+				m.Watchpoints = append(m.Watchpoints[:i:i], m.Watchpoints[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Watch calls the Watchpoints overlapping an access of byteCount bytes at the
+// offset, which is in bounds. Callers should check Watchpoints is non-empty
+// first, to avoid the call otherwise.
+func (m *MemoryInstance) Watch(write bool, offset, byteCount uint64) {
+	if byteCount == 0 {
+		return
+	}
+	for _, w := range m.Watchpoints {
+		if (write && !w.Write) || (!write && !w.Read) {
+			continue
+		}
+		if offset < uint64(w.Offset)+uint64(w.ByteCount) && uint64(w.Offset) < offset+byteCount {
+			w.Fn(write, uint32(offset), uint32(byteCount))
+		}
+	}
+}
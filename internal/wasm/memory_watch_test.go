@@ -0,0 +1,44 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestMemoryInstance_Watch(t *testing.T) {
+	m := &MemoryInstance{}
+
+	var fired []bool
+	remove := m.AddWatchpoint(&Watchpoint{
+		Offset: 10, ByteCount: 2, Write: true,
+		Fn: func(write bool, offset, byteCount uint32) {
+			require.Equal(t, uint32(9), offset)
+			fired = append(fired, write)
+		},
+	})
+
+	tests := []struct {
+		name              string
+		write             bool
+		offset, byteCount uint64
+		expectFired       bool
+	}{
+		{name: "overlaps start", write: true, offset: 9, byteCount: 2, expectFired: true},
+		{name: "before", write: true, offset: 9, byteCount: 1},
+		{name: "after", write: true, offset: 12, byteCount: 1},
+		{name: "empty", write: true, offset: 10, byteCount: 0},
+		{name: "read", offset: 9, byteCount: 2},
+	}
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			fired = nil
+			m.Watch(tc.write, tc.offset, tc.byteCount)
+			require.Equal(t, tc.expectFired, len(fired) == 1)
+		})
+	}
+
+	remove()
+	require.Equal(t, 0, len(m.Watchpoints))
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/fuel"
 	"github.com/tetratelabs/wazero/internal/ieee754"
 	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/wasmdebug"
@@ -45,12 +46,13 @@ type Module struct {
 	//
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#import-section%E2%91%A0
 	ImportSection []Import
-	// ImportFunctionCount ImportGlobalCount ImportMemoryCount, and ImportTableCount are
+	// ImportFunctionCount ImportGlobalCount ImportMemoryCount, ImportTableCount and ImportTagCount are
 	// the cached import count per ExternType set during decoding.
 	ImportFunctionCount,
 	ImportGlobalCount,
 	ImportMemoryCount,
-	ImportTableCount Index
+	ImportTableCount,
+	ImportTagCount Index
 	// ImportPerModule maps a module name to the list of Import to be imported from the module.
 	// This is used to do fast import resolution during instantiation.
 	ImportPerModule map[string][]*Import
@@ -108,6 +110,16 @@ type Module struct {
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#global-section%E2%91%A0
 	GlobalSection []Global
 
+	// TagSection contains the index in TypeSection of the type of each tag defined in this module, whose params are
+	// the payload of the exceptions of the tag. Tags are defined by the exception handling proposal.
+	//
+	// Note: The tag Index space begins with imported tags and ends with those defined in this module.
+	//
+	// Note: In the Binary Format, this is SectionIDTag.
+	//
+	// See https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/Exceptions.md#tag-section
+	TagSection []Index
+
 	// ExportSection contains each export defined in this module.
 	//
 	// Note: In the Binary Format, this is SectionIDExport.
@@ -158,6 +170,10 @@ type Module struct {
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#custom-section%E2%91%A0
 	CustomSections []*CustomSection
 
+	// NameSectionData is the raw content of the "name" section, only retained
+	// when custom sections are. NameSection holds its decoded form.
+	NameSectionData []byte
+
 	// DataCountSection is the optional section and holds the number of data segments in the data section.
 	//
 	// Note: This may exist in WebAssembly 2.0 or WebAssembly 1.0 with CoreFeatureBulkMemoryOperations.
@@ -172,6 +188,11 @@ type Module struct {
 	// IsHostModule true if this is the host module, false otherwise.
 	IsHostModule bool
 
+	// UsesExceptionHandling is true if a function uses an instruction of
+	// experimental.CoreFeaturesExceptionHandling, which only the interpreter
+	// supports. This is set by Validate.
+	UsesExceptionHandling bool
+
 	// functionDefinitionSectionInitOnce guards FunctionDefinitionSection so that it is initialized exactly once.
 	functionDefinitionSectionInitOnce sync.Once
 
@@ -185,6 +206,43 @@ type Module struct {
 	// as described in https://yurydelendik.github.io/webassembly-dwarf/, though it is not specified in the Wasm
 	// specification: https://github.com/WebAssembly/debugging/issues/1
 	DWARFLines *wasmdebug.DWARFLines
+
+	// SectionOffsets are the offsets in the binary of the contents of each
+	// non-custom section, indexed by SectionID, or zero if absent. These
+	// position validation errors.
+	SectionOffsets [SectionIDTag + 1]uint64
+}
+
+// SectionError is an error decoding or validating a module, attributed to
+// the section it is in.
+type SectionError struct {
+	// ID is the section the error is in.
+	ID SectionID
+	// Offset is the offset in the binary where the error was detected, or of
+	// the contents of the section if it isn't known more precisely.
+	Offset uint64
+	// Err is the error, whose message is returned by Error.
+	Err error
+}
+
+// Error implements error.Error
+func (e *SectionError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *SectionError) Unwrap() error {
+	return e.Err
+}
+
+// sectionError attributes a non-nil err to the section, unless it already
+// is attributed.
+func (m *Module) sectionError(id SectionID, err error) error {
+	var se *SectionError
+	if err == nil || errors.As(err, &se) {
+		return err
+	}
+	return &SectionError{ID: id, Offset: m.SectionOffsets[id], Err: err}
 }
 
 // ModuleID represents sha256 hash value uniquely assigned to Module.
@@ -200,7 +258,7 @@ const (
 
 // AssignModuleID calculates a sha256 checksum on `wasm` and other args, and set Module.ID to the result.
 // See the doc on Module.ID on what it's used for.
-func (m *Module) AssignModuleID(wasm []byte, listeners []experimental.FunctionListener, withEnsureTermination bool) {
+func (m *Module) AssignModuleID(wasm []byte, listeners []experimental.FunctionListener, withEnsureTermination bool, fuelCosts *fuel.Costs) {
 	h := sha256.New()
 	h.Write(wasm)
 	// Use the pre-allocated space backed by m.ID below.
@@ -214,6 +272,12 @@ func (m *Module) AssignModuleID(wasm []byte, listeners []experimental.FunctionLi
 	// Write the flag of ensureTermination to the checksum.
 	m.ID[0] = boolToByte(withEnsureTermination)
 	h.Write(m.ID[:1])
+	// Write the fuel costs to the checksum, as they are compiled into the code.
+	m.ID[0] = boolToByte(fuelCosts != nil)
+	h.Write(m.ID[:1])
+	if fuelCosts != nil {
+		fuelCosts.Hash(h)
+	}
 	// Get checksum by passing the slice underlying m.ID.
 	h.Sum(m.ID[:0])
 }
@@ -264,42 +328,47 @@ func (m *Module) Validate(enabledFeatures api.CoreFeatures) error {
 	}
 
 	if err := m.validateStartSection(); err != nil {
-		return err
+		return m.sectionError(SectionIDStart, err)
 	}
 
 	functions, globals, memory, tables, err := m.AllDeclarations()
 	if err != nil {
-		return err
+		return m.sectionError(SectionIDFunction, err)
 	}
 
 	if err = m.validateImports(enabledFeatures); err != nil {
-		return err
+		return m.sectionError(SectionIDImport, err)
+	}
+
+	tags := m.AllTags()
+	if err = m.validateTags(enabledFeatures, tags); err != nil {
+		return m.sectionError(SectionIDTag, err)
 	}
 
 	if err = m.validateGlobals(globals, uint32(len(functions)), MaximumGlobals); err != nil {
-		return err
+		return m.sectionError(SectionIDGlobal, err)
 	}
 
 	if err = m.validateMemory(memory, globals, enabledFeatures); err != nil {
-		return err
+		return m.sectionError(SectionIDData, err)
 	}
 
-	if err = m.validateExports(enabledFeatures, functions, globals, memory, tables); err != nil {
-		return err
+	if err = m.validateExports(enabledFeatures, functions, globals, memory, tables, tags); err != nil {
+		return m.sectionError(SectionIDExport, err)
 	}
 
 	if m.CodeSection != nil {
 		if err = m.validateFunctions(enabledFeatures, functions, globals, memory, tables, MaximumFunctionIndex); err != nil {
-			return err
+			return m.sectionError(SectionIDCode, err)
 		}
 	} // No need to validate host functions as NewHostModule validates
 
 	if err = m.validateTable(enabledFeatures, tables, MaximumTableIndex); err != nil {
-		return err
+		return m.sectionError(SectionIDElement, err)
 	}
 
 	if err = m.validateDataCountSection(); err != nil {
-		return err
+		return m.sectionError(SectionIDDataCount, err)
 	}
 	return nil
 }
@@ -365,14 +434,24 @@ func (m *Module) validateFunctions(enabledFeatures api.CoreFeatures, functions [
 	vs := &stacks{}
 	for idx, typeIndex := range m.FunctionSection {
 		if typeIndex >= typeCount {
-			return fmt.Errorf("invalid %s: type section index %d out of range", m.funcDesc(SectionIDFunction, Index(idx)), typeIndex)
+			return m.sectionError(SectionIDFunction,
+				fmt.Errorf("invalid %s: type section index %d out of range", m.funcDesc(SectionIDFunction, Index(idx)), typeIndex))
 		}
 		c := &m.CodeSection[idx]
 		if c.GoFunc != nil {
 			continue
 		}
 		if err = m.validateFunction(vs, enabledFeatures, Index(idx), functions, globals, memory, tables, declaredFuncIndexes, br); err != nil {
-			return fmt.Errorf("invalid %s: %w", m.funcDesc(SectionIDFunction, Index(idx)), err)
+			offset := m.SectionOffsets[SectionIDCode] + c.BodyOffsetInCodeSection
+			var ie *instructionError
+			if errors.As(err, &ie) {
+				offset += ie.pc
+			}
+			return &SectionError{
+				ID:     SectionIDCode,
+				Offset: offset,
+				Err:    fmt.Errorf("invalid %s: %w", m.funcDesc(SectionIDFunction, Index(idx)), err),
+			}
 		}
 	}
 	return nil
@@ -467,7 +546,7 @@ func (m *Module) validateMemory(memory *Memory, globals []GlobalType, _ api.Core
 	for i := range m.DataSection {
 		d := &m.DataSection[i]
 		if !d.IsPassive() {
-			if err := validateConstExpression(importedGlobals, 0, &d.OffsetExpression, ValueTypeI32); err != nil {
+			if err := validateConstExpression(importedGlobals, 0, &d.OffsetExpression, memory.AddressType()); err != nil {
 				return fmt.Errorf("calculate offset: %w", err)
 			}
 		}
@@ -498,7 +577,7 @@ func (m *Module) validateImports(enabledFeatures api.CoreFeatures) error {
 	return nil
 }
 
-func (m *Module) validateExports(enabledFeatures api.CoreFeatures, functions []Index, globals []GlobalType, memory *Memory, tables []Table) error {
+func (m *Module) validateExports(enabledFeatures api.CoreFeatures, functions []Index, globals []GlobalType, memory *Memory, tables []Table, tags []Index) error {
 	for i := range m.ExportSection {
 		exp := &m.ExportSection[i]
 		index := exp.Index
@@ -525,11 +604,56 @@ func (m *Module) validateExports(enabledFeatures api.CoreFeatures, functions []I
 			if index >= uint32(len(tables)) {
 				return fmt.Errorf("table for export[%q] out of range", exp.Name)
 			}
+		case ExternTypeTag:
+			if index >= uint32(len(tags)) {
+				return fmt.Errorf("tag for export[%q] out of range", exp.Name)
+			}
 		}
 	}
 	return nil
 }
 
+// validateTags ensures the types of `tags` have no results, and that neither tags nor exnref values are used unless
+// experimental.CoreFeaturesExceptionHandling is enabled.
+func (m *Module) validateTags(enabledFeatures api.CoreFeatures, tags []Index) error {
+	if !enabledFeatures.IsEnabled(experimental.CoreFeaturesExceptionHandling) {
+		if len(tags) > 0 {
+			return errors.New(`tags invalid as feature "exception-handling" is disabled`)
+		}
+		for i := range m.TypeSection {
+			if hasExnref(m.TypeSection[i].Params) || hasExnref(m.TypeSection[i].Results) {
+				return fmt.Errorf(`type[%d] invalid as feature "exception-handling" is disabled`, i)
+			}
+		}
+		for i := range m.CodeSection {
+			if hasExnref(m.CodeSection[i].LocalTypes) {
+				return fmt.Errorf(`locals of %s invalid as feature "exception-handling" is disabled`,
+					m.funcDesc(SectionIDFunction, Index(i)))
+			}
+		}
+		return nil
+	}
+
+	for i, typeIndex := range tags {
+		if typeIndex >= uint32(len(m.TypeSection)) {
+			return fmt.Errorf("invalid tag[%d]: type index %d out of range", i, typeIndex)
+		}
+		if len(m.TypeSection[typeIndex].Results) > 0 {
+			return fmt.Errorf("invalid tag[%d]: type %s has results", i, &m.TypeSection[typeIndex])
+		}
+	}
+	return nil
+}
+
+func hasExnref(types []ValueType) bool {
+	for _, t := range types {
+		if t == ValueTypeExnref {
+			return true
+		}
+	}
+	return false
+}
+
 func validateConstExpression(globals []GlobalType, numFuncs uint32, expr *ConstantExpression, expectedType ValueType) (err error) {
 	var actualType ValueType
 	switch expr.Opcode {
@@ -590,6 +714,16 @@ func validateConstExpression(globals []GlobalType, numFuncs uint32, expr *Consta
 			return fmt.Errorf("%s needs 16 bytes but was %d bytes", OpcodeVecV128ConstName, len(expr.Data))
 		}
 		actualType = ValueTypeV128
+	case OpcodeI32Add, OpcodeI32Sub, OpcodeI32Mul, OpcodeI64Add, OpcodeI64Sub, OpcodeI64Mul:
+		actualType, _, err = evalExtendedConst(expr.Data, func(index Index) (ValueType, uint64, error) {
+			if uint32(len(globals)) <= index {
+				return 0, 0, fmt.Errorf("global index out of range")
+			}
+			return globals[index].ValType, 0, nil
+		})
+		if err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("invalid opcode for const expression: 0x%x", expr.Opcode)
 	}
@@ -640,12 +774,26 @@ func paramNames(localNames IndirectNameMap, funcIdx uint32, paramLen int) []stri
 	return nil
 }
 
-func (m *ModuleInstance) buildMemory(module *Module) {
+// buildMemory builds the memory defined by the module, if any, with the
+// allocator if non-nil.
+func (m *ModuleInstance) buildMemory(module *Module, allocator experimental.MemoryAllocator) (err error) {
 	memSec := module.MemorySection
-	if memSec != nil {
+	if memSec == nil {
+		return
+	}
+	switch {
+	case allocator != nil && !memSec.IsShared:
+		if m.MemoryInstance, err = NewMemoryInstanceWithAllocator(memSec, allocator); err != nil {
+			return
+		}
+	case m.s != nil && m.s.MmapMemory:
+		m.MemoryInstance = NewMmapMemoryInstance(memSec)
+	default:
 		m.MemoryInstance = NewMemoryInstance(memSec)
-		m.MemoryInstance.definition = &module.MemoryDefinitionSection[0]
 	}
+	m.MemoryInstance.definition = &module.MemoryDefinitionSection[0]
+	m.MemoryInstance.owner = m
+	return
 }
 
 // Index is the offset in an index, not necessarily an absolute position in a Module section. This is because
@@ -750,6 +898,8 @@ type Import struct {
 	DescMem *Memory
 	// DescGlobal is the inlined GlobalType when Type equals ExternTypeGlobal
 	DescGlobal GlobalType
+	// DescTag is the index in Module.TypeSection of the tag type when Type equals ExternTypeTag
+	DescTag Index
 	// IndexPerType has the index of this import per ExternType.
 	IndexPerType Index
 }
@@ -759,6 +909,24 @@ type Memory struct {
 	Min, Cap, Max uint32
 	// IsMaxEncoded true if the Max is encoded in the original binary.
 	IsMaxEncoded bool
+	// Is64 is true if the memory is addressed with i64, as defined by the
+	// memory64 proposal.
+	Is64 bool
+	// IsShared is true if the memory can be shared between threads, as
+	// defined by the threads proposal. A shared memory always has a Max.
+	IsShared bool
+}
+
+// AddressType returns the type of addresses, offsets and sizes in the memory.
+func (m *Memory) AddressType() ValueType {
+	return addressType(m.Is64)
+}
+
+func addressType(is64 bool) ValueType {
+	if is64 {
+		return ValueTypeI64
+	}
+	return ValueTypeI32
 }
 
 // Validate ensures values assigned to Min, Cap and Max are within valid thresholds.
@@ -887,6 +1055,39 @@ type NameSection struct {
 
 	// ResultNames is a wazero-specific mechanism to store result names.
 	ResultNames IndirectNameMap
+
+	// LabelNames contains symbolic names for labels of blocks, loops and ifs
+	// in functions that have one, by function index and label index.
+	//
+	// The below, through TagNames, are from the extended-name-section
+	// proposal, and are only used for debugging.
+	// See https://github.com/WebAssembly/extended-name-section/blob/main/proposals/extended-name-section/Overview.md
+	LabelNames IndirectNameMap
+
+	// TypeNames are symbolic names of types by type index.
+	TypeNames NameMap
+
+	// TableNames are symbolic names of tables by table index, where imported
+	// tables precede module defined ones.
+	TableNames NameMap
+
+	// MemoryNames are symbolic names of memories by memory index, where
+	// an imported memory precedes a module defined one.
+	MemoryNames NameMap
+
+	// GlobalNames are symbolic names of globals by global index, where
+	// imported globals precede module defined ones.
+	GlobalNames NameMap
+
+	// ElementNames are symbolic names of element segments by their index.
+	ElementNames NameMap
+
+	// DataNames are symbolic names of data segments by their index.
+	DataNames NameMap
+
+	// TagNames are symbolic names of tags by tag index, where imported tags
+	// precede module defined ones.
+	TagNames NameMap
 }
 
 // CustomSection contains the name and raw data of a custom section.
@@ -956,6 +1157,19 @@ func (m *Module) AllDeclarations() (functions []Index, globals []GlobalType, mem
 	return
 }
 
+// AllTags returns the index in TypeSection of the type of each tag, beginning with imported tags.
+func (m *Module) AllTags() (tags []Index) {
+	if m.ImportTagCount == 0 && len(m.TagSection) == 0 {
+		return nil
+	}
+	for i := range m.ImportSection {
+		if imp := &m.ImportSection[i]; imp.Type == ExternTypeTag {
+			tags = append(tags, imp.DescTag)
+		}
+	}
+	return append(tags, m.TagSection...)
+}
+
 // SectionID identifies the sections of a Module in the WebAssembly 1.0 (20191205) Binary Format.
 //
 // Note: these are defined in the wasm package, instead of the binary package, as a key per section is needed regardless
@@ -984,6 +1198,12 @@ const (
 	// See https://www.w3.org/TR/2022/WD-wasm-core-2-20220419/binary/modules.html#data-count-section
 	// See https://www.w3.org/TR/2022/WD-wasm-core-2-20220419/appendix/changes.html#bulk-memory-and-table-instructions
 	SectionIDDataCount
+
+	// SectionIDTag is defined by the exception handling proposal, and ordered between SectionIDMemory and
+	// SectionIDGlobal.
+	//
+	// See https://github.com/WebAssembly/exception-handling/blob/main/proposals/exception-handling/Exceptions.md#tag-section
+	SectionIDTag
 )
 
 // SectionIDName returns the canonical name of a module section.
@@ -1016,6 +1236,8 @@ func SectionIDName(sectionID SectionID) string {
 		return "data"
 	case SectionIDDataCount:
 		return "data_count"
+	case SectionIDTag:
+		return "tag"
 	}
 	return "unknown"
 }
@@ -1033,6 +1255,8 @@ const (
 	// TODO: ValueTypeFuncref is not exposed in the api pkg yet.
 	ValueTypeFuncref   ValueType = 0x70
 	ValueTypeExternref           = api.ValueTypeExternref
+	// ValueTypeExnref is a reference to an exception, defined by the exception handling proposal.
+	ValueTypeExnref ValueType = 0x69
 )
 
 // ValueTypeName is an alias of api.ValueTypeName defined to simplify imports.
@@ -1041,12 +1265,43 @@ func ValueTypeName(t ValueType) string {
 		return "funcref"
 	} else if t == ValueTypeV128 {
 		return "v128"
+	} else if t == ValueTypeExnref {
+		return "exnref"
 	}
 	return api.ValueTypeName(t)
 }
 
 func isReferenceValueType(vt ValueType) bool {
-	return vt == ValueTypeExternref || vt == ValueTypeFuncref
+	return vt == ValueTypeExternref || vt == ValueTypeFuncref || vt == ValueTypeExnref
+}
+
+// ErrGCUnsupported is the error of modules using the GC proposal, e.g. as
+// compiled from Kotlin, Dart or Java. Its reference types can refer to type
+// indexes, so they can't be represented as a ValueType. See RATIONALE.md.
+var ErrGCUnsupported = errors.New("the GC proposal isn't supported")
+
+// GCValueTypeName returns the name of `t` if it is the leading byte of a
+// reference type of the GC proposal, or false otherwise.
+func GCValueTypeName(t ValueType) (string, bool) {
+	switch t {
+	case 0x6e:
+		return "anyref", true
+	case 0x6d:
+		return "eqref", true
+	case 0x6c:
+		return "i31ref", true
+	case 0x6b:
+		return "structref", true
+	case 0x6a:
+		return "arrayref", true
+	case 0x71:
+		return "nullref", true
+	case 0x72:
+		return "nullexternref", true
+	case 0x73:
+		return "nullfuncref", true
+	}
+	return "", false
 }
 
 // ExternType is an alias of api.ExternType defined to simplify imports.
@@ -1061,9 +1316,15 @@ const (
 	ExternTypeMemoryName = api.ExternTypeMemoryName
 	ExternTypeGlobal     = api.ExternTypeGlobal
 	ExternTypeGlobalName = api.ExternTypeGlobalName
+	// ExternTypeTag is defined by the exception handling proposal, and isn't exposed in the api pkg.
+	ExternTypeTag     ExternType = 0x04
+	ExternTypeTagName            = "tag"
 )
 
 // ExternTypeName is an alias of api.ExternTypeName defined to simplify imports.
 func ExternTypeName(t ValueType) string {
+	if t == ExternTypeTag {
+		return ExternTypeTagName
+	}
 	return api.ExternTypeName(t)
 }
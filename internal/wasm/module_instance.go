@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/tetratelabs/wazero/api"
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
 	"github.com/tetratelabs/wazero/sys"
 )
 
@@ -24,6 +25,15 @@ func (m *ModuleInstance) FailIfClosed() (err error) {
 	return nil
 }
 
+// CancelCallDone returns the Done channel of `ctx` if calls made with it fail
+// when it is done, or nil otherwise.
+func CancelCallDone(ctx context.Context) <-chan struct{} {
+	if cancel, _ := ctx.Value(internalsys.CancelCallKey{}).(bool); cancel {
+		return ctx.Done()
+	}
+	return nil
+}
+
 // CloseModuleOnCanceledOrTimeout take a context `ctx`, which might be a Cancel or Timeout context,
 // and spawns the Goroutine to check the context is canceled ot deadline exceeded. If it reaches
 // one of the conditions, it sets the appropriate exit code.
@@ -56,7 +66,7 @@ func (m *ModuleInstance) closeModuleOnCanceledOrTimeout(ctx context.Context, can
 			switch {
 			case errors.Is(ctx.Err(), context.Canceled):
 				// TODO: figure out how to report error here.
-				_ = m.closeWithExitCodeWithoutClosingResource(sys.ExitCodeContextCanceled)
+				_ = m.closeWithExitCodeWithoutClosingResource(canceledExitCode(ctx))
 			case errors.Is(ctx.Err(), context.DeadlineExceeded):
 				// TODO: figure out how to report error here.
 				_ = m.closeWithExitCodeWithoutClosingResource(sys.ExitCodeDeadlineExceeded)
@@ -74,13 +84,24 @@ func (m *ModuleInstance) CloseWithCtxErr(ctx context.Context) {
 	switch {
 	case errors.Is(ctx.Err(), context.Canceled):
 		// TODO: figure out how to report error here.
-		_ = m.CloseWithExitCode(ctx, sys.ExitCodeContextCanceled)
+		_ = m.CloseWithExitCode(ctx, canceledExitCode(ctx))
 	case errors.Is(ctx.Err(), context.DeadlineExceeded):
 		// TODO: figure out how to report error here.
 		_ = m.CloseWithExitCode(ctx, sys.ExitCodeDeadlineExceeded)
 	}
 }
 
+// canceledExitCode returns the exit code of a module closed as `ctx` was
+// canceled, which differs when it was canceled due to an interrupt.
+func canceledExitCode(ctx context.Context) uint32 {
+	if i, ok := ctx.Value(internalsys.InterruptKey{}).(*internalsys.Interrupt); ok {
+		if exitCode, ok := i.ExitCode(); ok {
+			return exitCode
+		}
+	}
+	return sys.ExitCodeContextCanceled
+}
+
 // Name implements the same method as documented on api.Module
 func (m *ModuleInstance) Name() string {
 	return m.ModuleName
@@ -91,6 +112,24 @@ func (m *ModuleInstance) String() string {
 	return fmt.Sprintf("Module[%s]", m.Name())
 }
 
+// HostState returns the state attached to this module by the embedder, or nil
+// if there is none or the module is closed.
+func (m *ModuleInstance) HostState() interface{} {
+	if sysCtx := m.Sys; sysCtx != nil {
+		return sysCtx.HostState()
+	}
+	return nil
+}
+
+// FSContext returns the file table of this module, or nil if it is a host
+// module or closed.
+func (m *ModuleInstance) FSContext() *internalsys.FSContext {
+	if sysCtx := m.Sys; sysCtx != nil && !m.Source.IsHostModule {
+		return sysCtx.FS()
+	}
+	return nil
+}
+
 // Close implements the same method as documented on api.Module.
 func (m *ModuleInstance) Close(ctx context.Context) (err error) {
 	return m.CloseWithExitCode(ctx, 0)
@@ -139,7 +178,22 @@ const (
 
 func (m *ModuleInstance) setExitCode(exitCode uint32, flag exitCodeFlag) bool {
 	closed := flag | uint64(exitCode)<<32 // Store exitCode as high-order bits.
-	return m.Closed.CompareAndSwap(0, closed)
+	if !m.Closed.CompareAndSwap(0, closed) {
+		return false
+	}
+	close(m.doneChan())
+	return true
+}
+
+// Done returns a channel that's closed when the module is closed. This allows
+// host functions which block, such as sleeping, to return early.
+func (m *ModuleInstance) Done() <-chan struct{} {
+	return m.doneChan()
+}
+
+func (m *ModuleInstance) doneChan() chan struct{} {
+	m.doneOnce.Do(func() { m.done = make(chan struct{}) })
+	return m.done
 }
 
 // ensureResourcesClosed ensures that resources assigned to ModuleInstance is released.
@@ -157,6 +211,15 @@ func (m *ModuleInstance) ensureResourcesClosed(ctx context.Context) (err error)
 		m.Sys = nil
 	}
 
+	// Free the memory if defined by this module, not imported.
+	if mem := m.MemoryInstance; mem != nil && m.Source.MemorySection != nil {
+		if mem.expBuffer != nil {
+			mem.expBuffer.Free()
+			mem.expBuffer = nil
+		}
+		mem.unmap()
+	}
+
 	if m.CodeCloser == nil {
 		return
 	}
@@ -169,6 +232,9 @@ func (m *ModuleInstance) ensureResourcesClosed(ctx context.Context) (err error)
 
 // Memory implements the same method as documented on api.Module.
 func (m *ModuleInstance) Memory() api.Memory {
+	if m.MemoryInstance == nil {
+		return nil // rather than a typed nil, as documented.
+	}
 	return m.MemoryInstance
 }
 
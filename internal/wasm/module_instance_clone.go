@@ -0,0 +1,98 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
+)
+
+// CloneModule is like Instantiate, except the state of the memory, globals and
+// tables defined by the source module of `template` is copied from it,
+// instead of initialized, and the start function isn't called.
+//
+// Note: `template` must not be executing, or its state may be inconsistent.
+func (s *Store) CloneModule(
+	ctx context.Context,
+	template *ModuleInstance,
+	name string,
+	sys *internalsys.Context,
+) (*ModuleInstance, error) {
+	if template.s != s {
+		return nil, errors.New("cannot clone a module from another runtime")
+	} else if template.Source.IsHostModule {
+		return nil, errors.New("cannot clone a host module")
+	}
+
+	return s.instantiateAndRegister(ctx, template.Source, name, sys, template.TypeIDs, func(m *ModuleInstance) {
+		m.copyState(template)
+	})
+}
+
+// copyState copies the state of the memory, globals, tables, and data and
+// element segments defined by the module from `template`. Imported ones are
+// shared, so are not copied.
+//
+// Function references to the template are replaced with the same function in
+// this module, as they are specific to its engine.
+func (m *ModuleInstance) copyState(template *ModuleInstance) {
+	module := m.Source
+
+	if module.MemorySection != nil {
+		mem, src := m.MemoryInstance, template.MemoryInstance
+		if pages, srcPages := mem.PageSize(), src.PageSize(); srcPages > pages {
+			mem.Grow(srcPages - pages) // Can't fail, as the template grew.
+		}
+		mem.copyFrom(src)
+	}
+
+	var refs map[Reference]Reference
+	cloneRef := func(ref Reference) Reference {
+		if ref == 0 {
+			return 0
+		}
+		if refs == nil {
+			// Map references to each function, including imported ones, as
+			// the engine may copy them into the module.
+			funcCount := module.ImportFunctionCount + Index(len(module.FunctionSection))
+			refs = make(map[Reference]Reference, funcCount)
+			for i := Index(0); i < funcCount; i++ {
+				refs[template.Engine.FunctionInstanceReference(i)] = m.Engine.FunctionInstanceReference(i)
+			}
+		}
+		if clone, ok := refs[ref]; ok {
+			return clone
+		}
+		return ref // A function of another module, which is shared.
+	}
+
+	for i := module.ImportGlobalCount; i < Index(len(m.Globals)); i++ {
+		g, src := m.Globals[i], template.Globals[i]
+		if g.Type.ValType == ValueTypeFuncref {
+			g.Val = uint64(cloneRef(Reference(src.Val)))
+		} else {
+			g.Val, g.ValHi = src.Val, src.ValHi
+		}
+	}
+
+	for i := module.ImportTableCount; i < Index(len(m.Tables)); i++ {
+		t, src := m.Tables[i], template.Tables[i]
+		t.References = make([]Reference, len(src.References))
+		if t.Type == RefTypeFuncref {
+			for j, ref := range src.References {
+				t.References[j] = cloneRef(ref)
+			}
+		} else {
+			copy(t.References, src.References)
+		}
+	}
+
+	// Segments are immutable, except they can be dropped.
+	for i, inst := range template.ElementInstances {
+		if inst == nil {
+			m.ElementInstances[i] = nil
+		}
+	}
+	m.DataInstances = make([]DataInstance, len(template.DataInstances))
+	copy(m.DataInstances, template.DataInstances)
+}
@@ -0,0 +1,209 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/tetratelabs/wazero/internal/platform"
+	internalsys "github.com/tetratelabs/wazero/internal/sys"
+)
+
+// Snapshot is the state of the memory, globals, tables, and data and element
+// segments defined by a module instance, as returned by
+// Store.Snapshot.
+//
+// Unlike the instance, this doesn't depend on the engine, as function
+// references are held as function indices. This allows the instance to be
+// closed while the snapshot is in use.
+type Snapshot struct {
+	memory []byte
+	// memoryImage is created from memory on first use, so that instances
+	// with a memory from NewMmapMemoryInstance share its pages copy-on-write.
+	// It is nil if unsupported.
+	memoryImage     *platform.CopyOnWriteImage
+	memoryImageOnce sync.Once
+	// globals are the values of globals defined by the module. Funcref ones
+	// are encoded as snapshotRef.
+	globals []snapshotGlobal
+	// tables are the elements of tables defined by the module. Funcref ones
+	// are encoded as snapshotRef.
+	tables [][]Reference
+	// droppedElements and droppedData are the segments dropped by the
+	// instance.
+	droppedElements, droppedData []bool
+	// hasExternref is true when the globals or tables hold a non-null
+	// externref, which is a host pointer, so can't be marshaled.
+	hasExternref bool
+}
+
+type snapshotGlobal struct {
+	val, valHi uint64
+}
+
+// Snapshot captures the state of the module instance, so that
+// InstantiateSnapshot can create instances with it.
+//
+// This errs if a function reference isn't to a function in the module,
+// including imported ones, as those can't be restored.
+//
+// Note: `m` must not be executing, or its state may be inconsistent.
+func (s *Store) Snapshot(m *ModuleInstance) (*Snapshot, error) {
+	module := m.Source
+	if m.s != s {
+		return nil, errors.New("cannot snapshot a module from another runtime")
+	} else if module.IsHostModule {
+		return nil, errors.New("cannot snapshot a host module")
+	}
+	ret := &Snapshot{}
+
+	if module.MemorySection != nil {
+		ret.memory = make([]byte, len(m.MemoryInstance.Buffer))
+		copy(ret.memory, m.MemoryInstance.Buffer)
+	}
+
+	var indices map[Reference]Index
+	snapshotRef := func(ref Reference) (Reference, error) {
+		if ref == 0 {
+			return 0, nil
+		}
+		if indices == nil {
+			funcCount := module.ImportFunctionCount + Index(len(module.FunctionSection))
+			indices = make(map[Reference]Index, funcCount)
+			for i := Index(0); i < funcCount; i++ {
+				indices[m.Engine.FunctionInstanceReference(i)] = i
+			}
+		}
+		if idx, ok := indices[ref]; ok {
+			return Reference(idx) + 1, nil // zero is null.
+		}
+		return 0, errors.New("cannot snapshot a reference to a function of another module")
+	}
+
+	for i := module.ImportGlobalCount; i < Index(len(m.Globals)); i++ {
+		g := m.Globals[i]
+		if g.Type.ValType == ValueTypeFuncref {
+			ref, err := snapshotRef(Reference(g.Val))
+			if err != nil {
+				return nil, fmt.Errorf("global[%d]: %w", i, err)
+			}
+			ret.globals = append(ret.globals, snapshotGlobal{val: uint64(ref)})
+		} else {
+			ret.hasExternref = ret.hasExternref || (g.Type.ValType == ValueTypeExternref && g.Val != 0)
+			ret.globals = append(ret.globals, snapshotGlobal{val: g.Val, valHi: g.ValHi})
+		}
+	}
+
+	for i := module.ImportTableCount; i < Index(len(m.Tables)); i++ {
+		t := m.Tables[i]
+		refs := make([]Reference, len(t.References))
+		if t.Type == RefTypeFuncref {
+			for j, ref := range t.References {
+				var err error
+				if refs[j], err = snapshotRef(ref); err != nil {
+					return nil, fmt.Errorf("table[%d][%d]: %w", i, j, err)
+				}
+			}
+		} else {
+			for _, ref := range t.References {
+				ret.hasExternref = ret.hasExternref || ref != 0
+			}
+			copy(refs, t.References)
+		}
+		ret.tables = append(ret.tables, refs)
+	}
+
+	ret.droppedElements = make([]bool, len(m.ElementInstances))
+	for i, inst := range m.ElementInstances {
+		ret.droppedElements[i] = inst == nil
+	}
+	ret.droppedData = make([]bool, len(m.DataInstances))
+	for i, inst := range m.DataInstances {
+		ret.droppedData[i] = inst == nil
+	}
+	return ret, nil
+}
+
+// InstantiateSnapshot is like Instantiate, except the state of the memory,
+// globals and tables defined by the module is restored from the snapshot,
+// instead of initialized, and the start function isn't called.
+//
+// Note: The snapshot must be of an instance of `module`.
+func (s *Store) InstantiateSnapshot(
+	ctx context.Context,
+	module *Module,
+	snapshot *Snapshot,
+	name string,
+	sys *internalsys.Context,
+	typeIDs []FunctionTypeID,
+) (*ModuleInstance, error) {
+	return s.instantiateAndRegister(ctx, module, name, sys, typeIDs, snapshot.restore)
+}
+
+// restore initializes the module instance from the snapshot.
+func (s *Snapshot) restore(m *ModuleInstance) {
+	module := m.Source
+
+	if module.MemorySection != nil {
+		mem := m.MemoryInstance
+		if pages, snapshotPages := mem.PageSize(), memoryBytesNumToPages(uint64(len(s.memory))); snapshotPages > pages {
+			mem.Grow(snapshotPages - pages) // Can't fail, as the snapshot grew.
+		}
+		if !s.mapMemory(mem) {
+			copy(mem.Buffer, s.memory)
+		}
+	}
+
+	restoreRef := func(ref Reference) Reference {
+		if ref == 0 {
+			return 0
+		}
+		return m.Engine.FunctionInstanceReference(Index(ref - 1))
+	}
+
+	for i, g := range s.globals {
+		global := m.Globals[module.ImportGlobalCount+Index(i)]
+		if global.Type.ValType == ValueTypeFuncref {
+			global.Val = uint64(restoreRef(Reference(g.val)))
+		} else {
+			global.Val, global.ValHi = g.val, g.valHi
+		}
+	}
+
+	for i, refs := range s.tables {
+		t := m.Tables[module.ImportTableCount+Index(i)]
+		t.References = make([]Reference, len(refs))
+		if t.Type == RefTypeFuncref {
+			for j, ref := range refs {
+				t.References[j] = restoreRef(ref)
+			}
+		} else {
+			copy(t.References, refs)
+		}
+	}
+
+	for i, dropped := range s.droppedElements {
+		if dropped {
+			m.ElementInstances[i] = nil
+		}
+	}
+	m.DataInstances = make([]DataInstance, len(module.DataSection))
+	for i := range module.DataSection {
+		if !s.droppedData[i] {
+			m.DataInstances[i] = module.DataSection[i].Init
+		}
+	}
+}
+
+// mapMemory maps the memory of the snapshot copy-on-write into `mem`,
+// returning false if unsupported.
+func (s *Snapshot) mapMemory(mem *MemoryInstance) bool {
+	if mem.mmapped == nil || mem.Shared || len(s.memory) == 0 {
+		return false
+	}
+	s.memoryImageOnce.Do(func() {
+		s.memoryImage, _ = platform.NewCopyOnWriteImage(s.memory)
+	})
+	return s.memoryImage != nil && s.memoryImage.MapInto(mem.Buffer) == nil
+}
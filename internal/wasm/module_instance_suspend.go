@@ -0,0 +1,404 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tetratelabs/wazero/internal/u32"
+	"github.com/tetratelabs/wazero/internal/u64"
+	"github.com/tetratelabs/wazero/internal/version"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
+)
+
+// suspendSignal is panicked by Suspend and Await, so that the engine unwinds
+// the call to capture its state.
+type suspendSignal struct {
+	// checkpoint is true when the state of the module is captured too.
+	checkpoint bool
+}
+
+// Suspend suspends the call which called the current host function, capturing
+// the state of the module into a Checkpoint. This panics, so never returns.
+func Suspend() {
+	panic(suspendSignal{checkpoint: true})
+}
+
+// Await suspends the call which called the current host function, so that it
+// can be resumed in the same module instance by ResumeCall. This panics, so
+// never returns.
+func Await() {
+	panic(suspendSignal{})
+}
+
+// IsSuspend returns true if `recovered` was panicked by Suspend or Await.
+func IsSuspend(recovered interface{}) bool {
+	_, ok := recovered.(suspendSignal)
+	return ok
+}
+
+// IsAwait returns true if `recovered` was panicked by Await.
+func IsAwait(recovered interface{}) bool {
+	s, ok := recovered.(suspendSignal)
+	return ok && !s.checkpoint
+}
+
+// ErrRuntimeSuspendUnsupported is the cause of the error of a call suspended
+// by Suspend in a ModuleEngine which can't capture it into a Checkpoint.
+var ErrRuntimeSuspendUnsupported = wasmruntime.New("suspending a call to a checkpoint is only supported by the interpreter")
+
+// CallState is the state of a call suspended by Suspend, as captured by the
+// ModuleEngine.
+type CallState struct {
+	// FuncIndex is the index of the function which was called.
+	FuncIndex Index
+	// Stack is the value stack, including the parameters of the host function
+	// which suspended the call.
+	Stack []uint64
+	// Frames are the frames of the call, from the one of FuncIndex to the one
+	// which called the host function which suspended the call.
+	Frames []CallFrame
+	// HostParamNumInUint64 and HostResultNumInUint64 are the sizes of the
+	// parameters and results of the host function which suspended the call.
+	HostParamNumInUint64, HostResultNumInUint64 int
+	// Engine is the state of a call suspended by Await, which is specific to
+	// the ModuleEngine, instead of Stack and Frames. When it implements
+	// Resumer, it resumes the call instead of the ModuleEngine. E.g. the
+	// compiler keeps the stack of the call, which native code points into.
+	Engine interface{}
+}
+
+// CallFrame is a frame of CallState.
+type CallFrame struct {
+	// FuncIndex is the index of the function of the frame in the module.
+	FuncIndex Index
+	// PC is the position in the function, which is specific to the engine.
+	PC uint64
+	// Base is the position in CallState.Stack where the frame starts.
+	Base uint64
+}
+
+// Resumer is implemented by ModuleEngines which can resume calls suspended by
+// Suspend.
+type Resumer interface {
+	// Resume resumes the call, as if the host function which suspended it
+	// returned `results`, and returns the results of the call.
+	Resume(ctx context.Context, call *CallState, results []uint64) ([]uint64, error)
+}
+
+// Checkpoint is the state of a module instance, and of a call to it which was
+// suspended by Suspend.
+type Checkpoint struct {
+	moduleID ModuleID
+	snapshot *Snapshot
+	call     CallState
+}
+
+// SuspendedError is the error of a call suspended by Suspend or Await.
+type SuspendedError struct {
+	// Checkpoint is set when the call was suspended by Suspend.
+	Checkpoint *Checkpoint
+	// Module and Call are set when the call was suspended by Await, to
+	// resume it with Module.ResumeCall.
+	Module *ModuleInstance
+	Call   *CallState
+}
+
+// Error implements error.
+func (e *SuspendedError) Error() string {
+	return "call suspended"
+}
+
+// NewSuspendedError returns a SuspendedError with the state of the call, which
+// the ModuleEngine of `m` suspended as `recovered` was panicked by Suspend or
+// Await.
+func NewSuspendedError(m *ModuleInstance, call *CallState, recovered interface{}) error {
+	if !recovered.(suspendSignal).checkpoint {
+		return &SuspendedError{Module: m, Call: call}
+	}
+	snapshot, err := m.s.Snapshot(m)
+	if err != nil {
+		return fmt.Errorf("cannot suspend call: %w", err)
+	}
+	return &SuspendedError{Checkpoint: &Checkpoint{moduleID: m.Source.ID, snapshot: snapshot, call: *call}}
+}
+
+// Resume restores the state of `m` from the checkpoint, and resumes its call
+// as if the host function which suspended it returned `results`.
+//
+// Note: `m` must be an instance of the same module compiled the same way, e.g.
+// with the same function listeners, as the one of the checkpoint.
+func (m *ModuleInstance) Resume(ctx context.Context, c *Checkpoint, results []uint64) ([]uint64, error) {
+	if m.Source.ID != c.moduleID {
+		return nil, errors.New("checkpoint is of another module")
+	}
+	r, err := m.resumer(&c.call, results)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.snapshot.validate(m.Source); err != nil {
+		return nil, err
+	}
+	if err = m.FailIfClosed(); err != nil {
+		return nil, err
+	}
+	c.snapshot.restore(m)
+	return r.Resume(ctx, &c.call, results)
+}
+
+// ResumeCall resumes a call to `m`, which was suspended by Await, as if the
+// host function which suspended it returned `results`.
+func (m *ModuleInstance) ResumeCall(ctx context.Context, call *CallState, results []uint64) ([]uint64, error) {
+	r, err := m.resumer(call, results)
+	if err != nil {
+		return nil, err
+	}
+	return r.Resume(ctx, call, results)
+}
+
+// resumer returns the Resumer of `m`, or an error if it can't resume the call
+// with `results`.
+func (m *ModuleInstance) resumer(call *CallState, results []uint64) (Resumer, error) {
+	// The state specific to the ModuleEngine resumes the call, if any.
+	r, ok := call.Engine.(Resumer)
+	if !ok {
+		r, ok = m.Engine.(Resumer)
+	}
+	if !ok {
+		return nil, ErrRuntimeSuspendUnsupported
+	}
+	if len(results) != call.HostResultNumInUint64 {
+		return nil, fmt.Errorf("expected %d results, but passed %d", call.HostResultNumInUint64, len(results))
+	}
+	return r, nil
+}
+
+// validate returns an error if the snapshot can't be restored to an instance
+// of `module`, e.g. as it was corrupted.
+func (s *Snapshot) validate(module *Module) error {
+	errInvalid := errors.New("invalid checkpoint")
+	if (module.MemorySection == nil) != (s.memory == nil) ||
+		len(s.globals) != len(module.GlobalSection) ||
+		len(s.tables) != len(module.TableSection) ||
+		len(s.droppedElements) != len(module.ElementSection) ||
+		len(s.droppedData) != len(module.DataSection) {
+		return errInvalid
+	}
+	if mem := module.MemorySection; mem != nil {
+		if pages := memoryBytesNumToPages(uint64(len(s.memory))); pages < mem.Min || pages > mem.Max ||
+			uint64(len(s.memory)) != uint64(pages)*uint64(MemoryPageSize) {
+			return errInvalid
+		}
+	}
+	funcCount := module.ImportFunctionCount + Index(len(module.FunctionSection))
+	for i, g := range s.globals {
+		if module.GlobalSection[i].Type.ValType == ValueTypeFuncref && g.val > uint64(funcCount) {
+			return errInvalid
+		}
+	}
+	for i, refs := range s.tables {
+		t := &module.TableSection[i]
+		if uint32(len(refs)) < t.Min || (t.Max != nil && uint32(len(refs)) > *t.Max) {
+			return errInvalid
+		}
+		if t.Type == RefTypeFuncref {
+			for _, ref := range refs {
+				if ref > Reference(funcCount) {
+					return errInvalid
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkpointMagic is the header of a marshaled Checkpoint.
+const checkpointMagic = "WAZEROCP"
+
+// Marshal encodes the checkpoint, so that UnmarshalCheckpoint can decode it,
+// e.g. in another process running the same version of wazero.
+//
+// This errs if the globals or tables of the module hold a non-null externref,
+// as it is a host pointer.
+func (c *Checkpoint) Marshal() ([]byte, error) {
+	s := c.snapshot
+	if s.hasExternref {
+		return nil, errors.New("cannot marshal a checkpoint with externref values")
+	}
+
+	buf := bytes.NewBuffer(nil)
+	wazeroVersion := version.GetWazeroVersion()
+	buf.WriteString(checkpointMagic)
+	buf.WriteByte(byte(len(wazeroVersion)))
+	buf.WriteString(wazeroVersion)
+	buf.Write(c.moduleID[:])
+
+	// The snapshot of the module instance.
+	if s.memory == nil {
+		buf.WriteByte(0)
+	} else {
+		buf.WriteByte(1)
+		buf.Write(u64.LeBytes(uint64(len(s.memory))))
+		buf.Write(s.memory)
+	}
+	buf.Write(u32.LeBytes(uint32(len(s.globals))))
+	for _, g := range s.globals {
+		buf.Write(u64.LeBytes(g.val))
+		buf.Write(u64.LeBytes(g.valHi))
+	}
+	buf.Write(u32.LeBytes(uint32(len(s.tables))))
+	for _, refs := range s.tables {
+		buf.Write(u32.LeBytes(uint32(len(refs))))
+		for _, ref := range refs {
+			buf.Write(u64.LeBytes(uint64(ref)))
+		}
+	}
+	writeBools(buf, s.droppedElements)
+	writeBools(buf, s.droppedData)
+
+	// The state of the call.
+	call := &c.call
+	buf.Write(u32.LeBytes(call.FuncIndex))
+	buf.Write(u32.LeBytes(uint32(call.HostParamNumInUint64)))
+	buf.Write(u32.LeBytes(uint32(call.HostResultNumInUint64)))
+	buf.Write(u32.LeBytes(uint32(len(call.Stack))))
+	for _, v := range call.Stack {
+		buf.Write(u64.LeBytes(v))
+	}
+	buf.Write(u32.LeBytes(uint32(len(call.Frames))))
+	for _, f := range call.Frames {
+		buf.Write(u32.LeBytes(f.FuncIndex))
+		buf.Write(u64.LeBytes(f.PC))
+		buf.Write(u64.LeBytes(f.Base))
+	}
+	return buf.Bytes(), nil
+}
+
+func writeBools(buf *bytes.Buffer, bools []bool) {
+	buf.Write(u32.LeBytes(uint32(len(bools))))
+	for _, b := range bools {
+		if b {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	}
+}
+
+// UnmarshalCheckpoint decodes a checkpoint encoded by Checkpoint.Marshal.
+func UnmarshalCheckpoint(data []byte) (*Checkpoint, error) {
+	r := &checkpointReader{r: bytes.NewReader(data)}
+
+	wazeroVersion := version.GetWazeroVersion()
+	if magic := r.bytes(len(checkpointMagic)); string(magic) != checkpointMagic {
+		return nil, errors.New("invalid checkpoint: invalid header")
+	}
+	if v := r.bytes(int(r.byte())); r.err == nil && string(v) != wazeroVersion {
+		return nil, fmt.Errorf("checkpoint is of wazero %s, but this is %s", v, wazeroVersion)
+	}
+
+	c := &Checkpoint{snapshot: &Snapshot{}}
+	copy(c.moduleID[:], r.bytes(len(c.moduleID)))
+
+	s := c.snapshot
+	if r.byte() == 1 {
+		s.memory = r.bytes(int(r.u64()))
+	}
+	s.globals = make([]snapshotGlobal, r.length())
+	for i := range s.globals {
+		s.globals[i] = snapshotGlobal{val: r.u64(), valHi: r.u64()}
+	}
+	s.tables = make([][]Reference, r.length())
+	for i := range s.tables {
+		refs := make([]Reference, r.length())
+		for j := range refs {
+			refs[j] = Reference(r.u64())
+		}
+		s.tables[i] = refs
+	}
+	s.droppedElements = r.bools()
+	s.droppedData = r.bools()
+
+	call := &c.call
+	call.FuncIndex = r.u32()
+	call.HostParamNumInUint64 = int(r.u32())
+	call.HostResultNumInUint64 = int(r.u32())
+	call.Stack = make([]uint64, r.length())
+	for i := range call.Stack {
+		call.Stack[i] = r.u64()
+	}
+	call.Frames = make([]CallFrame, r.length())
+	for i := range call.Frames {
+		call.Frames[i] = CallFrame{FuncIndex: r.u32(), PC: r.u64(), Base: r.u64()}
+	}
+
+	if r.err == nil && r.r.Len() > 0 {
+		r.err = errors.New("unexpected trailing bytes")
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid checkpoint: %w", r.err)
+	}
+	return c, nil
+}
+
+// checkpointReader decodes a checkpoint, recording the first error, after
+// which reads return zero values.
+type checkpointReader struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (r *checkpointReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	} else if n > r.r.Len() {
+		r.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	ret := make([]byte, n)
+	_, _ = r.r.Read(ret)
+	return ret
+}
+
+func (r *checkpointReader) byte() byte {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *checkpointReader) u32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *checkpointReader) u64() uint64 {
+	if b := r.bytes(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+// length reads the length of a slice, and errs if the remaining bytes can't
+// hold that many elements, so that corrupted data can't allocate too much.
+func (r *checkpointReader) length() int {
+	n := r.u32()
+	if r.err == nil && int(n) > r.r.Len() {
+		r.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	return int(n)
+}
+
+func (r *checkpointReader) bools() []bool {
+	ret := make([]bool, r.length())
+	for i := range ret {
+		ret[i] = r.byte() == 1
+	}
+	return ret
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/tetratelabs/wazero/experimental/sys"
+	"github.com/tetratelabs/wazero/internal/platform"
 	internalsys "github.com/tetratelabs/wazero/internal/sys"
 	"github.com/tetratelabs/wazero/internal/sysfs"
 	testfs "github.com/tetratelabs/wazero/internal/testing/fs"
@@ -100,6 +101,11 @@ func TestModuleInstance_Close(t *testing.T) {
 
 				// Outside callers should be able to know it was closed.
 				require.True(t, m.IsClosed())
+				select {
+				case <-m.Done():
+				default:
+					t.Fatal("expected Done to be closed")
+				}
 
 				// Verify our intended side-effect
 				require.Nil(t, s.Module(moduleName))
@@ -452,3 +458,28 @@ func TestModuleInstance_ensureResourcesClosed(t *testing.T) {
 	}
 	require.Equal(t, 2, closer.called)
 }
+
+func TestModuleInstance_ensureResourcesClosed_mmap(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+
+	mem := NewMmapMemoryInstance(&Memory{Min: 1, Max: 4})
+	require.NotNil(t, mem.mmapped)
+
+	// Closing a module importing the memory doesn't release it.
+	importer := &ModuleInstance{MemoryInstance: mem, Source: &Module{}}
+	require.NoError(t, importer.ensureResourcesClosed(context.Background()))
+	require.NotNil(t, mem.mmapped)
+	require.True(t, mem.WriteByte(0, 1))
+
+	definer := &ModuleInstance{MemoryInstance: mem, Source: &Module{MemorySection: &Memory{Min: 1, Max: 4}}}
+	require.NoError(t, definer.ensureResourcesClosed(context.Background()))
+	require.Nil(t, mem.mmapped)
+	require.Equal(t, uint32(0), mem.Size())
+	_, ok := mem.ReadByte(0)
+	require.False(t, ok)
+
+	// Ensure multiple invocation is safe.
+	require.NoError(t, definer.ensureResourcesClosed(context.Background()))
+}
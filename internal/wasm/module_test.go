@@ -8,6 +8,7 @@ import (
 
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/fuel"
 	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/u64"
@@ -325,6 +326,64 @@ func TestValidateConstExpression(t *testing.T) {
 	})
 }
 
+func TestValidateConstExpression_ExtendedConst(t *testing.T) {
+	globals := []GlobalType{{ValType: ValueTypeI32}, {ValType: ValueTypeI64}}
+	tests := []struct {
+		name        string
+		data        []byte
+		expected    ValueType
+		expectedErr string
+	}{
+		{
+			name:     "i32",
+			data:     []byte{OpcodeGlobalGet, 0, OpcodeI32Const, 16, OpcodeI32Add, OpcodeI32Const, 2, OpcodeI32Mul},
+			expected: ValueTypeI32,
+		},
+		{
+			name:     "i64",
+			data:     []byte{OpcodeGlobalGet, 1, OpcodeI64Const, 1, OpcodeI64Sub},
+			expected: ValueTypeI64,
+		},
+		{
+			name:        "result type mismatch",
+			data:        []byte{OpcodeGlobalGet, 1, OpcodeI64Const, 1, OpcodeI64Sub},
+			expected:    ValueTypeI32,
+			expectedErr: "const expression type mismatch expected i32 but got i64",
+		},
+		{
+			name:        "operand type mismatch",
+			data:        []byte{OpcodeGlobalGet, 1, OpcodeI32Const, 1, OpcodeI32Add},
+			expected:    ValueTypeI32,
+			expectedErr: "type mismatch on i32.add: expected two i32 values",
+		},
+		{
+			name:        "global index out of range",
+			data:        []byte{OpcodeGlobalGet, 2, OpcodeI32Const, 1, OpcodeI32Add},
+			expected:    ValueTypeI32,
+			expectedErr: "global index out of range",
+		},
+		{
+			name:        "more than one result",
+			data:        []byte{OpcodeI32Const, 1, OpcodeI32Const, 1, OpcodeI32Const, 1, OpcodeI32Add},
+			expected:    ValueTypeI32,
+			expectedErr: "const expression must have one result, but has 2",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			expr := &ConstantExpression{Opcode: tc.data[len(tc.data)-1], Data: tc.data}
+			err := validateConstExpression(globals, 0, expr, tc.expected)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestModule_Validate_Errors(t *testing.T) {
 	zero := Index(0)
 	tests := []struct {
@@ -653,6 +712,7 @@ func TestModule_validateExports(t *testing.T) {
 		globals         []GlobalType
 		memory          *Memory
 		tables          []Table
+		tags            []Index
 		expectedErr     string
 	}{
 		{name: "empty export section", exportSection: []Export{}},
@@ -727,13 +787,26 @@ func TestModule_validateExports(t *testing.T) {
 			tables:          []Table{},
 			expectedErr:     `memory for export["e"] out of range`,
 		},
+		{
+			name:            "tag",
+			enabledFeatures: api.CoreFeaturesV2 | experimental.CoreFeaturesExceptionHandling,
+			exportSection:   []Export{{Type: ExternTypeTag, Index: 0}},
+			tags:            []Index{0},
+		},
+		{
+			name:            "tag out of range",
+			enabledFeatures: api.CoreFeaturesV2 | experimental.CoreFeaturesExceptionHandling,
+			exportSection:   []Export{{Type: ExternTypeTag, Index: 1, Name: "e"}},
+			tags:            []Index{0},
+			expectedErr:     `tag for export["e"] out of range`,
+		},
 	}
 
 	for _, tt := range tests {
 		tc := tt
 		t.Run(tc.name, func(t *testing.T) {
 			m := Module{ExportSection: tc.exportSection}
-			err := m.validateExports(tc.enabledFeatures, tc.functions, tc.globals, tc.memory, tc.tables)
+			err := m.validateExports(tc.enabledFeatures, tc.functions, tc.globals, tc.memory, tc.tables, tc.tags)
 			if tc.expectedErr != "" {
 				require.EqualError(t, err, tc.expectedErr)
 			} else {
@@ -838,7 +911,7 @@ func TestModule_buildGlobals(t *testing.T) {
 func TestModule_buildMemoryInstance(t *testing.T) {
 	t.Run("nil", func(t *testing.T) {
 		m := ModuleInstance{}
-		m.buildMemory(&Module{})
+		_ = m.buildMemory(&Module{}, nil)
 		require.Nil(t, m.MemoryInstance)
 	})
 	t.Run("non-nil", func(t *testing.T) {
@@ -846,15 +919,60 @@ func TestModule_buildMemoryInstance(t *testing.T) {
 		max := uint32(10)
 		mDef := MemoryDefinition{moduleName: "foo"}
 		m := ModuleInstance{}
-		m.buildMemory(&Module{
+		err := m.buildMemory(&Module{
 			MemorySection:           &Memory{Min: min, Cap: min, Max: max},
 			MemoryDefinitionSection: []MemoryDefinition{mDef},
-		})
+		}, nil)
+		require.NoError(t, err)
 		mem := m.MemoryInstance
 		require.Equal(t, min, mem.Min)
 		require.Equal(t, max, mem.Max)
 		require.Equal(t, &mDef, mem.definition)
 	})
+	t.Run("allocator", func(t *testing.T) {
+		var allocated [2]uint64
+		m := ModuleInstance{}
+		err := m.buildMemory(&Module{
+			MemorySection:           &Memory{Min: 1, Cap: 2, Max: 10},
+			MemoryDefinitionSection: []MemoryDefinition{{}},
+		}, experimental.MemoryAllocatorFunc(func(cap, max uint64) experimental.LinearMemory {
+			allocated = [2]uint64{cap, max}
+			return &heapLinearMemory{}
+		}))
+		require.NoError(t, err)
+		require.Equal(t, [2]uint64{2 * 65536, 10 * 65536}, allocated)
+		require.Equal(t, 65536, len(m.MemoryInstance.Buffer))
+	})
+	t.Run("allocator returns short buffer", func(t *testing.T) {
+		m := ModuleInstance{}
+		err := m.buildMemory(&Module{
+			MemorySection:           &Memory{Min: 1, Max: 10},
+			MemoryDefinitionSection: []MemoryDefinition{{}},
+		}, experimental.MemoryAllocatorFunc(func(cap, max uint64) experimental.LinearMemory {
+			return &heapLinearMemory{max: 1}
+		}))
+		require.EqualError(t, err, "memory allocator returned 0 bytes, expected 65536")
+	})
+}
+
+// heapLinearMemory is an experimental.LinearMemory on the Go heap, which
+// can't grow above max bytes if non-zero.
+type heapLinearMemory struct {
+	buf   []byte
+	max   uint64
+	freed bool
+}
+
+func (m *heapLinearMemory) Reallocate(size uint64) []byte {
+	if m.max != 0 && size > m.max {
+		return nil
+	}
+	m.buf = append(m.buf, make([]byte, size-uint64(len(m.buf)))...)
+	return m.buf
+}
+
+func (m *heapLinearMemory) Free() {
+	m.freed = true
 }
 
 func TestModule_validateDataCountSection(t *testing.T) {
@@ -1012,9 +1130,9 @@ func TestModule_declaredFunctionIndexes(t *testing.T) {
 }
 
 func TestModule_AssignModuleID(t *testing.T) {
-	getID := func(bin []byte, lsns []experimental.FunctionListener, withEnsureTermination bool) ModuleID {
+	getID := func(bin []byte, lsns []experimental.FunctionListener, withEnsureTermination bool, fuelCosts *fuel.Costs) ModuleID {
 		m := Module{}
-		m.AssignModuleID(bin, lsns, withEnsureTermination)
+		m.AssignModuleID(bin, lsns, withEnsureTermination, fuelCosts)
 		return m.ID
 	}
 
@@ -1026,6 +1144,7 @@ func TestModule_AssignModuleID(t *testing.T) {
 		bin                   []byte
 		withEnsureTermination bool
 		listeners             []experimental.FunctionListener
+		fuelCosts             *fuel.Costs
 	}{
 		{bin: []byte{1, 2, 3}, withEnsureTermination: false},
 		{bin: []byte{1, 2, 3}, withEnsureTermination: true},
@@ -1081,8 +1200,13 @@ func TestModule_AssignModuleID(t *testing.T) {
 			listeners:             []experimental.FunctionListener{ml, ml},
 			withEnsureTermination: false,
 		},
+		{bin: []byte{1, 2, 3, 4}, fuelCosts: &fuel.Costs{}},
+		{bin: []byte{1, 2, 3, 4}, fuelCosts: &fuel.Costs{Default: 1}},
+		{bin: []byte{1, 2, 3, 4}, fuelCosts: &fuel.Costs{Default: 1, Instructions: map[string]uint64{"i32.add": 1}}},
+		{bin: []byte{1, 2, 3, 4}, fuelCosts: &fuel.Costs{Default: 1, Instructions: map[string]uint64{"i32.add": 2}}},
+		{bin: []byte{1, 2, 3, 4}, fuelCosts: &fuel.Costs{Default: 1, Instructions: map[string]uint64{"i32.sub": 2}}},
 	} {
-		id := getID(tc.bin, tc.listeners, tc.withEnsureTermination)
+		id := getID(tc.bin, tc.listeners, tc.withEnsureTermination, tc.fuelCosts)
 		_, exist := exists[id]
 		require.False(t, exist, i)
 		exists[id] = struct{}{}
@@ -3,14 +3,19 @@ package wasm
 import (
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/callstack"
 	"github.com/tetratelabs/wazero/internal/close"
+	"github.com/tetratelabs/wazero/internal/importresolver"
 	"github.com/tetratelabs/wazero/internal/internalapi"
 	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/memalloc"
 	internalsys "github.com/tetratelabs/wazero/internal/sys"
 	"github.com/tetratelabs/wazero/sys"
 )
@@ -58,6 +63,17 @@ type (
 		// Note: this is fixed to 2^27 but have this a field for testability.
 		functionMaxTypes uint32
 
+		// CallStackLimits are the default ModuleInstance.CallStackLimits.
+		CallStackLimits callstack.Limits
+
+		// MmapMemory is true when memories are created with
+		// NewMmapMemoryInstance.
+		MmapMemory bool
+
+		// MemoryAllocator, when non-nil, allocates memories unless overridden
+		// by the context of the instantiation.
+		MemoryAllocator experimental.MemoryAllocator
+
 		// mux is used to guard the fields from concurrent access.
 		mux sync.RWMutex
 	}
@@ -114,6 +130,10 @@ type (
 		// See /RATIONALE.md
 		Closed atomic.Uint64
 
+		// done is lazily created by Done, and closed with the module.
+		done     chan struct{}
+		doneOnce sync.Once
+
 		// CodeCloser is non-nil when the code should be closed after this module.
 		CodeCloser api.Closer
 
@@ -126,6 +146,16 @@ type (
 
 		// CloseNotifier is an experimental hook called once on close.
 		CloseNotifier close.Notifier
+
+		// CallStackLimits limit the call stack of calls to this module.
+		CallStackLimits callstack.Limits
+
+		// Tags holds the tags of the exception handling proposal, beginning with imported tags.
+		Tags []*TagInstance
+
+		// resolve is the experimental.ImportResolver of the context which
+		// instantiated this module, if any.
+		resolve experimental.ImportResolver
 	}
 
 	// DataInstance holds bytes corresponding to the data segment in a module.
@@ -189,17 +219,7 @@ func (m *ModuleInstance) applyElements(elems []ElementSegment) {
 			len(elem.Init) == 0 {
 			continue
 		}
-		var offset uint32
-		if elem.OffsetExpr.Opcode == OpcodeGlobalGet {
-			// Ignore error as it's already validated.
-			globalIdx, _, _ := leb128.LoadUint32(elem.OffsetExpr.Data)
-			global := m.Globals[globalIdx]
-			offset = uint32(global.Val)
-		} else {
-			// Ignore error as it's already validated.
-			o, _, _ := leb128.LoadInt32(elem.OffsetExpr.Data)
-			offset = uint32(o)
-		}
+		offset := uint32(executeConstExpressionI32(m.Globals, &elem.OffsetExpr))
 
 		table := m.Tables[elem.TableIndex]
 		references := table.References
@@ -243,9 +263,8 @@ func (m *ModuleInstance) validateData(data []DataSegment) (err error) {
 	for i := range data {
 		d := &data[i]
 		if !d.IsPassive() {
-			offset := int(executeConstExpressionI32(m.Globals, &d.OffsetExpression))
-			ceil := offset + len(d.Init)
-			if offset < 0 || ceil > len(m.MemoryInstance.Buffer) {
+			offset := executeDataOffset(m.Globals, &d.OffsetExpression)
+			if offset < 0 || offset > int64(len(m.MemoryInstance.Buffer)-len(d.Init)) {
 				return fmt.Errorf("%s[%d]: out of bounds memory access", SectionIDName(SectionIDData), i)
 			}
 		}
@@ -262,8 +281,8 @@ func (m *ModuleInstance) applyData(data []DataSegment) error {
 		d := &data[i]
 		m.DataInstances[i] = d.Init
 		if !d.IsPassive() {
-			offset := executeConstExpressionI32(m.Globals, &d.OffsetExpression)
-			if offset < 0 || int(offset)+len(d.Init) > len(m.MemoryInstance.Buffer) {
+			offset := executeDataOffset(m.Globals, &d.OffsetExpression)
+			if offset < 0 || offset > int64(len(m.MemoryInstance.Buffer)-len(d.Init)) {
 				return fmt.Errorf("%s[%d]: out of bounds memory access", SectionIDName(SectionIDData), i)
 			}
 			copy(m.MemoryInstance.Buffer[offset:], d.Init)
@@ -309,9 +328,21 @@ func (s *Store) Instantiate(
 	name string,
 	sys *internalsys.Context,
 	typeIDs []FunctionTypeID,
+) (*ModuleInstance, error) {
+	return s.instantiateAndRegister(ctx, module, name, sys, typeIDs, nil)
+}
+
+// instantiateAndRegister is like instantiate, but also registers the module.
+func (s *Store) instantiateAndRegister(
+	ctx context.Context,
+	module *Module,
+	name string,
+	sys *internalsys.Context,
+	typeIDs []FunctionTypeID,
+	restore func(m *ModuleInstance),
 ) (*ModuleInstance, error) {
 	// Instantiate the module and add it to the store so that other modules can import it.
-	m, err := s.instantiate(ctx, module, name, sys, typeIDs)
+	m, err := s.instantiate(ctx, module, name, sys, typeIDs, restore)
 	if err != nil {
 		return nil, err
 	}
@@ -324,23 +355,33 @@ func (s *Store) Instantiate(
 	return m, nil
 }
 
+// instantiate creates a module instance, initializing its state from data and
+// element segments and the start function, or with `restore` when non-nil.
 func (s *Store) instantiate(
 	ctx context.Context,
 	module *Module,
 	name string,
 	sysCtx *internalsys.Context,
 	typeIDs []FunctionTypeID,
+	restore func(m *ModuleInstance),
 ) (m *ModuleInstance, err error) {
 	m = &ModuleInstance{ModuleName: name, TypeIDs: typeIDs, Sys: sysCtx, s: s, Source: module}
+	if ctx != nil { // Ensure it doesn't crash on nil!
+		m.resolve, _ = ctx.Value(importresolver.Key{}).(experimental.ImportResolver)
+	}
+	m.CallStackLimits = s.CallStackLimits.With(ctx)
 
 	m.Tables = make([]*TableInstance, int(module.ImportTableCount)+len(module.TableSection))
 	m.Globals = make([]*GlobalInstance, int(module.ImportGlobalCount)+len(module.GlobalSection))
+	if tagCount := int(module.ImportTagCount) + len(module.TagSection); tagCount > 0 {
+		m.Tags = make([]*TagInstance, tagCount)
+	}
 	m.Engine, err = s.Engine.NewModuleEngine(module, m)
 	if err != nil {
 		return nil, err
 	}
 
-	if err = m.resolveImports(module); err != nil {
+	if err = m.resolveImports(ctx, module); err != nil {
 		return nil, err
 	}
 
@@ -352,7 +393,16 @@ func (s *Store) instantiate(
 	}
 
 	m.buildGlobals(module, m.Engine.FunctionInstanceReference)
-	m.buildMemory(module)
+	allocator := s.MemoryAllocator
+	if ctx != nil {
+		if a, ok := ctx.Value(memalloc.AllocatorKey{}).(experimental.MemoryAllocator); ok {
+			allocator = a
+		}
+	}
+	if err = m.buildMemory(module, allocator); err != nil {
+		return nil, err
+	}
+	m.buildTags(module)
 	m.Exports = module.Exports
 
 	// As of reference types proposal, data segment validation must happen after instantiation,
@@ -367,17 +417,22 @@ func (s *Store) instantiate(
 	// After engine creation, we can create the funcref element instances and initialize funcref type globals.
 	m.buildElementInstances(module.ElementSection)
 
-	// Now all the validation passes, we are safe to mutate memory instances (possibly imported ones).
-	if err = m.applyData(module.DataSection); err != nil {
-		return nil, err
-	}
+	if restore != nil {
+		// The state was already initialized, so restore it instead.
+		restore(m)
+	} else {
+		// Now all the validation passes, we are safe to mutate memory instances (possibly imported ones).
+		if err = m.applyData(module.DataSection); err != nil {
+			return nil, err
+		}
 
-	m.applyElements(module.ElementSection)
+		m.applyElements(module.ElementSection)
+	}
 
 	m.Engine.DoneInstantiation()
 
-	// Execute the start function.
-	if module.StartSection != nil {
+	// Execute the start function, unless the restored state is after it.
+	if restore == nil && module.StartSection != nil {
 		funcIdx := *module.StartSection
 		ce := m.Engine.NewFunction(funcIdx)
 		_, err = ce.Call(ctx)
@@ -390,15 +445,59 @@ func (s *Store) instantiate(
 	return
 }
 
-func (m *ModuleInstance) resolveImports(module *Module) (err error) {
-	for moduleName, imports := range module.ImportPerModule {
-		var importedModule *ModuleInstance
-		importedModule, err = m.s.module(moduleName)
-		if err != nil {
-			return err
+// ImportedModule returns the module instance which this module imports from
+// as `moduleName`. This allows an Engine to inspect imports in
+// NewModuleEngine, before they are resolved.
+func (m *ModuleInstance) ImportedModule(moduleName string) (*ModuleInstance, error) {
+	return m.s.importedModule(m.resolve, moduleName)
+}
+
+// importedModule returns the module instance imported as moduleName, which
+// is the one `resolve` returns, if non-nil, or otherwise the one instantiated
+// with that name.
+func (s *Store) importedModule(resolve experimental.ImportResolver, moduleName string) (*ModuleInstance, error) {
+	if resolve != nil {
+		if mod := resolve(moduleName); mod != nil {
+			if m, ok := mod.(*ModuleInstance); ok && m.s == s {
+				return m, nil
+			}
+			return nil, fmt.Errorf("module[%s] resolved to a module not instantiated in this runtime", moduleName)
 		}
+	}
+	return s.module(moduleName)
+}
 
+func (m *ModuleInstance) resolveImports(ctx context.Context, module *Module) (err error) {
+	var resolve experimental.ImportResolver
+	var memoryImports *importresolver.MemoryImport
+	if ctx != nil { // Ensure it doesn't crash on nil!
+		resolve, _ = ctx.Value(importresolver.Key{}).(experimental.ImportResolver)
+		memoryImports, _ = ctx.Value(importresolver.MemoryKey{}).(*importresolver.MemoryImport)
+	}
+	for moduleName, imports := range module.ImportPerModule {
+		var importedModule *ModuleInstance
 		for _, i := range imports {
+			if i.Type == ExternTypeMemory {
+				if mem := memoryImports.Lookup(moduleName, i.Name); mem != nil {
+					importedMemory := mem.(*MemoryInstance)
+					if owner := importedMemory.owner; owner == nil || owner.s != m.s {
+						return errorInvalidImport(i, errors.New("memory not instantiated in this runtime"))
+					}
+					if err = m.resolveImportedMemory(i, importedMemory, importedMemory.owner); err != nil {
+						return
+					}
+					continue
+				}
+			}
+
+			// Only require the imported module if not all its imports are
+			// overridden.
+			if importedModule == nil {
+				if importedModule, err = m.s.importedModule(resolve, moduleName); err != nil {
+					return
+				}
+			}
+
 			var imported *Export
 			imported, err = importedModule.getExport(i.Name, i.Type)
 			if err != nil {
@@ -442,20 +541,9 @@ func (m *ModuleInstance) resolveImports(module *Module) (err error) {
 				}
 				m.Tables[i.IndexPerType] = importedTable
 			case ExternTypeMemory:
-				expected := i.DescMem
-				importedMemory := importedModule.MemoryInstance
-
-				if expected.Min > memoryBytesNumToPages(uint64(len(importedMemory.Buffer))) {
-					err = errorMinSizeMismatch(i, expected.Min, importedMemory.Min)
+				if err = m.resolveImportedMemory(i, importedModule.MemoryInstance, importedModule); err != nil {
 					return
 				}
-
-				if expected.Max < importedMemory.Max {
-					err = errorMaxSizeMismatch(i, expected.Max, importedMemory.Max)
-					return
-				}
-				m.MemoryInstance = importedMemory
-				m.Engine.ResolveImportedMemory(importedModule.Engine)
 			case ExternTypeGlobal:
 				expected := i.DescGlobal
 				importedGlobal := importedModule.Globals[imported.Index]
@@ -472,12 +560,44 @@ func (m *ModuleInstance) resolveImports(module *Module) (err error) {
 					return
 				}
 				m.Globals[i.IndexPerType] = importedGlobal
+			case ExternTypeTag:
+				expected := &module.TypeSection[i.DescTag]
+				importedTag := importedModule.Tags[imported.Index]
+				if !importedTag.Type.EqualsSignature(expected.Params, expected.Results) {
+					err = errorInvalidImport(i, fmt.Errorf("signature mismatch: %s != %s", expected, importedTag.Type))
+					return
+				}
+				m.Tags[i.IndexPerType] = importedTag
 			}
 		}
 	}
 	return
 }
 
+// resolveImportedMemory resolves the memory import `i` to `importedMemory`,
+// which is exported by `importedModule`.
+func (m *ModuleInstance) resolveImportedMemory(i *Import, importedMemory *MemoryInstance, importedModule *ModuleInstance) error {
+	expected := i.DescMem
+	if expected.Is64 != importedMemory.Is64 {
+		return errorInvalidImport(i, fmt.Errorf("address type mismatch: %s != %s",
+			ValueTypeName(addressType(expected.Is64)), ValueTypeName(addressType(importedMemory.Is64))))
+	}
+	if expected.IsShared != importedMemory.Shared {
+		return errorInvalidImport(i, fmt.Errorf("shared mismatch: %v != %v", expected.IsShared, importedMemory.Shared))
+	}
+
+	if expected.Min > memoryBytesNumToPages(uint64(len(importedMemory.Buffer))) {
+		return errorMinSizeMismatch(i, expected.Min, importedMemory.Min)
+	}
+
+	if expected.Max < importedMemory.Max {
+		return errorMaxSizeMismatch(i, expected.Max, importedMemory.Max)
+	}
+	m.MemoryInstance = importedMemory
+	m.Engine.ResolveImportedMemory(importedModule.Engine)
+	return nil
+}
+
 func errorMinSizeMismatch(i *Import, expected, actual uint32) error {
 	return errorInvalidImport(i, fmt.Errorf("minimum size mismatch: %d > %d", expected, actual))
 }
@@ -505,10 +625,32 @@ func executeConstExpressionI32(importedGlobals []*GlobalInstance, expr *Constant
 		id, _, _ := leb128.LoadUint32(expr.Data)
 		g := importedGlobals[id]
 		ret = int32(g.Val)
+	case OpcodeI32Add, OpcodeI32Sub, OpcodeI32Mul:
+		_, v := evalExtendedConstInstance(importedGlobals, expr)
+		ret = int32(v)
 	}
 	return
 }
 
+// executeDataOffset returns the offset of an active data segment, which is an
+// i64 for a 64-bit memory, or an i32 otherwise.
+func executeDataOffset(importedGlobals []*GlobalInstance, expr *ConstantExpression) (ret int64) {
+	switch expr.Opcode {
+	case OpcodeI64Const:
+		ret, _, _ = leb128.LoadInt64(expr.Data)
+		return
+	case OpcodeGlobalGet:
+		id, _, _ := leb128.LoadUint32(expr.Data)
+		if g := importedGlobals[id]; g.Type.ValType == ValueTypeI64 {
+			return int64(g.Val)
+		}
+	case OpcodeI64Add, OpcodeI64Sub, OpcodeI64Mul:
+		_, v := evalExtendedConstInstance(importedGlobals, expr)
+		return int64(v)
+	}
+	return int64(executeConstExpressionI32(importedGlobals, expr))
+}
+
 // initialize initializes the value of this global instance given the const expr and imported globals.
 // funcRefResolver is called to get the actual funcref (engine specific) from the OpcodeRefFunc const expr.
 //
@@ -555,6 +697,8 @@ func (g *GlobalInstance) initialize(importedGlobals []*GlobalInstance, expr *Con
 		g.Val = uint64(funcRefResolver(v))
 	case OpcodeVecV128Const:
 		g.Val, g.ValHi = binary.LittleEndian.Uint64(expr.Data[0:8]), binary.LittleEndian.Uint64(expr.Data[8:16])
+	case OpcodeI32Add, OpcodeI32Sub, OpcodeI32Mul, OpcodeI64Add, OpcodeI64Sub, OpcodeI64Mul:
+		_, g.Val = evalExtendedConstInstance(importedGlobals, expr)
 	}
 }
 
@@ -688,15 +688,39 @@ func Test_resolveImports(t *testing.T) {
 
 	t.Run("module not instantiated", func(t *testing.T) {
 		m := &ModuleInstance{s: newStore()}
-		err := m.resolveImports(&Module{ImportPerModule: map[string][]*Import{"unknown": {{}}}})
+		err := m.resolveImports(testCtx, &Module{ImportPerModule: map[string][]*Import{"unknown": {{}}}})
 		require.EqualError(t, err, "module[unknown] not instantiated")
 	})
 	t.Run("export instance not found", func(t *testing.T) {
 		m := &ModuleInstance{s: newStore()}
 		m.s.nameToModule[moduleName] = &ModuleInstance{Exports: map[string]*Export{}, ModuleName: moduleName}
-		err := m.resolveImports(&Module{ImportPerModule: map[string][]*Import{moduleName: {{Name: "unknown"}}}})
+		err := m.resolveImports(testCtx, &Module{ImportPerModule: map[string][]*Import{moduleName: {{Name: "unknown"}}}})
 		require.EqualError(t, err, "\"unknown\" is not exported in module \"test\"")
 	})
+	t.Run("import resolver", func(t *testing.T) {
+		s := newStore()
+		resolved := &ModuleInstance{Exports: map[string]*Export{}, ModuleName: "resolved", s: s}
+		ctx := experimental.WithImportResolver(testCtx, func(name string) api.Module {
+			switch name {
+			case moduleName:
+				return resolved
+			case "other store":
+				return &ModuleInstance{s: newStore()}
+			}
+			return nil
+		})
+
+		m := &ModuleInstance{s: s}
+		err := m.resolveImports(ctx, &Module{ImportPerModule: map[string][]*Import{moduleName: {{Name: "unknown"}}}})
+		require.EqualError(t, err, "\"unknown\" is not exported in module \"resolved\"")
+
+		err = m.resolveImports(ctx, &Module{ImportPerModule: map[string][]*Import{"other store": {{}}}})
+		require.EqualError(t, err, "module[other store] resolved to a module not instantiated in this runtime")
+
+		// Otherwise, this falls back to the modules in the store.
+		err = m.resolveImports(ctx, &Module{ImportPerModule: map[string][]*Import{"unknown": {{}}}})
+		require.EqualError(t, err, "module[unknown] not instantiated")
+	})
 	t.Run("func", func(t *testing.T) {
 		t.Run("ok", func(t *testing.T) {
 			s := newStore()
@@ -730,7 +754,7 @@ func Test_resolveImports(t *testing.T) {
 			}
 
 			m := &ModuleInstance{Engine: &mockModuleEngine{resolveImportsCalled: map[Index]Index{}}, s: s, Source: module}
-			err := m.resolveImports(module)
+			err := m.resolveImports(testCtx, module)
 			require.NoError(t, err)
 
 			me := m.Engine.(*mockModuleEngine)
@@ -760,7 +784,7 @@ func Test_resolveImports(t *testing.T) {
 			}
 
 			m := &ModuleInstance{Engine: &mockModuleEngine{resolveImportsCalled: map[Index]Index{}}, s: s, Source: module}
-			err := m.resolveImports(module)
+			err := m.resolveImports(testCtx, module)
 			require.EqualError(t, err, "import func[test.target]: signature mismatch: v_f32 != v_v")
 		})
 	})
@@ -773,7 +797,7 @@ func Test_resolveImports(t *testing.T) {
 				Globals: []*GlobalInstance{g},
 				Exports: map[string]*Export{name: {Type: ExternTypeGlobal, Index: 0}}, ModuleName: moduleName,
 			}
-			err := m.resolveImports(
+			err := m.resolveImports(testCtx,
 				&Module{
 					ImportPerModule: map[string][]*Import{moduleName: {{Name: name, Type: ExternTypeGlobal, DescGlobal: g.Type}}},
 				},
@@ -792,7 +816,7 @@ func Test_resolveImports(t *testing.T) {
 				ModuleName: moduleName,
 			}
 			m := &ModuleInstance{Globals: make([]*GlobalInstance, 1), s: s}
-			err := m.resolveImports(&Module{
+			err := m.resolveImports(testCtx, &Module{
 				ImportPerModule: map[string][]*Import{moduleName: {
 					{Module: moduleName, Name: name, Type: ExternTypeGlobal, DescGlobal: GlobalType{Mutable: true}},
 				}},
@@ -810,7 +834,7 @@ func Test_resolveImports(t *testing.T) {
 				ModuleName: moduleName,
 			}
 			m := &ModuleInstance{Globals: make([]*GlobalInstance, 1), s: s}
-			err := m.resolveImports(&Module{
+			err := m.resolveImports(testCtx, &Module{
 				ImportPerModule: map[string][]*Import{moduleName: {
 					{Module: moduleName, Name: name, Type: ExternTypeGlobal, DescGlobal: GlobalType{ValType: ValueTypeF64}},
 				}},
@@ -833,7 +857,7 @@ func Test_resolveImports(t *testing.T) {
 				Engine:     importedME,
 			}
 			m := &ModuleInstance{s: s, Engine: &mockModuleEngine{resolveImportsCalled: map[Index]Index{}}}
-			err := m.resolveImports(&Module{
+			err := m.resolveImports(testCtx, &Module{
 				ImportPerModule: map[string][]*Import{
 					moduleName: {{Module: moduleName, Name: name, Type: ExternTypeMemory, DescMem: &Memory{Max: max}}},
 				},
@@ -853,7 +877,7 @@ func Test_resolveImports(t *testing.T) {
 				ModuleName: moduleName,
 			}
 			m := &ModuleInstance{s: s}
-			err := m.resolveImports(&Module{
+			err := m.resolveImports(testCtx, &Module{
 				ImportPerModule: map[string][]*Import{
 					moduleName: {{Module: moduleName, Name: name, Type: ExternTypeMemory, DescMem: importMemoryType}},
 				},
@@ -873,7 +897,7 @@ func Test_resolveImports(t *testing.T) {
 			max := uint32(10)
 			importMemoryType := &Memory{Max: max}
 			m := &ModuleInstance{s: s}
-			err := m.resolveImports(&Module{
+			err := m.resolveImports(testCtx, &Module{
 				ImportPerModule: map[string][]*Import{moduleName: {{Module: moduleName, Name: name, Type: ExternTypeMemory, DescMem: importMemoryType}}},
 			})
 			require.EqualError(t, err, "import memory[test.target]: maximum size mismatch: 10 < 65536")
@@ -216,6 +216,10 @@ func (m *Module) validateTable(enabledFeatures api.CoreFeatures, tables []Table,
 						return err
 					}
 				}
+			} else if IsExtendedConstOpcode(oc) {
+				if err := validateConstExpression(m.importedGlobalTypes(), 0, &elem.OffsetExpr, ValueTypeI32); err != nil {
+					return fmt.Errorf("%s[%d] has an invalid const expression: %w", SectionIDName(SectionIDElement), idx, err)
+				}
 			} else {
 				return fmt.Errorf("%s[%d] has an invalid const expression: %s", SectionIDName(SectionIDElement), idx, InstructionName(oc))
 			}
@@ -247,17 +251,7 @@ func (m *ModuleInstance) buildTables(module *Module, skipBoundCheck bool) (err e
 		for elemI := range module.ElementSection { // Do not loop over the value since elementSegments is a slice of value.
 			elem := &module.ElementSection[elemI]
 			table := m.Tables[elem.TableIndex]
-			var offset uint32
-			if elem.OffsetExpr.Opcode == OpcodeGlobalGet {
-				// Ignore error as it's already validated.
-				globalIdx, _, _ := leb128.LoadUint32(elem.OffsetExpr.Data)
-				global := m.Globals[globalIdx]
-				offset = uint32(global.Val)
-			} else { // i32.const
-				// Ignore error as it's already validated.
-				o, _, _ := leb128.LoadInt32(elem.OffsetExpr.Data)
-				offset = uint32(o)
-			}
+			offset := uint32(executeConstExpressionI32(m.Globals, &elem.OffsetExpr))
 
 			// Check to see if we are out-of-bounds
 			initCount := uint64(len(elem.Init))
@@ -300,6 +294,17 @@ func (m *Module) verifyImportGlobalI32(sectionID SectionID, sectionIdx Index, id
 	return fmt.Errorf("%s[%d] (global.get %d): out of range of imported globals", SectionIDName(sectionID), sectionIdx, idx)
 }
 
+// importedGlobalTypes returns the types of the imported globals, which
+// constant expressions can refer to.
+func (m *Module) importedGlobalTypes() (ret []GlobalType) {
+	for i := range m.ImportSection {
+		if imp := &m.ImportSection[i]; imp.Type == ExternTypeGlobal {
+			ret = append(ret, imp.DescGlobal)
+		}
+	}
+	return
+}
+
 // Grow appends the `initialRef` by `delta` times into the References slice.
 // Returns -1 if the operation is not valid, otherwise the old length of the table.
 //
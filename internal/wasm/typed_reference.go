@@ -0,0 +1,72 @@
+package wasm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/leb128"
+)
+
+// Leading bytes of the typed references of the function references proposal.
+const (
+	// RefTypeNullablePrefix prefixes the heap type of a `(ref null ht)` type.
+	RefTypeNullablePrefix byte = 0x63
+	// RefTypeNonNullablePrefix prefixes the heap type of a `(ref ht)` type.
+	RefTypeNonNullablePrefix byte = 0x64
+)
+
+// DecodeRefType decodes the rest of the typed reference whose leading byte
+// `b` was read, and returns the ValueType representing it, i.e. the funcref,
+// externref or exnref it is a subtype of, and whether it is nullable.
+// `typeCount` is the count of types its heap type can refer to.
+//
+// This returns `b` itself if it isn't a typed reference.
+func DecodeRefType(r *bytes.Reader, b byte, enabledFeatures api.CoreFeatures, typeCount int) (vt ValueType, nullable bool, err error) {
+	switch b {
+	case RefTypeNullablePrefix, RefTypeNonNullablePrefix:
+	default:
+		return b, true, nil
+	}
+	if !enabledFeatures.IsEnabled(experimental.CoreFeaturesFunctionReferences) {
+		return 0, false, errors.New(`typed reference invalid as feature "function-references" is disabled`)
+	}
+	vt, _, err = DecodeHeapType(r, enabledFeatures, typeCount)
+	return vt, b == RefTypeNullablePrefix, err
+}
+
+// DecodeHeapType decodes the heap type of a typed reference, or of the
+// ref.null instruction, and returns the ValueType of its nullable references,
+// as well as the count of bytes read. For example, this returns
+// ValueTypeFuncref for a type index, as all types are function types.
+func DecodeHeapType(r *bytes.Reader, enabledFeatures api.CoreFeatures, typeCount int) (ValueType, uint64, error) {
+	raw, num, err := leb128.DecodeInt33AsInt64(r)
+	if err != nil {
+		return 0, 0, fmt.Errorf("read heap type: %w", err)
+	}
+	switch raw {
+	case -16: // 0x70 in original byte = func
+		return ValueTypeFuncref, num, nil
+	case -17: // 0x6f in original byte = extern
+		return ValueTypeExternref, num, nil
+	case -23: // 0x69 in original byte = exn
+		if !enabledFeatures.IsEnabled(experimental.CoreFeaturesExceptionHandling) {
+			return 0, 0, errors.New(`heap type exn invalid as feature "exception-handling" is disabled`)
+		}
+		return ValueTypeExnref, num, nil
+	}
+	if raw < 0 {
+		if name, ok := GCValueTypeName(ValueType(raw & 0x7f)); ok {
+			return 0, 0, fmt.Errorf("heap type of %s invalid as %w", name, ErrGCUnsupported)
+		}
+		return 0, 0, fmt.Errorf("invalid heap type: %d", raw)
+	}
+	if !enabledFeatures.IsEnabled(experimental.CoreFeaturesFunctionReferences) {
+		return 0, 0, errors.New(`heap type index invalid as feature "function-references" is disabled`)
+	} else if raw >= int64(typeCount) {
+		return 0, 0, fmt.Errorf("heap type index out of range: %d", raw)
+	}
+	return ValueTypeFuncref, num, nil
+}
@@ -4,6 +4,7 @@
 package wasmdebug
 
 import (
+	"errors"
 	"fmt"
 	"runtime"
 	"runtime/debug"
@@ -43,25 +44,23 @@ func FuncName(moduleName, funcName string, funcIdx uint32) string {
 // signature returns a formatted signature similar to how it is defined in Go.
 //
 // * paramTypes should be from wasm.FunctionType
+// * paramNames are index-correlated with paramTypes or nil if unknown
 // * resultTypes should be from wasm.FunctionType
-// TODO: add paramNames
-func signature(funcName string, paramTypes []api.ValueType, resultTypes []api.ValueType) string {
+func signature(funcName string, paramTypes []api.ValueType, paramNames []string, resultTypes []api.ValueType) string {
 	var ret strings.Builder
 	ret.WriteString(funcName)
 
 	// Start params
 	ret.WriteByte('(')
-	paramCount := len(paramTypes)
-	switch paramCount {
-	case 0:
-	case 1:
-		ret.WriteString(api.ValueTypeName(paramTypes[0]))
-	default:
-		ret.WriteString(api.ValueTypeName(paramTypes[0]))
-		for _, vt := range paramTypes[1:] {
+	for i, vt := range paramTypes {
+		if i > 0 {
 			ret.WriteByte(',')
-			ret.WriteString(api.ValueTypeName(vt))
 		}
+		if i < len(paramNames) && paramNames[i] != "" {
+			ret.WriteString(paramNames[i])
+			ret.WriteByte(' ')
+		}
+		ret.WriteString(api.ValueTypeName(vt))
 	}
 	ret.WriteByte(')')
 
@@ -94,11 +93,12 @@ type ErrorBuilder interface {
 	//
 	// * funcName should be from FuncName
 	// * paramTypes should be from wasm.FunctionType
+	// * paramNames are from the name section, or nil if unknown
 	// * resultTypes should be from wasm.FunctionType
 	// * sources is the source code information for this frame and can be empty.
 	//
 	// Note: paramTypes and resultTypes are present because signature misunderstanding, mismatch or overflow are common.
-	AddFrame(funcName string, paramTypes, resultTypes []api.ValueType, sources []string)
+	AddFrame(funcName string, paramTypes []api.ValueType, paramNames []string, resultTypes []api.ValueType, sources []string)
 
 	// FromRecovered returns an error with the wasm stack trace appended to it.
 	FromRecovered(recovered interface{}) error
@@ -123,12 +123,17 @@ func (s *stackTrace) FromRecovered(recovered interface{}) error {
 
 	if exitErr, ok := recovered.(*sys.ExitError); ok { // Don't wrap an exit error!
 		return exitErr
+	} else if trapErr, ok := recovered.(*sys.TrapError); ok { // nor a trap, so callers can switch on it.
+		return trapErr
 	}
 
 	stack := strings.Join(s.frames, "\n\t")
 
 	// If the error was internal, don't mention it was recovered.
 	if wasmErr, ok := recovered.(*wasmruntime.Error); ok {
+		if errors.Is(wasmErr, wasmruntime.ErrRuntimeStackOverflow) {
+			stack = s.deepest(stackOverflowFrames)
+		}
 		return fmt.Errorf("wasm error: %w\nwasm stack trace:\n\t%s", wasmErr, stack)
 	}
 
@@ -147,9 +152,32 @@ func (s *stackTrace) FromRecovered(recovered interface{}) error {
 	}
 }
 
+// stackOverflowFrames is the count of the deepest frames in the stack trace of
+// a stack overflow, as the others are usually more of the same recursion.
+const stackOverflowFrames = 32
+
+// deepest returns the stack trace of the `n` deepest frames, followed by the
+// count of the omitted ones.
+func (s *stackTrace) deepest(n int) string {
+	count, end := 0, len(s.frames)
+	for i, frame := range s.frames {
+		if strings.HasPrefix(frame, "\t") { // source of the previous frame
+			continue
+		}
+		if count == n {
+			end = i
+		}
+		count++
+	}
+	if count <= n {
+		return strings.Join(s.frames, "\n\t")
+	}
+	return fmt.Sprintf("%s\n\t... %d frames omitted", strings.Join(s.frames[:end], "\n\t"), count-n)
+}
+
 // AddFrame implements ErrorBuilder.AddFrame
-func (s *stackTrace) AddFrame(funcName string, paramTypes, resultTypes []api.ValueType, sources []string) {
-	sig := signature(funcName, paramTypes, resultTypes)
+func (s *stackTrace) AddFrame(funcName string, paramTypes []api.ValueType, paramNames []string, resultTypes []api.ValueType, sources []string) {
+	sig := signature(funcName, paramTypes, paramNames, resultTypes)
 	s.frames = append(s.frames, sig)
 	for _, source := range sources {
 		s.frames = append(s.frames, "\t"+source)
@@ -8,6 +8,7 @@ import (
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasmruntime"
+	"github.com/tetratelabs/wazero/sys"
 )
 
 func TestFuncName(t *testing.T) {
@@ -41,6 +42,7 @@ func TestAddSignature(t *testing.T) {
 	tests := []struct {
 		name                    string
 		paramTypes, resultTypes []api.ValueType
+		paramNames              []string
 		expected                string
 	}{
 		{name: "v_v", expected: "x.y()"},
@@ -53,12 +55,14 @@ func TestAddSignature(t *testing.T) {
 		{name: "i32_i64", paramTypes: []api.ValueType{i32}, resultTypes: []api.ValueType{i64}, expected: "x.y(i32) i64"},
 		{name: "i64f32_i64f32", paramTypes: []api.ValueType{i64, f32}, resultTypes: []api.ValueType{i64, f32}, expected: "x.y(i64,f32) (i64,f32)"},
 		{name: "i64f32f64_f32i32f64", paramTypes: []api.ValueType{i64, f32, f64}, resultTypes: []api.ValueType{f32, i32, f64}, expected: "x.y(i64,f32,f64) (f32,i32,f64)"},
+		{name: "named i32f64_v", paramTypes: []api.ValueType{i32, f64}, paramNames: []string{"a", "b"}, expected: "x.y(a i32,b f64)"},
+		{name: "partly named i32f64_i64", paramTypes: []api.ValueType{i32, f64}, paramNames: []string{"", "b"}, resultTypes: []api.ValueType{i64}, expected: "x.y(i32,b f64) i64"},
 	}
 
 	for _, tt := range tests {
 		tc := tt
 		t.Run(tc.name, func(t *testing.T) {
-			withSignature := signature("x.y", tc.paramTypes, tc.resultTypes)
+			withSignature := signature("x.y", tc.paramTypes, tc.paramNames, tc.resultTypes)
 			require.Equal(t, tc.expected, withSignature)
 		})
 	}
@@ -81,7 +85,7 @@ func TestErrorBuilder(t *testing.T) {
 		{
 			name: "one",
 			build: func(builder ErrorBuilder) error {
-				builder.AddFrame("x.y", nil, nil, nil)
+				builder.AddFrame("x.y", nil, nil, nil, nil)
 				return builder.FromRecovered(argErr)
 			},
 			expectedErr: `invalid argument (recovered by wazero)
@@ -92,8 +96,8 @@ wasm stack trace:
 		{
 			name: "two",
 			build: func(builder ErrorBuilder) error {
-				builder.AddFrame("wasi_snapshot_preview1.fd_write", i32i32i32i32, []api.ValueType{i32}, nil)
-				builder.AddFrame("x.y", nil, nil, nil)
+				builder.AddFrame("wasi_snapshot_preview1.fd_write", i32i32i32i32, nil, []api.ValueType{i32}, nil)
+				builder.AddFrame("x.y", nil, nil, nil, nil)
 				return builder.FromRecovered(argErr)
 			},
 			expectedErr: `invalid argument (recovered by wazero)
@@ -105,9 +109,9 @@ wasm stack trace:
 		{
 			name: "wasmruntime.Error",
 			build: func(builder ErrorBuilder) error {
-				builder.AddFrame("wasi_snapshot_preview1.fd_write", i32i32i32i32, []api.ValueType{i32},
+				builder.AddFrame("wasi_snapshot_preview1.fd_write", i32i32i32i32, nil, []api.ValueType{i32},
 					[]string{"/opt/homebrew/Cellar/tinygo/0.26.0/src/runtime/runtime_tinygowasm.go:73:6"})
-				builder.AddFrame("x.y", nil, nil, nil)
+				builder.AddFrame("x.y", nil, nil, nil, nil)
 				return builder.FromRecovered(wasmruntime.ErrRuntimeStackOverflow)
 			},
 			expectedErr: `wasm error: stack overflow
@@ -129,10 +133,18 @@ wasm stack trace:
 	}
 }
 
+func TestErrorBuilder_TrapError(t *testing.T) {
+	builder := NewErrorBuilder()
+	builder.AddFrame("x.y", nil, nil, nil, nil)
+	trap := sys.NewTrapError(42, nil)
+	// The trap isn't wrapped, so that callers can switch on it.
+	require.Equal(t, error(trap), builder.FromRecovered(trap))
+}
+
 func TestErrorBuilderGoRuntimeError(t *testing.T) {
 	builder := NewErrorBuilder()
-	builder.AddFrame("wasi_snapshot_preview1.fd_write", i32i32i32i32, []api.ValueType{i32}, nil)
-	builder.AddFrame("x.y", nil, nil, nil)
+	builder.AddFrame("wasi_snapshot_preview1.fd_write", i32i32i32i32, nil, []api.ValueType{i32}, nil)
+	builder.AddFrame("x.y", nil, nil, nil, nil)
 	withStackTrace := builder.FromRecovered(rteErr)
 
 	require.Equal(t, rteErr, errors.Unwrap(withStackTrace))
@@ -17,7 +17,32 @@ type DWARFLines struct {
 	// linesPerEntry maps dwarf.Offset for dwarf.Entry to the list of lines contained by the entry.
 	// The value is sorted in the increasing order by the address.
 	linesPerEntry map[dwarf.Offset][]line
-	mux           sync.Mutex
+	// subprograms are the functions in the DWARF data sorted by their start
+	// address, built on demand.
+	subprograms []subprogram
+	mux         sync.Mutex
+}
+
+// subprogram is the address range [start, end) of a function named name.
+type subprogram struct {
+	start, end uint64
+	name       string
+}
+
+// Location is a position in a source file resolved from DWARF data.
+type Location struct {
+	// Function is the name of the function at this location, or empty if
+	// unknown, e.g. "main.main".
+	Function string
+	// File is the path of the source file.
+	File string
+	// Line is the 1-based line number, or zero if unknown.
+	Line int64
+	// Column is the 1-based column number, or zero if unknown.
+	Column int64
+	// Inlined is true when Function was inlined into the function of the
+	// next location.
+	Inlined bool
 }
 
 type line struct {
@@ -49,6 +74,98 @@ func isTombstoneAddr(addr uint64) bool {
 // Line returns the line information for the given instructionOffset which is an offset in
 // the code section of the original Wasm binary. Returns empty string if the info is not found.
 func (d *DWARFLines) Line(instructionOffset uint64) (ret []string) {
+	prefix := fmt.Sprintf("%#x: ", instructionOffset)
+	for i, l := range d.Locations(instructionOffset) {
+		if i == 1 {
+			prefix = strings.Repeat(" ", len(prefix))
+		}
+		ret = append(ret, formatLine(prefix, l.File, l.Line, l.Column, l.Inlined))
+	}
+	return
+}
+
+// FunctionName returns the name of the function whose code includes the given instructionOffset,
+// which is an offset in the code section of the original Wasm binary, or empty if unknown.
+func (d *DWARFLines) FunctionName(instructionOffset uint64) string {
+	if d == nil {
+		return ""
+	}
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	return d.functionName(instructionOffset)
+}
+
+// functionName is FunctionName while holding the lock.
+func (d *DWARFLines) functionName(instructionOffset uint64) string {
+	if d.subprograms == nil {
+		d.buildSubprograms()
+	}
+	n := len(d.subprograms)
+	// Find the last function starting at or before the instruction.
+	index := sort.Search(n, func(i int) bool { return d.subprograms[i].start > instructionOffset }) - 1
+	if index >= 0 && instructionOffset < d.subprograms[index].end {
+		return d.subprograms[index].name
+	}
+	return ""
+}
+
+func (d *DWARFLines) buildSubprograms() {
+	d.subprograms = []subprogram{} // non-nil even if empty, so this is built once.
+	r := d.d.Reader()
+	for {
+		ent, err := r.Next()
+		if err != nil || ent == nil {
+			break
+		}
+		if ent.Tag != dwarf.TagSubprogram {
+			continue
+		}
+		ranges, err := d.d.Ranges(ent)
+		if err != nil || len(ranges) == 0 {
+			continue
+		}
+		name := d.entryName(ent)
+		for _, pcs := range ranges {
+			if isTombstoneAddr(pcs[0]) || isTombstoneAddr(pcs[1]) {
+				continue
+			}
+			d.subprograms = append(d.subprograms, subprogram{start: pcs[0], end: pcs[1], name: name})
+		}
+	}
+	sort.Slice(d.subprograms, func(i, j int) bool { return d.subprograms[i].start < d.subprograms[j].start })
+}
+
+// entryName returns the name of a subprogram or an inlined subroutine, which may be on the entry it is
+// the concrete instance of.
+func (d *DWARFLines) entryName(ent *dwarf.Entry) string {
+	for i := 0; i < 4; i++ { // Guard against ill-formed DWARF info referring to itself.
+		if name, ok := ent.Val(dwarf.AttrName).(string); ok {
+			return name
+		} else if name, ok = ent.Val(dwarf.AttrLinkageName).(string); ok {
+			return name
+		}
+		origin, ok := ent.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset)
+		if !ok {
+			if origin, ok = ent.Val(dwarf.AttrSpecification).(dwarf.Offset); !ok {
+				return ""
+			}
+		}
+		r := d.d.Reader()
+		r.Seek(origin)
+		var err error
+		if ent, err = r.Next(); err != nil || ent == nil {
+			return ""
+		}
+	}
+	return ""
+}
+
+// Locations returns the source locations for the given instructionOffset which is an offset in
+// the code section of the original Wasm binary, or nil if the info is not found.
+//
+// When functions were inlined, the first location is in the innermost one, followed by the
+// locations they were called from.
+func (d *DWARFLines) Locations(instructionOffset uint64) (ret []Location) {
 	if d == nil {
 		return
 	}
@@ -177,13 +294,25 @@ entry:
 		panic("BUG: stored dwarf.LineReaderPos is invalid")
 	}
 
+	// functionOf returns the name of the function which the inlined routine at `i` was inlined into.
+	functionOf := func(i int) string {
+		if i < 0 {
+			return d.functionName(instructionOffset)
+		}
+		return d.entryName(inlinedRoutines[i])
+	}
+
 	// In the inlined case, the line info is the innermost inlined function call.
 	inlined := len(inlinedRoutines) != 0
-	prefix := fmt.Sprintf("%#x: ", instructionOffset)
-	ret = append(ret, formatLine(prefix, le.File.Name, int64(le.Line), int64(le.Column), inlined))
+	ret = append(ret, Location{
+		Function: functionOf(len(inlinedRoutines) - 1),
+		File:     le.File.Name,
+		Line:     int64(le.Line),
+		Column:   int64(le.Column),
+		Inlined:  inlined,
+	})
 
 	if inlined {
-		prefix = strings.Repeat(" ", len(prefix))
 		files := lineReader.Files()
 		// inlinedRoutines contain the inlined call information in the reverse order (children is higher than parent),
 		// so we traverse the reverse order and emit the inlined calls.
@@ -192,16 +321,20 @@ entry:
 			fileIndex, ok := inlined.Val(dwarf.AttrCallFile).(int64)
 			if !ok {
 				return
-			} else if fileIndex >= int64(len(files)) {
+			} else if fileIndex >= int64(len(files)) || files[fileIndex] == nil {
 				// This in theory shouldn't happen according to the spec, but guard against ill-formed DWARF info.
 				return
 			}
-			fileName := files[fileIndex]
 			line, _ := inlined.Val(dwarf.AttrCallLine).(int64)
 			col, _ := inlined.Val(dwarf.AttrCallColumn).(int64)
-			ret = append(ret, formatLine(prefix, fileName.Name, line, col,
+			ret = append(ret, Location{
+				Function: functionOf(i - 1),
+				File:     files[fileIndex].Name,
+				Line:     line,
+				Column:   col,
 				// Last one is the origin of the inlined function calls.
-				i != 0))
+				Inlined: i != 0,
+			})
 		}
 	}
 	return
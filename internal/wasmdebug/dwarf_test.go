@@ -273,3 +273,59 @@ func TestDWARFLines_Line_TinyGo(t *testing.T) {
 		})
 	}
 }
+
+func TestDWARFLines_Locations(t *testing.T) {
+	zig, err := binary.DecodeModule(dwarftestdata.ZigWasm, api.CoreFeaturesV2, wasm.MemoryLimitPages, false, true, false)
+	require.NoError(t, err)
+	tinyGo, err := binary.DecodeModule(dwarftestdata.TinyGoWasm, api.CoreFeaturesV2, wasm.MemoryLimitPages, false, true, false)
+	require.NoError(t, err)
+
+	// The offsets and the expected function names are from the wasmtime stack traces in the above tests, though
+	// wasmtime qualifies some Zig names.
+	for _, tc := range []struct {
+		name      string
+		mod       *wasm.Module
+		offset    uint64
+		functions []string
+		lines     []int64
+	}{
+		{name: "zig", mod: zig, offset: 0xa9 - 0x46, functions: []string{"default_panic"}, lines: []int64{889}},
+		{
+			name: "zig inlined", mod: zig, offset: 0x6b - 0x46,
+			functions: []string{"inlined_b", "inlined_a", "main"},
+			lines:     []int64{10, 6, 2},
+		},
+		{name: "tinygo", mod: tinyGo, offset: 0x3168 - 0x16f, functions: []string{"main.c"}, lines: []int64{16}},
+		{
+			name: "tinygo inlined", mod: tinyGo, offset: 0x2033 - 0x16f,
+			functions: []string{"(*internal/task.Task).Resume", "runtime.scheduler"},
+			lines:     []int64{109, 236},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			locations := tc.mod.DWARFLines.Locations(tc.offset)
+			require.Equal(t, len(tc.functions), len(locations))
+			for i, l := range locations {
+				require.Equal(t, tc.functions[i], l.Function)
+				require.Equal(t, tc.lines[i], l.Line)
+				require.Equal(t, i != len(locations)-1, l.Inlined)
+			}
+			require.Equal(t, tc.functions[len(tc.functions)-1], tc.mod.DWARFLines.FunctionName(tc.offset))
+		})
+	}
+
+	require.Equal(t, "", zig.DWARFLines.FunctionName(1<<20))
+	require.Nil(t, zig.DWARFLines.Locations(1<<20))
+}
+
+func TestDWARFLines_FunctionName_DebugName(t *testing.T) {
+	mod, err := binary.DecodeModule(dwarftestdata.ZigWasm, api.CoreFeaturesV2, wasm.MemoryLimitPages, false, true, false)
+	require.NoError(t, err)
+
+	// Without the name section, the debug name of a function is from DWARF.
+	mod.NameSection = nil
+	def := mod.FunctionDefinition(mod.ImportFunctionCount)
+	require.Equal(t, "", def.Name())
+	require.Equal(t, ".main", def.DebugName())
+}
@@ -27,18 +27,55 @@ var (
 	ErrRuntimeInvalidTableAccess = New("invalid table access")
 	// ErrRuntimeIndirectCallTypeMismatch indicates that the type check failed during call_indirect.
 	ErrRuntimeIndirectCallTypeMismatch = New("indirect call type mismatch")
+	// ErrRuntimeFuelExhausted indicates that the program consumed all the fuel
+	// of the call, and the Engine terminated the execution.
+	ErrRuntimeFuelExhausted = New("fuel exhausted")
+	// ErrRuntimeUnalignedAtomic indicates that an atomic instruction accessed
+	// an address which isn't a multiple of the size of the access.
+	ErrRuntimeUnalignedAtomic = New("unaligned atomic")
+	// ErrRuntimeExpectedSharedMemory indicates that memory.atomic.wait32 or
+	// memory.atomic.wait64 was executed on a memory which isn't shared.
+	ErrRuntimeExpectedSharedMemory = New("expected shared memory")
+	// ErrRuntimeNullExceptionReference indicates that throw_ref was executed
+	// on a null exnref.
+	ErrRuntimeNullExceptionReference = New("null exception reference")
+	// ErrRuntimeNullFunctionReference indicates that call_ref or
+	// return_call_ref was executed on a null function reference.
+	ErrRuntimeNullFunctionReference = New("null function reference")
+	// ErrRuntimeNullReference indicates that ref.as_non_null was executed on
+	// a null reference.
+	ErrRuntimeNullReference = New("null reference")
 )
 
 // Error is returned by a wasm.Engine during the execution of Wasm functions, and they indicate that the Wasm runtime
 // state is unrecoverable.
 type Error struct {
-	s string
+	s     string
+	cause error
 }
 
 func New(text string) *Error {
 	return &Error{s: text}
 }
 
+// NewContextDone returns an Error which indicates that the context.Context of
+// the call was done, and the Engine terminated the execution. `cause` is the
+// error of the context, e.g. context.DeadlineExceeded.
+func NewContextDone(cause error) *Error {
+	return &Error{s: cause.Error(), cause: cause}
+}
+
+// NewStackOverflow returns an Error which wraps ErrRuntimeStackOverflow with
+// the limit the call exceeded, e.g. "maximum call depth of 2000".
+func NewStackOverflow(limit string) *Error {
+	return &Error{s: ErrRuntimeStackOverflow.s + ": exceeded the " + limit, cause: ErrRuntimeStackOverflow}
+}
+
 func (e *Error) Error() string {
 	return e.s
 }
+
+// Unwrap returns the cause of the error, if any.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
@@ -0,0 +1,631 @@
+package wat
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// instrKind is the kind of an instruction, as far as its structure is
+// concerned.
+type instrKind byte
+
+const (
+	kindPlain instrKind = iota
+	// kindBlock is "block", "loop" or "try_table".
+	kindBlock
+	kindIf
+	// kindTry is the "try" of the legacy exception handling.
+	kindTry
+	kindElse
+	kindCatch
+	kindCatchAll
+	kindDelegate
+	kindEnd
+)
+
+// instr is a decoded instruction.
+type instr struct {
+	kind instrKind
+	// text is the instruction and its immediates, e.g. "i32.const 1" or
+	// "block $l (result i32)".
+	text string
+	// pops and pushes are the number of operands and results, or zero if
+	// unknown, which are only used to fold instructions.
+	pops, pushes int
+}
+
+// label is a label of an enclosing block.
+type label struct {
+	id string
+	// arity is the number of values a branch to the label takes.
+	arity int
+}
+
+// disassembler decodes the instructions of a function body or constant
+// expression.
+type disassembler struct {
+	p    *printer
+	body []byte
+	pos  int
+	err  error
+
+	// locals and labelIDs are the identifiers of the locals and labels of
+	// the function, if any.
+	locals, labelIDs []string
+	// labels are the enclosing blocks, starting with the function.
+	labels    []label
+	nextLabel int
+	// results is the number of results of the function.
+	results int
+}
+
+// instrs decodes the instructions until the end of the function, which is
+// excluded.
+func (d *disassembler) instrs() ([]instr, error) {
+	var ret []instr
+	for {
+		start := d.pos
+		in := d.instr()
+		if d.err != nil {
+			return nil, fmt.Errorf("invalid instruction at offset %d: %w", start, d.err)
+		}
+		if in.kind == kindEnd || in.kind == kindDelegate {
+			if len(d.labels) == 0 {
+				if d.pos != len(d.body) {
+					return nil, fmt.Errorf("unexpected instructions after the end at offset %d", d.pos)
+				}
+				return ret, nil
+			}
+		}
+		ret = append(ret, in)
+	}
+}
+
+func (d *disassembler) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+func (d *disassembler) byte() byte {
+	if d.err != nil {
+		return 0
+	} else if d.pos >= len(d.body) {
+		d.fail(errors.New("unexpected end"))
+		return 0
+	}
+	d.pos++
+	return d.body[d.pos-1]
+}
+
+func (d *disassembler) bytes(n int) []byte {
+	if d.err != nil {
+		return make([]byte, n)
+	} else if d.pos+n > len(d.body) {
+		d.fail(errors.New("unexpected end"))
+		return make([]byte, n)
+	}
+	d.pos += n
+	return d.body[d.pos-n : d.pos]
+}
+
+func (d *disassembler) u32() uint32 {
+	if d.err != nil {
+		return 0
+	}
+	v, n, err := leb128.LoadUint32(d.body[d.pos:])
+	d.pos += int(n)
+	d.fail(err)
+	return v
+}
+
+func (d *disassembler) u64() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n, err := leb128.LoadUint64(d.body[d.pos:])
+	d.pos += int(n)
+	d.fail(err)
+	return v
+}
+
+func (d *disassembler) s32() int32 {
+	if d.err != nil {
+		return 0
+	}
+	v, n, err := leb128.LoadInt32(d.body[d.pos:])
+	d.pos += int(n)
+	d.fail(err)
+	return v
+}
+
+func (d *disassembler) s64() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n, err := leb128.LoadInt64(d.body[d.pos:])
+	d.pos += int(n)
+	d.fail(err)
+	return v
+}
+
+// instr decodes the next instruction.
+func (d *disassembler) instr() instr {
+	code := d.byte()
+	switch code {
+	case wasm.OpcodeBlock, wasm.OpcodeLoop, wasm.OpcodeIf, wasm.OpcodeTry:
+		name := wasm.InstructionName(code)
+		bt, params, results := d.blockType()
+		l := d.pushLabel(results)
+		if code == wasm.OpcodeLoop {
+			d.labels[len(d.labels)-1].arity = params
+		}
+		in := instr{kind: kindBlock, text: name + l + bt, pushes: results}
+		switch code {
+		case wasm.OpcodeIf:
+			in.kind, in.pops = kindIf, 1
+		case wasm.OpcodeTry:
+			in.kind = kindTry
+		}
+		return in
+	case wasm.OpcodeTryTable:
+		bt, _, results := d.blockType()
+		// The labels of the catch clauses are outside the block.
+		var catches strings.Builder
+		for n := d.u32(); n > 0 && d.err == nil; n-- {
+			kind := d.byte()
+			switch kind {
+			case 0x00:
+				catches.WriteString(" (catch " + index(d.p.tags, d.u32()))
+			case 0x01:
+				catches.WriteString(" (catch_ref " + index(d.p.tags, d.u32()))
+			case 0x02:
+				catches.WriteString(" (catch_all")
+			case 0x03:
+				catches.WriteString(" (catch_all_ref")
+			default:
+				d.fail(fmt.Errorf("invalid catch clause 0x%x", kind))
+			}
+			catches.WriteString(" " + d.labelIndex(d.u32()) + ")")
+		}
+		l := d.pushLabel(results)
+		return instr{kind: kindBlock, text: "try_table" + l + bt + catches.String(), pushes: results}
+	case wasm.OpcodeElse:
+		return instr{kind: kindElse, text: "else"}
+	case wasm.OpcodeCatch:
+		return instr{kind: kindCatch, text: "catch " + index(d.p.tags, d.u32())}
+	case wasm.OpcodeCatchAll:
+		return instr{kind: kindCatchAll, text: "catch_all"}
+	case wasm.OpcodeEnd:
+		d.labels = d.labels[:len(d.labels)-1]
+		return instr{kind: kindEnd, text: "end"}
+	case wasm.OpcodeDelegate:
+		// The label is relative to the outside of the "try" it ends.
+		d.labels = d.labels[:len(d.labels)-1]
+		return instr{kind: kindDelegate, text: "delegate " + d.labelIndex(d.u32())}
+	case wasm.OpcodeSelect:
+		return instr{text: "select", pops: 3, pushes: 1}
+	case wasm.OpcodeTypedSelect:
+		n := d.u32()
+		types := make([]wasm.ValueType, 0, n)
+		for ; n > 0 && d.err == nil; n-- {
+			types = append(types, d.byte())
+		}
+		return instr{text: "select (result" + valueTypesText(types) + ")", pops: 3, pushes: len(types)}
+	}
+
+	key := uint16(code)
+	switch code {
+	case wasm.OpcodeMiscPrefix, wasm.OpcodeVecPrefix, wasm.OpcodeAtomicPrefix, wasm.OpcodeGCPrefix:
+		sub := d.u32()
+		if sub > 0xff {
+			d.fail(fmt.Errorf("invalid opcode 0x%x 0x%x", code, sub))
+			return instr{}
+		}
+		key = uint16(code)<<8 | uint16(sub)
+	}
+	name, ok := opcodeNames[key]
+	if !ok {
+		d.fail(fmt.Errorf("invalid opcode 0x%x", key))
+		return instr{}
+	}
+	return d.operator(name, opcodes[name])
+}
+
+// blockType decodes a block type, returning its text, e.g. " (result i32)",
+// and its number of params and results.
+func (d *disassembler) blockType() (string, int, int) {
+	if d.err != nil || d.pos >= len(d.body) {
+		d.fail(errors.New("unexpected end"))
+		return "", 0, 0
+	}
+	switch b := d.body[d.pos]; b {
+	case 0x40:
+		d.pos++
+		return "", 0, 0
+	case wasm.ValueTypeI32, wasm.ValueTypeI64, wasm.ValueTypeF32, wasm.ValueTypeF64, wasm.ValueTypeV128,
+		wasm.ValueTypeFuncref, wasm.ValueTypeExternref, wasm.ValueTypeExnref:
+		d.pos++
+		return " (result " + wasm.ValueTypeName(b) + ")", 0, 1
+	}
+	idx, _, err := leb128.DecodeInt33AsInt64(&byteReader{d})
+	if err != nil || idx < 0 {
+		d.fail(errors.New("invalid block type"))
+		return "", 0, 0
+	}
+	t := d.p.funcType(uint32(idx))
+	return " " + d.p.typeUse(uint32(idx), nil), len(t.Params), len(t.Results)
+}
+
+// byteReader reads the body of a disassembler.
+type byteReader struct{ d *disassembler }
+
+func (r *byteReader) ReadByte() (byte, error) {
+	if r.d.pos >= len(r.d.body) {
+		return 0, errors.New("unexpected end")
+	}
+	r.d.pos++
+	return r.d.body[r.d.pos-1], nil
+}
+
+// pushLabel enters a block, returning its identifier following a space, if
+// named.
+func (d *disassembler) pushLabel(arity int) string {
+	id := ""
+	if d.nextLabel < len(d.labelIDs) {
+		id = d.labelIDs[d.nextLabel]
+	}
+	d.nextLabel++
+	d.labels = append(d.labels, label{id: id, arity: arity})
+	if id == "" {
+		return ""
+	}
+	return " $" + identifier(id)
+}
+
+// labelIndex returns the label at `depth`, by its identifier unless unnamed
+// or shadowed by an inner label of the same identifier.
+func (d *disassembler) labelIndex(depth uint32) string {
+	i := len(d.labels) - 1 - int(depth)
+	if i < 0 || d.labels[i].id == "" {
+		return strconv.FormatUint(uint64(depth), 10)
+	}
+	for _, l := range d.labels[i+1:] {
+		if l.id == d.labels[i].id {
+			return strconv.FormatUint(uint64(depth), 10)
+		}
+	}
+	return "$" + identifier(d.labels[i].id)
+}
+
+// labelArity returns the number of values a branch to `depth` takes.
+func (d *disassembler) labelArity(depth uint32) int {
+	if i := len(d.labels) - 1 - int(depth); i >= 0 {
+		return d.labels[i].arity
+	}
+	return 0
+}
+
+func (d *disassembler) local(idx uint32) string {
+	if int(idx) < len(d.locals) && d.locals[idx] != "" {
+		return "$" + identifier(d.locals[idx])
+	}
+	return strconv.FormatUint(uint64(idx), 10)
+}
+
+// operator decodes the immediates of the instruction `name`.
+func (d *disassembler) operator(name string, op opcode) instr {
+	p := d.p
+	in := instr{text: name}
+	imm := func(s string) {
+		in.text += " " + s
+	}
+	switch op.imm {
+	case immNone:
+		in.pops, in.pushes = arity(name, d.results)
+	case immZero:
+		if d.byte() != 0 {
+			d.fail(errors.New("expected a zero byte"))
+		}
+	case immLabel:
+		depth := d.u32()
+		imm(d.labelIndex(depth))
+		switch op.code {
+		case wasm.OpcodeBr:
+			in.pops = d.labelArity(depth)
+		case wasm.OpcodeBrIf:
+			in.pops, in.pushes = d.labelArity(depth)+1, d.labelArity(depth)
+		}
+	case immBrTable:
+		n := d.u32()
+		for i := uint32(0); i <= n && d.err == nil; i++ {
+			depth := d.u32()
+			imm(d.labelIndex(depth))
+			in.pops = d.labelArity(depth) + 1
+		}
+	case immFunc:
+		idx := d.u32()
+		imm(index(p.funcs, idx))
+		if op.code == wasm.OpcodeRefFunc {
+			in.pushes = 1
+			break
+		}
+		t := p.funcType(p.funcTypeIndex(idx))
+		in.pops = len(t.Params)
+		if op.code == wasm.OpcodeCall {
+			in.pushes = len(t.Results)
+		}
+	case immCallIndirect:
+		typeIdx, table := d.u32(), d.u32()
+		if table != 0 {
+			imm(index(p.tables, table))
+		}
+		imm("(type " + index(p.types, typeIdx) + ")")
+		t := p.funcType(typeIdx)
+		in.pops = len(t.Params) + 1
+		if op.code == wasm.OpcodeCallIndirect {
+			in.pushes = len(t.Results)
+		}
+	case immTypeIndex:
+		typeIdx := d.u32()
+		imm(index(p.types, typeIdx))
+		t := p.funcType(typeIdx)
+		in.pops = len(t.Params) + 1
+		if op.code == wasm.OpcodeCallRef {
+			in.pushes = len(t.Results)
+		}
+	case immLocal:
+		imm(d.local(d.u32()))
+		switch op.code {
+		case wasm.OpcodeLocalGet:
+			in.pushes = 1
+		case wasm.OpcodeLocalSet:
+			in.pops = 1
+		default:
+			in.pops, in.pushes = 1, 1
+		}
+	case immGlobal:
+		imm(index(p.globals, d.u32()))
+		if op.code == wasm.OpcodeGlobalGet {
+			in.pushes = 1
+		} else {
+			in.pops = 1
+		}
+	case immTable:
+		if table := d.u32(); table != 0 {
+			imm(index(p.tables, table))
+		}
+		switch name {
+		case "table.get":
+			in.pops, in.pushes = 1, 1
+		case "table.set":
+			in.pops = 2
+		case "table.size":
+			in.pushes = 1
+		case "table.grow":
+			in.pops, in.pushes = 2, 1
+		default: // table.fill
+			in.pops = 3
+		}
+	case immTableCopy:
+		if dst, src := d.u32(), d.u32(); dst != 0 || src != 0 {
+			imm(index(p.tables, dst))
+			imm(index(p.tables, src))
+		}
+		in.pops = 3
+	case immTableInit:
+		elem, table := d.u32(), d.u32()
+		if table != 0 {
+			imm(index(p.tables, table))
+		}
+		imm(index(p.elems, elem))
+		in.pops = 3
+	case immElem:
+		imm(index(p.elems, d.u32()))
+	case immMemory:
+		if memory := d.u32(); memory != 0 {
+			imm(index(p.memories, memory))
+		}
+		switch name {
+		case "memory.size":
+			in.pushes = 1
+		case "memory.grow":
+			in.pops, in.pushes = 1, 1
+		default: // memory.fill
+			in.pops = 3
+		}
+	case immMemoryCopy:
+		if dst, src := d.u32(), d.u32(); dst != 0 || src != 0 {
+			imm(index(p.memories, dst))
+			imm(index(p.memories, src))
+		}
+		in.pops = 3
+	case immMemoryInit:
+		data, memory := d.u32(), d.u32()
+		if memory != 0 {
+			imm(index(p.memories, memory))
+		}
+		imm(index(p.datas, data))
+		in.pops = 3
+	case immData:
+		imm(index(p.datas, d.u32()))
+	case immI32:
+		imm(strconv.FormatInt(int64(d.s32()), 10))
+		in.pushes = 1
+	case immI64:
+		imm(strconv.FormatInt(d.s64(), 10))
+		in.pushes = 1
+	case immF32:
+		imm(floatText(uint64(binary.LittleEndian.Uint32(d.bytes(4))), 32))
+		in.pushes = 1
+	case immF64:
+		imm(floatText(binary.LittleEndian.Uint64(d.bytes(8)), 64))
+		in.pushes = 1
+	case immV128:
+		v := d.bytes(16)
+		imm(fmt.Sprintf("i32x4 0x%08x 0x%08x 0x%08x 0x%08x", binary.LittleEndian.Uint32(v), binary.LittleEndian.Uint32(v[4:]),
+			binary.LittleEndian.Uint32(v[8:]), binary.LittleEndian.Uint32(v[12:])))
+		in.pushes = 1
+	case immHeapType:
+		imm(d.heapType())
+		in.pushes = 1
+	case immTag:
+		idx := d.u32()
+		imm(index(p.tags, idx))
+		in.pops = len(p.funcType(p.tagTypeIndex(idx)).Params)
+	case immMemarg:
+		imm(d.memarg(op.align))
+		in.pops, in.pushes = memoryArity(name)
+	case immLane:
+		imm(strconv.Itoa(int(d.byte())))
+		if strings.Contains(name, "replace_lane") {
+			in.pops, in.pushes = 2, 1
+		} else {
+			in.pops, in.pushes = 1, 1
+		}
+	case immMemargLane:
+		imm(d.memarg(op.align))
+		imm(strconv.Itoa(int(d.byte())))
+		in.pops = 2
+		if strings.Contains(name, "load") {
+			in.pushes = 1
+		}
+	case immShuffle:
+		for _, lane := range d.bytes(16) {
+			imm(strconv.Itoa(int(lane)))
+		}
+		in.pops, in.pushes = 2, 1
+	}
+	in.text = strings.TrimSuffix(in.text, " ")
+	return in
+}
+
+// heapType decodes the heap type of "ref.null".
+func (d *disassembler) heapType() string {
+	if d.err == nil && d.pos < len(d.body) {
+		switch d.body[d.pos] {
+		case wasm.ValueTypeFuncref:
+			d.pos++
+			return "func"
+		case wasm.ValueTypeExternref:
+			d.pos++
+			return "extern"
+		case wasm.ValueTypeExnref:
+			d.pos++
+			return "exn"
+		}
+	}
+	idx, _, err := leb128.DecodeInt33AsInt64(&byteReader{d})
+	if err != nil || idx < 0 {
+		d.fail(errors.New("invalid heap type"))
+		return ""
+	}
+	return index(d.p.types, uint32(idx))
+}
+
+// memarg decodes the memory index, offset and alignment of a memory access,
+// returning those that aren't the default, e.g. "offset=4 align=1".
+func (d *disassembler) memarg(naturalAlign uint32) string {
+	var b strings.Builder
+	align := d.u32()
+	if align&0x40 != 0 {
+		align &^= 0x40
+		b.WriteString(" " + index(d.p.memories, d.u32()))
+	}
+	if offset := d.u64(); offset != 0 {
+		b.WriteString(" offset=" + strconv.FormatUint(offset, 10))
+	}
+	if align != naturalAlign && align < 64 {
+		b.WriteString(" align=" + strconv.FormatUint(1<<align, 10))
+	}
+	return strings.TrimPrefix(b.String(), " ")
+}
+
+// memoryArity returns the number of operands and results of a memory
+// access, e.g. "i32.atomic.rmw.cmpxchg".
+func memoryArity(name string) (pops, pushes int) {
+	switch {
+	case strings.Contains(name, "store"):
+		return 2, 0
+	case strings.Contains(name, "cmpxchg"), strings.Contains(name, "wait"):
+		return 3, 1
+	case strings.Contains(name, "rmw"), strings.Contains(name, "notify"):
+		return 2, 1
+	}
+	return 1, 1
+}
+
+// unaryOperators are the numeric operators of one operand, besides
+// conversions.
+var unaryOperators = map[string]bool{
+	"eqz": true, "clz": true, "ctz": true, "popcnt": true, "abs": true, "neg": true, "sqrt": true, "ceil": true,
+	"floor": true, "trunc": true, "nearest": true, "not": true, "any_true": true, "all_true": true, "bitmask": true,
+	"splat": true,
+}
+
+// arity returns the number of operands and results of the instruction
+// `name`, which has no immediates, given the number of `results` of the
+// function.
+func arity(name string, results int) (pops, pushes int) {
+	switch name {
+	case "unreachable", "nop", "rethrow":
+		return 0, 0
+	case "return":
+		return results, 0
+	case "drop", "throw_ref":
+		return 1, 0
+	case "ref.is_null", "ref.as_non_null":
+		return 1, 1
+	case "v128.bitselect":
+		return 3, 1
+	}
+	dot := strings.IndexByte(name, '.')
+	if dot < 0 {
+		return 0, 0
+	}
+	op := name[dot+1:]
+	if unaryOperators[op] {
+		return 1, 1
+	}
+	for _, conversion := range []string{"wrap", "extend", "trunc_", "convert", "demote", "promote", "reinterpret", "extadd_pairwise"} {
+		if strings.HasPrefix(op, conversion) {
+			return 1, 1
+		}
+	}
+	return 2, 1
+}
+
+// floatText returns the float of `size` bits with the IEEE 754 `bits`,
+// which parses to the same bits.
+func floatText(bits uint64, size int) string {
+	mantissaBits, expBits := 52, 11
+	if size == 32 {
+		mantissaBits, expBits = 23, 8
+	}
+	sign := ""
+	if bits>>(size-1) != 0 {
+		sign = "-"
+	}
+	exp := bits >> mantissaBits & (1<<expBits - 1)
+	mantissa := bits & (1<<mantissaBits - 1)
+	switch {
+	case exp != 1<<expBits-1:
+	case mantissa == 0:
+		return sign + "inf"
+	case mantissa == 1<<(mantissaBits-1):
+		return sign + "nan"
+	default:
+		return sign + "nan:0x" + strconv.FormatUint(mantissa, 16)
+	}
+	if size == 32 {
+		return strconv.FormatFloat(float64(math.Float32frombits(uint32(bits))), 'g', -1, 32)
+	}
+	return strconv.FormatFloat(math.Float64frombits(bits), 'g', -1, 64)
+}
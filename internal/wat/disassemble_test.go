@@ -0,0 +1,69 @@
+package wat
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// TestDisassemble_spectest disassembles the valid modules of the spec tests,
+// and requires the result compiles to a module of the same text.
+func TestDisassemble_spectest(t *testing.T) {
+	for _, version := range []string{"v1", "v2"} {
+		dir := path.Join("..", "integration_test", "spectest", version, "testdata")
+		files, err := filepath.Glob(path.Join(dir, "*.json"))
+		require.NoError(t, err)
+		for _, file := range files {
+			raw, err := os.ReadFile(file)
+			require.NoError(t, err)
+			var script struct {
+				Commands []spectestCommand `json:"commands"`
+			}
+			require.NoError(t, json.Unmarshal(raw, &script))
+			for _, c := range script.Commands {
+				if c.Type != "module" || !strings.HasSuffix(c.Filename, ".wasm") {
+					continue
+				}
+				bin, err := os.ReadFile(path.Join(dir, c.Filename))
+				require.NoError(t, err)
+				for _, folded := range []bool{false, true} {
+					testDisassemble(t, path.Join(version, c.Filename), bin, folded)
+				}
+			}
+		}
+	}
+}
+
+func testDisassemble(t *testing.T, name string, bin []byte, folded bool) {
+	text, err := Disassemble(bin, folded)
+	if err != nil {
+		t.Errorf("%s: %v", name, err)
+		return
+	}
+	recompiled, err := Compile(text)
+	if err != nil {
+		t.Errorf("%s: %v\n%s", name, err, text)
+		return
+	}
+	// Compare the text, as the binary can differ, e.g. in the encoding of
+	// integers.
+	actual, err := Disassemble(recompiled, folded)
+	require.NoError(t, err)
+	if string(text) != string(actual) {
+		expectedLines, actualLines := strings.Split(string(text), "\n"), strings.Split(string(actual), "\n")
+		for i := range expectedLines {
+			if i >= len(actualLines) || expectedLines[i] != actualLines[i] {
+				t.Errorf("%s:%d: expected %q", name, i+1, expectedLines[i])
+				if i < len(actualLines) {
+					t.Errorf("%s:%d: actual   %q", name, i+1, actualLines[i])
+				}
+				return
+			}
+		}
+	}
+}
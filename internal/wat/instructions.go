@@ -0,0 +1,856 @@
+package wat
+
+import (
+	"encoding/binary"
+	"strings"
+
+	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// funcCtx is the context of the instructions of a function, or of a constant
+// expression, which has neither locals nor labels.
+type funcCtx struct {
+	locals    map[string]uint32
+	numLocals uint32
+	// labels are the labels of the enclosing blocks, innermost last, which
+	// are "" unless named.
+	labels []string
+	// numLabels is the number of blocks so far, and labelNames the labels of
+	// those named, for the name section.
+	numLabels  uint32
+	labelNames []localName
+}
+
+// encoder encodes instructions in the binary format.
+type encoder struct {
+	b   *builder
+	f   *funcCtx
+	out []byte
+}
+
+// instrs encodes the instructions at `c`, until its end or one of the
+// keywords delimiting blocks, e.g. "end".
+func (e *encoder) instrs(c *cursor) error {
+	for n := c.peek(); n != nil; n = c.peek() {
+		if n.isList {
+			c.next()
+			if err := e.folded(n); err != nil {
+				return err
+			}
+			continue
+		}
+		if n.tok.kind != tokenKeyword {
+			return c.errorf(n, "unexpected token")
+		}
+		switch n.tok.text {
+		case "end", "else", "catch", "catch_all", "delegate":
+			return nil
+		}
+		c.next()
+		if err := e.plain(n, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pushLabel parses the optional label of a block, and pushes it.
+func (e *encoder) pushLabel(c *cursor) string {
+	label := c.id()
+	e.enterLabel(label)
+	return label
+}
+
+// enterLabel enters a block of the label `label`, which is "" unless named.
+func (e *encoder) enterLabel(label string) {
+	if label != "" {
+		e.f.labelNames = append(e.f.labelNames, localName{index: e.f.numLabels, name: label})
+	}
+	e.f.numLabels++
+	e.f.labels = append(e.f.labels, label)
+}
+
+func (e *encoder) popLabel() {
+	e.f.labels = e.f.labels[:len(e.f.labels)-1]
+}
+
+// dropEmptyElse removes the "else" just encoded if its block is empty, which
+// is equivalent, like the reference tools do.
+func (e *encoder) dropEmptyElse() {
+	if e.out[len(e.out)-1] == wasm.OpcodeElse {
+		e.out = e.out[:len(e.out)-1]
+	}
+}
+
+// endLabel parses the optional label after "end" or "else", which must match
+// the label of the block.
+func (e *encoder) endLabel(c *cursor, label string) error {
+	if n := c.peek(); n != nil && !n.isList && n.tok.kind == tokenID {
+		c.next()
+		if n.tok.text != label {
+			return n.errorf("mismatching label $%s", n.tok.text)
+		}
+	}
+	return nil
+}
+
+// expectKeyword consumes the keyword `keyword`.
+func expectKeyword(c *cursor, keyword string) error {
+	if !c.peekKeyword(keyword) {
+		return c.errorf(c.peek(), "expected %q", keyword)
+	}
+	c.next()
+	return nil
+}
+
+// blockType parses and encodes the type of a block: empty, a single result
+// or, unless it is either, a type index.
+func (e *encoder) blockType(c *cursor) ([]byte, error) {
+	var t funcType
+	idx := -1
+	if c.peekHead("type") {
+		i, _, err := e.b.typeUse(c, false)
+		if err != nil {
+			return nil, err
+		} else if int(i) >= len(e.b.types) {
+			return leb128.EncodeInt64(int64(i)), nil
+		}
+		t, idx = e.b.types[i], int(i)
+	} else {
+		var err error
+		if t, _, err = e.b.funcType(c, false); err != nil {
+			return nil, err
+		}
+	}
+	if len(t.params) == 0 {
+		switch len(t.results) {
+		case 0:
+			return []byte{0x40}, nil
+		case 1:
+			return []byte(t.results[0]), nil
+		}
+	}
+	if idx < 0 {
+		idx = int(e.b.typeIndex(t))
+	}
+	return leb128.EncodeInt64(int64(idx)), nil
+}
+
+// plain encodes the plain instruction `n` whose immediates are at `c`.
+func (e *encoder) plain(n *node, c *cursor) error {
+	switch n.tok.text {
+	case "block", "loop":
+		op := wasm.OpcodeBlock
+		if n.tok.text == "loop" {
+			op = wasm.OpcodeLoop
+		}
+		label := e.pushLabel(c)
+		bt, err := e.blockType(c)
+		if err != nil {
+			return err
+		}
+		e.out = append(append(e.out, op), bt...)
+		if err = e.instrs(c); err != nil {
+			return err
+		}
+		return e.end(c, label)
+	case "if":
+		label := e.pushLabel(c)
+		bt, err := e.blockType(c)
+		if err != nil {
+			return err
+		}
+		e.out = append(append(e.out, wasm.OpcodeIf), bt...)
+		if err = e.instrs(c); err != nil {
+			return err
+		}
+		if c.peekKeyword("else") {
+			c.next()
+			if err = e.endLabel(c, label); err != nil {
+				return err
+			}
+			e.out = append(e.out, wasm.OpcodeElse)
+			if err = e.instrs(c); err != nil {
+				return err
+			}
+			e.dropEmptyElse()
+		}
+		return e.end(c, label)
+	case "try":
+		label := e.pushLabel(c)
+		bt, err := e.blockType(c)
+		if err != nil {
+			return err
+		}
+		e.out = append(append(e.out, wasm.OpcodeTry), bt...)
+		if err = e.instrs(c); err != nil {
+			return err
+		}
+		for c.peekKeyword("catch") {
+			c.next()
+			tag, err := e.b.tags.resolve(c.next())
+			if err != nil {
+				return err
+			}
+			e.out = append(append(e.out, wasm.OpcodeCatch), leb128.EncodeUint32(tag)...)
+			if err = e.instrs(c); err != nil {
+				return err
+			}
+		}
+		if c.peekKeyword("catch_all") {
+			c.next()
+			e.out = append(e.out, wasm.OpcodeCatchAll)
+			if err = e.instrs(c); err != nil {
+				return err
+			}
+		}
+		if c.peekKeyword("delegate") {
+			c.next()
+			e.popLabel()
+			return e.delegate(c)
+		}
+		return e.end(c, label)
+	case "try_table":
+		h, err := e.tryTable(c)
+		if err != nil {
+			return err
+		}
+		e.enterLabel(h.label)
+		e.out = append(e.out, h.bytes...)
+		if err = e.instrs(c); err != nil {
+			return err
+		}
+		return e.end(c, h.label)
+	}
+	op, err := e.operator(n, c)
+	if err != nil {
+		return err
+	}
+	e.out = append(e.out, op...)
+	return nil
+}
+
+// end consumes "end" and its optional label, closing the innermost block.
+func (e *encoder) end(c *cursor, label string) error {
+	if err := expectKeyword(c, "end"); err != nil {
+		return err
+	}
+	if err := e.endLabel(c, label); err != nil {
+		return err
+	}
+	e.popLabel()
+	e.out = append(e.out, wasm.OpcodeEnd)
+	return nil
+}
+
+// delegate encodes "delegate" and its label, which ends a try block.
+func (e *encoder) delegate(c *cursor) error {
+	l, err := e.label(c.next(), c)
+	if err != nil {
+		return err
+	}
+	e.out = append(append(e.out, wasm.OpcodeDelegate), leb128.EncodeUint32(l)...)
+	return nil
+}
+
+// tryTableHeader is the label of a try_table block and its encoding,
+// including its catch clauses.
+type tryTableHeader struct {
+	label string
+	bytes []byte
+}
+
+// tryTable parses `$label? blocktype catch*` of a try_table block. The labels
+// of catch clauses are resolved outside of the block.
+func (e *encoder) tryTable(c *cursor) (h tryTableHeader, err error) {
+	h.label = c.id()
+	bt, err := e.blockType(c)
+	if err != nil {
+		return h, err
+	}
+	var catches []byte
+	count := uint32(0)
+	for {
+		n := c.peek()
+		var kind byte
+		switch n.headOrEmpty() {
+		case "catch":
+			kind = 0x00
+		case "catch_ref":
+			kind = 0x01
+		case "catch_all":
+			kind = 0x02
+		case "catch_all_ref":
+			kind = 0x03
+		default:
+			h.bytes = append(append([]byte{wasm.OpcodeTryTable}, bt...), leb128.EncodeUint32(count)...)
+			h.bytes = append(h.bytes, catches...)
+			return h, nil
+		}
+		c.next()
+		cc := newCursor(n, 1)
+		catches = append(catches, kind)
+		if kind < 0x02 {
+			tag, err := e.b.tags.resolve(cc.next())
+			if err != nil {
+				return h, err
+			}
+			catches = append(catches, leb128.EncodeUint32(tag)...)
+		}
+		l, err := e.label(cc.next(), cc)
+		if err != nil {
+			return h, err
+		}
+		if err = cc.end(); err != nil {
+			return h, err
+		}
+		catches = append(catches, leb128.EncodeUint32(l)...)
+		count++
+	}
+}
+
+// folded encodes the folded instruction `n`, i.e. its operands first.
+func (e *encoder) folded(n *node) error {
+	c := newCursor(n, 1)
+	op := n.list[0]
+	if op.isList || op.tok.kind != tokenKeyword {
+		return n.errorf("expected an instruction")
+	}
+	switch op.tok.text {
+	case "block", "loop":
+		code := wasm.OpcodeBlock
+		if op.tok.text == "loop" {
+			code = wasm.OpcodeLoop
+		}
+		e.pushLabel(c)
+		bt, err := e.blockType(c)
+		if err != nil {
+			return err
+		}
+		e.out = append(append(e.out, code), bt...)
+		return e.foldedEnd(c)
+	case "if":
+		label := c.id()
+		bt, err := e.blockType(c)
+		if err != nil {
+			return err
+		}
+		// The condition is outside the block.
+		for n := c.peek(); n != nil && n.isList && n.head() != "then"; n = c.peek() {
+			c.next()
+			if err = e.folded(n); err != nil {
+				return err
+			}
+		}
+		e.enterLabel(label)
+		e.out = append(append(e.out, wasm.OpcodeIf), bt...)
+		if !c.peekHead("then") {
+			return c.errorf(c.peek(), "expected (then ...)")
+		}
+		if err = e.clause(c.next()); err != nil {
+			return err
+		}
+		if c.peekHead("else") {
+			e.out = append(e.out, wasm.OpcodeElse)
+			if err = e.clause(c.next()); err != nil {
+				return err
+			}
+			e.dropEmptyElse()
+		}
+		return e.foldedEnd(c)
+	case "try":
+		e.pushLabel(c)
+		bt, err := e.blockType(c)
+		if err != nil {
+			return err
+		}
+		e.out = append(append(e.out, wasm.OpcodeTry), bt...)
+		if !c.peekHead("do") {
+			return c.errorf(c.peek(), "expected (do ...)")
+		}
+		if err = e.clause(c.next()); err != nil {
+			return err
+		}
+		for c.peekHead("catch") {
+			cc := newCursor(c.next(), 1)
+			tag, err := e.b.tags.resolve(cc.next())
+			if err != nil {
+				return err
+			}
+			e.out = append(append(e.out, wasm.OpcodeCatch), leb128.EncodeUint32(tag)...)
+			if err = e.instrs(cc); err != nil {
+				return err
+			} else if err = cc.end(); err != nil {
+				return err
+			}
+		}
+		if c.peekHead("catch_all") {
+			e.out = append(e.out, wasm.OpcodeCatchAll)
+			if err = e.clause(c.next()); err != nil {
+				return err
+			}
+		}
+		if c.peekHead("delegate") {
+			dc := newCursor(c.next(), 1)
+			e.popLabel()
+			if err = e.delegate(dc); err != nil {
+				return err
+			}
+			if err = dc.end(); err != nil {
+				return err
+			}
+			return c.end()
+		}
+		return e.foldedEnd(c)
+	case "try_table":
+		h, err := e.tryTable(c)
+		if err != nil {
+			return err
+		}
+		e.enterLabel(h.label)
+		e.out = append(e.out, h.bytes...)
+		return e.foldedEnd(c)
+	}
+
+	bytes, err := e.operator(op, c)
+	if err != nil {
+		return err
+	}
+	for !c.done() {
+		n := c.next()
+		if !n.isList {
+			return c.errorf(n, "unexpected token")
+		}
+		if err = e.folded(n); err != nil {
+			return err
+		}
+	}
+	e.out = append(e.out, bytes...)
+	return nil
+}
+
+// clause encodes the instructions of a clause of a folded block, e.g.
+// `(then instr*)`.
+func (e *encoder) clause(n *node) error {
+	c := newCursor(n, 1)
+	if err := e.instrs(c); err != nil {
+		return err
+	}
+	return c.end()
+}
+
+// foldedEnd encodes the rest of the instructions of a folded block, and
+// closes it.
+func (e *encoder) foldedEnd(c *cursor) error {
+	if err := e.instrs(c); err != nil {
+		return err
+	}
+	if err := c.end(); err != nil {
+		return err
+	}
+	e.popLabel()
+	e.out = append(e.out, wasm.OpcodeEnd)
+	return nil
+}
+
+// label resolves the label `n`, by identifier or depth.
+func (e *encoder) label(n *node, c *cursor) (uint32, error) {
+	if !isIndex(n) {
+		return 0, c.errorf(n, "expected a label")
+	}
+	if n.tok.kind == tokenID {
+		for i := len(e.f.labels) - 1; i >= 0; i-- {
+			if e.f.labels[i] == n.tok.text {
+				return uint32(len(e.f.labels) - 1 - i), nil
+			}
+		}
+		return 0, n.errorf("unknown label $%s", n.tok.text)
+	}
+	depth, ok := parseUint(n.tok.text, 32)
+	if !ok {
+		return 0, n.errorf("invalid label %q", n.tok.text)
+	}
+	return uint32(depth), nil
+}
+
+// local resolves the local `n`, by identifier or index.
+func (e *encoder) local(n *node, c *cursor) (uint32, error) {
+	if !isIndex(n) {
+		return 0, c.errorf(n, "expected a local")
+	}
+	if n.tok.kind == tokenID {
+		if idx, ok := e.f.locals[n.tok.text]; ok {
+			return idx, nil
+		}
+		return 0, n.errorf("unknown local $%s", n.tok.text)
+	}
+	idx, ok := parseUint(n.tok.text, 32)
+	if !ok {
+		return 0, n.errorf("invalid local %q", n.tok.text)
+	}
+	return uint32(idx), nil
+}
+
+// optionalIndex resolves the next node in `s` if it is an index, or returns
+// zero otherwise.
+func optionalIndex(c *cursor, s *space) (uint32, error) {
+	if isIndex(c.peek()) {
+		return s.resolve(c.next())
+	}
+	return 0, nil
+}
+
+// operator encodes the instruction `n`, other than a block, and its
+// immediates at `c`.
+func (e *encoder) operator(n *node, c *cursor) ([]byte, error) {
+	if n.tok.text == "select" {
+		var types []string
+		for c.peekHead("result") {
+			rc := newCursor(c.next(), 1)
+			for !rc.done() {
+				vt, err := e.b.valType(rc.next(), rc)
+				if err != nil {
+					return nil, err
+				}
+				types = append(types, vt)
+			}
+		}
+		if len(types) == 0 { // like the reference tools, even given "(result)"
+			return []byte{wasm.OpcodeSelect}, nil
+		}
+		return appendValTypes([]byte{wasm.OpcodeTypedSelect}, types), nil
+	}
+	op, ok := opcodes[n.tok.text]
+	if !ok {
+		return nil, n.errorf("unknown operator %q", n.tok.text)
+	}
+	var ret []byte
+	if op.prefix != 0 {
+		ret = append([]byte{op.prefix}, leb128.EncodeUint32(uint32(op.code))...)
+	} else {
+		ret = []byte{op.code}
+	}
+	b := e.b
+	switch op.imm {
+	case immNone:
+	case immZero:
+		ret = append(ret, 0x00)
+	case immLabel:
+		l, err := e.label(c.next(), c)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, leb128.EncodeUint32(l)...)
+	case immBrTable:
+		var labels []uint32
+		for isIndex(c.peek()) {
+			l, err := e.label(c.next(), c)
+			if err != nil {
+				return nil, err
+			}
+			labels = append(labels, l)
+		}
+		if len(labels) == 0 {
+			return nil, c.errorf(c.peek(), "expected a label")
+		}
+		ret = appendIndexes(ret, labels[:len(labels)-1])
+		ret = append(ret, leb128.EncodeUint32(labels[len(labels)-1])...)
+	case immFunc:
+		idx, err := b.funcs.resolve(c.next())
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, leb128.EncodeUint32(idx)...)
+	case immCallIndirect:
+		table, err := optionalIndex(c, &b.tables)
+		if err != nil {
+			return nil, err
+		}
+		t, _, err := b.typeUse(c, false)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, leb128.EncodeUint32(t)...)
+		ret = append(ret, leb128.EncodeUint32(table)...)
+	case immTypeIndex:
+		idx, err := b.typeSpace.resolve(c.next())
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, leb128.EncodeUint32(idx)...)
+	case immLocal:
+		idx, err := e.local(c.next(), c)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, leb128.EncodeUint32(idx)...)
+	case immGlobal:
+		idx, err := b.globals.resolve(c.next())
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, leb128.EncodeUint32(idx)...)
+	case immTable:
+		idx, err := optionalIndex(c, &b.tables)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, leb128.EncodeUint32(idx)...)
+	case immTableCopy:
+		var dst, src uint32
+		if isIndex(c.peek()) {
+			var err error
+			if dst, err = b.tables.resolve(c.next()); err != nil {
+				return nil, err
+			}
+			if src, err = b.tables.resolve(c.next()); err != nil {
+				return nil, err
+			}
+		}
+		ret = append(ret, leb128.EncodeUint32(dst)...)
+		ret = append(ret, leb128.EncodeUint32(src)...)
+	case immTableInit:
+		table, elem, err := twoIndexes(c, &b.tables, &b.elems)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, leb128.EncodeUint32(elem)...)
+		ret = append(ret, leb128.EncodeUint32(table)...)
+	case immElem:
+		idx, err := b.elems.resolve(c.next())
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, leb128.EncodeUint32(idx)...)
+	case immMemory:
+		idx, err := optionalIndex(c, &b.memories)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, leb128.EncodeUint32(idx)...)
+	case immMemoryCopy:
+		var dst, src uint32
+		if isIndex(c.peek()) {
+			var err error
+			if dst, err = b.memories.resolve(c.next()); err != nil {
+				return nil, err
+			}
+			if src, err = b.memories.resolve(c.next()); err != nil {
+				return nil, err
+			}
+		}
+		ret = append(ret, leb128.EncodeUint32(dst)...)
+		ret = append(ret, leb128.EncodeUint32(src)...)
+	case immMemoryInit:
+		memory, data, err := twoIndexes(c, &b.memories, &b.datas)
+		if err != nil {
+			return nil, err
+		}
+		b.usesDataCount = true
+		ret = append(ret, leb128.EncodeUint32(data)...)
+		ret = append(ret, leb128.EncodeUint32(memory)...)
+	case immData:
+		idx, err := b.datas.resolve(c.next())
+		if err != nil {
+			return nil, err
+		}
+		b.usesDataCount = true
+		ret = append(ret, leb128.EncodeUint32(idx)...)
+	case immI32:
+		v, err := number(c, parseInt, 32, "i32")
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, leb128.EncodeInt32(int32(uint32(v)))...)
+	case immI64:
+		v, err := number(c, parseInt, 64, "i64")
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, leb128.EncodeInt64(int64(v))...)
+	case immF32:
+		v, err := number(c, parseFloat, 32, "f32")
+		if err != nil {
+			return nil, err
+		}
+		ret = binary.LittleEndian.AppendUint32(ret, uint32(v))
+	case immF64:
+		v, err := number(c, parseFloat, 64, "f64")
+		if err != nil {
+			return nil, err
+		}
+		ret = binary.LittleEndian.AppendUint64(ret, v)
+	case immV128:
+		v, err := v128Const(c)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, v...)
+	case immHeapType:
+		ht, err := b.heapType(c.next(), c)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, ht...)
+	case immTag:
+		idx, err := b.tags.resolve(c.next())
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, leb128.EncodeUint32(idx)...)
+	case immMemarg:
+		m, err := e.memarg(c, op.align)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, m...)
+	case immLane:
+		lane, err := number(c, parseUint, 8, "lane index")
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, byte(lane))
+	case immMemargLane:
+		// The memory index is ambiguous with the lane index unless followed by
+		// another index or a memarg.
+		var m []byte
+		var err error
+		if next := c.i + 1; isIndex(c.peek()) && next < len(c.list) && (isIndex(c.list[next]) || isMemargKeyword(c.list[next])) {
+			m, err = e.memarg(c, op.align)
+		} else {
+			m, err = e.memarg(&cursor{list: memargKeywords(c), parent: c.parent}, op.align)
+		}
+		if err != nil {
+			return nil, err
+		}
+		lane, err := number(c, parseUint, 8, "lane index")
+		if err != nil {
+			return nil, err
+		}
+		ret = append(append(ret, m...), byte(lane))
+	case immShuffle:
+		for i := 0; i < 16; i++ {
+			lane, err := number(c, parseUint, 8, "lane index")
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, byte(lane))
+		}
+	default:
+		return nil, n.errorf("unexpected operator %q", n.tok.text)
+	}
+	return ret, nil
+}
+
+// twoIndexes parses `x? y`, e.g. the optional table and the element segment
+// of table.init.
+func twoIndexes(c *cursor, first, second *space) (x, y uint32, err error) {
+	if next := c.i + 1; next < len(c.list) && isIndex(c.list[next]) && isIndex(c.peek()) {
+		if x, err = first.resolve(c.next()); err != nil {
+			return
+		}
+	}
+	y, err = second.resolve(c.next())
+	return
+}
+
+// number parses the next node with `parse`.
+func number(c *cursor, parse func(string, int) (uint64, bool), bits int, kind string) (uint64, error) {
+	n := c.next()
+	if n == nil || n.isList || (n.tok.kind != tokenReserved && n.tok.kind != tokenKeyword) {
+		return 0, c.errorf(n, "expected %s", kind)
+	}
+	v, ok := parse(n.tok.text, bits)
+	if !ok {
+		return 0, n.errorf("invalid %s %q", kind, n.tok.text)
+	}
+	return v, nil
+}
+
+// v128Const parses `shape lanes`.
+func v128Const(c *cursor) ([]byte, error) {
+	shape := c.next()
+	if shape == nil || shape.isList {
+		return nil, c.errorf(shape, "expected a vector shape")
+	}
+	var lanes, bits int
+	parse := parseInt
+	switch shape.tok.text {
+	case "i8x16":
+		lanes, bits = 16, 8
+	case "i16x8":
+		lanes, bits = 8, 16
+	case "i32x4":
+		lanes, bits = 4, 32
+	case "i64x2":
+		lanes, bits = 2, 64
+	case "f32x4":
+		lanes, bits, parse = 4, 32, parseFloat
+	case "f64x2":
+		lanes, bits, parse = 2, 64, parseFloat
+	default:
+		return nil, shape.errorf("unknown vector shape %q", shape.tok.text)
+	}
+	ret := make([]byte, 0, 16)
+	for i := 0; i < lanes; i++ {
+		v, err := number(c, parse, bits, shape.tok.text+" lane")
+		if err != nil {
+			return nil, err
+		}
+		for j := 0; j < bits/8; j++ {
+			ret = append(ret, byte(v>>(8*j)))
+		}
+	}
+	return ret, nil
+}
+
+func isMemargKeyword(n *node) bool {
+	return n.tok.kind == tokenKeyword && !n.isList &&
+		(strings.HasPrefix(n.tok.text, "offset=") || strings.HasPrefix(n.tok.text, "align="))
+}
+
+// memargKeywords consumes the "offset=" and "align=" keywords at `c`.
+func memargKeywords(c *cursor) (ret []*node) {
+	for n := c.peek(); n != nil && isMemargKeyword(n); n = c.peek() {
+		ret = append(ret, c.next())
+	}
+	return
+}
+
+// memarg parses `memidx? offset=N? align=N?`, defaulting to the natural
+// alignment `align`.
+func (e *encoder) memarg(c *cursor, align uint32) ([]byte, error) {
+	memory, err := optionalIndex(c, &e.b.memories)
+	if err != nil {
+		return nil, err
+	}
+	is64 := int(memory) < len(e.b.memory64) && e.b.memory64[memory]
+	var offset uint64
+	if n := c.peek(); n != nil && isMemargKeyword(n) && strings.HasPrefix(n.tok.text, "offset=") {
+		c.next()
+		bits := 32
+		if is64 {
+			bits = 64
+		}
+		var ok bool
+		if offset, ok = parseUint(strings.TrimPrefix(n.tok.text, "offset="), bits); !ok {
+			return nil, n.errorf("invalid offset %q", n.tok.text)
+		}
+	}
+	if n := c.peek(); n != nil && isMemargKeyword(n) && strings.HasPrefix(n.tok.text, "align=") {
+		c.next()
+		a, ok := parseUint(strings.TrimPrefix(n.tok.text, "align="), 32)
+		if !ok || a == 0 || a&(a-1) != 0 {
+			return nil, n.errorf("alignment must be a power of two: %q", n.tok.text)
+		}
+		align = log2(uint32(a))
+	}
+	var ret []byte
+	if memory != 0 {
+		ret = append(leb128.EncodeUint32(align|0x40), leb128.EncodeUint32(memory)...)
+	} else {
+		ret = leb128.EncodeUint32(align)
+	}
+	return append(ret, leb128.EncodeUint64(offset)...), nil
+}
@@ -0,0 +1,304 @@
+package wat
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenKind is the kind of a token of the text format.
+//
+// See https://webassembly.github.io/spec/core/text/lexical.html#tokens
+type tokenKind byte
+
+const (
+	tokenLParen tokenKind = iota + 1
+	tokenRParen
+	// tokenKeyword begins with a lower-case letter, e.g. "i32.add" or "offset=8".
+	tokenKeyword
+	// tokenID begins with a '$', e.g. "$main".
+	tokenID
+	// tokenString is quoted, and its text is the bytes it denotes.
+	tokenString
+	// tokenReserved is any other sequence of idchars, e.g. the number "-1".
+	tokenReserved
+)
+
+// token is a token of the text format, and its position for error messages.
+type token struct {
+	kind tokenKind
+	// text is the token as written, except strings and quoted identifiers,
+	// whose escapes are decoded, and identifiers, which exclude the '$'.
+	text      string
+	line, col int
+}
+
+// isIDChar returns true if `c` is allowed in keywords, identifiers and
+// numbers.
+//
+// See https://webassembly.github.io/spec/core/text/values.html#text-idchar
+func isIDChar(c byte) bool {
+	switch {
+	case '0' <= c && c <= '9', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-./:<=>?@\\^_`|~", c) >= 0
+}
+
+// lexer splits the text format into tokens, skipping whitespace and comments.
+type lexer struct {
+	src       string
+	pos       int
+	line, col int
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1, col: 1}
+}
+
+// errorf returns an error at the position `line` and `col`.
+func errorf(line, col int, format string, args ...interface{}) error {
+	return fmt.Errorf("%d:%d: %s", line, col, fmt.Sprintf(format, args...))
+}
+
+func (l *lexer) advance(n int) {
+	for i := 0; i < n; i++ {
+		if l.src[l.pos] == '\n' {
+			l.line++
+			l.col = 1
+		} else {
+			l.col++
+		}
+		l.pos++
+	}
+}
+
+// skip skips whitespace and comments, erring on an unterminated block comment.
+func (l *lexer) skip() error {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			l.advance(1)
+		case strings.HasPrefix(l.src[l.pos:], ";;"):
+			end := strings.IndexByte(l.src[l.pos:], '\n')
+			if end < 0 {
+				end = len(l.src) - l.pos
+			}
+			l.advance(end)
+		case strings.HasPrefix(l.src[l.pos:], "(;"):
+			line, col := l.line, l.col
+			l.advance(2)
+			for depth := 1; depth > 0; {
+				switch {
+				case l.pos >= len(l.src):
+					return errorf(line, col, "unterminated block comment")
+				case strings.HasPrefix(l.src[l.pos:], "(;"):
+					depth++
+					l.advance(2)
+				case strings.HasPrefix(l.src[l.pos:], ";)"):
+					depth--
+					l.advance(2)
+				default:
+					l.advance(1)
+				}
+			}
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// next returns the next token, or false at the end of the source.
+func (l *lexer) next() (tok token, ok bool, err error) {
+	if err = l.skip(); err != nil || l.pos >= len(l.src) {
+		return
+	}
+	tok.line, tok.col, ok = l.line, l.col, true
+	switch c := l.src[l.pos]; {
+	case c == '(':
+		tok.kind = tokenLParen
+		l.advance(1)
+		return
+	case c == ')':
+		tok.kind = tokenRParen
+		l.advance(1)
+		return
+	case c == '"':
+		tok.kind = tokenString
+		tok.text, err = l.string()
+	case c == '$' && l.pos+1 < len(l.src) && l.src[l.pos+1] == '"':
+		tok.kind = tokenID
+		l.advance(1)
+		if tok.text, err = l.string(); err == nil && !utf8.ValidString(tok.text) {
+			err = errorf(tok.line, tok.col, "malformed UTF-8 encoding")
+		} else if err == nil && tok.text == "" {
+			err = errorf(tok.line, tok.col, "empty identifier")
+		}
+	case isIDChar(c):
+		start := l.pos
+		for l.pos < len(l.src) && isIDChar(l.src[l.pos]) {
+			l.advance(1)
+		}
+		tok.text = l.src[start:l.pos]
+		switch {
+		case c == '$':
+			tok.kind = tokenID
+			if tok.text = tok.text[1:]; tok.text == "" {
+				err = errorf(tok.line, tok.col, "empty identifier")
+			}
+		case 'a' <= c && c <= 'z':
+			tok.kind = tokenKeyword
+		default:
+			tok.kind = tokenReserved
+		}
+	default:
+		err = errorf(tok.line, tok.col, "unexpected character %q", c)
+	}
+	// Tokens other than parentheses must be separated, e.g. "1$x" and "a"b" are
+	// malformed.
+	if err == nil && l.pos < len(l.src) {
+		if c := l.src[l.pos]; c == '"' || isIDChar(c) {
+			err = errorf(l.line, l.col, "unknown operator: missing separator after %q", tok.text)
+		}
+	}
+	return
+}
+
+// string reads a quoted string, returning the bytes it denotes.
+//
+// See https://webassembly.github.io/spec/core/text/values.html#strings
+func (l *lexer) string() (string, error) {
+	line, col := l.line, l.col
+	l.advance(1) // '"'
+	var b strings.Builder
+	for {
+		if l.pos >= len(l.src) {
+			return "", errorf(line, col, "unterminated string")
+		}
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.advance(1)
+			return b.String(), nil
+		case c == '\n' || c < 0x20 || c == 0x7f:
+			return "", errorf(l.line, l.col, "illegal character in string: %q", c)
+		case c != '\\':
+			b.WriteByte(c)
+			l.advance(1)
+			continue
+		}
+		eline, ecol := l.line, l.col
+		l.advance(1)
+		if l.pos >= len(l.src) {
+			return "", errorf(line, col, "unterminated string")
+		}
+		switch c = l.src[l.pos]; c {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case '"', '\'', '\\':
+			b.WriteByte(c)
+		case 'u':
+			end := strings.IndexByte(l.src[l.pos:], '}')
+			if !strings.HasPrefix(l.src[l.pos:], "u{") || end < 0 {
+				return "", errorf(eline, ecol, "malformed unicode escape")
+			}
+			v, ok := parseNat(l.src[l.pos+2:l.pos+end], 16, 32)
+			if !ok || v >= 0x110000 || (v >= 0xd800 && v < 0xe000) {
+				return "", errorf(eline, ecol, "malformed unicode escape")
+			}
+			b.WriteRune(rune(v))
+			l.advance(end)
+		default:
+			if l.pos+1 >= len(l.src) || hexDigit(c) < 0 || hexDigit(l.src[l.pos+1]) < 0 {
+				return "", errorf(eline, ecol, "illegal escape")
+			}
+			b.WriteByte(byte(hexDigit(c)<<4 | hexDigit(l.src[l.pos+1])))
+			l.advance(1)
+		}
+		l.advance(1)
+	}
+}
+
+func hexDigit(c byte) int {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0')
+	case 'a' <= c && c <= 'f':
+		return int(c-'a') + 10
+	case 'A' <= c && c <= 'F':
+		return int(c-'A') + 10
+	}
+	return -1
+}
+
+// node is a node of the S-expression tree of the text format: either a token
+// or a parenthesized list of nodes.
+type node struct {
+	// tok is the token of an atom, or the left parenthesis of a list.
+	tok    token
+	isList bool
+	list   []*node
+}
+
+// head returns the keyword a list begins with, or "" if it doesn't.
+func (n *node) head() string {
+	if n.isList && len(n.list) > 0 && n.list[0].tok.kind == tokenKeyword {
+		return n.list[0].tok.text
+	}
+	return ""
+}
+
+// isKeyword returns true if `n` is the atom `keyword`.
+func (n *node) isKeyword(keyword string) bool {
+	return !n.isList && n.tok.kind == tokenKeyword && n.tok.text == keyword
+}
+
+func (n *node) errorf(format string, args ...interface{}) error {
+	return errorf(n.tok.line, n.tok.col, format, args...)
+}
+
+// parseNodes reads all S-expressions of the source. Annotations, i.e. lists
+// beginning with '@', are skipped.
+//
+// See https://github.com/WebAssembly/annotations/blob/main/proposals/annotations/Overview.md
+func parseNodes(src string) ([]*node, error) {
+	l := newLexer(src)
+	root := &node{isList: true}
+	stack := []*node{root}
+	for {
+		tok, ok, err := l.next()
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			break
+		}
+		top := stack[len(stack)-1]
+		switch tok.kind {
+		case tokenLParen:
+			n := &node{tok: tok, isList: true}
+			top.list = append(top.list, n)
+			stack = append(stack, n)
+		case tokenRParen:
+			if len(stack) == 1 {
+				return nil, errorf(tok.line, tok.col, "unexpected ')'")
+			}
+			stack = stack[:len(stack)-1]
+			if len(top.list) > 0 && top.list[0].tok.kind == tokenReserved && strings.HasPrefix(top.list[0].tok.text, "@") {
+				parent := stack[len(stack)-1]
+				parent.list = parent.list[:len(parent.list)-1]
+			}
+		default:
+			top.list = append(top.list, &node{tok: tok})
+		}
+	}
+	if len(stack) > 1 {
+		n := stack[len(stack)-1]
+		return nil, n.errorf("unclosed '('")
+	}
+	return root.list, nil
+}
@@ -0,0 +1,77 @@
+package wat
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestLexer(t *testing.T) {
+	l := newLexer(`(module $m ;; comment
+  (; nested (; block ;) comment ;)
+  (data "a\n\u{e9}\00" $"q") -1 offset=4)`)
+	var actual []token
+	for {
+		tok, ok, err := l.next()
+		require.NoError(t, err)
+		if !ok {
+			break
+		}
+		actual = append(actual, tok)
+	}
+	require.Equal(t, []token{
+		{kind: tokenLParen, line: 1, col: 1},
+		{kind: tokenKeyword, text: "module", line: 1, col: 2},
+		{kind: tokenID, text: "m", line: 1, col: 9},
+		{kind: tokenLParen, line: 3, col: 3},
+		{kind: tokenKeyword, text: "data", line: 3, col: 4},
+		{kind: tokenString, text: "a\né\x00", line: 3, col: 9},
+		{kind: tokenID, text: "q", line: 3, col: 24},
+		{kind: tokenRParen, line: 3, col: 28},
+		{kind: tokenReserved, text: "-1", line: 3, col: 30},
+		{kind: tokenKeyword, text: "offset=4", line: 3, col: 33},
+		{kind: tokenRParen, line: 3, col: 41},
+	}, actual)
+}
+
+func TestLexer_Errors(t *testing.T) {
+	tests := []struct {
+		input, expectedErr string
+	}{
+		{input: `"abc`, expectedErr: "1:1: unterminated string"},
+		{input: "\"a\nb\"", expectedErr: `1:3: illegal character in string: '\n'`},
+		{input: `"\q"`, expectedErr: "1:2: illegal escape"},
+		{input: `"\u{d800}"`, expectedErr: "1:2: malformed unicode escape"},
+		{input: "(; a", expectedErr: "1:1: unterminated block comment"},
+		{input: "$", expectedErr: "1:1: empty identifier"},
+		{input: `a"b"`, expectedErr: `1:2: unknown operator: missing separator after "a"`},
+		{input: "{", expectedErr: "1:1: unexpected character '{'"},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.input, func(t *testing.T) {
+			l := newLexer(tc.input)
+			var err error
+			for ok := true; ok && err == nil; {
+				_, ok, err = l.next()
+			}
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestParseNodes(t *testing.T) {
+	nodes, err := parseNodes(`(a (@annotation (b)) (c d)) e`)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(nodes))
+	require.Equal(t, "a", nodes[0].head())
+	require.Equal(t, 2, len(nodes[0].list))
+	require.Equal(t, "c", nodes[0].list[1].head())
+	require.True(t, nodes[1].isKeyword("e"))
+
+	_, err = parseNodes("(a")
+	require.EqualError(t, err, "1:1: unclosed '('")
+	_, err = parseNodes("a)")
+	require.EqualError(t, err, "1:2: unexpected ')'")
+}
@@ -0,0 +1,1386 @@
+package wat
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// funcType is a function type, whose value types are encoded, as a typed
+// reference is more than one byte.
+type funcType struct {
+	params, results []string
+}
+
+func (t *funcType) equal(o *funcType) bool {
+	if len(t.params) != len(o.params) || len(t.results) != len(o.results) {
+		return false
+	}
+	for i := range t.params {
+		if t.params[i] != o.params[i] {
+			return false
+		}
+	}
+	for i := range t.results {
+		if t.results[i] != o.results[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *funcType) encode() []byte {
+	ret := []byte{0x60}
+	ret = appendValTypes(ret, t.params)
+	return appendValTypes(ret, t.results)
+}
+
+func appendValTypes(dst []byte, vts []string) []byte {
+	dst = append(dst, leb128.EncodeUint32(uint32(len(vts)))...)
+	for _, vt := range vts {
+		dst = append(dst, vt...)
+	}
+	return dst
+}
+
+// space is an index space, e.g. of functions, and the identifiers of its
+// indexes.
+type space struct {
+	kind  string
+	ids   map[string]uint32
+	count uint32
+}
+
+// add adds an index identified by `id`, unless empty, returning it.
+func (s *space) add(n *node, id string) (uint32, error) {
+	if id != "" {
+		if _, ok := s.ids[id]; ok {
+			return 0, n.errorf("duplicate %s $%s", s.kind, id)
+		}
+		s.ids[id] = s.count
+	}
+	s.count++
+	return s.count - 1, nil
+}
+
+// resolve returns the index `n` refers to, by identifier or number.
+func (s *space) resolve(n *node) (uint32, error) {
+	if n == nil || n.isList {
+		return 0, errorfAt(n, "expected a %s index", s.kind)
+	}
+	switch n.tok.kind {
+	case tokenID:
+		if idx, ok := s.ids[n.tok.text]; ok {
+			return idx, nil
+		}
+		return 0, n.errorf("unknown %s $%s", s.kind, n.tok.text)
+	case tokenReserved:
+		if idx, ok := parseUint(n.tok.text, 32); ok {
+			return uint32(idx), nil
+		}
+	}
+	return 0, n.errorf("expected a %s index, but was %q", s.kind, n.tok.text)
+}
+
+// errorfAt is like node.errorf, except `n` may be nil at the end of a list.
+func errorfAt(n *node, format string, args ...interface{}) error {
+	if n == nil {
+		return fmt.Errorf(format, args...)
+	}
+	return n.errorf(format, args...)
+}
+
+// isIndex returns true if `n` is an identifier or a number, i.e. an index.
+func isIndex(n *node) bool {
+	return n != nil && !n.isList && (n.tok.kind == tokenID || n.tok.kind == tokenReserved)
+}
+
+// cursor reads the nodes of a list in order.
+type cursor struct {
+	list   []*node
+	i      int
+	parent *node
+}
+
+// newCursor returns a cursor over the nodes of the list `n` after `skip`,
+// e.g. 1 to skip its keyword.
+func newCursor(n *node, skip int) *cursor {
+	return &cursor{list: n.list, i: skip, parent: n}
+}
+
+func (c *cursor) done() bool {
+	return c.i >= len(c.list)
+}
+
+// peek returns the next node, or nil at the end.
+func (c *cursor) peek() *node {
+	if c.done() {
+		return nil
+	}
+	return c.list[c.i]
+}
+
+func (c *cursor) next() *node {
+	n := c.peek()
+	if n != nil {
+		c.i++
+	}
+	return n
+}
+
+// peekHead returns true if the next node is a list beginning with `keyword`.
+func (c *cursor) peekHead(keyword string) bool {
+	n := c.peek()
+	return n != nil && n.head() == keyword
+}
+
+// peekKeyword returns true if the next node is the atom `keyword`.
+func (c *cursor) peekKeyword(keyword string) bool {
+	n := c.peek()
+	return n != nil && n.isKeyword(keyword)
+}
+
+// id returns the next identifier, if it is one, or "".
+func (c *cursor) id() string {
+	if n := c.peek(); n != nil && !n.isList && n.tok.kind == tokenID {
+		c.i++
+		return n.tok.text
+	}
+	return ""
+}
+
+// string returns the next string.
+func (c *cursor) string() (string, error) {
+	n := c.next()
+	if n == nil || n.isList || n.tok.kind != tokenString {
+		return "", c.errorf(n, "expected a string")
+	}
+	return n.tok.text, nil
+}
+
+// name returns the next string, which must be valid UTF-8.
+func (c *cursor) name() (string, error) {
+	n := c.peek()
+	s, err := c.string()
+	if err == nil && !utf8.ValidString(s) {
+		err = n.errorf("malformed UTF-8 encoding")
+	}
+	return s, err
+}
+
+// end errs if there are nodes left.
+func (c *cursor) end() error {
+	if n := c.peek(); n != nil {
+		return c.errorf(n, "unexpected token")
+	}
+	return nil
+}
+
+// errorf returns an error at `n`, or at the end of the list if nil.
+func (c *cursor) errorf(n *node, format string, args ...interface{}) error {
+	if n == nil {
+		n = c.parent
+		format += " before ')'"
+	} else if !n.isList {
+		format += fmt.Sprintf(" at %q", n.tok.text)
+	}
+	return n.errorf(format, args...)
+}
+
+// builder builds the binary format of a module of the text format.
+type builder struct {
+	// types are the types of type fields, followed by those implicitly
+	// defined by type uses.
+	types []funcType
+
+	typeSpace, funcs, tables, memories, globals, tags, elems, datas space
+	// indexes are the indexes of the fields, by their node.
+	indexes map[*node]uint32
+	// memory64 are whether memories are 64-bit, by index.
+	memory64 []bool
+	// defined is the kind of the first definition of a function, table,
+	// memory, global or tag, after which imports are malformed.
+	defined string
+
+	// The entries of the sections, in the order of the fields defining them.
+	importSec, funcSec, tableSec, memorySec, tagSec, globalSec, exportSec, elemSec, codeSec, dataSec [][]byte
+	startSec                                                                                         []byte
+	// usesDataCount is set when an instruction refers to a data segment,
+	// which requires the data count section.
+	usesDataCount bool
+
+	moduleName string
+	funcNames  map[uint32]string
+	localNames map[uint32][]localName
+	labelNames map[uint32][]localName
+}
+
+// localName is the identifier of a local or label, for the name section.
+type localName struct {
+	index uint32
+	name  string
+}
+
+func newBuilder() *builder {
+	newSpace := func(kind string) space {
+		return space{kind: kind, ids: map[string]uint32{}}
+	}
+	return &builder{
+		typeSpace:  newSpace("type"),
+		funcs:      newSpace("func"),
+		tables:     newSpace("table"),
+		memories:   newSpace("memory"),
+		globals:    newSpace("global"),
+		tags:       newSpace("tag"),
+		elems:      newSpace("elem"),
+		datas:      newSpace("data"),
+		indexes:    map[*node]uint32{},
+		funcNames:  map[uint32]string{},
+		localNames: map[uint32][]localName{},
+		labelNames: map[uint32][]localName{},
+	}
+}
+
+// spaceOf returns the index space of the kind of an import or a definition.
+func (b *builder) spaceOf(kind string) *space {
+	switch kind {
+	case "func":
+		return &b.funcs
+	case "table":
+		return &b.tables
+	case "memory":
+		return &b.memories
+	case "global":
+		return &b.globals
+	case "tag":
+		return &b.tags
+	}
+	return nil
+}
+
+// externKind returns the kind of an import or an export in the binary format.
+func externKind(kind string) byte {
+	switch kind {
+	case "func":
+		return wasm.ExternTypeFunc
+	case "table":
+		return wasm.ExternTypeTable
+	case "memory":
+		return wasm.ExternTypeMemory
+	case "global":
+		return wasm.ExternTypeGlobal
+	}
+	return wasm.ExternTypeTag
+}
+
+// compileModule returns the binary format of the module with `fields`.
+func compileModule(name string, fields []*node) ([]byte, error) {
+	b := newBuilder()
+	b.moduleName = name
+	for _, f := range fields {
+		if err := b.declare(f); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range fields {
+		if f.head() == "type" {
+			if err := b.typeField(f); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, f := range fields {
+		if err := b.field(f); err != nil {
+			return nil, err
+		}
+	}
+	return b.encode(), nil
+}
+
+// declare adds the indexes a field defines to their space, so that fields can
+// refer to those of later ones.
+func (b *builder) declare(f *node) error {
+	if !f.isList {
+		return f.errorf("unexpected token %q", f.tok.text)
+	}
+	c := newCursor(f, 1)
+	kind := f.head()
+	switch kind {
+	case "type":
+		_, err := b.typeSpace.add(f, c.id())
+		return err
+	case "import":
+		c.next() // module
+		c.next() // name
+		desc := c.next()
+		s := b.spaceOf(desc.headOrEmpty())
+		if s == nil {
+			return c.errorf(desc, "invalid import description")
+		}
+		if b.defined != "" {
+			return f.errorf("import after %s", b.defined)
+		}
+		dc := newCursor(desc, 1)
+		idx, err := s.add(desc, dc.id())
+		b.indexes[f] = idx
+		if desc.head() == "memory" {
+			b.memory64 = append(b.memory64, dc.peekKeyword("i64"))
+		}
+		return err
+	case "func", "table", "memory", "global", "tag":
+		s := b.spaceOf(kind)
+		idx, err := s.add(f, c.id())
+		if err != nil {
+			return err
+		}
+		b.indexes[f] = idx
+		for c.peekHead("export") {
+			c.next()
+		}
+		if c.peekHead("import") {
+			if b.defined != "" {
+				return f.errorf("import after %s", b.defined)
+			}
+			c.next()
+		} else if b.defined == "" {
+			b.defined = kind
+		}
+		switch kind {
+		case "memory":
+			b.memory64 = append(b.memory64, c.peekKeyword("i64"))
+			if hasInline(c, "data") {
+				_, err = b.datas.add(f, "")
+			}
+		case "table":
+			if hasInline(c, "elem") {
+				_, err = b.elems.add(f, "")
+			}
+		}
+		return err
+	case "elem":
+		idx, err := b.elems.add(f, c.id())
+		b.indexes[f] = idx
+		return err
+	case "data":
+		idx, err := b.datas.add(f, c.id())
+		b.indexes[f] = idx
+		return err
+	case "export", "start":
+		return nil
+	}
+	return f.errorf("unknown module field %q", kind)
+}
+
+// headOrEmpty is like head, except it doesn't panic on a nil node.
+func (n *node) headOrEmpty() string {
+	if n == nil {
+		return ""
+	}
+	return n.head()
+}
+
+// hasInline returns true if the rest of the nodes at `c` include a list
+// beginning with `keyword`, e.g. the inline data of a memory.
+func hasInline(c *cursor, keyword string) bool {
+	for _, n := range c.list[c.i:] {
+		if n.head() == keyword {
+			return true
+		}
+	}
+	return false
+}
+
+// typeField parses `(type $id? (func (param ...)* (result ...)*))`.
+func (b *builder) typeField(f *node) error {
+	c := newCursor(f, 1)
+	c.id()
+	fn := c.next()
+	if fn.headOrEmpty() != "func" {
+		return c.errorf(fn, "expected a function type")
+	}
+	fc := newCursor(fn, 1)
+	fc.id()
+	t, _, err := b.funcType(fc, true)
+	if err != nil {
+		return err
+	}
+	if err = fc.end(); err != nil {
+		return err
+	}
+	b.types = append(b.types, t)
+	return c.end()
+}
+
+// funcType parses `(param ...)* (result ...)*`, returning the identifiers of
+// the params, or an error if they have any and `allowIDs` is false.
+func (b *builder) funcType(c *cursor, allowIDs bool) (t funcType, ids []string, err error) {
+	for c.peekHead("param") {
+		p := c.next()
+		pc := newCursor(p, 1)
+		if id := pc.id(); id != "" {
+			if !allowIDs {
+				return t, nil, p.errorf("unexpected identifier $%s", id)
+			}
+			vt, err := b.valType(pc.next(), pc)
+			if err != nil {
+				return t, nil, err
+			}
+			if err = pc.end(); err != nil {
+				return t, nil, err
+			}
+			t.params = append(t.params, vt)
+			ids = append(ids, id)
+			continue
+		}
+		for !pc.done() {
+			vt, err := b.valType(pc.next(), pc)
+			if err != nil {
+				return t, nil, err
+			}
+			t.params = append(t.params, vt)
+			ids = append(ids, "")
+		}
+	}
+	for c.peekHead("result") {
+		rc := newCursor(c.next(), 1)
+		for !rc.done() {
+			vt, err := b.valType(rc.next(), rc)
+			if err != nil {
+				return t, nil, err
+			}
+			t.results = append(t.results, vt)
+		}
+	}
+	return
+}
+
+// valType parses a value type, returning its binary format.
+func (b *builder) valType(n *node, c *cursor) (string, error) {
+	if n == nil {
+		return "", c.errorf(n, "expected a value type")
+	}
+	if n.isList {
+		if n.head() != "ref" {
+			return "", n.errorf("unknown value type")
+		}
+		rc := newCursor(n, 1)
+		prefix := wasm.RefTypeNonNullablePrefix
+		if rc.peekKeyword("null") {
+			rc.next()
+			prefix = wasm.RefTypeNullablePrefix
+		}
+		ht, err := b.heapType(rc.next(), rc)
+		if err != nil {
+			return "", err
+		}
+		return string(append([]byte{prefix}, ht...)), rc.end()
+	}
+	switch n.tok.text {
+	case "i32":
+		return string([]byte{wasm.ValueTypeI32}), nil
+	case "i64":
+		return string([]byte{wasm.ValueTypeI64}), nil
+	case "f32":
+		return string([]byte{wasm.ValueTypeF32}), nil
+	case "f64":
+		return string([]byte{wasm.ValueTypeF64}), nil
+	case "v128":
+		return string([]byte{wasm.ValueTypeV128}), nil
+	case "funcref":
+		return string([]byte{wasm.ValueTypeFuncref}), nil
+	case "externref":
+		return string([]byte{wasm.ValueTypeExternref}), nil
+	case "exnref":
+		return string([]byte{wasm.ValueTypeExnref}), nil
+	}
+	return "", c.errorf(n, "unknown value type")
+}
+
+// heapType parses the heap type of a typed reference or of ref.null.
+func (b *builder) heapType(n *node, c *cursor) ([]byte, error) {
+	if n != nil && !n.isList {
+		switch n.tok.text {
+		case "func":
+			return []byte{wasm.ValueTypeFuncref}, nil
+		case "extern":
+			return []byte{wasm.ValueTypeExternref}, nil
+		case "exn":
+			return []byte{wasm.ValueTypeExnref}, nil
+		}
+	}
+	if !isIndex(n) {
+		return nil, c.errorf(n, "expected a heap type")
+	}
+	idx, err := b.typeSpace.resolve(n)
+	if err != nil {
+		return nil, err
+	}
+	return leb128.EncodeInt64(int64(idx)), nil
+}
+
+// typeUse parses `(type x)? (param ...)* (result ...)*`, returning the index
+// of the type, which is appended if not defined, and the identifiers of its
+// params, which are only allowed if `allowIDs`.
+//
+// See https://webassembly.github.io/spec/core/text/modules.html#type-uses
+func (b *builder) typeUse(c *cursor, allowIDs bool) (uint32, []string, error) {
+	explicit := -1
+	if c.peekHead("type") {
+		n := c.next()
+		tc := newCursor(n, 1)
+		idx, err := b.typeSpace.resolve(tc.next())
+		if err != nil {
+			return 0, nil, err
+		}
+		if err = tc.end(); err != nil {
+			return 0, nil, err
+		}
+		explicit = int(idx)
+	}
+	inline := c.peekHead("param") || c.peekHead("result")
+	t, ids, err := b.funcType(c, allowIDs)
+	if err != nil {
+		return 0, nil, err
+	}
+	if explicit >= len(b.types) {
+		if inline {
+			return 0, nil, c.errorf(c.peek(), "unknown type %d", explicit)
+		}
+		// Otherwise, an unknown type is invalid, not malformed, so is left to
+		// validation.
+		return uint32(explicit), ids, nil
+	} else if explicit >= 0 {
+		if inline && !t.equal(&b.types[explicit]) {
+			return 0, nil, c.errorf(c.peek(), "inline function type doesn't match type %d", explicit)
+		} else if !inline {
+			ids = make([]string, len(b.types[explicit].params))
+		}
+		return uint32(explicit), ids, nil
+	}
+	return b.typeIndex(t), ids, nil
+}
+
+// typeIndex returns the index of the first type equal to `t`, appending it if
+// there is none.
+func (b *builder) typeIndex(t funcType) uint32 {
+	for i := range b.types {
+		if b.types[i].equal(&t) {
+			return uint32(i)
+		}
+	}
+	b.types = append(b.types, t)
+	return uint32(len(b.types) - 1)
+}
+
+// inlineExports parses `(export "name")*` of a definition of `kind`.
+func (b *builder) inlineExports(c *cursor, kind string, idx uint32) error {
+	for c.peekHead("export") {
+		ec := newCursor(c.next(), 1)
+		name, err := ec.name()
+		if err != nil {
+			return err
+		}
+		if err = ec.end(); err != nil {
+			return err
+		}
+		b.addExport(name, kind, idx)
+	}
+	return nil
+}
+
+func (b *builder) addExport(name, kind string, idx uint32) {
+	e := appendName(nil, name)
+	e = append(e, externKind(kind))
+	b.exportSec = append(b.exportSec, append(e, leb128.EncodeUint32(idx)...))
+}
+
+func appendName(dst []byte, name string) []byte {
+	dst = append(dst, leb128.EncodeUint32(uint32(len(name)))...)
+	return append(dst, name...)
+}
+
+// field parses a module field other than a type, adding it to its section.
+func (b *builder) field(f *node) error {
+	c := newCursor(f, 1)
+	switch kind := f.head(); kind {
+	case "type":
+		return nil
+	case "import":
+		module, err := c.name()
+		if err != nil {
+			return err
+		}
+		name, err := c.name()
+		if err != nil {
+			return err
+		}
+		desc := c.next()
+		if err = c.end(); err != nil {
+			return err
+		}
+		dc := newCursor(desc, 1)
+		id := dc.id()
+		return b.importDesc(desc.head(), module, name, id, b.indexes[f], dc)
+	case "func", "table", "memory", "global", "tag":
+		id := c.id()
+		idx := b.indexes[f]
+		if err := b.inlineExports(c, kind, idx); err != nil {
+			return err
+		}
+		if c.peekHead("import") {
+			ic := newCursor(c.next(), 1)
+			module, err := ic.name()
+			if err != nil {
+				return err
+			}
+			name, err := ic.name()
+			if err != nil {
+				return err
+			}
+			if err = ic.end(); err != nil {
+				return err
+			}
+			return b.importDesc(kind, module, name, id, idx, c)
+		}
+		switch kind {
+		case "func":
+			return b.funcField(c, id, idx)
+		case "table":
+			return b.tableField(f, c, idx)
+		case "memory":
+			return b.memoryField(f, c, idx)
+		case "global":
+			return b.globalField(c)
+		default:
+			t, _, err := b.typeUse(c, false)
+			if err != nil {
+				return err
+			}
+			b.tagSec = append(b.tagSec, append([]byte{0x00}, leb128.EncodeUint32(t)...))
+			return c.end()
+		}
+	case "export":
+		name, err := c.name()
+		if err != nil {
+			return err
+		}
+		desc := c.next()
+		s := b.spaceOf(desc.headOrEmpty())
+		if s == nil {
+			return c.errorf(desc, "invalid export description")
+		}
+		dc := newCursor(desc, 1)
+		idx, err := s.resolve(dc.next())
+		if err != nil {
+			return err
+		}
+		if err = dc.end(); err != nil {
+			return err
+		}
+		b.addExport(name, desc.head(), idx)
+		return c.end()
+	case "start":
+		if b.startSec != nil {
+			return f.errorf("multiple start sections")
+		}
+		idx, err := b.funcs.resolve(c.next())
+		if err != nil {
+			return err
+		}
+		b.startSec = leb128.EncodeUint32(idx)
+		return c.end()
+	case "elem":
+		return b.elemField(c)
+	default: // data
+		return b.dataField(c)
+	}
+}
+
+// importDesc adds the import of `kind` whose type is at `c`.
+func (b *builder) importDesc(kind, module, name, id string, idx uint32, c *cursor) error {
+	imp := appendName(appendName(nil, module), name)
+	imp = append(imp, externKind(kind))
+	switch kind {
+	case "func":
+		t, ids, err := b.typeUse(c, true)
+		if err != nil {
+			return err
+		}
+		imp = append(imp, leb128.EncodeUint32(t)...)
+		b.addFuncNames(idx, id, ids, nil)
+	case "table":
+		tt, err := b.tableType(c)
+		if err != nil {
+			return err
+		}
+		imp = append(imp, tt...)
+	case "memory":
+		mt, err := b.memoryType(c)
+		if err != nil {
+			return err
+		}
+		imp = append(imp, mt...)
+	case "global":
+		gt, err := b.globalType(c)
+		if err != nil {
+			return err
+		}
+		imp = append(imp, gt...)
+	case "tag":
+		t, _, err := b.typeUse(c, false)
+		if err != nil {
+			return err
+		}
+		imp = append(imp, 0x00)
+		imp = append(imp, leb128.EncodeUint32(t)...)
+	}
+	b.importSec = append(b.importSec, imp)
+	return c.end()
+}
+
+// addFuncNames records the names of a function and its locals for the name
+// section.
+func (b *builder) addFuncNames(idx uint32, id string, params, locals []string) {
+	if id != "" {
+		b.funcNames[idx] = id
+	}
+	var names []localName
+	for i, name := range append(append([]string{}, params...), locals...) {
+		if name != "" {
+			names = append(names, localName{index: uint32(i), name: name})
+		}
+	}
+	if len(names) > 0 {
+		b.localNames[idx] = names
+	}
+}
+
+// limits parses `min max?`, each of `bits`.
+func (b *builder) limits(c *cursor, bits int) (min uint64, max *uint64, err error) {
+	n := c.next()
+	if !isIndex(n) || n.tok.kind != tokenReserved {
+		return 0, nil, c.errorf(n, "expected the minimum of limits")
+	}
+	var ok bool
+	if min, ok = parseUint(n.tok.text, bits); !ok {
+		return 0, nil, n.errorf("invalid limits minimum: %s", n.tok.text)
+	}
+	if n = c.peek(); isIndex(n) && n.tok.kind == tokenReserved {
+		c.next()
+		m, ok := parseUint(n.tok.text, bits)
+		if !ok {
+			return 0, nil, n.errorf("invalid limits maximum: %s", n.tok.text)
+		}
+		max = &m
+	}
+	return
+}
+
+// tableType parses `min max? reftype`.
+func (b *builder) tableType(c *cursor) ([]byte, error) {
+	min, max, err := b.limits(c, 32)
+	if err != nil {
+		return nil, err
+	}
+	rt, err := b.valType(c.next(), c)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(rt), encodeLimits(0, min, max)...), nil
+}
+
+// encodeLimits encodes limits whose flags are `flags`, except "has max".
+func encodeLimits(flags byte, min uint64, max *uint64) []byte {
+	if max != nil {
+		flags |= 0x01
+	}
+	ret := append([]byte{flags}, leb128.EncodeUint64(min)...)
+	if max != nil {
+		ret = append(ret, leb128.EncodeUint64(*max)...)
+	}
+	return ret
+}
+
+// memoryType parses `i64? min max? shared?`.
+func (b *builder) memoryType(c *cursor) ([]byte, error) {
+	var flags byte
+	bits := 32
+	if c.peekKeyword("i64") {
+		c.next()
+		flags, bits = 0x04, 64
+	} else if c.peekKeyword("i32") {
+		c.next()
+	}
+	min, max, err := b.limits(c, bits)
+	if err != nil {
+		return nil, err
+	}
+	if c.peekKeyword("shared") {
+		c.next()
+		flags |= 0x02
+	}
+	return encodeLimits(flags, min, max), nil
+}
+
+// globalType parses `valtype` or `(mut valtype)`.
+func (b *builder) globalType(c *cursor) ([]byte, error) {
+	n := c.next()
+	if n.headOrEmpty() == "mut" {
+		mc := newCursor(n, 1)
+		vt, err := b.valType(mc.next(), mc)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(vt), 0x01), mc.end()
+	}
+	vt, err := b.valType(n, c)
+	return append([]byte(vt), 0x00), err
+}
+
+// funcField parses the rest of `(func $id? typeuse local* instr*)`.
+func (b *builder) funcField(c *cursor, id string, idx uint32) error {
+	t, params, err := b.typeUse(c, true)
+	if err != nil {
+		return err
+	}
+	fc := &funcCtx{locals: map[string]uint32{}}
+	for i, p := range params {
+		if p == "" {
+			continue
+		} else if _, ok := fc.locals[p]; ok {
+			return c.parent.errorf("duplicate local $%s", p)
+		}
+		fc.locals[p] = uint32(i)
+	}
+	fc.numLocals = uint32(len(params))
+
+	var localTypes, localIDs []string
+	for c.peekHead("local") {
+		l := c.next()
+		lc := newCursor(l, 1)
+		if id := lc.id(); id != "" {
+			if _, ok := fc.locals[id]; ok {
+				return l.errorf("duplicate local $%s", id)
+			}
+			vt, err := b.valType(lc.next(), lc)
+			if err != nil {
+				return err
+			}
+			if err = lc.end(); err != nil {
+				return err
+			}
+			fc.locals[id] = fc.numLocals
+			fc.numLocals++
+			localTypes, localIDs = append(localTypes, vt), append(localIDs, id)
+			continue
+		}
+		for !lc.done() {
+			vt, err := b.valType(lc.next(), lc)
+			if err != nil {
+				return err
+			}
+			fc.numLocals++
+			localTypes, localIDs = append(localTypes, vt), append(localIDs, "")
+		}
+	}
+
+	e := &encoder{b: b, f: fc}
+	if err = e.instrs(c); err != nil {
+		return err
+	}
+	if err = c.end(); err != nil {
+		return err
+	}
+	e.out = append(e.out, wasm.OpcodeEnd)
+
+	b.funcSec = append(b.funcSec, leb128.EncodeUint32(t))
+	b.codeSec = append(b.codeSec, encodeCode(localTypes, e.out))
+	b.addFuncNames(idx, id, params, localIDs)
+	if len(fc.labelNames) > 0 {
+		b.labelNames[idx] = fc.labelNames
+	}
+	return nil
+}
+
+// encodeCode encodes the body of a function, compressing runs of locals of
+// the same type.
+func encodeCode(localTypes []string, body []byte) []byte {
+	var runs []byte
+	count := 0
+	for i := 0; i < len(localTypes); {
+		j := i
+		for j < len(localTypes) && localTypes[j] == localTypes[i] {
+			j++
+		}
+		runs = append(runs, leb128.EncodeUint32(uint32(j-i))...)
+		runs = append(runs, localTypes[i]...)
+		count++
+		i = j
+	}
+	code := append(leb128.EncodeUint32(uint32(count)), runs...)
+	code = append(code, body...)
+	return append(leb128.EncodeUint32(uint32(len(code))), code...)
+}
+
+// constExpr parses the instructions at `c` as a constant expression.
+func (b *builder) constExpr(c *cursor) ([]byte, error) {
+	e := &encoder{b: b, f: &funcCtx{locals: map[string]uint32{}}}
+	if err := e.instrs(c); err != nil {
+		return nil, err
+	}
+	if err := c.end(); err != nil {
+		return nil, err
+	}
+	return append(e.out, wasm.OpcodeEnd), nil
+}
+
+// tableField parses the rest of a table definition, which may define its
+// elements inline.
+func (b *builder) tableField(f *node, c *cursor, idx uint32) error {
+	if n := c.peek(); n != nil && !isIndex(n) {
+		// The elements are inline: `reftype (elem ...)`.
+		rt, err := b.valType(c.next(), c)
+		if err != nil {
+			return err
+		}
+		elem := c.next()
+		if elem.headOrEmpty() != "elem" {
+			return c.errorf(elem, "expected inline elements")
+		}
+		if err = c.end(); err != nil {
+			return err
+		}
+		ec := newCursor(elem, 1)
+		var items [][]byte
+		var indexes []uint32
+		if isIndex(ec.peek()) {
+			if indexes, err = b.funcIndexes(ec); err != nil {
+				return err
+			}
+		} else if items, err = b.elemExprs(ec); err != nil {
+			return err
+		}
+		count := uint64(len(items) + len(indexes))
+		b.tableSec = append(b.tableSec, append([]byte(rt), encodeLimits(0, count, &count)...))
+		offset := []byte{wasm.OpcodeI32Const, 0, wasm.OpcodeEnd}
+		b.elemSec = append(b.elemSec, encodeActiveElem(idx, offset, rt, indexes, items))
+		return nil
+	}
+	tt, err := b.tableType(c)
+	if err != nil {
+		return err
+	}
+	if !c.done() { // an initializer of the function references proposal
+		init, err := b.constExpr(c)
+		if err != nil {
+			return err
+		}
+		tt = append(append([]byte{0x40, 0x00}, tt...), init...)
+	}
+	b.tableSec = append(b.tableSec, tt)
+	return nil
+}
+
+// memoryField parses the rest of a memory definition, which may define its
+// data inline.
+func (b *builder) memoryField(f *node, c *cursor, idx uint32) error {
+	is64 := false
+	if c.peekKeyword("i64") && c.i+1 < len(c.list) && c.list[c.i+1].head() == "data" {
+		c.next()
+		is64 = true
+	}
+	if c.peekHead("data") {
+		dc := newCursor(c.next(), 1)
+		var data []byte
+		for !dc.done() {
+			s, err := dc.string()
+			if err != nil {
+				return err
+			}
+			data = append(data, s...)
+		}
+		if err := c.end(); err != nil {
+			return err
+		}
+		pages := (uint64(len(data)) + 65535) / 65536
+		var flags byte
+		offset := []byte{wasm.OpcodeI32Const, 0, wasm.OpcodeEnd}
+		if is64 {
+			flags, offset[0] = 0x04, wasm.OpcodeI64Const
+		}
+		b.memorySec = append(b.memorySec, encodeLimits(flags, pages, &pages))
+		b.dataSec = append(b.dataSec, encodeActiveData(idx, offset, data))
+		return nil
+	}
+	mt, err := b.memoryType(c)
+	if err != nil {
+		return err
+	}
+	b.memorySec = append(b.memorySec, mt)
+	return c.end()
+}
+
+// globalField parses the rest of `(global $id? globaltype expr)`.
+func (b *builder) globalField(c *cursor) error {
+	gt, err := b.globalType(c)
+	if err != nil {
+		return err
+	}
+	init, err := b.constExpr(c)
+	if err != nil {
+		return err
+	}
+	b.globalSec = append(b.globalSec, append(gt, init...))
+	return nil
+}
+
+// funcIndexes parses `funcidx*`.
+func (b *builder) funcIndexes(c *cursor) ([]uint32, error) {
+	var ret []uint32
+	for !c.done() {
+		idx, err := b.funcs.resolve(c.next())
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, idx)
+	}
+	return ret, nil
+}
+
+// elemExprs parses `((item instr*) | instr)*`.
+func (b *builder) elemExprs(c *cursor) ([][]byte, error) {
+	var ret [][]byte
+	for !c.done() {
+		n := c.next()
+		if !n.isList {
+			return nil, n.errorf("expected an element expression")
+		}
+		ic := &cursor{list: []*node{n}, parent: n}
+		if n.head() == "item" {
+			ic = newCursor(n, 1)
+		}
+		expr, err := b.constExpr(ic)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, expr)
+	}
+	return ret, nil
+}
+
+// offset parses `(offset instr*)` or a folded instruction.
+func (b *builder) offset(c *cursor) ([]byte, error) {
+	n := c.next()
+	if n == nil || !n.isList {
+		return nil, c.errorf(n, "expected an offset")
+	}
+	if n.head() == "offset" {
+		return b.constExpr(newCursor(n, 1))
+	}
+	return b.constExpr(&cursor{list: []*node{n}, parent: n})
+}
+
+// elemField parses the rest of an elem field.
+//
+// See https://webassembly.github.io/spec/core/text/modules.html#element-segments
+func (b *builder) elemField(c *cursor) error {
+	c.id()
+	var mode byte // 0 active, 1 passive, 3 declarative
+	var table uint32
+	var offset []byte
+	switch {
+	case c.peekKeyword("declare"):
+		c.next()
+		mode = 3
+	case c.peekHead("table"), c.peekHead("offset"), isIndex(c.peek()):
+		if c.peekHead("table") {
+			tc := newCursor(c.next(), 1)
+			idx, err := b.tables.resolve(tc.next())
+			if err != nil {
+				return err
+			}
+			if err = tc.end(); err != nil {
+				return err
+			}
+			table = idx
+		} else if isIndex(c.peek()) { // legacy `(elem 0 (offset ...) ...)`
+			idx, err := b.tables.resolve(c.next())
+			if err != nil {
+				return err
+			}
+			table = idx
+		}
+		var err error
+		if offset, err = b.offset(c); err != nil {
+			return err
+		}
+	default:
+		// Either passive, or active with an offset given as an instruction.
+		if n := c.peek(); n != nil && n.isList && n.head() != "item" && n.head() != "ref" {
+			var err error
+			if offset, err = b.offset(c); err != nil {
+				return err
+			}
+		} else {
+			mode = 1
+		}
+	}
+
+	// elemlist: `reftype elemexpr*`, `func funcidx*`, or funcidx* after an
+	// offset.
+	rt := string([]byte{wasm.ValueTypeFuncref})
+	var indexes []uint32
+	var items [][]byte
+	var err error
+	switch n := c.peek(); {
+	case n != nil && n.isKeyword("func"):
+		c.next()
+		indexes, err = b.funcIndexes(c)
+	case n == nil || isIndex(n):
+		if mode != 0 && n != nil {
+			return c.errorf(n, "expected a reference type")
+		}
+		indexes, err = b.funcIndexes(c)
+	default:
+		if rt, err = b.valType(c.next(), c); err == nil {
+			items, err = b.elemExprs(c)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if mode == 0 {
+		b.elemSec = append(b.elemSec, encodeActiveElem(table, offset, rt, indexes, items))
+		return nil
+	}
+	flags := mode // passive 1, declarative 3
+	var elem []byte
+	if items != nil || rt != string([]byte{wasm.ValueTypeFuncref}) {
+		elem = append([]byte{flags | 0x04}, rt...)
+		elem = appendExprs(elem, items)
+	} else {
+		elem = append([]byte{flags}, 0x00) // elemkind funcref
+		elem = appendIndexes(elem, indexes)
+	}
+	b.elemSec = append(b.elemSec, elem)
+	return nil
+}
+
+// encodeActiveElem encodes an active element segment of either `indexes` or
+// `items`.
+func encodeActiveElem(table uint32, offset []byte, rt string, indexes []uint32, items [][]byte) []byte {
+	isFuncref := rt == string([]byte{wasm.ValueTypeFuncref})
+	var elem []byte
+	switch {
+	case items == nil && table == 0 && isFuncref:
+		elem = appendIndexes(append([]byte{0x00}, offset...), indexes)
+	case items == nil:
+		elem = append([]byte{0x02}, leb128.EncodeUint32(table)...)
+		elem = append(append(elem, offset...), 0x00)
+		elem = appendIndexes(elem, indexes)
+	case table == 0 && isFuncref:
+		elem = appendExprs(append([]byte{0x04}, offset...), items)
+	default:
+		elem = append([]byte{0x06}, leb128.EncodeUint32(table)...)
+		elem = append(append(elem, offset...), rt...)
+		elem = appendExprs(elem, items)
+	}
+	return elem
+}
+
+func appendIndexes(dst []byte, indexes []uint32) []byte {
+	dst = append(dst, leb128.EncodeUint32(uint32(len(indexes)))...)
+	for _, idx := range indexes {
+		dst = append(dst, leb128.EncodeUint32(idx)...)
+	}
+	return dst
+}
+
+func appendExprs(dst []byte, exprs [][]byte) []byte {
+	dst = append(dst, leb128.EncodeUint32(uint32(len(exprs)))...)
+	for _, expr := range exprs {
+		dst = append(dst, expr...)
+	}
+	return dst
+}
+
+// dataField parses the rest of a data field.
+//
+// See https://webassembly.github.io/spec/core/text/modules.html#data-segments
+func (b *builder) dataField(c *cursor) error {
+	c.id()
+	var memory uint32
+	var offset []byte
+	if c.peekHead("memory") {
+		mc := newCursor(c.next(), 1)
+		idx, err := b.memories.resolve(mc.next())
+		if err != nil {
+			return err
+		}
+		if err = mc.end(); err != nil {
+			return err
+		}
+		memory = idx
+	} else if isIndex(c.peek()) { // legacy `(data 0 (offset ...) ...)`
+		idx, err := b.memories.resolve(c.next())
+		if err != nil {
+			return err
+		}
+		memory = idx
+	}
+	if n := c.peek(); n != nil && n.isList {
+		var err error
+		if offset, err = b.offset(c); err != nil {
+			return err
+		}
+	} else if memory != 0 || c.i > 1 && c.list[c.i-1].isList {
+		return c.errorf(n, "expected an offset")
+	}
+	var data []byte
+	for !c.done() {
+		s, err := c.string()
+		if err != nil {
+			return err
+		}
+		data = append(data, s...)
+	}
+	if offset == nil {
+		d := append([]byte{0x01}, leb128.EncodeUint32(uint32(len(data)))...)
+		b.dataSec = append(b.dataSec, append(d, data...))
+		return nil
+	}
+	b.dataSec = append(b.dataSec, encodeActiveData(memory, offset, data))
+	return nil
+}
+
+func encodeActiveData(memory uint32, offset, data []byte) []byte {
+	var d []byte
+	if memory == 0 {
+		d = append([]byte{0x00}, offset...)
+	} else {
+		d = append([]byte{0x02}, leb128.EncodeUint32(memory)...)
+		d = append(d, offset...)
+	}
+	d = append(d, leb128.EncodeUint32(uint32(len(data)))...)
+	return append(d, data...)
+}
+
+// encode returns the binary format of the module.
+func (b *builder) encode() []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00})
+	section := func(id byte, payload []byte) {
+		buf.WriteByte(id)
+		buf.Write(leb128.EncodeUint32(uint32(len(payload))))
+		buf.Write(payload)
+	}
+	vector := func(id byte, items [][]byte) {
+		if len(items) > 0 {
+			section(id, appendExprs(nil, items))
+		}
+	}
+	types := make([][]byte, len(b.types))
+	for i := range b.types {
+		types[i] = b.types[i].encode()
+	}
+	vector(wasm.SectionIDType, types)
+	vector(wasm.SectionIDImport, b.importSec)
+	vector(wasm.SectionIDFunction, b.funcSec)
+	vector(wasm.SectionIDTable, b.tableSec)
+	vector(wasm.SectionIDMemory, b.memorySec)
+	vector(wasm.SectionIDTag, b.tagSec)
+	vector(wasm.SectionIDGlobal, b.globalSec)
+	vector(wasm.SectionIDExport, b.exportSec)
+	if b.startSec != nil {
+		section(wasm.SectionIDStart, b.startSec)
+	}
+	vector(wasm.SectionIDElement, b.elemSec)
+	if b.usesDataCount {
+		section(wasm.SectionIDDataCount, leb128.EncodeUint32(uint32(len(b.dataSec))))
+	}
+	vector(wasm.SectionIDCode, b.codeSec)
+	vector(wasm.SectionIDData, b.dataSec)
+	if names := b.encodeNames(); names != nil {
+		section(wasm.SectionIDCustom, names)
+	}
+	return buf.Bytes()
+}
+
+// encodeNames encodes the "name" section of the identifiers of the module,
+// its functions and their locals, or returns nil if there are none.
+func (b *builder) encodeNames() []byte {
+	var ret []byte
+	subsection := func(id byte, count int, payload []byte) {
+		payload = append(leb128.EncodeUint32(uint32(count)), payload...)
+		ret = append(ret, id)
+		ret = append(ret, leb128.EncodeUint32(uint32(len(payload)))...)
+		ret = append(ret, payload...)
+	}
+	if b.moduleName != "" {
+		name := appendName(nil, b.moduleName)
+		ret = append(append(ret, 0), leb128.EncodeUint32(uint32(len(name)))...)
+		ret = append(ret, name...)
+	}
+	var funcNames []byte
+	funcCount := 0
+	for idx := uint32(0); idx < b.funcs.count; idx++ {
+		if name, ok := b.funcNames[idx]; ok {
+			funcNames = append(funcNames, leb128.EncodeUint32(idx)...)
+			funcNames = appendName(funcNames, name)
+			funcCount++
+		}
+	}
+	if funcCount > 0 {
+		subsection(1, funcCount, funcNames)
+	}
+	indirect := func(id byte, names map[uint32][]localName) {
+		var payload []byte
+		count := 0
+		for idx := uint32(0); idx < b.funcs.count; idx++ {
+			if names, ok := names[idx]; ok {
+				payload = append(payload, leb128.EncodeUint32(idx)...)
+				payload = append(payload, leb128.EncodeUint32(uint32(len(names)))...)
+				for _, n := range names {
+					payload = append(payload, leb128.EncodeUint32(n.index)...)
+					payload = appendName(payload, n.name)
+				}
+				count++
+			}
+		}
+		if count > 0 {
+			subsection(id, count, payload)
+		}
+	}
+	indirect(2, b.localNames)
+	// The rest are of the extended-name-section proposal.
+	indirect(3, b.labelNames)
+	for _, s := range []struct {
+		id    byte
+		space *space
+	}{
+		{4, &b.typeSpace}, {5, &b.tables}, {6, &b.memories}, {7, &b.globals}, {8, &b.elems}, {9, &b.datas}, {11, &b.tags},
+	} {
+		if len(s.space.ids) == 0 {
+			continue
+		}
+		names := make([]string, s.space.count)
+		for name, idx := range s.space.ids {
+			names[idx] = name
+		}
+		var payload []byte
+		for idx, name := range names {
+			if name != "" {
+				payload = append(payload, leb128.EncodeUint32(uint32(idx))...)
+				payload = appendName(payload, name)
+			}
+		}
+		subsection(s.id, len(s.space.ids), payload)
+	}
+	if ret == nil {
+		return nil
+	}
+	return append(appendName(nil, "name"), ret...)
+}
@@ -0,0 +1,160 @@
+package wat
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// parseNat parses the digits of `s` in `base`, which may be separated by
+// single underscores, returning false if it is malformed or doesn't fit in
+// `bits`.
+//
+// See https://webassembly.github.io/spec/core/text/values.html#integers
+func parseNat(s string, base uint64, bits int) (v uint64, ok bool) {
+	if s == "" || s[0] == '_' || s[len(s)-1] == '_' {
+		return 0, false
+	}
+	overflow := false
+	for i := 0; i < len(s); i++ {
+		if s[i] == '_' {
+			if s[i-1] == '_' {
+				return 0, false
+			}
+			continue
+		}
+		d := hexDigit(s[i])
+		if d < 0 || uint64(d) >= base {
+			return 0, false
+		}
+		if v > (math.MaxUint64-uint64(d))/base {
+			overflow = true
+		}
+		v = v*base + uint64(d)
+	}
+	if overflow || (bits < 64 && v>>bits != 0) {
+		return 0, false
+	}
+	return v, true
+}
+
+// parseUint parses an unsigned integer of `bits`, e.g. an index or an offset.
+func parseUint(s string, bits int) (uint64, bool) {
+	if strings.HasPrefix(s, "0x") {
+		return parseNat(s[2:], 16, bits)
+	}
+	return parseNat(s, 10, bits)
+}
+
+// parseInt parses an integer of `bits`, which may be written signed or
+// unsigned, returning its two's complement.
+func parseInt(s string, bits int) (uint64, bool) {
+	neg := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg, s = true, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	default:
+		return parseUint(s, bits)
+	}
+	v, ok := parseUint(s, bits)
+	if !ok {
+		return 0, false
+	}
+	limit := uint64(1) << (bits - 1)
+	if neg {
+		if v > limit {
+			return 0, false
+		}
+		v = -v
+	} else if v >= limit {
+		return 0, false
+	}
+	if bits < 64 {
+		v &= 1<<bits - 1
+	}
+	return v, true
+}
+
+// parseFloat parses a float of `bits`, i.e. 32 or 64, returning its IEEE 754
+// bits.
+//
+// See https://webassembly.github.io/spec/core/text/values.html#floating-point
+func parseFloat(s string, bits int) (uint64, bool) {
+	mantissaBits, expBits := 52, 11
+	if bits == 32 {
+		mantissaBits, expBits = 23, 8
+	}
+	var sign uint64
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = 1<<(bits-1), s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	expMask := uint64(1<<expBits-1) << mantissaBits
+	switch {
+	case s == "inf":
+		return sign | expMask, true
+	case s == "nan":
+		return sign | expMask | 1<<(mantissaBits-1), true
+	case strings.HasPrefix(s, "nan:0x"):
+		payload, ok := parseNat(s[6:], 16, mantissaBits)
+		if !ok || payload == 0 {
+			return 0, false
+		}
+		return sign | expMask | payload, true
+	}
+
+	base, exp := uint64(10), "eE"
+	digits := s
+	if strings.HasPrefix(s, "0x") {
+		base, exp, digits = 16, "pP", s[2:]
+	}
+	// Validate the syntax, as strconv is more lenient, e.g. with underscores.
+	mantissa, exponent := digits, ""
+	if i := strings.IndexAny(digits, exp); i >= 0 {
+		mantissa, exponent = digits[:i], digits[i+1:]
+		if strings.HasPrefix(exponent, "+") || strings.HasPrefix(exponent, "-") {
+			exponent = exponent[1:]
+		}
+		if !isDigits(exponent, 10) {
+			return 0, false
+		}
+	}
+	whole, frac := mantissa, ""
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		whole, frac = mantissa[:i], mantissa[i+1:]
+	}
+	if !isDigits(whole, base) || (frac != "" && !isDigits(frac, base)) {
+		return 0, false
+	}
+
+	text := strings.ReplaceAll(s, "_", "")
+	if base == 16 && exponent == "" && !strings.ContainsAny(digits, exp) {
+		text += "p0"
+	}
+	f, err := strconv.ParseFloat(text, bits)
+	if err != nil { // out of range
+		return 0, false
+	}
+	if bits == 32 {
+		return sign | uint64(math.Float32bits(float32(f))), true
+	}
+	return sign | math.Float64bits(f), true
+}
+
+// isDigits returns true if `s` is a non-empty sequence of digits of `base`,
+// which may be separated by single underscores.
+func isDigits(s string, base uint64) bool {
+	if s == "" || s[0] == '_' || s[len(s)-1] == '_' || strings.Contains(s, "__") {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if d := hexDigit(s[i]); s[i] != '_' && (d < 0 || uint64(d) >= base) {
+			return false
+		}
+	}
+	return true
+}
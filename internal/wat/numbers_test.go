@@ -0,0 +1,81 @@
+package wat
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestParseInt(t *testing.T) {
+	tests := []struct {
+		input    string
+		bits     int
+		expected uint64
+	}{
+		{input: "0", bits: 32, expected: 0},
+		{input: "1_000", bits: 32, expected: 1000},
+		{input: "0xffff_ffff", bits: 32, expected: math.MaxUint32},
+		{input: "-1", bits: 32, expected: math.MaxUint32},
+		{input: "-0x8000_0000", bits: 32, expected: 0x8000_0000},
+		{input: "+0x7fff_ffff", bits: 32, expected: 0x7fff_ffff},
+		{input: "-1", bits: 64, expected: math.MaxUint64},
+		{input: "18446744073709551615", bits: 64, expected: math.MaxUint64},
+		{input: "255", bits: 8, expected: 255},
+		{input: "-128", bits: 8, expected: 0x80},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.input, func(t *testing.T) {
+			v, ok := parseInt(tc.input, tc.bits)
+			require.True(t, ok)
+			require.Equal(t, tc.expected, v)
+		})
+	}
+}
+
+func TestParseInt_Malformed(t *testing.T) {
+	for _, input := range []string{"", "_1", "1_", "1__0", "0x", "0xg", "a", "--1", "4294967296", "-0x8000_0001", "+0x8000_0000"} {
+		_, ok := parseInt(input, 32)
+		require.False(t, ok, input)
+	}
+}
+
+func TestParseFloat(t *testing.T) {
+	tests := []struct {
+		input    string
+		bits     int
+		expected uint64
+	}{
+		{input: "0", bits: 32, expected: 0},
+		{input: "-0", bits: 32, expected: 0x8000_0000},
+		{input: "1.5", bits: 32, expected: uint64(math.Float32bits(1.5))},
+		{input: "1.", bits: 64, expected: math.Float64bits(1)},
+		{input: "1e1_0", bits: 64, expected: math.Float64bits(1e10)},
+		{input: "0x1.8p1", bits: 64, expected: math.Float64bits(3)},
+		{input: "0x10", bits: 64, expected: math.Float64bits(16)},
+		{input: "0x1p-149", bits: 32, expected: 1},
+		{input: "inf", bits: 32, expected: 0x7f80_0000},
+		{input: "-inf", bits: 64, expected: 0xfff0_0000_0000_0000},
+		{input: "nan", bits: 32, expected: 0x7fc0_0000},
+		{input: "-nan:0x1", bits: 32, expected: 0xff80_0001},
+		{input: "nan:0xf_ffff_ffff_ffff", bits: 64, expected: 0x7fff_ffff_ffff_ffff},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.input, func(t *testing.T) {
+			v, ok := parseFloat(tc.input, tc.bits)
+			require.True(t, ok)
+			require.Equal(t, tc.expected, v)
+		})
+	}
+}
+
+func TestParseFloat_Malformed(t *testing.T) {
+	for _, input := range []string{"", ".1", "1e", "1e_1", "1_.0", "0x.1", "0x1p", "nan:0x0", "nan:0x80_0000", "1e39", "infinity"} {
+		_, ok := parseFloat(input, 32)
+		require.False(t, ok, input)
+	}
+}
@@ -0,0 +1,220 @@
+package wat
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// immediate is the kind of the immediates of an instruction.
+type immediate byte
+
+const (
+	immNone immediate = iota
+	immBlock
+	immLabel
+	immBrTable
+	immFunc
+	immCallIndirect
+	immTypeIndex
+	immLocal
+	immGlobal
+	immTable
+	immTableCopy
+	immTableInit
+	immElem
+	immMemory
+	immMemoryCopy
+	immMemoryInit
+	immData
+	immI32
+	immI64
+	immF32
+	immF64
+	immV128
+	immHeapType
+	immSelect
+	immTag
+	immMemarg
+	immLane
+	immMemargLane
+	immShuffle
+	immTryTable
+	immZero
+)
+
+// opcode is an instruction of the text format and its binary encoding.
+type opcode struct {
+	// prefix is the leading byte of a multi-byte opcode, e.g.
+	// wasm.OpcodeVecPrefix, or zero.
+	prefix byte
+	code   byte
+	imm    immediate
+	// align is the log2 of the natural alignment of a memory access.
+	align uint32
+}
+
+// opcodes are the instructions by their name, except the block delimiters
+// "else", "end", "catch", "catch_all" and "delegate", and "select", whose
+// opcode depends on its immediates.
+var opcodes = map[string]opcode{}
+
+// opcodeNames are the names of the instructions in opcodes, by their prefix
+// and code, i.e. `uint16(prefix)<<8 | uint16(code)`.
+var opcodeNames = map[uint16]string{}
+
+func init() {
+	for i := 0; i < 256; i++ {
+		code := byte(i)
+		name := wasm.InstructionName(code)
+		switch code {
+		case wasm.OpcodeElse, wasm.OpcodeEnd, wasm.OpcodeCatch, wasm.OpcodeCatchAll, wasm.OpcodeDelegate,
+			wasm.OpcodeSelect, wasm.OpcodeTypedSelect,
+			wasm.OpcodeGCPrefix, wasm.OpcodeMiscPrefix, wasm.OpcodeVecPrefix, wasm.OpcodeAtomicPrefix:
+			name = ""
+		}
+		if name != "" {
+			opcodes[name] = opcode{code: code, imm: immediateOf(code), align: naturalAlignment(name)}
+		}
+		if name = wasm.MiscInstructionName(code); name != "" {
+			opcodes[name] = opcode{prefix: wasm.OpcodeMiscPrefix, code: code, imm: miscImmediateOf(code)}
+		}
+		if name = wasm.VectorInstructionName(code); name != "" {
+			opcodes[name] = opcode{prefix: wasm.OpcodeVecPrefix, code: code, imm: vectorImmediateOf(code), align: naturalAlignment(name)}
+		}
+		if name = wasm.AtomicInstructionName(code); name != "" {
+			imm := immMemarg
+			if code == wasm.OpcodeAtomicFence {
+				imm = immZero
+			}
+			opcodes[name] = opcode{prefix: wasm.OpcodeAtomicPrefix, code: code, imm: imm, align: naturalAlignment(name)}
+		}
+	}
+	for name, op := range opcodes {
+		opcodeNames[uint16(op.prefix)<<8|uint16(op.code)] = name
+	}
+}
+
+func immediateOf(code byte) immediate {
+	switch code {
+	case wasm.OpcodeBlock, wasm.OpcodeLoop, wasm.OpcodeIf, wasm.OpcodeTry:
+		return immBlock
+	case wasm.OpcodeBr, wasm.OpcodeBrIf, wasm.OpcodeRethrow, wasm.OpcodeBrOnNull, wasm.OpcodeBrOnNonNull:
+		return immLabel
+	case wasm.OpcodeBrTable:
+		return immBrTable
+	case wasm.OpcodeCall, wasm.OpcodeTailCallReturnCall, wasm.OpcodeRefFunc:
+		return immFunc
+	case wasm.OpcodeCallIndirect, wasm.OpcodeTailCallReturnCallIndirect:
+		return immCallIndirect
+	case wasm.OpcodeCallRef, wasm.OpcodeTailCallReturnCallRef:
+		return immTypeIndex
+	case wasm.OpcodeLocalGet, wasm.OpcodeLocalSet, wasm.OpcodeLocalTee:
+		return immLocal
+	case wasm.OpcodeGlobalGet, wasm.OpcodeGlobalSet:
+		return immGlobal
+	case wasm.OpcodeTableGet, wasm.OpcodeTableSet:
+		return immTable
+	case wasm.OpcodeMemorySize, wasm.OpcodeMemoryGrow:
+		return immMemory
+	case wasm.OpcodeI32Const:
+		return immI32
+	case wasm.OpcodeI64Const:
+		return immI64
+	case wasm.OpcodeF32Const:
+		return immF32
+	case wasm.OpcodeF64Const:
+		return immF64
+	case wasm.OpcodeRefNull:
+		return immHeapType
+	case wasm.OpcodeThrow:
+		return immTag
+	case wasm.OpcodeTryTable:
+		return immTryTable
+	}
+	if code >= wasm.OpcodeI32Load && code <= wasm.OpcodeI64Store32 {
+		return immMemarg
+	}
+	return immNone
+}
+
+func miscImmediateOf(code byte) immediate {
+	switch code {
+	case wasm.OpcodeMiscMemoryInit:
+		return immMemoryInit
+	case wasm.OpcodeMiscDataDrop:
+		return immData
+	case wasm.OpcodeMiscMemoryCopy:
+		return immMemoryCopy
+	case wasm.OpcodeMiscMemoryFill:
+		return immMemory
+	case wasm.OpcodeMiscTableInit:
+		return immTableInit
+	case wasm.OpcodeMiscElemDrop:
+		return immElem
+	case wasm.OpcodeMiscTableCopy:
+		return immTableCopy
+	case wasm.OpcodeMiscTableGrow, wasm.OpcodeMiscTableSize, wasm.OpcodeMiscTableFill:
+		return immTable
+	}
+	return immNone
+}
+
+func vectorImmediateOf(code byte) immediate {
+	switch {
+	case code <= wasm.OpcodeVecV128Store:
+		return immMemarg
+	case code == wasm.OpcodeVecV128Const:
+		return immV128
+	case code == wasm.OpcodeVecV128i8x16Shuffle:
+		return immShuffle
+	case code >= wasm.OpcodeVecI8x16ExtractLaneS && code <= wasm.OpcodeVecF64x2ReplaceLane:
+		return immLane
+	case code >= wasm.OpcodeVecV128Load8Lane && code <= wasm.OpcodeVecV128Store64Lane:
+		return immMemargLane
+	case code == wasm.OpcodeVecV128Load32zero || code == wasm.OpcodeVecV128Load64zero:
+		return immMemarg
+	}
+	return immNone
+}
+
+// naturalAlignment returns the log2 of the natural alignment of a memory
+// access, i.e. of its size in bytes, derived from its name, e.g. 1 for
+// "i32.load16_s", 3 for "v128.load8x8_s" or 2 for "i32.atomic.rmw.add".
+func naturalAlignment(name string) uint32 {
+	for _, access := range []string{"load", "store", "rmw", "wait"} {
+		i := strings.Index(name, access)
+		if i < 0 {
+			continue
+		}
+		rest := name[i+len(access):]
+		end := 0
+		for end < len(rest) && rest[end] >= '0' && rest[end] <= '9' {
+			end++
+		}
+		if end == 0 {
+			break // the size of the type, e.g. "i64.load"
+		}
+		bits, _ := strconv.Atoi(rest[:end])
+		if rest = rest[end:]; strings.HasPrefix(rest, "x") { // e.g. "load8x8_s"
+			bits *= int(rest[1] - '0')
+		}
+		return log2(uint32(bits / 8))
+	}
+	switch {
+	case strings.HasPrefix(name, "i64."), strings.HasPrefix(name, "f64."):
+		return 3
+	case strings.HasPrefix(name, "v128."):
+		return 4
+	}
+	return 2
+}
+
+func log2(n uint32) (ret uint32) {
+	for n > 1 {
+		n >>= 1
+		ret++
+	}
+	return
+}
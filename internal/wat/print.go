@@ -0,0 +1,647 @@
+package wat
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+// features are the features Disassemble decodes, which are all those wazero
+// implements.
+const features = api.CoreFeaturesV2 | experimental.CoreFeaturesMemory64 | experimental.CoreFeaturesThreads |
+	experimental.CoreFeaturesTailCall | experimental.CoreFeaturesExceptionHandling |
+	experimental.CoreFeaturesFunctionReferences | experimental.CoreFeaturesExtendedConst
+
+// Disassemble returns the text format of the module `source` in the binary
+// format. Instructions are one per line, unless `folded`, in which case they
+// are folded expressions, e.g. `(i32.add (local.get 0) (i32.const 1))`.
+//
+// Indexes are printed as the identifiers in the name section, if any, so
+// the result compiles to an equivalent module.
+func Disassemble(source []byte, folded bool) ([]byte, error) {
+	m, err := binary.DecodeModule(source, features, wasm.MemoryLimitPages, false, false, false)
+	if err != nil {
+		return nil, err
+	}
+	return Print(m, folded)
+}
+
+// Print returns the text format of the decoded module `m`. See Disassemble.
+func Print(m *wasm.Module, folded bool) ([]byte, error) {
+	p := newPrinter(m, folded)
+	if err := p.module(); err != nil {
+		return nil, err
+	}
+	return p.out.Bytes(), nil
+}
+
+// printer writes the text format of a module.
+type printer struct {
+	m      *wasm.Module
+	folded bool
+	out    bytes.Buffer
+
+	// The identifiers of the index spaces, without the leading '$', or empty
+	// if unnamed.
+	types, funcs, tables, memories, globals, tags, elems, datas []string
+	// locals and labels are the identifiers of the locals and of the labels,
+	// in order of their blocks, by function index.
+	locals, labels map[uint32][]string
+}
+
+func newPrinter(m *wasm.Module, folded bool) *printer {
+	p := &printer{m: m, folded: folded}
+	names := m.NameSection
+	if names == nil {
+		names = &wasm.NameSection{}
+	}
+	memories := m.ImportMemoryCount
+	if m.MemorySection != nil {
+		memories++
+	}
+	p.types = identifiers(len(m.TypeSection), names.TypeNames)
+	p.funcs = identifiers(int(m.ImportFunctionCount)+len(m.FunctionSection), names.FunctionNames)
+	p.tables = identifiers(int(m.ImportTableCount)+len(m.TableSection), names.TableNames)
+	p.memories = identifiers(int(memories), names.MemoryNames)
+	p.globals = identifiers(int(m.ImportGlobalCount)+len(m.GlobalSection), names.GlobalNames)
+	p.tags = identifiers(int(m.ImportTagCount)+len(m.TagSection), names.TagNames)
+	p.elems = identifiers(len(m.ElementSection), names.ElementNames)
+	p.datas = identifiers(len(m.DataSection), names.DataNames)
+	p.locals = indirectIdentifiers(names.LocalNames)
+	p.labels = indirectIdentifiers(names.LabelNames)
+	return p
+}
+
+// identifiers returns the identifiers of `count` indexes from `names`, made
+// unique by a suffix, e.g. "f.1", as names needn't be unique.
+func identifiers(count int, names wasm.NameMap) []string {
+	ids := make([]string, count)
+	used := map[string]bool{}
+	for _, n := range names {
+		if int(n.Index) >= count || n.Name == "" || ids[n.Index] != "" {
+			continue
+		}
+		id := n.Name
+		for i := 1; used[id]; i++ {
+			id = fmt.Sprintf("%s.%d", n.Name, i)
+		}
+		used[id] = true
+		ids[n.Index] = id
+	}
+	return ids
+}
+
+func indirectIdentifiers(names wasm.IndirectNameMap) map[uint32][]string {
+	ret := map[uint32][]string{}
+	for _, n := range names {
+		count := 0
+		for _, nn := range n.NameMap {
+			if int(nn.Index) >= count {
+				count = int(nn.Index) + 1
+			}
+		}
+		ret[n.Index] = identifiers(count, n.NameMap)
+	}
+	return ret
+}
+
+// funcTypeIndex returns the type index of the function `idx`.
+func (p *printer) funcTypeIndex(idx uint32) uint32 {
+	if idx < p.m.ImportFunctionCount {
+		for i := range p.m.ImportSection {
+			if imp := &p.m.ImportSection[i]; imp.Type == wasm.ExternTypeFunc && imp.IndexPerType == idx {
+				return imp.DescFunc
+			}
+		}
+	} else if i := idx - p.m.ImportFunctionCount; int(i) < len(p.m.FunctionSection) {
+		return p.m.FunctionSection[i]
+	}
+	return math.MaxUint32
+}
+
+// tagTypeIndex returns the type index of the tag `idx`.
+func (p *printer) tagTypeIndex(idx uint32) uint32 {
+	if idx < p.m.ImportTagCount {
+		for i := range p.m.ImportSection {
+			if imp := &p.m.ImportSection[i]; imp.Type == wasm.ExternTypeTag && imp.IndexPerType == idx {
+				return imp.DescTag
+			}
+		}
+	} else if i := idx - p.m.ImportTagCount; int(i) < len(p.m.TagSection) {
+		return p.m.TagSection[i]
+	}
+	return math.MaxUint32
+}
+
+// funcType returns the type `idx`, or an empty one if there is none.
+func (p *printer) funcType(idx uint32) *wasm.FunctionType {
+	if int(idx) < len(p.m.TypeSection) {
+		return &p.m.TypeSection[idx]
+	}
+	return &wasm.FunctionType{}
+}
+
+// index returns the identifier of `idx` in `ids`, or its number if unnamed.
+func index(ids []string, idx uint32) string {
+	if int(idx) < len(ids) && ids[idx] != "" {
+		return "$" + identifier(ids[idx])
+	}
+	return strconv.FormatUint(uint64(idx), 10)
+}
+
+// definition returns the identifier of `idx` in `ids` following a space, or
+// its number in a comment if unnamed, e.g. " (;1;)".
+func definition(ids []string, idx uint32) string {
+	if int(idx) < len(ids) && ids[idx] != "" {
+		return " $" + identifier(ids[idx])
+	}
+	return fmt.Sprintf(" (;%d;)", idx)
+}
+
+// identifier returns `name` as an identifier, which is quoted unless all its
+// characters are allowed, e.g. `"a b"`.
+//
+// See https://webassembly.github.io/spec/core/text/values.html#text-id
+func identifier(name string) string {
+	for i := 0; i < len(name); i++ {
+		if !isIDChar(name[i]) {
+			return quote([]byte(name))
+		}
+	}
+	return name
+}
+
+// quote returns `s` as a string of the text format, escaping any bytes other
+// than printable ASCII.
+func quote(s []byte) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range s {
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c >= 0x20 && c < 0x7f:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "\\%02x", c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func (p *printer) module() error {
+	m := p.m
+	p.out.WriteString("(module")
+	if m.NameSection != nil && m.NameSection.ModuleName != "" {
+		p.out.WriteString(" $" + identifier(m.NameSection.ModuleName))
+	}
+	for i := range m.TypeSection {
+		t := &m.TypeSection[i]
+		p.field("(type%s (func%s))", definition(p.types, uint32(i)), funcTypeText(t, nil))
+	}
+	for i := range m.ImportSection {
+		p.importField(&m.ImportSection[i])
+	}
+	for i, t := range m.FunctionSection {
+		idx := m.ImportFunctionCount + uint32(i)
+		if int(i) >= len(m.CodeSection) {
+			return fmt.Errorf("function[%d] has no code", idx)
+		}
+		if err := p.funcField(idx, t, &m.CodeSection[i]); err != nil {
+			return fmt.Errorf("function[%d]: %w", idx, err)
+		}
+	}
+	for i := range m.TableSection {
+		idx := m.ImportTableCount + uint32(i)
+		p.field("(table%s %s)", definition(p.tables, idx), tableTypeText(&m.TableSection[i]))
+	}
+	if m.MemorySection != nil {
+		p.field("(memory%s %s)", definition(p.memories, m.ImportMemoryCount), memoryTypeText(m.MemorySection))
+	}
+	for i, t := range m.TagSection {
+		idx := m.ImportTagCount + uint32(i)
+		p.field("(tag%s %s)", definition(p.tags, idx), p.typeUse(t, nil))
+	}
+	for i := range m.GlobalSection {
+		g := &m.GlobalSection[i]
+		idx := m.ImportGlobalCount + uint32(i)
+		init, err := p.constExpr(&g.Init)
+		if err != nil {
+			return fmt.Errorf("global[%d]: %w", idx, err)
+		}
+		p.field("(global%s %s %s)", definition(p.globals, idx), globalTypeText(g.Type), init)
+	}
+	for i := range m.ExportSection {
+		e := &m.ExportSection[i]
+		p.field("(export %s (%s %s))", quote([]byte(e.Name)), externTypeKeyword(e.Type), p.externIndex(e.Type, e.Index))
+	}
+	if m.StartSection != nil {
+		p.field("(start %s)", index(p.funcs, *m.StartSection))
+	}
+	for i := range m.ElementSection {
+		if err := p.elemField(uint32(i), &m.ElementSection[i]); err != nil {
+			return fmt.Errorf("element[%d]: %w", i, err)
+		}
+	}
+	for i := range m.DataSection {
+		d := &m.DataSection[i]
+		mode := ""
+		if !d.Passive {
+			offset, err := p.offset(&d.OffsetExpression)
+			if err != nil {
+				return fmt.Errorf("data[%d]: %w", i, err)
+			}
+			mode = " " + offset
+		}
+		p.field("(data%s%s %s)", definition(p.datas, uint32(i)), mode, quote(d.Init))
+	}
+	p.out.WriteString(")\n")
+	return nil
+}
+
+// field writes a field of the module on a new line.
+func (p *printer) field(format string, args ...interface{}) {
+	p.out.WriteString("\n  ")
+	fmt.Fprintf(&p.out, format, args...)
+}
+
+func (p *printer) importField(imp *wasm.Import) {
+	var desc string
+	switch imp.Type {
+	case wasm.ExternTypeFunc:
+		desc = "func" + definition(p.funcs, imp.IndexPerType) + " " + p.typeUse(imp.DescFunc, nil)
+	case wasm.ExternTypeTable:
+		desc = "table" + definition(p.tables, imp.IndexPerType) + " " + tableTypeText(&imp.DescTable)
+	case wasm.ExternTypeMemory:
+		desc = "memory" + definition(p.memories, imp.IndexPerType) + " " + memoryTypeText(imp.DescMem)
+	case wasm.ExternTypeGlobal:
+		desc = "global" + definition(p.globals, imp.IndexPerType) + " " + globalTypeText(imp.DescGlobal)
+	case wasm.ExternTypeTag:
+		desc = "tag" + definition(p.tags, imp.IndexPerType) + " " + p.typeUse(imp.DescTag, nil)
+	}
+	p.field("(import %s %s (%s))", quote([]byte(imp.Module)), quote([]byte(imp.Name)), strings.TrimSuffix(desc, " "))
+}
+
+// typeUse returns the type use of the type `idx`, e.g.
+// "(type 0) (param i32) (result i32)", with the identifiers of its params if
+// `locals` are given.
+func (p *printer) typeUse(idx uint32, locals []string) string {
+	ret := "(type " + index(p.types, idx) + ")"
+	if int(idx) < len(p.m.TypeSection) {
+		ret += funcTypeText(&p.m.TypeSection[idx], locals)
+	}
+	return ret
+}
+
+// funcTypeText returns the params and results of `t`, each preceded by a
+// space, grouping unnamed params, e.g. " (param $x i32) (param i32 i32)".
+func funcTypeText(t *wasm.FunctionType, locals []string) string {
+	var b strings.Builder
+	group := ""
+	for i, vt := range t.Params {
+		if i < len(locals) && locals[i] != "" {
+			if group != "" {
+				b.WriteString(" (param" + group + ")")
+				group = ""
+			}
+			b.WriteString(" (param $" + identifier(locals[i]) + " " + wasm.ValueTypeName(vt) + ")")
+		} else {
+			group += " " + wasm.ValueTypeName(vt)
+		}
+	}
+	if group != "" {
+		b.WriteString(" (param" + group + ")")
+	}
+	if len(t.Results) > 0 {
+		b.WriteString(" (result" + valueTypesText(t.Results) + ")")
+	}
+	return b.String()
+}
+
+// valueTypesText returns the names of `types`, each preceded by a space.
+func valueTypesText(types []wasm.ValueType) string {
+	var b strings.Builder
+	for _, vt := range types {
+		b.WriteString(" " + wasm.ValueTypeName(vt))
+	}
+	return b.String()
+}
+
+func tableTypeText(t *wasm.Table) string {
+	ret := strconv.FormatUint(uint64(t.Min), 10)
+	if t.Max != nil {
+		ret += " " + strconv.FormatUint(uint64(*t.Max), 10)
+	}
+	return ret + " " + wasm.RefTypeName(t.Type)
+}
+
+func memoryTypeText(m *wasm.Memory) string {
+	ret := ""
+	if m.Is64 {
+		ret = "i64 "
+	}
+	ret += strconv.FormatUint(uint64(m.Min), 10)
+	if m.IsMaxEncoded {
+		ret += " " + strconv.FormatUint(uint64(m.Max), 10)
+	}
+	if m.IsShared {
+		ret += " shared"
+	}
+	return ret
+}
+
+func globalTypeText(t wasm.GlobalType) string {
+	if t.Mutable {
+		return "(mut " + wasm.ValueTypeName(t.ValType) + ")"
+	}
+	return wasm.ValueTypeName(t.ValType)
+}
+
+func externTypeKeyword(t wasm.ExternType) string {
+	if t == wasm.ExternTypeTag {
+		return "tag"
+	}
+	return wasm.ExternTypeName(t)
+}
+
+// externIndex returns the index `idx` of the extern type `t`.
+func (p *printer) externIndex(t wasm.ExternType, idx uint32) string {
+	switch t {
+	case wasm.ExternTypeFunc:
+		return index(p.funcs, idx)
+	case wasm.ExternTypeTable:
+		return index(p.tables, idx)
+	case wasm.ExternTypeMemory:
+		return index(p.memories, idx)
+	case wasm.ExternTypeGlobal:
+		return index(p.globals, idx)
+	default:
+		return index(p.tags, idx)
+	}
+}
+
+func (p *printer) funcField(idx, typeIdx uint32, code *wasm.Code) error {
+	locals := p.locals[idx]
+	p.field("(func%s %s", definition(p.funcs, idx), p.typeUse(typeIdx, locals))
+	t := p.funcType(typeIdx)
+	numParams := len(t.Params)
+	if len(code.LocalTypes) > 0 {
+		var b strings.Builder
+		group := ""
+		for i, vt := range code.LocalTypes {
+			if l := numParams + i; l < len(locals) && locals[l] != "" {
+				if group != "" {
+					b.WriteString(" (local" + group + ")")
+					group = ""
+				}
+				b.WriteString(" (local $" + identifier(locals[l]) + " " + wasm.ValueTypeName(vt) + ")")
+			} else {
+				group += " " + wasm.ValueTypeName(vt)
+			}
+		}
+		if group != "" {
+			b.WriteString(" (local" + group + ")")
+		}
+		p.out.WriteString("\n    " + b.String()[1:])
+	}
+
+	d := &disassembler{p: p, body: code.Body, locals: locals, labelIDs: p.labels[idx]}
+	d.labels = []label{{arity: len(t.Results)}}
+	d.results = len(t.Results)
+	instrs, err := d.instrs()
+	if err != nil {
+		return err
+	}
+	p.writeInstrs(instrs, 4)
+	p.out.WriteString(")")
+	return nil
+}
+
+func (p *printer) elemField(idx uint32, e *wasm.ElementSegment) error {
+	var b strings.Builder
+	b.WriteString("(elem" + definition(p.elems, idx))
+	switch e.Mode {
+	case wasm.ElementModeActive:
+		if e.TableIndex != 0 {
+			b.WriteString(" (table " + index(p.tables, e.TableIndex) + ")")
+		}
+		offset, err := p.offset(&e.OffsetExpr)
+		if err != nil {
+			return err
+		}
+		b.WriteString(" " + offset)
+	case wasm.ElementModeDeclarative:
+		b.WriteString(" declare")
+	}
+	funcs := e.Type == wasm.RefTypeFuncref
+	for _, init := range e.Init {
+		if init&(wasm.ElementInitNullReference|wasm.ElementInitImportedGlobalFunctionReference) != 0 {
+			funcs = false
+		}
+	}
+	if funcs {
+		b.WriteString(" func")
+		for _, init := range e.Init {
+			b.WriteString(" " + index(p.funcs, init))
+		}
+	} else {
+		b.WriteString(" " + wasm.RefTypeName(e.Type))
+		for _, init := range e.Init {
+			switch {
+			case init == wasm.ElementInitNullReference:
+				b.WriteString(" (ref.null " + strings.TrimSuffix(wasm.RefTypeName(e.Type), "ref") + ")")
+			case init&wasm.ElementInitImportedGlobalFunctionReference != 0:
+				g := init &^ wasm.ElementInitImportedGlobalFunctionReference
+				b.WriteString(" (global.get " + index(p.globals, g) + ")")
+			default:
+				b.WriteString(" (ref.func " + index(p.funcs, init) + ")")
+			}
+		}
+	}
+	b.WriteString(")")
+	p.field("%s", b.String())
+	return nil
+}
+
+// offset returns the offset of an active segment, which is abbreviated
+// unless it is more than one folded instruction.
+func (p *printer) offset(expr *wasm.ConstantExpression) (string, error) {
+	exprs, err := p.constExprs(expr)
+	if err != nil {
+		return "", err
+	} else if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return "(offset " + strings.Join(exprs, " ") + ")", nil
+}
+
+// constExpr returns the folded instructions of `expr`.
+func (p *printer) constExpr(expr *wasm.ConstantExpression) (string, error) {
+	exprs, err := p.constExprs(expr)
+	return strings.Join(exprs, " "), err
+}
+
+func (p *printer) constExprs(expr *wasm.ConstantExpression) ([]string, error) {
+	var body []byte
+	switch {
+	case expr.Opcode == wasm.OpcodeVecV128Const:
+		body = append([]byte{wasm.OpcodeVecPrefix, wasm.OpcodeVecV128Const}, expr.Data...)
+	case wasm.IsExtendedConstOpcode(expr.Opcode):
+		body = append([]byte{}, expr.Data...)
+	default:
+		body = append([]byte{expr.Opcode}, expr.Data...)
+	}
+	d := &disassembler{p: p, body: append(body, wasm.OpcodeEnd)}
+	d.labels = []label{{arity: 1}}
+	instrs, err := d.instrs()
+	if err != nil {
+		return nil, err
+	}
+	i := 0
+	var ret []string
+	for _, e := range fold(instrs, &i) {
+		var b bytes.Buffer
+		writeExpr(&b, e, -1)
+		ret = append(ret, b.String())
+	}
+	return ret, nil
+}
+
+// writeInstrs writes `instrs` each on a new line, indented by `indent`
+// spaces, folded if configured.
+func (p *printer) writeInstrs(instrs []instr, indent int) {
+	if p.folded {
+		i := 0
+		for _, e := range fold(instrs, &i) {
+			p.out.WriteString("\n" + strings.Repeat(" ", indent))
+			writeExpr(&p.out, e, indent)
+		}
+		return
+	}
+	for _, in := range instrs {
+		switch in.kind {
+		case kindElse, kindCatch, kindCatchAll:
+			indent -= 2
+		case kindEnd, kindDelegate:
+			indent -= 2
+		}
+		p.out.WriteString("\n" + strings.Repeat(" ", indent) + in.text)
+		switch in.kind {
+		case kindBlock, kindIf, kindTry, kindElse, kindCatch, kindCatchAll:
+			indent += 2
+		}
+	}
+}
+
+// expr is a folded instruction.
+type expr struct {
+	text string
+	// operands are the folded instructions of the operands.
+	operands []*expr
+	// clauses are the blocks of the instruction, e.g. "then" and "else" of
+	// "if", or a single one without a keyword of "block".
+	clauses []clause
+	results int
+}
+
+type clause struct {
+	keyword string
+	body    []*expr
+}
+
+// fold folds the instructions from `*i` until the end of their block. As
+// the operands of an instruction are those preceding it, this is only a
+// matter of presentation: the instructions are in the same order either way.
+func fold(instrs []instr, i *int) []*expr {
+	var stack []*expr
+	for *i < len(instrs) {
+		in := &instrs[*i]
+		switch in.kind {
+		case kindEnd, kindElse, kindCatch, kindCatchAll, kindDelegate:
+			return stack
+		}
+		*i++
+		e := &expr{text: in.text, results: in.pushes}
+		if in.pops > 0 && in.pops <= len(stack) {
+			operands := stack[len(stack)-in.pops:]
+			foldable := true
+			for _, o := range operands {
+				foldable = foldable && o.results == 1
+			}
+			if foldable {
+				e.operands = append(e.operands, operands...)
+				stack = stack[:len(stack)-in.pops]
+			}
+		}
+		switch in.kind {
+		case kindBlock:
+			e.clauses = []clause{{body: fold(instrs, i)}}
+			*i++ // end
+		case kindIf:
+			e.clauses = []clause{{keyword: "then", body: fold(instrs, i)}}
+			if *i < len(instrs) && instrs[*i].kind == kindElse {
+				*i++
+				e.clauses = append(e.clauses, clause{keyword: "else", body: fold(instrs, i)})
+			}
+			*i++ // end
+		case kindTry:
+			e.clauses = []clause{{keyword: "do", body: fold(instrs, i)}}
+			for *i < len(instrs) {
+				next := &instrs[*i]
+				*i++
+				if next.kind == kindEnd {
+					break
+				} else if next.kind == kindDelegate {
+					e.clauses = append(e.clauses, clause{keyword: next.text})
+					break
+				}
+				e.clauses = append(e.clauses, clause{keyword: next.text, body: fold(instrs, i)})
+			}
+		}
+		stack = append(stack, e)
+	}
+	return stack
+}
+
+// writeExpr writes the folded instruction `e`, whose nested instructions are
+// on new lines indented by `indent` spaces and more, or on a single line if
+// negative.
+func writeExpr(b *bytes.Buffer, e *expr, indent int) {
+	inner := indent + 2
+	if indent < 0 {
+		inner = -1
+	}
+	separate := func() {
+		if inner < 0 {
+			b.WriteByte(' ')
+		} else {
+			b.WriteString("\n" + strings.Repeat(" ", inner))
+		}
+	}
+	b.WriteString("(" + e.text)
+	for _, o := range e.operands {
+		separate()
+		writeExpr(b, o, inner)
+	}
+	for _, c := range e.clauses {
+		if c.keyword == "" {
+			for _, in := range c.body {
+				separate()
+				writeExpr(b, in, inner)
+			}
+			continue
+		}
+		separate()
+		writeExpr(b, &expr{text: c.keyword, clauses: []clause{{body: c.body}}}, inner)
+	}
+	b.WriteString(")")
+}
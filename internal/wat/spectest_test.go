@@ -0,0 +1,158 @@
+package wat
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+// allFeatures enables all features the spec tests may use.
+const allFeatures = api.CoreFeaturesV2 | experimental.CoreFeaturesMemory64 | experimental.CoreFeaturesThreads |
+	experimental.CoreFeaturesTailCall | experimental.CoreFeaturesExceptionHandling |
+	experimental.CoreFeaturesFunctionReferences | experimental.CoreFeaturesExtendedConst
+
+// spectestSkips are the reasons modules of the spec tests aren't compiled,
+// by the script and line.
+var spectestSkips = map[string]string{
+	// The identifier of a segment referred to its memory or table in the text
+	// format of WebAssembly 1.0, but names the segment since 2.0.
+	"v1/data.wast:5": "the identifier of a data segment names its memory",
+	"v1/elem.wast:4": "the identifier of an element segment names its table",
+}
+
+type spectestCommand struct {
+	Type       string `json:"type"`
+	Line       int    `json:"line"`
+	Filename   string `json:"filename"`
+	ModuleType string `json:"module_type"`
+}
+
+// TestCompile_spectest compiles the modules of the spec tests, comparing them
+// to the binaries the reference tools compiled, and checks that the malformed
+// modules in the text format err.
+func TestCompile_spectest(t *testing.T) {
+	for _, version := range []string{"v1", "v2"} {
+		dir := path.Join("..", "integration_test", "spectest", version, "testdata")
+		files, err := filepath.Glob(path.Join(dir, "*.json"))
+		require.NoError(t, err)
+		for _, file := range files {
+			wastName := strings.TrimSuffix(file, ".json") + ".wast"
+			t.Run(version+"/"+path.Base(wastName), func(t *testing.T) {
+				testSpectestFile(t, dir, file, wastName)
+			})
+		}
+	}
+}
+
+func testSpectestFile(t *testing.T, dir, jsonName, wastName string) {
+	raw, err := os.ReadFile(jsonName)
+	require.NoError(t, err)
+	var script struct {
+		Commands []spectestCommand `json:"commands"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &script))
+
+	wast, err := os.ReadFile(wastName)
+	require.NoError(t, err)
+	nodes, err := parseNodes(string(wast))
+	require.NoError(t, err)
+	byLine := map[int]*node{}
+	for _, n := range nodes {
+		byLine[n.tok.line] = n
+		for _, child := range n.list {
+			// The line is of the module of an assertion, or of the keyword
+			// "module" when on a later line than its parenthesis.
+			if child.head() == "module" {
+				if _, ok := byLine[child.tok.line]; !ok {
+					byLine[child.tok.line] = child
+				}
+			} else if !child.isList && child.tok.text == "module" {
+				if _, ok := byLine[child.tok.line]; !ok {
+					byLine[child.tok.line] = n
+				}
+			}
+		}
+	}
+
+	for _, c := range script.Commands {
+		if c.Filename == "" {
+			continue
+		}
+		bin, err := os.ReadFile(path.Join(dir, c.Filename))
+		require.NoError(t, err)
+		if strings.HasSuffix(c.Filename, ".wat") {
+			_, err := Compile(bin)
+			if err == nil {
+				t.Errorf("%s:%d: expected %s to be malformed", wastName, c.Line, c.Filename)
+			}
+			continue
+		}
+
+		if skip := spectestSkips[fmt.Sprintf("%s/%s:%d", path.Base(path.Dir(dir)), path.Base(wastName), c.Line)]; skip != "" {
+			t.Logf("skipping line %d: %s", c.Line, skip)
+			continue
+		}
+
+		n := byLine[c.Line]
+		require.NotNil(t, n, "%s:%d", wastName, c.Line)
+		if n.head() != "module" {
+			for _, child := range n.list {
+				if child.head() == "module" {
+					n = child
+					break
+				}
+			}
+		}
+		var actual []byte
+		if n.head() == "module" {
+			actual, err = compileModuleNode(n)
+		} else { // a script of only the fields of a module
+			actual, err = compileModule("", nodes)
+		}
+		if err != nil {
+			t.Errorf("%s:%d: %v", wastName, c.Line, err)
+			continue
+		}
+		requireEquivalent(t, bin, actual, wastName, c.Line)
+	}
+}
+
+// requireEquivalent requires that the binaries decode to the same module,
+// regardless of custom sections, or fail the same way.
+func requireEquivalent(t *testing.T, expected, actual []byte, wastName string, line int) {
+	e, expectedErr := decode(expected)
+	a, actualErr := decode(actual)
+	if expectedErr != nil || actualErr != nil {
+		if expectedErr == nil || actualErr == nil || expectedErr.Error() != actualErr.Error() {
+			t.Errorf("%s:%d: expected error %v, but was %v", wastName, line, expectedErr, actualErr)
+		}
+		return
+	}
+	if !reflect.DeepEqual(e, a) {
+		t.Errorf("%s:%d: modules differ\nexpected: %+v\nactual:   %+v", wastName, line, e, a)
+	}
+}
+
+func decode(bin []byte) (*wasm.Module, error) {
+	m, err := binary.DecodeModule(bin, allFeatures, wasm.MemoryLimitPages, false, false, false)
+	if err != nil {
+		return nil, err
+	}
+	m.NameSection = nil
+	m.CustomSections = nil
+	m.DataCountSection = nil
+	// Equivalent encodings can differ in size, e.g. of element segments.
+	m.SectionOffsets = [len(m.SectionOffsets)]uint64{}
+	return m, nil
+}
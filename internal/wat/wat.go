@@ -0,0 +1,80 @@
+// Package wat compiles the WebAssembly text format to the binary format.
+//
+// This supports the text format of WebAssembly 2.0, including the proposals
+// wazero implements, e.g. threads, tail calls and exception handling. Besides
+// identifiers and abbreviations, the module can be "binary" or "quote", like
+// in the scripts of the spec tests.
+//
+// See https://webassembly.github.io/spec/core/text/index.html
+package wat
+
+import (
+	"bytes"
+	"errors"
+)
+
+// IsText returns true if `source` is likely in the text format, i.e. it
+// begins with a parenthesis after any whitespace and comments. The binary
+// format begins with the magic number "\0asm" instead.
+func IsText(source []byte) bool {
+	l := newLexer(string(source))
+	if err := l.skip(); err != nil {
+		return true // an unterminated comment
+	}
+	return l.pos < len(l.src) && l.src[l.pos] == '('
+}
+
+// Compile returns the binary format of the module `source` in the text
+// format, which is either a module, e.g. `(module (func))`, or only its
+// fields, e.g. `(func)`. Errors are prefixed by the line and column, e.g.
+// "3:5: unknown operator \"i32.ad\"".
+//
+// The module is not validated, except as the text format requires, e.g. an
+// identifier must be defined.
+func Compile(source []byte) ([]byte, error) {
+	nodes, err := parseNodes(string(source))
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("empty module")
+	}
+	if nodes[0].head() != "module" {
+		return compileModule("", nodes)
+	} else if len(nodes) > 1 {
+		return nil, nodes[1].errorf("unexpected token after the module")
+	}
+	return compileModuleNode(nodes[0])
+}
+
+// compileModuleNode compiles `(module $id? field*)`.
+func compileModuleNode(n *node) ([]byte, error) {
+	c := newCursor(n, 1)
+	name := c.id()
+	switch {
+	case c.peekKeyword("binary"):
+		c.next()
+		return concatStrings(c)
+	case c.peekKeyword("quote"):
+		c.next()
+		text, err := concatStrings(c)
+		if err != nil {
+			return nil, err
+		}
+		return Compile(text)
+	}
+	return compileModule(name, c.list[c.i:])
+}
+
+// concatStrings returns the concatenation of the strings at `c`.
+func concatStrings(c *cursor) ([]byte, error) {
+	var buf bytes.Buffer
+	for !c.done() {
+		s, err := c.string()
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(s)
+	}
+	return buf.Bytes(), nil
+}
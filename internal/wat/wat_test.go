@@ -0,0 +1,136 @@
+package wat
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/binaryencoding"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+func TestIsText(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{name: "module", input: "(module)", expected: true},
+		{name: "leading comments", input: " ;; a\n(; b ;)\t(module)", expected: true},
+		{name: "unterminated comment", input: "(; (module)", expected: true},
+		{name: "binary", input: "\x00asm\x01\x00\x00\x00"},
+		{name: "empty"},
+		{name: "only a comment", input: ";; (module)"},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, IsText([]byte(tc.input)))
+		})
+	}
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *wasm.Module
+	}{
+		{
+			name:     "empty",
+			input:    "(module)",
+			expected: &wasm.Module{},
+		},
+		{
+			name:  "names",
+			input: `(module $math (func $add (param $x i32) (param $y i32) (result i32) (local $z i32) local.get $x local.get $y i32.add))`,
+			expected: &wasm.Module{
+				TypeSection:     []wasm.FunctionType{{Params: []wasm.ValueType{i32, i32}, Results: []wasm.ValueType{i32}}},
+				FunctionSection: []wasm.Index{0},
+				CodeSection: []wasm.Code{{
+					LocalTypes: []wasm.ValueType{i32},
+					Body: []byte{
+						wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Add, wasm.OpcodeEnd,
+					},
+				}},
+				NameSection: &wasm.NameSection{
+					ModuleName:    "math",
+					FunctionNames: wasm.NameMap{{Index: 0, Name: "add"}},
+					LocalNames: wasm.IndirectNameMap{{Index: 0, NameMap: wasm.NameMap{
+						{Index: 0, Name: "x"}, {Index: 1, Name: "y"}, {Index: 2, Name: "z"},
+					}}},
+				},
+			},
+		},
+		{
+			name: "fields without a module",
+			input: `(import "env" "f" (func $f (param i32)))
+(memory (export "memory") 1)
+(func (export "g") (call $f (i32.const 1)))`,
+			expected: &wasm.Module{
+				TypeSection: []wasm.FunctionType{{Params: []wasm.ValueType{i32}}, {}},
+				ImportSection: []wasm.Import{{
+					Type: wasm.ExternTypeFunc, Module: "env", Name: "f", DescFunc: 0,
+				}},
+				FunctionSection: []wasm.Index{1},
+				MemorySection:   &wasm.Memory{Min: 1},
+				ExportSection: []wasm.Export{
+					{Type: wasm.ExternTypeMemory, Name: "memory", Index: 0},
+					{Type: wasm.ExternTypeFunc, Name: "g", Index: 1},
+				},
+				CodeSection: []wasm.Code{{
+					Body: []byte{wasm.OpcodeI32Const, 1, wasm.OpcodeCall, 0, wasm.OpcodeEnd},
+				}},
+				NameSection: &wasm.NameSection{FunctionNames: wasm.NameMap{{Index: 0, Name: "f"}}},
+			},
+		},
+		{
+			name:  "folded if",
+			input: `(module (func (param i32) (result i32) (if (result i32) (local.get 0) (then (i32.const 1)) (else (i32.const 2)))))`,
+			expected: &wasm.Module{
+				TypeSection:     []wasm.FunctionType{{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}}},
+				FunctionSection: []wasm.Index{0},
+				CodeSection: []wasm.Code{{
+					Body: []byte{
+						wasm.OpcodeLocalGet, 0, wasm.OpcodeIf, i32,
+						wasm.OpcodeI32Const, 1, wasm.OpcodeElse, wasm.OpcodeI32Const, 2,
+						wasm.OpcodeEnd, wasm.OpcodeEnd,
+					},
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := Compile([]byte(tc.input))
+			require.NoError(t, err)
+			require.Equal(t, binaryencoding.EncodeModule(tc.expected), actual)
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []struct {
+		name, input, expectedErr string
+	}{
+		{name: "empty", input: " ;; nothing", expectedErr: "empty module"},
+		{name: "unknown operator", input: "(module\n  (func i32.ad))", expectedErr: `2:9: unknown operator "i32.ad"`},
+		{name: "unknown identifier", input: "(func call $f)", expectedErr: `1:12: unknown func $f`},
+		{name: "duplicate identifier", input: "(func $f) (func $f)", expectedErr: `1:11: duplicate func $f`},
+		{name: "import after definition", input: `(memory 1) (import "" "" (func))`, expectedErr: "1:12: import after memory"},
+		{name: "after the module", input: "(module) (module)", expectedErr: "1:10: unexpected token after the module"},
+		{name: "i32 out of range", input: "(func i32.const 0x1_0000_0000)", expectedErr: `1:17: invalid i32 "0x1_0000_0000"`},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Compile([]byte(tc.input))
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+const i32 = wasm.ValueTypeI32
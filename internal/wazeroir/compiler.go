@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/fuel"
 	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
@@ -21,6 +22,7 @@ const (
 	controlFrameKindLoop
 	controlFrameKindIfWithElse
 	controlFrameKindIfWithoutElse
+	controlFrameKindTry
 )
 
 type (
@@ -31,6 +33,10 @@ type (
 		originalStackLenWithoutParam int
 		blockType                    *wasm.FunctionType
 		kind                         controlFrameKind
+		// handler is the index in CompilationResult.ExceptionHandlers of the handler of a try or try_table, and
+		// inTryBody is true until its body ends, which is at the first catch clause of a try.
+		handler   int
+		inTryBody bool
 	}
 	controlFrames struct{ frames []controlFrame }
 )
@@ -54,7 +60,8 @@ func (c *controlFrame) asLabel() Label {
 	case controlFrameKindFunction:
 		return NewLabel(LabelKindReturn, 0)
 	case controlFrameKindIfWithElse,
-		controlFrameKindIfWithoutElse:
+		controlFrameKindIfWithoutElse,
+		controlFrameKindTry:
 		return NewLabel(LabelKindContinuation, c.frameID)
 	}
 	panic(fmt.Sprintf("unreachable: a bug in wazeroir implementation: %v", c.kind))
@@ -182,6 +189,8 @@ type Compiler struct {
 	funcs []uint32
 	// globals holds the global types for all declared globals in the module where the target function exists.
 	globals []wasm.GlobalType
+	// tags holds the type indexes for all declared tags in the module where the target function exists.
+	tags []wasm.Index
 
 	// needSourceOffset is true if this module requires DWARF based stack trace.
 	needSourceOffset bool
@@ -189,6 +198,11 @@ type Compiler struct {
 	bodyOffsetInCodeSection uint64
 
 	ensureTermination bool
+	// fuelCosts is non-nil when fuel metering is enabled.
+	fuelCosts *fuel.Costs
+	// fuelOperation is the index in result.Operations of the OperationConsumeFuel
+	// which is charged for the current Wasm instruction.
+	fuelOperation int
 	// Pre-allocated bytes.Reader to be used in various places.
 	br             *bytes.Reader
 	funcTypeToSigs funcTypeToIRSignatures
@@ -237,6 +251,10 @@ type CompilationResult struct {
 	LabelCallers map[Label]uint32
 	// UsesMemory is true if this function might use memory.
 	UsesMemory bool
+	// ExceptionHandlers holds the handlers of the try and try_table blocks of this function, in the order they begin.
+	ExceptionHandlers []ExceptionHandler
+	// UsesExceptionHandling is true if this function might throw or catch exceptions.
+	UsesExceptionHandling bool
 
 	// The following fields are per-module values, not per-function.
 
@@ -248,6 +266,9 @@ type CompilationResult struct {
 	Types []wasm.FunctionType
 	// HasMemory is true if the module from which this function is compiled has memory declaration.
 	HasMemory bool
+	// Memory64 is true if the memory of the module from which this function is compiled is 64-bit. If so, the
+	// addresses, offsets and sizes of memory operations are i64, otherwise i32.
+	Memory64 bool
 	// HasTable is true if the module from which this function is compiled has table declaration.
 	HasTable bool
 	// HasDataInstances is true if the module has data instances which might be used by memory.init or data.drop instructions.
@@ -256,9 +277,40 @@ type CompilationResult struct {
 	HasElementInstances bool
 }
 
+// ExceptionHandler handles the exceptions thrown by the operations in [Start, End) of CompilationResult.Operations,
+// which are the body of a try or try_table block. As blocks are nested, the handler of an exception thrown at some
+// operation is the last one in CompilationResult.ExceptionHandlers whose range includes it.
+type ExceptionHandler struct {
+	Start, End uint64
+	// Catches are the catch clauses, in the order they are checked.
+	Catches []Catch
+	// Outer is the index in CompilationResult.ExceptionHandlers of the handler of the exceptions which none of
+	// Catches catch, or -1 if they are thrown to the caller.
+	Outer int
+}
+
+// Catch is a catch clause of an ExceptionHandler.
+type Catch struct {
+	// Tag is the index of the tag of the exceptions caught, unless All is true.
+	Tag uint32
+	// All is true if this catches all exceptions.
+	All bool
+	// Ref is true if the exnref of the exception is pushed after its payload, as for catch_ref and catch_all_ref.
+	Ref bool
+	// RefBelowPayload is true if the exnref of the exception is pushed before its payload, as for catch and
+	// catch_all of a legacy try, so that rethrow can pick it.
+	RefBelowPayload bool
+	// Target is the label which the exception branches to, after the stack is reset to StackHeight values from the
+	// beginning of the function's parameters, and the payload and exnref are pushed.
+	Target      Label
+	StackHeight uint64
+}
+
 // NewCompiler returns the new *Compiler for the given parameters.
 // Use Compiler.Next function to get compilation result per function.
-func NewCompiler(enabledFeatures api.CoreFeatures, callFrameStackSizeInUint64 int, module *wasm.Module, ensureTermination bool) (*Compiler, error) {
+//
+// When fuelCosts is non-nil, OperationConsumeFuel is emitted at the beginning of each function and label.
+func NewCompiler(enabledFeatures api.CoreFeatures, callFrameStackSizeInUint64 int, module *wasm.Module, ensureTermination bool, fuelCosts *fuel.Costs) (*Compiler, error) {
 	functions, globals, mem, tables, err := module.AllDeclarations()
 	if err != nil {
 		return nil, err
@@ -279,6 +331,7 @@ func NewCompiler(enabledFeatures api.CoreFeatures, callFrameStackSizeInUint64 in
 			Functions:           functions,
 			Types:               types,
 			HasMemory:           hasMemory,
+			Memory64:            hasMemory && mem.Is64,
 			HasTable:            hasTable,
 			HasDataInstances:    hasDataInstances,
 			HasElementInstances: hasElementInstances,
@@ -286,8 +339,10 @@ func NewCompiler(enabledFeatures api.CoreFeatures, callFrameStackSizeInUint64 in
 		},
 		globals:           globals,
 		funcs:             functions,
+		tags:              module.AllTags(),
 		types:             types,
 		ensureTermination: ensureTermination,
+		fuelCosts:         fuelCosts,
 		br:                bytes.NewReader(nil),
 		funcTypeToSigs: funcTypeToIRSignatures{
 			indirectCalls: make([]*signature, len(types)),
@@ -309,6 +364,8 @@ func (c *Compiler) Next() (*CompilationResult, error) {
 	c.result.Operations = c.result.Operations[:0]
 	c.result.IROperationSourceOffsetsInWasmBinary = c.result.IROperationSourceOffsetsInWasmBinary[:0]
 	c.result.UsesMemory = false
+	c.result.ExceptionHandlers = c.result.ExceptionHandlers[:0]
+	c.result.UsesExceptionHandling = false
 	// Clears the existing entries in LabelCallers.
 	for frameID := uint32(0); frameID <= c.currentFrameID; frameID++ {
 		for k := LabelKind(0); k < LabelKindNum; k++ {
@@ -344,6 +401,10 @@ func (c *Compiler) compile(sig *wasm.FunctionType, body []byte, localTypes []was
 
 	c.initializeStack()
 
+	if c.fuelCosts != nil {
+		c.emitConsumeFuel()
+	}
+
 	// Emit const expressions for locals.
 	// Note that here we don't take function arguments
 	// into account, meaning that callers must push
@@ -379,6 +440,8 @@ func (c *Compiler) handleInstruction() error {
 			instName = wasm.VectorInstructionName(c.body[c.pc+1])
 		} else if op == wasm.OpcodeMiscPrefix {
 			instName = wasm.MiscInstructionName(c.body[c.pc+1])
+		} else if op == wasm.OpcodeAtomicPrefix {
+			instName = wasm.AtomicInstructionName(c.body[c.pc+1])
 		} else {
 			instName = wasm.InstructionName(op)
 		}
@@ -387,6 +450,10 @@ func (c *Compiler) handleInstruction() error {
 		)
 	}
 
+	if c.fuelCosts != nil && !c.unreachableState.on {
+		c.chargeFuel(op)
+	}
+
 	var peekValueType UnsignedType
 	if len(c.stack) > 0 {
 		peekValueType = c.stackPeek()
@@ -565,6 +632,136 @@ operatorSwitch:
 		c.emit(NewOperationBr(continuationLabel))
 		// Initiate the else block.
 		c.emit(NewOperationLabel(elseLabel))
+	case wasm.OpcodeTry, wasm.OpcodeTryTable:
+		c.br.Reset(c.body[c.pc+1:])
+		bt, num, err := wasm.DecodeBlockType(c.types, c.br, c.enabledFeatures)
+		if err != nil {
+			return fmt.Errorf("reading block type for %s instruction: %w", wasm.InstructionName(op), err)
+		}
+		c.pc += num
+
+		var catches []Catch
+		if op == wasm.OpcodeTryTable {
+			if catches, err = c.readTryTableCatches(); err != nil {
+				return err
+			}
+		}
+
+		if c.unreachableState.on {
+			// If it is currently in unreachable,
+			// just remove the entire block.
+			c.unreachableState.depth++
+			break operatorSwitch
+		}
+
+		// Create a new frame -- entering the body of try or try_table, whose exceptions are handled by the new
+		// handler, unless it throws them to the handler of an enclosing body.
+		c.result.UsesExceptionHandling = true
+		frame := controlFrame{
+			frameID:                      c.nextFrameID(),
+			originalStackLenWithoutParam: len(c.stack) - len(bt.Params),
+			kind:                         controlFrameKindBlockWithoutContinuationLabel,
+			blockType:                    bt,
+			handler:                      len(c.result.ExceptionHandlers),
+			inTryBody:                    true,
+		}
+		if op == wasm.OpcodeTry {
+			// The body and catch clauses of try branch to the continuation.
+			frame.kind = controlFrameKindTry
+		}
+		c.result.ExceptionHandlers = append(c.result.ExceptionHandlers, ExceptionHandler{
+			Start:   uint64(len(c.result.Operations)),
+			Catches: catches,
+			Outer:   c.enclosingHandler(0),
+		})
+		c.controlFrames.push(frame)
+	case wasm.OpcodeCatch, wasm.OpcodeCatchAll:
+		var tag uint32
+		if op == wasm.OpcodeCatch {
+			v, n, err := leb128.LoadUint32(c.body[c.pc+1:])
+			if err != nil {
+				return fmt.Errorf("read the tag for catch: %w", err)
+			}
+			c.pc += n
+			tag = v
+		}
+
+		if c.unreachableState.on && c.unreachableState.depth > 0 {
+			// If it is currently in unreachable, and the nested try,
+			// just remove the entire catch clause.
+			break operatorSwitch
+		}
+
+		frame := c.controlFrames.top()
+		c.endTryBody(frame)
+		if !c.unreachableState.on {
+			// Exit the body or the previous catch clause, like else.
+			continuationLabel := NewLabel(LabelKindContinuation, frame.frameID)
+			c.result.LabelCallers[continuationLabel]++
+			c.emit(NewOperationDrop(c.getFrameDropRange(frame, true)))
+			c.emit(NewOperationBr(continuationLabel))
+		}
+		c.resetUnreachable()
+
+		// The catch clause starts with the exnref of the exception, which only rethrow uses, below its payload.
+		c.stack = c.stack[:frame.originalStackLenWithoutParam]
+		catch := Catch{
+			Tag:             tag,
+			All:             op == wasm.OpcodeCatchAll,
+			RefBelowPayload: true,
+			Target:          NewLabel(LabelKindHeader, c.nextFrameID()),
+			StackHeight:     uint64(c.stackLenInUint64(len(c.stack))),
+		}
+		c.result.LabelCallers[catch.Target]++
+		handler := &c.result.ExceptionHandlers[frame.handler]
+		handler.Catches = append(handler.Catches, catch)
+		c.stackPush(UnsignedTypeI64)
+		if !catch.All {
+			for _, t := range c.types[c.tags[tag]].Params {
+				c.stackPush(wasmValueTypeToUnsignedType(t))
+			}
+		}
+		c.emit(NewOperationLabel(catch.Target))
+	case wasm.OpcodeThrow:
+		c.emit(NewOperationThrow(index))
+		c.result.UsesExceptionHandling = true
+		// Throw operation is stack-polymorphic, and mark the state as unreachable.
+		c.markUnreachable()
+	case wasm.OpcodeThrowRef:
+		c.emit(NewOperationThrowRef())
+		c.result.UsesExceptionHandling = true
+		c.markUnreachable()
+	case wasm.OpcodeRethrow:
+		l, n, err := leb128.LoadUint32(c.body[c.pc+1:])
+		if err != nil {
+			return fmt.Errorf("read the label for rethrow: %w", err)
+		}
+		c.pc += n
+
+		if c.unreachableState.on {
+			break operatorSwitch
+		}
+
+		// Throw the exnref at the bottom of the catch clause again.
+		target := c.controlFrames.get(int(l))
+		depth := c.stackLenInUint64(len(c.stack)) - 1 - c.stackLenInUint64(target.originalStackLenWithoutParam)
+		c.emit(NewOperationPick(depth, false))
+		c.emit(NewOperationThrowRef())
+		c.markUnreachable()
+	case wasm.OpcodeDelegate:
+		l, n, err := leb128.LoadUint32(c.body[c.pc+1:])
+		if err != nil {
+			return fmt.Errorf("read the label for delegate: %w", err)
+		}
+		c.pc += n
+
+		if !c.unreachableState.on || c.unreachableState.depth == 0 {
+			// The try catches nothing, but throws exceptions to the handler of the label, which is counted from
+			// outside the try.
+			c.result.ExceptionHandlers[c.controlFrames.top().handler].Outer = c.enclosingHandler(int(l) + 1)
+		}
+		// Otherwise, delegate ends the try like end.
+		fallthrough
 	case wasm.OpcodeEnd:
 		if c.unreachableState.on && c.unreachableState.depth > 0 {
 			c.unreachableState.depth--
@@ -576,6 +773,7 @@ operatorSwitch:
 			if c.controlFrames.empty() {
 				return nil
 			}
+			c.endTryBody(frame)
 
 			c.stack = c.stack[:frame.originalStackLenWithoutParam]
 			for _, t := range frame.blockType.Results {
@@ -600,6 +798,7 @@ operatorSwitch:
 		}
 
 		frame := c.controlFrames.pop()
+		c.endTryBody(frame)
 
 		// We need to reset the stack so that
 		// the values pushed inside the block.
@@ -634,7 +833,8 @@ operatorSwitch:
 			// Initiate the continuation.
 			c.emit(NewOperationLabel(continuationLabel))
 		case controlFrameKindBlockWithContinuationLabel,
-			controlFrameKindIfWithElse:
+			controlFrameKindIfWithElse,
+			controlFrameKindTry:
 			continuationLabel := NewLabel(LabelKindContinuation, frame.frameID)
 			c.result.LabelCallers[continuationLabel]++
 			c.emit(dropOp)
@@ -781,6 +981,76 @@ operatorSwitch:
 		c.emit(
 			NewOperationCallIndirect(typeIndex, tableIndex),
 		)
+	case wasm.OpcodeTailCallReturnCall:
+		// Drop the stack of the current function except the parameters of the callee, which replaces it.
+		c.emitTailCallDrop(c.funcTypeToSigs.get(c.funcs[index], false /* direct */).in)
+		c.emit(NewOperationTailCall(index))
+		// return_call is stack-polymorphic like return.
+		c.markUnreachable()
+	case wasm.OpcodeTailCallReturnCallIndirect:
+		typeIndex := index
+		tableIndex, n, err := leb128.LoadUint32(c.body[c.pc+1:])
+		if err != nil {
+			return fmt.Errorf("read target for return_call_indirect: %w", err)
+		}
+		c.pc += n
+		// This also keeps the offset in the table on top of the parameters.
+		c.emitTailCallDrop(c.funcTypeToSigs.get(typeIndex, true /* call_indirect */).in)
+		c.emit(NewOperationTailCallIndirect(typeIndex, tableIndex))
+		c.markUnreachable()
+	case wasm.OpcodeCallRef:
+		c.emit(NewOperationCallRef(index))
+	case wasm.OpcodeTailCallReturnCallRef:
+		// This also keeps the function reference on top of the parameters.
+		c.emitTailCallDrop(c.funcTypeToSigs.getRef(index).in)
+		c.emit(NewOperationTailCallRef(index))
+		c.markUnreachable()
+	case wasm.OpcodeRefAsNonNull:
+		c.emit(NewOperationRefAsNonNull())
+	case wasm.OpcodeBrOnNull, wasm.OpcodeBrOnNonNull:
+		targetIndex, n, err := leb128.LoadUint32(c.body[c.pc+1:])
+		if err != nil {
+			return fmt.Errorf("read the target for %s: %w", wasm.InstructionName(op), err)
+		}
+		c.pc += n
+
+		if c.unreachableState.on {
+			// If it is currently in unreachable, these are no-op like br_if.
+			break operatorSwitch
+		}
+
+		targetFrame := c.controlFrames.get(int(targetIndex))
+		targetFrame.ensureContinuation()
+		target := targetFrame.asLabel()
+		c.result.LabelCallers[target]++
+
+		// Branch to nullLabel if the reference is null, and to nonNullLabel otherwise.
+		nullLabel := NewLabel(LabelKindHeader, c.nextFrameID())
+		nonNullLabel := NewLabel(LabelKindHeader, c.nextFrameID())
+		c.result.LabelCallers[nullLabel]++
+		c.result.LabelCallers[nonNullLabel]++
+		c.emit(NewOperationPick(0, false))
+		c.emit(NewOperationEqz(UnsignedInt64))
+		c.emit(NewOperationBrIf(nullLabel, nonNullLabel, NopInclusiveRange))
+
+		if op == wasm.OpcodeBrOnNull {
+			// The null reference isn't passed to the label, so drop it before branching.
+			c.emit(NewOperationLabel(nullLabel))
+			c.emit(NewOperationDrop(InclusiveRange{Start: 0, End: 0}))
+			c.stackPop()
+			c.emit(NewOperationDrop(c.getFrameDropRange(targetFrame, false)))
+			c.emit(NewOperationBr(target))
+			c.stackPush(UnsignedTypeI64)
+			c.emit(NewOperationLabel(nonNullLabel))
+		} else {
+			// The non-null reference is passed to the label, and the null one is dropped otherwise.
+			c.emit(NewOperationLabel(nonNullLabel))
+			c.emit(NewOperationDrop(c.getFrameDropRange(targetFrame, false)))
+			c.emit(NewOperationBr(target))
+			c.emit(NewOperationLabel(nullLabel))
+			c.emit(NewOperationDrop(InclusiveRange{Start: 0, End: 0}))
+			c.stackPop()
+		}
 	case wasm.OpcodeDrop:
 		r := InclusiveRange{Start: 0, End: 0}
 		if peekValueType == UnsignedTypeV128 {
@@ -801,6 +1071,15 @@ operatorSwitch:
 	case wasm.OpcodeTypedSelect:
 		// Skips two bytes: vector size fixed to 1, and the value type for select.
 		c.pc += 2
+		if t := c.body[c.pc]; t == wasm.RefTypeNullablePrefix || t == wasm.RefTypeNonNullablePrefix {
+			// Also skip the heap type of a typed reference.
+			c.br.Reset(c.body[c.pc+1:])
+			_, n, err := wasm.DecodeHeapType(c.br, c.enabledFeatures, len(c.types))
+			if err != nil {
+				return fmt.Errorf("read the type for typed_select: %w", err)
+			}
+			c.pc += n
+		}
 		// If it is on the unreachable state, ignore the instruction.
 		if c.unreachableState.on {
 			break operatorSwitch
@@ -1582,7 +1861,13 @@ operatorSwitch:
 			NewOperationRefFunc(index),
 		)
 	case wasm.OpcodeRefNull:
-		c.pc++ // Skip the type of reftype as every ref value is opaque pointer.
+		// Skip the heap type as every ref value is opaque pointer.
+		c.br.Reset(c.body[c.pc+1:])
+		_, n, err := wasm.DecodeHeapType(c.br, c.enabledFeatures, len(c.types))
+		if err != nil {
+			return fmt.Errorf("read the heap type for ref.null: %w", err)
+		}
+		c.pc += n
 		c.emit(
 			NewOperationConstI64(0),
 		)
@@ -1756,6 +2041,44 @@ operatorSwitch:
 		default:
 			return fmt.Errorf("unsupported misc instruction in wazeroir: 0x%x", op)
 		}
+	case wasm.OpcodeAtomicPrefix:
+		c.pc++
+		atomicOp := c.body[c.pc]
+		if atomicOp == wasm.OpcodeAtomicFence {
+			c.pc++ // Skip the reserved byte.
+			c.emit(NewOperationAtomicFence())
+			break
+		}
+		imm, err := c.readMemoryArg(wasm.AtomicInstructionName(atomicOp))
+		if err != nil {
+			return err
+		}
+		switch {
+		case atomicOp == wasm.OpcodeAtomicMemoryNotify:
+			c.emit(NewOperationAtomicMemoryNotify(imm))
+		case atomicOp == wasm.OpcodeAtomicMemoryWait32:
+			c.emit(NewOperationAtomicMemoryWait(UnsignedTypeI32, imm))
+		case atomicOp == wasm.OpcodeAtomicMemoryWait64:
+			c.emit(NewOperationAtomicMemoryWait(UnsignedTypeI64, imm))
+		case atomicOp < wasm.OpcodeAtomicI32Load || atomicOp > wasm.OpcodeAtomicI64Rmw32CmpxchgU:
+			return fmt.Errorf("unsupported atomic instruction in wazeroir: 0x%x", atomicOp)
+		default:
+			t, size := wasm.AtomicAccess(atomicOp)
+			unsignedType := UnsignedTypeI32
+			if t == wasm.ValueTypeI64 {
+				unsignedType = UnsignedTypeI64
+			}
+			switch {
+			case atomicOp <= wasm.OpcodeAtomicI64Load32U:
+				c.emit(NewOperationAtomicLoad(unsignedType, byte(size), imm))
+			case atomicOp <= wasm.OpcodeAtomicI64Store32:
+				c.emit(NewOperationAtomicStore(unsignedType, byte(size), imm))
+			case atomicOp < wasm.OpcodeAtomicI32RmwCmpxchg:
+				c.emit(NewOperationAtomicRMW(unsignedType, byte(size), wasm.AtomicRMWOpOf(atomicOp), imm))
+			default:
+				c.emit(NewOperationAtomicRMWCmpxchg(unsignedType, byte(size), imm))
+			}
+		}
 	case wasm.OpcodeVecPrefix:
 		c.pc++
 		switch vecOp := c.body[c.pc]; vecOp {
@@ -2857,6 +3180,72 @@ operatorSwitch:
 	return nil
 }
 
+// readTryTableCatches reads the catch clauses of a try_table, whose labels are resolved outside it, as its frame isn't
+// pushed yet.
+func (c *Compiler) readTryTableCatches() ([]Catch, error) {
+	c.br.Reset(c.body[c.pc+1:])
+	num, n, err := leb128.DecodeUint32(c.br)
+	if err != nil {
+		return nil, fmt.Errorf("reading the number of catch clauses: %w", err)
+	}
+	c.pc += n
+
+	catches := make([]Catch, num)
+	for i := range catches {
+		catch := &catches[i]
+		kind, err := c.br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("reading catch clause %d: %w", i, err)
+		}
+		c.pc++
+		switch kind {
+		case wasm.CatchKindCatch, wasm.CatchKindCatchRef:
+			if catch.Tag, n, err = leb128.DecodeUint32(c.br); err != nil {
+				return nil, fmt.Errorf("reading the tag of catch clause %d: %w", i, err)
+			}
+			c.pc += n
+		default:
+			catch.All = true
+		}
+		catch.Ref = kind == wasm.CatchKindCatchRef || kind == wasm.CatchKindCatchAllRef
+
+		l, n, err := leb128.DecodeUint32(c.br)
+		if err != nil {
+			return nil, fmt.Errorf("reading the label of catch clause %d: %w", i, err)
+		}
+		c.pc += n
+		if c.unreachableState.on {
+			continue
+		}
+		// Catching an exception branches to the label like br.
+		targetFrame := c.controlFrames.get(int(l))
+		targetFrame.ensureContinuation()
+		catch.Target = targetFrame.asLabel()
+		catch.StackHeight = uint64(c.stackLenInUint64(targetFrame.originalStackLenWithoutParam))
+		c.result.LabelCallers[catch.Target]++
+	}
+	return catches, nil
+}
+
+// enclosingHandler returns the index in CompilationResult.ExceptionHandlers of the handler of the innermost try or
+// try_table body which encloses the control frame at `depth`, or -1 if exceptions are thrown to the caller.
+func (c *Compiler) enclosingHandler(depth int) int {
+	for i := len(c.controlFrames.frames) - 1 - depth; i >= 0; i-- {
+		if frame := &c.controlFrames.frames[i]; frame.inTryBody {
+			return frame.handler
+		}
+	}
+	return -1
+}
+
+// endTryBody ends the range of the handler of `frame`, if it is in the body of a try or try_table.
+func (c *Compiler) endTryBody(frame *controlFrame) {
+	if frame.inTryBody {
+		c.result.ExceptionHandlers[frame.handler].End = uint64(len(c.result.Operations))
+		frame.inTryBody = false
+	}
+}
+
 func (c *Compiler) nextFrameID() (id uint32) {
 	id = c.currentFrameID + 1
 	c.currentFrameID++
@@ -2870,6 +3259,11 @@ func (c *Compiler) applyToStack(opcode wasm.Opcode) (index uint32, err error) {
 		// and it DOES affect the signature of opcode.
 		wasm.OpcodeCall,
 		wasm.OpcodeCallIndirect,
+		wasm.OpcodeTailCallReturnCall,
+		wasm.OpcodeTailCallReturnCallIndirect,
+		wasm.OpcodeCallRef,
+		wasm.OpcodeTailCallReturnCallRef,
+		wasm.OpcodeThrow,
 		wasm.OpcodeLocalGet,
 		wasm.OpcodeLocalSet,
 		wasm.OpcodeLocalTee,
@@ -2971,6 +3365,41 @@ func (c *Compiler) emit(op UnionOperation) {
 			c.result.IROperationSourceOffsetsInWasmBinary = append(c.result.IROperationSourceOffsetsInWasmBinary,
 				c.currentOpPC+c.bodyOffsetInCodeSection)
 		}
+		if op.Kind == OperationKindLabel && c.fuelCosts != nil {
+			// Branches can enter here, so the instructions after the label are charged separately.
+			c.emitConsumeFuel()
+		}
+	}
+}
+
+// emitConsumeFuel emits OperationConsumeFuel, which is charged for the following
+// instructions until the next label.
+func (c *Compiler) emitConsumeFuel() {
+	c.fuelOperation = len(c.result.Operations)
+	c.emit(NewOperationConsumeFuel(0))
+}
+
+// chargeFuel adds the cost of the instruction `op` at the current pc to the
+// current OperationConsumeFuel.
+func (c *Compiler) chargeFuel(op wasm.Opcode) {
+	var name string
+	switch op {
+	case wasm.OpcodeMiscPrefix:
+		name = wasm.MiscInstructionName(c.body[c.pc+1])
+	case wasm.OpcodeVecPrefix:
+		name = wasm.VectorInstructionName(c.body[c.pc+1])
+	case wasm.OpcodeAtomicPrefix:
+		name = wasm.AtomicInstructionName(c.body[c.pc+1])
+	default:
+		name = wasm.InstructionName(op)
+	}
+
+	// The cost saturates, so that engines can handle it as int64.
+	consume := &c.result.Operations[c.fuelOperation]
+	if cost := c.fuelCosts.Of(name); cost < math.MaxInt64-consume.U1 {
+		consume.U1 += cost
+	} else {
+		consume.U1 = math.MaxInt64
 	}
 }
 
@@ -2980,7 +3409,7 @@ func (c *Compiler) emitDefaultValue(t wasm.ValueType) {
 	case wasm.ValueTypeI32:
 		c.stackPush(UnsignedTypeI32)
 		c.emit(NewOperationConstI32(0))
-	case wasm.ValueTypeI64, wasm.ValueTypeExternref, wasm.ValueTypeFuncref:
+	case wasm.ValueTypeI64, wasm.ValueTypeExternref, wasm.ValueTypeFuncref, wasm.ValueTypeExnref:
 		c.stackPush(UnsignedTypeI64)
 		c.emit(NewOperationConstI64(0))
 	case wasm.ValueTypeF32:
@@ -3011,6 +3440,29 @@ func (c *Compiler) localType(index wasm.Index) (t wasm.ValueType) {
 	return
 }
 
+// emitTailCallDrop emits the drop of the values of the current function, including its locals, below the operands
+// of a tail call, whose types are `in`, as applyToStack already popped them from c.stack. If the engine has call
+// frames on the stack, the parameters and the call frame of the current function are kept, as the engine reuses them.
+func (c *Compiler) emitTailCallDrop(in []UnsignedType) {
+	var keep int
+	for _, t := range in {
+		if keep++; t == UnsignedTypeV128 {
+			keep++
+		}
+	}
+	n := c.stackLenInUint64(len(c.stack))
+	if c.callFrameStackSizeInUint64 > 0 {
+		frame := c.sig.ParamNumInUint64
+		if frame < c.sig.ResultNumInUint64 {
+			frame = c.sig.ResultNumInUint64
+		}
+		n -= frame + c.callFrameStackSizeInUint64
+	}
+	if n > 0 {
+		c.emit(NewOperationDrop(InclusiveRange{Start: int32(keep), End: int32(keep + n - 1)}))
+	}
+}
+
 // getFrameDropRange returns the range (starting from top of the stack) that spans across the (uint64) stack. The range is
 // supposed to be dropped from the stack when the given frame exists or branch into it.
 //
@@ -3059,7 +3511,14 @@ func (c *Compiler) readMemoryArg(tag string) (MemoryArg, error) {
 		return MemoryArg{}, fmt.Errorf("reading alignment for %s: %w", tag, err)
 	}
 	c.pc += num
-	offset, num, err := leb128.LoadUint32(c.body[c.pc+1:])
+	var offset uint64
+	if c.result.Memory64 {
+		offset, num, err = leb128.LoadUint64(c.body[c.pc+1:])
+	} else {
+		var offset32 uint32
+		offset32, num, err = leb128.LoadUint32(c.body[c.pc+1:])
+		offset = uint64(offset32)
+	}
 	if err != nil {
 		return MemoryArg{}, fmt.Errorf("reading offset for %s: %w", tag, err)
 	}
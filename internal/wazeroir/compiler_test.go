@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/fuel"
 	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
@@ -184,7 +185,7 @@ func TestCompile(t *testing.T) {
 			for _, tp := range tc.module.TypeSection {
 				tp.CacheNumInUint64()
 			}
-			c, err := NewCompiler(enabledFeatures, 0, tc.module, false)
+			c, err := NewCompiler(enabledFeatures, 0, tc.module, false, nil)
 			require.NoError(t, err)
 
 			fn, err := c.Next()
@@ -309,7 +310,7 @@ func TestCompile_BulkMemoryOperations(t *testing.T) {
 		Types:            []wasm.FunctionType{v_v},
 	}
 
-	c, err := NewCompiler(api.CoreFeatureBulkMemoryOperations, 0, module, false)
+	c, err := NewCompiler(api.CoreFeatureBulkMemoryOperations, 0, module, false, nil)
 	require.NoError(t, err)
 
 	actual, err := c.Next()
@@ -593,7 +594,7 @@ func TestCompile_MultiValue(t *testing.T) {
 			for _, tp := range tc.module.TypeSection {
 				tp.CacheNumInUint64()
 			}
-			c, err := NewCompiler(enabledFeatures, 0, tc.module, false)
+			c, err := NewCompiler(enabledFeatures, 0, tc.module, false, nil)
 			require.NoError(t, err)
 
 			actual, err := c.Next()
@@ -629,7 +630,7 @@ func TestCompile_NonTrappingFloatToIntConversion(t *testing.T) {
 		Functions:    []wasm.Index{0},
 		Types:        []wasm.FunctionType{f32_i32},
 	}
-	c, err := NewCompiler(api.CoreFeatureNonTrappingFloatToIntConversion, 0, module, false)
+	c, err := NewCompiler(api.CoreFeatureNonTrappingFloatToIntConversion, 0, module, false, nil)
 	require.NoError(t, err)
 
 	actual, err := c.Next()
@@ -658,7 +659,7 @@ func TestCompile_SignExtensionOps(t *testing.T) {
 		Functions:    []wasm.Index{0},
 		Types:        []wasm.FunctionType{i32_i32},
 	}
-	c, err := NewCompiler(api.CoreFeatureSignExtensionOps, 0, module, false)
+	c, err := NewCompiler(api.CoreFeatureSignExtensionOps, 0, module, false, nil)
 	require.NoError(t, err)
 
 	actual, err := c.Next()
@@ -670,7 +671,7 @@ func requireCompilationResult(t *testing.T, enabledFeatures api.CoreFeatures, ex
 	if enabledFeatures == 0 {
 		enabledFeatures = api.CoreFeaturesV2
 	}
-	c, err := NewCompiler(enabledFeatures, 0, module, false)
+	c, err := NewCompiler(enabledFeatures, 0, module, false, nil)
 	require.NoError(t, err)
 
 	actual, err := c.Next()
@@ -713,7 +714,7 @@ func TestCompile_CallIndirectNonZeroTableIndex(t *testing.T) {
 		Types:        []wasm.FunctionType{v_v, v_v, v_v},
 	}
 
-	c, err := NewCompiler(api.CoreFeatureBulkMemoryOperations, 0, module, false)
+	c, err := NewCompiler(api.CoreFeatureBulkMemoryOperations, 0, module, false, nil)
 	require.NoError(t, err)
 
 	actual, err := c.Next()
@@ -806,7 +807,7 @@ func TestCompile_Refs(t *testing.T) {
 				FunctionSection: []wasm.Index{0},
 				CodeSection:     []wasm.Code{{Body: tc.body}},
 			}
-			c, err := NewCompiler(api.CoreFeaturesV2, 0, module, false)
+			c, err := NewCompiler(api.CoreFeaturesV2, 0, module, false, nil)
 			require.NoError(t, err)
 
 			actual, err := c.Next()
@@ -878,7 +879,7 @@ func TestCompile_TableGetOrSet(t *testing.T) {
 				CodeSection:     []wasm.Code{{Body: tc.body}},
 				TableSection:    []wasm.Table{{}},
 			}
-			c, err := NewCompiler(api.CoreFeaturesV2, 0, module, false)
+			c, err := NewCompiler(api.CoreFeaturesV2, 0, module, false, nil)
 			require.NoError(t, err)
 
 			actual, err := c.Next()
@@ -950,7 +951,7 @@ func TestCompile_TableGrowFillSize(t *testing.T) {
 				CodeSection:     []wasm.Code{{Body: tc.body}},
 				TableSection:    []wasm.Table{{}},
 			}
-			c, err := NewCompiler(api.CoreFeaturesV2, 0, module, false)
+			c, err := NewCompiler(api.CoreFeaturesV2, 0, module, false, nil)
 			require.NoError(t, err)
 
 			actual, err := c.Next()
@@ -1161,7 +1162,7 @@ func TestCompile_Locals(t *testing.T) {
 	for _, tt := range tests {
 		tc := tt
 		t.Run(tc.name, func(t *testing.T) {
-			c, err := NewCompiler(api.CoreFeaturesV2, 0, tc.mod, false)
+			c, err := NewCompiler(api.CoreFeaturesV2, 0, tc.mod, false, nil)
 			require.NoError(t, err)
 
 			actual, err := c.Next()
@@ -2644,7 +2645,7 @@ func TestCompile_Vec(t *testing.T) {
 				MemorySection:   &wasm.Memory{},
 				CodeSection:     []wasm.Code{{Body: tc.body}},
 			}
-			c, err := NewCompiler(api.CoreFeaturesV2, 0, module, false)
+			c, err := NewCompiler(api.CoreFeaturesV2, 0, module, false, nil)
 			require.NoError(t, err)
 
 			res, err := c.Next()
@@ -2724,7 +2725,7 @@ func TestCompile_unreachable_Br_BrIf_BrTable(t *testing.T) {
 	for _, tt := range tests {
 		tc := tt
 		t.Run(tc.name, func(t *testing.T) {
-			c, err := NewCompiler(api.CoreFeaturesV2, 0, tc.mod, false)
+			c, err := NewCompiler(api.CoreFeaturesV2, 0, tc.mod, false, nil)
 			require.NoError(t, err)
 
 			actual, err := c.Next()
@@ -2767,7 +2768,7 @@ func TestCompile_drop_vectors(t *testing.T) {
 	for _, tt := range tests {
 		tc := tt
 		t.Run(tc.name, func(t *testing.T) {
-			c, err := NewCompiler(api.CoreFeaturesV2, 0, tc.mod, false)
+			c, err := NewCompiler(api.CoreFeaturesV2, 0, tc.mod, false, nil)
 			require.NoError(t, err)
 
 			actual, err := c.Next()
@@ -2840,7 +2841,7 @@ func TestCompile_select_vectors(t *testing.T) {
 	for _, tt := range tests {
 		tc := tt
 		t.Run(tc.name, func(t *testing.T) {
-			c, err := NewCompiler(api.CoreFeaturesV2, 0, tc.mod, false)
+			c, err := NewCompiler(api.CoreFeaturesV2, 0, tc.mod, false, nil)
 			require.NoError(t, err)
 
 			actual, err := c.Next()
@@ -3062,7 +3063,7 @@ func Test_ensureTermination(t *testing.T) {
 					},
 				}},
 			}
-			c, err := NewCompiler(api.CoreFeaturesV2, 0, mod, tc.ensureTermination)
+			c, err := NewCompiler(api.CoreFeaturesV2, 0, mod, tc.ensureTermination, nil)
 			require.NoError(t, err)
 
 			actual, err := c.Next()
@@ -3071,3 +3072,44 @@ func Test_ensureTermination(t *testing.T) {
 		})
 	}
 }
+
+func Test_fuelCosts(t *testing.T) {
+	mod := &wasm.Module{
+		TypeSection:     []wasm.FunctionType{v_v},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []wasm.Code{{
+			Body: []byte{
+				wasm.OpcodeI32Const, 0,
+				wasm.OpcodeLoop, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeBrIf, 0, wasm.OpcodeEnd,
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeI32Add,
+				wasm.OpcodeDrop,
+				wasm.OpcodeEnd,
+			},
+		}},
+	}
+	c, err := NewCompiler(api.CoreFeaturesV2, 0, mod, false, &fuel.Costs{
+		Default:      1,
+		Instructions: map[string]uint64{wasm.OpcodeI32AddName: 10, wasm.OpcodeBrIfName: 100},
+	})
+	require.NoError(t, err)
+
+	actual, err := c.Next()
+	require.NoError(t, err)
+	// Each label is charged for the instructions until the next one.
+	require.Equal(t, `.entrypoint
+	ConsumeFuel 2
+	ConstI32 0x0
+	Br .L2
+.L2
+	ConsumeFuel 101
+	ConstI32 0x1
+	BrIf .L2, .L3
+.L3
+	ConsumeFuel 14
+	ConstI32 0x1
+	i32.Add
+	Drop 0..0
+	Br .return
+`, Format(actual.Operations))
+}
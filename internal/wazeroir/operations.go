@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"math"
 	"strings"
+
+	"github.com/tetratelabs/wazero/internal/wasm"
 )
 
 // UnsignedInt represents unsigned 32-bit or 64-bit integers.
@@ -415,6 +417,36 @@ func (o OperationKind) String() (ret string) {
 		ret = "V128ITruncSatFromF"
 	case OperationKindBuiltinFunctionCheckExitCode:
 		ret = "BuiltinFunctionCheckExitCode"
+	case OperationKindConsumeFuel:
+		ret = "ConsumeFuel"
+	case OperationKindAtomicMemoryWait:
+		ret = "AtomicMemoryWait"
+	case OperationKindAtomicMemoryNotify:
+		ret = "AtomicMemoryNotify"
+	case OperationKindAtomicFence:
+		ret = "AtomicFence"
+	case OperationKindAtomicLoad:
+		ret = "AtomicLoad"
+	case OperationKindAtomicStore:
+		ret = "AtomicStore"
+	case OperationKindAtomicRMW:
+		ret = "AtomicRMW"
+	case OperationKindAtomicRMWCmpxchg:
+		ret = "AtomicRMWCmpxchg"
+	case OperationKindTailCall:
+		ret = "TailCall"
+	case OperationKindTailCallIndirect:
+		ret = "TailCallIndirect"
+	case OperationKindThrow:
+		ret = "Throw"
+	case OperationKindThrowRef:
+		ret = "ThrowRef"
+	case OperationKindCallRef:
+		ret = "CallRef"
+	case OperationKindTailCallRef:
+		ret = "TailCallRef"
+	case OperationKindRefAsNonNull:
+		ret = "RefAsNonNull"
 	default:
 		panic(fmt.Errorf("unknown operation %d", o))
 	}
@@ -705,6 +737,41 @@ const (
 	// OperationKindBuiltinFunctionCheckExitCode is the Kind for NewOperationBuiltinFunctionCheckExitCode.
 	OperationKindBuiltinFunctionCheckExitCode
 
+	// OperationKindConsumeFuel is the Kind for NewOperationConsumeFuel.
+	OperationKindConsumeFuel
+
+	// OperationKindAtomicMemoryWait is the Kind for NewOperationAtomicMemoryWait.
+	OperationKindAtomicMemoryWait
+	// OperationKindAtomicMemoryNotify is the Kind for NewOperationAtomicMemoryNotify.
+	OperationKindAtomicMemoryNotify
+	// OperationKindAtomicFence is the Kind for NewOperationAtomicFence.
+	OperationKindAtomicFence
+	// OperationKindAtomicLoad is the Kind for NewOperationAtomicLoad.
+	OperationKindAtomicLoad
+	// OperationKindAtomicStore is the Kind for NewOperationAtomicStore.
+	OperationKindAtomicStore
+	// OperationKindAtomicRMW is the Kind for NewOperationAtomicRMW.
+	OperationKindAtomicRMW
+	// OperationKindAtomicRMWCmpxchg is the Kind for NewOperationAtomicRMWCmpxchg.
+	OperationKindAtomicRMWCmpxchg
+
+	// OperationKindTailCall is the Kind for NewOperationTailCall.
+	OperationKindTailCall
+	// OperationKindTailCallIndirect is the Kind for NewOperationTailCallIndirect.
+	OperationKindTailCallIndirect
+
+	// OperationKindThrow is the Kind for NewOperationThrow.
+	OperationKindThrow
+	// OperationKindThrowRef is the Kind for NewOperationThrowRef.
+	OperationKindThrowRef
+
+	// OperationKindCallRef is the Kind for NewOperationCallRef.
+	OperationKindCallRef
+	// OperationKindTailCallRef is the Kind for NewOperationTailCallRef.
+	OperationKindTailCallRef
+	// OperationKindRefAsNonNull is the Kind for NewOperationRefAsNonNull.
+	OperationKindRefAsNonNull
+
 	// operationKindEnd is always placed at the bottom of this iota definition to be used in the test.
 	operationKindEnd
 )
@@ -717,6 +784,142 @@ func NewOperationBuiltinFunctionCheckExitCode() UnionOperation {
 	return UnionOperation{Kind: OperationKindBuiltinFunctionCheckExitCode}
 }
 
+// NewOperationConsumeFuel is a constructor for UnionOperation with Kind OperationKindConsumeFuel.
+//
+// OperationConsumeFuel corresponds to the instruction to subtract `cost` from the remaining fuel of the call,
+// and trap with wasmruntime.ErrRuntimeFuelExhausted if it becomes negative.
+//
+// This is emitted at the beginning of each function and label when fuel metering is enabled, with the cost of
+// the Wasm instructions until the next label.
+func NewOperationConsumeFuel(cost uint64) UnionOperation {
+	return UnionOperation{Kind: OperationKindConsumeFuel, U1: cost}
+}
+
+// NewOperationAtomicMemoryWait is a constructor for UnionOperation with Kind OperationKindAtomicMemoryWait.
+//
+// This corresponds to wasm.OpcodeAtomicMemoryWait32Name wasm.OpcodeAtomicMemoryWait64Name, where unsignedType is
+// the type of the expected value.
+//
+// The engines are expected to call wasm.MemoryInstance Wait.
+func NewOperationAtomicMemoryWait(unsignedType UnsignedType, arg MemoryArg) UnionOperation {
+	return UnionOperation{Kind: OperationKindAtomicMemoryWait, B1: byte(unsignedType), U1: uint64(arg.Alignment), U2: arg.Offset}
+}
+
+// NewOperationAtomicMemoryNotify is a constructor for UnionOperation with Kind OperationKindAtomicMemoryNotify.
+//
+// This corresponds to wasm.OpcodeAtomicMemoryNotifyName.
+//
+// The engines are expected to call wasm.MemoryInstance Notify.
+func NewOperationAtomicMemoryNotify(arg MemoryArg) UnionOperation {
+	return UnionOperation{Kind: OperationKindAtomicMemoryNotify, U1: uint64(arg.Alignment), U2: arg.Offset}
+}
+
+// NewOperationAtomicFence is a constructor for UnionOperation with Kind OperationKindAtomicFence.
+//
+// This corresponds to wasm.OpcodeAtomicFenceName.
+//
+// The engines are expected to call wasm.MemoryInstance AtomicFence, if the module has a memory.
+func NewOperationAtomicFence() UnionOperation {
+	return UnionOperation{Kind: OperationKindAtomicFence}
+}
+
+// NewOperationAtomicLoad is a constructor for UnionOperation with Kind OperationKindAtomicLoad.
+//
+// This corresponds to the atomic loads, e.g. wasm.OpcodeAtomicI64Load16UName, where unsignedType is the type of the
+// result, and sizeInBytes is the size of the memory accessed.
+//
+// The engines are expected to call wasm.MemoryInstance AtomicLoad. Likewise, the other atomic operations call the
+// corresponding method of wasm.MemoryInstance, which checks the boundary and the alignment of the access.
+func NewOperationAtomicLoad(unsignedType UnsignedType, sizeInBytes byte, arg MemoryArg) UnionOperation {
+	return UnionOperation{Kind: OperationKindAtomicLoad, B1: byte(unsignedType), B2: sizeInBytes, U1: uint64(arg.Alignment), U2: arg.Offset}
+}
+
+// NewOperationAtomicStore is a constructor for UnionOperation with Kind OperationKindAtomicStore.
+//
+// This corresponds to the atomic stores, e.g. wasm.OpcodeAtomicI64Store16Name, where unsignedType is the type of the
+// value, and sizeInBytes is the size of the memory accessed.
+func NewOperationAtomicStore(unsignedType UnsignedType, sizeInBytes byte, arg MemoryArg) UnionOperation {
+	return UnionOperation{Kind: OperationKindAtomicStore, B1: byte(unsignedType), B2: sizeInBytes, U1: uint64(arg.Alignment), U2: arg.Offset}
+}
+
+// NewOperationAtomicRMW is a constructor for UnionOperation with Kind OperationKindAtomicRMW.
+//
+// This corresponds to the atomic read-modify-write instructions, except cmpxchg, e.g.
+// wasm.OpcodeAtomicI64Rmw16AddUName, where unsignedType is the type of the value and the result, and sizeInBytes is
+// the size of the memory accessed.
+func NewOperationAtomicRMW(unsignedType UnsignedType, sizeInBytes byte, op wasm.AtomicRMWOp, arg MemoryArg) UnionOperation {
+	return UnionOperation{Kind: OperationKindAtomicRMW, B1: byte(unsignedType), B2: sizeInBytes, U1: uint64(arg.Alignment), U2: arg.Offset, U3: uint64(op)}
+}
+
+// NewOperationAtomicRMWCmpxchg is a constructor for UnionOperation with Kind OperationKindAtomicRMWCmpxchg.
+//
+// This corresponds to the atomic compare-exchange instructions, e.g. wasm.OpcodeAtomicI64Rmw16CmpxchgUName, where
+// unsignedType is the type of the values and the result, and sizeInBytes is the size of the memory accessed.
+func NewOperationAtomicRMWCmpxchg(unsignedType UnsignedType, sizeInBytes byte, arg MemoryArg) UnionOperation {
+	return UnionOperation{Kind: OperationKindAtomicRMWCmpxchg, B1: byte(unsignedType), B2: sizeInBytes, U1: uint64(arg.Alignment), U2: arg.Offset}
+}
+
+// NewOperationTailCall is a constructor for UnionOperation with Kind OperationKindTailCall.
+//
+// This corresponds to wasm.OpcodeTailCallReturnCallName. The parameters of the callee are on top of the stack, and the
+// values of the current function below them were dropped, except its parameters and call frame if the engine has call
+// frames on the stack. Engines are expected to replace the current call frame with the one of the callee, and jump
+// into it, so that the callee returns its results to the caller of the current function.
+func NewOperationTailCall(functionIndex uint32) UnionOperation {
+	return UnionOperation{Kind: OperationKindTailCall, U1: uint64(functionIndex)}
+}
+
+// NewOperationTailCallIndirect is a constructor for UnionOperation with Kind OperationKindTailCallIndirect.
+//
+// This corresponds to wasm.OpcodeTailCallReturnCallIndirectName, and is like NewOperationCallIndirect with the call
+// frame reuse of NewOperationTailCall.
+func NewOperationTailCallIndirect(typeIndex, tableIndex uint32) UnionOperation {
+	return UnionOperation{Kind: OperationKindTailCallIndirect, U1: uint64(typeIndex), U2: uint64(tableIndex)}
+}
+
+// NewOperationThrow is a constructor for UnionOperation with Kind OperationKindThrow.
+//
+// This corresponds to wasm.OpcodeThrowName. The payload of the exception, which are the parameters of the type of the
+// tag at `tagIndex`, are on top of the stack. Engines are expected to unwind the stack until an
+// ExceptionHandler of CompilationResult catches the exception.
+func NewOperationThrow(tagIndex uint32) UnionOperation {
+	return UnionOperation{Kind: OperationKindThrow, U1: uint64(tagIndex)}
+}
+
+// NewOperationThrowRef is a constructor for UnionOperation with Kind OperationKindThrowRef.
+//
+// This corresponds to wasm.OpcodeThrowRefName, and is like NewOperationThrow, except that the exnref of the exception
+// is on top of the stack, and engines are expected to trap if it is null. This is also emitted for
+// wasm.OpcodeRethrowName, after picking the exnref of the exception caught.
+func NewOperationThrowRef() UnionOperation {
+	return UnionOperation{Kind: OperationKindThrowRef}
+}
+
+// NewOperationCallRef is a constructor for UnionOperation with Kind OperationKindCallRef.
+//
+// This corresponds to wasm.OpcodeCallRefName. The function reference is on top of the parameters of the callee, and
+// engines are expected to trap if it is null, or if the type of the function isn't the one at `typeIndex`, like
+// NewOperationCallIndirect.
+func NewOperationCallRef(typeIndex uint32) UnionOperation {
+	return UnionOperation{Kind: OperationKindCallRef, U1: uint64(typeIndex)}
+}
+
+// NewOperationTailCallRef is a constructor for UnionOperation with Kind OperationKindTailCallRef.
+//
+// This corresponds to wasm.OpcodeTailCallReturnCallRefName, and is like NewOperationCallRef with the call frame reuse
+// of NewOperationTailCall.
+func NewOperationTailCallRef(typeIndex uint32) UnionOperation {
+	return UnionOperation{Kind: OperationKindTailCallRef, U1: uint64(typeIndex)}
+}
+
+// NewOperationRefAsNonNull is a constructor for UnionOperation with Kind OperationKindRefAsNonNull.
+//
+// This corresponds to wasm.OpcodeRefAsNonNullName, and engines are expected to trap if the reference on top of the
+// stack is null, and leave it on the stack otherwise.
+func NewOperationRefAsNonNull() UnionOperation {
+	return UnionOperation{Kind: OperationKindRefAsNonNull}
+}
+
 // Label is the unique identifier for each block in a single function in wazeroir
 // where "block" consists of multiple operations, and must End with branching operations
 // (e.g. OperationKindBr or OperationKindBrIf).
@@ -828,7 +1031,9 @@ func (o UnionOperation) String() string {
 		OperationKindTableSize,
 		OperationKindTableGrow,
 		OperationKindTableFill,
-		OperationKindBuiltinFunctionCheckExitCode:
+		OperationKindBuiltinFunctionCheckExitCode,
+		OperationKindThrowRef,
+		OperationKindRefAsNonNull:
 		return o.Kind.String()
 
 	case OperationKindCall,
@@ -836,6 +1041,24 @@ func (o UnionOperation) String() string {
 		OperationKindGlobalSet:
 		return fmt.Sprintf("%s %d", o.Kind, o.B1)
 
+	case OperationKindConsumeFuel:
+		return fmt.Sprintf("%s %d", o.Kind, o.U1)
+
+	case OperationKindAtomicFence:
+		return o.Kind.String()
+
+	case OperationKindAtomicMemoryWait:
+		return fmt.Sprintf("%s.%s (align=%d, offset=%d)", UnsignedType(o.B1), o.Kind, o.U1, o.U2)
+
+	case OperationKindAtomicMemoryNotify:
+		return fmt.Sprintf("%s (align=%d, offset=%d)", o.Kind, o.U1, o.U2)
+
+	case OperationKindAtomicLoad, OperationKindAtomicStore, OperationKindAtomicRMWCmpxchg:
+		return fmt.Sprintf("%s.%s%d (align=%d, offset=%d)", UnsignedType(o.B1), o.Kind, o.B2*8, o.U1, o.U2)
+
+	case OperationKindAtomicRMW:
+		return fmt.Sprintf("%s.%s%d (op=%d, align=%d, offset=%d)", UnsignedType(o.B1), o.Kind, o.B2*8, o.U3, o.U1, o.U2)
+
 	case OperationKindLabel:
 		return Label(o.U1).String()
 
@@ -859,9 +1082,15 @@ func (o UnionOperation) String() string {
 		}
 		return fmt.Sprintf("%s [%s] %s", o.Kind, strings.Join(targets, ","), defaultLabel)
 
-	case OperationKindCallIndirect:
+	case OperationKindCallIndirect, OperationKindTailCallIndirect:
 		return fmt.Sprintf("%s: type=%d, table=%d", o.Kind, o.U1, o.U2)
 
+	case OperationKindTailCall, OperationKindThrow:
+		return fmt.Sprintf("%s %d", o.Kind, o.U1)
+
+	case OperationKindCallRef, OperationKindTailCallRef:
+		return fmt.Sprintf("%s: type=%d", o.Kind, o.U1)
+
 	case OperationKindDrop:
 		start := int64(o.U1)
 		end := int64(o.U2)
@@ -1199,7 +1428,9 @@ type MemoryArg struct {
 
 	// Offset is the address offset added to the instruction's dynamic address operand, yielding a 33-bit effective
 	// address that is the zero-based index at which the memory is accessed. Default to zero.
-	Offset uint32
+	//
+	// This is an u64 if CompilationResult.Memory64, yielding a 65-bit effective address.
+	Offset uint64
 }
 
 // NewOperationLoad is a constructor for UnionOperation with OperationKindLoad.
@@ -1209,7 +1440,7 @@ type MemoryArg struct {
 // The engines are expected to check the boundary of memory length, and exit the execution if this exceeds the boundary,
 // otherwise load the corresponding value following the semantics of the corresponding WebAssembly instruction.
 func NewOperationLoad(unsignedType UnsignedType, arg MemoryArg) UnionOperation {
-	return UnionOperation{Kind: OperationKindLoad, B1: byte(unsignedType), U1: uint64(arg.Alignment), U2: uint64(arg.Offset)}
+	return UnionOperation{Kind: OperationKindLoad, B1: byte(unsignedType), U1: uint64(arg.Alignment), U2: arg.Offset}
 }
 
 // NewOperationLoad8 is a constructor for UnionOperation with OperationKindLoad8.
@@ -1219,7 +1450,7 @@ func NewOperationLoad(unsignedType UnsignedType, arg MemoryArg) UnionOperation {
 // The engines are expected to check the boundary of memory length, and exit the execution if this exceeds the boundary,
 // otherwise load the corresponding value following the semantics of the corresponding WebAssembly instruction.
 func NewOperationLoad8(signedInt SignedInt, arg MemoryArg) UnionOperation {
-	return UnionOperation{Kind: OperationKindLoad8, B1: byte(signedInt), U1: uint64(arg.Alignment), U2: uint64(arg.Offset)}
+	return UnionOperation{Kind: OperationKindLoad8, B1: byte(signedInt), U1: uint64(arg.Alignment), U2: arg.Offset}
 }
 
 // NewOperationLoad16 is a constructor for UnionOperation with OperationKindLoad16.
@@ -1229,7 +1460,7 @@ func NewOperationLoad8(signedInt SignedInt, arg MemoryArg) UnionOperation {
 // The engines are expected to check the boundary of memory length, and exit the execution if this exceeds the boundary,
 // otherwise load the corresponding value following the semantics of the corresponding WebAssembly instruction.
 func NewOperationLoad16(signedInt SignedInt, arg MemoryArg) UnionOperation {
-	return UnionOperation{Kind: OperationKindLoad16, B1: byte(signedInt), U1: uint64(arg.Alignment), U2: uint64(arg.Offset)}
+	return UnionOperation{Kind: OperationKindLoad16, B1: byte(signedInt), U1: uint64(arg.Alignment), U2: arg.Offset}
 }
 
 // NewOperationLoad32 is a constructor for UnionOperation with OperationKindLoad32.
@@ -1243,7 +1474,7 @@ func NewOperationLoad32(signed bool, arg MemoryArg) UnionOperation {
 	if signed {
 		sigB = 1
 	}
-	return UnionOperation{Kind: OperationKindLoad32, B1: sigB, U1: uint64(arg.Alignment), U2: uint64(arg.Offset)}
+	return UnionOperation{Kind: OperationKindLoad32, B1: sigB, U1: uint64(arg.Alignment), U2: arg.Offset}
 }
 
 // NewOperationStore is a constructor for UnionOperation with OperationKindStore.
@@ -1253,7 +1484,7 @@ func NewOperationLoad32(signed bool, arg MemoryArg) UnionOperation {
 // The engines are expected to check the boundary of memory length, and exit the execution if this exceeds the boundary,
 // otherwise store the corresponding value following the semantics of the corresponding WebAssembly instruction.
 func NewOperationStore(unsignedType UnsignedType, arg MemoryArg) UnionOperation {
-	return UnionOperation{Kind: OperationKindStore, B1: byte(unsignedType), U1: uint64(arg.Alignment), U2: uint64(arg.Offset)}
+	return UnionOperation{Kind: OperationKindStore, B1: byte(unsignedType), U1: uint64(arg.Alignment), U2: arg.Offset}
 }
 
 // NewOperationStore8 is a constructor for UnionOperation with OperationKindStore8.
@@ -1263,7 +1494,7 @@ func NewOperationStore(unsignedType UnsignedType, arg MemoryArg) UnionOperation
 // The engines are expected to check the boundary of memory length, and exit the execution if this exceeds the boundary,
 // otherwise store the corresponding value following the semantics of the corresponding WebAssembly instruction.
 func NewOperationStore8(arg MemoryArg) UnionOperation {
-	return UnionOperation{Kind: OperationKindStore8, U1: uint64(arg.Alignment), U2: uint64(arg.Offset)}
+	return UnionOperation{Kind: OperationKindStore8, U1: uint64(arg.Alignment), U2: arg.Offset}
 }
 
 // NewOperationStore16 is a constructor for UnionOperation with OperationKindStore16.
@@ -1273,7 +1504,7 @@ func NewOperationStore8(arg MemoryArg) UnionOperation {
 // The engines are expected to check the boundary of memory length, and exit the execution if this exceeds the boundary,
 // otherwise store the corresponding value following the semantics of the corresponding WebAssembly instruction.
 func NewOperationStore16(arg MemoryArg) UnionOperation {
-	return UnionOperation{Kind: OperationKindStore16, U1: uint64(arg.Alignment), U2: uint64(arg.Offset)}
+	return UnionOperation{Kind: OperationKindStore16, U1: uint64(arg.Alignment), U2: arg.Offset}
 }
 
 // NewOperationStore32 is a constructor for UnionOperation with OperationKindStore32.
@@ -1283,7 +1514,7 @@ func NewOperationStore16(arg MemoryArg) UnionOperation {
 // The engines are expected to check the boundary of memory length, and exit the execution if this exceeds the boundary,
 // otherwise store the corresponding value following the semantics of the corresponding WebAssembly instruction.
 func NewOperationStore32(arg MemoryArg) UnionOperation {
-	return UnionOperation{Kind: OperationKindStore32, U1: uint64(arg.Alignment), U2: uint64(arg.Offset)}
+	return UnionOperation{Kind: OperationKindStore32, U1: uint64(arg.Alignment), U2: arg.Offset}
 }
 
 // NewOperationMemorySize is a constructor for UnionOperation with OperationKindMemorySize.
@@ -1980,7 +2211,7 @@ const (
 //	wasm.OpcodeVecV128Load32SplatName wasm.OpcodeVecV128Load64SplatName wasm.OpcodeVecV128Load32zeroName
 //	wasm.OpcodeVecV128Load64zeroName
 func NewOperationV128Load(loadType V128LoadType, arg MemoryArg) UnionOperation {
-	return UnionOperation{Kind: OperationKindV128Load, B1: loadType, U1: uint64(arg.Alignment), U2: uint64(arg.Offset)}
+	return UnionOperation{Kind: OperationKindV128Load, B1: loadType, U1: uint64(arg.Alignment), U2: arg.Offset}
 }
 
 // NewOperationV128LoadLane is a constructor for UnionOperation with OperationKindV128LoadLane.
@@ -1992,7 +2223,7 @@ func NewOperationV128Load(loadType V128LoadType, arg MemoryArg) UnionOperation {
 // laneIndex is >=0 && <(128/LaneSize).
 // laneSize is either 8, 16, 32, or 64.
 func NewOperationV128LoadLane(laneIndex, laneSize byte, arg MemoryArg) UnionOperation {
-	return UnionOperation{Kind: OperationKindV128LoadLane, B1: laneSize, B2: laneIndex, U1: uint64(arg.Alignment), U2: uint64(arg.Offset)}
+	return UnionOperation{Kind: OperationKindV128LoadLane, B1: laneSize, B2: laneIndex, U1: uint64(arg.Alignment), U2: arg.Offset}
 }
 
 // NewOperationV128Store is a constructor for UnionOperation with OperationKindV128Store.
@@ -2004,7 +2235,7 @@ func NewOperationV128Store(arg MemoryArg) UnionOperation {
 	return UnionOperation{
 		Kind: OperationKindV128Store,
 		U1:   uint64(arg.Alignment),
-		U2:   uint64(arg.Offset),
+		U2:   arg.Offset,
 	}
 }
 
@@ -2022,7 +2253,7 @@ func NewOperationV128StoreLane(laneIndex byte, laneSize byte, arg MemoryArg) Uni
 		B1:   laneSize,
 		B2:   laneIndex,
 		U1:   uint64(arg.Alignment),
-		U2:   uint64(arg.Offset),
+		U2:   arg.Offset,
 	}
 }
 
@@ -156,6 +156,34 @@ var (
 		in:  []UnsignedType{UnsignedTypeF64, UnsignedTypeF64},
 		out: []UnsignedType{UnsignedTypeF64},
 	}
+	signature_I64I32_None = &signature{
+		in: []UnsignedType{UnsignedTypeI64, UnsignedTypeI32},
+	}
+	signature_I64I64_None = &signature{
+		in: []UnsignedType{UnsignedTypeI64, UnsignedTypeI64},
+	}
+	signature_I64F32_None = &signature{
+		in: []UnsignedType{UnsignedTypeI64, UnsignedTypeF32},
+	}
+	signature_I64F64_None = &signature{
+		in: []UnsignedType{UnsignedTypeI64, UnsignedTypeF64},
+	}
+	signature_I64I32I32_None = &signature{
+		in: []UnsignedType{UnsignedTypeI64, UnsignedTypeI32, UnsignedTypeI32},
+	}
+	signature_I64I32I64_None = &signature{
+		in: []UnsignedType{UnsignedTypeI64, UnsignedTypeI32, UnsignedTypeI64},
+	}
+	signature_I64I64I64_None = &signature{
+		in: []UnsignedType{UnsignedTypeI64, UnsignedTypeI64, UnsignedTypeI64},
+	}
+	signature_I64V128_None = &signature{
+		in: []UnsignedType{UnsignedTypeI64, UnsignedTypeV128},
+	}
+	signature_I64V128_V128 = &signature{
+		in:  []UnsignedType{UnsignedTypeI64, UnsignedTypeV128},
+		out: []UnsignedType{UnsignedTypeV128},
+	}
 	signature_I32I32I32_None = &signature{
 		in: []UnsignedType{UnsignedTypeI32, UnsignedTypeI32, UnsignedTypeI32},
 	}
@@ -233,6 +261,42 @@ var (
 		in:  []UnsignedType{UnsignedTypeF64},
 		out: []UnsignedType{UnsignedTypeV128},
 	}
+	signature_I32I64_I64 = &signature{
+		in:  []UnsignedType{UnsignedTypeI32, UnsignedTypeI64},
+		out: []UnsignedType{UnsignedTypeI64},
+	}
+	signature_I32I32I32_I32 = &signature{
+		in:  []UnsignedType{UnsignedTypeI32, UnsignedTypeI32, UnsignedTypeI32},
+		out: []UnsignedType{UnsignedTypeI32},
+	}
+	signature_I32I32I64_I32 = &signature{
+		in:  []UnsignedType{UnsignedTypeI32, UnsignedTypeI32, UnsignedTypeI64},
+		out: []UnsignedType{UnsignedTypeI32},
+	}
+	signature_I32I64I64_I32 = &signature{
+		in:  []UnsignedType{UnsignedTypeI32, UnsignedTypeI64, UnsignedTypeI64},
+		out: []UnsignedType{UnsignedTypeI32},
+	}
+	signature_I32I64I64_I64 = &signature{
+		in:  []UnsignedType{UnsignedTypeI32, UnsignedTypeI64, UnsignedTypeI64},
+		out: []UnsignedType{UnsignedTypeI64},
+	}
+	signature_I64I32I32_I32 = &signature{
+		in:  []UnsignedType{UnsignedTypeI64, UnsignedTypeI32, UnsignedTypeI32},
+		out: []UnsignedType{UnsignedTypeI32},
+	}
+	signature_I64I32I64_I32 = &signature{
+		in:  []UnsignedType{UnsignedTypeI64, UnsignedTypeI32, UnsignedTypeI64},
+		out: []UnsignedType{UnsignedTypeI32},
+	}
+	signature_I64I64I64_I32 = &signature{
+		in:  []UnsignedType{UnsignedTypeI64, UnsignedTypeI64, UnsignedTypeI64},
+		out: []UnsignedType{UnsignedTypeI32},
+	}
+	signature_I64I64I64_I64 = &signature{
+		in:  []UnsignedType{UnsignedTypeI64, UnsignedTypeI64, UnsignedTypeI64},
+		out: []UnsignedType{UnsignedTypeI64},
+	}
 )
 
 // wasmOpcodeSignature returns the signature of given Wasm opcode.
@@ -241,6 +305,11 @@ var (
 // "index" parameter is not used by most of opcodes.
 // The returned signature is used for stack validation when lowering Wasm's opcodes to wazeroir.
 func (c *Compiler) wasmOpcodeSignature(op wasm.Opcode, index uint32) (*signature, error) {
+	if c.result.Memory64 {
+		if s := c.memory64Signature(op); s != nil {
+			return s, nil
+		}
+	}
 	switch op {
 	case wasm.OpcodeUnreachable, wasm.OpcodeNop, wasm.OpcodeBlock, wasm.OpcodeLoop:
 		return signature_None_None, nil
@@ -256,6 +325,28 @@ func (c *Compiler) wasmOpcodeSignature(op wasm.Opcode, index uint32) (*signature
 		return c.funcTypeToSigs.get(c.funcs[index], false /* direct */), nil
 	case wasm.OpcodeCallIndirect:
 		return c.funcTypeToSigs.get(index, true /* call_indirect */), nil
+	case wasm.OpcodeTailCallReturnCall:
+		// The results are returned to the caller, so the instruction has none.
+		return &signature{in: c.funcTypeToSigs.get(c.funcs[index], false /* direct */).in}, nil
+	case wasm.OpcodeTailCallReturnCallIndirect:
+		return &signature{in: c.funcTypeToSigs.get(index, true /* call_indirect */).in}, nil
+	case wasm.OpcodeCallRef:
+		return c.funcTypeToSigs.getRef(index), nil
+	case wasm.OpcodeTailCallReturnCallRef:
+		return &signature{in: c.funcTypeToSigs.getRef(index).in}, nil
+	case wasm.OpcodeRefAsNonNull:
+		return signature_I64_I64, nil
+	case wasm.OpcodeBrOnNull, wasm.OpcodeBrOnNonNull:
+		// The reference is popped by the compiler, as it is passed to the label by br_on_non_null.
+		return signature_None_None, nil
+	case wasm.OpcodeTry, wasm.OpcodeTryTable, wasm.OpcodeCatch, wasm.OpcodeCatchAll, wasm.OpcodeDelegate, wasm.OpcodeRethrow:
+		// The stack is manipulated by the compiler, as for the other control instructions.
+		return signature_None_None, nil
+	case wasm.OpcodeThrow:
+		// The payload is the params of the tag type.
+		return &signature{in: c.funcTypeToSigs.get(c.tags[index], false /* direct */).in}, nil
+	case wasm.OpcodeThrowRef:
+		return signature_I64_None, nil
 	case wasm.OpcodeDrop:
 		return signature_Unknown_None, nil
 	case wasm.OpcodeSelect, wasm.OpcodeTypedSelect:
@@ -476,6 +567,8 @@ func (c *Compiler) wasmOpcodeSignature(op wasm.Opcode, index uint32) (*signature
 		default:
 			return nil, fmt.Errorf("unsupported misc instruction in wazeroir: 0x%x", op)
 		}
+	case wasm.OpcodeAtomicPrefix:
+		return c.atomicSignature(c.body[c.pc+1]), nil
 	case wasm.OpcodeVecPrefix:
 		switch vecOp := c.body[c.pc+1]; vecOp {
 		case wasm.OpcodeVecV128Const:
@@ -596,7 +689,9 @@ func (c *Compiler) wasmOpcodeSignature(op wasm.Opcode, index uint32) (*signature
 type funcTypeToIRSignatures struct {
 	directCalls   []*signature
 	indirectCalls []*signature
-	wasmTypes     []wasm.FunctionType
+	// refCalls is allocated on the first call_ref, as few modules use it.
+	refCalls  []*signature
+	wasmTypes []wasm.FunctionType
 }
 
 // get returns the *signature for the direct or indirect function call against functions whose type is at `typeIndex`.
@@ -640,13 +735,29 @@ func (f *funcTypeToIRSignatures) get(typeIndex wasm.Index, indirect bool) *signa
 	return sig
 }
 
+// getRef returns the *signature for call_ref against functions whose type is at `typeIndex`, which takes the function
+// reference on top of the parameters.
+func (f *funcTypeToIRSignatures) getRef(typeIndex wasm.Index) *signature {
+	if f.refCalls == nil {
+		f.refCalls = make([]*signature, len(f.wasmTypes))
+	} else if sig := f.refCalls[typeIndex]; sig != nil {
+		return sig
+	}
+
+	direct := f.get(typeIndex, false)
+	sig := &signature{in: make([]UnsignedType, 0, len(direct.in)+1), out: direct.out}
+	sig.in = append(append(sig.in, direct.in...), UnsignedTypeI64)
+	f.refCalls[typeIndex] = sig
+	return sig
+}
+
 func wasmValueTypeToUnsignedType(vt wasm.ValueType) UnsignedType {
 	switch vt {
 	case wasm.ValueTypeI32:
 		return UnsignedTypeI32
 	case wasm.ValueTypeI64,
 		// From wazeroir layer, ref type values are opaque 64-bit pointers.
-		wasm.ValueTypeExternref, wasm.ValueTypeFuncref:
+		wasm.ValueTypeExternref, wasm.ValueTypeFuncref, wasm.ValueTypeExnref:
 		return UnsignedTypeI64
 	case wasm.ValueTypeF32:
 		return UnsignedTypeF32
@@ -664,7 +775,7 @@ func wasmValueTypeToUnsignedOutSignature(vt wasm.ValueType) *signature {
 		return signature_None_I32
 	case wasm.ValueTypeI64,
 		// From wazeroir layer, ref type values are opaque 64-bit pointers.
-		wasm.ValueTypeExternref, wasm.ValueTypeFuncref:
+		wasm.ValueTypeExternref, wasm.ValueTypeFuncref, wasm.ValueTypeExnref:
 		return signature_None_I64
 	case wasm.ValueTypeF32:
 		return signature_None_F32
@@ -682,7 +793,7 @@ func wasmValueTypeToUnsignedInSignature(vt wasm.ValueType) *signature {
 		return signature_I32_None
 	case wasm.ValueTypeI64,
 		// From wazeroir layer, ref type values are opaque 64-bit pointers.
-		wasm.ValueTypeExternref, wasm.ValueTypeFuncref:
+		wasm.ValueTypeExternref, wasm.ValueTypeFuncref, wasm.ValueTypeExnref:
 		return signature_I64_None
 	case wasm.ValueTypeF32:
 		return signature_F32_None
@@ -700,7 +811,7 @@ func wasmValueTypeToUnsignedInOutSignature(vt wasm.ValueType) *signature {
 		return signature_I32_I32
 	case wasm.ValueTypeI64,
 		// From wazeroir layer, ref type values are opaque 64-bit pointers.
-		wasm.ValueTypeExternref, wasm.ValueTypeFuncref:
+		wasm.ValueTypeExternref, wasm.ValueTypeFuncref, wasm.ValueTypeExnref:
 		return signature_I64_I64
 	case wasm.ValueTypeF32:
 		return signature_F32_F32
@@ -711,3 +822,105 @@ func wasmValueTypeToUnsignedInOutSignature(vt wasm.ValueType) *signature {
 	}
 	panic("unreachable")
 }
+
+// memory64Signature returns the signature of op if it is a memory instruction
+// on a 64-bit memory, whose addresses, offsets and sizes are i64, or nil.
+func (c *Compiler) memory64Signature(op wasm.Opcode) *signature {
+	switch op {
+	case wasm.OpcodeI32Load, wasm.OpcodeI32Load8S, wasm.OpcodeI32Load8U, wasm.OpcodeI32Load16S, wasm.OpcodeI32Load16U:
+		return signature_I64_I32
+	case wasm.OpcodeI64Load, wasm.OpcodeI64Load8S, wasm.OpcodeI64Load8U, wasm.OpcodeI64Load16S, wasm.OpcodeI64Load16U,
+		wasm.OpcodeI64Load32S, wasm.OpcodeI64Load32U:
+		return signature_I64_I64
+	case wasm.OpcodeF32Load:
+		return signature_I64_F32
+	case wasm.OpcodeF64Load:
+		return signature_I64_F64
+	case wasm.OpcodeI32Store, wasm.OpcodeI32Store8, wasm.OpcodeI32Store16:
+		return signature_I64I32_None
+	case wasm.OpcodeI64Store, wasm.OpcodeI64Store8, wasm.OpcodeI64Store16, wasm.OpcodeI64Store32:
+		return signature_I64I64_None
+	case wasm.OpcodeF32Store:
+		return signature_I64F32_None
+	case wasm.OpcodeF64Store:
+		return signature_I64F64_None
+	case wasm.OpcodeMemorySize:
+		return signature_None_I64
+	case wasm.OpcodeMemoryGrow:
+		return signature_I64_I64
+	case wasm.OpcodeMiscPrefix:
+		switch c.body[c.pc+1] {
+		case wasm.OpcodeMiscMemoryInit:
+			return signature_I64I32I32_None
+		case wasm.OpcodeMiscMemoryCopy:
+			return signature_I64I64I64_None
+		case wasm.OpcodeMiscMemoryFill:
+			return signature_I64I32I64_None
+		}
+	case wasm.OpcodeVecPrefix:
+		switch c.body[c.pc+1] {
+		case wasm.OpcodeVecV128Load, wasm.OpcodeVecV128Load8x8s, wasm.OpcodeVecV128Load8x8u,
+			wasm.OpcodeVecV128Load16x4s, wasm.OpcodeVecV128Load16x4u, wasm.OpcodeVecV128Load32x2s,
+			wasm.OpcodeVecV128Load32x2u, wasm.OpcodeVecV128Load8Splat, wasm.OpcodeVecV128Load16Splat,
+			wasm.OpcodeVecV128Load32Splat, wasm.OpcodeVecV128Load64Splat, wasm.OpcodeVecV128Load32zero,
+			wasm.OpcodeVecV128Load64zero:
+			return signature_I64_V128
+		case wasm.OpcodeVecV128Load8Lane, wasm.OpcodeVecV128Load16Lane,
+			wasm.OpcodeVecV128Load32Lane, wasm.OpcodeVecV128Load64Lane:
+			return signature_I64V128_V128
+		case wasm.OpcodeVecV128Store, wasm.OpcodeVecV128Store8Lane, wasm.OpcodeVecV128Store16Lane,
+			wasm.OpcodeVecV128Store32Lane, wasm.OpcodeVecV128Store64Lane:
+			return signature_I64V128_None
+		}
+	}
+	return nil
+}
+
+// atomicSignature returns the signature of the atomic instruction `op`, whose addresses are i64 if the memory is
+// 64-bit.
+func (c *Compiler) atomicSignature(op wasm.OpcodeAtomic) *signature {
+	if op == wasm.OpcodeAtomicFence {
+		return signature_None_None
+	}
+
+	is64 := c.result.Memory64
+	switch {
+	case op == wasm.OpcodeAtomicMemoryNotify:
+		return choose(is64, signature_I64I32_I32, signature_I32I32_I32)
+	case op == wasm.OpcodeAtomicMemoryWait32:
+		return choose(is64, signature_I64I32I64_I32, signature_I32I32I64_I32)
+	case op == wasm.OpcodeAtomicMemoryWait64:
+		return choose(is64, signature_I64I64I64_I32, signature_I32I64I64_I32)
+	}
+
+	if t, _ := wasm.AtomicAccess(op); t == wasm.ValueTypeI32 {
+		switch {
+		case op <= wasm.OpcodeAtomicI64Load32U:
+			return choose(is64, signature_I64_I32, signature_I32_I32)
+		case op <= wasm.OpcodeAtomicI64Store32:
+			return choose(is64, signature_I64I32_None, signature_I32I32_None)
+		case op < wasm.OpcodeAtomicI32RmwCmpxchg:
+			return choose(is64, signature_I64I32_I32, signature_I32I32_I32)
+		default:
+			return choose(is64, signature_I64I32I32_I32, signature_I32I32I32_I32)
+		}
+	}
+	switch {
+	case op <= wasm.OpcodeAtomicI64Load32U:
+		return choose(is64, signature_I64_I64, signature_I32_I64)
+	case op <= wasm.OpcodeAtomicI64Store32:
+		return choose(is64, signature_I64I64_None, signature_I32I64_None)
+	case op < wasm.OpcodeAtomicI32RmwCmpxchg:
+		return choose(is64, signature_I64I64_I64, signature_I32I64_I64)
+	default:
+		return choose(is64, signature_I64I64I64_I64, signature_I32I64I64_I64)
+	}
+}
+
+// choose returns s64 if is64, or s32 otherwise.
+func choose(is64 bool, s64, s32 *signature) *signature {
+	if is64 {
+		return s64
+	}
+	return s32
+}
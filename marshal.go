@@ -0,0 +1,110 @@
+package wazero
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/internal/version"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// compiledModuleMagic is the header of the result of CompiledModule.Marshal.
+const compiledModuleMagic = "WAZEROCM"
+
+// Marshal implements CompiledModule.Marshal
+func (c *compiledModule) Marshal() ([]byte, error) {
+	serializer, ok := c.compiledEngine.(wasm.EngineSerializer)
+	if !ok {
+		return nil, errors.New("compiled modules can only be marshaled by the compiler")
+	} else if c.snapshot != nil {
+		return nil, errors.New("snapshots cannot be marshaled")
+	} else if c.module.IsHostModule || c.binary == nil {
+		return nil, errors.New("host modules cannot be marshaled")
+	}
+
+	compiled, err := serializer.SerializeCompiledModule(c.module)
+	if err != nil {
+		return nil, err
+	}
+	return marshalCompiledModule(&marshaledModule{
+		version:  version.GetWazeroVersion(),
+		goos:     c.goos,
+		goarch:   c.goarch,
+		moduleID: c.module.ID,
+		binary:   c.binary,
+		compiled: compiled,
+	}), nil
+}
+
+// marshaledModule is the decoded result of CompiledModule.Marshal.
+type marshaledModule struct {
+	// version is the wazero version which compiled the module.
+	version string
+	// goos and goarch are the target of the compiled code.
+	goos, goarch string
+	// moduleID is the wasm.Module ID, which depends on the function listeners
+	// and whether the compiled code ensures termination.
+	moduleID wasm.ModuleID
+	// binary is the source of the module.
+	binary []byte
+	// compiled is the result of wasm.EngineSerializer.
+	compiled []byte
+}
+
+// marshalCompiledModule encodes the module as the magic, followed by the
+// strings version, goos and goarch, each prefixed by a byte length, the
+// module ID, the binary prefixed by a little-endian uint32 length, and the
+// compiled code until the end.
+func marshalCompiledModule(m *marshaledModule) []byte {
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString(compiledModuleMagic)
+	for _, s := range []string{m.version, m.goos, m.goarch} {
+		buf.WriteByte(byte(len(s)))
+		buf.WriteString(s)
+	}
+	buf.Write(m.moduleID[:])
+	_ = binary.Write(buf, binary.LittleEndian, uint32(len(m.binary)))
+	buf.Write(m.binary)
+	buf.Write(m.compiled)
+	return buf.Bytes()
+}
+
+// unmarshalCompiledModule decodes the result of marshalCompiledModule, or
+// errs if it isn't valid for this version of wazero, `goos` and `goarch`.
+func unmarshalCompiledModule(data []byte, goos, goarch string) (*marshaledModule, error) {
+	if !bytes.HasPrefix(data, []byte(compiledModuleMagic)) {
+		return nil, errors.New("invalid compiled module: missing magic")
+	}
+	data = data[len(compiledModuleMagic):]
+
+	var strs [3]string
+	for i := range strs {
+		if len(data) == 0 || len(data) < 1+int(data[0]) {
+			return nil, errors.New("invalid compiled module: truncated header")
+		}
+		strs[i], data = string(data[1:1+data[0]]), data[1+data[0]:]
+	}
+	m := &marshaledModule{version: strs[0], goos: strs[1], goarch: strs[2]}
+
+	if v := version.GetWazeroVersion(); m.version != v {
+		return nil, fmt.Errorf("compiled module is from wazero %s, but this is %s", m.version, v)
+	} else if m.goos != goos || m.goarch != goarch {
+		return nil, fmt.Errorf("compiled module is for %s/%s, but this is %s/%s",
+			m.goos, m.goarch, goos, goarch)
+	}
+
+	if len(data) < len(m.moduleID)+4 {
+		return nil, errors.New("invalid compiled module: truncated header")
+	}
+	copy(m.moduleID[:], data)
+	data = data[len(m.moduleID):]
+	binaryLen := binary.LittleEndian.Uint32(data)
+	data = data[4:]
+	if uint64(len(data)) < uint64(binaryLen) {
+		return nil, errors.New("invalid compiled module: truncated binary")
+	}
+	m.binary, m.compiled = data[:binaryLen], data[binaryLen:]
+	return m, nil
+}
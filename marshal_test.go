@@ -0,0 +1,197 @@
+package wazero
+
+import (
+	goruntime "runtime"
+	"testing"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/platform"
+	"github.com/tetratelabs/wazero/internal/testing/binaryencoding"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/version"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// binaryAnswer exports a function "answer" returning 42.
+var binaryAnswer = binaryencoding.EncodeModule(&wasm.Module{
+	TypeSection:     []wasm.FunctionType{{Results: []api.ValueType{api.ValueTypeI32}, ResultNumInUint64: 1}},
+	FunctionSection: []wasm.Index{0},
+	CodeSection:     []wasm.Code{{Body: []byte{wasm.OpcodeI32Const, 42, wasm.OpcodeEnd}}},
+	ExportSection:   []wasm.Export{{Name: "answer", Type: wasm.ExternTypeFunc, Index: 0}},
+})
+
+func TestCompiledModule_Marshal(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+
+	r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigCompiler())
+	compiled, err := r.CompileModule(testCtx, binaryAnswer)
+	require.NoError(t, err)
+	data, err := compiled.Marshal()
+	require.NoError(t, err)
+	require.NoError(t, r.Close(testCtx))
+
+	// Load the module in a new runtime, which has a new engine.
+	r = NewRuntimeWithConfig(testCtx, NewRuntimeConfigCompiler())
+	defer r.Close(testCtx)
+
+	compiled, err = r.UnmarshalCompiledModule(testCtx, data)
+	require.NoError(t, err)
+	mod, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig())
+	require.NoError(t, err)
+	results, err := mod.ExportedFunction("answer").Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{42}, results)
+
+	t.Run("different WithCloseOnContextDone", func(t *testing.T) {
+		r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigCompiler().WithCloseOnContextDone(true))
+		defer r.Close(testCtx)
+
+		_, err := r.UnmarshalCompiledModule(testCtx, data)
+		require.EqualError(t, err, "compiled module doesn't match the function listeners, fuel costs or RuntimeConfig.WithCloseOnContextDone of this runtime")
+	})
+
+	t.Run("different fuel costs", func(t *testing.T) {
+		ctx := experimental.WithFuelCosts(testCtx, experimental.FuelCosts{Default: 1})
+		_, err := r.UnmarshalCompiledModule(ctx, data)
+		require.EqualError(t, err, "compiled module doesn't match the function listeners, fuel costs or RuntimeConfig.WithCloseOnContextDone of this runtime")
+	})
+
+	t.Run("interpreter", func(t *testing.T) {
+		r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigInterpreter())
+		defer r.Close(testCtx)
+
+		_, err := r.UnmarshalCompiledModule(testCtx, data)
+		require.EqualError(t, err, "compiled modules can only be unmarshaled by the compiler")
+	})
+}
+
+func TestCompiledModule_Marshal_Errors(t *testing.T) {
+	t.Run("interpreter", func(t *testing.T) {
+		r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigInterpreter())
+		defer r.Close(testCtx)
+
+		compiled, err := r.CompileModule(testCtx, binaryAnswer)
+		require.NoError(t, err)
+		_, err = compiled.Marshal()
+		require.EqualError(t, err, "compiled modules can only be marshaled by the compiler")
+	})
+
+	if !platform.CompilerSupported() {
+		return
+	}
+
+	t.Run("host module", func(t *testing.T) {
+		r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigCompiler())
+		defer r.Close(testCtx)
+
+		compiled, err := r.NewHostModuleBuilder("host").
+			NewFunctionBuilder().WithFunc(func() {}).Export("noop").
+			Compile(testCtx)
+		require.NoError(t, err)
+		_, err = compiled.Marshal()
+		require.EqualError(t, err, "host modules cannot be marshaled")
+	})
+}
+
+func TestRuntimeConfig_WithCompilationTarget(t *testing.T) {
+	if !platform.CompilerSupported() {
+		t.Skip()
+	}
+
+	// Compile for the other supported architecture.
+	goarch := "arm64"
+	if goruntime.GOARCH == "arm64" {
+		goarch = "amd64"
+	}
+	config := NewRuntimeConfigCompiler().WithCompilationTarget("linux", goarch)
+	r := NewRuntimeWithConfig(testCtx, config)
+	defer r.Close(testCtx)
+
+	compiled, err := r.CompileModule(testCtx, binaryAnswer)
+	require.NoError(t, err)
+	data, err := compiled.Marshal()
+	require.NoError(t, err)
+
+	_, err = r.InstantiateModule(testCtx, compiled, NewModuleConfig())
+	require.EqualError(t, err, "cannot instantiate modules compiled for linux/"+goarch+": use CompiledModule.Marshal instead")
+
+	t.Run("unmarshal for target", func(t *testing.T) {
+		r := NewRuntimeWithConfig(testCtx, config)
+		defer r.Close(testCtx)
+
+		_, err := r.UnmarshalCompiledModule(testCtx, data)
+		require.NoError(t, err)
+	})
+
+	t.Run("unmarshal for this platform", func(t *testing.T) {
+		r := NewRuntimeWithConfig(testCtx, NewRuntimeConfigCompiler())
+		defer r.Close(testCtx)
+
+		_, err := r.UnmarshalCompiledModule(testCtx, data)
+		require.EqualError(t, err, "compiled module is for linux/"+goarch+", but this is "+goruntime.GOOS+"/"+goruntime.GOARCH)
+	})
+}
+
+func TestUnmarshalCompiledModule(t *testing.T) {
+	valid := &marshaledModule{
+		version:  version.GetWazeroVersion(),
+		goos:     goruntime.GOOS,
+		goarch:   goruntime.GOARCH,
+		moduleID: wasm.ModuleID{1, 2, 3},
+		binary:   []byte("binary"),
+		compiled: []byte("compiled"),
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		m, err := unmarshalCompiledModule(marshalCompiledModule(valid), goruntime.GOOS, goruntime.GOARCH)
+		require.NoError(t, err)
+		require.Equal(t, valid, m)
+	})
+
+	tests := []struct {
+		name        string
+		data        []byte
+		expectedErr string
+	}{
+		{
+			name:        "missing magic",
+			data:        binaryAnswer,
+			expectedErr: "invalid compiled module: missing magic",
+		},
+		{
+			name:        "truncated header",
+			data:        marshalCompiledModule(valid)[:len(compiledModuleMagic)+1],
+			expectedErr: "invalid compiled module: truncated header",
+		},
+		{
+			name:        "truncated binary",
+			data:        marshalCompiledModule(valid)[:len(marshalCompiledModule(valid))-len(valid.compiled)-1],
+			expectedErr: "invalid compiled module: truncated binary",
+		},
+		{
+			name: "different version",
+			data: marshalCompiledModule(&marshaledModule{
+				version: "v0.0.1", goos: valid.goos, goarch: valid.goarch,
+			}),
+			expectedErr: "compiled module is from wazero v0.0.1, but this is " + valid.version,
+		},
+		{
+			name: "different target",
+			data: marshalCompiledModule(&marshaledModule{
+				version: valid.version, goos: "plan9", goarch: "mips",
+			}),
+			expectedErr: "compiled module is for plan9/mips, but this is " + goruntime.GOOS + "/" + goruntime.GOARCH,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := unmarshalCompiledModule(tc.data, goruntime.GOOS, goruntime.GOARCH)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
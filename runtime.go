@@ -2,16 +2,25 @@ package wazero
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	goruntime "runtime"
 	"sync/atomic"
 
 	"github.com/tetratelabs/wazero/api"
 	experimentalapi "github.com/tetratelabs/wazero/experimental"
 	internalclose "github.com/tetratelabs/wazero/internal/close"
+	"github.com/tetratelabs/wazero/internal/component"
+	"github.com/tetratelabs/wazero/internal/engine/compiler"
+	"github.com/tetratelabs/wazero/internal/fuel"
+	"github.com/tetratelabs/wazero/internal/memalloc"
+	"github.com/tetratelabs/wazero/internal/platform"
 	internalsock "github.com/tetratelabs/wazero/internal/sock"
 	internalsys "github.com/tetratelabs/wazero/internal/sys"
 	"github.com/tetratelabs/wazero/internal/wasm"
 	binaryformat "github.com/tetratelabs/wazero/internal/wasm/binary"
+	"github.com/tetratelabs/wazero/internal/wat"
 	"github.com/tetratelabs/wazero/sys"
 )
 
@@ -31,9 +40,9 @@ import (
 //     All implementations are in wazero.
 //   - Closing this closes any CompiledModule or Module it instantiated.
 type Runtime interface {
-	// Instantiate instantiates a module from the WebAssembly binary (%.wasm)
-	// with default configuration, which notably calls the "_start" function,
-	// if it exists.
+	// Instantiate instantiates a module from the WebAssembly binary (%.wasm),
+	// or text format (%.wat), with default configuration, which notably calls
+	// the "_start" function, if it exists.
 	//
 	// Here's an example:
 	//	ctx := context.Background()
@@ -87,6 +96,11 @@ type Runtime interface {
 	// CompileModule decodes the WebAssembly binary (%.wasm) or errs if invalid.
 	// Any pre-compilation done after decoding wasm is dependent on RuntimeConfig.
 	//
+	// The source can also be in the WebAssembly text format (%.wat), which is
+	// compiled to the binary format first, e.g. `(module (func (export "f")))`.
+	// It is detected by its leading parenthesis, after any whitespace or
+	// comments.
+	//
 	// There are two main reasons to use CompileModule instead of Instantiate:
 	//   - Improve performance when the same module is instantiated multiple times under different names
 	//   - Reduce the amount of errors that can occur during InstantiateModule.
@@ -99,6 +113,51 @@ type Runtime interface {
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#name-section%E2%91%A0
 	CompileModule(ctx context.Context, binary []byte) (CompiledModule, error)
 
+	// ValidateModule decodes and validates the WebAssembly binary (%.wasm)
+	// against the RuntimeConfig, notably its CoreFeatures and memory limit,
+	// or errs if it is malformed or invalid.
+	//
+	// Unlike CompileModule, this doesn't compile the module, so is cheaper
+	// when a binary only needs to be checked, e.g. when it is uploaded.
+	//
+	// Here's an example:
+	//	if err := r.ValidateModule(ctx, wasm); err != nil {
+	//		var verr *wazero.ValidationError
+	//		if errors.As(err, &verr) {
+	//			log.Printf("rejected at offset %d in section %q", verr.Offset, verr.Section)
+	//		}
+	//	}
+	//
+	// # Errors
+	//
+	// Invalid binaries err with a *ValidationError, which includes the
+	// position of the problem.
+	ValidateModule(ctx context.Context, binary []byte) error
+
+	// UnmarshalCompiledModule loads a module from the result of
+	// CompiledModule.Marshal, without compiling it again.
+	//
+	// Here's an example:
+	//	// At build time
+	//	compiled, _ := r.CompileModule(ctx, wasm)
+	//	data, _ := compiled.Marshal()
+	//
+	//	// At runtime
+	//	compiled, _ := r.UnmarshalCompiledModule(ctx, data)
+	//	mod, _ := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig())
+	//
+	// # Errors
+	//
+	// This returns an error if `data` is invalid, or was marshaled by a
+	// different version of wazero, for a different GOOS or GOARCH, or by a
+	// runtime with different function listeners or
+	// RuntimeConfig.WithCloseOnContextDone. This also returns an error for
+	// the interpreter, which doesn't support marshaling.
+	//
+	// Note: The module is decoded and validated again, as `data` includes the
+	// original binary.
+	UnmarshalCompiledModule(ctx context.Context, data []byte) (CompiledModule, error)
+
 	// InstantiateModule instantiates the module or errs for reasons including
 	// exit or validation.
 	//
@@ -120,6 +179,60 @@ type Runtime interface {
 	//     cancellation or deadline triggered before a start function returned.
 	InstantiateModule(ctx context.Context, compiled CompiledModule, config ModuleConfig) (api.Module, error)
 
+	// CloneModule instantiates the module of `template`, copying the state of
+	// its memory, globals and tables instead of initializing them. This is
+	// much faster than InstantiateModule for modules with an expensive
+	// initialization, such as "_initialize" in a WASI reactor, so allows an
+	// isolated instance per request.
+	//
+	// Here's an example:
+	//	template, _ := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().
+	//		WithName("").WithStartFunctions("_initialize"))
+	//
+	//	// Per request
+	//	mod, _ := r.CloneModule(ctx, template, wazero.NewModuleConfig().WithName(""))
+	//	defer mod.Close(ctx)
+	//
+	// # Notes
+	//
+	//   - `config` applies as for InstantiateModule, except start functions
+	//     are not called, as the state is already initialized.
+	//   - Imported memories, globals and tables are shared, not copied.
+	//   - `template` must not be executing, or the copy may be inconsistent.
+	//   - `template` must be a guest module instantiated by this runtime.
+	//   - See RuntimeConfig.WithMemoryMmap to share memory copy-on-write.
+	CloneModule(ctx context.Context, template api.Module, config ModuleConfig) (api.Module, error)
+
+	// SnapshotModule captures the state of the memory, globals and tables of
+	// `mod` into a CompiledModule. Modules instantiated from it start with
+	// that state, instead of initializing it. Unlike CloneModule, `mod` can be
+	// closed afterwards.
+	//
+	// This allows running an expensive initialization once, such as the
+	// startup of a language runtime compiled to Wasm, like Wizer does at
+	// build time. Here's an example:
+	//	mod, _ := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().
+	//		WithStartFunctions("wizer.initialize"))
+	//	snapshot, _ := r.SnapshotModule(ctx, mod)
+	//	mod.Close(ctx)
+	//
+	//	// Later, "_start" runs from the initialized state.
+	//	mod, _ = r.InstantiateModule(ctx, snapshot, wazero.NewModuleConfig())
+	//
+	// # Notes
+	//
+	//   - The start function of the module isn't called when instantiating the
+	//     snapshot, but ModuleConfig.WithStartFunctions are.
+	//   - The snapshot uses the compiled code of `mod`, so becomes invalid
+	//     when the CompiledModule `mod` was instantiated from is closed. Use
+	//     CompileModule, as Instantiate closes it when `mod` is closed.
+	//   - The snapshot can't be marshaled, and closing it has no effect.
+	//   - Imported memories, globals and tables are not captured.
+	//   - This errs if a table or global references a function of another
+	//     module, or `mod` is a host module or from another Runtime.
+	//   - `mod` must not be executing, or the snapshot may be inconsistent.
+	SnapshotModule(ctx context.Context, mod api.Module) (CompiledModule, error)
+
 	// CloseWithExitCode closes all the modules that have been initialized in this Runtime with the provided exit code.
 	// An error is returned if any module returns an error when closed.
 	//
@@ -150,7 +263,15 @@ func NewRuntimeWithConfig(ctx context.Context, rConfig RuntimeConfig) Runtime {
 	config := rConfig.(*runtimeConfig)
 	var engine wasm.Engine
 	var cacheImpl *cache
-	if c := config.cache; c != nil {
+	goos, goarch := goruntime.GOOS, goruntime.GOARCH
+	if config.targetGOARCH != "" {
+		// The cache is not used, as it is specific to this platform.
+		goos, goarch = config.targetGOOS, config.targetGOARCH
+		var err error
+		if engine, err = compiler.NewEngineForTarget(ctx, config.enabledFeatures, goarch); err != nil {
+			panic(err) // WithCompilationTarget already verified the target.
+		}
+	} else if c := config.cache; c != nil {
 		// If the Cache is configured, we share the engine.
 		cacheImpl = c.(*cache)
 		engine = cacheImpl.initEngine(config.engineKind, config.newEngine, ctx, config.enabledFeatures)
@@ -159,6 +280,9 @@ func NewRuntimeWithConfig(ctx context.Context, rConfig RuntimeConfig) Runtime {
 		engine = config.newEngine(ctx, config.enabledFeatures, nil)
 	}
 	store := wasm.NewStore(config.enabledFeatures, engine)
+	store.CallStackLimits = config.callStackLimits
+	store.MmapMemory = config.memoryMmap
+	store.MemoryAllocator, _ = ctx.Value(memalloc.AllocatorKey{}).(experimentalapi.MemoryAllocator)
 	return &runtime{
 		cache:                 cacheImpl,
 		store:                 store,
@@ -168,6 +292,8 @@ func NewRuntimeWithConfig(ctx context.Context, rConfig RuntimeConfig) Runtime {
 		dwarfDisabled:         config.dwarfDisabled,
 		storeCustomSections:   config.storeCustomSections,
 		ensureTermination:     config.ensureTermination,
+		goos:                  goos,
+		goarch:                goarch,
 	}
 }
 
@@ -190,6 +316,10 @@ type runtime struct {
 	closed atomic.Uint64
 
 	ensureTermination bool
+
+	// goos and goarch are the platform modules are compiled for, which is
+	// only runtime.GOOS and runtime.GOARCH if they can be instantiated.
+	goos, goarch string
 }
 
 // Module implements Runtime.Module.
@@ -206,38 +336,136 @@ func (r *runtime) CompileModule(ctx context.Context, binary []byte) (CompiledMod
 		return nil, err
 	}
 
+	if wat.IsText(binary) {
+		var err error
+		if binary, err = wat.Compile(binary); err != nil {
+			return nil, fmt.Errorf("invalid text format: %w", err)
+		}
+	}
+
+	c, listeners, err := r.decodeModule(ctx, binary)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.store.Engine.CompileModule(ctx, c.module, listeners, r.ensureTermination); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ValidateModule implements Runtime.ValidateModule
+func (r *runtime) ValidateModule(_ context.Context, binary []byte) error {
+	if err := r.failIfClosed(); err != nil {
+		return err
+	}
+
+	m, err := binaryformat.DecodeModule(binary, r.enabledFeatures,
+		r.memoryLimitPages, r.memoryCapacityFromMax, false, false)
+	if err == nil {
+		err = m.Validate(r.enabledFeatures)
+	}
+	if err == nil {
+		return nil
+	}
+
+	verr := &ValidationError{Err: err}
+	var serr *wasm.SectionError
+	if errors.As(err, &serr) {
+		verr.Section = wasm.SectionIDName(serr.ID)
+		verr.Offset = serr.Offset
+	}
+	return verr
+}
+
+// ValidationError is returned by Runtime.ValidateModule when a binary is
+// malformed or invalid.
+type ValidationError struct {
+	// Section is the name of the section the error is in, e.g. "code", or
+	// empty if it isn't in one, e.g. when the magic number is invalid.
+	Section string
+
+	// Offset is the offset in the binary where the error was detected, or of
+	// the contents of Section when it isn't known more precisely.
+	Offset uint64
+
+	// Err is the cause of the error.
+	Err error
+}
+
+// Error implements error.Error
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%v (at offset %#x)", e.Err, e.Offset)
+}
+
+// Unwrap returns the cause of the error.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// UnmarshalCompiledModule implements Runtime.UnmarshalCompiledModule
+func (r *runtime) UnmarshalCompiledModule(ctx context.Context, data []byte) (CompiledModule, error) {
+	if err := r.failIfClosed(); err != nil {
+		return nil, err
+	}
+
+	serializer, ok := r.store.Engine.(wasm.EngineSerializer)
+	if !ok {
+		return nil, errors.New("compiled modules can only be unmarshaled by the compiler")
+	}
+
+	m, err := unmarshalCompiledModule(data, r.goos, r.goarch)
+	if err != nil {
+		return nil, err
+	}
+
+	c, listeners, err := r.decodeModule(ctx, m.binary)
+	if err != nil {
+		return nil, err
+	} else if c.module.ID != m.moduleID {
+		return nil, errors.New("compiled module doesn't match the function listeners, fuel costs or RuntimeConfig.WithCloseOnContextDone of this runtime")
+	}
+	if err = serializer.DeserializeCompiledModule(c.module, m.compiled, listeners); err != nil {
+		return nil, fmt.Errorf("invalid compiled module: %w", err)
+	}
+	return c, nil
+}
+
+// decodeModule decodes and validates the binary, returning the module and
+// its function listeners, ready to be compiled.
+func (r *runtime) decodeModule(ctx context.Context, binary []byte) (*compiledModule, []experimentalapi.FunctionListener, error) {
 	internal, err := binaryformat.DecodeModule(binary, r.enabledFeatures,
 		r.memoryLimitPages, r.memoryCapacityFromMax, !r.dwarfDisabled, r.storeCustomSections)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	} else if err = internal.Validate(r.enabledFeatures); err != nil {
 		// TODO: decoders should validate before returning, as that allows
 		// them to err with the correct position in the wasm binary.
-		return nil, err
+		return nil, nil, err
 	}
 
+	// Fall back to the WIT metadata of the module for the names of params,
+	// which bindings generators often embed instead of a name section.
+	component.NameParams(internal)
+
 	// Now that the module is validated, cache the memory definitions.
 	// TODO: lazy initialization of memory definition.
 	internal.BuildMemoryDefinitions()
 
-	c := &compiledModule{module: internal, compiledEngine: r.store.Engine}
+	c := &compiledModule{module: internal, compiledEngine: r.store.Engine, binary: binary, goos: r.goos, goarch: r.goarch}
 
 	// typeIDs are static and compile-time known.
 	typeIDs, err := r.store.GetFunctionTypeIDs(internal.TypeSection)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	c.typeIDs = typeIDs
 
 	listeners, err := buildFunctionListeners(ctx, internal)
 	if err != nil {
-		return nil, err
-	}
-	internal.AssignModuleID(binary, listeners, r.ensureTermination)
-	if err = r.store.Engine.CompileModule(ctx, internal, listeners, r.ensureTermination); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return c, nil
+	internal.AssignModuleID(binary, listeners, r.ensureTermination, fuel.CostsFromContext(ctx))
+	return c, listeners, nil
 }
 
 func buildFunctionListeners(ctx context.Context, internal *wasm.Module) ([]experimentalapi.FunctionListener, error) {
@@ -291,41 +519,24 @@ func (r *runtime) InstantiateModule(
 	code := compiled.(*compiledModule)
 	config := mConfig.(*moduleConfig)
 
-	// Only add guest module configuration to guests.
-	if !code.module.IsHostModule {
-		if sockConfig, ok := ctx.Value(internalsock.ConfigKey{}).(*internalsock.Config); ok {
-			config.sockConfig = sockConfig
-		}
-	}
-
-	var sysCtx *internalsys.Context
-	if sysCtx, err = config.toSysContext(); err != nil {
-		return
-	}
-
-	name := config.name
-	if !config.nameSet && code.module.NameSection != nil && code.module.NameSection.ModuleName != "" {
-		name = code.module.NameSection.ModuleName
+	if r.goos != goruntime.GOOS || r.goarch != goruntime.GOARCH {
+		return nil, fmt.Errorf("cannot instantiate modules compiled for %s/%s: use CompiledModule.Marshal instead", r.goos, r.goarch)
 	}
 
-	// Instantiate the module.
-	mod, err = r.store.Instantiate(ctx, code.module, name, sysCtx, code.typeIDs)
-	if err != nil {
+	var m *wasm.ModuleInstance
+	if m, config, err = r.newModuleInstance(ctx, code.module, code.typeIDs, config, nil, code.snapshot); err != nil {
 		// If there was an error, don't leak the compiled module.
 		if code.closeWithModule {
 			_ = code.Close(ctx) // don't overwrite the error
 		}
 		return
 	}
-
-	if closeNotifier, ok := ctx.Value(internalclose.NotifierKey{}).(internalclose.Notifier); ok {
-		mod.(*wasm.ModuleInstance).CloseNotifier = closeNotifier
-	}
+	mod = m
 
 	// Attach the code closer so that anything afterward closes the compiled
 	// code when closing the module.
 	if code.closeWithModule {
-		mod.(*wasm.ModuleInstance).CodeCloser = code
+		m.CodeCloser = code
 	}
 
 	// Now, invoke any start functions, failing at first error.
@@ -335,21 +546,163 @@ func (r *runtime) InstantiateModule(
 			continue
 		}
 		if _, err = start.Call(ctx); err != nil {
+			se, ok := err.(*sys.ExitError)
+			if ok && se.ExitCode() == 0 && config.procExitConfig != nil && config.procExitConfig.KeepOpen {
+				err = nil
+				return // Keep the module open, so that it can be reused.
+			}
+
 			_ = mod.Close(ctx) // Don't leak the module on error.
 
-			if se, ok := err.(*sys.ExitError); ok {
+			if ok {
 				if se.ExitCode() == 0 { // Don't err on success.
 					err = nil
 				}
 				return // Don't wrap an exit error
 			}
-			err = fmt.Errorf("module[%s] function[%s] failed: %w", name, fn, err)
+			err = fmt.Errorf("module[%s] function[%s] failed: %w", m.Name(), fn, err)
 			return
 		}
 	}
 	return
 }
 
+// CloneModule implements Runtime.CloneModule
+func (r *runtime) CloneModule(ctx context.Context, template api.Module, mConfig ModuleConfig) (api.Module, error) {
+	if err := r.failIfClosed(); err != nil {
+		return nil, err
+	}
+
+	m, ok := template.(*wasm.ModuleInstance)
+	if !ok {
+		return nil, fmt.Errorf("unsupported template %T", template)
+	}
+	mod, _, err := r.newModuleInstance(ctx, m.Source, m.TypeIDs, mConfig.(*moduleConfig), m, nil)
+	if err != nil {
+		return nil, err
+	}
+	return mod, nil
+}
+
+// SnapshotModule implements Runtime.SnapshotModule
+func (r *runtime) SnapshotModule(ctx context.Context, mod api.Module) (CompiledModule, error) {
+	if err := r.failIfClosed(); err != nil {
+		return nil, err
+	}
+
+	m, ok := mod.(*wasm.ModuleInstance)
+	if !ok {
+		return nil, fmt.Errorf("unsupported module %T", mod)
+	}
+	snapshot, err := r.store.Snapshot(m)
+	if err != nil {
+		return nil, err
+	}
+	return &compiledModule{
+		module:         m.Source,
+		compiledEngine: r.store.Engine,
+		typeIDs:        m.TypeIDs,
+		goos:           r.goos,
+		goarch:         r.goarch,
+		snapshot:       snapshot,
+	}, nil
+}
+
+// newModuleInstance instantiates the module, or clones `template` or restores
+// `snapshot` if non-nil, with the configuration from `config` and `ctx`, which
+// is returned.
+func (r *runtime) newModuleInstance(
+	ctx context.Context,
+	module *wasm.Module,
+	typeIDs []wasm.FunctionTypeID,
+	config *moduleConfig,
+	template *wasm.ModuleInstance,
+	snapshot *wasm.Snapshot,
+) (m *wasm.ModuleInstance, _ *moduleConfig, err error) {
+	name := config.name
+	if !config.nameSet && module.NameSection != nil && module.NameSection.ModuleName != "" {
+		name = module.NameSection.ModuleName
+	}
+
+	// Only add guest module configuration to guests.
+	if !module.IsHostModule {
+		// Clone, as the config may be reused for other modules.
+		config = config.clone()
+		if seed, ok := ctx.Value(internalsys.RandSeedKey{}).(int64); ok && config.randSource == nil {
+			config.randSource = platform.NewSeededRandSource(seed, name)
+		}
+		if sockConfig, ok := ctx.Value(internalsock.ConfigKey{}).(*internalsock.Config); ok {
+			config.sockConfig = sockConfig
+		}
+		if stdioConfig, ok := ctx.Value(internalsys.StdioConfigKey{}).(*internalsys.StdioConfig); ok {
+			config.stdioConfig = stdioConfig
+		}
+		if procExitConfig, ok := ctx.Value(internalsys.ProcExitConfigKey{}).(*internalsys.ProcExitConfig); ok {
+			config.procExitConfig = procExitConfig
+		}
+		if rightsConfig, ok := ctx.Value(internalsys.RightsConfigKey{}).(internalsys.RightsConfig); ok {
+			config.rightsConfig = rightsConfig
+		}
+		if hostState, ok := ctx.Value(internalsys.HostStateKey{}).(*internalsys.HostState); ok {
+			config.hostState = hostState
+		}
+	}
+
+	sysCtx, err := config.toSysContext()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Put a terminal stdin into raw mode before any start function reads it.
+	var restoreStdin func() error
+	if raw, _ := ctx.Value(internalsys.RawStdinKey{}).(bool); raw && !module.IsHostModule {
+		if f, ok := config.stdin.(*os.File); ok {
+			if restoreStdin, err = platform.MakeRaw(f.Fd()); err != nil && !platform.IsNotTerminal(err) {
+				return nil, nil, fmt.Errorf("error making stdin raw: %w", err)
+			}
+		}
+	}
+
+	switch {
+	case template != nil:
+		m, err = r.store.CloneModule(ctx, template, name, sysCtx)
+	case snapshot != nil:
+		m, err = r.store.InstantiateSnapshot(ctx, module, snapshot, name, sysCtx, typeIDs)
+	default:
+		m, err = r.store.Instantiate(ctx, module, name, sysCtx, typeIDs)
+	}
+	if err != nil {
+		if restoreStdin != nil {
+			_ = restoreStdin() // don't overwrite the error
+		}
+		return nil, nil, err
+	}
+
+	closeNotifier, _ := ctx.Value(internalclose.NotifierKey{}).(internalclose.Notifier)
+	if restoreStdin != nil {
+		closeNotifier = &restoreStdinNotifier{restore: restoreStdin, next: closeNotifier}
+	}
+	if closeNotifier != nil {
+		m.CloseNotifier = closeNotifier
+	}
+	return m, config, nil
+}
+
+// restoreStdinNotifier restores the mode of a terminal stdin made raw by
+// WithRawStdin, after notifying any CloseNotifier of the context.
+type restoreStdinNotifier struct {
+	restore func() error
+	next    internalclose.Notifier
+}
+
+// CloseNotify implements internalclose.Notifier.
+func (n *restoreStdinNotifier) CloseNotify(ctx context.Context, exitCode uint32) {
+	if n.next != nil {
+		n.next.CloseNotify(ctx, exitCode)
+	}
+	_ = n.restore() // the module is closed regardless
+}
+
 // Close implements api.Closer embedded in Runtime.
 func (r *runtime) Close(ctx context.Context) error {
 	return r.CloseWithExitCode(ctx, 0)
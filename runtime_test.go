@@ -4,6 +4,8 @@ import (
 	"context"
 	_ "embed"
 	"errors"
+	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -122,6 +124,67 @@ func TestRuntime_CompileModule(t *testing.T) {
 				require.True(t, ok)
 			},
 		},
+		{
+			name: "Imports and Exports",
+			wasm: &wasm.Module{
+				TypeSection: []wasm.FunctionType{{Params: []api.ValueType{api.ValueTypeI32}}},
+				ImportSection: []wasm.Import{
+					{Module: "env", Name: "f", Type: wasm.ExternTypeFunc, DescFunc: 0},
+					{Module: "env", Name: "t", Type: wasm.ExternTypeTable, DescTable: wasm.Table{Min: 1, Type: wasm.RefTypeFuncref}},
+					{Module: "env", Name: "g", Type: wasm.ExternTypeGlobal, DescGlobal: wasm.GlobalType{ValType: api.ValueTypeI64, Mutable: true}},
+				},
+				GlobalSection: []wasm.Global{{
+					Type: wasm.GlobalType{ValType: api.ValueTypeF32},
+					Init: wasm.ConstantExpression{Opcode: wasm.OpcodeF32Const, Data: []byte{0, 0, 0, 0}},
+				}},
+				ExportSection: []wasm.Export{
+					{Type: wasm.ExternTypeGlobal, Name: "pi", Index: 1},
+					{Type: wasm.ExternTypeTable, Name: "table", Index: 0},
+				},
+			},
+			expected: func(compiled CompiledModule) {
+				imports := compiled.Imports()
+				require.Equal(t, 3, len(imports))
+
+				f := imports[0]
+				require.Equal(t, api.ExternTypeFunc, f.Type())
+				require.Equal(t, "env", f.ModuleName())
+				require.Equal(t, "f", f.Name())
+				require.Equal(t, []api.ValueType{api.ValueTypeI32}, f.Definition().(api.FunctionDefinition).ParamTypes())
+
+				table := imports[1].Definition().(api.TableDefinition)
+				require.Equal(t, api.ExternTypeTable, imports[1].Type())
+				require.Equal(t, wasm.RefTypeFuncref, table.Type())
+				require.Equal(t, uint32(1), table.Min())
+				_, ok := table.Max()
+				require.False(t, ok)
+				require.Equal(t, []string{"table"}, table.ExportNames())
+
+				g := imports[2].Definition().(api.GlobalDefinition)
+				require.Equal(t, api.ValueTypeI64, g.Type())
+				require.True(t, g.Mutable())
+				moduleName, name, isImport := g.Import()
+				require.Equal(t, "env", moduleName)
+				require.Equal(t, "g", name)
+				require.True(t, isImport)
+
+				exports := compiled.Exports()
+				require.Equal(t, 2, len(exports))
+
+				pi := exports[0]
+				require.Equal(t, api.ExternTypeGlobal, pi.Type())
+				require.Equal(t, "pi", pi.Name())
+				g = pi.Definition().(api.GlobalDefinition)
+				require.Equal(t, uint32(1), g.Index())
+				require.Equal(t, api.ValueTypeF32, g.Type())
+				require.False(t, g.Mutable())
+				_, _, isImport = g.Import()
+				require.False(t, isImport)
+
+				require.Equal(t, "table", exports[1].Name())
+				require.Equal(t, table, exports[1].Definition())
+			},
+		},
 	}
 
 	_r := NewRuntime(testCtx)
@@ -151,6 +214,27 @@ func TestRuntime_CompileModule(t *testing.T) {
 	}
 }
 
+func TestRuntime_CompileModule_Text(t *testing.T) {
+	r := NewRuntime(testCtx)
+	defer r.Close(testCtx)
+
+	compiled, err := r.CompileModule(testCtx, []byte(`;; adds two numbers
+(module $math
+  (func $add (export "add") (param $x i32) (param $y i32) (result i32)
+    (i32.add (local.get $x) (local.get $y))))`))
+	require.NoError(t, err)
+	require.Equal(t, "math", compiled.Name())
+	add := compiled.ExportedFunctions()["add"]
+	require.Equal(t, "add", add.Name())
+	require.Equal(t, []string{"x", "y"}, add.ParamNames())
+
+	mod, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig())
+	require.NoError(t, err)
+	results, err := mod.ExportedFunction("add").Call(testCtx, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{3}, results)
+}
+
 func TestRuntime_CompileModule_Errors(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -171,6 +255,16 @@ func TestRuntime_CompileModule_Errors(t *testing.T) {
 			wasm:        binaryencoding.EncodeModule(&wasm.Module{MemorySection: &wasm.Memory{Min: 2, Cap: 2, Max: 70000, IsMaxEncoded: true}}),
 			expectedErr: "section memory: max 70000 pages (4 Gi) over limit of 65536 pages (4 Gi)",
 		},
+		{
+			name:        "malformed text",
+			wasm:        []byte("(module\n  (func i32.ad))"),
+			expectedErr: `invalid text format: 2:9: unknown operator "i32.ad"`,
+		},
+		{
+			name:        "invalid text",
+			wasm:        []byte(`(func (result i32))`),
+			expectedErr: "invalid function[0]: not enough results\n\thave ()\n\twant (i32)",
+		},
 	}
 
 	r := NewRuntime(testCtx)
@@ -186,6 +280,100 @@ func TestRuntime_CompileModule_Errors(t *testing.T) {
 	}
 }
 
+func TestRuntime_ValidateModule(t *testing.T) {
+	i32Result := []wasm.FunctionType{{Results: []wasm.ValueType{wasm.ValueTypeI32}, ResultNumInUint64: 1}}
+
+	tests := []struct {
+		name            string
+		features        api.CoreFeatures
+		wasm            []byte
+		expectedSection string
+		expectedOffset  uint64
+		expectedErr     string
+	}{
+		{
+			name:     "valid",
+			features: api.CoreFeaturesV2,
+			wasm:     binaryNamedZero,
+		},
+		{
+			name:        "invalid magic number",
+			features:    api.CoreFeaturesV2,
+			wasm:        []byte("pooh"),
+			expectedErr: "invalid magic number (at offset 0x0)",
+		},
+		{
+			name:            "malformed section",
+			features:        api.CoreFeaturesV2,
+			wasm:            binaryencoding.EncodeModule(&wasm.Module{MemorySection: &wasm.Memory{Min: 2, Cap: 2, Max: 70000, IsMaxEncoded: true}}),
+			expectedSection: "memory",
+			expectedOffset:  16,
+			expectedErr:     "section memory: max 70000 pages (4 Gi) over limit of 65536 pages (4 Gi) (at offset 0x10)",
+		},
+		{
+			name:     "invalid function body",
+			features: api.CoreFeaturesV2,
+			wasm: binaryencoding.EncodeModule(&wasm.Module{
+				TypeSection:     i32Result,
+				FunctionSection: []wasm.Index{0},
+				CodeSection:     []wasm.Code{{Body: []byte{wasm.OpcodeNop, wasm.OpcodeI64Const, 0, wasm.OpcodeEnd}}},
+			}),
+			expectedSection: "code",
+			expectedOffset:  27,
+			expectedErr:     "invalid function[0]: cannot use i64 as result[0] type i32 (at offset 0x1b)",
+		},
+		{
+			name:     "disabled feature",
+			features: api.CoreFeaturesV1,
+			wasm: binaryencoding.EncodeModule(&wasm.Module{
+				TypeSection:     i32Result,
+				FunctionSection: []wasm.Index{0},
+				CodeSection: []wasm.Code{{Body: []byte{
+					wasm.OpcodeI32Const, 0, wasm.OpcodeI32Extend8S, wasm.OpcodeEnd,
+				}}},
+			}),
+			expectedSection: "code",
+			expectedOffset:  26,
+			expectedErr:     `invalid function[0]: i32.extend8_s invalid as feature "sign-extension-ops" is disabled (at offset 0x1a)`,
+		},
+		{
+			name:     "invalid export",
+			features: api.CoreFeaturesV2,
+			wasm: binaryencoding.EncodeModule(&wasm.Module{
+				ExportSection: []wasm.Export{{Name: "f", Type: wasm.ExternTypeFunc, Index: 1}},
+			}),
+			expectedSection: "export",
+			expectedOffset:  10,
+			expectedErr:     `unknown function for export["f"] (at offset 0xa)`,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRuntimeWithConfig(testCtx, NewRuntimeConfig().WithCoreFeatures(tc.features))
+			defer r.Close(testCtx)
+
+			err := r.ValidateModule(testCtx, tc.wasm)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.expectedErr)
+
+			var verr *ValidationError
+			require.True(t, errors.As(err, &verr))
+			require.Equal(t, tc.expectedSection, verr.Section)
+			require.Equal(t, tc.expectedOffset, verr.Offset)
+
+			// The module is rejected the same way when compiled.
+			_, compileErr := r.CompileModule(testCtx, tc.wasm)
+			require.EqualError(t, compileErr, verr.Err.Error())
+		})
+	}
+}
+
 // TestModule_Memory only covers a couple cases to avoid duplication of internal/wasm/runtime_test.go
 func TestModule_Memory(t *testing.T) {
 	tests := []struct {
@@ -467,6 +655,77 @@ func TestRuntime_InstantiateModule_WithName(t *testing.T) {
 	require.Nil(t, ret)
 }
 
+func TestRuntime_InstantiateModule_WithRandSeed(t *testing.T) {
+	ctx := experimental.WithRandSeed(testCtx, 7)
+
+	// readRand instantiates a module named `name` and returns bytes read
+	// from its random source.
+	readRand := func(r Runtime, config ModuleConfig, name string) []byte {
+		compiled, err := r.CompileModule(ctx, binaryNamedZero)
+		require.NoError(t, err)
+		mod, err := r.InstantiateModule(ctx, compiled, config.WithName(name))
+		require.NoError(t, err)
+
+		buf := make([]byte, 8)
+		_, err = mod.(*wasm.ModuleInstance).Sys.RandSource().Read(buf)
+		require.NoError(t, err)
+		return buf
+	}
+
+	r1 := NewRuntime(ctx)
+	defer r1.Close(ctx)
+	config := NewModuleConfig()
+	a, b := readRand(r1, config, "a"), readRand(r1, config, "b")
+	require.NotEqual(t, a, b)
+
+	// The same module name reads the same values, regardless of other modules.
+	r2 := NewRuntime(ctx)
+	defer r2.Close(ctx)
+	require.Equal(t, b, readRand(r2, config, "b"))
+
+	// WithRandSource takes precedence.
+	require.Equal(t, []byte("abcdefgh"), readRand(r2, config.WithRandSource(strings.NewReader("abcdefgh")), "c"))
+}
+
+func TestRuntime_InstantiateModule_WithRawStdin(t *testing.T) {
+	var notified bool
+	ctx := experimental.WithRawStdin(testCtx)
+	ctx = experimental.WithCloseNotifier(ctx, experimental.CloseNotifyFunc(func(context.Context, uint32) {
+		notified = true
+	}))
+
+	r := NewRuntime(ctx)
+	defer r.Close(ctx)
+
+	t.Run("not a terminal", func(t *testing.T) {
+		stdin, w, err := os.Pipe()
+		require.NoError(t, err)
+		defer stdin.Close()
+		defer w.Close()
+
+		// Stdin is left as is, without an error.
+		mod, err := r.InstantiateWithConfig(ctx, binaryNamedZero, NewModuleConfig().WithStdin(stdin))
+		require.NoError(t, err)
+		require.NoError(t, mod.Close(ctx))
+		require.True(t, notified)
+	})
+
+	t.Run("restores after notifying", func(t *testing.T) {
+		var calls []string
+		n := &restoreStdinNotifier{
+			restore: func() error {
+				calls = append(calls, "restore")
+				return nil
+			},
+			next: experimental.CloseNotifyFunc(func(context.Context, uint32) {
+				calls = append(calls, "notify")
+			}),
+		}
+		n.CloseNotify(ctx, 0)
+		require.Equal(t, []string{"notify", "restore"}, calls)
+	})
+}
+
 func TestRuntime_InstantiateModule_ExitError(t *testing.T) {
 	r := NewRuntime(testCtx)
 	defer r.Close(testCtx)
@@ -546,6 +805,232 @@ func TestRuntime_InstantiateModule_ExitError(t *testing.T) {
 	}
 }
 
+// binaryCounterStart is the start function of binaryCounter.
+var binaryCounterStart = wasm.Index(0)
+
+// binaryCounter has a start function which increments a global, and exports
+// "inc", which increments it and returns it, and "inc_indirect", which calls
+// "inc" via a table.
+var binaryCounter = binaryencoding.EncodeModule(&wasm.Module{
+	TypeSection:     []wasm.FunctionType{{Results: []api.ValueType{api.ValueTypeI32}, ResultNumInUint64: 1}, {}},
+	FunctionSection: []wasm.Index{1, 0, 0},
+	GlobalSection: []wasm.Global{{
+		Type: wasm.GlobalType{ValType: api.ValueTypeI32, Mutable: true},
+		Init: wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+	}},
+	MemorySection: &wasm.Memory{Min: 1, Max: 3, IsMaxEncoded: true},
+	TableSection:  []wasm.Table{{Min: 1, Type: wasm.RefTypeFuncref}},
+	ElementSection: []wasm.ElementSegment{{
+		OffsetExpr: wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+		Init:       []wasm.Index{1},
+		Type:       wasm.RefTypeFuncref,
+	}},
+	StartSection: &binaryCounterStart,
+	CodeSection: []wasm.Code{
+		// The start function, which increments the global.
+		{Body: []byte{
+			wasm.OpcodeGlobalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add, wasm.OpcodeGlobalSet, 0,
+			wasm.OpcodeEnd,
+		}},
+		// "inc" increments the global and returns it.
+		{Body: []byte{
+			wasm.OpcodeGlobalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add, wasm.OpcodeGlobalSet, 0,
+			wasm.OpcodeGlobalGet, 0, wasm.OpcodeEnd,
+		}},
+		// "inc_indirect" calls "inc" via the table.
+		{Body: []byte{wasm.OpcodeI32Const, 0, wasm.OpcodeCallIndirect, 0, 0, wasm.OpcodeEnd}},
+	},
+	ExportSection: []wasm.Export{
+		{Name: "inc", Type: wasm.ExternTypeFunc, Index: 1},
+		{Name: "inc_indirect", Type: wasm.ExternTypeFunc, Index: 2},
+	},
+})
+
+func TestRuntime_TrapError(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config RuntimeConfig
+	}{
+		{name: "default", config: NewRuntimeConfig()},
+		{name: "interpreter", config: NewRuntimeConfigInterpreter()},
+	} {
+		config := tc.config
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRuntimeWithConfig(testCtx, config)
+			defer r.Close(testCtx)
+
+			_, err := r.NewHostModuleBuilder("env").
+				NewFunctionBuilder().WithFunc(func(percent uint32) {
+				if percent > 100 {
+					panic(sys.NewTrapError(7, percent))
+				}
+			}).Export("check").
+				Instantiate(testCtx)
+			require.NoError(t, err)
+
+			mod, err := r.Instantiate(testCtx, []byte(`(module
+  (import "env" "check" (func $check (param i32)))
+  (func (export "check") (param i32) (call $check (local.get 0))))`))
+			require.NoError(t, err)
+			check := mod.ExportedFunction("check")
+
+			_, err = check.Call(testCtx, 101)
+			trapErr, ok := err.(*sys.TrapError)
+			require.True(t, ok)
+			require.Equal(t, uint32(7), trapErr.Code())
+			require.Equal(t, uint32(101), trapErr.Payload())
+
+			// The module isn't closed by the trap.
+			_, err = check.Call(testCtx, 100)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRuntime_CloneModule(t *testing.T) {
+	configs := map[string]RuntimeConfig{"interpreter": NewRuntimeConfigInterpreter()}
+	if platform.CompilerSupported() {
+		configs["compiler"] = NewRuntimeConfigCompiler()
+	}
+	for n, c := range configs {
+		config := c
+		t.Run(n, func(t *testing.T) {
+			r := NewRuntimeWithConfig(testCtx, config)
+			defer r.Close(testCtx)
+
+			call := func(mod api.Module, name string) uint64 {
+				results, err := mod.ExportedFunction(name).Call(testCtx)
+				require.NoError(t, err)
+				return results[0]
+			}
+
+			template, err := r.Instantiate(testCtx, binaryCounter)
+			require.NoError(t, err)
+			require.Equal(t, uint64(2), call(template, "inc"))
+			_, ok := template.Memory().Grow(1)
+			require.True(t, ok)
+			require.True(t, template.Memory().WriteByte(70000, 7))
+
+			clone, err := r.CloneModule(testCtx, template, NewModuleConfig())
+			require.NoError(t, err)
+
+			// The start function wasn't called, and the table calls the clone.
+			require.Equal(t, uint64(3), call(clone, "inc_indirect"))
+			require.Equal(t, uint64(4), call(clone, "inc"))
+			require.Equal(t, uint64(3), call(template, "inc"))
+
+			// The memory is copied, not shared.
+			require.Equal(t, uint32(2), clone.Memory().Size()/65536)
+			b, _ := clone.Memory().ReadByte(70000)
+			require.Equal(t, byte(7), b)
+			require.True(t, clone.Memory().WriteByte(70000, 8))
+			b, _ = template.Memory().ReadByte(70000)
+			require.Equal(t, byte(7), b)
+		})
+	}
+
+	t.Run("host module", func(t *testing.T) {
+		r := NewRuntime(testCtx)
+		defer r.Close(testCtx)
+
+		host, err := r.NewHostModuleBuilder("host").Instantiate(testCtx)
+		require.NoError(t, err)
+		_, err = r.CloneModule(testCtx, host, NewModuleConfig().WithName("clone"))
+		require.EqualError(t, err, "cannot clone a host module")
+	})
+
+	t.Run("another runtime", func(t *testing.T) {
+		r1, r2 := NewRuntime(testCtx), NewRuntime(testCtx)
+		defer r1.Close(testCtx)
+		defer r2.Close(testCtx)
+
+		template, err := r1.Instantiate(testCtx, binaryCounter)
+		require.NoError(t, err)
+		_, err = r2.CloneModule(testCtx, template, NewModuleConfig())
+		require.EqualError(t, err, "cannot clone a module from another runtime")
+	})
+}
+
+func TestRuntime_SnapshotModule(t *testing.T) {
+	configs := map[string]RuntimeConfig{"interpreter": NewRuntimeConfigInterpreter()}
+	if platform.CompilerSupported() {
+		configs["compiler"] = NewRuntimeConfigCompiler()
+	}
+	for n, c := range configs {
+		config := c
+		t.Run(n, func(t *testing.T) {
+			r := NewRuntimeWithConfig(testCtx, config)
+			defer r.Close(testCtx)
+
+			call := func(mod api.Module, name string) uint64 {
+				results, err := mod.ExportedFunction(name).Call(testCtx)
+				require.NoError(t, err)
+				return results[0]
+			}
+
+			compiled, err := r.CompileModule(testCtx, binaryCounter)
+			require.NoError(t, err)
+			mod, err := r.InstantiateModule(testCtx, compiled, NewModuleConfig())
+			require.NoError(t, err)
+			require.Equal(t, uint64(2), call(mod, "inc"))
+			_, ok := mod.Memory().Grow(1)
+			require.True(t, ok)
+			require.True(t, mod.Memory().WriteByte(70000, 7))
+
+			snapshot, err := r.SnapshotModule(testCtx, mod)
+			require.NoError(t, err)
+			// The snapshot doesn't depend on the instance.
+			require.NoError(t, mod.Close(testCtx))
+
+			for i := 0; i < 2; i++ {
+				restored, err := r.InstantiateModule(testCtx, snapshot, NewModuleConfig().WithName(""))
+				require.NoError(t, err)
+
+				// The start function wasn't called, and the table calls the
+				// restored instance.
+				require.Equal(t, uint64(3), call(restored, "inc_indirect"))
+				require.Equal(t, uint64(4), call(restored, "inc"))
+
+				require.Equal(t, uint32(2), restored.Memory().Size()/65536)
+				b, _ := restored.Memory().ReadByte(70000)
+				require.Equal(t, byte(7), b)
+				require.True(t, restored.Memory().WriteByte(70000, 8))
+			}
+
+			// Closing the snapshot has no effect.
+			require.NoError(t, snapshot.Close(testCtx))
+			_, err = r.InstantiateModule(testCtx, snapshot, NewModuleConfig().WithName(""))
+			require.NoError(t, err)
+
+			if n == "compiler" {
+				_, err = snapshot.Marshal()
+				require.EqualError(t, err, "snapshots cannot be marshaled")
+			}
+		})
+	}
+
+	t.Run("host module", func(t *testing.T) {
+		r := NewRuntime(testCtx)
+		defer r.Close(testCtx)
+
+		host, err := r.NewHostModuleBuilder("host").Instantiate(testCtx)
+		require.NoError(t, err)
+		_, err = r.SnapshotModule(testCtx, host)
+		require.EqualError(t, err, "cannot snapshot a host module")
+	})
+
+	t.Run("another runtime", func(t *testing.T) {
+		r1, r2 := NewRuntime(testCtx), NewRuntime(testCtx)
+		defer r1.Close(testCtx)
+		defer r2.Close(testCtx)
+
+		mod, err := r1.Instantiate(testCtx, binaryCounter)
+		require.NoError(t, err)
+		_, err = r2.SnapshotModule(testCtx, mod)
+		require.EqualError(t, err, "cannot snapshot a module from another runtime")
+	})
+}
+
 func TestRuntime_CloseWithExitCode(t *testing.T) {
 	bin := binaryencoding.EncodeModule(&wasm.Module{
 		TypeSection:     []wasm.FunctionType{{}},
@@ -831,3 +1316,58 @@ func TestNewRuntime_concurrent(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestRuntime_MemoryMmap(t *testing.T) {
+	configs := map[string]RuntimeConfig{"interpreter": NewRuntimeConfigInterpreter()}
+	if platform.CompilerSupported() {
+		configs["compiler"] = NewRuntimeConfigCompiler()
+	}
+	for name, config := range configs {
+		config := config.WithMemoryMmap(true)
+		t.Run(name, func(t *testing.T) {
+			r := NewRuntimeWithConfig(testCtx, config)
+			defer r.Close(testCtx)
+
+			mod, err := r.Instantiate(testCtx, []byte(`(module
+  (memory (export "memory") 1)
+  (func (export "grow") (param i32) (result i32) (memory.grow (local.get 0)))
+  (func (export "store") (param i32 i32) (i32.store (local.get 0) (local.get 1)))
+  (func (export "load") (param i32) (result i32) (i32.load (local.get 0))))`))
+			require.NoError(t, err)
+
+			_, err = mod.ExportedFunction("store").Call(testCtx, 8, 42)
+			require.NoError(t, err)
+			results, err := mod.ExportedFunction("grow").Call(testCtx, 100)
+			require.NoError(t, err)
+			require.Equal(t, uint64(1), results[0])
+
+			results, err = mod.ExportedFunction("load").Call(testCtx, 8)
+			require.NoError(t, err)
+			require.Equal(t, uint64(42), results[0])
+
+			_, err = mod.ExportedFunction("store").Call(testCtx, 101*65536, 42)
+			require.Error(t, err)
+
+			// Clones share pages copy-on-write, so writes stay private.
+			clone, err := r.CloneModule(testCtx, mod, NewModuleConfig().WithName(""))
+			require.NoError(t, err)
+			results, err = clone.ExportedFunction("load").Call(testCtx, 8)
+			require.NoError(t, err)
+			require.Equal(t, uint64(42), results[0])
+			_, err = clone.ExportedFunction("store").Call(testCtx, 8, 43)
+			require.NoError(t, err)
+			results, err = mod.ExportedFunction("load").Call(testCtx, 8)
+			require.NoError(t, err)
+			require.Equal(t, uint64(42), results[0])
+
+			// Later clones see writes to the template.
+			_, err = mod.ExportedFunction("store").Call(testCtx, 8, 44)
+			require.NoError(t, err)
+			clone, err = r.CloneModule(testCtx, mod, NewModuleConfig().WithName(""))
+			require.NoError(t, err)
+			results, err = clone.ExportedFunction("load").Call(testCtx, 8)
+			require.NoError(t, err)
+			require.Equal(t, uint64(44), results[0])
+		})
+	}
+}
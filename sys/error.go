@@ -81,3 +81,62 @@ func (e *ExitError) Is(err error) bool {
 	}
 	return false
 }
+
+// TrapError aborts the execution of WebAssembly when a host function panics
+// with it, with a code and payload defined by the host. It is returned as is
+// to the caller of api.Function, so that it can switch on it.
+//
+// Here's an example of a host function which traps if its param is out of
+// range:
+//
+//	func(ctx context.Context, percent uint32) {
+//		if percent > 100 {
+//			panic(sys.NewTrapError(codeOutOfRange, percent))
+//		}
+//	--snip--
+//
+// Here's an example of its caller handling the trap:
+//
+//	_, err := fn.Call(ctx)
+//	if trap, ok := err.(*sys.TrapError); ok && trap.Code() == codeOutOfRange {
+//		// The payload is the percent out of range.
+//	}
+//	--snip--
+//
+// Note: Unlike ExitError, the module isn't closed, so can be called again.
+type TrapError struct {
+	code    uint32
+	payload interface{}
+}
+
+// NewTrapError returns a TrapError with the given code and payload, which
+// can be nil.
+func NewTrapError(code uint32, payload interface{}) *TrapError {
+	return &TrapError{code: code, payload: payload}
+}
+
+// Code returns the code the trap was raised with.
+func (e *TrapError) Code() uint32 {
+	return e.code
+}
+
+// Payload returns the payload the trap was raised with, or nil.
+func (e *TrapError) Payload() interface{} {
+	return e.payload
+}
+
+// Error implements the error interface.
+func (e *TrapError) Error() string {
+	if e.payload == nil {
+		return fmt.Sprintf("trap with code(%d)", e.code)
+	}
+	return fmt.Sprintf("trap with code(%d): %v", e.code, e.payload)
+}
+
+// Is allows use via errors.Is, comparing codes.
+func (e *TrapError) Is(err error) bool {
+	if target, ok := err.(*TrapError); ok {
+		return e.code == target.code
+	}
+	return false
+}
@@ -71,3 +71,15 @@ func TestExitError_Error(t *testing.T) {
 		require.EqualError(t, err, "module closed with exit_code(123)")
 	})
 }
+
+func TestTrapError(t *testing.T) {
+	err := sys.NewTrapError(42, "out of range")
+	require.Equal(t, uint32(42), err.Code())
+	require.Equal(t, "out of range", err.Payload())
+	require.EqualError(t, err, "trap with code(42): out of range")
+	require.EqualError(t, sys.NewTrapError(1, nil), "trap with code(1)")
+
+	require.ErrorIs(t, err, sys.NewTrapError(42, nil))
+	require.False(t, errors.Is(err, sys.NewTrapError(1, nil)))
+	require.False(t, errors.Is(err, sys.NewExitError(42)))
+}